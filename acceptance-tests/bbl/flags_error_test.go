@@ -15,7 +15,7 @@ var _ = Describe("bbl", func() {
 			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
 			Eventually(session).Should(gexec.Exit(1))
-			Expect(string(session.Err.Contents())).To(ContainSubstring("--iaas [gcp, aws, azure] must be provided or BBL_IAAS must be set"))
+			Expect(string(session.Err.Contents())).To(ContainSubstring("--iaas [gcp, aws, azure, openstack] must be provided or BBL_IAAS must be set"))
 			Expect(string(session.Err.Contents())).NotTo(ContainSubstring("panic"))
 		})
 	})
@@ -42,6 +42,29 @@ var _ = Describe("bbl", func() {
 					Expect(string(session.Out.Contents())).To(ContainSubstring(fmt.Sprintf("(%s/%s)", runtime.GOOS, runtime.GOARCH)))
 				})
 			})
+
+			Context("bbl provided build metadata", func() {
+				var (
+					pathToBBL string
+				)
+
+				BeforeEach(func() {
+					var err error
+					pathToBBL, err = gexec.Build("github.com/cloudfoundry/bosh-bootloader/bbl",
+						"--ldflags", "-X main.Version=1.2.3 -X main.GitSHA=abc123 -X main.BuildDate=2017-08-10T12:00:00Z")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("prints out the git sha and build date passed into the build process via LDFlags", func() {
+					session, err := gexec.Start(exec.Command(pathToBBL, "version", "--json"), GinkgoWriter, GinkgoWriter)
+
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(string(session.Out.Contents())).To(ContainSubstring(`"version":"1.2.3"`))
+					Expect(string(session.Out.Contents())).To(ContainSubstring(`"git_sha":"abc123"`))
+					Expect(string(session.Out.Contents())).To(ContainSubstring(`"build_date":"2017-08-10T12:00:00Z"`))
+				})
+			})
 		})
 
 		Describe("bbl --version", func() {
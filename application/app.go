@@ -3,6 +3,10 @@ package application
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
@@ -43,7 +47,7 @@ func (a App) getCommand(commandString string) (commands.Command, error) {
 	command, ok := a.commands[commandString]
 	if !ok {
 		a.usage.Print()
-		return nil, fmt.Errorf("unknown command: %s", commandString)
+		return nil, NewExitError(ExitCodeUsage, fmt.Errorf("unknown command: %s", commandString))
 	}
 	return command, nil
 }
@@ -78,20 +82,42 @@ func (a App) execute() error {
 		return versionCommand.Execute([]string{}, storage.State{})
 	}
 
+	dryRunner, canDryRun := command.(commands.DryRunner)
+	if a.configuration.Global.DryRun && !canDryRun {
+		return NewExitError(ExitCodeUsage, fmt.Errorf("bbl %s does not support --dry-run", a.configuration.Command))
+	}
+
+	jsonRunner, canJSON := command.(commands.JSONRunner)
+	if a.configuration.Global.JSON {
+		if !canJSON {
+			return NewExitError(ExitCodeUsage, fmt.Errorf("bbl %s does not support --json", a.configuration.Command))
+		}
+		if a.configuration.Global.DryRun {
+			return NewExitError(ExitCodeUsage, errors.New("--json cannot be used with --dry-run"))
+		}
+	}
+
 	err = command.CheckFastFails(a.configuration.SubcommandFlags, a.configuration.State)
 	if err != nil {
-		return err
+		return fastFailError(err)
 	}
 
-	err = command.Execute(a.configuration.SubcommandFlags, a.configuration.State)
+	switch {
+	case a.configuration.Global.DryRun:
+		err = dryRunner.DryRun(a.configuration.SubcommandFlags, a.configuration.State)
+	case a.configuration.Global.JSON:
+		err = jsonRunner.ExecuteJSON(a.configuration.SubcommandFlags, a.configuration.State)
+	default:
+		err = command.Execute(a.configuration.SubcommandFlags, a.configuration.State)
+	}
 	if err != nil {
 		switch err.(type) {
 		case awserr.RequestFailure:
 			requestFailure := err.(awserr.RequestFailure)
 			if requestFailure.StatusCode() == 403 {
-				return errors.New(fmt.Sprintf(
+				return NewExitError(ExitCodeCredentials, errors.New(fmt.Sprintf(
 					"The AWS credentials provided have insufficient permissions to perform the operation `bbl %s`.\nPlease refer to the bbl README:\nhttps://github.com/cloudfoundry/bosh-bootloader#configure-aws.\nOriginal error message from AWS:\n\n%s",
-					a.configuration.Command, requestFailure.Message()))
+					a.configuration.Command, requestFailure.Message())))
 			}
 			return err
 		default:
@@ -101,3 +127,23 @@ func (a App) execute() error {
 
 	return nil
 }
+
+// fastFailError attaches an exit code to an error returned by CheckFastFails.
+// Errors from the IAAS keep their exit code and errors from the system, like a
+// state dir that cannot be read or an executable that is missing, are plain
+// failures. Anything else is a problem with the flags or the state the
+// command was given.
+func fastFailError(err error) error {
+	switch err.(type) {
+	case ExitError:
+		return err
+	case *os.PathError, *os.LinkError, *os.SyscallError, *exec.Error, *url.Error, *net.OpError:
+		return err
+	}
+
+	if ExitCode(err) != ExitCodeFailure {
+		return err
+	}
+
+	return NewExitError(ExitCodeUsage, err)
+}
@@ -2,11 +2,13 @@ package application_test
 
 import (
 	"errors"
+	"os"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"google.golang.org/api/googleapi"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -31,6 +33,52 @@ func (snkp setNewKeyPairName) Execute(subcommandFlags []string, state storage.St
 
 func (snkp setNewKeyPairName) Usage() string { return "" }
 
+type dryRunCommand struct {
+	fakes.Command
+	DryRunCall struct {
+		CallCount int
+		Receives  struct {
+			SubcommandFlags []string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (d *dryRunCommand) DryRun(subcommandFlags []string, state storage.State) error {
+	d.DryRunCall.CallCount++
+	d.DryRunCall.Receives.SubcommandFlags = subcommandFlags
+	return d.DryRunCall.Returns.Error
+}
+
+type jsonCommand struct {
+	fakes.Command
+	ExecuteJSONCall struct {
+		CallCount int
+		Receives  struct {
+			SubcommandFlags []string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (j *jsonCommand) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	j.ExecuteJSONCall.CallCount++
+	j.ExecuteJSONCall.Receives.SubcommandFlags = subcommandFlags
+	return j.ExecuteJSONCall.Returns.Error
+}
+
+type dryRunJSONCommand struct {
+	dryRunCommand
+}
+
+func (d *dryRunJSONCommand) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return nil
+}
+
 var _ = Describe("App", func() {
 	var (
 		app        application.App
@@ -38,6 +86,8 @@ var _ = Describe("App", func() {
 		versionCmd *fakes.Command
 		someCmd    *fakes.Command
 		errorCmd   *fakes.Command
+		dryRunCmd  *dryRunCommand
+		jsonCmd    *jsonCommand
 		usage      *fakes.Usage
 	)
 
@@ -48,6 +98,8 @@ var _ = Describe("App", func() {
 			"--version":            versionCmd,
 			"some":                 someCmd,
 			"error":                errorCmd,
+			"dry-run":              dryRunCmd,
+			"json":                 jsonCmd,
 			"set-new-keypair-name": setNewKeyPairName{},
 		},
 			configuration,
@@ -59,6 +111,8 @@ var _ = Describe("App", func() {
 		helpCmd = &fakes.Command{}
 		versionCmd = &fakes.Command{}
 		errorCmd = &fakes.Command{}
+		dryRunCmd = &dryRunCommand{}
+		jsonCmd = &jsonCommand{}
 
 		someCmd = &fakes.Command{}
 		someCmd.ExecuteCall.PassState = true
@@ -99,6 +153,100 @@ var _ = Describe("App", func() {
 			})
 		})
 
+		Context("when --dry-run is set", func() {
+			It("runs the dry run instead of executing the command", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command:         "dry-run",
+					SubcommandFlags: []string{"--some-flag"},
+					Global: application.GlobalConfiguration{
+						DryRun: true,
+					},
+				})
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(dryRunCmd.CheckFastFailsCall.CallCount).To(Equal(1))
+				Expect(dryRunCmd.DryRunCall.CallCount).To(Equal(1))
+				Expect(dryRunCmd.DryRunCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+				Expect(dryRunCmd.ExecuteCall.CallCount).To(Equal(0))
+			})
+
+			It("executes commands that support dry runs when it is not set", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "dry-run",
+				})
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(dryRunCmd.DryRunCall.CallCount).To(Equal(0))
+				Expect(dryRunCmd.ExecuteCall.CallCount).To(Equal(1))
+			})
+
+			It("returns an error for commands that do not support dry runs", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "some",
+					Global: application.GlobalConfiguration{
+						DryRun: true,
+					},
+				})
+
+				err := app.Run()
+				Expect(err).To(MatchError("bbl some does not support --dry-run"))
+				Expect(application.ExitCode(err)).To(Equal(application.ExitCodeUsage))
+				Expect(someCmd.CheckFastFailsCall.CallCount).To(Equal(0))
+				Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when --json is set", func() {
+			It("prints json instead of executing the command", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command:         "json",
+					SubcommandFlags: []string{"--some-flag"},
+					Global: application.GlobalConfiguration{
+						JSON: true,
+					},
+				})
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(jsonCmd.CheckFastFailsCall.CallCount).To(Equal(1))
+				Expect(jsonCmd.ExecuteJSONCall.CallCount).To(Equal(1))
+				Expect(jsonCmd.ExecuteJSONCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+				Expect(jsonCmd.ExecuteCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error for commands that do not print json", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "some",
+					Global: application.GlobalConfiguration{
+						JSON: true,
+					},
+				})
+
+				err := app.Run()
+				Expect(err).To(MatchError("bbl some does not support --json"))
+				Expect(application.ExitCode(err)).To(Equal(application.ExitCodeUsage))
+				Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when --dry-run is also set", func() {
+				dryRunJSONCmd := &dryRunJSONCommand{}
+				app = application.New(application.CommandSet{"dry-run-json": dryRunJSONCmd}, application.Configuration{
+					Command: "dry-run-json",
+					Global: application.GlobalConfiguration{
+						JSON:   true,
+						DryRun: true,
+					},
+				}, usage)
+
+				err := app.Run()
+				Expect(err).To(MatchError("--json cannot be used with --dry-run"))
+				Expect(application.ExitCode(err)).To(Equal(application.ExitCodeUsage))
+				Expect(dryRunJSONCmd.DryRunCall.CallCount).To(Equal(0))
+			})
+		})
+
 		Context("when subcommand flags contains help", func() {
 			DescribeTable("prints command specific usage when help subcommand flag is provided", func(helpFlag string) {
 				someCmd.UsageCall.Returns.Usage = "some usage message"
@@ -215,8 +363,25 @@ var _ = Describe("App", func() {
 					err := app.Run()
 					Expect(someCmd.CheckFastFailsCall.CallCount).To(Equal(1))
 					Expect(err).To(MatchError("fast failed command"))
+					Expect(application.ExitCode(err)).To(Equal(application.ExitCodeUsage))
 					Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
 				})
+
+				DescribeTable("keeps the exit code of errors that are not about the flags or the state", func(fastFailErr error, expectedCode int) {
+					someCmd.CheckFastFailsCall.Returns.Error = fastFailErr
+					app = NewAppWithConfiguration(application.Configuration{
+						Command: "some",
+					})
+
+					err := app.Run()
+					Expect(err).To(MatchError(fastFailErr.Error()))
+					Expect(application.ExitCode(err)).To(Equal(expectedCode))
+				},
+					Entry("a failure to read a file", &os.PathError{Op: "open", Path: "some-file", Err: errors.New("permission denied")}, application.ExitCodeFailure),
+					Entry("a failed aws request", awserr.NewRequestFailure(awserr.New("InternalServerError", "some message", nil), 500, "some-request-id"), application.ExitCodeIAAS),
+					Entry("a forbidden gcp request", &googleapi.Error{Code: 403}, application.ExitCodeCredentials),
+					Entry("an explicit exit code", application.NewExitError(application.ExitCodeFailure, errors.New("some error")), application.ExitCodeFailure),
+				)
 			})
 
 			Context("when an unknown command is provided", func() {
@@ -226,6 +391,7 @@ var _ = Describe("App", func() {
 					})
 					err := app.Run()
 					Expect(err).To(MatchError("unknown command: some-unknown-command"))
+					Expect(application.ExitCode(err)).To(Equal(application.ExitCodeUsage))
 					Expect(usage.PrintCall.CallCount).To(Equal(1))
 				})
 			})
@@ -240,6 +406,7 @@ var _ = Describe("App", func() {
 					err := app.Run()
 
 					Expect(err).To(MatchError("The AWS credentials provided have insufficient permissions to perform the operation `bbl error`.\nPlease refer to the bbl README:\nhttps://github.com/cloudfoundry/bosh-bootloader#configure-aws.\nOriginal error message from AWS:\n\nUser is not authorized to perform: action:SubCommand"))
+					Expect(application.ExitCode(err)).To(Equal(application.ExitCodeCredentials))
 				})
 
 				It("returns an error when the error is not AccessDenied", func() {
@@ -252,6 +419,7 @@ var _ = Describe("App", func() {
 
 					Expect(err).To(ContainSubstring("InternalServerError"))
 					Expect(err).NotTo(ContainSubstring("README"))
+					Expect(application.ExitCode(err)).To(Equal(application.ExitCodeIAAS))
 				})
 			})
 
@@ -266,6 +434,7 @@ var _ = Describe("App", func() {
 					})
 					err := app.Run()
 					Expect(err).To(MatchError("error executing command"))
+					Expect(application.ExitCode(err)).To(Equal(application.ExitCodeFailure))
 				})
 			})
 		})
@@ -0,0 +1,84 @@
+package application
+
+import (
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const redactedFlagValue = "<redacted>"
+
+// secretFlags are the flags whose values are credentials or private keys and
+// are left out of the audit log.
+var secretFlags = map[string]bool{
+	"aws-secret-access-key":       true,
+	"aws-mfa-token":               true,
+	"aws-assume-role-external-id": true,
+	"azure-client-secret":         true,
+	"openstack-password":          true,
+	"gcp-service-account-key":     true,
+	"director-ca-key":             true,
+	"private-key":                 true,
+	"key":                         true,
+}
+
+var currentUser = defaultCurrentUser
+
+func defaultCurrentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+
+	return u.Username
+}
+
+// NewAuditRecord returns the audit log record of a bbl run with the
+// arguments it was started with, the values of secret flags redacted.
+func NewAuditRecord(command string, args []string, started time.Time, commandErr error) storage.AuditRecord {
+	record := storage.AuditRecord{
+		Time:     started.UTC(),
+		User:     currentUser(),
+		Command:  command,
+		Args:     redactArgs(args),
+		Result:   "success",
+		ExitCode: ExitCode(commandErr),
+	}
+
+	if commandErr != nil {
+		record.Result = "failure"
+		record.Error = commandErr.Error()
+	}
+
+	return record
+}
+
+func redactArgs(args []string) []string {
+	redacted := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		redacted = append(redacted, arg)
+
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		if parts := strings.SplitN(name, "=", 2); len(parts) == 2 {
+			if secretFlags[parts[0]] {
+				redacted[len(redacted)-1] = strings.SplitN(arg, "=", 2)[0] + "=" + redactedFlagValue
+			}
+			continue
+		}
+
+		if secretFlags[name] && i+1 < len(args) {
+			i++
+			redacted = append(redacted, redactedFlagValue)
+		}
+	}
+
+	return redacted
+}
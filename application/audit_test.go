@@ -0,0 +1,68 @@
+package application_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewAuditRecord", func() {
+	var started time.Time
+
+	BeforeEach(func() {
+		started = time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC)
+		application.SetCurrentUser(func() string { return "some-user" })
+	})
+
+	AfterEach(func() {
+		application.ResetCurrentUser()
+	})
+
+	It("records a successful run", func() {
+		record := application.NewAuditRecord("up", []string{"--iaas", "gcp", "up", "--name", "some-name"}, started, nil)
+
+		Expect(record).To(Equal(storage.AuditRecord{
+			Time:     started,
+			User:     "some-user",
+			Command:  "up",
+			Args:     []string{"--iaas", "gcp", "up", "--name", "some-name"},
+			Result:   "success",
+			ExitCode: 0,
+		}))
+	})
+
+	It("records the error of a failed run", func() {
+		record := application.NewAuditRecord("up", []string{"up"}, started, errors.New("failed to apply"))
+
+		Expect(record.Result).To(Equal("failure"))
+		Expect(record.ExitCode).To(Equal(1))
+		Expect(record.Error).To(Equal("failed to apply"))
+	})
+
+	It("redacts the values of secret flags", func() {
+		record := application.NewAuditRecord("up", []string{
+			"--aws-access-key-id", "some-access-key-id",
+			"--aws-secret-access-key", "some-secret-access-key",
+			"--gcp-service-account-key=some-service-account-key",
+			"-openstack-password", "some-password",
+			"up",
+			"--private-key", "some-private-key",
+			"--name", "some-name",
+		}, started, nil)
+
+		Expect(record.Args).To(Equal([]string{
+			"--aws-access-key-id", "some-access-key-id",
+			"--aws-secret-access-key", "<redacted>",
+			"--gcp-service-account-key=<redacted>",
+			"-openstack-password", "<redacted>",
+			"up",
+			"--private-key", "<redacted>",
+			"--name", "some-name",
+		}))
+	})
+})
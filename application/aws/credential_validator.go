@@ -2,6 +2,8 @@ package aws
 
 import (
 	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/application"
 )
 
 type CredentialValidator struct {
@@ -18,18 +20,24 @@ func NewCredentialValidator(accessKeyID, secretAccessKey, region string) Credent
 	}
 }
 
+// Validate requires the access key and secret together, or neither of them
+// to use the default credential chain of the aws sdk.
 func (c CredentialValidator) Validate() error {
-	if c.accessKeyID == "" {
-		return errors.New("AWS access key ID must be provided")
+	if c.accessKeyID == "" && c.secretAccessKey != "" {
+		return credentialError("AWS access key ID must be provided")
 	}
 
-	if c.secretAccessKey == "" {
-		return errors.New("AWS secret access key must be provided")
+	if c.secretAccessKey == "" && c.accessKeyID != "" {
+		return credentialError("AWS secret access key must be provided")
 	}
 
 	if c.region == "" {
-		return errors.New("AWS region must be provided")
+		return credentialError("AWS region must be provided")
 	}
 
 	return nil
 }
+
+func credentialError(message string) error {
+	return application.NewExitError(application.ExitCodeCredentials, errors.New(message))
+}
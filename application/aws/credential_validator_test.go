@@ -1,6 +1,7 @@
 package aws_test
 
 import (
+	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/application/aws"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -16,10 +17,18 @@ var _ = Describe("CredentialValidator", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("allows neither the access key id nor the secret to use the default credential chain", func() {
+			credentialValidator = aws.NewCredentialValidator("", "", "some-region")
+			err := credentialValidator.Validate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when the access key id is missing", func() {
 				credentialValidator = aws.NewCredentialValidator("", "some-secret-access-key", "some-region")
-				Expect(credentialValidator.Validate()).To(MatchError("AWS access key ID must be provided"))
+				err := credentialValidator.Validate()
+				Expect(err).To(MatchError("AWS access key ID must be provided"))
+				Expect(application.ExitCode(err)).To(Equal(application.ExitCodeCredentials))
 			})
 
 			It("returns an error when the secret access key is missing", func() {
@@ -5,6 +5,8 @@ import "github.com/cloudfoundry/bosh-bootloader/storage"
 type GlobalConfiguration struct {
 	StateDir string
 	Debug    bool
+	DryRun   bool
+	JSON     bool
 }
 
 type StringSlice []string
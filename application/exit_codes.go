@@ -0,0 +1,73 @@
+package application
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/keypair"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	ExitCodeSuccess     = 0
+	ExitCodeFailure     = 1
+	ExitCodeUsage       = 2
+	ExitCodeCredentials = 3
+	ExitCodeIAAS        = 4
+	ExitCodeStateLocked = 5
+)
+
+// ExitError attaches the exit code bbl should exit with to an error.
+type ExitError struct {
+	code int
+	err  error
+}
+
+func NewExitError(code int, err error) ExitError {
+	return ExitError{
+		code: code,
+		err:  err,
+	}
+}
+
+func (e ExitError) Error() string {
+	return e.err.Error()
+}
+
+func (e ExitError) ExitCode() int {
+	return e.code
+}
+
+// ExitCode returns the exit code for an error returned while running bbl.
+// Errors that are not recognized exit with ExitCodeFailure.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+
+	switch e := err.(type) {
+	case ExitError:
+		return e.code
+	case awserr.RequestFailure:
+		if e.StatusCode() == 401 || e.StatusCode() == 403 {
+			return ExitCodeCredentials
+		}
+		return ExitCodeIAAS
+	case awserr.Error:
+		return ExitCodeIAAS
+	case *googleapi.Error:
+		if e.Code == 401 || e.Code == 403 {
+			return ExitCodeCredentials
+		}
+		return ExitCodeIAAS
+	case terraform.ManagerError, terraform.ExecutorError,
+		bosh.ManagerCreateError, bosh.ManagerDeleteError, bosh.CreateEnvError, bosh.DeleteEnvError,
+		keypair.ManagerError:
+		return ExitCodeIAAS
+	case storage.StateLockedError:
+		return ExitCodeStateLocked
+	}
+
+	return ExitCodeFailure
+}
@@ -0,0 +1,45 @@
+package application_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/keypair"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+	"google.golang.org/api/googleapi"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExitCode", func() {
+	DescribeTable("returns the exit code for the error", func(err error, expectedCode int) {
+		Expect(application.ExitCode(err)).To(Equal(expectedCode))
+	},
+		Entry("no error", nil, 0),
+		Entry("an unclassified error", errors.New("some error"), 1),
+		Entry("an exit error", application.NewExitError(application.ExitCodeUsage, errors.New("some error")), 2),
+		Entry("a forbidden aws request", awserr.NewRequestFailure(awserr.New("UnauthorizedOperation", "some message", nil), 403, "some-request-id"), 3),
+		Entry("a failed aws request", awserr.NewRequestFailure(awserr.New("InternalServerError", "some message", nil), 500, "some-request-id"), 4),
+		Entry("an aws error", awserr.New("SomeError", "some message", nil), 4),
+		Entry("a forbidden gcp request", &googleapi.Error{Code: 403}, 3),
+		Entry("a failed gcp request", &googleapi.Error{Code: 500}, 4),
+		Entry("a terraform executor error", terraform.NewExecutorError("some-tf-state-file", errors.New("some error"), false), 4),
+		Entry("a bosh create env error", bosh.NewCreateEnvError(map[string]interface{}{}, errors.New("some error")), 4),
+		Entry("a bosh manager create error", bosh.NewManagerCreateError(storage.State{}, errors.New("some error")), 4),
+		Entry("a keypair manager error", keypair.NewManagerError(storage.State{}, errors.New("some error")), 4),
+		Entry("a locked state dir", storage.StateLockedError{PID: 1234}, 5),
+	)
+
+	Describe("ExitError", func() {
+		It("returns the message of the wrapped error", func() {
+			err := application.NewExitError(application.ExitCodeIAAS, errors.New("some error"))
+			Expect(err).To(MatchError("some error"))
+			Expect(err.ExitCode()).To(Equal(4))
+		})
+	})
+})
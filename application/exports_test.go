@@ -0,0 +1,19 @@
+package application
+
+import "time"
+
+func SetNow(f func() time.Time) {
+	now = f
+}
+
+func ResetNow() {
+	now = time.Now
+}
+
+func SetCurrentUser(f func() string) {
+	currentUser = f
+}
+
+func ResetCurrentUser() {
+	currentUser = defaultCurrentUser
+}
@@ -2,6 +2,8 @@ package gcp
 
 import (
 	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/application"
 )
 
 type CredentialValidator struct {
@@ -20,22 +22,24 @@ func NewCredentialValidator(projectID, serviceAccountKey, region, zone string) C
 	}
 }
 
+// Validate does not require a service account key, without one the gcp
+// clients use the application default credentials.
 func (c CredentialValidator) Validate() error {
 	if c.projectID == "" {
-		return errors.New("GCP project ID must be provided")
-	}
-
-	if c.serviceAccountKey == "" {
-		return errors.New("GCP service account key must be provided")
+		return credentialError("GCP project ID must be provided")
 	}
 
 	if c.region == "" {
-		return errors.New("GCP region must be provided")
+		return credentialError("GCP region must be provided")
 	}
 
 	if c.zone == "" {
-		return errors.New("GCP zone must be provided")
+		return credentialError("GCP zone must be provided")
 	}
 
 	return nil
 }
+
+func credentialError(message string) error {
+	return application.NewExitError(application.ExitCodeCredentials, errors.New(message))
+}
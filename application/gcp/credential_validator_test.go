@@ -1,6 +1,7 @@
 package gcp_test
 
 import (
+	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/application/gcp"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -16,15 +17,18 @@ var _ = Describe("CredentialValidator", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("allows the service account key to be left to the application default credentials", func() {
+			credentialValidator = gcp.NewCredentialValidator("some-project-id", "", "some-region", "some-zone")
+			err := credentialValidator.Validate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when the project id is missing", func() {
 				credentialValidator = gcp.NewCredentialValidator("", "some-service-account-key", "some-region", "some-zone")
-				Expect(credentialValidator.Validate()).To(MatchError("GCP project ID must be provided"))
-			})
-
-			It("returns an error when the service account key is missing", func() {
-				credentialValidator = gcp.NewCredentialValidator("some-project-id", "", "some-region", "some-zone")
-				Expect(credentialValidator.Validate()).To(MatchError("GCP service account key must be provided"))
+				err := credentialValidator.Validate()
+				Expect(err).To(MatchError("GCP project ID must be provided"))
+				Expect(application.ExitCode(err)).To(Equal(application.ExitCodeCredentials))
 			})
 
 			It("returns an error when the region is missing", func() {
@@ -0,0 +1,61 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateStore interface {
+	Set(state storage.State) error
+}
+
+type subprocessOutput interface {
+	String() string
+}
+
+type LatestErrorRecorder struct {
+	stateDir   string
+	getState   func(string) (storage.State, error)
+	stateStore stateStore
+	output     subprocessOutput
+}
+
+func NewLatestErrorRecorder(stateDir string, getState func(string) (storage.State, error), stateStore stateStore, output subprocessOutput) LatestErrorRecorder {
+	return LatestErrorRecorder{
+		stateDir:   stateDir,
+		getState:   getState,
+		stateStore: stateStore,
+		output:     output,
+	}
+}
+
+func (l LatestErrorRecorder) Record(commandErr error) error {
+	state, err := l.getState(l.stateDir)
+	if err != nil {
+		return fmt.Errorf("failed to record latest error: %s", err)
+	}
+
+	if reflect.DeepEqual(state, storage.State{}) {
+		return nil
+	}
+
+	state.LatestError = storage.LatestError{
+		Message: commandErr.Error(),
+		Output:  l.output.String(),
+	}
+
+	// this bbl is the one an up --detach continued in
+	if state.UpProgress.PID == os.Getpid() {
+		state.UpProgress.Failed = true
+	}
+
+	err = l.stateStore.Set(state)
+	if err != nil {
+		return fmt.Errorf("failed to record latest error: %s", err)
+	}
+
+	return nil
+}
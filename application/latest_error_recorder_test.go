@@ -0,0 +1,119 @@
+package application_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LatestErrorRecorder", func() {
+	var (
+		stateStore       *fakes.StateStore
+		subprocessOutput *bytes.Buffer
+
+		getStateCallCount int
+		getStateReceives  string
+		getStateReturns   storage.State
+		getStateError     error
+
+		recorder application.LatestErrorRecorder
+	)
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		subprocessOutput = bytes.NewBufferString("some subprocess output")
+
+		getStateCallCount = 0
+		getStateReturns = storage.State{EnvID: "some-env-id"}
+		getStateError = nil
+
+		getState := func(dir string) (storage.State, error) {
+			getStateCallCount++
+			getStateReceives = dir
+			return getStateReturns, getStateError
+		}
+
+		recorder = application.NewLatestErrorRecorder("some-state-dir", getState, stateStore, subprocessOutput)
+	})
+
+	It("saves the error and subprocess output to the latest state", func() {
+		err := recorder.Record(errors.New("some command error"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(getStateCallCount).To(Equal(1))
+		Expect(getStateReceives).To(Equal("some-state-dir"))
+
+		Expect(stateStore.SetCall.CallCount).To(Equal(1))
+		Expect(stateStore.SetCall.Receives[0].State).To(Equal(storage.State{
+			EnvID: "some-env-id",
+			LatestError: storage.LatestError{
+				Message: "some command error",
+				Output:  "some subprocess output",
+			},
+		}))
+	})
+
+	Context("when the up progress belongs to this bbl", func() {
+		It("marks the detached up as failed", func() {
+			getStateReturns.UpProgress = storage.UpProgress{
+				PID:   os.Getpid(),
+				Phase: "terraform",
+			}
+
+			err := recorder.Record(errors.New("some command error"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.Receives[0].State.UpProgress).To(Equal(storage.UpProgress{
+				PID:    os.Getpid(),
+				Phase:  "terraform",
+				Failed: true,
+			}))
+		})
+
+		It("leaves the progress of another bbl alone", func() {
+			getStateReturns.UpProgress = storage.UpProgress{
+				PID:   os.Getpid() + 1,
+				Phase: "terraform",
+			}
+
+			err := recorder.Record(errors.New("some command error"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.Receives[0].State.UpProgress.Failed).To(BeFalse())
+		})
+	})
+
+	Context("when there is no bbl state", func() {
+		It("does not create one", func() {
+			getStateReturns = storage.State{}
+
+			err := recorder.Record(errors.New("some command error"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+		})
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the state cannot be read", func() {
+			getStateError = errors.New("failed to get state")
+
+			err := recorder.Record(errors.New("some command error"))
+			Expect(err).To(MatchError("failed to record latest error: failed to get state"))
+		})
+
+		It("returns an error when the state cannot be saved", func() {
+			stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+			err := recorder.Record(errors.New("some command error"))
+			Expect(err).To(MatchError("failed to record latest error: failed to set state"))
+		})
+	})
+})
@@ -1,22 +1,93 @@
 package application
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 )
 
+const (
+	LogLevelError = "error"
+	LogLevelWarn  = "warn"
+	LogLevelInfo  = "info"
+	LogLevelDebug = "debug"
+
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var now = time.Now
+
+var logLevels = map[string]int{
+	LogLevelError: 0,
+	LogLevelWarn:  1,
+	LogLevelInfo:  2,
+	LogLevelDebug: 3,
+}
+
 type Logger struct {
 	newline bool
 	writer  io.Writer
+	level   int
+	json    bool
+	steps   []StepTiming
+}
+
+// logRecord is one line of output with the json log format.
+type logRecord struct {
+	Time     string `json:"time"`
+	Level    string `json:"level"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// StepTiming is how long bbl spent on a step, from its Step message until the
+// next one or until the command finished.
+type StepTiming struct {
+	Message  string
+	Started  time.Time
+	Duration time.Duration
 }
 
 func NewLogger(writer io.Writer) *Logger {
 	return &Logger{
 		newline: true,
 		writer:  writer,
+		level:   logLevels[LogLevelInfo],
+	}
+}
+
+func IsValidLogLevel(level string) bool {
+	_, ok := logLevels[level]
+	return ok
+}
+
+// SetLevel gates Step, Dot, Warn, Error and Debug output. Println, Printf
+// and Prompt are command output and are always written.
+func (l *Logger) SetLevel(level string) {
+	if value, ok := logLevels[level]; ok {
+		l.level = value
 	}
 }
 
+func IsValidLogFormat(format string) bool {
+	return format == LogFormatText || format == LogFormatJSON
+}
+
+// SetFormat writes steps, warnings, errors, debug messages and subprocess
+// output as one json record per line with the json format. Dots are not
+// written, and command output is written as is.
+func (l *Logger) SetFormat(format string) {
+	l.json = format == LogFormatJSON
+}
+
+func (l *Logger) enabled(level string) bool {
+	return logLevels[level] <= l.level
+}
+
 func (l *Logger) clear() {
 	if l.newline {
 		return
@@ -27,16 +98,168 @@ func (l *Logger) clear() {
 }
 
 func (l *Logger) Step(message string, a ...interface{}) {
+	l.finishStep()
+	l.steps = append(l.steps, StepTiming{
+		Message: fmt.Sprintf(message, a...),
+		Started: now(),
+	})
+
+	if !l.enabled(LogLevelInfo) {
+		return
+	}
+
+	if l.json {
+		l.record(LogLevelInfo, "", fmt.Sprintf(message, a...))
+		return
+	}
+
 	l.clear()
 	fmt.Fprintf(l.writer, "step: %s\n", fmt.Sprintf(message, a...))
 	l.newline = true
 }
 
+// Steps returns the timing of every step logged so far. Steps are timed even
+// when the log level hides them.
+func (l *Logger) Steps() []StepTiming {
+	l.finishStep()
+	return append([]StepTiming{}, l.steps...)
+}
+
+// Summary prints how long each step took, and the total, so that the phases
+// a long bbl up spends its time on stand out. It is hidden with the steps,
+// and for commands that took a single step.
+func (l *Logger) Summary() {
+	steps := l.Steps()
+	if len(steps) < 2 || !l.enabled(LogLevelInfo) {
+		return
+	}
+
+	var total time.Duration
+	width := len("total")
+	for _, step := range steps {
+		total += step.Duration
+		if len(step.Message) > width {
+			width = len(step.Message)
+		}
+	}
+
+	if l.json {
+		for _, step := range steps {
+			l.timing(step.Message, step.Duration)
+		}
+		l.timing("total", total)
+		return
+	}
+
+	l.clear()
+	fmt.Fprintln(l.writer, "step timings:")
+	for _, step := range steps {
+		fmt.Fprintf(l.writer, "  %-*s %8s\n", width, step.Message, step.Duration.Round(time.Second))
+	}
+	fmt.Fprintf(l.writer, "  %-*s %8s\n", width, "total", total.Round(time.Second))
+	l.newline = true
+}
+
+func (l *Logger) finishStep() {
+	if len(l.steps) == 0 {
+		return
+	}
+
+	last := &l.steps[len(l.steps)-1]
+	last.Duration = now().Sub(last.Started)
+}
+
 func (l *Logger) Dot() {
+	if !l.enabled(LogLevelInfo) || l.json {
+		return
+	}
+
 	l.writer.Write([]byte("\u2022"))
 	l.newline = false
 }
 
+func (l *Logger) Warn(message string, a ...interface{}) {
+	l.leveled(LogLevelWarn, "warning", message, a...)
+}
+
+func (l *Logger) Error(message string, a ...interface{}) {
+	l.leveled(LogLevelError, "error", message, a...)
+}
+
+func (l *Logger) Debug(message string, a ...interface{}) {
+	l.leveled(LogLevelDebug, "debug", message, a...)
+}
+
+func (l *Logger) leveled(level, prefix, message string, a ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+
+	if l.json {
+		l.record(level, "", fmt.Sprintf(message, a...))
+		return
+	}
+
+	l.clear()
+	fmt.Fprintf(l.writer, "%s: %s\n", prefix, fmt.Sprintf(message, a...))
+	l.newline = true
+}
+
+func (l *Logger) record(level, source, message string) {
+	l.write(logRecord{Level: level, Source: source, Message: message})
+}
+
+func (l *Logger) timing(message string, duration time.Duration) {
+	l.write(logRecord{Level: LogLevelInfo, Message: message, Duration: duration.Round(time.Second).String()})
+}
+
+func (l *Logger) write(record logRecord) {
+	l.clear()
+
+	record.Time = now().UTC().Format(time.RFC3339)
+	line, err := json.Marshal(record)
+	if err != nil {
+		return // not tested
+	}
+
+	l.writer.Write(append(line, '\n'))
+}
+
+// Writer returns a writer for the output of a subprocess such as terraform or
+// bosh. With the json format every line becomes an info record from source;
+// otherwise the output is written as is.
+func (l *Logger) Writer(source string) io.Writer {
+	return &subprocessWriter{logger: l, source: source}
+}
+
+type subprocessWriter struct {
+	logger  *Logger
+	source  string
+	partial []byte
+}
+
+func (w *subprocessWriter) Write(p []byte) (int, error) {
+	if !w.logger.json {
+		return w.logger.writer.Write(p)
+	}
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := string(bytes.TrimRight(w.partial[:i], "\r"))
+		w.partial = w.partial[i+1:]
+		if line != "" {
+			w.logger.record(LogLevelInfo, w.source, line)
+		}
+	}
+
+	return len(p), nil
+}
+
 func (l *Logger) Printf(message string, a ...interface{}) {
 	l.clear()
 	fmt.Fprintf(l.writer, "%s", fmt.Sprintf(message, a...))
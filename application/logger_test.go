@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -36,6 +38,103 @@ var _ = Describe("Logger", func() {
 		})
 	})
 
+	Describe("Steps", func() {
+		var currentTime time.Time
+
+		BeforeEach(func() {
+			currentTime = time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC)
+			application.SetNow(func() time.Time { return currentTime })
+		})
+
+		AfterEach(func() {
+			application.ResetNow()
+		})
+
+		It("times each step until the next step or until steps are read", func() {
+			logger.Step("generating terraform template")
+			currentTime = currentTime.Add(2 * time.Second)
+			logger.Step("creating %s", "jumpbox")
+			currentTime = currentTime.Add(5 * time.Second)
+
+			Expect(logger.Steps()).To(Equal([]application.StepTiming{
+				{
+					Message:  "generating terraform template",
+					Started:  time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC),
+					Duration: 2 * time.Second,
+				},
+				{
+					Message:  "creating jumpbox",
+					Started:  time.Date(2017, 7, 1, 12, 0, 2, 0, time.UTC),
+					Duration: 5 * time.Second,
+				},
+			}))
+		})
+
+		It("times steps that the log level hides", func() {
+			logger.SetLevel(application.LogLevelError)
+			logger.Step("creating jumpbox")
+			currentTime = currentTime.Add(time.Second)
+
+			Expect(buffer.String()).To(BeEmpty())
+			Expect(logger.Steps()).To(HaveLen(1))
+			Expect(logger.Steps()[0].Duration).To(Equal(time.Second))
+		})
+	})
+
+	Describe("Summary", func() {
+		var currentTime time.Time
+
+		BeforeEach(func() {
+			currentTime = time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC)
+			application.SetNow(func() time.Time { return currentTime })
+
+			logger.Step("applying terraform template")
+			currentTime = currentTime.Add(4*time.Minute + 12*time.Second)
+			logger.Step("creating bosh director")
+			currentTime = currentTime.Add(30*time.Minute + 800*time.Millisecond)
+			buffer.Reset()
+		})
+
+		AfterEach(func() {
+			application.ResetNow()
+		})
+
+		It("prints how long each step took and the total", func() {
+			logger.Summary()
+
+			Expect(buffer.String()).To(Equal(`step timings:
+  applying terraform template    4m12s
+  creating bosh director         30m1s
+  total                         34m13s
+`))
+		})
+
+		It("writes a json record per step with the json format", func() {
+			logger.SetFormat("json")
+			logger.Summary()
+
+			Expect(buffer.String()).To(Equal(`{"time":"2017-07-01T12:34:12Z","level":"info","message":"applying terraform template","duration":"4m12s"}
+{"time":"2017-07-01T12:34:12Z","level":"info","message":"creating bosh director","duration":"30m1s"}
+{"time":"2017-07-01T12:34:12Z","level":"info","message":"total","duration":"34m13s"}
+`))
+		})
+
+		It("is hidden with the steps", func() {
+			logger.SetLevel("warn")
+			logger.Summary()
+
+			Expect(buffer.String()).To(BeEmpty())
+		})
+
+		It("prints nothing for a single step", func() {
+			logger = application.NewLogger(buffer)
+			logger.Step("fetching the director's cloud config")
+			logger.Summary()
+
+			Expect(buffer.String()).To(Equal("step: fetching the director's cloud config\n"))
+		})
+	})
+
 	Describe("Dot", func() {
 		It("prints a dot", func() {
 			logger.Dot()
@@ -46,6 +145,133 @@ var _ = Describe("Logger", func() {
 		})
 	})
 
+	Describe("Warn", func() {
+		It("prints the warning message", func() {
+			logger.Warn("the %s may be replaced", "network")
+
+			Expect(buffer.String()).To(Equal("warning: the network may be replaced\n"))
+		})
+	})
+
+	Describe("Error", func() {
+		It("prints the error message", func() {
+			logger.Error("failed to %s", "record the error")
+
+			Expect(buffer.String()).To(Equal("error: failed to record the error\n"))
+		})
+	})
+
+	Describe("Debug", func() {
+		It("does not print the debug message at the default level", func() {
+			logger.Debug("some debug message")
+
+			Expect(buffer.String()).To(BeEmpty())
+		})
+	})
+
+	Describe("SetLevel", func() {
+		log := func() {
+			logger.Step("some step")
+			logger.Dot()
+			logger.Warn("some warning")
+			logger.Error("some error")
+			logger.Debug("some debug message")
+			logger.Println("some output")
+		}
+
+		It("prints everything at the debug level", func() {
+			logger.SetLevel("debug")
+			log()
+
+			Expect(buffer.String()).To(Equal("step: some step\n\u2022\nwarning: some warning\nerror: some error\ndebug: some debug message\nsome output\n"))
+		})
+
+		It("hides steps and dots at the warn level", func() {
+			logger.SetLevel("warn")
+			log()
+
+			Expect(buffer.String()).To(Equal("warning: some warning\nerror: some error\nsome output\n"))
+		})
+
+		It("only prints errors and command output at the error level", func() {
+			logger.SetLevel("error")
+			log()
+
+			Expect(buffer.String()).To(Equal("error: some error\nsome output\n"))
+		})
+
+		It("ignores unknown levels", func() {
+			logger.SetLevel("verbose")
+			log()
+
+			Expect(buffer.String()).To(Equal("step: some step\n\u2022\nwarning: some warning\nerror: some error\nsome output\n"))
+		})
+	})
+
+	DescribeTable("IsValidLogLevel", func(level string, valid bool) {
+		Expect(application.IsValidLogLevel(level)).To(Equal(valid))
+	},
+		Entry("error", "error", true),
+		Entry("warn", "warn", true),
+		Entry("info", "info", true),
+		Entry("debug", "debug", true),
+		Entry("unknown", "verbose", false),
+	)
+
+	Describe("SetFormat", func() {
+		BeforeEach(func() {
+			application.SetNow(func() time.Time { return time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC) })
+			logger.SetFormat("json")
+		})
+
+		AfterEach(func() {
+			application.ResetNow()
+		})
+
+		It("writes a json record per message at the log level, and command output as is", func() {
+			logger.SetLevel("debug")
+			logger.Step("creating %s", "jumpbox")
+			logger.Dot()
+			logger.Warn("some warning")
+			logger.Error("some error")
+			logger.Debug("some debug message")
+			logger.Println("some output")
+
+			Expect(buffer.String()).To(Equal(`{"time":"2017-07-01T12:00:00Z","level":"info","message":"creating jumpbox"}
+{"time":"2017-07-01T12:00:00Z","level":"warn","message":"some warning"}
+{"time":"2017-07-01T12:00:00Z","level":"error","message":"some error"}
+{"time":"2017-07-01T12:00:00Z","level":"debug","message":"some debug message"}
+some output
+`))
+		})
+
+		It("writes a record per line of subprocess output", func() {
+			writer := logger.Writer("terraform")
+			writer.Write([]byte("Initializing...\n\nApply "))
+			writer.Write([]byte("complete!\r\n"))
+
+			Expect(buffer.String()).To(Equal(`{"time":"2017-07-01T12:00:00Z","level":"info","source":"terraform","message":"Initializing..."}
+{"time":"2017-07-01T12:00:00Z","level":"info","source":"terraform","message":"Apply complete!"}
+`))
+		})
+	})
+
+	Describe("Writer", func() {
+		It("writes subprocess output as is with the text format", func() {
+			logger.Writer("bosh").Write([]byte("Deploying:\n  Creating instance"))
+
+			Expect(buffer.String()).To(Equal("Deploying:\n  Creating instance"))
+		})
+	})
+
+	DescribeTable("IsValidLogFormat", func(format string, valid bool) {
+		Expect(application.IsValidLogFormat(format)).To(Equal(valid))
+	},
+		Entry("text", "text", true),
+		Entry("json", "json", true),
+		Entry("unknown", "logfmt", false),
+	)
+
 	Describe("Println", func() {
 		It("prints out the message", func() {
 			logger.Println("hello world")
@@ -0,0 +1,133 @@
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// phaseKeywords maps words in step messages to the phase they are counted
+// under. The first match wins, so more specific keywords come first.
+var phaseKeywords = []struct {
+	keyword string
+	phase   string
+}{
+	{"cloudformation", "cloudformation"},
+	{"terraform", "terraform"},
+	{"infrastructure", "terraform"},
+	{"jumpbox", "jumpbox"},
+	{"director", "director"},
+	{"cloud config", "cloud_config"},
+	{"stemcell", "stemcell"},
+	{"keypair", "keypair"},
+	{"key pair", "keypair"},
+	{"ssh-keys", "keypair"},
+	{"credentials", "credentials"},
+}
+
+type MetricsWriter struct {
+	path string
+}
+
+func NewMetricsWriter(path string) MetricsWriter {
+	return MetricsWriter{
+		path: path,
+	}
+}
+
+// Write writes metrics about a bbl run in the Prometheus textfile format, so
+// that node_exporter's textfile collector can pick them up. The file is
+// replaced atomically so the collector never reads a partial file.
+func (m MetricsWriter) Write(command string, started time.Time, steps []StepTiming, commandErr error) error {
+	contents := m.render(command, started, steps, commandErr)
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(m.path), ".bbl-metrics")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics file: %s", err)
+	}
+
+	_, err = tempFile.Write(contents)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempFile.Name(), os.FileMode(0644))
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), m.path)
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return fmt.Errorf("failed to write metrics file: %s", err)
+	}
+
+	return nil
+}
+
+func (m MetricsWriter) render(command string, started time.Time, steps []StepTiming, commandErr error) []byte {
+	finished := now()
+	label := fmt.Sprintf("command=%q", command)
+
+	success := 1
+	if commandErr != nil {
+		success = 0
+	}
+
+	phases := map[string]time.Duration{}
+	for _, step := range steps {
+		phases[stepPhase(step.Message)] += step.Duration
+	}
+
+	var phaseNames []string
+	for phase := range phases {
+		phaseNames = append(phaseNames, phase)
+	}
+	sort.Strings(phaseNames)
+
+	buffer := bytes.NewBuffer([]byte{})
+
+	durationMetric := fmt.Sprintf("bbl_%s_duration_seconds", strings.Replace(command, "-", "_", -1))
+	writeMetric(buffer, durationMetric, fmt.Sprintf("Duration of the last bbl %s run.", command))
+	fmt.Fprintf(buffer, "%s %s\n", durationMetric, seconds(finished.Sub(started)))
+
+	writeMetric(buffer, "bbl_phase_duration_seconds", "Time the last bbl run spent in each phase.")
+	for _, phase := range phaseNames {
+		fmt.Fprintf(buffer, "bbl_phase_duration_seconds{%s,phase=%q} %s\n", label, phase, seconds(phases[phase]))
+	}
+
+	writeMetric(buffer, "bbl_run_success", "Whether the last bbl run succeeded.")
+	fmt.Fprintf(buffer, "bbl_run_success{%s} %d\n", label, success)
+
+	writeMetric(buffer, "bbl_last_error_code", "Exit code of the last bbl run, 0 on success.")
+	fmt.Fprintf(buffer, "bbl_last_error_code{%s} %d\n", label, ExitCode(commandErr))
+
+	writeMetric(buffer, "bbl_last_run_timestamp_seconds", "Unix time the last bbl run finished.")
+	fmt.Fprintf(buffer, "bbl_last_run_timestamp_seconds{%s} %d\n", label, finished.Unix())
+
+	return buffer.Bytes()
+}
+
+func stepPhase(message string) string {
+	message = strings.ToLower(message)
+	for _, k := range phaseKeywords {
+		if strings.Contains(message, k.keyword) {
+			return k.phase
+		}
+	}
+
+	return "other"
+}
+
+func writeMetric(buffer *bytes.Buffer, name, help string) {
+	fmt.Fprintf(buffer, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buffer, "# TYPE %s gauge\n", name)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
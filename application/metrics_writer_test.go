@@ -0,0 +1,126 @@
+package application_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/application"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MetricsWriter", func() {
+	var (
+		tempDir     string
+		metricsPath string
+		started     time.Time
+		steps       []application.StepTiming
+
+		writer application.MetricsWriter
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		metricsPath = filepath.Join(tempDir, "bbl.prom")
+
+		started = time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC)
+		application.SetNow(func() time.Time { return started.Add(90 * time.Second) })
+
+		steps = []application.StepTiming{
+			{Message: "verifying credentials", Duration: 500 * time.Millisecond},
+			{Message: "generating terraform template", Duration: time.Second},
+			{Message: "applying terraform template to aws", Duration: 30 * time.Second},
+			{Message: "creating jumpbox", Duration: 20 * time.Second},
+			{Message: "creating bosh director", Duration: 35 * time.Second},
+			{Message: "generating cloud config", Duration: 2500 * time.Millisecond},
+			{Message: "exiting", Duration: 500 * time.Millisecond},
+		}
+
+		writer = application.NewMetricsWriter(metricsPath)
+	})
+
+	AfterEach(func() {
+		application.ResetNow()
+		os.RemoveAll(tempDir)
+	})
+
+	It("writes the run duration, the time spent in each phase and the outcome", func() {
+		err := writer.Write("up", started, steps, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(metricsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(`# HELP bbl_up_duration_seconds Duration of the last bbl up run.
+# TYPE bbl_up_duration_seconds gauge
+bbl_up_duration_seconds 90.000
+# HELP bbl_phase_duration_seconds Time the last bbl run spent in each phase.
+# TYPE bbl_phase_duration_seconds gauge
+bbl_phase_duration_seconds{command="up",phase="cloud_config"} 2.500
+bbl_phase_duration_seconds{command="up",phase="credentials"} 0.500
+bbl_phase_duration_seconds{command="up",phase="director"} 35.000
+bbl_phase_duration_seconds{command="up",phase="jumpbox"} 20.000
+bbl_phase_duration_seconds{command="up",phase="other"} 0.500
+bbl_phase_duration_seconds{command="up",phase="terraform"} 31.000
+# HELP bbl_run_success Whether the last bbl run succeeded.
+# TYPE bbl_run_success gauge
+bbl_run_success{command="up"} 1
+# HELP bbl_last_error_code Exit code of the last bbl run, 0 on success.
+# TYPE bbl_last_error_code gauge
+bbl_last_error_code{command="up"} 0
+# HELP bbl_last_run_timestamp_seconds Unix time the last bbl run finished.
+# TYPE bbl_last_run_timestamp_seconds gauge
+bbl_last_run_timestamp_seconds{command="up"} 1498910490
+`))
+	})
+
+	It("names the duration metric after the command", func() {
+		err := writer.Write("create-lbs", started, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(metricsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("\nbbl_create_lbs_duration_seconds 90.000\n"))
+	})
+
+	It("records the failure and exit code when the command fails", func() {
+		err := writer.Write("up", started, steps, application.NewExitError(application.ExitCodeIAAS, errors.New("quota exceeded")))
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(metricsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("\nbbl_run_success{command=\"up\"} 0\n"))
+		Expect(string(contents)).To(ContainSubstring("\nbbl_last_error_code{command=\"up\"} 4\n"))
+	})
+
+	It("replaces an existing metrics file without leaving temporary files behind", func() {
+		err := ioutil.WriteFile(metricsPath, []byte("old metrics"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = writer.Write("up", started, steps, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(metricsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("old metrics"))
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	Context("when the metrics file cannot be written", func() {
+		It("returns an error", func() {
+			writer = application.NewMetricsWriter(filepath.Join(tempDir, "missing-dir", "bbl.prom"))
+
+			err := writer.Write("up", started, steps, nil)
+			Expect(err).To(MatchError(ContainSubstring("failed to write metrics file: ")))
+		})
+	})
+})
@@ -0,0 +1,31 @@
+package application
+
+// stateChangingCommands are the commands that write the state or change the
+// environment it describes. They hold the lock of the state dir while they
+// run, so that two of them never work with the same state at the same time.
+var stateChangingCommands = map[string]bool{
+	"up":                          true,
+	"destroy":                     true,
+	"down":                        true,
+	"create-lbs":                  true,
+	"update-lbs":                  true,
+	"rotate-lb-certs":             true,
+	"delete-lbs":                  true,
+	"cloud-config":                true,
+	"rotate":                      true,
+	"recreate-jumpbox":            true,
+	"regenerate-credhub-password": true,
+	"resize-director":             true,
+	"restore-state":               true,
+	"import-state":                true,
+	"migrate-state":               true,
+	"refresh":                     true,
+	"director-restore":            true,
+	"cleanup-leftovers":           true,
+}
+
+// ChangesState returns whether command writes the state or changes the
+// environment, and so must lock the state dir before the state is loaded.
+func ChangesState(command string) bool {
+	return stateChangingCommands[command]
+}
@@ -1,21 +1,44 @@
 package aws
 
 import (
+	"net/http"
+	"time"
+
 	goaws "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 )
 
 type Config struct {
 	AccessKeyID     string
 	SecretAccessKey string
+	SessionToken    string
 	Region          string
 }
 
+// ClientConfig uses the access key, and the session token of temporary
+// credentials, when one was provided. Without it the
+// clients fall back to the default credential chain of the sdk: environment
+// variables, the shared credentials file, and the ECS task or EC2 instance
+// role. Throttled requests are retried with backoff, and go through the
+// proxy of HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
 func (c Config) ClientConfig() *goaws.Config {
-	awsConfig := &goaws.Config{
-		Credentials: credentials.NewStaticCredentials(c.AccessKeyID, c.SecretAccessKey, ""),
-		Region:      goaws.String(c.Region),
+	awsConfig := request.WithRetryer(&goaws.Config{
+		Region:     goaws.String(c.Region),
+		HTTPClient: helpers.NewHTTPClient(),
+	}, newThrottleRetryer())
+
+	if c.AccessKeyID == "" && c.SecretAccessKey == "" {
+		// the instance role is read from the metadata endpoint, which the
+		// proxy cannot reach, with the short timeout the sdk uses for it
+		metadataClient := &http.Client{Transport: helpers.NewProxyTransport(), Timeout: 5 * time.Second}
+		awsConfig.Credentials = defaults.CredChain(defaults.Config().WithRegion(c.Region).WithHTTPClient(metadataClient), defaults.Handlers())
+		return awsConfig
 	}
 
+	awsConfig.Credentials = credentials.NewStaticCredentials(c.AccessKeyID, c.SecretAccessKey, c.SessionToken)
 	return awsConfig
 }
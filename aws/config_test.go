@@ -1,11 +1,15 @@
 package aws_test
 
 import (
+	"net/http"
+
 	goaws "github.com/aws/aws-sdk-go/aws"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/cloudfoundry/bosh-bootloader/aws"
 )
 
@@ -21,9 +25,79 @@ var _ = Describe("Config", func() {
 			awsConfig := &goaws.Config{
 				Credentials: credentials.NewStaticCredentials(config.AccessKeyID, config.SecretAccessKey, ""),
 				Region:      goaws.String(config.Region),
+				Retryer:     aws.NewThrottleRetryer(),
+			}
+
+			clientConfig := config.ClientConfig()
+			Expect(clientConfig.HTTPClient).NotTo(BeNil())
+			clientConfig.HTTPClient = nil
+			Expect(clientConfig).To(Equal(awsConfig))
+		})
+
+		It("goes through the proxy of the environment", func() {
+			transport := aws.Config{Region: "some-region"}.ClientConfig().HTTPClient.Transport.(*http.Transport)
+			Expect(transport.Proxy).NotTo(BeNil())
+		})
+
+		Describe("retries", func() {
+			var retryer request.Retryer
+
+			BeforeEach(func() {
+				retryer = aws.Config{Region: "some-region"}.ClientConfig().Retryer.(request.Retryer)
+			})
+
+			It("retries throttled requests more often than other failures", func() {
+				Expect(retryer.MaxRetries()).To(Equal(aws.MaxThrottleRetries))
+
+				throttled := &request.Request{
+					Error:        awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil),
+					HTTPResponse: &http.Response{StatusCode: http.StatusServiceUnavailable},
+					RetryCount:   aws.MaxRetries + 1,
+				}
+				Expect(retryer.ShouldRetry(throttled)).To(BeTrue())
+
+				failed := &request.Request{
+					Error:        awserr.New("InternalError", "We encountered an internal error.", nil),
+					HTTPResponse: &http.Response{StatusCode: http.StatusInternalServerError},
+					RetryCount:   aws.MaxRetries - 1,
+				}
+				Expect(retryer.ShouldRetry(failed)).To(BeTrue())
+
+				failed.RetryCount = aws.MaxRetries
+				Expect(retryer.ShouldRetry(failed)).To(BeFalse())
+			})
+
+			It("does not retry client errors", func() {
+				invalid := &request.Request{
+					Error:        awserr.New("InvalidParameterValue", "Invalid value.", nil),
+					HTTPResponse: &http.Response{StatusCode: http.StatusBadRequest},
+				}
+				Expect(retryer.ShouldRetry(invalid)).To(BeFalse())
+			})
+		})
+
+		It("uses the session token of temporary credentials", func() {
+			config := aws.Config{
+				AccessKeyID:     "some-access-key-id",
+				SecretAccessKey: "some-secret-access-key",
+				SessionToken:    "some-session-token",
+				Region:          "some-region",
+			}
+
+			value, err := config.ClientConfig().Credentials.Get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.SessionToken).To(Equal("some-session-token"))
+		})
+
+		It("falls back to the default credential chain without an access key", func() {
+			config := aws.Config{
+				Region: "some-region",
 			}
 
-			Expect(config.ClientConfig()).To(Equal(awsConfig))
+			awsConfig := config.ClientConfig()
+			Expect(awsConfig.Region).To(Equal(goaws.String("some-region")))
+			Expect(awsConfig.Credentials).NotTo(BeNil())
+			Expect(awsConfig.Credentials).NotTo(Equal(credentials.NewStaticCredentials("", "", "")))
 		})
 	})
 })
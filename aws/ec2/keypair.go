@@ -4,4 +4,6 @@ type KeyPair struct {
 	Name       string
 	PrivateKey string
 	PublicKey  string
+	Type       string
+	Bits       int
 }
@@ -6,17 +6,28 @@ type guidGenerator interface {
 	Generate() (string, error)
 }
 
+type sshKeyGenerator interface {
+	Generate(keyType string, bits int) (string, string, error)
+}
+
 type KeyPairCreator struct {
 	ec2ClientProvider ec2ClientProvider
+	sshKeyGenerator   sshKeyGenerator
 }
 
-func NewKeyPairCreator(ec2ClientProvider ec2ClientProvider) KeyPairCreator {
+func NewKeyPairCreator(ec2ClientProvider ec2ClientProvider, sshKeyGenerator sshKeyGenerator) KeyPairCreator {
 	return KeyPairCreator{
 		ec2ClientProvider: ec2ClientProvider,
+		sshKeyGenerator:   sshKeyGenerator,
 	}
 }
 
-func (c KeyPairCreator) Create(keyPairName string) (KeyPair, error) {
+// Create lets ec2 generate the keypair unless a key type is requested, in
+// which case the key is generated locally and imported.
+func (c KeyPairCreator) Create(keyPairName, keyType string, bits int) (KeyPair, error) {
+	if keyType != "" {
+		return c.importKeyPair(keyPairName, keyType, bits)
+	}
 
 	output, err := c.ec2ClientProvider.GetEC2Client().CreateKeyPair(&ec2.CreateKeyPairInput{
 		KeyName: &keyPairName,
@@ -35,3 +46,26 @@ func (c KeyPairCreator) Create(keyPairName string) (KeyPair, error) {
 		PrivateKey: keyMaterial,
 	}, nil
 }
+
+func (c KeyPairCreator) importKeyPair(keyPairName, keyType string, bits int) (KeyPair, error) {
+	privateKey, publicKey, err := c.sshKeyGenerator.Generate(keyType, bits)
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	_, err = c.ec2ClientProvider.GetEC2Client().ImportKeyPair(&ec2.ImportKeyPairInput{
+		KeyName:           &keyPairName,
+		PublicKeyMaterial: []byte(publicKey),
+	})
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	return KeyPair{
+		Name:       keyPairName,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+		Type:       keyType,
+		Bits:       bits,
+	}, nil
+}
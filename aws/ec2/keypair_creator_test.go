@@ -17,13 +17,15 @@ var _ = Describe("KeyPairCreator", func() {
 		keyPairCreator    ec2.KeyPairCreator
 		ec2Client         *fakes.EC2Client
 		awsClientProvider *fakes.AWSClientProvider
+		sshKeyGenerator   *fakes.SSHKeyGenerator
 	)
 
 	BeforeEach(func() {
 		awsClientProvider = &fakes.AWSClientProvider{}
 		ec2Client = &fakes.EC2Client{}
 		awsClientProvider.GetEC2ClientCall.Returns.EC2Client = ec2Client
-		keyPairCreator = ec2.NewKeyPairCreator(awsClientProvider)
+		sshKeyGenerator = &fakes.SSHKeyGenerator{}
+		keyPairCreator = ec2.NewKeyPairCreator(awsClientProvider, sshKeyGenerator)
 	})
 
 	Describe("Create", func() {
@@ -34,7 +36,7 @@ var _ = Describe("KeyPairCreator", func() {
 				KeyName:        goaws.String("keypair-guid"),
 			}
 
-			keyPair, err := keyPairCreator.Create("keypair-some-env-id")
+			keyPair, err := keyPairCreator.Create("keypair-some-env-id", "", 0)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(keyPair.Name).To(Equal("keypair-some-env-id"))
@@ -45,12 +47,50 @@ var _ = Describe("KeyPairCreator", func() {
 			}))
 		})
 
+		Context("when a key type is requested", func() {
+			It("generates the keypair locally and imports it to ec2", func() {
+				sshKeyGenerator.GenerateCall.Returns.PrivateKey = "some-private-key"
+				sshKeyGenerator.GenerateCall.Returns.PublicKey = "ssh-ed25519 some-public-key"
+
+				keyPair, err := keyPairCreator.Create("keypair-some-env-id", "ed25519", 0)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyGenerator.GenerateCall.Receives.KeyType).To(Equal("ed25519"))
+				Expect(ec2Client.CreateKeyPairCall.Receives.Input).To(BeNil())
+				Expect(ec2Client.ImportKeyPairCall.Receives.Input).To(Equal(&awsec2.ImportKeyPairInput{
+					KeyName:           goaws.String("keypair-some-env-id"),
+					PublicKeyMaterial: []byte("ssh-ed25519 some-public-key"),
+				}))
+
+				Expect(keyPair).To(Equal(ec2.KeyPair{
+					Name:       "keypair-some-env-id",
+					PrivateKey: "some-private-key",
+					PublicKey:  "ssh-ed25519 some-public-key",
+					Type:       "ed25519",
+				}))
+			})
+
+			It("returns an error when the key cannot be generated", func() {
+				sshKeyGenerator.GenerateCall.Returns.Error = errors.New("failed to generate key")
+
+				_, err := keyPairCreator.Create("keypair-some-env-id", "rsa", 4096)
+				Expect(err).To(MatchError("failed to generate key"))
+			})
+
+			It("returns an error when the import fails", func() {
+				ec2Client.ImportKeyPairCall.Returns.Error = errors.New("failed to import keypair")
+
+				_, err := keyPairCreator.Create("keypair-some-env-id", "rsa", 4096)
+				Expect(err).To(MatchError("failed to import keypair"))
+			})
+		})
+
 		Context("failure cases", func() {
 			Context("when the create keypair request fails", func() {
 				It("returns an error", func() {
 					ec2Client.CreateKeyPairCall.Returns.Error = errors.New("failed to create keypair")
 
-					_, err := keyPairCreator.Create("")
+					_, err := keyPairCreator.Create("", "", 0)
 					Expect(err).To(MatchError("failed to create keypair"))
 				})
 			})
@@ -7,7 +7,7 @@ type KeyPairSynchronizer struct {
 }
 
 type keypairCreator interface {
-	Create(keyPairName string) (KeyPair, error)
+	Create(keyPairName, keyType string, bits int) (KeyPair, error)
 }
 
 type keypairChecker interface {
@@ -39,7 +39,7 @@ func (k KeyPairSynchronizer) Sync(keyPair KeyPair) (KeyPair, error) {
 		keyPairName := keyPair.Name
 		k.logger.Step("creating keypair")
 
-		keyPair, err = k.creator.Create(keyPairName)
+		keyPair, err = k.creator.Create(keyPairName, keyPair.Type, keyPair.Bits)
 		if err != nil {
 			return KeyPair{}, err
 		}
@@ -68,6 +68,15 @@ var _ = Describe("KeyPairSynchronizer", func() {
 				}))
 			})
 
+			It("creates a keypair with the requested key type", func() {
+				stateKeyPair.Type = "ed25519"
+
+				_, err := manager.Sync(stateKeyPair)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(creator.CreateCall.Receives.KeyType).To(Equal("ed25519"))
+			})
+
 			Context("error cases", func() {
 				Context("when the keypair cannot be created", func() {
 					It("returns an error", func() {
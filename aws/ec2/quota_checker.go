@@ -0,0 +1,190 @@
+package ec2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/cloudfoundry/bosh-bootloader/aws/servicequotas"
+)
+
+// The service quotas that bbl up counts against. The vCPU quota covers the
+// standard instance families, which are the ones bbl uses.
+const (
+	elasticIPsQuotaCode = "L-0263D0A3"
+	vpcsQuotaCode       = "L-F678F1CE"
+	vCPUsQuotaCode      = "L-1216C47A"
+
+	standardInstanceFamilies = "acdhimrtz"
+
+	defaultDirectorInstanceType = "m4.xlarge"
+	jumpboxInstanceType         = "t2.micro"
+	natInstanceType             = "t2.medium"
+)
+
+type serviceQuotas interface {
+	Quota(serviceCode, quotaCode string) (servicequotas.Quota, error)
+}
+
+// QuotaEnvironment describes the parts of a new bbl environment that count
+// against the quotas of the account.
+type QuotaEnvironment struct {
+	NoDirector     bool
+	Jumpbox        bool
+	ExistingVPC    bool
+	NATGateway     bool
+	DirectorVMType string
+}
+
+type QuotaChecker struct {
+	ec2ClientProvider ec2ClientProvider
+	serviceQuotas     serviceQuotas
+}
+
+func NewQuotaChecker(ec2ClientProvider ec2ClientProvider, serviceQuotas serviceQuotas) QuotaChecker {
+	return QuotaChecker{
+		ec2ClientProvider: ec2ClientProvider,
+		serviceQuotas:     serviceQuotas,
+	}
+}
+
+// Check verifies the account can hold the vpc, elastic ips and vCPUs of the
+// instances that a new bbl environment creates.
+func (q QuotaChecker) Check(environment QuotaEnvironment) error {
+	client := q.ec2ClientProvider.GetEC2Client()
+
+	if !environment.ExistingVPC {
+		vpcs, err := client.DescribeVpcs(&awsec2.DescribeVpcsInput{})
+		if err != nil {
+			return err
+		}
+
+		err = q.checkQuota("vpc", vpcsQuotaCode, 1, len(vpcs.Vpcs))
+		if err != nil {
+			return err
+		}
+	}
+
+	addresses, err := client.DescribeAddresses(&awsec2.DescribeAddressesInput{
+		Filters: []*awsec2.Filter{{
+			Name:   aws.String("domain"),
+			Values: []*string{aws.String("vpc")},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// the director's address, and the nat's unless the vpc already exists
+	neededAddresses := 1
+	if !environment.ExistingVPC {
+		neededAddresses++
+	}
+
+	err = q.checkQuota("ec2", elasticIPsQuotaCode, neededAddresses, len(addresses.Addresses))
+	if err != nil {
+		return err
+	}
+
+	usedVCPUs, err := q.runningVCPUs(client)
+	if err != nil {
+		return err
+	}
+
+	return q.checkQuota("ec2", vCPUsQuotaCode, neededVCPUs(environment), usedVCPUs)
+}
+
+func (q QuotaChecker) checkQuota(serviceCode, quotaCode string, need, used int) error {
+	quota, err := q.serviceQuotas.Quota(serviceCode, quotaCode)
+	if err != nil {
+		return err
+	}
+
+	have := quota.Value - used
+	if have < 0 {
+		have = 0
+	}
+
+	if need > have {
+		return fmt.Errorf("quota %s: need %d, have %d", quota.Name, need, have)
+	}
+
+	return nil
+}
+
+func (q QuotaChecker) runningVCPUs(client Client) (int, error) {
+	input := &awsec2.DescribeInstancesInput{
+		Filters: []*awsec2.Filter{{
+			Name:   aws.String("instance-state-name"),
+			Values: []*string{aws.String("pending"), aws.String("running")},
+		}},
+	}
+
+	count := 0
+	for {
+		output, err := client.DescribeInstances(input)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceType := aws.StringValue(instance.InstanceType)
+				if instanceType != "" && strings.ContainsRune(standardInstanceFamilies, rune(instanceType[0])) {
+					count += instanceTypeVCPUs(instanceType)
+				}
+			}
+		}
+
+		if aws.StringValue(output.NextToken) == "" {
+			return count, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+func neededVCPUs(environment QuotaEnvironment) int {
+	vCPUs := 0
+	if !environment.ExistingVPC && !environment.NATGateway {
+		vCPUs += instanceTypeVCPUs(natInstanceType)
+	}
+
+	if environment.Jumpbox {
+		vCPUs += instanceTypeVCPUs(jumpboxInstanceType)
+	}
+
+	if !environment.NoDirector {
+		directorInstanceType := environment.DirectorVMType
+		if directorInstanceType == "" {
+			directorInstanceType = defaultDirectorInstanceType
+		}
+		vCPUs += instanceTypeVCPUs(directorInstanceType)
+	}
+
+	return vCPUs
+}
+
+// instanceTypeVCPUs returns the vCPUs of an instance type from its size, e.g.
+// 4 for m4.xlarge and 32 for m4.8xlarge. Sizes that do not follow the naming,
+// like metal, are not counted.
+func instanceTypeVCPUs(instanceType string) int {
+	size := instanceType[strings.Index(instanceType, ".")+1:]
+
+	switch size {
+	case "nano", "micro", "small":
+		return 1
+	case "medium", "large":
+		return 2
+	case "xlarge":
+		return 4
+	}
+
+	multiple, err := strconv.Atoi(strings.TrimSuffix(size, "xlarge"))
+	if err != nil || !strings.HasSuffix(size, "xlarge") {
+		return 0
+	}
+
+	return 4 * multiple
+}
@@ -0,0 +1,171 @@
+package ec2_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
+	"github.com/cloudfoundry/bosh-bootloader/aws/servicequotas"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QuotaChecker", func() {
+	var (
+		quotaChecker      ec2.QuotaChecker
+		ec2Client         *fakes.EC2Client
+		awsClientProvider *fakes.AWSClientProvider
+		serviceQuotas     *fakes.AWSServiceQuotas
+	)
+
+	var instances = func(instanceTypes ...string) []*awsec2.Instance {
+		instances := []*awsec2.Instance{}
+		for _, instanceType := range instanceTypes {
+			instances = append(instances, &awsec2.Instance{InstanceType: aws.String(instanceType)})
+		}
+		return instances
+	}
+
+	BeforeEach(func() {
+		awsClientProvider = &fakes.AWSClientProvider{}
+		ec2Client = &fakes.EC2Client{}
+		awsClientProvider.GetEC2ClientCall.Returns.EC2Client = ec2Client
+
+		serviceQuotas = &fakes.AWSServiceQuotas{}
+		serviceQuotas.QuotaCall.Returns.Quotas = map[string]servicequotas.Quota{
+			"L-F678F1CE": {Name: "VPCs per Region", Value: 5},
+			"L-0263D0A3": {Name: "EC2-VPC Elastic IPs", Value: 5},
+			"L-1216C47A": {Name: "Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances", Value: 16},
+		}
+
+		ec2Client.DescribeVpcsCall.Returns.Output = &awsec2.DescribeVpcsOutput{
+			Vpcs: []*awsec2.Vpc{{}, {}},
+		}
+		ec2Client.DescribeAddressesCall.Returns.Output = &awsec2.DescribeAddressesOutput{
+			Addresses: []*awsec2.Address{{}, {}},
+		}
+		ec2Client.DescribeInstancesCall.Returns.Output = &awsec2.DescribeInstancesOutput{
+			Reservations: []*awsec2.Reservation{{
+				Instances: instances("t2.micro", "m4.large", "p3.2xlarge"),
+			}},
+		}
+
+		quotaChecker = ec2.NewQuotaChecker(awsClientProvider, serviceQuotas)
+	})
+
+	Describe("Check", func() {
+		It("returns nil when the account has room for the environment", func() {
+			err := quotaChecker.Check(ec2.QuotaEnvironment{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(serviceQuotas.QuotaCall.Receives).To(Equal([]fakes.AWSServiceQuotasQuotaReceives{
+				{ServiceCode: "vpc", QuotaCode: "L-F678F1CE"},
+				{ServiceCode: "ec2", QuotaCode: "L-0263D0A3"},
+				{ServiceCode: "ec2", QuotaCode: "L-1216C47A"},
+			}))
+			Expect(ec2Client.DescribeAddressesCall.Receives.Input).To(Equal(&awsec2.DescribeAddressesInput{
+				Filters: []*awsec2.Filter{{
+					Name:   aws.String("domain"),
+					Values: []*string{aws.String("vpc")},
+				}},
+			}))
+			Expect(ec2Client.DescribeInstancesCall.Receives.Input).To(Equal(&awsec2.DescribeInstancesInput{
+				Filters: []*awsec2.Filter{{
+					Name:   aws.String("instance-state-name"),
+					Values: []*string{aws.String("pending"), aws.String("running")},
+				}},
+			}))
+		})
+
+		It("returns an error when there are no vpcs left", func() {
+			ec2Client.DescribeVpcsCall.Returns.Output.Vpcs = []*awsec2.Vpc{{}, {}, {}, {}, {}}
+
+			err := quotaChecker.Check(ec2.QuotaEnvironment{})
+			Expect(err).To(MatchError("quota VPCs per Region: need 1, have 0"))
+		})
+
+		It("returns an error when there are not enough elastic ips left", func() {
+			ec2Client.DescribeAddressesCall.Returns.Output = &awsec2.DescribeAddressesOutput{
+				Addresses: []*awsec2.Address{{}, {}, {}, {}},
+			}
+
+			err := quotaChecker.Check(ec2.QuotaEnvironment{})
+			Expect(err).To(MatchError("quota EC2-VPC Elastic IPs: need 2, have 1"))
+		})
+
+		It("returns an error when there are not enough vCPUs left for the nat, the jumpbox and the director", func() {
+			err := quotaChecker.Check(ec2.QuotaEnvironment{
+				Jumpbox:        true,
+				DirectorVMType: "m4.4xlarge",
+			})
+			Expect(err).To(MatchError("quota Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances: need 19, have 13"))
+		})
+
+		It("only needs the director's address and vCPUs in an existing vpc", func() {
+			ec2Client.DescribeAddressesCall.Returns.Output = &awsec2.DescribeAddressesOutput{
+				Addresses: []*awsec2.Address{{}, {}, {}, {}},
+			}
+			serviceQuotas.QuotaCall.Returns.Quotas["L-1216C47A"] = servicequotas.Quota{Name: "vCPUs", Value: 7}
+
+			err := quotaChecker.Check(ec2.QuotaEnvironment{ExistingVPC: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(serviceQuotas.QuotaCall.Receives).NotTo(ContainElement(fakes.AWSServiceQuotasQuotaReceives{ServiceCode: "vpc", QuotaCode: "L-F678F1CE"}))
+		})
+
+		It("does not need the vCPUs of a nat instance behind a nat gateway", func() {
+			serviceQuotas.QuotaCall.Returns.Quotas["L-1216C47A"] = servicequotas.Quota{Name: "vCPUs", Value: 7}
+
+			err := quotaChecker.Check(ec2.QuotaEnvironment{NATGateway: true})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("only needs the nat instance when there is no director", func() {
+			serviceQuotas.QuotaCall.Returns.Quotas["L-1216C47A"] = servicequotas.Quota{Name: "vCPUs", Value: 5}
+
+			err := quotaChecker.Check(ec2.QuotaEnvironment{NoDirector: true})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports a usage above the limit as nothing left", func() {
+			serviceQuotas.QuotaCall.Returns.Quotas["L-1216C47A"] = servicequotas.Quota{Name: "vCPUs", Value: 1}
+
+			err := quotaChecker.Check(ec2.QuotaEnvironment{NoDirector: true})
+			Expect(err).To(MatchError("quota vCPUs: need 2, have 0"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the vpcs cannot be described", func() {
+				ec2Client.DescribeVpcsCall.Returns.Error = errors.New("failed to describe vpcs")
+
+				err := quotaChecker.Check(ec2.QuotaEnvironment{})
+				Expect(err).To(MatchError("failed to describe vpcs"))
+			})
+
+			It("returns an error when a quota cannot be retrieved", func() {
+				serviceQuotas.QuotaCall.Returns.Error = errors.New("failed to get quota")
+
+				err := quotaChecker.Check(ec2.QuotaEnvironment{})
+				Expect(err).To(MatchError("failed to get quota"))
+			})
+
+			It("returns an error when the addresses cannot be described", func() {
+				ec2Client.DescribeAddressesCall.Returns.Error = errors.New("failed to describe addresses")
+
+				err := quotaChecker.Check(ec2.QuotaEnvironment{})
+				Expect(err).To(MatchError("failed to describe addresses"))
+			})
+
+			It("returns an error when the instances cannot be described", func() {
+				ec2Client.DescribeInstancesCall.Returns.Error = errors.New("failed to describe instances")
+
+				err := quotaChecker.Check(ec2.QuotaEnvironment{})
+				Expect(err).To(MatchError("failed to describe instances"))
+			})
+		})
+	})
+})
@@ -0,0 +1,3 @@
+package aws
+
+var NewThrottleRetryer = newThrottleRetryer
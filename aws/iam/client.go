@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsiam "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 //go:generate counterfeiter -o ./fakes/iam_client.go --fake-name Client . Client
@@ -12,8 +13,23 @@ type Client interface {
 	GetServerCertificate(*awsiam.GetServerCertificateInput) (*awsiam.GetServerCertificateOutput, error)
 	DeleteServerCertificate(*awsiam.DeleteServerCertificateInput) (*awsiam.DeleteServerCertificateOutput, error)
 	DeleteUserPolicy(*awsiam.DeleteUserPolicyInput) (*awsiam.DeleteUserPolicyOutput, error)
+	ListServerCertificates(*awsiam.ListServerCertificatesInput) (*awsiam.ListServerCertificatesOutput, error)
+	GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error)
+	GetRole(*awsiam.GetRoleInput) (*awsiam.GetRoleOutput, error)
+	SimulatePrincipalPolicy(*awsiam.SimulatePrincipalPolicyInput) (*awsiam.SimulatePolicyResponse, error)
+}
+
+// client adds the caller identity of sts to the iam api, which needs it to
+// tell whose policies to simulate.
+type client struct {
+	*awsiam.IAM
+	*sts.STS
 }
 
 func NewClient(config aws.Config) Client {
-	return awsiam.New(session.New(config.ClientConfig()))
+	awsSession := session.New(config.ClientConfig())
+	return client{
+		IAM: awsiam.New(awsSession),
+		STS: sts.New(awsSession),
+	}
 }
@@ -6,7 +6,6 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/aws/iam"
 
 	goaws "github.com/aws/aws-sdk-go/aws"
-	awsiam "github.com/aws/aws-sdk-go/service/iam"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -24,11 +23,12 @@ var _ = Describe("Client", func() {
 			_, ok := client.(iam.Client)
 			Expect(ok).To(BeTrue())
 
-			iamClient, ok := client.(*awsiam.IAM)
-			Expect(ok).To(BeTrue())
+			iamClient, stsClient := iam.ClientAPIs(client)
 
 			Expect(iamClient.Config.Credentials).To(Equal(credentials.NewStaticCredentials("some-access-key-id", "some-secret-access-key", "")))
 			Expect(iamClient.Config.Region).To(Equal(goaws.String("some-region")))
+			Expect(stsClient.Config.Credentials).To(Equal(credentials.NewStaticCredentials("some-access-key-id", "some-secret-access-key", "")))
+			Expect(stsClient.Config.Region).To(Equal(goaws.String("some-region")))
 		})
 	})
 })
@@ -0,0 +1,10 @@
+package iam
+
+import (
+	awsiam "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func ClientAPIs(c Client) (*awsiam.IAM, *sts.STS) {
+	return c.(client).IAM, c.(client).STS
+}
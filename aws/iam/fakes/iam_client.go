@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	awsiam "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/cloudfoundry/bosh-bootloader/aws/iam"
 )
 
@@ -48,6 +49,58 @@ type Client struct {
 		result1 *awsiam.DeleteUserPolicyOutput
 		result2 error
 	}
+	ListServerCertificatesStub        func(*awsiam.ListServerCertificatesInput) (*awsiam.ListServerCertificatesOutput, error)
+	listServerCertificatesMutex       sync.RWMutex
+	listServerCertificatesArgsForCall []struct {
+		arg1 *awsiam.ListServerCertificatesInput
+	}
+	listServerCertificatesReturns struct {
+		result1 *awsiam.ListServerCertificatesOutput
+		result2 error
+	}
+	listServerCertificatesReturnsOnCall map[int]struct {
+		result1 *awsiam.ListServerCertificatesOutput
+		result2 error
+	}
+	GetCallerIdentityStub        func(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error)
+	getCallerIdentityMutex       sync.RWMutex
+	getCallerIdentityArgsForCall []struct {
+		arg1 *sts.GetCallerIdentityInput
+	}
+	getCallerIdentityReturns struct {
+		result1 *sts.GetCallerIdentityOutput
+		result2 error
+	}
+	getCallerIdentityReturnsOnCall map[int]struct {
+		result1 *sts.GetCallerIdentityOutput
+		result2 error
+	}
+	GetRoleStub        func(*awsiam.GetRoleInput) (*awsiam.GetRoleOutput, error)
+	getRoleMutex       sync.RWMutex
+	getRoleArgsForCall []struct {
+		arg1 *awsiam.GetRoleInput
+	}
+	getRoleReturns struct {
+		result1 *awsiam.GetRoleOutput
+		result2 error
+	}
+	getRoleReturnsOnCall map[int]struct {
+		result1 *awsiam.GetRoleOutput
+		result2 error
+	}
+	SimulatePrincipalPolicyStub        func(*awsiam.SimulatePrincipalPolicyInput) (*awsiam.SimulatePolicyResponse, error)
+	simulatePrincipalPolicyMutex       sync.RWMutex
+	simulatePrincipalPolicyArgsForCall []struct {
+		arg1 *awsiam.SimulatePrincipalPolicyInput
+	}
+	simulatePrincipalPolicyReturns struct {
+		result1 *awsiam.SimulatePolicyResponse
+		result2 error
+	}
+	simulatePrincipalPolicyReturnsOnCall map[int]struct {
+		result1 *awsiam.SimulatePolicyResponse
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -205,6 +258,216 @@ func (fake *Client) DeleteUserPolicyReturnsOnCall(i int, result1 *awsiam.DeleteU
 	}{result1, result2}
 }
 
+func (fake *Client) ListServerCertificates(arg1 *awsiam.ListServerCertificatesInput) (*awsiam.ListServerCertificatesOutput, error) {
+	fake.listServerCertificatesMutex.Lock()
+	ret, specificReturn := fake.listServerCertificatesReturnsOnCall[len(fake.listServerCertificatesArgsForCall)]
+	fake.listServerCertificatesArgsForCall = append(fake.listServerCertificatesArgsForCall, struct {
+		arg1 *awsiam.ListServerCertificatesInput
+	}{arg1})
+	fake.recordInvocation("ListServerCertificates", []interface{}{arg1})
+	fake.listServerCertificatesMutex.Unlock()
+	if fake.ListServerCertificatesStub != nil {
+		return fake.ListServerCertificatesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.listServerCertificatesReturns.result1, fake.listServerCertificatesReturns.result2
+}
+
+func (fake *Client) ListServerCertificatesCallCount() int {
+	fake.listServerCertificatesMutex.RLock()
+	defer fake.listServerCertificatesMutex.RUnlock()
+	fake.getCallerIdentityMutex.RLock()
+	defer fake.getCallerIdentityMutex.RUnlock()
+	fake.getRoleMutex.RLock()
+	defer fake.getRoleMutex.RUnlock()
+	fake.simulatePrincipalPolicyMutex.RLock()
+	defer fake.simulatePrincipalPolicyMutex.RUnlock()
+	return len(fake.listServerCertificatesArgsForCall)
+}
+
+func (fake *Client) ListServerCertificatesArgsForCall(i int) *awsiam.ListServerCertificatesInput {
+	fake.listServerCertificatesMutex.RLock()
+	defer fake.listServerCertificatesMutex.RUnlock()
+	return fake.listServerCertificatesArgsForCall[i].arg1
+}
+
+func (fake *Client) ListServerCertificatesReturns(result1 *awsiam.ListServerCertificatesOutput, result2 error) {
+	fake.ListServerCertificatesStub = nil
+	fake.listServerCertificatesReturns = struct {
+		result1 *awsiam.ListServerCertificatesOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Client) ListServerCertificatesReturnsOnCall(i int, result1 *awsiam.ListServerCertificatesOutput, result2 error) {
+	fake.ListServerCertificatesStub = nil
+	if fake.listServerCertificatesReturnsOnCall == nil {
+		fake.listServerCertificatesReturnsOnCall = make(map[int]struct {
+			result1 *awsiam.ListServerCertificatesOutput
+			result2 error
+		})
+	}
+	fake.listServerCertificatesReturnsOnCall[i] = struct {
+		result1 *awsiam.ListServerCertificatesOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Client) GetCallerIdentity(arg1 *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	fake.getCallerIdentityMutex.Lock()
+	ret, specificReturn := fake.getCallerIdentityReturnsOnCall[len(fake.getCallerIdentityArgsForCall)]
+	fake.getCallerIdentityArgsForCall = append(fake.getCallerIdentityArgsForCall, struct {
+		arg1 *sts.GetCallerIdentityInput
+	}{arg1})
+	fake.recordInvocation("GetCallerIdentity", []interface{}{arg1})
+	fake.getCallerIdentityMutex.Unlock()
+	if fake.GetCallerIdentityStub != nil {
+		return fake.GetCallerIdentityStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.getCallerIdentityReturns.result1, fake.getCallerIdentityReturns.result2
+}
+
+func (fake *Client) GetCallerIdentityCallCount() int {
+	fake.getCallerIdentityMutex.RLock()
+	defer fake.getCallerIdentityMutex.RUnlock()
+	return len(fake.getCallerIdentityArgsForCall)
+}
+
+func (fake *Client) GetCallerIdentityArgsForCall(i int) *sts.GetCallerIdentityInput {
+	fake.getCallerIdentityMutex.RLock()
+	defer fake.getCallerIdentityMutex.RUnlock()
+	return fake.getCallerIdentityArgsForCall[i].arg1
+}
+
+func (fake *Client) GetCallerIdentityReturns(result1 *sts.GetCallerIdentityOutput, result2 error) {
+	fake.GetCallerIdentityStub = nil
+	fake.getCallerIdentityReturns = struct {
+		result1 *sts.GetCallerIdentityOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Client) GetCallerIdentityReturnsOnCall(i int, result1 *sts.GetCallerIdentityOutput, result2 error) {
+	fake.GetCallerIdentityStub = nil
+	if fake.getCallerIdentityReturnsOnCall == nil {
+		fake.getCallerIdentityReturnsOnCall = make(map[int]struct {
+			result1 *sts.GetCallerIdentityOutput
+			result2 error
+		})
+	}
+	fake.getCallerIdentityReturnsOnCall[i] = struct {
+		result1 *sts.GetCallerIdentityOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Client) GetRole(arg1 *awsiam.GetRoleInput) (*awsiam.GetRoleOutput, error) {
+	fake.getRoleMutex.Lock()
+	ret, specificReturn := fake.getRoleReturnsOnCall[len(fake.getRoleArgsForCall)]
+	fake.getRoleArgsForCall = append(fake.getRoleArgsForCall, struct {
+		arg1 *awsiam.GetRoleInput
+	}{arg1})
+	fake.recordInvocation("GetRole", []interface{}{arg1})
+	fake.getRoleMutex.Unlock()
+	if fake.GetRoleStub != nil {
+		return fake.GetRoleStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.getRoleReturns.result1, fake.getRoleReturns.result2
+}
+
+func (fake *Client) GetRoleCallCount() int {
+	fake.getRoleMutex.RLock()
+	defer fake.getRoleMutex.RUnlock()
+	return len(fake.getRoleArgsForCall)
+}
+
+func (fake *Client) GetRoleArgsForCall(i int) *awsiam.GetRoleInput {
+	fake.getRoleMutex.RLock()
+	defer fake.getRoleMutex.RUnlock()
+	return fake.getRoleArgsForCall[i].arg1
+}
+
+func (fake *Client) GetRoleReturns(result1 *awsiam.GetRoleOutput, result2 error) {
+	fake.GetRoleStub = nil
+	fake.getRoleReturns = struct {
+		result1 *awsiam.GetRoleOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Client) GetRoleReturnsOnCall(i int, result1 *awsiam.GetRoleOutput, result2 error) {
+	fake.GetRoleStub = nil
+	if fake.getRoleReturnsOnCall == nil {
+		fake.getRoleReturnsOnCall = make(map[int]struct {
+			result1 *awsiam.GetRoleOutput
+			result2 error
+		})
+	}
+	fake.getRoleReturnsOnCall[i] = struct {
+		result1 *awsiam.GetRoleOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Client) SimulatePrincipalPolicy(arg1 *awsiam.SimulatePrincipalPolicyInput) (*awsiam.SimulatePolicyResponse, error) {
+	fake.simulatePrincipalPolicyMutex.Lock()
+	ret, specificReturn := fake.simulatePrincipalPolicyReturnsOnCall[len(fake.simulatePrincipalPolicyArgsForCall)]
+	fake.simulatePrincipalPolicyArgsForCall = append(fake.simulatePrincipalPolicyArgsForCall, struct {
+		arg1 *awsiam.SimulatePrincipalPolicyInput
+	}{arg1})
+	fake.recordInvocation("SimulatePrincipalPolicy", []interface{}{arg1})
+	fake.simulatePrincipalPolicyMutex.Unlock()
+	if fake.SimulatePrincipalPolicyStub != nil {
+		return fake.SimulatePrincipalPolicyStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.simulatePrincipalPolicyReturns.result1, fake.simulatePrincipalPolicyReturns.result2
+}
+
+func (fake *Client) SimulatePrincipalPolicyCallCount() int {
+	fake.simulatePrincipalPolicyMutex.RLock()
+	defer fake.simulatePrincipalPolicyMutex.RUnlock()
+	return len(fake.simulatePrincipalPolicyArgsForCall)
+}
+
+func (fake *Client) SimulatePrincipalPolicyArgsForCall(i int) *awsiam.SimulatePrincipalPolicyInput {
+	fake.simulatePrincipalPolicyMutex.RLock()
+	defer fake.simulatePrincipalPolicyMutex.RUnlock()
+	return fake.simulatePrincipalPolicyArgsForCall[i].arg1
+}
+
+func (fake *Client) SimulatePrincipalPolicyReturns(result1 *awsiam.SimulatePolicyResponse, result2 error) {
+	fake.SimulatePrincipalPolicyStub = nil
+	fake.simulatePrincipalPolicyReturns = struct {
+		result1 *awsiam.SimulatePolicyResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Client) SimulatePrincipalPolicyReturnsOnCall(i int, result1 *awsiam.SimulatePolicyResponse, result2 error) {
+	fake.SimulatePrincipalPolicyStub = nil
+	if fake.simulatePrincipalPolicyReturnsOnCall == nil {
+		fake.simulatePrincipalPolicyReturnsOnCall = make(map[int]struct {
+			result1 *awsiam.SimulatePolicyResponse
+			result2 error
+		})
+	}
+	fake.simulatePrincipalPolicyReturnsOnCall[i] = struct {
+		result1 *awsiam.SimulatePolicyResponse
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *Client) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -214,6 +477,8 @@ func (fake *Client) Invocations() map[string][][]interface{} {
 	defer fake.deleteServerCertificateMutex.RUnlock()
 	fake.deleteUserPolicyMutex.RLock()
 	defer fake.deleteUserPolicyMutex.RUnlock()
+	fake.listServerCertificatesMutex.RLock()
+	defer fake.listServerCertificatesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
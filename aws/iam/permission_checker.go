@@ -0,0 +1,122 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsiam "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// requiredActions are the actions terraform and the director need to create
+// the resources of a bbl environment and its load balancers.
+var requiredActions = []string{
+	"ec2:AllocateAddress",
+	"ec2:AssociateRouteTable",
+	"ec2:AuthorizeSecurityGroupIngress",
+	"ec2:CreateInternetGateway",
+	"ec2:CreateKeyPair",
+	"ec2:CreateRoute",
+	"ec2:CreateRouteTable",
+	"ec2:CreateSecurityGroup",
+	"ec2:CreateSubnet",
+	"ec2:CreateTags",
+	"ec2:CreateVpc",
+	"ec2:DescribeAvailabilityZones",
+	"ec2:RunInstances",
+	"ec2:TerminateInstances",
+	"elasticloadbalancing:CreateLoadBalancer",
+	"iam:CreateInstanceProfile",
+	"iam:CreateRole",
+	"iam:PassRole",
+	"iam:PutRolePolicy",
+	"iam:UploadServerCertificate",
+}
+
+type PermissionChecker struct {
+	iamClientProvider iamClientProvider
+}
+
+func NewPermissionChecker(iamClientProvider iamClientProvider) PermissionChecker {
+	return PermissionChecker{
+		iamClientProvider: iamClientProvider,
+	}
+}
+
+// Check simulates the policies of the IAM user or role the credentials
+// belong to and returns the required actions it is not allowed to perform.
+func (p PermissionChecker) Check() ([]string, error) {
+	client := p.iamClientProvider.GetIAMClient()
+
+	identity, err := client.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the identity of the credentials: %s", err)
+	}
+
+	principal, err := principalARN(client, aws.StringValue(identity.Arn))
+	if err != nil {
+		return nil, err
+	}
+
+	// the root user of an account is allowed every action and its policies
+	// cannot be simulated.
+	if principal == "" {
+		return []string{}, nil
+	}
+
+	input := &awsiam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principal),
+		ActionNames:     aws.StringSlice(requiredActions),
+	}
+
+	denied := []string{}
+	for {
+		output, err := client.SimulatePrincipalPolicy(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate the policies of %s: %s", principal, err)
+		}
+
+		for _, result := range output.EvaluationResults {
+			if aws.StringValue(result.EvalDecision) != awsiam.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, aws.StringValue(result.EvalActionName))
+			}
+		}
+
+		if !aws.BoolValue(output.IsTruncated) {
+			return denied, nil
+		}
+		input.Marker = output.Marker
+	}
+}
+
+// principalARN returns the arn of the iam user or role whose policies apply to
+// the caller. The caller of assumed role credentials is a session of the
+// role, arn:aws:sts::<account>:assumed-role/<role>/<session>, which is looked
+// up for the arn of the role, with its path. It returns "" for the root user.
+func principalARN(client Client, callerARN string) (string, error) {
+	// arn:<partition>:<service>:<region>:<account>:<resource>
+	parts := strings.SplitN(callerARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return "", fmt.Errorf("failed to parse the arn of the credentials %q", callerARN)
+	}
+	service, resource := parts[2], parts[5]
+
+	switch {
+	case service == "iam" && resource == "root":
+		return "", nil
+	case service == "iam":
+		return callerARN, nil
+	case service == "sts" && strings.HasPrefix(resource, "assumed-role/"):
+		roleName := strings.Split(resource, "/")[1]
+
+		role, err := client.GetRole(&awsiam.GetRoleInput{RoleName: aws.String(roleName)})
+		if err != nil {
+			return "", fmt.Errorf("failed to get the iam role %s of the credentials: %s", roleName, err)
+		}
+
+		return aws.StringValue(role.Role.Arn), nil
+	default:
+		return "", fmt.Errorf("cannot simulate the policies of %s, only iam users and roles can be checked", callerARN)
+	}
+}
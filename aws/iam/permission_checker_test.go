@@ -0,0 +1,131 @@
+package iam_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsiam "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cloudfoundry/bosh-bootloader/aws/iam"
+	"github.com/cloudfoundry/bosh-bootloader/aws/iam/fakes"
+	awsClientFake "github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PermissionChecker", func() {
+	var (
+		iamClient         *fakes.Client
+		awsClientProvider *awsClientFake.AWSClientProvider
+		checker           iam.PermissionChecker
+	)
+
+	BeforeEach(func() {
+		iamClient = &fakes.Client{}
+		awsClientProvider = &awsClientFake.AWSClientProvider{}
+		awsClientProvider.GetIAMClientCall.Returns.IAMClient = iamClient
+
+		iamClient.GetCallerIdentityReturns(&sts.GetCallerIdentityOutput{
+			Arn: aws.String("arn:aws:iam::123456789012:user/some-user"),
+		}, nil)
+
+		checker = iam.NewPermissionChecker(awsClientProvider)
+	})
+
+	Describe("Check", func() {
+		It("returns the actions the user is denied", func() {
+			iamClient.SimulatePrincipalPolicyReturnsOnCall(0, &awsiam.SimulatePolicyResponse{
+				EvaluationResults: []*awsiam.EvaluationResult{
+					{EvalActionName: aws.String("ec2:CreateVpc"), EvalDecision: aws.String("allowed")},
+					{EvalActionName: aws.String("iam:CreateRole"), EvalDecision: aws.String("implicitDeny")},
+				},
+				IsTruncated: aws.Bool(true),
+				Marker:      aws.String("some-marker"),
+			}, nil)
+			iamClient.SimulatePrincipalPolicyReturnsOnCall(1, &awsiam.SimulatePolicyResponse{
+				EvaluationResults: []*awsiam.EvaluationResult{
+					{EvalActionName: aws.String("iam:PassRole"), EvalDecision: aws.String("explicitDeny")},
+				},
+			}, nil)
+
+			denied, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(denied).To(Equal([]string{"iam:CreateRole", "iam:PassRole"}))
+
+			Expect(iamClient.SimulatePrincipalPolicyCallCount()).To(Equal(2))
+			input := iamClient.SimulatePrincipalPolicyArgsForCall(1)
+			Expect(input.PolicySourceArn).To(Equal(aws.String("arn:aws:iam::123456789012:user/some-user")))
+			Expect(input.ActionNames).To(ContainElement(aws.String("ec2:RunInstances")))
+			Expect(input.Marker).To(Equal(aws.String("some-marker")))
+		})
+
+		Context("when the credentials are of an assumed role", func() {
+			It("simulates the policies of the role", func() {
+				iamClient.GetCallerIdentityReturns(&sts.GetCallerIdentityOutput{
+					Arn: aws.String("arn:aws:sts::123456789012:assumed-role/some-role/some-session"),
+				}, nil)
+				iamClient.GetRoleReturns(&awsiam.GetRoleOutput{
+					Role: &awsiam.Role{Arn: aws.String("arn:aws:iam::123456789012:role/some-path/some-role")},
+				}, nil)
+				iamClient.SimulatePrincipalPolicyReturns(&awsiam.SimulatePolicyResponse{}, nil)
+
+				_, err := checker.Check()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(iamClient.GetRoleArgsForCall(0).RoleName).To(Equal(aws.String("some-role")))
+				input := iamClient.SimulatePrincipalPolicyArgsForCall(0)
+				Expect(input.PolicySourceArn).To(Equal(aws.String("arn:aws:iam::123456789012:role/some-path/some-role")))
+			})
+		})
+
+		Context("when the credentials are of the root user", func() {
+			It("does not simulate any policies", func() {
+				iamClient.GetCallerIdentityReturns(&sts.GetCallerIdentityOutput{
+					Arn: aws.String("arn:aws:iam::123456789012:root"),
+				}, nil)
+
+				denied, err := checker.Check()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(denied).To(BeEmpty())
+
+				Expect(iamClient.SimulatePrincipalPolicyCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the identity cannot be found", func() {
+				iamClient.GetCallerIdentityReturns(nil, errors.New("AccessDenied"))
+
+				_, err := checker.Check()
+				Expect(err).To(MatchError("failed to get the identity of the credentials: AccessDenied"))
+			})
+
+			It("returns an error when the role cannot be found", func() {
+				iamClient.GetCallerIdentityReturns(&sts.GetCallerIdentityOutput{
+					Arn: aws.String("arn:aws:sts::123456789012:assumed-role/some-role/some-session"),
+				}, nil)
+				iamClient.GetRoleReturns(nil, errors.New("AccessDenied"))
+
+				_, err := checker.Check()
+				Expect(err).To(MatchError("failed to get the iam role some-role of the credentials: AccessDenied"))
+			})
+
+			It("returns an error when the credentials are of a federated user", func() {
+				iamClient.GetCallerIdentityReturns(&sts.GetCallerIdentityOutput{
+					Arn: aws.String("arn:aws:sts::123456789012:federated-user/some-user"),
+				}, nil)
+
+				_, err := checker.Check()
+				Expect(err).To(MatchError("cannot simulate the policies of arn:aws:sts::123456789012:federated-user/some-user, only iam users and roles can be checked"))
+			})
+
+			It("returns an error when the simulation fails", func() {
+				iamClient.SimulatePrincipalPolicyReturns(nil, errors.New("AccessDenied"))
+
+				_, err := checker.Check()
+				Expect(err).To(MatchError("failed to simulate the policies of arn:aws:iam::123456789012:user/some-user: AccessDenied"))
+			})
+		})
+	})
+})
@@ -0,0 +1,189 @@
+package pricing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws"
+)
+
+// The price list api is only served from us-east-1, for every region.
+const (
+	Endpoint       = "https://api.pricing.us-east-1.amazonaws.com/"
+	endpointRegion = "us-east-1"
+)
+
+// locations are the names the price list uses for the regions.
+var locations = map[string]string{
+	"us-east-1":      "US East (N. Virginia)",
+	"us-east-2":      "US East (Ohio)",
+	"us-west-1":      "US West (N. California)",
+	"us-west-2":      "US West (Oregon)",
+	"ca-central-1":   "Canada (Central)",
+	"eu-central-1":   "EU (Frankfurt)",
+	"eu-west-1":      "EU (Ireland)",
+	"eu-west-2":      "EU (London)",
+	"eu-west-3":      "EU (Paris)",
+	"ap-northeast-1": "Asia Pacific (Tokyo)",
+	"ap-northeast-2": "Asia Pacific (Seoul)",
+	"ap-south-1":     "Asia Pacific (Mumbai)",
+	"ap-southeast-1": "Asia Pacific (Singapore)",
+	"ap-southeast-2": "Asia Pacific (Sydney)",
+	"sa-east-1":      "South America (Sao Paulo)",
+}
+
+// Product picks the products of a service by their attributes, and the
+// dimension of their on-demand price that is charged in Unit, e.g. "Hrs" or
+// "GB-Mo".
+type Product struct {
+	ServiceCode string
+	Attributes  map[string]string
+	Unit        string
+}
+
+type Client struct {
+	credentials *credentials.Credentials
+	httpClient  *http.Client
+	endpoint    string
+}
+
+func NewClient(config aws.Config) Client {
+	clientConfig := config.ClientConfig()
+	return Client{
+		credentials: clientConfig.Credentials,
+		httpClient:  clientConfig.HTTPClient,
+		endpoint:    Endpoint,
+	}
+}
+
+// OnDemandPrice returns the on-demand price in USD of the product in the
+// region, per unit of the product.
+func (c Client) OnDemandPrice(region string, product Product) (float64, error) {
+	location, ok := locations[region]
+	if !ok {
+		return 0, fmt.Errorf("the price list has no prices for region %q", region)
+	}
+
+	attributes := []string{}
+	for attribute := range product.Attributes {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+
+	type filter struct {
+		Type  string
+		Field string
+		Value string
+	}
+	filters := []filter{{Type: "TERM_MATCH", Field: "location", Value: location}}
+	for _, attribute := range attributes {
+		filters = append(filters, filter{Type: "TERM_MATCH", Field: attribute, Value: product.Attributes[attribute]})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"ServiceCode":   product.ServiceCode,
+		"Filters":       filters,
+		"FormatVersion": "aws_v1",
+		"MaxResults":    100,
+	})
+	if err != nil {
+		return 0, err //not tested
+	}
+
+	contents, err := c.post("AWSPriceListService.GetProducts", body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the prices of %s: %s", product.ServiceCode, err)
+	}
+
+	var products struct {
+		PriceList []string
+	}
+	err = json.Unmarshal(contents, &products)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the prices of %s: %s", product.ServiceCode, err)
+	}
+
+	for _, priceList := range products.PriceList {
+		var price struct {
+			Terms struct {
+				OnDemand map[string]struct {
+					PriceDimensions map[string]struct {
+						Unit         string            `json:"unit"`
+						PricePerUnit map[string]string `json:"pricePerUnit"`
+					} `json:"priceDimensions"`
+				} `json:"OnDemand"`
+			} `json:"terms"`
+		}
+		err = json.Unmarshal([]byte(priceList), &price)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the prices of %s: %s", product.ServiceCode, err)
+		}
+
+		for _, term := range price.Terms.OnDemand {
+			for _, dimension := range term.PriceDimensions {
+				if dimension.Unit != product.Unit {
+					continue
+				}
+
+				usd, err := strconv.ParseFloat(dimension.PricePerUnit["USD"], 64)
+				if err != nil {
+					return 0, fmt.Errorf("failed to get the prices of %s: %s", product.ServiceCode, err)
+				}
+
+				return usd, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("no on-demand price in %s for %s %s", region, product.ServiceCode, describe(product.Attributes, attributes))
+}
+
+func (c Client) post(target string, body []byte) ([]byte, error) {
+	request, err := http.NewRequest("POST", c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err //not tested
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", target)
+
+	_, err = v4.NewSigner(c.credentials).Sign(request, bytes.NewReader(body), "pricing", endpointRegion, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err //not tested
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s", response.Status, strings.TrimSpace(string(contents)))
+	}
+
+	return contents, nil
+}
+
+func describe(values map[string]string, keys []string) string {
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, values[key]))
+	}
+
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,101 @@
+package pricing_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws"
+	"github.com/cloudfoundry/bosh-bootloader/aws/pricing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		client pricing.Client
+
+		requestBody    string
+		requestHeaders http.Header
+		responseStatus int
+		responseBody   string
+	)
+
+	BeforeEach(func() {
+		responseStatus = http.StatusOK
+		priceList, err := json.Marshal([]string{
+			`{"terms": {"OnDemand": {"SKU.TERM": {"priceDimensions": {"SKU.TERM.DATA": {"unit": "GB", "pricePerUnit": {"USD": "0.0080000000"}}}}}}}`,
+			`{"terms": {"OnDemand": {"SKU.TERM": {"priceDimensions": {"SKU.TERM.HOURS": {"unit": "Hrs", "pricePerUnit": {"USD": "0.2000000000"}}}}}}}`,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		responseBody = `{"PriceList": ` + string(priceList) + `}`
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requestBody = string(body)
+			requestHeaders = r.Header
+
+			w.WriteHeader(responseStatus)
+			w.Write([]byte(responseBody))
+		}))
+
+		client = pricing.NewClient(aws.Config{
+			AccessKeyID:     "some-access-key-id",
+			SecretAccessKey: "some-secret-access-key",
+			Region:          "some-region",
+		}).WithEndpoint(server.URL)
+	})
+
+	Describe("OnDemandPrice", func() {
+		var product pricing.Product
+
+		BeforeEach(func() {
+			product = pricing.Product{
+				ServiceCode: "AmazonEC2",
+				Attributes:  map[string]string{"productFamily": "NAT Gateway"},
+				Unit:        "Hrs",
+			}
+		})
+
+		It("returns the on-demand price of the product in the unit", func() {
+			price, err := client.OnDemandPrice("us-east-1", product)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(price).To(Equal(0.2))
+
+			Expect(requestBody).To(MatchJSON(`{
+				"ServiceCode": "AmazonEC2",
+				"Filters": [
+					{"Type": "TERM_MATCH", "Field": "location", "Value": "US East (N. Virginia)"},
+					{"Type": "TERM_MATCH", "Field": "productFamily", "Value": "NAT Gateway"}
+				],
+				"FormatVersion": "aws_v1",
+				"MaxResults": 100
+			}`))
+			Expect(requestHeaders.Get("X-Amz-Target")).To(Equal("AWSPriceListService.GetProducts"))
+			Expect(requestHeaders.Get("Authorization")).To(ContainSubstring("Credential=some-access-key-id/"))
+			Expect(requestHeaders.Get("Authorization")).To(ContainSubstring("/us-east-1/pricing/aws4_request"))
+		})
+
+		It("returns an error when the region has no location in the price list", func() {
+			_, err := client.OnDemandPrice("unknown-region", product)
+			Expect(err).To(MatchError(`the price list has no prices for region "unknown-region"`))
+		})
+
+		It("returns an error when no product has a price in the unit", func() {
+			product.Unit = "LCU-Hrs"
+
+			_, err := client.OnDemandPrice("us-east-1", product)
+			Expect(err).To(MatchError("no on-demand price in us-east-1 for AmazonEC2 productFamily=NAT Gateway"))
+		})
+
+		It("returns an error when the prices cannot be retrieved", func() {
+			responseStatus = http.StatusBadRequest
+			responseBody = `{"message": "access denied"}`
+
+			_, err := client.OnDemandPrice("us-east-1", product)
+			Expect(err).To(MatchError(`failed to get the prices of AmazonEC2: 400 Bad Request {"message": "access denied"}`))
+		})
+	})
+})
@@ -0,0 +1,31 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	MaxRetries         = 3
+	MaxThrottleRetries = 10
+)
+
+// throttleRetryer keeps the sdk's jittered exponential backoff, but retries
+// throttled requests, such as RequestLimitExceeded or Throttling, more often
+// than other failures, so that accounts shared by several environments do
+// not fail bbl up midway.
+type throttleRetryer struct {
+	client.DefaultRetryer
+}
+
+func newThrottleRetryer() throttleRetryer {
+	return throttleRetryer{client.DefaultRetryer{NumMaxRetries: MaxThrottleRetries}}
+}
+
+func (t throttleRetryer) ShouldRetry(r *request.Request) bool {
+	if r.Retryable == nil && r.IsErrorThrottle() {
+		return true
+	}
+
+	return r.RetryCount < MaxRetries && t.DefaultRetryer.ShouldRetry(r)
+}
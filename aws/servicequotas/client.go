@@ -0,0 +1,107 @@
+package servicequotas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws"
+)
+
+// Quota is the value of a quota in the region, e.g. the number of elastic ips
+// or of vCPUs the account may use.
+type Quota struct {
+	Name  string
+	Value int
+}
+
+type Client struct {
+	credentials *credentials.Credentials
+	httpClient  *http.Client
+	region      string
+	endpoint    string
+}
+
+func NewClient(config aws.Config) Client {
+	clientConfig := config.ClientConfig()
+	return Client{
+		credentials: clientConfig.Credentials,
+		httpClient:  clientConfig.HTTPClient,
+		region:      config.Region,
+		endpoint:    fmt.Sprintf("https://servicequotas.%s.amazonaws.com/", config.Region),
+	}
+}
+
+// Quota returns the value of the quota that applies to the account. Quotas
+// the account never had raised only have their aws default value.
+func (c Client) Quota(serviceCode, quotaCode string) (Quota, error) {
+	body, err := json.Marshal(map[string]string{
+		"ServiceCode": serviceCode,
+		"QuotaCode":   quotaCode,
+	})
+	if err != nil {
+		return Quota{}, err //not tested
+	}
+
+	contents, err := c.post("ServiceQuotasV20190624.GetServiceQuota", body)
+	if err != nil && strings.Contains(err.Error(), "NoSuchResourceException") {
+		contents, err = c.post("ServiceQuotasV20190624.GetAWSDefaultServiceQuota", body)
+	}
+	if err != nil {
+		return Quota{}, fmt.Errorf("failed to get the %s quota %s: %s", serviceCode, quotaCode, err)
+	}
+
+	var output struct {
+		Quota struct {
+			QuotaName string
+			Value     float64
+		}
+	}
+	err = json.Unmarshal(contents, &output)
+	if err != nil {
+		return Quota{}, fmt.Errorf("failed to get the %s quota %s: %s", serviceCode, quotaCode, err)
+	}
+
+	return Quota{
+		Name:  output.Quota.QuotaName,
+		Value: int(output.Quota.Value),
+	}, nil
+}
+
+func (c Client) post(target string, body []byte) ([]byte, error) {
+	request, err := http.NewRequest("POST", c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err //not tested
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", target)
+
+	_, err = v4.NewSigner(c.credentials).Sign(request, bytes.NewReader(body), "servicequotas", c.region, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err //not tested
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s", response.Status, strings.TrimSpace(string(contents)))
+	}
+
+	return contents, nil
+}
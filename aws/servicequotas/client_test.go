@@ -0,0 +1,98 @@
+package servicequotas_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws"
+	"github.com/cloudfoundry/bosh-bootloader/aws/servicequotas"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		client servicequotas.Client
+
+		requestBodies  []string
+		requestTargets []string
+		requestHeaders http.Header
+		responses      map[string]string
+		statuses       map[string]int
+	)
+
+	BeforeEach(func() {
+		requestBodies = []string{}
+		requestTargets = []string{}
+		responses = map[string]string{
+			"ServiceQuotasV20190624.GetServiceQuota": `{"Quota": {"QuotaName": "EC2-VPC Elastic IPs", "Value": 10.0}}`,
+		}
+		statuses = map[string]int{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			target := r.Header.Get("X-Amz-Target")
+			requestBodies = append(requestBodies, string(body))
+			requestTargets = append(requestTargets, target)
+			requestHeaders = r.Header
+
+			if status, ok := statuses[target]; ok {
+				w.WriteHeader(status)
+			}
+			w.Write([]byte(responses[target]))
+		}))
+
+		client = servicequotas.NewClient(aws.Config{
+			AccessKeyID:     "some-access-key-id",
+			SecretAccessKey: "some-secret-access-key",
+			Region:          "some-region",
+		}).WithEndpoint(server.URL)
+	})
+
+	Describe("Quota", func() {
+		It("returns the quota that applies to the account", func() {
+			quota, err := client.Quota("ec2", "L-0263D0A3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quota).To(Equal(servicequotas.Quota{Name: "EC2-VPC Elastic IPs", Value: 10}))
+
+			Expect(requestTargets).To(Equal([]string{"ServiceQuotasV20190624.GetServiceQuota"}))
+			Expect(requestBodies[0]).To(MatchJSON(`{"ServiceCode": "ec2", "QuotaCode": "L-0263D0A3"}`))
+			Expect(requestHeaders.Get("Authorization")).To(ContainSubstring("Credential=some-access-key-id/"))
+			Expect(requestHeaders.Get("Authorization")).To(ContainSubstring("/some-region/servicequotas/aws4_request"))
+		})
+
+		It("returns the aws default when the account has no value of its own", func() {
+			statuses["ServiceQuotasV20190624.GetServiceQuota"] = http.StatusBadRequest
+			responses["ServiceQuotasV20190624.GetServiceQuota"] = `{"__type": "NoSuchResourceException"}`
+			responses["ServiceQuotasV20190624.GetAWSDefaultServiceQuota"] = `{"Quota": {"QuotaName": "VPCs per Region", "Value": 5.0}}`
+
+			quota, err := client.Quota("vpc", "L-F678F1CE")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quota).To(Equal(servicequotas.Quota{Name: "VPCs per Region", Value: 5}))
+
+			Expect(requestTargets).To(Equal([]string{
+				"ServiceQuotasV20190624.GetServiceQuota",
+				"ServiceQuotasV20190624.GetAWSDefaultServiceQuota",
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the quota cannot be retrieved", func() {
+				statuses["ServiceQuotasV20190624.GetServiceQuota"] = http.StatusBadRequest
+				responses["ServiceQuotasV20190624.GetServiceQuota"] = `{"__type": "AccessDeniedException"}`
+
+				_, err := client.Quota("ec2", "L-0263D0A3")
+				Expect(err).To(MatchError(`failed to get the ec2 quota L-0263D0A3: 400 Bad Request {"__type": "AccessDeniedException"}`))
+			})
+
+			It("returns an error when the response is not valid", func() {
+				responses["ServiceQuotasV20190624.GetServiceQuota"] = "%%%"
+
+				_, err := client.Quota("ec2", "L-0263D0A3")
+				Expect(err).To(MatchError(ContainSubstring("failed to get the ec2 quota L-0263D0A3: invalid character")))
+			})
+		})
+	})
+})
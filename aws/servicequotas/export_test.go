@@ -0,0 +1,6 @@
+package servicequotas
+
+func (c Client) WithEndpoint(endpoint string) Client {
+	c.endpoint = endpoint
+	return c
+}
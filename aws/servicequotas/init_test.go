@@ -0,0 +1,13 @@
+package servicequotas_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestServiceQuotas(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "aws/servicequotas")
+}
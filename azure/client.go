@@ -1,31 +1,66 @@
 package azure
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+
 	"github.com/Azure/azure-sdk-for-go/arm/storage"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+)
+
+const (
+	AuthMethodClientSecret    = "client-secret"
+	AuthMethodCLI             = "cli"
+	AuthMethodManagedIdentity = "managed-identity"
+)
+
+// defaultManagedIdentityEndpoint is the token endpoint of the Azure Instance
+// Metadata Service, only reachable from an Azure vm.
+const defaultManagedIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+var (
+	runAZ                   = defaultRunAZ
+	managedIdentityEndpoint = defaultManagedIdentityEndpoint
 )
 
+func defaultRunAZ(args ...string) ([]byte, error) {
+	stderr := bytes.NewBuffer([]byte{})
+	cmd := exec.Command("az", args...)
+	cmd.Stderr = stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
 type AzureClient struct{}
 
 func NewClient() AzureClient {
 	return AzureClient{}
 }
 
-func (a AzureClient) ValidateCredentials(subscriptionID, tenantID, clientID, clientSecret string) error {
-	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, tenantID)
-	if err != nil {
-		return err
-	}
-	servicePrincipalToken, err := adal.NewServicePrincipalToken(*oauthConfig, clientID, clientSecret, azure.PublicCloud.ResourceManagerEndpoint)
+func (a AzureClient) ValidateCredentials(subscriptionID, tenantID, clientID, clientSecret, authMethod string) error {
+	tokenProvider, err := newTokenProvider(tenantID, clientID, clientSecret, authMethod)
 	if err != nil {
 		return err
 	}
 
 	ac := storage.NewAccountsClient(subscriptionID)
-	ac.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
-	ac.Sender = autorest.CreateSender(autorest.AsIs())
+	ac.Authorizer = autorest.NewBearerAuthorizer(tokenProvider)
+	ac.Sender = autorest.DecorateSender(helpers.NewHTTPClient(), autorest.AsIs())
 
 	_, err = ac.List()
 	if err != nil {
@@ -34,3 +69,89 @@ func (a AzureClient) ValidateCredentials(subscriptionID, tenantID, clientID, cli
 
 	return nil
 }
+
+func newTokenProvider(tenantID, clientID, clientSecret, authMethod string) (adal.OAuthTokenProvider, error) {
+	resource := azure.PublicCloud.ResourceManagerEndpoint
+
+	switch authMethod {
+	case "", AuthMethodClientSecret:
+		oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, tenantID)
+		if err != nil {
+			return nil, err
+		}
+
+		token, err := adal.NewServicePrincipalToken(*oauthConfig, clientID, clientSecret, resource)
+		if err != nil {
+			return nil, err
+		}
+
+		token.SetSender(helpers.NewHTTPClient())
+		return token, nil
+	case AuthMethodCLI:
+		return cliToken(tenantID, resource)
+	case AuthMethodManagedIdentity:
+		return managedIdentityToken(clientID, resource)
+	default:
+		return nil, fmt.Errorf("unknown azure auth method %q", authMethod)
+	}
+}
+
+// cliToken asks the az cli for a token of the account it is logged in with.
+// bbl runs for less than the hour the token is valid, so it is not refreshed.
+func cliToken(tenantID, resource string) (*adal.Token, error) {
+	output, err := runAZ("account", "get-access-token", "--resource", resource, "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a token from the az cli, run az login: %s", err)
+	}
+
+	var token struct {
+		AccessToken string `json:"accessToken"`
+		Tenant      string `json:"tenant"`
+	}
+	err = json.Unmarshal(output, &token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the token from the az cli: %s", err)
+	}
+
+	if token.Tenant != "" && token.Tenant != tenantID {
+		return nil, fmt.Errorf("the az cli is logged in to tenant %s, not %s", token.Tenant, tenantID)
+	}
+
+	return &adal.Token{AccessToken: token.AccessToken}, nil
+}
+
+// managedIdentityToken gets a token for the managed identity of the vm bbl
+// runs on. clientID selects a user-assigned identity, otherwise the
+// system-assigned identity is used.
+func managedIdentityToken(clientID, resource string) (*adal.Token, error) {
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", resource)
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", managedIdentityEndpoint, query.Encode()), nil)
+	if err != nil {
+		return nil, err //not tested
+	}
+	request.Header.Set("Metadata", "true")
+
+	response, err := helpers.NewHTTPClient().Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a token for the managed identity, is bbl running on an azure vm? %s", err)
+	}
+	defer response.Body.Close()
+
+	var token adal.Token
+	err = json.NewDecoder(response.Body).Decode(&token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the token of the managed identity: %s", err)
+	}
+
+	if response.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return nil, errors.New("failed to get a token for the managed identity: " + response.Status)
+	}
+
+	return &token, nil
+}
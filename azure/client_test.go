@@ -0,0 +1,116 @@
+package azure_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/cloudfoundry/bosh-bootloader/azure"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	Describe("token providers", func() {
+		Context("when the az cli is used", func() {
+			var azArgs []string
+
+			BeforeEach(func() {
+				azure.SetRunAZ(func(args ...string) ([]byte, error) {
+					azArgs = args
+					return []byte(`{"accessToken": "some-cli-token", "tenant": "some-tenant-id"}`), nil
+				})
+			})
+
+			AfterEach(func() {
+				azure.ResetRunAZ()
+			})
+
+			It("uses the token of the account the cli is logged in with", func() {
+				tokenProvider, err := azure.NewTokenProvider("some-tenant-id", "", "", "cli")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(tokenProvider.OAuthToken()).To(Equal("some-cli-token"))
+				Expect(azArgs).To(Equal([]string{"account", "get-access-token", "--resource", "https://management.azure.com/", "--output", "json"}))
+			})
+
+			It("returns an error when the cli is logged in to another tenant", func() {
+				_, err := azure.NewTokenProvider("other-tenant-id", "", "", "cli")
+				Expect(err).To(MatchError("the az cli is logged in to tenant some-tenant-id, not other-tenant-id"))
+			})
+
+			It("returns an error when the cli has no token", func() {
+				azure.SetRunAZ(func(...string) ([]byte, error) {
+					return nil, errors.New("Please run 'az login' to setup account.")
+				})
+
+				_, err := azure.NewTokenProvider("some-tenant-id", "", "", "cli")
+				Expect(err).To(MatchError("failed to get a token from the az cli, run az login: Please run 'az login' to setup account."))
+			})
+		})
+
+		Context("when a managed identity is used", func() {
+			var (
+				status  int
+				request *http.Request
+			)
+
+			BeforeEach(func() {
+				status = http.StatusOK
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					request = r
+					w.WriteHeader(status)
+					if status != http.StatusOK {
+						w.Write([]byte(`{"error": "invalid_request"}`))
+						return
+					}
+					w.Write([]byte(`{"access_token": "some-msi-token", "expires_on": "1508241600"}`))
+				}))
+
+				azure.SetManagedIdentityEndpoint(server.URL)
+			})
+
+			AfterEach(func() {
+				azure.ResetManagedIdentityEndpoint()
+			})
+
+			It("uses the token of the system-assigned identity", func() {
+				tokenProvider, err := azure.NewTokenProvider("some-tenant-id", "", "", "managed-identity")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(tokenProvider.OAuthToken()).To(Equal("some-msi-token"))
+				Expect(request.Header.Get("Metadata")).To(Equal("true"))
+				Expect(request.URL.Query().Get("resource")).To(Equal("https://management.azure.com/"))
+				Expect(request.URL.Query()).NotTo(HaveKey("client_id"))
+			})
+
+			It("uses the token of the user-assigned identity with the client id", func() {
+				_, err := azure.NewTokenProvider("some-tenant-id", "some-client-id", "", "managed-identity")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(request.URL.Query().Get("client_id")).To(Equal("some-client-id"))
+			})
+
+			It("returns an error when the identity has no token", func() {
+				status = http.StatusBadRequest
+
+				_, err := azure.NewTokenProvider("some-tenant-id", "", "", "managed-identity")
+				Expect(err).To(MatchError("failed to get a token for the managed identity: 400 Bad Request"))
+			})
+		})
+
+		It("uses the client secret by default", func() {
+			tokenProvider, err := azure.NewTokenProvider("some-tenant-id", "some-client-id", "some-client-secret", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tokenProvider).To(BeAssignableToTypeOf(&adal.ServicePrincipalToken{}))
+		})
+
+		It("returns an error for an unknown auth method", func() {
+			_, err := azure.NewTokenProvider("some-tenant-id", "", "", "password")
+			Expect(err).To(MatchError(`unknown azure auth method "password"`))
+		})
+	})
+})
@@ -0,0 +1,19 @@
+package azure
+
+var NewTokenProvider = newTokenProvider
+
+func SetRunAZ(f func(...string) ([]byte, error)) {
+	runAZ = f
+}
+
+func ResetRunAZ() {
+	runAZ = defaultRunAZ
+}
+
+func SetManagedIdentityEndpoint(endpoint string) {
+	managedIdentityEndpoint = endpoint
+}
+
+func ResetManagedIdentityEndpoint() {
+	managedIdentityEndpoint = defaultManagedIdentityEndpoint
+}
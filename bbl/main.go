@@ -5,10 +5,15 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ssh"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
@@ -18,6 +23,8 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation/templates"
 	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
 	"github.com/cloudfoundry/bosh-bootloader/aws/iam"
+	"github.com/cloudfoundry/bosh-bootloader/aws/pricing"
+	"github.com/cloudfoundry/bosh-bootloader/aws/servicequotas"
 	"github.com/cloudfoundry/bosh-bootloader/azure"
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/certs"
@@ -27,6 +34,8 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/gcp"
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/keypair"
+	"github.com/cloudfoundry/bosh-bootloader/leftovers"
+	"github.com/cloudfoundry/bosh-bootloader/openstack"
 	"github.com/cloudfoundry/bosh-bootloader/proxy"
 	"github.com/cloudfoundry/bosh-bootloader/stack"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -35,23 +44,35 @@ import (
 	awsapplication "github.com/cloudfoundry/bosh-bootloader/application/aws"
 	gcpapplication "github.com/cloudfoundry/bosh-bootloader/application/gcp"
 	awscloudconfig "github.com/cloudfoundry/bosh-bootloader/cloudconfig/aws"
+	azurecloudconfig "github.com/cloudfoundry/bosh-bootloader/cloudconfig/azure"
 	gcpcloudconfig "github.com/cloudfoundry/bosh-bootloader/cloudconfig/gcp"
+	openstackcloudconfig "github.com/cloudfoundry/bosh-bootloader/cloudconfig/openstack"
 	awskeypair "github.com/cloudfoundry/bosh-bootloader/keypair/aws"
 	gcpkeypair "github.com/cloudfoundry/bosh-bootloader/keypair/gcp"
+	generatedkeypair "github.com/cloudfoundry/bosh-bootloader/keypair/generated"
 	awsterraform "github.com/cloudfoundry/bosh-bootloader/terraform/aws"
+	azureterraform "github.com/cloudfoundry/bosh-bootloader/terraform/azure"
 	gcpterraform "github.com/cloudfoundry/bosh-bootloader/terraform/gcp"
+	openstackterraform "github.com/cloudfoundry/bosh-bootloader/terraform/openstack"
 )
 
 var (
 	Version     string
+	GitSHA      string
+	BuildDate   string
 	gcpBasePath string
 )
 
 func main() {
-	newConfig := config.NewConfig(storage.GetState)
+	started := time.Now()
+
+	newConfig := config.NewConfig(storage.GetState, storage.PullState, storage.NewStateLocker().Lock)
 	parsedFlags, err := newConfig.Bootstrap(os.Args)
 	if err != nil {
-		log.Fatalf("\n\n%s\n", err)
+		if _, locked := err.(storage.StateLockedError); locked {
+			exit(err)
+		}
+		exit(application.NewExitError(application.ExitCodeUsage, err))
 	}
 
 	loadedState := parsedFlags.State
@@ -59,16 +80,27 @@ func main() {
 	// Utilities
 	envIDGenerator := helpers.NewEnvIDGenerator(rand.Reader)
 	envGetter := helpers.NewEnvGetter()
+	sshKeyGenerator := helpers.NewSSHKeyGenerator(rand.Reader, rsa.GenerateKey, ed25519.GenerateKey, ssh.NewPublicKey)
+	upDetacher := helpers.NewUpDetacher(parsedFlags.StateDir, os.Args)
 	logger := application.NewLogger(os.Stdout)
+	logger.SetLevel(parsedFlags.LogLevel)
+	logger.SetFormat(parsedFlags.LogFormat)
+	if parsedFlags.JSON {
+		// only the json document is written to stdout
+		logger.SetLevel(application.LogLevelError)
+	}
 	stderrLogger := application.NewLogger(os.Stderr)
+	stderrLogger.SetLevel(parsedFlags.LogLevel)
+	stderrLogger.SetFormat(parsedFlags.LogFormat)
 
 	// Usage Command
 	usage := commands.NewUsage(logger)
 
 	storage.GetStateLogger = stderrLogger
 
-	stateStore := storage.NewStore(parsedFlags.StateDir)
+	stateStore := storage.NewStore(parsedFlags.StateDir, parsedFlags.StateBackups, parsedFlags.StateBackend)
 	stateValidator := application.NewStateValidator(parsedFlags.StateDir)
+	auditLog := storage.NewAuditLog(parsedFlags.StateDir)
 
 	awsCredentialValidator := awsapplication.NewCredentialValidator(loadedState.AWS.AccessKeyID, loadedState.AWS.SecretAccessKey, loadedState.AWS.Region)
 	gcpCredentialValidator := gcpapplication.NewCredentialValidator(loadedState.GCP.ProjectID, loadedState.GCP.ServiceAccountKey, loadedState.GCP.Region, loadedState.GCP.Zone)
@@ -78,6 +110,7 @@ func main() {
 	awsConfiguration := aws.Config{
 		AccessKeyID:     loadedState.AWS.AccessKeyID,
 		SecretAccessKey: loadedState.AWS.SecretAccessKey,
+		SessionToken:    loadedState.AWS.SessionToken,
 		Region:          loadedState.AWS.Region,
 	}
 
@@ -85,11 +118,12 @@ func main() {
 	awsClientProvider.SetConfig(awsConfiguration)
 
 	vpcStatusChecker := ec2.NewVPCStatusChecker(awsClientProvider)
-	awsKeyPairCreator := ec2.NewKeyPairCreator(awsClientProvider)
+	awsQuotaChecker := ec2.NewQuotaChecker(awsClientProvider, servicequotas.NewClient(awsConfiguration))
+	awsKeyPairCreator := ec2.NewKeyPairCreator(awsClientProvider, sshKeyGenerator)
 	awsKeyPairDeleter := ec2.NewKeyPairDeleter(awsClientProvider, logger)
 	keyPairChecker := ec2.NewKeyPairChecker(awsClientProvider)
 	keyPairSynchronizer := ec2.NewKeyPairSynchronizer(awsKeyPairCreator, keyPairChecker, logger)
-	awsKeyPairManager := awskeypair.NewManager(keyPairSynchronizer, awsKeyPairDeleter, awsClientProvider)
+	awsKeyPairManager := awskeypair.NewManager(keyPairSynchronizer, awsKeyPairDeleter, awsClientProvider, keyPairChecker)
 	awsAvailabilityZoneRetriever := ec2.NewAvailabilityZoneRetriever(awsClientProvider)
 	templateBuilder := templates.NewTemplateBuilder(logger)
 	stackManager := cloudformation.NewStackManager(awsClientProvider, logger)
@@ -102,55 +136,88 @@ func main() {
 	// GCP
 	gcpClientProvider := gcp.NewClientProvider(gcpBasePath)
 	if loadedState.IAAS == "gcp" {
-		err = gcpClientProvider.SetConfig(loadedState.GCP.ServiceAccountKey, loadedState.GCP.ProjectID, loadedState.GCP.Region, loadedState.GCP.Zone)
+		err = gcpClientProvider.SetConfig(loadedState.GCP.ServiceAccountKey, loadedState.GCP.ProjectID, loadedState.GCP.Region, loadedState.GCP.Zone, loadedState.GCP.ImpersonateServiceAccount)
 		if err != nil {
-			log.Fatalf("\n\n%s\n", err)
+			parsedFlags.Unlock()
+			exit(application.NewExitError(application.ExitCodeCredentials, err))
 		}
 	}
-	gcpKeyPairUpdater := gcp.NewKeyPairUpdater(rand.Reader, rsa.GenerateKey, ssh.NewPublicKey, gcpClientProvider.Client(), logger)
+	gcpKeyPairUpdater := gcp.NewKeyPairUpdater(sshKeyGenerator, gcpClientProvider.Client(), logger)
 	gcpKeyPairDeleter := gcp.NewKeyPairDeleter(gcpClientProvider.Client(), logger)
 	gcpNetworkInstancesChecker := gcp.NewNetworkInstancesChecker(gcpClientProvider.Client())
+	gcpQuotaChecker := gcp.NewQuotaChecker(gcpClientProvider.Client())
 	gcpKeyPairManager := gcpkeypair.NewManager(gcpKeyPairUpdater, gcpKeyPairDeleter)
 
 	// EnvID
 	envIDManager := helpers.NewEnvIDManager(envIDGenerator, gcpClientProvider.Client(), infrastructureManager)
 
 	// Keypair Manager
-	keyPairManager := keypair.NewManager(awsKeyPairManager, gcpKeyPairManager)
+	generatedKeyPairManager := generatedkeypair.NewManager(sshKeyGenerator)
+	keyPairManager := keypair.NewManager(awsKeyPairManager, gcpKeyPairManager, generatedKeyPairManager, generatedKeyPairManager)
+
+	// Subprocess output kept for latest-error --full
+	subprocessOutput := helpers.NewOutputTail(helpers.LatestErrorOutputSize)
+	latestErrorRecorder := application.NewLatestErrorRecorder(parsedFlags.StateDir, storage.GetState, stateStore, subprocessOutput)
 
 	// Terraform
 	terraformOutputBuffer := bytes.NewBuffer([]byte{})
 
-	terraformCmd := terraform.NewCmd(os.Stderr, terraformOutputBuffer)
-	terraformExecutor := terraform.NewExecutor(terraformCmd, parsedFlags.Debug)
+	// Downloads from the public internet, which go through the proxy of
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY, and are refused when offline so
+	// that bbl only reaches the IAAS
+	publicHTTPClient := helpers.NewHTTPClient()
+	bblCacheDir := filepath.Join(os.Getenv("HOME"), ".bbl")
+	terraformPluginDir := parsedFlags.TerraformPluginDir
+	if parsedFlags.Offline {
+		publicHTTPClient = &http.Client{Transport: helpers.NewOfflineTransport(parsedFlags.ArtifactsDir)}
+		bblCacheDir = parsedFlags.ArtifactsDir
+		if terraformPluginDir == "" {
+			terraformPluginDir = filepath.Join(parsedFlags.ArtifactsDir, "terraform-plugins")
+		}
+	}
+
+	terraformCacheDir := parsedFlags.TerraformCacheDir
+	if terraformCacheDir == "" {
+		terraformCacheDir = filepath.Join(bblCacheDir, "terraform")
+	}
+	terraformBinary := terraform.NewBinary(terraformCacheDir, terraform.ReleasesURL, publicHTTPClient, stderrLogger)
+	terraformCmd := terraform.NewCmd(stderrLogger.Writer("terraform"), io.MultiWriter(terraformOutputBuffer, subprocessOutput), terraformBinary, parsedFlags.TerraformTimeout)
+	terraformExecutor := terraform.NewExecutor(terraformCmd, logger.Writer("terraform"), parsedFlags.StateDir, terraformPluginDir, parsedFlags.Debug)
 	gcpTemplateGenerator := gcpterraform.NewTemplateGenerator()
-	gcpInputGenerator := gcpterraform.NewInputGenerator()
+	gcpInputGenerator := gcpterraform.NewInputGenerator(gcpClientProvider)
 	gcpOutputGenerator := gcpterraform.NewOutputGenerator(terraformExecutor)
 	awsTemplateGenerator := awsterraform.NewTemplateGenerator()
 	awsInputGenerator := awsterraform.NewInputGenerator(awsAvailabilityZoneRetriever)
 	awsOutputGenerator := awsterraform.NewOutputGenerator(terraformExecutor)
-	templateGenerator := terraform.NewTemplateGenerator(gcpTemplateGenerator, awsTemplateGenerator)
-	inputGenerator := terraform.NewInputGenerator(gcpInputGenerator, awsInputGenerator)
+	azureTemplateGenerator := azureterraform.NewTemplateGenerator()
+	azureInputGenerator := azureterraform.NewInputGenerator()
+	openstackTemplateGenerator := openstackterraform.NewTemplateGenerator()
+	openstackInputGenerator := openstackterraform.NewInputGenerator()
+	outputGenerator := terraform.NewOutputGenerator(terraformExecutor)
+	templateGenerator := terraform.NewTemplateGenerator(gcpTemplateGenerator, awsTemplateGenerator, azureTemplateGenerator, openstackTemplateGenerator)
+	inputGenerator := terraform.NewInputGenerator(gcpInputGenerator, awsInputGenerator, azureInputGenerator, openstackInputGenerator)
 	stackMigrator := stack.NewMigrator(terraformExecutor, infrastructureManager, certificateDescriber, userPolicyDeleter, awsAvailabilityZoneRetriever)
 	terraformManager := terraform.NewManager(terraform.NewManagerArgs{
-		Executor:              terraformExecutor,
-		TemplateGenerator:     templateGenerator,
-		InputGenerator:        inputGenerator,
-		AWSOutputGenerator:    awsOutputGenerator,
-		GCPOutputGenerator:    gcpOutputGenerator,
-		TerraformOutputBuffer: terraformOutputBuffer,
-		Logger:                logger,
-		StackMigrator:         stackMigrator,
+		Executor:                 terraformExecutor,
+		TemplateGenerator:        templateGenerator,
+		InputGenerator:           inputGenerator,
+		AWSOutputGenerator:       awsOutputGenerator,
+		GCPOutputGenerator:       gcpOutputGenerator,
+		AzureOutputGenerator:     outputGenerator,
+		OpenStackOutputGenerator: outputGenerator,
+		TerraformOutputBuffer:    terraformOutputBuffer,
+		Logger:                   logger,
 	})
 
 	// BOSH
 	hostKeyGetter := proxy.NewHostKeyGetter()
-	socks5Proxy := proxy.NewSocks5Proxy(logger, hostKeyGetter, 0)
-	boshCommand := bosh.NewCmd(os.Stderr)
-	boshExecutor := bosh.NewExecutor(boshCommand, ioutil.TempDir, ioutil.ReadFile, json.Unmarshal,
+	socks5Proxy := proxy.NewSocks5Proxy(logger, hostKeyGetter, loadedState.Jumpbox.ProxyPort)
+	boshCommand := bosh.NewCmd(stderrLogger.Writer("bosh"), subprocessOutput, parsedFlags.CreateEnvTimeout)
+	boshExecutor := bosh.NewExecutor(boshCommand, logger.Writer("bosh"), ioutil.TempDir, ioutil.ReadFile, json.Unmarshal,
 		json.Marshal, ioutil.WriteFile)
-	boshManager := bosh.NewManager(boshExecutor, logger, socks5Proxy)
-	boshClientProvider := bosh.NewClientProvider()
+	deploymentFetcher := bosh.NewDeploymentFetcher(filepath.Join(bblCacheDir, "deployments"), bosh.GitHubURL, publicHTTPClient, stderrLogger)
+	boshManager := bosh.NewManager(boshExecutor, logger, socks5Proxy, deploymentFetcher, parsedFlags.ArtifactsDir)
+	boshClientProvider := bosh.NewClientProvider(parsedFlags.BOSHTaskTimeout)
 
 	// Environment Validators
 	awsBrokenEnvironmentValidator := awsapplication.NewBrokenEnvironmentValidator(infrastructureManager)
@@ -161,17 +228,24 @@ func main() {
 	awsCloudFormationOpsGenerator := awscloudconfig.NewCloudFormationOpsGenerator(awsAvailabilityZoneRetriever, infrastructureManager)
 	awsTerraformOpsGenerator := awscloudconfig.NewTerraformOpsGenerator(terraformManager)
 	gcpOpsGenerator := gcpcloudconfig.NewOpsGenerator(terraformManager)
-	cloudConfigOpsGenerator := cloudconfig.NewOpsGenerator(awsCloudFormationOpsGenerator, awsTerraformOpsGenerator, gcpOpsGenerator)
-	cloudConfigManager := cloudconfig.NewManager(logger, boshCommand, cloudConfigOpsGenerator, boshClientProvider, socks5Proxy, terraformManager, sshKeyGetter)
+	azureOpsGenerator := azurecloudconfig.NewOpsGenerator(terraformManager)
+	openstackOpsGenerator := openstackcloudconfig.NewOpsGenerator(terraformManager)
+	cloudConfigOpsGenerator := cloudconfig.NewOpsGenerator(awsCloudFormationOpsGenerator, awsTerraformOpsGenerator, gcpOpsGenerator, azureOpsGenerator, openstackOpsGenerator)
+	cloudConfigManager := cloudconfig.NewManager(logger, boshCommand, cloudConfigOpsGenerator, boshClientProvider, socks5Proxy, terraformManager, sshKeyGetter, parsedFlags.StateDir)
 
 	// Subcommands
+	stemcellUploader := commands.NewStemcellUploader(logger, boshClientProvider, socks5Proxy, sshKeyGetter)
+	acmeClient := certs.NewACMEClient(certs.LetsEncryptDirectoryURL, publicHTTPClient)
+	letsEncrypt := commands.NewLetsEncrypt(acmeClient, terraformManager, stateStore, logger)
+
 	awsUp := commands.NewAWSUp(
 		awsCredentialValidator, keyPairManager, boshManager,
-		cloudConfigManager, stateStore, awsClientProvider, envIDManager, terraformManager, awsBrokenEnvironmentValidator)
+		cloudConfigManager, stateStore, awsClientProvider, envIDManager, terraformManager, awsBrokenEnvironmentValidator,
+		logger, upDetacher, stemcellUploader)
 
 	awsCreateLBs := commands.NewAWSCreateLBs(
 		logger, awsCredentialValidator, cloudConfigManager,
-		stateStore, terraformManager, awsEnvironmentValidator,
+		stateStore, terraformManager, awsEnvironmentValidator, letsEncrypt,
 	)
 
 	awsLBs := commands.NewAWSLBs(terraformManager, logger)
@@ -184,7 +258,30 @@ func main() {
 	)
 
 	azureClient := azure.NewClient()
-	azureUp := commands.NewAzureUp(azureClient, logger)
+	azureUp := commands.NewAzureUp(commands.NewAzureUpArgs{
+		AzureClient:        azureClient,
+		StateStore:         stateStore,
+		KeyPairManager:     keyPairManager,
+		BoshManager:        boshManager,
+		CloudConfigManager: cloudConfigManager,
+		TerraformManager:   terraformManager,
+		EnvIDManager:       envIDManager,
+		Logger:             logger,
+	})
+
+	openstackClient := openstack.NewClient()
+	openstackUp := commands.NewOpenStackUp(commands.NewOpenStackUpArgs{
+		OpenStackClient:    openstackClient,
+		StateStore:         stateStore,
+		KeyPairManager:     keyPairManager,
+		BoshManager:        boshManager,
+		CloudConfigManager: cloudConfigManager,
+		TerraformManager:   terraformManager,
+		EnvIDManager:       envIDManager,
+		Logger:             logger,
+	})
+
+	openstackCreateLBs := commands.NewOpenStackCreateLBs(terraformManager, cloudConfigManager, stateStore, logger)
 
 	gcpDeleteLBs := commands.NewGCPDeleteLBs(stateStore, terraformManager, cloudConfigManager)
 
@@ -197,9 +294,11 @@ func main() {
 		EnvIDManager:                 envIDManager,
 		CloudConfigManager:           cloudConfigManager,
 		GCPAvailabilityZoneRetriever: gcpClientProvider.Client(),
+		UpDetacher:                   upDetacher,
+		StemcellUploader:             stemcellUploader,
 	})
 
-	gcpCreateLBs := commands.NewGCPCreateLBs(terraformManager, cloudConfigManager, stateStore, logger, gcpClientProvider.Client())
+	gcpCreateLBs := commands.NewGCPCreateLBs(terraformManager, cloudConfigManager, stateStore, logger, gcpClientProvider.Client(), letsEncrypt)
 
 	gcpLBs := commands.NewGCPLBs(terraformManager, logger)
 
@@ -208,35 +307,62 @@ func main() {
 	// Commands
 	commandSet := application.CommandSet{}
 	commandSet["help"] = usage
-	commandSet["version"] = commands.NewVersion(Version, logger)
-	commandSet["up"] = commands.NewUp(awsUp, gcpUp, azureUp, envGetter, boshManager)
+	commandSet["version"] = commands.NewVersion(commands.BuildInfo{Version: Version, GitSHA: GitSHA, BuildDate: BuildDate}, logger)
+	commandSet["up"] = commands.NewUp(awsUp, gcpUp, azureUp, openstackUp, envGetter, boshManager, awsQuotaChecker, gcpQuotaChecker, terraformManager, logger, keyPairChecker)
 	commandSet["destroy"] = commands.NewDestroy(
 		credentialValidator, logger, os.Stdin, boshManager, vpcStatusChecker, stackManager,
 		infrastructureManager, awsKeyPairDeleter, gcpKeyPairDeleter, certificateDeleter,
 		stateStore, stateValidator, terraformManager, gcpNetworkInstancesChecker,
 	)
 	commandSet["down"] = commandSet["destroy"]
-	commandSet["create-lbs"] = commands.NewCreateLBs(awsCreateLBs, gcpCreateLBs, stateValidator, certificateValidator, boshManager)
+	commandSet["create-lbs"] = commands.NewCreateLBs(awsCreateLBs, gcpCreateLBs, openstackCreateLBs, stateValidator, certificateValidator, boshManager, terraformManager, logger)
 	commandSet["update-lbs"] = commands.NewUpdateLBs(awsUpdateLBs, gcpUpdateLBs, certificateValidator, stateValidator, logger, boshManager)
-	commandSet["delete-lbs"] = commands.NewDeleteLBs(gcpDeleteLBs, awsDeleteLBs, logger, stateValidator, boshManager)
+	commandSet[commands.RotateLBCertsCommand] = commands.NewRotateLBCerts(terraformManager, certificateValidator, stateValidator, stateStore, logger)
+	commandSet["delete-lbs"] = commands.NewDeleteLBs(gcpDeleteLBs, awsDeleteLBs, logger, stateValidator, boshManager, terraformManager)
 	commandSet["lbs"] = commands.NewLBs(gcpLBs, awsLBs, stateValidator, logger)
-	commandSet["jumpbox-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, commands.JumpboxAddressPropertyName)
-	commandSet["director-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, commands.DirectorAddressPropertyName)
-	commandSet["director-username"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, commands.DirectorUsernamePropertyName)
-	commandSet["director-password"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, commands.DirectorPasswordPropertyName)
-	commandSet["director-ca-cert"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, commands.DirectorCACertPropertyName)
+	commandSet["jumpbox-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, boshClientProvider, socks5Proxy, sshKeyGetter, commands.JumpboxAddressPropertyName)
+	commandSet["director-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, boshClientProvider, socks5Proxy, sshKeyGetter, commands.DirectorAddressPropertyName)
+	commandSet["director-username"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, boshClientProvider, socks5Proxy, sshKeyGetter, commands.DirectorUsernamePropertyName)
+	commandSet["director-password"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, boshClientProvider, socks5Proxy, sshKeyGetter, commands.DirectorPasswordPropertyName)
+	commandSet["director-ca-cert"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, boshClientProvider, socks5Proxy, sshKeyGetter, commands.DirectorCACertPropertyName)
 	commandSet["ssh-key"] = commands.NewSSHKey(logger, stateValidator, sshKeyGetter)
-	commandSet["env-id"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, commands.EnvIDPropertyName)
+	commandSet["ssh"] = commands.NewSSH(logger, stateValidator, sshKeyGetter, proxy.NewSSHShell(hostKeyGetter, proxy.NewTerminal(os.Stdin), os.Stdin, os.Stdout, os.Stderr))
+	commandSet["env-id"] = commands.NewStateQuery(logger, stateValidator, terraformManager, infrastructureManager, boshClientProvider, socks5Proxy, sshKeyGetter, commands.EnvIDPropertyName)
 	commandSet["latest-error"] = commands.NewLatestError(logger, stateValidator)
-	commandSet["print-env"] = commands.NewPrintEnv(logger, stateValidator, terraformManager)
-	commandSet["cloud-config"] = commands.NewCloudConfig(logger, stateValidator, cloudConfigManager)
+	commandSet["print-env"] = commands.NewPrintEnv(logger, stateValidator, terraformManager, proxy.NewSSHAgent())
+	commandSet[commands.DirectorBackupCommand] = commands.NewDirectorBackup(logger, stateValidator, sshKeyGetter, socks5Proxy, bosh.NewBBRCmd(os.Stdout, os.Stderr))
+	commandSet[commands.DirectorRestoreCommand] = commands.NewDirectorRestore(logger, stateValidator, sshKeyGetter, socks5Proxy, bosh.NewBBRCmd(os.Stdout, os.Stderr))
+	commandSet[commands.CleanupLeftoversCommand] = commands.NewCleanupLeftovers(leftovers.NewAWS(awsClientProvider, awsClientProvider), leftovers.NewGCP(gcpClientProvider.Client()), credentialValidator, logger, os.Stdin)
+	commandSet[commands.VerifyCommand] = commands.NewVerify(credentialValidator, awsAvailabilityZoneRetriever, gcpClientProvider.Client(),
+		iam.NewPermissionChecker(awsClientProvider), gcp.NewPermissionChecker(gcpClientProvider.Client()), awsQuotaChecker, gcpQuotaChecker, logger)
+	commandSet[commands.EstimateCostCommand] = commands.NewEstimateCost(credentialValidator, awsAvailabilityZoneRetriever, pricing.NewClient(awsConfiguration), gcp.NewPricer(gcpClientProvider.Client()), logger)
+	commandSet["open"] = commands.NewOpen(logger, stateValidator, socks5Proxy, sshKeyGetter, proxy.NewPortForwarder(logger))
+	commandSet["cloud-config"] = commands.NewCloudConfig(logger, stateValidator, cloudConfigManager, stateStore, terraformManager, gcpClientProvider.Client(), cloudconfig.NewFetcher(publicHTTPClient))
 	commandSet["bosh-deployment-vars"] = commands.NewBOSHDeploymentVars(logger, boshManager, stateValidator, terraformManager)
-	commandSet["rotate"] = commands.NewRotate(stateStore, keyPairManager, terraformManager, boshManager, stateValidator)
+	commandSet["deployments"] = commands.NewDeployments(logger, stateValidator, boshClientProvider, socks5Proxy, sshKeyGetter)
+	commandSet["status"] = commands.NewStatus(logger, stateValidator, terraformManager, boshClientProvider, socks5Proxy, sshKeyGetter, storage.NewProcessChecker())
+	commandSet["rotate"] = commands.NewRotate(stateStore, keyPairManager, terraformManager, boshManager, stateValidator, logger)
+	commandSet["history"] = commands.NewHistory(auditLog, logger)
+	commandSet["restore-state"] = commands.NewRestoreState(stateStore, stateValidator, logger)
+	commandSet["export-state"] = commands.NewExportState(stateStore, stateValidator, envGetter, logger)
+	commandSet["import-state"] = commands.NewImportState(stateStore, envGetter, logger)
+	commandSet["recreate-jumpbox"] = commands.NewRecreateJumpbox(stateStore, terraformManager, boshManager, stateValidator, logger)
+	commandSet["regenerate-credhub-password"] = commands.NewRegenerateCredhubPassword(stateStore, terraformManager, boshManager, stateValidator, logger)
+	commandSet["resize-director"] = commands.NewResizeDirector(stateStore, terraformManager, boshManager, stateValidator, logger)
+	commandSet["terraform-output"] = commands.NewTerraformOutput(logger, stateValidator, terraformManager)
+	commandSet["outputs"] = commands.NewOutputs(logger, stateValidator, terraformManager, infrastructureManager)
+	commandSet["state"] = commands.NewShowState(logger, stateValidator)
+	commandSet["drift"] = commands.NewDrift(logger, stateValidator, terraformManager)
+	commandSet["migrate-state"] = commands.NewMigrateState(stateValidator, stateStore, stackMigrator, logger)
+	commandSet["refresh"] = commands.NewRefresh(logger, stateValidator, stateStore, terraformManager)
+	commandSet["plan"] = commands.NewPlan(terraformManager, boshManager, cloudConfigManager, envIDManager, parsedFlags.StateDir, logger)
 
 	commandConfiguration := &application.Configuration{
 		Global: application.GlobalConfiguration{
 			StateDir: parsedFlags.StateDir,
 			Debug:    parsedFlags.Debug,
+			DryRun:   parsedFlags.DryRun,
+			JSON:     parsedFlags.JSON,
 		},
 		State:           loadedState,
 		ShowCommandHelp: parsedFlags.Help,
@@ -262,7 +388,40 @@ func main() {
 	app := application.New(commandSet, *commandConfiguration, usage)
 
 	err = app.Run()
+
+	logger.Summary()
+
+	// help and reading the history itself do not change the environment
+	command := commandConfiguration.Command
+	if command != "" && command != "help" && command != "version" && command != "history" && !commandConfiguration.ShowCommandHelp {
+		record := application.NewAuditRecord(command, os.Args[1:], started, err)
+		if auditErr := auditLog.Append(record); auditErr != nil {
+			stderrLogger.Error("%s", auditErr)
+		}
+	}
+
+	if parsedFlags.MetricsFile != "" && commandConfiguration.Command != "" {
+		metricsWriter := application.NewMetricsWriter(parsedFlags.MetricsFile)
+		if metricsErr := metricsWriter.Write(commandConfiguration.Command, started, logger.Steps(), err); metricsErr != nil {
+			stderrLogger.Error("%s", metricsErr)
+		}
+	}
+
+	// the latest error is recorded in the state while the state dir is
+	// still locked
+	if err != nil && !parsedFlags.DryRun {
+		if recordErr := latestErrorRecorder.Record(err); recordErr != nil {
+			stderrLogger.Error("failed to record the latest error: %s", recordErr)
+		}
+	}
+	parsedFlags.Unlock()
+
 	if err != nil {
-		log.Fatalf("\n\n%s\n", err)
+		exit(err)
 	}
 }
+
+func exit(err error) {
+	log.Printf("\n\n%s\n", err)
+	os.Exit(application.ExitCode(err))
+}
@@ -0,0 +1,32 @@
+package bosh
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+type BBRCmd struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func NewBBRCmd(stdout, stderr io.Writer) BBRCmd {
+	return BBRCmd{
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+// Run runs bbr with args in workingDirectory, where it writes backup
+// artifacts. env is added to bbl's own environment.
+func (c BBRCmd) Run(env []string, workingDirectory string, args []string) error {
+	command := exec.Command("bbr", args...)
+	command.Dir = workingDirectory
+	command.Env = append(os.Environ(), env...)
+
+	command.Stdout = c.stdout
+	command.Stderr = c.stderr
+
+	return command.Run()
+}
@@ -0,0 +1,78 @@
+package bosh_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BBRCmd", func() {
+	var (
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+
+		cmd bosh.BBRCmd
+
+		binDir       string
+		workingDir   string
+		originalPath string
+	)
+
+	BeforeEach(func() {
+		stdout = bytes.NewBuffer([]byte{})
+		stderr = bytes.NewBuffer([]byte{})
+
+		cmd = bosh.NewBBRCmd(stdout, stderr)
+
+		var err error
+		binDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(binDir, "bbr"), []byte(`#!/bin/sh
+echo "args: $@"
+echo "dir: $(pwd)"
+echo "proxy: ${BOSH_ALL_PROXY}"
+echo "some-error" >&2
+`), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		originalPath = os.Getenv("PATH")
+		os.Setenv("PATH", binDir+string(os.PathListSeparator)+originalPath)
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", originalPath)
+		os.RemoveAll(binDir)
+		os.RemoveAll(workingDir)
+	})
+
+	It("runs bbr with the args and env in the working directory", func() {
+		err := cmd.Run([]string{"BOSH_ALL_PROXY=socks5://localhost:1080"}, workingDir, []string{"director", "backup"})
+		Expect(err).NotTo(HaveOccurred())
+
+		resolvedWorkingDir, err := filepath.EvalSymlinks(workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stdout.String()).To(ContainSubstring("args: director backup"))
+		Expect(stdout.String()).To(ContainSubstring("dir: " + resolvedWorkingDir))
+		Expect(stdout.String()).To(ContainSubstring("proxy: socks5://localhost:1080"))
+		Expect(stderr.String()).To(Equal("some-error\n"))
+	})
+
+	It("returns an error when bbr fails", func() {
+		err := ioutil.WriteFile(filepath.Join(binDir, "bbr"), []byte("#!/bin/sh\nexit 1\n"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = cmd.Run(nil, workingDir, []string{"director", "backup"})
+		Expect(err).To(MatchError("exit status 1"))
+	})
+})
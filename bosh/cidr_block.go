@@ -9,6 +9,7 @@ import (
 type CIDRBlock struct {
 	CIDRSize int
 	firstIP  IP
+	maskBits int
 }
 
 func ParseCIDRBlock(cidrBlock string) (CIDRBlock, error) {
@@ -39,6 +40,7 @@ func ParseCIDRBlock(cidrBlock string) (CIDRBlock, error) {
 	return CIDRBlock{
 		CIDRSize: cidrSize,
 		firstIP:  ip,
+		maskBits: maskBits,
 	}, nil
 }
 
@@ -49,3 +51,33 @@ func (c CIDRBlock) GetFirstIP() IP {
 func (c CIDRBlock) GetLastIP() IP {
 	return c.firstIP.Add(c.CIDRSize - 1)
 }
+
+func (c CIDRBlock) Subnet(newBits, index int) (CIDRBlock, error) {
+	maskBits := c.maskBits + newBits
+	if maskBits > 32 {
+		return CIDRBlock{}, fmt.Errorf("%s is too small to be divided into /%d subnets", c, maskBits)
+	}
+
+	cidrSize := c.CIDRSize >> uint(newBits)
+	if index < 0 || index >= 1<<uint(newBits) {
+		return CIDRBlock{}, fmt.Errorf("%s does not have a /%d subnet at index %d", c, maskBits, index)
+	}
+
+	return CIDRBlock{
+		CIDRSize: cidrSize,
+		firstIP:  c.firstIP.Add(index * cidrSize),
+		maskBits: maskBits,
+	}, nil
+}
+
+func (c CIDRBlock) Contains(other CIDRBlock) bool {
+	return other.firstIP.ip >= c.firstIP.ip && other.GetLastIP().ip <= c.GetLastIP().ip
+}
+
+func (c CIDRBlock) Overlaps(other CIDRBlock) bool {
+	return other.firstIP.ip <= c.GetLastIP().ip && c.firstIP.ip <= other.GetLastIP().ip
+}
+
+func (c CIDRBlock) String() string {
+	return fmt.Sprintf("%s/%d", c.firstIP, c.maskBits)
+}
@@ -32,6 +32,58 @@ var _ = Describe("CIDRBlock", func() {
 		})
 	})
 
+	Describe("String", func() {
+		It("returns the cidr block in cidr notation", func() {
+			Expect(cidrBlock.String()).To(Equal("10.0.16.0/20"))
+		})
+	})
+
+	Describe("Subnet", func() {
+		It("returns the subnet at the given index", func() {
+			subnet, err := cidrBlock.Subnet(4, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnet.String()).To(Equal("10.0.18.0/24"))
+		})
+
+		It("returns an error when the index does not fit", func() {
+			_, err := cidrBlock.Subnet(4, 16)
+			Expect(err).To(MatchError("10.0.16.0/20 does not have a /24 subnet at index 16"))
+		})
+
+		It("returns an error when the subnet would be too small", func() {
+			_, err := cidrBlock.Subnet(13, 0)
+			Expect(err).To(MatchError("10.0.16.0/20 is too small to be divided into /33 subnets"))
+		})
+	})
+
+	Describe("Contains", func() {
+		It("returns true when the other block is within the cidr block", func() {
+			other, err := bosh.ParseCIDRBlock("10.0.17.0/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidrBlock.Contains(other)).To(BeTrue())
+		})
+
+		It("returns false when the other block extends past the cidr block", func() {
+			other, err := bosh.ParseCIDRBlock("10.0.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidrBlock.Contains(other)).To(BeFalse())
+		})
+	})
+
+	Describe("Overlaps", func() {
+		It("returns true when the blocks share addresses", func() {
+			other, err := bosh.ParseCIDRBlock("10.0.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidrBlock.Overlaps(other)).To(BeTrue())
+		})
+
+		It("returns false when the blocks are disjoint", func() {
+			other, err := bosh.ParseCIDRBlock("10.0.32.0/20")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidrBlock.Overlaps(other)).To(BeFalse())
+		})
+	})
+
 	Describe("ParseCIDRBlock", func() {
 		Context("failure cases", func() {
 			It("returns an error when input string is not a valid CIDR block", func() {
@@ -7,29 +7,76 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 
 	"golang.org/x/net/proxy"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 )
 
 type Client interface {
+	CloudConfig() (string, error)
 	UpdateCloudConfig(yaml []byte) error
 	ConfigureHTTPClient(proxy.Dialer)
 	Info() (Info, error)
+	Deployments() ([]Deployment, error)
+	Stemcells() ([]Stemcell, error)
+	UploadStemcell(stemcell SizeReader) error
+	UploadStemcellURL(stemcellURL string) error
+}
+
+type SizeReader interface {
+	io.Reader
+	Size() int64
 }
 
+var taskPollInterval = 2 * time.Second
+
 type Info struct {
 	Name    string `json:"name"`
 	UUID    string `json:"uuid"`
 	Version string `json:"version"`
 }
 
+type Deployment struct {
+	Name      string               `json:"name"`
+	Releases  []DeploymentRelease  `json:"releases"`
+	Stemcells []DeploymentStemcell `json:"stemcells"`
+}
+
+type DeploymentRelease struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type DeploymentStemcell struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Stemcell struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cloudConfig struct {
+	Properties string `json:"properties"`
+}
+
+type task struct {
+	ID     int    `json:"id"`
+	State  string `json:"state"`
+	Result string `json:"result"`
+}
+
 type client struct {
 	jumpbox         bool
 	directorAddress string
@@ -37,9 +84,14 @@ type client struct {
 	password        string
 	caCert          string
 	httpClient      *http.Client
+	taskTimeout     helpers.Timeout
 }
 
 func NewClient(jumpbox bool, directorAddress, username, password, caCert string) Client {
+	return newClient(jumpbox, directorAddress, username, password, caCert, helpers.Timeout{})
+}
+
+func newClient(jumpbox bool, directorAddress, username, password, caCert string, taskTimeout helpers.Timeout) client {
 	pool := x509.NewCertPool()
 	pool.AppendCertsFromPEM([]byte(caCert))
 
@@ -49,6 +101,9 @@ func NewClient(jumpbox bool, directorAddress, username, password, caCert string)
 				RootCAs: pool,
 			},
 		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
 	}
 
 	return client{
@@ -58,6 +113,7 @@ func NewClient(jumpbox bool, directorAddress, username, password, caCert string)
 		httpClient:      httpClient,
 		caCert:          caCert,
 		jumpbox:         jumpbox,
+		taskTimeout:     taskTimeout,
 	}
 }
 
@@ -97,6 +153,35 @@ func (c client) Info() (Info, error) {
 	return info, nil
 }
 
+// CloudConfig returns the cloud config the director is currently using, or an
+// empty string when none has been uploaded yet.
+func (c client) CloudConfig() (string, error) {
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/cloud_configs?limit=1", c.directorAddress), strings.NewReader(""))
+	if err != nil {
+		return "", err
+	}
+
+	response, err := c.doAuthenticated(request)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	var cloudConfigs []cloudConfig
+	if err := json.NewDecoder(response.Body).Decode(&cloudConfigs); err != nil {
+		return "", err
+	}
+
+	if len(cloudConfigs) == 0 {
+		return "", nil
+	}
+
+	return cloudConfigs[0].Properties, nil
+}
+
 func (c client) UpdateCloudConfig(yaml []byte) error {
 	request, err := http.NewRequest("POST", fmt.Sprintf("%s/cloud_configs", c.directorAddress), bytes.NewBuffer(yaml))
 	if err != nil {
@@ -105,16 +190,190 @@ func (c client) UpdateCloudConfig(yaml []byte) error {
 
 	request.Header.Set("Content-Type", "text/yaml")
 
-	var response *http.Response
+	response, err := c.doAuthenticated(request)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	return nil
+}
+
+func (c client) Deployments() ([]Deployment, error) {
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/deployments", c.directorAddress), strings.NewReader(""))
+	if err != nil {
+		return []Deployment{}, err
+	}
+
+	response, err := c.doAuthenticated(request)
+	if err != nil {
+		return []Deployment{}, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return []Deployment{}, fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	var deployments []Deployment
+	if err := json.NewDecoder(response.Body).Decode(&deployments); err != nil {
+		return []Deployment{}, err
+	}
+
+	return deployments, nil
+}
+
+func (c client) Stemcells() ([]Stemcell, error) {
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/stemcells", c.directorAddress), strings.NewReader(""))
+	if err != nil {
+		return []Stemcell{}, err
+	}
+
+	response, err := c.doAuthenticated(request)
+	if err != nil {
+		return []Stemcell{}, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return []Stemcell{}, fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	var stemcells []Stemcell
+	if err := json.NewDecoder(response.Body).Decode(&stemcells); err != nil {
+		return []Stemcell{}, err
+	}
+
+	return stemcells, nil
+}
+
+func (c client) UploadStemcell(stemcell SizeReader) error {
+	request, err := http.NewRequest("POST", fmt.Sprintf("%s/stemcells", c.directorAddress), stemcell)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/x-compressed")
+	request.ContentLength = stemcell.Size()
+
+	return c.runTask(request)
+}
+
+func (c client) UploadStemcellURL(stemcellURL string) error {
+	body, err := json.Marshal(map[string]string{"location": stemcellURL})
+	if err != nil {
+		return err //not tested
+	}
+
+	request, err := http.NewRequest("POST", fmt.Sprintf("%s/stemcells", c.directorAddress), bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	return c.runTask(request)
+}
+
+// runTask sends a request that starts a director task and waits for the task
+// to finish. A task that is still running after the task timeout is
+// cancelled.
+func (c client) runTask(request *http.Request) error {
+	response, err := c.doAuthenticated(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	var taskPath string
+	switch {
+	case response.StatusCode == http.StatusFound:
+		location, err := url.Parse(response.Header.Get("Location"))
+		if err != nil {
+			return err
+		}
+		taskPath = location.Path
+	case response.StatusCode == http.StatusOK && strings.HasPrefix(response.Request.URL.Path, "/tasks/"):
+		// the oauth client follows the redirect to the task itself
+		taskPath = response.Request.URL.Path
+	default:
+		return fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	timeout := c.taskTimeout.Duration()
+	started := time.Now()
+	for {
+		t, err := c.task(taskPath)
+		if err != nil {
+			return err
+		}
+
+		switch t.State {
+		case "done":
+			return nil
+		case "error", "cancelled", "timeout":
+			return fmt.Errorf("task %d %s: %s", t.ID, t.State, t.Result)
+		}
+
+		if timeout != 0 && time.Since(started) >= timeout {
+			c.cancelTask(taskPath)
+			return helpers.TimeoutError{Name: fmt.Sprintf("task %d", t.ID), Duration: timeout}
+		}
+
+		time.Sleep(taskPollInterval)
+	}
+}
+
+// cancelTask asks the director to cancel the task, at the next checkpoint of
+// the task. Failing to cancel it does not change the outcome of the run.
+func (c client) cancelTask(taskPath string) {
+	request, err := http.NewRequest("DELETE", fmt.Sprintf("%s%s", c.directorAddress, taskPath), strings.NewReader(""))
+	if err != nil {
+		return //not tested
+	}
+
+	response, err := c.doAuthenticated(request)
+	if err != nil {
+		return //not tested
+	}
+	response.Body.Close()
+}
+
+func (c client) task(taskPath string) (task, error) {
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s%s", c.directorAddress, taskPath), strings.NewReader(""))
+	if err != nil {
+		return task{}, err
+	}
+
+	response, err := c.doAuthenticated(request)
+	if err != nil {
+		return task{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return task{}, fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	var t task
+	if err := json.NewDecoder(response.Body).Decode(&t); err != nil {
+		return task{}, err
+	}
+
+	return t, nil
+}
+
+func (c client) doAuthenticated(request *http.Request) (*http.Response, error) {
 	if c.jumpbox {
 		urlParts, err := url.Parse(c.directorAddress)
 		if err != nil {
-			return err //not tested
+			return nil, err //not tested
 		}
 
 		boshHost, _, err := net.SplitHostPort(urlParts.Host)
 		if err != nil {
-			return err //not tested
+			return nil, err //not tested
 		}
 
 		ctx := context.Background()
@@ -126,26 +385,10 @@ func (c client) UpdateCloudConfig(yaml []byte) error {
 			TokenURL:     fmt.Sprintf("https://%s:8443/oauth/token", boshHost),
 		}
 
-		httpClient := conf.Client(ctx)
-
-		response, err = httpClient.Do(request)
-		if err != nil {
-			return err
-		}
-	} else {
-		request.SetBasicAuth(c.username, c.password)
-
-		var err error
-		response, err = c.httpClient.Do(request)
-		if err != nil {
-			return err
-		}
-
+		return conf.Client(ctx).Do(request)
 	}
 
-	if response.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
-	}
+	request.SetBasicAuth(c.username, c.password)
 
-	return nil
+	return c.httpClient.Do(request)
 }
@@ -1,11 +1,17 @@
 package bosh
 
-type ClientProvider struct{}
+import "github.com/cloudfoundry/bosh-bootloader/helpers"
 
-func NewClientProvider() ClientProvider {
-	return ClientProvider{}
+type ClientProvider struct {
+	taskTimeout helpers.Timeout
 }
 
-func (ClientProvider) Client(jumpbox bool, directorAddress, directorUsername, directorPassword, directorCACert string) Client {
-	return NewClient(jumpbox, directorAddress, directorUsername, directorPassword, directorCACert)
+func NewClientProvider(taskTimeout helpers.Timeout) ClientProvider {
+	return ClientProvider{
+		taskTimeout: taskTimeout,
+	}
+}
+
+func (p ClientProvider) Client(jumpbox bool, directorAddress, directorUsername, directorPassword, directorCACert string) Client {
+	return newClient(jumpbox, directorAddress, directorUsername, directorPassword, directorCACert, p.taskTimeout)
 }
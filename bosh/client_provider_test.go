@@ -2,6 +2,7 @@ package bosh_test
 
 import (
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -13,7 +14,7 @@ var _ = Describe("Client Provider", func() {
 		)
 
 		BeforeEach(func() {
-			clientProvider = bosh.NewClientProvider()
+			clientProvider = bosh.NewClientProvider(helpers.Timeout{})
 		})
 
 		It("returns a bosh client", func() {
@@ -10,9 +10,12 @@ import (
 	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,7 +31,12 @@ var _ = Describe("Client", func() {
 		username               string
 		password               string
 		cloudConfigContentType string
+		currentCloudConfigs    string
 		failStatus             int
+		stemcellContentType    string
+		stemcellBody           []byte
+		taskStates             []string
+		taskCancelled          bool
 	)
 
 	BeforeEach(func() {
@@ -73,14 +81,35 @@ var _ = Describe("Client", func() {
 				          "uuid": "some-uuid",
 				          "version": "some-version"
 		                }`))
+			case "/deployments":
+				if failStatus != 0 {
+					w.WriteHeader(failStatus)
+					w.Write([]byte("%%%%%%%%%%%%%%%%"))
+					return
+				}
+
+				username, password, _ = req.BasicAuth()
+
+				w.Write([]byte(`[{
+				          "name": "some-deployment",
+				          "releases": [{"name": "some-release", "version": "1.2.3"}],
+				          "stemcells": [{"name": "some-stemcell", "version": "3421.11"}]
+				        }]`))
 			case "/cloud_configs":
 				if failStatus != 0 {
 					w.WriteHeader(failStatus)
+					w.Write([]byte("%%%%%%%%%%%%%%%%"))
 					return
 				}
 
 				username, password, _ = req.BasicAuth()
 
+				if req.Method == "GET" {
+					Expect(req.URL.Query().Get("limit")).To(Equal("1"))
+					w.Write([]byte(currentCloudConfigs))
+					return
+				}
+
 				token = req.Header.Get("Authorization")
 				cloudConfigContentType = req.Header.Get("Content-Type")
 
@@ -89,6 +118,46 @@ var _ = Describe("Client", func() {
 				var err error
 				cloudConfig, err = ioutil.ReadAll(req.Body)
 				Expect(err).NotTo(HaveOccurred())
+			case "/stemcells":
+				username, password, _ = req.BasicAuth()
+
+				if req.Method == "GET" {
+					if failStatus != 0 {
+						w.WriteHeader(failStatus)
+						w.Write([]byte("%%%%%%%%%%%%%%%%"))
+						return
+					}
+
+					w.Write([]byte(`[{"name": "some-stemcell", "version": "3421.11", "cid": "some-cid"}]`))
+					return
+				}
+
+				if failStatus != 0 {
+					w.WriteHeader(failStatus)
+					return
+				}
+
+				stemcellContentType = req.Header.Get("Content-Type")
+
+				var err error
+				stemcellBody, err = ioutil.ReadAll(req.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				w.Header().Set("Location", fmt.Sprintf("https://%s/tasks/1", req.Host))
+				w.WriteHeader(http.StatusFound)
+			case "/tasks/1":
+				if req.Method == "DELETE" {
+					taskCancelled = true
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+
+				state := taskStates[0]
+				if len(taskStates) > 1 {
+					taskStates = taskStates[1:]
+				}
+
+				w.Write([]byte(fmt.Sprintf(`{"id": 1, "state": %q, "result": "some-result"}`, state)))
 			default:
 				dump, err := httputil.DumpRequest(req, true)
 				Expect(err).NotTo(HaveOccurred())
@@ -102,10 +171,16 @@ var _ = Describe("Client", func() {
 		}
 
 		fakeBOSH.TLS = tlsConfig
+
+		taskStates = []string{"done"}
+		taskCancelled = false
+		currentCloudConfigs = `[{"properties": "azs: []\n", "created_at": "2017-07-01 12:00:00 UTC"}]`
+		bosh.SetTaskPollInterval(0)
 	})
 
 	AfterEach(func() {
 		failStatus = 0
+		bosh.ResetTaskPollInterval()
 	})
 
 	Describe("ConfigureHttpClient", func() {
@@ -186,6 +261,107 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("Deployments", func() {
+		It("returns the deployments on the director", func() {
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+			deployments, err := client.Deployments()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(username).To(Equal("some-username"))
+			Expect(password).To(Equal("some-password"))
+			Expect(deployments).To(Equal([]bosh.Deployment{
+				{
+					Name:      "some-deployment",
+					Releases:  []bosh.DeploymentRelease{{Name: "some-release", Version: "1.2.3"}},
+					Stemcells: []bosh.DeploymentStemcell{{Name: "some-stemcell", Version: "3421.11"}},
+				},
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the response is not StatusOK", func() {
+				failStatus = http.StatusInternalServerError
+
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				_, err := client.Deployments()
+				Expect(err).To(MatchError("unexpected http response 500 Internal Server Error"))
+			})
+
+			It("returns an error when the url cannot be parsed", func() {
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, "%%%", "some-username", "some-password", "some-false")
+				_, err := client.Deployments()
+				Expect(err.(*url.Error).Op).To(Equal("parse"))
+			})
+
+			It("returns an error when it cannot parse deployments json", func() {
+				failStatus = http.StatusOK
+
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				_, err := client.Deployments()
+				Expect(err).To(MatchError(ContainSubstring("invalid character")))
+			})
+		})
+	})
+
+	Describe("CloudConfig", func() {
+		It("returns the director's latest cloud config", func() {
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+			cloudConfig, err := client.CloudConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(username).To(Equal("some-username"))
+			Expect(password).To(Equal("some-password"))
+			Expect(cloudConfig).To(Equal("azs: []\n"))
+		})
+
+		It("returns an empty cloud config when none has been uploaded", func() {
+			currentCloudConfigs = "[]"
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+			cloudConfig, err := client.CloudConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cloudConfig).To(BeEmpty())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the response is not 200", func() {
+				failStatus = http.StatusInternalServerError
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				_, err := client.CloudConfig()
+				Expect(err).To(MatchError("unexpected http response 500 Internal Server Error"))
+			})
+
+			It("returns an error when the director address is malformed", func() {
+				client := bosh.NewClient(false, "%%%%%%%%%%%%%%%", "", "", "")
+				_, err := client.CloudConfig()
+				Expect(err.(*url.Error).Op).To(Equal("parse"))
+			})
+
+			It("returns an error when it cannot parse the cloud configs json", func() {
+				currentCloudConfigs = "%%%%"
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				_, err := client.CloudConfig()
+				Expect(err).To(MatchError(ContainSubstring("invalid character")))
+			})
+		})
+	})
+
 	Describe("UpdateCloudConfig", func() {
 		Context("when a jumpbox is enabled", func() {
 			It("uploads the cloud-config", func() {
@@ -262,4 +438,124 @@ var _ = Describe("Client", func() {
 			})
 		})
 	})
+
+	Describe("Stemcells", func() {
+		It("returns the stemcells on the director", func() {
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+			stemcells, err := client.Stemcells()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(username).To(Equal("some-username"))
+			Expect(password).To(Equal("some-password"))
+			Expect(stemcells).To(Equal([]bosh.Stemcell{{Name: "some-stemcell", Version: "3421.11"}}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the response is not StatusOK", func() {
+				failStatus = http.StatusInternalServerError
+
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				_, err := client.Stemcells()
+				Expect(err).To(MatchError("unexpected http response 500 Internal Server Error"))
+			})
+
+			It("returns an error when it cannot parse stemcells json", func() {
+				failStatus = http.StatusOK
+
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				_, err := client.Stemcells()
+				Expect(err).To(MatchError(ContainSubstring("invalid character")))
+			})
+		})
+	})
+
+	Describe("UploadStemcell", func() {
+		It("uploads the stemcell and waits for the task to finish", func() {
+			taskStates = []string{"queued", "processing", "done"}
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+			err := client.UploadStemcell(strings.NewReader("some-stemcell-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stemcellBody).To(Equal([]byte("some-stemcell-contents")))
+			Expect(stemcellContentType).To(Equal("application/x-compressed"))
+			Expect(username).To(Equal("some-username"))
+			Expect(password).To(Equal("some-password"))
+			Expect(taskStates).To(Equal([]string{"done"}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the upload is not accepted", func() {
+				failStatus = http.StatusInternalServerError
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				err := client.UploadStemcell(strings.NewReader("some-stemcell-contents"))
+				Expect(err).To(MatchError("unexpected http response 500 Internal Server Error"))
+			})
+
+			It("returns an error when the task fails", func() {
+				taskStates = []string{"processing", "error"}
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				err := client.UploadStemcell(strings.NewReader("some-stemcell-contents"))
+				Expect(err).To(MatchError("task 1 error: some-result"))
+			})
+
+			It("cancels the task and returns an error when it runs past the task timeout", func() {
+				taskStates = []string{"processing"}
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClientProvider(helpers.Timeout{Limit: time.Nanosecond}).Client(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				err := client.UploadStemcell(strings.NewReader("some-stemcell-contents"))
+				Expect(err).To(MatchError("task 1 timed out after 1ns"))
+				Expect(taskCancelled).To(BeTrue())
+			})
+
+			It("returns an error when the director address is malformed", func() {
+				client := bosh.NewClient(false, "%%%%%%%%%%%%%%%", "", "", "")
+
+				err := client.UploadStemcell(strings.NewReader("some-stemcell-contents"))
+				Expect(err.(*url.Error).Op).To(Equal("parse"))
+			})
+		})
+	})
+
+	Describe("UploadStemcellURL", func() {
+		It("asks the director to fetch the stemcell and waits for the task to finish", func() {
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(false, fakeBOSH.URL, "some-username", "some-password", string(ca))
+			err := client.UploadStemcellURL("https://example.com/some-stemcell.tgz")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stemcellBody).To(MatchJSON(`{"location": "https://example.com/some-stemcell.tgz"}`))
+			Expect(stemcellContentType).To(Equal("application/json"))
+		})
+
+		Context("when a jumpbox is enabled", func() {
+			It("follows the redirect to the task", func() {
+				fakeBOSH.StartTLS()
+
+				client := bosh.NewClient(true, fakeBOSH.URL, "some-username", "some-password", string(ca))
+				socks5Client := &fakes.Socks5Client{}
+				socks5Client.DialCall.Stub = func(network, addr string) (net.Conn, error) {
+					u, _ := url.Parse(fakeBOSH.URL)
+					return net.Dial(network, u.Host)
+				}
+				client.ConfigureHTTPClient(socks5Client)
+
+				err := client.UploadStemcellURL("https://example.com/some-stemcell.tgz")
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
 })
@@ -4,15 +4,21 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 )
 
 type Cmd struct {
-	stderr io.Writer
+	stderr       io.Writer
+	outputBuffer io.Writer
+	timeout      helpers.Timeout
 }
 
-func NewCmd(stderr io.Writer) Cmd {
+func NewCmd(stderr, outputBuffer io.Writer, timeout helpers.Timeout) Cmd {
 	return Cmd{
-		stderr: stderr,
+		stderr:       stderr,
+		outputBuffer: outputBuffer,
+		timeout:      timeout,
 	}
 }
 
@@ -33,8 +39,13 @@ func (c Cmd) Run(stdout io.Writer, workingDirectory string, args []string) error
 	command := exec.Command(boshPath, args...)
 	command.Dir = workingDirectory
 
-	command.Stdout = stdout
-	command.Stderr = c.stderr
+	// The output of interpolate is the manifest with every variable filled
+	// in, so it is kept out of the buffer that ends up in the bbl state.
+	command.Stdout = io.MultiWriter(stdout, c.outputBuffer)
+	if args[0] == "interpolate" {
+		command.Stdout = stdout
+	}
+	command.Stderr = io.MultiWriter(c.stderr, c.outputBuffer)
 
-	return command.Run()
+	return helpers.RunCommand(command, fmt.Sprintf("bosh %s", args[0]), c.timeout)
 }
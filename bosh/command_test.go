@@ -9,8 +9,10 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -19,8 +21,9 @@ import (
 
 var _ = Describe("Cmd", func() {
 	var (
-		stdout *bytes.Buffer
-		stderr *bytes.Buffer
+		stdout       *bytes.Buffer
+		stderr       *bytes.Buffer
+		outputBuffer *bytes.Buffer
 
 		cmd bosh.Cmd
 
@@ -53,8 +56,9 @@ var _ = Describe("Cmd", func() {
 	BeforeEach(func() {
 		stdout = bytes.NewBuffer([]byte{})
 		stderr = bytes.NewBuffer([]byte{})
+		outputBuffer = bytes.NewBuffer([]byte{})
 
-		cmd = bosh.NewCmd(stderr)
+		cmd = bosh.NewCmd(stderr, outputBuffer, helpers.Timeout{})
 
 		fakeBOSHBackendServer = httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
 			switch request.URL.Path {
@@ -105,6 +109,19 @@ var _ = Describe("Cmd", func() {
 
 			Expect(stdout).To(MatchRegexp(fmt.Sprintf("working directory: (.*)%s", tempDir)))
 			Expect(stdout).To(ContainSubstring("create-env some-arg"))
+			Expect(outputBuffer).To(ContainSubstring("create-env some-arg"))
+		})
+	})
+
+	Context("when bosh interpolates a manifest", func() {
+		It("keeps the interpolated manifest out of the output buffer", func() {
+			os.Setenv("PATH", filepath.Dir(pathToBOSH))
+
+			err := cmd.Run(stdout, tempDir, []string{"interpolate", "some-manifest.yml"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout).To(ContainSubstring("some-interpolated-secret"))
+			Expect(outputBuffer.String()).NotTo(ContainSubstring("some-interpolated-secret"))
 		})
 	})
 
@@ -129,6 +146,16 @@ var _ = Describe("Cmd", func() {
 		})
 	})
 
+	Context("when the run times out", func() {
+		It("returns a timeout error", func() {
+			os.Setenv("PATH", filepath.Dir(pathToBOSH))
+			cmd = bosh.NewCmd(stderr, outputBuffer, helpers.Timeout{Deadline: time.Now().Add(-time.Second)})
+
+			err := cmd.Run(stdout, tempDir, []string{"create-env", "some-arg"})
+			Expect(err).To(MatchError("bosh create-env timed out after 1ns"))
+		})
+	})
+
 	Context("when an error occurs", func() {
 		BeforeEach(func() {
 			setFastFailBOSH(true)
@@ -145,6 +172,7 @@ var _ = Describe("Cmd", func() {
 				err := cmd.Run(stdout, tempDir, []string{"create-env"})
 				Expect(err).To(MatchError("exit status 1"))
 				Expect(stderr.String()).To(ContainSubstring("failed to bosh"))
+				Expect(outputBuffer.String()).To(ContainSubstring("failed to bosh"))
 			})
 		})
 	})
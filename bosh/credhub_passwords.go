@@ -0,0 +1,36 @@
+package bosh
+
+import (
+	yaml "gopkg.in/yaml.v2"
+)
+
+// CredhubPasswordVariables are the vars-store entries holding the UAA admin
+// and credhub passwords. credhub_encryption_password is left out on purpose,
+// regenerating it would make the data already in credhub unreadable.
+var CredhubPasswordVariables = []string{
+	"uaa_admin_client_secret",
+	"credhub_cli_password",
+	"uaa_clients_director_to_credhub",
+}
+
+// WithoutCredhubPasswords drops the UAA admin and credhub passwords from the
+// vars-store so that bosh generates new ones on the next create-env.
+func WithoutCredhubPasswords(variables string) (string, error) {
+	vars := map[interface{}]interface{}{}
+
+	err := yaml.Unmarshal([]byte(variables), &vars)
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range CredhubPasswordVariables {
+		delete(vars, name)
+	}
+
+	contents, err := yaml.Marshal(vars)
+	if err != nil {
+		return "", err
+	}
+
+	return string(contents), nil
+}
@@ -0,0 +1,170 @@
+package bosh
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	BOSHDeploymentRepo    = "cloudfoundry/bosh-deployment"
+	JumpboxDeploymentRepo = "cppforlife/jumpbox-deployment"
+	GitHubURL             = "https://github.com"
+)
+
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsCommitSHA tells whether version is the full sha of a commit, the only
+// version of a deployment that cannot move once it is pinned.
+func IsCommitSHA(version string) bool {
+	return commitSHA.MatchString(version)
+}
+
+type DeploymentFetcher struct {
+	cacheDir   string
+	githubURL  string
+	httpClient *http.Client
+	logger     logger
+}
+
+func NewDeploymentFetcher(cacheDir, githubURL string, httpClient *http.Client, logger logger) DeploymentFetcher {
+	return DeploymentFetcher{
+		cacheDir:   cacheDir,
+		githubURL:  githubURL,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
+// Path returns the checkout of a commit of the github repo in the cache dir,
+// downloading its archive first when it is not there yet. The archive is only
+// kept when the commit id git archive embeds in it is the pinned commit.
+func (f DeploymentFetcher) Path(repo, version string) (string, error) {
+	repoDir := filepath.Join(f.cacheDir, path.Base(repo))
+	versionDir := filepath.Join(repoDir, version)
+
+	_, err := os.Stat(versionDir)
+	if err == nil {
+		return versionDir, nil
+	}
+
+	if !IsCommitSHA(version) {
+		return "", fmt.Errorf("%s %s is not the full sha of a commit, pin it to a commit since tags and branches can move", path.Base(repo), version)
+	}
+
+	f.logger.Step("downloading %s %s to %s", path.Base(repo), version, versionDir)
+
+	url := fmt.Sprintf("%s/%s/archive/%s.tar.gz", f.githubURL, repo, version)
+	response, err := f.httpClient.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %s", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", url, response.Status)
+	}
+
+	err = os.MkdirAll(repoDir, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	// The archive is unpacked next to the version dir and renamed into
+	// place, so an interrupted download never leaves a partial checkout.
+	tempDir, err := ioutil.TempDir(repoDir, version)
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := untarDeployment(response.Body, tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return "", fmt.Errorf("failed to unpack %s: %s", url, err)
+	}
+
+	if commit != version {
+		os.RemoveAll(tempDir)
+		return "", fmt.Errorf("the archive %s is of commit %q instead of %s", url, commit, version)
+	}
+
+	err = os.Rename(tempDir, versionDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return "", err //not tested
+	}
+
+	return versionDir, nil
+}
+
+// untarDeployment writes the files of a github archive to dir, without the
+// <repo>-<version> directory github nests them in, and returns the commit id
+// git archive records in the global header of the archive.
+func untarDeployment(archive io.Reader, dir string) (string, error) {
+	gzipReader, err := gzip.NewReader(archive)
+	if err != nil {
+		return "", err
+	}
+	defer gzipReader.Close()
+
+	var commit string
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return commit, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			commit = header.PAXRecords["comment"]
+			continue
+		}
+
+		parts := strings.SplitN(header.Name, "/", 2)
+		if len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(parts[1]))
+		if !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return "", fmt.Errorf("%s is outside of the archive", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.ModePerm)
+		case tar.TypeReg:
+			err = writeArchiveFile(tarReader, target)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func writeArchiveFile(contents io.Reader, path string) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err //not tested
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err //not tested
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, contents)
+	return err
+}
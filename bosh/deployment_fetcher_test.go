@@ -0,0 +1,169 @@
+package bosh_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeploymentFetcher", func() {
+	var (
+		server   *httptest.Server
+		logger   *fakes.Logger
+		cacheDir string
+		requests []string
+
+		archive []byte
+		commit  string
+
+		fetcher bosh.DeploymentFetcher
+	)
+
+	tarGz := func(commit string, files map[string]string) []byte {
+		buffer := bytes.NewBuffer([]byte{})
+		gzipWriter := gzip.NewWriter(buffer)
+		tarWriter := tar.NewWriter(gzipWriter)
+
+		if commit != "" {
+			Expect(tarWriter.WriteHeader(&tar.Header{
+				Typeflag:   tar.TypeXGlobalHeader,
+				Name:       "pax_global_header",
+				PAXRecords: map[string]string{"comment": commit},
+			})).To(Succeed())
+		}
+
+		for name, contents := range files {
+			header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}
+			if contents == "" {
+				header.Typeflag = tar.TypeDir
+				header.Mode = 0755
+			}
+			Expect(tarWriter.WriteHeader(header)).To(Succeed())
+			_, err := tarWriter.Write([]byte(contents))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(tarWriter.Close()).To(Succeed())
+		Expect(gzipWriter.Close()).To(Succeed())
+		return buffer.Bytes()
+	}
+
+	BeforeEach(func() {
+		commit = "5a2b0ac3f1e4d6c7b8a9f0e1d2c3b4a5f6e7d8c9"
+
+		var err error
+		cacheDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		archive = tarGz(commit, map[string]string{
+			"bosh-deployment-1.2.3/":            "",
+			"bosh-deployment-1.2.3/bosh.yml":    "name: bosh",
+			"bosh-deployment-1.2.3/gcp/":        "",
+			"bosh-deployment-1.2.3/gcp/cpi.yml": "- type: replace",
+		})
+
+		requests = []string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Path)
+			switch r.URL.Path {
+			case "/cloudfoundry/bosh-deployment/archive/" + commit + ".tar.gz":
+				w.Write(archive)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		logger = &fakes.Logger{}
+		fetcher = bosh.NewDeploymentFetcher(cacheDir, server.URL, http.DefaultClient, logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	It("downloads the version into the cache dir without the archive's top directory", func() {
+		path, err := fetcher.Path(bosh.BOSHDeploymentRepo, commit)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(cacheDir, "bosh-deployment", commit)))
+
+		contents, err := ioutil.ReadFile(filepath.Join(path, "bosh.yml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("name: bosh"))
+
+		contents, err = ioutil.ReadFile(filepath.Join(path, "gcp", "cpi.yml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("- type: replace"))
+
+		Expect(logger.StepCall.Messages).To(ContainElement("downloading bosh-deployment " + commit + " to " + path))
+	})
+
+	It("uses the version in the cache dir without downloading it again", func() {
+		_, err := fetcher.Path(bosh.BOSHDeploymentRepo, commit)
+		Expect(err).NotTo(HaveOccurred())
+
+		path, err := fetcher.Path(bosh.BOSHDeploymentRepo, commit)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(cacheDir, "bosh-deployment", commit)))
+
+		Expect(requests).To(HaveLen(1))
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the version does not exist", func() {
+			missing := "0000000000000000000000000000000000000000"
+
+			_, err := fetcher.Path(bosh.BOSHDeploymentRepo, missing)
+			Expect(err).To(MatchError(ContainSubstring("failed to download " + server.URL + "/cloudfoundry/bosh-deployment/archive/" + missing + ".tar.gz: 404 Not Found")))
+			Expect(filepath.Join(cacheDir, "bosh-deployment", missing)).NotTo(BeADirectory())
+		})
+
+		It("returns an error without downloading when the version is not a commit sha", func() {
+			_, err := fetcher.Path(bosh.BOSHDeploymentRepo, "master")
+			Expect(err).To(MatchError("bosh-deployment master is not the full sha of a commit, pin it to a commit since tags and branches can move"))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("returns an error and leaves no checkout when the archive is of another commit", func() {
+			archive = tarGz("1111111111111111111111111111111111111111", map[string]string{
+				"bosh-deployment-1.2.3/bosh.yml": "name: bosh",
+			})
+
+			_, err := fetcher.Path(bosh.BOSHDeploymentRepo, commit)
+			Expect(err).To(MatchError(ContainSubstring(`is of commit "1111111111111111111111111111111111111111" instead of ` + commit)))
+			Expect(filepath.Join(cacheDir, "bosh-deployment", commit)).NotTo(BeADirectory())
+		})
+
+		It("returns an error and leaves no checkout when the archive cannot be unpacked", func() {
+			archive = []byte("not a tarball")
+
+			_, err := fetcher.Path(bosh.BOSHDeploymentRepo, commit)
+			Expect(err).To(MatchError(ContainSubstring("failed to unpack")))
+
+			entries, err := ioutil.ReadDir(filepath.Join(cacheDir, "bosh-deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("returns an error when a file of the archive is outside of it", func() {
+			archive = tarGz(commit, map[string]string{
+				"bosh-deployment-1.2.3/../../escaped.yml": "name: escaped",
+			})
+
+			_, err := fetcher.Path(bosh.BOSHDeploymentRepo, commit)
+			Expect(err).To(MatchError(ContainSubstring("is outside of the archive")))
+			Expect(filepath.Join(cacheDir, "escaped.yml")).NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -18,8 +18,120 @@ const boshDirectorEphemeralIPOps = `
   value: true
 `
 
+const boshDirectorGCPPreemptibleOps = `
+- type: replace
+  path: /resource_pools/name=vms/cloud_properties/preemptible?
+  value: true
+`
+
+const boshDirectorAWSSpotOps = `
+- type: replace
+  path: /resource_pools/name=vms/cloud_properties/spot_bid_price?
+  value: ((director_spot_bid_price))
+- type: replace
+  path: /resource_pools/name=vms/cloud_properties/spot_ondemand_fallback?
+  value: false
+`
+
+// boshDirectorAWSDefaultCredentialsOps lets the cpi that creates the director
+// find its credentials the way bbl did, in the environment, the shared
+// credentials file or the instance role, when no access key was provided.
+const boshDirectorAWSDefaultCredentialsOps = `
+- type: remove
+  path: /cloud_provider/properties/aws/access_key_id
+- type: remove
+  path: /cloud_provider/properties/aws/secret_access_key
+- type: replace
+  path: /cloud_provider/properties/aws/credentials_source?
+  value: env_or_profile
+`
+
+// boshDirectorAWSSessionTokenOps passes the session token of an assumed
+// role's temporary credentials to the cpi that creates the director.
+const boshDirectorAWSSessionTokenOps = `
+- type: replace
+  path: /cloud_provider/properties/aws/session_token?
+  value: ((session_token))
+`
+
+// jumpboxGCPDefaultCredentialsOps lets the cpi that creates the jumpbox find
+// the application default credentials the way bbl did, when no service
+// account key was provided.
+const jumpboxGCPDefaultCredentialsOps = `
+- type: remove
+  path: /cloud_provider/properties/google/json_key
+`
+
+const boshDirectorGCPDiskTypeOps = `
+- type: replace
+  path: /resource_pools/name=vms/cloud_properties/root_disk_type
+  value: ((director_disk_type))
+- type: replace
+  path: /disk_pools/name=disks/cloud_properties/type
+  value: ((director_disk_type))
+`
+
+const boshDirectorAWSDiskTypeOps = `
+- type: replace
+  path: /resource_pools/name=vms/cloud_properties/ephemeral_disk/type
+  value: ((director_disk_type))
+- type: replace
+  path: /disk_pools/name=disks/cloud_properties/type
+  value: ((director_disk_type))
+`
+
+const boshDirectorGCPVMTypeOps = `
+- type: replace
+  path: /resource_pools/name=vms/cloud_properties/machine_type
+  value: ((director_vm_type))
+`
+
+const boshDirectorAWSVMTypeOps = `
+- type: replace
+  path: /resource_pools/name=vms/cloud_properties/instance_type
+  value: ((director_vm_type))
+`
+
+const boshDirectorDiskSizeOps = `
+- type: replace
+  path: /disk_pools/name=disks/disk_size
+  value: ((director_disk_size))
+`
+
+// stemcellOps and stemcellURLOps replace the stemcell the cpi ops files of
+// bosh-deployment and jumpbox-deployment pin, the latter for local tarballs,
+// which create-env does not need a sha1 for.
+const stemcellOps = `
+- type: replace
+  path: /resource_pools/name=vms/stemcell?
+  value:
+    url: ((stemcell_url))
+    sha1: ((stemcell_sha1))
+`
+
+const stemcellURLOps = `
+- type: replace
+  path: /resource_pools/name=vms/stemcell?
+  value:
+    url: ((stemcell_url))
+`
+
+const jumpboxSSHPortOps = `
+- type: replace
+  path: /instance_groups/name=jumpbox/jobs/-
+  value:
+    name: pre-start-script
+    release: os-conf
+    properties:
+      script: |
+        #!/bin/bash
+        sed -i -e '/^Port /d' -e '1i Port ((jumpbox_ssh_port))' /etc/ssh/sshd_config
+        service ssh restart
+`
+
 type Executor struct {
 	command       command
+	stdout        io.Writer
 	tempDir       func(string, string) (string, error)
 	readFile      func(string) ([]byte, error)
 	unmarshalJSON func([]byte, interface{}) error
@@ -33,7 +145,30 @@ type InterpolateInput struct {
 	JumpboxDeploymentVars string
 	BOSHState             map[string]interface{}
 	Variables             string
-	OpsFile               string
+	OpsFiles              []string
+	DirectorSpot          bool
+	DirectorDiskType      string
+	DirectorVMType        string
+	DirectorDiskSize      int
+	DirectorExternalDB    bool
+	JumpboxSSHPort        int
+	AWSDefaultCredentials bool
+	AWSSessionToken       bool
+	GCPDefaultCredentials bool
+	NoPublicIPs           bool
+	DirectorSSH           bool
+	StemcellURL           string
+	StemcellSHA1          string
+
+	// ArtifactsDir holds the release and stemcell tarballs create-env reads
+	// instead of downloading them, when bbl is offline.
+	ArtifactsDir string
+
+	// BOSHDeploymentDir and JumpboxDeploymentDir are checkouts of
+	// bosh-deployment and jumpbox-deployment to interpolate instead of the
+	// copies compiled into bbl.
+	BOSHDeploymentDir    string
+	JumpboxDeploymentDir string
 }
 
 type InterpolateOutput struct {
@@ -50,6 +185,7 @@ type CreateEnvInput struct {
 	Manifest  string
 	Variables string
 	State     map[string]interface{}
+	Recreate  bool
 }
 
 type CreateEnvOutput struct {
@@ -68,11 +204,12 @@ type command interface {
 
 const VERSION_DEV_BUILD = "[DEV BUILD]"
 
-func NewExecutor(cmd command, tempDir func(string, string) (string, error), readFile func(string) ([]byte, error),
+func NewExecutor(cmd command, stdout io.Writer, tempDir func(string, string) (string, error), readFile func(string) ([]byte, error),
 	unmarshalJSON func([]byte, interface{}) error,
 	marshalJSON func(interface{}) ([]byte, error), writeFile func(string, []byte, os.FileMode) error) Executor {
 	return Executor{
 		command:       cmd,
+		stdout:        stdout,
 		tempDir:       tempDir,
 		readFile:      readFile,
 		unmarshalJSON: unmarshalJSON,
@@ -89,8 +226,20 @@ func (e Executor) JumpboxInterpolate(interpolateInput InterpolateInput) (Jumpbox
 
 	var jumpboxSetupFiles = map[string][]byte{
 		"jumpbox-deployment-vars.yml": []byte(interpolateInput.JumpboxDeploymentVars),
-		"jumpbox.yml":                 MustAsset("vendor/github.com/cppforlife/jumpbox-deployment/jumpbox.yml"),
-		"cpi.yml":                     MustAsset(fmt.Sprintf("vendor/github.com/cppforlife/jumpbox-deployment/%s/cpi.yml", interpolateInput.IAAS)),
+		"jumpbox-ssh-port.yml":        []byte(jumpboxSSHPortOps),
+		"gcp-default-credentials.yml": []byte(jumpboxGCPDefaultCredentialsOps),
+		"stemcell.yml":                []byte(stemcellOps),
+		"stemcell-url.yml":            []byte(stemcellURLOps),
+	}
+
+	unreadableFiles, err := e.writeDeploymentFiles(tempDir, "vendor/github.com/cppforlife/jumpbox-deployment", interpolateInput.JumpboxDeploymentDir, map[string]string{
+		"jumpbox.yml":        "jumpbox.yml",
+		"cpi.yml":            fmt.Sprintf("%s/cpi.yml", interpolateInput.IAAS),
+		"no-external-ip.yml": "no-external-ip.yml",
+	})
+	if err != nil {
+		//not tested
+		return JumpboxInterpolateOutput{}, err
 	}
 
 	if interpolateInput.Variables != "" {
@@ -113,6 +262,25 @@ func (e Executor) JumpboxInterpolate(interpolateInput InterpolateInput) (Jumpbox
 		"-o", filepath.Join(tempDir, "cpi.yml"),
 	}
 
+	if interpolateInput.JumpboxSSHPort != 0 {
+		args = append(args, "-o", filepath.Join(tempDir, "jumpbox-ssh-port.yml"))
+	}
+
+	if interpolateInput.NoPublicIPs {
+		args = append(args, "-o", filepath.Join(tempDir, "no-external-ip.yml"))
+	}
+
+	if interpolateInput.IAAS == "gcp" && interpolateInput.GCPDefaultCredentials {
+		args = append(args, "-o", filepath.Join(tempDir, "gcp-default-credentials.yml"))
+	}
+
+	args = append(args, stemcellOpsArgs(interpolateInput, tempDir)...)
+
+	err = unreadableFileError(args, unreadableFiles)
+	if err != nil {
+		return JumpboxInterpolateOutput{}, err
+	}
+
 	buffer := bytes.NewBuffer([]byte{})
 	err = e.command.Run(buffer, tempDir, args)
 	if err != nil {
@@ -124,9 +292,17 @@ func (e Executor) JumpboxInterpolate(interpolateInput InterpolateInput) (Jumpbox
 		return JumpboxInterpolateOutput{}, err
 	}
 
+	manifest := buffer.String()
+	if interpolateInput.ArtifactsDir != "" {
+		manifest, err = useLocalArtifacts(manifest, interpolateInput.ArtifactsDir)
+		if err != nil {
+			return JumpboxInterpolateOutput{}, err
+		}
+	}
+
 	return JumpboxInterpolateOutput{
 		Variables: string(varsStore),
-		Manifest:  buffer.String(),
+		Manifest:  manifest,
 	}, nil
 }
 
@@ -138,23 +314,51 @@ func (e Executor) DirectorInterpolate(interpolateInput InterpolateInput) (Interp
 	}
 
 	var directorSetupFiles = map[string][]byte{
-		"deployment-vars.yml":                 []byte(interpolateInput.DeploymentVars),
-		"user-ops-file.yml":                   []byte(interpolateInput.OpsFile),
-		"bosh.yml":                            MustAsset("vendor/github.com/cloudfoundry/bosh-deployment/bosh.yml"),
-		"cpi.yml":                             MustAsset(fmt.Sprintf("vendor/github.com/cloudfoundry/bosh-deployment/%s/cpi.yml", interpolateInput.IAAS)),
-		"iam-instance-profile.yml":            MustAsset("vendor/github.com/cloudfoundry/bosh-deployment/aws/iam-instance-profile.yml"),
-		"bosh-director-ephemeral-ip-ops.yml":  []byte(boshDirectorEphemeralIPOps),
-		"jumpbox-user.yml":                    MustAsset("vendor/github.com/cloudfoundry/bosh-deployment/jumpbox-user.yml"),
-		"gcp-external-ip-not-recommended.yml": MustAsset("vendor/github.com/cloudfoundry/bosh-deployment/external-ip-not-recommended.yml"),
-		"aws-external-ip-not-recommended.yml": MustAsset("vendor/github.com/cloudfoundry/bosh-deployment/external-ip-with-registry-not-recommended.yml"),
-		"uaa.yml":     MustAsset("vendor/github.com/cloudfoundry/bosh-deployment/uaa.yml"),
-		"credhub.yml": MustAsset("vendor/github.com/cloudfoundry/bosh-deployment/credhub.yml"),
+		"deployment-vars.yml":                []byte(interpolateInput.DeploymentVars),
+		"bosh-director-ephemeral-ip-ops.yml": []byte(boshDirectorEphemeralIPOps),
+		"gcp-director-preemptible.yml":       []byte(boshDirectorGCPPreemptibleOps),
+		"aws-director-spot.yml":              []byte(boshDirectorAWSSpotOps),
+		"aws-default-credentials.yml":        []byte(boshDirectorAWSDefaultCredentialsOps),
+		"aws-session-token.yml":              []byte(boshDirectorAWSSessionTokenOps),
+		"gcp-director-disk-type.yml":         []byte(boshDirectorGCPDiskTypeOps),
+		"aws-director-disk-type.yml":         []byte(boshDirectorAWSDiskTypeOps),
+		"gcp-director-vm-type.yml":           []byte(boshDirectorGCPVMTypeOps),
+		"aws-director-vm-type.yml":           []byte(boshDirectorAWSVMTypeOps),
+		"director-disk-size.yml":             []byte(boshDirectorDiskSizeOps),
+		"stemcell.yml":                       []byte(stemcellOps),
+		"stemcell-url.yml":                   []byte(stemcellURLOps),
+	}
+
+	unreadableFiles, err := e.writeDeploymentFiles(tempDir, "vendor/github.com/cloudfoundry/bosh-deployment", interpolateInput.BOSHDeploymentDir, map[string]string{
+		"bosh.yml":                                  "bosh.yml",
+		"cpi.yml":                                   fmt.Sprintf("%s/cpi.yml", interpolateInput.IAAS),
+		"iam-instance-profile.yml":                  "aws/iam-instance-profile.yml",
+		"gcp-service-account.yml":                   "gcp/service-account.yml",
+		"external-db.yml":                           "misc/external-db.yml",
+		"jumpbox-user.yml":                          "jumpbox-user.yml",
+		"gcp-external-ip-not-recommended.yml":       "external-ip-not-recommended.yml",
+		"aws-external-ip-not-recommended.yml":       "external-ip-with-registry-not-recommended.yml",
+		"azure-external-ip-not-recommended.yml":     "external-ip-not-recommended.yml",
+		"openstack-external-ip-not-recommended.yml": "external-ip-with-registry-not-recommended.yml",
+		"uaa.yml":     "uaa.yml",
+		"credhub.yml": "credhub.yml",
+	})
+	if err != nil {
+		//not tested
+		return InterpolateOutput{}, err
 	}
 
 	if interpolateInput.Variables != "" {
 		directorSetupFiles["variables.yml"] = []byte(interpolateInput.Variables)
 	}
 
+	var userOpsFileArgs []string
+	for i, opsFile := range interpolateInput.OpsFiles {
+		name := fmt.Sprintf("user-ops-file-%d.yml", i)
+		directorSetupFiles[name] = []byte(opsFile)
+		userOpsFileArgs = append(userOpsFileArgs, "-o", filepath.Join(tempDir, name))
+	}
+
 	for path, contents := range directorSetupFiles {
 		err = e.writeFile(filepath.Join(tempDir, path), contents, os.ModePerm)
 		if err != nil {
@@ -172,32 +376,88 @@ func (e Executor) DirectorInterpolate(interpolateInput InterpolateInput) (Interp
 		"-o", filepath.Join(tempDir, "cpi.yml"),
 	}
 
-	if interpolateInput.JumpboxDeploymentVars == "" {
-		args = append(args,
-			"-o", filepath.Join(tempDir, "jumpbox-user.yml"),
-		)
+	// behind a jumpbox the director only gets an ssh user for bbl ssh
+	// --director when it was asked for.
+	if interpolateInput.JumpboxDeploymentVars == "" || interpolateInput.DirectorSSH {
+		args = append(args, "-o", filepath.Join(tempDir, "jumpbox-user.yml"))
+	}
 
+	if interpolateInput.JumpboxDeploymentVars == "" {
 		switch interpolateInput.IAAS {
 		case "aws":
 			args = append(args, "-o", filepath.Join(tempDir, "aws-external-ip-not-recommended.yml"), "-o", filepath.Join(tempDir, "iam-instance-profile.yml"))
 		case "gcp":
-			args = append(args, "-o", filepath.Join(tempDir, "gcp-external-ip-not-recommended.yml"))
+			// without an external address bosh.yml creates the director
+			// at its internal ip.
+			if !interpolateInput.NoPublicIPs {
+				args = append(args, "-o", filepath.Join(tempDir, "gcp-external-ip-not-recommended.yml"))
+			}
+		case "azure":
+			args = append(args, "-o", filepath.Join(tempDir, "azure-external-ip-not-recommended.yml"))
+		case "openstack":
+			args = append(args, "-o", filepath.Join(tempDir, "openstack-external-ip-not-recommended.yml"))
 		}
 	} else {
+		if !interpolateInput.NoPublicIPs {
+			args = append(args, "-o", filepath.Join(tempDir, "bosh-director-ephemeral-ip-ops.yml"))
+		}
 		args = append(args,
-			"-o", filepath.Join(tempDir, "bosh-director-ephemeral-ip-ops.yml"),
 			"-o", filepath.Join(tempDir, "uaa.yml"),
 			"-o", filepath.Join(tempDir, "credhub.yml"),
 		)
 	}
 
+	if interpolateInput.IAAS == "aws" && interpolateInput.AWSDefaultCredentials {
+		args = append(args, "-o", filepath.Join(tempDir, "aws-default-credentials.yml"))
+	}
+
+	if interpolateInput.IAAS == "aws" && interpolateInput.AWSSessionToken {
+		args = append(args, "-o", filepath.Join(tempDir, "aws-session-token.yml"))
+	}
+
+	if interpolateInput.IAAS == "gcp" && interpolateInput.GCPDefaultCredentials {
+		args = append(args, "-o", filepath.Join(tempDir, "gcp-service-account.yml"))
+	}
+
+	if interpolateInput.DirectorSpot {
+		switch interpolateInput.IAAS {
+		case "aws":
+			args = append(args, "-o", filepath.Join(tempDir, "aws-director-spot.yml"))
+		case "gcp":
+			args = append(args, "-o", filepath.Join(tempDir, "gcp-director-preemptible.yml"))
+		}
+	}
+
+	if interpolateInput.DirectorDiskType != "" {
+		args = append(args, "-o", filepath.Join(tempDir, fmt.Sprintf("%s-director-disk-type.yml", interpolateInput.IAAS)))
+	}
+
+	if interpolateInput.DirectorVMType != "" {
+		args = append(args, "-o", filepath.Join(tempDir, fmt.Sprintf("%s-director-vm-type.yml", interpolateInput.IAAS)))
+	}
+
+	if interpolateInput.DirectorDiskSize != 0 {
+		args = append(args, "-o", filepath.Join(tempDir, "director-disk-size.yml"))
+	}
+
+	if interpolateInput.DirectorExternalDB {
+		args = append(args, "-o", filepath.Join(tempDir, "external-db.yml"))
+	}
+
+	args = append(args, stemcellOpsArgs(interpolateInput, tempDir)...)
+
+	err = unreadableFileError(args, unreadableFiles)
+	if err != nil {
+		return InterpolateOutput{}, err
+	}
+
 	buffer := bytes.NewBuffer([]byte{})
 	err = e.command.Run(buffer, tempDir, args)
 	if err != nil {
 		return InterpolateOutput{}, err
 	}
 
-	if interpolateInput.OpsFile != "" {
+	if len(userOpsFileArgs) > 0 {
 		err = e.writeFile(filepath.Join(tempDir, "bosh.yml"), buffer.Bytes(), os.ModePerm)
 		if err != nil {
 			//not tested
@@ -209,8 +469,8 @@ func (e Executor) DirectorInterpolate(interpolateInput InterpolateInput) (Interp
 			"--var-errs",
 			"--vars-store", filepath.Join(tempDir, "variables.yml"),
 			"--vars-file", filepath.Join(tempDir, "deployment-vars.yml"),
-			"-o", filepath.Join(tempDir, "user-ops-file.yml"),
 		}
+		args = append(args, userOpsFileArgs...)
 
 		buffer = bytes.NewBuffer([]byte{})
 		err = e.command.Run(buffer, tempDir, args)
@@ -224,12 +484,71 @@ func (e Executor) DirectorInterpolate(interpolateInput InterpolateInput) (Interp
 		return InterpolateOutput{}, err
 	}
 
+	manifest := buffer.String()
+	if interpolateInput.ArtifactsDir != "" {
+		manifest, err = useLocalArtifacts(manifest, interpolateInput.ArtifactsDir)
+		if err != nil {
+			return InterpolateOutput{}, err
+		}
+	}
+
 	return InterpolateOutput{
 		Variables: string(varsStore),
-		Manifest:  buffer.String(),
+		Manifest:  manifest,
 	}, nil
 }
 
+func stemcellOpsArgs(interpolateInput InterpolateInput, tempDir string) []string {
+	switch {
+	case interpolateInput.StemcellURL == "":
+		return nil
+	case interpolateInput.StemcellSHA1 == "":
+		return []string{"-o", filepath.Join(tempDir, "stemcell-url.yml")}
+	default:
+		return []string{"-o", filepath.Join(tempDir, "stemcell.yml")}
+	}
+}
+
+// writeDeploymentFiles writes the files of bosh-deployment or
+// jumpbox-deployment to the temp dir, from the checkout in dir or else from
+// the copy compiled into bbl under assetDir. Checkouts of other versions can
+// lack ops files bbl knows about, so a file that cannot be read is returned
+// instead of failing, and only fails the interpolation that uses it.
+func (e Executor) writeDeploymentFiles(tempDir, assetDir, dir string, files map[string]string) (map[string]error, error) {
+	unreadableFiles := map[string]error{}
+
+	for name, path := range files {
+		var contents []byte
+		if dir == "" {
+			contents = MustAsset(fmt.Sprintf("%s/%s", assetDir, path))
+		} else {
+			var err error
+			contents, err = e.readFile(filepath.Join(dir, filepath.FromSlash(path)))
+			if err != nil {
+				unreadableFiles[filepath.Join(tempDir, name)] = fmt.Errorf("failed to read %s of the deployment checkout:\n%s", path, err)
+				continue
+			}
+		}
+
+		err := e.writeFile(filepath.Join(tempDir, name), contents, os.ModePerm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return unreadableFiles, nil
+}
+
+func unreadableFileError(args []string, unreadableFiles map[string]error) error {
+	for _, arg := range args {
+		if err, ok := unreadableFiles[arg]; ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (e Executor) CreateEnv(createEnvInput CreateEnvInput) (CreateEnvOutput, error) {
 	tempDir, err := e.writePreviousFiles(createEnvInput.State, createEnvInput.Variables, createEnvInput.Manifest)
 	if err != nil {
@@ -246,7 +565,11 @@ func (e Executor) CreateEnv(createEnvInput CreateEnvInput) (CreateEnvOutput, err
 		"--state", statePath,
 	}
 
-	err = e.command.Run(os.Stdout, tempDir, args)
+	if createEnvInput.Recreate {
+		args = append(args, "--recreate")
+	}
+
+	err = e.command.Run(e.stdout, tempDir, args)
 	if err != nil {
 		state, readErr := e.readBOSHState(statePath)
 		if readErr != nil {
@@ -300,7 +623,7 @@ func (e Executor) DeleteEnv(deleteEnvInput DeleteEnvInput) error {
 		"--state", statePath,
 	}
 
-	err = e.command.Run(os.Stdout, tempDir, args)
+	err = e.command.Run(e.stdout, tempDir, args)
 	if err != nil {
 		state, readErr := e.readBOSHState(statePath)
 		if readErr != nil {
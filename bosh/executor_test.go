@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
@@ -55,13 +56,13 @@ var _ = Describe("Executor", func() {
 					"key": "value",
 				},
 				Variables: variablesYMLContents,
-				OpsFile:   "some-ops-file",
+				OpsFiles:  []string{"some-ops-file"},
 			}
 
 			gcpInterpolateInput = awsInterpolateInput
 			gcpInterpolateInput.IAAS = "gcp"
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 		})
 
 		AfterEach(func() {
@@ -100,7 +101,7 @@ var _ = Describe("Executor", func() {
 					"--var-errs",
 					"--vars-store", fmt.Sprintf("%s/variables.yml", tempDir),
 					"--vars-file", fmt.Sprintf("%s/deployment-vars.yml", tempDir),
-					"-o", fmt.Sprintf("%s/user-ops-file.yml", tempDir)})
+					"-o", fmt.Sprintf("%s/user-ops-file-0.yml", tempDir)})
 
 				_, _, args = cmd.RunArgsForCall(1)
 				Expect(args).To(Equal(expectedArgs))
@@ -110,6 +111,311 @@ var _ = Describe("Executor", func() {
 			})
 		})
 
+		Context("when aws uses the default credential chain", func() {
+			It("interpolates the default credentials ops file after the instance profile", func() {
+				awsInterpolateInput.AWSDefaultCredentials = true
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args[len(args)-4:]).To(Equal([]string{
+					"-o", fmt.Sprintf("%s/iam-instance-profile.yml", tempDir),
+					"-o", fmt.Sprintf("%s/aws-default-credentials.yml", tempDir),
+				}))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/aws-default-credentials.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/cloud_provider/properties/aws/credentials_source?"))
+				Expect(string(opsFile)).To(ContainSubstring("env_or_profile"))
+			})
+		})
+
+		Context("when gcp uses the application default credentials", func() {
+			It("interpolates the service account ops file", func() {
+				gcpInterpolateInput.GCPDefaultCredentials = true
+
+				_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(ContainElement(fmt.Sprintf("%s/gcp-service-account.yml", tempDir)))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/gcp-service-account.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("((service_account))"))
+			})
+		})
+
+		Context("when aws uses the temporary credentials of an assumed role", func() {
+			It("interpolates the session token ops file", func() {
+				awsInterpolateInput.AWSSessionToken = true
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(ContainElement(fmt.Sprintf("%s/aws-session-token.yml", tempDir)))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/aws-session-token.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("((session_token))"))
+			})
+		})
+
+		Context("when the aws director is a spot instance", func() {
+			It("interpolates the spot ops file", func() {
+				awsInterpolateInput.DirectorSpot = true
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(ContainElement(fmt.Sprintf("%s/aws-director-spot.yml", tempDir)))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/aws-director-spot.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("((director_spot_bid_price))"))
+			})
+		})
+
+		Context("when the aws director has a disk type", func() {
+			It("interpolates the disk type ops file", func() {
+				awsInterpolateInput.DirectorDiskType = "gp3"
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(ContainElement(fmt.Sprintf("%s/aws-director-disk-type.yml", tempDir)))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/aws-director-disk-type.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/resource_pools/name=vms/cloud_properties/ephemeral_disk/type"))
+				Expect(string(opsFile)).To(ContainSubstring("((director_disk_type))"))
+			})
+		})
+
+		Context("when the aws director has a vm type and disk size", func() {
+			It("interpolates the vm type and disk size ops files", func() {
+				awsInterpolateInput.DirectorVMType = "m4.2xlarge"
+				awsInterpolateInput.DirectorDiskSize = 128
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(ContainElement(fmt.Sprintf("%s/aws-director-vm-type.yml", tempDir)))
+				Expect(args).To(ContainElement(fmt.Sprintf("%s/director-disk-size.yml", tempDir)))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/aws-director-vm-type.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/resource_pools/name=vms/cloud_properties/instance_type"))
+				Expect(string(opsFile)).To(ContainSubstring("((director_vm_type))"))
+
+				opsFile, err = ioutil.ReadFile(fmt.Sprintf("%s/director-disk-size.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/disk_pools/name=disks/disk_size"))
+				Expect(string(opsFile)).To(ContainSubstring("((director_disk_size))"))
+			})
+		})
+
+		Context("when the director has an external database", func() {
+			It("interpolates the external db ops file", func() {
+				awsInterpolateInput.DirectorExternalDB = true
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(ContainElement(fmt.Sprintf("%s/external-db.yml", tempDir)))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/external-db.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/instance_groups/name=bosh/jobs/name=postgres-9.4"))
+				Expect(string(opsFile)).To(ContainSubstring("((external_db_host))"))
+			})
+		})
+
+		Context("when the director has a custom stemcell", func() {
+			It("interpolates the stemcell ops file after the cpi ops file", func() {
+				awsInterpolateInput.StemcellURL = "https://bosh.io/d/stemcells/some-stemcell?v=1.2"
+				awsInterpolateInput.StemcellSHA1 = "some-sha1"
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args[len(args)-2:]).To(Equal([]string{"-o", fmt.Sprintf("%s/stemcell.yml", tempDir)}))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/stemcell.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/resource_pools/name=vms/stemcell?"))
+				Expect(string(opsFile)).To(ContainSubstring("((stemcell_sha1))"))
+			})
+
+			It("interpolates the stemcell ops file without a sha1 for a local tarball", func() {
+				awsInterpolateInput.StemcellURL = "file:///some/stemcell.tgz"
+
+				_, err := executor.DirectorInterpolate(awsInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args[len(args)-2:]).To(Equal([]string{"-o", fmt.Sprintf("%s/stemcell-url.yml", tempDir)}))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/stemcell-url.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("((stemcell_url))"))
+				Expect(string(opsFile)).NotTo(ContainSubstring("sha1"))
+			})
+
+			It("interpolates the jumpbox with the stemcell ops file", func() {
+				gcpInterpolateInput.JumpboxDeploymentVars = "internal_cidr: 10.0.0.0/24"
+				gcpInterpolateInput.StemcellURL = "https://bosh.io/d/stemcells/some-stemcell?v=1.2"
+				gcpInterpolateInput.StemcellSHA1 = "some-sha1"
+
+				_, err := executor.JumpboxInterpolate(gcpInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args[len(args)-2:]).To(Equal([]string{"-o", fmt.Sprintf("%s/stemcell.yml", tempDir)}))
+			})
+		})
+
+		Context("when bbl is offline", func() {
+			var artifactsDir string
+
+			BeforeEach(func() {
+				var err error
+				artifactsDir, err = ioutil.TempDir("", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.MkdirAll(filepath.Join(artifactsDir, "releases"), os.ModePerm)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(artifactsDir, "stemcells"), os.ModePerm)).To(Succeed())
+
+				cmd.RunStub = func(stdout io.Writer, workingDirectory string, args []string) error {
+					stdout.Write([]byte(`name: bosh
+releases:
+- name: bosh
+  version: "262.3"
+  url: https://s3.amazonaws.com/bosh-compiled-release-tarballs/bosh-262.3-ubuntu-trusty-3421.9.tgz?versionId=some-version
+  sha1: some-bosh-sha1
+- name: bosh-google-cpi
+  version: 25.9.0
+  url: https://bosh.io/d/github.com/cloudfoundry-incubator/bosh-google-cpi-release?v=25.9.0
+  sha1: some-cpi-sha1
+- name: local-release
+  url: file:///some/local-release.tgz
+resource_pools:
+- name: vms
+  stemcell:
+    url: https://bosh.io/d/stemcells/bosh-google-kvm-ubuntu-trusty-go_agent?v=3421.9
+    sha1: some-stemcell-sha1
+`))
+					return nil
+				}
+
+				gcpInterpolateInput.ArtifactsDir = artifactsDir
+				gcpInterpolateInput.OpsFiles = nil
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(artifactsDir)
+			})
+
+			It("points the releases and stemcell at the tarballs in the artifacts dir", func() {
+				for _, name := range []string{
+					"releases/bosh-262.3-ubuntu-trusty-3421.9.tgz",
+					"releases/bosh-google-cpi-release-25.9.0.tgz",
+					"stemcells/bosh-google-kvm-ubuntu-trusty-go_agent-3421.9.tgz",
+				} {
+					Expect(ioutil.WriteFile(filepath.Join(artifactsDir, name), []byte("some-tarball"), os.ModePerm)).To(Succeed())
+				}
+
+				interpolateOutput, err := executor.DirectorInterpolate(gcpInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(interpolateOutput.Manifest).To(gomegamatchers.MatchYAML(fmt.Sprintf(`name: bosh
+releases:
+- name: bosh
+  version: "262.3"
+  url: file://%[1]s/releases/bosh-262.3-ubuntu-trusty-3421.9.tgz
+  sha1: some-bosh-sha1
+- name: bosh-google-cpi
+  version: 25.9.0
+  url: file://%[1]s/releases/bosh-google-cpi-release-25.9.0.tgz
+  sha1: some-cpi-sha1
+- name: local-release
+  url: file:///some/local-release.tgz
+resource_pools:
+- name: vms
+  stemcell:
+    url: file://%[1]s/stemcells/bosh-google-kvm-ubuntu-trusty-go_agent-3421.9.tgz
+    sha1: some-stemcell-sha1
+`, artifactsDir)))
+			})
+
+			It("returns an error listing the tarballs missing from the artifacts dir", func() {
+				Expect(ioutil.WriteFile(filepath.Join(artifactsDir, "releases", "bosh-262.3-ubuntu-trusty-3421.9.tgz"), []byte("some-tarball"), os.ModePerm)).To(Succeed())
+
+				gcpInterpolateInput.JumpboxDeploymentVars = "internal_cidr: 10.0.0.0/24"
+				_, err := executor.JumpboxInterpolate(gcpInterpolateInput)
+				Expect(err).To(MatchError(fmt.Sprintf(`bbl is offline and the artifacts dir is missing:
+  download https://bosh.io/d/github.com/cloudfoundry-incubator/bosh-google-cpi-release?v=25.9.0 to %[1]s/releases/bosh-google-cpi-release-25.9.0.tgz
+  download https://bosh.io/d/stemcells/bosh-google-kvm-ubuntu-trusty-go_agent?v=3421.9 to %[1]s/stemcells/bosh-google-kvm-ubuntu-trusty-go_agent-3421.9.tgz`, artifactsDir)))
+			})
+		})
+
+		Context("azure", func() {
+			It("generates a bosh manifest with an external ip", func() {
+				azureInterpolateInput := awsInterpolateInput
+				azureInterpolateInput.IAAS = "azure"
+				azureInterpolateInput.OpsFiles = nil
+
+				_, err := executor.DirectorInterpolate(azureInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cmd.RunCallCount()).To(Equal(1))
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(Equal([]string{
+					"interpolate", fmt.Sprintf("%s/bosh.yml", tempDir),
+					"--var-errs",
+					"--var-errs-unused",
+					"--vars-store", fmt.Sprintf("%s/variables.yml", tempDir),
+					"--vars-file", fmt.Sprintf("%s/deployment-vars.yml", tempDir),
+					"-o", fmt.Sprintf("%s/cpi.yml", tempDir),
+					"-o", fmt.Sprintf("%s/jumpbox-user.yml", tempDir),
+					"-o", fmt.Sprintf("%s/azure-external-ip-not-recommended.yml", tempDir),
+				}))
+			})
+		})
+
+		Context("openstack", func() {
+			It("generates a bosh manifest with an external ip and a registry", func() {
+				openstackInterpolateInput := awsInterpolateInput
+				openstackInterpolateInput.IAAS = "openstack"
+				openstackInterpolateInput.OpsFiles = nil
+
+				_, err := executor.DirectorInterpolate(openstackInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cmd.RunCallCount()).To(Equal(1))
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(Equal([]string{
+					"interpolate", fmt.Sprintf("%s/bosh.yml", tempDir),
+					"--var-errs",
+					"--var-errs-unused",
+					"--vars-store", fmt.Sprintf("%s/variables.yml", tempDir),
+					"--vars-file", fmt.Sprintf("%s/deployment-vars.yml", tempDir),
+					"-o", fmt.Sprintf("%s/cpi.yml", tempDir),
+					"-o", fmt.Sprintf("%s/jumpbox-user.yml", tempDir),
+					"-o", fmt.Sprintf("%s/openstack-external-ip-not-recommended.yml", tempDir),
+				}))
+			})
+		})
+
 		Context("gcp", func() {
 			It("generates a bosh manifest", func() {
 				cmd.RunStub = func(stdout io.Writer, workingDirectory string, args []string) error {
@@ -141,7 +447,7 @@ var _ = Describe("Executor", func() {
 					"--var-errs",
 					"--vars-store", fmt.Sprintf("%s/variables.yml", tempDir),
 					"--vars-file", fmt.Sprintf("%s/deployment-vars.yml", tempDir),
-					"-o", fmt.Sprintf("%s/user-ops-file.yml", tempDir)})
+					"-o", fmt.Sprintf("%s/user-ops-file-0.yml", tempDir)})
 
 				_, _, args = cmd.RunArgsForCall(1)
 				Expect(args).To(Equal(expectedArgs))
@@ -150,10 +456,73 @@ var _ = Describe("Executor", func() {
 				Expect(interpolateOutput.Variables).To(gomegamatchers.MatchYAML(variablesYMLContents))
 			})
 
+			Context("when there are no public ips", func() {
+				It("creates the director at its internal ip", func() {
+					gcpInterpolateInput.NoPublicIPs = true
+
+					_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).NotTo(ContainElement(fmt.Sprintf("%s/gcp-external-ip-not-recommended.yml", tempDir)))
+				})
+			})
+
+			Context("when the director is preemptible", func() {
+				It("interpolates the preemptible ops file", func() {
+					gcpInterpolateInput.DirectorSpot = true
+
+					_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/gcp-director-preemptible.yml", tempDir)))
+
+					opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/gcp-director-preemptible.yml", tempDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/resource_pools/name=vms/cloud_properties/preemptible?"))
+				})
+			})
+
+			Context("when the director has a disk type", func() {
+				It("interpolates the disk type ops file", func() {
+					gcpInterpolateInput.DirectorDiskType = "pd-ssd"
+
+					_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/gcp-director-disk-type.yml", tempDir)))
+					Expect(args).NotTo(ContainElement(fmt.Sprintf("%s/aws-director-disk-type.yml", tempDir)))
+
+					opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/gcp-director-disk-type.yml", tempDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/resource_pools/name=vms/cloud_properties/root_disk_type"))
+					Expect(string(opsFile)).To(ContainSubstring("/disk_pools/name=disks/cloud_properties/type"))
+				})
+			})
+
+			Context("when the director has a vm type", func() {
+				It("interpolates the machine type ops file", func() {
+					gcpInterpolateInput.DirectorVMType = "n1-standard-8"
+
+					_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/gcp-director-vm-type.yml", tempDir)))
+					Expect(args).NotTo(ContainElement(fmt.Sprintf("%s/director-disk-size.yml", tempDir)))
+
+					opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/gcp-director-vm-type.yml", tempDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/resource_pools/name=vms/cloud_properties/machine_type"))
+				})
+			})
+
 			Context("when there are jumpbox deployment vars", func() {
 				It("interpolates the jumpbox and bosh manifests", func() {
 					gcpInterpolateInput.JumpboxDeploymentVars = "internal_cidr: 10.0.0.0/24"
-					gcpInterpolateInput.OpsFile = ""
+					gcpInterpolateInput.OpsFiles = nil
 
 					cmd.RunStub = func(stdout io.Writer, workingDirectory string, args []string) error {
 						stdout.Write([]byte("some-manifest"))
@@ -204,11 +573,162 @@ var _ = Describe("Executor", func() {
 					Expect(interpolateOutput.Manifest).To(Equal("some-manifest"))
 					Expect(jumpboxInterpolateOutput.Variables).To(gomegamatchers.MatchYAML("key: value"))
 				})
+
+				It("adds the jumpbox user to the director when ssh to the director is enabled", func() {
+					gcpInterpolateInput.JumpboxDeploymentVars = "internal_cidr: 10.0.0.0/24"
+					gcpInterpolateInput.OpsFiles = nil
+					gcpInterpolateInput.DirectorSSH = true
+
+					_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/jumpbox-user.yml", tempDir)))
+				})
+
+				It("interpolates the default credentials ops file when there is no service account key", func() {
+					gcpInterpolateInput.JumpboxDeploymentVars = "internal_cidr: 10.0.0.0/24"
+					gcpInterpolateInput.GCPDefaultCredentials = true
+
+					_, err := executor.JumpboxInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/gcp-default-credentials.yml", tempDir)))
+
+					opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/gcp-default-credentials.yml", tempDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/cloud_provider/properties/google/json_key"))
+				})
+
+				It("interpolates the ssh port ops file when the jumpbox has an ssh port", func() {
+					gcpInterpolateInput.JumpboxDeploymentVars = "internal_cidr: 10.0.0.0/24"
+					gcpInterpolateInput.JumpboxSSHPort = 2222
+
+					_, err := executor.JumpboxInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/jumpbox-ssh-port.yml", tempDir)))
+
+					opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/jumpbox-ssh-port.yml", tempDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/instance_groups/name=jumpbox/jobs/-"))
+					Expect(string(opsFile)).To(ContainSubstring("Port ((jumpbox_ssh_port))"))
+				})
+
+				It("keeps the jumpbox and director off the internet when there are no public ips", func() {
+					gcpInterpolateInput.JumpboxDeploymentVars = "internal_cidr: 10.0.0.0/24"
+					gcpInterpolateInput.NoPublicIPs = true
+
+					_, err := executor.JumpboxInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args := cmd.RunArgsForCall(0)
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/no-external-ip.yml", tempDir)))
+
+					opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/no-external-ip.yml", tempDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(opsFile)).To(ContainSubstring("/instance_groups/name=jumpbox/networks/name=public"))
+
+					_, err = executor.DirectorInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, args = cmd.RunArgsForCall(1)
+					Expect(args).NotTo(ContainElement(fmt.Sprintf("%s/bosh-director-ephemeral-ip-ops.yml", tempDir)))
+					Expect(args).To(ContainElement(fmt.Sprintf("%s/credhub.yml", tempDir)))
+				})
+			})
+		})
+
+		Context("when a bosh-deployment checkout is given", func() {
+			var checkoutDir string
+
+			BeforeEach(func() {
+				var err error
+				checkoutDir, err = ioutil.TempDir("", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				for path, contents := range map[string]string{
+					"bosh.yml":                        "name: checkout-bosh",
+					"gcp/cpi.yml":                     "- type: replace\n  path: /checkout-cpi",
+					"jumpbox-user.yml":                "- type: replace\n  path: /checkout-jumpbox-user",
+					"external-ip-not-recommended.yml": "- type: replace\n  path: /checkout-external-ip",
+					"gcp/service-account.yml":         "- type: replace\n  path: /checkout-service-account",
+				} {
+					Expect(os.MkdirAll(filepath.Dir(filepath.Join(checkoutDir, path)), os.ModePerm)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(checkoutDir, path), []byte(contents), os.ModePerm)).To(Succeed())
+				}
+
+				gcpInterpolateInput.BOSHDeploymentDir = checkoutDir
+				gcpInterpolateInput.OpsFiles = nil
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(checkoutDir)
+			})
+
+			It("interpolates the manifest and ops files of the checkout", func() {
+				_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+
+				manifest, err := ioutil.ReadFile(fmt.Sprintf("%s/bosh.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(manifest)).To(Equal("name: checkout-bosh"))
+
+				opsFile, err := ioutil.ReadFile(fmt.Sprintf("%s/cpi.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/checkout-cpi"))
+
+				opsFile, err = ioutil.ReadFile(fmt.Sprintf("%s/jumpbox-user.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(ContainSubstring("/checkout-jumpbox-user"))
+			})
+
+			It("does not need the ops files of the checkout it does not use", func() {
+				Expect(filepath.Join(checkoutDir, "uaa.yml")).NotTo(BeAnExistingFile())
+
+				_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the checkout lacks an ops file it uses", func() {
+				gcpInterpolateInput.DirectorExternalDB = true
+
+				_, err := executor.DirectorInterpolate(gcpInterpolateInput)
+				Expect(err).To(MatchError(ContainSubstring("failed to read misc/external-db.yml of the deployment checkout:")))
+				Expect(cmd.RunCallCount()).To(Equal(0))
+			})
+
+			Context("when a jumpbox-deployment checkout is given", func() {
+				BeforeEach(func() {
+					Expect(os.MkdirAll(filepath.Join(checkoutDir, "jumpbox", "gcp"), os.ModePerm)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(checkoutDir, "jumpbox", "jumpbox.yml"), []byte("name: checkout-jumpbox"), os.ModePerm)).To(Succeed())
+
+					gcpInterpolateInput.JumpboxDeploymentDir = filepath.Join(checkoutDir, "jumpbox")
+				})
+
+				It("interpolates the manifest of the checkout", func() {
+					Expect(ioutil.WriteFile(filepath.Join(checkoutDir, "jumpbox", "gcp", "cpi.yml"), []byte("- type: replace"), os.ModePerm)).To(Succeed())
+
+					_, err := executor.JumpboxInterpolate(gcpInterpolateInput)
+					Expect(err).NotTo(HaveOccurred())
+
+					manifest, err := ioutil.ReadFile(fmt.Sprintf("%s/jumpbox.yml", tempDir))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(manifest)).To(Equal("name: checkout-jumpbox"))
+				})
+
+				It("returns an error when the checkout lacks the cpi ops file", func() {
+					_, err := executor.JumpboxInterpolate(gcpInterpolateInput)
+					Expect(err).To(MatchError(ContainSubstring("failed to read gcp/cpi.yml of the deployment checkout:")))
+					Expect(cmd.RunCallCount()).To(Equal(0))
+				})
 			})
 		})
 
-		Context("when a user opsfile is provided", func() {
-			It("re-interpolates the bosh manifest", func() {
+		Context("when user opsfiles are provided", func() {
+			It("re-interpolates the bosh manifest with each of them in order", func() {
 				interpolateInput := bosh.InterpolateInput{
 					IAAS: "gcp",
 					DeploymentVars: `internal_cidr: 10.0.0.0/24
@@ -217,12 +737,12 @@ var _ = Describe("Executor", func() {
 						"key": "value",
 					},
 					Variables: variablesYMLContents,
-					OpsFile: `
+					OpsFiles: []string{`
 ---
 - type: replace
 path: /networks/name=default/subnets/0/cloud_properties/tags/-
 value: sabeti-bosh-isolation
-		`,
+		`, "some-other-ops-file"},
 				}
 
 				manifest := `
@@ -252,7 +772,7 @@ networks
 				writtenManifest := []byte{}
 				cmd.RunStub = func(stdout io.Writer, workingDirectory string, args []string) error {
 					for _, arg := range args {
-						if arg == fmt.Sprintf("%s/user-ops-file.yml", tempDir) {
+						if arg == fmt.Sprintf("%s/user-ops-file-0.yml", tempDir) {
 							var err error
 							writtenManifest, err = ioutil.ReadFile(fmt.Sprintf("%s/bosh.yml", tempDir))
 							if err != nil {
@@ -290,14 +810,19 @@ networks
 					"--var-errs",
 					"--vars-store", fmt.Sprintf("%s/variables.yml", tempDir),
 					"--vars-file", fmt.Sprintf("%s/deployment-vars.yml", tempDir),
-					"-o", fmt.Sprintf("%s/user-ops-file.yml", tempDir)})
+					"-o", fmt.Sprintf("%s/user-ops-file-0.yml", tempDir),
+					"-o", fmt.Sprintf("%s/user-ops-file-1.yml", tempDir)})
 
 				_, _, args = cmd.RunArgsForCall(1)
 				Expect(args).To(Equal(expectedArgsWithUserOpsfile))
 
-				opsFileContents, err := ioutil.ReadFile(fmt.Sprintf("%s/user-ops-file.yml", tempDir))
+				opsFileContents, err := ioutil.ReadFile(fmt.Sprintf("%s/user-ops-file-0.yml", tempDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFileContents)).To(Equal(interpolateInput.OpsFiles[0]))
+
+				opsFileContents, err = ioutil.ReadFile(fmt.Sprintf("%s/user-ops-file-1.yml", tempDir))
 				Expect(err).NotTo(HaveOccurred())
-				Expect(string(opsFileContents)).To(Equal(interpolateInput.OpsFile))
+				Expect(string(opsFileContents)).To(Equal("some-other-ops-file"))
 				Expect(string(writtenManifest)).To(Equal(manifest))
 
 				Expect(interpolateOutput.Manifest).To(Equal(manifestWithUserOpsFile))
@@ -306,7 +831,7 @@ networks
 		})
 
 		It("does not pass in false to run command on interpolate", func() {
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 			_, err := executor.DirectorInterpolate(awsInterpolateInput)
 			Expect(err).NotTo(HaveOccurred())
 		})
@@ -315,7 +840,7 @@ networks
 			It("fails when trying to run command", func() {
 				cmd.RunReturnsOnCall(0, errors.New("failed to run command"))
 
-				executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+				executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 				_, err := executor.DirectorInterpolate(bosh.InterpolateInput{
 					IAAS: "aws",
 				})
@@ -325,10 +850,10 @@ networks
 			It("fails when trying to run the command to interpolate with the user opsfile", func() {
 				cmd.RunReturnsOnCall(1, errors.New("failed to run command"))
 
-				executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+				executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 				_, err := executor.DirectorInterpolate(bosh.InterpolateInput{
-					IAAS:    "aws",
-					OpsFile: "some-ops-file",
+					IAAS:     "aws",
+					OpsFiles: []string{"some-ops-file"},
 				})
 				Expect(err).To(MatchError("failed to run command"))
 			})
@@ -338,7 +863,7 @@ networks
 					return []byte{}, errors.New("failed to read variables file")
 				}
 
-				executor = bosh.NewExecutor(cmd, tempDirFunc, readFileFunc, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+				executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, readFileFunc, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 				_, err := executor.DirectorInterpolate(bosh.InterpolateInput{
 					IAAS: "aws",
 				})
@@ -370,7 +895,7 @@ networks
 				return tempDir, nil
 			}
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 		})
 
 		It("fails when the temporary directory cannot be created", func() {
@@ -378,7 +903,7 @@ networks
 				return "", errors.New("failed to create temp dir")
 			}
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 			err := callback(executor)
 			Expect(err).To(MatchError("failed to create temp dir"))
 		})
@@ -388,7 +913,7 @@ networks
 				return []byte{}, errors.New("failed to marshal state")
 			}
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, marshalFunc, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, marshalFunc, ioutil.WriteFile)
 			err := callback(executor)
 			Expect(err).To(MatchError("failed to marshal state"))
 		})
@@ -398,7 +923,7 @@ networks
 				return errors.New("failed to write file")
 			}
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, writeFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, writeFile)
 			err := callback(executor)
 			Expect(err).To(MatchError("failed to write file"))
 		})
@@ -438,7 +963,7 @@ networks
 				return tempDir, nil
 			}
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 
 			createEnvInput = bosh.CreateEnvInput{
 				Manifest:  "some-manifest",
@@ -487,6 +1012,21 @@ networks
 			}))
 		})
 
+		It("recreates the vm when requested", func() {
+			createEnvInput.Recreate = true
+
+			_, err := executor.CreateEnv(createEnvInput)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, args := cmd.RunArgsForCall(0)
+			Expect(args).To(Equal([]string{
+				"create-env", manifestPath,
+				"--vars-store", variablesPath,
+				"--state", statePath,
+				"--recreate",
+			}))
+		})
+
 		Context("failure cases", func() {
 			createEnvDeleteEnvFailureCases(func(executor bosh.Executor) error {
 				createEnvInput := bosh.CreateEnvInput{
@@ -501,7 +1041,7 @@ networks
 			Context("when command run fails", func() {
 				BeforeEach(func() {
 					cmd.RunReturns(errors.New("failed to run"))
-					executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+					executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 
 					cmd.RunStub = func(stdout io.Writer, workingDirectory string, args []string) error {
 						ioutil.WriteFile(statePath, []byte(`{"key": "value"}`), os.ModePerm)
@@ -523,7 +1063,7 @@ networks
 							return []byte{}, errors.New("failed to read file")
 						}
 
-						executor = bosh.NewExecutor(cmd, tempDirFunc, readFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+						executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, readFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 					})
 
 					It("returns an error", func() {
@@ -538,7 +1078,7 @@ networks
 							return errors.New("failed to unmarshal")
 						}
 
-						executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, unmarshalFunc, json.Marshal, ioutil.WriteFile)
+						executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, unmarshalFunc, json.Marshal, ioutil.WriteFile)
 					})
 
 					It("returns an error", func() {
@@ -553,7 +1093,7 @@ networks
 					return []byte{}, errors.New("failed to read file")
 				}
 
-				executor = bosh.NewExecutor(cmd, tempDirFunc, readFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+				executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, readFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 				_, err := executor.CreateEnv(createEnvInput)
 				Expect(err).To(MatchError("failed to read file"))
 			})
@@ -563,7 +1103,7 @@ networks
 					return errors.New("failed to unmarshal")
 				}
 
-				executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, unmarshalFunc, json.Marshal, ioutil.WriteFile)
+				executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, unmarshalFunc, json.Marshal, ioutil.WriteFile)
 				_, err := executor.CreateEnv(createEnvInput)
 				Expect(err).To(MatchError("failed to unmarshal"))
 			})
@@ -598,7 +1138,7 @@ networks
 				return tempDir, nil
 			}
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 
 			deleteEnvInput = bosh.DeleteEnvInput{
 				Manifest:  "some-manifest",
@@ -656,7 +1196,7 @@ networks
 			Context("when command run fails", func() {
 				BeforeEach(func() {
 					cmd.RunReturnsOnCall(0, errors.New("failed to run"))
-					executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+					executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 
 					cmd.RunStub = func(stdout io.Writer, workingDirectory string, args []string) error {
 						ioutil.WriteFile(statePath, []byte(`{"partial": "state"}`), os.ModePerm)
@@ -678,7 +1218,7 @@ networks
 							return []byte{}, errors.New("failed to read file")
 						}
 
-						executor = bosh.NewExecutor(cmd, tempDirFunc, readFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+						executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, readFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 					})
 
 					It("returns an error", func() {
@@ -715,7 +1255,7 @@ networks
 				return tempDir, nil
 			}
 
-			executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+			executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 		})
 
 		It("passes the correct args and dir to run command", func() {
@@ -738,7 +1278,7 @@ networks
 					return "", errors.New("failed to create temp dir")
 				}
 
-				executor = bosh.NewExecutor(cmd, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
+				executor = bosh.NewExecutor(cmd, os.Stdout, tempDirFunc, ioutil.ReadFile, json.Unmarshal, json.Marshal, ioutil.WriteFile)
 				_, err := executor.Version()
 				Expect(err).To(MatchError("failed to create temp dir"))
 			})
@@ -1,6 +1,17 @@
 package bosh
 
-import "os"
+import (
+	"os"
+	"time"
+)
+
+func SetOSGetenv(f func(string) string) {
+	osGetenv = f
+}
+
+func ResetOSGetenv() {
+	osGetenv = os.Getenv
+}
 
 func SetOSSetenv(f func(string, string) error) {
 	osSetenv = f
@@ -17,3 +28,11 @@ func SetOSUnsetenv(f func(string) error) {
 func ResetOSUnsetenv() {
 	osUnsetenv = os.Unsetenv
 }
+
+func SetTaskPollInterval(interval time.Duration) {
+	taskPollInterval = interval
+}
+
+func ResetTaskPollInterval() {
+	taskPollInterval = 2 * time.Second
+}
@@ -0,0 +1,109 @@
+package bosh
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// useLocalArtifacts points the releases and stemcells of an interpolated
+// manifest at tarballs in the artifacts dir, so that create-env does not
+// download them. A release or stemcell at
+//
+//	https://bosh.io/d/github.com/cloudfoundry/os-conf-release?v=13
+//	https://s3.amazonaws.com/bosh-compiled-release-tarballs/bosh-262.3-ubuntu-trusty-3421.9.tgz
+//
+// is read from releases/os-conf-release-13.tgz and
+// releases/bosh-262.3-ubuntu-trusty-3421.9.tgz of the artifacts dir. The
+// sha1s are kept so create-env still checks the tarballs.
+func useLocalArtifacts(manifest, artifactsDir string) (string, error) {
+	var manifestYAML yaml.MapSlice
+	err := yaml.Unmarshal([]byte(manifest), &manifestYAML)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the manifest for the artifacts dir: %s", err)
+	}
+
+	missing := []string{}
+	localURL := func(item yaml.MapSlice, kind string) {
+		remoteURL, ok := mapSliceValue(item, "url").(string)
+		if !ok {
+			return
+		}
+
+		localPath, ok := localArtifactPath(artifactsDir, kind, remoteURL)
+		if !ok {
+			return
+		}
+
+		if _, err := os.Stat(localPath); err != nil {
+			missing = append(missing, fmt.Sprintf("  download %s to %s", remoteURL, localPath))
+			return
+		}
+
+		setMapSliceValue(item, "url", "file://"+localPath)
+	}
+
+	releases, _ := mapSliceValue(manifestYAML, "releases").([]interface{})
+	for _, release := range releases {
+		if release, ok := release.(yaml.MapSlice); ok {
+			localURL(release, "releases")
+		}
+	}
+
+	resourcePools, _ := mapSliceValue(manifestYAML, "resource_pools").([]interface{})
+	for _, resourcePool := range resourcePools {
+		resourcePool, _ := resourcePool.(yaml.MapSlice)
+		if stemcell, ok := mapSliceValue(resourcePool, "stemcell").(yaml.MapSlice); ok {
+			localURL(stemcell, "stemcells")
+		}
+	}
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("bbl is offline and the artifacts dir is missing:\n%s", strings.Join(missing, "\n"))
+	}
+
+	contents, err := yaml.Marshal(manifestYAML)
+	if err != nil {
+		return "", err //not tested
+	}
+
+	return string(contents), nil
+}
+
+// localArtifactPath returns where in the artifacts dir the tarball of a
+// remote url is read from, and false for urls that are not downloaded.
+func localArtifactPath(artifactsDir, kind, remoteURL string) (string, bool) {
+	parsedURL, err := url.Parse(remoteURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return "", false
+	}
+
+	name := path.Base(parsedURL.Path)
+	if version := parsedURL.Query().Get("v"); version != "" {
+		name = fmt.Sprintf("%s-%s.tgz", name, version)
+	}
+
+	return filepath.Join(artifactsDir, kind, name), true
+}
+
+func mapSliceValue(mapSlice yaml.MapSlice, key string) interface{} {
+	for _, item := range mapSlice {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func setMapSliceValue(mapSlice yaml.MapSlice, key string, value interface{}) {
+	for i := range mapSlice {
+		if mapSlice[i].Key == key {
+			mapSlice[i].Value = value
+		}
+	}
+}
@@ -12,20 +12,29 @@ import (
 )
 
 var (
+	osGetenv   = os.Getenv
 	osSetenv   = os.Setenv
 	osUnsetenv = os.Unsetenv
 )
 
 const (
-	DIRECTOR_USERNAME    = "admin"
-	DIRECTOR_INTERNAL_IP = "10.0.0.6"
+	DIRECTOR_USERNAME = "admin"
 )
 
 type Manager struct {
-	executor    executor
-	logger      logger
-	socks5Proxy socks5Proxy
-	iaasInputs  InterpolateInput
+	executor          executor
+	logger            logger
+	socks5Proxy       socks5Proxy
+	deploymentFetcher deploymentFetcher
+	artifactsDir      string
+	iaasInputs        InterpolateInput
+}
+
+type internalNetwork struct {
+	cidr       string
+	gateway    string
+	jumpboxIP  string
+	directorIP string
 }
 
 type directorVars struct {
@@ -67,16 +76,24 @@ type logger interface {
 	Println(string)
 }
 
+type deploymentFetcher interface {
+	Path(repo, version string) (string, error)
+}
+
 type socks5Proxy interface {
 	Start(string, string) error
 	Addr() string
 }
 
-func NewManager(executor executor, logger logger, socks5Proxy socks5Proxy) *Manager {
+// NewManager returns a manager that deploys from the public releases and
+// stemcells, or from the tarballs in artifactsDir when it is not empty.
+func NewManager(executor executor, logger logger, socks5Proxy socks5Proxy, deploymentFetcher deploymentFetcher, artifactsDir string) *Manager {
 	return &Manager{
-		executor:    executor,
-		logger:      logger,
-		socks5Proxy: socks5Proxy,
+		executor:          executor,
+		logger:            logger,
+		socks5Proxy:       socks5Proxy,
+		deploymentFetcher: deploymentFetcher,
+		artifactsDir:      artifactsDir,
 	}
 }
 
@@ -90,10 +107,34 @@ func (m *Manager) Version() (string, error) {
 }
 
 func (m *Manager) CreateJumpbox(state storage.State, terraformOutputs map[string]interface{}) (storage.State, error) {
-	var err error
 	m.logger.Step("creating jumpbox")
 
-	m.iaasInputs, err = generateIAASInputs(state)
+	state, err := m.deployJumpbox(state, terraformOutputs, false)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	return state, nil
+}
+
+// RecreateJumpbox replaces the jumpbox VM without touching the director.
+// The previous jumpbox record is left as is when the new VM cannot be
+// created.
+func (m *Manager) RecreateJumpbox(state storage.State, terraformOutputs map[string]interface{}) (storage.State, error) {
+	m.logger.Step("recreating jumpbox")
+
+	newState, err := m.deployJumpbox(state, terraformOutputs, true)
+	if err != nil {
+		return storage.State{}, fmt.Errorf("failed to recreate jumpbox:\n%s", err)
+	}
+
+	return newState, nil
+}
+
+func (m *Manager) deployJumpbox(state storage.State, terraformOutputs map[string]interface{}, recreate bool) (storage.State, error) {
+	var err error
+
+	m.iaasInputs, err = m.generateIAASInputs(state)
 	if err != nil {
 		return storage.State{}, err
 	}
@@ -102,6 +143,9 @@ func (m *Manager) CreateJumpbox(state storage.State, terraformOutputs map[string
 	if err != nil {
 		return storage.State{}, err //not tested
 	}
+	m.iaasInputs.JumpboxSSHPort = state.SSHPort
+	m.iaasInputs.StemcellURL = state.BOSH.StemcellURL
+	m.iaasInputs.StemcellSHA1 = state.BOSH.StemcellSHA1
 	interpolateOutputs, err := m.executor.JumpboxInterpolate(m.iaasInputs)
 	if err != nil {
 		return storage.State{}, err
@@ -117,15 +161,19 @@ func (m *Manager) CreateJumpbox(state storage.State, terraformOutputs map[string
 		Manifest:  interpolateOutputs.Manifest,
 		State:     state.Jumpbox.State,
 		Variables: string(variables),
+		Recreate:  recreate,
 	})
 	switch err.(type) {
 	case CreateEnvError:
 		ceErr := err.(CreateEnvError)
 		state.Jumpbox = storage.Jumpbox{
-			Enabled:   true,
-			Variables: interpolateOutputs.Variables,
-			State:     ceErr.BOSHState(),
-			Manifest:  interpolateOutputs.Manifest,
+			Enabled:           true,
+			Variables:         interpolateOutputs.Variables,
+			State:             ceErr.BOSHState(),
+			Manifest:          interpolateOutputs.Manifest,
+			ProxyPort:         state.Jumpbox.ProxyPort,
+			DeploymentPath:    state.Jumpbox.DeploymentPath,
+			DeploymentVersion: state.Jumpbox.DeploymentVersion,
 		}
 		return storage.State{}, NewManagerCreateError(state, err)
 	case error:
@@ -133,11 +181,14 @@ func (m *Manager) CreateJumpbox(state storage.State, terraformOutputs map[string
 	}
 
 	state.Jumpbox = storage.Jumpbox{
-		Enabled:   true,
-		Variables: interpolateOutputs.Variables,
-		State:     createEnvOutputs.State,
-		Manifest:  interpolateOutputs.Manifest,
-		URL:       terraformOutputs["jumpbox_url"].(string),
+		Enabled:           true,
+		Variables:         interpolateOutputs.Variables,
+		State:             createEnvOutputs.State,
+		Manifest:          interpolateOutputs.Manifest,
+		URL:               terraformOutputs["jumpbox_url"].(string),
+		ProxyPort:         state.Jumpbox.ProxyPort,
+		DeploymentPath:    state.Jumpbox.DeploymentPath,
+		DeploymentVersion: state.Jumpbox.DeploymentVersion,
 	}
 
 	m.logger.Step("created jumpbox")
@@ -153,7 +204,7 @@ func (m *Manager) CreateJumpbox(state storage.State, terraformOutputs map[string
 		return storage.State{}, err
 	}
 
-	osSetenv("BOSH_ALL_PROXY", fmt.Sprintf("socks5://%s", m.socks5Proxy.Addr()))
+	m.setAllProxy(state)
 
 	return state, nil
 }
@@ -164,23 +215,33 @@ func (m *Manager) CreateDirector(state storage.State, terraformOutputs map[strin
 
 	directorAddress = terraformOutputs["director_address"].(string)
 
+	m.iaasInputs, err = m.generateIAASInputs(state)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	// The inputs are built from the state rather than kept from
+	// CreateJumpbox, so that commands which only redeploy the director
+	// interpolate it behind the jumpbox too.
 	if state.Jumpbox.Enabled {
-		directorAddress = fmt.Sprintf("https://%s:25555", DIRECTOR_INTERNAL_IP)
-	} else {
-		m.iaasInputs, err = generateIAASInputs(state)
+		network, err := directorInternalNetwork(state)
 		if err != nil {
 			return storage.State{}, err
 		}
+		directorAddress = fmt.Sprintf("https://%s:25555", network.directorIP)
+
+		m.iaasInputs.JumpboxDeploymentVars, err = m.GetJumpboxDeploymentVars(state, terraformOutputs)
+		if err != nil {
+			return storage.State{}, err //not tested
+		}
 	}
 
 	m.logger.Step("creating bosh director")
-	m.iaasInputs.DeploymentVars, err = m.GetDeploymentVars(state, terraformOutputs)
+	m.iaasInputs, err = m.directorInterpolateInput(m.iaasInputs, state, terraformOutputs)
 	if err != nil {
-		return storage.State{}, err //not tested
+		return storage.State{}, err
 	}
 
-	m.iaasInputs.OpsFile = state.BOSH.UserOpsFile
-
 	interpolateOutputs, err := m.executor.DirectorInterpolate(m.iaasInputs)
 	if err != nil {
 		return storage.State{}, err
@@ -195,9 +256,22 @@ func (m *Manager) CreateDirector(state storage.State, terraformOutputs map[strin
 	case CreateEnvError:
 		ceErr := err.(CreateEnvError)
 		state.BOSH = storage.BOSH{
-			Variables: interpolateOutputs.Variables,
-			State:     ceErr.BOSHState(),
-			Manifest:  interpolateOutputs.Manifest,
+			Variables:         interpolateOutputs.Variables,
+			State:             ceErr.BOSHState(),
+			Manifest:          interpolateOutputs.Manifest,
+			UserCACertificate: state.BOSH.UserCACertificate,
+			UserCAPrivateKey:  state.BOSH.UserCAPrivateKey,
+			UserOpsFile:       state.BOSH.UserOpsFile,
+			UserOpsFiles:      state.BOSH.UserOpsFiles,
+			DirectorSpot:      state.BOSH.DirectorSpot,
+			DirectorSpotPrice: state.BOSH.DirectorSpotPrice,
+			DirectorDiskType:  state.BOSH.DirectorDiskType,
+			DirectorVMType:    state.BOSH.DirectorVMType,
+			DirectorDiskSize:  state.BOSH.DirectorDiskSize,
+			DeploymentPath:    state.BOSH.DeploymentPath,
+			DeploymentVersion: state.BOSH.DeploymentVersion,
+			StemcellURL:       state.BOSH.StemcellURL,
+			StemcellSHA1:      state.BOSH.StemcellSHA1,
 		}
 		return storage.State{}, NewManagerCreateError(state, err)
 	case error:
@@ -220,14 +294,83 @@ func (m *Manager) CreateDirector(state storage.State, terraformOutputs map[strin
 		Variables:              interpolateOutputs.Variables,
 		State:                  createEnvOutputs.State,
 		Manifest:               interpolateOutputs.Manifest,
+		UserCACertificate:      state.BOSH.UserCACertificate,
+		UserCAPrivateKey:       state.BOSH.UserCAPrivateKey,
+		UserOpsFile:            state.BOSH.UserOpsFile,
+		UserOpsFiles:           state.BOSH.UserOpsFiles,
+		DirectorSpot:           state.BOSH.DirectorSpot,
+		DirectorSpotPrice:      state.BOSH.DirectorSpotPrice,
+		DirectorDiskType:       state.BOSH.DirectorDiskType,
+		DirectorVMType:         state.BOSH.DirectorVMType,
+		DirectorDiskSize:       state.BOSH.DirectorDiskSize,
+		DeploymentPath:         state.BOSH.DeploymentPath,
+		DeploymentVersion:      state.BOSH.DeploymentVersion,
+		StemcellURL:            state.BOSH.StemcellURL,
+		StemcellSHA1:           state.BOSH.StemcellSHA1,
 	}
 
 	m.logger.Step("created bosh director")
 	return state, nil
 }
 
+// DirectorManifest interpolates the manifest CreateDirector would deploy
+// for state without deploying it.
+func (m *Manager) DirectorManifest(state storage.State, terraformOutputs map[string]interface{}) (string, error) {
+	iaasInputs, err := m.generateIAASInputs(state)
+	if err != nil {
+		return "", err
+	}
+
+	if state.Jumpbox.Enabled {
+		iaasInputs.JumpboxDeploymentVars, err = m.GetJumpboxDeploymentVars(state, terraformOutputs)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	iaasInputs, err = m.directorInterpolateInput(iaasInputs, state, terraformOutputs)
+	if err != nil {
+		return "", err
+	}
+
+	interpolateOutputs, err := m.executor.DirectorInterpolate(iaasInputs)
+	if err != nil {
+		return "", err
+	}
+
+	return interpolateOutputs.Manifest, nil
+}
+
+func (m *Manager) directorInterpolateInput(iaasInputs InterpolateInput, state storage.State, terraformOutputs map[string]interface{}) (InterpolateInput, error) {
+	var err error
+	iaasInputs.DeploymentVars, err = m.GetDeploymentVars(state, terraformOutputs)
+	if err != nil {
+		return InterpolateInput{}, err //not tested
+	}
+
+	iaasInputs.OpsFiles = state.BOSH.OpsFiles()
+	iaasInputs.DirectorSpot = state.BOSH.DirectorSpot
+	iaasInputs.DirectorDiskType = state.BOSH.DirectorDiskType
+	iaasInputs.DirectorVMType = state.BOSH.DirectorVMType
+	iaasInputs.DirectorDiskSize = state.BOSH.DirectorDiskSize
+	iaasInputs.DirectorExternalDB = state.DirectorDB.Enabled
+	iaasInputs.StemcellURL = state.BOSH.StemcellURL
+	iaasInputs.StemcellSHA1 = state.BOSH.StemcellSHA1
+	iaasInputs.AWSDefaultCredentials = usesAWSDefaultCredentials(state)
+	iaasInputs.AWSSessionToken = state.IAAS == "aws" && state.AWS.SessionToken != ""
+
+	if state.BOSH.UserCACertificate != "" {
+		iaasInputs.Variables, err = withUserCA(iaasInputs.Variables, state.BOSH.UserCACertificate, state.BOSH.UserCAPrivateKey)
+		if err != nil {
+			return InterpolateInput{}, fmt.Errorf("failed to use director CA:\n%s", err.Error())
+		}
+	}
+
+	return iaasInputs, nil
+}
+
 func (m *Manager) Delete(state storage.State, terraformOutputs map[string]interface{}) error {
-	iaasInputs, err := generateIAASInputs(state)
+	iaasInputs, err := m.generateIAASInputs(state)
 	if err != nil {
 		return err
 	}
@@ -243,7 +386,7 @@ func (m *Manager) Delete(state storage.State, terraformOutputs map[string]interf
 			return err
 		}
 
-		osSetenv("BOSH_ALL_PROXY", fmt.Sprintf("socks5://%s", m.socks5Proxy.Addr()))
+		m.setAllProxy(state)
 
 		iaasInputs.JumpboxDeploymentVars, err = m.GetJumpboxDeploymentVars(state, terraformOutputs)
 		if err != nil {
@@ -256,7 +399,9 @@ func (m *Manager) Delete(state storage.State, terraformOutputs map[string]interf
 		return err //not tested
 	}
 
-	iaasInputs.OpsFile = state.BOSH.UserOpsFile
+	iaasInputs.OpsFiles = state.BOSH.OpsFiles()
+	iaasInputs.AWSDefaultCredentials = usesAWSDefaultCredentials(state)
+	iaasInputs.AWSSessionToken = state.IAAS == "aws" && state.AWS.SessionToken != ""
 
 	interpolateOutputs, err := m.executor.DirectorInterpolate(iaasInputs)
 	if err != nil {
@@ -286,7 +431,7 @@ func (m *Manager) DeleteJumpbox(state storage.State, terraformOutputs map[string
 	}
 
 	m.logger.Step("destroying jumpbox")
-	iaasInputs, err := generateIAASInputs(state)
+	iaasInputs, err := m.generateIAASInputs(state)
 	if err != nil {
 		return err
 	}
@@ -319,10 +464,15 @@ func (m *Manager) DeleteJumpbox(state storage.State, terraformOutputs map[string
 }
 
 func (m *Manager) GetJumpboxDeploymentVars(state storage.State, terraformOutputs map[string]interface{}) (string, error) {
-	vars := strings.Join([]string{
-		"internal_cidr: 10.0.0.0/24",
-		"internal_gw: 10.0.0.1",
-		"internal_ip: 10.0.0.5",
+	network, err := directorInternalNetwork(state)
+	if err != nil {
+		return "", err
+	}
+
+	vars := strings.Join(append([]string{
+		fmt.Sprintf("internal_cidr: %s", network.cidr),
+		fmt.Sprintf("internal_gw: %s", network.gateway),
+		fmt.Sprintf("internal_ip: %s", network.jumpboxIP),
 		fmt.Sprintf("director_name: %s", fmt.Sprintf("bosh-%s", state.EnvID)),
 		fmt.Sprintf("external_ip: %s", terraformOutputs["external_ip"]),
 		fmt.Sprintf("zone: %s", state.GCP.Zone),
@@ -330,8 +480,13 @@ func (m *Manager) GetJumpboxDeploymentVars(state storage.State, terraformOutputs
 		fmt.Sprintf("subnetwork: %s", terraformOutputs["subnetwork_name"]),
 		fmt.Sprintf("tags: [%s]", terraformOutputs["bosh_open_tag_name"]),
 		fmt.Sprintf("project_id: %s", state.GCP.ProjectID),
-		fmt.Sprintf("gcp_credentials_json: '%s'", state.GCP.ServiceAccountKey),
-	}, "\n")
+	}, gcpCredentialsVars(state, false)...), "\n")
+
+	if state.SSHPort != 0 {
+		vars = fmt.Sprintf("%s\njumpbox_ssh_port: %d", vars, state.SSHPort)
+	}
+
+	vars += stemcellVars(state)
 
 	return strings.TrimSuffix(vars, "\n"), nil
 }
@@ -339,69 +494,280 @@ func (m *Manager) GetJumpboxDeploymentVars(state storage.State, terraformOutputs
 func (m *Manager) GetDeploymentVars(state storage.State, terraformOutputs map[string]interface{}) (string, error) {
 	var vars string
 
+	network, err := directorInternalNetwork(state)
+	if err != nil {
+		return "", err
+	}
+
 	switch state.IAAS {
 	case "gcp":
 		if state.Jumpbox.Enabled {
-			vars = strings.Join([]string{
-				"internal_cidr: 10.0.0.0/24",
-				"internal_gw: 10.0.0.1",
-				fmt.Sprintf("internal_ip: %s", DIRECTOR_INTERNAL_IP),
+			vars = strings.Join(append([]string{
+				fmt.Sprintf("internal_cidr: %s", network.cidr),
+				fmt.Sprintf("internal_gw: %s", network.gateway),
+				fmt.Sprintf("internal_ip: %s", network.directorIP),
 				fmt.Sprintf("director_name: %s", fmt.Sprintf("bosh-%s", state.EnvID)),
 				fmt.Sprintf("zone: %s", state.GCP.Zone),
 				fmt.Sprintf("network: %s", terraformOutputs["network_name"]),
 				fmt.Sprintf("subnetwork: %s", terraformOutputs["subnetwork_name"]),
 				fmt.Sprintf("tags: [%s]", terraformOutputs["bosh_director_tag_name"]),
 				fmt.Sprintf("project_id: %s", state.GCP.ProjectID),
-				fmt.Sprintf("gcp_credentials_json: '%s'", state.GCP.ServiceAccountKey),
-			}, "\n")
+			}, gcpCredentialsVars(state, true)...), "\n")
 		} else {
-			vars = strings.Join([]string{
-				"internal_cidr: 10.0.0.0/24",
-				"internal_gw: 10.0.0.1",
-				fmt.Sprintf("internal_ip: %s", DIRECTOR_INTERNAL_IP),
+			gcpVars := []string{
+				fmt.Sprintf("internal_cidr: %s", network.cidr),
+				fmt.Sprintf("internal_gw: %s", network.gateway),
+				fmt.Sprintf("internal_ip: %s", network.directorIP),
 				fmt.Sprintf("director_name: %s", fmt.Sprintf("bosh-%s", state.EnvID)),
-				fmt.Sprintf("external_ip: %s", terraformOutputs["external_ip"]),
+			}
+			// without an external address the director is created and
+			// reached at its internal ip.
+			if !state.GCP.NoPublicIPs {
+				gcpVars = append(gcpVars, fmt.Sprintf("external_ip: %s", terraformOutputs["external_ip"]))
+			}
+			vars = strings.Join(append(append(gcpVars,
 				fmt.Sprintf("zone: %s", state.GCP.Zone),
 				fmt.Sprintf("network: %s", terraformOutputs["network_name"]),
 				fmt.Sprintf("subnetwork: %s", terraformOutputs["subnetwork_name"]),
 				fmt.Sprintf("tags: [%s, %s]", terraformOutputs["bosh_open_tag_name"], terraformOutputs["bosh_director_tag_name"]),
 				fmt.Sprintf("project_id: %s", state.GCP.ProjectID),
-				fmt.Sprintf("gcp_credentials_json: '%s'", state.GCP.ServiceAccountKey),
-			}, "\n")
+			), gcpCredentialsVars(state, true)...), "\n")
 		}
 	case "aws":
-		vars = strings.Join([]string{
-			"internal_cidr: 10.0.0.0/24",
-			"internal_gw: 10.0.0.1",
-			fmt.Sprintf("internal_ip: %s", DIRECTOR_INTERNAL_IP),
+		awsVars := []string{
+			fmt.Sprintf("internal_cidr: %s", network.cidr),
+			fmt.Sprintf("internal_gw: %s", network.gateway),
+			fmt.Sprintf("internal_ip: %s", network.directorIP),
 			fmt.Sprintf("director_name: %s", fmt.Sprintf("bosh-%s", state.EnvID)),
 			fmt.Sprintf("external_ip: %s", terraformOutputs["external_ip"]),
 			fmt.Sprintf("az: %s", terraformOutputs["bosh_subnet_availability_zone"]),
 			fmt.Sprintf("subnet_id: %s", terraformOutputs["bosh_subnet_id"]),
-			fmt.Sprintf("access_key_id: %s", state.AWS.AccessKeyID),
-			fmt.Sprintf("secret_access_key: %s", state.AWS.SecretAccessKey),
+		}
+		if !usesAWSDefaultCredentials(state) {
+			awsVars = append(awsVars,
+				fmt.Sprintf("access_key_id: %s", state.AWS.AccessKeyID),
+				fmt.Sprintf("secret_access_key: %s", state.AWS.SecretAccessKey),
+			)
+		}
+		if state.AWS.SessionToken != "" {
+			awsVars = append(awsVars, fmt.Sprintf("session_token: %s", state.AWS.SessionToken))
+		}
+		vars = strings.Join(append(awsVars,
 			fmt.Sprintf("iam_instance_profile: %s", terraformOutputs["bosh_iam_instance_profile"]),
 			fmt.Sprintf("default_key_name: %s", state.KeyPair.Name),
 			fmt.Sprintf("default_security_groups: [%s]", terraformOutputs["bosh_security_group"]),
 			fmt.Sprintf("region: %s", state.AWS.Region),
 			fmt.Sprintf("private_key: |-\n  %s", strings.Replace(state.KeyPair.PrivateKey, "\n", "\n  ", -1)),
+		), "\n")
+
+		if state.BOSH.DirectorSpot {
+			vars = fmt.Sprintf("%s\ndirector_spot_bid_price: %s", vars, state.BOSH.DirectorSpotPrice)
+		}
+	case "azure":
+		vars = strings.Join([]string{
+			fmt.Sprintf("internal_cidr: %s", network.cidr),
+			fmt.Sprintf("internal_gw: %s", network.gateway),
+			fmt.Sprintf("internal_ip: %s", network.directorIP),
+			fmt.Sprintf("director_name: %s", fmt.Sprintf("bosh-%s", state.EnvID)),
+			fmt.Sprintf("external_ip: %s", terraformOutputs["external_ip"]),
+			fmt.Sprintf("vnet_name: %s", terraformOutputs["vnet_name"]),
+			fmt.Sprintf("subnet_name: %s", terraformOutputs["subnet_name"]),
+			fmt.Sprintf("subscription_id: %s", state.Azure.SubscriptionID),
+			fmt.Sprintf("tenant_id: %s", state.Azure.TenantID),
+			fmt.Sprintf("client_id: %s", state.Azure.ClientID),
+			fmt.Sprintf("client_secret: %s", state.Azure.ClientSecret),
+			fmt.Sprintf("resource_group_name: %s", terraformOutputs["resource_group_name"]),
+			fmt.Sprintf("storage_account_name: %s", terraformOutputs["storage_account_name"]),
+			fmt.Sprintf("default_security_group: %s", terraformOutputs["default_security_group"]),
+			"ssh:",
+			fmt.Sprintf("  public_key: %s", strings.TrimSpace(state.KeyPair.PublicKey)),
+			fmt.Sprintf("  private_key: |-\n    %s", strings.Replace(state.KeyPair.PrivateKey, "\n", "\n    ", -1)),
+		}, "\n")
+	case "openstack":
+		vars = strings.Join([]string{
+			fmt.Sprintf("internal_cidr: %s", network.cidr),
+			fmt.Sprintf("internal_gw: %s", network.gateway),
+			fmt.Sprintf("internal_ip: %s", network.directorIP),
+			fmt.Sprintf("director_name: %s", fmt.Sprintf("bosh-%s", state.EnvID)),
+			fmt.Sprintf("external_ip: %s", terraformOutputs["external_ip"]),
+			fmt.Sprintf("az: %s", state.OpenStack.AZ),
+			fmt.Sprintf("net_id: %s", terraformOutputs["net_id"]),
+			fmt.Sprintf("auth_url: %s", state.OpenStack.AuthURL),
+			fmt.Sprintf("openstack_username: %s", state.OpenStack.Username),
+			fmt.Sprintf("openstack_password: %s", state.OpenStack.Password),
+			fmt.Sprintf("openstack_domain: %s", state.OpenStack.Domain),
+			fmt.Sprintf("openstack_project: %s", state.OpenStack.Project),
+			fmt.Sprintf("region: %s", state.OpenStack.Region),
+			fmt.Sprintf("default_key_name: %s", terraformOutputs["default_key_name"]),
+			fmt.Sprintf("default_security_groups: [%s]", terraformOutputs["default_security_group"]),
+			fmt.Sprintf("private_key: |-\n  %s", strings.Replace(state.KeyPair.PrivateKey, "\n", "\n  ", -1)),
+		}, "\n")
+	}
+
+	if state.BOSH.DirectorDiskType != "" {
+		vars = fmt.Sprintf("%s\ndirector_disk_type: %s", vars, state.BOSH.DirectorDiskType)
+	}
+
+	if state.BOSH.DirectorVMType != "" {
+		vars = fmt.Sprintf("%s\ndirector_vm_type: %s", vars, state.BOSH.DirectorVMType)
+	}
+
+	if state.BOSH.DirectorDiskSize != 0 {
+		vars = fmt.Sprintf("%s\ndirector_disk_size: %d", vars, state.BOSH.DirectorDiskSize*1024)
+	}
+
+	vars += stemcellVars(state)
+
+	if state.DirectorDB.Enabled {
+		vars = strings.Join([]string{
+			vars,
+			fmt.Sprintf("external_db_host: %s", terraformOutputs["director_db_host"]),
+			fmt.Sprintf("external_db_port: %s", terraformOutputs["director_db_port"]),
+			"external_db_user: bosh",
+			fmt.Sprintf("external_db_password: %s", state.DirectorDB.Password),
+			"external_db_adapter: postgres",
+			"external_db_name: bosh",
 		}, "\n")
 	}
 
 	return strings.TrimSuffix(vars, "\n"), nil
 }
 
-func generateIAASInputs(state storage.State) (InterpolateInput, error) {
+// setAllProxy sends the bosh cli to the director through the socks5 proxy
+// to the jumpbox. When bbl runs behind an http proxy, the internal network is
+// added to NO_PROXY as well, since the bosh cli would otherwise send the
+// requests to the director to the http proxy, which cannot reach it.
+func (m *Manager) setAllProxy(state storage.State) {
+	if osGetenv("HTTP_PROXY") != "" || osGetenv("HTTPS_PROXY") != "" || osGetenv("http_proxy") != "" || osGetenv("https_proxy") != "" {
+		network, err := directorInternalNetwork(state)
+		if err == nil {
+			osSetenv("NO_PROXY", withNoProxy(osGetenv("NO_PROXY")+","+osGetenv("no_proxy"), network.cidr))
+		}
+	}
+
+	osSetenv("BOSH_ALL_PROXY", fmt.Sprintf("socks5://%s", m.socks5Proxy.Addr()))
+}
+
+// withNoProxy adds host to a NO_PROXY list, once.
+func withNoProxy(noProxy, host string) string {
+	hosts := []string{}
+	seen := map[string]bool{}
+	for _, h := range append(strings.Split(noProxy, ","), host) {
+		h = strings.TrimSpace(h)
+		if h != "" && !seen[h] {
+			seen[h] = true
+			hosts = append(hosts, h)
+		}
+	}
+
+	return strings.Join(hosts, ",")
+}
+
+func directorInternalNetwork(state storage.State) (internalNetwork, error) {
+	cidr := state.Network.DirectorSubnetCIDR()
+
+	cidrBlock, err := ParseCIDRBlock(cidr)
+	if err != nil {
+		return internalNetwork{}, err
+	}
+
+	firstIP := cidrBlock.GetFirstIP()
+
+	return internalNetwork{
+		cidr:       cidr,
+		gateway:    firstIP.Add(1).String(),
+		jumpboxIP:  firstIP.Add(5).String(),
+		directorIP: firstIP.Add(6).String(),
+	}, nil
+}
+
+// usesGCPDefaultCredentials reports whether bbl found no service account key
+// for a gcp environment and left the credentials to the application default
+// credentials.
+func usesGCPDefaultCredentials(state storage.State) bool {
+	return state.IAAS == "gcp" && state.GCP.ServiceAccountKey == ""
+}
+
+// gcpCredentialsVars returns the vars the google cpi authenticates with: the
+// service account key, or without one the service account the director vm
+// runs as, which is the impersonated service account or the project's default
+// compute service account.
+func gcpCredentialsVars(state storage.State, director bool) []string {
+	if !usesGCPDefaultCredentials(state) {
+		return []string{fmt.Sprintf("gcp_credentials_json: '%s'", state.GCP.ServiceAccountKey)}
+	}
+
+	if !director {
+		return []string{}
+	}
+
+	serviceAccount := state.GCP.ImpersonateServiceAccount
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+
+	return []string{fmt.Sprintf("service_account: %s", serviceAccount)}
+}
+
+// usesAWSDefaultCredentials reports whether bbl found no access key for an aws
+// environment and left the credentials to the default credential chain.
+func usesAWSDefaultCredentials(state storage.State) bool {
+	return state.IAAS == "aws" && state.AWS.AccessKeyID == "" && state.AWS.SecretAccessKey == ""
+}
+
+// stemcellVars are the vars of the stemcell ops files, for the director and
+// jumpbox alike.
+func stemcellVars(state storage.State) string {
+	var vars string
+	if state.BOSH.StemcellURL != "" {
+		vars += fmt.Sprintf("\nstemcell_url: %q", state.BOSH.StemcellURL)
+	}
+
+	if state.BOSH.StemcellSHA1 != "" {
+		vars += fmt.Sprintf("\nstemcell_sha1: %s", state.BOSH.StemcellSHA1)
+	}
+
+	return vars
+}
+
+func (m *Manager) generateIAASInputs(state storage.State) (InterpolateInput, error) {
 	switch state.IAAS {
-	case "gcp", "aws":
-		return InterpolateInput{
-			IAAS:      state.IAAS,
-			BOSHState: state.BOSH.State,
-			Variables: state.BOSH.Variables,
-		}, nil
+	case "gcp", "aws", "azure", "openstack":
 	default:
 		return InterpolateInput{}, errors.New("A valid IAAS was not provided")
 	}
+
+	boshDeploymentDir, err := m.deploymentDir(BOSHDeploymentRepo, state.BOSH.DeploymentPath, state.BOSH.DeploymentVersion)
+	if err != nil {
+		return InterpolateInput{}, err
+	}
+
+	jumpboxDeploymentDir, err := m.deploymentDir(JumpboxDeploymentRepo, state.Jumpbox.DeploymentPath, state.Jumpbox.DeploymentVersion)
+	if err != nil {
+		return InterpolateInput{}, err
+	}
+
+	return InterpolateInput{
+		IAAS:                  state.IAAS,
+		BOSHState:             state.BOSH.State,
+		Variables:             state.BOSH.Variables,
+		GCPDefaultCredentials: usesGCPDefaultCredentials(state),
+		NoPublicIPs:           state.GCP.NoPublicIPs,
+		DirectorSSH:           state.DirectorSSH,
+		BOSHDeploymentDir:     boshDeploymentDir,
+		JumpboxDeploymentDir:  jumpboxDeploymentDir,
+		ArtifactsDir:          m.artifactsDir,
+	}, nil
+}
+
+// deploymentDir returns the checkout of the deployment pinned in the state,
+// or no dir for the copy compiled into bbl.
+func (m *Manager) deploymentDir(repo, path, version string) (string, error) {
+	if version == "" {
+		return path, nil
+	}
+
+	return m.deploymentFetcher.Path(repo, version)
 }
 
 func getJumpboxPrivateKey(v string) (string, error) {
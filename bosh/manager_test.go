@@ -42,7 +42,7 @@ var _ = Describe("Manager", func() {
 			boshExecutor = &fakes.BOSHExecutor{}
 			logger = &fakes.Logger{}
 			socks5Proxy = &fakes.Socks5Proxy{}
-			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy)
+			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, &fakes.DeploymentFetcher{}, "")
 
 			bosh.SetOSSetenv(func(key, value string) error {
 				osSetenvKey = key
@@ -141,7 +141,7 @@ gcp_credentials_json: 'some-credential-json'`,
 						"some-key": "some-value",
 					},
 					Variables: "",
-					OpsFile:   "some-ops-file",
+					OpsFiles:  []string{"some-ops-file"},
 				}))
 
 				Expect(socks5Proxy.StartCall.CallCount).To(Equal(0))
@@ -196,6 +196,189 @@ gcp_credentials_json: 'some-credential-json'`,
 			})
 		})
 
+		Context("when a user provided ca is in the state", func() {
+			BeforeEach(func() {
+				boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+					Manifest:  "some-manifest",
+					Variables: variablesYAML,
+				}
+
+				incomingGCPState.BOSH.UserCACertificate = "some-user-ca"
+				incomingGCPState.BOSH.UserCAPrivateKey = "some-user-ca-key"
+			})
+
+			It("seeds the variables with the ca so the director certificates are issued from it", func() {
+				_, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.Variables).To(MatchYAML(`default_ca:
+  ca: some-user-ca
+  certificate: some-user-ca
+  private_key: some-user-ca-key
+`))
+			})
+
+			It("drops certificates issued by a previously generated ca", func() {
+				incomingGCPState.BOSH.Variables = `admin_password: some-admin-password
+default_ca:
+  ca: some-generated-ca
+  certificate: some-generated-ca
+  private_key: some-generated-ca-key
+director_ssl:
+  ca: some-generated-ca
+  certificate: some-certificate
+  private_key: some-private-key
+`
+
+				_, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.Variables).To(MatchYAML(`admin_password: some-admin-password
+default_ca:
+  ca: some-user-ca
+  certificate: some-user-ca
+  private_key: some-user-ca-key
+`))
+			})
+
+			It("leaves the variables unchanged when the ca is already in use", func() {
+				incomingGCPState.BOSH.Variables = `default_ca:
+  ca: some-user-ca
+  certificate: some-user-ca
+  private_key: some-user-ca-key
+director_ssl:
+  ca: some-user-ca
+  certificate: some-certificate
+  private_key: some-private-key
+`
+
+				_, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.Variables).To(Equal(incomingGCPState.BOSH.Variables))
+			})
+
+			It("keeps the ca in the returned state", func() {
+				state, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.BOSH.UserCACertificate).To(Equal("some-user-ca"))
+				Expect(state.BOSH.UserCAPrivateKey).To(Equal("some-user-ca-key"))
+			})
+		})
+
+		Context("when the director is a spot instance", func() {
+			It("interpolates the director as spot and keeps the settings in the returned state", func() {
+				boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+					Manifest:  "some-manifest",
+					Variables: variablesYAML,
+				}
+
+				incomingGCPState.BOSH.DirectorSpot = true
+
+				state, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.DirectorSpot).To(BeTrue())
+				Expect(state.BOSH.DirectorSpot).To(BeTrue())
+			})
+		})
+
+		Context("when the director has a disk type", func() {
+			It("interpolates the director with the disk type and keeps it in the returned state", func() {
+				boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+					Manifest:  "some-manifest",
+					Variables: variablesYAML,
+				}
+
+				incomingGCPState.BOSH.DirectorDiskType = "pd-ssd"
+
+				state, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.DirectorDiskType).To(Equal("pd-ssd"))
+				Expect(state.BOSH.DirectorDiskType).To(Equal("pd-ssd"))
+			})
+		})
+
+		Context("when the director has user ops files", func() {
+			It("applies the ops file saved by earlier versions first and keeps both in the returned state", func() {
+				boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+					Manifest:  "some-manifest",
+					Variables: variablesYAML,
+				}
+
+				incomingGCPState.BOSH.UserOpsFile = "some-legacy-ops-file"
+				incomingGCPState.BOSH.UserOpsFiles = []string{"some-ops-file", "some-other-ops-file"}
+
+				state, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.OpsFiles).To(Equal([]string{"some-legacy-ops-file", "some-ops-file", "some-other-ops-file"}))
+				Expect(state.BOSH.UserOpsFile).To(Equal("some-legacy-ops-file"))
+				Expect(state.BOSH.UserOpsFiles).To(Equal([]string{"some-ops-file", "some-other-ops-file"}))
+			})
+		})
+
+		Context("when the director has a vm type and disk size", func() {
+			It("interpolates the director with them and keeps them in the returned state", func() {
+				boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+					Manifest:  "some-manifest",
+					Variables: variablesYAML,
+				}
+
+				incomingGCPState.BOSH.DirectorVMType = "n1-standard-8"
+				incomingGCPState.BOSH.DirectorDiskSize = 128
+
+				state, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.DirectorVMType).To(Equal("n1-standard-8"))
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.DirectorDiskSize).To(Equal(128))
+				Expect(state.BOSH.DirectorVMType).To(Equal("n1-standard-8"))
+				Expect(state.BOSH.DirectorDiskSize).To(Equal(128))
+			})
+		})
+
+		Context("when the director has a custom stemcell", func() {
+			It("interpolates the director with it and keeps it in the returned state", func() {
+				boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+					Manifest:  "some-manifest",
+					Variables: variablesYAML,
+				}
+
+				incomingGCPState.BOSH.StemcellURL = "https://bosh.io/d/stemcells/some-stemcell?v=1.2"
+				incomingGCPState.BOSH.StemcellSHA1 = "some-sha1"
+
+				state, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.StemcellURL).To(Equal("https://bosh.io/d/stemcells/some-stemcell?v=1.2"))
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.StemcellSHA1).To(Equal("some-sha1"))
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.DeploymentVars).To(HaveSuffix(`
+stemcell_url: "https://bosh.io/d/stemcells/some-stemcell?v=1.2"
+stemcell_sha1: some-sha1`))
+				Expect(state.BOSH.StemcellURL).To(Equal("https://bosh.io/d/stemcells/some-stemcell?v=1.2"))
+				Expect(state.BOSH.StemcellSHA1).To(Equal("some-sha1"))
+			})
+		})
+
+		Context("when the director has an external database", func() {
+			It("interpolates the director with the external db ops file", func() {
+				boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+					Manifest:  "some-manifest",
+					Variables: variablesYAML,
+				}
+
+				incomingGCPState.DirectorDB = storage.DirectorDB{Enabled: true, Password: "some-db-password"}
+
+				_, err := boshManager.CreateDirector(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.DirectorExternalDB).To(BeTrue())
+			})
+		})
+
 		Context("when iaas is aws", func() {
 			incomingAWSState := storage.State{
 				IAAS:  "aws",
@@ -243,8 +426,9 @@ gcp_credentials_json: 'some-credential-json'`,
 				})
 
 				It("generates a bosh manifest", func() {
-					incomingAWSState.BOSH.UserOpsFile = "some-ops-file"
-					_, err := boshManager.CreateDirector(incomingAWSState, terraformOutputs)
+					awsState := incomingAWSState
+					awsState.BOSH.UserOpsFiles = []string{"some-ops-file"}
+					_, err := boshManager.CreateDirector(awsState, terraformOutputs)
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput).To(Equal(bosh.InterpolateInput{
@@ -268,10 +452,39 @@ private_key: |-
 							"some-key": "some-value",
 						},
 						Variables: "",
-						OpsFile:   "some-ops-file",
+						OpsFiles:  []string{"some-ops-file"},
 					}))
 				})
 
+				It("lets the cpi use the default credential chain when there is no access key", func() {
+					awsState := incomingAWSState
+					awsState.AWS = storage.AWS{Region: "some-region"}
+
+					_, err := boshManager.CreateDirector(awsState, terraformOutputs)
+					Expect(err).NotTo(HaveOccurred())
+
+					interpolateInput := boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput
+					Expect(interpolateInput.AWSDefaultCredentials).To(BeTrue())
+					Expect(interpolateInput.DeploymentVars).NotTo(ContainSubstring("access_key_id"))
+					Expect(interpolateInput.DeploymentVars).NotTo(ContainSubstring("secret_access_key"))
+					Expect(interpolateInput.DeploymentVars).To(ContainSubstring("iam_instance_profile: some-bosh-iam-instance-profile"))
+				})
+
+				It("passes the session token of an assumed role to the cpi", func() {
+					awsState := incomingAWSState
+					awsState.AWS.SessionToken = "some-session-token"
+					awsState.AWS.AssumeRoleARN = "arn:aws:iam::123456789012:role/bbl"
+
+					_, err := boshManager.CreateDirector(awsState, terraformOutputs)
+					Expect(err).NotTo(HaveOccurred())
+
+					interpolateInput := boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput
+					Expect(interpolateInput.AWSSessionToken).To(BeTrue())
+					Expect(interpolateInput.DeploymentVars).To(ContainSubstring(`secret_access_key: some-secret-access-key
+session_token: some-session-token
+iam_instance_profile: some-bosh-iam-instance-profile`))
+				})
+
 				It("returns a state with a proper bosh state", func() {
 					state, err := boshManager.CreateDirector(incomingAWSState, terraformOutputs)
 					Expect(err).NotTo(HaveOccurred())
@@ -399,12 +612,13 @@ private_key: |-
 
 	Describe("CreateJumpbox", func() {
 		var (
-			boshExecutor     *fakes.BOSHExecutor
-			logger           *fakes.Logger
-			socks5Proxy      *fakes.Socks5Proxy
-			boshManager      *bosh.Manager
-			incomingGCPState storage.State
-			terraformOutputs map[string]interface{}
+			boshExecutor      *fakes.BOSHExecutor
+			logger            *fakes.Logger
+			socks5Proxy       *fakes.Socks5Proxy
+			deploymentFetcher *fakes.DeploymentFetcher
+			boshManager       *bosh.Manager
+			incomingGCPState  storage.State
+			terraformOutputs  map[string]interface{}
 
 			jumpboxDeploymentVars string
 			deploymentVars        string
@@ -418,7 +632,8 @@ private_key: |-
 			boshExecutor = &fakes.BOSHExecutor{}
 			logger = &fakes.Logger{}
 			socks5Proxy = &fakes.Socks5Proxy{}
-			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy)
+			deploymentFetcher = &fakes.DeploymentFetcher{}
+			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, deploymentFetcher, "")
 
 			bosh.SetOSSetenv(func(key, value string) error {
 				osSetenvKey = key
@@ -517,6 +732,36 @@ gcp_credentials_json: 'some-credential-json'`
 			Expect(logger.StepCall.Messages).To(gomegamatchers.ContainSequence([]string{"creating jumpbox", "created jumpbox"}))
 		})
 
+		It("moves the jumpbox ssh daemon to the ssh port in the state", func() {
+			incomingGCPState.SSHPort = 2222
+
+			_, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshExecutor.JumpboxInterpolateCall.Receives.InterpolateInput.JumpboxSSHPort).To(Equal(2222))
+			Expect(boshExecutor.JumpboxInterpolateCall.Receives.InterpolateInput.JumpboxDeploymentVars).To(HaveSuffix("\njumpbox_ssh_port: 2222"))
+		})
+
+		It("keeps the proxy port in the state", func() {
+			incomingGCPState.Jumpbox.ProxyPort = 1080
+
+			state, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state.Jumpbox.ProxyPort).To(Equal(1080))
+		})
+
+		It("deploys the jumpbox on the custom stemcell in the state", func() {
+			incomingGCPState.BOSH.StemcellURL = "file:///some/stemcell.tgz"
+
+			_, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshExecutor.JumpboxInterpolateCall.Receives.InterpolateInput.StemcellURL).To(Equal("file:///some/stemcell.tgz"))
+			Expect(boshExecutor.JumpboxInterpolateCall.Receives.InterpolateInput.JumpboxDeploymentVars).To(HaveSuffix(`
+stemcell_url: "file:///some/stemcell.tgz"`))
+		})
+
 		It("starts a socks5 proxy for the duration of creating the bosh director", func() {
 			socks5ProxyAddr := "localhost:1234"
 			socks5Proxy.AddrCall.Returns.Addr = socks5ProxyAddr
@@ -538,6 +783,50 @@ gcp_credentials_json: 'some-credential-json'`
 			}))
 		})
 
+		Context("when bbl runs behind an http proxy", func() {
+			var env map[string]string
+
+			BeforeEach(func() {
+				env = map[string]string{
+					"HTTPS_PROXY": "http://some-proxy:3128",
+					"NO_PROXY":    "localhost,.internal",
+				}
+
+				bosh.SetOSGetenv(func(key string) string {
+					return env[key]
+				})
+				bosh.SetOSSetenv(func(key, value string) error {
+					env[key] = value
+					return nil
+				})
+			})
+
+			AfterEach(func() {
+				bosh.ResetOSGetenv()
+			})
+
+			It("keeps the requests to the director network away from the proxy", func() {
+				_, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env["NO_PROXY"]).To(Equal("localhost,.internal,10.0.0.0/24"))
+				Expect(env["BOSH_ALL_PROXY"]).To(HavePrefix("socks5://"))
+
+				_, err = boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(env["NO_PROXY"]).To(Equal("localhost,.internal,10.0.0.0/24"))
+			})
+
+			It("leaves NO_PROXY alone without a proxy", func() {
+				delete(env, "HTTPS_PROXY")
+
+				_, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(env["NO_PROXY"]).To(Equal("localhost,.internal"))
+			})
+		})
+
 		Context("when bosh director is created after jumpbox", func() {
 			It("generates a jumpbox and bosh manifest", func() {
 				afterJumpboxState, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
@@ -556,6 +845,18 @@ gcp_credentials_json: 'some-credential-json'`
 				}))
 			})
 
+			It("interpolates the director behind the jumpbox when only the director is redeployed", func() {
+				afterJumpboxState, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, &fakes.DeploymentFetcher{}, "")
+				_, err = boshManager.CreateDirector(afterJumpboxState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.IAAS).To(Equal("gcp"))
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.JumpboxDeploymentVars).To(Equal(jumpboxDeploymentVars))
+			})
+
 			It("returns a bbl state with a proper jumpbox state", func() {
 				boshExecutor.CreateEnvCall.Returns.Output = bosh.CreateEnvOutput{
 					State: map[string]interface{}{
@@ -611,6 +912,66 @@ gcp_credentials_json: 'some-credential-json'`
 			})
 		})
 
+		Context("when the state pins bosh-deployment and jumpbox-deployment", func() {
+			It("interpolates the checkouts at the pinned paths", func() {
+				incomingGCPState.BOSH.DeploymentPath = "/some/bosh-deployment"
+				incomingGCPState.Jumpbox.DeploymentPath = "/some/jumpbox-deployment"
+
+				afterJumpboxState, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.JumpboxInterpolateCall.Receives.InterpolateInput.JumpboxDeploymentDir).To(Equal("/some/jumpbox-deployment"))
+				Expect(afterJumpboxState.Jumpbox.DeploymentPath).To(Equal("/some/jumpbox-deployment"))
+
+				state, err := boshManager.CreateDirector(afterJumpboxState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.BOSHDeploymentDir).To(Equal("/some/bosh-deployment"))
+				Expect(state.BOSH.DeploymentPath).To(Equal("/some/bosh-deployment"))
+				Expect(deploymentFetcher.PathCall.CallCount).To(Equal(0))
+			})
+
+			It("interpolates the pinned versions", func() {
+				incomingGCPState.BOSH.DeploymentVersion = "v1.2.3"
+				deploymentFetcher.PathCall.Returns.Path = "/cache/bosh-deployment/v1.2.3"
+
+				afterJumpboxState, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				state, err := boshManager.CreateDirector(afterJumpboxState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(deploymentFetcher.PathCall.Receives.Repo).To(Equal("cloudfoundry/bosh-deployment"))
+				Expect(deploymentFetcher.PathCall.Receives.Version).To(Equal("v1.2.3"))
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.BOSHDeploymentDir).To(Equal("/cache/bosh-deployment/v1.2.3"))
+				Expect(state.BOSH.DeploymentVersion).To(Equal("v1.2.3"))
+			})
+
+			It("returns an error when the pinned version cannot be fetched", func() {
+				incomingGCPState.Jumpbox.DeploymentVersion = "v0.0.0"
+				deploymentFetcher.PathCall.Returns.Error = errors.New("failed to download")
+
+				_, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).To(MatchError("failed to download"))
+				Expect(deploymentFetcher.PathCall.Receives.Repo).To(Equal("cppforlife/jumpbox-deployment"))
+				Expect(boshExecutor.JumpboxInterpolateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when bbl is offline", func() {
+			It("interpolates the jumpbox and director with the artifacts dir", func() {
+				boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, deploymentFetcher, "/some/artifacts")
+
+				afterJumpboxState, err := boshManager.CreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(boshExecutor.JumpboxInterpolateCall.Receives.InterpolateInput.ArtifactsDir).To(Equal("/some/artifacts"))
+
+				_, err = boshManager.CreateDirector(afterJumpboxState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.ArtifactsDir).To(Equal("/some/artifacts"))
+			})
+		})
+
 		Context("when an error occurs", func() {
 			Context("when the jumpbox variables cannot be parsed", func() {
 				BeforeEach(func() {
@@ -630,6 +991,58 @@ gcp_credentials_json: 'some-credential-json'`
 				Expect(err).To(MatchError("failed to start socks5Proxy"))
 			})
 		})
+
+		Describe("RecreateJumpbox", func() {
+			It("recreates the jumpbox vm and updates the jumpbox record", func() {
+				boshExecutor.CreateEnvCall.Returns.Output = bosh.CreateEnvOutput{
+					State: map[string]interface{}{
+						"some-new-key": "some-new-value",
+					},
+				}
+
+				state, err := boshManager.RecreateJumpbox(incomingGCPState, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.CreateEnvCall.Receives.Input.Recreate).To(BeTrue())
+				Expect(boshExecutor.CreateEnvCall.Receives.Input.State).To(Equal(map[string]interface{}{
+					"some-key": "some-value",
+				}))
+				Expect(socks5Proxy.StartCall.Receives.JumpboxExternalURL).To(Equal("some-jumpbox-url"))
+
+				Expect(state.Jumpbox).To(Equal(storage.Jumpbox{
+					Enabled:   true,
+					URL:       "some-jumpbox-url",
+					Variables: "jumpbox_ssh:\n  private_key: some-jumpbox-private-key",
+					Manifest:  "name: jumpbox",
+					State: map[string]interface{}{
+						"some-new-key": "some-new-value",
+					},
+				}))
+				Expect(state.BOSH).To(Equal(incomingGCPState.BOSH))
+				Expect(logger.StepCall.Messages).To(ContainElement("recreating jumpbox"))
+			})
+
+			Context("when an error occurs", func() {
+				It("returns a plain error when create env fails so the previous record is kept", func() {
+					boshExecutor.CreateEnvCall.Returns.Error = bosh.NewCreateEnvError(map[string]interface{}{
+						"partial": "jumpbox-state",
+					}, errors.New("failed to create env"))
+
+					_, err := boshManager.RecreateJumpbox(incomingGCPState, terraformOutputs)
+					Expect(err).To(MatchError("failed to recreate jumpbox:\nfailed to create env"))
+
+					_, ok := err.(bosh.ManagerCreateError)
+					Expect(ok).To(BeFalse())
+				})
+
+				It("returns an error when the new jumpbox cannot be reached", func() {
+					socks5Proxy.StartCall.Returns.Error = errors.New("failed to start socks5Proxy")
+
+					_, err := boshManager.RecreateJumpbox(incomingGCPState, terraformOutputs)
+					Expect(err).To(MatchError("failed to recreate jumpbox:\nfailed to start socks5Proxy"))
+				})
+			})
+		})
 	})
 
 	Describe("DeleteJumpbox", func() {
@@ -646,7 +1059,7 @@ gcp_credentials_json: 'some-credential-json'`
 			boshExecutor = &fakes.BOSHExecutor{}
 			logger = &fakes.Logger{}
 			socks5Proxy = &fakes.Socks5Proxy{}
-			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy)
+			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, &fakes.DeploymentFetcher{}, "")
 
 			vars = `jumpbox_ssh:
   private_key: some-private-key
@@ -736,6 +1149,81 @@ gcp_credentials_json: 'some-credential-json'`
 		})
 	})
 
+	Describe("DirectorManifest", func() {
+		var (
+			boshExecutor     *fakes.BOSHExecutor
+			boshManager      *bosh.Manager
+			state            storage.State
+			terraformOutputs map[string]interface{}
+		)
+
+		BeforeEach(func() {
+			boshExecutor = &fakes.BOSHExecutor{}
+			boshManager = bosh.NewManager(boshExecutor, &fakes.Logger{}, &fakes.Socks5Proxy{}, &fakes.DeploymentFetcher{}, "")
+
+			boshExecutor.DirectorInterpolateCall.Returns.Output = bosh.InterpolateOutput{
+				Manifest:  "some-manifest",
+				Variables: "some-new-variables",
+			}
+
+			terraformOutputs = map[string]interface{}{
+				"network_name":           "some-network",
+				"subnetwork_name":        "some-subnetwork",
+				"bosh_open_tag_name":     "some-jumpbox-tag",
+				"bosh_director_tag_name": "some-director-tag",
+				"internal_tag_name":      "some-internal-tag",
+				"external_ip":            "some-external-ip",
+				"director_address":       "some-director-address",
+				"jumpbox_url":            "some-jumpbox-url",
+			}
+
+			state = storage.State{
+				IAAS:  "gcp",
+				EnvID: "some-env-id",
+				GCP: storage.GCP{
+					Zone:              "some-zone",
+					ProjectID:         "some-project-id",
+					ServiceAccountKey: "some-credential-json",
+				},
+				BOSH: storage.BOSH{
+					Variables:   "some-variables",
+					UserOpsFile: "some-ops-file",
+				},
+			}
+		})
+
+		It("interpolates the director manifest without deploying it", func() {
+			manifest, err := boshManager.DirectorManifest(state, terraformOutputs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest).To(Equal("some-manifest"))
+
+			interpolateInput := boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput
+			Expect(interpolateInput.IAAS).To(Equal("gcp"))
+			Expect(interpolateInput.Variables).To(Equal("some-variables"))
+			Expect(interpolateInput.OpsFiles).To(Equal([]string{"some-ops-file"}))
+			Expect(interpolateInput.DeploymentVars).To(ContainSubstring("director_name: bosh-some-env-id"))
+			Expect(interpolateInput.JumpboxDeploymentVars).To(BeEmpty())
+			Expect(boshExecutor.CreateEnvCall.CallCount).To(Equal(0))
+		})
+
+		It("interpolates the manifest behind the jumpbox when the jumpbox is enabled", func() {
+			state.Jumpbox.Enabled = true
+
+			_, err := boshManager.DirectorManifest(state, terraformOutputs)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshExecutor.DirectorInterpolateCall.Receives.InterpolateInput.JumpboxDeploymentVars).NotTo(BeEmpty())
+			Expect(boshExecutor.JumpboxInterpolateCall.CallCount).To(Equal(0))
+		})
+
+		It("returns an error when the interpolation fails", func() {
+			boshExecutor.DirectorInterpolateCall.Returns.Error = errors.New("failed to interpolate")
+
+			_, err := boshManager.DirectorManifest(state, terraformOutputs)
+			Expect(err).To(MatchError("failed to interpolate"))
+		})
+	})
+
 	Describe("Delete", func() {
 		var (
 			boshExecutor *fakes.BOSHExecutor
@@ -751,7 +1239,7 @@ gcp_credentials_json: 'some-credential-json'`
 			boshExecutor = &fakes.BOSHExecutor{}
 			logger = &fakes.Logger{}
 			socks5Proxy = &fakes.Socks5Proxy{}
-			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy)
+			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, &fakes.DeploymentFetcher{}, "")
 
 			bosh.SetOSSetenv(func(key, value string) error {
 				osSetenvKey = key
@@ -912,7 +1400,7 @@ gcp_credentials_json: 'some-credential-json'`
 			boshExecutor = &fakes.BOSHExecutor{}
 			logger = &fakes.Logger{}
 			socks5Proxy = &fakes.Socks5Proxy{}
-			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy)
+			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, &fakes.DeploymentFetcher{}, "")
 		})
 
 		Context("gcp", func() {
@@ -967,6 +1455,71 @@ tags: [some-jumpbox-tag, some-director-tag]
 project_id: some-project-id
 gcp_credentials_json: 'some-credential-json'`))
 			})
+
+			Context("when there is no service account key", func() {
+				BeforeEach(func() {
+					incomingState.GCP.ServiceAccountKey = ""
+				})
+
+				It("runs the director as the default compute service account", func() {
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).NotTo(ContainSubstring("gcp_credentials_json"))
+					Expect(vars).To(HaveSuffix("project_id: some-project-id\nservice_account: default"))
+				})
+
+				It("runs the director as the impersonated service account", func() {
+					incomingState.GCP.ImpersonateServiceAccount = "bbl@some-project-id.iam.gserviceaccount.com"
+
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).To(HaveSuffix("service_account: bbl@some-project-id.iam.gserviceaccount.com"))
+				})
+
+				It("does not pass credentials to the jumpbox", func() {
+					vars, err := boshManager.GetJumpboxDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).NotTo(ContainSubstring("gcp_credentials_json"))
+					Expect(vars).NotTo(ContainSubstring("service_account"))
+				})
+			})
+
+			Context("when there are no public ips", func() {
+				It("leaves out the external ip of the director", func() {
+					incomingState.GCP.NoPublicIPs = true
+
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{
+						"external_ip": "10.0.0.6",
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).NotTo(ContainSubstring("external_ip"))
+					Expect(vars).To(ContainSubstring("internal_ip: 10.0.0.6\ndirector_name: bosh-some-env-id\nzone: some-zone"))
+				})
+			})
+
+			Context("when a custom subnet cidr is in the state", func() {
+				It("derives the director network from the subnet", func() {
+					incomingState.Network.SubnetCIDR = "172.16.4.0/24"
+
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).To(HavePrefix(`internal_cidr: 172.16.4.0/24
+internal_gw: 172.16.4.1
+internal_ip: 172.16.4.6
+`))
+				})
+
+				It("derives the jumpbox network from the subnet", func() {
+					incomingState.Network.SubnetCIDR = "172.16.4.0/24"
+
+					vars, err := boshManager.GetJumpboxDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).To(HavePrefix(`internal_cidr: 172.16.4.0/24
+internal_gw: 172.16.4.1
+internal_ip: 172.16.4.5
+`))
+				})
+			})
 		})
 
 		Context("aws", func() {
@@ -1031,6 +1584,153 @@ private_key: |-
 				})
 			})
 
+			Context("when the director is a spot instance", func() {
+				It("includes the spot bid price", func() {
+					incomingState.BOSH.DirectorSpot = true
+					incomingState.BOSH.DirectorSpotPrice = "0.05"
+
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).To(HaveSuffix(`
+director_spot_bid_price: 0.05`))
+				})
+			})
+
+			Context("when the director has a disk type", func() {
+				It("includes the disk type", func() {
+					incomingState.BOSH.DirectorDiskType = "gp3"
+
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).To(HaveSuffix(`
+director_disk_type: gp3`))
+				})
+			})
+
+			Context("when the director has an external database", func() {
+				It("includes the database connection from the terraform outputs", func() {
+					incomingState.DirectorDB = storage.DirectorDB{Enabled: true, Password: "some-db-password"}
+
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{
+						"director_db_host": "some-db-host",
+						"director_db_port": "5432",
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).To(HaveSuffix(`
+external_db_host: some-db-host
+external_db_port: 5432
+external_db_user: bosh
+external_db_password: some-db-password
+external_db_adapter: postgres
+external_db_name: bosh`))
+				})
+			})
+
+			Context("when the director has a vm type and disk size", func() {
+				It("includes the vm type and the disk size in MB", func() {
+					incomingState.BOSH.DirectorVMType = "m4.2xlarge"
+					incomingState.BOSH.DirectorDiskSize = 128
+
+					vars, err := boshManager.GetDeploymentVars(incomingState, map[string]interface{}{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vars).To(HaveSuffix(`
+director_vm_type: m4.2xlarge
+director_disk_size: 131072`))
+				})
+			})
+		})
+
+		Context("azure", func() {
+			It("returns a correct yaml string of bosh deployment variables", func() {
+				vars, err := boshManager.GetDeploymentVars(storage.State{
+					IAAS:  "azure",
+					EnvID: "some-env-id",
+					KeyPair: storage.KeyPair{
+						PublicKey:  "some-public-key\n",
+						PrivateKey: "some-private-key\nsome-more-private-key",
+					},
+					Azure: storage.Azure{
+						SubscriptionID: "some-subscription-id",
+						TenantID:       "some-tenant-id",
+						ClientID:       "some-client-id",
+						ClientSecret:   "some-client-secret",
+					},
+					TFState: "some-tf-state",
+				}, map[string]interface{}{
+					"external_ip":            "some-external-ip",
+					"vnet_name":              "some-vnet-name",
+					"subnet_name":            "some-subnet-name",
+					"resource_group_name":    "some-resource-group-name",
+					"storage_account_name":   "some-storage-account-name",
+					"default_security_group": "some-security-group",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vars).To(Equal(`internal_cidr: 10.0.0.0/24
+internal_gw: 10.0.0.1
+internal_ip: 10.0.0.6
+director_name: bosh-some-env-id
+external_ip: some-external-ip
+vnet_name: some-vnet-name
+subnet_name: some-subnet-name
+subscription_id: some-subscription-id
+tenant_id: some-tenant-id
+client_id: some-client-id
+client_secret: some-client-secret
+resource_group_name: some-resource-group-name
+storage_account_name: some-storage-account-name
+default_security_group: some-security-group
+ssh:
+  public_key: some-public-key
+  private_key: |-
+    some-private-key
+    some-more-private-key`))
+			})
+		})
+
+		Context("openstack", func() {
+			It("returns a correct yaml string of bosh deployment variables", func() {
+				vars, err := boshManager.GetDeploymentVars(storage.State{
+					IAAS:  "openstack",
+					EnvID: "some-env-id",
+					KeyPair: storage.KeyPair{
+						PrivateKey: "some-private-key\nsome-more-private-key",
+					},
+					OpenStack: storage.OpenStack{
+						AuthURL:  "some-auth-url",
+						AZ:       "some-az",
+						Region:   "some-region",
+						Username: "some-username",
+						Password: "some-password",
+						Domain:   "some-domain",
+						Project:  "some-project",
+					},
+					TFState: "some-tf-state",
+				}, map[string]interface{}{
+					"external_ip":            "some-external-ip",
+					"net_id":                 "some-net-id",
+					"default_key_name":       "some-key-name",
+					"default_security_group": "some-security-group",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vars).To(Equal(`internal_cidr: 10.0.0.0/24
+internal_gw: 10.0.0.1
+internal_ip: 10.0.0.6
+director_name: bosh-some-env-id
+external_ip: some-external-ip
+az: some-az
+net_id: some-net-id
+auth_url: some-auth-url
+openstack_username: some-username
+openstack_password: some-password
+openstack_domain: some-domain
+openstack_project: some-project
+region: some-region
+default_key_name: some-key-name
+default_security_groups: [some-security-group]
+private_key: |-
+  some-private-key
+  some-more-private-key`))
+			})
 		})
 	})
 
@@ -1046,7 +1746,7 @@ private_key: |-
 			boshExecutor = &fakes.BOSHExecutor{}
 			logger = &fakes.Logger{}
 			socks5Proxy = &fakes.Socks5Proxy{}
-			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy)
+			boshManager = bosh.NewManager(boshExecutor, logger, socks5Proxy, &fakes.DeploymentFetcher{}, "")
 
 			boshExecutor.VersionCall.Returns.Version = "2.0.24"
 		})
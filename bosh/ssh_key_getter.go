@@ -12,14 +12,29 @@ func NewSSHKeyGetter() SSHKeyGetter {
 }
 
 func (j SSHKeyGetter) Get(state storage.State) (string, error) {
-	var vars string
+	if !state.Jumpbox.Enabled {
+		return j.GetDirector(state)
+	}
+
+	return jumpboxSSHKey(state.Jumpbox.Variables)
+}
+
+// GetDirector returns the key of the jumpbox user on the director. Directors
+// deployed without a jumpbox by older versions of bbl use the top-level keypair.
+func (j SSHKeyGetter) GetDirector(state storage.State) (string, error) {
+	privateKey, err := jumpboxSSHKey(state.BOSH.Variables)
+	if err != nil {
+		return "", err
+	}
 
-	if state.Jumpbox.Enabled {
-		vars = state.Jumpbox.Variables
-	} else {
-		vars = state.BOSH.Variables
+	if privateKey == "" && !state.Jumpbox.Enabled {
+		return state.KeyPair.PrivateKey, nil
 	}
 
+	return privateKey, nil
+}
+
+func jumpboxSSHKey(vars string) (string, error) {
 	var variables struct {
 		JumpboxSSH struct {
 			PrivateKey string `yaml:"private_key"`
@@ -31,10 +46,5 @@ func (j SSHKeyGetter) Get(state storage.State) (string, error) {
 		return "", err
 	}
 
-	if variables.JumpboxSSH.PrivateKey == "" {
-		return state.KeyPair.PrivateKey, nil
-	}
-
 	return variables.JumpboxSSH.PrivateKey, nil
-
 }
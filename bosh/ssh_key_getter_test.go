@@ -87,4 +87,54 @@ var _ = Describe("SSHKeyGetter", func() {
 			})
 		})
 	})
+
+	Describe("GetDirector", func() {
+		var sshKeyGetter bosh.SSHKeyGetter
+
+		BeforeEach(func() {
+			sshKeyGetter = bosh.NewSSHKeyGetter()
+		})
+
+		It("returns the jumpbox user key from the bosh variables", func() {
+			privateKey, err := sshKeyGetter.GetDirector(storage.State{
+				Jumpbox: storage.Jumpbox{
+					Enabled:   true,
+					Variables: "jumpbox_ssh:\n  private_key: some-jumpbox-private-key",
+				},
+				BOSH: storage.BOSH{
+					Variables: "jumpbox_ssh:\n  private_key: some-director-private-key",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(privateKey).To(Equal("some-director-private-key"))
+		})
+
+		Context("when there is no jumpbox", func() {
+			It("returns the top-level keypair when the bosh variables have no key", func() {
+				privateKey, err := sshKeyGetter.GetDirector(storage.State{
+					BOSH:    storage.BOSH{Variables: "some-var: wut"},
+					KeyPair: storage.KeyPair{PrivateKey: "some-private-key"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(privateKey).To(Equal("some-private-key"))
+			})
+		})
+
+		Context("when the director was deployed without the jumpbox user", func() {
+			It("returns an empty key", func() {
+				privateKey, err := sshKeyGetter.GetDirector(storage.State{
+					Jumpbox: storage.Jumpbox{Enabled: true},
+					BOSH:    storage.BOSH{Variables: "some-var: wut"},
+					KeyPair: storage.KeyPair{PrivateKey: "some-private-key"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(privateKey).To(BeEmpty())
+			})
+		})
+
+		It("returns an error when the BOSH variables yaml cannot be unmarshaled", func() {
+			_, err := sshKeyGetter.GetDirector(storage.State{BOSH: storage.BOSH{Variables: "invalid yaml"}})
+			Expect(err).To(MatchError(ContainSubstring("line 1: cannot unmarshal !!str `invalid...`")))
+		})
+	})
 })
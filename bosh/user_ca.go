@@ -0,0 +1,50 @@
+package bosh
+
+import (
+	yaml "gopkg.in/yaml.v2"
+)
+
+const defaultCAVariable = "default_ca"
+
+// withUserCA seeds the vars-store with the user provided CA as default_ca so
+// that bosh issues the director certificates from it instead of generating
+// a CA. Certificates issued by a previous default_ca are dropped so they are
+// reissued by the new one.
+func withUserCA(variables, certificate, privateKey string) (string, error) {
+	vars := map[interface{}]interface{}{}
+
+	err := yaml.Unmarshal([]byte(variables), &vars)
+	if err != nil {
+		return "", err
+	}
+
+	var previousCA string
+	if defaultCA, ok := vars[defaultCAVariable].(map[interface{}]interface{}); ok {
+		previousCA, _ = defaultCA["certificate"].(string)
+	}
+
+	if previousCA == certificate {
+		return variables, nil
+	}
+
+	if previousCA != "" {
+		for name, value := range vars {
+			if issued, ok := value.(map[interface{}]interface{}); ok && issued["ca"] == previousCA {
+				delete(vars, name)
+			}
+		}
+	}
+
+	vars[defaultCAVariable] = map[interface{}]interface{}{
+		"ca":          certificate,
+		"certificate": certificate,
+		"private_key": privateKey,
+	}
+
+	contents, err := yaml.Marshal(vars)
+	if err != nil {
+		return "", err
+	}
+
+	return string(contents), nil
+}
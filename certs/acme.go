@@ -0,0 +1,596 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const LetsEncryptDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+var sleep func(time.Duration) = time.Sleep
+
+const (
+	acmePollInterval = 3 * time.Second
+	acmePollAttempts = 100
+)
+
+// ACMEAccount is the registration a certificate is issued under. It is kept
+// so that renewals reuse the same account.
+type ACMEAccount struct {
+	Email string
+	Key   string
+	URL   string
+}
+
+// ChallengeRecord is a TXT record that has to be published for a DNS-01
+// challenge to pass.
+type ChallengeRecord struct {
+	Name  string
+	Value string
+}
+
+type IssuedCertificate struct {
+	Certificate string
+	PrivateKey  string
+	Chain       string
+	NotAfter    time.Time
+}
+
+type httpClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// ACMEClient obtains certificates from an ACME v2 server such as Let's
+// Encrypt using DNS-01 challenges.
+type ACMEClient struct {
+	directoryURL string
+	client       httpClient
+}
+
+type acmeDirectory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+type acmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type acmeOrder struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+}
+
+type acmeChallenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+type acmeAuthorization struct {
+	Identifier acmeIdentifier  `json:"identifier"`
+	Status     string          `json:"status"`
+	Challenges []acmeChallenge `json:"challenges"`
+}
+
+type acmeProblem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+type acmeSession struct {
+	client     ACMEClient
+	directory  acmeDirectory
+	key        *ecdsa.PrivateKey
+	accountURL string
+	nonce      string
+}
+
+func NewACMEClient(directoryURL string, client httpClient) ACMEClient {
+	return ACMEClient{
+		directoryURL: directoryURL,
+		client:       client,
+	}
+}
+
+// Issue registers the account if it has not been registered yet, orders a
+// certificate for domains and calls present with the TXT records to publish
+// before the challenges are answered. The account is returned so that it can
+// be saved for renewals.
+func (c ACMEClient) Issue(account ACMEAccount, domains []string, present func([]ChallengeRecord) error) (ACMEAccount, IssuedCertificate, error) {
+	session, err := c.newSession(account)
+	if err != nil {
+		return account, IssuedCertificate{}, err
+	}
+
+	if account.Key == "" {
+		keyDER, err := x509.MarshalECPrivateKey(session.key)
+		if err != nil {
+			return account, IssuedCertificate{}, err
+		}
+		account.Key = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	}
+
+	if account.URL == "" {
+		account.URL, err = session.register(account.Email)
+		if err != nil {
+			return account, IssuedCertificate{}, fmt.Errorf("register acme account: %s", err)
+		}
+	}
+	session.accountURL = account.URL
+
+	certificate, err := session.issue(domains, present)
+	if err != nil {
+		return account, IssuedCertificate{}, err
+	}
+
+	return account, certificate, nil
+}
+
+func (c ACMEClient) newSession(account ACMEAccount) (*acmeSession, error) {
+	resp, err := c.get(c.directoryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	session := &acmeSession{client: c}
+	if err := json.NewDecoder(resp.Body).Decode(&session.directory); err != nil {
+		return nil, fmt.Errorf("read acme directory: %s", err)
+	}
+
+	if account.Key == "" {
+		session.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		block, _ := pem.Decode([]byte(account.Key))
+		if block == nil {
+			return nil, errors.New("acme account key is not PEM encoded")
+		}
+		session.key, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse acme account key: %s", err)
+		}
+	}
+
+	return session, nil
+}
+
+func (c ACMEClient) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, acmeResponseError(resp)
+	}
+
+	return resp, nil
+}
+
+func (s *acmeSession) register(email string) (string, error) {
+	payload := map[string]interface{}{"termsOfServiceAgreed": true}
+	if email != "" {
+		payload["contact"] = []string{"mailto:" + email}
+	}
+
+	resp, err := s.post(s.directory.NewAccount, payload, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return resp.Header.Get("Location"), nil
+}
+
+func (s *acmeSession) issue(domains []string, present func([]ChallengeRecord) error) (IssuedCertificate, error) {
+	identifiers := []acmeIdentifier{}
+	for _, domain := range domains {
+		identifiers = append(identifiers, acmeIdentifier{Type: "dns", Value: domain})
+	}
+
+	var order acmeOrder
+	resp, err := s.post(s.directory.NewOrder, map[string]interface{}{"identifiers": identifiers}, &order)
+	if err != nil {
+		return IssuedCertificate{}, fmt.Errorf("create acme order: %s", err)
+	}
+	resp.Body.Close()
+	orderURL := resp.Header.Get("Location")
+
+	records := []ChallengeRecord{}
+	pending := []string{}
+	challenges := map[string]acmeChallenge{}
+	for _, authorizationURL := range order.Authorizations {
+		var authorization acmeAuthorization
+		if err := s.postAsGet(authorizationURL, &authorization); err != nil {
+			return IssuedCertificate{}, err
+		}
+
+		if authorization.Status == "valid" {
+			continue
+		}
+
+		challenge, ok := dnsChallenge(authorization.Challenges)
+		if !ok {
+			return IssuedCertificate{}, fmt.Errorf("acme server did not offer a dns-01 challenge for %s", authorization.Identifier.Value)
+		}
+
+		value, err := s.challengeValue(challenge.Token)
+		if err != nil {
+			return IssuedCertificate{}, err
+		}
+
+		records = append(records, ChallengeRecord{
+			Name:  "_acme-challenge." + strings.TrimPrefix(authorization.Identifier.Value, "*."),
+			Value: value,
+		})
+		pending = append(pending, authorizationURL)
+		challenges[authorizationURL] = challenge
+	}
+
+	if len(records) > 0 {
+		if err := present(records); err != nil {
+			return IssuedCertificate{}, err
+		}
+	}
+
+	for _, authorizationURL := range pending {
+		resp, err := s.post(challenges[authorizationURL].URL, map[string]interface{}{}, nil)
+		if err != nil {
+			return IssuedCertificate{}, fmt.Errorf("answer dns-01 challenge: %s", err)
+		}
+		resp.Body.Close()
+
+		if err := s.waitForAuthorization(authorizationURL); err != nil {
+			return IssuedCertificate{}, err
+		}
+	}
+
+	certificateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, certificateKey)
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+
+	resp, err = s.post(order.Finalize, map[string]interface{}{"csr": base64.RawURLEncoding.EncodeToString(csr)}, &order)
+	if err != nil {
+		return IssuedCertificate{}, fmt.Errorf("finalize acme order: %s", err)
+	}
+	resp.Body.Close()
+
+	for attempt := 0; order.Status != "valid"; attempt++ {
+		if order.Status == "invalid" || attempt == acmePollAttempts {
+			return IssuedCertificate{}, fmt.Errorf("acme order is %s", order.Status)
+		}
+		sleep(acmePollInterval)
+
+		if err := s.postAsGet(orderURL, &order); err != nil {
+			return IssuedCertificate{}, err
+		}
+	}
+
+	resp, err = s.post(order.Certificate, nil, nil)
+	if err != nil {
+		return IssuedCertificate{}, fmt.Errorf("download certificate: %s", err)
+	}
+	defer resp.Body.Close()
+
+	bundle, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+
+	return certificateFromBundle(bundle, certificateKey)
+}
+
+func (s *acmeSession) waitForAuthorization(authorizationURL string) error {
+	for attempt := 0; attempt < acmePollAttempts; attempt++ {
+		var authorization acmeAuthorization
+		if err := s.postAsGet(authorizationURL, &authorization); err != nil {
+			return err
+		}
+
+		switch authorization.Status {
+		case "valid":
+			return nil
+		case "pending", "processing":
+			sleep(acmePollInterval)
+		default:
+			return fmt.Errorf("dns-01 challenge for %s is %s, check that the domain's name servers are delegated to the zone bbl manages", authorization.Identifier.Value, authorization.Status)
+		}
+	}
+
+	return fmt.Errorf("timed out waiting for the dns-01 challenge at %s", authorizationURL)
+}
+
+func (s *acmeSession) challengeValue(token string) (string, error) {
+	jwk, err := json.Marshal(s.jwk())
+	if err != nil {
+		return "", err
+	}
+
+	thumbprint := sha256.Sum256(jwk)
+	keyAuthorization := token + "." + base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	digest := sha256.Sum256([]byte(keyAuthorization))
+
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
+
+// jwk returns the account's public key with its members in lexicographic
+// order, as required for the thumbprint.
+func (s *acmeSession) jwk() interface{} {
+	return struct {
+		Crv string `json:"crv"`
+		Kty string `json:"kty"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}{
+		Crv: "P-256",
+		Kty: "EC",
+		X:   base64.RawURLEncoding.EncodeToString(paddedBytes(s.key.X, 32)),
+		Y:   base64.RawURLEncoding.EncodeToString(paddedBytes(s.key.Y, 32)),
+	}
+}
+
+func (s *acmeSession) postAsGet(url string, v interface{}) error {
+	resp, err := s.post(url, nil, v)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// post sends a JWS signed request. A nil payload sends a POST-as-GET. When v is
+// given the response body is decoded into it.
+func (s *acmeSession) post(url string, payload interface{}, v interface{}) (*http.Response, error) {
+	resp, err := s.signedPost(url, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		problem := readProblem(resp)
+		resp.Body.Close()
+		if problem.Type != "urn:ietf:params:acme:error:badNonce" {
+			return nil, fmt.Errorf("%s: %s", problem.Type, problem.Detail)
+		}
+
+		resp, err = s.signedPost(url, payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, acmeResponseError(resp)
+	}
+
+	if v != nil {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(body, v); err != nil {
+			return nil, fmt.Errorf("read acme response from %s: %s", url, err)
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
+
+func (s *acmeSession) signedPost(url string, payload interface{}) (*http.Response, error) {
+	if s.nonce == "" {
+		req, err := http.NewRequest("HEAD", s.directory.NewNonce, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.client.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		s.nonce = resp.Header.Get("Replay-Nonce")
+	}
+
+	body, err := s.sign(url, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/jose+json")
+
+	resp, err := s.client.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	s.nonce = resp.Header.Get("Replay-Nonce")
+
+	return resp, nil
+}
+
+func (s *acmeSession) sign(url string, payload interface{}) ([]byte, error) {
+	protected := map[string]interface{}{
+		"alg":   "ES256",
+		"nonce": s.nonce,
+		"url":   url,
+	}
+	if s.accountURL == "" {
+		protected["jwk"] = s.jwk()
+	} else {
+		protected["kid"] = s.accountURL
+	}
+
+	protectedJSON, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadJSON := []byte{}
+	if payload != nil {
+		payloadJSON, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	encodedProtected := base64.RawURLEncoding.EncodeToString(protectedJSON)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	digest := sha256.Sum256([]byte(encodedProtected + "." + encodedPayload))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	signature := append(paddedBytes(r, 32), paddedBytes(sig, 32)...)
+
+	return json.Marshal(map[string]string{
+		"protected": encodedProtected,
+		"payload":   encodedPayload,
+		"signature": base64.RawURLEncoding.EncodeToString(signature),
+	})
+}
+
+func dnsChallenge(challenges []acmeChallenge) (acmeChallenge, bool) {
+	for _, challenge := range challenges {
+		if challenge.Type == "dns-01" {
+			return challenge, true
+		}
+	}
+
+	return acmeChallenge{}, false
+}
+
+func certificateFromBundle(bundle []byte, key *rsa.PrivateKey) (IssuedCertificate, error) {
+	leafBlock, rest := pem.Decode(bundle)
+	if leafBlock == nil {
+		return IssuedCertificate{}, errors.New("acme server returned a certificate that is not PEM encoded")
+	}
+
+	leaf, err := x509.ParseCertificate(leafBlock.Bytes)
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+
+	chain := strings.TrimSpace(string(rest))
+	if chain != "" {
+		chain += "\n"
+	}
+
+	return IssuedCertificate{
+		Certificate: string(pem.EncodeToMemory(leafBlock)),
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		Chain:       chain,
+		NotAfter:    leaf.NotAfter,
+	}, nil
+}
+
+// SelfSignedCertificate creates a short lived certificate for domains. It is
+// used while the load balancers and DNS zone that a trusted certificate has to
+// be validated against do not exist yet.
+func SelfSignedCertificate(domains []string) (IssuedCertificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+
+	notAfter := now().Add(7 * 24 * time.Hour)
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: domains[0]},
+		DNSNames:              domains,
+		NotBefore:             now(),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+
+	return IssuedCertificate{
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		NotAfter:    notAfter,
+	}, nil
+}
+
+func readProblem(resp *http.Response) acmeProblem {
+	var problem acmeProblem
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &problem); err != nil || problem.Type == "" {
+		problem.Type = resp.Status
+		problem.Detail = strings.TrimSpace(string(body))
+	}
+
+	return problem
+}
+
+func acmeResponseError(resp *http.Response) error {
+	problem := readProblem(resp)
+	return fmt.Errorf("%s: %s", problem.Type, problem.Detail)
+}
+
+func paddedBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
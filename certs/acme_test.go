@@ -0,0 +1,287 @@
+package certs_test
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeACMEServer struct {
+	mutex sync.Mutex
+
+	server             *httptest.Server
+	leaf               certs.IssuedCertificate
+	intermediate       certs.IssuedCertificate
+	answered           map[string]bool
+	accountsRegistered int
+	finalizedCSR       *x509.CertificateRequest
+	protectedHeaders   map[string][]map[string]interface{}
+	authorizationState string
+}
+
+func newFakeACMEServer() *fakeACMEServer {
+	f := &fakeACMEServer{
+		answered:           map[string]bool{},
+		protectedHeaders:   map[string][]map[string]interface{}{},
+		authorizationState: "valid",
+	}
+
+	var err error
+	f.leaf, err = certs.SelfSignedCertificate([]string{"*.example.com", "*.ws.example.com"})
+	Expect(err).NotTo(HaveOccurred())
+	f.intermediate, err = certs.SelfSignedCertificate([]string{"intermediate"})
+	Expect(err).NotTo(HaveOccurred())
+
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+func (f *fakeACMEServer) url(path string) string {
+	return f.server.URL + path
+}
+
+func (f *fakeACMEServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	w.Header().Set("Replay-Nonce", "some-nonce")
+
+	if r.Method == "GET" && r.URL.Path == "/directory" {
+		json.NewEncoder(w).Encode(map[string]string{
+			"newNonce":   f.url("/new-nonce"),
+			"newAccount": f.url("/new-account"),
+			"newOrder":   f.url("/new-order"),
+		})
+		return
+	}
+
+	if r.Method == "HEAD" {
+		return
+	}
+
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"type":"urn:ietf:params:acme:error:malformed","detail":"not found"}`)
+		return
+	}
+
+	var jws map[string]string
+	Expect(json.NewDecoder(r.Body).Decode(&jws)).To(Succeed())
+
+	protectedJSON, err := base64.RawURLEncoding.DecodeString(jws["protected"])
+	Expect(err).NotTo(HaveOccurred())
+	var protected map[string]interface{}
+	Expect(json.Unmarshal(protectedJSON, &protected)).To(Succeed())
+	f.protectedHeaders[r.URL.Path] = append(f.protectedHeaders[r.URL.Path], protected)
+
+	payload, err := base64.RawURLEncoding.DecodeString(jws["payload"])
+	Expect(err).NotTo(HaveOccurred())
+
+	switch {
+	case r.URL.Path == "/new-account":
+		f.accountsRegistered++
+		w.Header().Set("Location", f.url("/account/1"))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"status":"valid"}`)
+	case r.URL.Path == "/new-order":
+		w.Header().Set("Location", f.url("/order/1"))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":         "pending",
+			"authorizations": []string{f.url("/authz/root"), f.url("/authz/ws")},
+			"finalize":       f.url("/finalize"),
+		})
+	case strings.HasPrefix(r.URL.Path, "/authz/"):
+		name := strings.TrimPrefix(r.URL.Path, "/authz/")
+		domain := "example.com"
+		if name == "ws" {
+			domain = "ws.example.com"
+		}
+
+		status := "pending"
+		if f.answered[name] {
+			status = f.authorizationState
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"identifier": map[string]string{"type": "dns", "value": domain},
+			"status":     status,
+			"wildcard":   true,
+			"challenges": []map[string]string{
+				{"type": "http-01", "url": f.url("/challenge/http/" + name), "token": "http-token"},
+				{"type": "dns-01", "url": f.url("/challenge/dns/" + name), "token": "dns-token-" + name},
+			},
+		})
+	case strings.HasPrefix(r.URL.Path, "/challenge/dns/"):
+		f.answered[strings.TrimPrefix(r.URL.Path, "/challenge/dns/")] = true
+		fmt.Fprint(w, `{"status":"processing"}`)
+	case r.URL.Path == "/finalize":
+		var request map[string]string
+		Expect(json.Unmarshal(payload, &request)).To(Succeed())
+		der, err := base64.RawURLEncoding.DecodeString(request["csr"])
+		Expect(err).NotTo(HaveOccurred())
+		f.finalizedCSR, err = x509.ParseCertificateRequest(der)
+		Expect(err).NotTo(HaveOccurred())
+
+		fmt.Fprint(w, `{"status":"processing"}`)
+	case r.URL.Path == "/order/1":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":      "valid",
+			"certificate": f.url("/certificate"),
+		})
+	case r.URL.Path == "/certificate":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		fmt.Fprint(w, f.leaf.Certificate+f.intermediate.Certificate)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"type":"urn:ietf:params:acme:error:malformed","detail":"not found"}`)
+	}
+}
+
+var _ = Describe("ACMEClient", func() {
+	var (
+		server  *fakeACMEServer
+		client  certs.ACMEClient
+		domains []string
+		records []certs.ChallengeRecord
+		present func([]certs.ChallengeRecord) error
+	)
+
+	BeforeEach(func() {
+		certs.SetSleep(func(time.Duration) {})
+
+		server = newFakeACMEServer()
+		client = certs.NewACMEClient(server.url("/directory"), http.DefaultClient)
+		domains = []string{"*.example.com", "*.ws.example.com"}
+
+		records = nil
+		present = func(r []certs.ChallengeRecord) error {
+			records = r
+			return nil
+		}
+	})
+
+	AfterEach(func() {
+		server.server.Close()
+		certs.ResetSleep()
+	})
+
+	Describe("Issue", func() {
+		It("registers an account and returns a certificate for the domains", func() {
+			account, certificate, err := client.Issue(certs.ACMEAccount{Email: "me@example.com"}, domains, present)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(server.accountsRegistered).To(Equal(1))
+			Expect(account.Email).To(Equal("me@example.com"))
+			Expect(account.URL).To(Equal(server.url("/account/1")))
+			Expect(account.Key).To(ContainSubstring("EC PRIVATE KEY"))
+
+			Expect(certificate.Certificate).To(Equal(server.leaf.Certificate))
+			Expect(certificate.Chain).To(Equal(server.intermediate.Certificate))
+			Expect(certificate.NotAfter).To(BeTemporally("~", server.leaf.NotAfter, time.Second))
+
+			keyBlock, _ := pem.Decode([]byte(certificate.PrivateKey))
+			Expect(keyBlock).NotTo(BeNil())
+			key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.finalizedCSR.PublicKey).To(Equal(&key.PublicKey))
+			Expect(server.finalizedCSR.DNSNames).To(Equal(domains))
+		})
+
+		It("presents a dns-01 record for each authorization", func() {
+			_, _, err := client.Issue(certs.ACMEAccount{}, domains, present)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].Name).To(Equal("_acme-challenge.example.com"))
+			Expect(records[1].Name).To(Equal("_acme-challenge.ws.example.com"))
+			Expect(records[0].Value).To(MatchRegexp(`^[A-Za-z0-9_-]{43}$`))
+			Expect(records[0].Value).NotTo(Equal(records[1].Value))
+			Expect(server.answered).To(Equal(map[string]bool{"root": true, "ws": true}))
+		})
+
+		It("signs the registration with the key and later requests with the account url", func() {
+			_, _, err := client.Issue(certs.ACMEAccount{}, domains, present)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(server.protectedHeaders["/new-account"][0]).To(HaveKey("jwk"))
+			Expect(server.protectedHeaders["/new-order"][0]).To(HaveKeyWithValue("kid", server.url("/account/1")))
+			Expect(server.protectedHeaders["/new-order"][0]).To(HaveKeyWithValue("alg", "ES256"))
+			Expect(server.protectedHeaders["/new-order"][0]).To(HaveKeyWithValue("url", server.url("/new-order")))
+		})
+
+		Context("when the account is already registered", func() {
+			It("reuses the account", func() {
+				account, _, err := client.Issue(certs.ACMEAccount{}, domains, present)
+				Expect(err).NotTo(HaveOccurred())
+
+				renewedAccount, _, err := client.Issue(account, domains, present)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(renewedAccount).To(Equal(account))
+				Expect(server.accountsRegistered).To(Equal(1))
+			})
+		})
+
+		Context("when presenting the records fails", func() {
+			It("returns the error without answering the challenges", func() {
+				_, _, err := client.Issue(certs.ACMEAccount{}, domains, func([]certs.ChallengeRecord) error {
+					return fmt.Errorf("terraform apply failed")
+				})
+				Expect(err).To(MatchError("terraform apply failed"))
+				Expect(server.answered).To(BeEmpty())
+			})
+		})
+
+		Context("when a challenge is invalid", func() {
+			It("returns an error", func() {
+				server.authorizationState = "invalid"
+
+				_, _, err := client.Issue(certs.ACMEAccount{}, domains, present)
+				Expect(err).To(MatchError(ContainSubstring("dns-01 challenge for example.com is invalid")))
+			})
+		})
+
+		Context("when the directory cannot be fetched", func() {
+			It("returns an error", func() {
+				client = certs.NewACMEClient(server.url("/missing"), http.DefaultClient)
+
+				_, _, err := client.Issue(certs.ACMEAccount{}, domains, present)
+				Expect(err).To(MatchError("urn:ietf:params:acme:error:malformed: not found"))
+			})
+		})
+
+		Context("when the account key is not PEM encoded", func() {
+			It("returns an error", func() {
+				_, _, err := client.Issue(certs.ACMEAccount{Key: "not-a-key"}, domains, present)
+				Expect(err).To(MatchError("acme account key is not PEM encoded"))
+			})
+		})
+	})
+
+	Describe("SelfSignedCertificate", func() {
+		It("returns a certificate for the domains", func() {
+			certificate, err := certs.SelfSignedCertificate(domains)
+			Expect(err).NotTo(HaveOccurred())
+
+			block, _ := pem.Decode([]byte(certificate.Certificate))
+			Expect(block).NotTo(BeNil())
+			parsed, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.DNSNames).To(Equal(domains))
+			Expect(certificate.PrivateKey).To(ContainSubstring("RSA PRIVATE KEY"))
+		})
+	})
+})
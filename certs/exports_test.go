@@ -4,6 +4,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"time"
 )
 
 func SetReadAll(f func(r io.Reader) ([]byte, error)) {
@@ -21,3 +22,19 @@ func SetStat(f func(name string) (os.FileInfo, error)) {
 func ResetStat() {
 	stat = os.Stat
 }
+
+func SetSleep(f func(time.Duration)) {
+	sleep = f
+}
+
+func ResetSleep() {
+	sleep = time.Sleep
+}
+
+func SetNow(f func() time.Time) {
+	now = f
+}
+
+func ResetNow() {
+	now = time.Now
+}
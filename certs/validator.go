@@ -2,7 +2,6 @@ package certs
 
 import (
 	"crypto/rsa"
-	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -10,158 +9,289 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/cloudfoundry/multierror"
 )
 
 var readAll func(r io.Reader) ([]byte, error) = ioutil.ReadAll
 var stat func(name string) (os.FileInfo, error) = os.Stat
+var now func() time.Time = time.Now
 
 type Validator struct{}
 
+// Problem is the specific reason a certificate, key or chain failed validation.
+type Problem string
+
+const (
+	ProblemMissing         Problem = "missing"
+	ProblemNotFound        Problem = "not found"
+	ProblemUnreadable      Problem = "unreadable"
+	ProblemMalformed       Problem = "malformed"
+	ProblemExpired         Problem = "expired"
+	ProblemNotYetValid     Problem = "not yet valid"
+	ProblemKeyMismatch     Problem = "key mismatch"
+	ProblemChainIncomplete Problem = "chain incomplete"
+	ProblemChainOutOfOrder Problem = "chain out of order"
+)
+
+// Failure records which file failed validation and why.
+type Failure struct {
+	File    string
+	Flag    string
+	Path    string
+	Problem Problem
+	Err     error
+}
+
+func (f Failure) Error() string {
+	return f.Err.Error()
+}
+
+// ValidationError is returned by Validate with every failure it found.
+type ValidationError struct {
+	Command  string
+	Failures []Failure
+}
+
+func (e ValidationError) Error() string {
+	validateErrors := multierror.NewMultiError(e.Command)
+	for _, failure := range e.Failures {
+		validateErrors.Add(failure)
+	}
+	return validateErrors.Error()
+}
+
+// Certificate describes a certificate that passed validation.
+type Certificate struct {
+	Subject   string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
 func NewValidator() Validator {
 	return Validator{}
 }
 
-func (v Validator) Validate(command, certPath, keyPath, chainPath string) error {
-	var err error
-	var certificateData []byte
+func (v Validator) Validate(command, certPath, keyPath, chainPath string) (Certificate, error) {
+	var failures []Failure
 	var chainData []byte
-	var certificate *x509.Certificate
-	var privateKey *rsa.PrivateKey
-
-	validateErrors := multierror.NewMultiError(command)
 
-	if certificateData, err = validateFileAndFormat("certificate", "--cert", certPath); err != nil {
-		validateErrors.Add(err)
+	certificateData, failure := validateFileAndFormat("certificate", "--cert", certPath)
+	if failure != nil {
+		failures = append(failures, *failure)
 	}
 
-	if _, err = validateFileAndFormat("key", "--key", keyPath); err != nil {
-		validateErrors.Add(err)
+	keyData, failure := validateFileAndFormat("key", "--key", keyPath)
+	if failure != nil {
+		failures = append(failures, *failure)
 	}
 
 	if chainPath != "" {
-		if chainData, err = validateFileAndFormat("chain", "--chain", chainPath); err != nil {
-			validateErrors.Add(err)
+		chainData, failure = validateFileAndFormat("chain", "--chain", chainPath)
+		if failure != nil {
+			failures = append(failures, *failure)
 		}
 	}
 
-	if validateErrors.Length() > 0 {
-		return validateErrors
+	if len(failures) > 0 {
+		return Certificate{}, ValidationError{Command: command, Failures: failures}
 	}
 
-	tlsCertificateStruct, err := tls.LoadX509KeyPair(certPath, keyPath)
+	certificateFailure := func(problem Problem, err error) Failure {
+		return Failure{File: "certificate", Flag: "--cert", Path: certPath, Problem: problem, Err: err}
+	}
+
+	certificate, err := parseCertificate(certificateData)
 	if err != nil {
-		validateErrors.Add(err)
-	} else {
-		privateKey = tlsCertificateStruct.PrivateKey.(*rsa.PrivateKey)
+		failures = append(failures, certificateFailure(ProblemMalformed, err))
+	} else if err := validateValidity(certificate); err != nil {
+		failures = append(failures, certificateFailure(validityProblem(certificate), err))
 	}
-	if certificate == nil {
-		loadKeyPairError := err
-		certificate, err = parseCertificate(certificateData, loadKeyPairError)
-		if err != nil {
-			validateErrors.Add(err)
-		}
+
+	privateKey, err := parsePrivateKey(keyData)
+	if err != nil {
+		failures = append(failures, Failure{File: "key", Flag: "--key", Path: keyPath, Problem: ProblemMalformed, Err: err})
 	}
 
-	var certPool *x509.CertPool
+	var chain []*x509.Certificate
 	if chainPath != "" {
-		certPool, err = parseChain(chainData)
+		chain, err = parseChain(chainData)
 		if err != nil {
-			validateErrors.Add(err)
+			failures = append(failures, Failure{File: "chain", Flag: "--chain", Path: chainPath, Problem: ProblemMalformed, Err: err})
 		}
 	}
 
 	if privateKey != nil && certificate != nil {
 		if err := validateCertAndKey(certificate, privateKey); err != nil {
-			validateErrors.Add(err)
+			failures = append(failures, Failure{File: "key", Flag: "--key", Path: keyPath, Problem: ProblemKeyMismatch, Err: err})
 		}
 	}
 
-	if certPool != nil && certificate != nil {
-		if err := validateCertAndChain(certificate, certPool); err != nil {
-			validateErrors.Add(err)
+	if chain != nil && certificate != nil {
+		if problem, err := validateCertAndChain(certificate, chain); err != nil {
+			failures = append(failures, Failure{File: "chain", Flag: "--chain", Path: chainPath, Problem: problem, Err: err})
 		}
 	}
 
-	if validateErrors.Length() > 0 {
-		return validateErrors
+	if len(failures) > 0 {
+		return Certificate{}, ValidationError{Command: command, Failures: failures}
 	}
 
-	return nil
+	return Certificate{
+		Subject:   certificate.Subject.CommonName,
+		NotBefore: certificate.NotBefore,
+		NotAfter:  certificate.NotAfter,
+	}, nil
 }
 
-func validateFileAndFormat(propertyName string, flagName string, filePath string) ([]byte, error) {
+func validateFileAndFormat(propertyName string, flagName string, filePath string) ([]byte, *Failure) {
+	fail := func(problem Problem, err error) ([]byte, *Failure) {
+		return []byte{}, &Failure{File: propertyName, Flag: flagName, Path: filePath, Problem: problem, Err: err}
+	}
+
 	if filePath == "" {
-		return []byte{}, fmt.Errorf("%s is required", flagName)
+		return fail(ProblemMissing, fmt.Errorf("%s is required", flagName))
 	}
 
 	file, err := os.Open(filePath)
 	if os.IsNotExist(err) {
-		return []byte{}, fmt.Errorf(`%s file not found: %q`, propertyName, filePath)
+		return fail(ProblemNotFound, fmt.Errorf(`%s file not found: %q`, propertyName, filePath))
 	} else if err != nil {
-		return []byte{}, err
+		return fail(ProblemUnreadable, err)
 	}
+	defer file.Close()
 
 	fileInfo, err := stat(file.Name())
 	if err != nil {
-		return []byte{}, fmt.Errorf("%s: %s", err, filePath)
+		return fail(ProblemUnreadable, fmt.Errorf("%s: %s", err, filePath))
 	}
 
 	if !fileInfo.Mode().IsRegular() {
-		return []byte{}, fmt.Errorf(`%s is not a regular file: %q`, propertyName, filePath)
+		return fail(ProblemUnreadable, fmt.Errorf(`%s is not a regular file: %q`, propertyName, filePath))
 	}
 
 	fileData, err := readAll(file)
 	if err != nil {
-		return []byte{}, fmt.Errorf("%s: %s", err, filePath)
+		return fail(ProblemUnreadable, fmt.Errorf("%s: %s", err, filePath))
 	}
 
 	p, _ := pem.Decode(fileData)
 	if p == nil {
-		return []byte{}, fmt.Errorf("%s is not PEM encoded: %q", propertyName, filePath)
+		return fail(ProblemMalformed, fmt.Errorf("%s is not PEM encoded: %q", propertyName, filePath))
 	}
 
 	return fileData, nil
 }
 
+func validateValidity(certificate *x509.Certificate) error {
+	currentTime := now()
+	if currentTime.After(certificate.NotAfter) {
+		return fmt.Errorf("certificate expired on %s", certificate.NotAfter.UTC().Format(time.RFC3339))
+	}
+	if currentTime.Before(certificate.NotBefore) {
+		return fmt.Errorf("certificate is not valid until %s", certificate.NotBefore.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func validityProblem(certificate *x509.Certificate) Problem {
+	if now().After(certificate.NotAfter) {
+		return ProblemExpired
+	}
+	return ProblemNotYetValid
+}
+
 func validateCertAndKey(certificate *x509.Certificate, privateKey *rsa.PrivateKey) error {
-	publicKey := certificate.PublicKey.(*rsa.PublicKey)
-	if privateKey.PublicKey.N.Cmp(publicKey.N) != 0 || privateKey.PublicKey.E != publicKey.E {
+	publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+	if !ok || privateKey.PublicKey.N.Cmp(publicKey.N) != 0 || privateKey.PublicKey.E != publicKey.E {
 		return errors.New("certificate and key mismatch")
 	}
 
 	return nil
 }
 
-func validateCertAndChain(certificate *x509.Certificate, certPool *x509.CertPool) error {
+// validateCertAndChain checks that the chain leads from the certificate to a
+// trusted certificate, and that each certificate in the chain is followed by
+// its issuer.
+func validateCertAndChain(certificate *x509.Certificate, chain []*x509.Certificate) (Problem, error) {
+	roots := x509.NewCertPool()
+	for _, chainCertificate := range chain {
+		roots.AddCert(chainCertificate)
+	}
+
 	opts := x509.VerifyOptions{
-		Roots: certPool,
+		Roots:       roots,
+		CurrentTime: now(),
 	}
 
 	if _, err := certificate.Verify(opts); err != nil {
-		return fmt.Errorf("certificate and chain mismatch: %s", err.Error())
+		if invalid, ok := err.(x509.CertificateInvalidError); ok && invalid.Reason == x509.Expired {
+			return ProblemExpired, fmt.Errorf("certificate and chain mismatch: %s", err.Error())
+		}
+		return ProblemChainIncomplete, fmt.Errorf("certificate and chain mismatch: %s", err.Error())
 	}
 
-	return nil
+	issued := certificate
+	for _, issuer := range chain {
+		if issued.CheckSignatureFrom(issuer) != nil {
+			return ProblemChainOutOfOrder, fmt.Errorf("chain is out of order: %q is not the issuer of %q", issuer.Subject.CommonName, issued.Subject.CommonName)
+		}
+		issued = issuer
+	}
+
+	return "", nil
 }
 
-func parseCertificate(certificateData []byte, loadKeyPairError error) (*x509.Certificate, error) {
+func parseCertificate(certificateData []byte) (*x509.Certificate, error) {
 	pemCertData, _ := pem.Decode(certificateData)
 	cert, err := x509.ParseCertificate(pemCertData.Bytes)
-	if err != nil && err != loadKeyPairError {
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse certificate: %s", err)
 	}
 
 	return cert, nil
 }
 
-func parseChain(chainData []byte) (*x509.CertPool, error) {
-	roots := x509.NewCertPool()
-	ok := roots.AppendCertsFromPEM(chainData)
-	if !ok {
+func parsePrivateKey(keyData []byte) (*rsa.PrivateKey, error) {
+	pemKeyData, _ := pem.Decode(keyData)
+	if privateKey, err := x509.ParsePKCS1PrivateKey(pemKeyData.Bytes); err == nil {
+		return privateKey, nil
+	}
+
+	if privateKey, err := x509.ParsePKCS8PrivateKey(pemKeyData.Bytes); err == nil {
+		if rsaPrivateKey, ok := privateKey.(*rsa.PrivateKey); ok {
+			return rsaPrivateKey, nil
+		}
+		return nil, errors.New("failed to parse private key: only RSA keys are supported")
+	}
+
+	return nil, errors.New("failed to parse private key")
+}
+
+func parseChain(chainData []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, chainData = pem.Decode(chainData)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chain")
+		}
+		chain = append(chain, certificate)
+	}
+
+	if len(chain) == 0 {
 		return nil, fmt.Errorf("failed to parse chain")
 	}
 
-	return roots, nil
+	return chain, nil
 }
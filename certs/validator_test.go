@@ -1,11 +1,18 @@
 package certs_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"os"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/certs"
 	"github.com/cloudfoundry/bosh-bootloader/testhelpers"
@@ -62,109 +69,122 @@ var _ = Describe("CertificateValidator", func() {
 
 			certs.ResetStat()
 			certs.ResetReadAll()
+			certs.SetNow(func() time.Time {
+				return time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+			})
+		})
+
+		AfterEach(func() {
+			certs.ResetNow()
 		})
 
 		Context("when using a PKCS#1 key", func() {
 			Context("when cert and key are valid", func() {
-				It("does not return an error", func() {
-					err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "")
-
+				It("returns the subject and validity of the certificate", func() {
+					certificate, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "")
 					Expect(err).NotTo(HaveOccurred())
+
+					Expect(certificate).To(Equal(certs.Certificate{
+						Subject:   "bbl-intermediate",
+						NotBefore: time.Date(2016, time.May, 26, 22, 13, 41, 0, time.UTC),
+						NotAfter:  time.Date(2018, time.May, 26, 22, 13, 41, 0, time.UTC),
+					}))
 				})
 			})
 
 			It("does not return an error when cert, key, and chain are valid", func() {
-				err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainFilePath)
+				_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainFilePath)
 
 				Expect(err).NotTo(HaveOccurred())
 			})
 
 			It("returns an error if cert and key are not provided", func() {
-				err := certificateValidator.Validate("some-command-name", "", "", "")
+				_, err := certificateValidator.Validate("some-command-name", "", "", "")
 				expectedErr := multierror.NewMultiError("some-command-name")
 				expectedErr.Add(errors.New("--cert is required"))
 				expectedErr.Add(errors.New("--key is required"))
 
-				Expect(err).To(Equal(expectedErr))
+				Expect(err).To(MatchError(expectedErr.Error()))
 			})
 
 			It("returns an error if the cert key file does not exist", func() {
-				err := certificateValidator.Validate("some-command-name", "/some/fake/cert/path", "/some/fake/key/path", "")
+				_, err := certificateValidator.Validate("some-command-name", "/some/fake/cert/path", "/some/fake/key/path", "")
 				expectedErr := multierror.NewMultiError("some-command-name")
 				expectedErr.Add(errors.New(`certificate file not found: "/some/fake/cert/path"`))
 				expectedErr.Add(errors.New(`key file not found: "/some/fake/key/path"`))
 
-				Expect(err).To(Equal(expectedErr))
+				Expect(err).To(MatchError(expectedErr.Error()))
 			})
 
 			It("returns an error if the cert and key are not regular files", func() {
-				err := certificateValidator.Validate("some-command-name", "/dev/null", "/dev/null", "")
+				_, err := certificateValidator.Validate("some-command-name", "/dev/null", "/dev/null", "")
 				expectedErr := multierror.NewMultiError("some-command-name")
 				expectedErr.Add(errors.New(`certificate is not a regular file: "/dev/null"`))
 				expectedErr.Add(errors.New(`key is not a regular file: "/dev/null"`))
 
-				Expect(err).To(Equal(expectedErr))
+				Expect(err).To(MatchError(expectedErr.Error()))
 			})
 
 			It("returns an error if the cert and key are not PEM encoded", func() {
-				err := certificateValidator.Validate("some-command-name", certNonPEMFilePath, keyNonPEMFilePath, "")
+				_, err := certificateValidator.Validate("some-command-name", certNonPEMFilePath, keyNonPEMFilePath, "")
 
 				expectedErr := multierror.NewMultiError("some-command-name")
 				expectedErr.Add(fmt.Errorf(`certificate is not PEM encoded: %q`, certNonPEMFilePath))
 				expectedErr.Add(fmt.Errorf(`key is not PEM encoded: %q`, keyNonPEMFilePath))
 
-				Expect(err).To(Equal(expectedErr))
+				Expect(err).To(MatchError(expectedErr.Error()))
 			})
 
 			It("returns an error if the key and cert are not compatible", func() {
-				err := certificateValidator.Validate("some-command-name", certFilePath, otherKeyFilePath, "")
+				_, err := certificateValidator.Validate("some-command-name", certFilePath, otherKeyFilePath, "")
 
 				expectedErr := multierror.NewMultiError("some-command-name")
-				expectedErr.Add(errors.New("tls: private key does not match public key"))
-				Expect(err).To(Equal(expectedErr))
+				expectedErr.Add(errors.New("certificate and key mismatch"))
+				Expect(err).To(MatchError(expectedErr.Error()))
+				Expect(err.(certs.ValidationError).Failures[0].Problem).To(Equal(certs.ProblemKeyMismatch))
 			})
 
 			Context("chain is provided", func() {
 				It("returns an error when chain file does not exist", func() {
-					err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "/some/fake/chain/path")
+					_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "/some/fake/chain/path")
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(errors.New(`chain file not found: "/some/fake/chain/path"`))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns an error when chain file is not a regular file", func() {
-					err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "/dev/null")
+					_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "/dev/null")
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(errors.New(`chain is not a regular file: "/dev/null"`))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns an error if the chain is not PEM encoded", func() {
-					err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainNonPEMFilePath)
+					_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainNonPEMFilePath)
 
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(fmt.Errorf(`chain is not PEM encoded: %q`, chainNonPEMFilePath))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns an error if the chain and cert are not compatible", func() {
-					err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, otherChainFilePath)
+					_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, otherChainFilePath)
 
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(errors.New("certificate and chain mismatch: x509: certificate signed by unknown authority"))
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns multiple errors if the cert, key and chain are incompatiable", func() {
-					err := certificateValidator.Validate("some-command-name", certFilePath, otherKeyFilePath, otherChainFilePath)
+					_, err := certificateValidator.Validate("some-command-name", certFilePath, otherKeyFilePath, otherChainFilePath)
 					expectedErr := multierror.NewMultiError("some-command-name")
-					expectedErr.Add(errors.New("tls: private key does not match public key"))
+					expectedErr.Add(errors.New("certificate and key mismatch"))
 					expectedErr.Add(errors.New("certificate and chain mismatch: x509: certificate signed by unknown authority"))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 			})
 
@@ -185,13 +205,13 @@ var _ = Describe("CertificateValidator", func() {
 					keyFile := createTempFile()
 					chainFile := createTempFile()
 
-					err := certificateValidator.Validate("some-command-name", certFile, keyFile, chainFile)
+					_, err := certificateValidator.Validate("some-command-name", certFile, keyFile, chainFile)
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(fmt.Errorf("open %s: permission denied", certFile))
 					expectedErr.Add(fmt.Errorf("open %s: permission denied", keyFile))
 					expectedErr.Add(fmt.Errorf("open %s: permission denied", chainFile))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns an error when file info cannot be retrieved", func() {
@@ -199,14 +219,14 @@ var _ = Describe("CertificateValidator", func() {
 						return nil, errors.New("failed to retrieve file info")
 					})
 
-					err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainFilePath)
+					_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainFilePath)
 
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(fmt.Errorf("failed to retrieve file info: %s", certFilePath))
 					expectedErr.Add(fmt.Errorf("failed to retrieve file info: %s", keyFilePath))
 					expectedErr.Add(fmt.Errorf("failed to retrieve file info: %s", chainFilePath))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns an error when the file cannot be read", func() {
@@ -214,14 +234,14 @@ var _ = Describe("CertificateValidator", func() {
 						return []byte{}, errors.New("bad read")
 					})
 
-					err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainFilePath)
+					_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, chainFilePath)
 
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(fmt.Errorf("bad read: %s", certFilePath))
 					expectedErr.Add(fmt.Errorf("bad read: %s", keyFilePath))
 					expectedErr.Add(fmt.Errorf("bad read: %s", chainFilePath))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns an error when the private key is not valid rsa", func() {
@@ -235,11 +255,11 @@ var _ = Describe("CertificateValidator", func() {
 				`), os.ModePerm)
 					Expect(err).NotTo(HaveOccurred())
 
-					err = certificateValidator.Validate("some-command-name", certFilePath, file.Name(), chainFilePath)
+					_, err = certificateValidator.Validate("some-command-name", certFilePath, file.Name(), chainFilePath)
 					expectedErr := multierror.NewMultiError("some-command-name")
-					expectedErr.Add(errors.New("tls: failed to parse private key"))
+					expectedErr.Add(errors.New("failed to parse private key"))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
 				})
 
 				It("returns an error when the certificate is not valid", func() {
@@ -253,11 +273,9 @@ var _ = Describe("CertificateValidator", func() {
 				`), os.ModePerm)
 					Expect(err).NotTo(HaveOccurred())
 
-					err = certificateValidator.Validate("some-command-name", file.Name(), keyFilePath, chainFilePath)
-					expectedErr := multierror.NewMultiError("some-command-name")
-					expectedErr.Add(errors.New("asn1: syntax error: sequence truncated"))
-
-					Expect(err).To(Equal(expectedErr))
+					_, err = certificateValidator.Validate("some-command-name", file.Name(), keyFilePath, chainFilePath)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse certificate")))
+					Expect(err.(certs.ValidationError).Failures[0].Problem).To(Equal(certs.ProblemMalformed))
 				})
 
 				It("returns an error when the chain is not valid", func() {
@@ -271,19 +289,101 @@ var _ = Describe("CertificateValidator", func() {
 				`), os.ModePerm)
 					Expect(err).NotTo(HaveOccurred())
 
-					err = certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, file.Name())
+					_, err = certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, file.Name())
 					expectedErr := multierror.NewMultiError("some-command-name")
 					expectedErr.Add(errors.New("failed to parse chain"))
 
-					Expect(err).To(Equal(expectedErr))
+					Expect(err).To(MatchError(expectedErr.Error()))
+				})
+			})
+		})
+
+		Context("when the certificate is outside of its validity period", func() {
+			It("returns an error when the certificate has expired", func() {
+				certs.SetNow(func() time.Time {
+					return time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+				})
+
+				_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "")
+				Expect(err).To(MatchError(ContainSubstring("certificate expired on 2018-05-26T22:13:41Z")))
+				Expect(err.(certs.ValidationError).Failures).To(Equal([]certs.Failure{{
+					File:    "certificate",
+					Flag:    "--cert",
+					Path:    certFilePath,
+					Problem: certs.ProblemExpired,
+					Err:     errors.New("certificate expired on 2018-05-26T22:13:41Z"),
+				}}))
+			})
+
+			It("returns an error when the certificate is not valid yet", func() {
+				certs.SetNow(func() time.Time {
+					return time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)
 				})
+
+				_, err := certificateValidator.Validate("some-command-name", certFilePath, keyFilePath, "")
+				Expect(err).To(MatchError(ContainSubstring("certificate is not valid until 2016-05-26T22:13:41Z")))
+				Expect(err.(certs.ValidationError).Failures[0].Problem).To(Equal(certs.ProblemNotYetValid))
+			})
+		})
+
+		Context("when the chain has an intermediate certificate", func() {
+			var (
+				leafFilePath    string
+				leafKeyFilePath string
+				intermediatePEM string
+				rootPEM         string
+			)
+
+			BeforeEach(func() {
+				var (
+					root, intermediate       *x509.Certificate
+					rootKey, intermediateKey *rsa.PrivateKey
+					leafCert, leafKey        string
+					err                      error
+				)
+				root, rootKey, rootPEM, _ = generateCertificate("some-root", nil, nil)
+				intermediate, intermediateKey, intermediatePEM, _ = generateCertificate("some-intermediate", root, rootKey)
+				_, _, leafCert, leafKey = generateCertificate("some-leaf", intermediate, intermediateKey)
+
+				leafFilePath, err = testhelpers.WriteContentsToTempFile(leafCert)
+				Expect(err).NotTo(HaveOccurred())
+
+				leafKeyFilePath, err = testhelpers.WriteContentsToTempFile(leafKey)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error when each certificate is followed by its issuer", func() {
+				chainPath, err := testhelpers.WriteContentsToTempFile(intermediatePEM + rootPEM)
+				Expect(err).NotTo(HaveOccurred())
+
+				certificate, err := certificateValidator.Validate("some-command-name", leafFilePath, leafKeyFilePath, chainPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(certificate.Subject).To(Equal("some-leaf"))
+			})
+
+			It("returns an error when the chain is out of order", func() {
+				chainPath, err := testhelpers.WriteContentsToTempFile(rootPEM + intermediatePEM)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = certificateValidator.Validate("some-command-name", leafFilePath, leafKeyFilePath, chainPath)
+				Expect(err).To(MatchError(ContainSubstring(`chain is out of order: "some-root" is not the issuer of "some-leaf"`)))
+				Expect(err.(certs.ValidationError).Failures[0].Problem).To(Equal(certs.ProblemChainOutOfOrder))
+			})
+
+			It("returns an error when the chain is missing the intermediate certificate", func() {
+				chainPath, err := testhelpers.WriteContentsToTempFile(rootPEM)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = certificateValidator.Validate("some-command-name", leafFilePath, leafKeyFilePath, chainPath)
+				Expect(err).To(MatchError(ContainSubstring("certificate and chain mismatch")))
+				Expect(err.(certs.ValidationError).Failures[0].Problem).To(Equal(certs.ProblemChainIncomplete))
 			})
 		})
 
 		Context("when using a PKCS#8 key", func() {
 			Context("when cert and key are valid", func() {
 				It("does not return an error", func() {
-					err := certificateValidator.Validate("some-command-name", "fixtures/pkcs8.crt", "fixtures/pkcs8.key", "")
+					_, err := certificateValidator.Validate("some-command-name", "fixtures/pkcs8.crt", "fixtures/pkcs8.key", "")
 
 					Expect(err).NotTo(HaveOccurred())
 				})
@@ -291,3 +391,34 @@ var _ = Describe("CertificateValidator", func() {
 		})
 	})
 })
+
+func generateCertificate(commonName string, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey, string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	certificate, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return certificate, key, string(certPEM), string(keyPEM)
+}
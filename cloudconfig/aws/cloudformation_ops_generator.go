@@ -33,6 +33,7 @@ func (a CloudFormationOpsGenerator) Generate(state storage.State) (string, error
 	if err != nil {
 		return "", err
 	}
+	ops = append(ops, spotOps(state)...)
 
 	cloudConfigOpsYAML, err := marshal(ops)
 	if err != nil {
@@ -73,6 +73,21 @@ var _ = Describe("CloudFormationOpsGenerator", func() {
 			Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOpsYAML))
 		})
 
+		It("returns an ops file with a spot vm extension when a spot bid price is set", func() {
+			incomingState.AWS.SpotBidPrice = "0.25"
+			incomingState.AWS.SpotOnDemandFallback = true
+
+			expectedSpotOpsFile, err := ioutil.ReadFile(filepath.Join("fixtures", "aws-spot-ops.yml"))
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedOps := strings.Join([]string{string(expectedOpsYAML), string(expectedSpotOpsFile)}, "\n")
+
+			opsYAML, err := opsGenerator.Generate(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOps))
+		})
+
 		DescribeTable("returns an ops file with additional vm extensions to support lb", func(lbType string, opsFile string, lbOutputs map[string]string) {
 			incomingState.LB.Type = lbType
 
@@ -0,0 +1,36 @@
+package aws
+
+import (
+	"strconv"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type spotVMExtension struct {
+	Name            string
+	CloudProperties spotCloudProperties `yaml:"cloud_properties"`
+}
+
+type spotCloudProperties struct {
+	SpotBidPrice         float64 `yaml:"spot_bid_price"`
+	SpotOnDemandFallback bool    `yaml:"spot_ondemand_fallback"`
+}
+
+// spotOps adds the spot vm_extension that lets instance groups run on spot
+// capacity, when a bid price was given to bbl up.
+func spotOps(state storage.State) []op {
+	price, err := strconv.ParseFloat(state.AWS.SpotBidPrice, 64)
+	if err != nil {
+		return []op{}
+	}
+
+	return []op{
+		createOp("replace", "/vm_extensions/-", spotVMExtension{
+			Name: "spot",
+			CloudProperties: spotCloudProperties{
+				SpotBidPrice:         price,
+				SpotOnDemandFallback: state.AWS.SpotOnDemandFallback,
+			},
+		}),
+	}
+}
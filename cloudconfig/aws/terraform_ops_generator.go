@@ -61,7 +61,8 @@ type lb struct {
 }
 
 type lbCloudProperties struct {
-	ELBs           []string
+	ELBs           []string `yaml:"elbs,omitempty"`
+	LBTargetGroups []string `yaml:"lb_target_groups,omitempty"`
 	SecurityGroups []string `yaml:"security_groups"`
 }
 
@@ -78,6 +79,7 @@ func (a TerraformOpsGenerator) Generate(state storage.State) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	ops = append(ops, spotOps(state)...)
 
 	cloudConfigOpsYAML, err := marshal(ops)
 	if err != nil {
@@ -167,6 +169,15 @@ func (a TerraformOpsGenerator) generateTerraformAWSOps(state storage.State) ([]o
 
 	switch state.LB.Type {
 	case "cf":
+		if state.LB.Flavor == "nlb" {
+			nlbOps, err := generateCFNLBOps(terraformOutputs, internalSecurityGroup)
+			if err != nil {
+				return []op{}, err
+			}
+			ops = append(ops, nlbOps...)
+			break
+		}
+
 		tfOutputs := []map[string]string{
 			map[string]string{"name": "router-lb", "lb": "cf_router_lb_name", "group": "cf_router_lb_internal_security_group"},
 			map[string]string{"name": "ssh-proxy-lb", "lb": "cf_ssh_lb_name", "group": "cf_ssh_lb_internal_security_group"},
@@ -221,6 +232,46 @@ func (a TerraformOpsGenerator) generateTerraformAWSOps(state storage.State) ([]o
 	return ops, nil
 }
 
+func generateCFNLBOps(terraformOutputs map[string]interface{}, internalSecurityGroup string) ([]op, error) {
+	ops := []op{}
+
+	tfOutputs := []map[string]string{
+		map[string]string{"name": "router-lb", "targetGroup": "cf_router_lb_target_group", "group": "cf_router_lb_internal_security_group"},
+		map[string]string{"name": "ssh-proxy-lb", "targetGroup": "cf_ssh_lb_target_group", "group": "cf_ssh_lb_internal_security_group"},
+		map[string]string{"name": "cf-tcp-router-network-properties", "targetGroup": "cf_tcp_lb_target_groups", "group": "cf_tcp_lb_internal_security_group"},
+	}
+
+	for _, details := range tfOutputs {
+		var targetGroups []string
+		switch targetGroup := terraformOutputs[details["targetGroup"]].(type) {
+		case string:
+			targetGroups = []string{targetGroup}
+		case []string:
+			targetGroups = targetGroup
+		default:
+			return []op{}, fmt.Errorf("missing %s terraform output", details["targetGroup"])
+		}
+
+		grp, ok := terraformOutputs[details["group"]].(string)
+		if !ok {
+			return []op{}, fmt.Errorf("missing %s terraform output", details["group"])
+		}
+
+		ops = append(ops, createOp("replace", "/vm_extensions/-", lb{
+			Name: details["name"],
+			CloudProperties: lbCloudProperties{
+				LBTargetGroups: targetGroups,
+				SecurityGroups: []string{
+					grp,
+					internalSecurityGroup,
+				},
+			},
+		}))
+	}
+
+	return ops, nil
+}
+
 func generateNetworkSubnet(az, cidr, subnet, securityGroup string) (networkSubnet, error) {
 	parsedCidr, err := bosh.ParseCIDRBlock(cidr)
 	if err != nil {
@@ -46,6 +46,9 @@ var _ = Describe("TerraformOpsGenerator", func() {
 				"cf_ssh_lb_internal_security_group":    "some-cf-ssh-lb-internal-security-group",
 				"cf_tcp_lb_name":                       "some-cf-tcp-lb-name",
 				"cf_tcp_lb_internal_security_group":    "some-cf-tcp-lb-internal-security-group",
+				"cf_router_lb_target_group":            "some-cf-router-lb-target-group",
+				"cf_ssh_lb_target_group":               "some-cf-ssh-lb-target-group",
+				"cf_tcp_lb_target_groups":              []string{"some-cf-tcp-lb-target-group-1024", "some-cf-tcp-lb-target-group-1025"},
 				"concourse_lb_name":                    "some-concourse-lb-name",
 				"concourse_lb_internal_security_group": "some-concourse-lb-internal-security-group",
 				"internal_az_subnet_id_mapping": map[string]interface{}{
@@ -101,6 +104,25 @@ var _ = Describe("TerraformOpsGenerator", func() {
 			})
 		})
 
+		Context("when there are cf nlbs", func() {
+			BeforeEach(func() {
+				baseOpsYAMLContents, err := ioutil.ReadFile(filepath.Join("fixtures", "aws-ops.yml"))
+				Expect(err).NotTo(HaveOccurred())
+				lbsOpsYAMLContents, err := ioutil.ReadFile(filepath.Join("fixtures", "terraform-aws-cf-nlb-ops.yml"))
+				Expect(err).NotTo(HaveOccurred())
+				expectedOpsYAML = strings.Join([]string{string(baseOpsYAMLContents), string(lbsOpsYAMLContents)}, "\n")
+			})
+
+			It("returns an ops file with target groups in the vm extensions", func() {
+				incomingState.LB.Type = "cf"
+				incomingState.LB.Flavor = "nlb"
+				opsYAML, err := opsGenerator.Generate(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOpsYAML))
+			})
+		})
+
 		Context("when there is a concourse lb", func() {
 			BeforeEach(func() {
 				baseOpsYAMLContents, err := ioutil.ReadFile(filepath.Join("fixtures", "aws-ops.yml"))
@@ -121,6 +143,25 @@ var _ = Describe("TerraformOpsGenerator", func() {
 			})
 		})
 
+		Context("when a spot bid price is set", func() {
+			BeforeEach(func() {
+				baseOpsYAMLContents, err := ioutil.ReadFile(filepath.Join("fixtures", "aws-ops.yml"))
+				Expect(err).NotTo(HaveOccurred())
+				spotOpsYAMLContents, err := ioutil.ReadFile(filepath.Join("fixtures", "aws-spot-ops.yml"))
+				Expect(err).NotTo(HaveOccurred())
+				expectedOpsYAML = strings.Join([]string{string(baseOpsYAMLContents), string(spotOpsYAMLContents)}, "\n")
+			})
+
+			It("returns an ops file with a spot vm extension", func() {
+				incomingState.AWS.SpotBidPrice = "0.25"
+				incomingState.AWS.SpotOnDemandFallback = true
+				opsYAML, err := opsGenerator.Generate(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOpsYAML))
+			})
+		})
+
 		Context("when an error occurs", func() {
 			Context("when terraform fails to get outputs", func() {
 				It("returns an error", func() {
@@ -153,9 +194,14 @@ var _ = Describe("TerraformOpsGenerator", func() {
 
 			DescribeTable("when a terraform output is missing", func(outputKey, lbType string) {
 				delete(terraformManager.GetOutputsCall.Returns.Outputs, outputKey)
+				lbFlavor := ""
+				if strings.HasSuffix(outputKey, "_target_group") || strings.HasSuffix(outputKey, "_target_groups") {
+					lbFlavor = "nlb"
+				}
 				_, err := opsGenerator.Generate(storage.State{
 					LB: storage.LB{
-						Type: lbType,
+						Type:   lbType,
+						Flavor: lbFlavor,
 					},
 				})
 				Expect(err).To(MatchError(fmt.Sprintf("missing %s terraform output", outputKey)))
@@ -170,6 +216,9 @@ var _ = Describe("TerraformOpsGenerator", func() {
 				Entry("when cf_ssh_lb_internal_security_group is missing", "cf_ssh_lb_internal_security_group", "cf"),
 				Entry("when cf_tcp_lb_name", "cf_tcp_lb_name", "cf"),
 				Entry("when cf_tcp_lb_internal_security_group is missing", "cf_tcp_lb_internal_security_group", "cf"),
+				Entry("when cf_router_lb_target_group is missing", "cf_router_lb_target_group", "cf"),
+				Entry("when cf_ssh_lb_target_group is missing", "cf_ssh_lb_target_group", "cf"),
+				Entry("when cf_tcp_lb_target_groups is missing", "cf_tcp_lb_target_groups", "cf"),
 
 				Entry("when concourse_lb_name is missing", "concourse_lb_name", "concourse"),
 				Entry("when concourse_lb_internal_security_group is missing", "concourse_lb_internal_security_group", "concourse"),
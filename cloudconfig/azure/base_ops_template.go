@@ -0,0 +1,93 @@
+package azure
+
+const (
+	BaseOps = `
+- type: replace
+  path: /compilation/vm_type
+  value: Standard_F4
+
+- type: replace
+  path: /vm_types/name=default/cloud_properties?
+  value:
+    instance_type: Standard_D1_v2
+
+- type: replace
+  path: /vm_types/name=minimal/cloud_properties?
+  value:
+    instance_type: Standard_F1
+
+- type: replace
+  path: /vm_types/name=sharedcpu/cloud_properties?
+  value:
+    instance_type: Standard_F1
+
+- type: replace
+  path: /vm_types/name=small/cloud_properties?
+  value:
+    instance_type: Standard_D2_v2
+
+- type: replace
+  path: /vm_types/name=medium/cloud_properties?
+  value:
+    instance_type: Standard_D3_v2
+
+- type: replace
+  path: /vm_types/name=large/cloud_properties?
+  value:
+    instance_type: Standard_D4_v2
+
+- type: replace
+  path: /vm_types/name=extra-large/cloud_properties?
+  value:
+    instance_type: Standard_D5_v2
+
+- type: replace
+  path: /vm_types/-
+  value:
+    name: Standard_F4
+    cloud_properties:
+      instance_type: Standard_F4
+
+- type: replace
+  path: /vm_extensions/name=1GB_ephemeral_disk/cloud_properties?
+  value:
+    ephemeral_disk:
+      size: 1024
+
+- type: replace
+  path: /vm_extensions/name=5GB_ephemeral_disk/cloud_properties?
+  value:
+    ephemeral_disk:
+      size: 5120
+
+- type: replace
+  path: /vm_extensions/name=10GB_ephemeral_disk/cloud_properties?
+  value:
+    ephemeral_disk:
+      size: 10240
+
+- type: replace
+  path: /vm_extensions/name=50GB_ephemeral_disk/cloud_properties?
+  value:
+    ephemeral_disk:
+      size: 51200
+
+- type: replace
+  path: /vm_extensions/name=100GB_ephemeral_disk/cloud_properties?
+  value:
+    ephemeral_disk:
+      size: 102400
+
+- type: replace
+  path: /vm_extensions/name=500GB_ephemeral_disk/cloud_properties?
+  value:
+    ephemeral_disk:
+      size: 512000
+
+- type: replace
+  path: /vm_extensions/name=1TB_ephemeral_disk/cloud_properties?
+  value:
+    ephemeral_disk:
+      size: 1048576
+`
+)
@@ -0,0 +1,171 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// azs are only names, the azure cpi places vms in availability sets instead
+// of zones.
+var azNames = []string{"z1", "z2", "z3"}
+
+type OpsGenerator struct {
+	terraformManager terraformManager
+}
+
+type terraformManager interface {
+	GetOutputs(storage.State) (map[string]interface{}, error)
+}
+
+type op struct {
+	Type  string
+	Path  string
+	Value interface{}
+}
+
+type az struct {
+	Name string `yaml:"name"`
+}
+
+type network struct {
+	Name    string
+	Subnets []networkSubnet
+	Type    string
+}
+
+type networkSubnet struct {
+	AZs             []string `yaml:"azs"`
+	Gateway         string
+	Range           string
+	Reserved        []string
+	Static          []string
+	DNS             []string              `yaml:"dns"`
+	CloudProperties subnetCloudProperties `yaml:"cloud_properties"`
+}
+
+type subnetCloudProperties struct {
+	VirtualNetworkName string `yaml:"virtual_network_name"`
+	SubnetName         string `yaml:"subnet_name"`
+	SecurityGroup      string `yaml:"security_group"`
+}
+
+var marshal func(interface{}) ([]byte, error) = yaml.Marshal
+
+func NewOpsGenerator(terraformManager terraformManager) OpsGenerator {
+	return OpsGenerator{
+		terraformManager: terraformManager,
+	}
+}
+
+func (o OpsGenerator) Generate(state storage.State) (string, error) {
+	ops, err := o.generateAzureOps(state)
+	if err != nil {
+		return "", err
+	}
+
+	cloudConfigOpsYAML, err := marshal(ops)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(
+		[]string{
+			BaseOps,
+			string(cloudConfigOpsYAML),
+		},
+		"\n",
+	), nil
+}
+
+func createOp(opType, opPath string, value interface{}) op {
+	return op{
+		Type:  opType,
+		Path:  opPath,
+		Value: value,
+	}
+}
+
+func (o *OpsGenerator) generateAzureOps(state storage.State) ([]op, error) {
+	terraformOutputs, err := o.terraformManager.GetOutputs(state)
+	if err != nil {
+		return []op{}, err
+	}
+
+	var ops []op
+	for _, name := range azNames {
+		ops = append(ops, createOp("replace", "/azs/-", az{
+			Name: name,
+		}))
+	}
+
+	subnet, err := generateNetworkSubnet(
+		state.Network.NetworkCIDR(),
+		state.Network.DirectorSubnetCIDR(),
+		terraformOutputs["vnet_name"].(string),
+		terraformOutputs["subnet_name"].(string),
+		terraformOutputs["default_security_group"].(string),
+	)
+	if err != nil {
+		return []op{}, err
+	}
+
+	ops = append(ops, createOp("replace", "/networks/-", network{
+		Name:    "private",
+		Subnets: []networkSubnet{subnet},
+		Type:    "manual",
+	}))
+
+	ops = append(ops, createOp("replace", "/networks/-", network{
+		Name:    "default",
+		Subnets: []networkSubnet{subnet},
+		Type:    "manual",
+	}))
+
+	return ops, nil
+}
+
+// generateNetworkSubnet spans the whole azure subnet. The director subnet is
+// reserved so bosh does not hand out the director's address.
+func generateNetworkSubnet(cidr, directorCIDR, vnetName, subnetName, securityGroup string) (networkSubnet, error) {
+	parsedCidr, err := bosh.ParseCIDRBlock(cidr)
+	if err != nil {
+		return networkSubnet{}, err
+	}
+
+	parsedDirectorCidr, err := bosh.ParseCIDRBlock(directorCIDR)
+	if err != nil {
+		return networkSubnet{}, err
+	}
+
+	gateway := parsedCidr.GetFirstIP().Add(1).String()
+	firstReserved := parsedCidr.GetFirstIP().Add(2).String()
+	secondReserved := parsedCidr.GetFirstIP().Add(3).String()
+	lastReserved := parsedCidr.GetLastIP().String()
+	lastStatic := parsedCidr.GetLastIP().Subtract(1).String()
+	firstStatic := parsedCidr.GetLastIP().Subtract(65).String()
+
+	return networkSubnet{
+		AZs:     azNames,
+		Gateway: gateway,
+		Range:   cidr,
+		Reserved: []string{
+			fmt.Sprintf("%s-%s", firstReserved, secondReserved),
+			fmt.Sprintf("%s-%s", parsedDirectorCidr.GetFirstIP().String(), parsedDirectorCidr.GetLastIP().String()),
+			fmt.Sprintf("%s", lastReserved),
+		},
+		Static: []string{
+			fmt.Sprintf("%s-%s", firstStatic, lastStatic),
+		},
+		DNS: []string{"168.63.129.16"},
+		CloudProperties: subnetCloudProperties{
+			VirtualNetworkName: vnetName,
+			SubnetName:         subnetName,
+			SecurityGroup:      securityGroup,
+		},
+	}, nil
+}
@@ -0,0 +1,91 @@
+package azure_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/cloudconfig/azure"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/pivotal-cf-experimental/gomegamatchers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AzureOpsGenerator", func() {
+	Describe("Generate", func() {
+		var (
+			terraformManager *fakes.TerraformManager
+			opsGenerator     azure.OpsGenerator
+
+			incomingState   storage.State
+			expectedOpsFile []byte
+		)
+
+		BeforeEach(func() {
+			terraformManager = &fakes.TerraformManager{}
+
+			incomingState = storage.State{
+				IAAS:    "azure",
+				TFState: "some-tf-state",
+				Azure: storage.Azure{
+					Region: "some-region",
+				},
+			}
+
+			terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+				"vnet_name":              "some-vnet-name",
+				"subnet_name":            "some-subnet-name",
+				"default_security_group": "some-security-group",
+			}
+
+			var err error
+			expectedOpsFile, err = ioutil.ReadFile(filepath.Join("fixtures", "azure-ops.yml"))
+			Expect(err).NotTo(HaveOccurred())
+
+			opsGenerator = azure.NewOpsGenerator(terraformManager)
+		})
+
+		It("returns an ops file to transform base cloud config into azure specific cloud config", func() {
+			opsYAML, err := opsGenerator.Generate(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(incomingState))
+
+			Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOpsFile))
+		})
+
+		Context("when a custom network cidr is in the state", func() {
+			It("uses that network and reserves the director subnet", func() {
+				incomingState.Network.CIDR = "172.16.0.0/16"
+				incomingState.Network.SubnetCIDR = "172.16.8.0/24"
+
+				opsYAML, err := opsGenerator.Generate(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(opsYAML).To(ContainSubstring("range: 172.16.0.0/16"))
+				Expect(opsYAML).To(ContainSubstring("- 172.16.8.0-172.16.8.255"))
+				Expect(opsYAML).NotTo(ContainSubstring("10.0.0.0/16"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when terraform output provider fails to retrieve", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to output")
+				_, err := opsGenerator.Generate(storage.State{})
+				Expect(err).To(MatchError("failed to output"))
+			})
+
+			It("returns an error when ops fail to marshal", func() {
+				azure.SetMarshal(func(interface{}) ([]byte, error) {
+					return []byte{}, errors.New("failed to marshal")
+				})
+				_, err := opsGenerator.Generate(incomingState)
+				Expect(err).To(MatchError("failed to marshal"))
+				azure.ResetMarshal()
+			})
+		})
+	})
+})
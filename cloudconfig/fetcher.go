@@ -0,0 +1,53 @@
+package cloudconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type Fetcher struct {
+	httpClient httpClient
+}
+
+type httpClient interface {
+	Get(url string) (*http.Response, error)
+}
+
+func NewFetcher(httpClient httpClient) Fetcher {
+	return Fetcher{
+		httpClient: httpClient,
+	}
+}
+
+// Fetch downloads a cloud config from url. When checksum is not empty the
+// sha256 of the downloaded config must match it.
+func (f Fetcher) Fetch(url, checksum string) (string, error) {
+	resp, err := f.httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch cloud config from %s: %s", url, resp.Status)
+	}
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if checksum != "" {
+		sum := sha256.Sum256(contents)
+		actual := hex.EncodeToString(sum[:])
+		if actual != strings.ToLower(checksum) {
+			return "", fmt.Errorf("cloud config from %s has sha256 %s, expected %s", url, actual, checksum)
+		}
+	}
+
+	return string(contents), nil
+}
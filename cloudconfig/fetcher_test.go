@@ -0,0 +1,76 @@
+package cloudconfig_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/bosh-bootloader/cloudconfig"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fetcher", func() {
+	var (
+		server  *httptest.Server
+		fetcher cloudconfig.Fetcher
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/cloud-config.yml" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("some-cloud-config"))
+		}))
+
+		fetcher = cloudconfig.NewFetcher(http.DefaultClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the cloud config at the url", func() {
+		cloudConfig, err := fetcher.Fetch(server.URL+"/cloud-config.yml", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cloudConfig).To(Equal("some-cloud-config"))
+	})
+
+	It("verifies the sha256 of the cloud config", func() {
+		cloudConfig, err := fetcher.Fetch(server.URL+"/cloud-config.yml", "BCD3CA7FC48EDC90DD54D5DE15FB9852DDA4DCE5B82F1A8E1CE21814995633ED")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cloudConfig).To(Equal("some-cloud-config"))
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the request fails", func() {
+			fetcher = cloudconfig.NewFetcher(&http.Client{
+				Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to connect")
+				}),
+			})
+
+			_, err := fetcher.Fetch(server.URL+"/cloud-config.yml", "")
+			Expect(err).To(MatchError(ContainSubstring("failed to connect")))
+		})
+
+		It("returns an error when the sha256 does not match", func() {
+			_, err := fetcher.Fetch(server.URL+"/cloud-config.yml", "some-sha256")
+			Expect(err).To(MatchError("cloud config from " + server.URL + "/cloud-config.yml has sha256 bcd3ca7fc48edc90dd54d5de15fb9852dda4dce5b82f1a8e1ce21814995633ed, expected some-sha256"))
+		})
+
+		It("returns an error when the server does not return the cloud config", func() {
+			_, err := fetcher.Fetch(server.URL+"/missing.yml", "")
+			Expect(err).To(MatchError("failed to fetch cloud config from " + server.URL + "/missing.yml: 404 Not Found"))
+		})
+	})
+})
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
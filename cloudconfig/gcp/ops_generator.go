@@ -118,12 +118,21 @@ func (o *OpsGenerator) generateGCPOps(state storage.State) ([]op, error) {
 		}))
 	}
 
+	networkCIDR, err := bosh.ParseCIDRBlock(state.Network.NetworkCIDR())
+	if err != nil {
+		return []op{}, err
+	}
+
 	var subnets []networkSubnet
 	for i, _ := range state.GCP.Zones {
-		cidr := fmt.Sprintf("10.0.%d.0/20", 16*(i+1))
+		cidr, err := networkCIDR.Subnet(4, i+1)
+		if err != nil {
+			return []op{}, err
+		}
+
 		subnet, err := generateNetworkSubnet(
 			fmt.Sprintf("z%d", i+1),
-			cidr,
+			cidr.String(),
 			terraformOutputs["network_name"].(string),
 			terraformOutputs["subnetwork_name"].(string),
 			terraformOutputs["internal_tag_name"].(string),
@@ -62,6 +62,20 @@ var _ = Describe("GCPOpsGenerator", func() {
 			Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOpsFile))
 		})
 
+		Context("when a custom network cidr is in the state", func() {
+			It("carves the zone subnets out of that network", func() {
+				incomingState.Network.CIDR = "172.16.0.0/16"
+
+				opsYAML, err := opsGenerator.Generate(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(opsYAML).To(ContainSubstring("range: 172.16.16.0/20"))
+				Expect(opsYAML).To(ContainSubstring("range: 172.16.32.0/20"))
+				Expect(opsYAML).To(ContainSubstring("range: 172.16.48.0/20"))
+				Expect(opsYAML).NotTo(ContainSubstring("10.0.16.0/20"))
+			})
+		})
+
 		DescribeTable("returns an ops file with additional vm extensions to support lb",
 			func(lbType string, lbOutputs map[string]interface{}) {
 				incomingState.LB.Type = lbType
@@ -7,8 +7,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
 	"golang.org/x/net/proxy"
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -20,6 +23,10 @@ var (
 	proxySOCKS5 func(string, string, *proxy.Auth, proxy.Dialer) (proxy.Dialer, error) = proxy.SOCKS5
 )
 
+// OpsDir is where, under the state dir, user ops files are picked up from and
+// applied on top of the generated cloud config.
+const OpsDir = "cloud-config/ops"
+
 type Manager struct {
 	logger             logger
 	command            command
@@ -28,6 +35,7 @@ type Manager struct {
 	socks5Proxy        socks5Proxy
 	terraformManager   terraformManager
 	sshKeyGetter       sshKeyGetter
+	stateDir           string
 }
 
 type logger interface {
@@ -60,7 +68,7 @@ type sshKeyGetter interface {
 }
 
 func NewManager(logger logger, cmd command, opsGenerator opsGenerator, boshClientProvider boshClientProvider,
-	socks5Proxy socks5Proxy, terraformManager terraformManager, sshKeyGetter sshKeyGetter) Manager {
+	socks5Proxy socks5Proxy, terraformManager terraformManager, sshKeyGetter sshKeyGetter, stateDir string) Manager {
 	return Manager{
 		logger:             logger,
 		command:            cmd,
@@ -69,6 +77,7 @@ func NewManager(logger logger, cmd command, opsGenerator opsGenerator, boshClien
 		socks5Proxy:        socks5Proxy,
 		terraformManager:   terraformManager,
 		sshKeyGetter:       sshKeyGetter,
+		stateDir:           stateDir,
 	}
 }
 
@@ -99,6 +108,15 @@ func (m Manager) Generate(state storage.State) (string, error) {
 		"-o", fmt.Sprintf("%s/ops.yml", workingDir),
 	}
 
+	userOpsFiles, err := m.userOpsFiles()
+	if err != nil {
+		return "", err
+	}
+
+	for _, opsFile := range userOpsFiles {
+		args = append(args, "-o", opsFile)
+	}
+
 	err = m.command.Run(buf, workingDir, args)
 	if err != nil {
 		return "", err
@@ -107,18 +125,120 @@ func (m Manager) Generate(state storage.State) (string, error) {
 	return buf.String(), nil
 }
 
+// userOpsFiles lists the ops files the user put in the ops dir of the state
+// dir, in name order so they apply the same way on every run.
+func (m Manager) userOpsFiles() ([]string, error) {
+	opsDir, err := filepath.Abs(filepath.Join(m.stateDir, OpsDir))
+	if err != nil {
+		return nil, err //not tested
+	}
+
+	opsFiles, err := filepath.Glob(filepath.Join(opsDir, "*.yml"))
+	if err != nil {
+		return nil, err //not tested
+	}
+
+	return opsFiles, nil
+}
+
 func (m Manager) Update(state storage.State) error {
+	boshClient, err := m.directorClient(state)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Step("generating cloud config")
+	cloudConfig, err := m.Generate(state)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Step("applying cloud config")
+	err = boshClient.UpdateCloudConfig([]byte(cloudConfig))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateIfChanged generates the cloud config from the current terraform
+// outputs and uploads it only when it differs from the cloud config the
+// director is using, or when force is set. It reports whether the director
+// was updated.
+func (m Manager) UpdateIfChanged(state storage.State, force bool) (bool, error) {
+	boshClient, err := m.directorClient(state)
+	if err != nil {
+		return false, err
+	}
+
+	m.logger.Step("generating cloud config")
+	cloudConfig, err := m.Generate(state)
+	if err != nil {
+		return false, err
+	}
+
+	if !force {
+		m.logger.Step("comparing with the director's cloud config")
+		currentCloudConfig, err := boshClient.CloudConfig()
+		if err != nil {
+			return false, err
+		}
+
+		if sameCloudConfig(currentCloudConfig, cloudConfig) {
+			return false, nil
+		}
+	}
+
+	m.logger.Step("applying cloud config")
+	err = boshClient.UpdateCloudConfig([]byte(cloudConfig))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Apply updates the director with the given cloud config instead of
+// generating one from the bbl state.
+func (m Manager) Apply(state storage.State, cloudConfig string) error {
+	boshClient, err := m.directorClient(state)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Step("applying cloud config")
+	err = boshClient.UpdateCloudConfig([]byte(cloudConfig))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Current returns the cloud config the director is using.
+func (m Manager) Current(state storage.State) (string, error) {
+	boshClient, err := m.directorClient(state)
+	if err != nil {
+		return "", err
+	}
+
+	m.logger.Step("fetching the director's cloud config")
+	return boshClient.CloudConfig()
+}
+
+func (m Manager) directorClient(state storage.State) (bosh.Client, error) {
 	boshClient := m.boshClientProvider.Client(state.Jumpbox.Enabled, state.BOSH.DirectorAddress, state.BOSH.DirectorUsername, state.BOSH.DirectorPassword, state.BOSH.DirectorSSLCA)
 
 	if state.Jumpbox.Enabled {
 		privateKey, err := m.sshKeyGetter.Get(state)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		terraformOutputs, err := m.terraformManager.GetOutputs(state)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		jumpboxURL := terraformOutputs["jumpbox_url"].(string)
@@ -126,28 +246,27 @@ func (m Manager) Update(state storage.State) error {
 		m.logger.Step("starting socks5 proxy")
 		err = m.socks5Proxy.Start(privateKey, jumpboxURL)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		socks5Client, err := proxySOCKS5("tcp", m.socks5Proxy.Addr(), nil, proxy.Direct)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		boshClient.ConfigureHTTPClient(socks5Client)
 	}
 
-	m.logger.Step("generating cloud config")
-	cloudConfig, err := m.Generate(state)
-	if err != nil {
-		return err
-	}
+	return boshClient, nil
+}
 
-	m.logger.Step("applying cloud config")
-	err = boshClient.UpdateCloudConfig([]byte(cloudConfig))
-	if err != nil {
-		return err
+// sameCloudConfig compares cloud configs as yaml so that formatting
+// differences do not cause an upload.
+func sameCloudConfig(a, b string) bool {
+	var aContents, bContents interface{}
+	if yaml.Unmarshal([]byte(a), &aContents) != nil || yaml.Unmarshal([]byte(b), &bContents) != nil {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
 	}
 
-	return nil
+	return reflect.DeepEqual(aContents, bContents)
 }
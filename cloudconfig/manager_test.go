@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/net/proxy"
@@ -31,6 +32,7 @@ var _ = Describe("Manager", func() {
 		manager            cloudconfig.Manager
 
 		tempDir       string
+		stateDir      string
 		incomingState storage.State
 
 		baseCloudConfig []byte
@@ -52,6 +54,9 @@ var _ = Describe("Manager", func() {
 		tempDir, err = ioutil.TempDir("", "")
 		Expect(err).NotTo(HaveOccurred())
 
+		stateDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
 		cloudconfig.SetTempDir(func(string, string) (string, error) {
 			return tempDir, nil
 		})
@@ -75,7 +80,7 @@ var _ = Describe("Manager", func() {
 		baseCloudConfig, err = ioutil.ReadFile("fixtures/base-cloud-config.yml")
 		Expect(err).NotTo(HaveOccurred())
 
-		manager = cloudconfig.NewManager(logger, cmd, opsGenerator, boshClientProvider, socks5Proxy, terraformManager, sshKeyGetter)
+		manager = cloudconfig.NewManager(logger, cmd, opsGenerator, boshClientProvider, socks5Proxy, terraformManager, sshKeyGetter, stateDir)
 	})
 
 	AfterEach(func() {
@@ -110,6 +115,32 @@ var _ = Describe("Manager", func() {
 			Expect(cloudConfigYAML).To(Equal("some-cloud-config"))
 		})
 
+		Context("when the user has ops files in the state dir", func() {
+			BeforeEach(func() {
+				opsDir := filepath.Join(stateDir, "cloud-config", "ops")
+				err := os.MkdirAll(opsDir, os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, name := range []string{"vm-types.yml", "extensions.yml", "README.md"} {
+					err = ioutil.WriteFile(filepath.Join(opsDir, name), []byte("some-user-ops"), os.ModePerm)
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			It("applies them in name order after the generated ops", func() {
+				_, err := manager.Generate(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, args := cmd.RunArgsForCall(0)
+				Expect(args).To(Equal([]string{
+					"interpolate", fmt.Sprintf("%s/cloud-config.yml", tempDir),
+					"-o", fmt.Sprintf("%s/ops.yml", tempDir),
+					"-o", filepath.Join(stateDir, "cloud-config", "ops", "extensions.yml"),
+					"-o", filepath.Join(stateDir, "cloud-config", "ops", "vm-types.yml"),
+				}))
+			})
+		})
+
 		Context("failure cases", func() {
 			Context("when temp dir fails", func() {
 				BeforeEach(func() {
@@ -192,6 +223,134 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Apply", func() {
+		It("updates the bosh director with the provided cloud config", func() {
+			err := manager.Apply(incomingState, "some-provided-cloud-config")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+			Expect(boshClient.UpdateCloudConfigCall.Receives.Yaml).To(Equal([]byte("some-provided-cloud-config")))
+
+			Expect(cmd.RunCallCount()).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"applying cloud config"}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the jumpbox ssh key cannot be retrieved", func() {
+				incomingState.Jumpbox.Enabled = true
+				sshKeyGetter.GetCall.Returns.Error = errors.New("failed to get jumpbox ssh key")
+
+				err := manager.Apply(incomingState, "some-provided-cloud-config")
+				Expect(err).To(MatchError("failed to get jumpbox ssh key"))
+			})
+
+			It("returns an error when bosh client fails to update cloud config", func() {
+				boshClient.UpdateCloudConfigCall.Returns.Error = errors.New("failed to update")
+
+				err := manager.Apply(incomingState, "some-provided-cloud-config")
+				Expect(err).To(MatchError("failed to update"))
+			})
+		})
+	})
+
+	Describe("Current", func() {
+		It("returns the director's cloud config", func() {
+			boshClient.CloudConfigCall.Returns.CloudConfig = "some-current-cloud-config"
+
+			cloudConfig, err := manager.Current(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+			Expect(cloudConfig).To(Equal("some-current-cloud-config"))
+			Expect(boshClient.UpdateCloudConfigCall.CallCount).To(Equal(0))
+		})
+
+		It("returns an error when bosh client fails to get the cloud config", func() {
+			boshClient.CloudConfigCall.Returns.Error = errors.New("failed to get cloud config")
+
+			_, err := manager.Current(incomingState)
+			Expect(err).To(MatchError("failed to get cloud config"))
+		})
+	})
+
+	Describe("UpdateIfChanged", func() {
+		It("does not update the director when its cloud config is the same", func() {
+			boshClient.CloudConfigCall.Returns.CloudConfig = "some-cloud-config\n"
+
+			updated, err := manager.UpdateIfChanged(incomingState, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+
+			Expect(boshClient.CloudConfigCall.CallCount).To(Equal(1))
+			Expect(boshClient.UpdateCloudConfigCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(Equal([]string{
+				"generating cloud config",
+				"comparing with the director's cloud config",
+			}))
+		})
+
+		It("ignores formatting differences", func() {
+			cmd.RunStub = func(stdout io.Writer, workingDirectory string, args []string) error {
+				stdout.Write([]byte("azs: [{name: z1}]"))
+				return nil
+			}
+			boshClient.CloudConfigCall.Returns.CloudConfig = "azs:\n- name: z1\n"
+
+			updated, err := manager.UpdateIfChanged(incomingState, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+		})
+
+		It("updates the director when its cloud config differs", func() {
+			boshClient.CloudConfigCall.Returns.CloudConfig = "some-stale-cloud-config"
+
+			updated, err := manager.UpdateIfChanged(incomingState, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+
+			Expect(boshClient.UpdateCloudConfigCall.Receives.Yaml).To(Equal([]byte("some-cloud-config")))
+			Expect(logger.StepCall.Messages).To(Equal([]string{
+				"generating cloud config",
+				"comparing with the director's cloud config",
+				"applying cloud config",
+			}))
+		})
+
+		It("updates the director without comparing when forced", func() {
+			boshClient.CloudConfigCall.Returns.CloudConfig = "some-cloud-config"
+
+			updated, err := manager.UpdateIfChanged(incomingState, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+
+			Expect(boshClient.CloudConfigCall.CallCount).To(Equal(0))
+			Expect(boshClient.UpdateCloudConfigCall.Receives.Yaml).To(Equal([]byte("some-cloud-config")))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the cloud config cannot be generated", func() {
+				cmd.RunReturns(errors.New("failed to run"))
+
+				_, err := manager.UpdateIfChanged(incomingState, false)
+				Expect(err).To(MatchError("failed to run"))
+			})
+
+			It("returns an error when the director's cloud config cannot be retrieved", func() {
+				boshClient.CloudConfigCall.Returns.Error = errors.New("failed to get cloud config")
+
+				_, err := manager.UpdateIfChanged(incomingState, false)
+				Expect(err).To(MatchError("failed to get cloud config"))
+			})
+
+			It("returns an error when bosh client fails to update cloud config", func() {
+				boshClient.UpdateCloudConfigCall.Returns.Error = errors.New("failed to update")
+
+				_, err := manager.UpdateIfChanged(incomingState, false)
+				Expect(err).To(MatchError("failed to update"))
+			})
+		})
+	})
+
 	Describe("Update", func() {
 		Context("when no jumpbox exists", func() {
 			It("logs steps taken", func() {
@@ -0,0 +1,44 @@
+package openstack
+
+const (
+	BaseOps = `
+- type: replace
+  path: /compilation/vm_type
+  value: large
+
+- type: replace
+  path: /vm_types/name=default/cloud_properties?
+  value:
+    instance_type: m1.small
+
+- type: replace
+  path: /vm_types/name=minimal/cloud_properties?
+  value:
+    instance_type: m1.small
+
+- type: replace
+  path: /vm_types/name=sharedcpu/cloud_properties?
+  value:
+    instance_type: m1.small
+
+- type: replace
+  path: /vm_types/name=small/cloud_properties?
+  value:
+    instance_type: m1.medium
+
+- type: replace
+  path: /vm_types/name=medium/cloud_properties?
+  value:
+    instance_type: m1.large
+
+- type: replace
+  path: /vm_types/name=large/cloud_properties?
+  value:
+    instance_type: m1.xlarge
+
+- type: replace
+  path: /vm_types/name=extra-large/cloud_properties?
+  value:
+    instance_type: m1.xlarge
+`
+)
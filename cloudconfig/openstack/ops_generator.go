@@ -0,0 +1,229 @@
+package openstack
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// all azs map to the single openstack availability zone bbl was given.
+var azNames = []string{"z1", "z2", "z3"}
+
+type OpsGenerator struct {
+	terraformManager terraformManager
+}
+
+type terraformManager interface {
+	GetOutputs(storage.State) (map[string]interface{}, error)
+}
+
+type op struct {
+	Type  string
+	Path  string
+	Value interface{}
+}
+
+type az struct {
+	Name            string            `yaml:"name"`
+	CloudProperties azCloudProperties `yaml:"cloud_properties"`
+}
+
+type azCloudProperties struct {
+	AvailabilityZone string `yaml:"availability_zone"`
+}
+
+type network struct {
+	Name    string
+	Subnets []networkSubnet
+	Type    string
+}
+
+type networkSubnet struct {
+	AZs             []string `yaml:"azs"`
+	Gateway         string
+	Range           string
+	Reserved        []string
+	Static          []string
+	DNS             []string              `yaml:"dns"`
+	CloudProperties subnetCloudProperties `yaml:"cloud_properties"`
+}
+
+type subnetCloudProperties struct {
+	NetID          string   `yaml:"net_id"`
+	SecurityGroups []string `yaml:"security_groups"`
+}
+
+type lb struct {
+	Name            string
+	CloudProperties lbCloudProperties `yaml:"cloud_properties"`
+}
+
+type lbCloudProperties struct {
+	LoadBalancerPools []lbPool `yaml:"loadbalancer_pools,omitempty"`
+}
+
+type lbPool struct {
+	Name string
+	Port int
+}
+
+var marshal func(interface{}) ([]byte, error) = yaml.Marshal
+
+func NewOpsGenerator(terraformManager terraformManager) OpsGenerator {
+	return OpsGenerator{
+		terraformManager: terraformManager,
+	}
+}
+
+func (o OpsGenerator) Generate(state storage.State) (string, error) {
+	ops, err := o.generateOpenStackOps(state)
+	if err != nil {
+		return "", err
+	}
+
+	cloudConfigOpsYAML, err := marshal(ops)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(
+		[]string{
+			BaseOps,
+			string(cloudConfigOpsYAML),
+		},
+		"\n",
+	), nil
+}
+
+func createOp(opType, opPath string, value interface{}) op {
+	return op{
+		Type:  opType,
+		Path:  opPath,
+		Value: value,
+	}
+}
+
+func (o *OpsGenerator) generateOpenStackOps(state storage.State) ([]op, error) {
+	terraformOutputs, err := o.terraformManager.GetOutputs(state)
+	if err != nil {
+		return []op{}, err
+	}
+
+	var ops []op
+	for _, name := range azNames {
+		ops = append(ops, createOp("replace", "/azs/-", az{
+			Name: name,
+			CloudProperties: azCloudProperties{
+				AvailabilityZone: state.OpenStack.AZ,
+			},
+		}))
+	}
+
+	subnet, err := generateNetworkSubnet(
+		state.Network.NetworkCIDR(),
+		state.Network.DirectorSubnetCIDR(),
+		terraformOutputs["net_id"].(string),
+		terraformOutputs["default_security_group"].(string),
+	)
+	if err != nil {
+		return []op{}, err
+	}
+
+	ops = append(ops, createOp("replace", "/networks/-", network{
+		Name:    "private",
+		Subnets: []networkSubnet{subnet},
+		Type:    "manual",
+	}))
+
+	ops = append(ops, createOp("replace", "/networks/-", network{
+		Name:    "default",
+		Subnets: []networkSubnet{subnet},
+		Type:    "manual",
+	}))
+
+	if state.LB.Type == "concourse" {
+		ops = append(ops, createOp("replace", "/vm_extensions/-", lb{
+			Name: "lb",
+			CloudProperties: lbCloudProperties{
+				LoadBalancerPools: []lbPool{
+					{Name: terraformOutputs["concourse_http_pool"].(string), Port: 80},
+					{Name: terraformOutputs["concourse_https_pool"].(string), Port: 443},
+					{Name: terraformOutputs["concourse_ssh_pool"].(string), Port: 2222},
+				},
+			},
+		}))
+	}
+
+	if state.LB.Type == "cf" {
+		ops = append(ops, createOp("replace", "/vm_extensions/-", lb{
+			Name: "cf-router-network-properties",
+			CloudProperties: lbCloudProperties{
+				LoadBalancerPools: []lbPool{
+					{Name: terraformOutputs["cf_router_http_pool"].(string), Port: 80},
+					{Name: terraformOutputs["cf_router_https_pool"].(string), Port: 443},
+				},
+			},
+		}))
+
+		ops = append(ops, createOp("replace", "/vm_extensions/-", lb{
+			Name: "diego-ssh-proxy-network-properties",
+			CloudProperties: lbCloudProperties{
+				LoadBalancerPools: []lbPool{
+					{Name: terraformOutputs["cf_ssh_proxy_pool"].(string), Port: 2222},
+				},
+			},
+		}))
+
+		// octavia listeners forward a single port, so the tcp router range
+		// is not load balanced. the extension exists for cf-deployment.
+		ops = append(ops, createOp("replace", "/vm_extensions/-", lb{
+			Name: "cf-tcp-router-network-properties",
+		}))
+	}
+
+	return ops, nil
+}
+
+// generateNetworkSubnet spans the whole openstack subnet. The director subnet
+// is reserved so bosh does not hand out the director's address.
+func generateNetworkSubnet(cidr, directorCIDR, netID, securityGroup string) (networkSubnet, error) {
+	parsedCidr, err := bosh.ParseCIDRBlock(cidr)
+	if err != nil {
+		return networkSubnet{}, err
+	}
+
+	parsedDirectorCidr, err := bosh.ParseCIDRBlock(directorCIDR)
+	if err != nil {
+		return networkSubnet{}, err
+	}
+
+	gateway := parsedCidr.GetFirstIP().Add(1).String()
+	firstReserved := parsedCidr.GetFirstIP().Add(2).String()
+	secondReserved := parsedCidr.GetFirstIP().Add(3).String()
+	lastReserved := parsedCidr.GetLastIP().String()
+	lastStatic := parsedCidr.GetLastIP().Subtract(1).String()
+	firstStatic := parsedCidr.GetLastIP().Subtract(65).String()
+
+	return networkSubnet{
+		AZs:     azNames,
+		Gateway: gateway,
+		Range:   cidr,
+		Reserved: []string{
+			fmt.Sprintf("%s-%s", firstReserved, secondReserved),
+			fmt.Sprintf("%s-%s", parsedDirectorCidr.GetFirstIP().String(), parsedDirectorCidr.GetLastIP().String()),
+			fmt.Sprintf("%s", lastReserved),
+		},
+		Static: []string{
+			fmt.Sprintf("%s-%s", firstStatic, lastStatic),
+		},
+		DNS: []string{"8.8.8.8"},
+		CloudProperties: subnetCloudProperties{
+			NetID:          netID,
+			SecurityGroups: []string{securityGroup},
+		},
+	}, nil
+}
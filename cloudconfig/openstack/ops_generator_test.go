@@ -0,0 +1,113 @@
+package openstack_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/cloudconfig/openstack"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/pivotal-cf-experimental/gomegamatchers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenStackOpsGenerator", func() {
+	Describe("Generate", func() {
+		var (
+			terraformManager *fakes.TerraformManager
+			opsGenerator     openstack.OpsGenerator
+
+			incomingState   storage.State
+			expectedOpsFile []byte
+		)
+
+		BeforeEach(func() {
+			terraformManager = &fakes.TerraformManager{}
+
+			incomingState = storage.State{
+				IAAS:    "openstack",
+				TFState: "some-tf-state",
+				OpenStack: storage.OpenStack{
+					AZ: "some-az",
+				},
+			}
+
+			terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+				"net_id":                 "some-net-id",
+				"default_security_group": "some-security-group",
+			}
+
+			var err error
+			expectedOpsFile, err = ioutil.ReadFile(filepath.Join("fixtures", "openstack-ops.yml"))
+			Expect(err).NotTo(HaveOccurred())
+
+			opsGenerator = openstack.NewOpsGenerator(terraformManager)
+		})
+
+		It("returns an ops file to transform base cloud config into openstack specific cloud config", func() {
+			opsYAML, err := opsGenerator.Generate(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(incomingState))
+
+			Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOpsFile))
+		})
+
+		DescribeTable("returns an ops file with additional vm extensions to support lb",
+			func(lbType string, lbOutputs map[string]interface{}) {
+				incomingState.LB.Type = lbType
+
+				expectedLBOpsFile, err := ioutil.ReadFile(filepath.Join("fixtures", fmt.Sprintf("openstack-%s-lb-ops.yml", lbType)))
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOps := strings.Join([]string{string(expectedOpsFile), string(expectedLBOpsFile)}, "\n")
+
+				lbOutputs["net_id"] = "some-net-id"
+				lbOutputs["default_security_group"] = "some-security-group"
+				terraformManager.GetOutputsCall.Returns.Outputs = lbOutputs
+
+				opsYAML, err := opsGenerator.Generate(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(opsYAML).To(gomegamatchers.MatchYAML(expectedOps))
+			},
+			Entry("cf load balancer exists", "cf",
+				map[string]interface{}{
+					"cf_router_http_pool":  "some-cf-router-http-pool",
+					"cf_router_https_pool": "some-cf-router-https-pool",
+					"cf_ssh_proxy_pool":    "some-cf-ssh-proxy-pool",
+				},
+			),
+			Entry("concourse load balancer exists", "concourse",
+				map[string]interface{}{
+					"concourse_http_pool":  "some-concourse-http-pool",
+					"concourse_https_pool": "some-concourse-https-pool",
+					"concourse_ssh_pool":   "some-concourse-ssh-pool",
+				},
+			),
+		)
+
+		Context("failure cases", func() {
+			It("returns an error when terraform output provider fails to retrieve", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to output")
+				_, err := opsGenerator.Generate(storage.State{})
+				Expect(err).To(MatchError("failed to output"))
+			})
+
+			It("returns an error when ops fail to marshal", func() {
+				openstack.SetMarshal(func(interface{}) ([]byte, error) {
+					return []byte{}, errors.New("failed to marshal")
+				})
+				_, err := opsGenerator.Generate(incomingState)
+				Expect(err).To(MatchError("failed to marshal"))
+				openstack.ResetMarshal()
+			})
+		})
+	})
+})
@@ -10,13 +10,18 @@ type OpsGenerator struct {
 	awsCloudFormationOpsGenerator opsGenerator
 	awsTerraformOpsGenerator      opsGenerator
 	gcpOpsGenerator               opsGenerator
+	azureOpsGenerator             opsGenerator
+	openstackOpsGenerator         opsGenerator
 }
 
-func NewOpsGenerator(awsCloudFormationOpsGenerator opsGenerator, awsTerraformOpsGenerator opsGenerator, gcpOpsGenerator opsGenerator) OpsGenerator {
+func NewOpsGenerator(awsCloudFormationOpsGenerator opsGenerator, awsTerraformOpsGenerator opsGenerator, gcpOpsGenerator opsGenerator, azureOpsGenerator opsGenerator,
+	openstackOpsGenerator opsGenerator) OpsGenerator {
 	return OpsGenerator{
 		awsCloudFormationOpsGenerator: awsCloudFormationOpsGenerator,
 		awsTerraformOpsGenerator:      awsTerraformOpsGenerator,
 		gcpOpsGenerator:               gcpOpsGenerator,
+		azureOpsGenerator:             azureOpsGenerator,
+		openstackOpsGenerator:         openstackOpsGenerator,
 	}
 }
 
@@ -24,6 +29,10 @@ func (o OpsGenerator) Generate(state storage.State) (string, error) {
 	switch state.IAAS {
 	case "gcp":
 		return o.gcpOpsGenerator.Generate(state)
+	case "azure":
+		return o.azureOpsGenerator.Generate(state)
+	case "openstack":
+		return o.openstackOpsGenerator.Generate(state)
 	case "aws":
 		if state.TFState != "" {
 			return o.awsTerraformOpsGenerator.Generate(state)
@@ -18,6 +18,8 @@ var _ = Describe("OpsGenerator", func() {
 			awsCloudFormationOpsGenerator *fakes.CloudConfigOpsGenerator
 			awsTerraformOpsGenerator      *fakes.CloudConfigOpsGenerator
 			gcpOpsGenerator               *fakes.CloudConfigOpsGenerator
+			azureOpsGenerator             *fakes.CloudConfigOpsGenerator
+			openstackOpsGenerator         *fakes.CloudConfigOpsGenerator
 			opsGenerator                  cloudconfig.OpsGenerator
 
 			incomingState storage.State
@@ -27,11 +29,15 @@ var _ = Describe("OpsGenerator", func() {
 			awsCloudFormationOpsGenerator = &fakes.CloudConfigOpsGenerator{}
 			awsTerraformOpsGenerator = &fakes.CloudConfigOpsGenerator{}
 			gcpOpsGenerator = &fakes.CloudConfigOpsGenerator{}
+			azureOpsGenerator = &fakes.CloudConfigOpsGenerator{}
+			openstackOpsGenerator = &fakes.CloudConfigOpsGenerator{}
 
 			awsCloudFormationOpsGenerator.GenerateCall.Returns.OpsYAML = "some-aws-cloudformation-ops"
 			awsTerraformOpsGenerator.GenerateCall.Returns.OpsYAML = "some-aws-terraform-ops"
 			gcpOpsGenerator.GenerateCall.Returns.OpsYAML = "some-gcp-ops"
-			opsGenerator = cloudconfig.NewOpsGenerator(awsCloudFormationOpsGenerator, awsTerraformOpsGenerator, gcpOpsGenerator)
+			azureOpsGenerator.GenerateCall.Returns.OpsYAML = "some-azure-ops"
+			openstackOpsGenerator.GenerateCall.Returns.OpsYAML = "some-openstack-ops"
+			opsGenerator = cloudconfig.NewOpsGenerator(awsCloudFormationOpsGenerator, awsTerraformOpsGenerator, gcpOpsGenerator, azureOpsGenerator, openstackOpsGenerator)
 		})
 
 		DescribeTable("returns an ops file to transform base cloud config to iaas specific cloud config", func(incomingState storage.State, expectedOpsYAML string) {
@@ -50,6 +56,12 @@ var _ = Describe("OpsGenerator", func() {
 				IAAS:    "aws",
 				TFState: "",
 			}, "some-aws-cloudformation-ops"),
+			Entry("when iaas is azure", storage.State{
+				IAAS: "azure",
+			}, "some-azure-ops"),
+			Entry("when iaas is openstack", storage.State{
+				IAAS: "openstack",
+			}, "some-openstack-ops"),
 		)
 
 		Context("failure cases", func() {
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 
@@ -8,22 +9,26 @@ import (
 )
 
 type AWSCreateLBs struct {
-	logger               logger
-	credentialValidator  credentialValidator
-	cloudConfigManager   cloudConfigManager
-	stateStore           stateStore
-	stateValidator       stateValidator
-	terraformManager     terraformApplier
-	environmentValidator environmentValidator
+	logger                 logger
+	credentialValidator    credentialValidator
+	cloudConfigManager     cloudConfigManager
+	stateStore             stateStore
+	stateValidator         stateValidator
+	terraformManager       terraformApplier
+	environmentValidator   environmentValidator
+	certificateProvisioner lbCertificateProvisioner
 }
 
 type AWSCreateLBsConfig struct {
-	LBType       string
-	CertPath     string
-	KeyPath      string
-	ChainPath    string
-	Domain       string
-	SkipIfExists bool
+	LBType           string
+	CertPath         string
+	KeyPath          string
+	ChainPath        string
+	Domain           string
+	SkipIfExists     bool
+	LBFlavor         string
+	LetsEncrypt      bool
+	LetsEncryptEmail string
 }
 
 type environmentValidator interface {
@@ -32,14 +37,16 @@ type environmentValidator interface {
 
 func NewAWSCreateLBs(logger logger, credentialValidator credentialValidator,
 	cloudConfigManager cloudConfigManager, stateStore stateStore,
-	terraformManager terraformApplier, environmentValidator environmentValidator) AWSCreateLBs {
+	terraformManager terraformApplier, environmentValidator environmentValidator,
+	certificateProvisioner lbCertificateProvisioner) AWSCreateLBs {
 	return AWSCreateLBs{
-		logger:               logger,
-		credentialValidator:  credentialValidator,
-		cloudConfigManager:   cloudConfigManager,
-		stateStore:           stateStore,
-		terraformManager:     terraformManager,
-		environmentValidator: environmentValidator,
+		logger:                 logger,
+		credentialValidator:    credentialValidator,
+		cloudConfigManager:     cloudConfigManager,
+		stateStore:             stateStore,
+		terraformManager:       terraformManager,
+		environmentValidator:   environmentValidator,
+		certificateProvisioner: certificateProvisioner,
 	}
 }
 
@@ -54,6 +61,15 @@ func (c AWSCreateLBs) Execute(config AWSCreateLBsConfig, state storage.State) er
 		return nil
 	}
 
+	if config.LetsEncrypt && state.LB.Type == "cf" && state.LB.Domain == config.Domain {
+		c.logger.Step("lb type \"cf\" exists, requesting a new certificate")
+		return renewLetsEncryptCertificate(c.certificateProvisioner, config.LetsEncryptEmail, state)
+	}
+
+	if state.AWS.ExistingVPCID != "" {
+		return errors.New("load balancers are not supported for environments in an existing VPC")
+	}
+
 	if err := c.checkFastFails(config.LBType, state.Stack.LBType); err != nil {
 		return err
 	}
@@ -62,7 +78,18 @@ func (c AWSCreateLBs) Execute(config AWSCreateLBsConfig, state storage.State) er
 		return err
 	}
 
-	if config.LBType == "cf" || config.LBType == "concourse" {
+	if config.Domain != "" {
+		state.LB.Domain = config.Domain
+	}
+
+	if config.LetsEncrypt {
+		state.LB.ACME.Email = config.LetsEncryptEmail
+
+		state, err = c.certificateProvisioner.Placeholder(state)
+		if err != nil {
+			return err
+		}
+	} else if config.LBType == "cf" || config.LBType == "concourse" {
 		certContents, err := ioutil.ReadFile(config.CertPath)
 		if err != nil {
 			return err
@@ -86,11 +113,10 @@ func (c AWSCreateLBs) Execute(config AWSCreateLBsConfig, state storage.State) er
 		}
 	}
 
-	if config.Domain != "" {
-		state.LB.Domain = config.Domain
-	}
-
 	state.LB.Type = config.LBType
+	if config.LBFlavor != "" {
+		state.LB.Flavor = config.LBFlavor
+	}
 
 	err = c.stateStore.Set(state)
 	if err != nil {
@@ -107,6 +133,13 @@ func (c AWSCreateLBs) Execute(config AWSCreateLBsConfig, state storage.State) er
 		return err
 	}
 
+	if config.LetsEncrypt {
+		state, err = c.certificateProvisioner.Provision(state)
+		if err != nil {
+			return err
+		}
+	}
+
 	if !state.NoDirector {
 		err = c.cloudConfigManager.Update(state)
 		if err != nil {
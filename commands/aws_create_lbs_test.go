@@ -18,14 +18,15 @@ import (
 var _ = Describe("AWS Create LBs", func() {
 	Describe("Execute", func() {
 		var (
-			command              commands.AWSCreateLBs
-			terraformManager     *fakes.TerraformManager
-			credentialValidator  *fakes.CredentialValidator
-			logger               *fakes.Logger
-			cloudConfigManager   *fakes.CloudConfigManager
-			stateStore           *fakes.StateStore
-			environmentValidator *fakes.EnvironmentValidator
-			incomingState        storage.State
+			command                commands.AWSCreateLBs
+			terraformManager       *fakes.TerraformManager
+			credentialValidator    *fakes.CredentialValidator
+			logger                 *fakes.Logger
+			cloudConfigManager     *fakes.CloudConfigManager
+			stateStore             *fakes.StateStore
+			environmentValidator   *fakes.EnvironmentValidator
+			certificateProvisioner *fakes.LBCertificateProvisioner
+			incomingState          storage.State
 
 			certPath  string
 			keyPath   string
@@ -39,6 +40,7 @@ var _ = Describe("AWS Create LBs", func() {
 			cloudConfigManager = &fakes.CloudConfigManager{}
 			stateStore = &fakes.StateStore{}
 			environmentValidator = &fakes.EnvironmentValidator{}
+			certificateProvisioner = &fakes.LBCertificateProvisioner{}
 
 			incomingState = storage.State{
 				AWS: storage.AWS{
@@ -84,7 +86,7 @@ var _ = Describe("AWS Create LBs", func() {
 
 			command = commands.NewAWSCreateLBs(logger, credentialValidator,
 				cloudConfigManager,
-				stateStore, terraformManager, environmentValidator)
+				stateStore, terraformManager, environmentValidator, certificateProvisioner)
 		})
 
 		It("returns an error if credential validator fails", func() {
@@ -174,6 +176,127 @@ var _ = Describe("AWS Create LBs", func() {
 				})
 			})
 
+			Context("when an nlb flavor is provided", func() {
+				BeforeEach(func() {
+					statePassedToTerraform.LB = storage.LB{
+						Type:   "cf",
+						Cert:   "some-cert",
+						Key:    "some-key",
+						Flavor: "nlb",
+					}
+
+					stateReturnedFromTerraform = statePassedToTerraform
+					stateReturnedFromTerraform.TFState = "some-updated-tf-state"
+					terraformManager.ApplyCall.Returns.BBLState = stateReturnedFromTerraform
+				})
+
+				It("saves the flavor in the state", func() {
+					err := command.Execute(commands.AWSCreateLBsConfig{
+						LBType:   "cf",
+						CertPath: certPath,
+						KeyPath:  keyPath,
+						LBFlavor: "nlb",
+					}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(statePassedToTerraform))
+				})
+			})
+
+			Context("when a certificate is requested from let's encrypt", func() {
+				var (
+					placeholderState storage.State
+					provisionedState storage.State
+				)
+
+				BeforeEach(func() {
+					placeholderState = incomingState
+					placeholderState.LB = storage.LB{
+						Domain: "some-domain",
+						Cert:   "some-placeholder-cert",
+						Key:    "some-placeholder-key",
+						ACME:   storage.ACME{Email: "me@example.com"},
+					}
+					certificateProvisioner.PlaceholderCall.Returns.State = placeholderState
+
+					statePassedToTerraform = placeholderState
+					statePassedToTerraform.LB.Type = "cf"
+
+					stateReturnedFromTerraform = statePassedToTerraform
+					stateReturnedFromTerraform.TFState = "some-updated-tf-state"
+					terraformManager.ApplyCall.Returns.BBLState = stateReturnedFromTerraform
+
+					provisionedState = stateReturnedFromTerraform
+					provisionedState.LB.Cert = "some-trusted-cert"
+					certificateProvisioner.ProvisionCall.Returns.State = provisionedState
+				})
+
+				It("creates the load balancer with a placeholder and then provisions the certificate", func() {
+					err := command.Execute(commands.AWSCreateLBsConfig{
+						LBType:           "cf",
+						Domain:           "some-domain",
+						LetsEncrypt:      true,
+						LetsEncryptEmail: "me@example.com",
+					}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(certificateProvisioner.PlaceholderCall.Receives.State.LB.Domain).To(Equal("some-domain"))
+					Expect(certificateProvisioner.PlaceholderCall.Receives.State.LB.ACME.Email).To(Equal("me@example.com"))
+					Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(statePassedToTerraform))
+					Expect(certificateProvisioner.ProvisionCall.Receives.State).To(Equal(stateReturnedFromTerraform))
+					Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(provisionedState))
+				})
+
+				Context("when the cf lb already exists for the domain", func() {
+					It("only requests a new certificate", func() {
+						incomingState.LB = storage.LB{
+							Type:   "cf",
+							Domain: "some-domain",
+							Cert:   "some-old-cert",
+							Key:    "some-old-key",
+						}
+
+						err := command.Execute(commands.AWSCreateLBsConfig{
+							LBType:           "cf",
+							Domain:           "some-domain",
+							LetsEncrypt:      true,
+							LetsEncryptEmail: "me@example.com",
+						}, incomingState)
+						Expect(err).NotTo(HaveOccurred())
+
+						expectedState := incomingState
+						expectedState.LB.ACME.Email = "me@example.com"
+						Expect(certificateProvisioner.ProvisionCall.Receives.State).To(Equal(expectedState))
+						Expect(certificateProvisioner.PlaceholderCall.CallCount).To(Equal(0))
+						Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+					})
+				})
+
+				It("returns an error when the placeholder cannot be created", func() {
+					certificateProvisioner.PlaceholderCall.Returns.Error = errors.New("failed to create placeholder")
+
+					err := command.Execute(commands.AWSCreateLBsConfig{
+						LBType:      "cf",
+						Domain:      "some-domain",
+						LetsEncrypt: true,
+					}, incomingState)
+					Expect(err).To(MatchError("failed to create placeholder"))
+					Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				})
+
+				It("returns an error when the certificate cannot be provisioned", func() {
+					certificateProvisioner.ProvisionCall.Returns.Error = errors.New("failed to provision")
+
+					err := command.Execute(commands.AWSCreateLBsConfig{
+						LBType:      "cf",
+						Domain:      "some-domain",
+						LetsEncrypt: true,
+					}, incomingState)
+					Expect(err).To(MatchError("failed to provision"))
+					Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				})
+			})
+
 			Context("when a domain exists", func() {
 				BeforeEach(func() {
 					incomingState.LB = storage.LB{
@@ -356,6 +479,19 @@ var _ = Describe("AWS Create LBs", func() {
 			})
 		})
 
+		It("returns an error when the environment is in an existing vpc", func() {
+			incomingState.AWS.ExistingVPCID = "vpc-123"
+
+			err := command.Execute(commands.AWSCreateLBsConfig{
+				LBType:   "concourse",
+				CertPath: certPath,
+				KeyPath:  keyPath,
+			}, incomingState)
+			Expect(err).To(MatchError("load balancers are not supported for environments in an existing VPC"))
+
+			Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+		})
+
 		It("returns an error when the environment validator fails", func() {
 			environmentValidator.ValidateCall.Returns.Error = errors.New("environment not found")
 
@@ -30,6 +30,7 @@ func (l AWSLBs) Execute(subcommandFlags []string, state storage.State) error {
 		switch state.LB.Type {
 		case "cf":
 			if len(subcommandFlags) > 0 && subcommandFlags[0] == "--json" {
+				dnsServers, _ := terraformOutputs["env_dns_zone_name_servers"].([]string)
 				lbOutput, err := json.Marshal(struct {
 					RouterLBName           string   `json:"cf_router_lb,omitempty"`
 					RouterLBURL            string   `json:"cf_router_lb_url,omitempty"`
@@ -45,7 +46,7 @@ func (l AWSLBs) Execute(subcommandFlags []string, state storage.State) error {
 					SSHProxyLBURL:          terraformOutputs["cf_ssh_lb_url"].(string),
 					TCPRouterLBName:        terraformOutputs["cf_tcp_lb_name"].(string),
 					TCPRouterLBURL:         terraformOutputs["cf_tcp_lb_url"].(string),
-					SystemDomainDNSServers: terraformOutputs["env_dns_zone_name_servers"].([]string),
+					SystemDomainDNSServers: dnsServers,
 				})
 				if err != nil {
 					// not tested
@@ -63,6 +64,13 @@ func (l AWSLBs) Execute(subcommandFlags []string, state storage.State) error {
 				}
 			}
 		case "concourse":
+			if len(subcommandFlags) > 0 && subcommandFlags[0] == "--json" {
+				return printJSON(l.logger, map[string]interface{}{
+					"concourse_lb":     terraformOutputs["concourse_lb_name"],
+					"concourse_lb_url": terraformOutputs["concourse_lb_url"],
+				})
+			}
+
 			l.logger.Printf("Concourse LB: %s [%s]\n", terraformOutputs["concourse_lb_name"], terraformOutputs["concourse_lb_url"])
 		default:
 			return errors.New("no lbs found")
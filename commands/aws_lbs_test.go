@@ -60,6 +60,20 @@ var _ = Describe("AWSLBs", func() {
 				}))
 			})
 
+			It("prints LB names and URLs in json format without DNS servers", func() {
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+					"cf_router_lb": "some-router-lb-name",
+					"cf_router_lb_url": "some-router-lb-url",
+					"cf_ssh_proxy_lb": "some-ssh-lb-name",
+					"cf_ssh_proxy_lb_url": "some-ssh-lb-url",
+					"cf_tcp_lb": "some-tcp-lb-name",
+					"cf_tcp_lb_url":  "some-tcp-lb-url"
+				}`))
+			})
+
 			Context("when the domain is specified", func() {
 				BeforeEach(func() {
 					incomingState.LB.Domain = "some-domain"
@@ -138,6 +152,17 @@ var _ = Describe("AWSLBs", func() {
 					"Concourse LB: some-concourse-lb-name [some-concourse-lb-url]\n",
 				}))
 			})
+
+			It("prints LB name and URL in json format", func() {
+				err := command.Execute([]string{"--json"}, incomingState)
+
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+					"concourse_lb": "some-concourse-lb-name",
+					"concourse_lb_url": "some-concourse-lb-url"
+				}`))
+			})
 		})
 
 		It("returns error when lb type is not cf or concourse", func() {
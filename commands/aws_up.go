@@ -2,7 +2,6 @@ package commands
 
 import (
 	"errors"
-	"io/ioutil"
 
 	"github.com/cloudfoundry/bosh-bootloader/aws"
 	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
@@ -30,6 +29,7 @@ type credentialValidator interface {
 
 type logger interface {
 	Step(string, ...interface{})
+	Warn(string, ...interface{})
 	Printf(string, ...interface{})
 	Println(string)
 	Prompt(string)
@@ -45,7 +45,10 @@ type configProvider interface {
 
 type cloudConfigManager interface {
 	Update(state storage.State) error
+	Apply(state storage.State, cloudConfig string) error
 	Generate(state storage.State) (string, error)
+	UpdateIfChanged(state storage.State, force bool) (bool, error)
+	Current(state storage.State) (string, error)
 }
 
 type brokenEnvironmentValidator interface {
@@ -60,19 +63,49 @@ type AWSUp struct {
 	stateStore                 stateStore
 	configProvider             configProvider
 	envIDManager               envIDManager
-	terraformManager           terraformApplier
+	terraformManager           terraformUpApplier
 	brokenEnvironmentValidator brokenEnvironmentValidator
+	logger                     logger
+	upDetacher                 upDetacher
+	stemcellUploader           stemcellUploader
 }
 
 type AWSUpConfig struct {
-	AccessKeyID     string
-	SecretAccessKey string
-	Region          string
-	OpsFilePath     string
-	BOSHAZ          string
-	Name            string
-	NoDirector      bool
-	Terraform       bool
+	AccessKeyID          string
+	SecretAccessKey      string
+	Region               string
+	OpsFilePaths         []string
+	BOSHAZ               string
+	Name                 string
+	NoDirector           bool
+	Terraform            bool
+	Detach               bool
+	VPCCIDR              string
+	SubnetCIDR           string
+	DirectorCACert       string
+	DirectorCAKey        string
+	DirectorSpot         bool
+	DirectorSpotMaxPrice string
+	SpotBidPrice         string
+	SpotOnDemandFallback bool
+	DirectorDiskType     string
+	DirectorVMType       string
+	DirectorDiskSize     int
+	DirectorExternalDB   bool
+	Targets              []string
+	SSHKeyType           string
+	SSHKeyBits           int
+	SkipKeyPair          bool
+	KeyPairName          string
+	PublicKey            string
+	PrivateKey           string
+	UploadStemcell       string
+	ExistingVPCID        string
+	ExistingSubnetIDs    []string
+	AZs                  []string
+	NAT                  string
+	Tags                 []storage.Tag
+	FromPhase            string
 }
 
 func NewAWSUp(
@@ -80,7 +113,8 @@ func NewAWSUp(
 	boshManager boshManager,
 	cloudConfigManager cloudConfigManager,
 	stateStore stateStore, configProvider configProvider, envIDManager envIDManager,
-	terraformManager terraformApplier, brokenEnvironmentValidator brokenEnvironmentValidator) AWSUp {
+	terraformManager terraformUpApplier, brokenEnvironmentValidator brokenEnvironmentValidator,
+	logger logger, upDetacher upDetacher, stemcellUploader stemcellUploader) AWSUp {
 
 	return AWSUp{
 		credentialValidator:        credentialValidator,
@@ -92,6 +126,9 @@ func NewAWSUp(
 		envIDManager:               envIDManager,
 		terraformManager:           terraformManager,
 		brokenEnvironmentValidator: brokenEnvironmentValidator,
+		logger:                     logger,
+		upDetacher:                 upDetacher,
+		stemcellUploader:           stemcellUploader,
 	}
 }
 
@@ -127,6 +164,13 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 		state.NoDirector = true
 	}
 
+	state = updateNetworkCIDRs(state, config.VPCCIDR, config.SubnetCIDR, u.logger)
+	state = useExistingVPC(state, config.ExistingVPCID, config.ExistingSubnetIDs)
+	state = useZones(state, config.AZs, nil)
+	state = useNAT(state, config.NAT)
+	state = useTags(state, config.Tags)
+	state = updateCloudConfigSpot(state, config.SpotBidPrice, config.SpotOnDemandFallback)
+
 	err := u.checkForFastFails(state, config)
 	if err != nil {
 		return err
@@ -137,25 +181,38 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 		return err
 	}
 
+	state, err = updateDirectorDB(state, config.DirectorExternalDB)
+	if err != nil {
+		return err
+	}
+
 	if err := u.stateStore.Set(state); err != nil {
 		return err
 	}
 
-	state, err = u.keyPairManager.Sync(state)
-	switch err := err.(type) {
-	case keypair.ManagerError:
-		updatedBBLState := err.BBLState()
-		setErr := u.stateStore.Set(updatedBBLState)
-		if setErr != nil {
-			errorList := helpers.Errors{}
-			errorList.Add(err)
-			errorList.Add(setErr)
-			return errorList
+	state = updateSSHKeyType(state, config.SSHKeyType, config.SSHKeyBits, u.logger)
+	if config.SkipKeyPair {
+		state = useExternalKeyPair(state, config.KeyPairName, config.PublicKey, config.PrivateKey)
+	}
+
+	if !skipUpPhase(state, UpPhaseKeyPair, config.FromPhase, u.logger) {
+		state, err = u.keyPairManager.Sync(state)
+		switch err := err.(type) {
+		case keypair.ManagerError:
+			updatedBBLState := err.BBLState()
+			setErr := u.stateStore.Set(updatedBBLState)
+			if setErr != nil {
+				errorList := helpers.Errors{}
+				errorList.Add(err)
+				errorList.Add(setErr)
+				return errorList
+			}
+			return err
+		case nil:
+		default:
+			return err
 		}
-		return err
-	case nil:
-	default:
-		return err
+		state = checkpointUpPhase(state, UpPhaseKeyPair)
 	}
 
 	if err := u.stateStore.Set(state); err != nil {
@@ -163,14 +220,24 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 	}
 
 	state.Stack.BOSHAZ = config.BOSHAZ
-	state, err = u.terraformManager.Apply(state)
-	if err != nil {
-		return handleTerraformError(err, u.stateStore)
+	if !skipUpPhase(state, UpPhaseTerraform, config.FromPhase, u.logger) {
+		state, err = applyTerraform(u.terraformManager, state, config.Targets, u.logger)
+		if err != nil {
+			return handleTerraformError(err, u.stateStore)
+		}
+
+		if len(config.Targets) == 0 {
+			state = checkpointUpPhase(state, UpPhaseTerraform)
+		}
+
+		err = u.stateStore.Set(state)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = u.stateStore.Set(state)
-	if err != nil {
-		return err
+	if config.Detach && !state.NoDirector {
+		return detachUp(state, u.upDetacher, u.stateStore, u.logger)
 	}
 
 	terraformOutputs, err := u.terraformManager.GetOutputs(state)
@@ -179,41 +246,74 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 	}
 
 	if !state.NoDirector {
-		opsFile := []byte{}
-		if config.OpsFilePath != "" {
-			opsFile, err = ioutil.ReadFile(config.OpsFilePath)
-			if err != nil {
-				return err
-			}
+		if state.AWS.ExistingVPCID != "" {
+			state.Network.SubnetCIDR, _ = terraformOutputs["bosh_subnet_cidr"].(string)
 		}
-		state.BOSH.UserOpsFile = string(opsFile)
 
-		state, err = u.boshManager.CreateDirector(state, terraformOutputs)
-		switch err.(type) {
-		case bosh.ManagerCreateError:
-			bcErr := err.(bosh.ManagerCreateError)
-			if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
-				errorList := helpers.Errors{}
-				errorList.Add(err)
-				errorList.Add(setErr)
-				return errorList
-			}
-			return err
-		case error:
+		var opsFiles []string
+		opsFiles, err = readOpsFiles(config.OpsFilePaths)
+		if err != nil {
 			return err
 		}
+		state = updateUserOpsFiles(state, opsFiles)
+		state = updateDirectorSpot(state, config.DirectorSpot, config.DirectorSpotMaxPrice, u.logger)
+		state = updateDirectorDiskType(state, config.DirectorDiskType, u.logger)
+		state = updateDirectorVMSize(state, config.DirectorVMType, config.DirectorDiskSize, u.logger)
+		if config.DirectorCACert != "" {
+			state.BOSH.UserCACertificate = config.DirectorCACert
+			state.BOSH.UserCAPrivateKey = config.DirectorCAKey
+		}
+
+		if !skipUpPhase(state, UpPhaseBOSH, config.FromPhase, u.logger) {
+			state, err = u.boshManager.CreateDirector(state, terraformOutputs)
+			switch err.(type) {
+			case bosh.ManagerCreateError:
+				bcErr := err.(bosh.ManagerCreateError)
+				if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
+					errorList := helpers.Errors{}
+					errorList.Add(err)
+					errorList.Add(setErr)
+					return errorList
+				}
+				return err
+			case error:
+				return err
+			}
+			state = checkpointUpPhase(state, UpPhaseBOSH)
+		}
 
 		err = u.stateStore.Set(state)
 		if err != nil {
 			return err
 		}
 
-		err = u.cloudConfigManager.Update(state)
-		if err != nil {
-			return err
+		if !skipUpPhase(state, UpPhaseCloudConfig, config.FromPhase, u.logger) {
+			err = u.cloudConfigManager.Update(state)
+			if err != nil {
+				return err
+			}
+			state = checkpointUpPhase(state, UpPhaseCloudConfig)
+
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return err
+			}
+		}
+
+		if config.UploadStemcell != "" {
+			state, err = u.stemcellUploader.Upload(state, config.UploadStemcell)
+			if err != nil {
+				return err
+			}
+
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+
+	return finishUp(state, u.stateStore)
 }
 
 func (u AWSUp) checkForFastFails(state storage.State, config AWSUpConfig) error {
@@ -29,6 +29,9 @@ var _ = Describe("AWSUp", func() {
 			stateStore                 *fakes.StateStore
 			awsClientProvider          *fakes.AWSClientProvider
 			envIDManager               *fakes.EnvIDManager
+			logger                     *fakes.Logger
+			upDetacher                 *fakes.UpDetacher
+			stemcellUploader           *fakes.StemcellUploader
 		)
 
 		BeforeEach(func() {
@@ -88,10 +91,19 @@ var _ = Describe("AWSUp", func() {
 
 			brokenEnvironmentValidator = &fakes.BrokenEnvironmentValidator{}
 
+			logger = &fakes.Logger{}
+
+			upDetacher = &fakes.UpDetacher{}
+			upDetacher.LogPathCall.Returns.Path = "some-state-dir/bbl-up.log"
+			upDetacher.DetachCall.Returns.PID = 1234
+
+			stemcellUploader = &fakes.StemcellUploader{}
+
 			command = commands.NewAWSUp(
 				credentialValidator, keyPairManager, boshManager,
 				cloudConfigManager, stateStore, awsClientProvider,
 				envIDManager, terraformManager, brokenEnvironmentValidator,
+				logger, upDetacher, stemcellUploader,
 			)
 		})
 
@@ -131,6 +143,75 @@ var _ = Describe("AWSUp", func() {
 			Expect(stateStore.SetCall.Receives[1].State.EnvID).To(Equal("bbl-lake-time-stamp"))
 		})
 
+		Context("when the detach flag is provided", func() {
+			It("applies terraform, records the up progress, and continues in the background", func() {
+				err := command.Execute(commands.AWSUpConfig{Detach: true}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(upDetacher.DetachCall.CallCount).To(Equal(1))
+
+				lastState := stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State
+				Expect(lastState.TFState).To(Equal("some-tf-state"))
+				Expect(lastState.UpProgress).To(Equal(storage.UpProgress{
+					PID:     1234,
+					Phase:   "terraform",
+					LogFile: "some-state-dir/bbl-up.log",
+				}))
+
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("bbl up is continuing in the background (task id: 1234), output is being written to some-state-dir/bbl-up.log\n"))
+			})
+
+			It("returns an error when the up cannot be detached", func() {
+				upDetacher.DetachCall.Returns.Error = errors.New("failed to detach")
+
+				err := command.Execute(commands.AWSUpConfig{Detach: true}, storage.State{})
+				Expect(err).To(MatchError("failed to detach"))
+
+				lastState := stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State
+				Expect(lastState.UpProgress).To(Equal(storage.UpProgress{}))
+			})
+		})
+
+		Context("when resuming a detached up", func() {
+			var detachedState storage.State
+
+			BeforeEach(func() {
+				err := command.Execute(commands.AWSUpConfig{Detach: true}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				detachedState = stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State
+				terraformManager.ApplyCall.CallCount = 0
+			})
+
+			It("does not re-apply terraform and clears the up progress once the director is created", func() {
+				boshManager.CreateDirectorCall.Returns.State = storage.State{
+					UpProgress: storage.UpProgress{PID: 1234, Phase: "terraform"},
+				}
+
+				err := command.Execute(commands.AWSUpConfig{}, detachedState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(logger.StepCall.Messages).To(ContainElement("skipping terraform, it has already converged"))
+
+				lastState := stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State
+				Expect(lastState.UpProgress).To(Equal(storage.UpProgress{}))
+			})
+
+			It("re-applies terraform when the configuration changed since the up was detached", func() {
+				detachedState.AWS.Region = "some-other-region"
+
+				err := command.Execute(commands.AWSUpConfig{}, detachedState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+			})
+		})
+
 		Context("when a name is passed in for env-id", func() {
 			It("passes that name in for the env id manager to use", func() {
 				err := command.Execute(commands.AWSUpConfig{
@@ -170,7 +251,7 @@ var _ = Describe("AWSUp", func() {
 				EnvID: "bbl-lake-time-stamp",
 			}))
 
-			Expect(stateStore.SetCall.CallCount).To(Equal(4))
+			Expect(stateStore.SetCall.CallCount).To(Equal(6))
 			actualState := stateStore.SetCall.Receives[3].State
 			Expect(actualState.KeyPair).To(Equal(storage.KeyPair{
 				Name:       "keypair-bbl-lake-time-stamp",
@@ -193,7 +274,7 @@ var _ = Describe("AWSUp", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
-			Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(storage.State{
+			Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(commands.CheckpointUpPhase(storage.State{
 				IAAS: "aws",
 				AWS: storage.AWS{
 					Region:          "some-aws-region",
@@ -206,10 +287,10 @@ var _ = Describe("AWSUp", func() {
 					PrivateKey: "some-private-key",
 					PublicKey:  "some-public-key",
 				},
-			}))
+			}, commands.UpPhaseKeyPair)))
 
-			Expect(stateStore.SetCall.CallCount).To(Equal(4))
-			Expect(stateStore.SetCall.Receives[2].State).To(Equal(storage.State{
+			Expect(stateStore.SetCall.CallCount).To(Equal(6))
+			Expect(stateStore.SetCall.Receives[2].State).To(Equal(commands.CheckpointUpPhase(storage.State{
 				IAAS: "aws",
 				AWS: storage.AWS{
 					Region:          "some-aws-region",
@@ -223,7 +304,28 @@ var _ = Describe("AWSUp", func() {
 					PublicKey:  "some-public-key",
 				},
 				TFState: "some-tf-state",
-			}))
+			}, commands.UpPhaseTerraform)))
+		})
+
+		Context("when targets are provided", func() {
+			It("applies only the targeted terraform resources and warns about divergence", func() {
+				terraformManager.ApplyTargetsCall.Returns.BBLState = storage.State{
+					IAAS:    "aws",
+					EnvID:   "bbl-lake-time-stamp",
+					TFState: "some-targeted-tf-state",
+				}
+
+				err := command.Execute(commands.AWSUpConfig{
+					Targets: []string{"aws_subnet.bosh_subnet"},
+				}, storage.State{EnvID: "bbl-lake-time-stamp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(terraformManager.ApplyTargetsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.ApplyTargetsCall.Receives.Targets).To(Equal([]string{"aws_subnet.bosh_subnet"}))
+				Expect(stateStore.SetCall.Receives[2].State.TFState).To(Equal("some-targeted-tf-state"))
+				Expect(logger.WarnCall.Messages).To(ContainElement("applying only the targeted terraform resources can leave the infrastructure diverged from the full terraform plan, run bbl up without --target to reconcile it."))
+			})
 		})
 
 		Context("failure cases", func() {
@@ -338,7 +440,7 @@ var _ = Describe("AWSUp", func() {
 					Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
 					Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
 					Expect(keyPairManager.SyncCall.CallCount).To(Equal(1))
-					Expect(stateStore.SetCall.CallCount).To(Equal(4))
+					Expect(stateStore.SetCall.CallCount).To(Equal(5))
 				})
 			})
 
@@ -380,8 +482,9 @@ var _ = Describe("AWSUp", func() {
 			err := command.Execute(commands.AWSUpConfig{}, incomingState)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(incomingState))
-			Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(incomingState))
+			appliedState := commands.CheckpointUpPhase(incomingState, commands.UpPhaseTerraform)
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(appliedState))
+			Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(appliedState))
 		})
 
 		Context("when ops file are passed in via --ops-file flag", func() {
@@ -398,13 +501,324 @@ var _ = Describe("AWSUp", func() {
 					AccessKeyID:     "some-aws-access-key-id",
 					SecretAccessKey: "some-aws-secret-access-key",
 					Region:          "some-aws-region",
-					OpsFilePath:     opsFilePath,
+					OpsFilePaths:    []string{opsFilePath},
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserOpsFiles).To(Equal([]string{"some-ops-file-contents"}))
+			})
+
+			It("keeps the saved ops files when none are passed in", func() {
+				terraformManager.ApplyCall.Returns.BBLState.BOSH.UserOpsFiles = []string{"some-saved-ops-file"}
+
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserOpsFiles).To(Equal([]string{"some-saved-ops-file"}))
+			})
+		})
+
+		Context("when an external director database is requested", func() {
+			It("saves the database and its password before applying terraform", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:        "some-aws-access-key-id",
+					SecretAccessKey:    "some-aws-secret-access-key",
+					Region:             "some-aws-region",
+					DirectorExternalDB: true,
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				directorDB := terraformManager.ApplyCall.Receives.BBLState.DirectorDB
+				Expect(directorDB.Enabled).To(BeTrue())
+				Expect(directorDB.Password).To(HaveLen(32))
+				Expect(stateStore.SetCall.Receives[1].State.DirectorDB).To(Equal(directorDB))
+			})
+
+			It("keeps the saved password", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:        "some-aws-access-key-id",
+					SecretAccessKey:    "some-aws-secret-access-key",
+					Region:             "some-aws-region",
+					DirectorExternalDB: true,
+				}, storage.State{
+					EnvID:      "bbl-lake-time-stamp",
+					DirectorDB: storage.DirectorDB{Enabled: true, Password: "some-db-password"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.DirectorDB.Password).To(Equal("some-db-password"))
+			})
+		})
+
+		Context("when a director ca is passed in", func() {
+			It("passes the ca certificate and key to the bosh manager", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+					DirectorCACert:  "some-ca-certificate",
+					DirectorCAKey:   "some-ca-private-key",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserCACertificate).To(Equal("some-ca-certificate"))
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserCAPrivateKey).To(Equal("some-ca-private-key"))
+			})
+		})
+
+		Context("when an ssh key type is passed in", func() {
+			It("records the key type on the keypair before syncing it", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+					SSHKeyBits:      4096,
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairManager.SyncCall.Receives.State.KeyPair.Type).To(Equal("rsa"))
+				Expect(keyPairManager.SyncCall.Receives.State.KeyPair.Bits).To(Equal(4096))
+			})
+		})
+
+		Context("when the keypair is skipped", func() {
+			It("records the provided keypair as managed outside of bbl", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+					SkipKeyPair:     true,
+					KeyPairName:     "some-keypair-name",
+					PublicKey:       "some-public-key",
+					PrivateKey:      "some-private-key",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairManager.SyncCall.Receives.State.KeyPair).To(Equal(storage.KeyPair{
+					Name:       "some-keypair-name",
+					PublicKey:  "some-public-key",
+					PrivateKey: "some-private-key",
+					External:   true,
+				}))
+			})
+		})
+
+		Context("when an existing vpc is passed in", func() {
+			BeforeEach(func() {
+				terraformManager.ApplyCall.Returns.BBLState.AWS.ExistingVPCID = "vpc-123"
+				terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+					"bosh_subnet_cidr": "10.1.2.0/24",
+				}
+			})
+
+			It("records the vpc and subnets before applying terraform", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:       "some-aws-access-key-id",
+					SecretAccessKey:   "some-aws-secret-access-key",
+					Region:            "some-aws-region",
+					ExistingVPCID:     "vpc-123",
+					ExistingSubnetIDs: []string{"subnet-1", "subnet-2"},
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.ExistingVPCID).To(Equal("vpc-123"))
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.ExistingSubnetIDs).To(Equal([]string{"subnet-1", "subnet-2"}))
+			})
+
+			It("deploys the director into the cidr of the first subnet", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:       "some-aws-access-key-id",
+					SecretAccessKey:   "some-aws-secret-access-key",
+					Region:            "some-aws-region",
+					ExistingVPCID:     "vpc-123",
+					ExistingSubnetIDs: []string{"subnet-1", "subnet-2"},
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.Network.SubnetCIDR).To(Equal("10.1.2.0/24"))
+			})
+		})
+
+		Context("when the director spot flag is passed in", func() {
+			It("marks the director as spot with the max price and warns about termination", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:          "some-aws-access-key-id",
+					SecretAccessKey:      "some-aws-secret-access-key",
+					Region:               "some-aws-region",
+					DirectorSpot:         true,
+					DirectorSpotMaxPrice: "0.05",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorSpot).To(BeTrue())
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorSpotPrice).To(Equal("0.05"))
+				Expect(logger.WarnCall.Messages).To(ContainElement("the director will run on preemptible/spot capacity and can be terminated by the IAAS at any time. Only use this for disposable environments."))
+			})
+		})
+
+		Context("when the spot bid price is passed in", func() {
+			It("stores the bid price and fallback for the cloud config", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:          "some-aws-access-key-id",
+					SecretAccessKey:      "some-aws-secret-access-key",
+					Region:               "some-aws-region",
+					SpotBidPrice:         "0.25",
+					SpotOnDemandFallback: true,
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.SpotBidPrice).To(Equal("0.25"))
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.SpotOnDemandFallback).To(BeTrue())
+			})
+
+			It("keeps the bid price of an earlier up when none is passed", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+					AWS:   storage.AWS{SpotBidPrice: "0.25"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.SpotBidPrice).To(Equal("0.25"))
+			})
+		})
+
+		Context("when tags are passed in", func() {
+			It("stores the tags for the terraform template", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+					Tags:            []storage.Tag{{Key: "cost-center", Value: "1234"}},
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+					Tags:  []storage.Tag{{Key: "owner", Value: "some-team"}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.Tags).To(Equal([]storage.Tag{{Key: "cost-center", Value: "1234"}}))
+			})
+
+			It("keeps the tags of an earlier up when none are passed", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+					Tags:  []storage.Tag{{Key: "owner", Value: "some-team"}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.Tags).To(Equal([]storage.Tag{{Key: "owner", Value: "some-team"}}))
+			})
+		})
+
+		Context("when a nat is passed in", func() {
+			It("stores a nat gateway for the terraform template", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+					NAT:             "gateway",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.NAT).To(Equal("gateway"))
+			})
+
+			It("keeps the nat gateway of an earlier up when none is passed", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+					AWS:   storage.AWS{NAT: "gateway"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.NAT).To(Equal("gateway"))
+			})
+
+			It("goes back to the nat instance", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-aws-access-key-id",
+					SecretAccessKey: "some-aws-secret-access-key",
+					Region:          "some-aws-region",
+					NAT:             "instance",
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+					AWS:   storage.AWS{NAT: "gateway"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.AWS.NAT).To(BeEmpty())
+			})
+		})
+
+		Context("when the director disk type is passed in", func() {
+			It("stores the disk type without warning for a new director", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:      "some-aws-access-key-id",
+					SecretAccessKey:  "some-aws-secret-access-key",
+					Region:           "some-aws-region",
+					DirectorDiskType: "gp3",
 				}, storage.State{
 					EnvID: "bbl-lake-time-stamp",
 				})
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserOpsFile).To(Equal("some-ops-file-contents"))
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorDiskType).To(Equal("gp3"))
+				Expect(logger.WarnCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the director vm type and disk size are passed in", func() {
+			It("stores the vm type and disk size without warning for a new director", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:      "some-aws-access-key-id",
+					SecretAccessKey:  "some-aws-secret-access-key",
+					Region:           "some-aws-region",
+					DirectorVMType:   "m4.2xlarge",
+					DirectorDiskSize: 128,
+				}, storage.State{
+					EnvID: "bbl-lake-time-stamp",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorVMType).To(Equal("m4.2xlarge"))
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorDiskSize).To(Equal(128))
+				Expect(logger.WarnCall.CallCount).To(Equal(0))
 			})
 		})
 
@@ -439,11 +853,116 @@ var _ = Describe("AWSUp", func() {
 			})
 		})
 
+		Describe("checkpoints", func() {
+			It("records each completed phase and clears them once up has completed", func() {
+				err := command.Execute(commands.AWSUpConfig{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateStore.SetCall.Receives[1].State.UpCheckpoints).To(HaveLen(1))
+				Expect(stateStore.SetCall.Receives[1].State.UpCheckpoints[0].Phase).To(Equal("keypair"))
+				Expect(stateStore.SetCall.Receives[4].State.UpCheckpoints).To(HaveLen(3))
+				Expect(stateStore.SetCall.Receives[5].State.UpCheckpoints).To(BeEmpty())
+			})
+
+			It("does not record terraform as completed when only targets are applied", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					Targets: []string{"aws_subnet.bosh_subnet"},
+				}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, checkpoint := range stateStore.SetCall.Receives[2].State.UpCheckpoints {
+					Expect(checkpoint.Phase).NotTo(Equal("terraform"))
+				}
+			})
+
+			Context("when an earlier up failed after terraform completed", func() {
+				var appliedState storage.State
+
+				BeforeEach(func() {
+					appliedState = commands.CheckpointUpPhase(terraformManager.ApplyCall.Returns.BBLState, commands.UpPhaseKeyPair)
+					appliedState = commands.CheckpointUpPhase(appliedState, commands.UpPhaseTerraform)
+				})
+
+				It("skips the phases whose state has not changed", func() {
+					err := command.Execute(commands.AWSUpConfig{}, appliedState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(keyPairManager.SyncCall.CallCount).To(Equal(0))
+					Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+					Expect(logger.StepCall.Messages).To(ContainElement("skipping keypair, it has already converged"))
+					Expect(logger.StepCall.Messages).To(ContainElement("skipping terraform, it has already converged"))
+					Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(appliedState))
+				})
+
+				It("applies terraform again when the infrastructure has changed", func() {
+					changedState := appliedState
+					changedState.AWS.NAT = "gateway"
+
+					err := command.Execute(commands.AWSUpConfig{}, changedState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(keyPairManager.SyncCall.CallCount).To(Equal(0))
+					Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				})
+
+				It("runs every phase from the one it is resumed from", func() {
+					err := command.Execute(commands.AWSUpConfig{FromPhase: "terraform"}, appliedState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.StepCall.Messages).To(ContainElement("skipping keypair, resuming from terraform"))
+					Expect(keyPairManager.SyncCall.CallCount).To(Equal(0))
+					Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+					Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				})
+			})
+
+			It("skips the director when it is resumed from the cloud config", func() {
+				err := command.Execute(commands.AWSUpConfig{FromPhase: "cloud-config"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+			})
+		})
+
+		Describe("stemcell upload", func() {
+			It("uploads the stemcell after updating the cloud config and saves the stemcell to the state", func() {
+				stemcellUploader.UploadCall.Returns.State = storage.State{
+					Stemcell: storage.Stemcell{Name: "some-stemcell", Version: "3421.11", Source: "some-stemcell.tgz"},
+				}
+
+				err := command.Execute(commands.AWSUpConfig{UploadStemcell: "some-stemcell.tgz"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stemcellUploader.UploadCall.CallCount).To(Equal(1))
+				Expect(stemcellUploader.UploadCall.Receives.Source).To(Equal("some-stemcell.tgz"))
+				Expect(stemcellUploader.UploadCall.Receives.State).To(Equal(commands.CheckpointUpPhase(cloudConfigManager.UpdateCall.Receives.State, commands.UpPhaseCloudConfig)))
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State).To(Equal(storage.State{
+					Stemcell: storage.Stemcell{Name: "some-stemcell", Version: "3421.11", Source: "some-stemcell.tgz"},
+				}))
+			})
+
+			It("does not upload a stemcell when none is provided", func() {
+				err := command.Execute(commands.AWSUpConfig{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stemcellUploader.UploadCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the stemcell cannot be uploaded", func() {
+				stemcellUploader.UploadCall.Returns.Error = errors.New("failed to upload stemcell")
+
+				err := command.Execute(commands.AWSUpConfig{UploadStemcell: "some-stemcell.tgz"}, storage.State{})
+				Expect(err).To(MatchError("failed to upload stemcell"))
+			})
+		})
+
 		Describe("cloud config", func() {
 			It("updates the bosh director with a cloud config provided an up-to-date state", func() {
 				err := command.Execute(commands.AWSUpConfig{}, storage.State{})
 				Expect(err).NotTo(HaveOccurred())
-				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(storage.State{
+				upToDateState := storage.State{
 					EnvID: "bbl-lake-time-stamp",
 					IAAS:  "aws",
 					KeyPair: storage.KeyPair{
@@ -471,7 +990,10 @@ var _ = Describe("AWSUp", func() {
 						Region:          "some-aws-region",
 					},
 					TFState: "some-tf-state",
-				}))
+				}
+				upToDateState = commands.CheckpointUpPhase(upToDateState, commands.UpPhaseTerraform)
+				upToDateState = commands.CheckpointUpPhase(upToDateState, commands.UpPhaseBOSH)
+				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(upToDateState))
 			})
 		})
 
@@ -514,7 +1036,7 @@ var _ = Describe("AWSUp", func() {
 					err := command.Execute(commands.AWSUpConfig{}, storage.State{})
 					Expect(err).NotTo(HaveOccurred())
 
-					Expect(stateStore.SetCall.CallCount).To(Equal(4))
+					Expect(stateStore.SetCall.CallCount).To(Equal(6))
 					Expect(stateStore.SetCall.Receives[3].State.IAAS).To(Equal("aws"))
 				})
 			})
@@ -529,7 +1051,7 @@ var _ = Describe("AWSUp", func() {
 						}, storage.State{})
 						Expect(err).NotTo(HaveOccurred())
 
-						Expect(stateStore.SetCall.CallCount).To(Equal(5))
+						Expect(stateStore.SetCall.CallCount).To(Equal(7))
 						Expect(stateStore.SetCall.Receives[1].State.AWS).To(Equal(storage.AWS{
 							AccessKeyID:     "some-aws-access-key-id",
 							SecretAccessKey: "some-aws-secret-access-key",
@@ -655,7 +1177,7 @@ var _ = Describe("AWSUp", func() {
 
 			It("returns an error when the ops file cannot be read", func() {
 				err := command.Execute(commands.AWSUpConfig{
-					OpsFilePath: "some/fake/path",
+					OpsFilePaths: []string{"some/fake/path"},
 				}, storage.State{})
 				Expect(err).To(MatchError("open some/fake/path: no such file or directory"))
 			})
@@ -7,28 +7,58 @@ import (
 )
 
 type azureClient interface {
-	ValidateCredentials(subscriptionID, tenantID, clientID, clientSecret string) error
+	ValidateCredentials(subscriptionID, tenantID, clientID, clientSecret, authMethod string) error
 }
 
-type AzureUpConfig struct{}
+type AzureUpConfig terraformUpConfig
 
 type AzureUp struct {
 	azureClient azureClient
-	logger      logger
+	terraformUp terraformUp
 }
 
-func NewAzureUp(azureClient azureClient, logger logger) AzureUp {
+type NewAzureUpArgs struct {
+	AzureClient        azureClient
+	StateStore         stateStore
+	KeyPairManager     keyPairManager
+	BoshManager        boshManager
+	CloudConfigManager cloudConfigManager
+	TerraformManager   terraformApplier
+	EnvIDManager       envIDManager
+	Logger             logger
+}
+
+func NewAzureUp(args NewAzureUpArgs) AzureUp {
 	return AzureUp{
-		azureClient: azureClient,
-		logger:      logger,
+		azureClient: args.AzureClient,
+		terraformUp: terraformUp{
+			stateStore:         args.StateStore,
+			keyPairManager:     args.KeyPairManager,
+			boshManager:        args.BoshManager,
+			cloudConfigManager: args.CloudConfigManager,
+			terraformManager:   args.TerraformManager,
+			envIDManager:       args.EnvIDManager,
+			logger:             args.Logger,
+		},
 	}
 }
 
 func (u AzureUp) Execute(upConfig AzureUpConfig, state storage.State) error {
-	u.logger.Step("verifying credentials")
-	err := u.azureClient.ValidateCredentials(state.Azure.SubscriptionID, state.Azure.TenantID, state.Azure.ClientID, state.Azure.ClientSecret)
+	u.terraformUp.logger.Step("verifying credentials")
+	err := u.azureClient.ValidateCredentials(state.Azure.SubscriptionID, state.Azure.TenantID, state.Azure.ClientID, state.Azure.ClientSecret, state.Azure.AuthMethod)
 	if err != nil {
 		return errors.New("Error: credentials are invalid")
 	}
+
+	return u.terraformUp.execute(terraformUpConfig(upConfig), state, checkAzureDirectorCredentials)
+}
+
+// the azure cpi only authenticates with a service principal, so the az cli
+// and managed identities can only pave the infrastructure.
+func checkAzureDirectorCredentials(state storage.State) error {
+	if !state.NoDirector && state.Azure.ClientSecret == "" {
+		return errors.New("the azure cpi needs a service principal to create the director, pass --azure-client-id and --azure-client-secret or --no-director")
+	}
+
 	return nil
 }
@@ -2,7 +2,9 @@ package commands_test
 
 import (
 	"errors"
+	"io/ioutil"
 
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -15,62 +17,293 @@ var _ = Describe("AzureUp", func() {
 	var (
 		azureUp commands.AzureUp
 
-		azureClient *fakes.AzureClient
-		logger      *fakes.Logger
+		azureClient        *fakes.AzureClient
+		stateStore         *fakes.StateStore
+		keyPairManager     *fakes.KeyPairManager
+		terraformManager   *fakes.TerraformManager
+		boshManager        *fakes.BOSHManager
+		cloudConfigManager *fakes.CloudConfigManager
+		envIDManager       *fakes.EnvIDManager
+		logger             *fakes.Logger
+
+		incomingState          storage.State
+		expectedEnvIDState     storage.State
+		expectedKeyPairState   storage.State
+		expectedTerraformState storage.State
+		expectedBOSHState      storage.State
 	)
 
 	BeforeEach(func() {
-		logger = &fakes.Logger{}
 		azureClient = &fakes.AzureClient{}
+		stateStore = &fakes.StateStore{}
+		keyPairManager = &fakes.KeyPairManager{}
+		terraformManager = &fakes.TerraformManager{}
+		boshManager = &fakes.BOSHManager{}
+		cloudConfigManager = &fakes.CloudConfigManager{}
+		envIDManager = &fakes.EnvIDManager{}
+		logger = &fakes.Logger{}
+
+		incomingState = storage.State{
+			IAAS: "azure",
+			Azure: storage.Azure{
+				SubscriptionID: "subscription-id",
+				TenantID:       "tenant-id",
+				ClientID:       "client-id",
+				ClientSecret:   "client-secret",
+				Region:         "some-region",
+			},
+		}
+
+		expectedEnvIDState = incomingState
+		expectedEnvIDState.EnvID = "some-env-id"
+
+		expectedKeyPairState = expectedEnvIDState
+		expectedKeyPairState.KeyPair = storage.KeyPair{
+			PrivateKey: "some-private-key",
+			PublicKey:  "some-public-key",
+		}
+		expectedKeyPairState = commands.CheckpointUpPhase(expectedKeyPairState, commands.UpPhaseKeyPair)
 
-		azureUp = commands.NewAzureUp(azureClient, logger)
+		expectedTerraformState = expectedKeyPairState
+		expectedTerraformState.TFState = "some-tf-state"
+		expectedTerraformState = commands.CheckpointUpPhase(expectedTerraformState, commands.UpPhaseTerraform)
+
+		expectedBOSHState = expectedTerraformState
+		expectedBOSHState.BOSH = storage.BOSH{
+			DirectorName: "bosh-some-env-id",
+			Manifest:     "some-bosh-manifest",
+		}
+		expectedBOSHState = commands.CheckpointUpPhase(expectedBOSHState, commands.UpPhaseBOSH)
+
+		envIDManager.SyncCall.Returns.State = expectedEnvIDState
+		keyPairManager.SyncCall.Returns.KeyPair = expectedKeyPairState.KeyPair
+		terraformManager.ApplyCall.Returns.BBLState = expectedTerraformState
+		terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+			"vnet_name": "some-vnet-name",
+		}
+		boshManager.CreateDirectorCall.Returns.State = expectedBOSHState
+
+		azureUp = commands.NewAzureUp(commands.NewAzureUpArgs{
+			AzureClient:        azureClient,
+			StateStore:         stateStore,
+			KeyPairManager:     keyPairManager,
+			BoshManager:        boshManager,
+			CloudConfigManager: cloudConfigManager,
+			TerraformManager:   terraformManager,
+			EnvIDManager:       envIDManager,
+			Logger:             logger,
+		})
 	})
 
 	Describe("Execute", func() {
-		It("validates credentials", func() {
-			err := azureUp.Execute(commands.AzureUpConfig{}, storage.State{
-				Azure: storage.Azure{
-					SubscriptionID: "subscription-id",
-					TenantID:       "tenant-id",
-					ClientID:       "client-id",
-					ClientSecret:   "client-secret",
-				},
-			})
+		It("creates the environment", func() {
+			err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(logger.StepCall.CallCount).To(Equal(1))
-			Expect(logger.StepCall.Messages).To(Equal([]string{"verifying credentials"}))
 
-			Expect(azureClient.ValidateCredentialsCall.CallCount).To(Equal(1))
+			By("validating credentials", func() {
+				Expect(logger.StepCall.Messages).To(ContainElement("verifying credentials"))
+				Expect(azureClient.ValidateCredentialsCall.CallCount).To(Equal(1))
+				Expect(azureClient.ValidateCredentialsCall.Receives.SubscriptionID).To(Equal("subscription-id"))
+				Expect(azureClient.ValidateCredentialsCall.Receives.TenantID).To(Equal("tenant-id"))
+				Expect(azureClient.ValidateCredentialsCall.Receives.ClientID).To(Equal("client-id"))
+				Expect(azureClient.ValidateCredentialsCall.Receives.ClientSecret).To(Equal("client-secret"))
+			})
+
+			By("retrieving the env ID and saving it to the state", func() {
+				Expect(envIDManager.SyncCall.CallCount).To(Equal(1))
+				Expect(envIDManager.SyncCall.Receives.State).To(Equal(incomingState))
+				Expect(stateStore.SetCall.Receives[0].State).To(Equal(expectedEnvIDState))
+			})
+
+			By("syncing the keypair and saving it to the state", func() {
+				Expect(keyPairManager.SyncCall.CallCount).To(Equal(1))
+				Expect(keyPairManager.SyncCall.Receives.State).To(Equal(expectedEnvIDState))
+				Expect(stateStore.SetCall.Receives[1].State).To(Equal(expectedKeyPairState))
+			})
+
+			By("creating azure resources via terraform and saving the terraform state", func() {
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(expectedKeyPairState))
+				Expect(stateStore.SetCall.Receives[2].State).To(Equal(expectedTerraformState))
+			})
+
+			By("creating a bosh director with the terraform outputs", func() {
+				Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(expectedTerraformState))
+				Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(expectedTerraformState))
+				Expect(stateStore.SetCall.Receives[3].State).To(Equal(expectedBOSHState))
+			})
+
+			By("updating the cloud config", func() {
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(expectedBOSHState))
+			})
+
+			By("clearing the checkpoints once every phase has completed", func() {
+				finalState := expectedBOSHState
+				finalState.UpCheckpoints = nil
+				Expect(stateStore.SetCall.CallCount).To(Equal(5))
+				Expect(stateStore.SetCall.Receives[4].State).To(Equal(finalState))
+			})
+		})
+
+		Context("when an ops file is provided", func() {
+			It("passes the ops file contents to the director", func() {
+				opsFile, err := ioutil.TempFile("", "ops-file")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = opsFile.Write([]byte("some-ops-file-contents"))
+				Expect(err).NotTo(HaveOccurred())
 
-			Expect(azureClient.ValidateCredentialsCall.Receives.SubscriptionID).To(Equal("subscription-id"))
-			Expect(azureClient.ValidateCredentialsCall.Receives.TenantID).To(Equal("tenant-id"))
-			Expect(azureClient.ValidateCredentialsCall.Receives.ClientID).To(Equal("client-id"))
-			Expect(azureClient.ValidateCredentialsCall.Receives.ClientSecret).To(Equal("client-secret"))
+				err = azureUp.Execute(commands.AzureUpConfig{OpsFilePaths: []string{opsFile.Name()}}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserOpsFiles).To(Equal([]string{"some-ops-file-contents"}))
+			})
+		})
+
+		Context("when a name is provided", func() {
+			It("passes the name to the env id manager", func() {
+				err := azureUp.Execute(commands.AzureUpConfig{Name: "some-name"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.Name).To(Equal("some-name"))
+			})
+		})
+
+		Context("when no director is requested", func() {
+			It("creates the infrastructure without a director", func() {
+				terraformManager.ApplyCall.Returns.BBLState.NoDirector = true
+
+				err := azureUp.Execute(commands.AzureUpConfig{NoDirector: true}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.NoDirector).To(BeTrue())
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when a director already exists", func() {
+				incomingState.BOSH = storage.BOSH{DirectorName: "some-director"}
+
+				err := azureUp.Execute(commands.AzureUpConfig{NoDirector: true}, incomingState)
+				Expect(err).To(MatchError(`Director already exists, you must re-create your environment to use "--no-director"`))
+			})
 		})
 
-		Context("given invalid credentials", func() {
+		Context("when an earlier up failed after some phases completed", func() {
+			It("skips the phases whose state has not changed", func() {
+				err := azureUp.Execute(commands.AzureUpConfig{}, expectedTerraformState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairManager.SyncCall.CallCount).To(Equal(0))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(logger.StepCall.Messages).To(ContainElement("skipping terraform, it has already converged"))
+				Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(expectedTerraformState))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+			})
+
+			It("runs a phase again when its state has changed", func() {
+				changedState := expectedTerraformState
+				changedState.Azure.Region = "some-other-region"
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, changedState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairManager.SyncCall.CallCount).To(Equal(0))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+			})
+
+			It("runs every phase from the one it is resumed from", func() {
+				err := azureUp.Execute(commands.AzureUpConfig{FromPhase: "terraform"}, expectedTerraformState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.StepCall.Messages).To(ContainElement("skipping keypair, resuming from terraform"))
+				Expect(keyPairManager.SyncCall.CallCount).To(Equal(0))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+			})
+		})
+
+		Context("when the az cli or a managed identity authenticates", func() {
 			BeforeEach(func() {
-				azureClient.ValidateCredentialsCall.Returns.Error = errors.New("invalid credentials")
+				incomingState.Azure.ClientID = ""
+				incomingState.Azure.ClientSecret = ""
+				incomingState.Azure.AuthMethod = "cli"
 			})
-			It("returns the error", func() {
-				err := azureUp.Execute(commands.AzureUpConfig{}, storage.State{
-					Azure: storage.Azure{
-						SubscriptionID: "subscription-id",
-						TenantID:       "tenant-id",
-						ClientID:       "client-id",
-						ClientSecret:   "client-secret",
-					},
-				})
+
+			It("validates the credentials with the auth method", func() {
+				err := azureUp.Execute(commands.AzureUpConfig{NoDirector: true}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(azureClient.ValidateCredentialsCall.Receives.AuthMethod).To(Equal("cli"))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+			})
+
+			It("returns an error when a director would be created without a service principal", func() {
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
+				Expect(err).To(MatchError("the azure cpi needs a service principal to create the director, pass --azure-client-id and --azure-client-secret or --no-director"))
+
+				Expect(envIDManager.SyncCall.CallCount).To(Equal(0))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the credentials are invalid", func() {
+				azureClient.ValidateCredentialsCall.Returns.Error = errors.New("invalid credentials")
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
 				Expect(err).To(MatchError("Error: credentials are invalid"))
-				Expect(logger.StepCall.CallCount).To(Equal(1))
-				Expect(logger.StepCall.Messages).To(Equal([]string{"verifying credentials"}))
 
-				Expect(azureClient.ValidateCredentialsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+			})
 
-				Expect(azureClient.ValidateCredentialsCall.Receives.SubscriptionID).To(Equal("subscription-id"))
-				Expect(azureClient.ValidateCredentialsCall.Receives.TenantID).To(Equal("tenant-id"))
-				Expect(azureClient.ValidateCredentialsCall.Receives.ClientID).To(Equal("client-id"))
-				Expect(azureClient.ValidateCredentialsCall.Receives.ClientSecret).To(Equal("client-secret"))
+			It("returns an error when the terraform version is invalid", func() {
+				terraformManager.ValidateVersionCall.Returns.Error = errors.New("terraform too old")
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
+				Expect(err).To(MatchError("terraform too old"))
+			})
+
+			It("returns an error when the keypair cannot be synced", func() {
+				keyPairManager.SyncCall.Returns.Error = errors.New("failed to sync keypair")
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to sync keypair"))
+			})
+
+			It("returns an error when terraform fails to apply", func() {
+				terraformManager.ApplyCall.Returns.Error = errors.New("failed to apply")
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to apply"))
+			})
+
+			It("returns an error when the terraform outputs cannot be retrieved", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to get outputs"))
+			})
+
+			It("saves the bosh state when the director fails to be created", func() {
+				failedState := expectedTerraformState
+				failedState.BOSH.State = map[string]interface{}{"partial": "state"}
+				boshManager.CreateDirectorCall.Returns.Error = bosh.NewManagerCreateError(failedState, errors.New("failed to create"))
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to create"))
+
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State).To(Equal(failedState))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the cloud config cannot be updated", func() {
+				cloudConfigManager.UpdateCall.Returns.Error = errors.New("failed to update cloud config")
+
+				err := azureUp.Execute(commands.AzureUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to update cloud config"))
 			})
 		})
 	})
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/multierror"
+)
+
+type certificateValidator interface {
+	Validate(command, certPath, keyPath, chainPath string) (certs.Certificate, error)
+}
+
+func validateCertificate(validator certificateValidator, logger logger, command, certPath, keyPath, chainPath string) error {
+	certificate, err := validator.Validate(command, certPath, keyPath, chainPath)
+	if err != nil {
+		return certificateValidationError(err)
+	}
+
+	logger.Step("validated certificate for %q, valid until %s", certificate.Subject, certificate.NotAfter.UTC().Format("2006-01-02"))
+	return nil
+}
+
+func certificateValidationError(err error) error {
+	validationError, ok := err.(certs.ValidationError)
+	if !ok {
+		return err
+	}
+
+	validateErrors := multierror.NewMultiError(validationError.Command)
+	for _, failure := range validationError.Failures {
+		validateErrors.Add(errors.New(certificateFailureMessage(failure)))
+	}
+
+	return validateErrors
+}
+
+func certificateFailureMessage(failure certs.Failure) string {
+	switch failure.Problem {
+	case certs.ProblemMissing:
+		return fmt.Sprintf("%s is required", failure.Flag)
+	case certs.ProblemNotFound:
+		return fmt.Sprintf("%s %q: %s file not found", failure.Flag, failure.Path, failure.File)
+	case certs.ProblemMalformed:
+		return fmt.Sprintf("%s %q: %s is malformed: %s", failure.Flag, failure.Path, failure.File, failure.Err)
+	case certs.ProblemExpired:
+		return fmt.Sprintf("%s %q: %s has expired: %s", failure.Flag, failure.Path, failure.File, failure.Err)
+	case certs.ProblemNotYetValid:
+		return fmt.Sprintf("%s %q: %s is not valid yet: %s", failure.Flag, failure.Path, failure.File, failure.Err)
+	case certs.ProblemKeyMismatch:
+		return fmt.Sprintf("%s %q: key does not match the certificate in --cert", failure.Flag, failure.Path)
+	case certs.ProblemChainIncomplete:
+		return fmt.Sprintf("%s %q: chain is incomplete, it must include every intermediate certificate up to a root: %s", failure.Flag, failure.Path, failure.Err)
+	case certs.ProblemChainOutOfOrder:
+		return fmt.Sprintf("%s %q: chain must list each certificate before its issuer: %s", failure.Flag, failure.Path, failure.Err)
+	default:
+		return fmt.Sprintf("%s %q: %s", failure.Flag, failure.Path, failure.Err)
+	}
+}
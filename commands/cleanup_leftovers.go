@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/leftovers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const CleanupLeftoversCommand = "cleanup-leftovers"
+
+type leftoversCleaner interface {
+	List(envID string) ([]leftovers.Resource, error)
+	Delete(resource leftovers.Resource) error
+}
+
+type CleanupLeftovers struct {
+	awsLeftovers        leftoversCleaner
+	gcpLeftovers        leftoversCleaner
+	credentialValidator credentialValidator
+	logger              logger
+	stdin               io.Reader
+}
+
+type cleanupLeftoversConfig struct {
+	envID     string
+	noConfirm bool
+}
+
+func NewCleanupLeftovers(awsLeftovers, gcpLeftovers leftoversCleaner, credentialValidator credentialValidator, logger logger, stdin io.Reader) CleanupLeftovers {
+	return CleanupLeftovers{
+		awsLeftovers:        awsLeftovers,
+		gcpLeftovers:        gcpLeftovers,
+		credentialValidator: credentialValidator,
+		logger:              logger,
+		stdin:               stdin,
+	}
+}
+
+func (c CleanupLeftovers) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	config, err := c.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.IAAS != "aws" && state.IAAS != "gcp" {
+		return fmt.Errorf("bbl cleanup-leftovers only supports aws and gcp, not %q", state.IAAS)
+	}
+
+	if config.envID != "" && config.envID == state.EnvID {
+		return fmt.Errorf("%q is the environment in the bbl state, run bbl destroy to delete it", config.envID)
+	}
+
+	return c.credentialValidator.Validate()
+}
+
+func (c CleanupLeftovers) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := c.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	cleaner := c.awsLeftovers
+	if state.IAAS == "gcp" {
+		cleaner = c.gcpLeftovers
+	}
+
+	found, err := cleaner.List(config.envID)
+	if err != nil {
+		return err
+	}
+
+	// the environment in the state is still managed by bbl, whatever it has
+	// not attached yet is not a leftover.
+	var resources []leftovers.Resource
+	for _, resource := range found {
+		if state.EnvID != "" && resource.EnvID == state.EnvID {
+			continue
+		}
+		resources = append(resources, resource)
+	}
+
+	if len(resources) == 0 {
+		c.logger.Println("no leftovers found")
+		return nil
+	}
+
+	lines := []string{"leftovers:"}
+	for _, resource := range resources {
+		lines = append(lines, fmt.Sprintf("  %s (env %s)", resource, resource.EnvID))
+	}
+	c.logger.Println(strings.Join(lines, "\n"))
+
+	if !config.noConfirm {
+		c.logger.Prompt(fmt.Sprintf("Are you sure you want to delete these %d resources? This operation cannot be undone!", len(resources)))
+
+		var proceed string
+		fmt.Fscanln(c.stdin, &proceed)
+
+		proceed = strings.ToLower(proceed)
+		if proceed != "yes" && proceed != "y" {
+			c.logger.Step("exiting")
+			return nil
+		}
+	}
+
+	errorList := helpers.Errors{}
+	failed := false
+	for _, resource := range resources {
+		c.logger.Step("deleting %s", resource)
+
+		err := cleaner.Delete(resource)
+		if err != nil {
+			errorList.Add(fmt.Errorf("failed to delete %s: %s", resource, err))
+			failed = true
+		}
+	}
+
+	if failed {
+		return errorList
+	}
+
+	c.logger.Step("deleted %d leftovers", len(resources))
+	return nil
+}
+
+func (CleanupLeftovers) parseFlags(subcommandFlags []string) (cleanupLeftoversConfig, error) {
+	cleanupFlags := flags.New(CleanupLeftoversCommand)
+
+	config := cleanupLeftoversConfig{}
+	cleanupFlags.String(&config.envID, "env-id", "")
+	cleanupFlags.Bool(&config.noConfirm, "n", "no-confirm", false)
+
+	err := cleanupFlags.Parse(subcommandFlags)
+	if err != nil {
+		return cleanupLeftoversConfig{}, err
+	}
+
+	return config, nil
+}
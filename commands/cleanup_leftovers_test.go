@@ -0,0 +1,173 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/leftovers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CleanupLeftovers", func() {
+	var (
+		command commands.CleanupLeftovers
+
+		awsLeftovers        *fakes.LeftoversCleaner
+		gcpLeftovers        *fakes.LeftoversCleaner
+		credentialValidator *fakes.CredentialValidator
+		logger              *fakes.Logger
+		stdin               *bytes.Buffer
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		awsLeftovers = &fakes.LeftoversCleaner{}
+		gcpLeftovers = &fakes.LeftoversCleaner{}
+		credentialValidator = &fakes.CredentialValidator{}
+		logger = &fakes.Logger{}
+		stdin = bytes.NewBuffer([]byte{})
+
+		state = storage.State{
+			IAAS:  "aws",
+			EnvID: "some-live-env",
+		}
+
+		command = commands.NewCleanupLeftovers(awsLeftovers, gcpLeftovers, credentialValidator, logger, stdin)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the credentials", func() {
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(credentialValidator.ValidateCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the credentials are invalid", func() {
+			credentialValidator.ValidateCall.Returns.Error = errors.New("invalid credentials")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("invalid credentials"))
+		})
+
+		It("returns an error when the iaas is not supported", func() {
+			state.IAAS = "azure"
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError(`bbl cleanup-leftovers only supports aws and gcp, not "azure"`))
+		})
+
+		It("returns an error when the env id is the environment in the state", func() {
+			err := command.CheckFastFails([]string{"--env-id", "some-live-env"}, state)
+			Expect(err).To(MatchError(`"some-live-env" is the environment in the bbl state, run bbl destroy to delete it`))
+		})
+
+		It("returns an error when flags cannot be parsed", func() {
+			err := command.CheckFastFails([]string{"--unknown-flag"}, state)
+			Expect(err).To(MatchError("flag provided but not defined: -unknown-flag"))
+		})
+	})
+
+	Describe("Execute", func() {
+		var (
+			eip           leftovers.Resource
+			securityGroup leftovers.Resource
+		)
+
+		BeforeEach(func() {
+			eip = leftovers.Resource{Type: leftovers.ElasticIP, Name: "some-env-bosh-eip", ID: "eipalloc-1", EnvID: "some-env"}
+			securityGroup = leftovers.Resource{Type: leftovers.SecurityGroup, Name: "some-env-bosh-security-group", ID: "sg-1", EnvID: "some-env"}
+
+			awsLeftovers.ListCall.Returns.Resources = []leftovers.Resource{
+				eip,
+				securityGroup,
+				{Type: leftovers.SecurityGroup, Name: "some-live-env-bosh-security-group", ID: "sg-2", EnvID: "some-live-env"},
+			}
+		})
+
+		It("deletes the leftovers of other environments after confirmation", func() {
+			stdin.WriteString("yes\n")
+
+			err := command.Execute([]string{"--env-id", "some-env"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsLeftovers.ListCall.Receives.EnvID).To(Equal("some-env"))
+			Expect(logger.PrintlnCall.Messages).To(ContainElement(`leftovers:
+  elastic ip some-env-bosh-eip (env some-env)
+  security group some-env-bosh-security-group (env some-env)`))
+			Expect(logger.PromptCall.Receives.Message).To(Equal("Are you sure you want to delete these 2 resources? This operation cannot be undone!"))
+
+			Expect(awsLeftovers.DeleteCall.Receives.Resources).To(Equal([]leftovers.Resource{eip, securityGroup}))
+			Expect(logger.StepCall.Messages).To(ContainElement("deleting elastic ip some-env-bosh-eip"))
+			Expect(logger.StepCall.Messages).To(ContainElement("deleted 2 leftovers"))
+			Expect(gcpLeftovers.ListCall.CallCount).To(Equal(0))
+		})
+
+		It("does not delete anything when the user does not confirm", func() {
+			stdin.WriteString("no\n")
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsLeftovers.DeleteCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(ContainElement("exiting"))
+		})
+
+		It("does not ask for confirmation with --no-confirm", func() {
+			err := command.Execute([]string{"--no-confirm"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PromptCall.CallCount).To(Equal(0))
+			Expect(awsLeftovers.DeleteCall.CallCount).To(Equal(2))
+		})
+
+		It("lists the gcp leftovers when the iaas is gcp", func() {
+			state.IAAS = "gcp"
+			gcpLeftovers.ListCall.Returns.Resources = []leftovers.Resource{
+				{Type: leftovers.Address, Name: "some-env-bosh-external-ip", EnvID: "some-env"},
+			}
+
+			err := command.Execute([]string{"-n"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsLeftovers.ListCall.CallCount).To(Equal(0))
+			Expect(gcpLeftovers.DeleteCall.CallCount).To(Equal(1))
+		})
+
+		It("prints when there are no leftovers", func() {
+			awsLeftovers.ListCall.Returns.Resources = nil
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(ContainElement("no leftovers found"))
+			Expect(logger.PromptCall.CallCount).To(Equal(0))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when listing the leftovers fails", func() {
+				awsLeftovers.ListCall.Returns.Error = errors.New("failed to list")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to list"))
+			})
+
+			It("deletes the rest and returns every error when deletes fail", func() {
+				awsLeftovers.DeleteCall.Stub = func(resource leftovers.Resource) error {
+					return errors.New("DependencyViolation")
+				}
+
+				err := command.Execute([]string{"-n"}, state)
+				Expect(err).To(MatchError(`the following errors occurred:
+failed to delete elastic ip some-env-bosh-eip: DependencyViolation,
+failed to delete security group some-env-bosh-security-group: DependencyViolation`))
+				Expect(awsLeftovers.DeleteCall.CallCount).To(Equal(2))
+			})
+		})
+	})
+})
@@ -1,22 +1,54 @@
 package commands
 
-import "github.com/cloudfoundry/bosh-bootloader/storage"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
 
 const (
 	CloudConfigCommand = "cloud-config"
 )
 
 type CloudConfig struct {
-	logger             logger
-	stateValidator     stateValidator
-	cloudConfigManager cloudConfigManager
+	logger                       logger
+	stateValidator               stateValidator
+	cloudConfigManager           cloudConfigManager
+	stateStore                   stateStore
+	terraformManager             terraformOutputter
+	gcpAvailabilityZoneRetriever gcpAvailabilityZoneRetriever
+	cloudConfigFetcher           cloudConfigFetcher
+}
+
+type cloudConfigFetcher interface {
+	Fetch(url, checksum string) (string, error)
 }
 
-func NewCloudConfig(logger logger, stateValidator stateValidator, cloudConfigManager cloudConfigManager) CloudConfig {
+type cloudConfigConfig struct {
+	regenerateAZs bool
+	fromURL       string
+	sha256        string
+	update        bool
+	force         bool
+	diff          bool
+	noColor       bool
+}
+
+func NewCloudConfig(logger logger, stateValidator stateValidator, cloudConfigManager cloudConfigManager, stateStore stateStore,
+	terraformManager terraformOutputter, gcpAvailabilityZoneRetriever gcpAvailabilityZoneRetriever, cloudConfigFetcher cloudConfigFetcher) CloudConfig {
 	return CloudConfig{
-		logger:             logger,
-		stateValidator:     stateValidator,
-		cloudConfigManager: cloudConfigManager,
+		logger:                       logger,
+		stateValidator:               stateValidator,
+		cloudConfigManager:           cloudConfigManager,
+		stateStore:                   stateStore,
+		terraformManager:             terraformManager,
+		gcpAvailabilityZoneRetriever: gcpAvailabilityZoneRetriever,
+		cloudConfigFetcher:           cloudConfigFetcher,
 	}
 }
 
@@ -26,10 +58,71 @@ func (c CloudConfig) CheckFastFails(subcommandFlags []string, state storage.Stat
 		return err
 	}
 
+	config, err := c.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.regenerateAZs && state.IAAS != "gcp" {
+		return errors.New(`--regenerate-azs is only supported when iaas="gcp"`)
+	}
+
+	if config.sha256 != "" && config.fromURL == "" {
+		return errors.New("--sha256 requires --from-url")
+	}
+
+	if config.force && !config.update {
+		return errors.New("--force requires --update")
+	}
+
+	if config.update && (config.regenerateAZs || config.fromURL != "") {
+		return errors.New("--update cannot be used with --regenerate-azs or --from-url")
+	}
+
+	if config.noColor && !config.diff {
+		return errors.New("--no-color requires --diff")
+	}
+
+	if config.diff && (config.update || config.regenerateAZs || config.fromURL != "") {
+		return errors.New("--diff cannot be used with --update, --regenerate-azs or --from-url")
+	}
+
+	if config.fromURL != "" {
+		if config.regenerateAZs {
+			return errors.New("--from-url cannot be used with --regenerate-azs")
+		}
+
+		fromURL, err := url.Parse(config.fromURL)
+		if err != nil || (fromURL.Scheme != "http" && fromURL.Scheme != "https") || fromURL.Host == "" {
+			return fmt.Errorf("--from-url must be an http or https URL, got %q", config.fromURL)
+		}
+	}
+
 	return nil
 }
 
 func (c CloudConfig) Execute(args []string, state storage.State) error {
+	config, err := c.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if config.regenerateAZs {
+		return c.regenerateAZs(state)
+	}
+
+	if config.fromURL != "" {
+		return c.applyFromURL(state, config.fromURL, config.sha256)
+	}
+
+	if config.update {
+		return c.updateIfChanged(state, config.force)
+	}
+
+	if config.diff {
+		return c.diff(state, !config.noColor)
+	}
+
 	contents, err := c.cloudConfigManager.Generate(state)
 	if err != nil {
 		return err
@@ -37,3 +130,190 @@ func (c CloudConfig) Execute(args []string, state storage.State) error {
 	c.logger.Println(string(contents))
 	return nil
 }
+
+func (c CloudConfig) DryRun(args []string, state storage.State) error {
+	config, err := c.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if config.regenerateAZs {
+		zones, err := c.gcpAvailabilityZoneRetriever.GetZones(state.GCP.Region)
+		if err != nil {
+			return err
+		}
+
+		zones, err = selectZones(zones, state.GCP.SelectedZones, state.GCP.Region)
+		if err != nil {
+			return err
+		}
+
+		warnOnZoneChange(state.GCP.Zones, zones, c.logger)
+		state.GCP.Zones = zones
+
+		contents, err := c.cloudConfigManager.Generate(state)
+		if err != nil {
+			return err
+		}
+
+		printDryRun(c.logger, "cloud-config", []string{
+			fmt.Sprintf("set the availability zones to [%s]", strings.Join(zones, ", ")),
+			"save the bbl state",
+			"update the director with the cloud config below",
+		}, contents)
+		return nil
+	}
+
+	if config.fromURL != "" {
+		c.logger.Step("fetching cloud config from %s", config.fromURL)
+		contents, err := c.cloudConfigFetcher.Fetch(config.fromURL, config.sha256)
+		if err != nil {
+			return err
+		}
+
+		printDryRun(c.logger, "cloud-config", []string{"update the director with the cloud config below"}, contents)
+		return nil
+	}
+
+	if config.update {
+		contents, err := c.cloudConfigManager.Generate(state)
+		if err != nil {
+			return err
+		}
+
+		change := "update the director with the cloud config below if it differs from the director's"
+		if config.force {
+			change = "update the director with the cloud config below"
+		}
+
+		printDryRun(c.logger, "cloud-config", []string{change}, contents)
+		return nil
+	}
+
+	return c.Execute(args, state)
+}
+
+func (c CloudConfig) regenerateAZs(state storage.State) error {
+	terraformOutputs, err := c.terraformManager.GetOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	if region, ok := terraformOutputs["region"].(string); ok && region != "" && region != state.GCP.Region {
+		return fmt.Errorf("the region in the state (%s) differs from the region the infrastructure was created in (%s), run `bbl up` to move the infrastructure before regenerating the availability zones",
+			state.GCP.Region, region)
+	}
+
+	zones, err := c.gcpAvailabilityZoneRetriever.GetZones(state.GCP.Region)
+	if err != nil {
+		return err
+	}
+
+	zones, err = selectZones(zones, state.GCP.SelectedZones, state.GCP.Region)
+	if err != nil {
+		return err
+	}
+
+	warnOnZoneChange(state.GCP.Zones, zones, c.logger)
+	state.GCP.Zones = zones
+
+	err = c.stateStore.Set(state)
+	if err != nil {
+		return err
+	}
+
+	err = c.cloudConfigManager.Update(state)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Step("regenerated cloud config with availability zones for %s", state.GCP.Region)
+	return nil
+}
+
+func (c CloudConfig) applyFromURL(state storage.State, fromURL, checksum string) error {
+	c.logger.Step("fetching cloud config from %s", fromURL)
+	contents, err := c.cloudConfigFetcher.Fetch(fromURL, checksum)
+	if err != nil {
+		return err
+	}
+
+	err = c.cloudConfigManager.Apply(state, contents)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Step("applied cloud config from %s", fromURL)
+	return nil
+}
+
+func (c CloudConfig) updateIfChanged(state storage.State, force bool) error {
+	updated, err := c.cloudConfigManager.UpdateIfChanged(state, force)
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		c.logger.Step("the director's cloud config is up to date")
+		return nil
+	}
+
+	c.logger.Step("updated the director's cloud config")
+	return nil
+}
+
+// diff prints how the cloud config bbl generates differs from the one the
+// director is using, which is what a re-run of bbl up would upload.
+func (c CloudConfig) diff(state storage.State, color bool) error {
+	cloudConfig, err := c.cloudConfigManager.Generate(state)
+	if err != nil {
+		return err
+	}
+
+	currentCloudConfig, err := c.cloudConfigManager.Current(state)
+	if err != nil {
+		return err
+	}
+
+	diff := diffLines(currentCloudConfig, cloudConfig)
+	if diff == "" {
+		c.logger.Step("the director's cloud config is up to date")
+		return nil
+	}
+
+	if color {
+		diff = colorDiff(diff)
+	}
+
+	c.logger.Println(diff)
+	return nil
+}
+
+func (c CloudConfig) parseArgs(args []string) (cloudConfigConfig, error) {
+	var config cloudConfigConfig
+
+	cloudConfigFlags := flags.New("cloud-config")
+	cloudConfigFlags.Bool(&config.regenerateAZs, "", "regenerate-azs", false)
+	cloudConfigFlags.String(&config.fromURL, "from-url", "")
+	cloudConfigFlags.String(&config.sha256, "sha256", "")
+	cloudConfigFlags.Bool(&config.update, "", "update", false)
+	cloudConfigFlags.Bool(&config.force, "", "force", false)
+	cloudConfigFlags.Bool(&config.diff, "", "diff", false)
+	cloudConfigFlags.Bool(&config.noColor, "", "no-color", false)
+
+	err := cloudConfigFlags.Parse(args)
+	if err != nil {
+		return cloudConfigConfig{}, err
+	}
+
+	return config, nil
+}
+
+func warnOnZoneChange(previousZones, zones []string, logger logger) {
+	if len(previousZones) == 0 || reflect.DeepEqual(previousZones, zones) {
+		return
+	}
+
+	logger.Warn("availability zones changed from [%s] to [%s], moving AZs may require redeploying existing deployments.",
+		strings.Join(previousZones, ", "), strings.Join(zones, ", "))
+}
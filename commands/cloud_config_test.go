@@ -17,12 +17,20 @@ var _ = Describe("CloudConfig", func() {
 		cloudConfig        commands.CloudConfig
 		state              storage.State
 		cloudConfigManager *fakes.CloudConfigManager
+		stateStore         *fakes.StateStore
+		terraformManager   *fakes.TerraformManager
+		gcpZones           *fakes.GCPClient
+		cloudConfigFetcher *fakes.CloudConfigFetcher
 	)
 
 	BeforeEach(func() {
 		logger = &fakes.Logger{}
 		stateValidator = &fakes.StateValidator{}
 		cloudConfigManager = &fakes.CloudConfigManager{}
+		stateStore = &fakes.StateStore{}
+		terraformManager = &fakes.TerraformManager{}
+		gcpZones = &fakes.GCPClient{}
+		cloudConfigFetcher = &fakes.CloudConfigFetcher{}
 
 		cloudConfigManager.GenerateCall.Returns.CloudConfig = "some-cloud-config"
 
@@ -35,7 +43,7 @@ var _ = Describe("CloudConfig", func() {
 			},
 		}
 
-		cloudConfig = commands.NewCloudConfig(logger, stateValidator, cloudConfigManager)
+		cloudConfig = commands.NewCloudConfig(logger, stateValidator, cloudConfigManager, stateStore, terraformManager, gcpZones, cloudConfigFetcher)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -44,6 +52,56 @@ var _ = Describe("CloudConfig", func() {
 			err := cloudConfig.CheckFastFails([]string{}, storage.State{})
 			Expect(err).To(MatchError("failed to validate state"))
 		})
+
+		It("returns an error when --regenerate-azs is used outside of gcp", func() {
+			err := cloudConfig.CheckFastFails([]string{"--regenerate-azs"}, storage.State{IAAS: "aws"})
+			Expect(err).To(MatchError(`--regenerate-azs is only supported when iaas="gcp"`))
+		})
+
+		It("returns an error when --sha256 is used without --from-url", func() {
+			err := cloudConfig.CheckFastFails([]string{"--sha256", "some-sha256"}, storage.State{})
+			Expect(err).To(MatchError("--sha256 requires --from-url"))
+		})
+
+		It("returns an error when --from-url is used with --regenerate-azs", func() {
+			err := cloudConfig.CheckFastFails([]string{"--from-url", "https://example.com/cloud-config.yml", "--regenerate-azs"}, storage.State{IAAS: "gcp"})
+			Expect(err).To(MatchError("--from-url cannot be used with --regenerate-azs"))
+		})
+
+		It("returns an error when --from-url is not an http or https url", func() {
+			err := cloudConfig.CheckFastFails([]string{"--from-url", "file:///tmp/cloud-config.yml"}, storage.State{})
+			Expect(err).To(MatchError(`--from-url must be an http or https URL, got "file:///tmp/cloud-config.yml"`))
+		})
+
+		It("returns an error when --force is used without --update", func() {
+			err := cloudConfig.CheckFastFails([]string{"--force"}, storage.State{})
+			Expect(err).To(MatchError("--force requires --update"))
+		})
+
+		It("returns an error when --update is used with --from-url", func() {
+			err := cloudConfig.CheckFastFails([]string{"--update", "--from-url", "https://example.com/cloud-config.yml"}, storage.State{})
+			Expect(err).To(MatchError("--update cannot be used with --regenerate-azs or --from-url"))
+		})
+
+		It("returns an error when --update is used with --regenerate-azs", func() {
+			err := cloudConfig.CheckFastFails([]string{"--update", "--regenerate-azs"}, storage.State{IAAS: "gcp"})
+			Expect(err).To(MatchError("--update cannot be used with --regenerate-azs or --from-url"))
+		})
+
+		It("returns an error when --no-color is used without --diff", func() {
+			err := cloudConfig.CheckFastFails([]string{"--no-color"}, storage.State{})
+			Expect(err).To(MatchError("--no-color requires --diff"))
+		})
+
+		It("returns an error when --diff is used with --update", func() {
+			err := cloudConfig.CheckFastFails([]string{"--diff", "--update"}, storage.State{})
+			Expect(err).To(MatchError("--diff cannot be used with --update, --regenerate-azs or --from-url"))
+		})
+
+		It("returns an error when an unknown flag is provided", func() {
+			err := cloudConfig.CheckFastFails([]string{"--some-unknown-flag"}, storage.State{})
+			Expect(err).To(MatchError("flag provided but not defined: -some-unknown-flag"))
+		})
 	})
 
 	Describe("Execute", func() {
@@ -55,6 +113,216 @@ var _ = Describe("CloudConfig", func() {
 			Expect(logger.PrintlnCall.Messages).To(ContainElement("some-cloud-config"))
 		})
 
+		Context("when --from-url is provided", func() {
+			BeforeEach(func() {
+				cloudConfigFetcher.FetchCall.Returns.CloudConfig = "some-published-cloud-config"
+			})
+
+			It("fetches the cloud config and applies it to the director", func() {
+				err := cloudConfig.Execute([]string{"--from-url", "https://example.com/cloud-config.yml", "--sha256", "some-sha256"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cloudConfigFetcher.FetchCall.Receives.URL).To(Equal("https://example.com/cloud-config.yml"))
+				Expect(cloudConfigFetcher.FetchCall.Receives.Checksum).To(Equal("some-sha256"))
+
+				Expect(cloudConfigManager.GenerateCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.ApplyCall.Receives.State).To(Equal(state))
+				Expect(cloudConfigManager.ApplyCall.Receives.CloudConfig).To(Equal("some-published-cloud-config"))
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{
+					"fetching cloud config from https://example.com/cloud-config.yml",
+					"applied cloud config from https://example.com/cloud-config.yml",
+				}))
+			})
+
+			Context("failure cases", func() {
+				It("returns an error when the cloud config cannot be fetched", func() {
+					cloudConfigFetcher.FetchCall.Returns.Error = errors.New("failed to fetch")
+
+					err := cloudConfig.Execute([]string{"--from-url", "https://example.com/cloud-config.yml"}, state)
+					Expect(err).To(MatchError("failed to fetch"))
+					Expect(cloudConfigManager.ApplyCall.CallCount).To(Equal(0))
+				})
+
+				It("returns an error when the cloud config cannot be applied", func() {
+					cloudConfigManager.ApplyCall.Returns.Error = errors.New("failed to apply")
+
+					err := cloudConfig.Execute([]string{"--from-url", "https://example.com/cloud-config.yml"}, state)
+					Expect(err).To(MatchError("failed to apply"))
+				})
+			})
+		})
+
+		Context("when --regenerate-azs is provided", func() {
+			BeforeEach(func() {
+				state.IAAS = "gcp"
+				state.GCP = storage.GCP{
+					Region: "us-east1",
+					Zones:  []string{"us-west1-a", "us-west1-b"},
+				}
+
+				terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+					"region": "us-east1",
+				}
+				gcpZones.GetZonesCall.Returns.Zones = []string{"us-east1-b", "us-east1-c", "us-east1-d"}
+			})
+
+			It("recomputes the zones for the region, saves them, and updates the cloud config", func() {
+				err := cloudConfig.Execute([]string{"--regenerate-azs"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(gcpZones.GetZonesCall.Receives.Region).To(Equal("us-east1"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State.GCP.Zones).To(Equal([]string{"us-east1-b", "us-east1-c", "us-east1-d"}))
+
+				Expect(cloudConfigManager.GenerateCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateCall.Receives.State.GCP.Zones).To(Equal([]string{"us-east1-b", "us-east1-c", "us-east1-d"}))
+
+				Expect(logger.WarnCall.Messages).To(ContainElement("availability zones changed from [us-west1-a, us-west1-b] to [us-east1-b, us-east1-c, us-east1-d], moving AZs may require redeploying existing deployments."))
+				Expect(logger.StepCall.Messages).To(ContainElement("regenerated cloud config with availability zones for us-east1"))
+			})
+
+			It("does not warn when the zones have not changed", func() {
+				state.GCP.Zones = []string{"us-east1-b", "us-east1-c", "us-east1-d"}
+
+				err := cloudConfig.Execute([]string{"--regenerate-azs"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+			})
+
+			Context("failure cases", func() {
+				It("returns an error when the region in the state differs from the terraform outputs", func() {
+					terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+						"region": "us-west1",
+					}
+
+					err := cloudConfig.Execute([]string{"--regenerate-azs"}, state)
+					Expect(err).To(MatchError("the region in the state (us-east1) differs from the region the infrastructure was created in (us-west1), run `bbl up` to move the infrastructure before regenerating the availability zones"))
+
+					Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(state))
+					Expect(gcpZones.GetZonesCall.CallCount).To(Equal(0))
+					Expect(stateStore.SetCall.CallCount).To(Equal(0))
+					Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				})
+
+				It("returns an error when the terraform outputs cannot be retrieved", func() {
+					terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+					err := cloudConfig.Execute([]string{"--regenerate-azs"}, state)
+					Expect(err).To(MatchError("failed to get outputs"))
+				})
+
+				It("returns an error when the zones cannot be retrieved", func() {
+					gcpZones.GetZonesCall.Returns.Error = errors.New("failed to get zones")
+
+					err := cloudConfig.Execute([]string{"--regenerate-azs"}, state)
+					Expect(err).To(MatchError("failed to get zones"))
+				})
+
+				It("returns an error when the state cannot be saved", func() {
+					stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+					err := cloudConfig.Execute([]string{"--regenerate-azs"}, state)
+					Expect(err).To(MatchError("failed to set state"))
+				})
+
+				It("returns an error when the cloud config cannot be updated", func() {
+					cloudConfigManager.UpdateCall.Returns.Error = errors.New("failed to update cloud config")
+
+					err := cloudConfig.Execute([]string{"--regenerate-azs"}, state)
+					Expect(err).To(MatchError("failed to update cloud config"))
+				})
+			})
+		})
+
+		Context("when --update is provided", func() {
+			It("updates the director when its cloud config has changed", func() {
+				cloudConfigManager.UpdateIfChangedCall.Returns.Updated = true
+
+				err := cloudConfig.Execute([]string{"--update"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cloudConfigManager.UpdateIfChangedCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateIfChangedCall.Receives.State).To(Equal(state))
+				Expect(cloudConfigManager.UpdateIfChangedCall.Receives.Force).To(BeFalse())
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				Expect(logger.StepCall.Messages).To(ContainElement("updated the director's cloud config"))
+			})
+
+			It("reports when the director's cloud config is already up to date", func() {
+				err := cloudConfig.Execute([]string{"--update"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.StepCall.Messages).To(ContainElement("the director's cloud config is up to date"))
+				Expect(logger.PrintlnCall.CallCount).To(Equal(0))
+			})
+
+			It("forces the update when --force is provided", func() {
+				cloudConfigManager.UpdateIfChangedCall.Returns.Updated = true
+
+				err := cloudConfig.Execute([]string{"--update", "--force"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cloudConfigManager.UpdateIfChangedCall.Receives.Force).To(BeTrue())
+			})
+
+			Context("failure cases", func() {
+				It("returns an error when the cloud config cannot be updated", func() {
+					cloudConfigManager.UpdateIfChangedCall.Returns.Error = errors.New("failed to update cloud config")
+
+					err := cloudConfig.Execute([]string{"--update"}, state)
+					Expect(err).To(MatchError("failed to update cloud config"))
+				})
+			})
+		})
+
+		Context("when --diff is provided", func() {
+			BeforeEach(func() {
+				cloudConfigManager.GenerateCall.Returns.CloudConfig = "azs:\n- name: z1\n- name: z2\nvm_types: []"
+				cloudConfigManager.CurrentCall.Returns.CloudConfig = "azs:\n- name: z1\nvm_types: []"
+			})
+
+			It("prints a colored diff of the director's cloud config and the generated one", func() {
+				err := cloudConfig.Execute([]string{"--diff"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cloudConfigManager.CurrentCall.Receives.State).To(Equal(state))
+				Expect(cloudConfigManager.UpdateIfChangedCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("  azs:\n  - name: z1\n\x1b[32m+ - name: z2\x1b[0m\n  vm_types: []"))
+			})
+
+			It("prints the diff without color when --no-color is provided", func() {
+				cloudConfigManager.CurrentCall.Returns.CloudConfig = "azs:\n- name: z1\n- name: z2\n- name: z3\nvm_types: []"
+
+				err := cloudConfig.Execute([]string{"--diff", "--no-color"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("  azs:\n  - name: z1\n  - name: z2\n- - name: z3\n  vm_types: []"))
+			})
+
+			It("reports when the director's cloud config is up to date", func() {
+				cloudConfigManager.CurrentCall.Returns.CloudConfig = cloudConfigManager.GenerateCall.Returns.CloudConfig
+
+				err := cloudConfig.Execute([]string{"--diff"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.StepCall.Messages).To(ContainElement("the director's cloud config is up to date"))
+				Expect(logger.PrintlnCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the director's cloud config cannot be fetched", func() {
+				cloudConfigManager.CurrentCall.Returns.Error = errors.New("failed to fetch cloud config")
+
+				err := cloudConfig.Execute([]string{"--diff"}, state)
+				Expect(err).To(MatchError("failed to fetch cloud config"))
+			})
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when the cloud config manager fails to generate", func() {
 				cloudConfigManager.GenerateCall.Returns.Error = errors.New("failed to generate cloud configuration")
@@ -63,4 +331,56 @@ var _ = Describe("CloudConfig", func() {
 			})
 		})
 	})
+
+	Describe("DryRun", func() {
+		It("prints the generated cloud config", func() {
+			err := cloudConfig.DryRun([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("some-cloud-config"))
+		})
+
+		It("prints the cloud config from a url without applying it", func() {
+			cloudConfigFetcher.FetchCall.Returns.CloudConfig = "some-published-cloud-config"
+
+			err := cloudConfig.DryRun([]string{"--from-url", "https://example.com/cloud-config.yml"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cloudConfigManager.ApplyCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl cloud-config --dry-run would:
+  - update the director with the cloud config below
+
+some-published-cloud-config`))
+		})
+
+		It("prints the cloud config that --update would upload without updating the director", func() {
+			err := cloudConfig.DryRun([]string{"--update"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cloudConfigManager.UpdateIfChangedCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl cloud-config --dry-run would:
+  - update the director with the cloud config below if it differs from the director's
+
+some-cloud-config`))
+		})
+
+		It("prints the zones that would be set without saving them", func() {
+			state.IAAS = "gcp"
+			state.GCP.Region = "us-east1"
+			gcpZones.GetZonesCall.Returns.Zones = []string{"us-east1-b", "us-east1-c"}
+
+			err := cloudConfig.DryRun([]string{"--regenerate-azs"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			Expect(cloudConfigManager.GenerateCall.Receives.State.GCP.Zones).To(Equal([]string{"us-east1-b", "us-east1-c"}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl cloud-config --dry-run would:
+  - set the availability zones to [us-east1-b, us-east1-c]
+  - save the bbl state
+  - update the director with the cloud config below
+
+some-cloud-config`))
+		})
+	})
 })
@@ -3,26 +3,76 @@ package commands
 const (
 	UpCommandUsage = `Deploys BOSH director on an IAAS
 
-  --iaas                     IAAS to deploy your BOSH director onto. Valid options: "gcp", "aws" (Defaults to environment variable BBL_IAAS)
-  [--name]                   Name to assign to your BOSH director (optional, will be randomly generated)
-  [--ops-file]               Path to BOSH ops file (optional)
-  [--jumpbox]                Deploy your BOSH director behind a jumpbox (supported when iaas="gcp")
-  [--no-director]            Skips creating BOSH environment
-
-  --aws-access-key-id        AWS Access Key ID to use (Defaults to environment variable BBL_AWS_ACCESS_KEY_ID)
-  --aws-secret-access-key    AWS Secret Access Key to use (Defaults to environment variable BBL_AWS_SECRET_ACCESS_KEY)
-  --aws-region               AWS Region to use (Defaults to environment variable BBL_AWS_REGION)
-  [--aws-bosh-az]            AWS Availability Zone to use for BOSH director (Defaults to environment variable BBL_AWS_BOSH_AZ)
-
-  --gcp-service-account-key  GCP Service Access Key to use (Defaults to environment variable BBL_GCP_SERVICE_ACCOUNT_KEY)
-  --gcp-project-id           GCP Project ID to use (Defaults to environment variable BBL_GCP_PROJECT_ID)
-  --gcp-zone                 GCP Zone to use for BOSH director (Defaults to environment variable BBL_GCP_ZONE)
-  --gcp-region               GCP Region to use (Defaults to environment variable BBL_GCP_REGION)`
+  --iaas                               IAAS to deploy your BOSH director onto. Valid options: "gcp", "aws" (Defaults to environment variable BBL_IAAS)
+  [--name]                             Name to assign to your BOSH director (optional, will be randomly generated)
+  [--ops-file]                         Path to a BOSH ops file for the director, can be repeated; saved in the state and applied on every later up until replaced (optional)
+  [--jumpbox]                          Deploy your BOSH director behind a jumpbox (supported when iaas="gcp")
+  [--director-ssh]                     Adds the jumpbox user to a director behind a jumpbox for bbl ssh --director and director-backup (optional, kept on every later up)
+  [--no-director]                      Skips creating BOSH environment
+  [--detach]                           Returns once infrastructure is created and continues deploying the BOSH director in the background (experimental)
+  [--subnet-cidr]                      CIDR block for the BOSH director subnet within the VPC/network (optional, defaults to 10.0.0.0/24)
+  [--director-ca-cert]                 Path to a CA certificate used to issue the BOSH director certificates (optional, requires --director-ca-key)
+  [--director-ca-key]                  Path to the private key of the director CA certificate (optional, requires --director-ca-cert)
+  [--director-spot]                    Runs the BOSH director on preemptible (gcp) or spot (aws) capacity, which can terminate it at any time (optional, must be passed on every up)
+  [--director-disk-type]               Disk type for the BOSH director's disks: "pd-standard" or "pd-ssd" (gcp), "gp2", "gp3" or "standard" (aws) (optional, defaults to "pd-standard"/"gp2"; changing it recreates the disks)
+  [--director-vm-type]                 Instance type (aws) or machine type (gcp) for the BOSH director VM, e.g. "m4.2xlarge" or "n1-standard-8" (optional, defaults to "m4.xlarge"/"n1-standard-1"; changing it recreates the VM)
+  [--director-disk-size]               Size in GB of the BOSH director's persistent disk (optional, defaults to 32)
+  [--director-external-db]             Keeps the BOSH director's database in RDS (aws) or Cloud SQL (gcp) instead of on its persistent disk (optional, only when creating the director; kept on every later up)
+  [--ssh-key-type]                     Algorithm for newly generated keypairs: "rsa" or "ed25519" (optional, defaults to "rsa"; existing keys are kept until rotated)
+  [--ssh-key-bits]                     Key size for newly generated rsa keypairs (optional, defaults to 2048)
+  [--skip-keypair]                     Uses the key pair from --public-key and --private-key instead of creating one in the IAAS (optional)
+  [--public-key]                       Path to the public key in authorized_keys format of an externally managed key pair (requires --skip-keypair, derived from --private-key when omitted)
+  [--private-key]                      Path to the private key of an externally managed key pair (requires --skip-keypair)
+  [--target]                           Terraform resource address to apply, limiting the apply to it and its dependencies. Requires an existing infrastructure. May be repeated (optional)
+  [--skip-quota-check]                 Skips checking the IAAS quotas for the resources a new environment creates (optional)
+  [--upload-stemcell]                  Path or URL of a stemcell to upload to the director after it is deployed, skipped if the director already has it (optional)
+  [--tag]                              Tag (aws) or label (gcp) of the resources bbl creates as key=value, may be repeated (optional, replaces the tags of an earlier up)
+  [--from-phase]                       Runs every phase from this one on, skipping the phases before it: "keypair", "terraform", "bosh" or "cloud-config" (optional)
+  [--bosh-deployment-path]             Path to a bosh-deployment checkout to deploy the director from instead of the copy in bbl (optional, kept on every later up)
+  [--bosh-deployment-version]          Full commit sha of cloudfoundry/bosh-deployment to download and deploy the director from, "bundled" for the copy in bbl (optional, kept on every later up)
+  [--jumpbox-deployment-path]          Path to a jumpbox-deployment checkout to deploy the jumpbox from instead of the copy in bbl (optional, kept on every later up)
+  [--jumpbox-deployment-version]       Full commit sha of cppforlife/jumpbox-deployment to download and deploy the jumpbox from, "bundled" for the copy in bbl (optional, kept on every later up)
+  [--director-stemcell-url]            URL or path of a stemcell tarball for the director and jumpbox instead of the one bosh-deployment pins, "bundled" to go back to it (optional, kept on every later up)
+  [--director-stemcell-name]           Name of the bosh.io stemcell for --director-stemcell-version (optional, defaults to the stemcell bosh-deployment pins for the IAAS)
+  [--director-stemcell-version]        Version of the bosh.io stemcell for the director and jumpbox (optional, kept on every later up)
+  [--director-stemcell-sha1]           SHA1 of the stemcell (required with --director-stemcell-version or an http --director-stemcell-url)
+
+  --aws-access-key-id                  AWS Access Key ID to use (Defaults to environment variable BBL_AWS_ACCESS_KEY_ID)
+  --aws-secret-access-key              AWS Secret Access Key to use (Defaults to environment variable BBL_AWS_SECRET_ACCESS_KEY)
+  --aws-region                         AWS Region to use (Defaults to environment variable BBL_AWS_REGION)
+  [--aws-profile]                      Profile in the shared AWS credentials file to read the access key and secret from when they are not provided (Defaults to environment variable AWS_PROFILE)
+  [--aws-bosh-az]                      AWS Availability Zone to use for BOSH director (Defaults to environment variable BBL_AWS_BOSH_AZ)
+  [--vpc-cidr]                         CIDR block for the VPC (optional, defaults to 10.0.0.0/16)
+  [--spot-max-price]                   Maximum hourly price to bid for the director spot instance (required with --director-spot when iaas="aws")
+  [--spot-bid-price]                   Maximum hourly price of the "spot" vm_extension added to the cloud config (optional, kept on every later up)
+  [--spot-ondemand-fallback]           Creates on-demand instances when the "spot" vm_extension cannot get spot capacity (optional, requires --spot-bid-price)
+  [--keypair-name]                     Name of the existing EC2 key pair matching --private-key (required with --skip-keypair when iaas="aws")
+  [--existing-vpc-id]                  ID of an existing VPC to deploy into instead of creating one, no load balancers can be attached (optional, requires --existing-subnet-ids)
+  [--existing-subnet-ids]              Comma separated IDs of subnets in the existing VPC, the first holds the director and all are added to the cloud config (requires --existing-vpc-id)
+  [--azs]                              Comma separated availability zones of the region to limit the environment to (optional, defaults to all, kept on every later up)
+  [--nat]                              NAT of the internal subnets: "instance" for a NAT instance or "gateway" for an AWS managed NAT gateway in every zone (optional, defaults to instance, kept on every later up)
+
+  [--gcp-service-account-key]          GCP Service Access Key to use, the application default credentials are used without one (Defaults to environment variable BBL_GCP_SERVICE_ACCOUNT_KEY)
+  --gcp-project-id                     GCP Project ID to use (Defaults to environment variable BBL_GCP_PROJECT_ID)
+  --gcp-zone                           GCP Zone to use for BOSH director (Defaults to environment variable BBL_GCP_ZONE)
+  --gcp-region                         GCP Region to use (Defaults to environment variable BBL_GCP_REGION)
+  [--gcp-impersonate-service-account]  Service account the service account key impersonates for all GCP and terraform calls (Defaults to environment variable BBL_GCP_IMPERSONATE_SERVICE_ACCOUNT)
+  [--zones]                            Comma separated zones of the region to limit the environment to (optional, defaults to all, kept on every later up)
+  [--network-cidr]                     CIDR block for the network (optional, defaults to 10.0.0.0/16)
+  [--gcp-firewall-rule]                Additional firewall rule as name:proto:ports:source-range, open to every vm in the network, may be repeated. Replaces the rules of an earlier up, which are kept without it (supported when iaas="gcp")
+  [--clear-gcp-firewall-rules]         Removes the firewall rules added with --gcp-firewall-rule (optional, supported when iaas="gcp")
+  [--ssh-port]                         Port the jumpbox accepts ssh connections on (optional, defaults to 22, requires --credhub; kept for later runs)
+  [--no-public-ips]                    Keep the jumpbox and director off the internet, reached over a vpn or interconnect (optional, gcp only, only when creating the environment; kept for later runs)
+  [--existing-network-name]            Name of an existing network to deploy into instead of creating one (optional, requires --existing-subnetwork-name)
+  [--existing-subnetwork-name]         Name of a subnetwork of the existing network in --gcp-region, the director is deployed into its first /24 (requires --existing-network-name)`
 
 	DestroyCommandUsage = `Tears down BOSH director infrastructure
 
   [--no-confirm]       Do not ask for confirmation (optional)
-  [--skip-if-missing]  Gracefully exit if there is no state file (optional)`
+  [--skip-if-missing]  Gracefully exit if there is no state file (optional)
+  [--json]             Prints what would be deleted as json and exits without deleting anything (optional)
+  [--stop-on-error]    Stops at the first failure instead of removing what it can and reporting what remains (optional)
+  [--retry-terraform]  Number of times to re-run terraform destroy when it fails on a resource that is still in use (optional, defaults to 0)`
 
 	CreateLBsCommandUsage = `Attaches load balancer(s) with a certificate, key, and optional chain
 
@@ -32,8 +82,12 @@ const (
   [--chain]           Path to SSL certificate chain (optional; applicable if --cert/--key are required; refer to table below)
   [--domain]          Creates a nameserver with a zone for given domain (supported when type="cf")
   [--skip-if-exists]  Skips creating load balancer(s) if it is already attached (optional)
+  [--enable-ipv6]     Also provisions an IPv6 address for the router load balancer (supported when iaas="gcp" and type="cf")
+  [--lb-flavor]       Load balancer flavor. Valid options: "elb" (default) or "nlb" (supported when iaas="aws" and type="cf")
+  [--lets-encrypt]    Requests a certificate from Let's Encrypt, validated with DNS records in the zone for --domain, instead of using --cert/--key (supported when type="cf")
+  [--lets-encrypt-email] Contact email for the Let's Encrypt account, used for expiry notices (optional)
 
-  --cert/--key requirements:
+  --cert/--key requirements (not applicable with --lets-encrypt):
   ------------------------------
   |     | cf       | concourse |
   ------------------------------
@@ -50,13 +104,23 @@ const (
   [--domain]           Updates domain in the nameserver zone (supported when type="cf", optional)
   [--skip-if-missing]  Skips updating load balancer(s) if it is not attached (optional)`
 
+	RotateLBCertsCommandUsage = `Replaces the load balancer certificate without recreating the load balancer(s), keeping the previous certificate attached until --cleanup
+
+  --cert       Path to the new SSL certificate
+  --key        Path to the new SSL certificate key
+  [--chain]    Path to the new SSL certificate chain (optional)
+  [--cleanup]  Removes the previous certificate once the new one has propagated (optional)`
+
 	DeleteLBsCommandUsage = `Deletes load balancer(s)
 
   [--skip-if-missing]  Skips deleting load balancer(s) if it is not attached (optional)`
 
 	LBsCommandUsage = "Prints attached load balancer(s)"
 
-	VersionCommandUsage = "Prints version"
+	VersionCommandUsage = `Prints version
+
+  [--verbose]  Also prints the git sha, build date, Go version and supported terraform and bosh versions (optional)
+  [--json]     Prints the version and build metadata as json (optional)`
 
 	UsageCommandUsage = "Prints helpful message for the given command"
 
@@ -66,23 +130,141 @@ const (
 
 	RotateCommandUsage = "Rotates the keypair for BOSH"
 
+	RecreateJumpboxCommandUsage = "Recreates the jumpbox VM without changing the director or infrastructure"
+
+	RegenerateCredhubPasswordCommandUsage = "Regenerates the UAA admin and credhub passwords and redeploys the BOSH director with them"
+
+	ResizeDirectorCommandUsage = `Redeploys the BOSH director with a different vm type or persistent disk size
+
+  [--vm-type]    VM type of the director, recreates the director vm (optional)
+  [--disk-size]  Persistent disk size of the director in GB, migrates the persistent disk (optional)`
+
+	OutputsCommandUsage = `Prints every terraform output of the environment, or the stack outputs of environments created with cloudformation
+
+  [--json]  Prints the outputs as json (optional)`
+
+	ShowStateCommandUsage = `Prints the bbl state as yaml with the credentials, private keys and terraform state redacted, to debug an environment without sharing its secrets
+
+  [--json]          Prints the state as json (optional)
+  [--show-secrets]  Prints the secrets instead of redacting them (optional)`
+
+	DriftCommandUsage = `Plans the terraform template against the stored terraform state and reports whether the infrastructure has diverged from what bbl last applied
+
+  [--json]  Prints whether the infrastructure drifted and the plan as json (optional)`
+
+	MigrateStateCommandUsage = "Upgrades a bbl-state.json written by an earlier bbl to the current schema version and migrates a cloudformation stack to terraform. Run it with --dry-run to print the changes first"
+
+	RefreshCommandUsage = "Refreshes the terraform state against the infrastructure and caches its outputs in the bbl state, after the infrastructure was changed outside of bbl"
+
+	PlanCommandUsage = `Writes the terraform template, BOSH director manifest and cloud config that up would deploy to the state dir and prints how they differ from what is deployed, without applying anything
+
+  [--name]  Name to assign to your BOSH director (optional, used when there is no environment yet)`
+
+	TerraformOutputCommandUsage = `Prints a terraform output of the environment
+
+  <name>    Name of the terraform output
+  [--json]  Prints the output as json, required for list and map outputs (optional)`
+
+	ExportStateCommandUsage = `Writes bbl-state.json, with the terraform state, manifests and variables it holds, and the files in the state dir to a single bundle encrypted with the base64 encoded 32 byte key in BBL_STATE_BUNDLE_KEY
+
+  --to  Path to write the bundle to`
+
+	ImportStateCommandUsage = `Unpacks a bundle written by export-state into the state dir, decrypting it with the key in BBL_STATE_BUNDLE_KEY
+
+  --from     Path of the bundle to import
+  [--force]  Replaces the files of a state dir that is not empty, after moving them to its backups (optional)`
+
+	HistoryCommandUsage = `Prints the bbl commands run against the environment, who ran them and whether they succeeded, from the audit.log in the state dir
+
+  [--last]  Number of the most recent commands to print (optional, prints every command by default)
+  [--json]  Prints the records as json (optional)`
+
+	RestoreStateCommandUsage = `Restores bbl-state.json, and bbl-secrets.enc when it was backed up with it, from a backup in the backups directory of the state dir
+
+  --from  Name of the backup to restore`
+
+	SSHCommandUsage = `Opens a shell on the jumpbox or, through the jumpbox, on the director with the keys in the state
+
+  [--jumpbox]   Opens a shell on the jumpbox
+  [--director]  Opens a shell on the director`
+
+	DirectorBackupCommandUsage = `Backs up the BOSH director with bbr, through the jumpbox when there is one
+
+  [--artifact-path]  Directory bbr writes the backup artifact to (optional, defaults to the current directory)`
+
+	DirectorRestoreCommandUsage = `Restores the BOSH director with bbr from a backup artifact
+
+  --artifact-path  Path to the backup artifact written by director-backup`
+
+	CleanupLeftoversCommandUsage = `Deletes unused resources that failed ups and destroys left behind, after confirmation
+
+  [--env-id]      Only deletes the leftovers of this environment (optional, defaults to every environment but the one in the state)
+  [--no-confirm]  Do not ask for confirmation (optional)`
+
+	VerifyCommandUsage = `Checks the credentials, region, permissions and quotas bbl up needs, without changing anything
+
+  [--no-director]  Checks the quotas for an environment without a director (optional)
+  [--credhub]      Checks the quotas for an environment with a jumpbox (optional)`
+
+	EstimateCostCommandUsage = `Prints the approximate monthly cost of the environment, or of the one bbl up would create, at the on-demand list prices of the IAAS
+
+  [--no-director]  Leaves the director out of the estimate (optional)
+  [--credhub]      Includes the jumpbox in the estimate (optional)
+  [--lb-type]      Includes the load balancers of this type in the estimate: "cf" or "concourse" (optional)`
+
 	JumpboxAddressCommandUsage = "Prints BOSH jumpbox address"
 
-	DirectorUsernameCommandUsage = "Prints BOSH director username"
+	DirectorUsernameCommandUsage = `Prints BOSH director username
+
+  [--check]  Verifies the director is reachable before printing (optional)`
+
+	DirectorPasswordCommandUsage = `Prints BOSH director password
+
+  [--check]  Verifies the director is reachable before printing (optional)`
+
+	DirectorAddressCommandUsage = `Prints BOSH director address
 
-	DirectorPasswordCommandUsage = "Prints BOSH director password"
+  [--check]  Verifies the director is reachable before printing (optional)`
 
-	DirectorAddressCommandUsage = "Prints BOSH director address"
+	DirectorCACertCommandUsage = `Prints BOSH director CA certificate
 
-	DirectorCACertCommandUsage = "Prints BOSH director CA certificate"
+  [--check]  Verifies the director is reachable before printing (optional)`
 
-	PrintEnvCommandUsage = "Prints required BOSH environment variables"
+	PrintEnvCommandUsage = `Prints required BOSH environment variables
 
-	LatestErrorCommandUsage = "Prints the output from the latest call to terraform"
+  [--shell]          Shell to print the variables for: "posix", "fish", "powershell" or "cmd" (optional, defaults to "posix")
+  [--json]           Prints the variables as a json document, proxying through the jumpbox without a tunnel (optional)
+  [--metadata-file]  Writes the variables, without secrets, to a Concourse metadata file instead of printing them (optional)
+  [--ssh-agent]      Loads the jumpbox key into the ssh agent, or a new one, instead of writing it to a file (optional)`
+
+	OpenCommandUsage = `Forwards a director, UAA or credhub port to a local port until interrupted
+
+  <service>       One of "director", "uaa" or "credhub"
+  [--local-port]  Local port to listen on (optional, defaults to a free port)`
+
+	LatestErrorCommandUsage = `Prints the output from the latest call to terraform
+
+  [--full]  Also prints the latest error with the tail of terraform/bosh output captured when it occurred (optional)`
 
 	BOSHDeploymentVarsCommandUsage = "Prints required variables for BOSH deployment"
 
-	CloudConfigUsage = "Prints suggested cloud configuration for BOSH environment"
+	CloudConfigUsage = `Prints suggested cloud configuration for BOSH environment
+
+  [--regenerate-azs]  Recomputes the GCP availability zones for the current region and updates the cloud config (optional)
+  [--from-url]        Applies the cloud config published at an http(s) URL instead of printing the generated one (optional)
+  [--sha256]          Expected sha256 of the cloud config fetched with --from-url (optional)
+  [--update]          Regenerates the cloud config from the terraform outputs and updates the director if it changed (optional)
+  [--force]           Updates the director with --update even if its cloud config has not changed (optional)
+  [--diff]            Prints how the generated cloud config differs from the director's, which bbl up would upload (optional)
+  [--no-color]        Prints the --diff output without colors (optional)`
+
+	StatusCommandUsage = `Prints a summary of the bbl environment
+
+  [--json]  Prints the summary as json (optional)`
+
+	DeploymentsCommandUsage = `Prints the deployments on the BOSH director
+
+  [--json]  Prints the deployments as json (optional)`
 )
 
 func (Up) Usage() string { return UpCommandUsage }
@@ -103,6 +285,18 @@ func (Usage) Usage() string { return UsageCommandUsage }
 
 func (PrintEnv) Usage() string { return PrintEnvCommandUsage }
 
+func (Open) Usage() string { return OpenCommandUsage }
+
+func (DirectorBackup) Usage() string { return DirectorBackupCommandUsage }
+
+func (DirectorRestore) Usage() string { return DirectorRestoreCommandUsage }
+
+func (CleanupLeftovers) Usage() string { return CleanupLeftoversCommandUsage }
+
+func (Verify) Usage() string { return VerifyCommandUsage }
+
+func (EstimateCost) Usage() string { return EstimateCostCommandUsage }
+
 func (LatestError) Usage() string { return LatestErrorCommandUsage }
 
 func (CloudConfig) Usage() string { return CloudConfigUsage }
@@ -111,8 +305,44 @@ func (BOSHDeploymentVars) Usage() string { return BOSHDeploymentVarsCommandUsage
 
 func (Rotate) Usage() string { return RotateCommandUsage }
 
+func (RotateLBCerts) Usage() string { return RotateLBCertsCommandUsage }
+
+func (RecreateJumpbox) Usage() string { return RecreateJumpboxCommandUsage }
+
+func (RegenerateCredhubPassword) Usage() string { return RegenerateCredhubPasswordCommandUsage }
+
+func (ResizeDirector) Usage() string { return ResizeDirectorCommandUsage }
+
+func (TerraformOutput) Usage() string { return TerraformOutputCommandUsage }
+
+func (RestoreState) Usage() string { return RestoreStateCommandUsage }
+
+func (History) Usage() string { return HistoryCommandUsage }
+
+func (ExportState) Usage() string { return ExportStateCommandUsage }
+
+func (ImportState) Usage() string { return ImportStateCommandUsage }
+
 func (SSHKey) Usage() string { return SSHKeyCommandUsage }
 
+func (SSH) Usage() string { return SSHCommandUsage }
+
+func (Deployments) Usage() string { return DeploymentsCommandUsage }
+
+func (Status) Usage() string { return StatusCommandUsage }
+
+func (Plan) Usage() string { return PlanCommandUsage }
+
+func (Outputs) Usage() string { return OutputsCommandUsage }
+
+func (ShowState) Usage() string { return ShowStateCommandUsage }
+
+func (Drift) Usage() string { return DriftCommandUsage }
+
+func (Refresh) Usage() string { return RefreshCommandUsage }
+
+func (MigrateState) Usage() string { return MigrateStateCommandUsage }
+
 func (s StateQuery) Usage() string {
 	switch s.propertyName {
 	case EnvIDPropertyName:
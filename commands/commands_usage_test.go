@@ -16,21 +16,68 @@ var _ = Describe("Commands Usage", func() {
 				usageText := upCmd.Usage()
 				Expect(usageText).To(Equal(`Deploys BOSH director on an IAAS
 
-  --iaas                     IAAS to deploy your BOSH director onto. Valid options: "gcp", "aws" (Defaults to environment variable BBL_IAAS)
-  [--name]                   Name to assign to your BOSH director (optional, will be randomly generated)
-  [--ops-file]               Path to BOSH ops file (optional)
-  [--jumpbox]                Deploy your BOSH director behind a jumpbox (supported when iaas="gcp")
-  [--no-director]            Skips creating BOSH environment
-
-  --aws-access-key-id        AWS Access Key ID to use (Defaults to environment variable BBL_AWS_ACCESS_KEY_ID)
-  --aws-secret-access-key    AWS Secret Access Key to use (Defaults to environment variable BBL_AWS_SECRET_ACCESS_KEY)
-  --aws-region               AWS Region to use (Defaults to environment variable BBL_AWS_REGION)
-  [--aws-bosh-az]            AWS Availability Zone to use for BOSH director (Defaults to environment variable BBL_AWS_BOSH_AZ)
-
-  --gcp-service-account-key  GCP Service Access Key to use (Defaults to environment variable BBL_GCP_SERVICE_ACCOUNT_KEY)
-  --gcp-project-id           GCP Project ID to use (Defaults to environment variable BBL_GCP_PROJECT_ID)
-  --gcp-zone                 GCP Zone to use for BOSH director (Defaults to environment variable BBL_GCP_ZONE)
-  --gcp-region               GCP Region to use (Defaults to environment variable BBL_GCP_REGION)`))
+  --iaas                               IAAS to deploy your BOSH director onto. Valid options: "gcp", "aws" (Defaults to environment variable BBL_IAAS)
+  [--name]                             Name to assign to your BOSH director (optional, will be randomly generated)
+  [--ops-file]                         Path to a BOSH ops file for the director, can be repeated; saved in the state and applied on every later up until replaced (optional)
+  [--jumpbox]                          Deploy your BOSH director behind a jumpbox (supported when iaas="gcp")
+  [--director-ssh]                     Adds the jumpbox user to a director behind a jumpbox for bbl ssh --director and director-backup (optional, kept on every later up)
+  [--no-director]                      Skips creating BOSH environment
+  [--detach]                           Returns once infrastructure is created and continues deploying the BOSH director in the background (experimental)
+  [--subnet-cidr]                      CIDR block for the BOSH director subnet within the VPC/network (optional, defaults to 10.0.0.0/24)
+  [--director-ca-cert]                 Path to a CA certificate used to issue the BOSH director certificates (optional, requires --director-ca-key)
+  [--director-ca-key]                  Path to the private key of the director CA certificate (optional, requires --director-ca-cert)
+  [--director-spot]                    Runs the BOSH director on preemptible (gcp) or spot (aws) capacity, which can terminate it at any time (optional, must be passed on every up)
+  [--director-disk-type]               Disk type for the BOSH director's disks: "pd-standard" or "pd-ssd" (gcp), "gp2", "gp3" or "standard" (aws) (optional, defaults to "pd-standard"/"gp2"; changing it recreates the disks)
+  [--director-vm-type]                 Instance type (aws) or machine type (gcp) for the BOSH director VM, e.g. "m4.2xlarge" or "n1-standard-8" (optional, defaults to "m4.xlarge"/"n1-standard-1"; changing it recreates the VM)
+  [--director-disk-size]               Size in GB of the BOSH director's persistent disk (optional, defaults to 32)
+  [--director-external-db]             Keeps the BOSH director's database in RDS (aws) or Cloud SQL (gcp) instead of on its persistent disk (optional, only when creating the director; kept on every later up)
+  [--ssh-key-type]                     Algorithm for newly generated keypairs: "rsa" or "ed25519" (optional, defaults to "rsa"; existing keys are kept until rotated)
+  [--ssh-key-bits]                     Key size for newly generated rsa keypairs (optional, defaults to 2048)
+  [--skip-keypair]                     Uses the key pair from --public-key and --private-key instead of creating one in the IAAS (optional)
+  [--public-key]                       Path to the public key in authorized_keys format of an externally managed key pair (requires --skip-keypair, derived from --private-key when omitted)
+  [--private-key]                      Path to the private key of an externally managed key pair (requires --skip-keypair)
+  [--target]                           Terraform resource address to apply, limiting the apply to it and its dependencies. Requires an existing infrastructure. May be repeated (optional)
+  [--skip-quota-check]                 Skips checking the IAAS quotas for the resources a new environment creates (optional)
+  [--upload-stemcell]                  Path or URL of a stemcell to upload to the director after it is deployed, skipped if the director already has it (optional)
+  [--tag]                              Tag (aws) or label (gcp) of the resources bbl creates as key=value, may be repeated (optional, replaces the tags of an earlier up)
+  [--from-phase]                       Runs every phase from this one on, skipping the phases before it: "keypair", "terraform", "bosh" or "cloud-config" (optional)
+  [--bosh-deployment-path]             Path to a bosh-deployment checkout to deploy the director from instead of the copy in bbl (optional, kept on every later up)
+  [--bosh-deployment-version]          Full commit sha of cloudfoundry/bosh-deployment to download and deploy the director from, "bundled" for the copy in bbl (optional, kept on every later up)
+  [--jumpbox-deployment-path]          Path to a jumpbox-deployment checkout to deploy the jumpbox from instead of the copy in bbl (optional, kept on every later up)
+  [--jumpbox-deployment-version]       Full commit sha of cppforlife/jumpbox-deployment to download and deploy the jumpbox from, "bundled" for the copy in bbl (optional, kept on every later up)
+  [--director-stemcell-url]            URL or path of a stemcell tarball for the director and jumpbox instead of the one bosh-deployment pins, "bundled" to go back to it (optional, kept on every later up)
+  [--director-stemcell-name]           Name of the bosh.io stemcell for --director-stemcell-version (optional, defaults to the stemcell bosh-deployment pins for the IAAS)
+  [--director-stemcell-version]        Version of the bosh.io stemcell for the director and jumpbox (optional, kept on every later up)
+  [--director-stemcell-sha1]           SHA1 of the stemcell (required with --director-stemcell-version or an http --director-stemcell-url)
+
+  --aws-access-key-id                  AWS Access Key ID to use (Defaults to environment variable BBL_AWS_ACCESS_KEY_ID)
+  --aws-secret-access-key              AWS Secret Access Key to use (Defaults to environment variable BBL_AWS_SECRET_ACCESS_KEY)
+  --aws-region                         AWS Region to use (Defaults to environment variable BBL_AWS_REGION)
+  [--aws-profile]                      Profile in the shared AWS credentials file to read the access key and secret from when they are not provided (Defaults to environment variable AWS_PROFILE)
+  [--aws-bosh-az]                      AWS Availability Zone to use for BOSH director (Defaults to environment variable BBL_AWS_BOSH_AZ)
+  [--vpc-cidr]                         CIDR block for the VPC (optional, defaults to 10.0.0.0/16)
+  [--spot-max-price]                   Maximum hourly price to bid for the director spot instance (required with --director-spot when iaas="aws")
+  [--spot-bid-price]                   Maximum hourly price of the "spot" vm_extension added to the cloud config (optional, kept on every later up)
+  [--spot-ondemand-fallback]           Creates on-demand instances when the "spot" vm_extension cannot get spot capacity (optional, requires --spot-bid-price)
+  [--keypair-name]                     Name of the existing EC2 key pair matching --private-key (required with --skip-keypair when iaas="aws")
+  [--existing-vpc-id]                  ID of an existing VPC to deploy into instead of creating one, no load balancers can be attached (optional, requires --existing-subnet-ids)
+  [--existing-subnet-ids]              Comma separated IDs of subnets in the existing VPC, the first holds the director and all are added to the cloud config (requires --existing-vpc-id)
+  [--azs]                              Comma separated availability zones of the region to limit the environment to (optional, defaults to all, kept on every later up)
+  [--nat]                              NAT of the internal subnets: "instance" for a NAT instance or "gateway" for an AWS managed NAT gateway in every zone (optional, defaults to instance, kept on every later up)
+
+  [--gcp-service-account-key]          GCP Service Access Key to use, the application default credentials are used without one (Defaults to environment variable BBL_GCP_SERVICE_ACCOUNT_KEY)
+  --gcp-project-id                     GCP Project ID to use (Defaults to environment variable BBL_GCP_PROJECT_ID)
+  --gcp-zone                           GCP Zone to use for BOSH director (Defaults to environment variable BBL_GCP_ZONE)
+  --gcp-region                         GCP Region to use (Defaults to environment variable BBL_GCP_REGION)
+  [--gcp-impersonate-service-account]  Service account the service account key impersonates for all GCP and terraform calls (Defaults to environment variable BBL_GCP_IMPERSONATE_SERVICE_ACCOUNT)
+  [--zones]                            Comma separated zones of the region to limit the environment to (optional, defaults to all, kept on every later up)
+  [--network-cidr]                     CIDR block for the network (optional, defaults to 10.0.0.0/16)
+  [--gcp-firewall-rule]                Additional firewall rule as name:proto:ports:source-range, open to every vm in the network, may be repeated. Replaces the rules of an earlier up, which are kept without it (supported when iaas="gcp")
+  [--clear-gcp-firewall-rules]         Removes the firewall rules added with --gcp-firewall-rule (optional, supported when iaas="gcp")
+  [--ssh-port]                         Port the jumpbox accepts ssh connections on (optional, defaults to 22, requires --credhub; kept for later runs)
+  [--no-public-ips]                    Keep the jumpbox and director off the internet, reached over a vpn or interconnect (optional, gcp only, only when creating the environment; kept for later runs)
+  [--existing-network-name]            Name of an existing network to deploy into instead of creating one (optional, requires --existing-subnetwork-name)
+  [--existing-subnetwork-name]         Name of a subnetwork of the existing network in --gcp-region, the director is deployed into its first /24 (requires --existing-network-name)`))
 			})
 		})
 	})
@@ -48,8 +95,12 @@ var _ = Describe("Commands Usage", func() {
   [--chain]           Path to SSL certificate chain (optional; applicable if --cert/--key are required; refer to table below)
   [--domain]          Creates a nameserver with a zone for given domain (supported when type="cf")
   [--skip-if-exists]  Skips creating load balancer(s) if it is already attached (optional)
+  [--enable-ipv6]     Also provisions an IPv6 address for the router load balancer (supported when iaas="gcp" and type="cf")
+  [--lb-flavor]       Load balancer flavor. Valid options: "elb" (default) or "nlb" (supported when iaas="aws" and type="cf")
+  [--lets-encrypt]    Requests a certificate from Let's Encrypt, validated with DNS records in the zone for --domain, instead of using --cert/--key (supported when type="cf")
+  [--lets-encrypt-email] Contact email for the Let's Encrypt account, used for expiry notices (optional)
 
-  --cert/--key requirements:
+  --cert/--key requirements (not applicable with --lets-encrypt):
   ------------------------------
   |     | cf       | concourse |
   ------------------------------
@@ -77,6 +128,21 @@ var _ = Describe("Commands Usage", func() {
 		})
 	})
 
+	Describe("Rotate LB Certs", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.RotateLBCerts{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Replaces the load balancer certificate without recreating the load balancer(s), keeping the previous certificate attached until --cleanup
+
+  --cert       Path to the new SSL certificate
+  --key        Path to the new SSL certificate key
+  [--chain]    Path to the new SSL certificate chain (optional)
+  [--cleanup]  Removes the previous certificate once the new one has propagated (optional)`))
+			})
+		})
+	})
+
 	Describe("Delete LBs", func() {
 		Describe("Usage", func() {
 			It("returns string describing usage", func() {
@@ -97,7 +163,76 @@ var _ = Describe("Commands Usage", func() {
 				Expect(usageText).To(Equal(`Tears down BOSH director infrastructure
 
   [--no-confirm]       Do not ask for confirmation (optional)
-  [--skip-if-missing]  Gracefully exit if there is no state file (optional)`))
+  [--skip-if-missing]  Gracefully exit if there is no state file (optional)
+  [--json]             Prints what would be deleted as json and exits without deleting anything (optional)
+  [--stop-on-error]    Stops at the first failure instead of removing what it can and reporting what remains (optional)
+  [--retry-terraform]  Number of times to re-run terraform destroy when it fails on a resource that is still in use (optional, defaults to 0)`))
+			})
+		})
+	})
+
+	Describe("Deployments", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Deployments{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints the deployments on the BOSH director
+
+  [--json]  Prints the deployments as json (optional)`))
+			})
+		})
+	})
+
+	Describe("Restore State", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.RestoreState{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Restores bbl-state.json, and bbl-secrets.enc when it was backed up with it, from a backup in the backups directory of the state dir
+
+  --from  Name of the backup to restore`))
+			})
+		})
+	})
+
+	Describe("Cloud Config", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.CloudConfig{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints suggested cloud configuration for BOSH environment
+
+  [--regenerate-azs]  Recomputes the GCP availability zones for the current region and updates the cloud config (optional)
+  [--from-url]        Applies the cloud config published at an http(s) URL instead of printing the generated one (optional)
+  [--sha256]          Expected sha256 of the cloud config fetched with --from-url (optional)
+  [--update]          Regenerates the cloud config from the terraform outputs and updates the director if it changed (optional)
+  [--force]           Updates the director with --update even if its cloud config has not changed (optional)
+  [--diff]            Prints how the generated cloud config differs from the director's, which bbl up would upload (optional)
+  [--no-color]        Prints the --diff output without colors (optional)`))
+			})
+		})
+	})
+
+	Describe("Status", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Status{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints a summary of the bbl environment
+
+  [--json]  Prints the summary as json (optional)`))
+			})
+		})
+	})
+
+	Describe("Latest Error", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.LatestError{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints the output from the latest call to terraform
+
+  [--full]  Also prints the latest error with the tail of terraform/bosh output captured when it occurred (optional)`))
 			})
 		})
 	})
@@ -118,20 +253,97 @@ var _ = Describe("Commands Usage", func() {
 	},
 		Entry("LBs", commands.LBs{}, "Prints attached load balancer(s)"),
 		Entry("jumpbox-address", newStateQuery("jumpbox address"), "Prints BOSH jumpbox address"),
-		Entry("director-address", newStateQuery("director address"), "Prints BOSH director address"),
-		Entry("director-password", newStateQuery("director password"), "Prints BOSH director password"),
-		Entry("director-username", newStateQuery("director username"), "Prints BOSH director username"),
-		Entry("director-ca-cert", newStateQuery("director ca cert"), "Prints BOSH director CA certificate"),
+		Entry("director-address", newStateQuery("director address"), `Prints BOSH director address
+
+  [--check]  Verifies the director is reachable before printing (optional)`),
+		Entry("director-password", newStateQuery("director password"), `Prints BOSH director password
+
+  [--check]  Verifies the director is reachable before printing (optional)`),
+		Entry("director-username", newStateQuery("director username"), `Prints BOSH director username
+
+  [--check]  Verifies the director is reachable before printing (optional)`),
+		Entry("director-ca-cert", newStateQuery("director ca cert"), `Prints BOSH director CA certificate
+
+  [--check]  Verifies the director is reachable before printing (optional)`),
 		Entry("env-id", newStateQuery("environment id"), "Prints environment ID"),
 		Entry("ssh-key", commands.SSHKey{}, "Prints SSH private key for the jumpbox user. This can be used to ssh to the director/use the director as a gateway host."),
-		Entry("print-env", commands.PrintEnv{}, "Prints required BOSH environment variables"),
-		Entry("latest-error", commands.LatestError{}, "Prints the output from the latest call to terraform"),
+		Entry("ssh", commands.SSH{}, `Opens a shell on the jumpbox or, through the jumpbox, on the director with the keys in the state
+
+  [--jumpbox]   Opens a shell on the jumpbox
+  [--director]  Opens a shell on the director`),
+		Entry("print-env", commands.PrintEnv{}, `Prints required BOSH environment variables
+
+  [--shell]          Shell to print the variables for: "posix", "fish", "powershell" or "cmd" (optional, defaults to "posix")
+  [--json]           Prints the variables as a json document, proxying through the jumpbox without a tunnel (optional)
+  [--metadata-file]  Writes the variables, without secrets, to a Concourse metadata file instead of printing them (optional)
+  [--ssh-agent]      Loads the jumpbox key into the ssh agent, or a new one, instead of writing it to a file (optional)`),
+		Entry("open", commands.Open{}, `Forwards a director, UAA or credhub port to a local port until interrupted
+
+  <service>       One of "director", "uaa" or "credhub"
+  [--local-port]  Local port to listen on (optional, defaults to a free port)`),
+		Entry("director-backup", commands.DirectorBackup{}, `Backs up the BOSH director with bbr, through the jumpbox when there is one
+
+  [--artifact-path]  Directory bbr writes the backup artifact to (optional, defaults to the current directory)`),
+		Entry("director-restore", commands.DirectorRestore{}, `Restores the BOSH director with bbr from a backup artifact
+
+  --artifact-path  Path to the backup artifact written by director-backup`),
+		Entry("cleanup-leftovers", commands.CleanupLeftovers{}, `Deletes unused resources that failed ups and destroys left behind, after confirmation
+
+  [--env-id]      Only deletes the leftovers of this environment (optional, defaults to every environment but the one in the state)
+  [--no-confirm]  Do not ask for confirmation (optional)`),
+		Entry("verify", commands.Verify{}, `Checks the credentials, region, permissions and quotas bbl up needs, without changing anything
+
+  [--no-director]  Checks the quotas for an environment without a director (optional)
+  [--credhub]      Checks the quotas for an environment with a jumpbox (optional)`),
+		Entry("estimate-cost", commands.EstimateCost{}, `Prints the approximate monthly cost of the environment, or of the one bbl up would create, at the on-demand list prices of the IAAS
+
+  [--no-director]  Leaves the director out of the estimate (optional)
+  [--credhub]      Includes the jumpbox in the estimate (optional)
+  [--lb-type]      Includes the load balancers of this type in the estimate: "cf" or "concourse" (optional)`),
 		Entry("bosh-deployment-vars", commands.BOSHDeploymentVars{}, "Prints required variables for BOSH deployment"),
-		Entry("version", commands.Version{}, "Prints version"),
-		Entry("cloud-config", commands.CloudConfig{}, "Prints suggested cloud configuration for BOSH environment"),
+		Entry("version", commands.Version{}, `Prints version
+
+  [--verbose]  Also prints the git sha, build date, Go version and supported terraform and bosh versions (optional)
+  [--json]     Prints the version and build metadata as json (optional)`),
+		Entry("recreate-jumpbox", commands.RecreateJumpbox{}, "Recreates the jumpbox VM without changing the director or infrastructure"),
+		Entry("terraform-output", commands.TerraformOutput{}, `Prints a terraform output of the environment
+
+  <name>    Name of the terraform output
+  [--json]  Prints the output as json, required for list and map outputs (optional)`),
+		Entry("regenerate-credhub-password", commands.RegenerateCredhubPassword{}, "Regenerates the UAA admin and credhub passwords and redeploys the BOSH director with them"),
+		Entry("resize-director", commands.ResizeDirector{}, `Redeploys the BOSH director with a different vm type or persistent disk size
+
+  [--vm-type]    VM type of the director, recreates the director vm (optional)
+  [--disk-size]  Persistent disk size of the director in GB, migrates the persistent disk (optional)`),
+		Entry("outputs", commands.Outputs{}, `Prints every terraform output of the environment, or the stack outputs of environments created with cloudformation
+
+  [--json]  Prints the outputs as json (optional)`),
+		Entry("state", commands.ShowState{}, `Prints the bbl state as yaml with the credentials, private keys and terraform state redacted, to debug an environment without sharing its secrets
+
+  [--json]          Prints the state as json (optional)
+  [--show-secrets]  Prints the secrets instead of redacting them (optional)`),
+		Entry("drift", commands.Drift{}, `Plans the terraform template against the stored terraform state and reports whether the infrastructure has diverged from what bbl last applied
+
+  [--json]  Prints whether the infrastructure drifted and the plan as json (optional)`),
+		Entry("migrate-state", commands.MigrateState{}, "Upgrades a bbl-state.json written by an earlier bbl to the current schema version and migrates a cloudformation stack to terraform. Run it with --dry-run to print the changes first"),
+		Entry("refresh", commands.Refresh{}, "Refreshes the terraform state against the infrastructure and caches its outputs in the bbl state, after the infrastructure was changed outside of bbl"),
+		Entry("plan", commands.Plan{}, `Writes the terraform template, BOSH director manifest and cloud config that up would deploy to the state dir and prints how they differ from what is deployed, without applying anything
+
+  [--name]  Name to assign to your BOSH director (optional, used when there is no environment yet)`),
+		Entry("history", commands.History{}, `Prints the bbl commands run against the environment, who ran them and whether they succeeded, from the audit.log in the state dir
+
+  [--last]  Number of the most recent commands to print (optional, prints every command by default)
+  [--json]  Prints the records as json (optional)`),
+		Entry("export-state", commands.ExportState{}, `Writes bbl-state.json, with the terraform state, manifests and variables it holds, and the files in the state dir to a single bundle encrypted with the base64 encoded 32 byte key in BBL_STATE_BUNDLE_KEY
+
+  --to  Path to write the bundle to`),
+		Entry("import-state", commands.ImportState{}, `Unpacks a bundle written by export-state into the state dir, decrypting it with the key in BBL_STATE_BUNDLE_KEY
+
+  --from     Path of the bundle to import
+  [--force]  Replaces the files of a state dir that is not empty, after moving them to its backups (optional)`),
 	)
 })
 
 func newStateQuery(propertyName string) commands.StateQuery {
-	return commands.NewStateQuery(nil, nil, nil, nil, propertyName)
+	return commands.NewStateQuery(nil, nil, nil, nil, nil, nil, nil, propertyName)
 }
@@ -1,6 +1,11 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
 	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
@@ -8,18 +13,25 @@ import (
 type CreateLBs struct {
 	awsCreateLBs         awsCreateLBs
 	gcpCreateLBs         gcpCreateLBs
+	openstackCreateLBs   openstackCreateLBs
 	stateValidator       stateValidator
 	certificateValidator certificateValidator
 	boshManager          boshManager
+	terraform            terraformPlanner
+	logger               logger
 }
 
 type lbConfig struct {
-	lbType       string
-	certPath     string
-	keyPath      string
-	chainPath    string
-	domain       string
-	skipIfExists bool
+	lbType           string
+	certPath         string
+	keyPath          string
+	chainPath        string
+	domain           string
+	skipIfExists     bool
+	enableIPv6       bool
+	lbFlavor         string
+	letsEncrypt      bool
+	letsEncryptEmail string
 }
 
 type gcpCreateLBs interface {
@@ -30,17 +42,21 @@ type awsCreateLBs interface {
 	Execute(AWSCreateLBsConfig, storage.State) error
 }
 
-type certificateValidator interface {
-	Validate(command, certPath, keyPath, chainPath string) error
+type openstackCreateLBs interface {
+	Execute(OpenStackCreateLBsConfig, storage.State) error
 }
 
-func NewCreateLBs(awsCreateLBs awsCreateLBs, gcpCreateLBs gcpCreateLBs, stateValidator stateValidator, certificateValidator certificateValidator, boshManager boshManager) CreateLBs {
+func NewCreateLBs(awsCreateLBs awsCreateLBs, gcpCreateLBs gcpCreateLBs, openstackCreateLBs openstackCreateLBs, stateValidator stateValidator, certificateValidator certificateValidator,
+	boshManager boshManager, terraform terraformPlanner, logger logger) CreateLBs {
 	return CreateLBs{
 		awsCreateLBs:         awsCreateLBs,
 		gcpCreateLBs:         gcpCreateLBs,
+		openstackCreateLBs:   openstackCreateLBs,
 		stateValidator:       stateValidator,
 		certificateValidator: certificateValidator,
 		boshManager:          boshManager,
+		terraform:            terraform,
+		logger:               logger,
 	}
 }
 
@@ -54,8 +70,46 @@ func (c CreateLBs) CheckFastFails(subcommandFlags []string, state storage.State)
 		return err
 	}
 
-	if !(state.IAAS == "gcp" && config.lbType == "concourse") {
-		err = c.certificateValidator.Validate("create-lbs", config.certPath, config.keyPath, config.chainPath)
+	if config.enableIPv6 {
+		switch {
+		case state.IAAS == "aws":
+			return errors.New(`--enable-ipv6 is not supported when iaas="aws", bbl creates classic load balancers which do not support IPv6 in a VPC`)
+		case state.IAAS == "openstack":
+			return errors.New(`--enable-ipv6 is not supported when iaas="openstack"`)
+		case config.lbType != "cf":
+			return errors.New(`--enable-ipv6 is only supported when type="cf", the concourse load balancer is regional and does not support IPv6`)
+		}
+	}
+
+	if config.lbFlavor != "" {
+		switch {
+		case config.lbFlavor != "elb" && config.lbFlavor != "nlb":
+			return fmt.Errorf("%q is not a valid lb flavor, valid lb flavors are: elb and nlb", config.lbFlavor)
+		case state.IAAS != "aws":
+			return errors.New(`--lb-flavor is only supported when iaas="aws"`)
+		case config.lbFlavor == "nlb" && config.lbType != "cf":
+			return errors.New(`--lb-flavor nlb is only supported when type="cf", the concourse load balancer is always a classic load balancer`)
+		}
+	}
+
+	if config.letsEncrypt {
+		switch {
+		case state.IAAS != "aws" && state.IAAS != "gcp":
+			return errors.New(`--lets-encrypt is only supported when iaas="aws" or iaas="gcp"`)
+		case config.lbType != "cf":
+			return errors.New(`--lets-encrypt is only supported when type="cf", the certificate is validated against the DNS zone bbl creates for --domain`)
+		case config.domain == "":
+			return errors.New("--domain is required when using --lets-encrypt")
+		case config.certPath != "" || config.keyPath != "" || config.chainPath != "":
+			return errors.New("--cert, --key and --chain cannot be used with --lets-encrypt")
+		}
+	} else if config.letsEncryptEmail != "" {
+		return errors.New("--lets-encrypt-email can only be used with --lets-encrypt")
+	}
+
+	// openstack listeners pass tcp through, so there is no certificate to check.
+	if !(state.IAAS == "gcp" && config.lbType == "concourse") && state.IAAS != "openstack" && !config.letsEncrypt {
+		err = validateCertificate(c.certificateValidator, c.logger, "create-lbs", config.certPath, config.keyPath, config.chainPath)
 		if err != nil {
 			return err
 		}
@@ -80,21 +134,34 @@ func (c CreateLBs) Execute(args []string, state storage.State) error {
 	switch state.IAAS {
 	case "gcp":
 		if err := c.gcpCreateLBs.Execute(GCPCreateLBsConfig{
-			LBType:       config.lbType,
-			CertPath:     config.certPath,
-			KeyPath:      config.keyPath,
-			Domain:       config.domain,
-			SkipIfExists: config.skipIfExists,
+			LBType:           config.lbType,
+			CertPath:         config.certPath,
+			KeyPath:          config.keyPath,
+			Domain:           config.domain,
+			SkipIfExists:     config.skipIfExists,
+			EnableIPv6:       config.enableIPv6,
+			LetsEncrypt:      config.letsEncrypt,
+			LetsEncryptEmail: config.letsEncryptEmail,
 		}, state); err != nil {
 			return err
 		}
 	case "aws":
 		if err := c.awsCreateLBs.Execute(AWSCreateLBsConfig{
+			LBType:           config.lbType,
+			CertPath:         config.certPath,
+			KeyPath:          config.keyPath,
+			ChainPath:        config.chainPath,
+			Domain:           config.domain,
+			SkipIfExists:     config.skipIfExists,
+			LBFlavor:         config.lbFlavor,
+			LetsEncrypt:      config.letsEncrypt,
+			LetsEncryptEmail: config.letsEncryptEmail,
+		}, state); err != nil {
+			return err
+		}
+	case "openstack":
+		if err := c.openstackCreateLBs.Execute(OpenStackCreateLBsConfig{
 			LBType:       config.lbType,
-			CertPath:     config.certPath,
-			KeyPath:      config.keyPath,
-			ChainPath:    config.chainPath,
-			Domain:       config.domain,
 			SkipIfExists: config.skipIfExists,
 		}, state); err != nil {
 			return err
@@ -104,6 +171,76 @@ func (c CreateLBs) Execute(args []string, state storage.State) error {
 	return nil
 }
 
+func (c CreateLBs) DryRun(args []string, state storage.State) error {
+	config, err := parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if config.lbType != "cf" && config.lbType != "concourse" {
+		return fmt.Errorf("%q is not a valid lb type, valid lb types are: concourse and cf", config.lbType)
+	}
+
+	currentLBType := state.LB.Type
+	if state.IAAS == "aws" && state.Stack.LBType != "" {
+		currentLBType = state.Stack.LBType
+	}
+
+	if config.skipIfExists && lbExists(currentLBType) {
+		printDryRun(c.logger, "create-lbs", nil, "")
+		return nil
+	}
+
+	if lbExists(currentLBType) && state.IAAS == "aws" {
+		return fmt.Errorf("bbl already has a %s load balancer attached, please remove the previous load balancer before attaching a new one", currentLBType)
+	}
+
+	state.LB.Type = config.lbType
+	state.LB.Domain = config.domain
+	state.LB.IPv6 = config.enableIPv6
+	if config.lbFlavor != "" {
+		state.LB.Flavor = config.lbFlavor
+	}
+
+	for _, file := range []struct {
+		path     string
+		contents *string
+	}{
+		{config.certPath, &state.LB.Cert},
+		{config.keyPath, &state.LB.Key},
+		{config.chainPath, &state.LB.Chain},
+	} {
+		if file.path == "" {
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(file.path)
+		if err != nil {
+			return err
+		}
+		*file.contents = string(contents)
+	}
+
+	plan, err := c.terraform.Plan(state)
+	if err != nil {
+		return err
+	}
+
+	changes := []string{
+		fmt.Sprintf("attach a %s load balancer with the terraform plan below", config.lbType),
+		"save the bbl state",
+	}
+	if config.letsEncrypt {
+		changes = append(changes, fmt.Sprintf("request a certificate for %s from let's encrypt and replace the placeholder certificate", strings.Join(lbCertificateDomains(config.domain), ", ")))
+	}
+	if !state.NoDirector {
+		changes = append(changes, "update the cloud config")
+	}
+
+	printDryRun(c.logger, "create-lbs", changes, plan)
+	return nil
+}
+
 func parseFlags(subcommandFlags []string) (lbConfig, error) {
 	lbFlags := flags.New("create-lbs")
 
@@ -114,6 +251,10 @@ func parseFlags(subcommandFlags []string) (lbConfig, error) {
 	lbFlags.String(&config.chainPath, "chain", "")
 	lbFlags.String(&config.domain, "domain", "")
 	lbFlags.Bool(&config.skipIfExists, "skip-if-exists", "", false)
+	lbFlags.Bool(&config.enableIPv6, "", "enable-ipv6", false)
+	lbFlags.String(&config.lbFlavor, "lb-flavor", "")
+	lbFlags.Bool(&config.letsEncrypt, "", "lets-encrypt", false)
+	lbFlags.String(&config.letsEncryptEmail, "lets-encrypt-email", "")
 
 	if err := lbFlags.Parse(subcommandFlags); err != nil {
 		return config, err
@@ -2,7 +2,10 @@ package commands_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"time"
 
+	"github.com/cloudfoundry/bosh-bootloader/certs"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -16,20 +19,27 @@ var _ = Describe("create-lbs", func() {
 		command              commands.CreateLBs
 		awsCreateLBs         *fakes.AWSCreateLBs
 		gcpCreateLBs         *fakes.GCPCreateLBs
+		openstackCreateLBs   *fakes.OpenStackCreateLBs
 		stateValidator       *fakes.StateValidator
 		certificateValidator *fakes.CertificateValidator
 		boshManager          *fakes.BOSHManager
+		terraformManager     *fakes.TerraformManager
+		logger               *fakes.Logger
 	)
 
 	BeforeEach(func() {
 		awsCreateLBs = &fakes.AWSCreateLBs{}
 		gcpCreateLBs = &fakes.GCPCreateLBs{}
+		openstackCreateLBs = &fakes.OpenStackCreateLBs{}
 		stateValidator = &fakes.StateValidator{}
 		certificateValidator = &fakes.CertificateValidator{}
 		boshManager = &fakes.BOSHManager{}
 		boshManager.VersionCall.Returns.Version = "2.0.24"
 
-		command = commands.NewCreateLBs(awsCreateLBs, gcpCreateLBs, stateValidator, certificateValidator, boshManager)
+		terraformManager = &fakes.TerraformManager{}
+		logger = &fakes.Logger{}
+
+		command = commands.NewCreateLBs(awsCreateLBs, gcpCreateLBs, openstackCreateLBs, stateValidator, certificateValidator, boshManager, terraformManager, logger)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -82,6 +92,165 @@ var _ = Describe("create-lbs", func() {
 				Expect(certificateValidator.ValidateCall.Receives.KeyPath).To(Equal("/path/to/key"))
 				Expect(certificateValidator.ValidateCall.Receives.ChainPath).To(Equal("/path/to/chain"))
 			})
+
+			It("explains which file failed and why", func() {
+				certificateValidator.ValidateCall.Returns.Error = certs.ValidationError{
+					Command: "create-lbs",
+					Failures: []certs.Failure{
+						{File: "certificate", Flag: "--cert", Path: "/path/to/cert", Problem: certs.ProblemExpired, Err: errors.New("certificate expired on 2018-05-26T22:13:41Z")},
+						{File: "key", Flag: "--key", Path: "/path/to/key", Problem: certs.ProblemKeyMismatch, Err: errors.New("certificate and key mismatch")},
+						{File: "chain", Flag: "--chain", Path: "/path/to/chain", Problem: certs.ProblemChainOutOfOrder, Err: errors.New("chain is out of order")},
+					},
+				}
+
+				err := command.CheckFastFails([]string{
+					"--cert", "/path/to/cert",
+					"--key", "/path/to/key",
+					"--chain", "/path/to/chain",
+				}, storage.State{})
+
+				Expect(err).To(MatchError(ContainSubstring(`--cert "/path/to/cert": certificate has expired: certificate expired on 2018-05-26T22:13:41Z`)))
+				Expect(err).To(MatchError(ContainSubstring(`--key "/path/to/key": key does not match the certificate in --cert`)))
+				Expect(err).To(MatchError(ContainSubstring(`--chain "/path/to/chain": chain must list each certificate before its issuer: chain is out of order`)))
+			})
+		})
+
+		It("prints the subject and expiry of a valid certificate", func() {
+			certificateValidator.ValidateCall.Returns.Certificate = certs.Certificate{
+				Subject:  "some-subject",
+				NotAfter: time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC),
+			}
+
+			err := command.CheckFastFails([]string{"--type", "cf"}, storage.State{IAAS: "gcp", NoDirector: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.StepCall.Messages).To(ContainElement(`validated certificate for "some-subject", valid until 2020-01-02`))
+		})
+
+		Context("when ipv6 is enabled", func() {
+			It("does not return an error for a gcp cf lb", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--enable-ipv6",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the iaas is aws", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--enable-ipv6",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--enable-ipv6 is not supported when iaas="aws", bbl creates classic load balancers which do not support IPv6 in a VPC`))
+			})
+
+			It("returns an error when the iaas is openstack", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--enable-ipv6",
+				}, storage.State{IAAS: "openstack"})
+				Expect(err).To(MatchError(`--enable-ipv6 is not supported when iaas="openstack"`))
+			})
+
+			It("returns an error when the lb type is concourse", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "concourse",
+					"--enable-ipv6",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`--enable-ipv6 is only supported when type="cf", the concourse load balancer is regional and does not support IPv6`))
+			})
+		})
+
+		Context("when an lb flavor is provided", func() {
+			It("does not return an error for an aws cf nlb", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--lb-flavor", "nlb",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the flavor is invalid", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--lb-flavor", "alb",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`"alb" is not a valid lb flavor, valid lb flavors are: elb and nlb`))
+			})
+
+			It("returns an error when the iaas is not aws", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--lb-flavor", "nlb",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`--lb-flavor is only supported when iaas="aws"`))
+			})
+
+			It("returns an error when the lb type is concourse", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "concourse",
+					"--lb-flavor", "nlb",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--lb-flavor nlb is only supported when type="cf", the concourse load balancer is always a classic load balancer`))
+			})
+		})
+
+		Context("when a certificate is requested from let's encrypt", func() {
+			It("does not validate a certificate", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--domain", "some-domain",
+					"--lets-encrypt",
+					"--lets-encrypt-email", "me@example.com",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(certificateValidator.ValidateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the iaas is not aws or gcp", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--domain", "some-domain",
+					"--lets-encrypt",
+				}, storage.State{IAAS: "openstack"})
+				Expect(err).To(MatchError(`--lets-encrypt is only supported when iaas="aws" or iaas="gcp"`))
+			})
+
+			It("returns an error when the lb type is concourse", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "concourse",
+					"--domain", "some-domain",
+					"--lets-encrypt",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`--lets-encrypt is only supported when type="cf", the certificate is validated against the DNS zone bbl creates for --domain`))
+			})
+
+			It("returns an error when no domain is provided", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--lets-encrypt",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--domain is required when using --lets-encrypt"))
+			})
+
+			It("returns an error when a certificate is also provided", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--domain", "some-domain",
+					"--cert", "/path/to/cert",
+					"--lets-encrypt",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("--cert, --key and --chain cannot be used with --lets-encrypt"))
+			})
+
+			It("returns an error when an email is provided without --lets-encrypt", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+					"--lets-encrypt-email", "me@example.com",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("--lets-encrypt-email can only be used with --lets-encrypt"))
+			})
 		})
 
 		Context("when iaas is gcp and lb type is concourse", func() {
@@ -97,6 +266,17 @@ var _ = Describe("create-lbs", func() {
 				Expect(certificateValidator.ValidateCall.CallCount).To(Equal(0))
 			})
 		})
+
+		Context("when iaas is openstack", func() {
+			It("does not call certificateValidator", func() {
+				err := command.CheckFastFails([]string{
+					"--type", "cf",
+				}, storage.State{IAAS: "openstack"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(certificateValidator.ValidateCall.CallCount).To(Equal(0))
+			})
+		})
 	})
 
 	Describe("Execute", func() {
@@ -121,6 +301,7 @@ var _ = Describe("create-lbs", func() {
 				"--key", "my-key",
 				"--domain", "some-domain",
 				"--skip-if-exists",
+				"--enable-ipv6",
 			}, storage.State{
 				IAAS: "gcp",
 			})
@@ -131,6 +312,7 @@ var _ = Describe("create-lbs", func() {
 				KeyPath:      "my-key",
 				Domain:       "some-domain",
 				SkipIfExists: true,
+				EnableIPv6:   true,
 			}))
 		})
 
@@ -157,6 +339,59 @@ var _ = Describe("create-lbs", func() {
 			}))
 		})
 
+		It("passes let's encrypt to AWS", func() {
+			err := command.Execute([]string{
+				"--type", "cf",
+				"--domain", "some-domain",
+				"--lets-encrypt",
+				"--lets-encrypt-email", "me@example.com",
+			}, storage.State{
+				IAAS: "aws",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsCreateLBs.ExecuteCall.Receives.Config).Should(Equal(commands.AWSCreateLBsConfig{
+				LBType:           "cf",
+				Domain:           "some-domain",
+				LetsEncrypt:      true,
+				LetsEncryptEmail: "me@example.com",
+			}))
+		})
+
+		It("passes the lb flavor to AWS", func() {
+			err := command.Execute([]string{
+				"--type", "cf",
+				"--cert", "my-cert",
+				"--key", "my-key",
+				"--lb-flavor", "nlb",
+			}, storage.State{
+				IAAS: "aws",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsCreateLBs.ExecuteCall.Receives.Config).Should(Equal(commands.AWSCreateLBsConfig{
+				LBType:   "cf",
+				CertPath: "my-cert",
+				KeyPath:  "my-key",
+				LBFlavor: "nlb",
+			}))
+		})
+
+		It("creates an OpenStack lb type if the iaas is OpenStack", func() {
+			err := command.Execute([]string{
+				"--type", "cf",
+				"--skip-if-exists",
+			}, storage.State{
+				IAAS: "openstack",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(openstackCreateLBs.ExecuteCall.Receives.Config).Should(Equal(commands.OpenStackCreateLBsConfig{
+				LBType:       "cf",
+				SkipIfExists: true,
+			}))
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when an invalid command line flag is supplied", func() {
 				err := command.Execute([]string{"--invalid-flag"}, storage.State{})
@@ -180,6 +415,94 @@ var _ = Describe("create-lbs", func() {
 				})
 				Expect(err).To(MatchError("something bad happened"))
 			})
+
+			It("returns an error when the OpenStackCreateLBs fails", func() {
+				openstackCreateLBs.ExecuteCall.Returns.Error = errors.New("something bad happened")
+
+				err := command.Execute([]string{"some-openstack-args"}, storage.State{
+					IAAS: "openstack",
+				})
+				Expect(err).To(MatchError("something bad happened"))
+			})
+		})
+	})
+
+	Describe("DryRun", func() {
+		var (
+			certPath string
+			keyPath  string
+		)
+
+		BeforeEach(func() {
+			certFile, err := ioutil.TempFile("", "cert")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = certFile.WriteString("some-cert")
+			Expect(err).NotTo(HaveOccurred())
+			certPath = certFile.Name()
+
+			keyFile, err := ioutil.TempFile("", "key")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = keyFile.WriteString("some-key")
+			Expect(err).NotTo(HaveOccurred())
+			keyPath = keyFile.Name()
+
+			terraformManager.PlanCall.Returns.Plan = "some-plan"
+		})
+
+		It("plans the load balancers without creating them", func() {
+			err := command.DryRun([]string{
+				"--type", "cf",
+				"--cert", certPath,
+				"--key", keyPath,
+				"--domain", "some-domain",
+			}, storage.State{IAAS: "gcp", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(gcpCreateLBs.ExecuteCall.CallCount).To(Equal(0))
+			Expect(terraformManager.PlanCall.Receives.BBLState.LB).To(Equal(storage.LB{
+				Type:   "cf",
+				Cert:   "some-cert",
+				Key:    "some-key",
+				Domain: "some-domain",
+			}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl create-lbs --dry-run would:
+  - attach a cf load balancer with the terraform plan below
+  - save the bbl state
+  - update the cloud config
+
+some-plan`))
+		})
+
+		It("changes nothing when the load balancer exists and --skip-if-exists is set", func() {
+			err := command.DryRun([]string{"--type", "concourse", "--skip-if-exists"}, storage.State{
+				IAAS: "aws",
+				Stack: storage.Stack{
+					LBType: "concourse",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.PlanCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring("change nothing"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the lb type is not valid", func() {
+				err := command.DryRun([]string{"--type", "other"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`"other" is not a valid lb type, valid lb types are: concourse and cf`))
+			})
+
+			It("returns an error when the cert cannot be read", func() {
+				err := command.DryRun([]string{"--type", "cf", "--cert", "/some/missing/cert"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+
+			It("returns an error when the terraform plan fails", func() {
+				terraformManager.PlanCall.Returns.Error = errors.New("failed to plan")
+
+				err := command.DryRun([]string{"--type", "concourse"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("failed to plan"))
+			})
 		})
 	})
 })
@@ -13,6 +13,7 @@ type DeleteLBs struct {
 	logger         logger
 	stateValidator stateValidator
 	boshManager    boshManager
+	terraform      terraformPlanner
 }
 
 type gcpDeleteLBs interface {
@@ -24,13 +25,14 @@ type awsDeleteLBs interface {
 }
 
 func NewDeleteLBs(gcpDeleteLBs gcpDeleteLBs, awsDeleteLBs awsDeleteLBs,
-	logger logger, stateValidator stateValidator, boshManager boshManager) DeleteLBs {
+	logger logger, stateValidator stateValidator, boshManager boshManager, terraform terraformPlanner) DeleteLBs {
 	return DeleteLBs{
 		gcpDeleteLBs:   gcpDeleteLBs,
 		awsDeleteLBs:   awsDeleteLBs,
 		logger:         logger,
 		stateValidator: stateValidator,
 		boshManager:    boshManager,
+		terraform:      terraform,
 	}
 }
 
@@ -73,6 +75,51 @@ func (d DeleteLBs) Execute(subcommandFlags []string, state storage.State) error
 	return nil
 }
 
+func (d DeleteLBs) DryRun(subcommandFlags []string, state storage.State) error {
+	config, err := d.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.IAAS != "gcp" && state.IAAS != "aws" {
+		return fmt.Errorf("%q is an invalid iaas type in state, supported iaas types are: [gcp, aws]", state.IAAS)
+	}
+
+	lbType := state.LB.Type
+	if state.Stack.LBType != "" {
+		lbType = state.Stack.LBType
+	}
+
+	if config.skipIfMissing && !lbExists(lbType) {
+		printDryRun(d.logger, "delete-lbs", nil, "")
+		return nil
+	}
+
+	if state.IAAS == "aws" && !lbExists(lbType) {
+		return LBNotFound
+	}
+
+	if state.IAAS == "aws" {
+		state.LB = storage.LB{}
+	} else {
+		state.LB.Type = ""
+	}
+
+	plan, err := d.terraform.Plan(state)
+	if err != nil {
+		return err
+	}
+
+	changes := []string{}
+	if !state.NoDirector {
+		changes = append(changes, "update the cloud config")
+	}
+	changes = append(changes, "delete the load balancers with the terraform plan below", "save the bbl state")
+
+	printDryRun(d.logger, "delete-lbs", changes, plan)
+	return nil
+}
+
 func (DeleteLBs) parseFlags(subcommandFlags []string) (deleteLBsConfig, error) {
 	lbFlags := flags.New("delete-lbs")
 
@@ -21,6 +21,7 @@ var _ = Describe("DeleteLBs", func() {
 		stateValidator *fakes.StateValidator
 		logger         *fakes.Logger
 		boshManager    *fakes.BOSHManager
+		terraform      *fakes.TerraformManager
 	)
 
 	BeforeEach(func() {
@@ -31,7 +32,9 @@ var _ = Describe("DeleteLBs", func() {
 		boshManager = &fakes.BOSHManager{}
 		boshManager.VersionCall.Returns.Version = "2.0.24"
 
-		command = commands.NewDeleteLBs(gcpDeleteLBs, awsDeleteLBs, logger, stateValidator, boshManager)
+		terraform = &fakes.TerraformManager{}
+
+		command = commands.NewDeleteLBs(gcpDeleteLBs, awsDeleteLBs, logger, stateValidator, boshManager, terraform)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -181,4 +184,52 @@ var _ = Describe("DeleteLBs", func() {
 			})
 		})
 	})
+
+	Describe("DryRun", func() {
+		BeforeEach(func() {
+			terraform.PlanCall.Returns.Plan = "some-plan"
+		})
+
+		It("plans the removal of the load balancers without deleting them", func() {
+			err := command.DryRun([]string{}, storage.State{
+				IAAS: "aws",
+				LB: storage.LB{
+					Type: "cf",
+					Cert: "some-cert",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsDeleteLBs.ExecuteCall.CallCount).To(Equal(0))
+			Expect(terraform.PlanCall.Receives.BBLState.LB).To(Equal(storage.LB{}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl delete-lbs --dry-run would:
+  - update the cloud config
+  - delete the load balancers with the terraform plan below
+  - save the bbl state
+
+some-plan`))
+		})
+
+		It("changes nothing when there are no load balancers and --skip-if-missing is set", func() {
+			err := command.DryRun([]string{"--skip-if-missing"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraform.PlanCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring("change nothing"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when there are no load balancers on aws", func() {
+				err := command.DryRun([]string{}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(commands.LBNotFound))
+			})
+
+			It("returns an error when the terraform plan fails", func() {
+				terraform.PlanCall.Returns.Error = errors.New("failed to plan")
+
+				err := command.DryRun([]string{}, storage.State{IAAS: "gcp", LB: storage.LB{Type: "concourse"}})
+				Expect(err).To(MatchError("failed to plan"))
+			})
+		})
+	})
 })
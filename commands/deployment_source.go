@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// bundled unpins a deployment or the director stemcell, going back to the
+// one bbl ships with.
+const bundled = "bundled"
+
+func validateDeploymentSources(config upConfig, noDirector bool) error {
+	if noDirector && (config.boshDeploymentPath != "" || config.boshDeploymentVersion != "") {
+		return errors.New("--bosh-deployment-path and --bosh-deployment-version cannot be used with --no-director")
+	}
+
+	err := validateDeploymentSource("bosh-deployment", config.boshDeploymentPath, config.boshDeploymentVersion, "bosh.yml")
+	if err != nil {
+		return err
+	}
+
+	return validateDeploymentSource("jumpbox-deployment", config.jumpboxDeploymentPath, config.jumpboxDeploymentVersion, "jumpbox.yml")
+}
+
+func validateDeploymentSource(name, path, version, manifest string) error {
+	if path != "" && version != "" {
+		return fmt.Errorf("--%s-path cannot be used with --%s-version", name, name)
+	}
+
+	if path != "" {
+		_, err := os.Stat(filepath.Join(path, manifest))
+		if err != nil {
+			return fmt.Errorf("--%s-path must be a checkout of %s with a %s", name, name, manifest)
+		}
+	}
+
+	if version != "" && version != bundled && !bosh.IsCommitSHA(version) {
+		return fmt.Errorf("--%s-version must be the full sha of a commit of %s, since tags and branches can move", name, name)
+	}
+
+	return nil
+}
+
+func updateDeploymentSources(state storage.State, config upConfig) storage.State {
+	state.BOSH.DeploymentPath, state.BOSH.DeploymentVersion = pinDeployment(
+		state.BOSH.DeploymentPath, state.BOSH.DeploymentVersion, config.boshDeploymentPath, config.boshDeploymentVersion)
+
+	state.Jumpbox.DeploymentPath, state.Jumpbox.DeploymentVersion = pinDeployment(
+		state.Jumpbox.DeploymentPath, state.Jumpbox.DeploymentVersion, config.jumpboxDeploymentPath, config.jumpboxDeploymentVersion)
+
+	return state
+}
+
+// pinDeployment returns the path and version a deployment is pinned to. The
+// path is kept absolute so that later runs from another directory find it.
+func pinDeployment(currentPath, currentVersion, path, version string) (string, string) {
+	switch {
+	case path != "":
+		if absolutePath, err := filepath.Abs(path); err == nil {
+			path = absolutePath
+		}
+		return path, ""
+	case version == bundled:
+		return "", ""
+	case version != "":
+		return "", version
+	}
+
+	return currentPath, currentVersion
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type Deployments struct {
+	logger             logger
+	stateValidator     stateValidator
+	boshClientProvider boshClientProvider
+	socks5Proxy        socks5Proxy
+	sshKeyGetter       sshKeyGetter
+}
+
+type deploymentsConfig struct {
+	json bool
+}
+
+func NewDeployments(logger logger, stateValidator stateValidator, boshClientProvider boshClientProvider,
+	socks5Proxy socks5Proxy, sshKeyGetter sshKeyGetter) Deployments {
+	return Deployments{
+		logger:             logger,
+		stateValidator:     stateValidator,
+		boshClientProvider: boshClientProvider,
+		socks5Proxy:        socks5Proxy,
+		sshKeyGetter:       sshKeyGetter,
+	}
+}
+
+func (d Deployments) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := d.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.NoDirector {
+		return errors.New("Error BBL does not manage this director.")
+	}
+
+	return nil
+}
+
+func (d Deployments) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := d.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	boshClient, err := directorClient(state, d.boshClientProvider, d.socks5Proxy, d.sshKeyGetter)
+	if err != nil {
+		return err
+	}
+
+	deployments, err := boshClient.Deployments()
+	if err != nil {
+		return err
+	}
+
+	if config.json {
+		output, err := json.Marshal(deployments)
+		if err != nil {
+			// not tested
+			return err
+		}
+
+		d.logger.Println(string(output))
+		return nil
+	}
+
+	d.logger.Println(deploymentsTable(deployments))
+	return nil
+}
+
+func (d Deployments) parseArgs(args []string) (deploymentsConfig, error) {
+	var config deploymentsConfig
+
+	deploymentsFlags := flags.New("deployments")
+	deploymentsFlags.Bool(&config.json, "", "json", false)
+
+	err := deploymentsFlags.Parse(args)
+	if err != nil {
+		return deploymentsConfig{}, err
+	}
+
+	return config, nil
+}
+
+func deploymentsTable(deployments []bosh.Deployment) string {
+	buf := bytes.NewBuffer([]byte{})
+	writer := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "Name\tRelease(s)\tStemcell(s)")
+	for _, deployment := range deployments {
+		var releases []string
+		for _, release := range deployment.Releases {
+			releases = append(releases, fmt.Sprintf("%s/%s", release.Name, release.Version))
+		}
+
+		var stemcells []string
+		for _, stemcell := range deployment.Stemcells {
+			stemcells = append(stemcells, fmt.Sprintf("%s/%s", stemcell.Name, stemcell.Version))
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", deployment.Name, strings.Join(releases, " "), strings.Join(stemcells, " "))
+	}
+	writer.Flush()
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,179 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"golang.org/x/net/proxy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deployments", func() {
+	var (
+		command commands.Deployments
+
+		incomingState storage.State
+
+		logger             *fakes.Logger
+		stateValidator     *fakes.StateValidator
+		boshClientProvider *fakes.BOSHClientProvider
+		boshClient         *fakes.BOSHClient
+		socks5Proxy        *fakes.Socks5Proxy
+		sshKeyGetter       *fakes.SSHKeyGetter
+	)
+
+	BeforeEach(func() {
+		incomingState = storage.State{
+			BOSH: storage.BOSH{
+				DirectorAddress:  "some-director-address",
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+				DirectorSSLCA:    "some-director-ca",
+			},
+		}
+
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		boshClient = &fakes.BOSHClient{}
+		boshClient.DeploymentsCall.Returns.Deployments = []bosh.Deployment{
+			{
+				Name:      "some-deployment",
+				Releases:  []bosh.DeploymentRelease{{Name: "some-release", Version: "1.2.3"}},
+				Stemcells: []bosh.DeploymentStemcell{{Name: "some-stemcell", Version: "3421.11"}},
+			},
+			{
+				Name:      "other-deployment",
+				Releases:  []bosh.DeploymentRelease{{Name: "some-release", Version: "1.2.3"}, {Name: "other-release", Version: "4"}},
+				Stemcells: []bosh.DeploymentStemcell{{Name: "some-stemcell", Version: "3421.11"}},
+			},
+		}
+		boshClientProvider = &fakes.BOSHClientProvider{}
+		boshClientProvider.ClientCall.Returns.Client = boshClient
+		socks5Proxy = &fakes.Socks5Proxy{}
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+
+		command = commands.NewDeployments(logger, stateValidator, boshClientProvider, socks5Proxy, sshKeyGetter)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			incomingState.NoDirector = true
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("Error BBL does not manage this director."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints a table of the deployments on the director", func() {
+			err := command.Execute([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClientProvider.ClientCall.Receives.Jumpbox).To(BeFalse())
+			Expect(boshClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorUsername).To(Equal("some-director-username"))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorPassword).To(Equal("some-director-password"))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorCACert).To(Equal("some-director-ca"))
+			Expect(socks5Proxy.StartCall.CallCount).To(Equal(0))
+			Expect(boshClient.ConfigureHTTPClientCall.CallCount).To(Equal(0))
+
+			Expect(boshClient.DeploymentsCall.CallCount).To(Equal(1))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				"Name              Release(s)                          Stemcell(s)\n" +
+					"some-deployment   some-release/1.2.3                  some-stemcell/3421.11\n" +
+					"other-deployment  some-release/1.2.3 other-release/4  some-stemcell/3421.11",
+			}))
+		})
+
+		Context("when --json is provided", func() {
+			It("prints the deployments as json", func() {
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+				Expect(logger.PrintlnCall.Messages[0]).To(MatchJSON(`[
+					{
+						"name": "some-deployment",
+						"releases": [{"name": "some-release", "version": "1.2.3"}],
+						"stemcells": [{"name": "some-stemcell", "version": "3421.11"}]
+					},
+					{
+						"name": "other-deployment",
+						"releases": [{"name": "some-release", "version": "1.2.3"}, {"name": "other-release", "version": "4"}],
+						"stemcells": [{"name": "some-stemcell", "version": "3421.11"}]
+					}
+				]`))
+			})
+		})
+
+		Context("when the director is behind a jumpbox", func() {
+			var socks5Client *fakes.Socks5Client
+
+			BeforeEach(func() {
+				incomingState.Jumpbox = storage.Jumpbox{
+					Enabled: true,
+					URL:     "some-jumpbox-url",
+				}
+				sshKeyGetter.GetCall.Returns.PrivateKey = "some-private-key"
+				socks5Proxy.AddrCall.Returns.Addr = "some-socks-proxy-addr"
+
+				socks5Client = &fakes.Socks5Client{}
+				commands.SetProxySOCKS5(func(network, addr string, auth *proxy.Auth, forward proxy.Dialer) (proxy.Dialer, error) {
+					Expect(addr).To(Equal("some-socks-proxy-addr"))
+					return socks5Client, nil
+				})
+			})
+
+			AfterEach(func() {
+				commands.ResetProxySOCKS5()
+			})
+
+			It("routes the bosh client through the socks5 proxy", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshClientProvider.ClientCall.Receives.Jumpbox).To(BeTrue())
+				Expect(sshKeyGetter.GetCall.Receives.State).To(Equal(incomingState))
+				Expect(socks5Proxy.StartCall.Receives.JumpboxPrivateKey).To(Equal("some-private-key"))
+				Expect(socks5Proxy.StartCall.Receives.JumpboxExternalURL).To(Equal("some-jumpbox-url"))
+				Expect(boshClient.ConfigureHTTPClientCall.Receives.Socks5Client).To(Equal(socks5Client))
+				Expect(boshClient.DeploymentsCall.CallCount).To(Equal(1))
+			})
+
+			It("returns an error when the ssh key getter fails", func() {
+				sshKeyGetter.GetCall.Returns.Error = errors.New("failed to get ssh key")
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to get ssh key"))
+			})
+
+			It("returns an error when the socks5 proxy fails to start", func() {
+				socks5Proxy.StartCall.Returns.Error = errors.New("failed to start proxy")
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to start proxy"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--invalid-flag"}, incomingState)
+				Expect(err).To(MatchError("flag provided but not defined: -invalid-flag"))
+			})
+
+			It("returns an error when the director deployments cannot be retrieved", func() {
+				boshClient.DeploymentsCall.Returns.Error = errors.New("failed to get deployments")
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to get deployments"))
+			})
+		})
+	})
+})
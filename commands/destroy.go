@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -30,8 +31,67 @@ type Destroy struct {
 }
 
 type destroyConfig struct {
-	NoConfirm     bool
-	SkipIfMissing bool
+	NoConfirm      bool
+	SkipIfMissing  bool
+	JSON           bool
+	StopOnError    bool
+	RetryTerraform int
+}
+
+// destroyReport records which resources a destroy removed and which it
+// left behind, so a partial teardown can be reported and resumed.
+type destroyReport struct {
+	removed   []string
+	remaining []string
+	errors    []error
+}
+
+func (r *destroyReport) remove(resource string) {
+	r.removed = append(r.removed, resource)
+}
+
+func (r *destroyReport) fail(resource string, err error) {
+	r.remaining = append(r.remaining, fmt.Sprintf("%s: %s", resource, err))
+	r.errors = append(r.errors, err)
+}
+
+func (r *destroyReport) skip(resource, reason string) {
+	r.remaining = append(r.remaining, fmt.Sprintf("%s: skipped, %s", resource, reason))
+}
+
+func (r destroyReport) failed() bool {
+	return len(r.errors) > 0
+}
+
+// err keeps a single failure as is, so its type still decides the exit code.
+func (r destroyReport) err() error {
+	if len(r.errors) == 1 {
+		return r.errors[0]
+	}
+
+	errorList := helpers.Errors{}
+	for _, err := range r.errors {
+		errorList.Add(err)
+	}
+	return errorList
+}
+
+func (r destroyReport) String() string {
+	lines := []string{"destroy did not remove everything, run bbl destroy again to remove what remains."}
+
+	if len(r.removed) > 0 {
+		lines = append(lines, "removed:")
+		for _, resource := range r.removed {
+			lines = append(lines, fmt.Sprintf("  %s", resource))
+		}
+	}
+
+	lines = append(lines, "remaining:")
+	for _, resource := range r.remaining {
+		lines = append(lines, fmt.Sprintf("  %s", resource))
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 type awsKeyPairDeleter interface {
@@ -105,6 +165,10 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 		return err
 	}
 
+	if config.RetryTerraform < 0 {
+		return fmt.Errorf("--retry-terraform must be 0 or more, got %d", config.RetryTerraform)
+	}
+
 	if config.SkipIfMissing && state.EnvID == "" {
 		d.logger.Step("state file not found, and --skip-if-missing flag provided, exiting")
 		return nil
@@ -121,7 +185,7 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 	}
 
 	var terraformOutputs map[string]interface{}
-	if state.IAAS == "gcp" {
+	if state.IAAS == "gcp" && state.GCP.ExistingNetworkName == "" {
 		terraformOutputs, err = d.terraformManager.GetOutputs(state)
 		if err == nil {
 			networkName, ok := terraformOutputs["network_name"].(string)
@@ -134,7 +198,7 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 		}
 	}
 
-	if state.IAAS == "aws" {
+	if state.IAAS == "aws" && state.AWS.ExistingVPCID == "" {
 		if state.TFState != "" {
 			outputs, err := d.terraformManager.GetOutputs(state)
 			if err == nil {
@@ -170,6 +234,31 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 	return nil
 }
 
+func (d Destroy) DryRun(subcommandFlags []string, state storage.State) error {
+	config, err := d.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.SkipIfMissing && state.EnvID == "" {
+		d.logger.Step("state file not found, and --skip-if-missing flag provided, exiting")
+		return nil
+	}
+
+	destroyPlan, err := d.plan(state)
+	if err != nil {
+		return err
+	}
+
+	plan, err := d.terraformManager.PlanDestroy(state)
+	if err != nil {
+		return err
+	}
+
+	printDryRun(d.logger, "destroy", append(destroyPlan.changes(), "delete the bbl state"), plan)
+	return nil
+}
+
 func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 	config, err := d.parseFlags(subcommandFlags)
 	if err != nil {
@@ -181,7 +270,28 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 		return nil
 	}
 
+	if config.JSON {
+		plan, err := d.plan(state)
+		if err != nil {
+			return err
+		}
+
+		planJSON, err := json.Marshal(plan)
+		if err != nil {
+			return err // not tested
+		}
+
+		d.logger.Println(string(planJSON))
+		return nil
+	}
+
 	if !config.NoConfirm {
+		plan, err := d.plan(state)
+		if err != nil {
+			return err
+		}
+
+		d.logger.Println(plan.String())
 		d.logger.Prompt(fmt.Sprintf("Are you sure you want to delete infrastructure for %q? This operation cannot be undone!", state.EnvID))
 
 		var proceed string
@@ -229,59 +339,66 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 		return err
 	}
 
-	if state.IAAS == "aws" {
-		if state.TFState != "" {
-			state, err = d.terraformManager.Destroy(state)
-			if err != nil {
-				return handleTerraformError(err, d.stateStore)
-			}
-		} else {
-			state, err = d.deleteStack(stack, state)
-			if err != nil {
-				return err
-			}
-		}
+	report := destroyReport{}
+	if !state.NoDirector {
+		report.remove("bosh director")
 	}
 
-	if state.IAAS == "gcp" {
-		state, err = d.terraformManager.Destroy(state)
-		if err != nil {
-			return handleTerraformError(err, d.stateStore)
+	state, err = d.deleteInfrastructure(state, stack, config.RetryTerraform)
+	if err != nil {
+		if config.StopOnError {
+			return err
 		}
-	}
+		report.fail("infrastructure", err)
+	} else {
+		report.remove("infrastructure")
 
-	if err := d.stateStore.Set(state); err != nil {
-		return err
+		if err := d.stateStore.Set(state); err != nil {
+			return err
+		}
 	}
 
-	if state.IAAS == "aws" {
-		if state.Stack.CertificateName != "" {
+	if state.IAAS == "aws" && state.Stack.CertificateName != "" {
+		if report.failed() {
+			report.skip("certificate", "the infrastructure using it was not removed")
+		} else {
 			d.logger.Step("deleting certificate")
 			err = d.certificateDeleter.Delete(state.Stack.CertificateName)
 			if err != nil {
-				return err
-			}
-
-			state.Stack.CertificateName = ""
+				if config.StopOnError {
+					return err
+				}
+				report.fail("certificate", err)
+			} else {
+				report.remove("certificate")
+				state.Stack.CertificateName = ""
 
-			if err := d.stateStore.Set(state); err != nil {
-				return err
+				if err := d.stateStore.Set(state); err != nil {
+					return err
+				}
 			}
 		}
 	}
 
-	switch state.IAAS {
-	case "aws":
-		err = d.awsKeyPairDeleter.Delete(state.KeyPair.Name)
+	if state.KeyPair.External {
+		d.logger.Step("skipping deletion of the key pair managed outside of bbl")
+	} else {
+		err = d.deleteKeyPair(state)
 		if err != nil {
-			return err
+			if config.StopOnError {
+				return err
+			}
+			report.fail("key pair", err)
+		} else {
+			// The key pair stays in the state until everything else is gone,
+			// terraform still needs its name and deleting it again is harmless.
+			report.remove("key pair")
 		}
+	}
 
-	case "gcp":
-		err = d.gcpKeyPairDeleter.Delete(state.KeyPair.PublicKey)
-		if err != nil {
-			return err
-		}
+	if report.failed() {
+		d.logger.Println(report.String())
+		return report.err()
 	}
 
 	err = d.stateStore.Set(storage.State{})
@@ -298,6 +415,9 @@ func (d Destroy) parseFlags(subcommandFlags []string) (destroyConfig, error) {
 	config := destroyConfig{}
 	destroyFlags.Bool(&config.NoConfirm, "n", "no-confirm", false)
 	destroyFlags.Bool(&config.SkipIfMissing, "", "skip-if-missing", false)
+	destroyFlags.Bool(&config.JSON, "", "json", false)
+	destroyFlags.Bool(&config.StopOnError, "", "stop-on-error", false)
+	destroyFlags.Int(&config.RetryTerraform, "retry-terraform", 0)
 
 	err := destroyFlags.Parse(subcommandFlags)
 	if err != nil {
@@ -332,6 +452,28 @@ func (d Destroy) deleteBOSH(state storage.State, stack cloudformation.Stack, ter
 	return state, nil
 }
 
+func (d Destroy) deleteInfrastructure(state storage.State, stack cloudformation.Stack, terraformRetries int) (storage.State, error) {
+	switch {
+	case state.IAAS == "aws" && state.TFState == "":
+		return d.deleteStack(stack, state)
+	case state.IAAS == "aws", state.IAAS == "gcp", state.IAAS == "azure", state.IAAS == "openstack":
+		return d.destroyTerraform(state, terraformRetries)
+	}
+
+	return state, nil
+}
+
+func (d Destroy) deleteKeyPair(state storage.State) error {
+	switch state.IAAS {
+	case "aws":
+		return d.awsKeyPairDeleter.Delete(state.KeyPair.Name)
+	case "gcp":
+		return d.gcpKeyPairDeleter.Delete(state.KeyPair.PublicKey)
+	}
+
+	return nil
+}
+
 func (d Destroy) deleteStack(stack cloudformation.Stack, state storage.State) (storage.State, error) {
 	if state.Stack.Name == "" {
 		d.logger.Println("No infrastructure found, skipping...")
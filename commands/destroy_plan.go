@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type destroyPlan struct {
+	EnvID       string   `json:"env_id"`
+	IAAS        string   `json:"iaas"`
+	Director    string   `json:"director,omitempty"`
+	Jumpbox     string   `json:"jumpbox,omitempty"`
+	LBType      string   `json:"lb_type,omitempty"`
+	Stack       string   `json:"stack,omitempty"`
+	Certificate string   `json:"certificate,omitempty"`
+	KeyPair     string   `json:"key_pair,omitempty"`
+	Resources   []string `json:"resources"`
+}
+
+func (d Destroy) plan(state storage.State) (destroyPlan, error) {
+	plan := destroyPlan{
+		EnvID:     state.EnvID,
+		IAAS:      state.IAAS,
+		LBType:    state.LB.Type,
+		Stack:     state.Stack.Name,
+		KeyPair:   state.KeyPair.Name,
+		Resources: []string{},
+	}
+
+	if state.LB.Type == "" {
+		plan.LBType = state.Stack.LBType
+	}
+
+	if state.IAAS == "aws" {
+		plan.Certificate = state.Stack.CertificateName
+	}
+
+	if !state.NoDirector && !state.BOSH.IsEmpty() {
+		plan.Director = state.BOSH.DirectorName
+		if plan.Director == "" {
+			plan.Director = state.BOSH.DirectorAddress
+		}
+	}
+
+	if state.Jumpbox.Enabled {
+		plan.Jumpbox = state.Jumpbox.URL
+	}
+
+	if state.TFState != "" {
+		resources, err := d.terraformManager.Resources(state)
+		if err != nil {
+			return destroyPlan{}, err
+		}
+		plan.Resources = resources
+	}
+
+	return plan, nil
+}
+
+func (p destroyPlan) String() string {
+	lines := []string{fmt.Sprintf("The following will be deleted for %q:", p.EnvID)}
+
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("  %s: %s", label, value))
+		}
+	}
+
+	add("BOSH director", p.Director)
+	add("Jumpbox", p.Jumpbox)
+	add("Load balancers", p.LBType)
+	add("CloudFormation stack", p.Stack)
+	add("Certificate", p.Certificate)
+	add("Key pair", p.KeyPair)
+
+	if len(p.Resources) > 0 {
+		lines = append(lines, "  Terraform resources:")
+		for _, resource := range p.Resources {
+			lines = append(lines, fmt.Sprintf("    %s", resource))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// changes lists what a destroy would delete, outside of the terraform
+// resources, for --dry-run.
+func (p destroyPlan) changes() []string {
+	changes := []string{}
+
+	add := func(format, value string) {
+		if value != "" {
+			changes = append(changes, fmt.Sprintf(format, value))
+		}
+	}
+
+	add("delete the BOSH director %s", p.Director)
+	add("delete the jumpbox %s", p.Jumpbox)
+	add("delete the %s load balancers", p.LBType)
+	add("delete the CloudFormation stack %s", p.Stack)
+	add("delete the certificate %s", p.Certificate)
+	add("delete the key pair %s", p.KeyPair)
+
+	if len(p.Resources) > 0 {
+		changes = append(changes, "destroy the terraform resources in the plan below")
+	}
+
+	return changes
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// terraformDependencyErrors match terraform destroy failures caused by a
+// resource that is still in use by another one, such as a leftover network
+// interface holding on to a subnet. They usually clear once the first pass
+// has removed the blocking resource.
+var terraformDependencyErrors = []string{
+	"DependencyViolation",
+	"InvalidGroup.InUse",
+	"resourceInUseByAnotherResource",
+	"is already being used by",
+	"has dependent object",
+	"is still in use",
+}
+
+var (
+	sleep                   = time.Sleep
+	terraformDestroyBackoff = 10 * time.Second
+)
+
+// destroyTerraform runs terraform destroy, running it again up to retries
+// times when it fails on a resource that is still in use. The partially
+// destroyed terraform state is saved before every retry.
+func (d Destroy) destroyTerraform(state storage.State, retries int) (storage.State, error) {
+	for attempt := 1; ; attempt++ {
+		updatedState, err := d.terraformManager.Destroy(state)
+		if err == nil {
+			return updatedState, nil
+		}
+
+		managerErr, ok := err.(terraformManagerError)
+		if !ok || attempt > retries {
+			return state, handleTerraformError(err, d.stateStore)
+		}
+
+		partialState, stateErr := managerErr.BBLState()
+		if stateErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(stateErr)
+			return state, errorList
+		}
+
+		if !isTerraformDependencyError(partialState.LatestTFOutput) {
+			return state, handleTerraformError(err, d.stateStore)
+		}
+
+		err = d.stateStore.Set(partialState)
+		if err != nil {
+			return state, err
+		}
+		state = partialState
+
+		backoff := time.Duration(attempt) * terraformDestroyBackoff
+		d.logger.Warn("terraform destroy failed on a resource that is still in use, retrying in %s (retry %d of %d)", backoff, attempt, retries)
+		sleep(backoff)
+	}
+}
+
+// isTerraformDependencyError looks for the cause of the failure in the output
+// of terraform, since the error itself only has its exit status.
+func isTerraformDependencyError(terraformOutput string) bool {
+	for _, message := range terraformDependencyErrors {
+		if strings.Contains(terraformOutput, message) {
+			return true
+		}
+	}
+
+	return false
+}
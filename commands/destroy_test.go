@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -98,6 +101,11 @@ var _ = Describe("Destroy", func() {
 			Expect(terraformManager.ValidateVersionCall.CallCount).To(Equal(0))
 		})
 
+		It("returns an error when --retry-terraform is negative", func() {
+			err := destroy.CheckFastFails([]string{"--retry-terraform", "-1"}, storage.State{IAAS: "aws"})
+			Expect(err).To(MatchError("--retry-terraform must be 0 or more, got -1"))
+		})
+
 		It("returns when there is no state and --skip-if-missing flag is provided", func() {
 			err := destroy.CheckFastFails([]string{"--skip-if-missing"}, storage.State{})
 
@@ -197,6 +205,20 @@ var _ = Describe("Destroy", func() {
 				Expect(err).To(MatchError("validation failed"))
 			})
 
+			It("does not check a network that bbl did not create", func() {
+				err := destroy.CheckFastFails([]string{}, storage.State{
+					IAAS:    "gcp",
+					EnvID:   "some-env-id",
+					TFState: "some-tf-state",
+					GCP: storage.GCP{
+						ExistingNetworkName: "some-network-name",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(networkInstancesChecker.ValidateSafeToDeleteCall.CallCount).To(Equal(0))
+			})
+
 			Context("when terraform output provider fails to get terraform outputs", func() {
 				It("does not fast fail", func() {
 					terraformManager.GetOutputsCall.Returns.Error = errors.New("terraform output provider failed")
@@ -317,6 +339,18 @@ var _ = Describe("Destroy", func() {
 					Expect(vpcStatusChecker.ValidateSafeToDeleteCall.Receives.VPCID).To(Equal("some-vpc-id"))
 					Expect(vpcStatusChecker.ValidateSafeToDeleteCall.Receives.EnvID).To(Equal("some-env-id"))
 				})
+
+				It("does not check a vpc that bbl did not create", func() {
+					state.AWS.ExistingVPCID = "some-vpc-id"
+					terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+						"vpc_id": "some-vpc-id",
+					}
+
+					err := destroy.CheckFastFails([]string{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(vpcStatusChecker.ValidateSafeToDeleteCall.CallCount).To(Equal(0))
+				})
 			})
 		})
 	})
@@ -360,6 +394,96 @@ var _ = Describe("Destroy", func() {
 			Entry("responding with 'N'", "N", false),
 		)
 
+		Context("before prompting for confirmation", func() {
+			It("prints what will be deleted", func() {
+				fmt.Fprintf(stdin, "no\n")
+				terraformManager.ResourcesCall.Returns.Resources = []string{
+					"google_compute_address.bosh-external-ip (some-address)",
+					"google_compute_network.bbl-network (some-network)",
+				}
+
+				err := destroy.Execute([]string{}, storage.State{
+					IAAS:  "gcp",
+					EnvID: "some-lake",
+					BOSH: storage.BOSH{
+						DirectorName: "some-director",
+					},
+					Jumpbox: storage.Jumpbox{
+						Enabled: true,
+						URL:     "some-jumpbox-url",
+					},
+					LB: storage.LB{
+						Type: "cf",
+					},
+					KeyPair: storage.KeyPair{
+						Name: "some-keypair",
+					},
+					TFState: "some-tf-state",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ResourcesCall.Receives.BBLState.TFState).To(Equal("some-tf-state"))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					`The following will be deleted for "some-lake":
+  BOSH director: some-director
+  Jumpbox: some-jumpbox-url
+  Load balancers: cf
+  Key pair: some-keypair
+  Terraform resources:
+    google_compute_address.bosh-external-ip (some-address)
+    google_compute_network.bbl-network (some-network)`,
+				}))
+			})
+
+			It("returns an error when the terraform resources cannot be listed", func() {
+				terraformManager.ResourcesCall.Returns.Error = errors.New("failed to list resources")
+
+				err := destroy.Execute([]string{}, storage.State{
+					EnvID:   "some-lake",
+					TFState: "some-tf-state",
+				})
+				Expect(err).To(MatchError("failed to list resources"))
+				Expect(logger.PromptCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the --json flag is supplied", func() {
+			It("prints the deletion plan as json and does not delete anything", func() {
+				terraformManager.ResourcesCall.Returns.Resources = []string{"aws_vpc.vpc (some-vpc-id)"}
+
+				err := destroy.Execute([]string{"--json"}, storage.State{
+					IAAS:  "aws",
+					EnvID: "some-lake",
+					BOSH: storage.BOSH{
+						DirectorName: "some-director",
+					},
+					Stack: storage.Stack{
+						CertificateName: "some-certificate",
+					},
+					KeyPair: storage.KeyPair{
+						Name: "some-keypair",
+					},
+					TFState: "some-tf-state",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+				Expect(logger.PrintlnCall.Messages[0]).To(MatchJSON(`{
+					"env_id": "some-lake",
+					"iaas": "aws",
+					"director": "some-director",
+					"certificate": "some-certificate",
+					"key_pair": "some-keypair",
+					"resources": ["aws_vpc.vpc (some-vpc-id)"]
+				}`))
+
+				Expect(logger.PromptCall.CallCount).To(Equal(0))
+				Expect(boshManager.DeleteCall.CallCount).To(Equal(0))
+				Expect(terraformManager.DestroyCall.CallCount).To(Equal(0))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+		})
+
 		Context("when the --no-confirm flag is supplied", func() {
 			DescribeTable("destroys without prompting the user for confirmation", func(flag string) {
 				err := destroy.Execute([]string{flag}, storage.State{
@@ -638,6 +762,84 @@ var _ = Describe("Destroy", func() {
 								Expect(err).To(MatchError("the following errors occurred:\nfailed to destroy,\nfailed to set state"))
 							})
 						})
+
+						Context("when --retry-terraform is provided", func() {
+							var (
+								sleeps       []time.Duration
+								failedState  storage.State
+								partialState storage.State
+								destroyErr   terraform.ManagerError
+								tfStateFile  string
+							)
+
+							BeforeEach(func() {
+								sleeps = []time.Duration{}
+								commands.SetSleep(func(d time.Duration) {
+									sleeps = append(sleeps, d)
+								})
+
+								file, err := ioutil.TempFile("", "")
+								Expect(err).NotTo(HaveOccurred())
+								_, err = file.WriteString("some-updated-tf-state")
+								Expect(err).NotTo(HaveOccurred())
+								Expect(file.Close()).To(Succeed())
+								tfStateFile = file.Name()
+
+								failedState = expectedBBLState
+								failedState.LatestTFOutput = "Error deleting subnet: DependencyViolation: The subnet has dependencies and cannot be deleted."
+
+								partialState = failedState
+								partialState.TFState = "some-updated-tf-state"
+
+								destroyErr = terraform.NewManagerError(failedState, terraform.NewExecutorError(tfStateFile, errors.New("exit status 1"), false))
+								terraformManager.DestroyCall.Returns.Error = destroyErr
+							})
+
+							AfterEach(func() {
+								commands.ResetSleep()
+								os.Remove(tfStateFile)
+							})
+
+							It("re-runs terraform destroy from the partially destroyed state", func() {
+								destroyedState := partialState
+								destroyedState.TFState = ""
+								terraformManager.DestroyCall.Stub = func(bblState storage.State) (storage.State, error) {
+									if terraformManager.DestroyCall.CallCount == 1 {
+										return storage.State{}, destroyErr
+									}
+									return destroyedState, nil
+								}
+
+								err := destroy.Execute([]string{"--retry-terraform", "2"}, state)
+								Expect(err).NotTo(HaveOccurred())
+
+								Expect(terraformManager.DestroyCall.CallCount).To(Equal(2))
+								Expect(terraformManager.DestroyCall.Receives.BBLState).To(Equal(partialState))
+								Expect(stateStore.SetCall.Receives[1].State).To(Equal(partialState))
+								Expect(sleeps).To(Equal([]time.Duration{10 * time.Second}))
+								Expect(logger.WarnCall.Messages).To(ContainElement("terraform destroy failed on a resource that is still in use, retrying in 10s (retry 1 of 2)"))
+							})
+
+							It("gives up after the given number of retries", func() {
+								err := destroy.Execute([]string{"--retry-terraform", "2", "--stop-on-error"}, state)
+								Expect(err).To(Equal(destroyErr))
+
+								Expect(terraformManager.DestroyCall.CallCount).To(Equal(3))
+								Expect(sleeps).To(Equal([]time.Duration{10 * time.Second, 20 * time.Second}))
+							})
+
+							It("does not retry failures that are not caused by a resource in use", func() {
+								failedState.LatestTFOutput = "Error deleting subnet: UnauthorizedOperation"
+								destroyErr = terraform.NewManagerError(failedState, terraform.NewExecutorError(tfStateFile, errors.New("exit status 1"), false))
+								terraformManager.DestroyCall.Returns.Error = destroyErr
+
+								err := destroy.Execute([]string{"--retry-terraform", "2", "--stop-on-error"}, state)
+								Expect(err).To(Equal(destroyErr))
+
+								Expect(terraformManager.DestroyCall.CallCount).To(Equal(1))
+								Expect(sleeps).To(BeEmpty())
+							})
+						})
 					})
 				})
 
@@ -666,6 +868,16 @@ var _ = Describe("Destroy", func() {
 					Expect(awsKeyPairDeleter.DeleteCall.Receives.Name).To(Equal("some-ec2-key-pair-name"))
 				})
 
+				It("does not delete a keypair managed outside of bbl", func() {
+					state.KeyPair.External = true
+
+					err := destroy.Execute([]string{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(0))
+					Expect(logger.StepCall.Messages).To(ContainElement("skipping deletion of the key pair managed outside of bbl"))
+				})
+
 				It("logs the bosh deletion", func() {
 					err := destroy.Execute([]string{}, state)
 					Expect(err).NotTo(HaveOccurred())
@@ -779,6 +991,64 @@ var _ = Describe("Destroy", func() {
 						})
 					})
 
+					Context("partial teardown", func() {
+						It("continues past a failed stack deletion and reports what remains", func() {
+							infrastructureManager.DeleteCall.Returns.Error = errors.New("failed to delete stack")
+
+							err := destroy.Execute([]string{}, state)
+							Expect(err).To(MatchError("failed to delete stack"))
+
+							Expect(certificateDeleter.DeleteCall.CallCount).To(Equal(0))
+							Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(1))
+							Expect(logger.PrintlnCall.Messages).To(ContainElement(strings.Join([]string{
+								"destroy did not remove everything, run bbl destroy again to remove what remains.",
+								"removed:",
+								"  bosh director",
+								"  key pair",
+								"remaining:",
+								"  infrastructure: failed to delete stack",
+								"  certificate: skipped, the infrastructure using it was not removed",
+							}, "\n")))
+
+							Expect(stateStore.SetCall.CallCount).To(Equal(1))
+							Expect(stateStore.SetCall.Receives[0].State.Stack.Name).To(Equal("some-stack-name"))
+						})
+
+						It("returns every error when more than one resource fails to delete", func() {
+							certificateDeleter.DeleteCall.Returns.Error = errors.New("failed to delete certificate")
+							awsKeyPairDeleter.DeleteCall.Returns.Error = errors.New("failed to delete keypair")
+
+							err := destroy.Execute([]string{}, state)
+							Expect(err).To(MatchError("the following errors occurred:\nfailed to delete certificate,\nfailed to delete keypair"))
+
+							Expect(logger.PrintlnCall.Messages).To(ContainElement(strings.Join([]string{
+								"destroy did not remove everything, run bbl destroy again to remove what remains.",
+								"removed:",
+								"  bosh director",
+								"  infrastructure",
+								"remaining:",
+								"  certificate: failed to delete certificate",
+								"  key pair: failed to delete keypair",
+							}, "\n")))
+
+							Expect(stateStore.SetCall.CallCount).To(Equal(2))
+							Expect(stateStore.SetCall.Receives[1].State.Stack.CertificateName).To(Equal("some-certificate-name"))
+						})
+
+						Context("when --stop-on-error is provided", func() {
+							It("stops at the first failure", func() {
+								infrastructureManager.DeleteCall.Returns.Error = errors.New("failed to delete stack")
+
+								err := destroy.Execute([]string{"--stop-on-error"}, state)
+								Expect(err).To(MatchError("failed to delete stack"))
+
+								Expect(certificateDeleter.DeleteCall.CallCount).To(Equal(0))
+								Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(0))
+								Expect(logger.PrintlnCall.Messages).NotTo(ContainElement(ContainSubstring("destroy did not remove everything")))
+							})
+						})
+					})
+
 					Context("when there is no stack to delete", func() {
 						BeforeEach(func() {
 							stackManager.DescribeCall.Returns.Error = cloudformation.StackNotFound
@@ -1097,5 +1367,66 @@ var _ = Describe("Destroy", func() {
 				})
 			})
 		})
+
+		DescribeTable("when the iaas only uses terraform", func(iaas string) {
+			bblState := storage.State{
+				IAAS:    iaas,
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+				KeyPair: storage.KeyPair{
+					PublicKey: "some-public-key",
+				},
+			}
+			terraformManager.DestroyCall.Returns.BBLState = storage.State{IAAS: iaas, EnvID: "some-env-id"}
+			stdin.Write([]byte("yes\n"))
+
+			err := destroy.Execute([]string{}, bblState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.DestroyCall.CallCount).To(Equal(1))
+			Expect(terraformManager.DestroyCall.Receives.BBLState).To(Equal(bblState))
+			Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(0))
+			Expect(gcpKeyPairDeleter.DeleteCall.CallCount).To(Equal(0))
+			Expect(stateStore.SetCall.Receives[len(stateStore.SetCall.Receives)-1].State).To(Equal(storage.State{}))
+		},
+			Entry("azure", "azure"),
+			Entry("openstack", "openstack"),
+		)
+	})
+
+	Describe("DryRun", func() {
+		It("lists what would be deleted and the terraform destroy plan", func() {
+			terraformManager.ResourcesCall.Returns.Resources = []string{"google_compute_network.bbl-network"}
+			terraformManager.PlanDestroyCall.Returns.Plan = "some-destroy-plan"
+
+			err := destroy.DryRun([]string{}, storage.State{
+				IAAS:    "gcp",
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+				BOSH: storage.BOSH{
+					DirectorName: "some-director",
+				},
+				KeyPair: storage.KeyPair{Name: "some-keypair"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshManager.DeleteCall.CallCount).To(Equal(0))
+			Expect(terraformManager.DestroyCall.CallCount).To(Equal(0))
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl destroy --dry-run would:
+  - delete the BOSH director some-director
+  - delete the key pair some-keypair
+  - destroy the terraform resources in the plan below
+  - delete the bbl state
+
+some-destroy-plan`))
+		})
+
+		It("returns an error when the terraform plan fails", func() {
+			terraformManager.PlanDestroyCall.Returns.Error = errors.New("failed to plan")
+
+			err := destroy.DryRun([]string{}, storage.State{IAAS: "gcp", EnvID: "some-env-id"})
+			Expect(err).To(MatchError("failed to plan"))
+		})
 	})
 })
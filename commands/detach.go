@@ -0,0 +1,42 @@
+package commands
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type upDetacher interface {
+	LogPath() string
+	Detach() (int, error)
+}
+
+func detachUp(state storage.State, upDetacher upDetacher, stateStore stateStore, logger logger) error {
+	state.UpProgress = storage.UpProgress{
+		Phase:   UpPhaseTerraform,
+		LogFile: upDetacher.LogPath(),
+	}
+
+	err := stateStore.Set(state)
+	if err != nil {
+		return err
+	}
+
+	pid, err := upDetacher.Detach()
+	if err != nil {
+		state.UpProgress = storage.UpProgress{}
+		if setErr := stateStore.Set(state); setErr != nil {
+			return setErr
+		}
+		return err
+	}
+
+	// the detached bbl waits for the lock of this one, so it loads the
+	// state with its pid
+	state.UpProgress.PID = pid
+	err = stateStore.Set(state)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("bbl up is continuing in the background (task id: %d), output is being written to %s\n", pid, state.UpProgress.LogFile)
+	logger.Printf("Run `bbl status` to check on its progress.\n")
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const diffContext = 3
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorReset = "\x1b[0m"
+)
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the lines removed from a with a "-" prefix and the lines
+// added in b with a "+" prefix, with a few unchanged lines around each change.
+// It returns an empty string when a and b are the same.
+func diffLines(a, b string) string {
+	lines := diffOps(splitLines(a), splitLines(b))
+
+	changed := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for j := i - diffContext; j <= i+diffContext; j++ {
+			if j >= 0 && j < len(lines) {
+				changed[j] = true
+			}
+		}
+	}
+
+	output := []string{}
+	for i, line := range lines {
+		if !changed[i] {
+			continue
+		}
+		if i > 0 && !changed[i-1] && len(output) > 0 {
+			output = append(output, "  ...")
+		}
+		output = append(output, fmt.Sprintf("%c %s", line.op, line.text))
+	}
+
+	return strings.Join(output, "\n")
+}
+
+// diffOps walks the longest common subsequence of a and b.
+func diffOps(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+
+	return lines
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
+
+// colorDiff shows the removed lines of a diff in red and the added lines in
+// green.
+func colorDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			lines[i] = colorRed + line + colorReset
+		case strings.HasPrefix(line, "+"):
+			lines[i] = colorGreen + line + colorReset
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// secretKeyPattern matches the names of manifest properties that hold
+// credentials, for the ones that are generated by the plan itself and so are
+// not in the director variables yet.
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|secret|private_key|token)`)
+
+// redactedDiff diffs the director manifests a and b with the values of the
+// director variables, the properties named like credentials and private keys
+// replaced with <redacted>, so that the diff can be shown.
+func redactedDiff(a, b, variables string) (string, error) {
+	secrets := map[string]bool{}
+
+	var vars interface{}
+	err := yaml.Unmarshal([]byte(variables), &vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the director variables: %s", err)
+	}
+	collectSecrets(vars, secrets)
+
+	redactedA, err := redactManifest(a, secrets)
+	if err != nil {
+		return "", err
+	}
+
+	redactedB, err := redactManifest(b, secrets)
+	if err != nil {
+		return "", err
+	}
+
+	return diffLines(redactedA, redactedB), nil
+}
+
+func collectSecrets(value interface{}, secrets map[string]bool) {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		for _, v := range value {
+			collectSecrets(v, secrets)
+		}
+	case []interface{}:
+		for _, v := range value {
+			collectSecrets(v, secrets)
+		}
+	case string:
+		if value != "" {
+			secrets[value] = true
+		}
+	}
+}
+
+func redactManifest(manifest string, secrets map[string]bool) (string, error) {
+	if manifest == "" {
+		return "", nil
+	}
+
+	var document yaml.MapSlice
+	err := yaml.Unmarshal([]byte(manifest), &document)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the director manifest: %s", err)
+	}
+
+	contents, err := yaml.Marshal(redactValue(document, false, secrets))
+	if err != nil {
+		return "", err //not tested
+	}
+
+	return string(contents), nil
+}
+
+func redactValue(value interface{}, secretKey bool, secrets map[string]bool) interface{} {
+	switch value := value.(type) {
+	case yaml.MapSlice:
+		redacted := yaml.MapSlice{}
+		for _, item := range value {
+			key := fmt.Sprint(item.Key)
+			redacted = append(redacted, yaml.MapItem{
+				Key:   item.Key,
+				Value: redactValue(item.Value, secretKey || secretKeyPattern.MatchString(key), secrets),
+			})
+		}
+		return redacted
+	case []interface{}:
+		redacted := []interface{}{}
+		for _, v := range value {
+			redacted = append(redacted, redactValue(v, secretKey, secrets))
+		}
+		return redacted
+	case string:
+		if secretKey || secrets[value] || strings.Contains(value, "PRIVATE KEY") {
+			return redactedValue
+		}
+		return value
+	}
+
+	return value
+}
@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const DirectorBackupCommand = "director-backup"
+
+type bbrCommand interface {
+	Run(env []string, workingDirectory string, args []string) error
+}
+
+type DirectorBackup struct {
+	logger         logger
+	stateValidator stateValidator
+	sshKeyGetter   directorSSHKeyGetter
+	socks5Proxy    socks5Proxy
+	bbr            bbrCommand
+}
+
+type directorBackupConfig struct {
+	artifactPath string
+}
+
+func NewDirectorBackup(logger logger, stateValidator stateValidator, sshKeyGetter directorSSHKeyGetter, socks5Proxy socks5Proxy, bbr bbrCommand) DirectorBackup {
+	return DirectorBackup{
+		logger:         logger,
+		stateValidator: stateValidator,
+		sshKeyGetter:   sshKeyGetter,
+		socks5Proxy:    socks5Proxy,
+		bbr:            bbr,
+	}
+}
+
+func (d DirectorBackup) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := d.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return validateBBRDirector(state)
+}
+
+func (d DirectorBackup) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := d.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	artifactPath, err := filepath.Abs(config.artifactPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(artifactPath, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	d.logger.Step("backing up the director to %s", artifactPath)
+	err = runBBRDirector(d.bbr, d.sshKeyGetter, d.socks5Proxy, state, artifactPath, "backup")
+	if err != nil {
+		return err
+	}
+
+	d.logger.Step("backed up the director")
+	return nil
+}
+
+func (DirectorBackup) parseFlags(subcommandFlags []string) (directorBackupConfig, error) {
+	backupFlags := flags.New(DirectorBackupCommand)
+
+	config := directorBackupConfig{}
+	backupFlags.String(&config.artifactPath, "artifact-path", ".")
+
+	err := backupFlags.Parse(subcommandFlags)
+	if err != nil {
+		return directorBackupConfig{}, err
+	}
+
+	return config, nil
+}
+
+func validateBBRDirector(state storage.State) error {
+	if state.NoDirector || state.BOSH.DirectorAddress == "" {
+		return errors.New("Error BBL does not manage this director.")
+	}
+
+	err := validateDirectorSSH(state)
+	if err != nil {
+		return err
+	}
+
+	if !hasBBRJob(state.BOSH.Manifest) {
+		return errors.New("The director is not deployed with the bbr job, run bbl up --ops-file with the bbr.yml ops file of bosh-deployment to add it.")
+	}
+
+	return nil
+}
+
+// hasBBRJob checks the director manifest for the database-backup-restorer
+// job that bbr.yml of bosh-deployment adds, without which bbr cannot back up
+// the director's database.
+func hasBBRJob(manifest string) bool {
+	var director struct {
+		InstanceGroups []struct {
+			Name string `yaml:"name"`
+			Jobs []struct {
+				Name string `yaml:"name"`
+			} `yaml:"jobs"`
+		} `yaml:"instance_groups"`
+	}
+
+	err := yaml.Unmarshal([]byte(manifest), &director)
+	if err != nil {
+		return false
+	}
+
+	for _, instanceGroup := range director.InstanceGroups {
+		if instanceGroup.Name != "bosh" {
+			continue
+		}
+
+		for _, job := range instanceGroup.Jobs {
+			if job.Name == "database-backup-restorer" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// runBBRDirector runs bbr director against the director in state, as the
+// jumpbox user with the director's ssh key. bbr reaches a director behind a
+// jumpbox through the socks5 proxy set in BOSH_ALL_PROXY.
+func runBBRDirector(bbr bbrCommand, sshKeyGetter directorSSHKeyGetter, socks5Proxy socks5Proxy, state storage.State, workingDirectory string, subcommand ...string) error {
+	directorKey, err := sshKeyGetter.GetDirector(state)
+	if err != nil {
+		return err
+	}
+
+	if directorKey == "" {
+		return errors.New("The director does not have an ssh key yet, run bbl up to add one.")
+	}
+
+	directorURL, err := url.Parse(state.BOSH.DirectorAddress)
+	if err != nil {
+		return err
+	}
+
+	keyDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(keyDir)
+
+	keyPath := filepath.Join(keyDir, "director.key")
+	err = ioutil.WriteFile(keyPath, []byte(directorKey), 0600)
+	if err != nil {
+		return err
+	}
+
+	var env []string
+	if state.Jumpbox.Enabled {
+		jumpboxKey, err := sshKeyGetter.Get(state)
+		if err != nil {
+			return err
+		}
+
+		err = socks5Proxy.Start(jumpboxKey, state.Jumpbox.URL)
+		if err != nil {
+			return err
+		}
+
+		env = append(env, fmt.Sprintf("BOSH_ALL_PROXY=socks5://%s", socks5Proxy.Addr()))
+	}
+
+	args := append([]string{
+		"director",
+		"--host", directorURL.Hostname(),
+		"--username", "jumpbox",
+		"--private-key-path", keyPath,
+	}, subcommand...)
+
+	err = bbr.Run(env, workingDirectory, args)
+	if err != nil {
+		return fmt.Errorf("bbr director %s failed: %s", subcommand[0], err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package commands_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const bbrManifest = `instance_groups:
+- name: bosh
+  jobs:
+  - name: director
+  - name: database-backup-restorer
+`
+
+var _ = Describe("DirectorBackup", func() {
+	var (
+		command commands.DirectorBackup
+
+		incomingState storage.State
+		artifactPath  string
+
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		sshKeyGetter   *fakes.SSHKeyGetter
+		socks5Proxy    *fakes.Socks5Proxy
+		bbr            *fakes.BBRCommand
+	)
+
+	BeforeEach(func() {
+		incomingState = storage.State{
+			Jumpbox: storage.Jumpbox{
+				Enabled: true,
+				URL:     "some-jumpbox-url:22",
+			},
+			DirectorSSH: true,
+			BOSH: storage.BOSH{
+				DirectorAddress: "https://10.0.0.6:25555",
+				Manifest:        bbrManifest,
+			},
+		}
+
+		tempDir, err := ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		artifactPath = filepath.Join(tempDir, "backups")
+
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+		sshKeyGetter.GetCall.Returns.PrivateKey = "some-jumpbox-key"
+		sshKeyGetter.GetDirectorCall.Returns.PrivateKey = "some-director-key"
+		socks5Proxy = &fakes.Socks5Proxy{}
+		socks5Proxy.AddrCall.Returns.Addr = "localhost:1080"
+		bbr = &fakes.BBRCommand{}
+
+		command = commands.NewDirectorBackup(logger, stateValidator, sshKeyGetter, socks5Proxy, bbr)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			err := command.CheckFastFails([]string{}, storage.State{NoDirector: true})
+			Expect(err).To(MatchError("Error BBL does not manage this director."))
+		})
+
+		It("returns an error when the director behind the jumpbox has no ssh user", func() {
+			incomingState.DirectorSSH = false
+
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("The director behind the jumpbox has no ssh user, run bbl up --director-ssh to add one."))
+		})
+
+		It("returns an error when the director is not deployed with the bbr job", func() {
+			incomingState.BOSH.Manifest = "instance_groups:\n- name: bosh\n  jobs:\n  - name: director\n"
+
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("The director is not deployed with the bbr job, run bbl up --ops-file with the bbr.yml ops file of bosh-deployment to add it."))
+		})
+
+		It("returns an error when flags cannot be parsed", func() {
+			err := command.CheckFastFails([]string{"--unknown-flag"}, incomingState)
+			Expect(err).To(MatchError("flag provided but not defined: -unknown-flag"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("runs bbr director backup through the jumpbox in the artifact path", func() {
+			var directorKey string
+			bbr.RunCall.Stub = func(env []string, workingDirectory string, args []string) error {
+				key, err := ioutil.ReadFile(args[6])
+				Expect(err).NotTo(HaveOccurred())
+				directorKey = string(key)
+				return nil
+			}
+
+			err := command.Execute([]string{"--artifact-path", artifactPath}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(socks5Proxy.StartCall.Receives.JumpboxPrivateKey).To(Equal("some-jumpbox-key"))
+			Expect(socks5Proxy.StartCall.Receives.JumpboxExternalURL).To(Equal("some-jumpbox-url:22"))
+
+			Expect(bbr.RunCall.CallCount).To(Equal(1))
+			Expect(bbr.RunCall.Receives.Env).To(Equal([]string{"BOSH_ALL_PROXY=socks5://localhost:1080"}))
+			Expect(bbr.RunCall.Receives.WorkingDirectory).To(Equal(artifactPath))
+			Expect(bbr.RunCall.Receives.Args).To(HaveLen(8))
+			Expect(bbr.RunCall.Receives.Args[:6]).To(Equal([]string{
+				"director",
+				"--host", "10.0.0.6",
+				"--username", "jumpbox",
+				"--private-key-path",
+			}))
+			Expect(bbr.RunCall.Receives.Args[7]).To(Equal("backup"))
+			Expect(directorKey).To(Equal("some-director-key"))
+
+			_, err = os.Stat(artifactPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(bbr.RunCall.Receives.Args[6])
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			Expect(logger.StepCall.Messages).To(ContainElement("backed up the director"))
+		})
+
+		It("connects to the director directly when there is no jumpbox", func() {
+			incomingState.Jumpbox = storage.Jumpbox{}
+
+			err := command.Execute([]string{"--artifact-path", artifactPath}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(socks5Proxy.StartCall.CallCount).To(Equal(0))
+			Expect(bbr.RunCall.Receives.Env).To(BeEmpty())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the director has no ssh key", func() {
+				sshKeyGetter.GetDirectorCall.Returns.PrivateKey = ""
+
+				err := command.Execute([]string{"--artifact-path", artifactPath}, incomingState)
+				Expect(err).To(MatchError("The director does not have an ssh key yet, run bbl up to add one."))
+				Expect(bbr.RunCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the socks5 proxy fails to start", func() {
+				socks5Proxy.StartCall.Returns.Error = errors.New("failed to start proxy")
+
+				err := command.Execute([]string{"--artifact-path", artifactPath}, incomingState)
+				Expect(err).To(MatchError("failed to start proxy"))
+			})
+
+			It("returns an error when bbr fails", func() {
+				bbr.RunCall.Returns.Error = errors.New("exit status 1")
+
+				err := command.Execute([]string{"--artifact-path", artifactPath}, incomingState)
+				Expect(err).To(MatchError("bbr director backup failed: exit status 1"))
+			})
+		})
+	})
+})
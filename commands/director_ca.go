@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+func readDirectorCA(certPath, keyPath string) (string, string, error) {
+	if certPath == "" && keyPath == "" {
+		return "", "", nil
+	}
+
+	if certPath == "" || keyPath == "" {
+		return "", "", errors.New("--director-ca-cert and --director-ca-key must be provided together")
+	}
+
+	certificate, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading director-ca-cert contents: %v", err)
+	}
+
+	privateKey, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading director-ca-key contents: %v", err)
+	}
+
+	return string(certificate), string(privateKey), nil
+}
+
+func validateDirectorCA(certificate, privateKey string) error {
+	keyPair, err := tls.X509KeyPair([]byte(certificate), []byte(privateKey))
+	if err != nil {
+		return fmt.Errorf("director CA certificate and key do not match: %s", err)
+	}
+
+	ca, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("director CA certificate could not be parsed: %s", err)
+	}
+
+	if !ca.IsCA {
+		return errors.New("director CA certificate is not a certificate authority")
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"golang.org/x/net/proxy"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+var proxySOCKS5 func(string, string, *proxy.Auth, proxy.Dialer) (proxy.Dialer, error) = proxy.SOCKS5
+
+type boshClientProvider interface {
+	Client(jumpbox bool, directorAddress, directorUsername, directorPassword, directorCACert string) bosh.Client
+}
+
+type socks5Proxy interface {
+	Start(string, string) error
+	Addr() string
+}
+
+func directorClient(state storage.State, boshClientProvider boshClientProvider, socks5Proxy socks5Proxy, sshKeyGetter sshKeyGetter) (bosh.Client, error) {
+	boshClient := boshClientProvider.Client(state.Jumpbox.Enabled, state.BOSH.DirectorAddress,
+		state.BOSH.DirectorUsername, state.BOSH.DirectorPassword, state.BOSH.DirectorSSLCA)
+
+	if state.Jumpbox.Enabled {
+		privateKey, err := sshKeyGetter.Get(state)
+		if err != nil {
+			return nil, err
+		}
+
+		err = socks5Proxy.Start(privateKey, state.Jumpbox.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		socks5Client, err := proxySOCKS5("tcp", socks5Proxy.Addr(), nil, proxy.Direct)
+		if err != nil {
+			return nil, err
+		}
+
+		boshClient.ConfigureHTTPClient(socks5Client)
+	}
+
+	return boshClient, nil
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func validateDirectorExternalDB(externalDB, jumpbox, noDirector bool, state storage.State) error {
+	if !externalDB && !state.DirectorDB.Enabled {
+		return nil
+	}
+
+	if jumpbox {
+		return errors.New("--director-external-db cannot be used with --credhub, uaa and credhub keep their data in the director's local database")
+	}
+
+	if !externalDB {
+		return nil
+	}
+
+	if noDirector {
+		return errors.New("--director-external-db cannot be used with --no-director")
+	}
+
+	if state.IAAS != "aws" && state.IAAS != "gcp" {
+		return errors.New(`--director-external-db is only supported when iaas="aws" or iaas="gcp"`)
+	}
+
+	if state.BOSH.DirectorAddress != "" && !state.DirectorDB.Enabled {
+		return errors.New("--director-external-db can only be used when creating the director, the existing director's database would not be migrated")
+	}
+
+	return nil
+}
+
+// updateDirectorDB enables the external director database and generates its
+// password the first time. Once enabled the database is kept on every later
+// up, since the director's data lives in it.
+func updateDirectorDB(state storage.State, externalDB bool) (storage.State, error) {
+	if !externalDB || state.DirectorDB.Enabled {
+		return state, nil
+	}
+
+	password := make([]byte, 16)
+	_, err := rand.Read(password)
+	if err != nil {
+		return storage.State{}, fmt.Errorf("failed to generate the director database password: %s", err)
+	}
+
+	state.DirectorDB = storage.DirectorDB{
+		Enabled:  true,
+		Password: fmt.Sprintf("%x", password),
+	}
+
+	return state, nil
+}
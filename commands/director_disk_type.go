@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+var directorDiskTypes = map[string][]string{
+	"aws": {"gp2", "gp3", "standard"},
+	"gcp": {"pd-standard", "pd-ssd"},
+}
+
+// defaultDirectorDiskTypes are the disk types bosh-deployment uses when
+// --director-disk-type has never been set.
+var defaultDirectorDiskTypes = map[string]string{
+	"aws": "gp2",
+	"gcp": "pd-standard",
+}
+
+func validateDirectorDiskType(diskType string, noDirector bool, iaas string) error {
+	if diskType == "" {
+		return nil
+	}
+
+	if noDirector {
+		return errors.New("--director-disk-type cannot be used with --no-director")
+	}
+
+	allowed, ok := directorDiskTypes[iaas]
+	if !ok {
+		return errors.New(`--director-disk-type is only supported when iaas="aws" or iaas="gcp"`)
+	}
+
+	for _, t := range allowed {
+		if diskType == t {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("--director-disk-type %q is not supported when iaas=%q, valid types are: %s", diskType, iaas, strings.Join(allowed, ", "))
+}
+
+func updateDirectorDiskType(state storage.State, diskType string, logger logger) storage.State {
+	if diskType == "" {
+		return state
+	}
+
+	current := state.BOSH.DirectorDiskType
+	if current == "" {
+		current = defaultDirectorDiskTypes[state.IAAS]
+	}
+
+	if state.BOSH.DirectorAddress != "" && diskType != current {
+		logger.Warn("changing the director disk type from %s to %s will recreate the director's disks.", current, diskType)
+	}
+
+	state.BOSH.DirectorDiskType = diskType
+	return state
+}
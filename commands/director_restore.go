@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const DirectorRestoreCommand = "director-restore"
+
+type DirectorRestore struct {
+	logger         logger
+	stateValidator stateValidator
+	sshKeyGetter   directorSSHKeyGetter
+	socks5Proxy    socks5Proxy
+	bbr            bbrCommand
+}
+
+type directorRestoreConfig struct {
+	artifactPath string
+}
+
+func NewDirectorRestore(logger logger, stateValidator stateValidator, sshKeyGetter directorSSHKeyGetter, socks5Proxy socks5Proxy, bbr bbrCommand) DirectorRestore {
+	return DirectorRestore{
+		logger:         logger,
+		stateValidator: stateValidator,
+		sshKeyGetter:   sshKeyGetter,
+		socks5Proxy:    socks5Proxy,
+		bbr:            bbr,
+	}
+}
+
+func (d DirectorRestore) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := d.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	config, err := d.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.artifactPath == "" {
+		return errors.New("--artifact-path is required")
+	}
+
+	_, err = os.Stat(config.artifactPath)
+	if err != nil {
+		return err
+	}
+
+	return validateBBRDirector(state)
+}
+
+func (d DirectorRestore) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := d.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	artifactPath, err := filepath.Abs(config.artifactPath)
+	if err != nil {
+		return err
+	}
+
+	d.logger.Step("restoring the director from %s", artifactPath)
+	err = runBBRDirector(d.bbr, d.sshKeyGetter, d.socks5Proxy, state, filepath.Dir(artifactPath), "restore", "--artifact-path", artifactPath)
+	if err != nil {
+		return err
+	}
+
+	d.logger.Step("restored the director")
+	return nil
+}
+
+func (DirectorRestore) parseFlags(subcommandFlags []string) (directorRestoreConfig, error) {
+	restoreFlags := flags.New(DirectorRestoreCommand)
+
+	config := directorRestoreConfig{}
+	restoreFlags.String(&config.artifactPath, "artifact-path", "")
+
+	err := restoreFlags.Parse(subcommandFlags)
+	if err != nil {
+		return directorRestoreConfig{}, err
+	}
+
+	return config, nil
+}
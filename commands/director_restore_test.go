@@ -0,0 +1,105 @@
+package commands_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DirectorRestore", func() {
+	var (
+		command commands.DirectorRestore
+
+		incomingState storage.State
+		artifactPath  string
+
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		sshKeyGetter   *fakes.SSHKeyGetter
+		socks5Proxy    *fakes.Socks5Proxy
+		bbr            *fakes.BBRCommand
+	)
+
+	BeforeEach(func() {
+		incomingState = storage.State{
+			BOSH: storage.BOSH{
+				DirectorAddress: "https://some-director-ip:25555",
+				Manifest:        bbrManifest,
+			},
+		}
+
+		tempDir, err := ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		artifactPath = filepath.Join(tempDir, "some-director-ip_20171017T000000Z")
+		Expect(os.Mkdir(artifactPath, os.ModePerm)).To(Succeed())
+
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+		sshKeyGetter.GetDirectorCall.Returns.PrivateKey = "some-director-key"
+		socks5Proxy = &fakes.Socks5Proxy{}
+		bbr = &fakes.BBRCommand{}
+
+		command = commands.NewDirectorRestore(logger, stateValidator, sshKeyGetter, socks5Proxy, bbr)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{"--artifact-path", artifactPath}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when no artifact path is provided", func() {
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("--artifact-path is required"))
+		})
+
+		It("returns an error when the artifact does not exist", func() {
+			err := command.CheckFastFails([]string{"--artifact-path", "/some/fake/path"}, incomingState)
+			Expect(err).To(MatchError("stat /some/fake/path: no such file or directory"))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			err := command.CheckFastFails([]string{"--artifact-path", artifactPath}, storage.State{})
+			Expect(err).To(MatchError("Error BBL does not manage this director."))
+		})
+
+		It("returns an error when the director is not deployed with the bbr job", func() {
+			incomingState.BOSH.Manifest = ""
+
+			err := command.CheckFastFails([]string{"--artifact-path", artifactPath}, incomingState)
+			Expect(err).To(MatchError("The director is not deployed with the bbr job, run bbl up --ops-file with the bbr.yml ops file of bosh-deployment to add it."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("runs bbr director restore with the artifact", func() {
+			err := command.Execute([]string{"--artifact-path", artifactPath}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bbr.RunCall.CallCount).To(Equal(1))
+			Expect(bbr.RunCall.Receives.WorkingDirectory).To(Equal(filepath.Dir(artifactPath)))
+			Expect(bbr.RunCall.Receives.Args[2]).To(Equal("some-director-ip"))
+			Expect(bbr.RunCall.Receives.Args[7:]).To(Equal([]string{"restore", "--artifact-path", artifactPath}))
+
+			Expect(logger.StepCall.Messages).To(ContainElement("restored the director"))
+		})
+
+		It("returns an error when bbr fails", func() {
+			bbr.RunCall.Returns.Error = errors.New("exit status 1")
+
+			err := command.Execute([]string{"--artifact-path", artifactPath}, incomingState)
+			Expect(err).To(MatchError("bbr director restore failed: exit status 1"))
+		})
+	})
+})
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func validateDirectorSpot(spot bool, maxPrice string, noDirector bool, iaas string) error {
+	if maxPrice != "" {
+		if !spot {
+			return errors.New("--spot-max-price requires --director-spot")
+		}
+
+		if iaas != "aws" {
+			return errors.New(`--spot-max-price is only supported when iaas="aws"`)
+		}
+
+		price, err := strconv.ParseFloat(maxPrice, 64)
+		if err != nil || price <= 0 {
+			return errors.New("--spot-max-price must be a positive number")
+		}
+	}
+
+	if !spot {
+		return nil
+	}
+
+	if noDirector {
+		return errors.New("--director-spot cannot be used with --no-director")
+	}
+
+	if iaas == "aws" && maxPrice == "" {
+		return errors.New(`--spot-max-price must be provided with --director-spot when iaas="aws"`)
+	}
+
+	return nil
+}
+
+// validateCloudConfigSpot checks the bid of the spot vm_extension bbl adds to
+// the cloud config. On gcp the preemptible vm_extension needs no settings.
+func validateCloudConfigSpot(bidPrice string, fallback bool, iaas string) error {
+	if fallback && bidPrice == "" {
+		return errors.New("--spot-ondemand-fallback requires --spot-bid-price")
+	}
+
+	if bidPrice == "" {
+		return nil
+	}
+
+	if iaas != "aws" {
+		return errors.New(`--spot-bid-price is only supported when iaas="aws"`)
+	}
+
+	price, err := strconv.ParseFloat(bidPrice, 64)
+	if err != nil || price <= 0 {
+		return errors.New("--spot-bid-price must be a positive number")
+	}
+
+	return nil
+}
+
+// updateCloudConfigSpot keeps the bid of an earlier up when none is passed.
+func updateCloudConfigSpot(state storage.State, bidPrice string, fallback bool) storage.State {
+	if bidPrice != "" {
+		state.AWS.SpotBidPrice = bidPrice
+		state.AWS.SpotOnDemandFallback = fallback
+	}
+	return state
+}
+
+func updateDirectorSpot(state storage.State, spot bool, maxPrice string, logger logger) storage.State {
+	if spot {
+		logger.Warn("the director will run on preemptible/spot capacity and can be terminated by the IAAS at any time. Only use this for disposable environments.")
+	}
+
+	state.BOSH.DirectorSpot = spot
+	state.BOSH.DirectorSpotPrice = maxPrice
+	return state
+}
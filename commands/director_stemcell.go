@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// defaultStemcellNames are the stemcells bosh-deployment deploys the director
+// on, whose other versions --director-stemcell-version picks from bosh.io.
+var defaultStemcellNames = map[string]string{
+	"aws":       "bosh-aws-xen-hvm-ubuntu-trusty-go_agent",
+	"gcp":       "bosh-google-kvm-ubuntu-trusty-go_agent",
+	"azure":     "bosh-azure-hyperv-ubuntu-trusty-go_agent",
+	"openstack": "bosh-openstack-kvm-ubuntu-trusty-go_agent",
+}
+
+const boshIOStemcellURL = "https://bosh.io/d/stemcells/%s?v=%s"
+
+func validateDirectorStemcell(config upConfig, noDirector bool) error {
+	url := config.directorStemcellURL
+	name := config.directorStemcellName
+	version := config.directorStemcellVersion
+	sha1 := config.directorStemcellSHA1
+
+	if url == "" && name == "" && version == "" && sha1 == "" {
+		return nil
+	}
+
+	if noDirector {
+		return errors.New("--director-stemcell-url, --director-stemcell-name and --director-stemcell-version cannot be used with --no-director")
+	}
+
+	if url != "" && (name != "" || version != "") {
+		return errors.New("--director-stemcell-url cannot be used with --director-stemcell-name or --director-stemcell-version")
+	}
+
+	if url == bundled {
+		return nil
+	}
+
+	if name != "" && version == "" {
+		return errors.New("--director-stemcell-name requires --director-stemcell-version")
+	}
+
+	if url == "" && version == "" {
+		return errors.New("--director-stemcell-sha1 requires --director-stemcell-url or --director-stemcell-version")
+	}
+
+	if url != "" && !isRemoteStemcell(url) {
+		_, err := os.Stat(strings.TrimPrefix(url, "file://"))
+		if err != nil {
+			return fmt.Errorf("--director-stemcell-url must be an http url or the path of a stemcell tarball: %s", err)
+		}
+		return nil
+	}
+
+	if sha1 == "" {
+		return errors.New("--director-stemcell-sha1 is required to download the stemcell")
+	}
+
+	return nil
+}
+
+func updateDirectorStemcell(state storage.State, config upConfig) storage.State {
+	switch {
+	case config.directorStemcellURL == bundled:
+		state.BOSH.StemcellURL = ""
+		state.BOSH.StemcellSHA1 = ""
+	case config.directorStemcellURL != "":
+		state.BOSH.StemcellURL = stemcellURL(config.directorStemcellURL)
+		state.BOSH.StemcellSHA1 = config.directorStemcellSHA1
+	case config.directorStemcellVersion != "":
+		name := config.directorStemcellName
+		if name == "" {
+			name = defaultStemcellNames[state.IAAS]
+		}
+
+		state.BOSH.StemcellURL = fmt.Sprintf(boshIOStemcellURL, name, config.directorStemcellVersion)
+		state.BOSH.StemcellSHA1 = config.directorStemcellSHA1
+	}
+
+	return state
+}
+
+func isRemoteStemcell(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+// stemcellURL turns the path of a local tarball into the absolute file url
+// create-env reads it from, so that later runs from another directory find
+// it.
+func stemcellURL(url string) string {
+	if isRemoteStemcell(url) {
+		return url
+	}
+
+	path := strings.TrimPrefix(url, "file://")
+	if absolutePath, err := filepath.Abs(path); err == nil {
+		path = absolutePath
+	}
+
+	return "file://" + path
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// defaultDirectorVMTypes are the vm types bosh-deployment uses when
+// --director-vm-type has never been set.
+var defaultDirectorVMTypes = map[string]string{
+	"aws": "m4.xlarge",
+	"gcp": "n1-standard-1",
+}
+
+// defaultDirectorDiskSize is the persistent disk size, in GB, bosh-deployment
+// uses when --director-disk-size has never been set.
+const defaultDirectorDiskSize = 32
+
+func validateDirectorVMSize(vmType string, diskSize int, noDirector bool, iaas string) error {
+	if vmType == "" && diskSize == 0 {
+		return nil
+	}
+
+	if noDirector {
+		return errors.New("--director-vm-type and --director-disk-size cannot be used with --no-director")
+	}
+
+	if _, ok := defaultDirectorVMTypes[iaas]; !ok {
+		return errors.New(`--director-vm-type and --director-disk-size are only supported when iaas="aws" or iaas="gcp"`)
+	}
+
+	if diskSize < 0 {
+		return errors.New("--director-disk-size must be a positive number of GB")
+	}
+
+	return nil
+}
+
+func updateDirectorVMSize(state storage.State, vmType string, diskSize int, logger logger) storage.State {
+	directorExists := state.BOSH.DirectorAddress != ""
+
+	if vmType != "" {
+		current := state.BOSH.DirectorVMType
+		if current == "" {
+			current = defaultDirectorVMTypes[state.IAAS]
+		}
+
+		if directorExists && vmType != current {
+			logger.Warn("changing the director vm type from %s to %s will recreate the director vm.", current, vmType)
+		}
+
+		state.BOSH.DirectorVMType = vmType
+	}
+
+	if diskSize != 0 {
+		current := state.BOSH.DirectorDiskSize
+		if current == 0 {
+			current = defaultDirectorDiskSize
+		}
+
+		if directorExists && diskSize != current {
+			logger.Warn("changing the director disk size from %dGB to %dGB will migrate the director's persistent disk.", current, diskSize)
+		}
+
+		state.BOSH.DirectorDiskSize = diskSize
+	}
+
+	return state
+}
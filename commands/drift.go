@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	DriftCommand = "drift"
+)
+
+type Drift struct {
+	logger           logger
+	stateValidator   stateValidator
+	terraformManager terraformDrifter
+}
+
+type driftConfig struct {
+	json bool
+}
+
+type driftOutput struct {
+	Drifted bool   `json:"drifted"`
+	Plan    string `json:"plan"`
+}
+
+func NewDrift(logger logger, stateValidator stateValidator, terraformManager terraformDrifter) Drift {
+	return Drift{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		terraformManager: terraformManager,
+	}
+}
+
+func (d Drift) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := d.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.TFState == "" {
+		return errors.New("bbl drift requires an environment created with terraform")
+	}
+
+	return nil
+}
+
+func (d Drift) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := d.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	plan, drifted, err := d.terraformManager.Drift(state)
+	if err != nil {
+		return err
+	}
+
+	if config.json {
+		return printJSON(d.logger, driftOutput{Drifted: drifted, Plan: plan})
+	}
+
+	if !drifted {
+		d.logger.Println("no drift: the infrastructure matches what bbl last applied")
+		return nil
+	}
+
+	d.logger.Println("drift detected: the infrastructure has diverged from what bbl last applied, bbl up would make these changes")
+	d.logger.Println(plan)
+	return nil
+}
+
+func (d Drift) parseArgs(args []string) (driftConfig, error) {
+	var config driftConfig
+
+	driftFlags := flags.New(DriftCommand)
+	driftFlags.Bool(&config.json, "", "json", false)
+
+	err := driftFlags.Parse(args)
+	if err != nil {
+		return driftConfig{}, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,105 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var (
+		logger           *fakes.Logger
+		stateValidator   *fakes.StateValidator
+		terraformManager *fakes.TerraformManager
+
+		command commands.Drift
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		terraformManager = &fakes.TerraformManager{}
+
+		state = storage.State{
+			IAAS:    "gcp",
+			TFState: "some-tf-state",
+		}
+
+		command = commands.NewDrift(logger, stateValidator, terraformManager)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when flags cannot be parsed", func() {
+			err := command.CheckFastFails([]string{"--unknown-flag"}, state)
+			Expect(err).To(MatchError("flag provided but not defined: -unknown-flag"))
+		})
+
+		It("returns an error when the environment was not created with terraform", func() {
+			err := command.CheckFastFails([]string{}, storage.State{IAAS: "aws"})
+			Expect(err).To(MatchError("bbl drift requires an environment created with terraform"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("reports that the infrastructure has not drifted", func() {
+			terraformManager.DriftCall.Returns.Plan = "No changes. Infrastructure is up-to-date."
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.DriftCall.Receives.BBLState).To(Equal(state))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"no drift: the infrastructure matches what bbl last applied"}))
+		})
+
+		It("reports the drift and prints the plan", func() {
+			terraformManager.DriftCall.Returns.Plan = "~ google_compute_firewall.bosh-open"
+			terraformManager.DriftCall.Returns.Drifted = true
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				"drift detected: the infrastructure has diverged from what bbl last applied, bbl up would make these changes",
+				"~ google_compute_firewall.bosh-open",
+			}))
+		})
+
+		Context("when --json is provided", func() {
+			It("prints the drift and the plan as json", func() {
+				terraformManager.DriftCall.Returns.Plan = "~ google_compute_firewall.bosh-open"
+				terraformManager.DriftCall.Returns.Drifted = true
+
+				err := command.Execute([]string{"--json"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+					"drifted": true,
+					"plan": "~ google_compute_firewall.bosh-open"
+				}`))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the drift cannot be checked", func() {
+				terraformManager.DriftCall.Returns.Error = errors.New("failed to plan")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to plan"))
+			})
+		})
+	})
+})
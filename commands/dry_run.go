@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// DryRunner is implemented by commands that support the global --dry-run flag.
+// DryRun runs the same read-only steps as Execute and reports what Execute
+// would change, without saving state or touching the director or the IAAS.
+type DryRunner interface {
+	DryRun(subcommandFlags []string, state storage.State) error
+}
+
+type terraformPlanner interface {
+	Plan(storage.State) (string, error)
+}
+
+func printDryRun(logger logger, command string, changes []string, plan string) {
+	lines := []string{fmt.Sprintf("bbl %s --dry-run would:", command)}
+	for _, change := range changes {
+		lines = append(lines, fmt.Sprintf("  - %s", change))
+	}
+	if len(changes) == 0 {
+		lines = append(lines, "  - change nothing")
+	}
+
+	if plan != "" {
+		lines = append(lines, "", strings.TrimSpace(plan))
+	}
+
+	logger.Println(strings.Join(lines, "\n"))
+}
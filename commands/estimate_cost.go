@@ -0,0 +1,519 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/pricing"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	EstimateCostCommand = "estimate-cost"
+
+	hoursPerMonth = 730
+
+	// the director and jumpbox sizes of bosh-deployment and
+	// jumpbox-deployment, and the director databases of the terraform
+	// templates
+	awsDirectorVMType        = "m4.xlarge"
+	awsDirectorEphemeralDisk = 25
+	awsJumpboxVMType         = "t2.micro"
+	awsJumpboxDisk           = 20
+	awsNATVMType             = "t2.medium"
+	awsDirectorDBClass       = "db.t2.medium"
+	awsDirectorDBStorage     = 20
+	gcpDirectorVMType        = "n1-standard-1"
+	gcpDirectorRootDisk      = 40
+	gcpJumpboxVMType         = "n1-standard-1"
+	gcpJumpboxDisk           = 20
+	gcpDirectorDBCores       = 1
+	gcpDirectorDBMemory      = 3.75
+	gcpDirectorDBStorage     = 10
+	directorPersistentDisk   = 32
+)
+
+type awsPricer interface {
+	OnDemandPrice(region string, product pricing.Product) (float64, error)
+}
+
+type gcpPricer interface {
+	Price(serviceID, description, region string) (float64, error)
+}
+
+type EstimateCost struct {
+	credentialValidator          credentialValidator
+	awsAvailabilityZoneRetriever awsAvailabilityZoneRetriever
+	awsPricer                    awsPricer
+	gcpPricer                    gcpPricer
+	logger                       logger
+}
+
+type estimateCostConfig struct {
+	noDirector bool
+	jumpbox    bool
+	lbType     string
+}
+
+// costItem is a resource of the environment, priced per unit.
+type costItem struct {
+	name     string
+	quantity float64
+	unit     string
+	price    func() (float64, error)
+}
+
+func NewEstimateCost(credentialValidator credentialValidator, awsAvailabilityZoneRetriever awsAvailabilityZoneRetriever,
+	awsPricer awsPricer, gcpPricer gcpPricer, logger logger) EstimateCost {
+	return EstimateCost{
+		credentialValidator:          credentialValidator,
+		awsAvailabilityZoneRetriever: awsAvailabilityZoneRetriever,
+		awsPricer:                    awsPricer,
+		gcpPricer:                    gcpPricer,
+		logger:                       logger,
+	}
+}
+
+func (e EstimateCost) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := e.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.IAAS != "aws" && state.IAAS != "gcp" {
+		return fmt.Errorf("bbl estimate-cost only supports aws and gcp, not %q", state.IAAS)
+	}
+
+	return e.credentialValidator.Validate()
+}
+
+// Execute prices the jumpbox, director, NAT, load balancers and director
+// database the state, or bbl up with the given flags, would create. Items
+// without a price are listed, and left out of the total.
+func (e EstimateCost) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := e.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	config.noDirector = config.noDirector || state.NoDirector
+	config.jumpbox = config.jumpbox || state.Jumpbox.Enabled
+	if config.lbType == "" {
+		config.lbType = state.LB.Type
+	}
+
+	var (
+		region string
+		items  []costItem
+	)
+	switch state.IAAS {
+	case "aws":
+		region = state.AWS.Region
+		items, err = e.awsItems(state, config)
+	case "gcp":
+		region = state.GCP.Region
+		items = e.gcpItems(state, config)
+	}
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	writer := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	total := 0.0
+	unpriced := 0
+	for _, item := range items {
+		price, err := item.price()
+		if err != nil {
+			e.logger.Warn("%s has no price: %s", item.name, err)
+			fmt.Fprintf(writer, "%s\t%s %s\tunknown\n", item.name, formatQuantity(item.quantity), item.unit)
+			unpriced++
+			continue
+		}
+
+		cost := price * item.quantity
+		total += cost
+		fmt.Fprintf(writer, "%s\t%s %s\t$%.2f\n", item.name, formatQuantity(item.quantity), item.unit, cost)
+	}
+	fmt.Fprintf(writer, "total\t\t$%.2f\n", total)
+	writer.Flush()
+
+	e.logger.Printf("Approximate monthly cost of the %s environment in %s, at on-demand list prices in USD:\n", state.IAAS, region)
+	e.logger.Println(strings.TrimSuffix(buf.String(), "\n"))
+	if unpriced > 0 {
+		e.logger.Println("The total leaves out the items without a price.")
+	}
+	e.logger.Println("Data transfer, load balancer traffic, snapshots, discounts and taxes, and the vms of bosh deployments, are not included.")
+
+	return nil
+}
+
+func (e EstimateCost) awsItems(state storage.State, config estimateCostConfig) ([]costItem, error) {
+	region := state.AWS.Region
+	price := func(product pricing.Product) func() (float64, error) {
+		return func() (float64, error) {
+			return e.awsPricer.OnDemandPrice(region, product)
+		}
+	}
+	instance := func(instanceType string) pricing.Product {
+		return pricing.Product{
+			ServiceCode: "AmazonEC2",
+			Attributes: map[string]string{
+				"instanceType":    instanceType,
+				"operatingSystem": "Linux",
+				"tenancy":         "Shared",
+				"preInstalledSw":  "NA",
+				"capacitystatus":  "Used",
+			},
+			Unit: "Hrs",
+		}
+	}
+	volume := func(volumeType string) pricing.Product {
+		return pricing.Product{
+			ServiceCode: "AmazonEC2",
+			Attributes:  map[string]string{"productFamily": "Storage", "volumeApiName": volumeType},
+			Unit:        "GB-Mo",
+		}
+	}
+
+	items := []costItem{}
+
+	switch {
+	case state.AWS.ExistingVPCID != "":
+		// the existing vpc routes its subnets to the internet itself
+	case state.AWS.NAT == "gateway":
+		zones := len(state.AWS.AZs)
+		if zones == 0 {
+			availabilityZones, err := e.awsAvailabilityZoneRetriever.Retrieve(region)
+			if err != nil {
+				return nil, fmt.Errorf("failed to retrieve the availability zones of region %q: %s", region, err)
+			}
+			zones = len(availabilityZones)
+		}
+
+		items = append(items, costItem{
+			name:     fmt.Sprintf("nat gateways (%d)", zones),
+			quantity: float64(zones * hoursPerMonth),
+			unit:     "hours",
+			price: price(pricing.Product{
+				ServiceCode: "AmazonEC2",
+				Attributes:  map[string]string{"productFamily": "NAT Gateway"},
+				Unit:        "Hrs",
+			}),
+		})
+	default:
+		items = append(items, costItem{
+			name:     fmt.Sprintf("nat vm (%s)", awsNATVMType),
+			quantity: hoursPerMonth,
+			unit:     "hours",
+			price:    price(instance(awsNATVMType)),
+		})
+	}
+
+	if config.jumpbox {
+		items = append(items,
+			costItem{
+				name:     fmt.Sprintf("jumpbox vm (%s)", awsJumpboxVMType),
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price:    price(instance(awsJumpboxVMType)),
+			},
+			costItem{
+				name:     "jumpbox disk (gp2)",
+				quantity: awsJumpboxDisk,
+				unit:     "GB-months",
+				price:    price(volume("gp2")),
+			},
+		)
+	}
+
+	if !config.noDirector {
+		vmType := valueOrDefault(state.BOSH.DirectorVMType, awsDirectorVMType)
+		diskType := valueOrDefault(state.BOSH.DirectorDiskType, "gp2")
+
+		items = append(items,
+			costItem{
+				name:     fmt.Sprintf("director vm (%s)", vmType),
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price:    price(instance(vmType)),
+			},
+			costItem{
+				name:     fmt.Sprintf("director disks (%s)", diskType),
+				quantity: float64(awsDirectorEphemeralDisk + directorDiskSize(state)),
+				unit:     "GB-months",
+				price:    price(volume(diskType)),
+			},
+		)
+	}
+
+	lbs := 0
+	switch config.lbType {
+	case "concourse":
+		lbs = 1
+	case "cf":
+		lbs = 3
+	}
+	if lbs > 0 {
+		productFamily := "Load Balancer"
+		if config.lbType == "cf" && state.LB.Flavor == "nlb" {
+			productFamily = "Load Balancer-Network"
+		}
+
+		items = append(items, costItem{
+			name:     fmt.Sprintf("%s load balancers (%d)", config.lbType, lbs),
+			quantity: float64(lbs * hoursPerMonth),
+			unit:     "hours",
+			price: price(pricing.Product{
+				ServiceCode: "AWSELB",
+				Attributes:  map[string]string{"productFamily": productFamily},
+				Unit:        "Hrs",
+			}),
+		})
+	}
+
+	if state.DirectorDB.Enabled {
+		items = append(items,
+			costItem{
+				name:     fmt.Sprintf("director database (%s, multi-az)", awsDirectorDBClass),
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price: price(pricing.Product{
+					ServiceCode: "AmazonRDS",
+					Attributes: map[string]string{
+						"instanceType":     awsDirectorDBClass,
+						"databaseEngine":   "PostgreSQL",
+						"deploymentOption": "Multi-AZ",
+					},
+					Unit: "Hrs",
+				}),
+			},
+			costItem{
+				name:     "director database storage (gp2, multi-az)",
+				quantity: awsDirectorDBStorage,
+				unit:     "GB-months",
+				price: price(pricing.Product{
+					ServiceCode: "AmazonRDS",
+					Attributes: map[string]string{
+						"productFamily":    "Database Storage",
+						"volumeType":       "General Purpose",
+						"databaseEngine":   "PostgreSQL",
+						"deploymentOption": "Multi-AZ",
+					},
+					Unit: "GB-Mo",
+				}),
+			},
+		)
+	}
+
+	return items, nil
+}
+
+func (e EstimateCost) gcpItems(state storage.State, config estimateCostConfig) []costItem {
+	region := state.GCP.Region
+	price := func(serviceID, description, priceRegion string) func() (float64, error) {
+		return func() (float64, error) {
+			return e.gcpPricer.Price(serviceID, description, priceRegion)
+		}
+	}
+	machine := func(machineType string) func() (float64, error) {
+		return func() (float64, error) {
+			family, cores, memory, err := gcpMachineResources(machineType)
+			if err != nil {
+				return 0, err
+			}
+
+			corePrice, err := e.gcpPricer.Price(gcp.ComputeEngineServiceID, fmt.Sprintf("%s Instance Core", family), region)
+			if err != nil {
+				return 0, err
+			}
+
+			memoryPrice, err := e.gcpPricer.Price(gcp.ComputeEngineServiceID, fmt.Sprintf("%s Instance Ram", family), region)
+			if err != nil {
+				return 0, err
+			}
+
+			return cores*corePrice + memory*memoryPrice, nil
+		}
+	}
+	disk := func(diskType string) func() (float64, error) {
+		description := "Storage PD Capacity"
+		if diskType == "pd-ssd" {
+			description = "SSD backed PD Capacity"
+		}
+
+		return price(gcp.ComputeEngineServiceID, description, region)
+	}
+
+	items := []costItem{}
+
+	if config.jumpbox {
+		items = append(items,
+			costItem{
+				name:     fmt.Sprintf("jumpbox vm (%s)", gcpJumpboxVMType),
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price:    machine(gcpJumpboxVMType),
+			},
+			costItem{
+				name:     "jumpbox disk (pd-standard)",
+				quantity: gcpJumpboxDisk,
+				unit:     "GB-months",
+				price:    disk("pd-standard"),
+			},
+		)
+	}
+
+	if !config.noDirector {
+		vmType := valueOrDefault(state.BOSH.DirectorVMType, gcpDirectorVMType)
+		diskType := valueOrDefault(state.BOSH.DirectorDiskType, "pd-standard")
+
+		items = append(items,
+			costItem{
+				name:     fmt.Sprintf("director vm (%s)", vmType),
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price:    machine(vmType),
+			},
+			costItem{
+				name:     fmt.Sprintf("director disks (%s)", diskType),
+				quantity: float64(gcpDirectorRootDisk + directorDiskSize(state)),
+				unit:     "GB-months",
+				price:    disk(diskType),
+			},
+		)
+	}
+
+	// the minimum charge covers the first five forwarding rules of a region,
+	// the concourse load balancer has two and the cf ones four, next to the
+	// two global rules of the router
+	forwardingRule := "Network Load Balancing: Forwarding Rule Minimum Service Charge"
+	switch config.lbType {
+	case "concourse":
+		items = append(items, costItem{
+			name:     "concourse forwarding rules",
+			quantity: hoursPerMonth,
+			unit:     "hours",
+			price:    price(gcp.ComputeEngineServiceID, forwardingRule, region),
+		})
+	case "cf":
+		items = append(items,
+			costItem{
+				name:     "cf forwarding rules",
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price:    price(gcp.ComputeEngineServiceID, forwardingRule, region),
+			},
+			costItem{
+				name:     "cf router global forwarding rules",
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price:    price(gcp.ComputeEngineServiceID, forwardingRule, "global"),
+			},
+		)
+	}
+
+	if state.DirectorDB.Enabled {
+		items = append(items,
+			costItem{
+				name:     "director database (db-custom-1-3840, regional)",
+				quantity: hoursPerMonth,
+				unit:     "hours",
+				price: func() (float64, error) {
+					corePrice, err := e.gcpPricer.Price(gcp.CloudSQLServiceID, "Cloud SQL for PostgreSQL: Regional - vCPU", region)
+					if err != nil {
+						return 0, err
+					}
+
+					memoryPrice, err := e.gcpPricer.Price(gcp.CloudSQLServiceID, "Cloud SQL for PostgreSQL: Regional - RAM", region)
+					if err != nil {
+						return 0, err
+					}
+
+					return gcpDirectorDBCores*corePrice + gcpDirectorDBMemory*memoryPrice, nil
+				},
+			},
+			costItem{
+				name:     "director database storage (ssd, regional)",
+				quantity: gcpDirectorDBStorage,
+				unit:     "GB-months",
+				price:    price(gcp.CloudSQLServiceID, "Cloud SQL for PostgreSQL: Regional - Standard storage", region),
+			},
+		)
+	}
+
+	return items
+}
+
+// gcpMachineResources returns the catalog family, cores and GB of memory of
+// the predefined n1 and custom machine types.
+func gcpMachineResources(machineType string) (string, float64, float64, error) {
+	parts := strings.Split(machineType, "-")
+
+	switch {
+	case len(parts) == 3 && parts[0] == "n1":
+		cores, err := strconv.Atoi(parts[2])
+		if err != nil {
+			break
+		}
+
+		memoryPerCore := map[string]float64{"standard": 3.75, "highmem": 6.5, "highcpu": 0.9}[parts[1]]
+		if memoryPerCore == 0 {
+			break
+		}
+
+		return "N1 Predefined", float64(cores), float64(cores) * memoryPerCore, nil
+	case len(parts) == 3 && parts[0] == "custom":
+		cores, err := strconv.Atoi(parts[1])
+		if err != nil {
+			break
+		}
+
+		memory, err := strconv.Atoi(parts[2])
+		if err != nil {
+			break
+		}
+
+		return "Custom", float64(cores), float64(memory) / 1024, nil
+	}
+
+	return "", 0, 0, fmt.Errorf("the price of machine type %q cannot be estimated", machineType)
+}
+
+func directorDiskSize(state storage.State) int {
+	if state.BOSH.DirectorDiskSize != 0 {
+		return state.BOSH.DirectorDiskSize
+	}
+
+	return directorPersistentDisk
+}
+
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+func (EstimateCost) parseFlags(subcommandFlags []string) (estimateCostConfig, error) {
+	estimateCostFlags := flags.New(EstimateCostCommand)
+
+	config := estimateCostConfig{}
+	estimateCostFlags.Bool(&config.noDirector, "", "no-director", false)
+	estimateCostFlags.Bool(&config.jumpbox, "", "credhub", false)
+	estimateCostFlags.String(&config.lbType, "lb-type", "")
+
+	err := estimateCostFlags.Parse(subcommandFlags)
+	if err != nil {
+		return estimateCostConfig{}, err
+	}
+
+	if config.lbType != "" && config.lbType != "cf" && config.lbType != "concourse" {
+		return estimateCostConfig{}, fmt.Errorf(`--lb-type must be "cf" or "concourse", got %q`, config.lbType)
+	}
+
+	return config, nil
+}
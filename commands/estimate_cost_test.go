@@ -0,0 +1,222 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/pricing"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EstimateCost", func() {
+	var (
+		command commands.EstimateCost
+
+		credentialValidator          *fakes.CredentialValidator
+		awsAvailabilityZoneRetriever *fakes.AvailabilityZoneRetriever
+		awsPricer                    *fakes.AWSPricer
+		gcpPricer                    *fakes.GCPPricer
+		logger                       *fakes.Logger
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		credentialValidator = &fakes.CredentialValidator{}
+		awsAvailabilityZoneRetriever = &fakes.AvailabilityZoneRetriever{}
+		awsAvailabilityZoneRetriever.RetrieveCall.Returns.AZs = []string{"some-region-1a", "some-region-1b", "some-region-1c"}
+		awsPricer = &fakes.AWSPricer{}
+		awsPricer.OnDemandPriceCall.Returns.Price = 0.1
+		gcpPricer = &fakes.GCPPricer{}
+		gcpPricer.PriceCall.Returns.Price = 0.01
+		logger = &fakes.Logger{}
+
+		state = storage.State{
+			IAAS: "aws",
+			AWS:  storage.AWS{Region: "some-region"},
+			GCP:  storage.GCP{Region: "some-region", Zone: "some-zone"},
+		}
+
+		command = commands.NewEstimateCost(credentialValidator, awsAvailabilityZoneRetriever, awsPricer, gcpPricer, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the credentials", func() {
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(credentialValidator.ValidateCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the credentials are invalid", func() {
+			credentialValidator.ValidateCall.Returns.Error = errors.New("invalid credentials")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("invalid credentials"))
+		})
+
+		It("returns an error when the iaas is not supported", func() {
+			state.IAAS = "openstack"
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError(`bbl estimate-cost only supports aws and gcp, not "openstack"`))
+		})
+
+		It("returns an error when the lb type is not supported", func() {
+			err := command.CheckFastFails([]string{"--lb-type", "other"}, state)
+			Expect(err).To(MatchError(`--lb-type must be "cf" or "concourse", got "other"`))
+		})
+	})
+
+	Describe("Execute", func() {
+		Context("on aws", func() {
+			It("prices the nat and the director", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(awsPricer.OnDemandPriceCall.Receives.Region).To(Equal("some-region"))
+				Expect(awsPricer.OnDemandPriceCall.Receives.Products).To(HaveLen(3))
+				Expect(awsPricer.OnDemandPriceCall.Receives.Products[0].Attributes["instanceType"]).To(Equal("t2.medium"))
+				Expect(awsPricer.OnDemandPriceCall.Receives.Products[1].Attributes["instanceType"]).To(Equal("m4.xlarge"))
+				Expect(awsPricer.OnDemandPriceCall.Receives.Products[2]).To(Equal(pricing.Product{
+					ServiceCode: "AmazonEC2",
+					Attributes:  map[string]string{"productFamily": "Storage", "volumeApiName": "gp2"},
+					Unit:        "GB-Mo",
+				}))
+
+				Expect(logger.PrintfCall.Messages).To(ContainElement("Approximate monthly cost of the aws environment in some-region, at on-demand list prices in USD:\n"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`nat vm (t2.medium)       730 hours     $73.00
+director vm (m4.xlarge)  730 hours     $73.00
+director disks (gp2)     57 GB-months  $5.70
+total                                  $151.70`))
+			})
+
+			It("prices the nat gateways, jumpbox, load balancers and director database", func() {
+				state.AWS.NAT = "gateway"
+				state.BOSH.DirectorVMType = "m4.large"
+				state.BOSH.DirectorDiskSize = 100
+				state.DirectorDB.Enabled = true
+
+				err := command.Execute([]string{"--credhub", "--lb-type", "cf"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(awsAvailabilityZoneRetriever.RetrieveCall.Receives.Region).To(Equal("some-region"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`nat gateways (3)                            2190 hours     $219.00
+jumpbox vm (t2.micro)                       730 hours      $73.00
+jumpbox disk (gp2)                          20 GB-months   $2.00
+director vm (m4.large)                      730 hours      $73.00
+director disks (gp2)                        125 GB-months  $12.50
+cf load balancers (3)                       2190 hours     $219.00
+director database (db.t2.medium, multi-az)  730 hours      $73.00
+director database storage (gp2, multi-az)   20 GB-months   $2.00
+total                                                      $673.50`))
+			})
+
+			It("prices the network load balancers of cf", func() {
+				state.LB = storage.LB{Type: "cf", Flavor: "nlb"}
+
+				err := command.Execute([]string{"--no-director"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(awsPricer.OnDemandPriceCall.Receives.Products).To(HaveLen(2))
+				Expect(awsPricer.OnDemandPriceCall.Receives.Products[1]).To(Equal(pricing.Product{
+					ServiceCode: "AWSELB",
+					Attributes:  map[string]string{"productFamily": "Load Balancer-Network"},
+					Unit:        "Hrs",
+				}))
+			})
+
+			It("does not price a nat in an existing vpc", func() {
+				state.AWS.ExistingVPCID = "vpc-1234"
+
+				err := command.Execute([]string{"--no-director"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(awsPricer.OnDemandPriceCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the availability zones cannot be retrieved", func() {
+				state.AWS.NAT = "gateway"
+				awsAvailabilityZoneRetriever.RetrieveCall.Returns.Error = errors.New("no such host")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(`failed to retrieve the availability zones of region "some-region": no such host`))
+			})
+		})
+
+		Context("on gcp", func() {
+			BeforeEach(func() {
+				state.IAAS = "gcp"
+			})
+
+			It("prices the jumpbox, director, forwarding rules and director database", func() {
+				state.Jumpbox.Enabled = true
+				state.LB.Type = "cf"
+				state.DirectorDB.Enabled = true
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(gcpPricer.PriceCall.Receives.Descriptions).To(Equal([]string{
+					"N1 Predefined Instance Core",
+					"N1 Predefined Instance Ram",
+					"Storage PD Capacity",
+					"N1 Predefined Instance Core",
+					"N1 Predefined Instance Ram",
+					"Storage PD Capacity",
+					"Network Load Balancing: Forwarding Rule Minimum Service Charge",
+					"Network Load Balancing: Forwarding Rule Minimum Service Charge",
+					"Cloud SQL for PostgreSQL: Regional - vCPU",
+					"Cloud SQL for PostgreSQL: Regional - RAM",
+					"Cloud SQL for PostgreSQL: Regional - Standard storage",
+				}))
+				Expect(gcpPricer.PriceCall.Receives.Regions[7]).To(Equal("global"))
+				Expect(gcpPricer.PriceCall.Receives.ServiceIDs[8]).To(Equal(gcp.CloudSQLServiceID))
+
+				Expect(logger.PrintfCall.Messages).To(ContainElement("Approximate monthly cost of the gcp environment in some-region, at on-demand list prices in USD:\n"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`jumpbox vm (n1-standard-1)                      730 hours     $34.67
+jumpbox disk (pd-standard)                      20 GB-months  $0.20
+director vm (n1-standard-1)                     730 hours     $34.67
+director disks (pd-standard)                    72 GB-months  $0.72
+cf forwarding rules                             730 hours     $7.30
+cf router global forwarding rules               730 hours     $7.30
+director database (db-custom-1-3840, regional)  730 hours     $34.67
+director database storage (ssd, regional)       10 GB-months  $0.10
+total                                                         $119.64`))
+			})
+
+			It("prices custom machine types and ssd disks", func() {
+				state.BOSH.DirectorVMType = "custom-2-8192"
+				state.BOSH.DirectorDiskType = "pd-ssd"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(gcpPricer.PriceCall.Receives.Descriptions).To(Equal([]string{
+					"Custom Instance Core",
+					"Custom Instance Ram",
+					"SSD backed PD Capacity",
+				}))
+			})
+
+			It("lists the items without a price and leaves them out of the total", func() {
+				state.BOSH.DirectorVMType = "e2-medium"
+				state.LB.Type = "concourse"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCall.Messages).To(Equal([]string{`director vm (e2-medium) has no price: the price of machine type "e2-medium" cannot be estimated`}))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`director vm (e2-medium)       730 hours     unknown
+director disks (pd-standard)  72 GB-months  $0.72
+concourse forwarding rules    730 hours     $7.30
+total                                       $8.02`))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("The total leaves out the items without a price."))
+			})
+		})
+	})
+})
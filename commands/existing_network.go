@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const directorSubnetCIDRSize = 256
+
+func validateExistingNetwork(networkName, subnetworkName, networkCIDR, subnetCIDR string, state storage.State) error {
+	cidrsProvided := networkCIDR != "" || subnetCIDR != ""
+
+	if networkName == "" && subnetworkName == "" {
+		if cidrsProvided && state.GCP.ExistingNetworkName != "" {
+			return errors.New("--network-cidr and --subnet-cidr cannot be used with an existing network")
+		}
+		return nil
+	}
+
+	if state.IAAS != "gcp" {
+		return errors.New(`--existing-network-name and --existing-subnetwork-name are only supported when iaas="gcp"`)
+	}
+
+	if networkName == "" || subnetworkName == "" {
+		return errors.New("--existing-network-name and --existing-subnetwork-name must be provided together")
+	}
+
+	if cidrsProvided {
+		return errors.New("--network-cidr and --subnet-cidr cannot be used with an existing network")
+	}
+
+	if state.TFState != "" {
+		if networkName != state.GCP.ExistingNetworkName || subnetworkName != state.GCP.ExistingSubnetworkName {
+			return errors.New("--existing-network-name and --existing-subnetwork-name cannot be changed for an existing environment")
+		}
+	}
+
+	return nil
+}
+
+func useExistingNetwork(state storage.State, networkName, subnetworkName string) storage.State {
+	if networkName != "" {
+		state.GCP.ExistingNetworkName = networkName
+		state.GCP.ExistingSubnetworkName = subnetworkName
+	}
+
+	return state
+}
+
+// existingNetworkCIDRs uses the range of the existing subnetwork as the
+// network CIDR, with the director in its first /24.
+func existingNetworkCIDRs(state storage.State, terraformOutputs map[string]interface{}) (storage.State, error) {
+	cidr, ok := terraformOutputs["subnetwork_cidr"].(string)
+	if !ok {
+		return state, errors.New("missing subnetwork_cidr terraform output")
+	}
+
+	network, err := bosh.ParseCIDRBlock(cidr)
+	if err != nil {
+		return state, fmt.Errorf("subnetwork %s has an invalid range: %s", state.GCP.ExistingSubnetworkName, err)
+	}
+
+	newBits := 0
+	for size := network.CIDRSize; size > directorSubnetCIDRSize; size >>= 1 {
+		newBits++
+	}
+
+	directorSubnet, err := network.Subnet(newBits, 0)
+	if err != nil {
+		return state, err
+	}
+
+	state.Network.CIDR = network.String()
+	state.Network.SubnetCIDR = directorSubnet.String()
+
+	return state, nil
+}
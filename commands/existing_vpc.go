@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func parseExistingSubnetIDs(subnetIDs string) []string {
+	ids := []string{}
+	for _, id := range strings.Split(subnetIDs, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func validateExistingVPC(vpcID string, subnetIDs []string, vpcCIDR, subnetCIDR string, state storage.State) error {
+	cidrsProvided := vpcCIDR != "" || subnetCIDR != ""
+
+	if vpcID == "" && len(subnetIDs) == 0 {
+		if cidrsProvided && state.AWS.ExistingVPCID != "" {
+			return errors.New("--vpc-cidr and --subnet-cidr cannot be used with an existing VPC")
+		}
+		return nil
+	}
+
+	if state.IAAS != "aws" {
+		return errors.New(`--existing-vpc-id and --existing-subnet-ids are only supported when iaas="aws"`)
+	}
+
+	if vpcID == "" || len(subnetIDs) == 0 {
+		return errors.New("--existing-vpc-id and --existing-subnet-ids must be provided together")
+	}
+
+	if cidrsProvided {
+		return errors.New("--vpc-cidr and --subnet-cidr cannot be used with an existing VPC")
+	}
+
+	if state.TFState != "" || state.Stack.Name != "" {
+		if vpcID != state.AWS.ExistingVPCID || !reflect.DeepEqual(subnetIDs, state.AWS.ExistingSubnetIDs) {
+			return errors.New("--existing-vpc-id and --existing-subnet-ids cannot be changed for an existing environment")
+		}
+	}
+
+	if lbExists(state.LB.Type) || lbExists(state.Stack.LBType) {
+		return errors.New("--existing-vpc-id cannot be used with load balancers")
+	}
+
+	return nil
+}
+
+// useExistingVPC records the VPC and subnets an environment is deployed into.
+// The first subnet holds the jumpbox and director, every subnet is added to the
+// cloud config.
+func useExistingVPC(state storage.State, vpcID string, subnetIDs []string) storage.State {
+	if vpcID != "" {
+		state.AWS.ExistingVPCID = vpcID
+		state.AWS.ExistingSubnetIDs = subnetIDs
+	}
+
+	return state
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ExportStateCommand = "export-state"
+)
+
+type stateExporter interface {
+	Export(key string) ([]byte, error)
+}
+
+// ExportState writes the state directory to a single encrypted bundle that
+// import-state unpacks, to hand an environment to another operator or to
+// the next stage of a pipeline.
+type ExportState struct {
+	stateExporter  stateExporter
+	stateValidator stateValidator
+	envGetter      envGetter
+	logger         logger
+}
+
+type exportStateConfig struct {
+	to string
+}
+
+func NewExportState(stateExporter stateExporter, stateValidator stateValidator, envGetter envGetter, logger logger) ExportState {
+	return ExportState{
+		stateExporter:  stateExporter,
+		stateValidator: stateValidator,
+		envGetter:      envGetter,
+		logger:         logger,
+	}
+}
+
+func (e ExportState) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := e.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	config, err := e.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.to == "" {
+		return errors.New("--to must be provided")
+	}
+
+	if e.envGetter.Get(storage.StateBundleKeyEnv) == "" {
+		return fmt.Errorf("%s must be set to the key to encrypt the state bundle with", storage.StateBundleKeyEnv)
+	}
+
+	return nil
+}
+
+func (e ExportState) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := e.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := e.stateExporter.Export(e.envGetter.Get(storage.StateBundleKeyEnv))
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(config.to, bundle, os.FileMode(0600))
+	if err != nil {
+		return err
+	}
+
+	e.logger.Step("exported the state to %s", config.to)
+	return nil
+}
+
+func (e ExportState) parseArgs(args []string) (exportStateConfig, error) {
+	var config exportStateConfig
+
+	exportStateFlags := flags.New(ExportStateCommand)
+	exportStateFlags.String(&config.to, "to", "")
+
+	err := exportStateFlags.Parse(args)
+	if err != nil {
+		return exportStateConfig{}, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,94 @@
+package commands_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExportState", func() {
+	var (
+		stateExporter  *fakes.StateExporter
+		stateValidator *fakes.StateValidator
+		envGetter      *fakes.EnvGetter
+		logger         *fakes.Logger
+		tempDir        string
+
+		command commands.ExportState
+	)
+
+	BeforeEach(func() {
+		stateExporter = &fakes.StateExporter{}
+		stateValidator = &fakes.StateValidator{}
+		envGetter = &fakes.EnvGetter{Values: map[string]string{"BBL_STATE_BUNDLE_KEY": "some-key"}}
+		logger = &fakes.Logger{}
+
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		command = commands.NewExportState(stateExporter, stateValidator, envGetter, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{"--to", "some-bundle"}, storage.State{})
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when --to is not provided", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("--to must be provided"))
+		})
+
+		It("returns an error when the key is not set", func() {
+			envGetter.Values = map[string]string{}
+
+			err := command.CheckFastFails([]string{"--to", "some-bundle"}, storage.State{})
+			Expect(err).To(MatchError("BBL_STATE_BUNDLE_KEY must be set to the key to encrypt the state bundle with"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("writes the encrypted bundle to the given path", func() {
+			stateExporter.ExportCall.Returns.Bundle = []byte("some-bundle")
+			path := filepath.Join(tempDir, "some-env.bundle")
+
+			err := command.Execute([]string{"--to", path}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateExporter.ExportCall.Receives.Key).To(Equal("some-key"))
+
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-bundle"))
+
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			Expect(logger.StepCall.Messages).To(Equal([]string{"exported the state to " + path}))
+		})
+
+		It("returns an error when the state cannot be exported", func() {
+			stateExporter.ExportCall.Returns.Error = errors.New("failed to export")
+
+			err := command.Execute([]string{"--to", filepath.Join(tempDir, "some-env.bundle")}, storage.State{})
+			Expect(err).To(MatchError("failed to export"))
+		})
+	})
+})
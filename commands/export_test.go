@@ -1,6 +1,11 @@
 package commands
 
-import yaml "gopkg.in/yaml.v2"
+import (
+	"time"
+
+	"golang.org/x/net/proxy"
+	yaml "gopkg.in/yaml.v2"
+)
 
 func SetMarshal(f func(interface{}) ([]byte, error)) {
 	marshal = f
@@ -17,3 +22,29 @@ func SetUnmarshal(f func([]byte, interface{}) error) {
 func ResetUnmarshal() {
 	unmarshal = yaml.Unmarshal
 }
+
+func SetProxySOCKS5(f func(string, string, *proxy.Auth, proxy.Dialer) (proxy.Dialer, error)) {
+	proxySOCKS5 = f
+}
+
+func ResetProxySOCKS5() {
+	proxySOCKS5 = proxy.SOCKS5
+}
+
+func SetWaitForInterrupt(f func()) {
+	waitForInterrupt = f
+}
+
+func ResetWaitForInterrupt() {
+	waitForInterrupt = waitForSignal
+}
+
+func SetSleep(f func(time.Duration)) {
+	sleep = f
+}
+
+func ResetSleep() {
+	sleep = time.Sleep
+}
+
+var CheckpointUpPhase = checkpointUpPhase
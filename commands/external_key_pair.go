@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// readExternalKeyPair reads the keys of a key pair that is managed outside of
+// bbl. Without --public-key the public key is derived from the private key,
+// as an EC2 key pair is only referred to by its name.
+func readExternalKeyPair(publicKeyPath, privateKeyPath string) (string, string, error) {
+	if publicKeyPath == "" && privateKeyPath == "" {
+		return "", "", nil
+	}
+
+	if privateKeyPath == "" {
+		return "", "", errors.New("--public-key requires --private-key")
+	}
+
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading private-key contents: %v", err)
+	}
+
+	if publicKeyPath == "" {
+		signer, err := ssh.ParsePrivateKey(privateKey)
+		if err != nil {
+			return "", "", fmt.Errorf("private key could not be parsed: %s", err)
+		}
+
+		return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))), string(privateKey), nil
+	}
+
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading public-key contents: %v", err)
+	}
+
+	return strings.TrimSpace(string(publicKey)), string(privateKey), nil
+}
+
+func validateExternalKeyPair(publicKey, privateKey string) error {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return fmt.Errorf("private key could not be parsed: %s", err)
+	}
+
+	parsedPublicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return fmt.Errorf("public key could not be parsed: %s", err)
+	}
+
+	if !bytes.Equal(parsedPublicKey.Marshal(), signer.PublicKey().Marshal()) {
+		return errors.New("public key does not match private key")
+	}
+
+	return nil
+}
+
+func validateSkipKeyPair(skipKeyPair bool, keyPairName, publicKey, privateKey string, state storage.State) error {
+	if !skipKeyPair {
+		return nil
+	}
+
+	if !state.KeyPair.IsEmpty() && !state.KeyPair.External {
+		return errors.New("--skip-keypair cannot be used for an environment whose key pair was created by bbl")
+	}
+
+	if keyPairName != "" && state.IAAS != "aws" {
+		return errors.New(`--keypair-name is only supported when iaas="aws"`)
+	}
+
+	if publicKey == "" {
+		if !state.KeyPair.External {
+			return errors.New("--skip-keypair requires --private-key")
+		}
+	} else {
+		err := validateExternalKeyPair(publicKey, privateKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.IAAS == "aws" && keyPairName == "" && state.KeyPair.Name == "" {
+		return errors.New(`--skip-keypair requires --keypair-name when iaas="aws"`)
+	}
+
+	return nil
+}
+
+// useExternalKeyPair records a key pair that is managed outside of bbl.
+// Values that are not provided are kept from a previous up.
+func useExternalKeyPair(state storage.State, keyPairName, publicKey, privateKey string) storage.State {
+	state.KeyPair.External = true
+
+	if keyPairName != "" {
+		state.KeyPair.Name = keyPairName
+	}
+
+	if publicKey != "" {
+		state.KeyPair.PublicKey = publicKey
+		state.KeyPair.PrivateKey = privateKey
+	}
+
+	return state
+}
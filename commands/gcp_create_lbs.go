@@ -16,14 +16,18 @@ type GCPCreateLBs struct {
 	stateStore                stateStore
 	logger                    logger
 	availabilityZoneRetriever availabilityZoneRetriever
+	certificateProvisioner    lbCertificateProvisioner
 }
 
 type GCPCreateLBsConfig struct {
-	LBType       string
-	CertPath     string
-	KeyPath      string
-	Domain       string
-	SkipIfExists bool
+	LBType           string
+	CertPath         string
+	KeyPath          string
+	Domain           string
+	SkipIfExists     bool
+	EnableIPv6       bool
+	LetsEncrypt      bool
+	LetsEncryptEmail string
 }
 
 type availabilityZoneRetriever interface {
@@ -34,13 +38,15 @@ func NewGCPCreateLBs(terraformManager terraformApplier,
 	cloudConfigManager cloudConfigManager,
 	stateStore stateStore, logger logger,
 	availabilityZoneRetriever availabilityZoneRetriever,
+	certificateProvisioner lbCertificateProvisioner,
 ) GCPCreateLBs {
 	return GCPCreateLBs{
-		terraformManager:   terraformManager,
-		cloudConfigManager: cloudConfigManager,
-		stateStore:         stateStore,
-		logger:             logger,
+		terraformManager:          terraformManager,
+		cloudConfigManager:        cloudConfigManager,
+		stateStore:                stateStore,
+		logger:                    logger,
 		availabilityZoneRetriever: availabilityZoneRetriever,
+		certificateProvisioner:    certificateProvisioner,
 	}
 }
 
@@ -55,7 +61,12 @@ func (c GCPCreateLBs) Execute(config GCPCreateLBsConfig, state storage.State) er
 		return err
 	}
 
-	state.GCP.Zones, err = c.availabilityZoneRetriever.GetZones(state.GCP.Region)
+	zones, err := c.availabilityZoneRetriever.GetZones(state.GCP.Region)
+	if err != nil {
+		return err
+	}
+
+	state.GCP.Zones, err = selectZones(zones, state.GCP.SelectedZones, state.GCP.Region)
 	if err != nil {
 		return err
 	}
@@ -65,12 +76,27 @@ func (c GCPCreateLBs) Execute(config GCPCreateLBsConfig, state storage.State) er
 		return nil
 	}
 
+	if config.LetsEncrypt && state.LB.Type == "cf" && state.LB.Domain == config.Domain {
+		c.logger.Step("lb type \"cf\" exists, requesting a new certificate")
+		return renewLetsEncryptCertificate(c.certificateProvisioner, config.LetsEncryptEmail, state)
+	}
+
 	state.LB.Type = config.LBType
 
 	var cert, key []byte
 	if config.LBType == "cf" {
 		state.LB.Domain = config.Domain
+		state.LB.IPv6 = config.EnableIPv6
+	}
 
+	if config.LetsEncrypt {
+		state.LB.ACME.Email = config.LetsEncryptEmail
+
+		state, err = c.certificateProvisioner.Placeholder(state)
+		if err != nil {
+			return err
+		}
+	} else if config.LBType == "cf" {
 		cert, err = ioutil.ReadFile(config.CertPath)
 		if err != nil {
 			return err
@@ -113,6 +139,13 @@ func (c GCPCreateLBs) Execute(config GCPCreateLBsConfig, state storage.State) er
 		return err
 	}
 
+	if config.LetsEncrypt {
+		state, err = c.certificateProvisioner.Provision(state)
+		if err != nil {
+			return err
+		}
+	}
+
 	if !state.NoDirector {
 		err = c.cloudConfigManager.Update(state)
 		if err != nil {
@@ -132,7 +165,7 @@ func (GCPCreateLBs) checkFastFails(config GCPCreateLBsConfig, state storage.Stat
 		return fmt.Errorf("%q is not a valid lb type, valid lb types are: concourse, cf", config.LBType)
 	}
 
-	if config.LBType == "cf" {
+	if config.LBType == "cf" && !config.LetsEncrypt {
 		errs := multierror.NewMultiError("create-lbs")
 		if err := validateCertOrKeyFlag("cert", config.CertPath); err != nil {
 			errs.Add(err)
@@ -23,6 +23,7 @@ var _ = Describe("GCPCreateLBs", func() {
 		logger                    *fakes.Logger
 		terraformExecutorError    *fakes.TerraformExecutorError
 		availabilityZoneRetriever *fakes.GCPClient
+		certificateProvisioner    *fakes.LBCertificateProvisioner
 
 		bblState    storage.State
 		command     commands.GCPCreateLBs
@@ -39,8 +40,9 @@ var _ = Describe("GCPCreateLBs", func() {
 		logger = &fakes.Logger{}
 		terraformExecutorError = &fakes.TerraformExecutorError{}
 		availabilityZoneRetriever = &fakes.GCPClient{}
+		certificateProvisioner = &fakes.LBCertificateProvisioner{}
 
-		command = commands.NewGCPCreateLBs(terraformManager, cloudConfigManager, stateStore, logger, availabilityZoneRetriever)
+		command = commands.NewGCPCreateLBs(terraformManager, cloudConfigManager, stateStore, logger, availabilityZoneRetriever, certificateProvisioner)
 
 		tempCertFile, err := ioutil.TempFile("", "cert")
 		Expect(err).NotTo(HaveOccurred())
@@ -114,6 +116,94 @@ var _ = Describe("GCPCreateLBs", func() {
 					},
 				}))
 			})
+
+			It("records that ipv6 is enabled", func() {
+				err := command.Execute(commands.GCPCreateLBsConfig{
+					LBType:     "cf",
+					CertPath:   certPath,
+					KeyPath:    keyPath,
+					EnableIPv6: true,
+				}, bblState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.LB.IPv6).To(BeTrue())
+			})
+
+			Context("when a certificate is requested from let's encrypt", func() {
+				var placeholderState, appliedState, provisionedState storage.State
+
+				BeforeEach(func() {
+					placeholderState = bblState
+					placeholderState.GCP.Zones = []string{"z1", "z2", "z3"}
+					placeholderState.LB = storage.LB{
+						Type:   "cf",
+						Domain: "some-domain",
+						Cert:   "some-placeholder-cert",
+						Key:    "some-placeholder-key",
+						ACME:   storage.ACME{Email: "me@example.com"},
+					}
+					certificateProvisioner.PlaceholderCall.Returns.State = placeholderState
+
+					appliedState = placeholderState
+					appliedState.TFState = "some-new-tfstate"
+					terraformManager.ApplyCall.Returns.BBLState = appliedState
+
+					provisionedState = appliedState
+					provisionedState.LB.Cert = "some-trusted-cert"
+					certificateProvisioner.ProvisionCall.Returns.State = provisionedState
+				})
+
+				It("creates the load balancer with a placeholder and then provisions the certificate", func() {
+					err := command.Execute(commands.GCPCreateLBsConfig{
+						LBType:           "cf",
+						Domain:           "some-domain",
+						LetsEncrypt:      true,
+						LetsEncryptEmail: "me@example.com",
+					}, bblState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(certificateProvisioner.PlaceholderCall.Receives.State.LB.Domain).To(Equal("some-domain"))
+					Expect(certificateProvisioner.PlaceholderCall.Receives.State.LB.ACME.Email).To(Equal("me@example.com"))
+					Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(placeholderState))
+					Expect(certificateProvisioner.ProvisionCall.Receives.State).To(Equal(appliedState))
+					Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(provisionedState))
+				})
+
+				Context("when the cf lb already exists for the domain", func() {
+					It("only requests a new certificate", func() {
+						bblState.LB = storage.LB{
+							Type:   "cf",
+							Domain: "some-domain",
+							Cert:   "some-old-cert",
+							Key:    "some-old-key",
+							ACME:   storage.ACME{Email: "me@example.com"},
+						}
+
+						err := command.Execute(commands.GCPCreateLBsConfig{
+							LBType:      "cf",
+							Domain:      "some-domain",
+							LetsEncrypt: true,
+						}, bblState)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(certificateProvisioner.ProvisionCall.Receives.State.LB).To(Equal(bblState.LB))
+						Expect(certificateProvisioner.PlaceholderCall.CallCount).To(Equal(0))
+						Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+					})
+				})
+
+				It("returns an error when the certificate cannot be provisioned", func() {
+					certificateProvisioner.ProvisionCall.Returns.Error = errors.New("failed to provision")
+
+					err := command.Execute(commands.GCPCreateLBsConfig{
+						LBType:      "cf",
+						Domain:      "some-domain",
+						LetsEncrypt: true,
+					}, bblState)
+					Expect(err).To(MatchError("failed to provision"))
+					Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				})
+			})
 		})
 
 		Context("when lb type is concourse", func() {
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+var (
+	gcpFirewallRuleNameRegexp = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+	gcpFirewallRulePortRegexp = regexp.MustCompile(`^[0-9]+(-[0-9]+)?$`)
+)
+
+// useGCPFirewallRules replaces the firewall rules of an earlier up with rules
+// when any are given, and removes them with clear. Otherwise the rules in the
+// state are kept.
+func useGCPFirewallRules(state storage.State, rules []storage.GCPFirewallRule, clear bool) storage.State {
+	switch {
+	case clear:
+		state.GCP.FirewallRules = nil
+	case len(rules) > 0:
+		state.GCP.FirewallRules = rules
+	}
+
+	return state
+}
+
+func parseGCPFirewallRules(rules []string) ([]storage.GCPFirewallRule, error) {
+	var firewallRules []storage.GCPFirewallRule
+	names := map[string]bool{}
+
+	for _, rule := range rules {
+		firewallRule, err := parseGCPFirewallRule(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		if names[firewallRule.Name] {
+			return nil, fmt.Errorf("Invalid firewall rule %q: name %q is used more than once.", rule, firewallRule.Name)
+		}
+		names[firewallRule.Name] = true
+
+		firewallRules = append(firewallRules, firewallRule)
+	}
+
+	return firewallRules, nil
+}
+
+func parseGCPFirewallRule(rule string) (storage.GCPFirewallRule, error) {
+	parts := strings.Split(rule, ":")
+	if len(parts) != 4 {
+		return storage.GCPFirewallRule{}, fmt.Errorf("Invalid firewall rule %q: expected format name:proto:ports:source-range.", rule)
+	}
+
+	name, protocol, ports, sourceRange := parts[0], parts[1], parts[2], parts[3]
+
+	if !gcpFirewallRuleNameRegexp.MatchString(name) {
+		return storage.GCPFirewallRule{}, fmt.Errorf("Invalid firewall rule %q: name must consist of lowercase letters, digits and dashes, starting with a letter.", rule)
+	}
+
+	switch protocol {
+	case "tcp", "udp":
+		if ports == "" {
+			return storage.GCPFirewallRule{}, fmt.Errorf("Invalid firewall rule %q: ports are required for protocol %q.", rule, protocol)
+		}
+	case "icmp":
+		if ports != "" {
+			return storage.GCPFirewallRule{}, fmt.Errorf("Invalid firewall rule %q: ports are not supported for protocol \"icmp\".", rule)
+		}
+	default:
+		return storage.GCPFirewallRule{}, fmt.Errorf("Invalid firewall rule %q: protocol must be one of \"tcp\", \"udp\" or \"icmp\".", rule)
+	}
+
+	var portList []string
+	if ports != "" {
+		portList = strings.Split(ports, ",")
+		for _, port := range portList {
+			if !gcpFirewallRulePortRegexp.MatchString(port) {
+				return storage.GCPFirewallRule{}, fmt.Errorf("Invalid firewall rule %q: %q is not a valid port or port range.", rule, port)
+			}
+		}
+	}
+
+	if _, _, err := net.ParseCIDR(sourceRange); err != nil {
+		return storage.GCPFirewallRule{}, fmt.Errorf("Invalid firewall rule %q: %q is not a valid CIDR source range.", rule, sourceRange)
+	}
+
+	return storage.GCPFirewallRule{
+		Name:        name,
+		Protocol:    protocol,
+		Ports:       portList,
+		SourceRange: sourceRange,
+	}, nil
+}
@@ -28,15 +28,19 @@ func (l GCPLBs) Execute(subcommandFlags []string, state storage.State) error {
 
 	switch state.LB.Type {
 	case "cf":
+		routerLBIPv6, _ := terraformOutputs["router_lb_ipv6"].(string)
+
 		if len(subcommandFlags) > 0 && subcommandFlags[0] == "--json" {
 			lbOutput, err := json.Marshal(struct {
 				RouterLBIP             string   `json:"cf_router_lb,omitempty"`
+				RouterLBIPv6           string   `json:"cf_router_lb_ipv6,omitempty"`
 				SSHProxyLBIP           string   `json:"cf_ssh_proxy_lb,omitempty"`
 				TCPRouterLBIP          string   `json:"cf_tcp_router_lb,omitempty"`
 				WebSocketLBIP          string   `json:"cf_websocket_lb,omitempty"`
 				SystemDomainDNSServers []string `json:"cf_system_domain_dns_servers,omitempty"`
 			}{
 				RouterLBIP:             terraformOutputs["router_lb_ip"].(string),
+				RouterLBIPv6:           routerLBIPv6,
 				SSHProxyLBIP:           terraformOutputs["ssh_proxy_lb_ip"].(string),
 				TCPRouterLBIP:          terraformOutputs["tcp_router_lb_ip"].(string),
 				WebSocketLBIP:          terraformOutputs["ws_lb_ip"].(string),
@@ -50,6 +54,9 @@ func (l GCPLBs) Execute(subcommandFlags []string, state storage.State) error {
 			l.logger.Println(string(lbOutput))
 		} else {
 			l.logger.Printf("CF Router LB: %s\n", terraformOutputs["router_lb_ip"])
+			if routerLBIPv6 != "" {
+				l.logger.Printf("CF Router LB (IPv6): %s\n", routerLBIPv6)
+			}
 			l.logger.Printf("CF SSH Proxy LB: %s\n", terraformOutputs["ssh_proxy_lb_ip"])
 			l.logger.Printf("CF TCP Router LB: %s\n", terraformOutputs["tcp_router_lb_ip"])
 			l.logger.Printf("CF WebSocket LB: %s\n", terraformOutputs["ws_lb_ip"])
@@ -59,6 +66,10 @@ func (l GCPLBs) Execute(subcommandFlags []string, state storage.State) error {
 			}
 		}
 	case "concourse":
+		if len(subcommandFlags) > 0 && subcommandFlags[0] == "--json" {
+			return printJSON(l.logger, map[string]interface{}{"concourse_lb": terraformOutputs["concourse_lb_ip"]})
+		}
+
 		l.logger.Printf("Concourse LB: %s\n", terraformOutputs["concourse_lb_ip"])
 	default:
 		return errors.New("no lbs found")
@@ -53,6 +53,37 @@ var _ = Describe("GCPLBs", func() {
 			}))
 		})
 
+		Context("when ipv6 is enabled", func() {
+			BeforeEach(func() {
+				terraformManager.GetOutputsCall.Returns.Outputs["router_lb_ipv6"] = "some-router-lb-ipv6"
+				terraformManager.GetOutputsCall.Returns.Outputs["system_domain_dns_servers"] = []string{}
+				incomingState.LB = storage.LB{
+					Type: "cf",
+					IPv6: true,
+				}
+			})
+
+			It("prints the ipv6 address of the router lb", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintfCall.Messages).To(ContainElement("CF Router LB (IPv6): some-router-lb-ipv6\n"))
+			})
+
+			It("includes the ipv6 address in json format", func() {
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+					"cf_router_lb": "some-router-lb-ip",
+					"cf_router_lb_ipv6": "some-router-lb-ipv6",
+					"cf_ssh_proxy_lb": "some-ssh-proxy-lb-ip",
+					"cf_tcp_router_lb": "some-tcp-router-lb-ip",
+					"cf_websocket_lb": "some-ws-lb-ip"
+				}`))
+			})
+		})
+
 		Context("when the domain is specified", func() {
 			BeforeEach(func() {
 				terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
@@ -119,6 +150,17 @@ var _ = Describe("GCPLBs", func() {
 			}))
 		})
 
+		It("prints LB ips for lb type concourse in json format", func() {
+			incomingState.LB = storage.LB{
+				Type: "concourse",
+			}
+			err := command.Execute([]string{"--json"}, incomingState)
+
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"concourse_lb": "some-concourse-lb-ip"}`))
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when terraform output provider fails", func() {
 				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to return terraform output")
@@ -3,7 +3,6 @@ package commands
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	yaml "gopkg.in/yaml.v2"
@@ -26,20 +25,49 @@ type GCPUp struct {
 	boshManager                  boshManager
 	cloudConfigManager           cloudConfigManager
 	logger                       logger
-	terraformManager             terraformApplier
+	terraformManager             terraformUpApplier
 	envIDManager                 envIDManager
 	gcpAvailabilityZoneRetriever gcpAvailabilityZoneRetriever
+	upDetacher                   upDetacher
+	stemcellUploader             stemcellUploader
 }
 
 type GCPUpConfig struct {
-	ServiceAccountKey string
-	ProjectID         string
-	Zone              string
-	Region            string
-	OpsFilePath       string
-	Name              string
-	NoDirector        bool
-	Jumpbox           bool
+	ServiceAccountKey  string
+	ProjectID          string
+	Zone               string
+	Region             string
+	OpsFilePaths       []string
+	Name               string
+	NoDirector         bool
+	Jumpbox            bool
+	Detach             bool
+	FirewallRules      []storage.GCPFirewallRule
+	ClearFirewallRules bool
+	NetworkCIDR        string
+	SubnetCIDR         string
+	DirectorCACert     string
+	DirectorCAKey      string
+	DirectorSpot       bool
+	DirectorDiskType   string
+	DirectorVMType     string
+	DirectorDiskSize   int
+	DirectorExternalDB bool
+	Targets            []string
+	SSHKeyType         string
+	SSHKeyBits         int
+	SkipKeyPair        bool
+	PublicKey          string
+	PrivateKey         string
+	UploadStemcell     string
+	SSHPort            int
+	NoPublicIPs        bool
+	Tags               []storage.Tag
+
+	ExistingNetworkName    string
+	ExistingSubnetworkName string
+	Zones                  []string
+	FromPhase              string
 }
 
 type gcpKeyPairCreator interface {
@@ -58,9 +86,11 @@ type terraformManagerError interface {
 type boshManager interface {
 	CreateDirector(bblState storage.State, terraformOutputs map[string]interface{}) (storage.State, error)
 	CreateJumpbox(bblState storage.State, terraformOutputs map[string]interface{}) (storage.State, error)
+	RecreateJumpbox(bblState storage.State, terraformOutputs map[string]interface{}) (storage.State, error)
 	Delete(bblState storage.State, terraformOutputs map[string]interface{}) error
 	DeleteJumpbox(bblState storage.State, terraformOutputs map[string]interface{}) error
 	GetDeploymentVars(bblState storage.State, terraformOutputs map[string]interface{}) (string, error)
+	DirectorManifest(bblState storage.State, terraformOutputs map[string]interface{}) (string, error)
 	Version() (string, error)
 }
 
@@ -75,12 +105,14 @@ type gcpAvailabilityZoneRetriever interface {
 type NewGCPUpArgs struct {
 	StateStore                   stateStore
 	KeyPairManager               keyPairManager
-	TerraformManager             terraformApplier
+	TerraformManager             terraformUpApplier
 	BoshManager                  boshManager
 	Logger                       logger
 	EnvIDManager                 envIDManager
 	CloudConfigManager           cloudConfigManager
 	GCPAvailabilityZoneRetriever gcpAvailabilityZoneRetriever
+	UpDetacher                   upDetacher
+	StemcellUploader             stemcellUploader
 }
 
 func NewGCPUp(args NewGCPUpArgs) GCPUp {
@@ -93,23 +125,31 @@ func NewGCPUp(args NewGCPUpArgs) GCPUp {
 		logger:                       args.Logger,
 		envIDManager:                 args.EnvIDManager,
 		gcpAvailabilityZoneRetriever: args.GCPAvailabilityZoneRetriever,
+		upDetacher:                   args.UpDetacher,
+		stemcellUploader:             args.StemcellUploader,
 	}
 }
 
 func (u GCPUp) Execute(upConfig GCPUpConfig, state storage.State) error {
 	state.Jumpbox.Enabled = upConfig.Jumpbox
+	if upConfig.SSHPort != 0 {
+		state.SSHPort = upConfig.SSHPort
+	}
+	state = useGCPFirewallRules(state, upConfig.FirewallRules, upConfig.ClearFirewallRules)
+	state = updateNetworkCIDRs(state, upConfig.NetworkCIDR, upConfig.SubnetCIDR, u.logger)
+	state = useExistingNetwork(state, upConfig.ExistingNetworkName, upConfig.ExistingSubnetworkName)
+	state = useNoPublicIPs(state, upConfig.NoPublicIPs)
+	state = useTags(state, upConfig.Tags)
+	state = useZones(state, nil, upConfig.Zones)
 
 	err := u.terraformManager.ValidateVersion()
 	if err != nil {
 		return err
 	}
 
-	var opsFileContents []byte
-	if upConfig.OpsFilePath != "" {
-		opsFileContents, err = ioutil.ReadFile(upConfig.OpsFilePath)
-		if err != nil {
-			return fmt.Errorf("error reading ops-file contents: %v", err)
-		}
+	opsFiles, err := readOpsFiles(upConfig.OpsFilePaths)
+	if err != nil {
+		return fmt.Errorf("error reading ops-file contents: %v", err)
 	}
 
 	if upConfig.NoDirector {
@@ -129,67 +169,115 @@ func (u GCPUp) Execute(upConfig GCPUpConfig, state storage.State) error {
 		return err
 	}
 
-	if err := u.stateStore.Set(state); err != nil {
+	state, err = updateDirectorDB(state, upConfig.DirectorExternalDB)
+	if err != nil {
 		return err
 	}
 
-	state, err = u.keyPairManager.Sync(state)
-	if err != nil {
+	if err := u.stateStore.Set(state); err != nil {
 		return err
 	}
 
+	state = updateSSHKeyType(state, upConfig.SSHKeyType, upConfig.SSHKeyBits, u.logger)
+	if upConfig.SkipKeyPair {
+		state = useExternalKeyPair(state, "", upConfig.PublicKey, upConfig.PrivateKey)
+	}
+
+	if !skipUpPhase(state, UpPhaseKeyPair, upConfig.FromPhase, u.logger) {
+		state, err = u.keyPairManager.Sync(state)
+		if err != nil {
+			return err
+		}
+		state = checkpointUpPhase(state, UpPhaseKeyPair)
+	}
+
 	if err := u.stateStore.Set(state); err != nil {
 		return err
 	}
 
-	state.GCP.Zones, err = u.gcpAvailabilityZoneRetriever.GetZones(state.GCP.Region)
+	zones, err := u.gcpAvailabilityZoneRetriever.GetZones(state.GCP.Region)
 	if err != nil {
 		return err
 	}
 
-	err = u.stateStore.Set(state)
+	zones, err = selectZones(zones, state.GCP.SelectedZones, state.GCP.Region)
 	if err != nil {
 		return err
 	}
 
-	state, err = u.terraformManager.Apply(state)
-	if err != nil {
-		return handleTerraformError(err, u.stateStore)
-	}
+	warnOnZoneChange(state.GCP.Zones, zones, u.logger)
+	state.GCP.Zones = zones
 
 	err = u.stateStore.Set(state)
 	if err != nil {
 		return err
 	}
 
+	if !skipUpPhase(state, UpPhaseTerraform, upConfig.FromPhase, u.logger) {
+		state, err = applyTerraform(u.terraformManager, state, upConfig.Targets, u.logger)
+		if err != nil {
+			return handleTerraformError(err, u.stateStore)
+		}
+
+		if len(upConfig.Targets) == 0 {
+			state = checkpointUpPhase(state, UpPhaseTerraform)
+		}
+
+		err = u.stateStore.Set(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if upConfig.Detach && !state.NoDirector {
+		return detachUp(state, u.upDetacher, u.stateStore, u.logger)
+	}
+
 	terraformOutputs, err := u.terraformManager.GetOutputs(state)
 	if err != nil {
 		return err
 	}
 
 	if !state.NoDirector {
-		state.BOSH.UserOpsFile = string(opsFileContents)
-
-		if upConfig.Jumpbox {
-			state, err = u.boshManager.CreateJumpbox(state, terraformOutputs)
+		if state.GCP.ExistingNetworkName != "" {
+			state, err = existingNetworkCIDRs(state, terraformOutputs)
 			if err != nil {
 				return err
 			}
 		}
 
-		state, err = u.boshManager.CreateDirector(state, terraformOutputs)
-		switch err.(type) {
-		case bosh.ManagerCreateError:
-			bcErr := err.(bosh.ManagerCreateError)
-			if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
-				errorList := helpers.Errors{}
-				errorList.Add(err)
-				errorList.Add(setErr)
-				return errorList
+		state = updateUserOpsFiles(state, opsFiles)
+		state = updateDirectorSpot(state, upConfig.DirectorSpot, "", u.logger)
+		state = updateDirectorDiskType(state, upConfig.DirectorDiskType, u.logger)
+		state = updateDirectorVMSize(state, upConfig.DirectorVMType, upConfig.DirectorDiskSize, u.logger)
+		if upConfig.DirectorCACert != "" {
+			state.BOSH.UserCACertificate = upConfig.DirectorCACert
+			state.BOSH.UserCAPrivateKey = upConfig.DirectorCAKey
+		}
+
+		if !skipUpPhase(state, UpPhaseBOSH, upConfig.FromPhase, u.logger) {
+			if upConfig.Jumpbox {
+				state, err = u.boshManager.CreateJumpbox(state, terraformOutputs)
+				if err != nil {
+					return err
+				}
 			}
-			return err
-		case error:
-			return err
+
+			state, err = u.boshManager.CreateDirector(state, terraformOutputs)
+			switch err.(type) {
+			case bosh.ManagerCreateError:
+				bcErr := err.(bosh.ManagerCreateError)
+				if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
+					errorList := helpers.Errors{}
+					errorList.Add(err)
+					errorList.Add(setErr)
+					return errorList
+				}
+				return err
+			case error:
+				return err
+			}
+			state = checkpointUpPhase(state, UpPhaseBOSH)
 		}
 
 		err = u.stateStore.Set(state)
@@ -197,19 +285,37 @@ func (u GCPUp) Execute(upConfig GCPUpConfig, state storage.State) error {
 			return err
 		}
 
-		err := u.cloudConfigManager.Update(state)
-		if err != nil {
-			return err
+		if !skipUpPhase(state, UpPhaseCloudConfig, upConfig.FromPhase, u.logger) {
+			err = u.cloudConfigManager.Update(state)
+			if err != nil {
+				return err
+			}
+			state = checkpointUpPhase(state, UpPhaseCloudConfig)
+
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return err
+			}
+		}
+
+		if upConfig.UploadStemcell != "" {
+			state, err = u.stemcellUploader.Upload(state, upConfig.UploadStemcell)
+			if err != nil {
+				return err
+			}
+
+			err = u.stateStore.Set(state)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	return nil
+	return finishUp(state, u.stateStore)
 }
 
 func (u GCPUp) validateState(state storage.State) error {
 	switch {
-	case state.GCP.ServiceAccountKey == "":
-		return errors.New("GCP service account key must be provided")
 	case state.GCP.ProjectID == "":
 		return errors.New("GCP project ID must be provided")
 	case state.GCP.Region == "":
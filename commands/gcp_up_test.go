@@ -36,6 +36,8 @@ var _ = Describe("GCPUp", func() {
 		logger                *fakes.Logger
 		terraformManagerError *fakes.TerraformManagerError
 		gcpZones              *fakes.GCPClient
+		upDetacher            *fakes.UpDetacher
+		stemcellUploader      *fakes.StemcellUploader
 
 		serviceAccountKeyPath string
 		serviceAccountKey     string
@@ -62,6 +64,10 @@ var _ = Describe("GCPUp", func() {
 		cloudConfigManager = &fakes.CloudConfigManager{}
 		terraformManagerError = &fakes.TerraformManagerError{}
 		gcpZones = &fakes.GCPClient{}
+		upDetacher = &fakes.UpDetacher{}
+		stemcellUploader = &fakes.StemcellUploader{}
+		upDetacher.LogPathCall.Returns.Path = "some-state-dir/bbl-up.log"
+		upDetacher.DetachCall.Returns.PID = 1234
 
 		tempFile, err := ioutil.TempFile("", "gcpServiceAccountKey")
 		Expect(err).NotTo(HaveOccurred())
@@ -89,12 +95,14 @@ var _ = Describe("GCPUp", func() {
 			PrivateKey: "some-private-key",
 			PublicKey:  "some-public-key",
 		}
+		expectedKeyPairState = commands.CheckpointUpPhase(expectedKeyPairState, commands.UpPhaseKeyPair)
 
 		expectedZonesState = expectedKeyPairState
 		expectedZonesState.GCP.Zones = []string{"some-zone", "some-other-zone"}
 
 		expectedTerraformState = expectedZonesState
 		expectedTerraformState.TFState = "some-tf-state"
+		expectedTerraformState = commands.CheckpointUpPhase(expectedTerraformState, commands.UpPhaseTerraform)
 
 		expectedBOSHState = expectedTerraformState
 		expectedBOSHState.BOSH = storage.BOSH{
@@ -111,6 +119,7 @@ var _ = Describe("GCPUp", func() {
 			Variables: variablesYAML,
 			Manifest:  "some-bosh-manifest",
 		}
+		expectedBOSHState = commands.CheckpointUpPhase(expectedBOSHState, commands.UpPhaseBOSH)
 
 		expectedAvailabilityZones = []string{"some-zone", "some-other-zone"}
 
@@ -133,6 +142,8 @@ var _ = Describe("GCPUp", func() {
 			EnvIDManager:                 envIDManager,
 			CloudConfigManager:           cloudConfigManager,
 			GCPAvailabilityZoneRetriever: gcpZones,
+			UpDetacher:                   upDetacher,
+			StemcellUploader:             stemcellUploader,
 		})
 
 		body, err := ioutil.ReadFile("fixtures/terraform_template_no_lb.tf")
@@ -228,6 +239,133 @@ var _ = Describe("GCPUp", func() {
 				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
 				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(expectedBOSHState))
 			})
+
+			By("not uploading a stemcell", func() {
+				Expect(stemcellUploader.UploadCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when a stemcell is provided", func() {
+			It("uploads the stemcell after updating the cloud config and saves the stemcell to the state", func() {
+				expectedStemcellState := expectedBOSHState
+				expectedStemcellState.Stemcell = storage.Stemcell{Name: "some-stemcell", Version: "3421.11", Source: "some-stemcell.tgz"}
+				stemcellUploader.UploadCall.Returns.State = expectedStemcellState
+
+				err := gcpUp.Execute(commands.GCPUpConfig{UploadStemcell: "some-stemcell.tgz"}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stemcellUploader.UploadCall.CallCount).To(Equal(1))
+				Expect(stemcellUploader.UploadCall.Receives.Source).To(Equal("some-stemcell.tgz"))
+				Expect(stemcellUploader.UploadCall.Receives.State).To(Equal(commands.CheckpointUpPhase(expectedBOSHState, commands.UpPhaseCloudConfig)))
+
+				expectedStemcellState.UpCheckpoints = nil
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State).To(Equal(expectedStemcellState))
+			})
+
+			It("returns an error when the stemcell cannot be uploaded", func() {
+				stemcellUploader.UploadCall.Returns.Error = errors.New("failed to upload stemcell")
+
+				err := gcpUp.Execute(commands.GCPUpConfig{UploadStemcell: "some-stemcell.tgz"}, expectedIAASState)
+				Expect(err).To(MatchError("failed to upload stemcell"))
+			})
+		})
+
+		Context("when targets are provided", func() {
+			It("applies only the targeted terraform resources and warns about divergence", func() {
+				terraformManager.ApplyTargetsCall.Returns.BBLState = expectedTerraformState
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					Targets: []string{"google_compute_firewall.bosh-open", "google_compute_subnetwork.bbl-subnet"},
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(terraformManager.ApplyTargetsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.ApplyTargetsCall.Receives.Targets).To(Equal([]string{"google_compute_firewall.bosh-open", "google_compute_subnetwork.bbl-subnet"}))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(logger.WarnCall.Messages).To(ContainElement("applying only the targeted terraform resources can leave the infrastructure diverged from the full terraform plan, run bbl up without --target to reconcile it."))
+			})
+		})
+
+		Context("when the detach flag is provided", func() {
+			It("applies terraform, records the up progress, and continues in the background", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{Detach: true}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(upDetacher.DetachCall.CallCount).To(Equal(1))
+
+				detachedState := expectedTerraformState
+				detachedState.UpProgress = storage.UpProgress{
+					PID:     1234,
+					Phase:   "terraform",
+					LogFile: "some-state-dir/bbl-up.log",
+				}
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State).To(Equal(detachedState))
+
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("bbl up is continuing in the background (task id: 1234), output is being written to some-state-dir/bbl-up.log\n"))
+			})
+
+			It("returns an error when the state fails to be set before detaching", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{}, {}, {}, {}, {Error: errors.New("failed to set state")}}
+
+				err := gcpUp.Execute(commands.GCPUpConfig{Detach: true}, expectedIAASState)
+				Expect(err).To(MatchError("failed to set state"))
+				Expect(upDetacher.DetachCall.CallCount).To(Equal(0))
+			})
+
+			It("clears the up progress when the up cannot be detached", func() {
+				upDetacher.DetachCall.Returns.Error = errors.New("failed to detach")
+
+				err := gcpUp.Execute(commands.GCPUpConfig{Detach: true}, expectedIAASState)
+				Expect(err).To(MatchError("failed to detach"))
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State.UpProgress).To(Equal(storage.UpProgress{}))
+			})
+		})
+
+		Context("when resuming a detached up", func() {
+			var resumedState storage.State
+
+			BeforeEach(func() {
+				resumedState = expectedTerraformState
+				resumedState.UpProgress = storage.UpProgress{PID: 1234, Phase: "terraform"}
+			})
+
+			It("does not re-apply terraform and clears the up progress once every phase completed", func() {
+				boshManager.CreateDirectorCall.Returns.State = resumedState
+
+				err := gcpUp.Execute(commands.GCPUpConfig{}, resumedState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(logger.StepCall.Messages).To(ContainElement("skipping terraform, it has already converged"))
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State.UpProgress).To(Equal(storage.UpProgress{}))
+			})
+
+			It("records each phase as it completes", func() {
+				boshManager.CreateDirectorCall.Returns.State = resumedState
+				cloudConfigManager.UpdateCall.Returns.Error = errors.New("failed to update cloud config")
+
+				err := gcpUp.Execute(commands.GCPUpConfig{}, resumedState)
+				Expect(err).To(MatchError("failed to update cloud config"))
+
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State.UpProgress).To(Equal(storage.UpProgress{
+					PID:   1234,
+					Phase: "bosh",
+				}))
+			})
+
+			It("re-applies terraform when the configuration changed since the up was detached", func() {
+				resumedState.GCP.Region = "some-other-region"
+
+				err := gcpUp.Execute(commands.GCPUpConfig{}, resumedState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+			})
 		})
 
 		Context("when a name is passed in for env-id", func() {
@@ -260,7 +398,46 @@ var _ = Describe("GCPUp", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				err = gcpUp.Execute(commands.GCPUpConfig{
-					OpsFilePath: opsFilePath,
+					OpsFilePaths: []string{opsFilePath, opsFilePath},
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserOpsFiles).To(Equal([]string{"some-ops-file-contents", "some-ops-file-contents"}))
+			})
+		})
+
+		Context("when an external director database is requested", func() {
+			It("saves the database and its password before applying terraform", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorExternalDB: true,
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				directorDB := terraformManager.ApplyCall.Receives.BBLState.DirectorDB
+				Expect(directorDB.Enabled).To(BeTrue())
+				Expect(directorDB.Password).To(HaveLen(32))
+			})
+		})
+
+		Context("when a director ca is passed in", func() {
+			It("passes the ca certificate and key to the bosh manager", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorCACert: "some-ca-certificate",
+					DirectorCAKey:  "some-ca-private-key",
 				}, storage.State{
 					GCP: storage.GCP{
 						ServiceAccountKey: serviceAccountKeyPath,
@@ -271,7 +448,218 @@ var _ = Describe("GCPUp", func() {
 				})
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserOpsFile).To(Equal("some-ops-file-contents"))
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserCACertificate).To(Equal("some-ca-certificate"))
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserCAPrivateKey).To(Equal("some-ca-private-key"))
+			})
+		})
+
+		Context("when an ssh key type is passed in", func() {
+			It("records the key type on the keypair before syncing it", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					SSHKeyType: "ed25519",
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairManager.SyncCall.Receives.State.KeyPair.Type).To(Equal("ed25519"))
+			})
+		})
+
+		Context("when the keypair is skipped", func() {
+			It("records the provided keypair as managed outside of bbl", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					SkipKeyPair: true,
+					PublicKey:   "some-public-key",
+					PrivateKey:  "some-private-key",
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairManager.SyncCall.Receives.State.KeyPair).To(Equal(storage.KeyPair{
+					PublicKey:  "some-public-key",
+					PrivateKey: "some-private-key",
+					External:   true,
+				}))
+			})
+		})
+
+		Context("when the availability zones for the region have changed", func() {
+			It("warns that existing deployments may need to be redeployed", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+						Zones:             []string{"some-old-zone"},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCall.Messages).To(ContainElement("availability zones changed from [some-old-zone] to [some-zone, some-other-zone], moving AZs may require redeploying existing deployments."))
+			})
+		})
+
+		Context("when zones are selected", func() {
+			It("limits the zones of the region to the selected ones", func() {
+				gcpZones.GetZonesCall.Returns.Zones = []string{"some-zone", "some-other-zone", "some-third-zone"}
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					Zones: []string{"some-third-zone", "some-zone"},
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.Receives.BBLState.GCP.Zones).To(Equal([]string{"some-zone", "some-third-zone"}))
+				Expect(terraformManager.ApplyCall.Receives.BBLState.GCP.SelectedZones).To(Equal([]string{"some-third-zone", "some-zone"}))
+			})
+
+			It("returns an error when a selected zone is not in the region", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+						SelectedZones:     []string{"some-missing-zone"},
+					},
+				})
+				Expect(err).To(MatchError(`zone "some-missing-zone" is not in region some-region, choose from: some-zone, some-other-zone`))
+			})
+		})
+
+		Context("when the director spot flag is passed in", func() {
+			It("marks the director as preemptible and warns about preemption", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorSpot: true,
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorSpot).To(BeTrue())
+				Expect(logger.WarnCall.Messages).To(ContainElement("the director will run on preemptible/spot capacity and can be terminated by the IAAS at any time. Only use this for disposable environments."))
+			})
+		})
+
+		Context("when the director disk type is passed in", func() {
+			It("stores the disk type", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorDiskType: "pd-ssd",
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorDiskType).To(Equal("pd-ssd"))
+			})
+
+			It("warns that the disks will be recreated when the director already exists", func() {
+				terraformManager.ApplyCall.Returns.BBLState.IAAS = "gcp"
+				terraformManager.ApplyCall.Returns.BBLState.BOSH.DirectorAddress = "some-director-address"
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorDiskType: "pd-ssd",
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCall.Messages).To(ContainElement("changing the director disk type from pd-standard to pd-ssd will recreate the director's disks."))
+			})
+
+			It("does not warn when the disk type is unchanged", func() {
+				terraformManager.ApplyCall.Returns.BBLState.IAAS = "gcp"
+				terraformManager.ApplyCall.Returns.BBLState.BOSH.DirectorAddress = "some-director-address"
+				terraformManager.ApplyCall.Returns.BBLState.BOSH.DirectorDiskType = "pd-ssd"
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorDiskType: "pd-ssd",
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCall.Messages).NotTo(ContainElement(ContainSubstring("director disk type")))
+			})
+		})
+
+		Context("when the director vm type and disk size are passed in", func() {
+			It("stores the vm type and disk size", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorVMType:   "n1-standard-8",
+					DirectorDiskSize: 128,
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorVMType).To(Equal("n1-standard-8"))
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorDiskSize).To(Equal(128))
+			})
+
+			It("warns that the vm is recreated and the disk migrated when the director already exists", func() {
+				terraformManager.ApplyCall.Returns.BBLState.IAAS = "gcp"
+				terraformManager.ApplyCall.Returns.BBLState.BOSH.DirectorAddress = "some-director-address"
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					DirectorVMType:   "n1-standard-8",
+					DirectorDiskSize: 128,
+				}, storage.State{
+					GCP: storage.GCP{
+						ServiceAccountKey: serviceAccountKeyPath,
+						ProjectID:         "some-project-id",
+						Zone:              "some-zone",
+						Region:            "some-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCall.Messages).To(ContainElement("changing the director vm type from n1-standard-1 to n1-standard-8 will recreate the director vm."))
+				Expect(logger.WarnCall.Messages).To(ContainElement("changing the director disk size from 32GB to 128GB will migrate the director's persistent disk."))
 			})
 		})
 
@@ -297,7 +685,7 @@ var _ = Describe("GCPUp", func() {
 				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
 				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
 				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
-				Expect(stateStore.SetCall.CallCount).To(Equal(4))
+				Expect(stateStore.SetCall.CallCount).To(Equal(5))
 				Expect(stateStore.SetCall.Receives[3].State.NoDirector).To(Equal(true))
 			})
 
@@ -318,12 +706,221 @@ var _ = Describe("GCPUp", func() {
 					Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
 					Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
 					Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
-					Expect(stateStore.SetCall.CallCount).To(Equal(4))
+					Expect(stateStore.SetCall.CallCount).To(Equal(5))
 					Expect(stateStore.SetCall.Receives[3].State.NoDirector).To(Equal(true))
 				})
 			})
 		})
 
+		Context("when firewall rules are provided", func() {
+			var firewallRules []storage.GCPFirewallRule
+
+			BeforeEach(func() {
+				firewallRules = []storage.GCPFirewallRule{
+					{
+						Name:        "some-app",
+						Protocol:    "tcp",
+						Ports:       []string{"8080"},
+						SourceRange: "10.0.0.0/8",
+					},
+				}
+			})
+
+			It("records the rules in the state", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					FirewallRules: firewallRules,
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.GCP.FirewallRules).To(Equal(firewallRules))
+			})
+
+			It("removes rules from a previous up that are no longer provided", func() {
+				state := expectedIAASState
+				state.GCP.FirewallRules = append([]storage.GCPFirewallRule{{
+					Name:        "old-app",
+					Protocol:    "udp",
+					Ports:       []string{"53"},
+					SourceRange: "0.0.0.0/0",
+				}}, firewallRules...)
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					FirewallRules: firewallRules,
+				}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.GCP.FirewallRules).To(Equal(firewallRules))
+			})
+		})
+
+		Context("when no firewall rules are provided", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = expectedIAASState
+				state.GCP.FirewallRules = []storage.GCPFirewallRule{{
+					Name:        "old-app",
+					Protocol:    "udp",
+					Ports:       []string{"53"},
+					SourceRange: "0.0.0.0/0",
+				}}
+			})
+
+			It("keeps the rules from a previous up", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.GCP.FirewallRules).To(Equal(state.GCP.FirewallRules))
+			})
+
+			It("removes the rules from a previous up when they are cleared", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					ClearFirewallRules: true,
+				}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.GCP.FirewallRules).To(BeEmpty())
+			})
+		})
+
+		Context("when an ssh port is provided", func() {
+			It("records the port in the state", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					Jumpbox: true,
+					SSHPort: 2222,
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.SSHPort).To(Equal(2222))
+			})
+
+			It("keeps the port from a previous up when none is provided", func() {
+				state := expectedIAASState
+				state.SSHPort = 2222
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					Jumpbox: true,
+				}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.SSHPort).To(Equal(2222))
+			})
+		})
+
+		Context("when tags are provided", func() {
+			It("records them in the state", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					Tags: []storage.Tag{{Key: "cost-center", Value: "1234"}},
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.Tags).To(Equal([]storage.Tag{{Key: "cost-center", Value: "1234"}}))
+			})
+		})
+
+		Context("when no public ips are requested", func() {
+			It("records it in the state", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					NoPublicIPs: true,
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.GCP.NoPublicIPs).To(BeTrue())
+			})
+
+			It("keeps it from a previous up", func() {
+				state := expectedIAASState
+				state.GCP.NoPublicIPs = true
+
+				err := gcpUp.Execute(commands.GCPUpConfig{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.GCP.NoPublicIPs).To(BeTrue())
+			})
+		})
+
+		Context("when network cidrs are provided", func() {
+			It("records the cidrs in the state", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					NetworkCIDR: "172.16.0.0/16",
+					SubnetCIDR:  "172.16.0.0/24",
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.Network).To(Equal(storage.Network{
+					CIDR:       "172.16.0.0/16",
+					SubnetCIDR: "172.16.0.0/24",
+				}))
+				Expect(logger.PrintlnCall.Messages).To(BeEmpty())
+			})
+
+			It("keeps the cidrs from the state when none are provided", func() {
+				state := expectedIAASState
+				state.Network = storage.Network{CIDR: "172.16.0.0/16"}
+
+				err := gcpUp.Execute(commands.GCPUpConfig{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.Network).To(Equal(storage.Network{CIDR: "172.16.0.0/16"}))
+			})
+
+			It("warns when the cidrs of an existing environment change", func() {
+				state := expectedIAASState
+				state.TFState = "some-tf-state"
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					NetworkCIDR: "172.16.0.0/16",
+					SubnetCIDR:  "172.16.0.0/24",
+				}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.WarnCall.Messages).To(ContainElement("changing the network CIDR from 10.0.0.0/16 to 172.16.0.0/16 and the subnet CIDR from 10.0.0.0/24 to 172.16.0.0/24 will replace the network, its subnets, and any VMs deployed to them."))
+			})
+		})
+
+		Context("when an existing network is provided", func() {
+			BeforeEach(func() {
+				terraformManager.ApplyCall.Returns.BBLState.GCP.ExistingNetworkName = "some-network"
+				terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+					"subnetwork_cidr": "10.5.0.0/20",
+				}
+			})
+
+			It("records the network and subnetwork in the state", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					ExistingNetworkName:    "some-network",
+					ExistingSubnetworkName: "some-subnetwork",
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.GCP.ExistingNetworkName).To(Equal("some-network"))
+				Expect(envIDManager.SyncCall.Receives.State.GCP.ExistingSubnetworkName).To(Equal("some-subnetwork"))
+			})
+
+			It("deploys the director into the first /24 of the subnetwork", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					ExistingNetworkName:    "some-network",
+					ExistingSubnetworkName: "some-subnetwork",
+				}, expectedIAASState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.Network).To(Equal(storage.Network{
+					CIDR:       "10.5.0.0/20",
+					SubnetCIDR: "10.5.0.0/24",
+				}))
+			})
+
+			It("returns an error when the subnetwork range is missing", func() {
+				terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{}
+
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					ExistingNetworkName:    "some-network",
+					ExistingSubnetworkName: "some-subnetwork",
+				}, expectedIAASState)
+				Expect(err).To(MatchError("missing subnetwork_cidr terraform output"))
+			})
+		})
+
 		Context("when the jumpbox flag is provided", func() {
 			BeforeEach(func() {
 				terraformManager.ApplyCall.Returns.BBLState.Jumpbox.Enabled = true
@@ -347,9 +944,23 @@ var _ = Describe("GCPUp", func() {
 				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
 				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
 				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
-				Expect(stateStore.SetCall.CallCount).To(Equal(5))
+				Expect(stateStore.SetCall.CallCount).To(Equal(7))
 				Expect(stateStore.SetCall.Receives[0].State.Jumpbox.Enabled).To(Equal(true))
 			})
+
+			It("skips the jumpbox along with the director when it is resumed from the cloud config", func() {
+				err := gcpUp.Execute(commands.GCPUpConfig{
+					Jumpbox:   true,
+					FromPhase: "cloud-config",
+				}, expectedBOSHState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.StepCall.Messages).To(ContainElement("skipping bosh, resuming from cloud-config"))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+			})
 		})
 
 		Context("reentrance", func() {
@@ -448,7 +1059,7 @@ var _ = Describe("GCPUp", func() {
 			It("returns an error when the ops file cannot be read", func() {
 				err := gcpUp.Execute(commands.GCPUpConfig{
 					ServiceAccountKey: serviceAccountKeyPath,
-					OpsFilePath:       "some/fake/path",
+					OpsFilePaths:      []string{"some/fake/path"},
 				}, storage.State{})
 				Expect(err).To(MatchError("error reading ops-file contents: open some/fake/path: no such file or directory"))
 			})
@@ -485,16 +1096,7 @@ var _ = Describe("GCPUp", func() {
 				Entry("returns an error when the state is empty", func() storage.State {
 					return storage.State{}
 				},
-					"GCP service account key must be provided"),
-				Entry("returns an error when service account key is missing", func() storage.State {
-					return storage.State{
-						GCP: storage.GCP{
-							ProjectID: "p",
-							Zone:      "z",
-							Region:    "us-west1",
-						},
-					}
-				}, "GCP service account key must be provided"),
+					"GCP project ID must be provided"),
 				Entry("returns an error when project ID is missing", func() storage.State {
 					return storage.State{
 						GCP: storage.GCP{
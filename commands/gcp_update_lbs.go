@@ -17,5 +17,7 @@ func (g GCPUpdateLBs) Execute(config GCPCreateLBsConfig, state storage.State) er
 		config.Domain = state.LB.Domain
 	}
 
+	config.EnableIPv6 = state.LB.IPv6
+
 	return g.gcpCreateLBs.Execute(config, state)
 }
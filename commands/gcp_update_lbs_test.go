@@ -74,5 +74,20 @@ var _ = Describe("GCP Update LBs", func() {
 				Expect(gcpCreateLBs.ExecuteCall.Receives.State).To(Equal(state))
 			})
 		})
+
+		Context("when ipv6 is enabled in the state", func() {
+			It("keeps ipv6 enabled", func() {
+				state.LB.IPv6 = true
+
+				err := command.Execute(commands.GCPCreateLBsConfig{
+					CertPath: "some-cert-path",
+					KeyPath:  "some-key-path",
+					LBType:   "cf",
+				}, state)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gcpCreateLBs.ExecuteCall.Receives.Config.EnableIPv6).To(BeTrue())
+			})
+		})
 	})
 })
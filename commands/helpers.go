@@ -1,6 +1,18 @@
 package commands
 
-import "github.com/cloudfoundry/bosh-bootloader/helpers"
+import (
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func applyTerraform(terraformManager terraformUpApplier, state storage.State, targets []string, logger logger) (storage.State, error) {
+	if len(targets) == 0 {
+		return terraformManager.Apply(state)
+	}
+
+	logger.Warn("applying only the targeted terraform resources can leave the infrastructure diverged from the full terraform plan, run bbl up without --target to reconcile it.")
+	return terraformManager.ApplyTargets(state, targets)
+}
 
 func handleTerraformError(err error, stateStore stateStore) error {
 	switch err.(type) {
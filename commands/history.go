@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	HistoryCommand = "history"
+)
+
+type auditLog interface {
+	Records() ([]storage.AuditRecord, error)
+}
+
+// History prints the audit log of the bbl commands run against the
+// environment. It does not need a bbl state, so that the history of a
+// destroyed environment can still be read.
+type History struct {
+	auditLog auditLog
+	logger   logger
+}
+
+type historyConfig struct {
+	json bool
+	last int
+}
+
+func NewHistory(auditLog auditLog, logger logger) History {
+	return History{
+		auditLog: auditLog,
+		logger:   logger,
+	}
+}
+
+func (h History) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	config, err := h.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.last < 0 {
+		return errors.New("--last must not be negative")
+	}
+
+	return nil
+}
+
+func (h History) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := h.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.auditLog.Records()
+	if err != nil {
+		return err
+	}
+
+	if config.last > 0 && len(records) > config.last {
+		records = records[len(records)-config.last:]
+	}
+
+	if config.json {
+		return printJSON(h.logger, records)
+	}
+
+	if len(records) == 0 {
+		h.logger.Println("no bbl commands have been recorded for this environment")
+		return nil
+	}
+
+	h.logger.Println(historyTable(records))
+	return nil
+}
+
+func (h History) parseArgs(args []string) (historyConfig, error) {
+	var config historyConfig
+
+	historyFlags := flags.New(HistoryCommand)
+	historyFlags.Bool(&config.json, "", "json", false)
+	historyFlags.Int(&config.last, "last", 0)
+
+	err := historyFlags.Parse(args)
+	if err != nil {
+		return historyConfig{}, err
+	}
+
+	return config, nil
+}
+
+func historyTable(records []storage.AuditRecord) string {
+	buf := bytes.NewBuffer([]byte{})
+	writer := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "Time\tUser\tCommand\tResult")
+	for _, record := range records {
+		result := record.Result
+		if record.Error != "" {
+			result = fmt.Sprintf("%s: %s", result, strings.SplitN(record.Error, "\n", 2)[0])
+		}
+
+		command := strings.Join(append([]string{"bbl"}, record.Args...), " ")
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", record.Time.Format(time.RFC3339), record.User, command, result)
+	}
+	writer.Flush()
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
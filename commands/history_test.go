@@ -0,0 +1,105 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var (
+		auditLog *fakes.AuditLog
+		logger   *fakes.Logger
+
+		command commands.History
+	)
+
+	BeforeEach(func() {
+		auditLog = &fakes.AuditLog{}
+		logger = &fakes.Logger{}
+
+		auditLog.RecordsCall.Returns.Records = []storage.AuditRecord{
+			{
+				Time:    time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC),
+				User:    "some-user",
+				Command: "up",
+				Args:    []string{"up", "--name", "some-name"},
+				Result:  "success",
+			},
+			{
+				Time:     time.Date(2017, 7, 2, 9, 30, 0, 0, time.UTC),
+				User:     "some-other-user",
+				Command:  "destroy",
+				Args:     []string{"destroy"},
+				Result:   "failure",
+				ExitCode: 1,
+				Error:    "failed to destroy\nsome-output",
+			},
+		}
+
+		command = commands.NewHistory(auditLog, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when an unknown flag is provided", func() {
+			err := command.CheckFastFails([]string{"--some-unknown-flag"}, storage.State{})
+			Expect(err).To(MatchError("flag provided but not defined: -some-unknown-flag"))
+		})
+
+		It("returns an error when --last is negative", func() {
+			err := command.CheckFastFails([]string{"--last", "-1"}, storage.State{})
+			Expect(err).To(MatchError("--last must not be negative"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints the recorded commands", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				"Time                  User             Command                  Result\n" +
+					"2017-07-01T12:00:00Z  some-user        bbl up --name some-name  success\n" +
+					"2017-07-02T09:30:00Z  some-other-user  bbl destroy              failure: failed to destroy",
+			}))
+		})
+
+		It("prints only the last records with --last", func() {
+			err := command.Execute([]string{"--last", "1", "--json"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+			Expect(logger.PrintlnCall.Messages[0]).To(MatchJSON(`[{
+				"time": "2017-07-02T09:30:00Z",
+				"user": "some-other-user",
+				"command": "destroy",
+				"args": ["destroy"],
+				"result": "failure",
+				"exitCode": 1,
+				"error": "failed to destroy\nsome-output"
+			}]`))
+		})
+
+		It("reports that nothing has been recorded", func() {
+			auditLog.RecordsCall.Returns.Records = []storage.AuditRecord{}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"no bbl commands have been recorded for this environment"}))
+		})
+
+		It("returns an error when the audit log cannot be read", func() {
+			auditLog.RecordsCall.Returns.Error = errors.New("failed to read")
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).To(MatchError("failed to read"))
+		})
+	})
+})
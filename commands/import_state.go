@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ImportStateCommand = "import-state"
+)
+
+type stateImporter interface {
+	Import(bundle []byte, key string, replace bool) error
+}
+
+// ImportState unpacks a bundle written by export-state into the state
+// directory.
+type ImportState struct {
+	stateImporter stateImporter
+	envGetter     envGetter
+	logger        logger
+}
+
+type importStateConfig struct {
+	from  string
+	force bool
+}
+
+func NewImportState(stateImporter stateImporter, envGetter envGetter, logger logger) ImportState {
+	return ImportState{
+		stateImporter: stateImporter,
+		envGetter:     envGetter,
+		logger:        logger,
+	}
+}
+
+func (i ImportState) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	config, err := i.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.from == "" {
+		return errors.New("--from must be provided")
+	}
+
+	if i.envGetter.Get(storage.StateBundleKeyEnv) == "" {
+		return fmt.Errorf("%s must be set to the key the state bundle was encrypted with", storage.StateBundleKeyEnv)
+	}
+
+	return nil
+}
+
+func (i ImportState) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := i.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := ioutil.ReadFile(config.from)
+	if err != nil {
+		return err
+	}
+
+	err = i.stateImporter.Import(bundle, i.envGetter.Get(storage.StateBundleKeyEnv), config.force)
+	if err != nil {
+		return err
+	}
+
+	i.logger.Step("imported the state from %s", config.from)
+	return nil
+}
+
+func (i ImportState) parseArgs(args []string) (importStateConfig, error) {
+	var config importStateConfig
+
+	importStateFlags := flags.New(ImportStateCommand)
+	importStateFlags.String(&config.from, "from", "")
+	importStateFlags.Bool(&config.force, "", "force", false)
+
+	err := importStateFlags.Parse(args)
+	if err != nil {
+		return importStateConfig{}, err
+	}
+
+	return config, nil
+}
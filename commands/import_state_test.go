@@ -0,0 +1,93 @@
+package commands_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImportState", func() {
+	var (
+		stateImporter *fakes.StateImporter
+		envGetter     *fakes.EnvGetter
+		logger        *fakes.Logger
+		bundlePath    string
+
+		command commands.ImportState
+	)
+
+	BeforeEach(func() {
+		stateImporter = &fakes.StateImporter{}
+		envGetter = &fakes.EnvGetter{Values: map[string]string{"BBL_STATE_BUNDLE_KEY": "some-key"}}
+		logger = &fakes.Logger{}
+
+		tempDir, err := ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		bundlePath = filepath.Join(tempDir, "some-env.bundle")
+		err = ioutil.WriteFile(bundlePath, []byte("some-bundle"), os.FileMode(0600))
+		Expect(err).NotTo(HaveOccurred())
+
+		command = commands.NewImportState(stateImporter, envGetter, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(filepath.Dir(bundlePath))
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when --from is not provided", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("--from must be provided"))
+		})
+
+		It("returns an error when the key is not set", func() {
+			envGetter.Values = map[string]string{}
+
+			err := command.CheckFastFails([]string{"--from", bundlePath}, storage.State{})
+			Expect(err).To(MatchError("BBL_STATE_BUNDLE_KEY must be set to the key the state bundle was encrypted with"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("imports the bundle into the state dir", func() {
+			err := command.Execute([]string{"--from", bundlePath}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateImporter.ImportCall.CallCount).To(Equal(1))
+			Expect(string(stateImporter.ImportCall.Receives.Bundle)).To(Equal("some-bundle"))
+			Expect(stateImporter.ImportCall.Receives.Key).To(Equal("some-key"))
+			Expect(stateImporter.ImportCall.Receives.Replace).To(BeFalse())
+			Expect(logger.StepCall.Messages).To(Equal([]string{"imported the state from " + bundlePath}))
+		})
+
+		It("replaces an existing state with --force", func() {
+			err := command.Execute([]string{"--from", bundlePath, "--force"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateImporter.ImportCall.Receives.Replace).To(BeTrue())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the bundle cannot be read", func() {
+				err := command.Execute([]string{"--from", "/some/missing/bundle"}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+				Expect(stateImporter.ImportCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the bundle cannot be imported", func() {
+				stateImporter.ImportCall.Returns.Error = errors.New("failed to import")
+
+				err := command.Execute([]string{"--from", bundlePath}, storage.State{})
+				Expect(err).To(MatchError("failed to import"))
+			})
+		})
+	})
+})
@@ -9,12 +9,34 @@ type terraformApplier interface {
 	Apply(storage.State) (storage.State, error)
 }
 
+type terraformUpApplier interface {
+	terraformApplier
+	ApplyTargets(storage.State, []string) (storage.State, error)
+}
+
 type terraformDestroyer interface {
 	ValidateVersion() error
 	GetOutputs(storage.State) (map[string]interface{}, error)
 	Destroy(storage.State) (storage.State, error)
+	Resources(storage.State) ([]string, error)
+	PlanDestroy(storage.State) (string, error)
+}
+
+type terraformPlanGenerator interface {
+	ValidateVersion() error
+	GetOutputs(storage.State) (map[string]interface{}, error)
+	Template(storage.State) string
+	Plan(storage.State) (string, error)
 }
 
 type terraformOutputter interface {
 	GetOutputs(storage.State) (map[string]interface{}, error)
 }
+
+type terraformDrifter interface {
+	Drift(storage.State) (string, bool, error)
+}
+
+type terraformRefresher interface {
+	Refresh(storage.State) (storage.State, error)
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// JSONRunner is implemented by commands that support the global --json flag.
+// ExecuteJSON runs the same steps as Execute and prints the result as a json
+// document instead of text.
+type JSONRunner interface {
+	ExecuteJSON(subcommandFlags []string, state storage.State) error
+}
+
+func printJSON(logger logger, v interface{}) error {
+	output, err := json.Marshal(v)
+	if err != nil {
+		// not tested
+		return err
+	}
+
+	logger.Println(string(output))
+	return nil
+}
+
+func withJSONFlag(subcommandFlags []string) []string {
+	return append([]string{"--json"}, subcommandFlags...)
+}
+
+func (s StateQuery) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return s.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (s SSHKey) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return s.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (l LBs) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return l.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (t TerraformOutput) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return t.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (s Status) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return s.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (d Deployments) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return d.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (v Version) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return v.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (d Destroy) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return d.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (o Outputs) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return o.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (d Drift) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return d.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (p PrintEnv) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return p.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (s ShowState) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return s.Execute(withJSONFlag(subcommandFlags), state)
+}
+
+func (h History) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return h.Execute(withJSONFlag(subcommandFlags), state)
+}
@@ -1,6 +1,11 @@
 package commands
 
-import "github.com/cloudfoundry/bosh-bootloader/storage"
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
 
 type LatestError struct {
 	logger         logger
@@ -24,6 +29,26 @@ func (l LatestError) CheckFastFails(subcommandFlags []string, state storage.Stat
 }
 
 func (l LatestError) Execute(subcommandFlags []string, bblState storage.State) error {
+	var full bool
+	latestErrorFlags := flags.New("latest-error")
+	latestErrorFlags.Bool(&full, "", "full", false)
+
+	err := latestErrorFlags.Parse(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
 	l.logger.Println(bblState.LatestTFOutput)
+
+	if full {
+		if bblState.LatestError.Message == "" {
+			l.logger.Println("No error has been recorded.")
+			return nil
+		}
+
+		l.logger.Println(fmt.Sprintf("Latest error:\n%s", bblState.LatestError.Message))
+		l.logger.Println(fmt.Sprintf("Subprocess output:\n%s", bblState.LatestError.Output))
+	}
+
 	return nil
 }
@@ -45,5 +45,38 @@ var _ = Describe("latest-error", func() {
 
 			Expect(logger.PrintlnCall.Messages).To(ContainElement("some tf output"))
 		})
+
+		Context("when --full is provided", func() {
+			It("also prints the latest error and subprocess output", func() {
+				bblState := storage.State{
+					LatestTFOutput: "some tf output",
+					LatestError: storage.LatestError{
+						Message: "some error",
+						Output:  "some subprocess output",
+					},
+				}
+
+				err := command.Execute([]string{"--full"}, bblState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"some tf output",
+					"Latest error:\nsome error",
+					"Subprocess output:\nsome subprocess output",
+				}))
+			})
+
+			It("says so when no error has been recorded", func() {
+				err := command.Execute([]string{"--full"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("No error has been recorded."))
+			})
+		})
+
+		It("returns an error when the flags cannot be parsed", func() {
+			err := command.Execute([]string{"--invalid-flag"}, storage.State{})
+			Expect(err).To(MatchError("flag provided but not defined: -invalid-flag"))
+		})
 	})
 })
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type certificateIssuer interface {
+	Issue(account certs.ACMEAccount, domains []string, present func([]certs.ChallengeRecord) error) (certs.ACMEAccount, certs.IssuedCertificate, error)
+}
+
+type lbCertificateProvisioner interface {
+	Placeholder(state storage.State) (storage.State, error)
+	Provision(state storage.State) (storage.State, error)
+}
+
+type LetsEncrypt struct {
+	issuer           certificateIssuer
+	terraformManager terraformApplier
+	stateStore       stateStore
+	logger           logger
+}
+
+func NewLetsEncrypt(issuer certificateIssuer, terraformManager terraformApplier, stateStore stateStore, logger logger) LetsEncrypt {
+	return LetsEncrypt{
+		issuer:           issuer,
+		terraformManager: terraformManager,
+		stateStore:       stateStore,
+		logger:           logger,
+	}
+}
+
+// Placeholder sets a self-signed certificate for the lb domain so that the
+// load balancers and the DNS zone can be created before a trusted
+// certificate is issued.
+func (l LetsEncrypt) Placeholder(state storage.State) (storage.State, error) {
+	if state.LB.Domain == "" {
+		return storage.State{}, errors.New("--domain is required to request a certificate from let's encrypt")
+	}
+
+	certificate, err := certs.SelfSignedCertificate(lbCertificateDomains(state.LB.Domain))
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	state.LB.Cert = certificate.Certificate
+	state.LB.Key = certificate.PrivateKey
+	state.LB.Chain = ""
+
+	return state, nil
+}
+
+// Provision requests a certificate for the lb domain, publishing the DNS-01
+// challenges as TXT records in the zone bbl manages, and applies it to the
+// load balancers. The ACME account is saved so that renewals reuse it.
+func (l LetsEncrypt) Provision(state storage.State) (storage.State, error) {
+	domains := lbCertificateDomains(state.LB.Domain)
+	l.logger.Step("requesting a certificate for %s from let's encrypt", strings.Join(domains, ", "))
+
+	account := certs.ACMEAccount{
+		Email: state.LB.ACME.Email,
+		Key:   state.LB.ACME.AccountKey,
+		URL:   state.LB.ACME.AccountURL,
+	}
+
+	account, certificate, err := l.issuer.Issue(account, domains, func(records []certs.ChallengeRecord) error {
+		state.LB.ACME.Challenges = []storage.ACMEChallenge{}
+		for _, record := range records {
+			state.LB.ACME.Challenges = append(state.LB.ACME.Challenges, storage.ACMEChallenge{
+				Name:  record.Name,
+				Value: record.Value,
+			})
+		}
+
+		l.logger.Step("publishing dns-01 challenge records")
+		appliedState, err := l.apply(state)
+		if err != nil {
+			return err
+		}
+		state = appliedState
+
+		return nil
+	})
+
+	state.LB.ACME.AccountKey = account.Key
+	state.LB.ACME.AccountURL = account.URL
+	if err != nil {
+		if setErr := l.stateStore.Set(state); setErr != nil {
+			return storage.State{}, setErr
+		}
+		return storage.State{}, err
+	}
+
+	state.LB.Cert = certificate.Certificate
+	state.LB.Key = certificate.PrivateKey
+	state.LB.Chain = certificate.Chain
+	state.LB.ACME.Challenges = nil
+
+	l.logger.Step("installing the certificate, valid until %s", certificate.NotAfter.UTC().Format("2006-01-02"))
+	return l.apply(state)
+}
+
+func (l LetsEncrypt) apply(state storage.State) (storage.State, error) {
+	state, err := l.terraformManager.Apply(state)
+	if err != nil {
+		return storage.State{}, handleTerraformError(err, l.stateStore)
+	}
+
+	if err := l.stateStore.Set(state); err != nil {
+		return storage.State{}, err
+	}
+
+	return state, nil
+}
+
+// renewLetsEncryptCertificate requests a new certificate for load balancers
+// that already exist, reusing the saved account.
+func renewLetsEncryptCertificate(provisioner lbCertificateProvisioner, email string, state storage.State) error {
+	if email != "" {
+		state.LB.ACME.Email = email
+	}
+
+	_, err := provisioner.Provision(state)
+	return err
+}
+
+// lbCertificateDomains returns the names the cf router serves under domain.
+func lbCertificateDomains(domain string) []string {
+	return []string{"*." + domain, "*.ws." + domain}
+}
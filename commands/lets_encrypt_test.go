@@ -0,0 +1,187 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LetsEncrypt", func() {
+	var (
+		issuer           *fakes.CertificateIssuer
+		terraformManager *fakes.TerraformManager
+		stateStore       *fakes.StateStore
+		logger           *fakes.Logger
+
+		appliedStates []storage.State
+		state         storage.State
+		letsEncrypt   commands.LetsEncrypt
+	)
+
+	BeforeEach(func() {
+		issuer = &fakes.CertificateIssuer{}
+		terraformManager = &fakes.TerraformManager{}
+		stateStore = &fakes.StateStore{}
+		logger = &fakes.Logger{}
+
+		appliedStates = nil
+		terraformManager.ApplyCall.Stub = func(state storage.State) (storage.State, error) {
+			appliedStates = append(appliedStates, state)
+			state.TFState = "some-updated-tf-state"
+			return state, nil
+		}
+
+		state = storage.State{
+			IAAS: "aws",
+			LB: storage.LB{
+				Type:   "cf",
+				Domain: "some-domain",
+				Cert:   "some-placeholder-cert",
+				Key:    "some-placeholder-key",
+				ACME: storage.ACME{
+					Email: "me@example.com",
+				},
+			},
+		}
+
+		letsEncrypt = commands.NewLetsEncrypt(issuer, terraformManager, stateStore, logger)
+	})
+
+	Describe("Placeholder", func() {
+		It("sets a self-signed certificate for the lb domain", func() {
+			state.LB.Chain = "some-chain"
+
+			placeholderState, err := letsEncrypt.Placeholder(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(placeholderState.LB.Cert).To(ContainSubstring("BEGIN CERTIFICATE"))
+			Expect(placeholderState.LB.Key).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
+			Expect(placeholderState.LB.Chain).To(BeEmpty())
+		})
+
+		It("returns an error when there is no domain", func() {
+			state.LB.Domain = ""
+
+			_, err := letsEncrypt.Placeholder(state)
+			Expect(err).To(MatchError("--domain is required to request a certificate from let's encrypt"))
+		})
+	})
+
+	Describe("Provision", func() {
+		BeforeEach(func() {
+			issuer.IssueCall.Records = []certs.ChallengeRecord{
+				{Name: "_acme-challenge.some-domain", Value: "some-value"},
+				{Name: "_acme-challenge.ws.some-domain", Value: "some-ws-value"},
+			}
+			issuer.IssueCall.Returns.Account = certs.ACMEAccount{
+				Email: "me@example.com",
+				Key:   "some-account-key",
+				URL:   "some-account-url",
+			}
+			issuer.IssueCall.Returns.Certificate = certs.IssuedCertificate{
+				Certificate: "some-cert",
+				PrivateKey:  "some-key",
+				Chain:       "some-chain",
+				NotAfter:    time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC),
+			}
+		})
+
+		It("requests a certificate for the lb domain", func() {
+			_, err := letsEncrypt.Provision(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(issuer.IssueCall.Receives.Domains).To(Equal([]string{"*.some-domain", "*.ws.some-domain"}))
+			Expect(issuer.IssueCall.Receives.Account).To(Equal(certs.ACMEAccount{Email: "me@example.com"}))
+		})
+
+		It("publishes the challenges before installing the certificate", func() {
+			provisionedState, err := letsEncrypt.Provision(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(appliedStates).To(HaveLen(2))
+			Expect(appliedStates[0].LB.ACME.Challenges).To(Equal([]storage.ACMEChallenge{
+				{Name: "_acme-challenge.some-domain", Value: "some-value"},
+				{Name: "_acme-challenge.ws.some-domain", Value: "some-ws-value"},
+			}))
+			Expect(appliedStates[0].LB.Cert).To(Equal("some-placeholder-cert"))
+
+			Expect(appliedStates[1].LB.ACME.Challenges).To(BeEmpty())
+			Expect(appliedStates[1].LB.Cert).To(Equal("some-cert"))
+			Expect(appliedStates[1].LB.Key).To(Equal("some-key"))
+			Expect(appliedStates[1].LB.Chain).To(Equal("some-chain"))
+
+			Expect(provisionedState.TFState).To(Equal("some-updated-tf-state"))
+			Expect(stateStore.SetCall.CallCount).To(Equal(2))
+			Expect(stateStore.SetCall.Receives[1].State).To(Equal(provisionedState))
+
+			Expect(logger.StepCall.Messages).To(ContainElement("installing the certificate, valid until 2020-01-02"))
+		})
+
+		It("saves the account for renewals", func() {
+			provisionedState, err := letsEncrypt.Provision(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provisionedState.LB.ACME).To(Equal(storage.ACME{
+				Email:      "me@example.com",
+				AccountKey: "some-account-key",
+				AccountURL: "some-account-url",
+			}))
+		})
+
+		Context("when the account was registered before", func() {
+			It("reuses the account", func() {
+				state.LB.ACME.AccountKey = "some-account-key"
+				state.LB.ACME.AccountURL = "some-account-url"
+
+				_, err := letsEncrypt.Provision(state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(issuer.IssueCall.Receives.Account).To(Equal(certs.ACMEAccount{
+					Email: "me@example.com",
+					Key:   "some-account-key",
+					URL:   "some-account-url",
+				}))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the challenges cannot be published", func() {
+				terraformManager.ApplyCall.Stub = nil
+				terraformManager.ApplyCall.Returns.Error = errors.New("failed to apply")
+
+				_, err := letsEncrypt.Provision(state)
+				Expect(err).To(MatchError("failed to apply"))
+			})
+
+			It("saves the account and returns an error when the certificate cannot be issued", func() {
+				issuer.IssueCall.Returns.Error = errors.New("dns-01 challenge is invalid")
+
+				_, err := letsEncrypt.Provision(state)
+				Expect(err).To(MatchError("dns-01 challenge is invalid"))
+
+				savedState := stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State
+				Expect(savedState.LB.ACME.AccountKey).To(Equal("some-account-key"))
+				Expect(savedState.LB.Cert).To(Equal("some-placeholder-cert"))
+			})
+
+			It("returns an error when the certificate cannot be installed", func() {
+				terraformManager.ApplyCall.Stub = func(state storage.State) (storage.State, error) {
+					if state.LB.Cert == "some-cert" {
+						return storage.State{}, errors.New("failed to install")
+					}
+					return state, nil
+				}
+
+				_, err := letsEncrypt.Provision(state)
+				Expect(err).To(MatchError("failed to install"))
+			})
+		})
+	})
+})
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	MigrateStateCommand = "migrate-state"
+)
+
+type stackMigrator interface {
+	Migrate(storage.State) (storage.State, error)
+}
+
+// MigrateState upgrades a state written by an earlier bbl, so that the
+// schema and a cloudformation stack are only migrated when it is asked to.
+type MigrateState struct {
+	stateValidator stateValidator
+	stateStore     stateStore
+	stackMigrator  stackMigrator
+	logger         logger
+}
+
+func NewMigrateState(stateValidator stateValidator, stateStore stateStore, stackMigrator stackMigrator, logger logger) MigrateState {
+	return MigrateState{
+		stateValidator: stateValidator,
+		stateStore:     stateStore,
+		stackMigrator:  stackMigrator,
+		logger:         logger,
+	}
+}
+
+func (m MigrateState) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := m.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	return flags.New(MigrateStateCommand).Parse(subcommandFlags)
+}
+
+func (m MigrateState) Execute(subcommandFlags []string, state storage.State) error {
+	state, changes := storage.Migrate(state)
+	if len(changes) > 0 {
+		err := m.stateStore.Set(state)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			m.logger.Step("migrated the state: %s", change)
+		}
+	}
+
+	if state.Stack.Name != "" {
+		stackName := state.Stack.Name
+
+		m.logger.Step("migrating cloudformation stack %s to terraform", stackName)
+		var err error
+		state, err = m.stackMigrator.Migrate(state)
+		if err != nil {
+			return err
+		}
+
+		err = m.stateStore.Set(state)
+		if err != nil {
+			return err
+		}
+
+		m.logger.Step("migrated cloudformation stack %s to terraform, run bbl up to apply the terraform template", stackName)
+		return nil
+	}
+
+	if len(changes) == 0 {
+		m.logger.Println(fmt.Sprintf("the state is already at schema version %d, there is nothing to migrate", storage.STATE_VERSION))
+	}
+
+	return nil
+}
+
+func (m MigrateState) DryRun(subcommandFlags []string, state storage.State) error {
+	migrated, changes := storage.Migrate(state)
+	if state.Stack.Name != "" {
+		changes = append(changes, fmt.Sprintf("import the resources of cloudformation stack %s into the terraform state and delete the stack", state.Stack.Name))
+	}
+
+	current, err := json.MarshalIndent(redactState(state), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	upgraded, err := json.MarshalIndent(redactState(migrated), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	printDryRun(m.logger, MigrateStateCommand, changes, diffLines(string(current), string(upgraded)))
+	return nil
+}
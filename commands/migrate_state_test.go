@@ -0,0 +1,161 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MigrateState", func() {
+	var (
+		stateValidator *fakes.StateValidator
+		stateStore     *fakes.StateStore
+		stackMigrator  *fakes.StackMigrator
+		logger         *fakes.Logger
+
+		command commands.MigrateState
+	)
+
+	BeforeEach(func() {
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+		stackMigrator = &fakes.StackMigrator{}
+		logger = &fakes.Logger{}
+
+		command = commands.NewMigrateState(stateValidator, stateStore, stackMigrator, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when an unknown flag is provided", func() {
+			err := command.CheckFastFails([]string{"--some-unknown-flag"}, storage.State{})
+			Expect(err).To(MatchError("flag provided but not defined: -some-unknown-flag"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("upgrades the schema and saves the state", func() {
+			err := command.Execute([]string{}, storage.State{
+				Version: 5,
+				IAAS:    "gcp",
+				BOSH:    storage.BOSH{UserOpsFile: "some-ops-file"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(storage.State{
+				Version: 8,
+				IAAS:    "gcp",
+				BOSH:    storage.BOSH{UserOpsFiles: []string{"some-ops-file"}},
+			}))
+			Expect(logger.StepCall.Messages).To(Equal([]string{
+				"migrated the state: move the single bosh userOpsFile into userOpsFiles",
+				"migrated the state: upgrade the schema from version 5 to 8",
+			}))
+			Expect(stackMigrator.MigrateCall.CallCount).To(Equal(0))
+		})
+
+		It("migrates a cloudformation stack to terraform", func() {
+			stackMigrator.MigrateCall.Returns.State = storage.State{
+				Version:                    8,
+				IAAS:                       "aws",
+				TFState:                    "some-tf-state",
+				MigratedFromCloudFormation: true,
+			}
+
+			err := command.Execute([]string{}, storage.State{
+				Version: 8,
+				IAAS:    "aws",
+				Stack:   storage.Stack{Name: "some-stack"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stackMigrator.MigrateCall.Receives.State.Stack.Name).To(Equal("some-stack"))
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(stackMigrator.MigrateCall.Returns.State))
+			Expect(logger.StepCall.Messages).To(Equal([]string{
+				"migrating cloudformation stack some-stack to terraform",
+				"migrated cloudformation stack some-stack to terraform, run bbl up to apply the terraform template",
+			}))
+		})
+
+		It("reports that a current state has nothing to migrate", func() {
+			err := command.Execute([]string{}, storage.State{Version: 8, IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Messages).To(ContainElement("the state is already at schema version 8, there is nothing to migrate"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the upgraded state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, storage.State{Version: 5})
+				Expect(err).To(MatchError("failed to set state"))
+			})
+
+			It("returns an error without saving when the stack cannot be migrated", func() {
+				stackMigrator.MigrateCall.Returns.Error = errors.New("failed to migrate")
+
+				err := command.Execute([]string{}, storage.State{Version: 8, Stack: storage.Stack{Name: "some-stack"}})
+				Expect(err).To(MatchError("failed to migrate"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("DryRun", func() {
+		It("prints the changes and the diff of the state without saving it", func() {
+			err := command.DryRun([]string{}, storage.State{
+				Version: 7,
+				IAAS:    "aws",
+				Stack:   storage.Stack{Name: "some-stack"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			Expect(stackMigrator.MigrateCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+			Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`bbl migrate-state --dry-run would:
+  - upgrade the schema from version 7 to 8
+  - import the resources of cloudformation stack some-stack into the terraform state and delete the stack`))
+			Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`-   "version": 7,
++   "version": 8,`))
+		})
+
+		It("does not print the credentials in the state", func() {
+			err := command.DryRun([]string{}, storage.State{
+				Version: 7,
+				IAAS:    "aws",
+				BOSH: storage.BOSH{
+					Credentials: map[string]string{"admin": "some-admin-password"},
+					Variables:   "some-variables",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`-     "credentials": {`))
+			Expect(logger.PrintlnCall.Messages[0]).NotTo(ContainSubstring("some-admin-password"))
+			Expect(logger.PrintlnCall.Messages[0]).NotTo(ContainSubstring("some-variables"))
+		})
+
+		It("changes nothing in a current state", func() {
+			err := command.DryRun([]string{}, storage.State{Version: 8, IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"bbl migrate-state --dry-run would:\n  - change nothing"}))
+		})
+	})
+})
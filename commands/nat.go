@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	natGateway  = "gateway"
+	natInstance = "instance"
+)
+
+func validateNAT(nat, existingVPCID string, state storage.State) error {
+	if nat == "" {
+		return nil
+	}
+
+	if state.IAAS != "aws" {
+		return errors.New(`--nat is only supported when iaas="aws"`)
+	}
+
+	if nat != natGateway && nat != natInstance {
+		return fmt.Errorf(`--nat must be "gateway" or "instance", got %q`, nat)
+	}
+
+	if existingVPCID != "" || state.AWS.ExistingVPCID != "" {
+		return errors.New("--nat cannot be used with an existing VPC, which routes its subnets to the internet itself")
+	}
+
+	return nil
+}
+
+// useNAT records the NAT of the internal subnets, which is kept on every later
+// up until another one is chosen. The NAT instance is the default and is not
+// recorded.
+func useNAT(state storage.State, nat string) storage.State {
+	switch nat {
+	case natGateway:
+		state.AWS.NAT = natGateway
+	case natInstance:
+		state.AWS.NAT = ""
+	}
+
+	return state
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const maxNetworkCIDRMaskBits = 24
+
+func validateNetworkCIDRs(network storage.Network) error {
+	networkCIDR, err := parseCIDR(network.NetworkCIDR())
+	if err != nil {
+		return err
+	}
+
+	if networkCIDR.CIDRSize < 1<<(32-maxNetworkCIDRMaskBits) {
+		return fmt.Errorf("Network CIDR %s is too small, it must be a /%d or larger.", networkCIDR, maxNetworkCIDRMaskBits)
+	}
+
+	subnetCIDR, err := parseCIDR(network.DirectorSubnetCIDR())
+	if err != nil {
+		return err
+	}
+
+	if !networkCIDR.Contains(subnetCIDR) {
+		return fmt.Errorf("Subnet CIDR %s must be within the network CIDR %s.", subnetCIDR, networkCIDR)
+	}
+
+	// Availability zone subnets start at the second /+4 block of the network and
+	// load balancer subnets at the third /+8 block.
+	directorRange, err := networkCIDR.Subnet(7, 0)
+	if err != nil {
+		return err
+	}
+
+	if !directorRange.Contains(subnetCIDR) {
+		return fmt.Errorf("Subnet CIDR %s overlaps the subnets reserved for availability zones and load balancers in %s; it must be within %s.", subnetCIDR, networkCIDR, directorRange)
+	}
+
+	return nil
+}
+
+func parseCIDR(cidr string) (bosh.CIDRBlock, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil || ip.To4() == nil {
+		return bosh.CIDRBlock{}, fmt.Errorf("%q is not a valid IPv4 CIDR block.", cidr)
+	}
+
+	if !ip.Equal(ipNet.IP) {
+		return bosh.CIDRBlock{}, fmt.Errorf("%q is not a valid IPv4 CIDR block, did you mean %q?", cidr, ipNet.String())
+	}
+
+	return bosh.ParseCIDRBlock(cidr)
+}
+
+func mergeNetworkCIDRs(network storage.Network, networkCIDR, subnetCIDR string) storage.Network {
+	if networkCIDR != "" {
+		network.CIDR = networkCIDR
+	}
+	if subnetCIDR != "" {
+		network.SubnetCIDR = subnetCIDR
+	}
+
+	return network
+}
+
+func updateNetworkCIDRs(state storage.State, networkCIDR, subnetCIDR string, logger logger) storage.State {
+	network := mergeNetworkCIDRs(state.Network, networkCIDR, subnetCIDR)
+
+	changed := network.NetworkCIDR() != state.Network.NetworkCIDR() || network.DirectorSubnetCIDR() != state.Network.DirectorSubnetCIDR()
+	if changed && state.TFState != "" {
+		logger.Warn("changing the network CIDR from %s to %s and the subnet CIDR from %s to %s will replace the network, its subnets, and any VMs deployed to them.",
+			state.Network.NetworkCIDR(), network.NetworkCIDR(), state.Network.DirectorSubnetCIDR(), network.DirectorSubnetCIDR())
+	}
+
+	state.Network = network
+	return state
+}
@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func validateNoPublicIPs(noPublicIPs bool, state storage.State) error {
+	if noPublicIPs && state.IAAS != "gcp" {
+		return errors.New(`--no-public-ips is only supported when iaas="gcp"`)
+	}
+
+	if !noPublicIPs && !state.GCP.NoPublicIPs {
+		return nil
+	}
+
+	if state.TFState != "" && !state.GCP.NoPublicIPs {
+		return errors.New("--no-public-ips can only be used when creating the environment, the existing jumpbox and director keep their external addresses")
+	}
+
+	return nil
+}
+
+// useNoPublicIPs keeps the jumpbox and director off the internet. Once set it
+// is kept on every later up, since they are only reachable at their internal
+// addresses.
+func useNoPublicIPs(state storage.State, noPublicIPs bool) storage.State {
+	if noPublicIPs {
+		state.GCP.NoPublicIPs = true
+	}
+
+	return state
+}
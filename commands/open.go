@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+var waitForInterrupt = waitForSignal
+
+func waitForSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	signal.Stop(signals)
+}
+
+var openServicePorts = map[string]int{
+	"director": 25555,
+	"uaa":      8443,
+	"credhub":  8844,
+}
+
+type Open struct {
+	logger         logger
+	stateValidator stateValidator
+	socks5Proxy    socks5Proxy
+	sshKeyGetter   sshKeyGetter
+	portForwarder  portForwarder
+}
+
+type portForwarder interface {
+	Forward(localPort int, target string, dialer proxy.Dialer) (string, error)
+	Close() error
+}
+
+type openConfig struct {
+	service   string
+	localPort int
+}
+
+func NewOpen(logger logger, stateValidator stateValidator, socks5Proxy socks5Proxy, sshKeyGetter sshKeyGetter, portForwarder portForwarder) Open {
+	return Open{
+		logger:         logger,
+		stateValidator: stateValidator,
+		socks5Proxy:    socks5Proxy,
+		sshKeyGetter:   sshKeyGetter,
+		portForwarder:  portForwarder,
+	}
+}
+
+func (o Open) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := o.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = o.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.NoDirector {
+		return errors.New("Error BBL does not manage this director.")
+	}
+
+	return nil
+}
+
+func (o Open) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := o.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	directorURL, err := url.Parse(state.BOSH.DirectorAddress)
+	if err != nil {
+		return err
+	}
+	target := net.JoinHostPort(directorURL.Hostname(), fmt.Sprintf("%d", openServicePorts[config.service]))
+
+	var dialer proxy.Dialer = proxy.Direct
+	if state.Jumpbox.Enabled {
+		privateKey, err := o.sshKeyGetter.Get(state)
+		if err != nil {
+			return err
+		}
+
+		err = o.socks5Proxy.Start(privateKey, state.Jumpbox.URL)
+		if err != nil {
+			return err
+		}
+
+		dialer, err = proxySOCKS5("tcp", o.socks5Proxy.Addr(), nil, proxy.Direct)
+		if err != nil {
+			return err
+		}
+	}
+
+	localAddr, err := o.portForwarder.Forward(config.localPort, target, dialer)
+	if err != nil {
+		return err
+	}
+
+	o.logger.Step("forwarding %s to %s, press Ctrl-C to stop", localAddr, target)
+	o.logger.Println(fmt.Sprintf("https://%s", localAddr))
+
+	waitForInterrupt()
+
+	return o.portForwarder.Close()
+}
+
+func (o Open) parseArgs(args []string) (openConfig, error) {
+	var config openConfig
+
+	openFlags := flags.New("open")
+	openFlags.Int(&config.localPort, "local-port", 0)
+
+	// the service may be given before or after the flags
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		config.service = args[0]
+		args = args[1:]
+	}
+
+	err := openFlags.Parse(args)
+	if err != nil {
+		return openConfig{}, err
+	}
+
+	remaining := openFlags.Args()
+	if config.service == "" && len(remaining) > 0 {
+		config.service = remaining[0]
+		remaining = remaining[1:]
+	}
+
+	if config.service == "" || len(remaining) > 0 {
+		return openConfig{}, fmt.Errorf("bbl open requires exactly one service: %s", strings.Join(openServices(), ", "))
+	}
+
+	if _, ok := openServicePorts[config.service]; !ok {
+		return openConfig{}, fmt.Errorf("unknown service %q, valid services are: %s", config.service, strings.Join(openServices(), ", "))
+	}
+
+	if config.localPort < 0 || config.localPort > 65535 {
+		return openConfig{}, errors.New("--local-port must be between 0 and 65535")
+	}
+
+	return config, nil
+}
+
+func openServices() []string {
+	var services []string
+	for service := range openServicePorts {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	return services
+}
@@ -0,0 +1,196 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"golang.org/x/net/proxy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Open", func() {
+	var (
+		command commands.Open
+
+		incomingState storage.State
+
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		socks5Proxy    *fakes.Socks5Proxy
+		sshKeyGetter   *fakes.SSHKeyGetter
+		portForwarder  *fakes.PortForwarder
+
+		interrupted bool
+	)
+
+	BeforeEach(func() {
+		incomingState = storage.State{
+			BOSH: storage.BOSH{
+				DirectorAddress: "https://10.0.0.6:25555",
+			},
+		}
+
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		socks5Proxy = &fakes.Socks5Proxy{}
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+		portForwarder = &fakes.PortForwarder{}
+		portForwarder.ForwardCall.Returns.Addr = "127.0.0.1:12345"
+
+		interrupted = false
+		commands.SetWaitForInterrupt(func() {
+			Expect(portForwarder.CloseCall.CallCount).To(Equal(0))
+			interrupted = true
+		})
+
+		command = commands.NewOpen(logger, stateValidator, socks5Proxy, sshKeyGetter, portForwarder)
+	})
+
+	AfterEach(func() {
+		commands.ResetWaitForInterrupt()
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+			err := command.CheckFastFails([]string{"credhub"}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when no service is provided", func() {
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("bbl open requires exactly one service: credhub, director, uaa"))
+		})
+
+		It("returns an error when more than one service is provided", func() {
+			err := command.CheckFastFails([]string{"credhub", "uaa"}, incomingState)
+			Expect(err).To(MatchError("bbl open requires exactly one service: credhub, director, uaa"))
+		})
+
+		It("returns an error when the service is unknown", func() {
+			err := command.CheckFastFails([]string{"concourse"}, incomingState)
+			Expect(err).To(MatchError(`unknown service "concourse", valid services are: credhub, director, uaa`))
+		})
+
+		It("returns an error when the local port is out of range", func() {
+			err := command.CheckFastFails([]string{"credhub", "--local-port", "70000"}, incomingState)
+			Expect(err).To(MatchError("--local-port must be between 0 and 65535"))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			incomingState.NoDirector = true
+			err := command.CheckFastFails([]string{"credhub"}, incomingState)
+			Expect(err).To(MatchError("Error BBL does not manage this director."))
+		})
+	})
+
+	Describe("Execute", func() {
+		DescribeTable("forwards the service port until interrupted",
+			func(service, target string) {
+				err := command.Execute([]string{service}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(portForwarder.ForwardCall.CallCount).To(Equal(1))
+				Expect(portForwarder.ForwardCall.Receives.LocalPort).To(Equal(0))
+				Expect(portForwarder.ForwardCall.Receives.Target).To(Equal(target))
+				Expect(portForwarder.ForwardCall.Receives.Dialer).To(Equal(proxy.Direct))
+				Expect(socks5Proxy.StartCall.CallCount).To(Equal(0))
+
+				Expect(logger.StepCall.Messages).To(ContainElement("forwarding 127.0.0.1:12345 to " + target + ", press Ctrl-C to stop"))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{"https://127.0.0.1:12345"}))
+
+				Expect(interrupted).To(BeTrue())
+				Expect(portForwarder.CloseCall.CallCount).To(Equal(1))
+			},
+			Entry("director", "director", "10.0.0.6:25555"),
+			Entry("uaa", "uaa", "10.0.0.6:8443"),
+			Entry("credhub", "credhub", "10.0.0.6:8844"),
+		)
+
+		It("listens on the requested local port", func() {
+			err := command.Execute([]string{"--local-port", "8844", "credhub"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(portForwarder.ForwardCall.Receives.LocalPort).To(Equal(8844))
+			Expect(portForwarder.ForwardCall.Receives.Target).To(Equal("10.0.0.6:8844"))
+		})
+
+		Context("when the director is behind a jumpbox", func() {
+			var socks5Client *fakes.Socks5Client
+
+			BeforeEach(func() {
+				incomingState.Jumpbox = storage.Jumpbox{
+					Enabled: true,
+					URL:     "some-jumpbox-url",
+				}
+				sshKeyGetter.GetCall.Returns.PrivateKey = "some-private-key"
+				socks5Proxy.AddrCall.Returns.Addr = "some-socks-proxy-addr"
+
+				socks5Client = &fakes.Socks5Client{}
+				commands.SetProxySOCKS5(func(network, addr string, auth *proxy.Auth, forward proxy.Dialer) (proxy.Dialer, error) {
+					Expect(addr).To(Equal("some-socks-proxy-addr"))
+					return socks5Client, nil
+				})
+			})
+
+			AfterEach(func() {
+				commands.ResetProxySOCKS5()
+			})
+
+			It("dials the service through the socks5 proxy", func() {
+				err := command.Execute([]string{"credhub"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyGetter.GetCall.Receives.State).To(Equal(incomingState))
+				Expect(socks5Proxy.StartCall.Receives.JumpboxPrivateKey).To(Equal("some-private-key"))
+				Expect(socks5Proxy.StartCall.Receives.JumpboxExternalURL).To(Equal("some-jumpbox-url"))
+				Expect(portForwarder.ForwardCall.Receives.Dialer).To(Equal(socks5Client))
+			})
+
+			It("returns an error when the ssh key getter fails", func() {
+				sshKeyGetter.GetCall.Returns.Error = errors.New("failed to get ssh key")
+				err := command.Execute([]string{"credhub"}, incomingState)
+				Expect(err).To(MatchError("failed to get ssh key"))
+			})
+
+			It("returns an error when the socks5 proxy fails to start", func() {
+				socks5Proxy.StartCall.Returns.Error = errors.New("failed to start proxy")
+				err := command.Execute([]string{"credhub"}, incomingState)
+				Expect(err).To(MatchError("failed to start proxy"))
+			})
+
+			It("returns an error when the socks5 dialer cannot be created", func() {
+				commands.SetProxySOCKS5(func(network, addr string, auth *proxy.Auth, forward proxy.Dialer) (proxy.Dialer, error) {
+					return nil, errors.New("failed to create dialer")
+				})
+				err := command.Execute([]string{"credhub"}, incomingState)
+				Expect(err).To(MatchError("failed to create dialer"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"credhub", "--invalid-flag"}, incomingState)
+				Expect(err).To(MatchError("flag provided but not defined: -invalid-flag"))
+			})
+
+			It("returns an error when the port cannot be forwarded", func() {
+				portForwarder.ForwardCall.Returns.Error = errors.New("failed to forward")
+				err := command.Execute([]string{"credhub"}, incomingState)
+				Expect(err).To(MatchError("failed to forward"))
+				Expect(interrupted).To(BeFalse())
+			})
+
+			It("returns an error when the port forwarder fails to close", func() {
+				portForwarder.CloseCall.Returns.Error = errors.New("failed to close")
+				err := command.Execute([]string{"credhub"}, incomingState)
+				Expect(err).To(MatchError("failed to close"))
+			})
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+)
+
+// OpenStackCreateLBs attaches an octavia load balancer. Listeners pass TCP
+// through to the vms, so TLS terminates on the router or the web node and no
+// certificate is uploaded to openstack.
+type OpenStackCreateLBs struct {
+	terraformManager   terraformApplier
+	cloudConfigManager cloudConfigManager
+	stateStore         stateStore
+	logger             logger
+}
+
+type OpenStackCreateLBsConfig struct {
+	LBType       string
+	SkipIfExists bool
+}
+
+func NewOpenStackCreateLBs(terraformManager terraformApplier, cloudConfigManager cloudConfigManager,
+	stateStore stateStore, logger logger) OpenStackCreateLBs {
+	return OpenStackCreateLBs{
+		terraformManager:   terraformManager,
+		cloudConfigManager: cloudConfigManager,
+		stateStore:         stateStore,
+		logger:             logger,
+	}
+}
+
+func (c OpenStackCreateLBs) Execute(config OpenStackCreateLBsConfig, state storage.State) error {
+	err := c.terraformManager.ValidateVersion()
+	if err != nil {
+		return err
+	}
+
+	err = c.checkFastFails(config, state)
+	if err != nil {
+		return err
+	}
+
+	if config.SkipIfExists && config.LBType == state.LB.Type {
+		c.logger.Step(fmt.Sprintf("lb type %q exists, skipping...", config.LBType))
+		return nil
+	}
+
+	state.LB.Type = config.LBType
+
+	state, err = c.terraformManager.Apply(state)
+	switch err.(type) {
+	case terraform.ManagerError:
+		taError := err.(terraform.ManagerError)
+		var bblStateErr error
+		state, bblStateErr = taError.BBLState()
+		if bblStateErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(bblStateErr)
+			return errorList
+		}
+		if setErr := c.stateStore.Set(state); setErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(setErr)
+			return errorList
+		}
+		return taError
+	case error:
+		return err
+	}
+
+	if err := c.stateStore.Set(state); err != nil {
+		return err
+	}
+
+	if !state.NoDirector {
+		err = c.cloudConfigManager.Update(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (OpenStackCreateLBs) checkFastFails(config OpenStackCreateLBsConfig, state storage.State) error {
+	if config.LBType == "" {
+		return fmt.Errorf("--type is a required flag")
+	}
+
+	if config.LBType != "concourse" && config.LBType != "cf" {
+		return fmt.Errorf("%q is not a valid lb type, valid lb types are: concourse, cf", config.LBType)
+	}
+
+	if state.IAAS != "openstack" {
+		return fmt.Errorf("iaas type must be openstack")
+	}
+
+	if state.TFState == "" {
+		return BBLNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenStackCreateLBs", func() {
+	var (
+		terraformManager       *fakes.TerraformManager
+		cloudConfigManager     *fakes.CloudConfigManager
+		stateStore             *fakes.StateStore
+		logger                 *fakes.Logger
+		terraformExecutorError *fakes.TerraformExecutorError
+
+		bblState storage.State
+		command  commands.OpenStackCreateLBs
+	)
+
+	BeforeEach(func() {
+		terraformManager = &fakes.TerraformManager{}
+		cloudConfigManager = &fakes.CloudConfigManager{}
+		stateStore = &fakes.StateStore{}
+		logger = &fakes.Logger{}
+		terraformExecutorError = &fakes.TerraformExecutorError{}
+
+		command = commands.NewOpenStackCreateLBs(terraformManager, cloudConfigManager, stateStore, logger)
+
+		bblState = storage.State{
+			IAAS: "openstack",
+			OpenStack: storage.OpenStack{
+				Region: "some-region",
+			},
+			BOSH: storage.BOSH{
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+				DirectorAddress:  "some-director-address",
+			},
+			TFState: "some-tfstate",
+		}
+	})
+
+	Describe("Execute", func() {
+		DescribeTable("calls terraform manager apply with the lb type",
+			func(lbType string) {
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: lbType,
+				}, bblState)
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedState := bblState
+				expectedState.LB = storage.LB{Type: lbType}
+
+				Expect(terraformManager.ValidateVersionCall.CallCount).To(Equal(1))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(expectedState))
+			},
+			Entry("cf", "cf"),
+			Entry("concourse", "concourse"),
+		)
+
+		It("saves the updated tfstate", func() {
+			updatedState := bblState
+			updatedState.LB = storage.LB{Type: "concourse"}
+			updatedState.TFState = "some-new-tfstate"
+			terraformManager.ApplyCall.Returns.BBLState = updatedState
+
+			err := command.Execute(commands.OpenStackCreateLBsConfig{
+				LBType: "concourse",
+			}, bblState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(updatedState))
+		})
+
+		It("uploads a new cloud-config to the bosh director", func() {
+			terraformManager.ApplyCall.Returns.BBLState = bblState
+
+			err := command.Execute(commands.OpenStackCreateLBsConfig{
+				LBType: "concourse",
+			}, bblState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+			Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(bblState))
+		})
+
+		It("no-ops if SkipIfExists is supplied and the LBType does not change", func() {
+			bblState.LB.Type = "concourse"
+			err := command.Execute(commands.OpenStackCreateLBsConfig{
+				LBType:       "concourse",
+				SkipIfExists: true,
+			}, bblState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.StepCall.Messages).To(ContainElement(`lb type "concourse" exists, skipping...`))
+			Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+			Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+		})
+
+		Context("when there is no BOSH director", func() {
+			It("does not call the CloudConfigManager", func() {
+				terraformManager.ApplyCall.Returns.BBLState.NoDirector = true
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, storage.State{
+					IAAS:       "openstack",
+					TFState:    "some-prev-tf-state",
+					NoDirector: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error if terraform manager version validator fails", func() {
+				terraformManager.ValidateVersionCall.Returns.Error = errors.New("cannot validate version")
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, bblState)
+				Expect(err).To(MatchError("cannot validate version"))
+			})
+
+			It("returns a helpful error when no lb type is provided", func() {
+				err := command.Execute(commands.OpenStackCreateLBsConfig{}, bblState)
+				Expect(err).To(MatchError("--type is a required flag"))
+			})
+
+			It("returns an error when the lb type is not concourse or cf", func() {
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "some-fake-lb",
+				}, bblState)
+				Expect(err).To(MatchError(`"some-fake-lb" is not a valid lb type, valid lb types are: concourse, cf`))
+			})
+
+			It("returns an error when the iaas type is not openstack", func() {
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, storage.State{IAAS: "aws", TFState: "some-tf-state"})
+				Expect(err).To(MatchError("iaas type must be openstack"))
+			})
+
+			It("returns a BBLNotFound error when the tf state is empty", func() {
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, storage.State{IAAS: "openstack"})
+				Expect(err).To(MatchError(commands.BBLNotFound))
+			})
+
+			It("saves the tf state even if the applier fails", func() {
+				terraformExecutorError.TFStateCall.Returns.TFState = "some-updated-tf-state"
+				terraformExecutorError.ErrorCall.Returns = "failed to apply"
+				terraformManager.ApplyCall.Returns.Error = terraform.NewManagerError(bblState, terraformExecutorError)
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, bblState)
+				Expect(err).To(MatchError("failed to apply"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State.TFState).To(Equal("some-updated-tf-state"))
+			})
+
+			It("returns an error if terraform manager apply fails with non terraform manager apply error", func() {
+				terraformManager.ApplyCall.Returns.Error = errors.New("failed to apply")
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "cf",
+				}, bblState)
+				Expect(err).To(MatchError("failed to apply"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when both the applier fails and terraformManagerError.BBLState fails", func() {
+				terraformExecutorError.TFStateCall.Returns.Error = errors.New("failed to get tf state")
+				terraformExecutorError.ErrorCall.Returns = "failed to apply"
+				terraformManager.ApplyCall.Returns.Error = terraform.NewManagerError(bblState, terraformExecutorError)
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, bblState)
+				Expect(err).To(MatchError("the following errors occurred:\nfailed to apply,\nfailed to get tf state"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when both the applier fails and state fails to be set", func() {
+				terraformExecutorError.TFStateCall.Returns.TFState = "some-updated-tf-state"
+				terraformExecutorError.ErrorCall.Returns = "failed to apply"
+				terraformManager.ApplyCall.Returns.Error = terraform.NewManagerError(bblState, terraformExecutorError)
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{errors.New("state failed to be set")}}
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, bblState)
+				Expect(err).To(MatchError("the following errors occurred:\nfailed to apply,\nstate failed to be set"))
+			})
+
+			It("returns an error when the state store fails to save the state after applying terraform", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to save state")}}
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, bblState)
+				Expect(err).To(MatchError("failed to save state"))
+			})
+
+			It("returns an error when the cloud config fails to be updated", func() {
+				terraformManager.ApplyCall.Returns.BBLState = bblState
+				cloudConfigManager.UpdateCall.Returns.Error = errors.New("failed to update cloud config")
+
+				err := command.Execute(commands.OpenStackCreateLBsConfig{
+					LBType: "concourse",
+				}, bblState)
+				Expect(err).To(MatchError("failed to update cloud config"))
+			})
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type openstackClient interface {
+	ValidateCredentials(authURL, username, password, domain, project, region string) error
+}
+
+type OpenStackUpConfig terraformUpConfig
+
+type OpenStackUp struct {
+	openstackClient openstackClient
+	terraformUp     terraformUp
+}
+
+type NewOpenStackUpArgs struct {
+	OpenStackClient    openstackClient
+	StateStore         stateStore
+	KeyPairManager     keyPairManager
+	BoshManager        boshManager
+	CloudConfigManager cloudConfigManager
+	TerraformManager   terraformApplier
+	EnvIDManager       envIDManager
+	Logger             logger
+}
+
+func NewOpenStackUp(args NewOpenStackUpArgs) OpenStackUp {
+	return OpenStackUp{
+		openstackClient: args.OpenStackClient,
+		terraformUp: terraformUp{
+			stateStore:         args.StateStore,
+			keyPairManager:     args.KeyPairManager,
+			boshManager:        args.BoshManager,
+			cloudConfigManager: args.CloudConfigManager,
+			terraformManager:   args.TerraformManager,
+			envIDManager:       args.EnvIDManager,
+			logger:             args.Logger,
+		},
+	}
+}
+
+func (u OpenStackUp) Execute(upConfig OpenStackUpConfig, state storage.State) error {
+	u.terraformUp.logger.Step("verifying credentials")
+	openstack := state.OpenStack
+	err := u.openstackClient.ValidateCredentials(openstack.AuthURL, openstack.Username, openstack.Password, openstack.Domain, openstack.Project, openstack.Region)
+	if err != nil {
+		return fmt.Errorf("Error: credentials are invalid: %s", err)
+	}
+
+	return u.terraformUp.execute(terraformUpConfig(upConfig), state, func(storage.State) error { return nil })
+}
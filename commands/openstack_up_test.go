@@ -0,0 +1,257 @@
+package commands_test
+
+import (
+	"errors"
+	"io/ioutil"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenStackUp", func() {
+	var (
+		openstackUp commands.OpenStackUp
+
+		openstackClient    *fakes.OpenStackClient
+		stateStore         *fakes.StateStore
+		keyPairManager     *fakes.KeyPairManager
+		terraformManager   *fakes.TerraformManager
+		boshManager        *fakes.BOSHManager
+		cloudConfigManager *fakes.CloudConfigManager
+		envIDManager       *fakes.EnvIDManager
+		logger             *fakes.Logger
+
+		incomingState          storage.State
+		expectedEnvIDState     storage.State
+		expectedKeyPairState   storage.State
+		expectedTerraformState storage.State
+		expectedBOSHState      storage.State
+	)
+
+	BeforeEach(func() {
+		openstackClient = &fakes.OpenStackClient{}
+		stateStore = &fakes.StateStore{}
+		keyPairManager = &fakes.KeyPairManager{}
+		terraformManager = &fakes.TerraformManager{}
+		boshManager = &fakes.BOSHManager{}
+		cloudConfigManager = &fakes.CloudConfigManager{}
+		envIDManager = &fakes.EnvIDManager{}
+		logger = &fakes.Logger{}
+
+		incomingState = storage.State{
+			IAAS: "openstack",
+			OpenStack: storage.OpenStack{
+				AuthURL:         "some-auth-url",
+				AZ:              "some-az",
+				ExternalNetwork: "some-external-network",
+				Region:          "some-region",
+				Username:        "some-username",
+				Password:        "some-password",
+				Domain:          "some-domain",
+				Project:         "some-project",
+			},
+		}
+
+		expectedEnvIDState = incomingState
+		expectedEnvIDState.EnvID = "some-env-id"
+
+		expectedKeyPairState = expectedEnvIDState
+		expectedKeyPairState.KeyPair = storage.KeyPair{
+			PrivateKey: "some-private-key",
+			PublicKey:  "some-public-key",
+		}
+		expectedKeyPairState = commands.CheckpointUpPhase(expectedKeyPairState, commands.UpPhaseKeyPair)
+
+		expectedTerraformState = expectedKeyPairState
+		expectedTerraformState.TFState = "some-tf-state"
+		expectedTerraformState = commands.CheckpointUpPhase(expectedTerraformState, commands.UpPhaseTerraform)
+
+		expectedBOSHState = expectedTerraformState
+		expectedBOSHState.BOSH = storage.BOSH{
+			DirectorName: "bosh-some-env-id",
+			Manifest:     "some-bosh-manifest",
+		}
+		expectedBOSHState = commands.CheckpointUpPhase(expectedBOSHState, commands.UpPhaseBOSH)
+
+		envIDManager.SyncCall.Returns.State = expectedEnvIDState
+		keyPairManager.SyncCall.Returns.KeyPair = expectedKeyPairState.KeyPair
+		terraformManager.ApplyCall.Returns.BBLState = expectedTerraformState
+		terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+			"net_id": "some-net-id",
+		}
+		boshManager.CreateDirectorCall.Returns.State = expectedBOSHState
+
+		openstackUp = commands.NewOpenStackUp(commands.NewOpenStackUpArgs{
+			OpenStackClient:    openstackClient,
+			StateStore:         stateStore,
+			KeyPairManager:     keyPairManager,
+			BoshManager:        boshManager,
+			CloudConfigManager: cloudConfigManager,
+			TerraformManager:   terraformManager,
+			EnvIDManager:       envIDManager,
+			Logger:             logger,
+		})
+	})
+
+	Describe("Execute", func() {
+		It("creates the environment", func() {
+			err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("validating the credentials", func() {
+				Expect(logger.StepCall.Messages).To(ContainElement("verifying credentials"))
+				Expect(openstackClient.ValidateCredentialsCall.CallCount).To(Equal(1))
+				Expect(openstackClient.ValidateCredentialsCall.Receives.AuthURL).To(Equal("some-auth-url"))
+				Expect(openstackClient.ValidateCredentialsCall.Receives.Username).To(Equal("some-username"))
+				Expect(openstackClient.ValidateCredentialsCall.Receives.Password).To(Equal("some-password"))
+				Expect(openstackClient.ValidateCredentialsCall.Receives.Domain).To(Equal("some-domain"))
+				Expect(openstackClient.ValidateCredentialsCall.Receives.Project).To(Equal("some-project"))
+				Expect(openstackClient.ValidateCredentialsCall.Receives.Region).To(Equal("some-region"))
+			})
+
+			By("retrieving the env ID and saving it to the state", func() {
+				Expect(envIDManager.SyncCall.CallCount).To(Equal(1))
+				Expect(envIDManager.SyncCall.Receives.State).To(Equal(incomingState))
+				Expect(stateStore.SetCall.Receives[0].State).To(Equal(expectedEnvIDState))
+			})
+
+			By("syncing the keypair and saving it to the state", func() {
+				Expect(keyPairManager.SyncCall.CallCount).To(Equal(1))
+				Expect(keyPairManager.SyncCall.Receives.State).To(Equal(expectedEnvIDState))
+				Expect(stateStore.SetCall.Receives[1].State).To(Equal(expectedKeyPairState))
+			})
+
+			By("creating openstack resources via terraform and saving the terraform state", func() {
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(expectedKeyPairState))
+				Expect(stateStore.SetCall.Receives[2].State).To(Equal(expectedTerraformState))
+			})
+
+			By("creating a bosh director with the terraform outputs", func() {
+				Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(expectedTerraformState))
+				Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(expectedTerraformState))
+				Expect(stateStore.SetCall.Receives[3].State).To(Equal(expectedBOSHState))
+			})
+
+			By("updating the cloud config", func() {
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(expectedBOSHState))
+			})
+
+			By("clearing the checkpoints once every phase has completed", func() {
+				finalState := expectedBOSHState
+				finalState.UpCheckpoints = nil
+				Expect(stateStore.SetCall.CallCount).To(Equal(5))
+				Expect(stateStore.SetCall.Receives[4].State).To(Equal(finalState))
+			})
+		})
+
+		Context("when an ops file is provided", func() {
+			It("passes the ops file contents to the director", func() {
+				opsFile, err := ioutil.TempFile("", "ops-file")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = opsFile.Write([]byte("some-ops-file-contents"))
+				Expect(err).NotTo(HaveOccurred())
+
+				err = openstackUp.Execute(commands.OpenStackUpConfig{OpsFilePaths: []string{opsFile.Name()}}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.UserOpsFiles).To(Equal([]string{"some-ops-file-contents"}))
+			})
+		})
+
+		Context("when a name is provided", func() {
+			It("passes the name to the env id manager", func() {
+				err := openstackUp.Execute(commands.OpenStackUpConfig{Name: "some-name"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.Name).To(Equal("some-name"))
+			})
+		})
+
+		Context("when no director is requested", func() {
+			It("creates the infrastructure without a director", func() {
+				terraformManager.ApplyCall.Returns.BBLState.NoDirector = true
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{NoDirector: true}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.NoDirector).To(BeTrue())
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when a director already exists", func() {
+				incomingState.BOSH = storage.BOSH{DirectorName: "some-director"}
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{NoDirector: true}, incomingState)
+				Expect(err).To(MatchError(`Director already exists, you must re-create your environment to use "--no-director"`))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the credentials are invalid", func() {
+				openstackClient.ValidateCredentialsCall.Returns.Error = errors.New("failed to authenticate with keystone: 401 Unauthorized")
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+				Expect(err).To(MatchError("Error: credentials are invalid: failed to authenticate with keystone: 401 Unauthorized"))
+				Expect(envIDManager.SyncCall.CallCount).To(Equal(0))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the terraform version is invalid", func() {
+				terraformManager.ValidateVersionCall.Returns.Error = errors.New("terraform too old")
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+				Expect(err).To(MatchError("terraform too old"))
+			})
+
+			It("returns an error when the keypair cannot be synced", func() {
+				keyPairManager.SyncCall.Returns.Error = errors.New("failed to sync keypair")
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to sync keypair"))
+			})
+
+			It("returns an error when terraform fails to apply", func() {
+				terraformManager.ApplyCall.Returns.Error = errors.New("failed to apply")
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to apply"))
+			})
+
+			It("returns an error when the terraform outputs cannot be retrieved", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to get outputs"))
+			})
+
+			It("saves the bosh state when the director fails to be created", func() {
+				failedState := expectedTerraformState
+				failedState.BOSH.State = map[string]interface{}{"partial": "state"}
+				boshManager.CreateDirectorCall.Returns.Error = bosh.NewManagerCreateError(failedState, errors.New("failed to create"))
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to create"))
+
+				Expect(stateStore.SetCall.Receives[stateStore.SetCall.CallCount-1].State).To(Equal(failedState))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the cloud config cannot be updated", func() {
+				cloudConfigManager.UpdateCall.Returns.Error = errors.New("failed to update cloud config")
+
+				err := openstackUp.Execute(commands.OpenStackUpConfig{}, incomingState)
+				Expect(err).To(MatchError("failed to update cloud config"))
+			})
+		})
+	})
+})
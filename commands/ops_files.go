@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"io/ioutil"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func readOpsFiles(paths []string) ([]string, error) {
+	var opsFiles []string
+	for _, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		opsFiles = append(opsFiles, string(contents))
+	}
+
+	return opsFiles, nil
+}
+
+// updateUserOpsFiles replaces the ops files saved in the state when --ops-file
+// is passed. Otherwise the saved ops files are kept so that every up applies them.
+func updateUserOpsFiles(state storage.State, opsFiles []string) storage.State {
+	if len(opsFiles) == 0 {
+		return state
+	}
+
+	state.BOSH.UserOpsFile = ""
+	state.BOSH.UserOpsFiles = opsFiles
+	return state
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	OutputsCommand = "outputs"
+)
+
+type Outputs struct {
+	logger                logger
+	stateValidator        stateValidator
+	terraformManager      terraformOutputter
+	infrastructureManager infrastructureManager
+}
+
+type outputsConfig struct {
+	json bool
+}
+
+func NewOutputs(logger logger, stateValidator stateValidator, terraformManager terraformOutputter, infrastructureManager infrastructureManager) Outputs {
+	return Outputs{
+		logger:                logger,
+		stateValidator:        stateValidator,
+		terraformManager:      terraformManager,
+		infrastructureManager: infrastructureManager,
+	}
+}
+
+func (o Outputs) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := o.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = o.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.TFState == "" && state.Stack.Name == "" {
+		return errors.New("bbl outputs requires an environment created with terraform or cloudformation")
+	}
+
+	return nil
+}
+
+func (o Outputs) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := o.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := o.outputs(state)
+	if err != nil {
+		return err
+	}
+
+	if config.json {
+		return printJSON(o.logger, outputs)
+	}
+
+	var names []string
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		value, ok := outputs[name].(string)
+		if !ok {
+			contents, err := json.Marshal(outputs[name])
+			if err != nil {
+				return err //not tested
+			}
+			value = string(contents)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: %s", name, value))
+	}
+
+	o.logger.Println(strings.Join(lines, "\n"))
+	return nil
+}
+
+// outputs returns the terraform outputs, or the stack outputs of AWS
+// environments that were created with cloudformation and not migrated.
+func (o Outputs) outputs(state storage.State) (map[string]interface{}, error) {
+	if state.TFState != "" {
+		return o.terraformManager.GetOutputs(state)
+	}
+
+	stack, err := o.infrastructureManager.Describe(state.Stack.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]interface{}{}
+	for name, value := range stack.Outputs {
+		outputs[name] = value
+	}
+
+	return outputs, nil
+}
+
+func (o Outputs) parseArgs(args []string) (outputsConfig, error) {
+	var config outputsConfig
+
+	outputsFlags := flags.New(OutputsCommand)
+	outputsFlags.Bool(&config.json, "", "json", false)
+
+	err := outputsFlags.Parse(args)
+	if err != nil {
+		return outputsConfig{}, err
+	}
+
+	if len(outputsFlags.Args()) > 0 {
+		return outputsConfig{}, errors.New("bbl outputs does not take any arguments, use bbl terraform-output to print a single output")
+	}
+
+	return config, nil
+}
@@ -0,0 +1,128 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Outputs", func() {
+	var (
+		logger                *fakes.Logger
+		stateValidator        *fakes.StateValidator
+		terraformManager      *fakes.TerraformManager
+		infrastructureManager *fakes.InfrastructureManager
+
+		command commands.Outputs
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		terraformManager = &fakes.TerraformManager{}
+		infrastructureManager = &fakes.InfrastructureManager{}
+
+		terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+			"director_address": "some-director-address",
+			"extra_ips":        []interface{}{"10.0.0.1", "10.0.0.2"},
+			"router_pools":     map[string]interface{}{"z1": "some-pool"},
+		}
+
+		state = storage.State{
+			IAAS:    "gcp",
+			TFState: "some-tf-state",
+		}
+
+		command = commands.NewOutputs(logger, stateValidator, terraformManager, infrastructureManager)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when arguments are given", func() {
+			err := command.CheckFastFails([]string{"director_address"}, state)
+			Expect(err).To(MatchError("bbl outputs does not take any arguments, use bbl terraform-output to print a single output"))
+		})
+
+		It("returns an error when the environment has no outputs", func() {
+			err := command.CheckFastFails([]string{}, storage.State{IAAS: "gcp"})
+			Expect(err).To(MatchError("bbl outputs requires an environment created with terraform or cloudformation"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints every terraform output sorted by name", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(state))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`director_address: some-director-address
+extra_ips: ["10.0.0.1","10.0.0.2"]
+router_pools: {"z1":"some-pool"}`))
+		})
+
+		Context("when --json is provided", func() {
+			It("prints every terraform output as json", func() {
+				err := command.Execute([]string{"--json"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+					"director_address": "some-director-address",
+					"extra_ips": ["10.0.0.1", "10.0.0.2"],
+					"router_pools": {"z1": "some-pool"}
+				}`))
+			})
+		})
+
+		Context("when the aws environment was created with cloudformation", func() {
+			BeforeEach(func() {
+				state = storage.State{
+					IAAS:  "aws",
+					Stack: storage.Stack{Name: "some-stack-name"},
+				}
+				infrastructureManager.DescribeCall.Returns.Stack = cloudformation.Stack{
+					Outputs: map[string]string{
+						"BOSHSubnet": "some-subnet-id",
+						"BOSHEIP":    "some-eip",
+					},
+				}
+			})
+
+			It("prints the stack outputs", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(0))
+				Expect(infrastructureManager.DescribeCall.Receives.StackName).To(Equal("some-stack-name"))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("BOSHEIP: some-eip\nBOSHSubnet: some-subnet-id"))
+			})
+
+			It("returns an error when the stack cannot be described", func() {
+				infrastructureManager.DescribeCall.Returns.Error = errors.New("failed to describe stack")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to describe stack"))
+			})
+		})
+
+		It("returns an error when the terraform outputs cannot be retrieved", func() {
+			terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+			err := command.Execute([]string{}, state)
+			Expect(err).To(MatchError("failed to get outputs"))
+		})
+	})
+})
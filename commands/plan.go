@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	PlanCommand = "plan"
+
+	PlanTemplateFile    = "terraform/bbl-template.tf"
+	PlanManifestFile    = "bosh-deployment/director.yml"
+	PlanCloudConfigFile = "cloud-config/cloud-config.yml"
+)
+
+// Plan writes what bbl up would deploy into the state dir and prints how it
+// differs from what is deployed, with the director credentials redacted. It
+// never saves the state, so an environment id generated for a new
+// environment is only used for the plan.
+type Plan struct {
+	terraformManager   terraformPlanGenerator
+	boshManager        boshManager
+	cloudConfigManager cloudConfigManager
+	envIDManager       envIDManager
+	stateDir           string
+	logger             logger
+}
+
+type planConfig struct {
+	name string
+}
+
+func NewPlan(terraformManager terraformPlanGenerator, boshManager boshManager, cloudConfigManager cloudConfigManager,
+	envIDManager envIDManager, stateDir string, logger logger) Plan {
+	return Plan{
+		terraformManager:   terraformManager,
+		boshManager:        boshManager,
+		cloudConfigManager: cloudConfigManager,
+		envIDManager:       envIDManager,
+		stateDir:           stateDir,
+		logger:             logger,
+	}
+}
+
+func (p Plan) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := p.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	err = p.terraformManager.ValidateVersion()
+	if err != nil {
+		return err
+	}
+
+	if planDirector(state) {
+		return fastFailBOSHVersion(p.boshManager)
+	}
+
+	return nil
+}
+
+func (p Plan) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := p.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.EnvID == "" {
+		state, err = p.envIDManager.Sync(state, config.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	sections := []string{}
+
+	err = p.writeFile(PlanTemplateFile, p.terraformManager.Template(state))
+	if err != nil {
+		return err
+	}
+
+	plan, err := p.terraformManager.Plan(state)
+	if err != nil {
+		return err
+	}
+	sections = append(sections, planSection("Terraform plan", PlanTemplateFile, strings.TrimSpace(plan)))
+
+	if !planDirector(state) {
+		sections = append(sections, "The director manifest and cloud config are planned once the infrastructure exists.")
+		p.logger.Println(strings.Join(sections, "\n\n"))
+		return nil
+	}
+
+	terraformOutputs, err := p.terraformManager.GetOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := p.boshManager.DirectorManifest(state, terraformOutputs)
+	if err != nil {
+		return err
+	}
+
+	err = p.writeFile(PlanManifestFile, manifest)
+	if err != nil {
+		return err
+	}
+
+	manifestDiff, err := redactedDiff(state.BOSH.Manifest, manifest, state.BOSH.Variables)
+	if err != nil {
+		return err
+	}
+	sections = append(sections, planSection("Director manifest", PlanManifestFile, manifestDiff))
+
+	cloudConfig, err := p.cloudConfigManager.Generate(state)
+	if err != nil {
+		return err
+	}
+
+	err = p.writeFile(PlanCloudConfigFile, cloudConfig)
+	if err != nil {
+		return err
+	}
+
+	currentCloudConfig := ""
+	if !state.BOSH.IsEmpty() {
+		currentCloudConfig, err = p.cloudConfigManager.Current(state)
+		if err != nil {
+			return err
+		}
+	}
+	sections = append(sections, planSection("Cloud config", PlanCloudConfigFile, diffLines(currentCloudConfig, cloudConfig)))
+
+	p.logger.Println(strings.Join(sections, "\n\n"))
+	return nil
+}
+
+func (p Plan) parseArgs(subcommandFlags []string) (planConfig, error) {
+	var config planConfig
+
+	planFlags := flags.New(PlanCommand)
+	planFlags.String(&config.name, "name", "")
+
+	err := planFlags.Parse(subcommandFlags)
+	if err != nil {
+		return planConfig{}, err
+	}
+
+	return config, nil
+}
+
+// writeFile replaces name in the state dir with contents. The files can hold
+// director credentials and are only readable by the owner.
+func (p Plan) writeFile(name, contents string) error {
+	path := filepath.Join(p.stateDir, name)
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, []byte(contents), os.FileMode(0600))
+}
+
+// planDirector reports whether the director can be planned. Its manifest and
+// the cloud config are generated from terraform outputs.
+func planDirector(state storage.State) bool {
+	return state.TFState != "" && !state.NoDirector
+}
+
+func planSection(title, file, body string) string {
+	if body == "" {
+		body = "No changes."
+	}
+
+	return fmt.Sprintf("%s (%s):\n%s", title, file, body)
+}
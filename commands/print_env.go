@@ -1,32 +1,73 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/proxy"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
+const (
+	posixShell      = "posix"
+	fishShell       = "fish"
+	powershellShell = "powershell"
+	cmdShell        = "cmd"
+)
+
 type PrintEnv struct {
 	stateValidator   stateValidator
 	logger           logger
 	terraformManager terraformOutputter
+	sshAgent         sshAgent
+}
+
+type sshAgent interface {
+	Add(key string) (proxy.AgentEnv, error)
+}
+
+type printEnvConfig struct {
+	shell        string
+	json         bool
+	metadataFile string
+	sshAgent     bool
+}
+
+// envVar is one variable print-env sets. Multiline values are quoted for the
+// shell, and secrets are left out of the metadata file, since Concourse shows
+// it in the build log.
+type envVar struct {
+	name      string
+	value     string
+	multiline bool
+	secret    bool
+}
+
+type metadataField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 type envSetter interface {
 	Set(key, value string) error
 }
 
-func NewPrintEnv(logger logger, stateValidator stateValidator, terraformManager terraformOutputter) PrintEnv {
+func NewPrintEnv(logger logger, stateValidator stateValidator, terraformManager terraformOutputter, sshAgent sshAgent) PrintEnv {
 	return PrintEnv{
 		stateValidator:   stateValidator,
 		logger:           logger,
 		terraformManager: terraformManager,
+		sshAgent:         sshAgent,
 	}
 }
 
@@ -36,61 +77,289 @@ func (p PrintEnv) CheckFastFails(subcommandFlags []string, state storage.State)
 		return err
 	}
 
+	_, err = p.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (p PrintEnv) Execute(args []string, state storage.State) error {
+	config, err := p.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// the json document and the metadata file are read by programs, which can
+	// reach the director through the jumpbox without a tunnel.
+	structured := config.json || config.metadataFile != ""
+
+	shell := config.shell
+	if structured {
+		shell = posixShell
+	}
+
+	vars, tunnel, err := p.variables(state, shell, structured, config.sshAgent)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case config.metadataFile != "":
+		return writeMetadataFile(config.metadataFile, vars)
+	case config.json:
+		document := map[string]string{}
+		for _, v := range vars {
+			document[v.name] = v.value
+		}
+		return printJSON(p.logger, document)
+	}
+
+	for _, v := range vars {
+		if v.multiline {
+			p.logger.Println(quotedExportLine(shell, v.name, v.value))
+		} else {
+			p.logger.Println(exportLine(shell, v.name, v.value))
+		}
+	}
+
+	if tunnel != "" {
+		p.logger.Println(tunnel)
+	}
+
+	return nil
+}
+
+// variables returns the variables for the bosh and credhub clis, and the ssh
+// command that opens the tunnel through the jumpbox they proxy over. With
+// direct, the clis proxy over ssh to the jumpbox themselves instead. With
+// useAgent, the jumpbox key is loaded into an ssh agent rather than written
+// to a file.
+func (p PrintEnv) variables(state storage.State, shell string, direct, useAgent bool) ([]envVar, string, error) {
 	if state.NoDirector {
 		directorAddress, err := p.getExternalIP(state)
 		if err != nil {
-			return err
+			return nil, "", err
 		}
-		p.logger.Println(fmt.Sprintf("export BOSH_ENVIRONMENT=https://%s:25555", directorAddress))
 
-		return nil
+		return []envVar{{name: "BOSH_ENVIRONMENT", value: fmt.Sprintf("https://%s:25555", directorAddress)}}, "", nil
+	}
+
+	vars := []envVar{
+		{name: "BOSH_CLIENT", value: state.BOSH.DirectorUsername},
+		{name: "BOSH_CLIENT_SECRET", value: state.BOSH.DirectorPassword, secret: true},
+		{name: "BOSH_ENVIRONMENT", value: state.BOSH.DirectorAddress},
+		{name: "BOSH_CA_CERT", value: state.BOSH.DirectorSSLCA, multiline: true},
 	}
 
-	p.logger.Println(fmt.Sprintf("export BOSH_CLIENT=%s", state.BOSH.DirectorUsername))
-	p.logger.Println(fmt.Sprintf("export BOSH_CLIENT_SECRET=%s", state.BOSH.DirectorPassword))
-	p.logger.Println(fmt.Sprintf("export BOSH_ENVIRONMENT=%s", state.BOSH.DirectorAddress))
-	p.logger.Println(fmt.Sprintf("export BOSH_CA_CERT='%s'", state.BOSH.DirectorSSLCA))
+	if !state.Jumpbox.Enabled {
+		return vars, "", nil
+	}
+
+	privateKeyContents, err := p.privateKeyFromJumpboxVariables(state.Jumpbox.Variables)
+	if err != nil {
+		return nil, "", err
+	}
 
-	if state.Jumpbox.Enabled {
-		portNumber, err := p.getPort()
+	var privateKeyPath string
+	if useAgent {
+		agentEnv, err := p.sshAgent.Add(privateKeyContents)
 		if err != nil {
-			// not tested
-			return err
+			return nil, "", err
 		}
 
+		if agentEnv.Socket != "" {
+			vars = append(vars,
+				envVar{name: "SSH_AUTH_SOCK", value: agentEnv.Socket},
+				envVar{name: "SSH_AGENT_PID", value: agentEnv.PID},
+			)
+		}
+	} else {
 		dir, err := ioutil.TempDir("", "bosh-jumpbox")
 		if err != nil {
 			// not tested
-			return err
+			return nil, "", err
 		}
 
-		privateKeyPath := filepath.Join(dir, "bosh_jumpbox_private.key")
-
-		privateKeyContents, err := p.privateKeyFromJumpboxVariables(state.Jumpbox.Variables)
-		if err != nil {
-			return err
-		}
+		privateKeyPath = filepath.Join(dir, "bosh_jumpbox_private.key")
 
 		err = ioutil.WriteFile(privateKeyPath, []byte(privateKeyContents), 0600)
 		if err != nil {
 			// not tested
-			return err
+			return nil, "", err
+		}
+	}
+
+	var tunnel string
+	if direct {
+		vars = append(vars,
+			envVar{name: "BOSH_ALL_PROXY", value: fmt.Sprintf("ssh+socks5://jumpbox@%s?private-key=%s", state.Jumpbox.URL, privateKeyPath)},
+			envVar{name: "BOSH_GW_PRIVATE_KEY", value: privateKeyPath},
+			envVar{name: "JUMPBOX_URL", value: state.Jumpbox.URL},
+		)
+	} else {
+		portNumber := strconv.Itoa(state.Jumpbox.ProxyPort)
+		if state.Jumpbox.ProxyPort == 0 {
+			portNumber, err = p.getPort()
+			if err != nil {
+				// not tested
+				return nil, "", err
+			}
 		}
 
 		jumpboxURL := strings.Split(state.Jumpbox.URL, ":")[0]
 
-		p.logger.Println(fmt.Sprintf("export BOSH_ALL_PROXY=socks5://localhost:%s", portNumber))
-		p.logger.Println(fmt.Sprintf("export BOSH_GW_PRIVATE_KEY=%s", privateKeyPath))
-		p.logger.Println(fmt.Sprintf("ssh -f -N -o StrictHostKeyChecking=no -D %s jumpbox@%s -i $BOSH_GW_PRIVATE_KEY", portNumber, jumpboxURL))
+		sshPortOption := ""
+		if _, sshPort, err := net.SplitHostPort(state.Jumpbox.URL); err == nil && sshPort != "22" {
+			sshPortOption = fmt.Sprintf(" -p %s", sshPort)
+		}
+
+		vars = append(vars, envVar{name: "BOSH_ALL_PROXY", value: fmt.Sprintf("socks5://localhost:%s", portNumber)})
+
+		identityOption := ""
+		if !useAgent {
+			vars = append(vars, envVar{name: "BOSH_GW_PRIVATE_KEY", value: privateKeyPath})
+			identityOption = fmt.Sprintf(" -i %s", envReference(shell, "BOSH_GW_PRIVATE_KEY"))
+		}
+		tunnel = fmt.Sprintf("ssh -f -N -o StrictHostKeyChecking=no -D %s%s jumpbox@%s%s", portNumber, sshPortOption, jumpboxURL, identityOption)
+	}
+
+	credhubVars, err := p.credhubFromDirectorVariables(state.BOSH.Variables)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if credhubVars.CLIPassword != "" {
+		if directorURL, err := url.Parse(state.BOSH.DirectorAddress); err == nil && directorURL.Hostname() != "" {
+			vars = append(vars, envVar{name: "CREDHUB_SERVER", value: fmt.Sprintf("https://%s:8844", directorURL.Hostname())})
+		}
+		vars = append(vars,
+			envVar{name: "CREDHUB_CA_CERT", value: credhubVars.TLS.CA, multiline: true},
+			envVar{name: "CREDHUB_USERNAME", value: "credhub-cli"},
+			envVar{name: "CREDHUB_PASSWORD", value: credhubVars.CLIPassword, secret: true},
+		)
+	}
+
+	return vars, tunnel, nil
+}
+
+// writeMetadataFile writes the variables as the name and value pairs of a
+// Concourse resource's metadata.
+func writeMetadataFile(path string, vars []envVar) error {
+	fields := []metadataField{}
+	for _, v := range vars {
+		if !v.secret {
+			fields = append(fields, metadataField{Name: v.name, Value: v.value})
+		}
+	}
+
+	contents, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		// not tested
+		return err
+	}
+
+	err = ioutil.WriteFile(path, contents, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the metadata file: %s", err)
 	}
 
 	return nil
 }
 
+func (PrintEnv) parseFlags(subcommandFlags []string) (printEnvConfig, error) {
+	printEnvFlags := flags.New("print-env")
+
+	config := printEnvConfig{}
+	printEnvFlags.String(&config.shell, "shell", posixShell)
+	printEnvFlags.Bool(&config.json, "", "json", false)
+	printEnvFlags.String(&config.metadataFile, "metadata-file", "")
+	printEnvFlags.Bool(&config.sshAgent, "", "ssh-agent", false)
+
+	err := printEnvFlags.Parse(subcommandFlags)
+	if err != nil {
+		return printEnvConfig{}, err
+	}
+
+	switch config.shell {
+	case posixShell, fishShell, powershellShell, cmdShell:
+	default:
+		return printEnvConfig{}, errors.New(`--shell must be one of "posix", "fish", "powershell" or "cmd"`)
+	}
+
+	if config.json && config.metadataFile != "" {
+		return printEnvConfig{}, errors.New("--json cannot be used with --metadata-file")
+	}
+
+	// the bosh cli only proxies over ssh itself with a key file
+	if config.sshAgent && (config.json || config.metadataFile != "") {
+		return printEnvConfig{}, errors.New("--ssh-agent cannot be used with --json or --metadata-file")
+	}
+
+	return config, nil
+}
+
+func exportLine(shell, name, value string) string {
+	switch shell {
+	case fishShell:
+		return fmt.Sprintf("set -gx %s %s", name, value)
+	case powershellShell:
+		return fmt.Sprintf("$env:%s=%s", name, powershellQuote(value))
+	case cmdShell:
+		return fmt.Sprintf("set %s=%s", name, value)
+	default:
+		return fmt.Sprintf("export %s=%s", name, value)
+	}
+}
+
+// quotedExportLine is exportLine for values that may span several lines,
+// such as CA certs.
+func quotedExportLine(shell, name, value string) string {
+	switch shell {
+	case fishShell:
+		value = strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value)
+		return fmt.Sprintf("set -gx %s '%s'", name, value)
+	case powershellShell:
+		return exportLine(shell, name, value)
+	case cmdShell:
+		return cmdFileExportLine(name, value)
+	default:
+		return fmt.Sprintf("export %s='%s'", name, value)
+	}
+}
+
+// cmdFileExportLine writes a multi-line value, which a cmd variable cannot
+// hold, to a file in %TEMP% from the printed lines themselves and points the
+// variable at it; the bosh and credhub clis accept a CA cert path as well.
+// The file is overwritten every time, so nothing piles up in %TEMP%.
+func cmdFileExportLine(name, value string) string {
+	path := fmt.Sprintf(`%%TEMP%%\%s.crt`, strings.ToLower(name))
+
+	escape := strings.NewReplacer("^", "^^", "&", "^&", "|", "^|", "<", "^<", ">", "^>", "(", "^(", ")", "^)", "%", "%%")
+	var echoes []string
+	for _, line := range strings.Split(strings.TrimSuffix(value, "\n"), "\n") {
+		echoes = append(echoes, "echo("+escape.Replace(line))
+	}
+
+	return fmt.Sprintf("(%s) > \"%s\"\n%s", strings.Join(echoes, "& "), path, exportLine(cmdShell, name, path))
+}
+
+func powershellQuote(value string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(value, "'", "''", -1))
+}
+
+func envReference(shell, name string) string {
+	switch shell {
+	case powershellShell:
+		return fmt.Sprintf("$env:%s", name)
+	case cmdShell:
+		return fmt.Sprintf("%%%s%%", name)
+	default:
+		return fmt.Sprintf("$%s", name)
+	}
+}
+
 func (p PrintEnv) getExternalIP(state storage.State) (string, error) {
 	terraformOutputs, err := p.terraformManager.GetOutputs(state)
 	if err != nil {
@@ -130,3 +399,21 @@ func (p PrintEnv) privateKeyFromJumpboxVariables(jumpboxVariables string) (strin
 
 	return jumpboxVars.JumpboxSSH.PrivateKey, nil
 }
+
+type credhubVariables struct {
+	CLIPassword string `yaml:"credhub_cli_password"`
+	TLS         struct {
+		CA string `yaml:"ca"`
+	} `yaml:"credhub_tls"`
+}
+
+func (p PrintEnv) credhubFromDirectorVariables(directorVariables string) (credhubVariables, error) {
+	var credhubVars credhubVariables
+
+	err := yaml.Unmarshal([]byte(directorVariables), &credhubVars)
+	if err != nil {
+		return credhubVariables{}, fmt.Errorf("error unmarshalling director variables: %v", err)
+	}
+
+	return credhubVars, nil
+}
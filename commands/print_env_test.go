@@ -1,12 +1,16 @@
 package commands_test
 
 import (
+	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/proxy"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -17,6 +21,7 @@ var _ = Describe("PrintEnv", func() {
 		logger           *fakes.Logger
 		stateValidator   *fakes.StateValidator
 		terraformManager *fakes.TerraformManager
+		sshAgent         *fakes.SSHAgent
 		printEnv         commands.PrintEnv
 		state            storage.State
 	)
@@ -25,6 +30,7 @@ var _ = Describe("PrintEnv", func() {
 		logger = &fakes.Logger{}
 		stateValidator = &fakes.StateValidator{}
 		terraformManager = &fakes.TerraformManager{}
+		sshAgent = &fakes.SSHAgent{}
 
 		state = storage.State{
 			BOSH: storage.BOSH{
@@ -35,7 +41,7 @@ var _ = Describe("PrintEnv", func() {
 			},
 		}
 
-		printEnv = commands.NewPrintEnv(logger, stateValidator, terraformManager)
+		printEnv = commands.NewPrintEnv(logger, stateValidator, terraformManager, sshAgent)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -44,6 +50,21 @@ var _ = Describe("PrintEnv", func() {
 			err := printEnv.CheckFastFails([]string{}, storage.State{})
 			Expect(err).To(MatchError("failed to validate state"))
 		})
+
+		It("returns an error when the shell is not supported", func() {
+			err := printEnv.CheckFastFails([]string{"--shell", "tcsh"}, storage.State{})
+			Expect(err).To(MatchError(`--shell must be one of "posix", "fish", "powershell" or "cmd"`))
+		})
+
+		It("returns an error when both --json and --metadata-file are passed", func() {
+			err := printEnv.CheckFastFails([]string{"--json", "--metadata-file", "metadata"}, storage.State{})
+			Expect(err).To(MatchError("--json cannot be used with --metadata-file"))
+		})
+
+		It("returns an error when --ssh-agent is passed with --json", func() {
+			err := printEnv.CheckFastFails([]string{"--ssh-agent", "--json"}, storage.State{})
+			Expect(err).To(MatchError("--ssh-agent cannot be used with --json or --metadata-file"))
+		})
 	})
 
 	Describe("Execute", func() {
@@ -87,6 +108,25 @@ jumpbox_ssh:
 				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`ssh -f -N -o StrictHostKeyChecking=no -D \d+ jumpbox@some-magical-jumpbox-url -i \$BOSH_GW_PRIVATE_KEY`)))
 			})
 
+			It("tunnels to the jumpbox on its ssh port when it is not 22", func() {
+				state.Jumpbox.URL = "some-magical-jumpbox-url:2222"
+
+				err := printEnv.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`ssh -f -N -o StrictHostKeyChecking=no -D \d+ -p 2222 jumpbox@some-magical-jumpbox-url -i \$BOSH_GW_PRIVATE_KEY`)))
+			})
+
+			It("tunnels on the proxy port of the state", func() {
+				state.Jumpbox.ProxyPort = 1080
+
+				err := printEnv.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("export BOSH_ALL_PROXY=socks5://localhost:1080"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`ssh -f -N -o StrictHostKeyChecking=no -D 1080 jumpbox@some-magical-jumpbox-url -i \$BOSH_GW_PRIVATE_KEY`)))
+			})
+
 			It("writes private key to file in temp dir", func() {
 				err := printEnv.Execute([]string{}, state)
 				Expect(err).NotTo(HaveOccurred())
@@ -103,6 +143,74 @@ jumpbox_ssh:
 				}
 			})
 
+			Context("when the key is loaded into an ssh agent", func() {
+				It("tunnels with the agent instead of a key file", func() {
+					err := printEnv.Execute([]string{"--ssh-agent"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(sshAgent.AddCall.CallCount).To(Equal(1))
+					Expect(sshAgent.AddCall.Receives.Key).To(Equal("some-private-key"))
+
+					Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`export BOSH_ALL_PROXY=socks5://localhost:\d+`)))
+					Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`^ssh -f -N -o StrictHostKeyChecking=no -D \d+ jumpbox@some-magical-jumpbox-url$`)))
+					Expect(logger.PrintlnCall.Messages).NotTo(ContainElement(MatchRegexp("BOSH_GW_PRIVATE_KEY")))
+					Expect(logger.PrintlnCall.Messages).NotTo(ContainElement(MatchRegexp("SSH_AUTH_SOCK")))
+				})
+
+				It("prints the agent bbl started", func() {
+					sshAgent.AddCall.Returns.AgentEnv = proxy.AgentEnv{Socket: "/tmp/bbl-ssh-agent/agent.sock", PID: "1234"}
+
+					err := printEnv.Execute([]string{"--ssh-agent"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Messages).To(ContainElement("export SSH_AUTH_SOCK=/tmp/bbl-ssh-agent/agent.sock"))
+					Expect(logger.PrintlnCall.Messages).To(ContainElement("export SSH_AGENT_PID=1234"))
+				})
+
+				It("returns an error when the key cannot be added", func() {
+					sshAgent.AddCall.Returns.Error = errors.New("failed to add the jumpbox key to the ssh agent")
+
+					err := printEnv.Execute([]string{"--ssh-agent"}, state)
+					Expect(err).To(MatchError("failed to add the jumpbox key to the ssh agent"))
+				})
+			})
+
+			It("does not print credhub vars when the director has no credhub", func() {
+				err := printEnv.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).NotTo(ContainElement(MatchRegexp("export CREDHUB_")))
+			})
+
+			Context("when the director has credhub", func() {
+				BeforeEach(func() {
+					state.BOSH.DirectorAddress = "https://10.0.0.6:25555"
+					state.BOSH.Variables = `
+credhub_cli_password: some-credhub-password
+credhub_tls:
+  ca: some-credhub-ca
+`
+				})
+
+				It("prints the credhub connection vars", func() {
+					err := printEnv.Execute([]string{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Messages).To(ContainElement("export CREDHUB_SERVER=https://10.0.0.6:8844"))
+					Expect(logger.PrintlnCall.Messages).To(ContainElement("export CREDHUB_CA_CERT='some-credhub-ca'"))
+					Expect(logger.PrintlnCall.Messages).To(ContainElement("export CREDHUB_USERNAME=credhub-cli"))
+					Expect(logger.PrintlnCall.Messages).To(ContainElement("export CREDHUB_PASSWORD=some-credhub-password"))
+				})
+			})
+
+			Context("when the director variables yaml is invalid", func() {
+				It("returns the error", func() {
+					state.BOSH.Variables = "%%%"
+					err := printEnv.Execute([]string{}, state)
+					Expect(err).To(MatchError("error unmarshalling director variables: yaml: could not find expected directive name"))
+				})
+			})
+
 			Context("when the jumpbox variables yaml is invalid", func() {
 				It("returns the error", func() {
 					state.Jumpbox.Variables = "%%%"
@@ -112,6 +220,178 @@ jumpbox_ssh:
 			})
 		})
 
+		Context("when a shell is provided", func() {
+			BeforeEach(func() {
+				state.BOSH.DirectorSSLCA = "some-director-ca-cert\nwith-a-quote'"
+				state.Jumpbox = storage.Jumpbox{
+					Enabled: true,
+					URL:     "some-magical-jumpbox-url:22",
+					Variables: `
+jumpbox_ssh:
+  private_key: some-private-key
+`,
+				}
+			})
+
+			It("prints fish set commands", func() {
+				err := printEnv.Execute([]string{"--shell", "fish"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("set -gx BOSH_CLIENT some-director-username"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("set -gx BOSH_CLIENT_SECRET some-director-password"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`set -gx BOSH_CA_CERT 'some-director-ca-cert
+with-a-quote\''`))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("set -gx BOSH_ENVIRONMENT some-director-address"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`set -gx BOSH_ALL_PROXY socks5://localhost:\d+`)))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`ssh -f -N -o StrictHostKeyChecking=no -D \d+ jumpbox@some-magical-jumpbox-url -i \$BOSH_GW_PRIVATE_KEY`)))
+			})
+
+			It("prints powershell env assignments", func() {
+				err := printEnv.Execute([]string{"--shell", "powershell"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("$env:BOSH_CLIENT='some-director-username'"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("$env:BOSH_CLIENT_SECRET='some-director-password'"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`$env:BOSH_CA_CERT='some-director-ca-cert
+with-a-quote'''`))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("$env:BOSH_ENVIRONMENT='some-director-address'"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`\$env:BOSH_ALL_PROXY='socks5://localhost:\d+'`)))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`ssh -f -N -o StrictHostKeyChecking=no -D \d+ jumpbox@some-magical-jumpbox-url -i \$env:BOSH_GW_PRIVATE_KEY`)))
+			})
+
+			It("prints cmd set commands that write the ca cert to a file", func() {
+				err := printEnv.Execute([]string{"--shell", "cmd"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("set BOSH_CLIENT=some-director-username"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("set BOSH_CLIENT_SECRET=some-director-password"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("set BOSH_ENVIRONMENT=some-director-address"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(MatchRegexp(`ssh -f -N -o StrictHostKeyChecking=no -D \d+ jumpbox@some-magical-jumpbox-url -i %BOSH_GW_PRIVATE_KEY%`)))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`(echo(some-director-ca-cert& echo(with-a-quote') > "%TEMP%\bosh_ca_cert.crt"
+set BOSH_CA_CERT=%TEMP%\bosh_ca_cert.crt`))
+			})
+
+			It("escapes the characters cmd would interpret in the ca cert", func() {
+				state.BOSH.DirectorSSLCA = "some-cert (100%) & more\n"
+
+				err := printEnv.Execute([]string{"--shell", "cmd"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement(`(echo(some-cert ^(100%%^) ^& more) > "%TEMP%\bosh_ca_cert.crt"
+set BOSH_CA_CERT=%TEMP%\bosh_ca_cert.crt`))
+			})
+		})
+
+		Context("when the variables are read by a program", func() {
+			BeforeEach(func() {
+				state.BOSH.DirectorAddress = "https://10.0.0.6:25555"
+				state.BOSH.Variables = `
+credhub_cli_password: some-credhub-password
+credhub_tls:
+  ca: some-credhub-ca
+`
+				state.Jumpbox = storage.Jumpbox{
+					Enabled: true,
+					URL:     "some-magical-jumpbox-url:22",
+					Variables: `
+jumpbox_ssh:
+  private_key: some-private-key
+`,
+				}
+			})
+
+			It("prints a json document that proxies through the jumpbox without a tunnel", func() {
+				err := printEnv.Execute([]string{"--json"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+
+				var document map[string]string
+				err = json.Unmarshal([]byte(logger.PrintlnCall.Messages[0]), &document)
+				Expect(err).NotTo(HaveOccurred())
+
+				privateKeyPath := document["BOSH_GW_PRIVATE_KEY"]
+				Expect(privateKeyPath).To(HaveSuffix("bosh_jumpbox_private.key"))
+
+				privateKey, err := ioutil.ReadFile(privateKeyPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(privateKey)).To(Equal("some-private-key"))
+
+				Expect(document).To(Equal(map[string]string{
+					"BOSH_CLIENT":         "some-director-username",
+					"BOSH_CLIENT_SECRET":  "some-director-password",
+					"BOSH_ENVIRONMENT":    "https://10.0.0.6:25555",
+					"BOSH_CA_CERT":        "some-director-ca-cert",
+					"BOSH_ALL_PROXY":      "ssh+socks5://jumpbox@some-magical-jumpbox-url:22?private-key=" + privateKeyPath,
+					"BOSH_GW_PRIVATE_KEY": privateKeyPath,
+					"JUMPBOX_URL":         "some-magical-jumpbox-url:22",
+					"CREDHUB_SERVER":      "https://10.0.0.6:8844",
+					"CREDHUB_CA_CERT":     "some-credhub-ca",
+					"CREDHUB_USERNAME":    "credhub-cli",
+					"CREDHUB_PASSWORD":    "some-credhub-password",
+				}))
+			})
+
+			It("prints the json document with the global --json flag", func() {
+				err := printEnv.ExecuteJSON([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+				Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`"JUMPBOX_URL":"some-magical-jumpbox-url:22"`))
+			})
+
+			Context("when a metadata file is passed", func() {
+				var metadataFile string
+
+				BeforeEach(func() {
+					dir, err := ioutil.TempDir("", "")
+					Expect(err).NotTo(HaveOccurred())
+
+					metadataFile = filepath.Join(dir, "metadata")
+				})
+
+				AfterEach(func() {
+					os.RemoveAll(filepath.Dir(metadataFile))
+				})
+
+				It("writes the variables without secrets instead of printing them", func() {
+					err := printEnv.Execute([]string{"--metadata-file", metadataFile}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Messages).To(BeEmpty())
+
+					contents, err := ioutil.ReadFile(metadataFile)
+					Expect(err).NotTo(HaveOccurred())
+
+					var metadata []map[string]string
+					err = json.Unmarshal(contents, &metadata)
+					Expect(err).NotTo(HaveOccurred())
+
+					var names []string
+					for _, field := range metadata {
+						names = append(names, field["name"])
+					}
+					Expect(names).To(Equal([]string{
+						"BOSH_CLIENT",
+						"BOSH_ENVIRONMENT",
+						"BOSH_CA_CERT",
+						"BOSH_ALL_PROXY",
+						"BOSH_GW_PRIVATE_KEY",
+						"JUMPBOX_URL",
+						"CREDHUB_SERVER",
+						"CREDHUB_CA_CERT",
+						"CREDHUB_USERNAME",
+					}))
+					Expect(metadata[1]).To(Equal(map[string]string{"name": "BOSH_ENVIRONMENT", "value": "https://10.0.0.6:25555"}))
+				})
+
+				It("returns an error when the metadata file cannot be written", func() {
+					err := printEnv.Execute([]string{"--metadata-file", filepath.Join(metadataFile, "missing", "metadata")}, state)
+					Expect(err).To(MatchError(ContainSubstring("failed to write the metadata file: ")))
+				})
+			})
+		})
+
 		Context("when there is no director", func() {
 			BeforeEach(func() {
 				terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	RecreateJumpboxCommand = "recreate-jumpbox"
+)
+
+type RecreateJumpbox struct {
+	stateStore     stateStore
+	terraform      terraformOutputter
+	boshManager    boshManager
+	stateValidator stateValidator
+	logger         logger
+}
+
+func NewRecreateJumpbox(stateStore stateStore, terraform terraformOutputter, boshManager boshManager, stateValidator stateValidator, logger logger) RecreateJumpbox {
+	return RecreateJumpbox{
+		stateStore:     stateStore,
+		terraform:      terraform,
+		boshManager:    boshManager,
+		stateValidator: stateValidator,
+		logger:         logger,
+	}
+}
+
+func (r RecreateJumpbox) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if !state.Jumpbox.Enabled {
+		return errors.New("bbl recreate-jumpbox requires an environment with a jumpbox")
+	}
+
+	return nil
+}
+
+func (r RecreateJumpbox) Execute(args []string, state storage.State) error {
+	terraformOutputs, err := r.terraform.GetOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	state, err = r.boshManager.RecreateJumpbox(state, terraformOutputs)
+	if err != nil {
+		return err
+	}
+
+	err = r.stateStore.Set(state)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("recreated jumpbox at %s", state.Jumpbox.URL)
+	return nil
+}
@@ -0,0 +1,128 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RecreateJumpbox", func() {
+	var (
+		stateStore       *fakes.StateStore
+		terraformManager *fakes.TerraformManager
+		boshManager      *fakes.BOSHManager
+		stateValidator   *fakes.StateValidator
+		logger           *fakes.Logger
+
+		command commands.RecreateJumpbox
+
+		incomingState storage.State
+	)
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		terraformManager = &fakes.TerraformManager{}
+		boshManager = &fakes.BOSHManager{}
+		stateValidator = &fakes.StateValidator{}
+		logger = &fakes.Logger{}
+
+		incomingState = storage.State{
+			IAAS: "gcp",
+			Jumpbox: storage.Jumpbox{
+				Enabled: true,
+				URL:     "some-jumpbox-url",
+				State: map[string]interface{}{
+					"some-key": "some-value",
+				},
+			},
+			BOSH: storage.BOSH{
+				DirectorAddress: "some-director-address",
+			},
+		}
+
+		command = commands.NewRecreateJumpbox(stateStore, terraformManager, boshManager, stateValidator, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when the environment does not have a jumpbox", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("bbl recreate-jumpbox requires an environment with a jumpbox"))
+		})
+	})
+
+	Describe("Execute", func() {
+		var recreatedState storage.State
+
+		BeforeEach(func() {
+			terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+				"jumpbox_url": "some-new-jumpbox-url",
+			}
+
+			recreatedState = incomingState
+			recreatedState.Jumpbox = storage.Jumpbox{
+				Enabled: true,
+				URL:     "some-new-jumpbox-url",
+				State: map[string]interface{}{
+					"some-new-key": "some-new-value",
+				},
+			}
+			boshManager.RecreateJumpboxCall.Returns.State = recreatedState
+		})
+
+		It("recreates the jumpbox and saves the new jumpbox record", func() {
+			err := command.Execute([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(incomingState))
+
+			Expect(boshManager.RecreateJumpboxCall.CallCount).To(Equal(1))
+			Expect(boshManager.RecreateJumpboxCall.Receives.State).To(Equal(incomingState))
+			Expect(boshManager.RecreateJumpboxCall.Receives.TerraformOutputs).To(Equal(map[string]interface{}{
+				"jumpbox_url": "some-new-jumpbox-url",
+			}))
+
+			Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+			Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(recreatedState))
+
+			Expect(logger.StepCall.Messages).To(ContainElement("recreated jumpbox at some-new-jumpbox-url"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the terraform outputs cannot be retrieved", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to get outputs"))
+			})
+
+			It("does not save the state when the jumpbox cannot be recreated", func() {
+				boshManager.RecreateJumpboxCall.Returns.Error = errors.New("failed to recreate jumpbox")
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to recreate jumpbox"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to set state"))
+			})
+		})
+	})
+})
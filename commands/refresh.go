@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	RefreshCommand = "refresh"
+)
+
+// Refresh updates the terraform state with infrastructure changed outside of
+// bbl and caches its outputs in the bbl state again, so that commands which
+// only read the outputs see the changes.
+type Refresh struct {
+	logger           logger
+	stateValidator   stateValidator
+	stateStore       stateStore
+	terraformManager terraformRefresher
+}
+
+func NewRefresh(logger logger, stateValidator stateValidator, stateStore stateStore, terraformManager terraformRefresher) Refresh {
+	return Refresh{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		stateStore:       stateStore,
+		terraformManager: terraformManager,
+	}
+}
+
+func (r Refresh) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = flags.New(RefreshCommand).Parse(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.TFState == "" {
+		return errors.New("bbl refresh requires an environment created with terraform")
+	}
+
+	return nil
+}
+
+func (r Refresh) Execute(subcommandFlags []string, state storage.State) error {
+	state, err := r.terraformManager.Refresh(state)
+	if err != nil {
+		return err
+	}
+
+	err = r.stateStore.Set(state)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("refreshed the terraform state and cached its outputs")
+	return nil
+}
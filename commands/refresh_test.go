@@ -0,0 +1,94 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Refresh", func() {
+	var (
+		logger           *fakes.Logger
+		stateValidator   *fakes.StateValidator
+		stateStore       *fakes.StateStore
+		terraformManager *fakes.TerraformManager
+
+		command commands.Refresh
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+		terraformManager = &fakes.TerraformManager{}
+
+		state = storage.State{
+			IAAS:    "gcp",
+			TFState: "some-tf-state",
+		}
+
+		command = commands.NewRefresh(logger, stateValidator, stateStore, terraformManager)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when flags cannot be parsed", func() {
+			err := command.CheckFastFails([]string{"--unknown-flag"}, state)
+			Expect(err).To(MatchError("flag provided but not defined: -unknown-flag"))
+		})
+
+		It("returns an error when the environment was not created with terraform", func() {
+			err := command.CheckFastFails([]string{}, storage.State{IAAS: "aws"})
+			Expect(err).To(MatchError("bbl refresh requires an environment created with terraform"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("refreshes the terraform state and saves it with the cached outputs", func() {
+			refreshedState := state
+			refreshedState.TFState = "some-refreshed-tf-state"
+			refreshedState.TFOutputs = storage.TFOutputs{
+				Checksum: "some-checksum",
+				Outputs:  map[string]interface{}{"external_ip": "some-external-ip"},
+			}
+			terraformManager.RefreshCall.Returns.BBLState = refreshedState
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.RefreshCall.Receives.BBLState).To(Equal(state))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(refreshedState))
+			Expect(logger.StepCall.Messages).To(ContainElement("refreshed the terraform state and cached its outputs"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when terraform fails to refresh", func() {
+				terraformManager.RefreshCall.Returns.Error = errors.New("failed to refresh")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to refresh"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to save state")}}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to save state"))
+			})
+		})
+	})
+})
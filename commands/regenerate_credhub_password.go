@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	RegenerateCredhubPasswordCommand = "regenerate-credhub-password"
+)
+
+type RegenerateCredhubPassword struct {
+	stateStore     stateStore
+	terraform      terraformOutputter
+	boshManager    boshManager
+	stateValidator stateValidator
+	logger         logger
+}
+
+func NewRegenerateCredhubPassword(stateStore stateStore, terraform terraformOutputter, boshManager boshManager,
+	stateValidator stateValidator, logger logger) RegenerateCredhubPassword {
+	return RegenerateCredhubPassword{
+		stateStore:     stateStore,
+		terraform:      terraform,
+		boshManager:    boshManager,
+		stateValidator: stateValidator,
+		logger:         logger,
+	}
+}
+
+func (r RegenerateCredhubPassword) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.NoDirector {
+		return errors.New("bbl regenerate-credhub-password cannot be used when bbl does not manage the director")
+	}
+
+	if !state.Jumpbox.Enabled {
+		return errors.New("bbl regenerate-credhub-password requires an environment with credhub, created with bbl up --credhub")
+	}
+
+	return nil
+}
+
+func (r RegenerateCredhubPassword) DryRun(args []string, state storage.State) error {
+	changes := []string{
+		fmt.Sprintf("regenerate the UAA admin and credhub passwords of the BOSH director %q", state.BOSH.DirectorName),
+		"redeploy the BOSH director with the new passwords",
+		"save the bbl state",
+	}
+
+	printDryRun(r.logger, RegenerateCredhubPasswordCommand, changes, "")
+	return nil
+}
+
+func (r RegenerateCredhubPassword) Execute(args []string, state storage.State) error {
+	terraformOutputs, err := r.terraform.GetOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("regenerating UAA admin and credhub passwords")
+	variables, err := bosh.WithoutCredhubPasswords(state.BOSH.Variables)
+	if err != nil {
+		return fmt.Errorf("failed to read director variables:\n%s", err.Error())
+	}
+
+	rotatedState := state
+	rotatedState.BOSH.Variables = variables
+
+	rotatedState, err = r.boshManager.CreateDirector(rotatedState, terraformOutputs)
+	switch err.(type) {
+	case bosh.ManagerCreateError:
+		// The old passwords stay in the state so the director remains
+		// reachable. The create-env state is kept so that running the
+		// command again picks up where this deploy stopped.
+		failedState := state
+		failedState.BOSH.State = err.(bosh.ManagerCreateError).State().BOSH.State
+		if setErr := r.stateStore.Set(failedState); setErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(setErr)
+			return errorList
+		}
+		return err
+	case error:
+		return err
+	}
+
+	err = r.stateStore.Set(rotatedState)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("regenerated UAA admin and credhub passwords, run bbl print-env to use them")
+	return nil
+}
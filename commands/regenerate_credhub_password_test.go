@@ -0,0 +1,186 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RegenerateCredhubPassword", func() {
+	var (
+		stateStore       *fakes.StateStore
+		terraformManager *fakes.TerraformManager
+		boshManager      *fakes.BOSHManager
+		stateValidator   *fakes.StateValidator
+		logger           *fakes.Logger
+
+		command commands.RegenerateCredhubPassword
+
+		incomingState storage.State
+	)
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		terraformManager = &fakes.TerraformManager{}
+		boshManager = &fakes.BOSHManager{}
+		stateValidator = &fakes.StateValidator{}
+		logger = &fakes.Logger{}
+
+		incomingState = storage.State{
+			IAAS: "gcp",
+			Jumpbox: storage.Jumpbox{
+				Enabled: true,
+			},
+			BOSH: storage.BOSH{
+				DirectorName:     "bosh-some-env-id",
+				DirectorPassword: "some-director-password",
+				Variables: `admin_password: some-director-password
+credhub_cli_password: some-old-credhub-password
+credhub_encryption_password: some-encryption-password
+uaa_admin_client_secret: some-old-uaa-secret
+uaa_clients_director_to_credhub: some-old-director-to-credhub-secret
+`,
+				State: map[string]interface{}{
+					"some-key": "some-value",
+				},
+			},
+		}
+
+		command = commands.NewRegenerateCredhubPassword(stateStore, terraformManager, boshManager, stateValidator, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			incomingState.NoDirector = true
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("bbl regenerate-credhub-password cannot be used when bbl does not manage the director"))
+		})
+
+		It("returns an error when the environment does not have credhub", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("bbl regenerate-credhub-password requires an environment with credhub, created with bbl up --credhub"))
+		})
+	})
+
+	Describe("DryRun", func() {
+		It("prints what would change without redeploying the director", func() {
+			err := command.DryRun([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(ContainElement(ContainSubstring(`regenerate the UAA admin and credhub passwords of the BOSH director "bosh-some-env-id"`)))
+			Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+		})
+	})
+
+	Describe("Execute", func() {
+		var rotatedState storage.State
+
+		BeforeEach(func() {
+			terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+				"director_address": "some-director-address",
+			}
+
+			rotatedState = incomingState
+			rotatedState.BOSH = storage.BOSH{
+				DirectorName:     "bosh-some-env-id",
+				DirectorPassword: "some-director-password",
+				Variables:        "credhub_cli_password: some-new-credhub-password\n",
+			}
+			boshManager.CreateDirectorCall.Returns.State = rotatedState
+		})
+
+		It("redeploys the director without the old passwords and saves the new ones", func() {
+			err := command.Execute([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(incomingState))
+
+			Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+			Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.Variables).To(MatchYAML(`admin_password: some-director-password
+credhub_encryption_password: some-encryption-password
+`))
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(rotatedState))
+
+			Expect(logger.StepCall.Messages).To(ContainElement("regenerated UAA admin and credhub passwords, run bbl print-env to use them"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the terraform outputs cannot be retrieved", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to get outputs"))
+			})
+
+			It("returns an error when the director variables are invalid", func() {
+				incomingState.BOSH.Variables = "%%%"
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError(ContainSubstring("failed to read director variables:")))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+			})
+
+			It("does not save the state when the director cannot be redeployed", func() {
+				boshManager.CreateDirectorCall.Returns.Error = errors.New("failed to create director")
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to create director"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			Context("when create-env fails", func() {
+				It("keeps the old passwords and saves the create-env state", func() {
+					failedState := incomingState
+					failedState.BOSH = storage.BOSH{
+						Variables: "credhub_cli_password: some-new-credhub-password\n",
+						State: map[string]interface{}{
+							"some-new-key": "some-new-value",
+						},
+					}
+					boshManager.CreateDirectorCall.Returns.Error = bosh.NewManagerCreateError(failedState, errors.New("failed to create env"))
+
+					err := command.Execute([]string{}, incomingState)
+					Expect(err).To(MatchError("failed to create env"))
+
+					expectedState := incomingState
+					expectedState.BOSH.State = map[string]interface{}{
+						"some-new-key": "some-new-value",
+					}
+
+					Expect(stateStore.SetCall.CallCount).To(Equal(1))
+					Expect(stateStore.SetCall.Receives[0].State).To(Equal(expectedState))
+				})
+
+				It("returns both errors when the state cannot be saved", func() {
+					boshManager.CreateDirectorCall.Returns.Error = bosh.NewManagerCreateError(incomingState, errors.New("failed to create env"))
+					stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+					err := command.Execute([]string{}, incomingState)
+					Expect(err).To(MatchError("the following errors occurred:\nfailed to create env,\nfailed to set state"))
+				})
+			})
+
+			It("returns an error when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("failed to set state"))
+			})
+		})
+	})
+})
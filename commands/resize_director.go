@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ResizeDirectorCommand = "resize-director"
+)
+
+type ResizeDirector struct {
+	stateStore     stateStore
+	terraform      terraformOutputter
+	boshManager    boshManager
+	stateValidator stateValidator
+	logger         logger
+}
+
+type resizeDirectorConfig struct {
+	vmType   string
+	diskSize int
+}
+
+func NewResizeDirector(stateStore stateStore, terraform terraformOutputter, boshManager boshManager,
+	stateValidator stateValidator, logger logger) ResizeDirector {
+	return ResizeDirector{
+		stateStore:     stateStore,
+		terraform:      terraform,
+		boshManager:    boshManager,
+		stateValidator: stateValidator,
+		logger:         logger,
+	}
+}
+
+func (r ResizeDirector) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.NoDirector {
+		return errors.New("bbl resize-director cannot be used when bbl does not manage the director")
+	}
+
+	if state.BOSH.DirectorAddress == "" {
+		return errors.New("bbl resize-director requires a director, run bbl up first")
+	}
+
+	if _, ok := defaultDirectorVMTypes[state.IAAS]; !ok {
+		return errors.New(`bbl resize-director is only supported when iaas="aws" or iaas="gcp"`)
+	}
+
+	config, err := r.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	// bosh migrates the persistent disk by copying it to the new one, which
+	// cannot hold the director's data when it is smaller.
+	_, diskSize := directorVMSize(state)
+	if config.diskSize != 0 && config.diskSize < diskSize {
+		return fmt.Errorf("--disk-size cannot shrink the director's %dGB persistent disk", diskSize)
+	}
+
+	return nil
+}
+
+func (r ResizeDirector) DryRun(args []string, state storage.State) error {
+	config, err := r.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	vmType, diskSize := directorVMSize(state)
+
+	changes := []string{}
+	if config.vmType != "" && config.vmType != vmType {
+		changes = append(changes, fmt.Sprintf("recreate the BOSH director vm %q with vm type %s instead of %s", state.BOSH.DirectorName, config.vmType, vmType))
+	}
+	if config.diskSize != 0 && config.diskSize != diskSize {
+		changes = append(changes, fmt.Sprintf("migrate the persistent disk of the BOSH director %q from %dGB to %dGB", state.BOSH.DirectorName, diskSize, config.diskSize))
+	}
+	if len(changes) > 0 {
+		changes = append(changes, "save the bbl state")
+	}
+
+	printDryRun(r.logger, ResizeDirectorCommand, changes, "")
+	return nil
+}
+
+func (r ResizeDirector) Execute(args []string, state storage.State) error {
+	config, err := r.parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	vmType, diskSize := directorVMSize(state)
+	if (config.vmType == "" || config.vmType == vmType) && (config.diskSize == 0 || config.diskSize == diskSize) {
+		r.logger.Step("the director already has vm type %s and a %dGB persistent disk", vmType, diskSize)
+		return nil
+	}
+
+	terraformOutputs, err := r.terraform.GetOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("resizing the director")
+	resizedState := updateDirectorVMSize(state, config.vmType, config.diskSize, r.logger)
+
+	resizedState, err = r.boshManager.CreateDirector(resizedState, terraformOutputs)
+	switch err.(type) {
+	case bosh.ManagerCreateError:
+		// The new size is kept in the state so that running bbl up or this
+		// command again finishes the resize.
+		bcErr := err.(bosh.ManagerCreateError)
+		if setErr := r.stateStore.Set(bcErr.State()); setErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(setErr)
+			return errorList
+		}
+		return err
+	case error:
+		return err
+	}
+
+	err = r.stateStore.Set(resizedState)
+	if err != nil {
+		return err
+	}
+
+	vmType, diskSize = directorVMSize(resizedState)
+	r.logger.Step("resized the director to vm type %s with a %dGB persistent disk", vmType, diskSize)
+	return nil
+}
+
+func (ResizeDirector) parseFlags(subcommandFlags []string) (resizeDirectorConfig, error) {
+	resizeFlags := flags.New(ResizeDirectorCommand)
+
+	config := resizeDirectorConfig{}
+	resizeFlags.String(&config.vmType, "vm-type", "")
+	resizeFlags.Int(&config.diskSize, "disk-size", 0)
+
+	err := resizeFlags.Parse(subcommandFlags)
+	if err != nil {
+		return resizeDirectorConfig{}, err
+	}
+
+	if config.vmType == "" && config.diskSize == 0 {
+		return resizeDirectorConfig{}, errors.New("bbl resize-director requires --vm-type or --disk-size")
+	}
+
+	if config.diskSize < 0 {
+		return resizeDirectorConfig{}, errors.New("--disk-size must be a positive number of GB")
+	}
+
+	return config, nil
+}
+
+// directorVMSize returns the vm type and persistent disk size the director
+// is deployed with, falling back to the bosh-deployment defaults.
+func directorVMSize(state storage.State) (string, int) {
+	vmType := state.BOSH.DirectorVMType
+	if vmType == "" {
+		vmType = defaultDirectorVMTypes[state.IAAS]
+	}
+
+	diskSize := state.BOSH.DirectorDiskSize
+	if diskSize == 0 {
+		diskSize = defaultDirectorDiskSize
+	}
+
+	return vmType, diskSize
+}
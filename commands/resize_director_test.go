@@ -0,0 +1,227 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResizeDirector", func() {
+	var (
+		stateStore       *fakes.StateStore
+		terraformManager *fakes.TerraformManager
+		boshManager      *fakes.BOSHManager
+		stateValidator   *fakes.StateValidator
+		logger           *fakes.Logger
+
+		command commands.ResizeDirector
+
+		incomingState storage.State
+	)
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		terraformManager = &fakes.TerraformManager{}
+		boshManager = &fakes.BOSHManager{}
+		stateValidator = &fakes.StateValidator{}
+		logger = &fakes.Logger{}
+
+		incomingState = storage.State{
+			IAAS: "gcp",
+			BOSH: storage.BOSH{
+				DirectorName:    "bosh-some-env-id",
+				DirectorAddress: "https://10.0.0.6:25555",
+				State: map[string]interface{}{
+					"some-key": "some-value",
+				},
+			},
+		}
+
+		command = commands.NewResizeDirector(stateStore, terraformManager, boshManager, stateValidator, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+			err := command.CheckFastFails([]string{"--vm-type", "n1-standard-4"}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			incomingState.NoDirector = true
+			err := command.CheckFastFails([]string{"--vm-type", "n1-standard-4"}, incomingState)
+			Expect(err).To(MatchError("bbl resize-director cannot be used when bbl does not manage the director"))
+		})
+
+		It("returns an error when there is no director yet", func() {
+			incomingState.BOSH.DirectorAddress = ""
+			err := command.CheckFastFails([]string{"--vm-type", "n1-standard-4"}, incomingState)
+			Expect(err).To(MatchError("bbl resize-director requires a director, run bbl up first"))
+		})
+
+		It("returns an error when the iaas is not supported", func() {
+			incomingState.IAAS = "azure"
+			err := command.CheckFastFails([]string{"--vm-type", "Standard_D2_v2"}, incomingState)
+			Expect(err).To(MatchError(`bbl resize-director is only supported when iaas="aws" or iaas="gcp"`))
+		})
+
+		It("returns an error when neither flag is passed", func() {
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("bbl resize-director requires --vm-type or --disk-size"))
+		})
+
+		It("returns an error when the disk size is negative", func() {
+			err := command.CheckFastFails([]string{"--disk-size", "-10"}, incomingState)
+			Expect(err).To(MatchError("--disk-size must be a positive number of GB"))
+		})
+
+		It("returns an error when the disk size is below the current one", func() {
+			err := command.CheckFastFails([]string{"--disk-size", "16"}, incomingState)
+			Expect(err).To(MatchError("--disk-size cannot shrink the director's 32GB persistent disk"))
+		})
+
+		It("returns an error when the flags cannot be parsed", func() {
+			err := command.CheckFastFails([]string{"--disk-size", "big"}, incomingState)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("DryRun", func() {
+		It("prints what would change without redeploying the director", func() {
+			err := command.DryRun([]string{"--vm-type", "n1-standard-4", "--disk-size", "64"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(ContainElement(ContainSubstring(`recreate the BOSH director vm "bosh-some-env-id" with vm type n1-standard-4 instead of n1-standard-1`)))
+			Expect(logger.PrintlnCall.Messages).To(ContainElement(ContainSubstring(`migrate the persistent disk of the BOSH director "bosh-some-env-id" from 32GB to 64GB`)))
+			Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+		})
+
+		It("prints that nothing changes when the director already has the size", func() {
+			incomingState.BOSH.DirectorVMType = "n1-standard-4"
+
+			err := command.DryRun([]string{"--vm-type", "n1-standard-4"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(ContainElement(ContainSubstring("change nothing")))
+		})
+	})
+
+	Describe("Execute", func() {
+		var resizedState storage.State
+
+		BeforeEach(func() {
+			terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+				"director_address": "some-director-address",
+			}
+
+			resizedState = incomingState
+			resizedState.BOSH = storage.BOSH{
+				DirectorName:     "bosh-some-env-id",
+				DirectorAddress:  "https://10.0.0.6:25555",
+				DirectorVMType:   "n1-standard-4",
+				DirectorDiskSize: 64,
+				State: map[string]interface{}{
+					"some-new-key": "some-new-value",
+				},
+			}
+			boshManager.CreateDirectorCall.Returns.State = resizedState
+		})
+
+		It("redeploys the director with the new size and saves the state", func() {
+			err := command.Execute([]string{"--vm-type", "n1-standard-4", "--disk-size", "64"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(incomingState))
+
+			Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+			Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorVMType).To(Equal("n1-standard-4"))
+			Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorDiskSize).To(Equal(64))
+			Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.State).To(Equal(incomingState.BOSH.State))
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State).To(Equal(resizedState))
+
+			Expect(logger.WarnCall.Messages).To(ContainElement("changing the director vm type from n1-standard-1 to n1-standard-4 will recreate the director vm."))
+			Expect(logger.WarnCall.Messages).To(ContainElement("changing the director disk size from 32GB to 64GB will migrate the director's persistent disk."))
+			Expect(logger.StepCall.Messages).To(ContainElement("resized the director to vm type n1-standard-4 with a 64GB persistent disk"))
+		})
+
+		It("keeps the current vm type when only the disk size changes", func() {
+			incomingState.BOSH.DirectorVMType = "n1-standard-2"
+
+			err := command.Execute([]string{"--disk-size", "64"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorVMType).To(Equal("n1-standard-2"))
+			Expect(boshManager.CreateDirectorCall.Receives.State.BOSH.DirectorDiskSize).To(Equal(64))
+		})
+
+		It("does not redeploy the director when it already has the size", func() {
+			incomingState.BOSH.DirectorVMType = "n1-standard-4"
+
+			err := command.Execute([]string{"--vm-type", "n1-standard-4", "--disk-size", "32"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(ContainElement("the director already has vm type n1-standard-4 and a 32GB persistent disk"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("bbl resize-director requires --vm-type or --disk-size"))
+			})
+
+			It("returns an error when the terraform outputs cannot be retrieved", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+				err := command.Execute([]string{"--vm-type", "n1-standard-4"}, incomingState)
+				Expect(err).To(MatchError("failed to get outputs"))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+			})
+
+			It("does not save the state when the director cannot be redeployed", func() {
+				boshManager.CreateDirectorCall.Returns.Error = errors.New("failed to create director")
+
+				err := command.Execute([]string{"--vm-type", "n1-standard-4"}, incomingState)
+				Expect(err).To(MatchError("failed to create director"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			Context("when create-env fails", func() {
+				It("saves the state with the new size", func() {
+					boshManager.CreateDirectorCall.Returns.Error = bosh.NewManagerCreateError(resizedState, errors.New("failed to create env"))
+
+					err := command.Execute([]string{"--vm-type", "n1-standard-4"}, incomingState)
+					Expect(err).To(MatchError("failed to create env"))
+
+					Expect(stateStore.SetCall.CallCount).To(Equal(1))
+					Expect(stateStore.SetCall.Receives[0].State).To(Equal(resizedState))
+				})
+
+				It("returns both errors when the state cannot be saved", func() {
+					boshManager.CreateDirectorCall.Returns.Error = bosh.NewManagerCreateError(resizedState, errors.New("failed to create env"))
+					stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+					err := command.Execute([]string{"--vm-type", "n1-standard-4"}, incomingState)
+					Expect(err).To(MatchError("the following errors occurred:\nfailed to create env,\nfailed to set state"))
+				})
+			})
+
+			It("returns an error when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{"--vm-type", "n1-standard-4"}, incomingState)
+				Expect(err).To(MatchError("failed to set state"))
+			})
+		})
+	})
+})
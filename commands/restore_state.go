@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	RestoreStateCommand = "restore-state"
+)
+
+type stateRestorer interface {
+	Backups() ([]string, error)
+	Restore(name string) error
+}
+
+type RestoreState struct {
+	stateRestorer  stateRestorer
+	stateValidator stateValidator
+	logger         logger
+}
+
+type restoreStateConfig struct {
+	from string
+}
+
+func NewRestoreState(stateRestorer stateRestorer, stateValidator stateValidator, logger logger) RestoreState {
+	return RestoreState{
+		stateRestorer:  stateRestorer,
+		stateValidator: stateValidator,
+		logger:         logger,
+	}
+}
+
+func (r RestoreState) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	config, err := r.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.from == "" {
+		backups, err := r.stateRestorer.Backups()
+		if err != nil {
+			return err
+		}
+
+		if len(backups) == 0 {
+			return errors.New("--from must be provided, but there are no state backups to restore")
+		}
+
+		return fmt.Errorf("--from must be provided, available backups:\n  %s", strings.Join(backups, "\n  "))
+	}
+
+	return nil
+}
+
+func (r RestoreState) Execute(args []string, state storage.State) error {
+	config, err := r.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	err = r.stateRestorer.Restore(config.from)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("restored state from %s", config.from)
+	return nil
+}
+
+func (r RestoreState) parseArgs(args []string) (restoreStateConfig, error) {
+	var config restoreStateConfig
+
+	restoreStateFlags := flags.New("restore-state")
+	restoreStateFlags.String(&config.from, "from", "")
+
+	err := restoreStateFlags.Parse(args)
+	if err != nil {
+		return restoreStateConfig{}, err
+	}
+
+	return config, nil
+}
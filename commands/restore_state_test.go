@@ -0,0 +1,80 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RestoreState", func() {
+	var (
+		stateRestorer  *fakes.StateRestorer
+		stateValidator *fakes.StateValidator
+		logger         *fakes.Logger
+
+		command commands.RestoreState
+	)
+
+	BeforeEach(func() {
+		stateRestorer = &fakes.StateRestorer{}
+		stateValidator = &fakes.StateValidator{}
+		logger = &fakes.Logger{}
+
+		command = commands.NewRestoreState(stateRestorer, stateValidator, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+			err := command.CheckFastFails([]string{"--from", "some-backup"}, storage.State{})
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("lists the available backups when --from is not provided", func() {
+			stateRestorer.BackupsCall.Returns.Backups = []string{"some-backup", "some-other-backup"}
+
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("--from must be provided, available backups:\n  some-backup\n  some-other-backup"))
+		})
+
+		It("returns an error when --from is not provided and there are no backups", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("--from must be provided, but there are no state backups to restore"))
+		})
+
+		It("returns an error when the backups cannot be listed", func() {
+			stateRestorer.BackupsCall.Returns.Error = errors.New("failed to list backups")
+
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("failed to list backups"))
+		})
+
+		It("returns an error when an unknown flag is provided", func() {
+			err := command.CheckFastFails([]string{"--some-unknown-flag"}, storage.State{})
+			Expect(err).To(MatchError("flag provided but not defined: -some-unknown-flag"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("restores the named backup", func() {
+			err := command.Execute([]string{"--from", "some-backup"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateRestorer.RestoreCall.CallCount).To(Equal(1))
+			Expect(stateRestorer.RestoreCall.Receives.Name).To(Equal("some-backup"))
+			Expect(logger.StepCall.Messages).To(ContainElement("restored state from some-backup"))
+		})
+
+		It("returns an error when the backup cannot be restored", func() {
+			stateRestorer.RestoreCall.Returns.Error = errors.New("failed to restore")
+
+			err := command.Execute([]string{"--from", "some-backup"}, storage.State{})
+			Expect(err).To(MatchError("failed to restore"))
+		})
+	})
+})
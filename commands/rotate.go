@@ -1,6 +1,10 @@
 package commands
 
-import "github.com/cloudfoundry/bosh-bootloader/storage"
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
 
 type Rotate struct {
 	stateStore     stateStore
@@ -8,15 +12,18 @@ type Rotate struct {
 	terraform      terraformOutputter
 	boshManager    boshManager
 	stateValidator stateValidator
+	logger         logger
 }
 
-func NewRotate(stateStore stateStore, keyPairManager keyPairManager, terraform terraformOutputter, boshManager boshManager, stateValidator stateValidator) Rotate {
+func NewRotate(stateStore stateStore, keyPairManager keyPairManager, terraform terraformOutputter, boshManager boshManager,
+	stateValidator stateValidator, logger logger) Rotate {
 	return Rotate{
 		stateStore:     stateStore,
 		keyPairManager: keyPairManager,
 		terraform:      terraform,
 		boshManager:    boshManager,
 		stateValidator: stateValidator,
+		logger:         logger,
 	}
 }
 
@@ -29,6 +36,16 @@ func (r Rotate) CheckFastFails(subcommandFlags []string, state storage.State) er
 	return nil
 }
 
+func (r Rotate) DryRun(args []string, state storage.State) error {
+	changes := []string{fmt.Sprintf("replace the key pair %q with a new key pair", state.KeyPair.Name), "save the bbl state"}
+	if !state.NoDirector {
+		changes = append(changes, "recreate the BOSH director with the new key pair")
+	}
+
+	printDryRun(r.logger, "rotate", changes, "")
+	return nil
+}
+
 func (r Rotate) Execute(args []string, state storage.State) error {
 	state, err := r.keyPairManager.Rotate(state)
 	if err != nil {
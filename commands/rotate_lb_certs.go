@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const RotateLBCertsCommand = "rotate-lb-certs"
+
+type lbCertificateRotator interface {
+	Apply(storage.State) (storage.State, error)
+	Plan(storage.State) (string, error)
+}
+
+type RotateLBCerts struct {
+	terraform            lbCertificateRotator
+	certificateValidator certificateValidator
+	stateValidator       stateValidator
+	stateStore           stateStore
+	logger               logger
+}
+
+type rotateLBCertsConfig struct {
+	certPath  string
+	keyPath   string
+	chainPath string
+	cleanup   bool
+}
+
+func NewRotateLBCerts(terraform lbCertificateRotator, certificateValidator certificateValidator, stateValidator stateValidator,
+	stateStore stateStore, logger logger) RotateLBCerts {
+	return RotateLBCerts{
+		terraform:            terraform,
+		certificateValidator: certificateValidator,
+		stateValidator:       stateValidator,
+		stateStore:           stateStore,
+		logger:               logger,
+	}
+}
+
+func (r RotateLBCerts) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	config, err := r.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	err = r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case state.Stack.CertificateName != "":
+		return errors.New("rotate-lb-certs does not support certificates uploaded by cloudformation, please use update-lbs")
+	case !lbExists(state.LB.Type):
+		return LBNotFound
+	case state.IAAS != "aws" && state.IAAS != "gcp":
+		return fmt.Errorf("rotate-lb-certs is not supported on %s", state.IAAS)
+	case state.IAAS == "gcp" && state.LB.Type != "cf":
+		return errors.New("rotate-lb-certs requires a cf lb on gcp, concourse lbs do not terminate tls")
+	}
+
+	if config.cleanup {
+		return nil
+	}
+
+	if state.LB.Previous.Cert != "" {
+		return errors.New("a previous certificate is still attached, run `bbl rotate-lb-certs --cleanup` once the new certificate has propagated")
+	}
+
+	return validateCertificate(r.certificateValidator, r.logger, RotateLBCertsCommand, config.certPath, config.keyPath, config.chainPath)
+}
+
+func (r RotateLBCerts) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := r.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.cleanup {
+		if state.LB.Previous.Cert == "" {
+			r.logger.Println("no previous lb certificate to clean up")
+			return nil
+		}
+
+		r.logger.Step("removing the previous lb certificate")
+		state.LB.Previous = storage.LBCertificate{}
+
+		return r.apply(state)
+	}
+
+	state, err = rotateLBCertificate(config, state)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("attaching the new lb certificate")
+	err = r.apply(state)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Println("the previous lb certificate is kept until you run `bbl rotate-lb-certs --cleanup`")
+	return nil
+}
+
+func (r RotateLBCerts) DryRun(subcommandFlags []string, state storage.State) error {
+	config, err := r.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	var changes []string
+	if config.cleanup {
+		if state.LB.Previous.Cert == "" {
+			printDryRun(r.logger, RotateLBCertsCommand, nil, "")
+			return nil
+		}
+
+		state.LB.Previous = storage.LBCertificate{}
+		changes = []string{"remove the previous lb certificate with the terraform plan below", "save the bbl state"}
+	} else {
+		state, err = rotateLBCertificate(config, state)
+		if err != nil {
+			return err
+		}
+
+		changes = []string{"attach the new lb certificate and keep the previous one with the terraform plan below", "save the bbl state"}
+	}
+
+	plan, err := r.terraform.Plan(state)
+	if err != nil {
+		return err
+	}
+
+	printDryRun(r.logger, RotateLBCertsCommand, changes, plan)
+	return nil
+}
+
+func (r RotateLBCerts) apply(state storage.State) error {
+	state, err := r.terraform.Apply(state)
+	if err != nil {
+		return handleTerraformError(err, r.stateStore)
+	}
+
+	return r.stateStore.Set(state)
+}
+
+// rotateLBCertificate keeps the current certificate as the previous one and
+// moves the new certificate into the other certificate resource, so that
+// terraform creates it and repoints the listeners without replacing the lbs.
+func rotateLBCertificate(config rotateLBCertsConfig, state storage.State) (storage.State, error) {
+	state.LB.Previous = storage.LBCertificate{
+		Cert:  state.LB.Cert,
+		Key:   state.LB.Key,
+		Chain: state.LB.Chain,
+	}
+
+	certContents, err := ioutil.ReadFile(config.certPath)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	keyContents, err := ioutil.ReadFile(config.keyPath)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	state.LB.Cert = string(certContents)
+	state.LB.Key = string(keyContents)
+	state.LB.Chain = ""
+
+	if config.chainPath != "" {
+		chainContents, err := ioutil.ReadFile(config.chainPath)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		state.LB.Chain = string(chainContents)
+	}
+
+	if state.LB.CertSlot == "alt" {
+		state.LB.CertSlot = ""
+	} else {
+		state.LB.CertSlot = "alt"
+	}
+
+	return state, nil
+}
+
+func (RotateLBCerts) parseFlags(subcommandFlags []string) (rotateLBCertsConfig, error) {
+	rotateFlags := flags.New(RotateLBCertsCommand)
+
+	config := rotateLBCertsConfig{}
+	rotateFlags.String(&config.certPath, "cert", "")
+	rotateFlags.String(&config.keyPath, "key", "")
+	rotateFlags.String(&config.chainPath, "chain", "")
+	rotateFlags.Bool(&config.cleanup, "", "cleanup", false)
+
+	err := rotateFlags.Parse(subcommandFlags)
+	if err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
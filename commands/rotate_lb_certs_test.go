@@ -0,0 +1,279 @@
+package commands_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotateLBCerts", func() {
+	var (
+		terraformManager     *fakes.TerraformManager
+		certificateValidator *fakes.CertificateValidator
+		stateValidator       *fakes.StateValidator
+		stateStore           *fakes.StateStore
+		logger               *fakes.Logger
+
+		command commands.RotateLBCerts
+
+		incomingState storage.State
+		certPath      string
+		keyPath       string
+		chainPath     string
+	)
+
+	BeforeEach(func() {
+		terraformManager = &fakes.TerraformManager{}
+		certificateValidator = &fakes.CertificateValidator{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+		logger = &fakes.Logger{}
+
+		terraformManager.ApplyCall.Stub = func(state storage.State) (storage.State, error) {
+			state.TFState = "some-updated-tf-state"
+			return state, nil
+		}
+
+		incomingState = storage.State{
+			IAAS: "aws",
+			LB: storage.LB{
+				Type:  "cf",
+				Cert:  "some-old-cert",
+				Key:   "some-old-key",
+				Chain: "some-old-chain",
+			},
+		}
+
+		tempDir, err := ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		certPath = filepath.Join(tempDir, "new.crt")
+		keyPath = filepath.Join(tempDir, "new.key")
+		chainPath = filepath.Join(tempDir, "new-chain.crt")
+		Expect(ioutil.WriteFile(certPath, []byte("some-new-cert"), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(keyPath, []byte("some-new-key"), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(chainPath, []byte("some-new-chain"), os.ModePerm)).To(Succeed())
+
+		command = commands.NewRotateLBCerts(terraformManager, certificateValidator, stateValidator, stateStore, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the new certificate", func() {
+			certificateValidator.ValidateCall.Returns.Certificate = certs.Certificate{Subject: "*.some-domain", NotAfter: time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+			err := command.CheckFastFails([]string{"--cert", certPath, "--key", keyPath, "--chain", chainPath}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(certificateValidator.ValidateCall.Receives.Command).To(Equal("rotate-lb-certs"))
+			Expect(certificateValidator.ValidateCall.Receives.CertificatePath).To(Equal(certPath))
+			Expect(certificateValidator.ValidateCall.Receives.KeyPath).To(Equal(keyPath))
+			Expect(certificateValidator.ValidateCall.Receives.ChainPath).To(Equal(chainPath))
+		})
+
+		It("does not validate a certificate when cleaning up", func() {
+			incomingState.LB.Previous = storage.LBCertificate{Cert: "some-older-cert"}
+
+			err := command.CheckFastFails([]string{"--cleanup"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(certificateValidator.ValidateCall.CallCount).To(Equal(0))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.CheckFastFails([]string{}, incomingState)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+
+			It("returns an error when there is no lb", func() {
+				incomingState.LB = storage.LB{}
+
+				err := command.CheckFastFails([]string{}, incomingState)
+				Expect(err).To(MatchError(commands.LBNotFound))
+			})
+
+			It("returns an error when the certificate was uploaded by cloudformation", func() {
+				incomingState.Stack = storage.Stack{LBType: "cf", CertificateName: "some-certificate"}
+
+				err := command.CheckFastFails([]string{}, incomingState)
+				Expect(err).To(MatchError("rotate-lb-certs does not support certificates uploaded by cloudformation, please use update-lbs"))
+			})
+
+			It("returns an error on openstack", func() {
+				incomingState.IAAS = "openstack"
+
+				err := command.CheckFastFails([]string{}, incomingState)
+				Expect(err).To(MatchError("rotate-lb-certs is not supported on openstack"))
+			})
+
+			It("returns an error for a concourse lb on gcp", func() {
+				incomingState.IAAS = "gcp"
+				incomingState.LB.Type = "concourse"
+
+				err := command.CheckFastFails([]string{}, incomingState)
+				Expect(err).To(MatchError("rotate-lb-certs requires a cf lb on gcp, concourse lbs do not terminate tls"))
+			})
+
+			It("returns an error when the previous certificate has not been cleaned up", func() {
+				incomingState.LB.Previous = storage.LBCertificate{Cert: "some-older-cert"}
+
+				err := command.CheckFastFails([]string{"--cert", certPath, "--key", keyPath}, incomingState)
+				Expect(err).To(MatchError("a previous certificate is still attached, run `bbl rotate-lb-certs --cleanup` once the new certificate has propagated"))
+			})
+
+			It("returns an error when the certificate is invalid", func() {
+				certificateValidator.ValidateCall.Returns.Error = errors.New("failed to validate")
+
+				err := command.CheckFastFails([]string{"--cert", certPath, "--key", keyPath}, incomingState)
+				Expect(err).To(MatchError("failed to validate"))
+			})
+
+			It("returns an error when flags cannot be parsed", func() {
+				err := command.CheckFastFails([]string{"--unknown-flag"}, incomingState)
+				Expect(err).To(MatchError("flag provided but not defined: -unknown-flag"))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("attaches the new certificate in the other slot and keeps the previous one", func() {
+			err := command.Execute([]string{"--cert", certPath, "--key", keyPath, "--chain", chainPath}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+			appliedLB := terraformManager.ApplyCall.Receives.BBLState.LB
+			Expect(appliedLB.Cert).To(Equal("some-new-cert"))
+			Expect(appliedLB.Key).To(Equal("some-new-key"))
+			Expect(appliedLB.Chain).To(Equal("some-new-chain"))
+			Expect(appliedLB.CertSlot).To(Equal("alt"))
+			Expect(appliedLB.Previous).To(Equal(storage.LBCertificate{
+				Cert:  "some-old-cert",
+				Key:   "some-old-key",
+				Chain: "some-old-chain",
+			}))
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives[0].State.TFState).To(Equal("some-updated-tf-state"))
+			Expect(stateStore.SetCall.Receives[0].State.LB.CertSlot).To(Equal("alt"))
+
+			Expect(logger.PrintlnCall.Messages).To(ContainElement("the previous lb certificate is kept until you run `bbl rotate-lb-certs --cleanup`"))
+		})
+
+		It("moves the certificate back to the original slot on the next rotation", func() {
+			incomingState.LB.CertSlot = "alt"
+
+			err := command.Execute([]string{"--cert", certPath, "--key", keyPath}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			appliedLB := terraformManager.ApplyCall.Receives.BBLState.LB
+			Expect(appliedLB.CertSlot).To(BeEmpty())
+			Expect(appliedLB.Chain).To(BeEmpty())
+		})
+
+		Context("when --cleanup is provided", func() {
+			BeforeEach(func() {
+				incomingState.LB.CertSlot = "alt"
+				incomingState.LB.Previous = storage.LBCertificate{Cert: "some-older-cert", Key: "some-older-key"}
+			})
+
+			It("removes the previous certificate", func() {
+				err := command.Execute([]string{"--cleanup"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				appliedLB := terraformManager.ApplyCall.Receives.BBLState.LB
+				Expect(appliedLB.Previous).To(Equal(storage.LBCertificate{}))
+				Expect(appliedLB.Cert).To(Equal("some-old-cert"))
+				Expect(appliedLB.CertSlot).To(Equal("alt"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State.LB.Previous).To(Equal(storage.LBCertificate{}))
+			})
+
+			It("does nothing when there is no previous certificate", func() {
+				incomingState.LB.Previous = storage.LBCertificate{}
+
+				err := command.Execute([]string{"--cleanup"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("no previous lb certificate to clean up"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the certificate cannot be read", func() {
+				err := command.Execute([]string{"--cert", "/some/fake/path", "--key", keyPath}, incomingState)
+				Expect(err).To(MatchError("open /some/fake/path: no such file or directory"))
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+			})
+
+			It("saves the state returned with a terraform manager error", func() {
+				managerError := &fakes.TerraformManagerError{}
+				managerError.BBLStateCall.Returns.BBLState = storage.State{TFState: "some-partial-tf-state"}
+				terraformManager.ApplyCall.Stub = nil
+				terraformManager.ApplyCall.Returns.Error = managerError
+
+				err := command.Execute([]string{"--cert", certPath, "--key", keyPath}, incomingState)
+				Expect(err).To(Equal(managerError))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State.TFState).To(Equal("some-partial-tf-state"))
+			})
+
+			It("returns an error when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to save")}}
+
+				err := command.Execute([]string{"--cert", certPath, "--key", keyPath}, incomingState)
+				Expect(err).To(MatchError("failed to save"))
+			})
+		})
+	})
+
+	Describe("DryRun", func() {
+		It("prints the plan for attaching the new certificate without applying it", func() {
+			terraformManager.PlanCall.Returns.Plan = "some-plan"
+
+			err := command.DryRun([]string{"--cert", certPath, "--key", keyPath}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.PlanCall.Receives.BBLState.LB.Cert).To(Equal("some-new-cert"))
+			Expect(terraformManager.PlanCall.Receives.BBLState.LB.Previous.Cert).To(Equal("some-old-cert"))
+			Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl rotate-lb-certs --dry-run would:
+  - attach the new lb certificate and keep the previous one with the terraform plan below
+  - save the bbl state
+
+some-plan`))
+		})
+
+		It("prints the plan for removing the previous certificate", func() {
+			incomingState.LB.Previous = storage.LBCertificate{Cert: "some-older-cert"}
+			terraformManager.PlanCall.Returns.Plan = "some-plan"
+
+			err := command.DryRun([]string{"--cleanup"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.PlanCall.Receives.BBLState.LB.Previous).To(Equal(storage.LBCertificate{}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl rotate-lb-certs --dry-run would:
+  - remove the previous lb certificate with the terraform plan below
+  - save the bbl state
+
+some-plan`))
+		})
+	})
+})
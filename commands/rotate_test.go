@@ -18,6 +18,7 @@ var _ = Describe("Rotate", func() {
 		terraformManager *fakes.TerraformManager
 		boshManager      *fakes.BOSHManager
 		stateValidator   *fakes.StateValidator
+		logger           *fakes.Logger
 
 		command commands.Rotate
 
@@ -31,7 +32,9 @@ var _ = Describe("Rotate", func() {
 		boshManager = &fakes.BOSHManager{}
 		stateValidator = &fakes.StateValidator{}
 
-		command = commands.NewRotate(stateStore, keyPairManager, terraformManager, boshManager, stateValidator)
+		logger = &fakes.Logger{}
+
+		command = commands.NewRotate(stateStore, keyPairManager, terraformManager, boshManager, stateValidator, logger)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -166,4 +169,21 @@ var _ = Describe("Rotate", func() {
 			})
 		})
 	})
+
+	Describe("DryRun", func() {
+		It("lists the rotation without rotating the key pair", func() {
+			err := command.DryRun([]string{}, storage.State{
+				KeyPair: storage.KeyPair{Name: "some-keypair"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(keyPairManager.RotateCall.CallCount).To(Equal(0))
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal(`bbl rotate --dry-run would:
+  - replace the key pair "some-keypair" with a new key pair
+  - save the bbl state
+  - recreate the BOSH director with the new key pair`))
+		})
+	})
 })
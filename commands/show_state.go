@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	ShowStateCommand = "state"
+
+	redactedValue = "<redacted>"
+)
+
+// ShowState prints the bbl state for debugging, with the credentials it
+// holds redacted so that the output can be shared.
+type ShowState struct {
+	logger         logger
+	stateValidator stateValidator
+}
+
+type showStateConfig struct {
+	json        bool
+	showSecrets bool
+}
+
+func NewShowState(logger logger, stateValidator stateValidator) ShowState {
+	return ShowState{
+		logger:         logger,
+		stateValidator: stateValidator,
+	}
+}
+
+func (s ShowState) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := s.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.parseArgs(subcommandFlags)
+	return err
+}
+
+func (s ShowState) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if !config.showSecrets {
+		state = redactState(state)
+	}
+
+	if config.json {
+		return printJSON(s.logger, state)
+	}
+
+	// round trip through json so that the yaml keys match bbl-state.json
+	contents, err := json.Marshal(state)
+	if err != nil {
+		return err //not tested
+	}
+
+	var document interface{}
+	err = json.Unmarshal(contents, &document)
+	if err != nil {
+		return err //not tested
+	}
+
+	output, err := yaml.Marshal(document)
+	if err != nil {
+		return err //not tested
+	}
+
+	s.logger.Println(strings.TrimSuffix(string(output), "\n"))
+	return nil
+}
+
+func (s ShowState) parseArgs(args []string) (showStateConfig, error) {
+	var config showStateConfig
+
+	showStateFlags := flags.New(ShowStateCommand)
+	showStateFlags.Bool(&config.json, "", "json", false)
+	showStateFlags.Bool(&config.showSecrets, "", "show-secrets", false)
+
+	err := showStateFlags.Parse(args)
+	if err != nil {
+		return showStateConfig{}, err
+	}
+
+	return config, nil
+}
+
+// redactState replaces the credentials, private keys and the terraform
+// state, which holds the credentials of the resources it created, that are
+// set in state, along with the interpolated manifests and the output of the
+// last failure, which can print them too. Empty fields are left empty so that the output still shows
+// what is missing.
+func redactState(state storage.State) storage.State {
+	secrets := []*string{
+		&state.AWS.SecretAccessKey,
+		&state.AWS.SessionToken,
+		&state.Azure.ClientSecret,
+		&state.GCP.ServiceAccountKey,
+		&state.OpenStack.Password,
+		&state.KeyPair.PrivateKey,
+		&state.Jumpbox.Variables,
+		&state.Jumpbox.Manifest,
+		&state.BOSH.DirectorPassword,
+		&state.BOSH.DirectorSSLPrivateKey,
+		&state.BOSH.Variables,
+		&state.BOSH.Manifest,
+		&state.BOSH.UserCAPrivateKey,
+		&state.DirectorDB.Password,
+		&state.LB.Key,
+		&state.LB.Previous.Key,
+		&state.LB.ACME.AccountKey,
+		&state.TFState,
+		&state.LatestTFOutput,
+		&state.LatestError.Output,
+	}
+
+	for _, secret := range secrets {
+		if *secret != "" {
+			*secret = redactedValue
+		}
+	}
+
+	if len(state.BOSH.Credentials) > 0 {
+		credentials := map[string]string{}
+		for name := range state.BOSH.Credentials {
+			credentials[name] = redactedValue
+		}
+		state.BOSH.Credentials = credentials
+	}
+
+	return state
+}
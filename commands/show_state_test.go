@@ -0,0 +1,148 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ShowState", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+
+		command commands.ShowState
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+
+		state = storage.State{
+			Version: 8,
+			IAAS:    "gcp",
+			EnvID:   "some-env-id",
+			GCP: storage.GCP{
+				ServiceAccountKey: "some-service-account-key",
+				ProjectID:         "some-project-id",
+			},
+			KeyPair: storage.KeyPair{
+				PrivateKey: "some-private-key",
+				PublicKey:  "some-public-key",
+			},
+			BOSH: storage.BOSH{
+				DirectorUsername:      "admin",
+				DirectorPassword:      "some-director-password",
+				DirectorSSLPrivateKey: "some-director-ssl-private-key",
+				Credentials:           map[string]string{"mbusPassword": "some-mbus-password"},
+				Manifest:              "instance_groups:\n- name: bosh\n  properties:\n    director:\n      password: some-manifest-password\n",
+			},
+			Jumpbox: storage.Jumpbox{
+				Manifest: "instance_groups:\n- name: jumpbox\n  properties:\n    private_key: some-jumpbox-private-key\n",
+			},
+			TFState:        "some-tf-state",
+			LatestTFOutput: "some-tf-output with some-tf-password",
+			LatestError: storage.LatestError{
+				Message: "some-error",
+				Output:  "some-error-output with some-error-password",
+			},
+		}
+
+		command = commands.NewShowState(logger, stateValidator)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when an unknown flag is provided", func() {
+			err := command.CheckFastFails([]string{"--some-unknown-flag"}, state)
+			Expect(err).To(MatchError("flag provided but not defined: -some-unknown-flag"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints the state as yaml with the secrets redacted", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+			output := logger.PrintlnCall.Messages[0]
+			Expect(output).To(ContainSubstring("envID: some-env-id"))
+			Expect(output).To(ContainSubstring("projectID: some-project-id"))
+			Expect(output).To(ContainSubstring("publicKey: some-public-key"))
+			Expect(output).To(ContainSubstring("directorUsername: admin"))
+			Expect(output).To(ContainSubstring("serviceAccountKey: <redacted>"))
+			Expect(output).To(ContainSubstring("directorPassword: <redacted>"))
+			Expect(output).To(ContainSubstring("mbusPassword: <redacted>"))
+			Expect(output).To(ContainSubstring("tfState: <redacted>"))
+			Expect(output).NotTo(ContainSubstring("some-service-account-key"))
+			Expect(output).NotTo(ContainSubstring("some-private-key"))
+			Expect(output).NotTo(ContainSubstring("some-director-password"))
+			Expect(output).NotTo(ContainSubstring("some-director-ssl-private-key"))
+			Expect(output).NotTo(ContainSubstring("some-mbus-password"))
+			Expect(output).NotTo(ContainSubstring("some-tf-state"))
+		})
+
+		It("redacts the manifests and the output of the last failure, which can hold secrets", func() {
+			err := command.Execute([]string{"--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			var printed storage.State
+			err = json.Unmarshal([]byte(logger.PrintlnCall.Messages[0]), &printed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(printed.BOSH.Manifest).To(Equal("<redacted>"))
+			Expect(printed.Jumpbox.Manifest).To(Equal("<redacted>"))
+			Expect(printed.LatestTFOutput).To(Equal("<redacted>"))
+			Expect(printed.LatestError.Output).To(Equal("<redacted>"))
+			Expect(printed.LatestError.Message).To(Equal("some-error"))
+			Expect(logger.PrintlnCall.Messages[0]).NotTo(ContainSubstring("some-manifest-password"))
+			Expect(logger.PrintlnCall.Messages[0]).NotTo(ContainSubstring("some-jumpbox-private-key"))
+		})
+
+		It("leaves secrets that are not set empty", func() {
+			err := command.Execute([]string{"--json"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var printed storage.State
+			err = json.Unmarshal([]byte(logger.PrintlnCall.Messages[0]), &printed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(printed.BOSH.DirectorPassword).To(BeEmpty())
+			Expect(printed.TFState).To(BeEmpty())
+		})
+
+		It("prints the state as json with --json", func() {
+			err := command.Execute([]string{"--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			var printed storage.State
+			err = json.Unmarshal([]byte(logger.PrintlnCall.Messages[0]), &printed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(printed.EnvID).To(Equal("some-env-id"))
+			Expect(printed.GCP.ServiceAccountKey).To(Equal("<redacted>"))
+			Expect(printed.KeyPair.PrivateKey).To(Equal("<redacted>"))
+		})
+
+		It("prints the secrets with --show-secrets", func() {
+			err := command.Execute([]string{"--json", "--show-secrets"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			var printed storage.State
+			err = json.Unmarshal([]byte(logger.PrintlnCall.Messages[0]), &printed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(printed).To(Equal(state))
+		})
+	})
+})
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const SSHCommand = "ssh"
+
+type SSH struct {
+	logger         logger
+	stateValidator stateValidator
+	sshKeyGetter   directorSSHKeyGetter
+	sshShell       sshShell
+}
+
+type directorSSHKeyGetter interface {
+	Get(storage.State) (string, error)
+	GetDirector(storage.State) (string, error)
+}
+
+type sshShell interface {
+	Jumpbox(key, jumpboxURL string) error
+	Director(jumpboxKey, jumpboxURL, directorKey, directorAddr string) error
+}
+
+type sshConfig struct {
+	jumpbox  bool
+	director bool
+}
+
+func NewSSH(logger logger, stateValidator stateValidator, sshKeyGetter directorSSHKeyGetter, sshShell sshShell) SSH {
+	return SSH{
+		logger:         logger,
+		stateValidator: stateValidator,
+		sshKeyGetter:   sshKeyGetter,
+		sshShell:       sshShell,
+	}
+}
+
+func (s SSH) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := s.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	config, err := s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.jumpbox && !state.Jumpbox.Enabled {
+		return errors.New("This environment does not have a jumpbox.")
+	}
+
+	if config.director && (state.NoDirector || state.BOSH.DirectorAddress == "") {
+		return errors.New("Error BBL does not manage this director.")
+	}
+
+	if config.director {
+		return validateDirectorSSH(state)
+	}
+
+	return nil
+}
+
+func (s SSH) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.jumpbox {
+		privateKey, err := s.sshKeyGetter.Get(state)
+		if err != nil {
+			return err
+		}
+
+		s.logger.Step("opening a shell on the jumpbox at %s", state.Jumpbox.URL)
+		return s.sshShell.Jumpbox(privateKey, state.Jumpbox.URL)
+	}
+
+	directorKey, err := s.sshKeyGetter.GetDirector(state)
+	if err != nil {
+		return err
+	}
+
+	if directorKey == "" {
+		return errors.New("The director does not have an ssh key yet, run bbl up to add one.")
+	}
+
+	directorURL, err := url.Parse(state.BOSH.DirectorAddress)
+	if err != nil {
+		return err
+	}
+	directorAddr := net.JoinHostPort(directorURL.Hostname(), "22")
+
+	var jumpboxKey, jumpboxURL string
+	if state.Jumpbox.Enabled {
+		jumpboxKey, err = s.sshKeyGetter.Get(state)
+		if err != nil {
+			return err
+		}
+		jumpboxURL = state.Jumpbox.URL
+	}
+
+	s.logger.Step("opening a shell on the director at %s", directorAddr)
+	return s.sshShell.Director(jumpboxKey, jumpboxURL, directorKey, directorAddr)
+}
+
+// validateDirectorSSH checks the director has the jumpbox user, which a
+// director behind a jumpbox only gets with bbl up --director-ssh.
+func validateDirectorSSH(state storage.State) error {
+	if state.Jumpbox.Enabled && !state.DirectorSSH {
+		return errors.New("The director behind the jumpbox has no ssh user, run bbl up --director-ssh to add one.")
+	}
+
+	return nil
+}
+
+func (s SSH) parseArgs(args []string) (sshConfig, error) {
+	var config sshConfig
+
+	sshFlags := flags.New(SSHCommand)
+	sshFlags.Bool(&config.jumpbox, "", "jumpbox", false)
+	sshFlags.Bool(&config.director, "", "director", false)
+
+	err := sshFlags.Parse(args)
+	if err != nil {
+		return sshConfig{}, err
+	}
+
+	if config.jumpbox == config.director {
+		return sshConfig{}, errors.New("bbl ssh requires exactly one of --jumpbox or --director")
+	}
+
+	return config, nil
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"errors"
 
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -13,6 +14,10 @@ type SSHKey struct {
 	sshKeyGetter   sshKeyGetter
 }
 
+type sshKeyConfig struct {
+	json bool
+}
+
 type sshKeyGetter interface {
 	Get(storage.State) (string, error)
 }
@@ -33,10 +38,20 @@ func (s SSHKey) CheckFastFails(subcommandFlags []string, state storage.State) er
 		return err
 	}
 
+	_, err = s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (s SSHKey) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
 	privateKey, err := s.sshKeyGetter.Get(state)
 	if err != nil {
 		return err
@@ -46,7 +61,25 @@ func (s SSHKey) Execute(subcommandFlags []string, state storage.State) error {
 		return errors.New("Could not retrieve the ssh key, please make sure you are targeting the proper state dir.")
 	}
 
+	if config.json {
+		return printJSON(s.logger, map[string]string{"ssh_key": privateKey})
+	}
+
 	s.logger.Println(privateKey)
 
 	return nil
 }
+
+func (s SSHKey) parseArgs(args []string) (sshKeyConfig, error) {
+	var config sshKeyConfig
+
+	sshKeyFlags := flags.New("ssh-key")
+	sshKeyFlags.Bool(&config.json, "", "json", false)
+
+	err := sshKeyFlags.Parse(args)
+	if err != nil {
+		return sshKeyConfig{}, err
+	}
+
+	return config, nil
+}
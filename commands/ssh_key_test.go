@@ -40,6 +40,11 @@ var _ = Describe("SSHKey", func() {
 			err := sshKeyCommand.CheckFastFails([]string{}, incomingState)
 			Expect(err).To(MatchError("state validator failed"))
 		})
+
+		It("returns an error when an unknown flag is provided", func() {
+			err := sshKeyCommand.CheckFastFails([]string{"--unknown-flag"}, incomingState)
+			Expect(err).To(MatchError("flag provided but not defined: -unknown-flag"))
+		})
 	})
 
 	Describe("Execute", func() {
@@ -52,6 +57,13 @@ var _ = Describe("SSHKey", func() {
 			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"some-private-ssh-key"}))
 		})
 
+		It("prints the private ssh key as json", func() {
+			err := sshKeyCommand.ExecuteJSON([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"ssh_key": "some-private-ssh-key"}`))
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when the ssh key getter fails", func() {
 				sshKeyGetter.GetCall.Returns.Error = errors.New("jumpbox ssh key getter failed")
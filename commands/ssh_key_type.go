@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func updateSSHKeyType(state storage.State, keyType string, bits int, logger logger) storage.State {
+	if keyType == "" && bits == 0 {
+		return state
+	}
+
+	if keyType == "" {
+		keyType = helpers.SSHKeyTypeRSA
+	}
+
+	changed := keyType != state.KeyPair.Type || bits != state.KeyPair.Bits
+	if changed && state.KeyPair.PrivateKey != "" {
+		logger.Println(fmt.Sprintf("the existing keypair will continue to be used, run `bbl rotate` to replace it with a %s key", keyType))
+	}
+
+	state.KeyPair.Type = keyType
+	state.KeyPair.Bits = bits
+	return state
+}
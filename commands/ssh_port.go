@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+)
+
+const jumpboxAgentPort = 6868
+
+func validateSSHPort(port int, jumpbox bool, iaas string) error {
+	if port == 0 {
+		return nil
+	}
+
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("--ssh-port must be between 1 and 65535, got %d", port)
+	}
+
+	if iaas != "gcp" {
+		return errors.New(`--ssh-port is only supported when iaas="gcp"`)
+	}
+
+	if !jumpbox {
+		return errors.New("--ssh-port requires --credhub, which deploys the director behind a jumpbox")
+	}
+
+	if port == jumpboxAgentPort {
+		return fmt.Errorf("--ssh-port cannot be %d, which the jumpbox agent listens on", jumpboxAgentPort)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSH", func() {
+	var (
+		command commands.SSH
+
+		incomingState storage.State
+
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		sshKeyGetter   *fakes.SSHKeyGetter
+		sshShell       *fakes.SSHShell
+	)
+
+	BeforeEach(func() {
+		incomingState = storage.State{
+			Jumpbox: storage.Jumpbox{
+				Enabled: true,
+				URL:     "10.0.0.5:22",
+			},
+			BOSH: storage.BOSH{
+				DirectorAddress: "https://10.0.0.6:25555",
+			},
+		}
+
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+		sshKeyGetter.GetCall.Returns.PrivateKey = "some-jumpbox-key"
+		sshKeyGetter.GetDirectorCall.Returns.PrivateKey = "some-director-key"
+		sshShell = &fakes.SSHShell{}
+
+		command = commands.NewSSH(logger, stateValidator, sshKeyGetter, sshShell)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{"--jumpbox"}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when neither --jumpbox nor --director is provided", func() {
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("bbl ssh requires exactly one of --jumpbox or --director"))
+		})
+
+		It("returns an error when both --jumpbox and --director are provided", func() {
+			err := command.CheckFastFails([]string{"--jumpbox", "--director"}, incomingState)
+			Expect(err).To(MatchError("bbl ssh requires exactly one of --jumpbox or --director"))
+		})
+
+		It("returns an error when the environment has no jumpbox", func() {
+			incomingState.Jumpbox = storage.Jumpbox{}
+
+			err := command.CheckFastFails([]string{"--jumpbox"}, incomingState)
+			Expect(err).To(MatchError("This environment does not have a jumpbox."))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			incomingState.NoDirector = true
+
+			err := command.CheckFastFails([]string{"--director"}, incomingState)
+			Expect(err).To(MatchError("Error BBL does not manage this director."))
+		})
+
+		It("returns an error when the director behind the jumpbox has no ssh user", func() {
+			err := command.CheckFastFails([]string{"--director"}, incomingState)
+			Expect(err).To(MatchError("The director behind the jumpbox has no ssh user, run bbl up --director-ssh to add one."))
+		})
+
+		It("does not return an error when the director behind the jumpbox has an ssh user", func() {
+			incomingState.DirectorSSH = true
+
+			err := command.CheckFastFails([]string{"--director"}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Execute", func() {
+		Context("when --jumpbox is provided", func() {
+			It("opens a shell on the jumpbox with the jumpbox key", func() {
+				err := command.Execute([]string{"--jumpbox"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyGetter.GetCall.Receives.State).To(Equal(incomingState))
+				Expect(sshShell.JumpboxCall.CallCount).To(Equal(1))
+				Expect(sshShell.JumpboxCall.Receives.Key).To(Equal("some-jumpbox-key"))
+				Expect(sshShell.JumpboxCall.Receives.JumpboxURL).To(Equal("10.0.0.5:22"))
+				Expect(logger.StepCall.Messages).To(ContainElement("opening a shell on the jumpbox at 10.0.0.5:22"))
+			})
+		})
+
+		Context("when --director is provided", func() {
+			It("opens a shell on the director through the jumpbox", func() {
+				err := command.Execute([]string{"--director"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyGetter.GetDirectorCall.Receives.State).To(Equal(incomingState))
+				Expect(sshShell.DirectorCall.CallCount).To(Equal(1))
+				Expect(sshShell.DirectorCall.Receives.JumpboxKey).To(Equal("some-jumpbox-key"))
+				Expect(sshShell.DirectorCall.Receives.JumpboxURL).To(Equal("10.0.0.5:22"))
+				Expect(sshShell.DirectorCall.Receives.DirectorKey).To(Equal("some-director-key"))
+				Expect(sshShell.DirectorCall.Receives.DirectorAddr).To(Equal("10.0.0.6:22"))
+				Expect(logger.StepCall.Messages).To(ContainElement("opening a shell on the director at 10.0.0.6:22"))
+			})
+
+			Context("when there is no jumpbox", func() {
+				It("opens a shell on the director directly", func() {
+					incomingState.Jumpbox = storage.Jumpbox{}
+
+					err := command.Execute([]string{"--director"}, incomingState)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(sshKeyGetter.GetCall.CallCount).To(Equal(0))
+					Expect(sshShell.DirectorCall.Receives.JumpboxKey).To(BeEmpty())
+					Expect(sshShell.DirectorCall.Receives.JumpboxURL).To(BeEmpty())
+					Expect(sshShell.DirectorCall.Receives.DirectorAddr).To(Equal("10.0.0.6:22"))
+				})
+			})
+
+			It("returns an error when the director has no ssh key", func() {
+				sshKeyGetter.GetDirectorCall.Returns.PrivateKey = ""
+
+				err := command.Execute([]string{"--director"}, incomingState)
+				Expect(err).To(MatchError("The director does not have an ssh key yet, run bbl up to add one."))
+				Expect(sshShell.DirectorCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the jumpbox key cannot be retrieved", func() {
+				sshKeyGetter.GetCall.Returns.Error = errors.New("failed to get key")
+
+				err := command.Execute([]string{"--jumpbox"}, incomingState)
+				Expect(err).To(MatchError("failed to get key"))
+			})
+
+			It("returns an error when the director key cannot be retrieved", func() {
+				sshKeyGetter.GetDirectorCall.Returns.Error = errors.New("failed to get director key")
+
+				err := command.Execute([]string{"--director"}, incomingState)
+				Expect(err).To(MatchError("failed to get director key"))
+			})
+
+			It("returns an error when the jumpbox shell fails", func() {
+				sshShell.JumpboxCall.Returns.Error = errors.New("failed to ssh")
+
+				err := command.Execute([]string{"--jumpbox"}, incomingState)
+				Expect(err).To(MatchError("failed to ssh"))
+			})
+
+			It("returns an error when the director shell fails", func() {
+				sshShell.DirectorCall.Returns.Error = errors.New("failed to ssh")
+
+				err := command.Execute([]string{"--director"}, incomingState)
+				Expect(err).To(MatchError("failed to ssh"))
+			})
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
@@ -28,17 +29,38 @@ type StateQuery struct {
 	stateValidator        stateValidator
 	terraformManager      terraformOutputter
 	infrastructureManager infrastructureManager
+	boshClientProvider    boshClientProvider
+	socks5Proxy           socks5Proxy
+	sshKeyGetter          sshKeyGetter
 	propertyName          string
 }
 
+type stateQueryConfig struct {
+	check bool
+	json  bool
+}
+
+var stateQueryJSONKeys = map[string]string{
+	EnvIDPropertyName:            "env_id",
+	JumpboxAddressPropertyName:   "jumpbox_address",
+	DirectorUsernamePropertyName: "director_username",
+	DirectorPasswordPropertyName: "director_password",
+	DirectorAddressPropertyName:  "director_address",
+	DirectorCACertPropertyName:   "director_ca_cert",
+}
+
 type getPropertyFunc func(storage.State) string
 
-func NewStateQuery(logger logger, stateValidator stateValidator, terraformManager terraformOutputter, infrastructureManager infrastructureManager, propertyName string) StateQuery {
+func NewStateQuery(logger logger, stateValidator stateValidator, terraformManager terraformOutputter, infrastructureManager infrastructureManager,
+	boshClientProvider boshClientProvider, socks5Proxy socks5Proxy, sshKeyGetter sshKeyGetter, propertyName string) StateQuery {
 	return StateQuery{
 		logger:                logger,
 		stateValidator:        stateValidator,
 		terraformManager:      terraformManager,
 		infrastructureManager: infrastructureManager,
+		boshClientProvider:    boshClientProvider,
+		socks5Proxy:           socks5Proxy,
+		sshKeyGetter:          sshKeyGetter,
 		propertyName:          propertyName,
 	}
 }
@@ -53,10 +75,30 @@ func (s StateQuery) CheckFastFails(subcommandFlags []string, state storage.State
 		return errors.New("Error BBL does not manage this director.")
 	}
 
+	config, err := s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.check {
+		if !s.isDirectorQuery() {
+			return fmt.Errorf("--check is not supported when querying the %s", s.propertyName)
+		}
+
+		if state.NoDirector {
+			return errors.New("--check cannot be used when bbl does not manage the director")
+		}
+	}
+
 	return nil
 }
 
 func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
 	var propertyValue string
 	switch s.propertyName {
 	case JumpboxAddressPropertyName:
@@ -87,10 +129,61 @@ func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error
 		return fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", s.propertyName)
 	}
 
+	if config.check {
+		err := s.checkDirector(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.json {
+		return printJSON(s.logger, map[string]string{stateQueryJSONKeys[s.propertyName]: propertyValue})
+	}
+
 	s.logger.Println(propertyValue)
 	return nil
 }
 
+func (s StateQuery) checkDirector(state storage.State) error {
+	boshClient, err := directorClient(state, s.boshClientProvider, s.socks5Proxy, s.sshKeyGetter)
+	if err != nil {
+		return fmt.Errorf("Could not connect to the director through the jumpbox at %s: %s", state.Jumpbox.URL, err)
+	}
+
+	_, err = boshClient.Info()
+	if err != nil {
+		if state.Jumpbox.Enabled {
+			return fmt.Errorf("Could not reach the director at %s through the jumpbox at %s: %s", state.BOSH.DirectorAddress, state.Jumpbox.URL, err)
+		}
+		return fmt.Errorf("Could not reach the director at %s: %s", state.BOSH.DirectorAddress, err)
+	}
+
+	return nil
+}
+
+func (s StateQuery) isDirectorQuery() bool {
+	switch s.propertyName {
+	case DirectorUsernamePropertyName, DirectorPasswordPropertyName, DirectorAddressPropertyName, DirectorCACertPropertyName:
+		return true
+	}
+	return false
+}
+
+func (s StateQuery) parseArgs(args []string) (stateQueryConfig, error) {
+	var config stateQueryConfig
+
+	stateQueryFlags := flags.New("state-query")
+	stateQueryFlags.Bool(&config.check, "", "check", false)
+	stateQueryFlags.Bool(&config.json, "", "json", false)
+
+	err := stateQueryFlags.Parse(args)
+	if err != nil {
+		return stateQueryConfig{}, err
+	}
+
+	return config, nil
+}
+
 func (s StateQuery) getEIP(state storage.State) (string, error) {
 	switch state.IAAS {
 	case "aws":
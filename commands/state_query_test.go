@@ -21,6 +21,10 @@ var _ = Describe("StateQuery", func() {
 		fakeStateValidator        *fakes.StateValidator
 		fakeTerraformManager      *fakes.TerraformManager
 		fakeInfrastructureManager *fakes.InfrastructureManager
+		fakeBOSHClientProvider    *fakes.BOSHClientProvider
+		fakeBOSHClient            *fakes.BOSHClient
+		fakeSocks5Proxy           *fakes.Socks5Proxy
+		fakeSSHKeyGetter          *fakes.SSHKeyGetter
 	)
 
 	BeforeEach(func() {
@@ -28,12 +32,17 @@ var _ = Describe("StateQuery", func() {
 		fakeStateValidator = &fakes.StateValidator{}
 		fakeTerraformManager = &fakes.TerraformManager{}
 		fakeInfrastructureManager = &fakes.InfrastructureManager{}
+		fakeBOSHClient = &fakes.BOSHClient{}
+		fakeBOSHClientProvider = &fakes.BOSHClientProvider{}
+		fakeBOSHClientProvider.ClientCall.Returns.Client = fakeBOSHClient
+		fakeSocks5Proxy = &fakes.Socks5Proxy{}
+		fakeSSHKeyGetter = &fakes.SSHKeyGetter{}
 	})
 
 	Describe("CheckFastFails", func() {
 		It("returns an error when the state validator fails", func() {
 			fakeStateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
-			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "")
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "")
 
 			err := command.CheckFastFails([]string{}, storage.State{})
 
@@ -52,7 +61,7 @@ var _ = Describe("StateQuery", func() {
 
 			DescribeTable("prints out the director information",
 				func(propertyName string) {
-					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, propertyName)
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, propertyName)
 
 					err := command.CheckFastFails([]string{}, state)
 					Expect(err).To(MatchError("Error BBL does not manage this director."))
@@ -64,6 +73,29 @@ var _ = Describe("StateQuery", func() {
 		})
 	})
 
+	Describe("CheckFastFails with --check", func() {
+		It("returns an error when the property is not a director property", func() {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "jumpbox address")
+
+			err := command.CheckFastFails([]string{"--check"}, storage.State{})
+			Expect(err).To(MatchError("--check is not supported when querying the jumpbox address"))
+		})
+
+		It("returns an error when bbl does not manage the director", func() {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director address")
+
+			err := command.CheckFastFails([]string{"--check"}, storage.State{NoDirector: true})
+			Expect(err).To(MatchError("--check cannot be used when bbl does not manage the director"))
+		})
+
+		It("returns an error when an unknown flag is provided", func() {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director address")
+
+			err := command.CheckFastFails([]string{"--some-unknown-flag"}, storage.State{})
+			Expect(err).To(MatchError("flag provided but not defined: -some-unknown-flag"))
+		})
+	})
+
 	Describe("Execute", func() {
 		Context("bbl manages the jumpbox", func() {
 			var state storage.State
@@ -78,7 +110,7 @@ var _ = Describe("StateQuery", func() {
 			})
 
 			It("prints out the jumpbox information", func() {
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "jumpbox address")
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "jumpbox address")
 
 				err := command.Execute([]string{}, state)
 				Expect(err).NotTo(HaveOccurred())
@@ -103,7 +135,7 @@ var _ = Describe("StateQuery", func() {
 
 			DescribeTable("prints out the director information",
 				func(propertyName, expectedOutput string) {
-					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, propertyName)
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, propertyName)
 
 					err := command.Execute([]string{}, state)
 					Expect(err).NotTo(HaveOccurred())
@@ -115,6 +147,82 @@ var _ = Describe("StateQuery", func() {
 				Entry("director-password", "director password", "some-director-password"),
 				Entry("director-ssl-ca", "director ca cert", "some-director-ssl-ca"),
 			)
+
+			DescribeTable("prints out the director information as json",
+				func(propertyName, expectedOutput string) {
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, propertyName)
+
+					err := command.ExecuteJSON([]string{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeLogger.PrintlnCall.Receives.Message).To(MatchJSON(expectedOutput))
+				},
+				Entry("director-address", "director address", `{"director_address": "some-director-address"}`),
+				Entry("director-username", "director username", `{"director_username": "some-director-username"}`),
+				Entry("director-password", "director password", `{"director_password": "some-director-password"}`),
+				Entry("director-ssl-ca", "director ca cert", `{"director_ca_cert": "some-director-ssl-ca"}`),
+			)
+
+			Context("when --check is provided", func() {
+				var command commands.StateQuery
+
+				BeforeEach(func() {
+					command = commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director password")
+				})
+
+				It("checks that the director is reachable before printing the value", func() {
+					err := command.Execute([]string{"--check"}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeBOSHClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+					Expect(fakeBOSHClientProvider.ClientCall.Receives.DirectorUsername).To(Equal("some-director-username"))
+					Expect(fakeBOSHClientProvider.ClientCall.Receives.DirectorPassword).To(Equal("some-director-password"))
+					Expect(fakeBOSHClientProvider.ClientCall.Receives.DirectorCACert).To(Equal("some-director-ssl-ca"))
+					Expect(fakeBOSHClient.InfoCall.CallCount).To(Equal(1))
+					Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("some-director-password"))
+				})
+
+				It("does not check the director without the flag", func() {
+					err := command.Execute([]string{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeBOSHClientProvider.ClientCall.CallCount).To(Equal(0))
+					Expect(fakeBOSHClient.InfoCall.CallCount).To(Equal(0))
+				})
+
+				Context("failure cases", func() {
+					It("returns an error and prints nothing when the director is unreachable", func() {
+						fakeBOSHClient.InfoCall.Returns.Error = errors.New("connection refused")
+
+						err := command.Execute([]string{"--check"}, state)
+						Expect(err).To(MatchError("Could not reach the director at some-director-address: connection refused"))
+						Expect(fakeLogger.PrintlnCall.CallCount).To(Equal(0))
+					})
+
+					It("returns an error when the director is unreachable through the jumpbox", func() {
+						state.Jumpbox = storage.Jumpbox{
+							Enabled: true,
+							URL:     "some-jumpbox-url",
+						}
+						fakeBOSHClient.InfoCall.Returns.Error = errors.New("connection refused")
+
+						err := command.Execute([]string{"--check"}, state)
+						Expect(err).To(MatchError("Could not reach the director at some-director-address through the jumpbox at some-jumpbox-url: connection refused"))
+					})
+
+					It("returns an error when the proxy through the jumpbox cannot be started", func() {
+						state.Jumpbox = storage.Jumpbox{
+							Enabled: true,
+							URL:     "some-jumpbox-url",
+						}
+						fakeSocks5Proxy.StartCall.Returns.Error = errors.New("ssh handshake failed")
+
+						err := command.Execute([]string{"--check"}, state)
+						Expect(err).To(MatchError("Could not connect to the director through the jumpbox at some-jumpbox-url: ssh handshake failed"))
+						Expect(fakeLogger.PrintlnCall.CallCount).To(Equal(0))
+					})
+				})
+			})
 		})
 
 		Context("bbl does not manage the bosh director", func() {
@@ -128,7 +236,7 @@ var _ = Describe("StateQuery", func() {
 			})
 
 			It("prints the env id", func() {
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "environment id")
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "environment id")
 
 				err := command.Execute([]string{}, state)
 				Expect(err).NotTo(HaveOccurred())
@@ -136,6 +244,15 @@ var _ = Describe("StateQuery", func() {
 				Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("some-env-id"))
 			})
 
+			It("prints the env id as json", func() {
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "environment id")
+
+				err := command.ExecuteJSON([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeLogger.PrintlnCall.Receives.Message).To(MatchJSON(`{"env_id": "some-env-id"}`))
+			})
+
 			Context("gcp", func() {
 				It("prints the eip as the director-address", func() {
 					fakeTerraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
@@ -144,7 +261,7 @@ var _ = Describe("StateQuery", func() {
 
 					state.IAAS = "gcp"
 
-					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "director address")
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director address")
 					err := command.Execute([]string{}, state)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("https://some-external-ip:25555"))
@@ -161,7 +278,7 @@ var _ = Describe("StateQuery", func() {
 
 					state.IAAS = "aws"
 
-					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "director address")
+					command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director address")
 					err := command.Execute([]string{}, state)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("https://some-external-ip:25555"))
@@ -172,7 +289,7 @@ var _ = Describe("StateQuery", func() {
 		Context("failure cases", func() {
 			It("returns an error when the terraform output provider fails", func() {
 				fakeTerraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get terraform output")
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "director address")
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director address")
 
 				err := command.Execute([]string{}, storage.State{
 					IAAS:       "gcp",
@@ -184,7 +301,7 @@ var _ = Describe("StateQuery", func() {
 
 			It("returns an error when the infrastructure manager fails", func() {
 				fakeInfrastructureManager.DescribeCall.Returns.Error = errors.New("failed to describe stack")
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "director address")
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director address")
 
 				err := command.Execute([]string{}, storage.State{
 					IAAS:       "aws",
@@ -195,7 +312,7 @@ var _ = Describe("StateQuery", func() {
 			})
 
 			It("returns an error when an external ip cannot be found", func() {
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, "director address")
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, "director address")
 
 				err := command.Execute([]string{}, storage.State{
 					IAAS:       "lol",
@@ -207,7 +324,7 @@ var _ = Describe("StateQuery", func() {
 
 			It("returns an error when the state value is empty", func() {
 				propertyName := fmt.Sprintf("%s-%d", "some-name", rand.Int())
-				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, propertyName)
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, fakeTerraformManager, fakeInfrastructureManager, fakeBOSHClientProvider, fakeSocks5Proxy, fakeSSHKeyGetter, propertyName)
 				err := command.Execute([]string{}, storage.State{
 					BOSH: storage.BOSH{},
 				})
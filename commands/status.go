@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	UpStatusRunning = "running"
+	UpStatusFailed  = "failed"
+	UpStatusStale   = "stale"
+)
+
+type processChecker interface {
+	Running(pid int) bool
+}
+
+type Status struct {
+	logger             logger
+	stateValidator     stateValidator
+	terraformManager   terraformOutputter
+	boshClientProvider boshClientProvider
+	socks5Proxy        socks5Proxy
+	sshKeyGetter       sshKeyGetter
+	processChecker     processChecker
+}
+
+type statusConfig struct {
+	json bool
+}
+
+type environmentStatus struct {
+	IAAS                 string            `json:"iaas"`
+	EnvID                string            `json:"env_id"`
+	Region               string            `json:"region,omitempty"`
+	Zone                 string            `json:"zone,omitempty"`
+	DirectorManaged      bool              `json:"director_managed"`
+	DirectorAddress      string            `json:"director_address,omitempty"`
+	DirectorReachable    bool              `json:"director_reachable"`
+	LBType               string            `json:"lb_type,omitempty"`
+	LBs                  map[string]string `json:"lbs,omitempty"`
+	TerraformLastApplied string            `json:"terraform_last_applied,omitempty"`
+	UpInProgress         string            `json:"up_in_progress,omitempty"`
+	UpStatus             string            `json:"up_status,omitempty"`
+	UpPID                int               `json:"up_pid,omitempty"`
+	UpLogFile            string            `json:"up_log_file,omitempty"`
+	LatestError          string            `json:"latest_error,omitempty"`
+}
+
+func NewStatus(logger logger, stateValidator stateValidator, terraformManager terraformOutputter,
+	boshClientProvider boshClientProvider, socks5Proxy socks5Proxy, sshKeyGetter sshKeyGetter, processChecker processChecker) Status {
+	return Status{
+		logger:             logger,
+		stateValidator:     stateValidator,
+		terraformManager:   terraformManager,
+		boshClientProvider: boshClientProvider,
+		socks5Proxy:        socks5Proxy,
+		sshKeyGetter:       sshKeyGetter,
+		processChecker:     processChecker,
+	}
+}
+
+func (s Status) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := s.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s Status) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := s.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	status := environmentStatus{
+		IAAS:                 state.IAAS,
+		EnvID:                state.EnvID,
+		DirectorManaged:      !state.NoDirector,
+		DirectorAddress:      state.BOSH.DirectorAddress,
+		LBType:               state.LB.Type,
+		TerraformLastApplied: state.TFLastApplied,
+		UpInProgress:         state.UpProgress.Phase,
+		UpPID:                state.UpProgress.PID,
+		UpLogFile:            state.UpProgress.LogFile,
+		LatestError:          state.LatestError.Message,
+	}
+
+	switch state.IAAS {
+	case "aws":
+		status.Region = state.AWS.Region
+	case "azure":
+		status.Region = state.Azure.Region
+	case "gcp":
+		status.Region = state.GCP.Region
+		status.Zone = state.GCP.Zone
+	case "openstack":
+		status.Region = state.OpenStack.Region
+	}
+
+	if state.UpProgress.Phase != "" {
+		status.UpStatus = s.upStatus(state.UpProgress)
+	}
+
+	if state.TFState != "" && lbExists(state.LB.Type) {
+		terraformOutputs, err := s.terraformManager.GetOutputs(state)
+		if err == nil {
+			status.LBs = lbEndpoints(state, terraformOutputs)
+		}
+	}
+
+	if !state.NoDirector && state.BOSH.DirectorAddress != "" {
+		status.DirectorReachable = s.directorReachable(state)
+	}
+
+	if config.json {
+		output, err := json.Marshal(status)
+		if err != nil {
+			// not tested
+			return err
+		}
+
+		s.logger.Println(string(output))
+		return nil
+	}
+
+	s.logger.Println(statusTable(status))
+	return nil
+}
+
+func (s Status) directorReachable(state storage.State) bool {
+	boshClient, err := directorClient(state, s.boshClientProvider, s.socks5Proxy, s.sshKeyGetter)
+	if err != nil {
+		return false
+	}
+
+	_, err = boshClient.Info()
+	return err == nil
+}
+
+// upStatus reports a detached up whose bbl exited without recording a
+// failure, because it was killed or the machine restarted, as stale.
+func (s Status) upStatus(progress storage.UpProgress) string {
+	switch {
+	case progress.Failed:
+		return UpStatusFailed
+	case progress.PID == 0 || !s.processChecker.Running(progress.PID):
+		return UpStatusStale
+	}
+
+	return UpStatusRunning
+}
+
+func (s Status) parseArgs(args []string) (statusConfig, error) {
+	var config statusConfig
+
+	statusFlags := flags.New("status")
+	statusFlags.Bool(&config.json, "", "json", false)
+
+	err := statusFlags.Parse(args)
+	if err != nil {
+		return statusConfig{}, err
+	}
+
+	return config, nil
+}
+
+func lbEndpoints(state storage.State, terraformOutputs map[string]interface{}) map[string]string {
+	var outputNames map[string]string
+	switch {
+	case state.IAAS == "gcp" && state.LB.Type == "cf":
+		outputNames = map[string]string{
+			"cf_router_lb":     "router_lb_ip",
+			"cf_ssh_proxy_lb":  "ssh_proxy_lb_ip",
+			"cf_tcp_router_lb": "tcp_router_lb_ip",
+			"cf_websocket_lb":  "ws_lb_ip",
+		}
+	case state.IAAS == "gcp" && state.LB.Type == "concourse":
+		outputNames = map[string]string{
+			"concourse_lb": "concourse_lb_ip",
+		}
+	case state.IAAS == "aws" && state.LB.Type == "cf":
+		outputNames = map[string]string{
+			"cf_router_lb":    "cf_router_lb_url",
+			"cf_ssh_proxy_lb": "cf_ssh_lb_url",
+			"cf_tcp_lb":       "cf_tcp_lb_url",
+		}
+	case state.IAAS == "aws" && state.LB.Type == "concourse":
+		outputNames = map[string]string{
+			"concourse_lb": "concourse_lb_url",
+		}
+	}
+
+	endpoints := map[string]string{}
+	for name, outputName := range outputNames {
+		if endpoint, ok := terraformOutputs[outputName].(string); ok {
+			endpoints[name] = endpoint
+		}
+	}
+
+	return endpoints
+}
+
+func statusTable(status environmentStatus) string {
+	buf := bytes.NewBuffer([]byte{})
+	writer := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "IAAS:\t%s\n", status.IAAS)
+	fmt.Fprintf(writer, "Environment ID:\t%s\n", valueOrNone(status.EnvID))
+	if status.Region != "" {
+		fmt.Fprintf(writer, "Region:\t%s\n", status.Region)
+	}
+	if status.Zone != "" {
+		fmt.Fprintf(writer, "Zone:\t%s\n", status.Zone)
+	}
+	fmt.Fprintf(writer, "Director managed by bbl:\t%s\n", yesOrNo(status.DirectorManaged))
+	if status.DirectorManaged {
+		fmt.Fprintf(writer, "Director address:\t%s\n", valueOrNone(status.DirectorAddress))
+		fmt.Fprintf(writer, "Director reachable:\t%s\n", yesOrNo(status.DirectorReachable))
+	}
+	fmt.Fprintf(writer, "Load balancers:\t%s\n", valueOrNone(status.LBType))
+
+	var lbNames []string
+	for name := range status.LBs {
+		lbNames = append(lbNames, name)
+	}
+	sort.Strings(lbNames)
+	for _, name := range lbNames {
+		fmt.Fprintf(writer, "  %s:\t%s\n", name, status.LBs[name])
+	}
+
+	fmt.Fprintf(writer, "Terraform last applied:\t%s\n", valueOrNone(status.TerraformLastApplied))
+	if status.UpInProgress != "" {
+		fmt.Fprintf(writer, "Detached up:\t%s (pid %d), %s completed, see %s\n", status.UpStatus, status.UpPID, status.UpInProgress, status.UpLogFile)
+	}
+	if status.LatestError != "" {
+		fmt.Fprintf(writer, "Latest error:\t%s\n", strings.SplitN(status.LatestError, "\n", 2)[0])
+	}
+	writer.Flush()
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func yesOrNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,244 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Status", func() {
+	var (
+		command commands.Status
+
+		incomingState storage.State
+
+		logger             *fakes.Logger
+		stateValidator     *fakes.StateValidator
+		terraformManager   *fakes.TerraformManager
+		boshClientProvider *fakes.BOSHClientProvider
+		boshClient         *fakes.BOSHClient
+		socks5Proxy        *fakes.Socks5Proxy
+		sshKeyGetter       *fakes.SSHKeyGetter
+		processChecker     *fakes.ProcessChecker
+	)
+
+	BeforeEach(func() {
+		incomingState = storage.State{
+			IAAS:  "gcp",
+			EnvID: "some-env-id",
+			GCP: storage.GCP{
+				Region: "some-region",
+				Zone:   "some-zone",
+			},
+			BOSH: storage.BOSH{
+				DirectorAddress: "some-director-address",
+			},
+			LB: storage.LB{
+				Type: "concourse",
+			},
+			TFState:       "some-tf-state",
+			TFLastApplied: "2017-08-01T12:30:00Z",
+		}
+
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		terraformManager = &fakes.TerraformManager{}
+		terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+			"concourse_lb_ip": "some-concourse-lb-ip",
+		}
+		boshClient = &fakes.BOSHClient{}
+		boshClientProvider = &fakes.BOSHClientProvider{}
+		boshClientProvider.ClientCall.Returns.Client = boshClient
+		socks5Proxy = &fakes.Socks5Proxy{}
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+		processChecker = &fakes.ProcessChecker{}
+		processChecker.RunningCall.Returns.Running = true
+
+		command = commands.NewStatus(logger, stateValidator, terraformManager, boshClientProvider, socks5Proxy, sshKeyGetter, processChecker)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints a summary of the environment", func() {
+			err := command.Execute([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(incomingState))
+			Expect(boshClient.InfoCall.CallCount).To(Equal(1))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				"IAAS:                     gcp\n" +
+					"Environment ID:           some-env-id\n" +
+					"Region:                   some-region\n" +
+					"Zone:                     some-zone\n" +
+					"Director managed by bbl:  yes\n" +
+					"Director address:         some-director-address\n" +
+					"Director reachable:       yes\n" +
+					"Load balancers:           concourse\n" +
+					"  concourse_lb:           some-concourse-lb-ip\n" +
+					"Terraform last applied:   2017-08-01T12:30:00Z",
+			}))
+		})
+
+		Context("when --json is provided", func() {
+			It("prints the summary as json", func() {
+				incomingState.UpProgress = storage.UpProgress{
+					PID:     1234,
+					Phase:   "terraform",
+					LogFile: "some-state-dir/bbl-up.log",
+				}
+				incomingState.LatestError = storage.LatestError{
+					Message: "some error",
+				}
+
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+				Expect(logger.PrintlnCall.Messages[0]).To(MatchJSON(`{
+					"iaas": "gcp",
+					"env_id": "some-env-id",
+					"region": "some-region",
+					"zone": "some-zone",
+					"director_managed": true,
+					"director_address": "some-director-address",
+					"director_reachable": true,
+					"lb_type": "concourse",
+					"lbs": {
+						"concourse_lb": "some-concourse-lb-ip"
+					},
+					"terraform_last_applied": "2017-08-01T12:30:00Z",
+					"up_in_progress": "terraform",
+					"up_status": "running",
+					"up_pid": 1234,
+					"up_log_file": "some-state-dir/bbl-up.log",
+					"latest_error": "some error"
+				}`))
+			})
+		})
+
+		Context("when an up continues in the background", func() {
+			BeforeEach(func() {
+				incomingState.UpProgress = storage.UpProgress{
+					PID:     1234,
+					Phase:   "bosh",
+					LogFile: "some-state-dir/bbl-up.log",
+				}
+			})
+
+			It("reports the up as running while its bbl is running", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(processChecker.RunningCall.Receives.PID).To(Equal(1234))
+				Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring("Detached up:              running (pid 1234), bosh completed, see some-state-dir/bbl-up.log"))
+			})
+
+			It("reports the up as failed when it recorded a failure", func() {
+				incomingState.UpProgress.Failed = true
+				incomingState.LatestError = storage.LatestError{
+					Message: "failed to update cloud config\nsome details",
+				}
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(processChecker.RunningCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring("Detached up:              failed (pid 1234), bosh completed, see some-state-dir/bbl-up.log\n" +
+					"Latest error:             failed to update cloud config"))
+				Expect(logger.PrintlnCall.Messages[0]).NotTo(ContainSubstring("some details"))
+			})
+
+			It("reports the up as stale when its bbl is no longer running", func() {
+				processChecker.RunningCall.Returns.Running = false
+
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`"up_status":"stale"`))
+			})
+		})
+
+		DescribeTable("reports the region of the iaas", func(state storage.State, expectedRegion string) {
+			err := command.Execute([]string{"--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`"region":"` + expectedRegion + `"`))
+		},
+			Entry("on aws", storage.State{IAAS: "aws", AWS: storage.AWS{Region: "some-aws-region"}}, "some-aws-region"),
+			Entry("on azure", storage.State{IAAS: "azure", Azure: storage.Azure{Region: "some-azure-region"}}, "some-azure-region"),
+			Entry("on openstack", storage.State{IAAS: "openstack", OpenStack: storage.OpenStack{Region: "some-openstack-region"}}, "some-openstack-region"),
+		)
+
+		Context("when the director cannot be reached", func() {
+			It("reports the director as unreachable", func() {
+				boshClient.InfoCall.Returns.Error = errors.New("connection refused")
+
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`"director_reachable":false`))
+			})
+		})
+
+		Context("when parts of the environment do not exist yet", func() {
+			It("does not query terraform or the director", func() {
+				err := command.Execute([]string{}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(0))
+				Expect(boshClientProvider.ClientCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"IAAS:                     aws\n" +
+						"Environment ID:           none\n" +
+						"Director managed by bbl:  yes\n" +
+						"Director address:         none\n" +
+						"Director reachable:       no\n" +
+						"Load balancers:           none\n" +
+						"Terraform last applied:   none",
+				}))
+			})
+
+			It("does not fail when the terraform outputs cannot be retrieved", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages[0]).NotTo(ContainSubstring(`"lbs"`))
+			})
+		})
+
+		Context("when bbl does not manage the director", func() {
+			It("does not check whether the director is reachable", func() {
+				incomingState.NoDirector = true
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshClientProvider.ClientCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring("Director managed by bbl:  no\n"))
+				Expect(logger.PrintlnCall.Messages[0]).NotTo(ContainSubstring("Director reachable"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--invalid-flag"}, incomingState)
+				Expect(err).To(MatchError("flag provided but not defined: -invalid-flag"))
+			})
+		})
+	})
+})
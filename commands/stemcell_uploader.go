@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stemcellUploader interface {
+	Upload(state storage.State, source string) (storage.State, error)
+}
+
+type StemcellUploader struct {
+	logger             logger
+	boshClientProvider boshClientProvider
+	socks5Proxy        socks5Proxy
+	sshKeyGetter       sshKeyGetter
+}
+
+type stemcellManifest struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+type stemcellFile struct {
+	*os.File
+	size int64
+}
+
+func (f stemcellFile) Size() int64 {
+	return f.size
+}
+
+func NewStemcellUploader(logger logger, boshClientProvider boshClientProvider, socks5Proxy socks5Proxy, sshKeyGetter sshKeyGetter) StemcellUploader {
+	return StemcellUploader{
+		logger:             logger,
+		boshClientProvider: boshClientProvider,
+		socks5Proxy:        socks5Proxy,
+		sshKeyGetter:       sshKeyGetter,
+	}
+}
+
+// Upload uploads the stemcell at source, a local path or a URL the director
+// downloads itself, unless the director already has it.
+func (s StemcellUploader) Upload(state storage.State, source string) (storage.State, error) {
+	boshClient, err := directorClient(state, s.boshClientProvider, s.socks5Proxy, s.sshKeyGetter)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	existing, err := boshClient.Stemcells()
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	if isStemcellURL(source) {
+		return s.uploadURL(boshClient, state, source, existing)
+	}
+
+	manifest, err := readStemcellManifest(source)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	if !hasStemcell(existing, manifest.Name, manifest.Version) {
+		file, err := os.Open(source)
+		if err != nil {
+			return storage.State{}, err
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return storage.State{}, err //not tested
+		}
+
+		s.logger.Step("uploading stemcell %s/%s", manifest.Name, manifest.Version)
+		err = boshClient.UploadStemcell(stemcellFile{File: file, size: info.Size()})
+		if err != nil {
+			return storage.State{}, err
+		}
+	} else {
+		s.logger.Step("stemcell %s/%s already exists on the director, skipping upload", manifest.Name, manifest.Version)
+	}
+
+	state.Stemcell = storage.Stemcell{Name: manifest.Name, Version: manifest.Version, Source: source}
+	return state, nil
+}
+
+func (s StemcellUploader) uploadURL(boshClient bosh.Client, state storage.State, source string, existing []bosh.Stemcell) (storage.State, error) {
+	if state.Stemcell.Source == source && hasStemcell(existing, state.Stemcell.Name, state.Stemcell.Version) {
+		s.logger.Step("stemcell %s/%s already exists on the director, skipping upload", state.Stemcell.Name, state.Stemcell.Version)
+		return state, nil
+	}
+
+	s.logger.Step("uploading stemcell from %s", source)
+	err := boshClient.UploadStemcellURL(source)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	uploaded, err := boshClient.Stemcells()
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	state.Stemcell = storage.Stemcell{Source: source}
+	for _, stemcell := range uploaded {
+		if !hasStemcell(existing, stemcell.Name, stemcell.Version) {
+			state.Stemcell.Name = stemcell.Name
+			state.Stemcell.Version = stemcell.Version
+			break
+		}
+	}
+
+	return state, nil
+}
+
+func isStemcellURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+func hasStemcell(stemcells []bosh.Stemcell, name, version string) bool {
+	for _, stemcell := range stemcells {
+		if stemcell.Name == name && stemcell.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readStemcellManifest reads the name and version from the stemcell.MF of a
+// stemcell tarball.
+func readStemcellManifest(path string) (stemcellManifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return stemcellManifest{}, fmt.Errorf("error reading stemcell: %v", err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return stemcellManifest{}, fmt.Errorf("error reading stemcell: %v", err)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return stemcellManifest{}, fmt.Errorf("error reading stemcell: %s does not contain a stemcell.MF", path)
+		}
+		if err != nil {
+			return stemcellManifest{}, fmt.Errorf("error reading stemcell: %v", err)
+		}
+
+		if strings.TrimPrefix(header.Name, "./") != "stemcell.MF" {
+			continue
+		}
+
+		contents, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return stemcellManifest{}, fmt.Errorf("error reading stemcell: %v", err) //not tested
+		}
+
+		var manifest stemcellManifest
+		err = yaml.Unmarshal(contents, &manifest)
+		if err != nil {
+			return stemcellManifest{}, fmt.Errorf("error reading stemcell manifest: %v", err)
+		}
+
+		return manifest, nil
+	}
+}
+
+func validateUploadStemcell(source string, noDirector bool, iaas string) error {
+	if noDirector {
+		return errors.New("--upload-stemcell cannot be used with --no-director")
+	}
+
+	if iaas != "aws" && iaas != "gcp" {
+		return errors.New(`--upload-stemcell is only supported when iaas="aws" or iaas="gcp"`)
+	}
+
+	if isStemcellURL(source) {
+		return nil
+	}
+
+	_, err := readStemcellManifest(source)
+	return err
+}
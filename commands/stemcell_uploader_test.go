@@ -0,0 +1,216 @@
+package commands_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StemcellUploader", func() {
+	var (
+		uploader commands.StemcellUploader
+
+		incomingState storage.State
+		stemcellPath  string
+
+		logger             *fakes.Logger
+		boshClientProvider *fakes.BOSHClientProvider
+		boshClient         *fakes.BOSHClient
+		socks5Proxy        *fakes.Socks5Proxy
+		sshKeyGetter       *fakes.SSHKeyGetter
+	)
+
+	BeforeEach(func() {
+		incomingState = storage.State{
+			BOSH: storage.BOSH{
+				DirectorAddress:  "some-director-address",
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+				DirectorSSLCA:    "some-director-ca",
+			},
+		}
+
+		var err error
+		stemcellPath, err = writeStemcell("name: some-stemcell\nversion: 3421.10\n")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = &fakes.Logger{}
+		boshClient = &fakes.BOSHClient{}
+		boshClientProvider = &fakes.BOSHClientProvider{}
+		boshClientProvider.ClientCall.Returns.Client = boshClient
+		socks5Proxy = &fakes.Socks5Proxy{}
+		sshKeyGetter = &fakes.SSHKeyGetter{}
+
+		uploader = commands.NewStemcellUploader(logger, boshClientProvider, socks5Proxy, sshKeyGetter)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(filepath.Dir(stemcellPath))
+	})
+
+	Context("when the stemcell is a local file", func() {
+		It("uploads the stemcell and records it in the state", func() {
+			state, err := uploader.Upload(incomingState, stemcellPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+			Expect(boshClient.UploadStemcellCall.CallCount).To(Equal(1))
+			Expect(boshClient.UploadStemcellCall.Receives.Stemcell.Size()).To(BeNumerically(">", 0))
+			Expect(logger.StepCall.Messages).To(ContainElement("uploading stemcell some-stemcell/3421.10"))
+
+			Expect(state.Stemcell).To(Equal(storage.Stemcell{
+				Name:    "some-stemcell",
+				Version: "3421.10",
+				Source:  stemcellPath,
+			}))
+			Expect(state.BOSH).To(Equal(incomingState.BOSH))
+		})
+
+		It("skips the upload when the director already has the stemcell", func() {
+			boshClient.StemcellsCall.Returns.Stemcells = []bosh.Stemcell{{Name: "some-stemcell", Version: "3421.10"}}
+
+			state, err := uploader.Upload(incomingState, stemcellPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClient.UploadStemcellCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(ContainElement("stemcell some-stemcell/3421.10 already exists on the director, skipping upload"))
+			Expect(state.Stemcell.Version).To(Equal("3421.10"))
+		})
+
+		It("returns an error when the file is not a stemcell", func() {
+			err := ioutil.WriteFile(stemcellPath, []byte("not a stemcell"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = uploader.Upload(incomingState, stemcellPath)
+			Expect(err).To(MatchError(ContainSubstring("error reading stemcell")))
+		})
+
+		It("returns an error when the stemcell has no manifest", func() {
+			emptyPath, err := writeTarball(map[string]string{"image": "some-image"})
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(filepath.Dir(emptyPath))
+
+			_, err = uploader.Upload(incomingState, emptyPath)
+			Expect(err).To(MatchError(ContainSubstring("does not contain a stemcell.MF")))
+		})
+
+		It("returns an error when the stemcell cannot be uploaded", func() {
+			boshClient.UploadStemcellCall.Returns.Error = errors.New("failed to upload")
+
+			_, err := uploader.Upload(incomingState, stemcellPath)
+			Expect(err).To(MatchError("failed to upload"))
+		})
+	})
+
+	Context("when the stemcell is a url", func() {
+		var stemcellURL = "https://example.com/some-stemcell.tgz"
+
+		It("has the director download the stemcell and records the new stemcell in the state", func() {
+			calls := 0
+			boshClient.StemcellsCall.Stub = func() ([]bosh.Stemcell, error) {
+				calls++
+				if calls == 1 {
+					return []bosh.Stemcell{{Name: "some-stemcell", Version: "3421.9"}}, nil
+				}
+				return []bosh.Stemcell{{Name: "some-stemcell", Version: "3421.9"}, {Name: "some-stemcell", Version: "3421.10"}}, nil
+			}
+
+			state, err := uploader.Upload(incomingState, stemcellURL)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClient.UploadStemcellURLCall.Receives.StemcellURL).To(Equal(stemcellURL))
+			Expect(state.Stemcell).To(Equal(storage.Stemcell{
+				Name:    "some-stemcell",
+				Version: "3421.10",
+				Source:  stemcellURL,
+			}))
+		})
+
+		It("skips the upload when the stemcell from the same url is still on the director", func() {
+			incomingState.Stemcell = storage.Stemcell{Name: "some-stemcell", Version: "3421.10", Source: stemcellURL}
+			boshClient.StemcellsCall.Returns.Stemcells = []bosh.Stemcell{{Name: "some-stemcell", Version: "3421.10"}}
+
+			state, err := uploader.Upload(incomingState, stemcellURL)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClient.UploadStemcellURLCall.CallCount).To(Equal(0))
+			Expect(state.Stemcell).To(Equal(incomingState.Stemcell))
+		})
+
+		It("uploads again when the recorded stemcell was deleted from the director", func() {
+			incomingState.Stemcell = storage.Stemcell{Name: "some-stemcell", Version: "3421.10", Source: stemcellURL}
+
+			_, err := uploader.Upload(incomingState, stemcellURL)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boshClient.UploadStemcellURLCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the stemcell cannot be uploaded", func() {
+			boshClient.UploadStemcellURLCall.Returns.Error = errors.New("failed to upload")
+
+			_, err := uploader.Upload(incomingState, stemcellURL)
+			Expect(err).To(MatchError("failed to upload"))
+		})
+	})
+
+	It("returns an error when the director stemcells cannot be listed", func() {
+		boshClient.StemcellsCall.Returns.Error = errors.New("failed to list stemcells")
+
+		_, err := uploader.Upload(incomingState, stemcellPath)
+		Expect(err).To(MatchError("failed to list stemcells"))
+	})
+})
+
+func writeStemcell(manifest string) (string, error) {
+	return writeTarball(map[string]string{
+		"stemcell.MF": manifest,
+		"image":       "some-image",
+	})
+}
+
+func writeTarball(files map[string]string) (string, error) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "stemcell.tgz")
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, contents := range files {
+		err = tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))})
+		if err != nil {
+			return "", err
+		}
+
+		_, err = tarWriter.Write([]byte(contents))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return path, gzipWriter.Close()
+}
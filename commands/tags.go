@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+var (
+	gcpLabelKeyRegexp   = regexp.MustCompile(`^[a-z][-_a-z0-9]{0,62}$`)
+	gcpLabelValueRegexp = regexp.MustCompile(`^[-_a-z0-9]{0,63}$`)
+
+	// reservedTagKeys are the tags bbl sets on its aws resources itself.
+	reservedTagKeys = map[string]bool{"Name": true, "EnvID": true}
+)
+
+func parseTags(tags []string, iaas string) ([]storage.Tag, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	if iaas != "aws" && iaas != "gcp" {
+		return nil, errors.New(`--tag is only supported when iaas="aws" or iaas="gcp"`)
+	}
+
+	var parsedTags []storage.Tag
+	keys := map[string]bool{}
+
+	for _, tag := range tags {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid tag %q: expected format key=value.", tag)
+		}
+
+		key, value := parts[0], parts[1]
+
+		switch iaas {
+		case "aws":
+			if reservedTagKeys[key] || strings.HasPrefix(key, "aws:") {
+				return nil, fmt.Errorf("Invalid tag %q: key %q is reserved.", tag, key)
+			}
+
+			if len(key) > 127 || len(value) > 255 {
+				return nil, fmt.Errorf("Invalid tag %q: keys can be up to 127 and values up to 255 characters long.", tag)
+			}
+		case "gcp":
+			if !gcpLabelKeyRegexp.MatchString(key) || !gcpLabelValueRegexp.MatchString(value) {
+				return nil, fmt.Errorf("Invalid tag %q: gcp labels must consist of lowercase letters, digits, dashes and underscores, with keys starting with a letter.", tag)
+			}
+		}
+
+		if keys[key] {
+			return nil, fmt.Errorf("Invalid tag %q: key %q is used more than once.", tag, key)
+		}
+		keys[key] = true
+
+		parsedTags = append(parsedTags, storage.Tag{Key: key, Value: value})
+	}
+
+	return parsedTags, nil
+}
+
+// useTags replaces the tags of an earlier up when tags are provided, and
+// otherwise keeps them, so that a later up does not untag the environment.
+func useTags(state storage.State, tags []storage.Tag) storage.State {
+	if len(tags) > 0 {
+		state.Tags = tags
+	}
+
+	return state
+}
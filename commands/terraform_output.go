@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	TerraformOutputCommand = "terraform-output"
+)
+
+type TerraformOutput struct {
+	logger           logger
+	stateValidator   stateValidator
+	terraformManager terraformOutputter
+}
+
+type terraformOutputConfig struct {
+	name string
+	json bool
+}
+
+func NewTerraformOutput(logger logger, stateValidator stateValidator, terraformManager terraformOutputter) TerraformOutput {
+	return TerraformOutput{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		terraformManager: terraformManager,
+	}
+}
+
+func (t TerraformOutput) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := t.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = t.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.TFState == "" {
+		return errors.New("bbl terraform-output requires an environment created with terraform")
+	}
+
+	return nil
+}
+
+func (t TerraformOutput) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := t.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := t.terraformManager.GetOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	value, ok := outputs[config.name]
+	if !ok {
+		var names []string
+		for name := range outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("terraform output %q does not exist, available outputs are: %s", config.name, strings.Join(names, ", "))
+	}
+
+	if config.json {
+		contents, err := json.Marshal(value)
+		if err != nil {
+			return err //not tested
+		}
+
+		t.logger.Println(string(contents))
+		return nil
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Errorf("terraform output %q is a list or map, use --json to print it", config.name)
+	}
+
+	t.logger.Println(fmt.Sprintf("%v", value))
+	return nil
+}
+
+func (t TerraformOutput) parseArgs(args []string) (terraformOutputConfig, error) {
+	var config terraformOutputConfig
+
+	outputFlags := flags.New("terraform-output")
+	outputFlags.Bool(&config.json, "", "json", false)
+
+	// the output name may be given before or after the flags
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		config.name = args[0]
+		args = args[1:]
+	}
+
+	err := outputFlags.Parse(args)
+	if err != nil {
+		return terraformOutputConfig{}, err
+	}
+
+	remaining := outputFlags.Args()
+	if config.name == "" && len(remaining) > 0 {
+		config.name = remaining[0]
+		remaining = remaining[1:]
+	}
+
+	if config.name == "" || len(remaining) > 0 {
+		return terraformOutputConfig{}, errors.New("bbl terraform-output requires exactly one output name")
+	}
+
+	return config, nil
+}
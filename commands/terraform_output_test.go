@@ -0,0 +1,116 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TerraformOutput", func() {
+	var (
+		logger           *fakes.Logger
+		stateValidator   *fakes.StateValidator
+		terraformManager *fakes.TerraformManager
+
+		command commands.TerraformOutput
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		terraformManager = &fakes.TerraformManager{}
+
+		terraformManager.GetOutputsCall.Returns.Outputs = map[string]interface{}{
+			"director_address": "some-director-address",
+			"extra_ips":        []interface{}{"10.0.0.1", "10.0.0.2"},
+			"router_pools":     map[string]interface{}{"z1": "some-pool"},
+		}
+
+		state = storage.State{
+			IAAS:    "gcp",
+			TFState: "some-tf-state",
+		}
+
+		command = commands.NewTerraformOutput(logger, stateValidator, terraformManager)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when state validator fails", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+			err := command.CheckFastFails([]string{"director_address"}, state)
+			Expect(err).To(MatchError("state validator failed"))
+		})
+
+		It("returns an error when no output name is given", func() {
+			err := command.CheckFastFails([]string{"--json"}, state)
+			Expect(err).To(MatchError("bbl terraform-output requires exactly one output name"))
+		})
+
+		It("returns an error when more than one output name is given", func() {
+			err := command.CheckFastFails([]string{"director_address", "extra_ips"}, state)
+			Expect(err).To(MatchError("bbl terraform-output requires exactly one output name"))
+		})
+
+		It("returns an error when the environment has no terraform state", func() {
+			err := command.CheckFastFails([]string{"director_address"}, storage.State{IAAS: "aws"})
+			Expect(err).To(MatchError("bbl terraform-output requires an environment created with terraform"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints the output", func() {
+			err := command.Execute([]string{"director_address"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.GetOutputsCall.Receives.BBLState).To(Equal(state))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"some-director-address"}))
+		})
+
+		It("prints list and map outputs as json with --json", func() {
+			err := command.Execute([]string{"extra_ips", "--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = command.Execute([]string{"--json", "router_pools"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				`["10.0.0.1","10.0.0.2"]`,
+				`{"z1":"some-pool"}`,
+			}))
+		})
+
+		It("prints string outputs as json with --json", func() {
+			err := command.Execute([]string{"director_address", "--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{`"some-director-address"`}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error listing the available outputs when the output does not exist", func() {
+				err := command.Execute([]string{"missing_output"}, state)
+				Expect(err).To(MatchError(`terraform output "missing_output" does not exist, available outputs are: director_address, extra_ips, router_pools`))
+			})
+
+			It("returns an error for list outputs without --json", func() {
+				err := command.Execute([]string{"extra_ips"}, state)
+				Expect(err).To(MatchError(`terraform output "extra_ips" is a list or map, use --json to print it`))
+			})
+
+			It("returns an error when the outputs cannot be retrieved", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get outputs")
+
+				err := command.Execute([]string{"director_address"}, state)
+				Expect(err).To(MatchError("failed to get outputs"))
+			})
+		})
+	})
+})
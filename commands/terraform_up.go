@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type terraformUpConfig struct {
+	OpsFilePaths []string
+	Name         string
+	NoDirector   bool
+	SSHKeyType   string
+	SSHKeyBits   int
+	FromPhase    string
+}
+
+// terraformUp runs the phases of up for the iaases whose infrastructure is
+// created by terraform alone and whose key pair only lives in the bbl state.
+type terraformUp struct {
+	stateStore         stateStore
+	keyPairManager     keyPairManager
+	boshManager        boshManager
+	cloudConfigManager cloudConfigManager
+	terraformManager   terraformApplier
+	envIDManager       envIDManager
+	logger             logger
+}
+
+// execute calls checkState once the state knows whether a director is
+// created, before anything is written to the state dir.
+func (u terraformUp) execute(upConfig terraformUpConfig, state storage.State, checkState func(storage.State) error) error {
+	err := u.terraformManager.ValidateVersion()
+	if err != nil {
+		return err
+	}
+
+	opsFiles, err := readOpsFiles(upConfig.OpsFilePaths)
+	if err != nil {
+		return fmt.Errorf("error reading ops-file contents: %v", err)
+	}
+
+	if upConfig.NoDirector {
+		if !state.BOSH.IsEmpty() {
+			return errors.New(`Director already exists, you must re-create your environment to use "--no-director"`)
+		}
+
+		state.NoDirector = true
+	}
+
+	err = checkState(state)
+	if err != nil {
+		return err
+	}
+
+	state, err = u.envIDManager.Sync(state, upConfig.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := u.stateStore.Set(state); err != nil {
+		return err
+	}
+
+	state = updateSSHKeyType(state, upConfig.SSHKeyType, upConfig.SSHKeyBits, u.logger)
+
+	if !skipUpPhase(state, UpPhaseKeyPair, upConfig.FromPhase, u.logger) {
+		state, err = u.keyPairManager.Sync(state)
+		if err != nil {
+			return err
+		}
+		state = checkpointUpPhase(state, UpPhaseKeyPair)
+	}
+
+	if err := u.stateStore.Set(state); err != nil {
+		return err
+	}
+
+	if !skipUpPhase(state, UpPhaseTerraform, upConfig.FromPhase, u.logger) {
+		state, err = u.terraformManager.Apply(state)
+		if err != nil {
+			return handleTerraformError(err, u.stateStore)
+		}
+		state = checkpointUpPhase(state, UpPhaseTerraform)
+
+		if err := u.stateStore.Set(state); err != nil {
+			return err
+		}
+	}
+
+	if state.NoDirector {
+		return finishUp(state, u.stateStore)
+	}
+
+	terraformOutputs, err := u.terraformManager.GetOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	state = updateUserOpsFiles(state, opsFiles)
+
+	if !skipUpPhase(state, UpPhaseBOSH, upConfig.FromPhase, u.logger) {
+		state, err = u.boshManager.CreateDirector(state, terraformOutputs)
+		switch err.(type) {
+		case bosh.ManagerCreateError:
+			bcErr := err.(bosh.ManagerCreateError)
+			if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
+				errorList := helpers.Errors{}
+				errorList.Add(err)
+				errorList.Add(setErr)
+				return errorList
+			}
+			return err
+		case error:
+			return err
+		}
+		state = checkpointUpPhase(state, UpPhaseBOSH)
+
+		if err := u.stateStore.Set(state); err != nil {
+			return err
+		}
+	}
+
+	if !skipUpPhase(state, UpPhaseCloudConfig, upConfig.FromPhase, u.logger) {
+		err = u.cloudConfigManager.Update(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	return finishUp(state, u.stateStore)
+}
@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
 	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
@@ -11,8 +14,15 @@ type Up struct {
 	awsUp       awsUp
 	azureUp     azureUp
 	gcpUp       gcpUp
+	openstackUp openstackUp
 	envGetter   envGetter
 	boshManager boshManager
+	terraform   terraformPlanner
+	logger      logger
+
+	awsQuotaChecker   awsQuotaChecker
+	gcpQuotaChecker   gcpQuotaChecker
+	awsKeyPairChecker awsKeyPairChecker
 }
 
 type awsUp interface {
@@ -27,24 +37,97 @@ type azureUp interface {
 	Execute(azureUpConfig AzureUpConfig, state storage.State) error
 }
 
+type openstackUp interface {
+	Execute(openstackUpConfig OpenStackUpConfig, state storage.State) error
+}
+
+type awsQuotaChecker interface {
+	Check(environment ec2.QuotaEnvironment) error
+}
+
+type gcpQuotaChecker interface {
+	Check(region string, noDirector, jumpbox bool, directorVMType string) error
+}
+
+type awsKeyPairChecker interface {
+	HasKeyPair(keyPairName string) (bool, error)
+}
+
 type envGetter interface {
 	Get(name string) string
 }
 
 type upConfig struct {
-	name       string
-	opsFile    string
-	noDirector bool
-	jumpbox    bool
+	name             string
+	opsFiles         []string
+	noDirector       bool
+	jumpbox          bool
+	directorSSH      bool
+	detach           bool
+	gcpFirewallRules []string
+	clearFirewall    bool
+	vpcCIDR          string
+	networkCIDR      string
+	subnetCIDR       string
+	directorCACert   string
+	directorCAKey    string
+	directorSpot     bool
+	directorSpotMax  string
+	spotBidPrice     string
+	spotFallback     bool
+	directorDiskType string
+	directorVMType   string
+	directorDiskSize int
+	directorDB       bool
+	targets          []string
+	sshKeyType       string
+	sshKeyBits       int
+	skipKeyPair      bool
+	keyPairName      string
+	publicKey        string
+	privateKey       string
+	skipQuotaCheck   bool
+	uploadStemcell   string
+	sshPort          int
+	noPublicIPs      bool
+	nat              string
+	tags             []string
+	fromPhase        string
+
+	boshDeploymentPath       string
+	boshDeploymentVersion    string
+	jumpboxDeploymentPath    string
+	jumpboxDeploymentVersion string
+
+	directorStemcellURL     string
+	directorStemcellName    string
+	directorStemcellVersion string
+	directorStemcellSHA1    string
+
+	existingVPCID     string
+	existingSubnetIDs string
+	azs               string
+	zones             string
+
+	existingNetworkName    string
+	existingSubnetworkName string
 }
 
-func NewUp(awsUp awsUp, gcpUp gcpUp, azureUp azureUp, envGetter envGetter, boshManager boshManager) Up {
+func NewUp(awsUp awsUp, gcpUp gcpUp, azureUp azureUp, openstackUp openstackUp, envGetter envGetter, boshManager boshManager,
+	awsQuotaChecker awsQuotaChecker, gcpQuotaChecker gcpQuotaChecker, terraform terraformPlanner, logger logger,
+	awsKeyPairChecker awsKeyPairChecker) Up {
 	return Up{
-		awsUp:       awsUp,
-		azureUp:     azureUp,
-		gcpUp:       gcpUp,
-		envGetter:   envGetter,
-		boshManager: boshManager,
+		awsUp:             awsUp,
+		azureUp:           azureUp,
+		gcpUp:             gcpUp,
+		openstackUp:       openstackUp,
+		envGetter:         envGetter,
+		boshManager:       boshManager,
+		terraform:         terraform,
+		logger:            logger,
+		awsQuotaChecker:   awsQuotaChecker,
+		gcpQuotaChecker:   gcpQuotaChecker,
+		awsKeyPairChecker: awsKeyPairChecker,
 	}
 }
 
@@ -65,6 +148,217 @@ func (u Up) CheckFastFails(args []string, state storage.State) error {
 		return fmt.Errorf("The director name cannot be changed for an existing environment. Current name is %s.", state.EnvID)
 	}
 
+	if config.clearFirewall {
+		if state.IAAS != "gcp" {
+			return errors.New(`--clear-gcp-firewall-rules is only supported when iaas="gcp"`)
+		}
+
+		if len(config.gcpFirewallRules) > 0 {
+			return errors.New("--clear-gcp-firewall-rules cannot be used with --gcp-firewall-rule")
+		}
+	}
+
+	if len(config.gcpFirewallRules) > 0 {
+		if state.IAAS != "gcp" {
+			return errors.New(`--gcp-firewall-rule is only supported when iaas="gcp"`)
+		}
+
+		_, err = parseGCPFirewallRules(config.gcpFirewallRules)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.jumpbox && (state.IAAS == "azure" || state.IAAS == "openstack") {
+		return fmt.Errorf("--credhub is not supported when iaas=%q", state.IAAS)
+	}
+
+	if len(config.targets) > 0 && state.IAAS != "aws" && state.IAAS != "gcp" {
+		return errors.New(`--target is only supported when iaas="aws" or iaas="gcp"`)
+	}
+
+	// a targeted apply only converges part of an existing infrastructure, the
+	// first apply has to create all of it
+	if len(config.targets) > 0 && state.TFState == "" {
+		return errors.New("--target can only be used once the infrastructure exists, run `bbl up` without --target first")
+	}
+
+	err = validateExistingVPC(config.existingVPCID, parseExistingSubnetIDs(config.existingSubnetIDs), config.vpcCIDR, config.subnetCIDR, state)
+	if err != nil {
+		return err
+	}
+
+	err = validateZones(parseZones(config.azs), parseZones(config.zones), config.existingVPCID, state)
+	if err != nil {
+		return err
+	}
+
+	err = validateExistingNetwork(config.existingNetworkName, config.existingSubnetworkName, config.networkCIDR, config.subnetCIDR, state)
+	if err != nil {
+		return err
+	}
+
+	if config.vpcCIDR != "" && state.IAAS != "aws" {
+		return errors.New(`--vpc-cidr is only supported when iaas="aws", use --network-cidr instead`)
+	}
+
+	if config.networkCIDR != "" && state.IAAS != "gcp" {
+		return errors.New(`--network-cidr is only supported when iaas="gcp", use --vpc-cidr instead`)
+	}
+
+	if config.vpcCIDR != "" || config.networkCIDR != "" || config.subnetCIDR != "" {
+		networkCIDR := config.vpcCIDR
+		if state.IAAS == "gcp" {
+			networkCIDR = config.networkCIDR
+		}
+
+		err = validateNetworkCIDRs(mergeNetworkCIDRs(state.Network, networkCIDR, config.subnetCIDR))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateDirectorSpot(config.directorSpot, config.directorSpotMax, config.noDirector || state.NoDirector, state.IAAS)
+	if err != nil {
+		return err
+	}
+
+	err = validateCloudConfigSpot(config.spotBidPrice, config.spotFallback, state.IAAS)
+	if err != nil {
+		return err
+	}
+
+	err = validateDirectorDiskType(config.directorDiskType, config.noDirector || state.NoDirector, state.IAAS)
+	if err != nil {
+		return err
+	}
+
+	err = validateDirectorVMSize(config.directorVMType, config.directorDiskSize, config.noDirector || state.NoDirector, state.IAAS)
+	if err != nil {
+		return err
+	}
+
+	err = validateDirectorExternalDB(config.directorDB, config.jumpbox, config.noDirector || state.NoDirector, state)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseTags(config.tags, state.IAAS)
+	if err != nil {
+		return err
+	}
+
+	err = validateNAT(config.nat, config.existingVPCID, state)
+	if err != nil {
+		return err
+	}
+
+	err = validateNoPublicIPs(config.noPublicIPs, state)
+	if err != nil {
+		return err
+	}
+
+	err = validateFromPhase(config.fromPhase, config.noDirector || state.NoDirector, state)
+	if err != nil {
+		return err
+	}
+
+	err = validateDeploymentSources(config, config.noDirector || state.NoDirector)
+	if err != nil {
+		return err
+	}
+
+	err = validateDirectorStemcell(config, config.noDirector || state.NoDirector)
+	if err != nil {
+		return err
+	}
+
+	err = helpers.ValidateSSHKeyType(config.sshKeyType, config.sshKeyBits)
+	if err != nil {
+		return err
+	}
+
+	if !config.skipKeyPair && (config.keyPairName != "" || config.publicKey != "" || config.privateKey != "") {
+		return errors.New("--keypair-name, --public-key and --private-key require --skip-keypair")
+	}
+
+	publicKey, privateKey, err := readExternalKeyPair(config.publicKey, config.privateKey)
+	if err != nil {
+		return err
+	}
+
+	err = validateSkipKeyPair(config.skipKeyPair, config.keyPairName, publicKey, privateKey, state)
+	if err != nil {
+		return err
+	}
+
+	if config.skipKeyPair && config.keyPairName != "" {
+		exists, err := u.awsKeyPairChecker.HasKeyPair(config.keyPairName)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("keypair %q does not exist in %s", config.keyPairName, state.AWS.Region)
+		}
+	}
+
+	caCertificate, caPrivateKey, err := readDirectorCA(config.directorCACert, config.directorCAKey)
+	if err != nil {
+		return err
+	}
+
+	if caCertificate != "" {
+		if config.noDirector || state.NoDirector {
+			return errors.New("--director-ca-cert and --director-ca-key cannot be used with --no-director")
+		}
+
+		err = validateDirectorCA(caCertificate, caPrivateKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateSSHPort(config.sshPort, config.jumpbox, state.IAAS)
+	if err != nil {
+		return err
+	}
+
+	if config.uploadStemcell != "" {
+		err = validateUploadStemcell(config.uploadStemcell, config.noDirector || state.NoDirector, state.IAAS)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !config.skipQuotaCheck {
+		err = u.checkQuotas(config, state)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u Up) checkQuotas(config upConfig, state storage.State) error {
+	if state.TFState != "" || state.Stack.Name != "" {
+		return nil
+	}
+
+	switch state.IAAS {
+	case "aws":
+		return u.awsQuotaChecker.Check(ec2.QuotaEnvironment{
+			NoDirector:     config.noDirector,
+			Jumpbox:        config.jumpbox,
+			ExistingVPC:    config.existingVPCID != "",
+			NATGateway:     config.nat == "gateway",
+			DirectorVMType: config.directorVMType,
+		})
+	case "gcp":
+		return u.gcpQuotaChecker.Check(state.GCP.Region, config.noDirector, config.jumpbox, config.directorVMType)
+	}
+
 	return nil
 }
 
@@ -74,28 +368,203 @@ func (u Up) Execute(args []string, state storage.State) error {
 		return err
 	}
 
+	caCertificate, caPrivateKey, err := readDirectorCA(config.directorCACert, config.directorCAKey)
+	if err != nil {
+		return err
+	}
+
+	publicKey, privateKey, err := readExternalKeyPair(config.publicKey, config.privateKey)
+	if err != nil {
+		return err
+	}
+
+	tags, err := parseTags(config.tags, state.IAAS)
+	if err != nil {
+		return err
+	}
+
+	state = updateDeploymentSources(state, config)
+	state = updateDirectorStemcell(state, config)
+
+	if config.directorSSH {
+		state.DirectorSSH = true
+	}
+
 	switch state.IAAS {
 	case "aws":
 		err = u.awsUp.Execute(AWSUpConfig{
-			OpsFilePath: config.opsFile,
-			Name:        config.name,
-			NoDirector:  config.noDirector,
+			OpsFilePaths:         config.opsFiles,
+			Name:                 config.name,
+			NoDirector:           config.noDirector,
+			Detach:               config.detach,
+			VPCCIDR:              config.vpcCIDR,
+			SubnetCIDR:           config.subnetCIDR,
+			DirectorCACert:       caCertificate,
+			DirectorCAKey:        caPrivateKey,
+			DirectorSpot:         config.directorSpot,
+			DirectorSpotMaxPrice: config.directorSpotMax,
+			SpotBidPrice:         config.spotBidPrice,
+			SpotOnDemandFallback: config.spotFallback,
+			DirectorDiskType:     config.directorDiskType,
+			DirectorVMType:       config.directorVMType,
+			DirectorDiskSize:     config.directorDiskSize,
+			DirectorExternalDB:   config.directorDB,
+			Targets:              config.targets,
+			SSHKeyType:           config.sshKeyType,
+			SSHKeyBits:           config.sshKeyBits,
+			SkipKeyPair:          config.skipKeyPair,
+			KeyPairName:          config.keyPairName,
+			PublicKey:            publicKey,
+			PrivateKey:           privateKey,
+			UploadStemcell:       config.uploadStemcell,
+			ExistingVPCID:        config.existingVPCID,
+			ExistingSubnetIDs:    parseExistingSubnetIDs(config.existingSubnetIDs),
+			AZs:                  parseZones(config.azs),
+			NAT:                  config.nat,
+			Tags:                 tags,
+			FromPhase:            config.fromPhase,
 		}, state)
 	case "gcp":
+		var firewallRules []storage.GCPFirewallRule
+		firewallRules, err = parseGCPFirewallRules(config.gcpFirewallRules)
+		if err != nil {
+			return err
+		}
+
 		err = u.gcpUp.Execute(GCPUpConfig{
-			OpsFilePath: config.opsFile,
-			Name:        config.name,
-			NoDirector:  config.noDirector,
-			Jumpbox:     config.jumpbox,
+			OpsFilePaths:       config.opsFiles,
+			Name:               config.name,
+			NoDirector:         config.noDirector,
+			Jumpbox:            config.jumpbox,
+			Detach:             config.detach,
+			FirewallRules:      firewallRules,
+			ClearFirewallRules: config.clearFirewall,
+			NetworkCIDR:        config.networkCIDR,
+			SubnetCIDR:         config.subnetCIDR,
+			DirectorCACert:     caCertificate,
+			DirectorCAKey:      caPrivateKey,
+			DirectorSpot:       config.directorSpot,
+			DirectorDiskType:   config.directorDiskType,
+			DirectorVMType:     config.directorVMType,
+			DirectorDiskSize:   config.directorDiskSize,
+			DirectorExternalDB: config.directorDB,
+			Targets:            config.targets,
+			SSHKeyType:         config.sshKeyType,
+			SSHKeyBits:         config.sshKeyBits,
+			SkipKeyPair:        config.skipKeyPair,
+			PublicKey:          publicKey,
+			PrivateKey:         privateKey,
+			UploadStemcell:     config.uploadStemcell,
+			SSHPort:            config.sshPort,
+			NoPublicIPs:        config.noPublicIPs,
+			Tags:               tags,
+
+			ExistingNetworkName:    config.existingNetworkName,
+			ExistingSubnetworkName: config.existingSubnetworkName,
+			Zones:                  parseZones(config.zones),
+			FromPhase:              config.fromPhase,
 		}, state)
 	case "azure":
-		err = u.azureUp.Execute(AzureUpConfig{}, state)
+		err = u.azureUp.Execute(AzureUpConfig{
+			OpsFilePaths: config.opsFiles,
+			Name:         config.name,
+			NoDirector:   config.noDirector,
+			SSHKeyType:   config.sshKeyType,
+			SSHKeyBits:   config.sshKeyBits,
+			FromPhase:    config.fromPhase,
+		}, state)
+	case "openstack":
+		err = u.openstackUp.Execute(OpenStackUpConfig{
+			OpsFilePaths: config.opsFiles,
+			Name:         config.name,
+			NoDirector:   config.noDirector,
+			SSHKeyType:   config.sshKeyType,
+			SSHKeyBits:   config.sshKeyBits,
+			FromPhase:    config.fromPhase,
+		}, state)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u Up) DryRun(args []string, state storage.State) error {
+	config, err := u.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	changes := []string{}
+	if state.EnvID == "" {
+		changes = append(changes, fmt.Sprintf("create a new %s environment", state.IAAS))
 	}
 
+	switch state.IAAS {
+	case "aws":
+		state = updateNetworkCIDRs(state, config.vpcCIDR, config.subnetCIDR, u.logger)
+		state = useExistingVPC(state, config.existingVPCID, parseExistingSubnetIDs(config.existingSubnetIDs))
+		state = useZones(state, parseZones(config.azs), nil)
+		state = useNAT(state, config.nat)
+	case "gcp":
+		state.Jumpbox.Enabled = config.jumpbox
+		if config.sshPort != 0 {
+			state.SSHPort = config.sshPort
+		}
+		var firewallRules []storage.GCPFirewallRule
+		firewallRules, err = parseGCPFirewallRules(config.gcpFirewallRules)
+		if err != nil {
+			return err
+		}
+		state = useGCPFirewallRules(state, firewallRules, config.clearFirewall)
+		state = updateNetworkCIDRs(state, config.networkCIDR, config.subnetCIDR, u.logger)
+		state = useExistingNetwork(state, config.existingNetworkName, config.existingSubnetworkName)
+		state = useNoPublicIPs(state, config.noPublicIPs)
+		state = useZones(state, nil, parseZones(config.zones))
+	}
+
+	tags, err := parseTags(config.tags, state.IAAS)
 	if err != nil {
 		return err
 	}
+	state = useTags(state, tags)
+
+	if config.directorSSH {
+		state.DirectorSSH = true
+	}
+
+	state, err = updateDirectorDB(state, config.directorDB)
+	if err != nil {
+		return err
+	}
+
+	var plan string
+	if state.TFState == "" {
+		changes = append(changes, "apply the terraform template for the infrastructure")
+	} else {
+		plan, err = u.terraform.Plan(state)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, "apply the terraform plan below to the infrastructure")
+	}
+
+	if !config.noDirector && !state.NoDirector {
+		if state.Jumpbox.Enabled {
+			changes = append(changes, "create or update the jumpbox")
+		}
+		changes = append(changes, "create or update the BOSH director", "update the cloud config")
+
+		if config.uploadStemcell != "" {
+			changes = append(changes, fmt.Sprintf("upload the stemcell %s", config.uploadStemcell))
+		}
+	}
+
+	changes = append(changes, "save the bbl state")
 
+	printDryRun(u.logger, "up", changes, plan)
 	return nil
 }
 
@@ -105,9 +574,54 @@ func (u Up) parseArgs(args []string) (upConfig, error) {
 	upFlags := flags.New("up")
 
 	upFlags.String(&config.name, "name", "")
-	upFlags.String(&config.opsFile, "ops-file", "")
+	upFlags.Slice(&config.opsFiles, "ops-file")
 	upFlags.Bool(&config.noDirector, "", "no-director", false)
 	upFlags.Bool(&config.jumpbox, "", "credhub", false)
+	upFlags.Bool(&config.directorSSH, "", "director-ssh", false)
+	upFlags.Bool(&config.detach, "", "detach", false)
+	upFlags.Slice(&config.gcpFirewallRules, "gcp-firewall-rule")
+	upFlags.Bool(&config.clearFirewall, "", "clear-gcp-firewall-rules", false)
+	upFlags.String(&config.vpcCIDR, "vpc-cidr", "")
+	upFlags.String(&config.networkCIDR, "network-cidr", "")
+	upFlags.String(&config.subnetCIDR, "subnet-cidr", "")
+	upFlags.String(&config.directorCACert, "director-ca-cert", "")
+	upFlags.String(&config.directorCAKey, "director-ca-key", "")
+	upFlags.Bool(&config.directorSpot, "", "director-spot", false)
+	upFlags.String(&config.directorSpotMax, "spot-max-price", "")
+	upFlags.String(&config.spotBidPrice, "spot-bid-price", "")
+	upFlags.Bool(&config.spotFallback, "", "spot-ondemand-fallback", false)
+	upFlags.String(&config.directorDiskType, "director-disk-type", "")
+	upFlags.String(&config.directorVMType, "director-vm-type", "")
+	upFlags.Int(&config.directorDiskSize, "director-disk-size", 0)
+	upFlags.Bool(&config.directorDB, "", "director-external-db", false)
+	upFlags.Slice(&config.targets, "target")
+	upFlags.String(&config.sshKeyType, "ssh-key-type", "")
+	upFlags.Int(&config.sshKeyBits, "ssh-key-bits", 0)
+	upFlags.Bool(&config.skipKeyPair, "", "skip-keypair", false)
+	upFlags.String(&config.keyPairName, "keypair-name", "")
+	upFlags.String(&config.publicKey, "public-key", "")
+	upFlags.String(&config.privateKey, "private-key", "")
+	upFlags.Bool(&config.skipQuotaCheck, "", "skip-quota-check", false)
+	upFlags.String(&config.uploadStemcell, "upload-stemcell", "")
+	upFlags.Int(&config.sshPort, "ssh-port", 0)
+	upFlags.Bool(&config.noPublicIPs, "", "no-public-ips", false)
+	upFlags.String(&config.existingVPCID, "existing-vpc-id", "")
+	upFlags.String(&config.existingSubnetIDs, "existing-subnet-ids", "")
+	upFlags.String(&config.azs, "azs", "")
+	upFlags.String(&config.nat, "nat", "")
+	upFlags.Slice(&config.tags, "tag")
+	upFlags.String(&config.fromPhase, "from-phase", "")
+	upFlags.String(&config.zones, "zones", "")
+	upFlags.String(&config.existingNetworkName, "existing-network-name", "")
+	upFlags.String(&config.existingSubnetworkName, "existing-subnetwork-name", "")
+	upFlags.String(&config.boshDeploymentPath, "bosh-deployment-path", "")
+	upFlags.String(&config.boshDeploymentVersion, "bosh-deployment-version", "")
+	upFlags.String(&config.jumpboxDeploymentPath, "jumpbox-deployment-path", "")
+	upFlags.String(&config.jumpboxDeploymentVersion, "jumpbox-deployment-version", "")
+	upFlags.String(&config.directorStemcellURL, "director-stemcell-url", "")
+	upFlags.String(&config.directorStemcellName, "director-stemcell-name", "")
+	upFlags.String(&config.directorStemcellVersion, "director-stemcell-version", "")
+	upFlags.String(&config.directorStemcellSHA1, "director-stemcell-sha1", "")
 
 	err := upFlags.Parse(args)
 	if err != nil {
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	UpPhaseKeyPair     = "keypair"
+	UpPhaseTerraform   = "terraform"
+	UpPhaseBOSH        = "bosh"
+	UpPhaseCloudConfig = "cloud-config"
+)
+
+var upPhases = []string{UpPhaseKeyPair, UpPhaseTerraform, UpPhaseBOSH, UpPhaseCloudConfig}
+
+func upPhaseIndex(phase string) int {
+	for i, p := range upPhases {
+		if p == phase {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func validateFromPhase(fromPhase string, noDirector bool, state storage.State) error {
+	if fromPhase == "" {
+		return nil
+	}
+
+	if upPhaseIndex(fromPhase) == -1 {
+		return fmt.Errorf("--from-phase must be one of %s", strings.Join(upPhases, ", "))
+	}
+
+	if noDirector && upPhaseIndex(fromPhase) >= upPhaseIndex(UpPhaseBOSH) {
+		return fmt.Errorf("--from-phase %s cannot be used without a director", fromPhase)
+	}
+
+	var completed bool
+	switch fromPhase {
+	case UpPhaseKeyPair:
+		completed = true
+	case UpPhaseTerraform:
+		completed = !state.KeyPair.IsEmpty()
+	case UpPhaseBOSH:
+		completed = state.TFState != ""
+	case UpPhaseCloudConfig:
+		completed = !state.BOSH.IsEmpty()
+	}
+
+	if !completed {
+		return fmt.Errorf("--from-phase %s needs the phases before it to have completed, run bbl up without --from-phase", fromPhase)
+	}
+
+	return nil
+}
+
+// skipUpPhase returns whether phase can be skipped, because it comes before
+// fromPhase or, without fromPhase, because the state has not changed since
+// the phase last completed.
+func skipUpPhase(state storage.State, phase, fromPhase string, logger logger) bool {
+	if fromPhase != "" {
+		if upPhaseIndex(phase) < upPhaseIndex(fromPhase) {
+			logger.Step("skipping %s, resuming from %s", phase, fromPhase)
+			return true
+		}
+
+		return false
+	}
+
+	if upPhaseConverged(state, phase) {
+		logger.Step("skipping %s, it has already converged", phase)
+		return true
+	}
+
+	return false
+}
+
+// upPhaseConverged returns whether phase last completed with the state as it
+// is now.
+func upPhaseConverged(state storage.State, phase string) bool {
+	checksum := upPhaseChecksum(state, phase)
+	for _, checkpoint := range state.UpCheckpoints {
+		if checkpoint.Phase == phase && checkpoint.Checksum == checksum {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkpointUpPhase records that phase completed, and reports it as the
+// progress of a detached up.
+func checkpointUpPhase(state storage.State, phase string) storage.State {
+	if state.UpProgress.PID != 0 && !state.UpProgress.Failed {
+		state.UpProgress.Phase = phase
+	}
+
+	checkpoints := []storage.UpCheckpoint{}
+	for _, checkpoint := range state.UpCheckpoints {
+		if checkpoint.Phase != phase {
+			checkpoints = append(checkpoints, checkpoint)
+		}
+	}
+
+	state.UpCheckpoints = append(checkpoints, storage.UpCheckpoint{
+		Phase:    phase,
+		Checksum: upPhaseChecksum(state, phase),
+	})
+
+	return state
+}
+
+// finishUp drops the checkpoints once every phase has completed, so that the
+// next bbl up converges the whole environment again, and the progress of a
+// detached up.
+func finishUp(state storage.State, stateStore stateStore) error {
+	if len(state.UpCheckpoints) == 0 && state.UpProgress == (storage.UpProgress{}) {
+		return nil
+	}
+
+	state.UpCheckpoints = nil
+	state.UpProgress = storage.UpProgress{}
+	return stateStore.Set(state)
+}
+
+// upPhaseChecksum hashes the part of the state a phase reads and writes,
+// leaving out what changes on every run.
+func upPhaseChecksum(state storage.State, phase string) string {
+	state.Version = 0
+	state.UpProgress = storage.UpProgress{}
+	state.UpCheckpoints = nil
+	state.LatestError = storage.LatestError{}
+	state.LatestTFOutput = ""
+	state.TFLastApplied = ""
+	state.TFOutputs = storage.TFOutputs{}
+
+	var inputs interface{} = state
+	switch phase {
+	case UpPhaseKeyPair:
+		inputs = state.KeyPair
+	case UpPhaseTerraform:
+		state.Jumpbox = storage.Jumpbox{}
+		state.BOSH = storage.BOSH{}
+		state.Stemcell = storage.Stemcell{}
+		inputs = state
+	}
+
+	contents, err := json.Marshal(inputs)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(contents))
+}
@@ -2,13 +2,19 @@ package commands_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/testhelpers"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -19,19 +25,33 @@ var _ = Describe("Up", func() {
 		fakeAWSUp       *fakes.AWSUp
 		fakeAzureUp     *fakes.AzureUp
 		fakeGCPUp       *fakes.GCPUp
+		fakeOpenStackUp *fakes.OpenStackUp
 		fakeEnvGetter   *fakes.EnvGetter
 		fakeBOSHManager *fakes.BOSHManager
+
+		fakeAWSQuotaChecker *fakes.AWSQuotaChecker
+		fakeGCPQuotaChecker *fakes.GCPQuotaChecker
+		fakeTerraform       *fakes.TerraformManager
+		fakeLogger          *fakes.Logger
+		fakeKeyPairChecker  *fakes.KeyPairChecker
 	)
 
 	BeforeEach(func() {
 		fakeAWSUp = &fakes.AWSUp{}
 		fakeAzureUp = &fakes.AzureUp{}
 		fakeGCPUp = &fakes.GCPUp{}
+		fakeOpenStackUp = &fakes.OpenStackUp{}
 		fakeEnvGetter = &fakes.EnvGetter{}
 		fakeBOSHManager = &fakes.BOSHManager{}
 		fakeBOSHManager.VersionCall.Returns.Version = "2.0.24"
+		fakeAWSQuotaChecker = &fakes.AWSQuotaChecker{}
+		fakeGCPQuotaChecker = &fakes.GCPQuotaChecker{}
+		fakeTerraform = &fakes.TerraformManager{}
+		fakeLogger = &fakes.Logger{}
+		fakeKeyPairChecker = &fakes.KeyPairChecker{}
 
-		command = commands.NewUp(fakeAWSUp, fakeGCPUp, fakeAzureUp, fakeEnvGetter, fakeBOSHManager)
+		command = commands.NewUp(fakeAWSUp, fakeGCPUp, fakeAzureUp, fakeOpenStackUp, fakeEnvGetter, fakeBOSHManager, fakeAWSQuotaChecker, fakeGCPQuotaChecker,
+			fakeTerraform, fakeLogger, fakeKeyPairChecker)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -102,103 +122,1709 @@ var _ = Describe("Up", func() {
 				})
 			})
 		})
-	})
 
-	Describe("Execute", func() {
-		Context("when the iaas is aws", func() {
-			It("it works", func() {
-				err := command.Execute([]string{}, storage.State{IAAS: "aws"})
+		Context("when network cidrs are provided", func() {
+			It("does not return an error when the subnet fits within the network", func() {
+				err := command.CheckFastFails([]string{
+					"--network-cidr", "172.16.0.0/16",
+					"--subnet-cidr", "172.16.1.0/24",
+				}, storage.State{IAAS: "gcp"})
 				Expect(err).NotTo(HaveOccurred())
+			})
 
-				Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(1))
+			It("validates the subnet against the network already in the state", func() {
+				err := command.CheckFastFails([]string{
+					"--subnet-cidr", "10.0.0.0/24",
+				}, storage.State{
+					IAAS: "aws",
+					Network: storage.Network{
+						CIDR: "172.16.0.0/16",
+					},
+				})
+				Expect(err).To(MatchError("Subnet CIDR 10.0.0.0/24 must be within the network CIDR 172.16.0.0/16."))
+			})
+
+			It("returns an error when --vpc-cidr is used on gcp", func() {
+				err := command.CheckFastFails([]string{"--vpc-cidr", "172.16.0.0/16"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`--vpc-cidr is only supported when iaas="aws", use --network-cidr instead`))
 			})
+
+			It("returns an error when --network-cidr is used on aws", func() {
+				err := command.CheckFastFails([]string{"--network-cidr", "172.16.0.0/16"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--network-cidr is only supported when iaas="gcp", use --vpc-cidr instead`))
+			})
+
+			DescribeTable("returns an error when the cidrs are invalid", func(vpcCIDR, subnetCIDR, expectedError string) {
+				err := command.CheckFastFails([]string{
+					"--vpc-cidr", vpcCIDR,
+					"--subnet-cidr", subnetCIDR,
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("when the vpc cidr cannot be parsed", "not-a-cidr", "10.0.0.0/24",
+					`"not-a-cidr" is not a valid IPv4 CIDR block.`),
+				Entry("when the vpc cidr has host bits set", "172.16.1.0/16", "172.16.0.0/24",
+					`"172.16.1.0/16" is not a valid IPv4 CIDR block, did you mean "172.16.0.0/16"?`),
+				Entry("when the vpc cidr is too small", "172.16.0.0/25", "172.16.0.0/26",
+					"Network CIDR 172.16.0.0/25 is too small, it must be a /24 or larger."),
+				Entry("when the subnet is outside the vpc", "172.16.0.0/16", "10.0.0.0/24",
+					"Subnet CIDR 10.0.0.0/24 must be within the network CIDR 172.16.0.0/16."),
+				Entry("when the subnet overlaps the availability zone subnets", "172.16.0.0/16", "172.16.16.0/24",
+					"Subnet CIDR 172.16.16.0/24 overlaps the subnets reserved for availability zones and load balancers in 172.16.0.0/16; it must be within 172.16.0.0/23."),
+				Entry("when the subnet overlaps the load balancer subnets", "172.16.0.0/16", "172.16.2.0/24",
+					"Subnet CIDR 172.16.2.0/24 overlaps the subnets reserved for availability zones and load balancers in 172.16.0.0/16; it must be within 172.16.0.0/23."),
+			)
 		})
 
-		Context("when the iaas is azure", func() {
-			It("it works", func() {
-				err := command.Execute([]string{}, storage.State{IAAS: "azure"})
+		Context("when gcp firewall rules are provided", func() {
+			It("does not return an error for valid rules", func() {
+				err := command.CheckFastFails([]string{
+					"--gcp-firewall-rule", "some-app:tcp:8080,9000-9010:10.0.0.0/8",
+					"--gcp-firewall-rule", "ping:icmp::0.0.0.0/0",
+				}, storage.State{IAAS: "gcp"})
 				Expect(err).NotTo(HaveOccurred())
+			})
 
-				Expect(fakeAzureUp.ExecuteCall.CallCount).To(Equal(1))
+			It("returns an error when the iaas is not gcp", func() {
+				err := command.CheckFastFails([]string{
+					"--gcp-firewall-rule", "some-app:tcp:8080:10.0.0.0/8",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--gcp-firewall-rule is only supported when iaas="gcp"`))
+			})
+
+			DescribeTable("returns an error when a rule is invalid", func(rule, expectedError string) {
+				err := command.CheckFastFails([]string{
+					"--gcp-firewall-rule", rule,
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("when the rule has too few parts", "some-app:tcp:8080",
+					`Invalid firewall rule "some-app:tcp:8080": expected format name:proto:ports:source-range.`),
+				Entry("when the name is invalid", "Some_App:tcp:8080:10.0.0.0/8",
+					`Invalid firewall rule "Some_App:tcp:8080:10.0.0.0/8": name must consist of lowercase letters, digits and dashes, starting with a letter.`),
+				Entry("when the protocol is invalid", "some-app:sctp:8080:10.0.0.0/8",
+					`Invalid firewall rule "some-app:sctp:8080:10.0.0.0/8": protocol must be one of "tcp", "udp" or "icmp".`),
+				Entry("when tcp ports are missing", "some-app:tcp::10.0.0.0/8",
+					`Invalid firewall rule "some-app:tcp::10.0.0.0/8": ports are required for protocol "tcp".`),
+				Entry("when icmp ports are provided", "ping:icmp:80:10.0.0.0/8",
+					`Invalid firewall rule "ping:icmp:80:10.0.0.0/8": ports are not supported for protocol "icmp".`),
+				Entry("when a port is invalid", "some-app:tcp:http:10.0.0.0/8",
+					`Invalid firewall rule "some-app:tcp:http:10.0.0.0/8": "http" is not a valid port or port range.`),
+				Entry("when the source range is invalid", "some-app:tcp:8080:10.0.0.0",
+					`Invalid firewall rule "some-app:tcp:8080:10.0.0.0": "10.0.0.0" is not a valid CIDR source range.`),
+			)
+
+			It("returns an error when a rule name is repeated", func() {
+				err := command.CheckFastFails([]string{
+					"--gcp-firewall-rule", "some-app:tcp:8080:10.0.0.0/8",
+					"--gcp-firewall-rule", "some-app:udp:53:10.0.0.0/8",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`Invalid firewall rule "some-app:udp:53:10.0.0.0/8": name "some-app" is used more than once.`))
+			})
+
+			It("returns an error when the rules are also cleared", func() {
+				err := command.CheckFastFails([]string{
+					"--gcp-firewall-rule", "some-app:tcp:8080:10.0.0.0/8",
+					"--clear-gcp-firewall-rules",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--clear-gcp-firewall-rules cannot be used with --gcp-firewall-rule"))
 			})
 		})
 
-		Context("when the iaas is gcp", func() {
-			It("it works", func() {
-				err := command.Execute([]string{}, storage.State{IAAS: "gcp"})
+		Context("when gcp firewall rules are cleared", func() {
+			It("does not return an error on gcp", func() {
+				err := command.CheckFastFails([]string{"--clear-gcp-firewall-rules"}, storage.State{IAAS: "gcp"})
 				Expect(err).NotTo(HaveOccurred())
+			})
 
-				Expect(fakeGCPUp.ExecuteCall.CallCount).To(Equal(1))
+			It("returns an error when the iaas is not gcp", func() {
+				err := command.CheckFastFails([]string{"--clear-gcp-firewall-rules"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--clear-gcp-firewall-rules is only supported when iaas="gcp"`))
 			})
 		})
+		Context("when the director spot flags are provided", func() {
+			It("does not return an error for a preemptible gcp director", func() {
+				err := command.CheckFastFails([]string{"--director-spot"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
 
-		Context("when an ops-file is provided via command line flag", func() {
-			It("populates the aws config with the correct ops-file path", func() {
-				err := command.Execute([]string{
-					"--ops-file", "some-ops-file-path",
-				}, storage.State{IAAS: "aws"})
+			It("does not return an error for an aws spot director with a max price", func() {
+				err := command.CheckFastFails([]string{"--director-spot", "--spot-max-price", "0.05"}, storage.State{IAAS: "aws"})
 				Expect(err).NotTo(HaveOccurred())
+			})
 
-				Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.OpsFilePath).To(Equal("some-ops-file-path"))
+			DescribeTable("returns an error when the flags are invalid", func(args []string, iaas, expectedError string) {
+				err := command.CheckFastFails(args, storage.State{IAAS: iaas})
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("aws without a max price", []string{"--director-spot"}, "aws",
+					`--spot-max-price must be provided with --director-spot when iaas="aws"`),
+				Entry("max price without spot", []string{"--spot-max-price", "0.05"}, "aws",
+					"--spot-max-price requires --director-spot"),
+				Entry("max price on gcp", []string{"--director-spot", "--spot-max-price", "0.05"}, "gcp",
+					`--spot-max-price is only supported when iaas="aws"`),
+				Entry("a non numeric max price", []string{"--director-spot", "--spot-max-price", "cheap"}, "aws",
+					"--spot-max-price must be a positive number"),
+				Entry("a zero max price", []string{"--director-spot", "--spot-max-price", "0"}, "aws",
+					"--spot-max-price must be a positive number"),
+				Entry("no director", []string{"--director-spot", "--no-director"}, "gcp",
+					"--director-spot cannot be used with --no-director"),
+			)
+		})
+
+		Context("when the cloud config spot flags are provided", func() {
+			It("does not return an error for an aws bid price with fallback", func() {
+				err := command.CheckFastFails([]string{"--spot-bid-price", "0.25", "--spot-ondemand-fallback"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
 			})
 
-			It("populates the gcp config with the correct ops-file path", func() {
-				err := command.Execute([]string{
-					"--ops-file", "some-ops-file-path",
+			DescribeTable("returns an error when the flags are invalid", func(args []string, iaas, expectedError string) {
+				err := command.CheckFastFails(args, storage.State{IAAS: iaas})
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("fallback without a bid price", []string{"--spot-ondemand-fallback"}, "aws",
+					"--spot-ondemand-fallback requires --spot-bid-price"),
+				Entry("bid price on gcp", []string{"--spot-bid-price", "0.25"}, "gcp",
+					`--spot-bid-price is only supported when iaas="aws"`),
+				Entry("a non numeric bid price", []string{"--spot-bid-price", "cheap"}, "aws",
+					"--spot-bid-price must be a positive number"),
+				Entry("a negative bid price", []string{"--spot-bid-price", "-1"}, "aws",
+					"--spot-bid-price must be a positive number"),
+			)
+		})
+
+		Context("when --credhub is provided", func() {
+			It("returns an error when iaas is azure", func() {
+				err := command.CheckFastFails([]string{"--credhub"}, storage.State{IAAS: "azure"})
+				Expect(err).To(MatchError(`--credhub is not supported when iaas="azure"`))
+			})
+
+			It("returns an error when iaas is openstack", func() {
+				err := command.CheckFastFails([]string{"--credhub"}, storage.State{IAAS: "openstack"})
+				Expect(err).To(MatchError(`--credhub is not supported when iaas="openstack"`))
+			})
+		})
+
+		Context("when a target is provided", func() {
+			It("returns an error when iaas is azure", func() {
+				err := command.CheckFastFails([]string{"--target", "some-resource.address"}, storage.State{IAAS: "azure"})
+				Expect(err).To(MatchError(`--target is only supported when iaas="aws" or iaas="gcp"`))
+			})
+
+			It("returns an error when the infrastructure has not been created yet", func() {
+				err := command.CheckFastFails([]string{"--target", "some-resource.address"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--target can only be used once the infrastructure exists, run `bbl up` without --target first"))
+			})
+		})
+
+		Context("when a director disk type is provided", func() {
+			It("does not return an error for a gcp disk type", func() {
+				err := command.CheckFastFails([]string{"--director-disk-type", "pd-ssd"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error for an aws disk type", func() {
+				err := command.CheckFastFails([]string{"--director-disk-type", "gp3"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			DescribeTable("returns an error when the disk type is invalid", func(args []string, iaas, expectedError string) {
+				err := command.CheckFastFails(args, storage.State{IAAS: iaas})
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("an aws disk type on gcp", []string{"--director-disk-type", "gp3"}, "gcp",
+					`--director-disk-type "gp3" is not supported when iaas="gcp", valid types are: pd-standard, pd-ssd`),
+				Entry("a gcp disk type on aws", []string{"--director-disk-type", "pd-ssd"}, "aws",
+					`--director-disk-type "pd-ssd" is not supported when iaas="aws", valid types are: gp2, gp3, standard`),
+				Entry("azure", []string{"--director-disk-type", "pd-ssd"}, "azure",
+					`--director-disk-type is only supported when iaas="aws" or iaas="gcp"`),
+				Entry("no director", []string{"--director-disk-type", "pd-ssd", "--no-director"}, "gcp",
+					"--director-disk-type cannot be used with --no-director"),
+			)
+		})
+
+		Context("when a director vm type or disk size is provided", func() {
+			It("does not return an error on aws", func() {
+				err := command.CheckFastFails([]string{"--director-vm-type", "m4.2xlarge", "--director-disk-size", "128"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error on gcp", func() {
+				err := command.CheckFastFails([]string{"--director-vm-type", "n1-standard-8"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			DescribeTable("returns an error when the vm size is invalid", func(args []string, iaas, expectedError string) {
+				err := command.CheckFastFails(args, storage.State{IAAS: iaas})
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("azure", []string{"--director-vm-type", "Standard_D4_v2"}, "azure",
+					`--director-vm-type and --director-disk-size are only supported when iaas="aws" or iaas="gcp"`),
+				Entry("no director", []string{"--director-disk-size", "128", "--no-director"}, "gcp",
+					"--director-vm-type and --director-disk-size cannot be used with --no-director"),
+				Entry("a negative disk size", []string{"--director-disk-size", "-1"}, "aws",
+					"--director-disk-size must be a positive number of GB"),
+			)
+		})
+
+		Context("when a bosh-deployment or jumpbox-deployment is pinned", func() {
+			var checkoutDir string
+
+			BeforeEach(func() {
+				var err error
+				checkoutDir, err = ioutil.TempDir("", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(ioutil.WriteFile(filepath.Join(checkoutDir, "bosh.yml"), []byte("name: bosh"), os.ModePerm)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(checkoutDir)
+			})
+
+			It("does not return an error for a checkout or a version", func() {
+				err := command.CheckFastFails([]string{"--bosh-deployment-path", checkoutDir, "--jumpbox-deployment-version", "5a2b0ac3f1e4d6c7b8a9f0e1d2c3b4a5f6e7d8c9"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			DescribeTable("returns an error when the pin is invalid", func(args []string, expectedError string) {
+				err := command.CheckFastFails(args, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("a path and a version", []string{"--bosh-deployment-path", "/some/path", "--bosh-deployment-version", "v1.2.3"},
+					"--bosh-deployment-path cannot be used with --bosh-deployment-version"),
+				Entry("a path without a manifest", []string{"--jumpbox-deployment-path", "/some/missing/path"},
+					"--jumpbox-deployment-path must be a checkout of jumpbox-deployment with a jumpbox.yml"),
+				Entry("a version that is not a commit sha", []string{"--bosh-deployment-version", "v1.2.3"},
+					"--bosh-deployment-version must be the full sha of a commit of bosh-deployment, since tags and branches can move"),
+				Entry("no director", []string{"--bosh-deployment-version", "v1.2.3", "--no-director"},
+					"--bosh-deployment-path and --bosh-deployment-version cannot be used with --no-director"),
+			)
+		})
+
+		Context("when a director stemcell is requested", func() {
+			var stemcellPath string
+
+			BeforeEach(func() {
+				stemcell, err := ioutil.TempFile("", "stemcell")
+				Expect(err).NotTo(HaveOccurred())
+				stemcell.Close()
+
+				stemcellPath = stemcell.Name()
+			})
+
+			AfterEach(func() {
+				os.Remove(stemcellPath)
+			})
+
+			DescribeTable("does not return an error", func(args []string) {
+				err := command.CheckFastFails(args, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			},
+				Entry("a url and sha1", []string{"--director-stemcell-url", "https://example.com/stemcell.tgz", "--director-stemcell-sha1", "some-sha1"}),
+				Entry("a version and sha1", []string{"--director-stemcell-version", "3421.11", "--director-stemcell-sha1", "some-sha1"}),
+				Entry("the bundled stemcell", []string{"--director-stemcell-url", "bundled"}),
+			)
+
+			It("does not return an error for a local tarball without a sha1", func() {
+				err := command.CheckFastFails([]string{"--director-stemcell-url", stemcellPath}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.CheckFastFails([]string{"--director-stemcell-url", "file://" + stemcellPath}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			DescribeTable("returns an error when the stemcell is invalid", func(args []string, expectedError string) {
+				err := command.CheckFastFails(args, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			},
+				Entry("no director", []string{"--director-stemcell-version", "3421.11", "--director-stemcell-sha1", "some-sha1", "--no-director"},
+					"--director-stemcell-url, --director-stemcell-name and --director-stemcell-version cannot be used with --no-director"),
+				Entry("a url and a version", []string{"--director-stemcell-url", "https://example.com/stemcell.tgz", "--director-stemcell-version", "3421.11"},
+					"--director-stemcell-url cannot be used with --director-stemcell-name or --director-stemcell-version"),
+				Entry("a name without a version", []string{"--director-stemcell-name", "some-stemcell"},
+					"--director-stemcell-name requires --director-stemcell-version"),
+				Entry("only a sha1", []string{"--director-stemcell-sha1", "some-sha1"},
+					"--director-stemcell-sha1 requires --director-stemcell-url or --director-stemcell-version"),
+				Entry("a url without a sha1", []string{"--director-stemcell-url", "https://example.com/stemcell.tgz"},
+					"--director-stemcell-sha1 is required to download the stemcell"),
+				Entry("a version without a sha1", []string{"--director-stemcell-version", "3421.11"},
+					"--director-stemcell-sha1 is required to download the stemcell"),
+				Entry("a missing tarball", []string{"--director-stemcell-url", "/some/missing/stemcell.tgz"},
+					"--director-stemcell-url must be an http url or the path of a stemcell tarball"),
+			)
+		})
+
+		Context("when an external director database is requested", func() {
+			It("does not return an error when creating the director", func() {
+				err := command.CheckFastFails([]string{"--director-external-db"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error when the director already uses it", func() {
+				err := command.CheckFastFails([]string{"--director-external-db"}, storage.State{
+					IAAS:       "gcp",
+					BOSH:       storage.BOSH{DirectorAddress: "some-director-address"},
+					DirectorDB: storage.DirectorDB{Enabled: true},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when credhub is enabled later", func() {
+				err := command.CheckFastFails([]string{"--credhub"}, storage.State{
+					IAAS:       "gcp",
+					DirectorDB: storage.DirectorDB{Enabled: true},
+				})
+				Expect(err).To(MatchError("--director-external-db cannot be used with --credhub, uaa and credhub keep their data in the director's local database"))
+			})
+
+			DescribeTable("returns an error when the external database cannot be used", func(args []string, state storage.State, expectedError string) {
+				err := command.CheckFastFails(args, state)
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("azure", []string{"--director-external-db"}, storage.State{IAAS: "azure"},
+					`--director-external-db is only supported when iaas="aws" or iaas="gcp"`),
+				Entry("no director", []string{"--director-external-db", "--no-director"}, storage.State{IAAS: "aws"},
+					"--director-external-db cannot be used with --no-director"),
+				Entry("credhub", []string{"--director-external-db", "--credhub"}, storage.State{IAAS: "gcp"},
+					"--director-external-db cannot be used with --credhub, uaa and credhub keep their data in the director's local database"),
+				Entry("an existing director", []string{"--director-external-db"}, storage.State{IAAS: "aws", BOSH: storage.BOSH{DirectorAddress: "some-director-address"}},
+					"--director-external-db can only be used when creating the director, the existing director's database would not be migrated"),
+			)
+		})
+
+		Context("when an ssh key type is provided", func() {
+			It("does not return an error for ed25519", func() {
+				err := command.CheckFastFails([]string{"--ssh-key-type", "ed25519"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the key type is invalid", func() {
+				err := command.CheckFastFails([]string{"--ssh-key-type", "dsa"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`ssh key type must be one of "rsa" or "ed25519"`))
+			})
+
+			It("returns an error when bits are provided for ed25519", func() {
+				err := command.CheckFastFails([]string{"--ssh-key-type", "ed25519", "--ssh-key-bits", "4096"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("ssh key bits are only supported for rsa keys"))
+			})
+		})
+
+		Context("when the keypair is skipped", func() {
+			var (
+				publicKeyPath  string
+				privateKeyPath string
+			)
+
+			BeforeEach(func() {
+				var err error
+				publicKeyPath, err = testhelpers.WriteContentsToTempFile(testhelpers.JUMPBOX_SSH_PUBLIC_KEY)
+				Expect(err).NotTo(HaveOccurred())
+
+				privateKeyPath, err = testhelpers.WriteContentsToTempFile(testhelpers.JUMPBOX_SSH_KEY)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error when the public key matches the private key", func() {
+				err := command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--public-key", publicKeyPath,
+					"--private-key", privateKeyPath,
 				}, storage.State{IAAS: "gcp"})
 				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not require the keys again for an environment with an external keypair", func() {
+				err := command.CheckFastFails([]string{"--skip-keypair"}, storage.State{
+					IAAS: "aws",
+					KeyPair: storage.KeyPair{
+						Name:       "some-keypair-name",
+						PublicKey:  "some-public-key",
+						PrivateKey: "some-private-key",
+						External:   true,
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the public key does not match the private key", func() {
+				otherKeyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.PRIVATE_KEY)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--public-key", publicKeyPath,
+					"--private-key", otherKeyPath,
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("public key does not match private key"))
+			})
+
+			It("returns an error when the public key cannot be parsed", func() {
+				err := command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--public-key", privateKeyPath,
+					"--private-key", privateKeyPath,
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(ContainSubstring("public key could not be parsed")))
+			})
 
-				Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.OpsFilePath).To(Equal("some-ops-file-path"))
+			It("returns an error when the private key cannot be read", func() {
+				err := command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--public-key", publicKeyPath,
+					"--private-key", "/some/fake/path",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("error reading private-key contents: open /some/fake/path: no such file or directory"))
+			})
+
+			It("returns an error when no keypair name is provided on aws", func() {
+				err := command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--public-key", publicKeyPath,
+					"--private-key", privateKeyPath,
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--skip-keypair requires --keypair-name when iaas="aws"`))
 			})
+
+			DescribeTable("returns an error when the flags are invalid", func(args []string, state storage.State, expectedError string) {
+				err := command.CheckFastFails(args, state)
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("keys without skip", []string{"--public-key", "some-path", "--private-key", "some-path"}, storage.State{IAAS: "gcp"},
+					"--keypair-name, --public-key and --private-key require --skip-keypair"),
+				Entry("only a public key", []string{"--skip-keypair", "--public-key", "some-path"}, storage.State{IAAS: "gcp"},
+					"--public-key requires --private-key"),
+				Entry("no keys", []string{"--skip-keypair"}, storage.State{IAAS: "gcp"},
+					"--skip-keypair requires --private-key"),
+				Entry("a bbl created keypair", []string{"--skip-keypair"}, storage.State{IAAS: "gcp", KeyPair: storage.KeyPair{PublicKey: "some-public-key"}},
+					"--skip-keypair cannot be used for an environment whose key pair was created by bbl"),
+				Entry("a keypair name on gcp", []string{"--skip-keypair", "--keypair-name", "some-name"}, storage.State{IAAS: "gcp", KeyPair: storage.KeyPair{External: true}},
+					`--keypair-name is only supported when iaas="aws"`),
+			)
 		})
 
-		Context("failure cases", func() {
-			It("returns an error when the desired up command fails", func() {
-				fakeAWSUp.ExecuteCall.Returns.Error = errors.New("failed execution")
-				err := command.Execute([]string{}, storage.State{IAAS: "aws"})
-				Expect(err).To(MatchError("failed execution"))
+		Context("when an existing aws keypair is named", func() {
+			var privateKeyPath string
+
+			BeforeEach(func() {
+				var err error
+				privateKeyPath, err = testhelpers.WriteContentsToTempFile(testhelpers.JUMPBOX_SSH_KEY)
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeKeyPairChecker.HasKeyPairCall.Returns.Present = true
 			})
 
-			It("returns an error when undefined flags are passed", func() {
-				err := command.Execute([]string{"--foo", "bar"}, storage.State{})
-				Expect(err).To(MatchError("flag provided but not defined: -foo"))
+			It("checks that the keypair exists", func() {
+				err := command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--keypair-name", "some-existing-keypair",
+					"--private-key", privateKeyPath,
+				}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeKeyPairChecker.HasKeyPairCall.Recieves.Name).To(Equal("some-existing-keypair"))
+			})
+
+			It("returns an error when the keypair does not exist", func() {
+				fakeKeyPairChecker.HasKeyPairCall.Returns.Present = false
+
+				err := command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--keypair-name", "some-existing-keypair",
+					"--private-key", privateKeyPath,
+				}, storage.State{IAAS: "aws", AWS: storage.AWS{Region: "some-region"}})
+				Expect(err).To(MatchError(`keypair "some-existing-keypair" does not exist in some-region`))
+			})
+
+			It("returns an error when the keypair cannot be checked", func() {
+				fakeKeyPairChecker.HasKeyPairCall.Returns.Error = errors.New("failed to describe keypairs")
+
+				err := command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--keypair-name", "some-existing-keypair",
+					"--private-key", privateKeyPath,
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("failed to describe keypairs"))
+			})
+
+			It("returns an error when the private key cannot be parsed", func() {
+				publicKeyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.JUMPBOX_SSH_PUBLIC_KEY)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.CheckFastFails([]string{
+					"--skip-keypair",
+					"--keypair-name", "some-existing-keypair",
+					"--private-key", publicKeyPath,
+				}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(ContainSubstring("private key could not be parsed")))
+				Expect(fakeKeyPairChecker.HasKeyPairCall.CallCount).To(Equal(0))
 			})
 		})
-	})
 
-	Context("when the --credhub flag is specified", func() {
-		It("executes the GCP up with gcp details from args", func() {
-			err := command.Execute([]string{
-				"--credhub",
-			}, storage.State{IAAS: "gcp"})
-			Expect(err).NotTo(HaveOccurred())
+		Context("when a director ca is provided", func() {
+			var (
+				caCertPath string
+				caKeyPath  string
+			)
 
-			Expect(fakeGCPUp.ExecuteCall.CallCount).To(Equal(1))
-			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig).To(Equal(commands.GCPUpConfig{
-				Jumpbox: true,
-			}))
+			BeforeEach(func() {
+				var err error
+				caCertPath, err = testhelpers.WriteContentsToTempFile(testhelpers.DIRECTOR_CA_CERT)
+				Expect(err).NotTo(HaveOccurred())
+
+				caKeyPath, err = testhelpers.WriteContentsToTempFile(testhelpers.DIRECTOR_CA_KEY)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error when the certificate and key match", func() {
+				err := command.CheckFastFails([]string{
+					"--director-ca-cert", caCertPath,
+					"--director-ca-key", caKeyPath,
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when only one of the certificate and key is provided", func() {
+				err := command.CheckFastFails([]string{"--director-ca-cert", caCertPath}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--director-ca-cert and --director-ca-key must be provided together"))
+			})
+
+			It("returns an error when the certificate cannot be read", func() {
+				err := command.CheckFastFails([]string{
+					"--director-ca-cert", "/some/fake/path",
+					"--director-ca-key", caKeyPath,
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("error reading director-ca-cert contents: open /some/fake/path: no such file or directory"))
+			})
+
+			It("returns an error when the certificate and key do not match", func() {
+				otherKeyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.BBL_KEY)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.CheckFastFails([]string{
+					"--director-ca-cert", caCertPath,
+					"--director-ca-key", otherKeyPath,
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(ContainSubstring("director CA certificate and key do not match")))
+			})
+
+			It("returns an error when the certificate is not a certificate authority", func() {
+				certPath, err := testhelpers.WriteContentsToTempFile(testhelpers.BBL_CERT)
+				Expect(err).NotTo(HaveOccurred())
+
+				keyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.BBL_KEY)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.CheckFastFails([]string{
+					"--director-ca-cert", certPath,
+					"--director-ca-key", keyPath,
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("director CA certificate is not a certificate authority"))
+			})
+
+			It("returns an error when used with --no-director", func() {
+				err := command.CheckFastFails([]string{
+					"--director-ca-cert", caCertPath,
+					"--director-ca-key", caKeyPath,
+					"--no-director",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--director-ca-cert and --director-ca-key cannot be used with --no-director"))
+			})
 		})
-	})
 
-	Context("when the user provides the name flag", func() {
-		It("passes the name flag in the up config", func() {
-			err := command.Execute([]string{
-				"--name", "a-better-name",
-			}, storage.State{IAAS: "aws"})
-			Expect(err).NotTo(HaveOccurred())
+		Context("when a stemcell to upload is provided", func() {
+			It("does not return an error for a url", func() {
+				err := command.CheckFastFails([]string{"--upload-stemcell", "https://example.com/some-stemcell.tgz"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
 
-			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.Name).To(Equal("a-better-name"))
+			It("returns an error when the local stemcell cannot be read", func() {
+				err := command.CheckFastFails([]string{"--upload-stemcell", "/some/missing/stemcell.tgz"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(ContainSubstring("error reading stemcell: open /some/missing/stemcell.tgz")))
+			})
+
+			It("returns an error when used with --no-director", func() {
+				err := command.CheckFastFails([]string{"--upload-stemcell", "https://example.com/some-stemcell.tgz", "--no-director"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--upload-stemcell cannot be used with --no-director"))
+			})
+
+			It("returns an error when the iaas is not aws or gcp", func() {
+				err := command.CheckFastFails([]string{"--upload-stemcell", "https://example.com/some-stemcell.tgz"}, storage.State{IAAS: "azure"})
+				Expect(err).To(MatchError(`--upload-stemcell is only supported when iaas="aws" or iaas="gcp"`))
+			})
 		})
-	})
 
-	Context("when the user provides the no-director flag", func() {
-		It("passes no-director as true in the AWS up config", func() {
-			err := command.Execute([]string{
-				"--no-director",
-			}, storage.State{IAAS: "aws"})
-			Expect(err).NotTo(HaveOccurred())
+		Context("when a phase to resume from is provided", func() {
+			It("does not return an error when the phases before it have completed", func() {
+				err := command.CheckFastFails([]string{"--from-phase", "bosh"}, storage.State{IAAS: "aws", TFState: "some-tf-state"})
+				Expect(err).NotTo(HaveOccurred())
+			})
 
-			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.NoDirector).To(Equal(true))
+			It("returns an error for an unknown phase", func() {
+				err := command.CheckFastFails([]string{"--from-phase", "director"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("--from-phase must be one of keypair, terraform, bosh, cloud-config"))
+			})
+
+			It("returns an error when the phases before it have not completed", func() {
+				err := command.CheckFastFails([]string{"--from-phase", "cloud-config"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--from-phase cloud-config needs the phases before it to have completed, run bbl up without --from-phase"))
+			})
+
+			It("returns an error when it resumes from a director phase without a director", func() {
+				err := command.CheckFastFails([]string{"--from-phase", "bosh", "--no-director"}, storage.State{IAAS: "aws", TFState: "some-tf-state"})
+				Expect(err).To(MatchError("--from-phase bosh cannot be used without a director"))
+			})
+		})
+
+		Context("when an ssh port is provided", func() {
+			It("does not return an error for a gcp environment with a jumpbox", func() {
+				err := command.CheckFastFails([]string{"--ssh-port", "2222", "--credhub"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the port is out of range", func() {
+				err := command.CheckFastFails([]string{"--ssh-port", "70000", "--credhub"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--ssh-port must be between 1 and 65535, got 70000"))
+			})
+
+			It("returns an error when the iaas is not gcp", func() {
+				err := command.CheckFastFails([]string{"--ssh-port", "2222"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--ssh-port is only supported when iaas="gcp"`))
+			})
+
+			It("returns an error without a jumpbox", func() {
+				err := command.CheckFastFails([]string{"--ssh-port", "2222"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--ssh-port requires --credhub, which deploys the director behind a jumpbox"))
+			})
+
+			It("returns an error when the port is the jumpbox agent port", func() {
+				err := command.CheckFastFails([]string{"--ssh-port", "6868", "--credhub"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--ssh-port cannot be 6868, which the jumpbox agent listens on"))
+			})
+		})
+
+		Context("when tags are provided", func() {
+			It("does not return an error for aws tags", func() {
+				err := command.CheckFastFails([]string{"--tag", "cost-center=1234", "--tag", "Owner=Some Team"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the iaas is not aws or gcp", func() {
+				err := command.CheckFastFails([]string{"--tag", "cost-center=1234"}, storage.State{IAAS: "azure"})
+				Expect(err).To(MatchError(`--tag is only supported when iaas="aws" or iaas="gcp"`))
+			})
+
+			It("returns an error for a tag without a key", func() {
+				err := command.CheckFastFails([]string{"--tag", "=1234"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`Invalid tag "=1234": expected format key=value.`))
+			})
+
+			It("returns an error for a tag bbl sets itself", func() {
+				err := command.CheckFastFails([]string{"--tag", "Name=some-name"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`Invalid tag "Name=some-name": key "Name" is reserved.`))
+			})
+
+			It("returns an error for a key used more than once", func() {
+				err := command.CheckFastFails([]string{"--tag", "owner=a", "--tag", "owner=b"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`Invalid tag "owner=b": key "owner" is used more than once.`))
+			})
+
+			It("returns an error for a label gcp does not accept", func() {
+				err := command.CheckFastFails([]string{"--tag", "Owner=Some Team"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`Invalid tag "Owner=Some Team": gcp labels must consist of lowercase letters, digits, dashes and underscores, with keys starting with a letter.`))
+			})
+		})
+
+		Context("when a nat is provided", func() {
+			It("does not return an error for a nat gateway on aws", func() {
+				err := command.CheckFastFails([]string{"--nat", "gateway"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the iaas is not aws", func() {
+				err := command.CheckFastFails([]string{"--nat", "gateway"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`--nat is only supported when iaas="aws"`))
+			})
+
+			It("returns an error for an unknown nat", func() {
+				err := command.CheckFastFails([]string{"--nat", "vpn"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--nat must be "gateway" or "instance", got "vpn"`))
+			})
+
+			It("returns an error in an existing vpc", func() {
+				err := command.CheckFastFails([]string{"--nat", "gateway"}, storage.State{
+					IAAS: "aws",
+					AWS:  storage.AWS{ExistingVPCID: "vpc-123"},
+				})
+				Expect(err).To(MatchError("--nat cannot be used with an existing VPC, which routes its subnets to the internet itself"))
+			})
+		})
+
+		Context("when no public ips are requested", func() {
+			It("does not return an error for a new gcp environment", func() {
+				err := command.CheckFastFails([]string{"--no-public-ips", "--credhub"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error for an environment that already has no public ips", func() {
+				err := command.CheckFastFails([]string{"--no-public-ips"}, storage.State{
+					IAAS:    "gcp",
+					TFState: "some-tf-state",
+					GCP:     storage.GCP{NoPublicIPs: true},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the iaas is not gcp", func() {
+				err := command.CheckFastFails([]string{"--no-public-ips"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--no-public-ips is only supported when iaas="gcp"`))
+			})
+
+			It("does not return an error with an external director database, which only has a private ip", func() {
+				err := command.CheckFastFails([]string{"--no-public-ips", "--director-external-db"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error for an existing environment with public ips", func() {
+				err := command.CheckFastFails([]string{"--no-public-ips"}, storage.State{
+					IAAS:    "gcp",
+					TFState: "some-tf-state",
+				})
+				Expect(err).To(MatchError("--no-public-ips can only be used when creating the environment, the existing jumpbox and director keep their external addresses"))
+			})
+		})
+
+		Context("when an existing vpc is provided", func() {
+			It("does not return an error for a new aws environment", func() {
+				err := command.CheckFastFails([]string{"--existing-vpc-id", "vpc-123", "--existing-subnet-ids", "subnet-1,subnet-2"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error when the vpc matches the existing environment", func() {
+				err := command.CheckFastFails([]string{"--existing-vpc-id", "vpc-123", "--existing-subnet-ids", "subnet-1, subnet-2"}, storage.State{
+					IAAS:    "aws",
+					TFState: "some-tf-state",
+					AWS: storage.AWS{
+						ExistingVPCID:     "vpc-123",
+						ExistingSubnetIDs: []string{"subnet-1", "subnet-2"},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the iaas is not aws", func() {
+				err := command.CheckFastFails([]string{"--existing-vpc-id", "vpc-123", "--existing-subnet-ids", "subnet-1"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError(`--existing-vpc-id and --existing-subnet-ids are only supported when iaas="aws"`))
+			})
+
+			It("returns an error when the subnets are missing", func() {
+				err := command.CheckFastFails([]string{"--existing-vpc-id", "vpc-123"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("--existing-vpc-id and --existing-subnet-ids must be provided together"))
+			})
+
+			It("returns an error when network cidrs are provided", func() {
+				err := command.CheckFastFails([]string{"--existing-vpc-id", "vpc-123", "--existing-subnet-ids", "subnet-1", "--vpc-cidr", "172.16.0.0/16"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("--vpc-cidr and --subnet-cidr cannot be used with an existing VPC"))
+			})
+
+			It("returns an error when network cidrs are provided for an environment in an existing vpc", func() {
+				err := command.CheckFastFails([]string{"--subnet-cidr", "10.0.0.0/24"}, storage.State{
+					IAAS: "aws",
+					AWS:  storage.AWS{ExistingVPCID: "vpc-123"},
+				})
+				Expect(err).To(MatchError("--vpc-cidr and --subnet-cidr cannot be used with an existing VPC"))
+			})
+
+			It("returns an error when the vpc of an existing environment changes", func() {
+				err := command.CheckFastFails([]string{"--existing-vpc-id", "vpc-456", "--existing-subnet-ids", "subnet-1"}, storage.State{
+					IAAS:    "aws",
+					TFState: "some-tf-state",
+					AWS: storage.AWS{
+						ExistingVPCID:     "vpc-123",
+						ExistingSubnetIDs: []string{"subnet-1"},
+					},
+				})
+				Expect(err).To(MatchError("--existing-vpc-id and --existing-subnet-ids cannot be changed for an existing environment"))
+			})
+
+			It("returns an error when the environment has load balancers", func() {
+				err := command.CheckFastFails([]string{"--existing-vpc-id", "vpc-123", "--existing-subnet-ids", "subnet-1"}, storage.State{
+					IAAS: "aws",
+					LB:   storage.LB{Type: "cf"},
+				})
+				Expect(err).To(MatchError("--existing-vpc-id cannot be used with load balancers"))
+			})
+		})
+
+		Context("when zones are selected", func() {
+			It("does not return an error for aws azs or gcp zones", func() {
+				err := command.CheckFastFails([]string{"--azs", "us-east-1a,us-east-1c"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.CheckFastFails([]string{"--zones", "us-west1-a"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error when the same azs are passed to a created environment", func() {
+				err := command.CheckFastFails([]string{"--azs", "us-east-1c,us-east-1a"}, storage.State{
+					IAAS:    "aws",
+					TFState: "some-tf-state",
+					AWS:     storage.AWS{AZs: []string{"us-east-1a", "us-east-1c"}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			DescribeTable("returns an error when the flags are invalid", func(args []string, state storage.State, expectedError string) {
+				err := command.CheckFastFails(args, state)
+				Expect(err).To(MatchError(expectedError))
+			},
+				Entry("azs on gcp", []string{"--azs", "us-west1-a"}, storage.State{IAAS: "gcp"},
+					`--azs is only supported when iaas="aws", use --zones when iaas="gcp"`),
+				Entry("zones on aws", []string{"--zones", "us-east-1a"}, storage.State{IAAS: "aws"},
+					`--zones is only supported when iaas="gcp", use --azs when iaas="aws"`),
+				Entry("azs with an existing vpc", []string{"--azs", "us-east-1a", "--existing-vpc-id", "vpc-123", "--existing-subnet-ids", "subnet-1"}, storage.State{IAAS: "aws"},
+					"--azs cannot be used with an existing VPC, the subnets decide the availability zones"),
+				Entry("azs for an environment in an existing vpc", []string{"--azs", "us-east-1a"}, storage.State{IAAS: "aws", AWS: storage.AWS{ExistingVPCID: "vpc-123"}},
+					"--azs cannot be used with an existing VPC, the subnets decide the availability zones"),
+				Entry("changed azs for a created environment", []string{"--azs", "us-east-1a"}, storage.State{IAAS: "aws", TFState: "some-tf-state", AWS: storage.AWS{AZs: []string{"us-east-1a", "us-east-1c"}}},
+					"--azs cannot be changed once the environment is created, its subnets keep their availability zones"),
+				Entry("azs for a created environment in every az", []string{"--azs", "us-east-1a"}, storage.State{IAAS: "aws", TFState: "some-tf-state"},
+					"--azs cannot be changed once the environment is created, its subnets keep their availability zones"),
+			)
+		})
+
+		Context("when an existing gcp network is provided", func() {
+			It("does not return an error for a new gcp environment", func() {
+				err := command.CheckFastFails([]string{"--existing-network-name", "some-network", "--existing-subnetwork-name", "some-subnetwork"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the iaas is not gcp", func() {
+				err := command.CheckFastFails([]string{"--existing-network-name", "some-network", "--existing-subnetwork-name", "some-subnetwork"}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError(`--existing-network-name and --existing-subnetwork-name are only supported when iaas="gcp"`))
+			})
+
+			It("returns an error when the subnetwork is missing", func() {
+				err := command.CheckFastFails([]string{"--existing-network-name", "some-network"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--existing-network-name and --existing-subnetwork-name must be provided together"))
+			})
+
+			It("returns an error when network cidrs are provided", func() {
+				err := command.CheckFastFails([]string{"--existing-network-name", "some-network", "--existing-subnetwork-name", "some-subnetwork", "--network-cidr", "172.16.0.0/16"}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("--network-cidr and --subnet-cidr cannot be used with an existing network"))
+			})
+
+			It("returns an error when the network of an existing environment changes", func() {
+				err := command.CheckFastFails([]string{"--existing-network-name", "other-network", "--existing-subnetwork-name", "some-subnetwork"}, storage.State{
+					IAAS:    "gcp",
+					TFState: "some-tf-state",
+					GCP: storage.GCP{
+						ExistingNetworkName:    "some-network",
+						ExistingSubnetworkName: "some-subnetwork",
+					},
+				})
+				Expect(err).To(MatchError("--existing-network-name and --existing-subnetwork-name cannot be changed for an existing environment"))
+			})
+		})
+
+		Context("when checking quotas", func() {
+			It("checks the aws quotas for a new environment", func() {
+				err := command.CheckFastFails([]string{"--no-director", "--nat", "gateway"}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeAWSQuotaChecker.CheckCall.CallCount).To(Equal(1))
+				Expect(fakeAWSQuotaChecker.CheckCall.Receives.Environment).To(Equal(ec2.QuotaEnvironment{
+					NoDirector: true,
+					NATGateway: true,
+				}))
+				Expect(fakeGCPQuotaChecker.CheckCall.CallCount).To(Equal(0))
+			})
+
+			It("checks the gcp quotas for a new environment", func() {
+				err := command.CheckFastFails([]string{"--credhub", "--director-vm-type", "n1-standard-4"}, storage.State{
+					IAAS: "gcp",
+					GCP:  storage.GCP{Region: "some-region"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeGCPQuotaChecker.CheckCall.CallCount).To(Equal(1))
+				Expect(fakeGCPQuotaChecker.CheckCall.Receives.Region).To(Equal("some-region"))
+				Expect(fakeGCPQuotaChecker.CheckCall.Receives.NoDirector).To(BeFalse())
+				Expect(fakeGCPQuotaChecker.CheckCall.Receives.Jumpbox).To(BeTrue())
+				Expect(fakeGCPQuotaChecker.CheckCall.Receives.DirectorVMType).To(Equal("n1-standard-4"))
+				Expect(fakeAWSQuotaChecker.CheckCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when a quota is exceeded", func() {
+				fakeGCPQuotaChecker.CheckCall.Returns.Error = errors.New("quota CPUS: need 1, have 0")
+
+				err := command.CheckFastFails([]string{}, storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("quota CPUS: need 1, have 0"))
+			})
+
+			It("does not check the quotas of an existing environment", func() {
+				err := command.CheckFastFails([]string{}, storage.State{IAAS: "aws", TFState: "some-tf-state"})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.CheckFastFails([]string{}, storage.State{IAAS: "aws", Stack: storage.Stack{Name: "some-stack"}})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeAWSQuotaChecker.CheckCall.CallCount).To(Equal(0))
+			})
+
+			It("does not check the quotas when --skip-quota-check is provided", func() {
+				err := command.CheckFastFails([]string{"--skip-quota-check"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeGCPQuotaChecker.CheckCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		Context("when the iaas is aws", func() {
+			It("it works", func() {
+				err := command.Execute([]string{}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(1))
+			})
+		})
+
+		Context("when the iaas is azure", func() {
+			It("it works", func() {
+				err := command.Execute([]string{}, storage.State{IAAS: "azure"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeAzureUp.ExecuteCall.CallCount).To(Equal(1))
+			})
+
+			It("passes the up flags to azure up", func() {
+				err := command.Execute([]string{
+					"--name", "some-name",
+					"--ops-file", "some-ops-file",
+					"--no-director",
+					"--ssh-key-type", "rsa",
+					"--ssh-key-bits", "4096",
+				}, storage.State{IAAS: "azure"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeAzureUp.ExecuteCall.Receives.AzureUpConfig).To(Equal(commands.AzureUpConfig{
+					OpsFilePaths: []string{"some-ops-file"},
+					Name:         "some-name",
+					NoDirector:   true,
+					SSHKeyType:   "rsa",
+					SSHKeyBits:   4096,
+				}))
+			})
+		})
+
+		Context("when the iaas is openstack", func() {
+			It("passes the up flags to openstack up", func() {
+				err := command.Execute([]string{
+					"--name", "some-name",
+					"--ops-file", "some-ops-file",
+					"--no-director",
+					"--ssh-key-type", "rsa",
+					"--ssh-key-bits", "4096",
+				}, storage.State{IAAS: "openstack"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeOpenStackUp.ExecuteCall.CallCount).To(Equal(1))
+				Expect(fakeOpenStackUp.ExecuteCall.Receives.OpenStackUpConfig).To(Equal(commands.OpenStackUpConfig{
+					OpsFilePaths: []string{"some-ops-file"},
+					Name:         "some-name",
+					NoDirector:   true,
+					SSHKeyType:   "rsa",
+					SSHKeyBits:   4096,
+				}))
+			})
+		})
+
+		Context("when the iaas is gcp", func() {
+			It("it works", func() {
+				err := command.Execute([]string{}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeGCPUp.ExecuteCall.CallCount).To(Equal(1))
+			})
+		})
+
+		Context("when an ops-file is provided via command line flag", func() {
+			It("populates the aws config with the correct ops-file path", func() {
+				err := command.Execute([]string{
+					"--ops-file", "some-ops-file-path",
+				}, storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.OpsFilePaths).To(Equal([]string{"some-ops-file-path"}))
+			})
+
+			It("populates the gcp config with every ops-file path in order", func() {
+				err := command.Execute([]string{
+					"--ops-file", "some-ops-file-path",
+					"--ops-file", "some-other-ops-file-path",
+				}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.OpsFilePaths).To(Equal([]string{"some-ops-file-path", "some-other-ops-file-path"}))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the desired up command fails", func() {
+				fakeAWSUp.ExecuteCall.Returns.Error = errors.New("failed execution")
+				err := command.Execute([]string{}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("failed execution"))
+			})
+
+			It("returns an error when undefined flags are passed", func() {
+				err := command.Execute([]string{"--foo", "bar"}, storage.State{})
+				Expect(err).To(MatchError("flag provided but not defined: -foo"))
+			})
+		})
+	})
+
+	Context("when the --credhub flag is specified", func() {
+		It("executes the GCP up with gcp details from args", func() {
+			err := command.Execute([]string{
+				"--credhub",
+			}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.CallCount).To(Equal(1))
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig).To(Equal(commands.GCPUpConfig{
+				Jumpbox: true,
+			}))
+		})
+	})
+
+	Context("when the user provides the name flag", func() {
+		It("passes the name flag in the up config", func() {
+			err := command.Execute([]string{
+				"--name", "a-better-name",
+			}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.Name).To(Equal("a-better-name"))
+		})
+	})
+
+	Context("when the user provides the no-director flag", func() {
+		It("passes no-director as true in the AWS up config", func() {
+			err := command.Execute([]string{
+				"--no-director",
+			}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.NoDirector).To(Equal(true))
+		})
+	})
+	Context("when the user provides the detach flag", func() {
+		It("passes detach as true in the AWS up config", func() {
+			err := command.Execute([]string{
+				"--detach",
+			}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.Detach).To(Equal(true))
+		})
+
+		It("passes detach as true in the GCP up config", func() {
+			err := command.Execute([]string{
+				"--detach",
+			}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.Detach).To(Equal(true))
+		})
+	})
+
+	Context("when the user provides gcp firewall rules", func() {
+		It("passes the parsed rules in the GCP up config", func() {
+			err := command.Execute([]string{
+				"--gcp-firewall-rule", "some-app:tcp:8080,9000-9010:10.0.0.0/8",
+				"--gcp-firewall-rule", "ping:icmp::0.0.0.0/0",
+			}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.FirewallRules).To(Equal([]storage.GCPFirewallRule{
+				{
+					Name:        "some-app",
+					Protocol:    "tcp",
+					Ports:       []string{"8080", "9000-9010"},
+					SourceRange: "10.0.0.0/8",
+				},
+				{
+					Name:        "ping",
+					Protocol:    "icmp",
+					SourceRange: "0.0.0.0/0",
+				},
+			}))
+		})
+	})
+
+	Context("when the user clears the gcp firewall rules", func() {
+		It("passes clear in the GCP up config", func() {
+			err := command.Execute([]string{"--clear-gcp-firewall-rules"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.ClearFirewallRules).To(BeTrue())
+		})
+	})
+
+	Context("when the user provides the director spot flags", func() {
+		It("passes spot and the max price in the AWS up config", func() {
+			err := command.Execute([]string{"--director-spot", "--spot-max-price", "0.05"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.DirectorSpot).To(BeTrue())
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.DirectorSpotMaxPrice).To(Equal("0.05"))
+		})
+
+		It("passes spot in the GCP up config", func() {
+			err := command.Execute([]string{"--director-spot"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DirectorSpot).To(BeTrue())
+		})
+	})
+
+	Context("when the user provides the cloud config spot flags", func() {
+		It("passes the bid price and fallback in the AWS up config", func() {
+			err := command.Execute([]string{"--spot-bid-price", "0.25", "--spot-ondemand-fallback"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.SpotBidPrice).To(Equal("0.25"))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.SpotOnDemandFallback).To(BeTrue())
+		})
+	})
+
+	Context("when the user provides targets", func() {
+		It("passes the targets in the AWS up config", func() {
+			err := command.Execute([]string{"--target", "aws_subnet.bosh_subnet", "--target", "aws_instance.nat"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.Targets).To(Equal([]string{"aws_subnet.bosh_subnet", "aws_instance.nat"}))
+		})
+
+		It("passes the targets in the GCP up config", func() {
+			err := command.Execute([]string{"--target", "google_compute_firewall.bosh-open"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.Targets).To(Equal([]string{"google_compute_firewall.bosh-open"}))
+		})
+	})
+
+	Context("when the user provides a stemcell to upload", func() {
+		It("passes the stemcell in the AWS up config", func() {
+			err := command.Execute([]string{"--upload-stemcell", "https://example.com/some-stemcell.tgz"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.UploadStemcell).To(Equal("https://example.com/some-stemcell.tgz"))
+		})
+
+		It("passes the stemcell in the GCP up config", func() {
+			err := command.Execute([]string{"--upload-stemcell", "some-stemcell.tgz"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.UploadStemcell).To(Equal("some-stemcell.tgz"))
+		})
+	})
+
+	Context("when the user provides a phase to resume from", func() {
+		It("passes the phase in the AWS up config", func() {
+			err := command.Execute([]string{"--from-phase", "terraform"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.FromPhase).To(Equal("terraform"))
+		})
+
+		It("passes the phase in the GCP up config", func() {
+			err := command.Execute([]string{"--from-phase", "bosh"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.FromPhase).To(Equal("bosh"))
+		})
+	})
+
+	Context("when the user provides an ssh port", func() {
+		It("passes the ssh port in the GCP up config", func() {
+			err := command.Execute([]string{"--ssh-port", "2222", "--credhub"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.SSHPort).To(Equal(2222))
+		})
+	})
+
+	Context("when the user provides tags", func() {
+		It("passes the tags in the AWS up config", func() {
+			err := command.Execute([]string{"--tag", "cost-center=1234"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.Tags).To(Equal([]storage.Tag{{Key: "cost-center", Value: "1234"}}))
+		})
+
+		It("passes the tags in the GCP up config", func() {
+			err := command.Execute([]string{"--tag", "cost-center=1234", "--tag", "owner=some-team"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.Tags).To(Equal([]storage.Tag{
+				{Key: "cost-center", Value: "1234"},
+				{Key: "owner", Value: "some-team"},
+			}))
+		})
+	})
+
+	Context("when the user asks for ssh to the director", func() {
+		It("records it in the state", func() {
+			err := command.Execute([]string{"--director-ssh"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.DirectorSSH).To(BeTrue())
+		})
+
+		It("keeps it on later ups", func() {
+			err := command.Execute([]string{}, storage.State{IAAS: "gcp", DirectorSSH: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.DirectorSSH).To(BeTrue())
+		})
+	})
+
+	Context("when the user provides a nat", func() {
+		It("passes the nat in the AWS up config", func() {
+			err := command.Execute([]string{"--nat", "gateway"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.NAT).To(Equal("gateway"))
+		})
+	})
+
+	Context("when the user requests no public ips", func() {
+		It("passes it in the GCP up config", func() {
+			err := command.Execute([]string{"--no-public-ips"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.NoPublicIPs).To(BeTrue())
+		})
+	})
+
+	Context("when the user provides a director disk type", func() {
+		It("passes the disk type in the AWS up config", func() {
+			err := command.Execute([]string{"--director-disk-type", "gp3"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.DirectorDiskType).To(Equal("gp3"))
+		})
+
+		It("passes the disk type in the GCP up config", func() {
+			err := command.Execute([]string{"--director-disk-type", "pd-ssd"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DirectorDiskType).To(Equal("pd-ssd"))
+		})
+	})
+
+	Context("when the user provides a director vm type and disk size", func() {
+		It("passes them in the AWS up config", func() {
+			err := command.Execute([]string{"--director-vm-type", "m4.2xlarge", "--director-disk-size", "128"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.DirectorVMType).To(Equal("m4.2xlarge"))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.DirectorDiskSize).To(Equal(128))
+		})
+
+		It("passes them in the GCP up config", func() {
+			err := command.Execute([]string{"--director-vm-type", "n1-standard-8", "--director-disk-size", "128"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DirectorVMType).To(Equal("n1-standard-8"))
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DirectorDiskSize).To(Equal(128))
+		})
+	})
+
+	Context("when the user pins bosh-deployment and jumpbox-deployment", func() {
+		It("keeps the pins in the state", func() {
+			err := command.Execute([]string{"--bosh-deployment-path", "/some/bosh-deployment", "--jumpbox-deployment-version", "5a2b0ac3f1e4d6c7b8a9f0e1d2c3b4a5f6e7d8c9"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.DeploymentPath).To(Equal("/some/bosh-deployment"))
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.Jumpbox.DeploymentVersion).To(Equal("5a2b0ac3f1e4d6c7b8a9f0e1d2c3b4a5f6e7d8c9"))
+		})
+
+		It("replaces the pins of an earlier up", func() {
+			state := storage.State{
+				IAAS:    "aws",
+				BOSH:    storage.BOSH{DeploymentVersion: "v1.0.0"},
+				Jumpbox: storage.Jumpbox{DeploymentPath: "/some/jumpbox-deployment"},
+			}
+
+			err := command.Execute([]string{"--bosh-deployment-path", "some-relative-path"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			workingDir, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.State.BOSH.DeploymentPath).To(Equal(filepath.Join(workingDir, "some-relative-path")))
+			Expect(fakeAWSUp.ExecuteCall.Receives.State.BOSH.DeploymentVersion).To(BeEmpty())
+			Expect(fakeAWSUp.ExecuteCall.Receives.State.Jumpbox.DeploymentPath).To(Equal("/some/jumpbox-deployment"))
+		})
+
+		It("unpins a deployment for the bundled version", func() {
+			state := storage.State{
+				IAAS: "gcp",
+				BOSH: storage.BOSH{DeploymentVersion: "v1.0.0"},
+			}
+
+			err := command.Execute([]string{"--bosh-deployment-version", "bundled"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.DeploymentVersion).To(BeEmpty())
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.DeploymentPath).To(BeEmpty())
+		})
+	})
+
+	Context("when the user requests a director stemcell", func() {
+		It("keeps the url and sha1 in the state", func() {
+			err := command.Execute([]string{"--director-stemcell-url", "https://example.com/stemcell.tgz", "--director-stemcell-sha1", "some-sha1"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.StemcellURL).To(Equal("https://example.com/stemcell.tgz"))
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.StemcellSHA1).To(Equal("some-sha1"))
+		})
+
+		It("keeps a local tarball as an absolute file url", func() {
+			err := command.Execute([]string{"--director-stemcell-url", "some-stemcell.tgz"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			workingDir, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.State.BOSH.StemcellURL).To(Equal("file://" + filepath.Join(workingDir, "some-stemcell.tgz")))
+			Expect(fakeAWSUp.ExecuteCall.Receives.State.BOSH.StemcellSHA1).To(BeEmpty())
+		})
+
+		DescribeTable("builds the bosh.io url of a version", func(iaas string, args []string, expectedURL string) {
+			err := command.Execute(args, storage.State{IAAS: iaas})
+			Expect(err).NotTo(HaveOccurred())
+
+			var state storage.State
+			if iaas == "aws" {
+				state = fakeAWSUp.ExecuteCall.Receives.State
+			} else {
+				state = fakeGCPUp.ExecuteCall.Receives.State
+			}
+			Expect(state.BOSH.StemcellURL).To(Equal(expectedURL))
+			Expect(state.BOSH.StemcellSHA1).To(Equal("some-sha1"))
+		},
+			Entry("the default aws stemcell", "aws", []string{"--director-stemcell-version", "3421.11", "--director-stemcell-sha1", "some-sha1"},
+				"https://bosh.io/d/stemcells/bosh-aws-xen-hvm-ubuntu-trusty-go_agent?v=3421.11"),
+			Entry("the default gcp stemcell", "gcp", []string{"--director-stemcell-version", "3421.11", "--director-stemcell-sha1", "some-sha1"},
+				"https://bosh.io/d/stemcells/bosh-google-kvm-ubuntu-trusty-go_agent?v=3421.11"),
+			Entry("a named stemcell", "gcp", []string{"--director-stemcell-name", "some-stemcell", "--director-stemcell-version", "1.2", "--director-stemcell-sha1", "some-sha1"},
+				"https://bosh.io/d/stemcells/some-stemcell?v=1.2"),
+		)
+
+		It("keeps the stemcell of an earlier up", func() {
+			state := storage.State{
+				IAAS: "gcp",
+				BOSH: storage.BOSH{StemcellURL: "file:///some/stemcell.tgz"},
+			}
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.StemcellURL).To(Equal("file:///some/stemcell.tgz"))
+		})
+
+		It("goes back to the bundled stemcell", func() {
+			state := storage.State{
+				IAAS: "gcp",
+				BOSH: storage.BOSH{StemcellURL: "https://example.com/stemcell.tgz", StemcellSHA1: "some-sha1"},
+			}
+
+			err := command.Execute([]string{"--director-stemcell-url", "bundled"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.StemcellURL).To(BeEmpty())
+			Expect(fakeGCPUp.ExecuteCall.Receives.State.BOSH.StemcellSHA1).To(BeEmpty())
+		})
+	})
+
+	Context("when the user requests an external director database", func() {
+		It("passes it in the AWS up config", func() {
+			err := command.Execute([]string{"--director-external-db"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.DirectorExternalDB).To(BeTrue())
+		})
+
+		It("passes it in the GCP up config", func() {
+			err := command.Execute([]string{"--director-external-db"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DirectorExternalDB).To(BeTrue())
+		})
+	})
+
+	Context("when the user provides the ssh key flags", func() {
+		It("passes the key type and bits in the AWS up config", func() {
+			err := command.Execute([]string{"--ssh-key-type", "rsa", "--ssh-key-bits", "4096"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.SSHKeyType).To(Equal("rsa"))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.SSHKeyBits).To(Equal(4096))
+		})
+
+		It("passes the key type in the GCP up config", func() {
+			err := command.Execute([]string{"--ssh-key-type", "ed25519"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.SSHKeyType).To(Equal("ed25519"))
+		})
+	})
+
+	Context("when the user provides network cidrs", func() {
+		It("passes the vpc and subnet cidrs in the AWS up config", func() {
+			err := command.Execute([]string{
+				"--vpc-cidr", "172.16.0.0/16",
+				"--subnet-cidr", "172.16.0.0/24",
+			}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.VPCCIDR).To(Equal("172.16.0.0/16"))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.SubnetCIDR).To(Equal("172.16.0.0/24"))
+		})
+
+		It("passes the network and subnet cidrs in the GCP up config", func() {
+			err := command.Execute([]string{
+				"--network-cidr", "172.16.0.0/16",
+				"--subnet-cidr", "172.16.0.0/24",
+			}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.NetworkCIDR).To(Equal("172.16.0.0/16"))
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.SubnetCIDR).To(Equal("172.16.0.0/24"))
+		})
+	})
+
+	Context("when the user provides an existing vpc", func() {
+		It("passes the vpc and subnet ids in the AWS up config", func() {
+			err := command.Execute([]string{
+				"--existing-vpc-id", "vpc-123",
+				"--existing-subnet-ids", "subnet-1,subnet-2",
+			}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.ExistingVPCID).To(Equal("vpc-123"))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.ExistingSubnetIDs).To(Equal([]string{"subnet-1", "subnet-2"}))
+		})
+	})
+
+	Context("when the user selects zones", func() {
+		It("passes the azs in the AWS up config", func() {
+			err := command.Execute([]string{"--azs", "us-east-1a, us-east-1c"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.AZs).To(Equal([]string{"us-east-1a", "us-east-1c"}))
+		})
+
+		It("passes the zones in the GCP up config", func() {
+			err := command.Execute([]string{"--zones", "us-west1-a,us-west1-c"}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.Zones).To(Equal([]string{"us-west1-a", "us-west1-c"}))
+		})
+	})
+
+	Context("when the user provides an existing gcp network", func() {
+		It("passes the network and subnetwork names in the GCP up config", func() {
+			err := command.Execute([]string{
+				"--existing-network-name", "some-network",
+				"--existing-subnetwork-name", "some-subnetwork",
+			}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.ExistingNetworkName).To(Equal("some-network"))
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.ExistingSubnetworkName).To(Equal("some-subnetwork"))
+		})
+	})
+
+	Context("when the user skips the keypair", func() {
+		It("passes the key contents in the up config", func() {
+			publicKeyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.JUMPBOX_SSH_PUBLIC_KEY + "\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			privateKeyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.JUMPBOX_SSH_KEY)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = command.Execute([]string{
+				"--skip-keypair",
+				"--keypair-name", "some-keypair-name",
+				"--public-key", publicKeyPath,
+				"--private-key", privateKeyPath,
+			}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.SkipKeyPair).To(BeTrue())
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.KeyPairName).To(Equal("some-keypair-name"))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.PublicKey).To(Equal(testhelpers.JUMPBOX_SSH_PUBLIC_KEY))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.PrivateKey).To(Equal(testhelpers.JUMPBOX_SSH_KEY))
+		})
+	})
+
+	Context("when the user names an existing aws keypair without its public key", func() {
+		It("derives the public key from the private key", func() {
+			privateKeyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.JUMPBOX_SSH_KEY)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = command.Execute([]string{
+				"--skip-keypair",
+				"--keypair-name", "some-existing-keypair",
+				"--private-key", privateKeyPath,
+			}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.SkipKeyPair).To(BeTrue())
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.KeyPairName).To(Equal("some-existing-keypair"))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.PublicKey).To(Equal(testhelpers.JUMPBOX_SSH_PUBLIC_KEY))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.PrivateKey).To(Equal(testhelpers.JUMPBOX_SSH_KEY))
+		})
+	})
+
+	Context("when the user provides a director ca", func() {
+		It("passes the certificate and key contents in the up config", func() {
+			caCertPath, err := testhelpers.WriteContentsToTempFile(testhelpers.DIRECTOR_CA_CERT)
+			Expect(err).NotTo(HaveOccurred())
+
+			caKeyPath, err := testhelpers.WriteContentsToTempFile(testhelpers.DIRECTOR_CA_KEY)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = command.Execute([]string{
+				"--director-ca-cert", caCertPath,
+				"--director-ca-key", caKeyPath,
+			}, storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DirectorCACert).To(Equal(testhelpers.DIRECTOR_CA_CERT))
+			Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DirectorCAKey).To(Equal(testhelpers.DIRECTOR_CA_KEY))
+		})
+	})
+
+	Describe("DryRun", func() {
+		It("lists the steps for a new environment without applying anything", func() {
+			err := command.DryRun([]string{"--upload-stemcell", "some-stemcell.tgz"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeTerraform.PlanCall.CallCount).To(Equal(0))
+			Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(0))
+			Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal(`bbl up --dry-run would:
+  - create a new aws environment
+  - apply the terraform template for the infrastructure
+  - create or update the BOSH director
+  - update the cloud config
+  - upload the stemcell some-stemcell.tgz
+  - save the bbl state`))
+		})
+
+		It("lists the steps for a new azure environment", func() {
+			err := command.DryRun([]string{}, storage.State{IAAS: "azure"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAzureUp.ExecuteCall.CallCount).To(Equal(0))
+			Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal(`bbl up --dry-run would:
+  - create a new azure environment
+  - apply the terraform template for the infrastructure
+  - create or update the BOSH director
+  - update the cloud config
+  - save the bbl state`))
+		})
+
+		It("plans the terraform changes for an existing environment", func() {
+			fakeTerraform.PlanCall.Returns.Plan = "some-plan\n"
+
+			err := command.DryRun([]string{
+				"--credhub",
+				"--gcp-firewall-rule", "credhub:tcp:8443:10.0.0.0/8",
+				"--network-cidr", "10.1.0.0/16",
+			}, storage.State{
+				IAAS:    "gcp",
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeGCPUp.ExecuteCall.CallCount).To(Equal(0))
+			Expect(fakeTerraform.PlanCall.CallCount).To(Equal(1))
+			Expect(fakeTerraform.PlanCall.Receives.BBLState.Jumpbox.Enabled).To(BeTrue())
+			Expect(fakeTerraform.PlanCall.Receives.BBLState.GCP.FirewallRules).To(HaveLen(1))
+			Expect(fakeTerraform.PlanCall.Receives.BBLState.Network.CIDR).To(Equal("10.1.0.0/16"))
+			Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal(`bbl up --dry-run would:
+  - apply the terraform plan below to the infrastructure
+  - create or update the jumpbox
+  - create or update the BOSH director
+  - update the cloud config
+  - save the bbl state
+
+some-plan`))
+		})
+
+		Context("when the state has gcp firewall rules", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = storage.State{
+					IAAS:    "gcp",
+					EnvID:   "some-env-id",
+					TFState: "some-tf-state",
+					GCP: storage.GCP{
+						FirewallRules: []storage.GCPFirewallRule{{
+							Name:        "some-app",
+							Protocol:    "tcp",
+							Ports:       []string{"8080"},
+							SourceRange: "10.0.0.0/8",
+						}},
+					},
+				}
+			})
+
+			It("plans with the rules from the state when none are provided", func() {
+				err := command.DryRun([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeTerraform.PlanCall.Receives.BBLState.GCP.FirewallRules).To(Equal(state.GCP.FirewallRules))
+			})
+
+			It("plans without the rules when they are cleared", func() {
+				err := command.DryRun([]string{"--clear-gcp-firewall-rules"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeTerraform.PlanCall.Receives.BBLState.GCP.FirewallRules).To(BeEmpty())
+			})
+		})
+
+		It("plans the external director database", func() {
+			err := command.DryRun([]string{"--director-external-db"}, storage.State{IAAS: "aws", EnvID: "some-env-id", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeTerraform.PlanCall.Receives.BBLState.DirectorDB.Enabled).To(BeTrue())
+		})
+
+		It("leaves out the director with --no-director", func() {
+			err := command.DryRun([]string{"--no-director"}, storage.State{IAAS: "aws", EnvID: "some-env-id", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeLogger.PrintlnCall.Receives.Message).NotTo(ContainSubstring("director"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the terraform plan fails", func() {
+				fakeTerraform.PlanCall.Returns.Error = errors.New("failed to plan")
+
+				err := command.DryRun([]string{}, storage.State{IAAS: "aws", EnvID: "some-env-id", TFState: "some-tf-state"})
+				Expect(err).To(MatchError("failed to plan"))
+			})
 		})
 	})
 })
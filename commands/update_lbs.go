@@ -106,7 +106,7 @@ func (u UpdateLBs) CheckFastFails(subcommandFlags []string, state storage.State)
 		return LBNotFound
 	}
 
-	err = u.certificateValidator.Validate("update-lbs", config.certPath, config.keyPath, config.chainPath)
+	err = validateCertificate(u.certificateValidator, u.logger, "update-lbs", config.certPath, config.keyPath, config.chainPath)
 	if err != nil {
 		return err
 	}
@@ -2,7 +2,9 @@ package commands_test
 
 import (
 	"errors"
+	"time"
 
+	"github.com/cloudfoundry/bosh-bootloader/certs"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -80,6 +82,30 @@ var _ = Describe("Update LBs", func() {
 			Expect(certificateValidator.ValidateCall.Receives.ChainPath).To(Equal("/path/to/chain"))
 		})
 
+		It("explains which file failed and why", func() {
+			certificateValidator.ValidateCall.Returns.Error = certs.ValidationError{
+				Command: "update-lbs",
+				Failures: []certs.Failure{
+					{File: "chain", Flag: "--chain", Path: "/path/to/chain", Problem: certs.ProblemChainIncomplete, Err: errors.New("certificate and chain mismatch")},
+				},
+			}
+
+			err := command.CheckFastFails([]string{"--chain", "/path/to/chain"}, incomingState)
+			Expect(err).To(MatchError(ContainSubstring(`--chain "/path/to/chain": chain is incomplete, it must include every intermediate certificate up to a root: certificate and chain mismatch`)))
+		})
+
+		It("prints the subject and expiry of a valid certificate", func() {
+			certificateValidator.ValidateCall.Returns.Certificate = certs.Certificate{
+				Subject:  "some-subject",
+				NotAfter: time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC),
+			}
+
+			err := command.CheckFastFails([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.StepCall.Messages).To(ContainElement(`validated certificate for "some-subject", valid until 2020-01-02`))
+		})
+
 		It("returns an error if there is no lb", func() {
 			err := command.CheckFastFails([]string{}, storage.State{
 				Stack: storage.Stack{
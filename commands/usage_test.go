@@ -41,32 +41,85 @@ Usage:
 Global Options:
   --help      [-h]       Prints usage
   --state-dir            Directory containing bbl-state.json
+  --config               Flags to use when they are not passed, defaults to bbl.yml or bbl.json in the state dir
+  --state-backups        Number of previous states kept in the backups directory of the state dir (default 5)
+  --state-bucket         S3 bucket that holds a shared copy of the state (requires --state-key)
+  --state-key            Key prefix of the state in the state bucket
+  --proxy-port           Port of the socks5 proxy to the jumpbox, reusing a proxy already running on it (default a free port)
   --debug                Prints debugging output
+  --dry-run              Prints what a command would change without changing anything
+  --json                 Prints the output of query commands such as director-address, env-id, lbs and ssh-key as json
+  --log-level            Sets the output level: "error", "warn", "info" (default) or "debug"
+  --log-format           Sets the output format: "text" (default) or "json", one record per line
+  --metrics-file         Writes Prometheus textfile metrics about the run to this path
   --version              Prints version
+  --terraform-plugin-dir Directory containing terraform provider plugins
+  --terraform-cache-dir  Directory the pinned terraform is downloaded to (default ~/.bbl/terraform)
+  --offline              Never downloads from the public internet, using the artifacts in --artifacts-dir instead
+  --artifacts-dir        Directory of the terraform, providers, deployments, releases and stemcells bbl uses when --offline
+  --timeout              Fails the run when terraform, bosh create-env or a director task is still running after this long, e.g. "2h"
+  --terraform-timeout    Interrupts each terraform run after this long
+  --create-env-timeout   Interrupts each run of the bosh cli, such as create-env, after this long
+  --bosh-task-timeout    Cancels director tasks, such as stemcell uploads, that run for longer than this
+  --secret-store         Where director secrets are kept: "inline", "encrypted-file" (requires BBL_SECRET_STORE_KEY), "vault" (requires VAULT_ADDR and VAULT_TOKEN) or "credhub" (requires CREDHUB_SERVER, CREDHUB_CLIENT and CREDHUB_SECRET)
+  --vault-path           Vault kv secret the secrets are kept in with --secret-store vault, defaults to secret/bbl/<env-id>
+  --credhub-path         CredHub path the secrets and create-env variables are kept under with --secret-store credhub, defaults to /bbl/<env-id>
 
 Commands:
-  bosh-deployment-vars   Prints required variables for BOSH deployment
-  cloud-config           Prints suggested cloud configuration for BOSH environment
-  create-lbs             Attaches load balancer(s)
-  delete-lbs             Deletes attached load balancer(s)
-  destroy                Tears down BOSH director infrastructure
-  jumpbox-address        Prints BOSH jumpbox address
-  director-address       Prints BOSH director address
-  director-username      Prints BOSH director username
-  director-password      Prints BOSH director password
-  director-ca-cert       Prints BOSH director CA certificate
-  env-id                 Prints environment ID
-  latest-error           Prints the output from the latest call to terraform
-  print-env              Prints BOSH friendly environment variables
-  rotate                 Rotates the keypair for BOSH
-  help                   Prints usage
-  lbs                    Prints attached load balancer(s)
-  ssh-key                Prints SSH private key
-  up                     Deploys BOSH director on an IAAS
-  update-lbs             Updates load balancer(s)
-  version                Prints version
+  bosh-deployment-vars        Prints required variables for BOSH deployment
+  cloud-config                Prints suggested cloud configuration for BOSH environment
+  cleanup-leftovers           Deletes resources failed ups and destroys left behind
+  create-lbs                  Attaches load balancer(s)
+  delete-lbs                  Deletes attached load balancer(s)
+  deployments                 Prints the deployments on the BOSH director
+  destroy                     Tears down BOSH director infrastructure
+  jumpbox-address             Prints BOSH jumpbox address
+  director-address            Prints BOSH director address
+  director-username           Prints BOSH director username
+  director-password           Prints BOSH director password
+  director-ca-cert            Prints BOSH director CA certificate
+  director-backup             Backs up the BOSH director with bbr
+  director-restore            Restores the BOSH director with bbr
+  drift                       Reports infrastructure that diverged from the bbl state
+  env-id                      Prints environment ID
+  estimate-cost               Prints the approximate monthly cost of the environment
+  export-state                Writes the state dir to an encrypted bundle
+  import-state                Unpacks a bundle written by export-state into the state dir
+  latest-error                Prints the output from the latest call to terraform
+  migrate-state               Upgrades a bbl state written by an earlier bbl to the current schema
+  open                        Forwards a director, UAA or credhub port locally
+  outputs                     Prints every terraform output of the environment
+  plan                        Writes what up would deploy to the state dir and prints the changes
+  print-env                   Prints BOSH friendly environment variables
+  recreate-jumpbox            Recreates the jumpbox VM
+  regenerate-credhub-password Regenerates the UAA admin and credhub passwords
+  refresh                     Reads infrastructure changed outside of bbl into the bbl state
+  resize-director             Changes the vm type or persistent disk size of the director
+  restore-state               Restores the state from a backup
+  rotate                      Rotates the keypair for BOSH
+  rotate-lb-certs             Replaces the load balancer certificate in place
+  help                        Prints usage
+  history                     Prints the bbl commands run against the environment
+  lbs                         Prints attached load balancer(s)
+  ssh                         Opens a shell on the jumpbox or director
+  ssh-key                     Prints SSH private key
+  state                       Prints the bbl state with its secrets redacted
+  status                      Prints a summary of the bbl environment
+  terraform-output            Prints a terraform output of the environment
+  up                          Deploys BOSH director on an IAAS
+  update-lbs                  Updates load balancer(s)
+  verify                      Checks credentials, permissions and quotas before up
+  version                     Prints version
 
   Use "bbl [command] --help" for more information about a command.
+
+Exit Codes:
+  0  Success
+  1  Unclassified failure
+  2  Invalid usage or input
+  3  Missing or rejected IAAS credentials
+  4  IAAS, terraform or bosh failure
+  5  State dir is locked by another bbl process
 `, "\n")))
 		})
 	})
@@ -81,8 +134,29 @@ Commands:
 Global Options:
   --help      [-h]       Prints usage
   --state-dir            Directory containing bbl-state.json
+  --config               Flags to use when they are not passed, defaults to bbl.yml or bbl.json in the state dir
+  --state-backups        Number of previous states kept in the backups directory of the state dir (default 5)
+  --state-bucket         S3 bucket that holds a shared copy of the state (requires --state-key)
+  --state-key            Key prefix of the state in the state bucket
+  --proxy-port           Port of the socks5 proxy to the jumpbox, reusing a proxy already running on it (default a free port)
   --debug                Prints debugging output
+  --dry-run              Prints what a command would change without changing anything
+  --json                 Prints the output of query commands such as director-address, env-id, lbs and ssh-key as json
+  --log-level            Sets the output level: "error", "warn", "info" (default) or "debug"
+  --log-format           Sets the output format: "text" (default) or "json", one record per line
+  --metrics-file         Writes Prometheus textfile metrics about the run to this path
   --version              Prints version
+  --terraform-plugin-dir Directory containing terraform provider plugins
+  --terraform-cache-dir  Directory the pinned terraform is downloaded to (default ~/.bbl/terraform)
+  --offline              Never downloads from the public internet, using the artifacts in --artifacts-dir instead
+  --artifacts-dir        Directory of the terraform, providers, deployments, releases and stemcells bbl uses when --offline
+  --timeout              Fails the run when terraform, bosh create-env or a director task is still running after this long, e.g. "2h"
+  --terraform-timeout    Interrupts each terraform run after this long
+  --create-env-timeout   Interrupts each run of the bosh cli, such as create-env, after this long
+  --bosh-task-timeout    Cancels director tasks, such as stemcell uploads, that run for longer than this
+  --secret-store         Where director secrets are kept: "inline", "encrypted-file" (requires BBL_SECRET_STORE_KEY), "vault" (requires VAULT_ADDR and VAULT_TOKEN) or "credhub" (requires CREDHUB_SERVER, CREDHUB_CLIENT and CREDHUB_SECRET)
+  --vault-path           Vault kv secret the secrets are kept in with --secret-store vault, defaults to secret/bbl/<env-id>
+  --credhub-path         CredHub path the secrets and create-env variables are kept under with --secret-store credhub, defaults to /bbl/<env-id>
 
 [my-command command options]
   some message
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const VerifyCommand = "verify"
+
+type awsAvailabilityZoneRetriever interface {
+	Retrieve(region string) ([]string, error)
+}
+
+type permissionChecker interface {
+	Check() ([]string, error)
+}
+
+type Verify struct {
+	credentialValidator          credentialValidator
+	awsAvailabilityZoneRetriever awsAvailabilityZoneRetriever
+	gcpAvailabilityZoneRetriever gcpAvailabilityZoneRetriever
+	awsPermissionChecker         permissionChecker
+	gcpPermissionChecker         permissionChecker
+	awsQuotaChecker              awsQuotaChecker
+	gcpQuotaChecker              gcpQuotaChecker
+	logger                       logger
+}
+
+type verifyConfig struct {
+	noDirector bool
+	jumpbox    bool
+}
+
+func NewVerify(credentialValidator credentialValidator, awsAvailabilityZoneRetriever awsAvailabilityZoneRetriever,
+	gcpAvailabilityZoneRetriever gcpAvailabilityZoneRetriever, awsPermissionChecker, gcpPermissionChecker permissionChecker,
+	awsQuotaChecker awsQuotaChecker, gcpQuotaChecker gcpQuotaChecker, logger logger) Verify {
+	return Verify{
+		credentialValidator:          credentialValidator,
+		awsAvailabilityZoneRetriever: awsAvailabilityZoneRetriever,
+		gcpAvailabilityZoneRetriever: gcpAvailabilityZoneRetriever,
+		awsPermissionChecker:         awsPermissionChecker,
+		gcpPermissionChecker:         gcpPermissionChecker,
+		awsQuotaChecker:              awsQuotaChecker,
+		gcpQuotaChecker:              gcpQuotaChecker,
+		logger:                       logger,
+	}
+}
+
+func (v Verify) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := v.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if state.IAAS != "aws" && state.IAAS != "gcp" {
+		return fmt.Errorf("bbl verify only supports aws and gcp, not %q", state.IAAS)
+	}
+
+	return v.credentialValidator.Validate()
+}
+
+// Execute runs every check, even after one fails, so that all the problems
+// that would stop bbl up are reported together.
+func (v Verify) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := v.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	errorList := helpers.Errors{}
+	failed := false
+	check := func(name string, errs ...error) {
+		ok := true
+		for _, err := range errs {
+			if err != nil {
+				errorList.Add(err)
+				ok = false
+			}
+		}
+
+		if !ok {
+			failed = true
+			return
+		}
+		v.logger.Step("%s ok", name)
+	}
+
+	var permissions permissionChecker
+	switch state.IAAS {
+	case "aws":
+		check("region", v.checkAWSRegion(state.AWS.Region))
+		permissions = v.awsPermissionChecker
+	case "gcp":
+		check("region and zone", v.checkGCPZone(state.GCP.Region, state.GCP.Zone))
+		permissions = v.gcpPermissionChecker
+	}
+
+	check("permissions", checkPermissions(permissions)...)
+
+	switch state.IAAS {
+	case "aws":
+		check("quotas", v.awsQuotaChecker.Check(ec2.QuotaEnvironment{NoDirector: config.noDirector, Jumpbox: config.jumpbox}))
+	case "gcp":
+		check("quotas", v.gcpQuotaChecker.Check(state.GCP.Region, config.noDirector, config.jumpbox, ""))
+	}
+
+	if failed {
+		return errorList
+	}
+
+	v.logger.Println("bbl up can create the environment")
+	return nil
+}
+
+func (v Verify) checkAWSRegion(region string) error {
+	zones, err := v.awsAvailabilityZoneRetriever.Retrieve(region)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the availability zones of region %q: %s", region, err)
+	}
+
+	if len(zones) == 0 {
+		return fmt.Errorf("region %q has no availability zones", region)
+	}
+
+	return nil
+}
+
+func (v Verify) checkGCPZone(region, zone string) error {
+	zones, err := v.gcpAvailabilityZoneRetriever.GetZones(region)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the zones of region %q: %s", region, err)
+	}
+
+	for _, regionZone := range zones {
+		if regionZone == zone {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("zone %q is not in region %q", zone, region)
+}
+
+func checkPermissions(checker permissionChecker) []error {
+	missing, err := checker.Check()
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, permission := range missing {
+		errs = append(errs, fmt.Errorf("missing permission %s", permission))
+	}
+
+	return errs
+}
+
+func (Verify) parseFlags(subcommandFlags []string) (verifyConfig, error) {
+	verifyFlags := flags.New(VerifyCommand)
+
+	config := verifyConfig{}
+	verifyFlags.Bool(&config.noDirector, "", "no-director", false)
+	verifyFlags.Bool(&config.jumpbox, "", "credhub", false)
+
+	err := verifyFlags.Parse(subcommandFlags)
+	if err != nil {
+		return verifyConfig{}, err
+	}
+
+	return config, nil
+}
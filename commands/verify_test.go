@@ -0,0 +1,150 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verify", func() {
+	var (
+		command commands.Verify
+
+		credentialValidator          *fakes.CredentialValidator
+		awsAvailabilityZoneRetriever *fakes.AvailabilityZoneRetriever
+		gcpClient                    *fakes.GCPClient
+		awsPermissionChecker         *fakes.PermissionChecker
+		gcpPermissionChecker         *fakes.PermissionChecker
+		awsQuotaChecker              *fakes.AWSQuotaChecker
+		gcpQuotaChecker              *fakes.GCPQuotaChecker
+		logger                       *fakes.Logger
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		credentialValidator = &fakes.CredentialValidator{}
+		awsAvailabilityZoneRetriever = &fakes.AvailabilityZoneRetriever{}
+		awsAvailabilityZoneRetriever.RetrieveCall.Returns.AZs = []string{"some-region-1a"}
+		gcpClient = &fakes.GCPClient{}
+		gcpClient.GetZonesCall.Returns.Zones = []string{"some-zone", "other-zone"}
+		awsPermissionChecker = &fakes.PermissionChecker{}
+		gcpPermissionChecker = &fakes.PermissionChecker{}
+		awsQuotaChecker = &fakes.AWSQuotaChecker{}
+		gcpQuotaChecker = &fakes.GCPQuotaChecker{}
+		logger = &fakes.Logger{}
+
+		state = storage.State{
+			IAAS: "aws",
+			AWS:  storage.AWS{Region: "some-region"},
+			GCP:  storage.GCP{Region: "some-region", Zone: "some-zone"},
+		}
+
+		command = commands.NewVerify(credentialValidator, awsAvailabilityZoneRetriever, gcpClient,
+			awsPermissionChecker, gcpPermissionChecker, awsQuotaChecker, gcpQuotaChecker, logger)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the credentials", func() {
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(credentialValidator.ValidateCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the credentials are invalid", func() {
+			credentialValidator.ValidateCall.Returns.Error = errors.New("invalid credentials")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("invalid credentials"))
+		})
+
+		It("returns an error when the iaas is not supported", func() {
+			state.IAAS = "azure"
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError(`bbl verify only supports aws and gcp, not "azure"`))
+		})
+
+		It("returns an error when flags cannot be parsed", func() {
+			err := command.CheckFastFails([]string{"--unknown-flag"}, state)
+			Expect(err).To(MatchError("flag provided but not defined: -unknown-flag"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("checks the aws region, permissions and quotas", func() {
+			err := command.Execute([]string{"--no-director"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsAvailabilityZoneRetriever.RetrieveCall.Receives.Region).To(Equal("some-region"))
+			Expect(awsPermissionChecker.CheckCall.CallCount).To(Equal(1))
+			Expect(awsQuotaChecker.CheckCall.Receives.Environment).To(Equal(ec2.QuotaEnvironment{NoDirector: true}))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"region ok", "permissions ok", "quotas ok"}))
+			Expect(logger.PrintlnCall.Messages).To(ContainElement("bbl up can create the environment"))
+
+			Expect(gcpPermissionChecker.CheckCall.CallCount).To(Equal(0))
+			Expect(gcpQuotaChecker.CheckCall.CallCount).To(Equal(0))
+		})
+
+		It("checks the gcp zone, permissions and quotas", func() {
+			state.IAAS = "gcp"
+
+			err := command.Execute([]string{"--credhub"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(gcpClient.GetZonesCall.Receives.Region).To(Equal("some-region"))
+			Expect(gcpPermissionChecker.CheckCall.CallCount).To(Equal(1))
+			Expect(gcpQuotaChecker.CheckCall.Receives.Region).To(Equal("some-region"))
+			Expect(gcpQuotaChecker.CheckCall.Receives.Jumpbox).To(BeTrue())
+			Expect(logger.StepCall.Messages).To(Equal([]string{"region and zone ok", "permissions ok", "quotas ok"}))
+
+			Expect(awsPermissionChecker.CheckCall.CallCount).To(Equal(0))
+			Expect(awsQuotaChecker.CheckCall.CallCount).To(Equal(0))
+		})
+
+		Context("failure cases", func() {
+			It("reports every problem at once", func() {
+				awsAvailabilityZoneRetriever.RetrieveCall.Returns.AZs = []string{}
+				awsPermissionChecker.CheckCall.Returns.Missing = []string{"ec2:CreateVpc", "iam:PassRole"}
+				awsQuotaChecker.CheckCall.Returns.Error = errors.New("quota EC2-VPC Elastic IPs: need 2, have 1")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(`the following errors occurred:
+region "some-region" has no availability zones,
+missing permission ec2:CreateVpc,
+missing permission iam:PassRole,
+quota EC2-VPC Elastic IPs: need 2, have 1`))
+				Expect(logger.PrintlnCall.Messages).NotTo(ContainElement("bbl up can create the environment"))
+			})
+
+			It("returns an error when the aws region cannot be found", func() {
+				awsAvailabilityZoneRetriever.RetrieveCall.Returns.Error = errors.New("no such host")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(`failed to retrieve the availability zones of region "some-region": no such host`))
+			})
+
+			It("returns an error when the gcp zone is not in the region", func() {
+				state.IAAS = "gcp"
+				state.GCP.Zone = "far-zone"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(`zone "far-zone" is not in region "some-region"`))
+			})
+
+			It("returns an error when the permissions cannot be checked", func() {
+				awsPermissionChecker.CheckCall.Returns.Error = errors.New("AccessDenied")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("AccessDenied"))
+				Expect(awsQuotaChecker.CheckCall.CallCount).To(Equal(1))
+			})
+		})
+	})
+})
@@ -1,34 +1,127 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"runtime"
+	"strings"
 
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
 )
 
-const BBLDevVersion = "dev"
+const (
+	BBLDevVersion     = "dev"
+	unknownBuildValue = "unknown"
+)
+
+// BuildInfo holds the values embedded into the bbl binary at build time
+// with -ldflags "-X main.Version=... -X main.GitSHA=... -X main.BuildDate=...".
+type BuildInfo struct {
+	Version   string
+	GitSHA    string
+	BuildDate string
+}
 
 type Version struct {
-	logger  logger
-	version string
+	logger   logger
+	metadata versionMetadata
 }
 
-func NewVersion(version string, logger logger) Version {
-	if version == "" {
-		version = BBLDevVersion
-	}
+type versionConfig struct {
+	json    bool
+	verbose bool
+}
+
+type versionMetadata struct {
+	Version           string `json:"version"`
+	GitSHA            string `json:"git_sha"`
+	BuildDate         string `json:"build_date"`
+	GoVersion         string `json:"go_version"`
+	OS                string `json:"os"`
+	Arch              string `json:"arch"`
+	TerraformVersions string `json:"terraform_versions"`
+	BOSHVersions      string `json:"bosh_versions"`
+}
+
+func NewVersion(buildInfo BuildInfo, logger logger) Version {
 	return Version{
-		logger:  logger,
-		version: fmt.Sprintf("%s (%s/%s)", version, runtime.GOOS, runtime.GOARCH),
+		logger: logger,
+		metadata: versionMetadata{
+			Version:           valueOrDefault(buildInfo.Version, BBLDevVersion),
+			GitSHA:            valueOrDefault(buildInfo.GitSHA, unknownBuildValue),
+			BuildDate:         valueOrDefault(buildInfo.BuildDate, unknownBuildValue),
+			GoVersion:         runtime.Version(),
+			OS:                runtime.GOOS,
+			Arch:              runtime.GOARCH,
+			TerraformVersions: terraform.PinnedVersion,
+			BOSHVersions:      fmt.Sprintf(">= %s", minimumBOSHVersion),
+		},
 	}
 }
 
+func (v Version) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := v.parseArgs(subcommandFlags)
+	return err
+}
+
 func (v Version) Execute(subcommandFlags []string, state storage.State) error {
-	v.logger.Printf("bbl %s\n", v.version)
+	config, err := v.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	m := v.metadata
+	switch {
+	case config.json:
+		output, err := json.Marshal(m)
+		if err != nil {
+			// not tested
+			return err
+		}
+
+		v.logger.Println(string(output))
+	case config.verbose:
+		v.logger.Println(strings.Join([]string{
+			fmt.Sprintf("bbl %s (%s/%s)", m.Version, m.OS, m.Arch),
+			fmt.Sprintf("  git sha:    %s", m.GitSHA),
+			fmt.Sprintf("  build date: %s", m.BuildDate),
+			fmt.Sprintf("  go version: %s", m.GoVersion),
+			fmt.Sprintf("  terraform:  %s", m.TerraformVersions),
+			fmt.Sprintf("  bosh cli:   %s", m.BOSHVersions),
+		}, "\n"))
+	default:
+		v.logger.Printf("bbl %s (%s/%s)\n", m.Version, m.OS, m.Arch)
+	}
+
 	return nil
 }
 
-func (v Version) CheckFastFails(subcommandFlags []string, state storage.State) error {
-	return nil
+func (v Version) parseArgs(args []string) (versionConfig, error) {
+	var config versionConfig
+
+	versionFlags := flags.New("version")
+	versionFlags.Bool(&config.json, "", "json", false)
+	versionFlags.Bool(&config.verbose, "", "verbose", false)
+
+	err := versionFlags.Parse(args)
+	if err != nil {
+		return versionConfig{}, err
+	}
+
+	if config.json && config.verbose {
+		return versionConfig{}, errors.New("--json cannot be used with --verbose")
+	}
+
+	return config, nil
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
 }
@@ -24,19 +24,29 @@ var _ = Describe("Version", func() {
 
 	Describe("CheckFastFails", func() {
 		BeforeEach(func() {
-			version = commands.NewVersion("", logger)
+			version = commands.NewVersion(commands.BuildInfo{}, logger)
 		})
 
 		It("returns no error", func() {
 			err := version.CheckFastFails([]string{}, storage.State{})
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("returns an error when --json and --verbose are both provided", func() {
+			err := version.CheckFastFails([]string{"--json", "--verbose"}, storage.State{})
+			Expect(err).To(MatchError("--json cannot be used with --verbose"))
+		})
+
+		It("returns an error when the flags cannot be parsed", func() {
+			err := version.CheckFastFails([]string{"--invalid-flag"}, storage.State{})
+			Expect(err).To(MatchError("flag provided but not defined: -invalid-flag"))
+		})
 	})
 
 	Describe("Execute", func() {
 		Context("when no version number was passed in", func() {
 			BeforeEach(func() {
-				version = commands.NewVersion("", logger)
+				version = commands.NewVersion(commands.BuildInfo{}, logger)
 			})
 
 			Describe("Execute", func() {
@@ -48,12 +58,25 @@ var _ = Describe("Version", func() {
 						fmt.Sprintf("bbl dev (%s/%s)\n", runtime.GOOS, runtime.GOARCH),
 					}))
 				})
+
+				It("prints unknown for the missing build metadata when --verbose is provided", func() {
+					err := version.Execute([]string{"--verbose"}, storage.State{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+					Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring("  git sha:    unknown\n"))
+					Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring("  build date: unknown\n"))
+				})
 			})
 		})
 
 		Context("when a version number was passed in", func() {
 			BeforeEach(func() {
-				version = commands.NewVersion("1.2.3", logger)
+				version = commands.NewVersion(commands.BuildInfo{
+					Version:   "1.2.3",
+					GitSHA:    "abc123",
+					BuildDate: "2017-08-10T12:00:00Z",
+				}, logger)
 			})
 
 			Describe("Execute", func() {
@@ -64,7 +87,66 @@ var _ = Describe("Version", func() {
 					Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 						fmt.Sprintf("bbl 1.2.3 (%s/%s)\n", runtime.GOOS, runtime.GOARCH),
 					}))
+					Expect(logger.PrintlnCall.CallCount).To(Equal(0))
+				})
+
+				Context("when --verbose is provided", func() {
+					It("prints out the build metadata", func() {
+						err := version.Execute([]string{"--verbose"}, storage.State{})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+							fmt.Sprintf("bbl 1.2.3 (%s/%s)\n", runtime.GOOS, runtime.GOARCH) +
+								"  git sha:    abc123\n" +
+								"  build date: 2017-08-10T12:00:00Z\n" +
+								fmt.Sprintf("  go version: %s\n", runtime.Version()) +
+								"  terraform:  0.10.8\n" +
+								"  bosh cli:   >= 2.0.24",
+						}))
+					})
 				})
+
+				Context("when --json is provided", func() {
+					It("prints out the build metadata as json", func() {
+						err := version.Execute([]string{"--json"}, storage.State{})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+						Expect(logger.PrintlnCall.Messages[0]).To(MatchJSON(fmt.Sprintf(`{
+							"version": "1.2.3",
+							"git_sha": "abc123",
+							"build_date": "2017-08-10T12:00:00Z",
+							"go_version": %q,
+							"os": %q,
+							"arch": %q,
+							"terraform_versions": "0.10.8",
+							"bosh_versions": ">= 2.0.24"
+						}`, runtime.Version(), runtime.GOOS, runtime.GOARCH)))
+					})
+				})
+
+				Context("when the global --json flag is set", func() {
+					It("prints out the build metadata as json", func() {
+						err := version.ExecuteJSON([]string{}, storage.State{})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+						Expect(logger.PrintlnCall.Messages[0]).To(ContainSubstring(`"version":"1.2.3"`))
+					})
+
+					It("returns an error when --verbose is provided", func() {
+						err := version.ExecuteJSON([]string{"--verbose"}, storage.State{})
+						Expect(err).To(MatchError("--json cannot be used with --verbose"))
+					})
+				})
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				version = commands.NewVersion(commands.BuildInfo{}, logger)
+				err := version.Execute([]string{"--invalid-flag"}, storage.State{})
+				Expect(err).To(MatchError("flag provided but not defined: -invalid-flag"))
 			})
 		})
 	})
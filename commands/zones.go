@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func parseZones(zones string) []string {
+	var names []string
+	for _, name := range strings.Split(zones, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func validateZones(azs, zones []string, existingVPCID string, state storage.State) error {
+	if len(azs) > 0 {
+		if state.IAAS != "aws" {
+			return errors.New(`--azs is only supported when iaas="aws", use --zones when iaas="gcp"`)
+		}
+
+		if existingVPCID != "" || state.AWS.ExistingVPCID != "" {
+			return errors.New("--azs cannot be used with an existing VPC, the subnets decide the availability zones")
+		}
+
+		if state.TFState != "" && !sameZones(azs, state.AWS.AZs) {
+			return errors.New("--azs cannot be changed once the environment is created, its subnets keep their availability zones")
+		}
+	}
+
+	if len(zones) > 0 && state.IAAS != "gcp" {
+		return errors.New(`--zones is only supported when iaas="gcp", use --azs when iaas="aws"`)
+	}
+
+	return nil
+}
+
+func sameZones(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	inB := map[string]bool{}
+	for _, zone := range b {
+		inB[zone] = true
+	}
+
+	for _, zone := range a {
+		if !inB[zone] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// useZones records the zones an environment is limited to. Zones passed to an
+// earlier up are kept when none are passed.
+func useZones(state storage.State, azs, zones []string) storage.State {
+	if len(azs) > 0 {
+		state.AWS.AZs = azs
+	}
+
+	if len(zones) > 0 {
+		state.GCP.SelectedZones = zones
+	}
+
+	return state
+}
+
+// selectZones limits the zones of the region to the selected ones, keeping
+// the order of the region so the cloud config azs do not move around.
+func selectZones(zones, selected []string, region string) ([]string, error) {
+	if len(selected) == 0 {
+		return zones, nil
+	}
+
+	available := map[string]bool{}
+	for _, zone := range zones {
+		available[zone] = true
+	}
+
+	isSelected := map[string]bool{}
+	for _, zone := range selected {
+		if !available[zone] {
+			return nil, fmt.Errorf("zone %q is not in region %s, choose from: %s", zone, region, strings.Join(zones, ", "))
+		}
+		isSelected[zone] = true
+	}
+
+	selectedZones := []string{}
+	for _, zone := range zones {
+		if isSelected[zone] {
+			selectedZones = append(selectedZones, zone)
+		}
+	}
+
+	return selectedZones, nil
+}
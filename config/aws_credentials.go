@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+
+	goaws "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cloudfoundry/bosh-bootloader/aws"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	defaultAWSProfile = "default"
+
+	assumeRoleSessionName = "bosh-bootloader"
+)
+
+var (
+	awsProfileCredentials = sharedAWSCredentials
+	awsAssumeRole         = stsAssumeRole
+)
+
+// sharedAWSCredentials reads the access key and secret of profile from the
+// shared credentials file (~/.aws/credentials, or AWS_SHARED_CREDENTIALS_FILE).
+func sharedAWSCredentials(profile string) (string, string, error) {
+	provider := &credentials.SharedCredentialsProvider{Profile: profile}
+
+	value, err := provider.Retrieve()
+	if err != nil {
+		return "", "", err
+	}
+
+	return value.AccessKeyID, value.SecretAccessKey, nil
+}
+
+// stsAssumeRole exchanges the credentials of awsState, or those of the
+// default credential chain, for the temporary credentials of the role.
+func stsAssumeRole(awsState storage.AWS, mfaToken string) (credentials.Value, error) {
+	config := aws.Config{
+		AccessKeyID:     awsState.AccessKeyID,
+		SecretAccessKey: awsState.SecretAccessKey,
+		Region:          awsState.Region,
+	}
+
+	input := &sts.AssumeRoleInput{
+		RoleArn:         goaws.String(awsState.AssumeRoleARN),
+		RoleSessionName: goaws.String(assumeRoleSessionName),
+	}
+	if awsState.AssumeRoleExternalID != "" {
+		input.ExternalId = goaws.String(awsState.AssumeRoleExternalID)
+	}
+	if awsState.MFASerial != "" {
+		input.SerialNumber = goaws.String(awsState.MFASerial)
+		input.TokenCode = goaws.String(mfaToken)
+	}
+
+	output, err := sts.New(session.New(config.ClientConfig())).AssumeRole(input)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	return credentials.Value{
+		AccessKeyID:     goaws.StringValue(output.Credentials.AccessKeyId),
+		SecretAccessKey: goaws.StringValue(output.Credentials.SecretAccessKey),
+		SessionToken:    goaws.StringValue(output.Credentials.SessionToken),
+	}, nil
+}
+
+// assumeAWSRole replaces the credentials of awsState with the temporary
+// credentials of its role. Like profile credentials they are only kept in
+// memory, the state store saves the role instead.
+func assumeAWSRole(awsState storage.AWS, mfaToken string) (storage.AWS, error) {
+	if awsState.MFASerial != "" && mfaToken == "" {
+		return storage.AWS{}, fmt.Errorf("--aws-mfa-token must be provided to assume %s with MFA device %s", awsState.AssumeRoleARN, awsState.MFASerial)
+	}
+
+	value, err := awsAssumeRole(awsState, mfaToken)
+	if err != nil {
+		return storage.AWS{}, fmt.Errorf("failed to assume AWS role %s: %s", awsState.AssumeRoleARN, err)
+	}
+
+	awsState.AccessKeyID = value.AccessKeyID
+	awsState.SecretAccessKey = value.SecretAccessKey
+	awsState.SessionToken = value.SessionToken
+
+	return awsState, nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	bblflags "github.com/cloudfoundry/bosh-bootloader/flags"
+	flags "github.com/jessevdk/go-flags"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// configFileNames are looked for in the state dir when --config is not given.
+var configFileNames = []string{"bbl.yml", "bbl.json"}
+
+// applyConfigFile parses the global flags of the config file in front of the
+// command line ones, and returns the remaining args with the flags of the
+// command's section inserted after the command.
+func applyConfigFile(parser *flags.Parser, args, remainingArgs []string, path, stateDir string) ([]string, error) {
+	if stateDir == "" {
+		var err error
+		stateDir, err = os.Getwd()
+		if err != nil {
+			return nil, err //not tested
+		}
+	}
+
+	globalArgs, commandArgs, err := configFileArgs(parser, path, stateDir, remainingArgs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(globalArgs) > 0 {
+		remainingArgs, err = parser.ParseArgs(append(globalArgs, args...))
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file: %s", err)
+		}
+	}
+
+	command := []string{remainingArgs[0]}
+	command = append(command, commandArgs...)
+	return append(command, remainingArgs[1:]...), nil
+}
+
+// configFileArgs reads the flags declared in the config file and returns them
+// as the arguments to parse before the global flags on the command line, and
+// the ones to insert before the flags of the command. Command line flags are
+// parsed last and win, and an environment variable wins over the flag it sets.
+func configFileArgs(parser *flags.Parser, path, stateDir, command string) ([]string, []string, error) {
+	if path == "" {
+		for _, name := range configFileNames {
+			candidate := filepath.Join(stateDir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+
+		if path == "" {
+			return nil, nil, nil
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the config file: %s", err)
+	}
+
+	// json is yaml, so both formats are read the same way.
+	var config map[string]interface{}
+	err = yaml.Unmarshal(contents, &config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the config file %s: %s", path, err)
+	}
+
+	var globalArgs, commandArgs []string
+	for _, name := range sortedKeys(config) {
+		value := config[name]
+
+		if section, ok := value.(map[interface{}]interface{}); ok {
+			if name != command {
+				continue
+			}
+
+			for _, flagName := range sortedSectionKeys(section) {
+				if os.Getenv(bblflags.EnvName(command, flagName)) != "" {
+					continue
+				}
+
+				args, err := flagArgs(flagName, section[flagName], path)
+				if err != nil {
+					return nil, nil, err
+				}
+				commandArgs = append(commandArgs, args...)
+			}
+			continue
+		}
+
+		option := parser.FindOptionByLongName(name)
+		if option == nil {
+			return nil, nil, fmt.Errorf("unknown flag %q in the config file %s", name, path)
+		}
+
+		if option.EnvDefaultKey != "" && os.Getenv(option.EnvDefaultKey) != "" {
+			continue
+		}
+
+		args, err := flagArgs(name, value, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		globalArgs = append(globalArgs, args...)
+	}
+
+	return globalArgs, commandArgs, nil
+}
+
+func flagArgs(name string, value interface{}, path string) ([]string, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return []string{fmt.Sprintf("--%s", name)}, nil
+		}
+		return []string{}, nil
+	case string, int, float64:
+		return []string{fmt.Sprintf("--%s", name), fmt.Sprintf("%v", v)}, nil
+	case []interface{}:
+		var args []string
+		for _, element := range v {
+			elementArgs, err := flagArgs(name, element, path)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, elementArgs...)
+		}
+		return args, nil
+	default:
+		return nil, fmt.Errorf("the value of %q in the config file %s must be a string, number, boolean or list", name, path)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSectionKeys(m map[interface{}]interface{}) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, fmt.Sprintf("%v", key))
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+func SetAWSProfileCredentials(f func(string) (string, string, error)) {
+	awsProfileCredentials = f
+}
+
+func ResetAWSProfileCredentials() {
+	awsProfileCredentials = sharedAWSCredentials
+}
+
+func SetAWSAssumeRole(f func(storage.AWS, string) (credentials.Value, error)) {
+	awsAssumeRole = f
+}
+
+func ResetAWSAssumeRole() {
+	awsAssumeRole = stsAssumeRole
+}
@@ -6,53 +6,124 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
+	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	flags "github.com/jessevdk/go-flags"
 )
 
 type globalFlags struct {
-	Help     bool   `short:"h" long:"help"`
-	Debug    bool   `short:"d" long:"debug"         env:"BBL_DEBUG"`
-	Version  bool   `short:"v" long:"version"`
-	StateDir string `short:"s" long:"state-dir"`
-	IAAS     string `long:"iaas"                    env:"BBL_IAAS"`
+	Help      bool   `short:"h" long:"help"`
+	Debug     bool   `short:"d" long:"debug"         env:"BBL_DEBUG"`
+	DryRun    bool   `long:"dry-run"                 env:"BBL_DRY_RUN"`
+	JSON      bool   `long:"json"                    env:"BBL_JSON"`
+	Version   bool   `short:"v" long:"version"`
+	StateDir  string `short:"s" long:"state-dir"     env:"BBL_STATE_DIR"`
+	Config    string `long:"config"                  env:"BBL_CONFIG"`
+	IAAS      string `long:"iaas"                    env:"BBL_IAAS"`
+	LogLevel  string `long:"log-level"               env:"BBL_LOG_LEVEL"`
+	LogFormat string `long:"log-format"              env:"BBL_LOG_FORMAT"`
+
+	MetricsFile        string `long:"metrics-file"         env:"BBL_METRICS_FILE"`
+	TerraformPluginDir string `long:"terraform-plugin-dir" env:"BBL_TERRAFORM_PLUGIN_DIR"`
+	TerraformCacheDir  string `long:"terraform-cache-dir"  env:"BBL_TERRAFORM_CACHE_DIR"`
+	SecretStore        string `long:"secret-store"         env:"BBL_SECRET_STORE"`
+	VaultPath          string `long:"vault-path"           env:"BBL_VAULT_PATH"`
+	CredHubPath        string `long:"credhub-path"         env:"BBL_CREDHUB_PATH"`
+	StateBackups       int    `long:"state-backups"        env:"BBL_STATE_BACKUPS" default:"5"`
+	ProxyPort          int    `long:"proxy-port"           env:"BBL_PROXY_PORT"`
+	StateBucket        string `long:"state-bucket"         env:"BBL_STATE_BUCKET"`
+	StateKey           string `long:"state-key"            env:"BBL_STATE_KEY"`
+	Offline            bool   `long:"offline"              env:"BBL_OFFLINE"`
+	ArtifactsDir       string `long:"artifacts-dir"        env:"BBL_ARTIFACTS_DIR"`
+
+	Timeout          time.Duration `long:"timeout"            env:"BBL_TIMEOUT"`
+	TerraformTimeout time.Duration `long:"terraform-timeout"  env:"BBL_TERRAFORM_TIMEOUT"`
+	CreateEnvTimeout time.Duration `long:"create-env-timeout" env:"BBL_CREATE_ENV_TIMEOUT"`
+	BOSHTaskTimeout  time.Duration `long:"bosh-task-timeout"  env:"BBL_BOSH_TASK_TIMEOUT"`
 
 	AWSAccessKeyID     string `long:"aws-access-key-id"       env:"BBL_AWS_ACCESS_KEY_ID"`
 	AWSSecretAccessKey string `long:"aws-secret-access-key"   env:"BBL_AWS_SECRET_ACCESS_KEY"`
 	AWSRegion          string `long:"aws-region"              env:"BBL_AWS_REGION"`
+	AWSProfile         string `long:"aws-profile"             env:"AWS_PROFILE"`
+
+	AWSAssumeRoleARN        string `long:"aws-assume-role-arn"         env:"BBL_AWS_ASSUME_ROLE_ARN"`
+	AWSAssumeRoleExternalID string `long:"aws-assume-role-external-id" env:"BBL_AWS_ASSUME_ROLE_EXTERNAL_ID"`
+	AWSMFASerial            string `long:"aws-mfa-serial"              env:"BBL_AWS_MFA_SERIAL"`
+	AWSMFAToken             string `long:"aws-mfa-token"               env:"BBL_AWS_MFA_TOKEN"`
 
 	AzureSubscriptionID string `long:"azure-subscription-id"  env:"BBL_AZURE_SUBSCRIPTION_ID"`
 	AzureTenantID       string `long:"azure-tenant-id"        env:"BBL_AZURE_TENANT_ID"`
 	AzureClientID       string `long:"azure-client-id"        env:"BBL_AZURE_CLIENT_ID"`
 	AzureClientSecret   string `long:"azure-client-secret"    env:"BBL_AZURE_CLIENT_SECRET"`
+	AzureAuthMethod     string `long:"azure-auth-method"      env:"BBL_AZURE_AUTH_METHOD"`
+	AzureRegion         string `long:"azure-region"           env:"BBL_AZURE_REGION"`
+
+	OpenStackAuthURL         string `long:"openstack-auth-url"          env:"BBL_OPENSTACK_AUTH_URL"`
+	OpenStackAZ              string `long:"openstack-az"                env:"BBL_OPENSTACK_AZ"`
+	OpenStackExternalNetwork string `long:"openstack-external-network"  env:"BBL_OPENSTACK_EXTERNAL_NETWORK"`
+	OpenStackRegion          string `long:"openstack-region"            env:"BBL_OPENSTACK_REGION"`
+	OpenStackUsername        string `long:"openstack-username"          env:"BBL_OPENSTACK_USERNAME"`
+	OpenStackPassword        string `long:"openstack-password"          env:"BBL_OPENSTACK_PASSWORD"`
+	OpenStackDomain          string `long:"openstack-domain"            env:"BBL_OPENSTACK_DOMAIN"`
+	OpenStackProject         string `long:"openstack-project"           env:"BBL_OPENSTACK_PROJECT"`
 
 	GCPServiceAccountKey string `long:"gcp-service-account-key" env:"BBL_GCP_SERVICE_ACCOUNT_KEY"`
 	GCPProjectID         string `long:"gcp-project-id"          env:"BBL_GCP_PROJECT_ID"`
 	GCPZone              string `long:"gcp-zone"                env:"BBL_GCP_ZONE"`
 	GCPRegion            string `long:"gcp-region"              env:"BBL_GCP_REGION"`
+
+	GCPImpersonateServiceAccount string `long:"gcp-impersonate-service-account" env:"BBL_GCP_IMPERSONATE_SERVICE_ACCOUNT"`
 }
 
 type ParsedFlags struct {
-	State         storage.State
-	RemainingArgs []string
-	Help          bool
-	Debug         bool
-	Version       bool
-	StateDir      string
+	State              storage.State
+	RemainingArgs      []string
+	Help               bool
+	Debug              bool
+	DryRun             bool
+	JSON               bool
+	LogLevel           string
+	LogFormat          string
+	MetricsFile        string
+	Version            bool
+	StateDir           string
+	StateBackups       int
+	StateBackend       storage.StateBackend
+	TerraformPluginDir string
+	TerraformCacheDir  string
+	Offline            bool
+	ArtifactsDir       string
+	TerraformTimeout   helpers.Timeout
+	CreateEnvTimeout   helpers.Timeout
+	BOSHTaskTimeout    helpers.Timeout
+
+	// Unlock releases the lock of the state dir, taken for a command that
+	// changes the state. It does nothing for other commands.
+	Unlock func()
 }
 
-func NewConfig(getState func(string) (storage.State, error)) Config {
+// NewConfig reads the state with getState, after downloading it with
+// pullState when it is shared through a state bucket. lockState locks the
+// state dir for the commands that change the state before either of them
+// runs, so that the state they load cannot be changed by another bbl.
+func NewConfig(getState func(string) (storage.State, error), pullState func(storage.StateBackend, string) error, lockState func(string) (func(), error)) Config {
 	return Config{
-		getState: getState,
+		getState:  getState,
+		pullState: pullState,
+		lockState: lockState,
 	}
 }
 
 type Config struct {
-	getState func(string) (storage.State, error)
+	getState  func(string) (storage.State, error)
+	pullState func(storage.StateBackend, string) error
+	lockState func(string) (func(), error)
 }
 
-func (c Config) Bootstrap(args []string) (ParsedFlags, error) {
+func (c Config) Bootstrap(args []string) (parsedFlags ParsedFlags, err error) {
 	var globalFlags globalFlags
 
 	parser := flags.NewParser(&globalFlags, flags.IgnoreUnknown)
@@ -62,15 +133,88 @@ func (c Config) Bootstrap(args []string) (ParsedFlags, error) {
 		return ParsedFlags{}, err
 	}
 
+	if len(remainingArgs) > 0 && !globalFlags.Help && !globalFlags.Version {
+		remainingArgs, err = applyConfigFile(parser, args[1:], remainingArgs, globalFlags.Config, globalFlags.StateDir)
+		if err != nil {
+			return ParsedFlags{}, err
+		}
+	}
+
+	if globalFlags.LogLevel == "" {
+		globalFlags.LogLevel = application.LogLevelInfo
+	}
+	if !application.IsValidLogLevel(globalFlags.LogLevel) {
+		return ParsedFlags{}, fmt.Errorf("--log-level must be one of %q, %q, %q or %q",
+			application.LogLevelError, application.LogLevelWarn, application.LogLevelInfo, application.LogLevelDebug)
+	}
+
+	if globalFlags.LogFormat == "" {
+		globalFlags.LogFormat = application.LogFormatText
+	}
+	if !application.IsValidLogFormat(globalFlags.LogFormat) {
+		return ParsedFlags{}, fmt.Errorf("--log-format must be %q or %q", application.LogFormatText, application.LogFormatJSON)
+	}
+
+	if globalFlags.StateBackups < 0 {
+		return ParsedFlags{}, errors.New("--state-backups must not be negative")
+	}
+
+	if (globalFlags.StateBucket == "") != (globalFlags.StateKey == "") {
+		return ParsedFlags{}, errors.New("--state-bucket and --state-key must be provided together")
+	}
+
+	if globalFlags.Offline != (globalFlags.ArtifactsDir != "") {
+		return ParsedFlags{}, errors.New("--offline and --artifacts-dir must be provided together")
+	}
+
+	timeouts := []struct {
+		flag  string
+		value time.Duration
+	}{
+		{"--timeout", globalFlags.Timeout},
+		{"--terraform-timeout", globalFlags.TerraformTimeout},
+		{"--create-env-timeout", globalFlags.CreateEnvTimeout},
+		{"--bosh-task-timeout", globalFlags.BOSHTaskTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			return ParsedFlags{}, fmt.Errorf("%s must not be negative", timeout.flag)
+		}
+	}
+
+	// --timeout bounds the whole run, so every phase also stops at its
+	// deadline
+	var deadline time.Time
+	if globalFlags.Timeout != 0 {
+		deadline = time.Now().Add(globalFlags.Timeout)
+	}
+	terraformTimeout := helpers.Timeout{Limit: globalFlags.TerraformTimeout, Deadline: deadline}
+	createEnvTimeout := helpers.Timeout{Limit: globalFlags.CreateEnvTimeout, Deadline: deadline}
+	boshTaskTimeout := helpers.Timeout{Limit: globalFlags.BOSHTaskTimeout, Deadline: deadline}
+
 	nonStatefulCommand := len(remainingArgs) == 0 || globalFlags.Help || globalFlags.Version
 	nonStatefulCommand = nonStatefulCommand || (remainingArgs[0] == "help" || remainingArgs[0] == "version")
 	if nonStatefulCommand {
 		return ParsedFlags{
-			RemainingArgs: remainingArgs,
-			Help:          globalFlags.Help,
-			Debug:         globalFlags.Debug,
-			Version:       globalFlags.Version,
-			StateDir:      globalFlags.StateDir,
+			RemainingArgs:      remainingArgs,
+			Help:               globalFlags.Help,
+			Debug:              globalFlags.Debug,
+			DryRun:             globalFlags.DryRun,
+			JSON:               globalFlags.JSON,
+			LogLevel:           globalFlags.LogLevel,
+			LogFormat:          globalFlags.LogFormat,
+			MetricsFile:        globalFlags.MetricsFile,
+			Version:            globalFlags.Version,
+			StateDir:           globalFlags.StateDir,
+			StateBackups:       globalFlags.StateBackups,
+			TerraformPluginDir: globalFlags.TerraformPluginDir,
+			TerraformCacheDir:  globalFlags.TerraformCacheDir,
+			Offline:            globalFlags.Offline,
+			ArtifactsDir:       globalFlags.ArtifactsDir,
+			TerraformTimeout:   terraformTimeout,
+			CreateEnvTimeout:   createEnvTimeout,
+			BOSHTaskTimeout:    boshTaskTimeout,
+			Unlock:             func() {},
 		}, nil
 	}
 
@@ -83,11 +227,52 @@ func (c Config) Bootstrap(args []string) (ParsedFlags, error) {
 		}
 	}
 
+	unlock := func() {}
+	if application.ChangesState(remainingArgs[0]) && !globalFlags.DryRun && !commandHelp(remainingArgs[1:]) {
+		unlock, err = c.lockState(stateDir)
+		if err != nil {
+			return ParsedFlags{}, err
+		}
+
+		defer func() {
+			if err != nil {
+				unlock()
+			}
+		}()
+	}
+
+	// A state dir that was shared through a state bucket keeps using it
+	// without the flags, so that it is never changed without the other copy.
+	stateBucket, stateKey := globalFlags.StateBucket, globalFlags.StateKey
+	if stateBucket == "" {
+		localState, err := c.getState(stateDir)
+		if err != nil {
+			return ParsedFlags{}, err
+		}
+		stateBucket, stateKey = localState.StateBucket, localState.StateKey
+	}
+
+	var stateBackend storage.StateBackend
+	if stateBucket != "" {
+		stateBackend = storage.NewS3StateBackend(stateBucket, stateKey,
+			globalFlags.AWSAccessKeyID, globalFlags.AWSSecretAccessKey)
+
+		err = c.pullState(stateBackend, stateDir)
+		if err != nil {
+			return ParsedFlags{}, fmt.Errorf("failed to download the state from s3://%s/%s: %s", stateBucket, stateKey, err)
+		}
+	}
+
 	state, err := c.getState(stateDir)
 	if err != nil {
 		return ParsedFlags{}, err
 	}
 
+	if stateBucket != "" {
+		state.StateBucket = stateBucket
+		state.StateKey = stateKey
+	}
+
 	if globalFlags.IAAS != "" {
 		if state.IAAS != "" && globalFlags.IAAS != state.IAAS {
 			iaasMismatch := fmt.Sprintf("The iaas type cannot be changed for an existing environment. The current iaas type is %s.", state.IAAS)
@@ -96,11 +281,48 @@ func (c Config) Bootstrap(args []string) (ParsedFlags, error) {
 		state.IAAS = globalFlags.IAAS
 	}
 
+	if globalFlags.SecretStore != "" {
+		switch globalFlags.SecretStore {
+		case storage.SecretStoreInline, storage.SecretStoreEncryptedFile, storage.SecretStoreVault, storage.SecretStoreCredHub:
+		default:
+			return ParsedFlags{}, fmt.Errorf("--secret-store must be %q, %q, %q or %q", storage.SecretStoreInline, storage.SecretStoreEncryptedFile, storage.SecretStoreVault, storage.SecretStoreCredHub)
+		}
+		state.SecretStore = globalFlags.SecretStore
+	}
+
+	if globalFlags.VaultPath != "" {
+		if state.SecretStore != storage.SecretStoreVault {
+			return ParsedFlags{}, fmt.Errorf("--vault-path can only be used with --secret-store %s", storage.SecretStoreVault)
+		}
+		state.VaultPath = globalFlags.VaultPath
+	}
+
+	if globalFlags.CredHubPath != "" {
+		if state.SecretStore != storage.SecretStoreCredHub {
+			return ParsedFlags{}, fmt.Errorf("--credhub-path can only be used with --secret-store %s", storage.SecretStoreCredHub)
+		}
+		state.CredHubPath = globalFlags.CredHubPath
+	}
+
+	if globalFlags.ProxyPort != 0 {
+		if globalFlags.ProxyPort < 0 || globalFlags.ProxyPort > 65535 {
+			return ParsedFlags{}, errors.New("--proxy-port must be between 1 and 65535")
+		}
+		state.Jumpbox.ProxyPort = globalFlags.ProxyPort
+	}
+
 	if globalFlags.AWSAccessKeyID != "" {
 		state.AWS.AccessKeyID = globalFlags.AWSAccessKeyID
+		state.AWS.Profile = ""
 	}
 	if globalFlags.AWSSecretAccessKey != "" {
 		state.AWS.SecretAccessKey = globalFlags.AWSSecretAccessKey
+		state.AWS.Profile = ""
+	}
+	if globalFlags.AWSProfile != "" && globalFlags.AWSAccessKeyID == "" && globalFlags.AWSSecretAccessKey == "" {
+		state.AWS.Profile = globalFlags.AWSProfile
+		state.AWS.AccessKeyID = ""
+		state.AWS.SecretAccessKey = ""
 	}
 	if globalFlags.AWSRegion != "" {
 		if state.AWS.Region != "" && globalFlags.AWSRegion != state.AWS.Region {
@@ -109,6 +331,28 @@ func (c Config) Bootstrap(args []string) (ParsedFlags, error) {
 		}
 		state.AWS.Region = globalFlags.AWSRegion
 	}
+	if globalFlags.AWSAssumeRoleARN != "" {
+		state.AWS.AssumeRoleARN = globalFlags.AWSAssumeRoleARN
+	}
+	if globalFlags.AWSAssumeRoleExternalID != "" {
+		state.AWS.AssumeRoleExternalID = globalFlags.AWSAssumeRoleExternalID
+	}
+	if globalFlags.AWSMFASerial != "" {
+		state.AWS.MFASerial = globalFlags.AWSMFASerial
+	}
+	if state.IAAS == "aws" {
+		state.AWS, err = resolveAWSProfile(state.AWS)
+		if err != nil {
+			return ParsedFlags{}, err
+		}
+
+		if state.AWS.AssumeRoleARN != "" {
+			state.AWS, err = assumeAWSRole(state.AWS, globalFlags.AWSMFAToken)
+			if err != nil {
+				return ParsedFlags{}, err
+			}
+		}
+	}
 
 	if globalFlags.GCPServiceAccountKey != "" {
 		serviceAccountKey, err := parseServiceAccountKey(globalFlags.GCPServiceAccountKey)
@@ -130,6 +374,9 @@ func (c Config) Bootstrap(args []string) (ParsedFlags, error) {
 		}
 		state.GCP.Region = globalFlags.GCPRegion
 	}
+	if globalFlags.GCPImpersonateServiceAccount != "" {
+		state.GCP.ImpersonateServiceAccount = globalFlags.GCPImpersonateServiceAccount
+	}
 	if globalFlags.AzureSubscriptionID != "" {
 		state.Azure.SubscriptionID = globalFlags.AzureSubscriptionID
 	}
@@ -142,18 +389,90 @@ func (c Config) Bootstrap(args []string) (ParsedFlags, error) {
 	if globalFlags.AzureClientSecret != "" {
 		state.Azure.ClientSecret = globalFlags.AzureClientSecret
 	}
+	if globalFlags.AzureAuthMethod != "" {
+		state.Azure.AuthMethod = globalFlags.AzureAuthMethod
+	}
+	if globalFlags.AzureRegion != "" {
+		if state.Azure.Region != "" && globalFlags.AzureRegion != state.Azure.Region {
+			regionMismatch := fmt.Sprintf("The region cannot be changed for an existing environment. The current region is %s.", state.Azure.Region)
+			return ParsedFlags{}, errors.New(regionMismatch)
+		}
+		state.Azure.Region = globalFlags.AzureRegion
+	}
+	if globalFlags.OpenStackAuthURL != "" {
+		state.OpenStack.AuthURL = globalFlags.OpenStackAuthURL
+	}
+	if globalFlags.OpenStackAZ != "" {
+		state.OpenStack.AZ = globalFlags.OpenStackAZ
+	}
+	if globalFlags.OpenStackExternalNetwork != "" {
+		state.OpenStack.ExternalNetwork = globalFlags.OpenStackExternalNetwork
+	}
+	if globalFlags.OpenStackRegion != "" {
+		if state.OpenStack.Region != "" && globalFlags.OpenStackRegion != state.OpenStack.Region {
+			regionMismatch := fmt.Sprintf("The region cannot be changed for an existing environment. The current region is %s.", state.OpenStack.Region)
+			return ParsedFlags{}, errors.New(regionMismatch)
+		}
+		state.OpenStack.Region = globalFlags.OpenStackRegion
+	}
+	if globalFlags.OpenStackUsername != "" {
+		state.OpenStack.Username = globalFlags.OpenStackUsername
+	}
+	if globalFlags.OpenStackPassword != "" {
+		state.OpenStack.Password = globalFlags.OpenStackPassword
+	}
+	if globalFlags.OpenStackDomain != "" {
+		state.OpenStack.Domain = globalFlags.OpenStackDomain
+	}
+	if globalFlags.OpenStackProject != "" {
+		state.OpenStack.Project = globalFlags.OpenStackProject
+	}
 
 	err = validate(state)
 	if err != nil {
 		return ParsedFlags{}, err
 	}
 
-	return ParsedFlags{State: state, RemainingArgs: remainingArgs, Help: globalFlags.Help, Debug: globalFlags.Debug, Version: globalFlags.Version, StateDir: globalFlags.StateDir}, nil
+	return ParsedFlags{
+		State:              state,
+		RemainingArgs:      remainingArgs,
+		Help:               globalFlags.Help,
+		Debug:              globalFlags.Debug,
+		DryRun:             globalFlags.DryRun,
+		JSON:               globalFlags.JSON,
+		LogLevel:           globalFlags.LogLevel,
+		LogFormat:          globalFlags.LogFormat,
+		MetricsFile:        globalFlags.MetricsFile,
+		Version:            globalFlags.Version,
+		StateDir:           globalFlags.StateDir,
+		StateBackups:       globalFlags.StateBackups,
+		StateBackend:       stateBackend,
+		TerraformPluginDir: globalFlags.TerraformPluginDir,
+		TerraformCacheDir:  globalFlags.TerraformCacheDir,
+		Offline:            globalFlags.Offline,
+		ArtifactsDir:       globalFlags.ArtifactsDir,
+		TerraformTimeout:   terraformTimeout,
+		CreateEnvTimeout:   createEnvTimeout,
+		BOSHTaskTimeout:    boshTaskTimeout,
+		Unlock:             unlock,
+	}, nil
+}
+
+// commandHelp returns whether the subcommand flags ask for the usage of the
+// command rather than running it.
+func commandHelp(subcommandFlags []string) bool {
+	for _, flag := range subcommandFlags {
+		if flag == "-h" || flag == "--help" {
+			return true
+		}
+	}
+
+	return false
 }
 
 func validate(state storage.State) error {
-	if state.IAAS == "" || (state.IAAS != "gcp" && state.IAAS != "aws" && state.IAAS != "azure") {
-		return errors.New("--iaas [gcp, aws, azure] must be provided or BBL_IAAS must be set")
+	if state.IAAS == "" || (state.IAAS != "gcp" && state.IAAS != "aws" && state.IAAS != "azure" && state.IAAS != "openstack") {
+		return errors.New("--iaas [gcp, aws, azure, openstack] must be provided or BBL_IAAS must be set")
 	}
 	if state.IAAS == "aws" {
 		err := validateAWSFlags(state.AWS)
@@ -173,14 +492,51 @@ func validate(state storage.State) error {
 			return err
 		}
 	}
+	if state.IAAS == "openstack" {
+		err := validateOpenStackFlags(state.OpenStack)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// resolveAWSProfile reads the credentials of the profile from the shared
+// credentials file when no access key and secret were provided. Without a
+// profile the default one is used if it exists. The credentials are only kept
+// in memory, the state store saves the profile name instead.
+func resolveAWSProfile(awsState storage.AWS) (storage.AWS, error) {
+	if awsState.AccessKeyID != "" || awsState.SecretAccessKey != "" {
+		return awsState, nil
+	}
+
+	profile := awsState.Profile
+	if profile == "" {
+		profile = defaultAWSProfile
+	}
+
+	accessKeyID, secretAccessKey, err := awsProfileCredentials(profile)
+	if err != nil {
+		if awsState.Profile == "" {
+			return awsState, nil
+		}
+		return storage.AWS{}, fmt.Errorf("failed to read AWS credentials for profile %q: %s", profile, err)
+	}
+
+	awsState.Profile = profile
+	awsState.AccessKeyID = accessKeyID
+	awsState.SecretAccessKey = secretAccessKey
+
+	return awsState, nil
+}
+
+// validateAWSFlags allows the access key and secret to be left out together,
+// the aws clients then use the default credential chain of the sdk.
 func validateAWSFlags(awsFlags storage.AWS) error {
-	if awsFlags.AccessKeyID == "" {
+	if awsFlags.AccessKeyID == "" && awsFlags.SecretAccessKey != "" {
 		return errors.New("AWS access key ID must be provided")
 	}
-	if awsFlags.SecretAccessKey == "" {
+	if awsFlags.SecretAccessKey == "" && awsFlags.AccessKeyID != "" {
 		return errors.New("AWS secret access key must be provided")
 	}
 	if awsFlags.Region == "" {
@@ -189,10 +545,9 @@ func validateAWSFlags(awsFlags storage.AWS) error {
 	return nil
 }
 
+// validateGCPFlags does not require a service account key, the gcp clients
+// use the application default credentials without one.
 func validateGCPFlags(gcpFlags storage.GCP) error {
-	if gcpFlags.ServiceAccountKey == "" {
-		return errors.New("GCP service account key must be provided")
-	}
 	if gcpFlags.ProjectID == "" {
 		return errors.New("GCP project ID must be provided")
 	}
@@ -212,11 +567,50 @@ func validateAzureFlags(azureFlags storage.Azure) error {
 	if azureFlags.TenantID == "" {
 		return errors.New("Azure tenant id must be provided")
 	}
-	if azureFlags.ClientID == "" {
-		return errors.New("Azure client id must be provided")
+	switch azureFlags.AuthMethod {
+	case "", "client-secret":
+		if azureFlags.ClientID == "" {
+			return errors.New("Azure client id must be provided")
+		}
+		if azureFlags.ClientSecret == "" {
+			return errors.New("Azure client secret must be provided")
+		}
+	case "cli", "managed-identity":
+		// the az cli or the vm's managed identity provide the credentials, the
+		// client id only selects a user-assigned managed identity.
+	default:
+		return fmt.Errorf("Azure auth method must be client-secret, cli or managed-identity, not %q", azureFlags.AuthMethod)
+	}
+	if azureFlags.Region == "" {
+		return errors.New("Azure region must be provided")
+	}
+	return nil
+}
+
+func validateOpenStackFlags(openstackFlags storage.OpenStack) error {
+	if openstackFlags.AuthURL == "" {
+		return errors.New("OpenStack auth url must be provided")
+	}
+	if openstackFlags.AZ == "" {
+		return errors.New("OpenStack availability zone must be provided")
+	}
+	if openstackFlags.ExternalNetwork == "" {
+		return errors.New("OpenStack external network must be provided")
+	}
+	if openstackFlags.Region == "" {
+		return errors.New("OpenStack region must be provided")
+	}
+	if openstackFlags.Username == "" {
+		return errors.New("OpenStack username must be provided")
+	}
+	if openstackFlags.Password == "" {
+		return errors.New("OpenStack password must be provided")
+	}
+	if openstackFlags.Domain == "" {
+		return errors.New("OpenStack domain must be provided")
 	}
-	if azureFlags.ClientSecret == "" {
-		return errors.New("Azure client secret must be provided")
+	if openstackFlags.Project == "" {
+		return errors.New("OpenStack project must be provided")
 	}
 	return nil
 }
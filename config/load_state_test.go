@@ -2,10 +2,16 @@ package config_test
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/cloudfoundry/bosh-bootloader/config"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -13,14 +19,30 @@ import (
 )
 
 var _ = Describe("InitializeState", func() {
-	var c config.Config
+	var (
+		c           config.Config
+		pullState   func(storage.StateBackend, string) error
+		stateLocker *fakes.StateLocker
+	)
 
 	BeforeEach(func() {
 		getState := func(string) (storage.State, error) {
 			return storage.State{}, nil
 		}
-		c = config.NewConfig(getState)
+		pullState = func(storage.StateBackend, string) error {
+			return nil
+		}
+		stateLocker = &fakes.StateLocker{}
+		c = config.NewConfig(getState, pullState, stateLocker.Lock)
 		os.Clearenv()
+
+		config.SetAWSProfileCredentials(func(string) (string, string, error) {
+			return "", "", errors.New("no shared credentials file")
+		})
+	})
+
+	AfterEach(func() {
+		config.ResetAWSProfileCredentials()
 	})
 
 	Context("using AWS", func() {
@@ -70,6 +92,14 @@ var _ = Describe("InitializeState", func() {
 								"--debug",
 								"--version",
 								"--state-dir", "some-state-dir",
+								"--terraform-plugin-dir", "some-plugin-dir",
+								"--terraform-cache-dir", "some-cache-dir",
+								"--offline",
+								"--artifacts-dir", "some-artifacts-dir",
+								"--timeout", "2h",
+								"--terraform-timeout", "30m",
+								"--create-env-timeout", "45m",
+								"--bosh-task-timeout", "10m",
 							}, args[1:]...)
 						})
 
@@ -82,6 +112,17 @@ var _ = Describe("InitializeState", func() {
 							Expect(parsedFlags.Debug).To(BeTrue())
 							Expect(parsedFlags.Version).To(BeTrue())
 							Expect(parsedFlags.StateDir).To(Equal("some-state-dir"))
+							Expect(parsedFlags.TerraformPluginDir).To(Equal("some-plugin-dir"))
+							Expect(parsedFlags.TerraformCacheDir).To(Equal("some-cache-dir"))
+							Expect(parsedFlags.Offline).To(BeTrue())
+							Expect(parsedFlags.ArtifactsDir).To(Equal("some-artifacts-dir"))
+
+							Expect(parsedFlags.TerraformTimeout.Limit).To(Equal(30 * time.Minute))
+							Expect(parsedFlags.CreateEnvTimeout.Limit).To(Equal(45 * time.Minute))
+							Expect(parsedFlags.BOSHTaskTimeout.Limit).To(Equal(10 * time.Minute))
+							Expect(parsedFlags.TerraformTimeout.Deadline).To(BeTemporally("~", time.Now().Add(2*time.Hour), time.Minute))
+							Expect(parsedFlags.CreateEnvTimeout.Deadline).To(Equal(parsedFlags.TerraformTimeout.Deadline))
+							Expect(parsedFlags.BOSHTaskTimeout.Deadline).To(Equal(parsedFlags.TerraformTimeout.Deadline))
 						})
 					})
 				})
@@ -104,6 +145,177 @@ var _ = Describe("InitializeState", func() {
 				)
 			})
 
+			Context("when the credentials come from the shared credentials file", func() {
+				var profileArg string
+
+				BeforeEach(func() {
+					profileArg = ""
+					config.SetAWSProfileCredentials(func(profile string) (string, string, error) {
+						profileArg = profile
+						return "profile-access-key-id", "profile-secret-key", nil
+					})
+				})
+
+				It("reads the credentials of the profile passed by flag", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl", "up",
+						"--iaas", "aws",
+						"--aws-profile", "some-profile",
+						"--aws-region", "some-region",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(profileArg).To(Equal("some-profile"))
+					Expect(parsedFlags.State.AWS).To(Equal(storage.AWS{
+						AccessKeyID:     "profile-access-key-id",
+						SecretAccessKey: "profile-secret-key",
+						Region:          "some-region",
+						Profile:         "some-profile",
+					}))
+				})
+
+				It("reads the profile from AWS_PROFILE", func() {
+					os.Setenv("AWS_PROFILE", "env-profile")
+
+					parsedFlags, err := c.Bootstrap([]string{"bbl", "up", "--iaas", "aws", "--aws-region", "some-region"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(profileArg).To(Equal("env-profile"))
+					Expect(parsedFlags.State.AWS.Profile).To(Equal("env-profile"))
+				})
+
+				It("falls back to the default profile when no credentials are provided", func() {
+					parsedFlags, err := c.Bootstrap([]string{"bbl", "up", "--iaas", "aws", "--aws-region", "some-region"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(profileArg).To(Equal("default"))
+					Expect(parsedFlags.State.AWS.AccessKeyID).To(Equal("profile-access-key-id"))
+					Expect(parsedFlags.State.AWS.Profile).To(Equal("default"))
+				})
+
+				It("prefers explicit credentials over the profile", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl", "up",
+						"--iaas", "aws",
+						"--aws-profile", "some-profile",
+						"--aws-access-key-id", "some-access-key",
+						"--aws-secret-access-key", "some-secret-key",
+						"--aws-region", "some-region",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(profileArg).To(BeEmpty())
+					Expect(parsedFlags.State.AWS.AccessKeyID).To(Equal("some-access-key"))
+					Expect(parsedFlags.State.AWS.Profile).To(BeEmpty())
+				})
+
+				Context("when the profile cannot be read", func() {
+					It("returns an error", func() {
+						config.SetAWSProfileCredentials(func(string) (string, string, error) {
+							return "", "", errors.New("profile not found")
+						})
+
+						_, err := c.Bootstrap([]string{
+							"bbl", "up",
+							"--iaas", "aws",
+							"--aws-profile", "missing-profile",
+							"--aws-region", "some-region",
+						})
+						Expect(err).To(MatchError(`failed to read AWS credentials for profile "missing-profile": profile not found`))
+					})
+				})
+
+				Context("when a role is assumed", func() {
+					var (
+						assumedState storage.AWS
+						mfaToken     string
+					)
+
+					BeforeEach(func() {
+						config.SetAWSAssumeRole(func(awsState storage.AWS, token string) (credentials.Value, error) {
+							assumedState = awsState
+							mfaToken = token
+							return credentials.Value{
+								AccessKeyID:     "temporary-access-key-id",
+								SecretAccessKey: "temporary-secret-key",
+								SessionToken:    "some-session-token",
+							}, nil
+						})
+					})
+
+					AfterEach(func() {
+						config.ResetAWSAssumeRole()
+					})
+
+					It("replaces the credentials with the temporary credentials of the role", func() {
+						parsedFlags, err := c.Bootstrap([]string{
+							"bbl", "up",
+							"--iaas", "aws",
+							"--aws-profile", "some-profile",
+							"--aws-region", "some-region",
+							"--aws-assume-role-arn", "arn:aws:iam::123456789012:role/bbl",
+							"--aws-assume-role-external-id", "some-external-id",
+							"--aws-mfa-serial", "arn:aws:iam::123456789012:mfa/some-user",
+							"--aws-mfa-token", "123456",
+						})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(assumedState.AccessKeyID).To(Equal("profile-access-key-id"))
+						Expect(mfaToken).To(Equal("123456"))
+						Expect(parsedFlags.State.AWS).To(Equal(storage.AWS{
+							AccessKeyID:          "temporary-access-key-id",
+							SecretAccessKey:      "temporary-secret-key",
+							SessionToken:         "some-session-token",
+							Region:               "some-region",
+							Profile:              "some-profile",
+							AssumeRoleARN:        "arn:aws:iam::123456789012:role/bbl",
+							AssumeRoleExternalID: "some-external-id",
+							MFASerial:            "arn:aws:iam::123456789012:mfa/some-user",
+						}))
+					})
+
+					It("returns an error when the mfa token is missing", func() {
+						_, err := c.Bootstrap([]string{
+							"bbl", "up",
+							"--iaas", "aws",
+							"--aws-region", "some-region",
+							"--aws-assume-role-arn", "arn:aws:iam::123456789012:role/bbl",
+							"--aws-mfa-serial", "arn:aws:iam::123456789012:mfa/some-user",
+						})
+						Expect(err).To(MatchError("--aws-mfa-token must be provided to assume arn:aws:iam::123456789012:role/bbl with MFA device arn:aws:iam::123456789012:mfa/some-user"))
+					})
+
+					It("returns an error when the role cannot be assumed", func() {
+						config.SetAWSAssumeRole(func(storage.AWS, string) (credentials.Value, error) {
+							return credentials.Value{}, errors.New("AccessDenied")
+						})
+
+						_, err := c.Bootstrap([]string{
+							"bbl", "up",
+							"--iaas", "aws",
+							"--aws-region", "some-region",
+							"--aws-assume-role-arn", "arn:aws:iam::123456789012:role/bbl",
+						})
+						Expect(err).To(MatchError("failed to assume AWS role arn:aws:iam::123456789012:role/bbl: AccessDenied"))
+					})
+				})
+
+				Context("when there are no credentials and no default profile", func() {
+					It("leaves the credentials to the default credential chain", func() {
+						config.SetAWSProfileCredentials(func(string) (string, string, error) {
+							return "", "", errors.New("profile not found")
+						})
+
+						parsedFlags, err := c.Bootstrap([]string{"bbl", "up", "--iaas", "aws", "--aws-region", "some-region"})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(parsedFlags.State.AWS).To(Equal(storage.AWS{
+							Region: "some-region",
+						}))
+					})
+				})
+			})
+
 			Context("when configuration is passed in by env vars", func() {
 				Context("when configuration is valid", func() {
 					var args []string
@@ -150,10 +362,14 @@ var _ = Describe("InitializeState", func() {
 					Context("when configuration includes global flags", func() {
 						BeforeEach(func() {
 							os.Setenv("BBL_DEBUG", "true")
+							os.Setenv("BBL_TERRAFORM_PLUGIN_DIR", "some-plugin-dir")
+							os.Setenv("BBL_TERRAFORM_CACHE_DIR", "some-cache-dir")
 						})
 
 						AfterEach(func() {
 							os.Unsetenv("BBL_DEBUG")
+							os.Unsetenv("BBL_TERRAFORM_PLUGIN_DIR")
+							os.Unsetenv("BBL_TERRAFORM_CACHE_DIR")
 						})
 
 						It("returns global flags", func() {
@@ -162,6 +378,8 @@ var _ = Describe("InitializeState", func() {
 							Expect(err).NotTo(HaveOccurred())
 
 							Expect(parsedFlags.Debug).To(BeTrue())
+							Expect(parsedFlags.TerraformPluginDir).To(Equal("some-plugin-dir"))
+							Expect(parsedFlags.TerraformCacheDir).To(Equal("some-cache-dir"))
 						})
 					})
 				})
@@ -185,7 +403,7 @@ var _ = Describe("InitializeState", func() {
 						EnvID: "some-env-id",
 					}, nil
 				}
-				c = config.NewConfig(getState)
+				c = config.NewConfig(getState, pullState, stateLocker.Lock)
 			})
 
 			Context("when no configuration is passed in", func() {
@@ -246,12 +464,415 @@ var _ = Describe("InitializeState", func() {
 					"The region cannot be changed for an existing environment. The current region is some-region."),
 			)
 
+			Context("when a secret store is passed in", func() {
+				It("records the secret store in the state", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--secret-store", "encrypted-file",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.State.SecretStore).To(Equal("encrypted-file"))
+				})
+
+				It("returns an error for an unknown secret store", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--secret-store", "some-secret-store",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError(`--secret-store must be "inline", "encrypted-file", "vault" or "credhub"`))
+				})
+
+				It("records the vault path in the state", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--secret-store", "vault",
+						"--vault-path", "secret/some-path",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.State.SecretStore).To(Equal("vault"))
+					Expect(parsedFlags.State.VaultPath).To(Equal("secret/some-path"))
+				})
+
+				It("returns an error for a vault path without the vault secret store", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--vault-path", "secret/some-path",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("--vault-path can only be used with --secret-store vault"))
+				})
+
+				It("records the credhub path in the state", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--secret-store", "credhub",
+						"--credhub-path", "/some/path",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.State.SecretStore).To(Equal("credhub"))
+					Expect(parsedFlags.State.CredHubPath).To(Equal("/some/path"))
+				})
+			})
+
+			Context("when a proxy port is passed in", func() {
+				It("records the proxy port in the state", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--proxy-port", "1080",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.State.Jumpbox.ProxyPort).To(Equal(1080))
+				})
+
+				It("returns an error for a port out of range", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--proxy-port", "70000",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("--proxy-port must be between 1 and 65535"))
+				})
+			})
+
+			Context("when a log level is passed in", func() {
+				It("returns the log level", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--log-level", "warn",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.LogLevel).To(Equal("warn"))
+				})
+
+				It("defaults to info", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.LogLevel).To(Equal("info"))
+				})
+
+				It("returns an error for an unknown log level", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--log-level", "verbose",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError(`--log-level must be one of "error", "warn", "info" or "debug"`))
+				})
+			})
+
+			Context("when a log format is passed in", func() {
+				It("returns the log format", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--log-format", "json",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.LogFormat).To(Equal("json"))
+				})
+
+				It("defaults to text", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.LogFormat).To(Equal("text"))
+				})
+
+				It("returns an error for an unknown log format", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--log-format", "logfmt",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError(`--log-format must be "text" or "json"`))
+				})
+			})
+
+			Context("when --dry-run is passed in", func() {
+				It("returns dry run as true", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--dry-run",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.DryRun).To(BeTrue())
+				})
+
+				It("defaults to false", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.DryRun).To(BeFalse())
+				})
+			})
+
+			Context("when --json is passed in", func() {
+				It("returns json as true", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"director-address",
+						"--json",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.JSON).To(BeTrue())
+					Expect(parsedFlags.RemainingArgs).To(Equal([]string{"director-address"}))
+				})
+
+				It("returns json as true for commands that do not need a state", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--json",
+						"version",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.JSON).To(BeTrue())
+				})
+			})
+
+			Context("when --metrics-file is passed in", func() {
+				It("returns the metrics file path", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--metrics-file", "/var/lib/node_exporter/bbl.prom",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.MetricsFile).To(Equal("/var/lib/node_exporter/bbl.prom"))
+				})
+			})
+
+			Context("when the number of state backups is passed in", func() {
+				It("returns the number of state backups", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--state-backups", "10",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.StateBackups).To(Equal(10))
+				})
+
+				It("defaults to 5", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.StateBackups).To(Equal(5))
+				})
+
+				It("returns an error when the number is negative", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--state-backups", "-1",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("--state-backups must not be negative"))
+				})
+			})
+
+			Context("when timeouts are passed in", func() {
+				It("does not bound anything without them", func() {
+					parsedFlags, err := c.Bootstrap([]string{"bbl", "create-lbs"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.TerraformTimeout).To(Equal(helpers.Timeout{}))
+					Expect(parsedFlags.CreateEnvTimeout).To(Equal(helpers.Timeout{}))
+					Expect(parsedFlags.BOSHTaskTimeout).To(Equal(helpers.Timeout{}))
+				})
+
+				It("returns an error when a timeout is negative", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--create-env-timeout", "-1m",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("--create-env-timeout must not be negative"))
+				})
+			})
+
+			Context("when a state bucket and key are passed in", func() {
+				var (
+					getState           func(string) (storage.State, error)
+					pullStateCallCount int
+					pullStateDir       string
+				)
+
+				BeforeEach(func() {
+					getState = func(string) (storage.State, error) {
+						return storage.State{
+							IAAS: "aws",
+							AWS: storage.AWS{
+								AccessKeyID:     "some-access-key-id",
+								SecretAccessKey: "some-secret-access-key",
+								Region:          "some-region",
+							},
+						}, nil
+					}
+					pullStateCallCount = 0
+					pullState = func(backend storage.StateBackend, dir string) error {
+						pullStateCallCount++
+						pullStateDir = dir
+						return nil
+					}
+					c = config.NewConfig(getState, pullState, stateLocker.Lock)
+				})
+
+				It("downloads the state before loading it and returns the backend", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--state-dir", "some-state-dir",
+						"--state-bucket", "some-bucket",
+						"--state-key", "some-key",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(pullStateCallCount).To(Equal(1))
+					Expect(pullStateDir).To(Equal("some-state-dir"))
+					Expect(parsedFlags.StateBackend).To(BeAssignableToTypeOf(&storage.S3StateBackend{}))
+				})
+
+				It("reads the bucket and key from the environment", func() {
+					os.Setenv("BBL_STATE_BUCKET", "some-bucket")
+					os.Setenv("BBL_STATE_KEY", "some-key")
+
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(pullStateCallCount).To(Equal(1))
+					Expect(parsedFlags.StateBackend).NotTo(BeNil())
+				})
+
+				It("records the bucket and key in the state", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--state-bucket", "some-bucket",
+						"--state-key", "some-key",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(parsedFlags.State.StateBucket).To(Equal("some-bucket"))
+					Expect(parsedFlags.State.StateKey).To(Equal("some-key"))
+				})
+
+				It("keeps using the bucket and key recorded in the state", func() {
+					getState = func(string) (storage.State, error) {
+						return storage.State{
+							IAAS: "aws",
+							AWS: storage.AWS{
+								AccessKeyID:     "some-access-key-id",
+								SecretAccessKey: "some-secret-access-key",
+								Region:          "some-region",
+							},
+							StateBucket: "some-bucket",
+							StateKey:    "some-key",
+						}, nil
+					}
+					c = config.NewConfig(getState, pullState, stateLocker.Lock)
+
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"--state-dir", "some-state-dir",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(pullStateCallCount).To(Equal(1))
+					Expect(pullStateDir).To(Equal("some-state-dir"))
+					Expect(parsedFlags.StateBackend).NotTo(BeNil())
+				})
+
+				It("does not use a backend when no bucket is passed in", func() {
+					parsedFlags, err := c.Bootstrap([]string{
+						"bbl",
+						"create-lbs",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(pullStateCallCount).To(Equal(0))
+					Expect(parsedFlags.StateBackend).To(BeNil())
+				})
+
+				It("returns an error when only one of bucket and key is passed in", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--state-bucket", "some-bucket",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("--state-bucket and --state-key must be provided together"))
+				})
+
+				It("returns an error when only one of offline and artifacts dir is passed in", func() {
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--offline",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("--offline and --artifacts-dir must be provided together"))
+
+					_, err = c.Bootstrap([]string{
+						"bbl",
+						"--artifacts-dir", "some-artifacts-dir",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("--offline and --artifacts-dir must be provided together"))
+				})
+
+				It("returns an error when the state cannot be downloaded", func() {
+					c = config.NewConfig(getState, func(storage.StateBackend, string) error {
+						return errors.New("access denied")
+					}, stateLocker.Lock)
+
+					_, err := c.Bootstrap([]string{
+						"bbl",
+						"--state-bucket", "some-bucket",
+						"--state-key", "some-key",
+						"create-lbs",
+					})
+					Expect(err).To(MatchError("failed to download the state from s3://some-bucket/some-key: access denied"))
+				})
+			})
+
 			Context("when invalid state dir is passed in", func() {
 				BeforeEach(func() {
 					getState := func(string) (storage.State, error) {
 						return storage.State{}, errors.New("some state dir error")
 					}
-					c = config.NewConfig(getState)
+					c = config.NewConfig(getState, pullState, stateLocker.Lock)
 					os.Clearenv()
 				})
 
@@ -281,6 +902,88 @@ var _ = Describe("InitializeState", func() {
 		})
 	})
 
+	Context("using a config file", func() {
+		var stateDir string
+
+		BeforeEach(func() {
+			var err error
+			stateDir, err = ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(stateDir, "bbl.yml"), []byte(`
+iaas: aws
+aws-access-key-id: some-access-key
+aws-secret-access-key: some-secret-key
+aws-region: some-region
+up:
+  name: some-env-id
+  ops-file: [some-ops-file.yml, other-ops-file.yml]
+  credhub: true
+create-lbs:
+  type: cf
+`), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("uses the global flags and the flags of the command from bbl.yml in the state dir", func() {
+			parsedFlags, err := c.Bootstrap([]string{"bbl", "--state-dir", stateDir, "up"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(parsedFlags.State.IAAS).To(Equal("aws"))
+			Expect(parsedFlags.State.AWS.AccessKeyID).To(Equal("some-access-key"))
+			Expect(parsedFlags.State.AWS.Region).To(Equal("some-region"))
+			Expect(parsedFlags.RemainingArgs).To(Equal([]string{
+				"up",
+				"--credhub",
+				"--name", "some-env-id",
+				"--ops-file", "some-ops-file.yml",
+				"--ops-file", "other-ops-file.yml",
+			}))
+		})
+
+		It("lets the command line flags win", func() {
+			parsedFlags, err := c.Bootstrap([]string{"bbl", "--state-dir", stateDir, "--aws-access-key-id", "other-access-key", "up", "--name", "other-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(parsedFlags.State.AWS.AccessKeyID).To(Equal("other-access-key"))
+			Expect(parsedFlags.RemainingArgs[len(parsedFlags.RemainingArgs)-2:]).To(Equal([]string{"--name", "other-env-id"}))
+		})
+
+		It("lets the environment variables win over the global flags", func() {
+			os.Setenv("BBL_AWS_REGION", "other-region")
+
+			parsedFlags, err := c.Bootstrap([]string{"bbl", "--state-dir", stateDir, "up"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(parsedFlags.State.AWS.Region).To(Equal("other-region"))
+		})
+
+		It("reads bbl.json, or the file passed with --config", func() {
+			configPath := filepath.Join(stateDir, "ci.json")
+			err := ioutil.WriteFile(configPath, []byte(`{"iaas": "aws", "aws-region": "json-region", "create-lbs": {"type": "concourse"}}`), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			parsedFlags, err := c.Bootstrap([]string{"bbl", "--config", configPath, "create-lbs"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(parsedFlags.State.AWS.Region).To(Equal("json-region"))
+			Expect(parsedFlags.RemainingArgs).To(Equal([]string{"create-lbs", "--type", "concourse"}))
+		})
+
+		It("returns an error for an unknown global flag", func() {
+			err := ioutil.WriteFile(filepath.Join(stateDir, "bbl.yml"), []byte("aws-regoin: some-region\n"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.Bootstrap([]string{"bbl", "--state-dir", stateDir, "up"})
+			Expect(err).To(MatchError(fmt.Sprintf(`unknown flag "aws-regoin" in the config file %s`, filepath.Join(stateDir, "bbl.yml"))))
+		})
+
+		It("returns an error when the config file cannot be read", func() {
+			_, err := c.Bootstrap([]string{"bbl", "--config", filepath.Join(stateDir, "missing.yml"), "up"})
+			Expect(err).To(MatchError(ContainSubstring("failed to read the config file")))
+		})
+	})
+
 	Context("using GCP", func() {
 		var (
 			serviceAccountKeyPath string
@@ -333,6 +1036,15 @@ var _ = Describe("InitializeState", func() {
 						Expect(parsedFlags.RemainingArgs).To(Equal([]string{"up", "--name", "some-env-id"}))
 					})
 
+					It("records the impersonated service account", func() {
+						args = append(args, "--gcp-impersonate-service-account", "some-env@some-project-id.iam.gserviceaccount.com")
+
+						parsedFlags, err := c.Bootstrap(args)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(parsedFlags.State.GCP.ImpersonateServiceAccount).To(Equal("some-env@some-project-id.iam.gserviceaccount.com"))
+					})
+
 					Context("when service account key is passed inline", func() {
 						var args []string
 
@@ -443,10 +1155,12 @@ var _ = Describe("InitializeState", func() {
 							}
 						})
 
-						It("returns an error", func() {
-							_, err := c.Bootstrap(args)
+						It("leaves the credentials to the application default credentials", func() {
+							parsedFlags, err := c.Bootstrap(args)
+							Expect(err).NotTo(HaveOccurred())
 
-							Expect(err).To(MatchError(ContainSubstring("GCP service account key must be provided")))
+							Expect(parsedFlags.State.GCP.ServiceAccountKey).To(BeEmpty())
+							Expect(parsedFlags.State.GCP.ProjectID).To(Equal("some-project-id"))
 						})
 					})
 
@@ -579,7 +1293,7 @@ var _ = Describe("InitializeState", func() {
 						EnvID: "some-env-id",
 					}, nil
 				}
-				c = config.NewConfig(getState)
+				c = config.NewConfig(getState, pullState, stateLocker.Lock)
 			})
 
 			Context("when no configuration is passed in", func() {
@@ -657,6 +1371,7 @@ var _ = Describe("InitializeState", func() {
 							"--azure-tenant-id", "tenant-id",
 							"--azure-client-id", "client-id",
 							"--azure-client-secret", "client-secret",
+							"--azure-region", "some-region",
 						}
 					})
 
@@ -671,6 +1386,7 @@ var _ = Describe("InitializeState", func() {
 						Expect(state.Azure.TenantID).To(Equal("tenant-id"))
 						Expect(state.Azure.ClientID).To(Equal("client-id"))
 						Expect(state.Azure.ClientSecret).To(Equal("client-secret"))
+						Expect(state.Azure.Region).To(Equal("some-region"))
 					})
 
 					It("returns the remaining arguments", func() {
@@ -705,6 +1421,22 @@ var _ = Describe("InitializeState", func() {
 					})
 				})
 
+				Context("when the az cli authenticates", func() {
+					It("does not need a client id or secret", func() {
+						parsedFlags, err := c.Bootstrap([]string{
+							"bbl", "up", "--name", "some-env-id",
+							"--iaas", "azure",
+							"--azure-subscription-id", "subscription-id",
+							"--azure-tenant-id", "tenant-id",
+							"--azure-auth-method", "cli",
+							"--azure-region", "some-region",
+						})
+
+						Expect(err).NotTo(HaveOccurred())
+						Expect(parsedFlags.State.Azure.AuthMethod).To(Equal("cli"))
+					})
+				})
+
 				Context("when configuration is invalid", func() {
 					var args []string
 
@@ -716,6 +1448,7 @@ var _ = Describe("InitializeState", func() {
 								"--azure-tenant-id", "tenant-id",
 								"--azure-client-id", "client-id",
 								"--azure-client-secret", "client-secret",
+								"--azure-region", "some-region",
 							}
 						})
 
@@ -734,6 +1467,7 @@ var _ = Describe("InitializeState", func() {
 								"--azure-subscription-id", "subscription-id",
 								"--azure-client-id", "client-id",
 								"--azure-client-secret", "client-secret",
+								"--azure-region", "some-region",
 							}
 						})
 
@@ -752,6 +1486,7 @@ var _ = Describe("InitializeState", func() {
 								"--azure-subscription-id", "subscription-id",
 								"--azure-tenant-id", "tenant-id",
 								"--azure-client-secret", "client-secret",
+								"--azure-region", "some-region",
 							}
 						})
 
@@ -770,6 +1505,7 @@ var _ = Describe("InitializeState", func() {
 								"--azure-subscription-id", "subscription-id",
 								"--azure-tenant-id", "tenant-id",
 								"--azure-client-id", "client-id",
+								"--azure-region", "some-region",
 							}
 						})
 
@@ -779,6 +1515,40 @@ var _ = Describe("InitializeState", func() {
 							Expect(err).To(MatchError(ContainSubstring("Azure client secret must be provided")))
 						})
 					})
+
+					Context("when region is missing", func() {
+						BeforeEach(func() {
+							args = []string{
+								"bbl", "up", "--name", "some-env-id",
+								"--iaas", "azure",
+								"--azure-subscription-id", "subscription-id",
+								"--azure-tenant-id", "tenant-id",
+								"--azure-client-id", "client-id",
+								"--azure-client-secret", "client-secret",
+							}
+						})
+
+						It("returns an error", func() {
+							_, err := c.Bootstrap(args)
+
+							Expect(err).To(MatchError(ContainSubstring("Azure region must be provided")))
+						})
+					})
+
+					Context("when the auth method is unknown", func() {
+						It("returns an error", func() {
+							_, err := c.Bootstrap([]string{
+								"bbl", "up", "--name", "some-env-id",
+								"--iaas", "azure",
+								"--azure-subscription-id", "subscription-id",
+								"--azure-tenant-id", "tenant-id",
+								"--azure-auth-method", "password",
+								"--azure-region", "some-region",
+							})
+
+							Expect(err).To(MatchError(`Azure auth method must be client-secret, cli or managed-identity, not "password"`))
+						})
+					})
 				})
 			})
 
@@ -793,6 +1563,7 @@ var _ = Describe("InitializeState", func() {
 					os.Setenv("BBL_AZURE_TENANT_ID", "azure-tenant-id")
 					os.Setenv("BBL_AZURE_CLIENT_ID", "azure-client-id")
 					os.Setenv("BBL_AZURE_CLIENT_SECRET", "azure-client-secret")
+					os.Setenv("BBL_AZURE_REGION", "azure-region")
 				})
 
 				It("returns a state containing configuration", func() {
@@ -807,6 +1578,7 @@ var _ = Describe("InitializeState", func() {
 					Expect(state.Azure.TenantID).To(Equal("azure-tenant-id"))
 					Expect(state.Azure.ClientID).To(Equal("azure-client-id"))
 					Expect(state.Azure.ClientSecret).To(Equal("azure-client-secret"))
+					Expect(state.Azure.Region).To(Equal("azure-region"))
 				})
 
 				It("returns the remaining arguments", func() {
@@ -851,11 +1623,12 @@ var _ = Describe("InitializeState", func() {
 							TenantID:       "tenant-id",
 							ClientID:       "client-id",
 							ClientSecret:   "client-secret",
+							Region:         "some-region",
 						},
 						EnvID: "some-env-id",
 					}, nil
 				}
-				c = config.NewConfig(getState)
+				c = config.NewConfig(getState, pullState, stateLocker.Lock)
 			})
 
 			Context("when no configuration is passed in", func() {
@@ -898,6 +1671,7 @@ var _ = Describe("InitializeState", func() {
 						"--azure-tenant-id", "tenant-id",
 						"--azure-client-id", "client-id",
 						"--azure-client-secret", "client-secret",
+						"--azure-region", "some-region",
 					})
 					Expect(err).NotTo(HaveOccurred())
 
@@ -913,10 +1687,152 @@ var _ = Describe("InitializeState", func() {
 				},
 				Entry("returns an error for non-matching IAAS", []string{"bbl", "create-lbs", "--iaas", "aws"},
 					"The iaas type cannot be changed for an existing environment. The current iaas type is azure."),
+				Entry("returns an error for non-matching region", []string{"bbl", "create-lbs", "--azure-region", "some-other-region"},
+					"The region cannot be changed for an existing environment. The current region is some-region."),
 			)
 		})
 	})
 
+	Context("using OpenStack", func() {
+		var args []string
+
+		BeforeEach(func() {
+			args = []string{
+				"bbl", "up",
+				"--iaas", "openstack",
+				"--openstack-auth-url", "some-auth-url",
+				"--openstack-az", "some-az",
+				"--openstack-external-network", "some-external-network",
+				"--openstack-region", "some-region",
+				"--openstack-username", "some-username",
+				"--openstack-password", "some-password",
+				"--openstack-domain", "some-domain",
+				"--openstack-project", "some-project",
+			}
+		})
+
+		Context("when configuration is passed in by flag", func() {
+			It("returns a state object containing configuration flags", func() {
+				parsedFlags, err := c.Bootstrap(args)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(parsedFlags.State.IAAS).To(Equal("openstack"))
+				Expect(parsedFlags.State.OpenStack).To(Equal(storage.OpenStack{
+					AuthURL:         "some-auth-url",
+					AZ:              "some-az",
+					ExternalNetwork: "some-external-network",
+					Region:          "some-region",
+					Username:        "some-username",
+					Password:        "some-password",
+					Domain:          "some-domain",
+					Project:         "some-project",
+				}))
+				Expect(parsedFlags.RemainingArgs).To(Equal([]string{"up"}))
+			})
+		})
+
+		Context("when configuration is passed in by env vars", func() {
+			var envVars = map[string]string{
+				"BBL_IAAS":                       "openstack",
+				"BBL_OPENSTACK_AUTH_URL":         "env-auth-url",
+				"BBL_OPENSTACK_AZ":               "env-az",
+				"BBL_OPENSTACK_EXTERNAL_NETWORK": "env-external-network",
+				"BBL_OPENSTACK_REGION":           "env-region",
+				"BBL_OPENSTACK_USERNAME":         "env-username",
+				"BBL_OPENSTACK_PASSWORD":         "env-password",
+				"BBL_OPENSTACK_DOMAIN":           "env-domain",
+				"BBL_OPENSTACK_PROJECT":          "env-project",
+			}
+
+			BeforeEach(func() {
+				for name, value := range envVars {
+					os.Setenv(name, value)
+				}
+			})
+
+			AfterEach(func() {
+				for name := range envVars {
+					os.Unsetenv(name)
+				}
+			})
+
+			It("returns a state containing configuration", func() {
+				parsedFlags, err := c.Bootstrap([]string{"bbl", "up"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(parsedFlags.State.IAAS).To(Equal("openstack"))
+				Expect(parsedFlags.State.OpenStack).To(Equal(storage.OpenStack{
+					AuthURL:         "env-auth-url",
+					AZ:              "env-az",
+					ExternalNetwork: "env-external-network",
+					Region:          "env-region",
+					Username:        "env-username",
+					Password:        "env-password",
+					Domain:          "env-domain",
+					Project:         "env-project",
+				}))
+			})
+		})
+
+		DescribeTable("when configuration is invalid",
+			func(missingFlag, expected string) {
+				var incompleteArgs []string
+				for i := 0; i < len(args); i++ {
+					if args[i] == missingFlag {
+						i++
+						continue
+					}
+					incompleteArgs = append(incompleteArgs, args[i])
+				}
+
+				_, err := c.Bootstrap(incompleteArgs)
+				Expect(err).To(MatchError(expected))
+			},
+			Entry("when auth url is missing", "--openstack-auth-url", "OpenStack auth url must be provided"),
+			Entry("when az is missing", "--openstack-az", "OpenStack availability zone must be provided"),
+			Entry("when external network is missing", "--openstack-external-network", "OpenStack external network must be provided"),
+			Entry("when region is missing", "--openstack-region", "OpenStack region must be provided"),
+			Entry("when username is missing", "--openstack-username", "OpenStack username must be provided"),
+			Entry("when password is missing", "--openstack-password", "OpenStack password must be provided"),
+			Entry("when domain is missing", "--openstack-domain", "OpenStack domain must be provided"),
+			Entry("when project is missing", "--openstack-project", "OpenStack project must be provided"),
+		)
+
+		Context("when a previous state exists", func() {
+			BeforeEach(func() {
+				c = config.NewConfig(func(dir string) (storage.State, error) {
+					return storage.State{
+						IAAS: "openstack",
+						OpenStack: storage.OpenStack{
+							AuthURL:         "some-auth-url",
+							AZ:              "some-az",
+							ExternalNetwork: "some-external-network",
+							Region:          "some-region",
+							Username:        "some-username",
+							Password:        "some-password",
+							Domain:          "some-domain",
+							Project:         "some-project",
+						},
+						EnvID: "some-env-id",
+					}, nil
+				}, pullState, stateLocker.Lock)
+			})
+
+			It("returns state with existing configuration", func() {
+				parsedFlags, err := c.Bootstrap([]string{"bbl", "create-lbs"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(parsedFlags.State.EnvID).To(Equal("some-env-id"))
+				Expect(parsedFlags.State.OpenStack.AuthURL).To(Equal("some-auth-url"))
+			})
+
+			It("returns an error for a non-matching region", func() {
+				_, err := c.Bootstrap([]string{"bbl", "create-lbs", "--openstack-region", "some-other-region"})
+				Expect(err).To(MatchError("The region cannot be changed for an existing environment. The current region is some-region."))
+			})
+		})
+	})
+
 	DescribeTable("when IAAS is not set",
 		func(args []string, expectError bool, expected string) {
 			_, err := c.Bootstrap(args)
@@ -934,9 +1850,9 @@ var _ = Describe("InitializeState", func() {
 				"--aws-secret-access-key", "some-secret-key",
 				"--aws-region", "some-region",
 			},
-			true, "--iaas [gcp, aws, azure] must be provided or BBL_IAAS must be set"),
+			true, "--iaas [gcp, aws, azure, openstack] must be provided or BBL_IAAS must be set"),
 		Entry("when IAAS is unsupported", []string{"bbl", "up", "--iaas", "not-a-real-iaas"}, true,
-			"--iaas [gcp, aws, azure] must be provided or BBL_IAAS must be set"),
+			"--iaas [gcp, aws, azure, openstack] must be provided or BBL_IAAS must be set"),
 		Entry("when help flag is set", []string{"bbl", "up", "--help"}, false, ""),
 		Entry("when help command is used", []string{"bbl", "help"}, false, ""),
 		Entry("when no command is used", []string{"bbl"}, false, ""),
@@ -944,4 +1860,73 @@ var _ = Describe("InitializeState", func() {
 		Entry("when version command is used", []string{"bbl", "version"}, false, ""),
 		// Entry("when invalid flag is passed", []string{"bbl", "--foo", "bar"}, true, "flag provided but not defined: -foo"),
 	)
+	Describe("locking the state dir", func() {
+		var (
+			stateDir     string
+			lockedOnLoad bool
+		)
+
+		BeforeEach(func() {
+			var err error
+			stateDir, err = ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			lockedOnLoad = false
+			getState := func(string) (storage.State, error) {
+				lockedOnLoad = stateLocker.LockCall.CallCount == 1
+				return storage.State{IAAS: "gcp", GCP: storage.GCP{
+					ServiceAccountKey: "some-service-account-key",
+					ProjectID:         "some-project-id",
+					Zone:              "some-zone",
+					Region:            "some-region",
+				}}, nil
+			}
+			c = config.NewConfig(getState, pullState, stateLocker.Lock)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(stateDir)
+		})
+
+		It("locks the state dir before loading the state for a command that changes it", func() {
+			parsedFlags, err := c.Bootstrap([]string{"bbl", "--state-dir", stateDir, "update-lbs"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateLocker.LockCall.CallCount).To(Equal(1))
+			Expect(stateLocker.LockCall.Receives.Dir).To(Equal(stateDir))
+			Expect(lockedOnLoad).To(BeTrue())
+
+			parsedFlags.Unlock()
+			Expect(stateLocker.UnlockCall.CallCount).To(Equal(1))
+		})
+
+		DescribeTable("does not lock the state dir",
+			func(args []string) {
+				parsedFlags, err := c.Bootstrap(append([]string{"bbl", "--state-dir", stateDir}, args...))
+				Expect(err).NotTo(HaveOccurred())
+
+				parsedFlags.Unlock()
+				Expect(stateLocker.LockCall.CallCount).To(Equal(0))
+			},
+			Entry("for a command that only reads the state", []string{"lbs"}),
+			Entry("for a dry run", []string{"--dry-run", "up"}),
+			Entry("for the usage of a command", []string{"up", "--help"}),
+		)
+
+		It("returns the error of the lock without loading the state", func() {
+			stateLocker.LockCall.Returns.Error = storage.StateLockedError{PID: 1234}
+
+			_, err := c.Bootstrap([]string{"bbl", "--state-dir", stateDir, "restore-state"})
+			Expect(err).To(Equal(storage.StateLockedError{PID: 1234}))
+			Expect(lockedOnLoad).To(BeFalse())
+		})
+
+		It("releases the lock when the flags are invalid", func() {
+			_, err := c.Bootstrap([]string{"bbl", "--state-dir", stateDir, "--iaas", "aws", "up"})
+			Expect(err).To(HaveOccurred())
+
+			Expect(stateLocker.LockCall.CallCount).To(Equal(1))
+			Expect(stateLocker.UnlockCall.CallCount).To(Equal(1))
+		})
+	})
 })
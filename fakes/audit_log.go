@@ -0,0 +1,18 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type AuditLog struct {
+	RecordsCall struct {
+		CallCount int
+		Returns   struct {
+			Records []storage.AuditRecord
+			Error   error
+		}
+	}
+}
+
+func (a *AuditLog) Records() ([]storage.AuditRecord, error) {
+	a.RecordsCall.CallCount++
+	return a.RecordsCall.Returns.Records, a.RecordsCall.Returns.Error
+}
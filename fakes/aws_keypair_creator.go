@@ -10,11 +10,15 @@ type KeyPairCreator struct {
 		}
 		Receives struct {
 			KeyPairName string
+			KeyType     string
+			Bits        int
 		}
 	}
 }
 
-func (k *KeyPairCreator) Create(keyPairName string) (ec2.KeyPair, error) {
+func (k *KeyPairCreator) Create(keyPairName, keyType string, bits int) (ec2.KeyPair, error) {
 	k.CreateCall.Receives.KeyPairName = keyPairName
+	k.CreateCall.Receives.KeyType = keyType
+	k.CreateCall.Receives.Bits = bits
 	return k.CreateCall.Returns.KeyPair, k.CreateCall.Returns.Error
 }
@@ -0,0 +1,24 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/aws/pricing"
+
+type AWSPricer struct {
+	OnDemandPriceCall struct {
+		CallCount int
+		Receives  struct {
+			Region   string
+			Products []pricing.Product
+		}
+		Returns struct {
+			Price float64
+			Error error
+		}
+	}
+}
+
+func (a *AWSPricer) OnDemandPrice(region string, product pricing.Product) (float64, error) {
+	a.OnDemandPriceCall.CallCount++
+	a.OnDemandPriceCall.Receives.Region = region
+	a.OnDemandPriceCall.Receives.Products = append(a.OnDemandPriceCall.Receives.Products, product)
+	return a.OnDemandPriceCall.Returns.Price, a.OnDemandPriceCall.Returns.Error
+}
@@ -0,0 +1,21 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/aws/ec2"
+
+type AWSQuotaChecker struct {
+	CheckCall struct {
+		CallCount int
+		Receives  struct {
+			Environment ec2.QuotaEnvironment
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (q *AWSQuotaChecker) Check(environment ec2.QuotaEnvironment) error {
+	q.CheckCall.CallCount++
+	q.CheckCall.Receives.Environment = environment
+	return q.CheckCall.Returns.Error
+}
@@ -0,0 +1,29 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/aws/servicequotas"
+
+type AWSServiceQuotas struct {
+	QuotaCall struct {
+		CallCount int
+		Receives  []AWSServiceQuotasQuotaReceives
+		Returns   struct {
+			Quotas map[string]servicequotas.Quota
+			Error  error
+		}
+	}
+}
+
+type AWSServiceQuotasQuotaReceives struct {
+	ServiceCode string
+	QuotaCode   string
+}
+
+func (s *AWSServiceQuotas) Quota(serviceCode, quotaCode string) (servicequotas.Quota, error) {
+	s.QuotaCall.CallCount++
+	s.QuotaCall.Receives = append(s.QuotaCall.Receives, AWSServiceQuotasQuotaReceives{
+		ServiceCode: serviceCode,
+		QuotaCode:   quotaCode,
+	})
+
+	return s.QuotaCall.Returns.Quotas[quotaCode], s.QuotaCall.Returns.Error
+}
@@ -8,6 +8,7 @@ type AzureClient struct {
 			TenantID       string
 			ClientID       string
 			ClientSecret   string
+			AuthMethod     string
 		}
 		Returns struct {
 			Error error
@@ -15,11 +16,12 @@ type AzureClient struct {
 	}
 }
 
-func (a *AzureClient) ValidateCredentials(subscriptionID, tenantID, clientID, clientSecret string) error {
+func (a *AzureClient) ValidateCredentials(subscriptionID, tenantID, clientID, clientSecret, authMethod string) error {
 	a.ValidateCredentialsCall.CallCount++
 	a.ValidateCredentialsCall.Receives.SubscriptionID = subscriptionID
 	a.ValidateCredentialsCall.Receives.TenantID = tenantID
 	a.ValidateCredentialsCall.Receives.ClientID = clientID
 	a.ValidateCredentialsCall.Receives.ClientSecret = clientSecret
+	a.ValidateCredentialsCall.Receives.AuthMethod = authMethod
 	return a.ValidateCredentialsCall.Returns.Error
 }
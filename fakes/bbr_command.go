@@ -0,0 +1,29 @@
+package fakes
+
+type BBRCommand struct {
+	RunCall struct {
+		CallCount int
+		Stub      func(env []string, workingDirectory string, args []string) error
+		Receives  struct {
+			Env              []string
+			WorkingDirectory string
+			Args             []string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (b *BBRCommand) Run(env []string, workingDirectory string, args []string) error {
+	b.RunCall.CallCount++
+	b.RunCall.Receives.Env = env
+	b.RunCall.Receives.WorkingDirectory = workingDirectory
+	b.RunCall.Receives.Args = args
+
+	if b.RunCall.Stub != nil {
+		return b.RunCall.Stub(env, workingDirectory, args)
+	}
+
+	return b.RunCall.Returns.Error
+}
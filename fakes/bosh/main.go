@@ -28,6 +28,10 @@ func main() {
 		fmt.Printf("working directory: %s\n", dir)
 		fmt.Printf("bosh %s/n", removeBrackets(fmt.Sprintf("%+v", os.Args)))
 	}
+
+	if os.Args[1] == "interpolate" {
+		fmt.Println("director_password: some-interpolated-secret")
+	}
 }
 
 func postArgsToBackendServer(command string, args []string) {
@@ -1,11 +1,21 @@
 package fakes
 
 import (
+	"io/ioutil"
+
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"golang.org/x/net/proxy"
 )
 
 type BOSHClient struct {
+	CloudConfigCall struct {
+		CallCount int
+		Returns   struct {
+			CloudConfig string
+			Error       error
+		}
+	}
+
 	UpdateCloudConfigCall struct {
 		CallCount int
 		Receives  struct {
@@ -30,6 +40,49 @@ type BOSHClient struct {
 			Error error
 		}
 	}
+
+	DeploymentsCall struct {
+		CallCount int
+		Returns   struct {
+			Deployments []bosh.Deployment
+			Error       error
+		}
+	}
+
+	StemcellsCall struct {
+		CallCount int
+		Stub      func() ([]bosh.Stemcell, error)
+		Returns   struct {
+			Stemcells []bosh.Stemcell
+			Error     error
+		}
+	}
+
+	UploadStemcellCall struct {
+		CallCount int
+		Receives  struct {
+			Stemcell bosh.SizeReader
+			Contents []byte
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	UploadStemcellURLCall struct {
+		CallCount int
+		Receives  struct {
+			StemcellURL string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (c *BOSHClient) CloudConfig() (string, error) {
+	c.CloudConfigCall.CallCount++
+	return c.CloudConfigCall.Returns.CloudConfig, c.CloudConfigCall.Returns.Error
 }
 
 func (c *BOSHClient) UpdateCloudConfig(yaml []byte) error {
@@ -47,3 +100,29 @@ func (c *BOSHClient) Info() (bosh.Info, error) {
 	c.InfoCall.CallCount++
 	return c.InfoCall.Returns.Info, c.InfoCall.Returns.Error
 }
+
+func (c *BOSHClient) Deployments() ([]bosh.Deployment, error) {
+	c.DeploymentsCall.CallCount++
+	return c.DeploymentsCall.Returns.Deployments, c.DeploymentsCall.Returns.Error
+}
+
+func (c *BOSHClient) Stemcells() ([]bosh.Stemcell, error) {
+	c.StemcellsCall.CallCount++
+	if c.StemcellsCall.Stub != nil {
+		return c.StemcellsCall.Stub()
+	}
+	return c.StemcellsCall.Returns.Stemcells, c.StemcellsCall.Returns.Error
+}
+
+func (c *BOSHClient) UploadStemcell(stemcell bosh.SizeReader) error {
+	c.UploadStemcellCall.CallCount++
+	c.UploadStemcellCall.Receives.Stemcell = stemcell
+	c.UploadStemcellCall.Receives.Contents, _ = ioutil.ReadAll(stemcell)
+	return c.UploadStemcellCall.Returns.Error
+}
+
+func (c *BOSHClient) UploadStemcellURL(stemcellURL string) error {
+	c.UploadStemcellURLCall.CallCount++
+	c.UploadStemcellURLCall.Receives.StemcellURL = stemcellURL
+	return c.UploadStemcellURLCall.Returns.Error
+}
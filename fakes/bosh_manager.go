@@ -42,6 +42,17 @@ type BOSHManager struct {
 			Error error
 		}
 	}
+	RecreateJumpboxCall struct {
+		CallCount int
+		Receives  struct {
+			State            storage.State
+			TerraformOutputs map[string]interface{}
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
 	DeleteJumpboxCall struct {
 		CallCount int
 		Receives  struct {
@@ -63,6 +74,17 @@ type BOSHManager struct {
 			Error error
 		}
 	}
+	DirectorManifestCall struct {
+		CallCount int
+		Receives  struct {
+			State            storage.State
+			TerraformOutputs map[string]interface{}
+		}
+		Returns struct {
+			Manifest string
+			Error    error
+		}
+	}
 	GetJumpboxDeploymentVarsCall struct {
 		CallCount int
 		Receives  struct {
@@ -84,6 +106,13 @@ func (b *BOSHManager) CreateJumpbox(state storage.State, terraformOutputs map[st
 	return state, b.CreateJumpboxCall.Returns.Error
 }
 
+func (b *BOSHManager) RecreateJumpbox(state storage.State, terraformOutputs map[string]interface{}) (storage.State, error) {
+	b.RecreateJumpboxCall.CallCount++
+	b.RecreateJumpboxCall.Receives.State = state
+	b.RecreateJumpboxCall.Receives.TerraformOutputs = terraformOutputs
+	return b.RecreateJumpboxCall.Returns.State, b.RecreateJumpboxCall.Returns.Error
+}
+
 func (b *BOSHManager) CreateDirector(state storage.State, terraformOutputs map[string]interface{}) (storage.State, error) {
 	b.CreateDirectorCall.CallCount++
 	b.CreateDirectorCall.Receives.State = state
@@ -113,6 +142,13 @@ func (b *BOSHManager) GetDeploymentVars(state storage.State, terraformOutputs ma
 	return b.GetDeploymentVarsCall.Returns.Vars, b.GetDeploymentVarsCall.Returns.Error
 }
 
+func (b *BOSHManager) DirectorManifest(state storage.State, terraformOutputs map[string]interface{}) (string, error) {
+	b.DirectorManifestCall.CallCount++
+	b.DirectorManifestCall.Receives.State = state
+	b.DirectorManifestCall.Receives.TerraformOutputs = terraformOutputs
+	return b.DirectorManifestCall.Returns.Manifest, b.DirectorManifestCall.Returns.Error
+}
+
 func (b *BOSHManager) GetJumpboxDeploymentVars(state storage.State, terraformOutputs map[string]interface{}) (string, error) {
 	b.GetJumpboxDeploymentVarsCall.CallCount++
 	b.GetJumpboxDeploymentVarsCall.Receives.State = state
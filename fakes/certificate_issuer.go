@@ -0,0 +1,33 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/certs"
+
+type CertificateIssuer struct {
+	IssueCall struct {
+		CallCount int
+		Records   []certs.ChallengeRecord
+		Receives  struct {
+			Account certs.ACMEAccount
+			Domains []string
+		}
+		Returns struct {
+			Account     certs.ACMEAccount
+			Certificate certs.IssuedCertificate
+			Error       error
+		}
+	}
+}
+
+func (c *CertificateIssuer) Issue(account certs.ACMEAccount, domains []string, present func([]certs.ChallengeRecord) error) (certs.ACMEAccount, certs.IssuedCertificate, error) {
+	c.IssueCall.CallCount++
+	c.IssueCall.Receives.Account = account
+	c.IssueCall.Receives.Domains = domains
+
+	if len(c.IssueCall.Records) > 0 {
+		if err := present(c.IssueCall.Records); err != nil {
+			return c.IssueCall.Returns.Account, certs.IssuedCertificate{}, err
+		}
+	}
+
+	return c.IssueCall.Returns.Account, c.IssueCall.Returns.Certificate, c.IssueCall.Returns.Error
+}
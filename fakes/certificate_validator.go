@@ -1,10 +1,13 @@
 package fakes
 
+import "github.com/cloudfoundry/bosh-bootloader/certs"
+
 type CertificateValidator struct {
 	ValidateCall struct {
 		CallCount int
 		Returns   struct {
-			Error error
+			Certificate certs.Certificate
+			Error       error
 		}
 		Receives struct {
 			Command         string
@@ -15,11 +18,11 @@ type CertificateValidator struct {
 	}
 }
 
-func (c *CertificateValidator) Validate(command, certificatePath, keyPath, chainPath string) error {
+func (c *CertificateValidator) Validate(command, certificatePath, keyPath, chainPath string) (certs.Certificate, error) {
 	c.ValidateCall.CallCount++
 	c.ValidateCall.Receives.Command = command
 	c.ValidateCall.Receives.CertificatePath = certificatePath
 	c.ValidateCall.Receives.KeyPath = keyPath
 	c.ValidateCall.Receives.ChainPath = chainPath
-	return c.ValidateCall.Returns.Error
+	return c.ValidateCall.Returns.Certificate, c.ValidateCall.Returns.Error
 }
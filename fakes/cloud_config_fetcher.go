@@ -0,0 +1,22 @@
+package fakes
+
+type CloudConfigFetcher struct {
+	FetchCall struct {
+		CallCount int
+		Receives  struct {
+			URL      string
+			Checksum string
+		}
+		Returns struct {
+			CloudConfig string
+			Error       error
+		}
+	}
+}
+
+func (c *CloudConfigFetcher) Fetch(url, checksum string) (string, error) {
+	c.FetchCall.CallCount++
+	c.FetchCall.Receives.URL = url
+	c.FetchCall.Receives.Checksum = checksum
+	return c.FetchCall.Returns.CloudConfig, c.FetchCall.Returns.Error
+}
@@ -14,6 +14,37 @@ type CloudConfigManager struct {
 			Error error
 		}
 	}
+	ApplyCall struct {
+		CallCount int
+		Receives  struct {
+			State       storage.State
+			CloudConfig string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	UpdateIfChangedCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+			Force bool
+		}
+		Returns struct {
+			Updated bool
+			Error   error
+		}
+	}
+	CurrentCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			CloudConfig string
+			Error       error
+		}
+	}
 	GenerateCall struct {
 		CallCount int
 		Receives  struct {
@@ -37,3 +68,23 @@ func (c *CloudConfigManager) Generate(state storage.State) (string, error) {
 	c.GenerateCall.Receives.State = state
 	return c.GenerateCall.Returns.CloudConfig, c.GenerateCall.Returns.Error
 }
+
+func (c *CloudConfigManager) Apply(state storage.State, cloudConfig string) error {
+	c.ApplyCall.CallCount++
+	c.ApplyCall.Receives.State = state
+	c.ApplyCall.Receives.CloudConfig = cloudConfig
+	return c.ApplyCall.Returns.Error
+}
+
+func (c *CloudConfigManager) UpdateIfChanged(state storage.State, force bool) (bool, error) {
+	c.UpdateIfChangedCall.CallCount++
+	c.UpdateIfChangedCall.Receives.State = state
+	c.UpdateIfChangedCall.Receives.Force = force
+	return c.UpdateIfChangedCall.Returns.Updated, c.UpdateIfChangedCall.Returns.Error
+}
+
+func (c *CloudConfigManager) Current(state storage.State) (string, error) {
+	c.CurrentCall.CallCount++
+	c.CurrentCall.Receives.State = state
+	return c.CurrentCall.Returns.CloudConfig, c.CurrentCall.Returns.Error
+}
@@ -0,0 +1,23 @@
+package fakes
+
+type DeploymentFetcher struct {
+	PathCall struct {
+		CallCount int
+		Receives  struct {
+			Repo    string
+			Version string
+		}
+		Returns struct {
+			Path  string
+			Error error
+		}
+	}
+}
+
+func (f *DeploymentFetcher) Path(repo, version string) (string, error) {
+	f.PathCall.CallCount++
+	f.PathCall.Receives.Repo = repo
+	f.PathCall.Receives.Version = version
+
+	return f.PathCall.Returns.Path, f.PathCall.Returns.Error
+}
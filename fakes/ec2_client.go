@@ -73,6 +73,71 @@ type EC2Client struct {
 			Error  error
 		}
 	}
+
+	DescribeAddressesCall struct {
+		Receives struct {
+			Input *awsec2.DescribeAddressesInput
+		}
+		Returns struct {
+			Output *awsec2.DescribeAddressesOutput
+			Error  error
+		}
+	}
+
+	ReleaseAddressCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awsec2.ReleaseAddressInput
+		}
+		Returns struct {
+			Output *awsec2.ReleaseAddressOutput
+			Error  error
+		}
+	}
+
+	DescribeSecurityGroupsCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awsec2.DescribeSecurityGroupsInput
+		}
+		Returns struct {
+			Output *awsec2.DescribeSecurityGroupsOutput
+			Error  error
+		}
+	}
+
+	DeleteSecurityGroupCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awsec2.DeleteSecurityGroupInput
+		}
+		Returns struct {
+			Output *awsec2.DeleteSecurityGroupOutput
+			Error  error
+		}
+	}
+
+	DescribeNetworkInterfacesCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awsec2.DescribeNetworkInterfacesInput
+		}
+		Returns struct {
+			Output *awsec2.DescribeNetworkInterfacesOutput
+			Error  error
+		}
+	}
+
+	DescribeTagsCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awsec2.DescribeTagsInput
+		}
+		Returns struct {
+			Output *awsec2.DescribeTagsOutput
+			Error  error
+		}
+	}
 }
 
 func (c *EC2Client) ImportKeyPair(input *awsec2.ImportKeyPairInput) (*awsec2.ImportKeyPairOutput, error) {
@@ -116,3 +181,44 @@ func (c *EC2Client) DescribeVpcs(input *awsec2.DescribeVpcsInput) (*awsec2.Descr
 
 	return c.DescribeVpcsCall.Returns.Output, c.DescribeVpcsCall.Returns.Error
 }
+
+func (c *EC2Client) DescribeAddresses(input *awsec2.DescribeAddressesInput) (*awsec2.DescribeAddressesOutput, error) {
+	c.DescribeAddressesCall.Receives.Input = input
+
+	return c.DescribeAddressesCall.Returns.Output, c.DescribeAddressesCall.Returns.Error
+}
+
+func (c *EC2Client) ReleaseAddress(input *awsec2.ReleaseAddressInput) (*awsec2.ReleaseAddressOutput, error) {
+	c.ReleaseAddressCall.CallCount++
+	c.ReleaseAddressCall.Receives.Input = input
+
+	return c.ReleaseAddressCall.Returns.Output, c.ReleaseAddressCall.Returns.Error
+}
+
+func (c *EC2Client) DescribeSecurityGroups(input *awsec2.DescribeSecurityGroupsInput) (*awsec2.DescribeSecurityGroupsOutput, error) {
+	c.DescribeSecurityGroupsCall.CallCount++
+	c.DescribeSecurityGroupsCall.Receives.Input = input
+
+	return c.DescribeSecurityGroupsCall.Returns.Output, c.DescribeSecurityGroupsCall.Returns.Error
+}
+
+func (c *EC2Client) DeleteSecurityGroup(input *awsec2.DeleteSecurityGroupInput) (*awsec2.DeleteSecurityGroupOutput, error) {
+	c.DeleteSecurityGroupCall.CallCount++
+	c.DeleteSecurityGroupCall.Receives.Input = input
+
+	return c.DeleteSecurityGroupCall.Returns.Output, c.DeleteSecurityGroupCall.Returns.Error
+}
+
+func (c *EC2Client) DescribeNetworkInterfaces(input *awsec2.DescribeNetworkInterfacesInput) (*awsec2.DescribeNetworkInterfacesOutput, error) {
+	c.DescribeNetworkInterfacesCall.CallCount++
+	c.DescribeNetworkInterfacesCall.Receives.Input = input
+
+	return c.DescribeNetworkInterfacesCall.Returns.Output, c.DescribeNetworkInterfacesCall.Returns.Error
+}
+
+func (c *EC2Client) DescribeTags(input *awsec2.DescribeTagsInput) (*awsec2.DescribeTagsOutput, error) {
+	c.DescribeTagsCall.CallCount++
+	c.DescribeTagsCall.Receives.Input = input
+
+	return c.DescribeTagsCall.Returns.Output, c.DescribeTagsCall.Returns.Error
+}
@@ -1,6 +1,9 @@
 package fakes
 
-import compute "google.golang.org/api/compute/v1"
+import (
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	compute "google.golang.org/api/compute/v1"
+)
 
 type GCPClient struct {
 	ProjectIDCall struct {
@@ -73,6 +76,84 @@ type GCPClient struct {
 			Error       error
 		}
 	}
+	ListAllInstancesCall struct {
+		CallCount int
+		Returns   struct {
+			InstanceAggregatedList *compute.InstanceAggregatedList
+			Error                  error
+		}
+	}
+	ListAddressesCall struct {
+		CallCount int
+		Returns   struct {
+			AddressList *compute.AddressList
+			Error       error
+		}
+	}
+	DeleteAddressCall struct {
+		CallCount int
+		Receives  struct {
+			Names []string
+		}
+		Returns struct {
+			Operation *compute.Operation
+			Error     error
+		}
+	}
+	ListGlobalAddressesCall struct {
+		CallCount int
+		Returns   struct {
+			AddressList *compute.AddressList
+			Error       error
+		}
+	}
+	DeleteGlobalAddressCall struct {
+		CallCount int
+		Receives  struct {
+			Names []string
+		}
+		Returns struct {
+			Operation *compute.Operation
+			Error     error
+		}
+	}
+	ListFirewallsCall struct {
+		CallCount int
+		Returns   struct {
+			FirewallList *compute.FirewallList
+			Error        error
+		}
+	}
+	DeleteFirewallCall struct {
+		CallCount int
+		Receives  struct {
+			Names []string
+		}
+		Returns struct {
+			Operation *compute.Operation
+			Error     error
+		}
+	}
+	TestIamPermissionsCall struct {
+		CallCount int
+		Receives  struct {
+			Permissions []string
+		}
+		Returns struct {
+			Permissions []string
+			Error       error
+		}
+	}
+	ListSKUsCall struct {
+		CallCount int
+		Receives  struct {
+			ServiceID string
+		}
+		Returns struct {
+			SKUs  []gcp.SKU
+			Error error
+		}
+	}
 }
 
 func (g *GCPClient) ProjectID() string {
@@ -119,3 +200,53 @@ func (g *GCPClient) GetNetworks(name string) (*compute.NetworkList, error) {
 	g.GetNetworksCall.Receives.Name = name
 	return g.GetNetworksCall.Returns.NetworkList, g.GetNetworksCall.Returns.Error
 }
+
+func (g *GCPClient) ListAllInstances() (*compute.InstanceAggregatedList, error) {
+	g.ListAllInstancesCall.CallCount++
+	return g.ListAllInstancesCall.Returns.InstanceAggregatedList, g.ListAllInstancesCall.Returns.Error
+}
+
+func (g *GCPClient) ListAddresses() (*compute.AddressList, error) {
+	g.ListAddressesCall.CallCount++
+	return g.ListAddressesCall.Returns.AddressList, g.ListAddressesCall.Returns.Error
+}
+
+func (g *GCPClient) DeleteAddress(name string) (*compute.Operation, error) {
+	g.DeleteAddressCall.CallCount++
+	g.DeleteAddressCall.Receives.Names = append(g.DeleteAddressCall.Receives.Names, name)
+	return g.DeleteAddressCall.Returns.Operation, g.DeleteAddressCall.Returns.Error
+}
+
+func (g *GCPClient) ListGlobalAddresses() (*compute.AddressList, error) {
+	g.ListGlobalAddressesCall.CallCount++
+	return g.ListGlobalAddressesCall.Returns.AddressList, g.ListGlobalAddressesCall.Returns.Error
+}
+
+func (g *GCPClient) DeleteGlobalAddress(name string) (*compute.Operation, error) {
+	g.DeleteGlobalAddressCall.CallCount++
+	g.DeleteGlobalAddressCall.Receives.Names = append(g.DeleteGlobalAddressCall.Receives.Names, name)
+	return g.DeleteGlobalAddressCall.Returns.Operation, g.DeleteGlobalAddressCall.Returns.Error
+}
+
+func (g *GCPClient) ListFirewalls() (*compute.FirewallList, error) {
+	g.ListFirewallsCall.CallCount++
+	return g.ListFirewallsCall.Returns.FirewallList, g.ListFirewallsCall.Returns.Error
+}
+
+func (g *GCPClient) DeleteFirewall(name string) (*compute.Operation, error) {
+	g.DeleteFirewallCall.CallCount++
+	g.DeleteFirewallCall.Receives.Names = append(g.DeleteFirewallCall.Receives.Names, name)
+	return g.DeleteFirewallCall.Returns.Operation, g.DeleteFirewallCall.Returns.Error
+}
+
+func (g *GCPClient) TestIamPermissions(permissions []string) ([]string, error) {
+	g.TestIamPermissionsCall.CallCount++
+	g.TestIamPermissionsCall.Receives.Permissions = permissions
+	return g.TestIamPermissionsCall.Returns.Permissions, g.TestIamPermissionsCall.Returns.Error
+}
+
+func (g *GCPClient) ListSKUs(serviceID string) ([]gcp.SKU, error) {
+	g.ListSKUsCall.CallCount++
+	g.ListSKUsCall.Receives.ServiceID = serviceID
+	return g.ListSKUsCall.Returns.SKUs, g.ListSKUsCall.Returns.Error
+}
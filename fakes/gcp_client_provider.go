@@ -12,15 +12,23 @@ type GCPClientProvider struct {
 	SetConfigCall struct {
 		CallCount int
 		Receives  struct {
-			ServiceAccountKey string
-			ProjectID         string
-			Region            string
-			Zone              string
+			ServiceAccountKey         string
+			ProjectID                 string
+			Region                    string
+			Zone                      string
+			ImpersonateServiceAccount string
 		}
 		Returns struct {
 			Error error
 		}
 	}
+	AccessTokenCall struct {
+		CallCount int
+		Returns   struct {
+			AccessToken string
+			Error       error
+		}
+	}
 }
 
 func (g *GCPClientProvider) Client() gcp.GCPClient {
@@ -29,12 +37,19 @@ func (g *GCPClientProvider) Client() gcp.GCPClient {
 	return g.ClientCall.Returns.Client
 }
 
-func (g *GCPClientProvider) SetConfig(serviceAccountKey, projectID, region, zone string) error {
+func (g *GCPClientProvider) SetConfig(serviceAccountKey, projectID, region, zone, impersonateServiceAccount string) error {
 	g.SetConfigCall.CallCount++
 	g.SetConfigCall.Receives.ServiceAccountKey = serviceAccountKey
 	g.SetConfigCall.Receives.ProjectID = projectID
 	g.SetConfigCall.Receives.Region = region
 	g.SetConfigCall.Receives.Zone = zone
+	g.SetConfigCall.Receives.ImpersonateServiceAccount = impersonateServiceAccount
 
 	return g.SetConfigCall.Returns.Error
 }
+
+func (g *GCPClientProvider) AccessToken() (string, error) {
+	g.AccessTokenCall.CallCount++
+
+	return g.AccessTokenCall.Returns.AccessToken, g.AccessTokenCall.Returns.Error
+}
@@ -5,15 +5,21 @@ import "github.com/cloudfoundry/bosh-bootloader/storage"
 type GCPKeyPairUpdater struct {
 	UpdateCall struct {
 		CallCount int
-		Returns   struct {
+		Receives  struct {
+			KeyType string
+			Bits    int
+		}
+		Returns struct {
 			KeyPair storage.KeyPair
 			Error   error
 		}
 	}
 }
 
-func (g *GCPKeyPairUpdater) Update() (storage.KeyPair, error) {
+func (g *GCPKeyPairUpdater) Update(keyType string, bits int) (storage.KeyPair, error) {
 	g.UpdateCall.CallCount++
+	g.UpdateCall.Receives.KeyType = keyType
+	g.UpdateCall.Receives.Bits = bits
 
 	return g.UpdateCall.Returns.KeyPair, g.UpdateCall.Returns.Error
 }
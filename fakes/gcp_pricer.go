@@ -0,0 +1,24 @@
+package fakes
+
+type GCPPricer struct {
+	PriceCall struct {
+		CallCount int
+		Receives  struct {
+			ServiceIDs   []string
+			Descriptions []string
+			Regions      []string
+		}
+		Returns struct {
+			Price float64
+			Error error
+		}
+	}
+}
+
+func (g *GCPPricer) Price(serviceID, description, region string) (float64, error) {
+	g.PriceCall.CallCount++
+	g.PriceCall.Receives.ServiceIDs = append(g.PriceCall.Receives.ServiceIDs, serviceID)
+	g.PriceCall.Receives.Descriptions = append(g.PriceCall.Receives.Descriptions, description)
+	g.PriceCall.Receives.Regions = append(g.PriceCall.Receives.Regions, region)
+	return g.PriceCall.Returns.Price, g.PriceCall.Returns.Error
+}
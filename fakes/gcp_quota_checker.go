@@ -0,0 +1,25 @@
+package fakes
+
+type GCPQuotaChecker struct {
+	CheckCall struct {
+		CallCount int
+		Receives  struct {
+			Region         string
+			NoDirector     bool
+			Jumpbox        bool
+			DirectorVMType string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (q *GCPQuotaChecker) Check(region string, noDirector, jumpbox bool, directorVMType string) error {
+	q.CheckCall.CallCount++
+	q.CheckCall.Receives.Region = region
+	q.CheckCall.Receives.NoDirector = noDirector
+	q.CheckCall.Receives.Jumpbox = jumpbox
+	q.CheckCall.Receives.DirectorVMType = directorVMType
+	return q.CheckCall.Returns.Error
+}
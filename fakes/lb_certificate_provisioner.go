@@ -0,0 +1,38 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type LBCertificateProvisioner struct {
+	PlaceholderCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
+	ProvisionCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
+}
+
+func (p *LBCertificateProvisioner) Placeholder(state storage.State) (storage.State, error) {
+	p.PlaceholderCall.CallCount++
+	p.PlaceholderCall.Receives.State = state
+	return p.PlaceholderCall.Returns.State, p.PlaceholderCall.Returns.Error
+}
+
+func (p *LBCertificateProvisioner) Provision(state storage.State) (storage.State, error) {
+	p.ProvisionCall.CallCount++
+	p.ProvisionCall.Receives.State = state
+	return p.ProvisionCall.Returns.State, p.ProvisionCall.Returns.Error
+}
@@ -0,0 +1,40 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/leftovers"
+
+type LeftoversCleaner struct {
+	ListCall struct {
+		CallCount int
+		Receives  struct {
+			EnvID string
+		}
+		Returns struct {
+			Resources []leftovers.Resource
+			Error     error
+		}
+	}
+	DeleteCall struct {
+		CallCount int
+		Receives  struct {
+			Resources []leftovers.Resource
+		}
+		Stub func(leftovers.Resource) error
+	}
+}
+
+func (l *LeftoversCleaner) List(envID string) ([]leftovers.Resource, error) {
+	l.ListCall.CallCount++
+	l.ListCall.Receives.EnvID = envID
+	return l.ListCall.Returns.Resources, l.ListCall.Returns.Error
+}
+
+func (l *LeftoversCleaner) Delete(resource leftovers.Resource) error {
+	l.DeleteCall.CallCount++
+	l.DeleteCall.Receives.Resources = append(l.DeleteCall.Receives.Resources, resource)
+
+	if l.DeleteCall.Stub != nil {
+		return l.DeleteCall.Stub(resource)
+	}
+
+	return nil
+}
@@ -45,6 +45,24 @@ type Logger struct {
 			Message string
 		}
 	}
+
+	WarnCall struct {
+		CallCount int
+		Receives  struct {
+			Message   string
+			Arguments []interface{}
+		}
+		Messages []string
+	}
+
+	ErrorCall struct {
+		CallCount int
+		Receives  struct {
+			Message   string
+			Arguments []interface{}
+		}
+		Messages []string
+	}
 }
 
 func (l *Logger) Step(message string, a ...interface{}) {
@@ -55,6 +73,22 @@ func (l *Logger) Step(message string, a ...interface{}) {
 	l.StepCall.Messages = append(l.StepCall.Messages, fmt.Sprintf(message, a...))
 }
 
+func (l *Logger) Warn(message string, a ...interface{}) {
+	l.WarnCall.CallCount++
+	l.WarnCall.Receives.Message = message
+	l.WarnCall.Receives.Arguments = a
+
+	l.WarnCall.Messages = append(l.WarnCall.Messages, fmt.Sprintf(message, a...))
+}
+
+func (l *Logger) Error(message string, a ...interface{}) {
+	l.ErrorCall.CallCount++
+	l.ErrorCall.Receives.Message = message
+	l.ErrorCall.Receives.Arguments = a
+
+	l.ErrorCall.Messages = append(l.ErrorCall.Messages, fmt.Sprintf(message, a...))
+}
+
 func (l *Logger) Dot() {
 	l.DotCall.CallCount++
 }
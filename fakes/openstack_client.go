@@ -0,0 +1,29 @@
+package fakes
+
+type OpenStackClient struct {
+	ValidateCredentialsCall struct {
+		CallCount int
+		Receives  struct {
+			AuthURL  string
+			Username string
+			Password string
+			Domain   string
+			Project  string
+			Region   string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (o *OpenStackClient) ValidateCredentials(authURL, username, password, domain, project, region string) error {
+	o.ValidateCredentialsCall.CallCount++
+	o.ValidateCredentialsCall.Receives.AuthURL = authURL
+	o.ValidateCredentialsCall.Receives.Username = username
+	o.ValidateCredentialsCall.Receives.Password = password
+	o.ValidateCredentialsCall.Receives.Domain = domain
+	o.ValidateCredentialsCall.Receives.Project = project
+	o.ValidateCredentialsCall.Receives.Region = region
+	return o.ValidateCredentialsCall.Returns.Error
+}
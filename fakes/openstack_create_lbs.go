@@ -0,0 +1,27 @@
+package fakes
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type OpenStackCreateLBs struct {
+	Name        string
+	ExecuteCall struct {
+		CallCount int
+		Receives  struct {
+			Config commands.OpenStackCreateLBsConfig
+			State  storage.State
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (u *OpenStackCreateLBs) Execute(config commands.OpenStackCreateLBsConfig, state storage.State) error {
+	u.ExecuteCall.CallCount++
+	u.ExecuteCall.Receives.Config = config
+	u.ExecuteCall.Receives.State = state
+	return u.ExecuteCall.Returns.Error
+}
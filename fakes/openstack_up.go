@@ -0,0 +1,27 @@
+package fakes
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type OpenStackUp struct {
+	Name        string
+	ExecuteCall struct {
+		CallCount int
+		Receives  struct {
+			OpenStackUpConfig commands.OpenStackUpConfig
+			State             storage.State
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (u *OpenStackUp) Execute(openstackUpConfig commands.OpenStackUpConfig, state storage.State) error {
+	u.ExecuteCall.CallCount++
+	u.ExecuteCall.Receives.OpenStackUpConfig = openstackUpConfig
+	u.ExecuteCall.Receives.State = state
+	return u.ExecuteCall.Returns.Error
+}
@@ -0,0 +1,16 @@
+package fakes
+
+type PermissionChecker struct {
+	CheckCall struct {
+		CallCount int
+		Returns   struct {
+			Missing []string
+			Error   error
+		}
+	}
+}
+
+func (p *PermissionChecker) Check() ([]string, error) {
+	p.CheckCall.CallCount++
+	return p.CheckCall.Returns.Missing, p.CheckCall.Returns.Error
+}
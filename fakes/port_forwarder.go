@@ -0,0 +1,39 @@
+package fakes
+
+import "golang.org/x/net/proxy"
+
+type PortForwarder struct {
+	ForwardCall struct {
+		CallCount int
+		Receives  struct {
+			LocalPort int
+			Target    string
+			Dialer    proxy.Dialer
+		}
+		Returns struct {
+			Addr  string
+			Error error
+		}
+	}
+	CloseCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (p *PortForwarder) Forward(localPort int, target string, dialer proxy.Dialer) (string, error) {
+	p.ForwardCall.CallCount++
+	p.ForwardCall.Receives.LocalPort = localPort
+	p.ForwardCall.Receives.Target = target
+	p.ForwardCall.Receives.Dialer = dialer
+
+	return p.ForwardCall.Returns.Addr, p.ForwardCall.Returns.Error
+}
+
+func (p *PortForwarder) Close() error {
+	p.CloseCall.CallCount++
+
+	return p.CloseCall.Returns.Error
+}
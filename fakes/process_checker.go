@@ -0,0 +1,19 @@
+package fakes
+
+type ProcessChecker struct {
+	RunningCall struct {
+		CallCount int
+		Receives  struct {
+			PID int
+		}
+		Returns struct {
+			Running bool
+		}
+	}
+}
+
+func (p *ProcessChecker) Running(pid int) bool {
+	p.RunningCall.CallCount++
+	p.RunningCall.Receives.PID = pid
+	return p.RunningCall.Returns.Running
+}
@@ -0,0 +1,23 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/proxy"
+
+type SSHAgent struct {
+	AddCall struct {
+		CallCount int
+		Receives  struct {
+			Key string
+		}
+		Returns struct {
+			AgentEnv proxy.AgentEnv
+			Error    error
+		}
+	}
+}
+
+func (a *SSHAgent) Add(key string) (proxy.AgentEnv, error) {
+	a.AddCall.CallCount++
+	a.AddCall.Receives.Key = key
+
+	return a.AddCall.Returns.AgentEnv, a.AddCall.Returns.Error
+}
@@ -0,0 +1,24 @@
+package fakes
+
+type SSHKeyGenerator struct {
+	GenerateCall struct {
+		CallCount int
+		Receives  struct {
+			KeyType string
+			Bits    int
+		}
+		Returns struct {
+			PrivateKey string
+			PublicKey  string
+			Error      error
+		}
+	}
+}
+
+func (s *SSHKeyGenerator) Generate(keyType string, bits int) (string, string, error) {
+	s.GenerateCall.CallCount++
+	s.GenerateCall.Receives.KeyType = keyType
+	s.GenerateCall.Receives.Bits = bits
+
+	return s.GenerateCall.Returns.PrivateKey, s.GenerateCall.Returns.PublicKey, s.GenerateCall.Returns.Error
+}
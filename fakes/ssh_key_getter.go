@@ -13,6 +13,16 @@ type SSHKeyGetter struct {
 			Error      error
 		}
 	}
+	GetDirectorCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			PrivateKey string
+			Error      error
+		}
+	}
 }
 
 func (s *SSHKeyGetter) Get(state storage.State) (string, error) {
@@ -21,3 +31,10 @@ func (s *SSHKeyGetter) Get(state storage.State) (string, error) {
 
 	return s.GetCall.Returns.PrivateKey, s.GetCall.Returns.Error
 }
+
+func (s *SSHKeyGetter) GetDirector(state storage.State) (string, error) {
+	s.GetDirectorCall.CallCount++
+	s.GetDirectorCall.Receives.State = state
+
+	return s.GetDirectorCall.Returns.PrivateKey, s.GetDirectorCall.Returns.Error
+}
@@ -0,0 +1,44 @@
+package fakes
+
+type SSHShell struct {
+	JumpboxCall struct {
+		CallCount int
+		Receives  struct {
+			Key        string
+			JumpboxURL string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	DirectorCall struct {
+		CallCount int
+		Receives  struct {
+			JumpboxKey   string
+			JumpboxURL   string
+			DirectorKey  string
+			DirectorAddr string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *SSHShell) Jumpbox(key, jumpboxURL string) error {
+	s.JumpboxCall.CallCount++
+	s.JumpboxCall.Receives.Key = key
+	s.JumpboxCall.Receives.JumpboxURL = jumpboxURL
+
+	return s.JumpboxCall.Returns.Error
+}
+
+func (s *SSHShell) Director(jumpboxKey, jumpboxURL, directorKey, directorAddr string) error {
+	s.DirectorCall.CallCount++
+	s.DirectorCall.Receives.JumpboxKey = jumpboxKey
+	s.DirectorCall.Receives.JumpboxURL = jumpboxURL
+	s.DirectorCall.Receives.DirectorKey = directorKey
+	s.DirectorCall.Receives.DirectorAddr = directorAddr
+
+	return s.DirectorCall.Returns.Error
+}
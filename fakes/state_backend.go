@@ -0,0 +1,61 @@
+package fakes
+
+type StateBackend struct {
+	GetCall struct {
+		CallCount int
+		Stub      func(string) ([]byte, error)
+		Receives  struct {
+			Name string
+		}
+		Returns struct {
+			Contents []byte
+			Error    error
+		}
+	}
+
+	SetCall struct {
+		CallCount int
+		Receives  []StateBackendSetCallReceive
+		Returns   struct {
+			Error error
+		}
+	}
+
+	DeleteCall struct {
+		CallCount int
+		Receives  []string
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+type StateBackendSetCallReceive struct {
+	Name     string
+	Contents []byte
+}
+
+func (s *StateBackend) Get(name string) ([]byte, error) {
+	s.GetCall.CallCount++
+	s.GetCall.Receives.Name = name
+
+	if s.GetCall.Stub != nil {
+		return s.GetCall.Stub(name)
+	}
+
+	return s.GetCall.Returns.Contents, s.GetCall.Returns.Error
+}
+
+func (s *StateBackend) Set(name string, contents []byte) error {
+	s.SetCall.CallCount++
+	s.SetCall.Receives = append(s.SetCall.Receives, StateBackendSetCallReceive{Name: name, Contents: contents})
+
+	return s.SetCall.Returns.Error
+}
+
+func (s *StateBackend) Delete(name string) error {
+	s.DeleteCall.CallCount++
+	s.DeleteCall.Receives = append(s.DeleteCall.Receives, name)
+
+	return s.DeleteCall.Returns.Error
+}
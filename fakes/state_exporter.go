@@ -0,0 +1,20 @@
+package fakes
+
+type StateExporter struct {
+	ExportCall struct {
+		CallCount int
+		Receives  struct {
+			Key string
+		}
+		Returns struct {
+			Bundle []byte
+			Error  error
+		}
+	}
+}
+
+func (s *StateExporter) Export(key string) ([]byte, error) {
+	s.ExportCall.CallCount++
+	s.ExportCall.Receives.Key = key
+	return s.ExportCall.Returns.Bundle, s.ExportCall.Returns.Error
+}
@@ -0,0 +1,23 @@
+package fakes
+
+type StateImporter struct {
+	ImportCall struct {
+		CallCount int
+		Receives  struct {
+			Bundle  []byte
+			Key     string
+			Replace bool
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateImporter) Import(bundle []byte, key string, replace bool) error {
+	s.ImportCall.CallCount++
+	s.ImportCall.Receives.Bundle = bundle
+	s.ImportCall.Receives.Key = key
+	s.ImportCall.Receives.Replace = replace
+	return s.ImportCall.Returns.Error
+}
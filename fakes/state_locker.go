@@ -0,0 +1,28 @@
+package fakes
+
+type StateLocker struct {
+	LockCall struct {
+		CallCount int
+		Receives  struct {
+			Dir string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	UnlockCall struct {
+		CallCount int
+	}
+}
+
+func (s *StateLocker) Lock(dir string) (func(), error) {
+	s.LockCall.CallCount++
+	s.LockCall.Receives.Dir = dir
+
+	if s.LockCall.Returns.Error != nil {
+		return nil, s.LockCall.Returns.Error
+	}
+
+	return func() { s.UnlockCall.CallCount++ }, nil
+}
@@ -0,0 +1,32 @@
+package fakes
+
+type StateRestorer struct {
+	BackupsCall struct {
+		CallCount int
+		Returns   struct {
+			Backups []string
+			Error   error
+		}
+	}
+
+	RestoreCall struct {
+		CallCount int
+		Receives  struct {
+			Name string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateRestorer) Backups() ([]string, error) {
+	s.BackupsCall.CallCount++
+	return s.BackupsCall.Returns.Backups, s.BackupsCall.Returns.Error
+}
+
+func (s *StateRestorer) Restore(name string) error {
+	s.RestoreCall.CallCount++
+	s.RestoreCall.Receives.Name = name
+	return s.RestoreCall.Returns.Error
+}
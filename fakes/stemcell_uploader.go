@@ -0,0 +1,24 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StemcellUploader struct {
+	UploadCall struct {
+		CallCount int
+		Receives  struct {
+			State  storage.State
+			Source string
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
+}
+
+func (s *StemcellUploader) Upload(state storage.State, source string) (storage.State, error) {
+	s.UploadCall.CallCount++
+	s.UploadCall.Receives.State = state
+	s.UploadCall.Receives.Source = source
+	return s.UploadCall.Returns.State, s.UploadCall.Returns.Error
+}
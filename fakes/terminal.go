@@ -0,0 +1,39 @@
+package fakes
+
+type Terminal struct {
+	SizeCall struct {
+		CallCount int
+		Returns   struct {
+			Width  int
+			Height int
+			Error  error
+		}
+	}
+	MakeRawCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+	RestoreCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (t *Terminal) Size() (int, int, error) {
+	t.SizeCall.CallCount++
+
+	return t.SizeCall.Returns.Width, t.SizeCall.Returns.Height, t.SizeCall.Returns.Error
+}
+
+func (t *Terminal) MakeRaw() (func() error, error) {
+	t.MakeRawCall.CallCount++
+
+	return func() error {
+		t.RestoreCall.CallCount++
+		return t.RestoreCall.Returns.Error
+	}, t.MakeRawCall.Returns.Error
+}
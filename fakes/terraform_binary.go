@@ -0,0 +1,16 @@
+package fakes
+
+type TerraformBinary struct {
+	PathCall struct {
+		CallCount int
+		Returns   struct {
+			Path  string
+			Error error
+		}
+	}
+}
+
+func (t *TerraformBinary) Path() (string, error) {
+	t.PathCall.CallCount++
+	return t.PathCall.Returns.Path, t.PathCall.Returns.Error
+}
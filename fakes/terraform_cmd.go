@@ -18,6 +18,7 @@ type TerraformCmd struct {
 			WorkingDirectory string
 			Args             []string
 			Debug            bool
+			InitArgs         []string
 		}
 	}
 }
@@ -36,6 +37,7 @@ func (t *TerraformCmd) Run(stdout io.Writer, workingDirectory string, args []str
 		}
 	case "init":
 		t.RunCall.Initialized = true
+		t.RunCall.Receives.InitArgs = args
 	default:
 		if !t.RunCall.Initialized {
 			return errors.New("must initialize terraform v0.10.* before running any other commands")
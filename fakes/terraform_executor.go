@@ -14,6 +14,7 @@ type TerraformExecutor struct {
 			Inputs   map[string]string
 			Template string
 			TFState  string
+			Targets  []string
 		}
 		Returns struct {
 			TFState string
@@ -32,6 +33,44 @@ type TerraformExecutor struct {
 			Error   error
 		}
 	}
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs   map[string]string
+			Template string
+			TFState  string
+			Destroy  bool
+		}
+		Returns struct {
+			Plan  string
+			Error error
+		}
+	}
+	RefreshCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs   map[string]string
+			Template string
+			TFState  string
+		}
+		Returns struct {
+			TFState string
+			Error   error
+		}
+	}
+	DriftCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs   map[string]string
+			Template string
+			TFState  string
+		}
+		Returns struct {
+			Plan    string
+			Drifted bool
+			Error   error
+		}
+	}
 	ImportCall struct {
 		CallCount int
 		Receives  struct {
@@ -76,11 +115,12 @@ type TerraformExecutor struct {
 	}
 }
 
-func (t *TerraformExecutor) Apply(inputs map[string]string, template, tfState string) (string, error) {
+func (t *TerraformExecutor) Apply(inputs map[string]string, template, tfState string, targets []string) (string, error) {
 	t.ApplyCall.CallCount++
 	t.ApplyCall.Receives.Inputs = inputs
 	t.ApplyCall.Receives.Template = template
 	t.ApplyCall.Receives.TFState = tfState
+	t.ApplyCall.Receives.Targets = targets
 	return t.ApplyCall.Returns.TFState, t.ApplyCall.Returns.Error
 }
 
@@ -131,3 +171,28 @@ func (t *TerraformExecutor) Outputs(tfState string) (map[string]interface{}, err
 
 	return t.OutputsCall.Returns.Outputs, t.OutputsCall.Returns.Error
 }
+
+func (t *TerraformExecutor) Plan(inputs map[string]string, template, tfState string, destroy bool) (string, error) {
+	t.PlanCall.CallCount++
+	t.PlanCall.Receives.Inputs = inputs
+	t.PlanCall.Receives.Template = template
+	t.PlanCall.Receives.TFState = tfState
+	t.PlanCall.Receives.Destroy = destroy
+	return t.PlanCall.Returns.Plan, t.PlanCall.Returns.Error
+}
+
+func (t *TerraformExecutor) Drift(inputs map[string]string, template, tfState string) (string, bool, error) {
+	t.DriftCall.CallCount++
+	t.DriftCall.Receives.Inputs = inputs
+	t.DriftCall.Receives.Template = template
+	t.DriftCall.Receives.TFState = tfState
+	return t.DriftCall.Returns.Plan, t.DriftCall.Returns.Drifted, t.DriftCall.Returns.Error
+}
+
+func (t *TerraformExecutor) Refresh(inputs map[string]string, template, tfState string) (string, error) {
+	t.RefreshCall.CallCount++
+	t.RefreshCall.Receives.Inputs = inputs
+	t.RefreshCall.Receives.Template = template
+	t.RefreshCall.Receives.TFState = tfState
+	return t.RefreshCall.Returns.TFState, t.RefreshCall.Returns.Error
+}
@@ -7,6 +7,7 @@ import (
 type TerraformManager struct {
 	ApplyCall struct {
 		CallCount int
+		Stub      func(storage.State) (storage.State, error)
 		Receives  struct {
 			BBLState storage.State
 		}
@@ -15,8 +16,20 @@ type TerraformManager struct {
 			Error    error
 		}
 	}
+	ApplyTargetsCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+			Targets  []string
+		}
+		Returns struct {
+			BBLState storage.State
+			Error    error
+		}
+	}
 	DestroyCall struct {
 		CallCount int
+		Stub      func(storage.State) (storage.State, error)
 		Receives  struct {
 			BBLState storage.State
 		}
@@ -46,6 +59,66 @@ type TerraformManager struct {
 			Error   error
 		}
 	}
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Plan  string
+			Error error
+		}
+	}
+	RefreshCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			BBLState storage.State
+			Error    error
+		}
+	}
+	DriftCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Plan    string
+			Drifted bool
+			Error   error
+		}
+	}
+	TemplateCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Template string
+		}
+	}
+	PlanDestroyCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Plan  string
+			Error error
+		}
+	}
+	ResourcesCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Resources []string
+			Error     error
+		}
+	}
 	VersionCall struct {
 		CallCount int
 		Returns   struct {
@@ -65,16 +138,69 @@ func (t *TerraformManager) Apply(bblState storage.State) (storage.State, error)
 	t.ApplyCall.CallCount++
 	t.ApplyCall.Receives.BBLState = bblState
 
+	if t.ApplyCall.Stub != nil {
+		return t.ApplyCall.Stub(bblState)
+	}
+
 	return t.ApplyCall.Returns.BBLState, t.ApplyCall.Returns.Error
 }
 
+func (t *TerraformManager) ApplyTargets(bblState storage.State, targets []string) (storage.State, error) {
+	t.ApplyTargetsCall.CallCount++
+	t.ApplyTargetsCall.Receives.BBLState = bblState
+	t.ApplyTargetsCall.Receives.Targets = targets
+
+	return t.ApplyTargetsCall.Returns.BBLState, t.ApplyTargetsCall.Returns.Error
+}
+
 func (t *TerraformManager) Destroy(bblState storage.State) (storage.State, error) {
 	t.DestroyCall.CallCount++
 	t.DestroyCall.Receives.BBLState = bblState
 
+	if t.DestroyCall.Stub != nil {
+		return t.DestroyCall.Stub(bblState)
+	}
+
 	return t.DestroyCall.Returns.BBLState, t.DestroyCall.Returns.Error
 }
 
+func (t *TerraformManager) Plan(bblState storage.State) (string, error) {
+	t.PlanCall.CallCount++
+	t.PlanCall.Receives.BBLState = bblState
+	return t.PlanCall.Returns.Plan, t.PlanCall.Returns.Error
+}
+
+func (t *TerraformManager) Drift(bblState storage.State) (string, bool, error) {
+	t.DriftCall.CallCount++
+	t.DriftCall.Receives.BBLState = bblState
+	return t.DriftCall.Returns.Plan, t.DriftCall.Returns.Drifted, t.DriftCall.Returns.Error
+}
+
+func (t *TerraformManager) Refresh(bblState storage.State) (storage.State, error) {
+	t.RefreshCall.CallCount++
+	t.RefreshCall.Receives.BBLState = bblState
+	return t.RefreshCall.Returns.BBLState, t.RefreshCall.Returns.Error
+}
+
+func (t *TerraformManager) Template(bblState storage.State) string {
+	t.TemplateCall.CallCount++
+	t.TemplateCall.Receives.BBLState = bblState
+	return t.TemplateCall.Returns.Template
+}
+
+func (t *TerraformManager) PlanDestroy(bblState storage.State) (string, error) {
+	t.PlanDestroyCall.CallCount++
+	t.PlanDestroyCall.Receives.BBLState = bblState
+	return t.PlanDestroyCall.Returns.Plan, t.PlanDestroyCall.Returns.Error
+}
+
+func (t *TerraformManager) Resources(bblState storage.State) ([]string, error) {
+	t.ResourcesCall.CallCount++
+	t.ResourcesCall.Receives.BBLState = bblState
+
+	return t.ResourcesCall.Returns.Resources, t.ResourcesCall.Returns.Error
+}
+
 func (t *TerraformManager) Import(bblState storage.State, outputs map[string]string) (storage.State, error) {
 	t.ImportCall.CallCount++
 	t.ImportCall.Receives.BBLState = bblState
@@ -0,0 +1,28 @@
+package fakes
+
+type UpDetacher struct {
+	LogPathCall struct {
+		CallCount int
+		Returns   struct {
+			Path string
+		}
+	}
+
+	DetachCall struct {
+		CallCount int
+		Returns   struct {
+			PID   int
+			Error error
+		}
+	}
+}
+
+func (u *UpDetacher) LogPath() string {
+	u.LogPathCall.CallCount++
+	return u.LogPathCall.Returns.Path
+}
+
+func (u *UpDetacher) Detach() (int, error) {
+	u.DetachCall.CallCount++
+	return u.DetachCall.Returns.PID, u.DetachCall.Returns.Error
+}
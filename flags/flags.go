@@ -2,9 +2,31 @@ package flags
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
 )
 
+// lbCommands take generic flags like --cert and --domain, so their
+// environment variables are prefixed, BBL_LB_CERT for --cert.
+var lbCommands = map[string]bool{
+	"create-lbs":      true,
+	"update-lbs":      true,
+	"delete-lbs":      true,
+	"rotate-lb-certs": true,
+}
+
+// EnvName returns the environment variable that sets the flag of the command
+// when the flag is not passed, BBL_OPS_FILE for --ops-file.
+func EnvName(command, name string) string {
+	if lbCommands[command] && !strings.HasPrefix(name, "lb-") {
+		name = "lb-" + name
+	}
+
+	return "BBL_" + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
 type Flags struct {
 	set *flag.FlagSet
 }
@@ -30,10 +52,67 @@ func (f Flags) String(v *string, name string, value string) {
 	f.set.StringVar(v, name, value, "")
 }
 
+func (f Flags) Int(v *int, name string, value int) {
+	f.set.IntVar(v, name, value, "")
+}
+
+func (f Flags) Slice(v *[]string, name string) {
+	f.set.Var((*stringSlice)(v), name, "")
+}
+
+// Parse parses the args, then sets the flags that were not passed from their
+// environment variables. Repeated flags take a comma separated list.
 func (f Flags) Parse(args []string) error {
-	return f.set.Parse(args)
+	err := f.set.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	// the short and long names of a bool flag share a value.
+	passed := map[flag.Value]bool{}
+	f.set.Visit(func(fl *flag.Flag) {
+		passed[fl.Value] = true
+	})
+
+	f.set.VisitAll(func(fl *flag.Flag) {
+		if err != nil || passed[fl.Value] || len(fl.Name) < 2 {
+			return
+		}
+
+		envName := EnvName(f.set.Name(), fl.Name)
+		value, ok := os.LookupEnv(envName)
+		if !ok || value == "" {
+			return
+		}
+
+		values := []string{value}
+		if _, ok := fl.Value.(*stringSlice); ok {
+			values = strings.Split(value, ",")
+		}
+
+		for _, v := range values {
+			if setErr := fl.Value.Set(v); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %s", value, envName, setErr)
+				return
+			}
+		}
+		passed[fl.Value] = true
+	})
+
+	return err
 }
 
 func (f Flags) Args() []string {
 	return f.set.Args()
 }
+
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
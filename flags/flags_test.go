@@ -1,6 +1,8 @@
 package flags_test
 
 import (
+	"os"
+
 	"github.com/cloudfoundry/bosh-bootloader/flags"
 
 	. "github.com/onsi/ginkgo"
@@ -12,12 +14,17 @@ var _ = Describe("Flags", func() {
 		f         flags.Flags
 		boolVal   bool
 		stringVal string
+		sliceVal  []string
+		intVal    int
 	)
 
 	BeforeEach(func() {
 		f = flags.New("test")
 		f.Bool(&boolVal, "b", "bool", false)
 		f.String(&stringVal, "string", "")
+		sliceVal = nil
+		f.Slice(&sliceVal, "slice")
+		f.Int(&intVal, "int", 0)
 	})
 
 	Describe("Parse", func() {
@@ -49,6 +56,68 @@ var _ = Describe("Flags", func() {
 				Expect(stringVal).To(Equal("string_value"))
 			})
 		})
+
+		Context("Int flags", func() {
+			It("can parse int fields from flags", func() {
+				err := f.Parse([]string{"--int", "4096"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(intVal).To(Equal(4096))
+			})
+		})
+
+		Context("Slice flags", func() {
+			It("collects each occurrence of the flag", func() {
+				err := f.Parse([]string{"--slice", "first", "--slice", "second"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sliceVal).To(Equal([]string{"first", "second"}))
+			})
+		})
+	})
+
+	Describe("environment variables", func() {
+		AfterEach(func() {
+			os.Unsetenv("BBL_STRING")
+			os.Unsetenv("BBL_BOOL")
+			os.Unsetenv("BBL_SLICE")
+			os.Unsetenv("BBL_INT")
+		})
+
+		It("sets the flags that are not passed from BBL_ environment variables", func() {
+			os.Setenv("BBL_STRING", "env_value")
+			os.Setenv("BBL_BOOL", "true")
+			os.Setenv("BBL_SLICE", "first,second")
+			os.Setenv("BBL_INT", "2048")
+
+			err := f.Parse([]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stringVal).To(Equal("env_value"))
+			Expect(boolVal).To(BeTrue())
+			Expect(sliceVal).To(Equal([]string{"first", "second"}))
+			Expect(intVal).To(Equal(2048))
+		})
+
+		It("lets the passed flags win", func() {
+			os.Setenv("BBL_STRING", "env_value")
+			os.Setenv("BBL_BOOL", "false")
+
+			err := f.Parse([]string{"--string", "flag_value", "-b"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stringVal).To(Equal("flag_value"))
+			Expect(boolVal).To(BeTrue())
+		})
+
+		It("returns an error when the value is invalid", func() {
+			os.Setenv("BBL_INT", "many")
+
+			err := f.Parse([]string{})
+			Expect(err).To(MatchError(ContainSubstring(`invalid value "many" for BBL_INT`)))
+		})
+
+		It("prefixes the flags of the load balancer commands with LB", func() {
+			Expect(flags.EnvName("create-lbs", "cert")).To(Equal("BBL_LB_CERT"))
+			Expect(flags.EnvName("create-lbs", "lb-flavor")).To(Equal("BBL_LB_FLAVOR"))
+			Expect(flags.EnvName("up", "ops-file")).To(Equal("BBL_OPS_FILE"))
+		})
 	})
 
 	Describe("Args", func() {
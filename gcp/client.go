@@ -1,15 +1,31 @@
 package gcp
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 
 	compute "google.golang.org/api/compute/v1"
 )
 
+const (
+	ResourceManagerBasePath = "https://cloudresourcemanager.googleapis.com/v1/"
+	CloudBillingBasePath    = "https://cloudbilling.googleapis.com/v1/"
+)
+
 type GCPClient struct {
-	service   *compute.Service
-	projectID string
-	zone      string
+	service                 *compute.Service
+	httpClient              *http.Client
+	resourceManagerBasePath string
+	cloudBillingBasePath    string
+	projectID               string
+	region                  string
+	zone                    string
 }
 
 func (c GCPClient) ProjectID() string {
@@ -40,13 +56,18 @@ func (c GCPClient) GetZones(region string) ([]string, error) {
 	}
 
 	zonesInProject, err := c.service.Zones.List(c.projectID).Do()
+	if err != nil {
+		return []string{}, err
+	}
 
+	// zones that are down or being retired cannot take new vms.
 	zonesInRegion := []string{}
 	for _, zone := range zonesInProject.Items {
-		if _, ok := zoneURLs[zone.SelfLink]; ok {
+		if _, ok := zoneURLs[zone.SelfLink]; ok && zone.Status == "UP" && zone.Deprecated == nil {
 			zonesInRegion = append(zonesInRegion, zone.Name)
 		}
 	}
+	sort.Strings(zonesInRegion)
 
 	return zonesInRegion, nil
 }
@@ -63,3 +84,131 @@ func (c GCPClient) GetNetworks(name string) (*compute.NetworkList, error) {
 	networksListCall := c.service.Networks.List(c.projectID)
 	return networksListCall.Filter(fmt.Sprintf("name eq %s", name)).Do()
 }
+
+func (c GCPClient) ListAllInstances() (*compute.InstanceAggregatedList, error) {
+	return c.service.Instances.AggregatedList(c.projectID).Do()
+}
+
+func (c GCPClient) ListAddresses() (*compute.AddressList, error) {
+	return c.service.Addresses.List(c.projectID, c.region).Do()
+}
+
+func (c GCPClient) DeleteAddress(name string) (*compute.Operation, error) {
+	return c.service.Addresses.Delete(c.projectID, c.region, name).Do()
+}
+
+func (c GCPClient) ListGlobalAddresses() (*compute.AddressList, error) {
+	return c.service.GlobalAddresses.List(c.projectID).Do()
+}
+
+func (c GCPClient) DeleteGlobalAddress(name string) (*compute.Operation, error) {
+	return c.service.GlobalAddresses.Delete(c.projectID, name).Do()
+}
+
+func (c GCPClient) ListFirewalls() (*compute.FirewallList, error) {
+	return c.service.Firewalls.List(c.projectID).Do()
+}
+
+func (c GCPClient) DeleteFirewall(name string) (*compute.Operation, error) {
+	return c.service.Firewalls.Delete(c.projectID, name).Do()
+}
+
+// TestIamPermissions returns the permissions, out of those given, that the
+// credentials hold on the project.
+func (c GCPClient) TestIamPermissions(permissions []string) ([]string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"permissions": permissions,
+	})
+	if err != nil {
+		return nil, err //not tested
+	}
+
+	url := fmt.Sprintf("%sprojects/%s:testIamPermissions", c.resourceManagerBasePath, c.projectID)
+	response, err := c.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to test the permissions on project %q: %s", c.projectID, err)
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to test the permissions on project %q: %s", c.projectID, err) //not tested
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to test the permissions on project %q: %s %s", c.projectID, response.Status, strings.TrimSpace(string(contents)))
+	}
+
+	var granted struct {
+		Permissions []string `json:"permissions"`
+	}
+	err = json.Unmarshal(contents, &granted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to test the permissions on project %q: %s", c.projectID, err)
+	}
+
+	return granted.Permissions, nil
+}
+
+// SKU is a priced item of a service in the Cloud Billing catalog.
+type SKU struct {
+	Description    string   `json:"description"`
+	ServiceRegions []string `json:"serviceRegions"`
+	Category       struct {
+		UsageType string `json:"usageType"`
+	} `json:"category"`
+	PricingInfo []struct {
+		PricingExpression struct {
+			UsageUnit   string `json:"usageUnit"`
+			TieredRates []struct {
+				UnitPrice struct {
+					Units string `json:"units"`
+					Nanos int64  `json:"nanos"`
+				} `json:"unitPrice"`
+			} `json:"tieredRates"`
+		} `json:"pricingExpression"`
+	} `json:"pricingInfo"`
+}
+
+// ListSKUs returns the SKUs of a service in the Cloud Billing catalog, with
+// their public list prices in USD.
+func (c GCPClient) ListSKUs(serviceID string) ([]SKU, error) {
+	skus := []SKU{}
+	pageToken := ""
+	for {
+		query := url.Values{"currencyCode": {"USD"}, "pageSize": {"5000"}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		response, err := c.httpClient.Get(fmt.Sprintf("%sservices/%s/skus?%s", c.cloudBillingBasePath, serviceID, query.Encode()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the skus of service %q: %s", serviceID, err)
+		}
+
+		contents, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the skus of service %q: %s", serviceID, err) //not tested
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to list the skus of service %q: %s %s", serviceID, response.Status, strings.TrimSpace(string(contents)))
+		}
+
+		var page struct {
+			SKUs          []SKU  `json:"skus"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = json.Unmarshal(contents, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the skus of service %q: %s", serviceID, err)
+		}
+
+		skus = append(skus, page.SKUs...)
+		if page.NextPageToken == "" {
+			return skus, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
@@ -2,27 +2,43 @@ package gcp
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
 
 	compute "google.golang.org/api/compute/v1"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 )
 
 const (
-	GoogleComputeAuth = "https://www.googleapis.com/auth/compute"
+	GoogleComputeAuth         = "https://www.googleapis.com/auth/compute"
+	CloudPlatformReadOnlyAuth = "https://www.googleapis.com/auth/cloud-platform.read-only"
 )
 
 func gcpHTTPClientFunc(config *jwt.Config) *http.Client {
-	return config.Client(context.Background())
+	return config.Client(proxyContext())
 }
 
-var gcpHTTPClient = gcpHTTPClientFunc
+// proxyContext makes the oauth2 token requests, and the GCP calls made with
+// their tokens, go through the proxy of HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func proxyContext() context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, helpers.NewHTTPClient())
+}
+
+var (
+	gcpHTTPClient         = gcpHTTPClientFunc
+	gcpDefaultTokenSource = google.DefaultTokenSource
+)
 
 type ClientProvider struct {
-	basePath string
-	client   GCPClient
+	basePath    string
+	client      GCPClient
+	tokenSource oauth2.TokenSource
 }
 
 func NewClientProvider(gcpBasePath string) *ClientProvider {
@@ -31,29 +47,88 @@ func NewClientProvider(gcpBasePath string) *ClientProvider {
 	}
 }
 
-func (p *ClientProvider) SetConfig(serviceAccountKey, projectID, region, zone string) error {
-	config, err := google.JWTConfigFromJSON([]byte(serviceAccountKey), compute.ComputeScope)
-	if err != nil {
-		return err
+// SetConfig authenticates with the service account key, or with the
+// application default credentials (gcloud user credentials, or the service
+// account of the vm bbl runs on) when there is no key. When
+// impersonateServiceAccount is set, those credentials are only used to issue
+// tokens for that service account, which every later GCP call is made as.
+func (p *ClientProvider) SetConfig(serviceAccountKey, projectID, region, zone, impersonateServiceAccount string) error {
+	// the read-only scope lets the permissions of the key be tested
+	scopes := []string{compute.ComputeScope, CloudPlatformReadOnlyAuth}
+	if impersonateServiceAccount != "" || serviceAccountKey == "" {
+		scopes = []string{CloudPlatformAuth}
 	}
 
-	if p.basePath != "" {
-		config.TokenURL = p.basePath
+	var httpClient *http.Client
+	p.tokenSource = nil
+	if serviceAccountKey == "" {
+		tokenSource, err := gcpDefaultTokenSource(proxyContext(), scopes...)
+		if err != nil {
+			return fmt.Errorf("failed to find application default credentials, pass --gcp-service-account-key or run gcloud auth application-default login: %s", err)
+		}
+
+		p.tokenSource = oauth2.ReuseTokenSource(nil, tokenSource)
+		httpClient = &http.Client{Transport: &oauth2.Transport{Source: p.tokenSource, Base: helpers.NewProxyTransport()}}
+	} else {
+		config, err := google.JWTConfigFromJSON([]byte(serviceAccountKey), scopes...)
+		if err != nil {
+			return err
+		}
+
+		if p.basePath != "" {
+			config.TokenURL = p.basePath
+		}
+
+		httpClient = gcpHTTPClient(config)
+	}
+
+	var err error
+	if impersonateServiceAccount != "" {
+		iamBasePath := IAMCredentialsBasePath
+		if p.basePath != "" {
+			iamBasePath = p.basePath + "/"
+		}
+
+		tokenSource := oauth2.ReuseTokenSource(nil, impersonatedTokenSource{
+			client:         httpClient,
+			basePath:       iamBasePath,
+			serviceAccount: impersonateServiceAccount,
+		})
+
+		_, err = tokenSource.Token()
+		if err != nil {
+			return err
+		}
+
+		p.tokenSource = tokenSource
+		httpClient = &http.Client{Transport: &oauth2.Transport{Source: tokenSource, Base: helpers.NewProxyTransport()}}
 	}
 
-	service, err := compute.New(gcpHTTPClient(config))
+	if httpClient != nil {
+		httpClient = &http.Client{Transport: newRetryTransport(httpClient.Transport)}
+	}
+
+	service, err := compute.New(httpClient)
 	if err != nil {
 		return err
 	}
 
+	resourceManagerBasePath := ResourceManagerBasePath
+	cloudBillingBasePath := CloudBillingBasePath
 	if p.basePath != "" {
 		service.BasePath = p.basePath
+		resourceManagerBasePath = p.basePath + "/"
+		cloudBillingBasePath = p.basePath + "/"
 	}
 
 	p.client = GCPClient{
-		service:   service,
-		projectID: projectID,
-		zone:      zone,
+		service:                 service,
+		httpClient:              httpClient,
+		resourceManagerBasePath: resourceManagerBasePath,
+		cloudBillingBasePath:    cloudBillingBasePath,
+		projectID:               projectID,
+		region:                  region,
+		zone:                    zone,
 	}
 
 	_, err = p.client.GetRegion(region)
@@ -72,3 +147,19 @@ func (p *ClientProvider) SetConfig(serviceAccountKey, projectID, region, zone st
 func (p *ClientProvider) Client() GCPClient {
 	return p.client
 }
+
+// AccessToken returns a token for the impersonated service account, or of the
+// application default credentials, which terraform uses in place of the
+// service account key.
+func (p *ClientProvider) AccessToken() (string, error) {
+	if p.tokenSource == nil {
+		return "", errors.New("no service account is being impersonated and no application default credentials are used")
+	}
+
+	token, err := p.tokenSource.Token()
+	if err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
@@ -2,6 +2,7 @@ package gcp_test
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -10,6 +11,8 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/gcp"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
 )
 
@@ -36,6 +39,22 @@ var _ = Describe("ClientProvider", func() {
 				w.Write([]byte(`{}`))
 			case "/proj-id/regions/region":
 				w.Write([]byte(`{}`))
+			case "/projects/proj-id:testIamPermissions":
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != `{"permissions":["compute.networks.create","compute.instances.create"]}` {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Write([]byte(`{"permissions": ["compute.networks.create"]}`))
+			case "/services/6F81-5844-456A/skus":
+				if r.URL.Query().Get("pageToken") == "" {
+					w.Write([]byte(`{"skus": [{"description": "N1 Predefined Instance Core running in Americas"}], "nextPageToken": "page-2"}`))
+					return
+				}
+				w.Write([]byte(`{"skus": [{"description": "N1 Predefined Instance Ram running in Americas"}]}`))
+			case "/services/unknown/skus":
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error": {"message": "service not found"}}`))
 			default:
 				w.WriteHeader(http.StatusNotFound)
 			}
@@ -54,7 +73,7 @@ var _ = Describe("ClientProvider", func() {
 		})
 
 		It("returns an error when the service account key is not valid json", func() {
-			err := clientProvider.SetConfig("1231:123", "proj-id", "region", "zone")
+			err := clientProvider.SetConfig("1231:123", "proj-id", "region", "zone", "")
 			Expect(err).To(MatchError("invalid character ':' after top-level value"))
 		})
 
@@ -62,7 +81,7 @@ var _ = Describe("ClientProvider", func() {
 			gcp.SetGCPHTTPClient(func(*jwt.Config) *http.Client {
 				return nil
 			})
-			err := clientProvider.SetConfig(`{"type": "service_account"}`, "proj-id", "region", "zone")
+			err := clientProvider.SetConfig(`{"type": "service_account"}`, "proj-id", "region", "zone", "")
 			Expect(err).To(MatchError("client is nil"))
 		})
 
@@ -72,7 +91,7 @@ var _ = Describe("ClientProvider", func() {
 				"private_key": %q
 			}`, privateKey)
 
-			err := clientProvider.SetConfig(serviceAccountKey, "proj-id", "region", "bad-zone")
+			err := clientProvider.SetConfig(serviceAccountKey, "proj-id", "region", "bad-zone", "")
 			Expect(err).To(MatchError(ContainSubstring("googleapi")))
 			Expect(err).To(MatchError(ContainSubstring("404")))
 		})
@@ -82,9 +101,168 @@ var _ = Describe("ClientProvider", func() {
 				"type": "service_account",
 				"private_key": %q
 			}`, privateKey)
-			err := clientProvider.SetConfig(serviceAccountKey, "proj-id", "bad-region", "zone")
+			err := clientProvider.SetConfig(serviceAccountKey, "proj-id", "bad-region", "zone", "")
 			Expect(err).To(MatchError(ContainSubstring("googleapi")))
 			Expect(err).To(MatchError(ContainSubstring("404")))
 		})
+
+		Context("when there is no service account key", func() {
+			var (
+				scopes         []string
+				authorizations []string
+			)
+
+			BeforeEach(func() {
+				scopes = []string{}
+				authorizations = []string{}
+
+				gcp.SetGCPDefaultTokenSource(func(_ context.Context, s ...string) (oauth2.TokenSource, error) {
+					scopes = s
+					return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "default-token"}), nil
+				})
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Path {
+					case "/proj-id/zones/zone", "/proj-id/regions/region":
+						authorizations = append(authorizations, r.Header.Get("Authorization"))
+						w.Write([]byte(`{}`))
+					default:
+						w.WriteHeader(http.StatusNotFound)
+					}
+				}))
+
+				clientProvider = gcp.NewClientProvider(server.URL)
+			})
+
+			AfterEach(func() {
+				gcp.ResetGCPDefaultTokenSource()
+			})
+
+			It("makes the GCP calls with the application default credentials", func() {
+				err := clientProvider.SetConfig("", "proj-id", "region", "zone", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(scopes).To(Equal([]string{gcp.CloudPlatformAuth}))
+				Expect(authorizations).To(Equal([]string{"Bearer default-token", "Bearer default-token"}))
+
+				accessToken, err := clientProvider.AccessToken()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(accessToken).To(Equal("default-token"))
+			})
+
+			It("returns an error when there are no application default credentials", func() {
+				gcp.SetGCPDefaultTokenSource(func(context.Context, ...string) (oauth2.TokenSource, error) {
+					return nil, errors.New("could not find default credentials")
+				})
+
+				err := clientProvider.SetConfig("", "proj-id", "region", "zone", "")
+				Expect(err).To(MatchError("failed to find application default credentials, pass --gcp-service-account-key or run gcloud auth application-default login: could not find default credentials"))
+			})
+		})
+
+		Context("when a service account is impersonated", func() {
+			var (
+				serviceAccountKey   string
+				impersonationStatus int
+				authorizations      []string
+			)
+
+			BeforeEach(func() {
+				serviceAccountKey = fmt.Sprintf(`{
+					"type": "service_account",
+					"private_key": %q
+				}`, privateKey)
+
+				impersonationStatus = http.StatusOK
+				authorizations = []string{}
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Path {
+					case "/projects/-/serviceAccounts/target@proj-id.iam.gserviceaccount.com:generateAccessToken":
+						w.WriteHeader(impersonationStatus)
+						if impersonationStatus != http.StatusOK {
+							w.Write([]byte(`{"error": {"message": "Permission 'iam.serviceAccounts.getAccessToken' denied"}}`))
+							return
+						}
+						w.Write([]byte(`{"accessToken": "impersonated-token", "expireTime": "2099-01-01T00:00:00Z"}`))
+					case "/proj-id/zones/zone", "/proj-id/regions/region":
+						authorizations = append(authorizations, r.Header.Get("Authorization"))
+						w.Write([]byte(`{}`))
+					default:
+						w.WriteHeader(http.StatusNotFound)
+					}
+				}))
+
+				clientProvider = gcp.NewClientProvider(server.URL)
+			})
+
+			It("makes the GCP calls as the impersonated service account", func() {
+				err := clientProvider.SetConfig(serviceAccountKey, "proj-id", "region", "zone", "target@proj-id.iam.gserviceaccount.com")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(authorizations).To(Equal([]string{"Bearer impersonated-token", "Bearer impersonated-token"}))
+
+				accessToken, err := clientProvider.AccessToken()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(accessToken).To(Equal("impersonated-token"))
+			})
+
+			It("fails fast when the key cannot impersonate the service account", func() {
+				impersonationStatus = http.StatusForbidden
+
+				err := clientProvider.SetConfig(serviceAccountKey, "proj-id", "region", "zone", "target@proj-id.iam.gserviceaccount.com")
+				Expect(err).To(MatchError(`the service account key cannot impersonate "target@proj-id.iam.gserviceaccount.com", it needs the iam.serviceAccounts.getAccessToken permission on that service account: Permission 'iam.serviceAccounts.getAccessToken' denied`))
+				Expect(authorizations).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Client", func() {
+		AfterEach(func() {
+			gcp.ResetGCPHTTPClient()
+		})
+
+		It("tests the permissions the credentials hold on the project", func() {
+			err := clientProvider.SetConfig(fmt.Sprintf(`{"type": "service_account", "private_key": %q}`, privateKey), "proj-id", "region", "zone", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			granted, err := clientProvider.Client().TestIamPermissions([]string{"compute.networks.create", "compute.instances.create"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(granted).To(Equal([]string{"compute.networks.create"}))
+		})
+
+		It("returns an error when the permissions cannot be tested", func() {
+			err := clientProvider.SetConfig(fmt.Sprintf(`{"type": "service_account", "private_key": %q}`, privateKey), "proj-id", "region", "zone", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = clientProvider.Client().TestIamPermissions([]string{"compute.networks.create"})
+			Expect(err).To(MatchError(ContainSubstring(`failed to test the permissions on project "proj-id": 400 Bad Request`)))
+		})
+
+		It("lists every page of the skus of a service", func() {
+			err := clientProvider.SetConfig(fmt.Sprintf(`{"type": "service_account", "private_key": %q}`, privateKey), "proj-id", "region", "zone", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			skus, err := clientProvider.Client().ListSKUs(gcp.ComputeEngineServiceID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(skus).To(HaveLen(2))
+			Expect(skus[0].Description).To(Equal("N1 Predefined Instance Core running in Americas"))
+			Expect(skus[1].Description).To(Equal("N1 Predefined Instance Ram running in Americas"))
+		})
+
+		It("returns an error when the skus cannot be listed", func() {
+			err := clientProvider.SetConfig(fmt.Sprintf(`{"type": "service_account", "private_key": %q}`, privateKey), "proj-id", "region", "zone", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = clientProvider.Client().ListSKUs("unknown")
+			Expect(err).To(MatchError(`failed to list the skus of service "unknown": 404 Not Found {"error": {"message": "service not found"}}`))
+		})
+	})
+
+	Describe("AccessToken", func() {
+		It("returns an error when neither an impersonated service account nor the application default credentials are used", func() {
+			_, err := clientProvider.AccessToken()
+			Expect(err).To(MatchError("no service account is being impersonated and no application default credentials are used"))
+		})
 	})
 })
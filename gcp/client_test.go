@@ -0,0 +1,125 @@
+package gcp_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2/jwt"
+)
+
+var _ = Describe("GCPClient", func() {
+	var (
+		client       gcp.GCPClient
+		zoneStatus   int
+		rateLimited  int
+		zoneRequests int
+		sleeps       []time.Duration
+	)
+
+	BeforeEach(func() {
+		zoneStatus = http.StatusOK
+		rateLimited = 0
+		zoneRequests = 0
+		sleeps = nil
+
+		gcp.SetSleep(func(d time.Duration) {
+			sleeps = append(sleeps, d)
+		})
+
+		gcp.SetGCPHTTPClient(func(*jwt.Config) *http.Client {
+			return &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						InsecureSkipVerify: true,
+					},
+				},
+			}
+		})
+
+		var server *httptest.Server
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/proj-id/zones/zone":
+				w.Write([]byte(`{}`))
+			case "/proj-id/regions/region":
+				w.Write([]byte(fmt.Sprintf(`{"zones": ["%[1]s/proj-id/zones/region-c", "%[1]s/proj-id/zones/region-a", "%[1]s/proj-id/zones/region-b", "%[1]s/proj-id/zones/region-d"]}`, server.URL)))
+			case "/proj-id/zones":
+				zoneRequests++
+				if zoneRequests <= rateLimited {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(`{"error": {"code": 403, "errors": [{"reason": "rateLimitExceeded"}], "message": "Rate Limit Exceeded"}}`))
+					return
+				}
+
+				w.WriteHeader(zoneStatus)
+				w.Write([]byte(fmt.Sprintf(`{"items": [
+					{"name": "region-c", "status": "UP", "selfLink": "%[1]s/proj-id/zones/region-c"},
+					{"name": "region-a", "status": "UP", "selfLink": "%[1]s/proj-id/zones/region-a"},
+					{"name": "region-b", "status": "DOWN", "selfLink": "%[1]s/proj-id/zones/region-b"},
+					{"name": "region-d", "status": "UP", "selfLink": "%[1]s/proj-id/zones/region-d", "deprecated": {"state": "DEPRECATED"}},
+					{"name": "other-a", "status": "UP", "selfLink": "%[1]s/proj-id/zones/other-a"}
+				]}`, server.URL)))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		privateKey, err := ioutil.ReadFile("fixtures/service-account-key")
+		Expect(err).NotTo(HaveOccurred())
+
+		clientProvider := gcp.NewClientProvider(server.URL)
+		err = clientProvider.SetConfig(fmt.Sprintf(`{"type": "service_account", "private_key": %q}`, privateKey), "proj-id", "region", "zone", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		client = clientProvider.Client()
+	})
+
+	AfterEach(func() {
+		gcp.ResetGCPHTTPClient()
+		gcp.ResetSleep()
+	})
+
+	Describe("GetZones", func() {
+		It("lists the zones of the region that are up, in order", func() {
+			zones, err := client.GetZones("region")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zones).To(Equal([]string{"region-a", "region-c"}))
+		})
+
+		It("returns an error when the zones cannot be listed", func() {
+			zoneStatus = http.StatusForbidden
+
+			_, err := client.GetZones("region")
+			Expect(err).To(MatchError(ContainSubstring("403")))
+			Expect(zoneRequests).To(Equal(1))
+		})
+
+		It("retries with backoff when the rate limit is exceeded", func() {
+			rateLimited = 2
+
+			zones, err := client.GetZones("region")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zones).To(Equal([]string{"region-a", "region-c"}))
+
+			Expect(zoneRequests).To(Equal(3))
+			Expect(sleeps).To(HaveLen(2))
+			Expect(sleeps[0]).To(BeNumerically("~", 750*time.Millisecond, 250*time.Millisecond))
+			Expect(sleeps[1]).To(BeNumerically("~", 1500*time.Millisecond, 500*time.Millisecond))
+		})
+
+		It("gives up when the rate limit is still exceeded after the retries", func() {
+			rateLimited = gcp.MaxRetries + 1
+
+			_, err := client.GetZones("region")
+			Expect(err).To(MatchError(ContainSubstring("Rate Limit Exceeded")))
+			Expect(zoneRequests).To(Equal(gcp.MaxRetries + 1))
+		})
+	})
+})
@@ -2,7 +2,11 @@ package gcp
 
 import (
 	"net/http"
+	"time"
 
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
 )
 
@@ -13,3 +17,19 @@ func SetGCPHTTPClient(f func(*jwt.Config) *http.Client) {
 func ResetGCPHTTPClient() {
 	gcpHTTPClient = gcpHTTPClientFunc
 }
+
+func SetGCPDefaultTokenSource(f func(context.Context, ...string) (oauth2.TokenSource, error)) {
+	gcpDefaultTokenSource = f
+}
+
+func ResetGCPDefaultTokenSource() {
+	gcpDefaultTokenSource = google.DefaultTokenSource
+}
+
+func SetSleep(f func(time.Duration)) {
+	sleep = f
+}
+
+func ResetSleep() {
+	sleep = time.Sleep
+}
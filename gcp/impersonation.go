@@ -0,0 +1,85 @@
+package gcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	IAMCredentialsBasePath = "https://iamcredentials.googleapis.com/v1/"
+	CloudPlatformAuth      = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// impersonatedTokenSource issues access tokens for serviceAccount through the
+// IAM Credentials API, authenticating as the account of the service account
+// key. The caller needs iam.serviceAccounts.getAccessToken on serviceAccount.
+type impersonatedTokenSource struct {
+	client         *http.Client
+	basePath       string
+	serviceAccount string
+}
+
+func (s impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"scope": []string{CloudPlatformAuth},
+	})
+	if err != nil {
+		return nil, err //not tested
+	}
+
+	url := fmt.Sprintf("%sprojects/-/serviceAccounts/%s:generateAccessToken", s.basePath, s.serviceAccount)
+	response, err := s.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate service account %q: %s", s.serviceAccount, err)
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate service account %q: %s", s.serviceAccount, err) //not tested
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		message := strings.TrimSpace(string(contents))
+		if err := json.Unmarshal(contents, &failure); err == nil && failure.Error.Message != "" {
+			message = failure.Error.Message
+		}
+
+		if response.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("the service account key cannot impersonate %q, it needs the iam.serviceAccounts.getAccessToken permission on that service account: %s", s.serviceAccount, message)
+		}
+		return nil, fmt.Errorf("failed to impersonate service account %q: %s %s", s.serviceAccount, response.Status, message)
+	}
+
+	var token struct {
+		AccessToken string `json:"accessToken"`
+		ExpireTime  string `json:"expireTime"`
+	}
+	err = json.Unmarshal(contents, &token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate service account %q: %s", s.serviceAccount, err)
+	}
+
+	expiry, err := time.Parse(time.RFC3339, token.ExpireTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate service account %q: %s", s.serviceAccount, err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}, nil
+}
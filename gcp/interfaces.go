@@ -7,6 +7,11 @@ type metadataSetter interface {
 	SetCommonInstanceMetadata(metadata *compute.Metadata) (*compute.Operation, error)
 }
 
+type quotaGetter interface {
+	GetProject() (*compute.Project, error)
+	GetRegion(region string) (*compute.Region, error)
+}
+
 type instanceLister interface {
 	ListInstances() (*compute.InstanceList, error)
 }
@@ -14,3 +19,15 @@ type instanceLister interface {
 type logger interface {
 	Step(string, ...interface{})
 }
+
+type sshKeyGenerator interface {
+	Generate(keyType string, bits int) (string, string, error)
+}
+
+type permissionTester interface {
+	TestIamPermissions(permissions []string) ([]string, error)
+}
+
+type skuLister interface {
+	ListSKUs(serviceID string) ([]SKU, error)
+}
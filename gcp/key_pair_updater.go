@@ -1,43 +1,30 @@
 package gcp
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
-	"io"
 	"strings"
 
 	compute "google.golang.org/api/compute/v1"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
-
-	"golang.org/x/crypto/ssh"
 )
 
 type KeyPairUpdater struct {
-	random                io.Reader
-	rsaKeyGenerator       rsaKeyGenerator
-	sshPublicKeyGenerator sshPublicKeyGenerator
-	client                metadataSetter
-	logger                logger
+	sshKeyGenerator sshKeyGenerator
+	client          metadataSetter
+	logger          logger
 }
 
-type rsaKeyGenerator func(io.Reader, int) (*rsa.PrivateKey, error)
-type sshPublicKeyGenerator func(interface{}) (ssh.PublicKey, error)
-
-func NewKeyPairUpdater(random io.Reader, generateRSAKey rsaKeyGenerator, generateSSHPublicKey sshPublicKeyGenerator, client metadataSetter, logger logger) KeyPairUpdater {
+func NewKeyPairUpdater(sshKeyGenerator sshKeyGenerator, client metadataSetter, logger logger) KeyPairUpdater {
 	return KeyPairUpdater{
-		random:                random,
-		rsaKeyGenerator:       generateRSAKey,
-		sshPublicKeyGenerator: generateSSHPublicKey,
-		client:                client,
-		logger:                logger,
+		sshKeyGenerator: sshKeyGenerator,
+		client:          client,
+		logger:          logger,
 	}
 }
 
-func (k KeyPairUpdater) Update() (storage.KeyPair, error) {
-	privateKey, publicKey, err := k.createKeyPair()
+func (k KeyPairUpdater) Update(keyType string, bits int) (storage.KeyPair, error) {
+	privateKey, publicKey, err := k.sshKeyGenerator.Generate(keyType, bits)
 	if err != nil {
 		return storage.KeyPair{}, err
 	}
@@ -81,29 +68,7 @@ func (k KeyPairUpdater) Update() (storage.KeyPair, error) {
 	return storage.KeyPair{
 		PrivateKey: privateKey,
 		PublicKey:  publicKey,
+		Type:       keyType,
+		Bits:       bits,
 	}, nil
 }
-
-func (keyPairUpdater KeyPairUpdater) createKeyPair() (string, string, error) {
-	rsaKey, err := keyPairUpdater.rsaKeyGenerator(keyPairUpdater.random, 2048)
-	if err != nil {
-		return "", "", err
-	}
-
-	publicKey, err := keyPairUpdater.sshPublicKeyGenerator(rsaKey.Public())
-	if err != nil {
-		return "", "", err
-	}
-
-	rawPublicKey := string(ssh.MarshalAuthorizedKey(publicKey))
-	rawPublicKey = strings.TrimSuffix(rawPublicKey, "\n")
-
-	privateKey := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PRIVATE KEY",
-			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
-		},
-	)
-
-	return string(privateKey), rawPublicKey, nil
-}
@@ -6,13 +6,14 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
-	"io"
 	"strings"
 
 	compute "google.golang.org/api/compute/v1"
 
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ssh"
 
 	. "github.com/onsi/ginkgo"
@@ -37,11 +38,12 @@ var _ = Describe("KeyPairUpdater", func() {
 			Name: "some-project-id",
 		}
 
-		keyPairUpdater = gcp.NewKeyPairUpdater(rand.Reader, rsa.GenerateKey, ssh.NewPublicKey, gcpClient, logger)
+		sshKeyGenerator := helpers.NewSSHKeyGenerator(rand.Reader, rsa.GenerateKey, ed25519.GenerateKey, ssh.NewPublicKey)
+		keyPairUpdater = gcp.NewKeyPairUpdater(sshKeyGenerator, gcpClient, logger)
 	})
 
 	It("generates a keypair", func() {
-		keyPair, err := keyPairUpdater.Update()
+		keyPair, err := keyPairUpdater.Update("", 0)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(keyPair.PrivateKey).NotTo(BeEmpty())
 		Expect(keyPair.PublicKey).NotTo(BeEmpty())
@@ -65,8 +67,36 @@ var _ = Describe("KeyPairUpdater", func() {
 		Expect(rawPublicKey).To(Equal(keyPair.PublicKey))
 	})
 
+	It("generates an ed25519 keypair", func() {
+		keyPair, err := keyPairUpdater.Update("ed25519", 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyPair.Type).To(Equal("ed25519"))
+
+		signer, err := ssh.ParsePrivateKey([]byte(keyPair.PrivateKey))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer.PublicKey().Type()).To(Equal("ssh-ed25519"))
+
+		rawPublicKey := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(signer.PublicKey())), "\n")
+		Expect(rawPublicKey).To(Equal(keyPair.PublicKey))
+
+		Expect(*gcpClient.SetCommonInstanceMetadataCall.Receives.Metadata.Items[0].Value).To(Equal("vcap:" + keyPair.PublicKey + " vcap"))
+	})
+
+	It("generates an rsa keypair with the requested bits", func() {
+		keyPair, err := keyPairUpdater.Update("rsa", 3072)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyPair.Type).To(Equal("rsa"))
+		Expect(keyPair.Bits).To(Equal(3072))
+
+		signer, err := ssh.ParsePrivateKey([]byte(keyPair.PrivateKey))
+		Expect(err).NotTo(HaveOccurred())
+
+		rawPublicKey := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(signer.PublicKey())), "\n")
+		Expect(rawPublicKey).To(Equal(keyPair.PublicKey))
+	})
+
 	It("updates common metadata for given project id", func() {
-		_, err := keyPairUpdater.Update()
+		_, err := keyPairUpdater.Update("", 0)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(gcpClient.GetProjectCall.CallCount).To(Equal(1))
@@ -96,7 +126,7 @@ var _ = Describe("KeyPairUpdater", func() {
 				},
 			},
 		}
-		_, err := keyPairUpdater.Update()
+		_, err := keyPairUpdater.Update("", 0)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(gcpClient.SetCommonInstanceMetadataCall.Receives.Metadata.Items).To(HaveLen(2))
@@ -109,38 +139,27 @@ var _ = Describe("KeyPairUpdater", func() {
 	})
 
 	Context("failure cases", func() {
-		It("returns an error when the rsaKeyGenerator fails", func() {
-			keyPairUpdater = gcp.NewKeyPairUpdater(rand.Reader,
-				func(_ io.Reader, _ int) (*rsa.PrivateKey, error) {
-					return nil, errors.New("rsa key generator failed")
-				},
-				ssh.NewPublicKey, gcpClient, logger)
-
-			_, err := keyPairUpdater.Update()
-			Expect(err).To(MatchError("rsa key generator failed"))
-		})
-
-		It("returns an error when the ssh public key generator fails", func() {
-			keyPairUpdater = gcp.NewKeyPairUpdater(rand.Reader, rsa.GenerateKey,
-				func(_ interface{}) (ssh.PublicKey, error) {
-					return nil, errors.New("ssh public key gen failed")
-				}, gcpClient, logger)
-
-			_, err := keyPairUpdater.Update()
-			Expect(err).To(MatchError("ssh public key gen failed"))
+		It("returns an error when the ssh key generator fails", func() {
+			sshKeyGenerator := &fakes.SSHKeyGenerator{}
+			sshKeyGenerator.GenerateCall.Returns.Error = errors.New("ssh key generator failed")
+			keyPairUpdater = gcp.NewKeyPairUpdater(sshKeyGenerator, gcpClient, logger)
+
+			_, err := keyPairUpdater.Update("ed25519", 0)
+			Expect(err).To(MatchError("ssh key generator failed"))
+			Expect(sshKeyGenerator.GenerateCall.Receives.KeyType).To(Equal("ed25519"))
 		})
 
 		It("returns an error when project could not be found", func() {
 			gcpClient.GetProjectCall.Returns.Error = errors.New("project could not be found")
 
-			_, err := keyPairUpdater.Update()
+			_, err := keyPairUpdater.Update("", 0)
 			Expect(err).To(MatchError("project could not be found"))
 		})
 
 		It("returns an error when set common instance metadata fails", func() {
 			gcpClient.SetCommonInstanceMetadataCall.Returns.Error = errors.New("updating ssh-key failed")
 
-			_, err := keyPairUpdater.Update()
+			_, err := keyPairUpdater.Update("", 0)
 			Expect(err).To(MatchError("updating ssh-key failed"))
 		})
 	})
@@ -0,0 +1,53 @@
+package gcp
+
+// requiredPermissions are the permissions terraform and the director need to
+// create the resources of a bbl environment and its load balancers.
+var requiredPermissions = []string{
+	"compute.addresses.create",
+	"compute.disks.create",
+	"compute.firewalls.create",
+	"compute.forwardingRules.create",
+	"compute.globalAddresses.create",
+	"compute.globalForwardingRules.create",
+	"compute.instanceGroups.create",
+	"compute.instances.create",
+	"compute.instances.delete",
+	"compute.networks.create",
+	"compute.projects.setCommonInstanceMetadata",
+	"compute.subnetworks.create",
+	"compute.targetPools.create",
+	"iam.serviceAccounts.actAs",
+}
+
+type PermissionChecker struct {
+	client permissionTester
+}
+
+func NewPermissionChecker(client permissionTester) PermissionChecker {
+	return PermissionChecker{
+		client: client,
+	}
+}
+
+// Check returns the required permissions the credentials do not hold on the
+// project.
+func (p PermissionChecker) Check() ([]string, error) {
+	granted, err := p.client.TestIamPermissions(requiredPermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	held := map[string]bool{}
+	for _, permission := range granted {
+		held[permission] = true
+	}
+
+	missing := []string{}
+	for _, permission := range requiredPermissions {
+		if !held[permission] {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing, nil
+}
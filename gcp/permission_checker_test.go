@@ -0,0 +1,44 @@
+package gcp_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("permission checker", func() {
+	var (
+		client            *fakes.GCPClient
+		permissionChecker gcp.PermissionChecker
+	)
+
+	BeforeEach(func() {
+		client = &fakes.GCPClient{}
+		permissionChecker = gcp.NewPermissionChecker(client)
+	})
+
+	Describe("Check", func() {
+		It("returns the required permissions that were not granted", func() {
+			client.TestIamPermissionsCall.Returns.Permissions = []string{"compute.networks.create"}
+
+			missing, err := permissionChecker.Check()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.TestIamPermissionsCall.Receives.Permissions).To(ContainElement("compute.instances.create"))
+			Expect(missing).To(ContainElement("compute.instances.create"))
+			Expect(missing).NotTo(ContainElement("compute.networks.create"))
+			Expect(missing).To(HaveLen(len(client.TestIamPermissionsCall.Receives.Permissions) - 1))
+		})
+
+		It("returns an error when the permissions cannot be tested", func() {
+			client.TestIamPermissionsCall.Returns.Error = errors.New("failed to test")
+
+			_, err := permissionChecker.Check()
+			Expect(err).To(MatchError("failed to test"))
+		})
+	})
+})
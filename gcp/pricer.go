@@ -0,0 +1,72 @@
+package gcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	ComputeEngineServiceID = "6F81-5844-456A"
+	CloudSQLServiceID      = "9662-B51E-5089"
+)
+
+type Pricer struct {
+	client skuLister
+	skus   map[string][]SKU
+}
+
+func NewPricer(client skuLister) Pricer {
+	return Pricer{
+		client: client,
+		skus:   map[string][]SKU{},
+	}
+}
+
+// Price returns the on-demand list price, per usage unit, of the SKU of the
+// service that is sold in the region and whose description starts with
+// description. The SKUs of a service are listed once and reused.
+func (p Pricer) Price(serviceID, description, region string) (float64, error) {
+	skus, ok := p.skus[serviceID]
+	if !ok {
+		var err error
+		skus, err = p.client.ListSKUs(serviceID)
+		if err != nil {
+			return 0, err
+		}
+		p.skus[serviceID] = skus
+	}
+
+	for _, sku := range skus {
+		if sku.Category.UsageType != "OnDemand" || !strings.HasPrefix(sku.Description, description) || !contains(sku.ServiceRegions, region) {
+			continue
+		}
+
+		for _, pricingInfo := range sku.PricingInfo {
+			for _, rate := range pricingInfo.PricingExpression.TieredRates {
+				units, err := strconv.ParseFloat(rate.UnitPrice.Units, 64)
+				if err != nil && rate.UnitPrice.Units != "" {
+					return 0, fmt.Errorf("failed to parse the price of %q: %s", sku.Description, err)
+				}
+
+				// the first tier of some skus is free
+				price := units + float64(rate.UnitPrice.Nanos)/1e9
+				if price > 0 {
+					return price, nil
+				}
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("no on-demand price for %q in %s", description, region)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
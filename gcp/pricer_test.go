@@ -0,0 +1,90 @@
+package gcp_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pricer", func() {
+	var (
+		client *fakes.GCPClient
+		pricer gcp.Pricer
+	)
+
+	BeforeEach(func() {
+		client = &fakes.GCPClient{}
+		err := json.Unmarshal([]byte(`[
+			{
+				"description": "Preemptible N1 Predefined Instance Core running in Americas",
+				"serviceRegions": ["us-central1"],
+				"category": {"usageType": "Preemptible"},
+				"pricingInfo": [{"pricingExpression": {"tieredRates": [{"unitPrice": {"nanos": 6655000}}]}}]
+			},
+			{
+				"description": "N1 Predefined Instance Core running in EMEA",
+				"serviceRegions": ["europe-west1"],
+				"category": {"usageType": "OnDemand"},
+				"pricingInfo": [{"pricingExpression": {"tieredRates": [{"unitPrice": {"nanos": 34773000}}]}}]
+			},
+			{
+				"description": "N1 Predefined Instance Core running in Americas",
+				"serviceRegions": ["us-central1", "us-east1"],
+				"category": {"usageType": "OnDemand"},
+				"pricingInfo": [{"pricingExpression": {"tieredRates": [{"unitPrice": {"nanos": 31611000}}]}}]
+			},
+			{
+				"description": "Storage PD Capacity",
+				"serviceRegions": ["us-central1"],
+				"category": {"usageType": "OnDemand"},
+				"pricingInfo": [{"pricingExpression": {"tieredRates": [{"unitPrice": {}}, {"unitPrice": {"units": "1", "nanos": 40000000}}]}}]
+			}
+		]`), &client.ListSKUsCall.Returns.SKUs)
+		Expect(err).NotTo(HaveOccurred())
+
+		pricer = gcp.NewPricer(client)
+	})
+
+	Describe("Price", func() {
+		It("returns the on-demand price of the sku sold in the region", func() {
+			price, err := pricer.Price(gcp.ComputeEngineServiceID, "N1 Predefined Instance Core", "us-central1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(price).To(BeNumerically("~", 0.031611, 1e-9))
+
+			Expect(client.ListSKUsCall.Receives.ServiceID).To(Equal(gcp.ComputeEngineServiceID))
+		})
+
+		It("skips the free tiers", func() {
+			price, err := pricer.Price(gcp.ComputeEngineServiceID, "Storage PD Capacity", "us-central1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(price).To(BeNumerically("~", 1.04, 1e-9))
+		})
+
+		It("lists the skus of a service once", func() {
+			_, err := pricer.Price(gcp.ComputeEngineServiceID, "N1 Predefined Instance Core", "us-central1")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = pricer.Price(gcp.ComputeEngineServiceID, "N1 Predefined Instance Core", "europe-west1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.ListSKUsCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when no sku matches", func() {
+			_, err := pricer.Price(gcp.ComputeEngineServiceID, "N1 Predefined Instance Ram", "us-central1")
+			Expect(err).To(MatchError(`no on-demand price for "N1 Predefined Instance Ram" in us-central1`))
+		})
+
+		It("returns an error when the skus cannot be listed", func() {
+			client.ListSKUsCall.Returns.Error = errors.New("failed to list")
+
+			_, err := pricer.Price(gcp.ComputeEngineServiceID, "N1 Predefined Instance Core", "us-central1")
+			Expect(err).To(MatchError("failed to list"))
+		})
+	})
+})
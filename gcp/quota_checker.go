@@ -0,0 +1,98 @@
+package gcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	compute "google.golang.org/api/compute/v1"
+)
+
+const (
+	defaultDirectorMachineType = "n1-standard-1"
+	jumpboxMachineType         = "n1-standard-1"
+)
+
+type QuotaChecker struct {
+	client quotaGetter
+}
+
+func NewQuotaChecker(client quotaGetter) QuotaChecker {
+	return QuotaChecker{
+		client: client,
+	}
+}
+
+// Check verifies the project and region can hold the network, subnetwork,
+// director address and VM cores that a new bbl environment creates.
+func (q QuotaChecker) Check(region string, noDirector, jumpbox bool, directorVMType string) error {
+	project, err := q.client.GetProject()
+	if err != nil {
+		return err
+	}
+
+	err = checkQuotas(project.Quotas, map[string]int{"NETWORKS": 1, "SUBNETWORKS": 1})
+	if err != nil {
+		return err
+	}
+
+	cpus := 0
+	if !noDirector {
+		if directorVMType == "" {
+			directorVMType = defaultDirectorMachineType
+		}
+		cpus += machineTypeCPUs(directorVMType)
+	}
+	if jumpbox {
+		cpus += machineTypeCPUs(jumpboxMachineType)
+	}
+
+	regionQuotas, err := q.client.GetRegion(region)
+	if err != nil {
+		return err
+	}
+
+	// the static address is in use once it is attached to the jumpbox or the
+	// director
+	return checkQuotas(regionQuotas.Quotas, map[string]int{"STATIC_ADDRESSES": 1, "IN_USE_ADDRESSES": 1, "CPUS": cpus})
+}
+
+// machineTypeCPUs returns the cores of a machine type from its name, e.g. 8
+// for n1-standard-8 and 4 for custom-4-8192. Shared core machine types, like
+// f1-micro, count as one.
+func machineTypeCPUs(machineType string) int {
+	parts := strings.Split(machineType, "-")
+	if len(parts) >= 3 && parts[len(parts)-3] == "custom" {
+		cpus, err := strconv.Atoi(parts[len(parts)-2])
+		if err == nil {
+			return cpus
+		}
+	}
+
+	cpus, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 1
+	}
+
+	return cpus
+}
+
+func checkQuotas(quotas []*compute.Quota, needs map[string]int) error {
+	for _, quota := range quotas {
+		need, ok := needs[quota.Metric]
+		if !ok || need == 0 {
+			continue
+		}
+
+		have := int(quota.Limit - quota.Usage)
+		if have < 0 {
+			have = 0
+		}
+
+		if need > have {
+			return fmt.Errorf("quota %s: need %d, have %d", quota.Metric, need, have)
+		}
+	}
+
+	return nil
+}
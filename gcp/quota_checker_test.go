@@ -0,0 +1,129 @@
+package gcp_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	compute "google.golang.org/api/compute/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("quota checker", func() {
+	var (
+		client       *fakes.GCPClient
+		quotaChecker gcp.QuotaChecker
+	)
+
+	BeforeEach(func() {
+		client = &fakes.GCPClient{}
+		client.GetProjectCall.Returns.Project = &compute.Project{
+			Quotas: []*compute.Quota{
+				{Metric: "NETWORKS", Limit: 5, Usage: 2},
+				{Metric: "SUBNETWORKS", Limit: 100, Usage: 10},
+				{Metric: "FIREWALLS", Limit: 100, Usage: 100},
+			},
+		}
+		client.GetRegionCall.Returns.Region = &compute.Region{
+			Quotas: []*compute.Quota{
+				{Metric: "CPUS", Limit: 24, Usage: 4},
+				{Metric: "STATIC_ADDRESSES", Limit: 8, Usage: 1},
+				{Metric: "IN_USE_ADDRESSES", Limit: 8, Usage: 2},
+			},
+		}
+
+		quotaChecker = gcp.NewQuotaChecker(client)
+	})
+
+	Describe("Check", func() {
+		It("returns nil when the project has room for the environment", func() {
+			err := quotaChecker.Check("some-region", false, true, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.GetProjectCall.CallCount).To(Equal(1))
+			Expect(client.GetRegionCall.Receives.Region).To(Equal("some-region"))
+		})
+
+		It("returns an error when there are no networks left", func() {
+			client.GetProjectCall.Returns.Project.Quotas[0].Usage = 5
+
+			err := quotaChecker.Check("some-region", false, false, "")
+			Expect(err).To(MatchError("quota NETWORKS: need 1, have 0"))
+		})
+
+		It("returns an error when there are no subnetworks left", func() {
+			client.GetProjectCall.Returns.Project.Quotas[1].Usage = 100
+
+			err := quotaChecker.Check("some-region", false, false, "")
+			Expect(err).To(MatchError("quota SUBNETWORKS: need 1, have 0"))
+		})
+
+		It("returns an error when there are no static addresses left", func() {
+			client.GetRegionCall.Returns.Region.Quotas[1].Usage = 8
+
+			err := quotaChecker.Check("some-region", false, false, "")
+			Expect(err).To(MatchError("quota STATIC_ADDRESSES: need 1, have 0"))
+		})
+
+		It("returns an error when there are no addresses left to use", func() {
+			client.GetRegionCall.Returns.Region.Quotas[2].Usage = 8
+
+			err := quotaChecker.Check("some-region", false, false, "")
+			Expect(err).To(MatchError("quota IN_USE_ADDRESSES: need 1, have 0"))
+		})
+
+		Context("when checking cpus", func() {
+			BeforeEach(func() {
+				client.GetRegionCall.Returns.Region.Quotas[0].Usage = 23
+			})
+
+			It("needs a cpu for the director", func() {
+				err := quotaChecker.Check("some-region", false, false, "")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("needs another cpu for the jumpbox", func() {
+				err := quotaChecker.Check("some-region", false, true, "")
+				Expect(err).To(MatchError("quota CPUS: need 2, have 1"))
+			})
+
+			It("needs the cores of the director's machine type", func() {
+				client.GetRegionCall.Returns.Region.Quotas[0].Usage = 20
+
+				err := quotaChecker.Check("some-region", false, false, "n1-standard-4")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = quotaChecker.Check("some-region", false, false, "n1-standard-8")
+				Expect(err).To(MatchError("quota CPUS: need 8, have 4"))
+
+				err = quotaChecker.Check("some-region", false, false, "custom-6-16384")
+				Expect(err).To(MatchError("quota CPUS: need 6, have 4"))
+			})
+
+			It("does not need cpus when there is no director or jumpbox", func() {
+				client.GetRegionCall.Returns.Region.Quotas[0].Usage = 24
+
+				err := quotaChecker.Check("some-region", true, false, "")
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the project cannot be retrieved", func() {
+				client.GetProjectCall.Returns.Error = errors.New("failed to get project")
+
+				err := quotaChecker.Check("some-region", false, false, "")
+				Expect(err).To(MatchError("failed to get project"))
+			})
+
+			It("returns an error when the region cannot be retrieved", func() {
+				client.GetRegionCall.Returns.Error = errors.New("failed to get region")
+
+				err := quotaChecker.Check("some-region", false, false, "")
+				Expect(err).To(MatchError("failed to get region"))
+			})
+		})
+	})
+})
@@ -0,0 +1,94 @@
+package gcp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	MaxRetries   = 8
+	retryBackoff = time.Second
+	maxBackoff   = 32 * time.Second
+)
+
+var sleep = time.Sleep
+
+// retryTransport retries requests that GCP rejected because a rate limit or
+// quota per interval was exceeded, with jittered exponential backoff, rather
+// than failing bbl up midway.
+type retryTransport struct {
+	transport http.RoundTripper
+}
+
+func newRetryTransport(transport http.RoundTripper) retryTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return retryTransport{transport: transport}
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// requests whose body cannot be sent again are not retried
+	rewindable := req.Body == nil || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.transport.RoundTrip(req)
+		if err != nil || !rewindable || attempt == MaxRetries {
+			return resp, err
+		}
+
+		limited, err := rateLimited(resp)
+		if err != nil || !limited {
+			return resp, err
+		}
+		resp.Body.Close()
+
+		sleep(backoff(attempt))
+
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			retry := *req
+			retry.Body = body
+			req = &retry
+		}
+	}
+}
+
+// rateLimited reads the body of forbidden responses for the reason of the
+// error, and leaves it readable for the caller.
+func rateLimited(resp *http.Response) (bool, error) {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, nil
+	case http.StatusForbidden:
+	default:
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return bytes.Contains(body, []byte(`"rateLimitExceeded"`)) ||
+		bytes.Contains(body, []byte(`"userRateLimitExceeded"`)), nil
+}
+
+func backoff(attempt int) time.Duration {
+	delay := retryBackoff << uint(attempt)
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
@@ -1,6 +1,10 @@
 package helpers
 
-import "regexp"
+import (
+	"os"
+	"regexp"
+	"time"
+)
 
 func SetMatchString(f func(string, string) (bool, error)) {
 	matchString = f
@@ -9,3 +13,19 @@ func SetMatchString(f func(string, string) (bool, error)) {
 func ResetMatchString() {
 	matchString = regexp.MatchString
 }
+
+func SetExecutable(f func() (string, error)) {
+	executable = f
+}
+
+func ResetExecutable() {
+	executable = os.Executable
+}
+
+func SetInterruptGracePeriod(d time.Duration) {
+	interruptGracePeriod = d
+}
+
+func ResetInterruptGracePeriod() {
+	interruptGracePeriod = 2 * time.Minute
+}
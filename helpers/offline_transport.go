@@ -0,0 +1,23 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// OfflineTransport refuses every request, for the http clients bbl would
+// otherwise use to download from the public internet when it runs with
+// --offline.
+type OfflineTransport struct {
+	artifactsDir string
+}
+
+func NewOfflineTransport(artifactsDir string) OfflineTransport {
+	return OfflineTransport{
+		artifactsDir: artifactsDir,
+	}
+}
+
+func (t OfflineTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("bbl is offline and does not download %s, put it in the artifacts dir %s", request.URL, t.artifactsDir)
+}
@@ -0,0 +1,27 @@
+package helpers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OfflineTransport", func() {
+	It("refuses requests without sending them", func() {
+		requestCount := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestCount++
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: helpers.NewOfflineTransport("/some/artifacts")}
+
+		_, err := client.Get(server.URL + "/some/file.tgz")
+		Expect(err).To(MatchError(ContainSubstring("bbl is offline and does not download " + server.URL + "/some/file.tgz, put it in the artifacts dir /some/artifacts")))
+		Expect(requestCount).To(Equal(0))
+	})
+})
@@ -0,0 +1,39 @@
+package helpers
+
+import "sync"
+
+const LatestErrorOutputSize = 64 * 1024
+
+type OutputTail struct {
+	mutex *sync.Mutex
+	size  int
+	data  *[]byte
+}
+
+func NewOutputTail(size int) OutputTail {
+	return OutputTail{
+		mutex: &sync.Mutex{},
+		size:  size,
+		data:  &[]byte{},
+	}
+}
+
+func (o OutputTail) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	data := append(*o.data, p...)
+	if len(data) > o.size {
+		data = append([]byte{}, data[len(data)-o.size:]...)
+	}
+	*o.data = data
+
+	return len(p), nil
+}
+
+func (o OutputTail) String() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return string(*o.data)
+}
@@ -0,0 +1,33 @@
+package helpers_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OutputTail", func() {
+	It("keeps everything written while under the size limit", func() {
+		outputTail := helpers.NewOutputTail(16)
+
+		n, err := outputTail.Write([]byte("some output"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(11))
+
+		Expect(outputTail.String()).To(Equal("some output"))
+	})
+
+	It("keeps only the most recent output once the size limit is exceeded", func() {
+		outputTail := helpers.NewOutputTail(10)
+
+		_, err := outputTail.Write([]byte("first line\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		n, err := outputTail.Write([]byte("second line\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(12))
+
+		Expect(outputTail.String()).To(Equal("cond line\n"))
+	})
+})
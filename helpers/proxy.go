@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// metadataHosts serve the instance metadata and credentials of the vm bbl
+// runs on. They are link-local, so a proxy can never reach them.
+var metadataHosts = map[string]bool{
+	"169.254.169.254":          true,
+	"metadata.google.internal": true,
+}
+
+// ProxyFromEnvironment returns the proxy HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY set for a request, like http.ProxyFromEnvironment, except for the
+// IAAS metadata endpoints, which are always reached directly.
+func ProxyFromEnvironment(request *http.Request) (*url.URL, error) {
+	if metadataHosts[request.URL.Hostname()] {
+		return nil, nil
+	}
+
+	return http.ProxyFromEnvironment(request)
+}
+
+// NewHTTPClient returns a client with the timeouts of http.DefaultClient that
+// goes through the proxy of ProxyFromEnvironment, for the IAAS APIs and the
+// downloads bbl makes.
+func NewHTTPClient() *http.Client {
+	return &http.Client{Transport: NewProxyTransport()}
+}
+
+func NewProxyTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
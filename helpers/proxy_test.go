@@ -0,0 +1,51 @@
+package helpers_test
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProxyFromEnvironment", func() {
+	BeforeEach(func() {
+		os.Setenv("HTTP_PROXY", "http://some-proxy:3128")
+		os.Setenv("HTTPS_PROXY", "http://some-proxy:3128")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("HTTP_PROXY")
+		os.Unsetenv("HTTPS_PROXY")
+	})
+
+	It("uses the proxy of the environment for the IAAS apis and downloads", func() {
+		request, err := http.NewRequest("GET", "https://ec2.us-east-1.amazonaws.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		proxyURL, err := helpers.ProxyFromEnvironment(request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proxyURL.String()).To(Equal("http://some-proxy:3128"))
+	})
+
+	DescribeTable("reaches the metadata endpoints directly", func(url string) {
+		request, err := http.NewRequest("GET", url, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		proxyURL, err := helpers.ProxyFromEnvironment(request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proxyURL).To(BeNil())
+	},
+		Entry("aws and azure", "http://169.254.169.254/metadata/identity/oauth2/token"),
+		Entry("gcp", "http://metadata.google.internal:80/computeMetadata/v1/"),
+	)
+
+	It("is the proxy of the http client", func() {
+		transport := helpers.NewHTTPClient().Transport.(*http.Transport)
+		Expect(transport.Proxy).NotTo(BeNil())
+		Expect(transport.TLSHandshakeTimeout).NotTo(BeZero())
+	})
+})
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	SSHKeyTypeRSA     = "rsa"
+	SSHKeyTypeED25519 = "ed25519"
+
+	DefaultRSAKeyBits = 2048
+	minimumRSAKeyBits = 2048
+)
+
+type rsaKeyGenerator func(io.Reader, int) (*rsa.PrivateKey, error)
+type ed25519KeyGenerator func(io.Reader) (ed25519.PublicKey, ed25519.PrivateKey, error)
+type sshPublicKeyGenerator func(interface{}) (ssh.PublicKey, error)
+
+type SSHKeyGenerator struct {
+	random                io.Reader
+	rsaKeyGenerator       rsaKeyGenerator
+	ed25519KeyGenerator   ed25519KeyGenerator
+	sshPublicKeyGenerator sshPublicKeyGenerator
+}
+
+func NewSSHKeyGenerator(random io.Reader, generateRSAKey rsaKeyGenerator, generateED25519Key ed25519KeyGenerator, generateSSHPublicKey sshPublicKeyGenerator) SSHKeyGenerator {
+	return SSHKeyGenerator{
+		random:                random,
+		rsaKeyGenerator:       generateRSAKey,
+		ed25519KeyGenerator:   generateED25519Key,
+		sshPublicKeyGenerator: generateSSHPublicKey,
+	}
+}
+
+func ValidateSSHKeyType(keyType string, bits int) error {
+	switch keyType {
+	case "", SSHKeyTypeRSA:
+		if bits != 0 && bits < minimumRSAKeyBits {
+			return fmt.Errorf("ssh key bits must be at least %d", minimumRSAKeyBits)
+		}
+	case SSHKeyTypeED25519:
+		if bits != 0 {
+			return fmt.Errorf("ssh key bits are only supported for %s keys", SSHKeyTypeRSA)
+		}
+	default:
+		return fmt.Errorf("ssh key type must be one of %q or %q", SSHKeyTypeRSA, SSHKeyTypeED25519)
+	}
+
+	return nil
+}
+
+// Generate returns a PEM encoded private key and its public key in
+// authorized_keys format. An empty key type generates an RSA key.
+func (g SSHKeyGenerator) Generate(keyType string, bits int) (string, string, error) {
+	err := ValidateSSHKeyType(keyType, bits)
+	if err != nil {
+		return "", "", err
+	}
+
+	var (
+		publicKey  interface{}
+		privateKey []byte
+	)
+
+	switch keyType {
+	case SSHKeyTypeED25519:
+		edPublicKey, edPrivateKey, err := g.ed25519KeyGenerator(g.random)
+		if err != nil {
+			return "", "", err
+		}
+
+		publicKey = edPublicKey
+		privateKey, err = g.marshalED25519PrivateKey(edPublicKey, edPrivateKey)
+		if err != nil {
+			return "", "", err
+		}
+	default:
+		if bits == 0 {
+			bits = DefaultRSAKeyBits
+		}
+
+		rsaKey, err := g.rsaKeyGenerator(g.random, bits)
+		if err != nil {
+			return "", "", err
+		}
+
+		publicKey = rsaKey.Public()
+		privateKey = pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+		})
+	}
+
+	sshPublicKey, err := g.sshPublicKeyGenerator(publicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	rawPublicKey := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPublicKey)), "\n")
+
+	return string(privateKey), rawPublicKey, nil
+}
+
+// marshalED25519PrivateKey encodes the key in the unencrypted openssh-key-v1
+// format, which is the only PEM format ssh clients accept for ed25519 keys.
+func (g SSHKeyGenerator) marshalED25519PrivateKey(publicKey ed25519.PublicKey, privateKey ed25519.PrivateKey) ([]byte, error) {
+	check := make([]byte, 4)
+	_, err := io.ReadFull(g.random, check)
+	if err != nil {
+		return nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check)
+
+	publicKeyBlock := ssh.Marshal(struct {
+		KeyType   string
+		PublicKey []byte
+	}{ssh.KeyAlgoED25519, publicKey})
+
+	privateKeyBlock := struct {
+		Check1     uint32
+		Check2     uint32
+		KeyType    string
+		PublicKey  []byte
+		PrivateKey []byte
+		Comment    string
+		Pad        []byte `ssh:"rest"`
+	}{
+		Check1:     checkInt,
+		Check2:     checkInt,
+		KeyType:    ssh.KeyAlgoED25519,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}
+
+	for i := 0; len(ssh.Marshal(privateKeyBlock))%8 != 0; i++ {
+		privateKeyBlock.Pad = append(privateKeyBlock.Pad, byte(i+1))
+	}
+
+	key := ssh.Marshal(struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{"none", "none", "", 1, publicKeyBlock, ssh.Marshal(privateKeyBlock)})
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte("openssh-key-v1\x00"), key...),
+	}), nil
+}
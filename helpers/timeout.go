@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// interruptGracePeriod is how long a command that timed out has to save its
+// state after it is interrupted, before it is killed.
+var interruptGracePeriod = 2 * time.Minute
+
+// Timeout bounds how long an operation may run: by its own limit, by the
+// deadline of the whole bbl run, or by whichever comes first. The zero value
+// does not bound anything.
+type Timeout struct {
+	Limit    time.Duration
+	Deadline time.Time
+}
+
+// Duration returns how long the operation may run when it starts now, or 0
+// when it is not bounded.
+func (t Timeout) Duration() time.Duration {
+	duration := t.Limit
+	if !t.Deadline.IsZero() {
+		remaining := t.Deadline.Sub(time.Now())
+		if remaining <= 0 {
+			remaining = time.Nanosecond
+		}
+		if duration == 0 || remaining < duration {
+			duration = remaining
+		}
+	}
+
+	return duration
+}
+
+type TimeoutError struct {
+	Name     string
+	Duration time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.Name, e.Duration)
+}
+
+// RunCommand runs the command to completion, or until the timeout. A command
+// that times out is interrupted, so that terraform and bosh write their state
+// before they exit, and killed if it is still running after the grace period.
+func RunCommand(command *exec.Cmd, name string, timeout Timeout) error {
+	duration := timeout.Duration()
+	if duration == 0 {
+		return command.Run()
+	}
+
+	err := command.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- command.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(duration):
+	}
+
+	command.Process.Signal(os.Interrupt)
+	select {
+	case <-done:
+	case <-time.After(interruptGracePeriod):
+		command.Process.Kill()
+		<-done
+	}
+
+	if duration > time.Second {
+		duration = duration.Round(time.Second)
+	}
+
+	return TimeoutError{Name: name, Duration: duration}
+}
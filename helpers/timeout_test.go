@@ -0,0 +1,75 @@
+package helpers_test
+
+import (
+	"os/exec"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timeout", func() {
+	Describe("Duration", func() {
+		It("does not bound anything by default", func() {
+			Expect(helpers.Timeout{}.Duration()).To(Equal(time.Duration(0)))
+		})
+
+		It("returns the limit", func() {
+			Expect(helpers.Timeout{Limit: time.Minute}.Duration()).To(Equal(time.Minute))
+		})
+
+		It("returns the time left until the deadline when it comes first", func() {
+			timeout := helpers.Timeout{Limit: time.Hour, Deadline: time.Now().Add(time.Minute)}
+			Expect(timeout.Duration()).To(BeNumerically("~", time.Minute, time.Second))
+
+			timeout = helpers.Timeout{Deadline: time.Now().Add(time.Minute)}
+			Expect(timeout.Duration()).To(BeNumerically("~", time.Minute, time.Second))
+		})
+
+		It("returns the limit when the deadline comes later", func() {
+			timeout := helpers.Timeout{Limit: time.Minute, Deadline: time.Now().Add(time.Hour)}
+			Expect(timeout.Duration()).To(Equal(time.Minute))
+		})
+
+		It("leaves no time once the deadline passed", func() {
+			timeout := helpers.Timeout{Deadline: time.Now().Add(-time.Minute)}
+			Expect(timeout.Duration()).To(Equal(time.Nanosecond))
+		})
+	})
+
+	Describe("RunCommand", func() {
+		AfterEach(func() {
+			helpers.ResetInterruptGracePeriod()
+		})
+
+		It("runs the command without a timeout", func() {
+			err := helpers.RunCommand(exec.Command("sh", "-c", "exit 3"), "sh", helpers.Timeout{})
+			Expect(err).To(MatchError("exit status 3"))
+		})
+
+		It("returns the result of a command that finishes in time", func() {
+			err := helpers.RunCommand(exec.Command("true"), "true", helpers.Timeout{Limit: time.Minute})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("interrupts a command that times out", func() {
+			started := time.Now()
+
+			err := helpers.RunCommand(exec.Command("sleep", "10"), "sleep", helpers.Timeout{Limit: 100 * time.Millisecond})
+			Expect(err).To(MatchError("sleep timed out after 100ms"))
+			Expect(err).To(BeAssignableToTypeOf(helpers.TimeoutError{}))
+			Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("kills a command that is still running after the grace period", func() {
+			helpers.SetInterruptGracePeriod(100 * time.Millisecond)
+			started := time.Now()
+
+			err := helpers.RunCommand(exec.Command("sh", "-c", `trap "" INT; sleep 10`), "sh", helpers.Timeout{Limit: 100 * time.Millisecond})
+			Expect(err).To(MatchError("sh timed out after 100ms"))
+			Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+		})
+	})
+})
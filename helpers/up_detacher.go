@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const UpLogFileName = storage.UpLogFileName
+
+var executable = os.Executable
+
+type UpDetacher struct {
+	stateDir string
+	args     []string
+}
+
+func NewUpDetacher(stateDir string, args []string) UpDetacher {
+	return UpDetacher{
+		stateDir: stateDir,
+		args:     args,
+	}
+}
+
+func (u UpDetacher) LogPath() string {
+	return filepath.Join(u.stateDir, UpLogFileName)
+}
+
+func (u UpDetacher) Detach() (int, error) {
+	bblPath, err := executable()
+	if err != nil {
+		return 0, err
+	}
+
+	logFile, err := os.OpenFile(u.LogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.FileMode(0644))
+	if err != nil {
+		return 0, err
+	}
+	defer logFile.Close()
+
+	var args []string
+	for _, arg := range u.args[1:] {
+		if arg == "--detach" || arg == "-detach" || strings.HasPrefix(arg, "--detach=") || strings.HasPrefix(arg, "-detach=") {
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	command := exec.Command(bblPath, args...)
+	command.Stdout = logFile
+	command.Stderr = logFile
+	command.SysProcAttr = detachedProcAttr()
+
+	err = command.Start()
+	if err != nil {
+		return 0, err
+	}
+
+	pid := command.Process.Pid
+
+	err = command.Process.Release()
+	if err != nil {
+		return 0, err //not tested
+	}
+
+	return pid, nil
+}
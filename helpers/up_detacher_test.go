@@ -0,0 +1,98 @@
+package helpers_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpDetacher", func() {
+	var (
+		stateDir   string
+		upDetacher helpers.UpDetacher
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		helpers.SetExecutable(func() (string, error) {
+			return "/bin/echo", nil
+		})
+
+		upDetacher = helpers.NewUpDetacher(stateDir, []string{"bbl", "--state-dir", stateDir, "up", "--detach", "--name", "some-name"})
+	})
+
+	AfterEach(func() {
+		helpers.ResetExecutable()
+		os.RemoveAll(stateDir)
+	})
+
+	Describe("LogPath", func() {
+		It("returns the path of the log file in the state dir", func() {
+			Expect(upDetacher.LogPath()).To(Equal(filepath.Join(stateDir, "bbl-up.log")))
+		})
+	})
+
+	Describe("Detach", func() {
+		It("re-runs bbl without the detach flag in the background, logging to the state dir", func() {
+			pid, err := upDetacher.Detach()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pid).NotTo(BeZero())
+
+			Eventually(func() (string, error) {
+				contents, err := ioutil.ReadFile(filepath.Join(stateDir, "bbl-up.log"))
+				return string(contents), err
+			}).Should(Equal("--state-dir " + stateDir + " up --name some-name\n"))
+		})
+
+		It("starts bbl in a new session so that it survives a terminal hangup", func() {
+			scriptPath := filepath.Join(stateDir, "print-session")
+			err := ioutil.WriteFile(scriptPath, []byte("#!/bin/sh\necho \"$$ $(ps -o sid= -p $$)\"\n"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			helpers.SetExecutable(func() (string, error) {
+				return scriptPath, nil
+			})
+
+			_, err = upDetacher.Detach()
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() ([]string, error) {
+				contents, err := ioutil.ReadFile(filepath.Join(stateDir, "bbl-up.log"))
+				return strings.Fields(string(contents)), err
+			}).Should(HaveLen(2))
+
+			contents, err := ioutil.ReadFile(filepath.Join(stateDir, "bbl-up.log"))
+			Expect(err).NotTo(HaveOccurred())
+
+			ids := strings.Fields(string(contents))
+			Expect(ids[1]).To(Equal(ids[0]))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the bbl executable cannot be found", func() {
+				helpers.SetExecutable(func() (string, error) {
+					return "", errors.New("failed to find executable")
+				})
+
+				_, err := upDetacher.Detach()
+				Expect(err).To(MatchError("failed to find executable"))
+			})
+
+			It("returns an error when the log file cannot be opened", func() {
+				upDetacher = helpers.NewUpDetacher("/some/missing/dir", []string{"bbl", "up", "--detach"})
+
+				_, err := upDetacher.Detach()
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+	})
+})
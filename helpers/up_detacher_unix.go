@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package helpers
+
+import "syscall"
+
+// detachedProcAttr starts the detached bbl in a session of its own, so that
+// it is not sent a SIGHUP when the terminal that started it goes away.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,9 @@
+package helpers
+
+import "syscall"
+
+// detachedProcAttr starts the detached bbl in a process group of its own, so
+// that it does not receive the console's Ctrl+C and close events.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -14,6 +14,7 @@ type Manager struct {
 	keyPairSynchronizer keyPairSynchronizer
 	keyPairDeleter      keyPairDeleter
 	clientProvider      clientProvider
+	keyPairChecker      keyPairChecker
 }
 
 type keyPairSynchronizer interface {
@@ -28,15 +29,25 @@ type clientProvider interface {
 	SetConfig(config aws.Config)
 }
 
-func NewManager(keyPairSynchronizer keyPairSynchronizer, keyPairDeleter keyPairDeleter, clientProvider clientProvider) Manager {
+type keyPairChecker interface {
+	HasKeyPair(keyPairName string) (bool, error)
+}
+
+func NewManager(keyPairSynchronizer keyPairSynchronizer, keyPairDeleter keyPairDeleter, clientProvider clientProvider,
+	keyPairChecker keyPairChecker) Manager {
 	return Manager{
 		keyPairSynchronizer: keyPairSynchronizer,
 		keyPairDeleter:      keyPairDeleter,
 		clientProvider:      clientProvider,
+		keyPairChecker:      keyPairChecker,
 	}
 }
 
 func (m Manager) Sync(state storage.State) (storage.State, error) {
+	if state.KeyPair.External {
+		return m.verify(state)
+	}
+
 	if state.EnvID == "" {
 		return storage.State{}, errors.New("env id must be set to generate a keypair")
 	}
@@ -49,6 +60,8 @@ func (m Manager) Sync(state storage.State) (storage.State, error) {
 		Name:       state.KeyPair.Name,
 		PublicKey:  state.KeyPair.PublicKey,
 		PrivateKey: state.KeyPair.PrivateKey,
+		Type:       state.KeyPair.Type,
+		Bits:       state.KeyPair.Bits,
 	})
 	if err != nil {
 		return storage.State{}, keypair.NewManagerError(state, err)
@@ -60,6 +73,21 @@ func (m Manager) Sync(state storage.State) (storage.State, error) {
 	return state, nil
 }
 
+// verify checks that a key pair managed outside of bbl exists, since bbl
+// uses it as is rather than creating one.
+func (m Manager) verify(state storage.State) (storage.State, error) {
+	exists, err := m.keyPairChecker.HasKeyPair(state.KeyPair.Name)
+	if err != nil {
+		return storage.State{}, keypair.NewManagerError(state, err)
+	}
+
+	if !exists {
+		return storage.State{}, keypair.NewManagerError(state, fmt.Errorf("keypair %q does not exist", state.KeyPair.Name))
+	}
+
+	return state, nil
+}
+
 func (m Manager) Rotate(state storage.State) (storage.State, error) {
 	if state.KeyPair.IsEmpty() {
 		return storage.State{}, errors.New("no key found to rotate")
@@ -68,6 +96,7 @@ func (m Manager) Rotate(state storage.State) (storage.State, error) {
 	m.clientProvider.SetConfig(aws.Config{
 		AccessKeyID:     state.AWS.AccessKeyID,
 		SecretAccessKey: state.AWS.SecretAccessKey,
+		SessionToken:    state.AWS.SessionToken,
 		Region:          state.AWS.Region,
 	})
 
@@ -78,6 +107,8 @@ func (m Manager) Rotate(state storage.State) (storage.State, error) {
 
 	keyPair, err := m.keyPairSynchronizer.Sync(ec2.KeyPair{
 		Name: state.KeyPair.Name,
+		Type: state.KeyPair.Type,
+		Bits: state.KeyPair.Bits,
 	})
 	if err != nil {
 		return storage.State{}, err
@@ -20,6 +20,7 @@ var _ = Describe("Manager", func() {
 			awsKeyPairSynchronizer *fakes.AWSKeyPairSynchronizer
 			awsKeyPairDeleter      *fakes.AWSKeyPairDeleter
 			awsClientProvider      *fakes.AWSClientProvider
+			keyPairChecker         *fakes.KeyPairChecker
 			keyPairManager         keypairaws.Manager
 		)
 
@@ -27,6 +28,7 @@ var _ = Describe("Manager", func() {
 			awsKeyPairSynchronizer = &fakes.AWSKeyPairSynchronizer{}
 			awsKeyPairDeleter = &fakes.AWSKeyPairDeleter{}
 			awsClientProvider = &fakes.AWSClientProvider{}
+			keyPairChecker = &fakes.KeyPairChecker{}
 
 			awsKeyPairSynchronizer.SyncCall.Returns.KeyPair = ec2.KeyPair{
 				Name:       "some-keypair-name",
@@ -34,7 +36,7 @@ var _ = Describe("Manager", func() {
 				PublicKey:  "some-new-public-key",
 			}
 
-			keyPairManager = keypairaws.NewManager(awsKeyPairSynchronizer, awsKeyPairDeleter, awsClientProvider)
+			keyPairManager = keypairaws.NewManager(awsKeyPairSynchronizer, awsKeyPairDeleter, awsClientProvider, keyPairChecker)
 		})
 
 		Context("when the keypair is empty", func() {
@@ -87,6 +89,23 @@ var _ = Describe("Manager", func() {
 				}))
 			})
 
+			It("creates the new keypair with the requested key type", func() {
+				_, err := keyPairManager.Rotate(storage.State{
+					KeyPair: storage.KeyPair{
+						Name:       "some-keypair-name",
+						PrivateKey: "some-existing-private-key",
+						PublicKey:  "some-existing-public-key",
+						Type:       "ed25519",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(awsKeyPairSynchronizer.SyncCall.Receives.KeyPair).To(Equal(ec2.KeyPair{
+					Name: "some-keypair-name",
+					Type: "ed25519",
+				}))
+			})
+
 		})
 
 		Context("failure cases", func() {
@@ -121,6 +140,7 @@ var _ = Describe("Manager", func() {
 			awsKeyPairSynchronizer *fakes.AWSKeyPairSynchronizer
 			awsKeyPairDeleter      *fakes.AWSKeyPairDeleter
 			awsClientProvider      *fakes.AWSClientProvider
+			keyPairChecker         *fakes.KeyPairChecker
 
 			keyPairManager keypairaws.Manager
 
@@ -131,11 +151,12 @@ var _ = Describe("Manager", func() {
 			awsKeyPairSynchronizer = &fakes.AWSKeyPairSynchronizer{}
 			awsKeyPairDeleter = &fakes.AWSKeyPairDeleter{}
 			awsClientProvider = &fakes.AWSClientProvider{}
+			keyPairChecker = &fakes.KeyPairChecker{}
 			incomingState = storage.State{
 				EnvID: "some-env-id",
 			}
 
-			keyPairManager = keypairaws.NewManager(awsKeyPairSynchronizer, awsKeyPairDeleter, awsClientProvider)
+			keyPairManager = keypairaws.NewManager(awsKeyPairSynchronizer, awsKeyPairDeleter, awsClientProvider, keyPairChecker)
 		})
 
 		It("generates a keypair name if one doesn't exist", func() {
@@ -197,6 +218,40 @@ var _ = Describe("Manager", func() {
 			})
 		})
 
+		Context("when the keypair is managed outside of bbl", func() {
+			BeforeEach(func() {
+				incomingState.KeyPair = storage.KeyPair{
+					Name:       "some-existing-keypair",
+					PrivateKey: "some-private-key",
+					External:   true,
+				}
+			})
+
+			It("uses the existing keypair without creating one", func() {
+				keyPairChecker.HasKeyPairCall.Returns.Present = true
+
+				updatedState, err := keyPairManager.Sync(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(updatedState).To(Equal(incomingState))
+
+				Expect(keyPairChecker.HasKeyPairCall.Recieves.Name).To(Equal("some-existing-keypair"))
+				Expect(awsKeyPairSynchronizer.SyncCall.CallCount).To(Equal(0))
+			})
+
+			It("returns a manager error when the keypair does not exist", func() {
+				_, err := keyPairManager.Sync(incomingState)
+				Expect(err).To(MatchError(keypair.NewManagerError(incomingState, errors.New(`keypair "some-existing-keypair" does not exist`))))
+				Expect(awsKeyPairSynchronizer.SyncCall.CallCount).To(Equal(0))
+			})
+
+			It("returns a manager error when the keypair cannot be checked", func() {
+				keyPairChecker.HasKeyPairCall.Returns.Error = errors.New("failed to describe keypairs")
+
+				_, err := keyPairManager.Sync(incomingState)
+				Expect(err).To(MatchError(keypair.NewManagerError(incomingState, errors.New("failed to describe keypairs"))))
+			})
+		})
+
 		Context("failure cases", func() {
 			Context("when the state doesn't have an env id", func() {
 				It("returns an error", func() {
@@ -12,7 +12,7 @@ type Manager struct {
 }
 
 type keyPairUpdater interface {
-	Update() (storage.KeyPair, error)
+	Update(keyType string, bits int) (storage.KeyPair, error)
 }
 
 type keyPairDeleter interface {
@@ -28,7 +28,7 @@ func NewManager(keyPairUpdater keyPairUpdater, keyPairDeleter keyPairDeleter) Ma
 
 func (m Manager) Sync(state storage.State) (storage.State, error) {
 	if state.KeyPair.IsEmpty() {
-		keyPair, err := m.keyPairUpdater.Update()
+		keyPair, err := m.keyPairUpdater.Update(state.KeyPair.Type, state.KeyPair.Bits)
 		if err != nil {
 			return storage.State{}, err
 		}
@@ -48,7 +48,7 @@ func (m Manager) Rotate(state storage.State) (storage.State, error) {
 		return storage.State{}, err
 	}
 
-	keyPair, err := m.keyPairUpdater.Update()
+	keyPair, err := m.keyPairUpdater.Update(state.KeyPair.Type, state.KeyPair.Bits)
 	if err != nil {
 		return storage.State{}, err
 	}
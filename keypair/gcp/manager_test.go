@@ -44,6 +44,20 @@ var _ = Describe("Manager", func() {
 				}))
 			})
 
+			It("generates the requested key type", func() {
+				_, err := keyPairManager.Sync(storage.State{
+					KeyPair: storage.KeyPair{
+						Type: "rsa",
+						Bits: 4096,
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairUpdater.UpdateCall.CallCount).To(Equal(1))
+				Expect(keyPairUpdater.UpdateCall.Receives.KeyType).To(Equal("rsa"))
+				Expect(keyPairUpdater.UpdateCall.Receives.Bits).To(Equal(4096))
+			})
+
 			Context("failure cases", func() {
 				It("returns an error when key pair updater fails", func() {
 					keyPairUpdater.UpdateCall.Returns.Error = errors.New("failed to update")
@@ -131,6 +145,19 @@ var _ = Describe("Manager", func() {
 					},
 				}))
 			})
+
+			It("generates the new keypair with the requested key type", func() {
+				_, err := keyPairManager.Rotate(storage.State{
+					KeyPair: storage.KeyPair{
+						PrivateKey: "some-existing-private-key",
+						PublicKey:  "some-existing-public-key",
+						Type:       "ed25519",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(keyPairUpdater.UpdateCall.Receives.KeyType).To(Equal("ed25519"))
+			})
 		})
 
 		Context("failure cases", func() {
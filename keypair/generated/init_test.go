@@ -0,0 +1,13 @@
+package generated_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenerated(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "keypair/generated")
+}
@@ -0,0 +1,57 @@
+package generated
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// Manager keeps the key pair in the bbl state only, for the iaases where
+// nothing but terraform and the cpi use it. On azure the public key reaches
+// the director vm through the cpi's ssh variable, on openstack terraform
+// registers it as the nova keypair the cpi uses as its default key.
+type Manager struct {
+	sshKeyGenerator sshKeyGenerator
+}
+
+type sshKeyGenerator interface {
+	Generate(keyType string, bits int) (string, string, error)
+}
+
+func NewManager(sshKeyGenerator sshKeyGenerator) Manager {
+	return Manager{
+		sshKeyGenerator: sshKeyGenerator,
+	}
+}
+
+func (m Manager) Sync(state storage.State) (storage.State, error) {
+	if state.KeyPair.IsEmpty() {
+		return m.generate(state)
+	}
+
+	return state, nil
+}
+
+func (m Manager) Rotate(state storage.State) (storage.State, error) {
+	if state.KeyPair.IsEmpty() {
+		return storage.State{}, errors.New("no key found to rotate")
+	}
+
+	return m.generate(state)
+}
+
+func (m Manager) generate(state storage.State) (storage.State, error) {
+	privateKey, publicKey, err := m.sshKeyGenerator.Generate(state.KeyPair.Type, state.KeyPair.Bits)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	state.KeyPair = storage.KeyPair{
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+		Type:       state.KeyPair.Type,
+		Bits:       state.KeyPair.Bits,
+	}
+
+	return state, nil
+}
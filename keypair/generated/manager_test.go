@@ -0,0 +1,110 @@
+package generated_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/keypair/generated"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Manager", func() {
+	var (
+		sshKeyGenerator *fakes.SSHKeyGenerator
+		keyPairManager  generated.Manager
+	)
+
+	BeforeEach(func() {
+		sshKeyGenerator = &fakes.SSHKeyGenerator{}
+		sshKeyGenerator.GenerateCall.Returns.PrivateKey = "some-private-key"
+		sshKeyGenerator.GenerateCall.Returns.PublicKey = "some-public-key"
+
+		keyPairManager = generated.NewManager(sshKeyGenerator)
+	})
+
+	Describe("Sync", func() {
+		Context("when keypair is empty", func() {
+			It("generates a keypair and saves it to the state", func() {
+				state, err := keyPairManager.Sync(storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyGenerator.GenerateCall.CallCount).To(Equal(1))
+				Expect(state).To(Equal(storage.State{
+					KeyPair: storage.KeyPair{
+						PrivateKey: "some-private-key",
+						PublicKey:  "some-public-key",
+					},
+				}))
+			})
+
+			It("generates the requested key type", func() {
+				state, err := keyPairManager.Sync(storage.State{
+					KeyPair: storage.KeyPair{
+						Type: "rsa",
+						Bits: 4096,
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyGenerator.GenerateCall.Receives.KeyType).To(Equal("rsa"))
+				Expect(sshKeyGenerator.GenerateCall.Receives.Bits).To(Equal(4096))
+				Expect(state.KeyPair.Type).To(Equal("rsa"))
+				Expect(state.KeyPair.Bits).To(Equal(4096))
+			})
+		})
+
+		Context("when keypair is not empty", func() {
+			It("does not generate a keypair", func() {
+				state, err := keyPairManager.Sync(storage.State{
+					KeyPair: storage.KeyPair{
+						PrivateKey: "some-existing-private-key",
+						PublicKey:  "some-existing-public-key",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sshKeyGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(state.KeyPair.PrivateKey).To(Equal("some-existing-private-key"))
+			})
+		})
+
+		Context("when the keypair cannot be generated", func() {
+			It("returns an error", func() {
+				sshKeyGenerator.GenerateCall.Returns.Error = errors.New("failed to generate")
+
+				_, err := keyPairManager.Sync(storage.State{})
+				Expect(err).To(MatchError("failed to generate"))
+			})
+		})
+	})
+
+	Describe("Rotate", func() {
+		It("replaces the keypair with a new one", func() {
+			state, err := keyPairManager.Rotate(storage.State{
+				KeyPair: storage.KeyPair{
+					PrivateKey: "some-old-private-key",
+					PublicKey:  "some-old-public-key",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshKeyGenerator.GenerateCall.CallCount).To(Equal(1))
+			Expect(state.KeyPair).To(Equal(storage.KeyPair{
+				PrivateKey: "some-private-key",
+				PublicKey:  "some-public-key",
+			}))
+		})
+
+		Context("when there is no keypair", func() {
+			It("returns an error", func() {
+				_, err := keyPairManager.Rotate(storage.State{})
+				Expect(err).To(MatchError("no key found to rotate"))
+
+				Expect(sshKeyGenerator.GenerateCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+})
@@ -1,14 +1,17 @@
 package keypair
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
 type Manager struct {
-	awsManager keyPairManager
-	gcpManager keyPairManager
+	awsManager       keyPairManager
+	gcpManager       keyPairManager
+	azureManager     keyPairManager
+	openstackManager keyPairManager
 }
 
 type keyPairManager interface {
@@ -16,30 +19,51 @@ type keyPairManager interface {
 	Rotate(state storage.State) (storage.State, error)
 }
 
-func NewManager(awsManager keyPairManager, gcpManager keyPairManager) Manager {
+func NewManager(awsManager keyPairManager, gcpManager keyPairManager, azureManager keyPairManager, openstackManager keyPairManager) Manager {
 	return Manager{
-		awsManager: awsManager,
-		gcpManager: gcpManager,
+		awsManager:       awsManager,
+		gcpManager:       gcpManager,
+		azureManager:     azureManager,
+		openstackManager: openstackManager,
 	}
 }
 
 func (m Manager) Sync(state storage.State) (storage.State, error) {
+	if state.KeyPair.External {
+		if state.IAAS == "aws" && state.KeyPair.Name != "" {
+			return m.awsManager.Sync(state)
+		}
+		return state, nil
+	}
+
 	switch state.IAAS {
 	case "aws":
 		return m.awsManager.Sync(state)
 	case "gcp":
 		return m.gcpManager.Sync(state)
+	case "azure":
+		return m.azureManager.Sync(state)
+	case "openstack":
+		return m.openstackManager.Sync(state)
 	default:
 		return storage.State{}, fmt.Errorf("invalid iaas was provided: %s", state.IAAS)
 	}
 }
 
 func (m Manager) Rotate(state storage.State) (storage.State, error) {
+	if state.KeyPair.External {
+		return storage.State{}, errors.New("the key pair is managed outside of bbl and cannot be rotated")
+	}
+
 	switch state.IAAS {
 	case "aws":
 		return m.awsManager.Rotate(state)
 	case "gcp":
 		return m.gcpManager.Rotate(state)
+	case "azure":
+		return m.azureManager.Rotate(state)
+	case "openstack":
+		return m.openstackManager.Rotate(state)
 	default:
 		return storage.State{}, fmt.Errorf("invalid iaas was provided: %s", state.IAAS)
 	}
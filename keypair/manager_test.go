@@ -14,8 +14,10 @@ import (
 var _ = Describe("Manager", func() {
 	Describe("Sync", func() {
 		var (
-			awsManager *fakes.KeyPairManager
-			gcpManager *fakes.KeyPairManager
+			awsManager       *fakes.KeyPairManager
+			gcpManager       *fakes.KeyPairManager
+			azureManager     *fakes.KeyPairManager
+			openstackManager *fakes.KeyPairManager
 
 			keyPairManager keypair.Manager
 		)
@@ -32,7 +34,17 @@ var _ = Describe("Manager", func() {
 				Name: "some-gcp-keypair",
 			}
 
-			keyPairManager = keypair.NewManager(awsManager, gcpManager)
+			azureManager = &fakes.KeyPairManager{}
+			azureManager.SyncCall.Returns.KeyPair = storage.KeyPair{
+				PublicKey: "some-azure-public-key",
+			}
+
+			openstackManager = &fakes.KeyPairManager{}
+			openstackManager.SyncCall.Returns.KeyPair = storage.KeyPair{
+				PublicKey: "some-openstack-public-key",
+			}
+
+			keyPairManager = keypair.NewManager(awsManager, gcpManager, azureManager, openstackManager)
 		})
 
 		Context("when iaas is aws", func() {
@@ -95,6 +107,82 @@ var _ = Describe("Manager", func() {
 			})
 		})
 
+		Context("when iaas is azure", func() {
+			It("calls the azure manager sync and returns state", func() {
+				state, err := keyPairManager.Sync(storage.State{
+					IAAS: "azure",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(azureManager.SyncCall.CallCount).To(Equal(1))
+				Expect(azureManager.SyncCall.Receives.State).To(Equal(storage.State{
+					IAAS: "azure",
+				}))
+
+				Expect(state).To(Equal(storage.State{
+					IAAS: "azure",
+					KeyPair: storage.KeyPair{
+						PublicKey: "some-azure-public-key",
+					},
+				}))
+			})
+		})
+
+		Context("when iaas is openstack", func() {
+			It("calls the openstack manager sync and returns state", func() {
+				state, err := keyPairManager.Sync(storage.State{
+					IAAS: "openstack",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(openstackManager.SyncCall.CallCount).To(Equal(1))
+
+				Expect(state).To(Equal(storage.State{
+					IAAS: "openstack",
+					KeyPair: storage.KeyPair{
+						PublicKey: "some-openstack-public-key",
+					},
+				}))
+			})
+		})
+
+		Context("when the key pair is managed outside of bbl", func() {
+			It("returns the state without syncing the key pair", func() {
+				incomingState := storage.State{
+					IAAS: "gcp",
+					KeyPair: storage.KeyPair{
+						PublicKey:  "some-public-key",
+						PrivateKey: "some-private-key",
+						External:   true,
+					},
+				}
+
+				state, err := keyPairManager.Sync(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state).To(Equal(incomingState))
+
+				Expect(awsManager.SyncCall.CallCount).To(Equal(0))
+				Expect(gcpManager.SyncCall.CallCount).To(Equal(0))
+			})
+
+			It("has the aws manager verify a named aws keypair", func() {
+				incomingState := storage.State{
+					IAAS: "aws",
+					KeyPair: storage.KeyPair{
+						Name:       "some-existing-keypair",
+						PrivateKey: "some-private-key",
+						External:   true,
+					},
+				}
+				awsManager.SyncCall.Returns.KeyPair = incomingState.KeyPair
+
+				state, err := keyPairManager.Sync(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state).To(Equal(incomingState))
+
+				Expect(awsManager.SyncCall.CallCount).To(Equal(1))
+				Expect(awsManager.SyncCall.Receives.State).To(Equal(incomingState))
+			})
+		})
+
 		Context("failure cases", func() {
 			Context("when iaas is invalid", func() {
 				It("returns an error", func() {
@@ -109,8 +197,10 @@ var _ = Describe("Manager", func() {
 
 	Describe("Rotate", func() {
 		var (
-			awsManager *fakes.KeyPairManager
-			gcpManager *fakes.KeyPairManager
+			awsManager       *fakes.KeyPairManager
+			gcpManager       *fakes.KeyPairManager
+			azureManager     *fakes.KeyPairManager
+			openstackManager *fakes.KeyPairManager
 
 			keyPairManager keypair.Manager
 		)
@@ -127,7 +217,17 @@ var _ = Describe("Manager", func() {
 				Name: "some-new-gcp-keypair",
 			}
 
-			keyPairManager = keypair.NewManager(awsManager, gcpManager)
+			azureManager = &fakes.KeyPairManager{}
+			azureManager.RotateCall.Returns.KeyPair = storage.KeyPair{
+				PublicKey: "some-new-azure-public-key",
+			}
+
+			openstackManager = &fakes.KeyPairManager{}
+			openstackManager.RotateCall.Returns.KeyPair = storage.KeyPair{
+				PublicKey: "some-new-openstack-public-key",
+			}
+
+			keyPairManager = keypair.NewManager(awsManager, gcpManager, azureManager, openstackManager)
 		})
 
 		Context("when iaas is aws", func() {
@@ -204,7 +304,61 @@ var _ = Describe("Manager", func() {
 			})
 		})
 
+		Context("when iaas is azure", func() {
+			It("calls the azure manager rotate and returns state", func() {
+				state, err := keyPairManager.Rotate(storage.State{
+					IAAS: "azure",
+					KeyPair: storage.KeyPair{
+						PublicKey: "some-azure-public-key",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(azureManager.RotateCall.CallCount).To(Equal(1))
+				Expect(state).To(Equal(storage.State{
+					IAAS: "azure",
+					KeyPair: storage.KeyPair{
+						PublicKey: "some-new-azure-public-key",
+					},
+				}))
+			})
+		})
+
+		Context("when iaas is openstack", func() {
+			It("calls the openstack manager rotate and returns state", func() {
+				state, err := keyPairManager.Rotate(storage.State{
+					IAAS: "openstack",
+					KeyPair: storage.KeyPair{
+						PublicKey: "some-openstack-public-key",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(openstackManager.RotateCall.CallCount).To(Equal(1))
+				Expect(state).To(Equal(storage.State{
+					IAAS: "openstack",
+					KeyPair: storage.KeyPair{
+						PublicKey: "some-new-openstack-public-key",
+					},
+				}))
+			})
+		})
+
 		Context("failure cases", func() {
+			Context("when the key pair is managed outside of bbl", func() {
+				It("returns an error", func() {
+					_, err := keyPairManager.Rotate(storage.State{
+						IAAS: "aws",
+						KeyPair: storage.KeyPair{
+							Name:     "some-aws-keypair",
+							External: true,
+						},
+					})
+					Expect(err).To(MatchError("the key pair is managed outside of bbl and cannot be rotated"))
+					Expect(awsManager.RotateCall.CallCount).To(Equal(0))
+				})
+			})
+
 			Context("when iaas is invalid", func() {
 				It("returns an error", func() {
 					_, err := keyPairManager.Rotate(storage.State{
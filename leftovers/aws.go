@@ -0,0 +1,237 @@
+package leftovers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
+	"github.com/cloudfoundry/bosh-bootloader/aws/iam"
+	awsterraform "github.com/cloudfoundry/bosh-bootloader/terraform/aws"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+	awsiam "github.com/aws/aws-sdk-go/service/iam"
+)
+
+// terraformUniqueIDLength is the length of the id terraform appends to a
+// name_prefix, such as the one bbl gives its server certificates.
+const terraformUniqueIDLength = 26
+
+var (
+	elasticIPSuffixes = []string{
+		"-bosh-eip",
+		"-nat-eip",
+	}
+
+	securityGroupSuffixes = []string{
+		"-bosh-security-group",
+		"-internal-security-group",
+		"-nat-security-group",
+		"-director-db-security-group",
+		"-concourse-lb-security-group",
+		"-concourse-lb-internal-security-group",
+		"-cf-router-lb-security-group",
+		"-cf-router-lb-internal-security-group",
+		"-cf-ssh-lb-security-group",
+		"-cf-ssh-lb-internal-security-group",
+		"-cf-tcp-lb-security-group",
+		"-cf-tcp-lb-internal-security-group",
+	}
+)
+
+type ec2ClientProvider interface {
+	GetEC2Client() ec2.Client
+}
+
+type iamClientProvider interface {
+	GetIAMClient() iam.Client
+}
+
+type AWS struct {
+	ec2ClientProvider ec2ClientProvider
+	iamClientProvider iamClientProvider
+}
+
+func NewAWS(ec2ClientProvider ec2ClientProvider, iamClientProvider iamClientProvider) AWS {
+	return AWS{
+		ec2ClientProvider: ec2ClientProvider,
+		iamClientProvider: iamClientProvider,
+	}
+}
+
+// List finds the unattached elastic ips and security groups of the env, or of
+// every env when envID is empty. Server certificate names only carry a prefix
+// of the env id, so they are only looked for when envID is given.
+func (a AWS) List(envID string) ([]Resource, error) {
+	addresses, err := a.elasticIPs(envID)
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroups, err := a.securityGroups(envID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := append(addresses, securityGroups...)
+
+	if envID != "" {
+		certificates, err := a.serverCertificates(envID)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, certificates...)
+	}
+
+	return resources, nil
+}
+
+func (a AWS) Delete(resource Resource) error {
+	var err error
+	switch resource.Type {
+	case ElasticIP:
+		_, err = a.ec2ClientProvider.GetEC2Client().ReleaseAddress(&awsec2.ReleaseAddressInput{
+			AllocationId: aws.String(resource.ID),
+		})
+	case SecurityGroup:
+		_, err = a.ec2ClientProvider.GetEC2Client().DeleteSecurityGroup(&awsec2.DeleteSecurityGroupInput{
+			GroupId: aws.String(resource.ID),
+		})
+	case ServerCertificate:
+		_, err = a.iamClientProvider.GetIAMClient().DeleteServerCertificate(&awsiam.DeleteServerCertificateInput{
+			ServerCertificateName: aws.String(resource.Name),
+		})
+	default:
+		err = fmt.Errorf("unknown aws resource type %q", resource.Type)
+	}
+
+	return err
+}
+
+func (a AWS) elasticIPs(envID string) ([]Resource, error) {
+	client := a.ec2ClientProvider.GetEC2Client()
+
+	// addresses do not carry their tags, so the names are looked up separately
+	tags, err := client.DescribeTags(&awsec2.DescribeTagsInput{
+		Filters: []*awsec2.Filter{
+			{Name: aws.String("resource-type"), Values: []*string{aws.String("elastic-ip")}},
+			{Name: aws.String("key"), Values: []*string{aws.String("Name")}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	for _, tag := range tags.Tags {
+		names[aws.StringValue(tag.ResourceId)] = aws.StringValue(tag.Value)
+	}
+
+	output, err := client.DescribeAddresses(&awsec2.DescribeAddressesInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []Resource{}
+	for _, address := range output.Addresses {
+		if address.AssociationId != nil {
+			continue
+		}
+
+		allocationID := aws.StringValue(address.AllocationId)
+		name := names[allocationID]
+		resourceEnvID, ok := envIDFor(name, envID, elasticIPSuffixes)
+		if !ok {
+			continue
+		}
+
+		resources = append(resources, Resource{
+			Type:  ElasticIP,
+			Name:  name,
+			ID:    allocationID,
+			EnvID: resourceEnvID,
+		})
+	}
+
+	return resources, nil
+}
+
+func (a AWS) securityGroups(envID string) ([]Resource, error) {
+	client := a.ec2ClientProvider.GetEC2Client()
+
+	interfaces, err := client.DescribeNetworkInterfaces(&awsec2.DescribeNetworkInterfacesInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := map[string]bool{}
+	for _, networkInterface := range interfaces.NetworkInterfaces {
+		for _, group := range networkInterface.Groups {
+			inUse[aws.StringValue(group.GroupId)] = true
+		}
+	}
+
+	output, err := client.DescribeSecurityGroups(&awsec2.DescribeSecurityGroupsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []Resource{}
+	for _, group := range output.SecurityGroups {
+		groupID := aws.StringValue(group.GroupId)
+		if inUse[groupID] || aws.StringValue(group.GroupName) == "default" {
+			continue
+		}
+
+		name := nameTag(group.Tags)
+		resourceEnvID, ok := envIDFor(name, envID, securityGroupSuffixes)
+		if !ok {
+			continue
+		}
+
+		resources = append(resources, Resource{
+			Type:  SecurityGroup,
+			Name:  name,
+			ID:    groupID,
+			EnvID: resourceEnvID,
+		})
+	}
+
+	return resources, nil
+}
+
+func (a AWS) serverCertificates(envID string) ([]Resource, error) {
+	output, err := a.iamClientProvider.GetIAMClient().ListServerCertificates(&awsiam.ListServerCertificatesInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := awsterraform.ShortEnvID(envID)
+
+	resources := []Resource{}
+	for _, certificate := range output.ServerCertificateMetadataList {
+		name := aws.StringValue(certificate.ServerCertificateName)
+		if !strings.HasPrefix(name, prefix) || len(name) != len(prefix)+terraformUniqueIDLength {
+			continue
+		}
+
+		resources = append(resources, Resource{
+			Type:  ServerCertificate,
+			Name:  name,
+			EnvID: envID,
+		})
+	}
+
+	return resources, nil
+}
+
+func nameTag(tags []*awsec2.Tag) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == "Name" {
+			return aws.StringValue(tag.Value)
+		}
+	}
+
+	return ""
+}
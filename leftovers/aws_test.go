@@ -0,0 +1,173 @@
+package leftovers_test
+
+import (
+	"errors"
+
+	iamfakes "github.com/cloudfoundry/bosh-bootloader/aws/iam/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/leftovers"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+	awsiam "github.com/aws/aws-sdk-go/service/iam"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AWS", func() {
+	var (
+		ec2Client      *fakes.EC2Client
+		iamClient      *iamfakes.Client
+		clientProvider *fakes.AWSClientProvider
+
+		awsLeftovers leftovers.AWS
+	)
+
+	nameTag := func(name string) []*awsec2.Tag {
+		return []*awsec2.Tag{{Key: aws.String("Name"), Value: aws.String(name)}}
+	}
+
+	BeforeEach(func() {
+		ec2Client = &fakes.EC2Client{}
+		iamClient = &iamfakes.Client{}
+		clientProvider = &fakes.AWSClientProvider{}
+		clientProvider.GetEC2ClientCall.Returns.EC2Client = ec2Client
+		clientProvider.GetIAMClientCall.Returns.IAMClient = iamClient
+
+		ec2Client.DescribeTagsCall.Returns.Output = &awsec2.DescribeTagsOutput{
+			Tags: []*awsec2.TagDescription{
+				{ResourceId: aws.String("eipalloc-1"), Key: aws.String("Name"), Value: aws.String("some-env-bosh-eip")},
+				{ResourceId: aws.String("eipalloc-2"), Key: aws.String("Name"), Value: aws.String("other-env-nat-eip")},
+				{ResourceId: aws.String("eipalloc-3"), Key: aws.String("Name"), Value: aws.String("some-env-nat-eip")},
+				{ResourceId: aws.String("eipalloc-4"), Key: aws.String("Name"), Value: aws.String("not-bbl")},
+			},
+		}
+		ec2Client.DescribeAddressesCall.Returns.Output = &awsec2.DescribeAddressesOutput{
+			Addresses: []*awsec2.Address{
+				{AllocationId: aws.String("eipalloc-1")},
+				{AllocationId: aws.String("eipalloc-2")},
+				{AllocationId: aws.String("eipalloc-3"), AssociationId: aws.String("eipassoc-3")},
+				{AllocationId: aws.String("eipalloc-4")},
+				{AllocationId: aws.String("eipalloc-5")},
+			},
+		}
+		ec2Client.DescribeNetworkInterfacesCall.Returns.Output = &awsec2.DescribeNetworkInterfacesOutput{
+			NetworkInterfaces: []*awsec2.NetworkInterface{
+				{Groups: []*awsec2.GroupIdentifier{{GroupId: aws.String("sg-2")}}},
+			},
+		}
+		ec2Client.DescribeSecurityGroupsCall.Returns.Output = &awsec2.DescribeSecurityGroupsOutput{
+			SecurityGroups: []*awsec2.SecurityGroup{
+				{GroupId: aws.String("sg-1"), GroupName: aws.String("some-group"), Tags: nameTag("some-env-cf-router-lb-internal-security-group")},
+				{GroupId: aws.String("sg-2"), GroupName: aws.String("some-group"), Tags: nameTag("some-env-bosh-security-group")},
+				{GroupId: aws.String("sg-3"), GroupName: aws.String("default"), Tags: nameTag("some-env-internal-security-group")},
+			},
+		}
+		iamClient.ListServerCertificatesReturns(&awsiam.ListServerCertificatesOutput{
+			ServerCertificateMetadataList: []*awsiam.ServerCertificateMetadata{
+				{ServerCertificateName: aws.String("some-env20171017120000000000000001")},
+				{ServerCertificateName: aws.String("some-env-other20171017120000000000000001")},
+			},
+		}, nil)
+
+		awsLeftovers = leftovers.NewAWS(clientProvider, clientProvider)
+	})
+
+	Describe("List", func() {
+		It("lists the unused resources of every env", func() {
+			resources, err := awsLeftovers.List("")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(Equal([]leftovers.Resource{
+				{Type: leftovers.ElasticIP, Name: "some-env-bosh-eip", ID: "eipalloc-1", EnvID: "some-env"},
+				{Type: leftovers.ElasticIP, Name: "other-env-nat-eip", ID: "eipalloc-2", EnvID: "other-env"},
+				{Type: leftovers.SecurityGroup, Name: "some-env-cf-router-lb-internal-security-group", ID: "sg-1", EnvID: "some-env"},
+			}))
+			Expect(iamClient.ListServerCertificatesCallCount()).To(Equal(0))
+		})
+
+		It("lists only the env's resources, with its server certificates, when an env id is given", func() {
+			resources, err := awsLeftovers.List("some-env")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(Equal([]leftovers.Resource{
+				{Type: leftovers.ElasticIP, Name: "some-env-bosh-eip", ID: "eipalloc-1", EnvID: "some-env"},
+				{Type: leftovers.SecurityGroup, Name: "some-env-cf-router-lb-internal-security-group", ID: "sg-1", EnvID: "some-env"},
+				{Type: leftovers.ServerCertificate, Name: "some-env20171017120000000000000001", EnvID: "some-env"},
+			}))
+		})
+
+		It("looks up the names of elastic ips from their tags", func() {
+			_, err := awsLeftovers.List("")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ec2Client.DescribeTagsCall.Receives.Input).To(Equal(&awsec2.DescribeTagsInput{
+				Filters: []*awsec2.Filter{
+					{Name: aws.String("resource-type"), Values: []*string{aws.String("elastic-ip")}},
+					{Name: aws.String("key"), Values: []*string{aws.String("Name")}},
+				},
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when describing tags fails", func() {
+				ec2Client.DescribeTagsCall.Returns.Error = errors.New("failed to describe tags")
+
+				_, err := awsLeftovers.List("")
+				Expect(err).To(MatchError("failed to describe tags"))
+			})
+
+			It("returns an error when describing addresses fails", func() {
+				ec2Client.DescribeAddressesCall.Returns.Error = errors.New("failed to describe addresses")
+
+				_, err := awsLeftovers.List("")
+				Expect(err).To(MatchError("failed to describe addresses"))
+			})
+
+			It("returns an error when describing security groups fails", func() {
+				ec2Client.DescribeSecurityGroupsCall.Returns.Error = errors.New("failed to describe security groups")
+
+				_, err := awsLeftovers.List("")
+				Expect(err).To(MatchError("failed to describe security groups"))
+			})
+
+			It("returns an error when listing server certificates fails", func() {
+				iamClient.ListServerCertificatesReturns(nil, errors.New("failed to list certificates"))
+
+				_, err := awsLeftovers.List("some-env")
+				Expect(err).To(MatchError("failed to list certificates"))
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		It("releases elastic ips", func() {
+			err := awsLeftovers.Delete(leftovers.Resource{Type: leftovers.ElasticIP, ID: "eipalloc-1"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ec2Client.ReleaseAddressCall.Receives.Input.AllocationId).To(Equal(aws.String("eipalloc-1")))
+		})
+
+		It("deletes security groups", func() {
+			ec2Client.DeleteSecurityGroupCall.Returns.Error = errors.New("DependencyViolation")
+
+			err := awsLeftovers.Delete(leftovers.Resource{Type: leftovers.SecurityGroup, ID: "sg-1"})
+			Expect(err).To(MatchError("DependencyViolation"))
+
+			Expect(ec2Client.DeleteSecurityGroupCall.Receives.Input.GroupId).To(Equal(aws.String("sg-1")))
+		})
+
+		It("deletes server certificates", func() {
+			err := awsLeftovers.Delete(leftovers.Resource{Type: leftovers.ServerCertificate, Name: "some-cert"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(iamClient.DeleteServerCertificateArgsForCall(0).ServerCertificateName).To(Equal(aws.String("some-cert")))
+		})
+
+		It("returns an error for an unknown type", func() {
+			err := awsLeftovers.Delete(leftovers.Resource{Type: leftovers.FirewallRule})
+			Expect(err).To(MatchError(`unknown aws resource type "firewall rule"`))
+		})
+	})
+})
@@ -0,0 +1,167 @@
+package leftovers
+
+import (
+	"fmt"
+	"strings"
+
+	compute "google.golang.org/api/compute/v1"
+)
+
+var (
+	addressSuffixes = []string{
+		"-bosh-external-ip",
+		"-concourse",
+		"-cf-ssh-proxy",
+		"-cf-tcp-router",
+		"-cf-ws",
+	}
+
+	globalAddressSuffixes = []string{
+		"-cf",
+		"-cf-ipv6",
+	}
+
+	firewallRuleSuffixes = []string{
+		"-external",
+		"-bosh-open",
+		"-bosh-director",
+		"-internal-to-director",
+		"-internal",
+		"-concourse-open",
+		"-cf-open",
+		"-cf-health-check",
+		"-cf-ssh-proxy-open",
+		"-cf-tcp-router",
+	}
+)
+
+type gcpClient interface {
+	ListAllInstances() (*compute.InstanceAggregatedList, error)
+	ListAddresses() (*compute.AddressList, error)
+	DeleteAddress(name string) (*compute.Operation, error)
+	ListGlobalAddresses() (*compute.AddressList, error)
+	DeleteGlobalAddress(name string) (*compute.Operation, error)
+	ListFirewalls() (*compute.FirewallList, error)
+	DeleteFirewall(name string) (*compute.Operation, error)
+}
+
+type GCP struct {
+	client gcpClient
+}
+
+func NewGCP(client gcpClient) GCP {
+	return GCP{
+		client: client,
+	}
+}
+
+// List finds the reserved but unused addresses of the env, or of every env
+// when envID is empty, and its firewall rules on networks without any vms.
+func (g GCP) List(envID string) ([]Resource, error) {
+	addresses, err := g.client.ListAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	globalAddresses, err := g.client.ListGlobalAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := append(
+		reservedAddresses(addresses, Address, envID, addressSuffixes),
+		reservedAddresses(globalAddresses, GlobalAddress, envID, globalAddressSuffixes)...,
+	)
+
+	firewallRules, err := g.firewallRules(envID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(resources, firewallRules...), nil
+}
+
+func (g GCP) Delete(resource Resource) error {
+	var err error
+	switch resource.Type {
+	case Address:
+		_, err = g.client.DeleteAddress(resource.Name)
+	case GlobalAddress:
+		_, err = g.client.DeleteGlobalAddress(resource.Name)
+	case FirewallRule:
+		_, err = g.client.DeleteFirewall(resource.Name)
+	default:
+		err = fmt.Errorf("unknown gcp resource type %q", resource.Type)
+	}
+
+	return err
+}
+
+func (g GCP) firewallRules(envID string) ([]Resource, error) {
+	instances, err := g.client.ListAllInstances()
+	if err != nil {
+		return nil, err
+	}
+
+	networksInUse := map[string]bool{}
+	for _, scopedList := range instances.Items {
+		for _, instance := range scopedList.Instances {
+			for _, networkInterface := range instance.NetworkInterfaces {
+				networksInUse[networkInterface.Network] = true
+			}
+		}
+	}
+
+	firewalls, err := g.client.ListFirewalls()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []Resource{}
+	for _, firewall := range firewalls.Items {
+		if networksInUse[firewall.Network] {
+			continue
+		}
+
+		resourceEnvID, ok := envIDFor(firewall.Name, envID, firewallRuleSuffixes)
+		if !ok {
+			continue
+		}
+
+		// names like "-internal" are common outside bbl too, so without an env
+		// id only rules on a network bbl named for the env are picked up.
+		if envID == "" && !strings.HasSuffix(firewall.Network, fmt.Sprintf("/networks/%s-network", resourceEnvID)) {
+			continue
+		}
+
+		resources = append(resources, Resource{
+			Type:  FirewallRule,
+			Name:  firewall.Name,
+			EnvID: resourceEnvID,
+		})
+	}
+
+	return resources, nil
+}
+
+func reservedAddresses(addresses *compute.AddressList, resourceType, envID string, suffixes []string) []Resource {
+	resources := []Resource{}
+	for _, address := range addresses.Items {
+		if address.Status != "RESERVED" {
+			continue
+		}
+
+		resourceEnvID, ok := envIDFor(address.Name, envID, suffixes)
+		if !ok {
+			continue
+		}
+
+		resources = append(resources, Resource{
+			Type:  resourceType,
+			Name:  address.Name,
+			EnvID: resourceEnvID,
+		})
+	}
+
+	return resources
+}
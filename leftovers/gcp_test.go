@@ -0,0 +1,129 @@
+package leftovers_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/leftovers"
+
+	compute "google.golang.org/api/compute/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GCP", func() {
+	var (
+		client *fakes.GCPClient
+
+		gcpLeftovers leftovers.GCP
+	)
+
+	BeforeEach(func() {
+		client = &fakes.GCPClient{}
+
+		client.ListAddressesCall.Returns.AddressList = &compute.AddressList{
+			Items: []*compute.Address{
+				{Name: "some-env-bosh-external-ip", Status: "RESERVED"},
+				{Name: "some-env-cf-ws", Status: "IN_USE"},
+				{Name: "not-bbl", Status: "RESERVED"},
+			},
+		}
+		client.ListGlobalAddressesCall.Returns.AddressList = &compute.AddressList{
+			Items: []*compute.Address{
+				{Name: "other-env-cf", Status: "RESERVED"},
+			},
+		}
+		client.ListAllInstancesCall.Returns.InstanceAggregatedList = &compute.InstanceAggregatedList{
+			Items: map[string]compute.InstancesScopedList{
+				"zones/some-zone": {
+					Instances: []*compute.Instance{{
+						NetworkInterfaces: []*compute.NetworkInterface{{Network: "https://www.googleapis.com/compute/v1/projects/some-project/global/networks/busy-env-network"}}},
+					},
+				},
+			},
+		}
+		client.ListFirewallsCall.Returns.FirewallList = &compute.FirewallList{
+			Items: []*compute.Firewall{
+				{Name: "some-env-internal", Network: "https://www.googleapis.com/compute/v1/projects/some-project/global/networks/some-env-network"},
+				{Name: "busy-env-internal", Network: "https://www.googleapis.com/compute/v1/projects/some-project/global/networks/busy-env-network"},
+				{Name: "default-allow-internal", Network: "https://www.googleapis.com/compute/v1/projects/some-project/global/networks/default"},
+			},
+		}
+
+		gcpLeftovers = leftovers.NewGCP(client)
+	})
+
+	Describe("List", func() {
+		It("lists the reserved addresses and the firewall rules of empty bbl networks", func() {
+			resources, err := gcpLeftovers.List("")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(Equal([]leftovers.Resource{
+				{Type: leftovers.Address, Name: "some-env-bosh-external-ip", EnvID: "some-env"},
+				{Type: leftovers.GlobalAddress, Name: "other-env-cf", EnvID: "other-env"},
+				{Type: leftovers.FirewallRule, Name: "some-env-internal", EnvID: "some-env"},
+			}))
+		})
+
+		It("lists the env's firewall rules on any empty network when an env id is given", func() {
+			client.ListFirewallsCall.Returns.FirewallList.Items[2].Name = "some-env-bosh-open"
+
+			resources, err := gcpLeftovers.List("some-env")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(Equal([]leftovers.Resource{
+				{Type: leftovers.Address, Name: "some-env-bosh-external-ip", EnvID: "some-env"},
+				{Type: leftovers.FirewallRule, Name: "some-env-internal", EnvID: "some-env"},
+				{Type: leftovers.FirewallRule, Name: "some-env-bosh-open", EnvID: "some-env"},
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when listing addresses fails", func() {
+				client.ListAddressesCall.Returns.Error = errors.New("failed to list addresses")
+
+				_, err := gcpLeftovers.List("")
+				Expect(err).To(MatchError("failed to list addresses"))
+			})
+
+			It("returns an error when listing instances fails", func() {
+				client.ListAllInstancesCall.Returns.Error = errors.New("failed to list instances")
+
+				_, err := gcpLeftovers.List("")
+				Expect(err).To(MatchError("failed to list instances"))
+			})
+
+			It("returns an error when listing firewalls fails", func() {
+				client.ListFirewallsCall.Returns.Error = errors.New("failed to list firewalls")
+
+				_, err := gcpLeftovers.List("")
+				Expect(err).To(MatchError("failed to list firewalls"))
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes each type of resource", func() {
+			Expect(gcpLeftovers.Delete(leftovers.Resource{Type: leftovers.Address, Name: "some-address"})).To(Succeed())
+			Expect(gcpLeftovers.Delete(leftovers.Resource{Type: leftovers.GlobalAddress, Name: "some-global-address"})).To(Succeed())
+			Expect(gcpLeftovers.Delete(leftovers.Resource{Type: leftovers.FirewallRule, Name: "some-firewall"})).To(Succeed())
+
+			Expect(client.DeleteAddressCall.Receives.Names).To(Equal([]string{"some-address"}))
+			Expect(client.DeleteGlobalAddressCall.Receives.Names).To(Equal([]string{"some-global-address"}))
+			Expect(client.DeleteFirewallCall.Receives.Names).To(Equal([]string{"some-firewall"}))
+		})
+
+		It("returns an error when the delete fails", func() {
+			client.DeleteFirewallCall.Returns.Error = errors.New("resource in use")
+
+			err := gcpLeftovers.Delete(leftovers.Resource{Type: leftovers.FirewallRule, Name: "some-firewall"})
+			Expect(err).To(MatchError("resource in use"))
+		})
+
+		It("returns an error for an unknown type", func() {
+			err := gcpLeftovers.Delete(leftovers.Resource{Type: leftovers.ElasticIP})
+			Expect(err).To(MatchError(`unknown gcp resource type "elastic ip"`))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package leftovers_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLeftovers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "leftovers")
+}
@@ -0,0 +1,56 @@
+package leftovers
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ElasticIP         = "elastic ip"
+	SecurityGroup     = "security group"
+	ServerCertificate = "server certificate"
+	Address           = "address"
+	GlobalAddress     = "global address"
+	FirewallRule      = "firewall rule"
+)
+
+// Resource is an IaaS resource that bbl created for an environment and that
+// nothing uses any more, usually left behind by a failed up or destroy.
+type Resource struct {
+	Type  string
+	Name  string
+	ID    string
+	EnvID string
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s %s", r.Type, r.Name)
+}
+
+// envIDFor returns the env id a resource name was built from when the name is
+// one bbl gives the resource, the env id followed by one of the suffixes. With
+// no env id, any env's resources match, and the longest matching suffix wins.
+func envIDFor(name, envID string, suffixes []string) (string, bool) {
+	if envID != "" {
+		for _, suffix := range suffixes {
+			if name == envID+suffix {
+				return envID, true
+			}
+		}
+
+		return "", false
+	}
+
+	match := ""
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) && len(name) > len(suffix) && len(suffix) > len(match) {
+			match = suffix
+		}
+	}
+
+	if match == "" {
+		return "", false
+	}
+
+	return strings.TrimSuffix(name, match), true
+}
@@ -0,0 +1,118 @@
+package openstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+)
+
+type OpenStackClient struct {
+	httpClient *http.Client
+}
+
+func NewClient() OpenStackClient {
+	return OpenStackClient{
+		httpClient: helpers.NewHTTPClient(),
+	}
+}
+
+type authRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods  []string `json:"methods"`
+			Password struct {
+				User struct {
+					Name     string `json:"name"`
+					Password string `json:"password"`
+					Domain   struct {
+						Name string `json:"name"`
+					} `json:"domain"`
+				} `json:"user"`
+			} `json:"password"`
+		} `json:"identity"`
+		Scope struct {
+			Project struct {
+				Name   string `json:"name"`
+				Domain struct {
+					Name string `json:"name"`
+				} `json:"domain"`
+			} `json:"project"`
+		} `json:"scope"`
+	} `json:"auth"`
+}
+
+type authResponse struct {
+	Token struct {
+		Catalog []struct {
+			Endpoints []struct {
+				Region   string `json:"region"`
+				RegionID string `json:"region_id"`
+			} `json:"endpoints"`
+		} `json:"catalog"`
+	} `json:"token"`
+}
+
+// ValidateCredentials gets a keystone v3 token scoped to the project, the
+// same the terraform provider and the cpi do, and checks the region is in its
+// service catalog.
+func (c OpenStackClient) ValidateCredentials(authURL, username, password, domain, project, region string) error {
+	var request authRequest
+	request.Auth.Identity.Methods = []string{"password"}
+	request.Auth.Identity.Password.User.Name = username
+	request.Auth.Identity.Password.User.Password = password
+	request.Auth.Identity.Password.User.Domain.Name = domain
+	request.Auth.Scope.Project.Name = project
+	request.Auth.Scope.Project.Domain.Name = domain
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err //not tested
+	}
+
+	response, err := c.httpClient.Post(tokensURL(authURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to authenticate with keystone: %s", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate with keystone: %s", err) //not tested
+	}
+
+	if response.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to authenticate with keystone: %s %s", response.Status, strings.TrimSpace(string(responseBody)))
+	}
+
+	var token authResponse
+	err = json.Unmarshal(responseBody, &token)
+	if err != nil {
+		return fmt.Errorf("failed to read the keystone token: %s", err)
+	}
+
+	for _, service := range token.Token.Catalog {
+		for _, endpoint := range service.Endpoints {
+			if endpoint.Region == region || endpoint.RegionID == region {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("region %q is not in the service catalog of project %q", region, project)
+}
+
+// tokensURL accepts the auth url with or without the /v3 suffix, as the
+// openstack clients do.
+func tokensURL(authURL string) string {
+	authURL = strings.TrimSuffix(authURL, "/")
+	if !strings.HasSuffix(authURL, "/v3") {
+		authURL += "/v3"
+	}
+
+	return authURL + "/auth/tokens"
+}
@@ -0,0 +1,101 @@
+package openstack_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/bosh-bootloader/openstack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server      *httptest.Server
+		client      openstack.OpenStackClient
+		requestPath string
+		requestBody string
+		status      int
+		response    string
+	)
+
+	BeforeEach(func() {
+		status = http.StatusCreated
+		response = `{"token": {"catalog": [{"type": "compute", "endpoints": [{"region": "some-region", "region_id": "some-region"}]}]}}`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requestPath = r.URL.Path
+			requestBody = string(body)
+
+			w.WriteHeader(status)
+			w.Write([]byte(response))
+		}))
+
+		client = openstack.NewClient()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("ValidateCredentials", func() {
+		It("gets a token scoped to the project", func() {
+			err := client.ValidateCredentials(server.URL+"/v3", "some-user", "some-password", "some-domain", "some-project", "some-region")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestPath).To(Equal("/v3/auth/tokens"))
+			Expect(requestBody).To(MatchJSON(`{
+				"auth": {
+					"identity": {
+						"methods": ["password"],
+						"password": {
+							"user": {"name": "some-user", "password": "some-password", "domain": {"name": "some-domain"}}
+						}
+					},
+					"scope": {
+						"project": {"name": "some-project", "domain": {"name": "some-domain"}}
+					}
+				}
+			}`))
+		})
+
+		It("adds the version to an auth url without it", func() {
+			err := client.ValidateCredentials(server.URL+"/", "some-user", "some-password", "some-domain", "some-project", "some-region")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestPath).To(Equal("/v3/auth/tokens"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when keystone rejects the credentials", func() {
+				status = http.StatusUnauthorized
+				response = `{"error": {"message": "The request you have made requires authentication."}}`
+
+				err := client.ValidateCredentials(server.URL, "some-user", "some-password", "some-domain", "some-project", "some-region")
+				Expect(err).To(MatchError(`failed to authenticate with keystone: 401 Unauthorized {"error": {"message": "The request you have made requires authentication."}}`))
+			})
+
+			It("returns an error when the region is not in the catalog", func() {
+				err := client.ValidateCredentials(server.URL, "some-user", "some-password", "some-domain", "some-project", "other-region")
+				Expect(err).To(MatchError(`region "other-region" is not in the service catalog of project "some-project"`))
+			})
+
+			It("returns an error when the token cannot be read", func() {
+				response = "%%%"
+
+				err := client.ValidateCredentials(server.URL, "some-user", "some-password", "some-domain", "some-project", "some-region")
+				Expect(err).To(MatchError(ContainSubstring("failed to read the keystone token: invalid character")))
+			})
+
+			It("returns an error when keystone cannot be reached", func() {
+				server.Close()
+
+				err := client.ValidateCredentials(server.URL, "some-user", "some-password", "some-domain", "some-project", "some-region")
+				Expect(err).To(MatchError(ContainSubstring("failed to authenticate with keystone:")))
+			})
+		})
+	})
+})
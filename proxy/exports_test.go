@@ -1,6 +1,9 @@
 package proxy
 
-import "net"
+import (
+	"net"
+	"time"
+)
 
 func SetNetListen(f func(net, laddr string) (net.Listener, error)) {
 	netListen = f
@@ -9,3 +12,27 @@ func SetNetListen(f func(net, laddr string) (net.Listener, error)) {
 func ResetNetListen() {
 	netListen = net.Listen
 }
+
+func SetProxyCheckTimeout(timeout time.Duration) {
+	proxyCheckTimeout = timeout
+}
+
+func ResetProxyCheckTimeout() {
+	proxyCheckTimeout = 5 * time.Second
+}
+
+func SetSleep(f func(time.Duration)) {
+	sleep = f
+}
+
+func ResetSleep() {
+	sleep = time.Sleep
+}
+
+func SetKeepaliveInterval(interval time.Duration) {
+	keepaliveInterval = interval
+}
+
+func ResetKeepaliveInterval() {
+	keepaliveInterval = 30 * time.Second
+}
@@ -3,8 +3,11 @@ package proxy_test
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"strconv"
+	"sync"
 	"testing"
 
 	"golang.org/x/crypto/ssh"
@@ -123,3 +126,154 @@ func startSSHServer(httpServerURL string) string {
 
 	return listener.Addr().String()
 }
+
+// startHostKeySSHServer only completes the key exchange of each connection,
+// enough for a client to see the host key.
+func startHostKeySSHServer(signer ssh.Signer) string {
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal("failed to listen for connection: ", err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				ssh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// forwardingSSHServer forwards the connections of its clients, answers
+// keepalives, and can drop its clients to test reconnecting.
+type forwardingSSHServer struct {
+	URL string
+
+	listener    net.Listener
+	mutex       sync.Mutex
+	conns       []*ssh.ServerConn
+	connections int
+	keepalives  int
+}
+
+func startForwardingSSHServer(signer ssh.Signer) *forwardingSSHServer {
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal("failed to listen for connection: ", err)
+	}
+
+	server := &forwardingSSHServer{URL: listener.Addr().String(), listener: listener}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn, config)
+		}
+	}()
+
+	return server
+}
+
+func (f *forwardingSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+
+	f.mutex.Lock()
+	f.conns = append(f.conns, serverConn)
+	f.connections++
+	f.mutex.Unlock()
+
+	go func() {
+		for req := range reqs {
+			if req.Type == "keepalive@openssh.com" {
+				f.mutex.Lock()
+				f.keepalives++
+				f.mutex.Unlock()
+			}
+			req.Reply(req.Type == "keepalive@openssh.com", nil)
+		}
+	}()
+
+	for newChannel := range chans {
+		var target struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		targetConn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			targetConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+
+		go func() {
+			defer channel.Close()
+			defer targetConn.Close()
+
+			go io.Copy(targetConn, channel)
+			io.Copy(channel, targetConn)
+		}()
+	}
+}
+
+func (f *forwardingSSHServer) DropClients() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *forwardingSSHServer) Stop() {
+	f.listener.Close()
+	f.DropClients()
+}
+
+func (f *forwardingSSHServer) Connections() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.connections
+}
+
+func (f *forwardingSSHServer) Keepalives() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.keepalives
+}
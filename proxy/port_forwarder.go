@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	netproxy "golang.org/x/net/proxy"
+)
+
+type PortForwarder struct {
+	logger   logger
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	mutex    sync.Mutex
+}
+
+func NewPortForwarder(logger logger) *PortForwarder {
+	return &PortForwarder{
+		logger: logger,
+		conns:  map[net.Conn]struct{}{},
+	}
+}
+
+// Forward listens on localPort, or on a free port when localPort is 0, and
+// copies every connection to target through dialer. It returns the local
+// address once the listener is open.
+func (p *PortForwarder) Forward(localPort int, target string, dialer netproxy.Dialer) (string, error) {
+	listener, err := netListen("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		return "", err
+	}
+	p.listener = listener
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go p.forward(conn, target, dialer)
+		}
+	}()
+
+	return listener.Addr().String(), nil
+}
+
+// Close stops listening and closes the connections that are still open.
+func (p *PortForwarder) Close() error {
+	if p.listener == nil {
+		return nil
+	}
+
+	err := p.listener.Close()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for conn := range p.conns {
+		conn.Close()
+	}
+
+	return err
+}
+
+func (p *PortForwarder) forward(local net.Conn, target string, dialer netproxy.Dialer) {
+	remote, err := dialer.Dial("tcp", target)
+	if err != nil {
+		p.logger.Println(fmt.Sprintf("err: failed to connect to %s: %s", target, err.Error()))
+		local.Close()
+		return
+	}
+
+	p.track(local, remote)
+	defer p.untrack(local, remote)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func (p *PortForwarder) track(conns ...net.Conn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, conn := range conns {
+		p.conns[conn] = struct{}{}
+	}
+}
+
+func (p *PortForwarder) untrack(conns ...net.Conn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, conn := range conns {
+		conn.Close()
+		delete(p.conns, conn)
+	}
+}
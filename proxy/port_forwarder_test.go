@@ -0,0 +1,93 @@
+package proxy_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/proxy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PortForwarder", func() {
+	var (
+		portForwarder *proxy.PortForwarder
+		logger        *fakes.Logger
+
+		httpServer         *httptest.Server
+		httpServerHostPort string
+	)
+
+	BeforeEach(func() {
+		httpServer = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(http.StatusTeapot)
+		}))
+		httpServerHostPort = strings.TrimPrefix(httpServer.URL, "http://")
+
+		logger = &fakes.Logger{}
+		portForwarder = proxy.NewPortForwarder(logger)
+	})
+
+	AfterEach(func() {
+		portForwarder.Close()
+		httpServer.Close()
+		proxy.ResetNetListen()
+	})
+
+	Describe("Forward", func() {
+		It("forwards connections on the local port to the target", func() {
+			addr, err := portForwarder.Forward(0, httpServerHostPort, &net.Dialer{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addr).To(HavePrefix("127.0.0.1:"))
+
+			resp, err := http.Get("http://" + addr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusTeapot))
+		})
+
+		It("stops accepting connections once closed", func() {
+			addr, err := portForwarder.Forward(0, httpServerHostPort, &net.Dialer{})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = portForwarder.Close()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = net.Dial("tcp", addr)
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the local port cannot be opened", func() {
+				proxy.SetNetListen(func(network, laddr string) (net.Listener, error) {
+					return nil, errors.New("failed to listen")
+				})
+
+				_, err := portForwarder.Forward(0, httpServerHostPort, &net.Dialer{})
+				Expect(err).To(MatchError("failed to listen"))
+			})
+
+			It("logs an error when the target cannot be reached", func() {
+				addr, err := portForwarder.Forward(0, httpServerHostPort, failingDialer{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = http.Get("http://" + addr)
+				Expect(err).To(HaveOccurred())
+
+				Eventually(func() []string {
+					return logger.PrintlnCall.Messages
+				}).Should(ContainElement(ContainSubstring("err: failed to connect to " + httpServerHostPort + ": failed to dial")))
+			})
+		})
+	})
+})
+
+type failingDialer struct{}
+
+func (failingDialer) Dial(network, addr string) (net.Conn, error) {
+	return nil, errors.New("failed to dial")
+}
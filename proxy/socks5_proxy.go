@@ -1,23 +1,43 @@
 package proxy
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
+	"sync"
+	"time"
 
 	socks5 "github.com/armon/go-socks5"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/net/context"
+	"golang.org/x/net/proxy"
 )
 
-var netListen = net.Listen
+const maxReconnectAttempts = 5
+
+var (
+	netListen         = net.Listen
+	sleep             = time.Sleep
+	proxyCheckTimeout = 5 * time.Second
+	keepaliveInterval = 30 * time.Second
+	keepaliveTimeout  = 15 * time.Second
+
+	errHostKeySeen = errors.New("host key seen")
+)
 
 type Socks5Proxy struct {
 	logger        logger
 	hostKeyGetter hostKeyGetter
 	port          int
 	started       bool
+
+	mutex        sync.Mutex
+	client       *ssh.Client
+	url          string
+	clientConfig *ssh.ClientConfig
 }
 
 type logger interface {
@@ -52,7 +72,20 @@ func (s *Socks5Proxy) Start(key, url string) error {
 		return err
 	}
 
-	clientConfig := &ssh.ClientConfig{
+	if s.port != 0 {
+		running, err := s.running(url, hostKey)
+		if err != nil {
+			return err
+		}
+
+		if running {
+			s.started = true
+			return nil
+		}
+	}
+
+	s.url = url
+	s.clientConfig = &ssh.ClientConfig{
 		User: "jumpbox",
 		Auth: []ssh.AuthMethod{
 			ssh.PublicKeys(signer),
@@ -60,14 +93,14 @@ func (s *Socks5Proxy) Start(key, url string) error {
 		HostKeyCallback: ssh.FixedHostKey(hostKey),
 	}
 
-	serverConn, err := ssh.Dial("tcp", url, clientConfig)
+	s.client, err = ssh.Dial("tcp", url, s.clientConfig)
 	if err != nil {
 		return err
 	}
 
 	conf := &socks5.Config{
 		Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return serverConn.Dial(network, addr)
+			return s.dial(network, addr)
 		},
 	}
 	server, err := socks5.New(conf)
@@ -89,10 +122,159 @@ func (s *Socks5Proxy) Start(key, url string) error {
 		}
 	}()
 
+	go s.keepalive(keepaliveInterval)
+
 	s.started = true
 	return nil
 }
 
+func (s *Socks5Proxy) sshClient() *ssh.Client {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.client
+}
+
+// dial opens a connection through the jumpbox, reconnecting to it once when
+// the ssh connection broke. Connections the jumpbox refuses, such as to a
+// director that is not up yet, do not cause a reconnect.
+func (s *Socks5Proxy) dial(network, addr string) (net.Conn, error) {
+	client := s.sshClient()
+
+	conn, err := client.Dial(network, addr)
+	if _, rejected := err.(*ssh.OpenChannelError); err == nil || rejected {
+		return conn, err
+	}
+
+	client, reconnectErr := s.reconnect(client)
+	if reconnectErr != nil {
+		return nil, err
+	}
+
+	return client.Dial(network, addr)
+}
+
+// keepalive pings the jumpbox, so that idle connections are not dropped by
+// firewalls and load balancers, and reconnects as soon as a ping fails
+// rather than when the bosh cli next needs the proxy. It stops when the
+// jumpbox cannot be reached again; dial still tries to reconnect.
+func (s *Socks5Proxy) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		client := s.sshClient()
+
+		replied := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			replied <- err
+		}()
+
+		var err error
+		select {
+		case err = <-replied:
+		case <-time.After(keepaliveTimeout):
+			err = errors.New("timed out")
+		}
+
+		if err != nil {
+			if _, err := s.reconnect(client); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// reconnect replaces the broken ssh connection, retrying with backoff. When
+// another connection replaced it already, that one is used.
+func (s *Socks5Proxy) reconnect(broken *ssh.Client) (*ssh.Client, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.client != broken {
+		return s.client, nil
+	}
+	broken.Close()
+
+	var err error
+	for attempt := 0; attempt < maxReconnectAttempts; attempt++ {
+		if attempt > 0 {
+			sleep(time.Duration(1<<uint(attempt-1)) * time.Second)
+		}
+
+		var client *ssh.Client
+		client, err = ssh.Dial("tcp", s.url, s.clientConfig)
+		if err == nil {
+			s.client = client
+			return client, nil
+		}
+	}
+
+	s.logger.Println(fmt.Sprintf("err: failed to reconnect the socks5 proxy to the jumpbox: %s", err))
+	return nil, err
+}
+
+// running reports whether a proxy to the jumpbox already listens on the port,
+// such as one started by a previous bbl or by the ssh command of print-env,
+// so that it can be reused. The jumpbox is recognized by the host key of the
+// ssh server the proxy reaches on the jumpbox itself.
+func (s *Socks5Proxy) running(url string, hostKey ssh.PublicKey) (bool, error) {
+	conn, err := net.DialTimeout("tcp", s.Addr(), proxyCheckTimeout)
+	if err != nil {
+		return false, nil
+	}
+	conn.Close()
+
+	inUse := fmt.Errorf("port %d is in use by something other than a proxy to the jumpbox %s", s.port, url)
+
+	_, sshPort, err := net.SplitHostPort(url)
+	if err != nil {
+		return false, err
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", s.Addr(), nil, deadlineDialer{})
+	if err != nil {
+		// not tested
+		return false, err
+	}
+
+	sshAddr := net.JoinHostPort("127.0.0.1", sshPort)
+	conn, err = dialer.Dial("tcp", sshAddr)
+	if err != nil {
+		return false, inUse
+	}
+	defer conn.Close()
+
+	// the handshake is abandoned as soon as the host key is seen
+	var seen ssh.PublicKey
+	ssh.NewClientConn(conn, sshAddr, &ssh.ClientConfig{
+		User: "jumpbox",
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			seen = key
+			return errHostKeySeen
+		},
+	})
+	if seen == nil || !bytes.Equal(seen.Marshal(), hostKey.Marshal()) {
+		return false, inUse
+	}
+
+	return true, nil
+}
+
+// deadlineDialer bounds the whole check, so that a port held by something
+// that never answers does not hang bbl.
+type deadlineDialer struct{}
+
+func (deadlineDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := net.DialTimeout(network, addr, proxyCheckTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return conn, conn.SetDeadline(time.Now().Add(proxyCheckTimeout))
+}
+
 func (s *Socks5Proxy) Addr() string {
 	return fmt.Sprintf("127.0.0.1:%d", s.port)
 }
@@ -2,7 +2,10 @@ package proxy_test
 
 import (
 	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -12,7 +15,9 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/proxy"
 
+	socks5 "github.com/armon/go-socks5"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/context"
 	goproxy "golang.org/x/net/proxy"
 
 	. "github.com/onsi/ginkgo"
@@ -106,6 +111,134 @@ var _ = Describe("Socks5Proxy", func() {
 			})
 		})
 
+		Context("when a proxy to the jumpbox already listens on the port", func() {
+			var (
+				existingProxy net.Listener
+				port          int
+			)
+
+			BeforeEach(func() {
+				signer, err := ssh.ParsePrivateKey([]byte(sshPrivateKey))
+				Expect(err).NotTo(HaveOccurred())
+
+				jumpboxSSHURL := startHostKeySSHServer(signer)
+
+				server, err := socks5.New(&socks5.Config{
+					Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						return net.Dial(network, jumpboxSSHURL)
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				existingProxy, err = net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+				go server.Serve(existingProxy)
+
+				port = existingProxy.Addr().(*net.TCPAddr).Port
+				socks5Proxy = proxy.NewSocks5Proxy(logger, hostKeyGetter, port)
+			})
+
+			AfterEach(func() {
+				existingProxy.Close()
+			})
+
+			It("reuses the proxy", func() {
+				err := socks5Proxy.Start(sshPrivateKey, sshServerURL)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(socks5Proxy.Addr()).To(Equal(fmt.Sprintf("127.0.0.1:%d", port)))
+				Expect(logger.PrintlnMessages()).To(BeEmpty())
+			})
+
+			It("returns an error when the proxy is to a different jumpbox", func() {
+				otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+				Expect(err).NotTo(HaveOccurred())
+
+				otherSigner, err := ssh.NewSignerFromKey(otherKey)
+				Expect(err).NotTo(HaveOccurred())
+				hostKeyGetter.GetCall.Returns.HostKey = otherSigner.PublicKey()
+
+				err = socks5Proxy.Start(sshPrivateKey, sshServerURL)
+				Expect(err).To(MatchError(fmt.Sprintf("port %d is in use by something other than a proxy to the jumpbox %s", port, sshServerURL)))
+			})
+		})
+
+		Context("when the connection to the jumpbox breaks", func() {
+			var (
+				jumpbox *forwardingSSHServer
+				get     func() (string, error)
+			)
+
+			BeforeEach(func() {
+				signer, err := ssh.ParsePrivateKey([]byte(sshPrivateKey))
+				Expect(err).NotTo(HaveOccurred())
+
+				jumpbox = startForwardingSSHServer(signer)
+				proxy.SetSleep(func(time.Duration) {})
+
+				get = func() (string, error) {
+					socks5Client, err := goproxy.SOCKS5("tcp", socks5Proxy.Addr(), nil, goproxy.Direct)
+					if err != nil {
+						return "", err
+					}
+
+					conn, err := socks5Client.Dial("tcp", httpServerHostPort)
+					if err != nil {
+						return "", err
+					}
+					defer conn.Close()
+
+					_, err = conn.Write([]byte("GET / HTTP/1.0\r\n\r\n"))
+					if err != nil {
+						return "", err
+					}
+
+					return bufio.NewReader(conn).ReadString('\n')
+				}
+			})
+
+			AfterEach(func() {
+				jumpbox.Stop()
+				proxy.ResetSleep()
+				proxy.ResetKeepaliveInterval()
+			})
+
+			It("reconnects when a connection is opened", func() {
+				err := socks5Proxy.Start(sshPrivateKey, jumpbox.URL)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(get, "5s").Should(Equal("HTTP/1.0 200 OK\r\n"))
+
+				jumpbox.DropClients()
+
+				Expect(get()).To(Equal("HTTP/1.0 200 OK\r\n"))
+				Expect(jumpbox.Connections()).To(Equal(2))
+			})
+
+			It("sends keepalives and reconnects when one fails", func() {
+				proxy.SetKeepaliveInterval(10 * time.Millisecond)
+
+				err := socks5Proxy.Start(sshPrivateKey, jumpbox.URL)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(jumpbox.Keepalives, "5s").Should(BeNumerically(">", 0))
+
+				jumpbox.DropClients()
+
+				Eventually(jumpbox.Connections, "5s").Should(Equal(2))
+			})
+
+			It("logs an error when the jumpbox cannot be reached again", func() {
+				err := socks5Proxy.Start(sshPrivateKey, jumpbox.URL)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(get, "5s").Should(Equal("HTTP/1.0 200 OK\r\n"))
+
+				jumpbox.Stop()
+
+				_, err = get()
+				Expect(err).To(HaveOccurred())
+				Expect(logger.PrintlnMessages()).To(ContainElement(ContainSubstring("err: failed to reconnect the socks5 proxy to the jumpbox: ")))
+			})
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when it cannot parse the private key", func() {
 				err := socks5Proxy.Start("some-bad-private-key", sshServerURL)
@@ -123,7 +256,7 @@ var _ = Describe("Socks5Proxy", func() {
 				Expect(err).To(MatchError("dial tcp: address some-bad-url: missing port in address"))
 			})
 
-			Context("when it cannot start a socks5 proxy server", func() {
+			Context("when the port is in use by something other than a proxy", func() {
 				var (
 					fakeServer net.Listener
 				)
@@ -133,19 +266,18 @@ var _ = Describe("Socks5Proxy", func() {
 					fakeServer, err = net.Listen("tcp", "127.0.0.1:9999")
 					Expect(err).NotTo(HaveOccurred())
 
+					proxy.SetProxyCheckTimeout(100 * time.Millisecond)
 					socks5Proxy = proxy.NewSocks5Proxy(logger, hostKeyGetter, 9999)
 				})
 
 				AfterEach(func() {
 					fakeServer.Close()
+					proxy.ResetProxyCheckTimeout()
 				})
 
-				It("logs a helpful error message", func() {
+				It("returns an error", func() {
 					err := socks5Proxy.Start(sshPrivateKey, sshServerURL)
-					Expect(err).NotTo(HaveOccurred())
-					Eventually(func() []string {
-						return logger.PrintlnMessages()
-					}, "10s").Should(ContainElement("err: failed to start socks5 proxy: listen tcp 127.0.0.1:9999: bind: address already in use"))
+					Expect(err).To(MatchError(fmt.Sprintf("port 9999 is in use by something other than a proxy to the jumpbox %s", sshServerURL)))
 				})
 			})
 
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var agentPIDPattern = regexp.MustCompile(`SSH_AGENT_PID=(\d+)`)
+
+// SSHAgent loads keys into an ssh-agent with ssh-add, so that they are never
+// written to disk.
+type SSHAgent struct{}
+
+// AgentEnv is the agent bbl started, when there was none to use. Both are
+// empty when the key was added to the agent of SSH_AUTH_SOCK.
+type AgentEnv struct {
+	Socket string
+	PID    string
+}
+
+func NewSSHAgent() SSHAgent {
+	return SSHAgent{}
+}
+
+// Add loads the key into the agent of SSH_AUTH_SOCK, or into a dedicated
+// agent listening on a socket only the user can reach when there is none.
+func (a SSHAgent) Add(key string) (AgentEnv, error) {
+	var agentEnv AgentEnv
+
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		var err error
+		agentEnv, err = a.start()
+		if err != nil {
+			return AgentEnv{}, err
+		}
+		socket = agentEnv.Socket
+	}
+
+	var stderr bytes.Buffer
+	command := exec.Command("ssh-add", "-")
+	command.Env = append(os.Environ(), fmt.Sprintf("SSH_AUTH_SOCK=%s", socket))
+	command.Stdin = strings.NewReader(key)
+	command.Stderr = &stderr
+
+	err := command.Run()
+	if err != nil {
+		agentEnv.stop()
+		return AgentEnv{}, fmt.Errorf("failed to add the jumpbox key to the ssh agent: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return agentEnv, nil
+}
+
+func (SSHAgent) start() (AgentEnv, error) {
+	dir, err := ioutil.TempDir("", "bbl-ssh-agent")
+	if err != nil {
+		return AgentEnv{}, err
+	}
+
+	socket := filepath.Join(dir, "agent.sock")
+	output, err := exec.Command("ssh-agent", "-s", "-a", socket).Output()
+	if err != nil {
+		return AgentEnv{}, fmt.Errorf("failed to start an ssh agent: %s", err)
+	}
+
+	pid := ""
+	if match := agentPIDPattern.FindSubmatch(output); match != nil {
+		pid = string(match[1])
+	}
+
+	return AgentEnv{Socket: socket, PID: pid}, nil
+}
+
+// stop stops the agent bbl started, when the key could not be added to it.
+func (e AgentEnv) stop() {
+	pid, err := strconv.Atoi(e.PID)
+	if err != nil {
+		return
+	}
+
+	if process, err := os.FindProcess(pid); err == nil {
+		process.Kill()
+	}
+}
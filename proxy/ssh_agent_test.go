@@ -0,0 +1,104 @@
+package proxy_test
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/cloudfoundry/bosh-bootloader/proxy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSHAgent", func() {
+	var (
+		sshAgent     proxy.SSHAgent
+		authSock     string
+		startedAgent proxy.AgentEnv
+	)
+
+	listKeys := func(socket string) (string, error) {
+		command := exec.Command("ssh-add", "-l")
+		command.Env = append(os.Environ(), "SSH_AUTH_SOCK="+socket)
+		output, err := command.CombinedOutput()
+		return string(output), err
+	}
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("ssh-agent"); err != nil {
+			Skip("ssh-agent is not installed")
+		}
+
+		sshAgent = proxy.NewSSHAgent()
+		authSock = os.Getenv("SSH_AUTH_SOCK")
+		startedAgent = proxy.AgentEnv{}
+	})
+
+	AfterEach(func() {
+		os.Setenv("SSH_AUTH_SOCK", authSock)
+
+		if startedAgent.PID != "" {
+			pid, err := strconv.Atoi(startedAgent.PID)
+			Expect(err).NotTo(HaveOccurred())
+
+			process, err := os.FindProcess(pid)
+			Expect(err).NotTo(HaveOccurred())
+			process.Kill()
+		}
+	})
+
+	Context("when there is no ssh agent", func() {
+		BeforeEach(func() {
+			os.Unsetenv("SSH_AUTH_SOCK")
+		})
+
+		It("starts one and adds the key to it", func() {
+			var err error
+			startedAgent, err = sshAgent.Add(sshPrivateKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(startedAgent.Socket).To(HaveSuffix("agent.sock"))
+			Expect(startedAgent.PID).To(MatchRegexp(`^\d+$`))
+
+			keys, err := listKeys(startedAgent.Socket)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(ContainSubstring("(RSA)"))
+		})
+
+		It("returns an error when the key is invalid", func() {
+			var err error
+			startedAgent, err = sshAgent.Add("some-bad-private-key")
+			Expect(err).To(MatchError(ContainSubstring("failed to add the jumpbox key to the ssh agent: ")))
+		})
+	})
+
+	Context("when there is an ssh agent", func() {
+		var userAgent proxy.AgentEnv
+
+		BeforeEach(func() {
+			os.Unsetenv("SSH_AUTH_SOCK")
+
+			var err error
+			userAgent, err = sshAgent.Add(sshPrivateKey)
+			Expect(err).NotTo(HaveOccurred())
+			startedAgent = userAgent
+
+			command := exec.Command("ssh-add", "-D")
+			command.Env = append(os.Environ(), "SSH_AUTH_SOCK="+userAgent.Socket)
+			Expect(command.Run()).To(Succeed())
+
+			os.Setenv("SSH_AUTH_SOCK", userAgent.Socket)
+		})
+
+		It("adds the key to it", func() {
+			agentEnv, err := sshAgent.Add(sshPrivateKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(agentEnv).To(Equal(proxy.AgentEnv{}))
+
+			keys, err := listKeys(userAgent.Socket)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(ContainSubstring("(RSA)"))
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type SSHShell struct {
+	hostKeyGetter hostKeyGetter
+	terminal      terminal
+	stdin         io.Reader
+	stdout        io.Writer
+	stderr        io.Writer
+}
+
+type terminal interface {
+	Size() (int, int, error)
+	MakeRaw() (func() error, error)
+}
+
+func NewSSHShell(hostKeyGetter hostKeyGetter, terminal terminal, stdin io.Reader, stdout, stderr io.Writer) SSHShell {
+	return SSHShell{
+		hostKeyGetter: hostKeyGetter,
+		terminal:      terminal,
+		stdin:         stdin,
+		stdout:        stdout,
+		stderr:        stderr,
+	}
+}
+
+// Jumpbox opens an interactive shell on the jumpbox at jumpboxURL.
+func (s SSHShell) Jumpbox(key, jumpboxURL string) error {
+	client, err := s.dial(key, jumpboxURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return s.shell(client)
+}
+
+// Director opens an interactive shell on the director at directorAddr. The
+// connection goes through the jumpbox unless jumpboxURL is empty.
+func (s SSHShell) Director(jumpboxKey, jumpboxURL, directorKey, directorAddr string) error {
+	if jumpboxURL == "" {
+		return s.Jumpbox(directorKey, directorAddr)
+	}
+
+	jumpbox, err := s.dial(jumpboxKey, jumpboxURL)
+	if err != nil {
+		return err
+	}
+	defer jumpbox.Close()
+
+	signer, err := ssh.ParsePrivateKey([]byte(directorKey))
+	if err != nil {
+		return err
+	}
+
+	conn, err := jumpbox.Dial("tcp", directorAddr)
+	if err != nil {
+		return err
+	}
+
+	// The director's host key is not in the state. It is only reachable
+	// through the jumpbox, whose host key is checked.
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, directorAddr, &ssh.ClientConfig{
+		User: "jumpbox",
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(signer),
+		},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	director := ssh.NewClient(clientConn, channels, requests)
+	defer director.Close()
+
+	return s.shell(director)
+}
+
+func (s SSHShell) dial(key, url string) (*ssh.Client, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	hostKey, err := s.hostKeyGetter.Get(key, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.Dial("tcp", url, &ssh.ClientConfig{
+		User: "jumpbox",
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(signer),
+		},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	})
+}
+
+// shell runs a login shell wired to stdin, stdout and stderr. A terminal is
+// only requested when stdin is one, so that bbl ssh can be scripted.
+func (s SSHShell) shell(client *ssh.Client) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdin = s.stdin
+	session.Stdout = s.stdout
+	session.Stderr = s.stderr
+
+	width, height, err := s.terminal.Size()
+	if err == nil {
+		term := os.Getenv("TERM")
+		if term == "" {
+			term = "xterm"
+		}
+
+		err = session.RequestPty(term, height, width, ssh.TerminalModes{ssh.ECHO: 1})
+		if err != nil {
+			return err
+		}
+
+		restore, err := s.terminal.MakeRaw()
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	err = session.Shell()
+	if err != nil {
+		return err
+	}
+
+	err = session.Wait()
+	if _, ok := err.(*ssh.ExitError); ok {
+		// the exit status belongs to the last command run in the shell
+		return nil
+	}
+
+	return err
+}
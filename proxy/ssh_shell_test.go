@@ -0,0 +1,309 @@
+package proxy_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/proxy"
+
+	"golang.org/x/crypto/ssh"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSHShell", func() {
+	var (
+		sshShell      proxy.SSHShell
+		hostKeyGetter *fakes.HostKeyGetter
+		terminal      *fakes.Terminal
+		stdout        *bytes.Buffer
+		stderr        *bytes.Buffer
+
+		jumpbox  *shellServer
+		director *shellServer
+	)
+
+	BeforeEach(func() {
+		signer, err := ssh.ParsePrivateKey([]byte(sshPrivateKey))
+		Expect(err).NotTo(HaveOccurred())
+
+		hostKeyGetter = &fakes.HostKeyGetter{}
+		hostKeyGetter.GetCall.Returns.HostKey = signer.PublicKey()
+
+		terminal = &fakes.Terminal{}
+		terminal.SizeCall.Returns.Width = 80
+		terminal.SizeCall.Returns.Height = 24
+
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+
+		jumpbox = startShellServer("jumpbox", 0)
+		director = startShellServer("director", 0)
+
+		sshShell = proxy.NewSSHShell(hostKeyGetter, terminal, strings.NewReader("some-input\n"), stdout, stderr)
+	})
+
+	AfterEach(func() {
+		jumpbox.Close()
+		director.Close()
+	})
+
+	Describe("Jumpbox", func() {
+		It("opens a shell on the jumpbox", func() {
+			err := sshShell.Jumpbox(sshPrivateKey, jumpbox.Addr())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hostKeyGetter.GetCall.Receives.PrivateKey).To(Equal(sshPrivateKey))
+			Expect(hostKeyGetter.GetCall.Receives.ServerURL).To(Equal(jumpbox.Addr()))
+
+			Expect(stdout.String()).To(Equal("jumpbox shell: some-input\n"))
+			Expect(stderr.String()).To(Equal("jumpbox stderr\n"))
+		})
+
+		It("requests a terminal and puts the local one in raw mode until the shell exits", func() {
+			err := sshShell.Jumpbox(sshPrivateKey, jumpbox.Addr())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(<-jumpbox.pty).To(Equal("80x24"))
+			Expect(terminal.MakeRawCall.CallCount).To(Equal(1))
+			Expect(terminal.RestoreCall.CallCount).To(Equal(1))
+		})
+
+		Context("when stdin is not a terminal", func() {
+			It("does not request a terminal", func() {
+				terminal.SizeCall.Returns.Error = errors.New("not a terminal")
+
+				err := sshShell.Jumpbox(sshPrivateKey, jumpbox.Addr())
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jumpbox.pty).To(BeEmpty())
+				Expect(terminal.MakeRawCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the last command in the shell fails", func() {
+			It("does not return an error", func() {
+				failing := startShellServer("failing", 1)
+				defer failing.Close()
+
+				err := sshShell.Jumpbox(sshPrivateKey, failing.Addr())
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the key is invalid", func() {
+				err := sshShell.Jumpbox("some-invalid-key", jumpbox.Addr())
+				Expect(err).To(MatchError("ssh: no key found"))
+			})
+
+			It("returns an error when the host key cannot be retrieved", func() {
+				hostKeyGetter.GetCall.Returns.Error = errors.New("failed to get host key")
+
+				err := sshShell.Jumpbox(sshPrivateKey, jumpbox.Addr())
+				Expect(err).To(MatchError("failed to get host key"))
+			})
+
+			It("returns an error when the terminal cannot be put in raw mode", func() {
+				terminal.MakeRawCall.Returns.Error = errors.New("failed to make raw")
+
+				err := sshShell.Jumpbox(sshPrivateKey, jumpbox.Addr())
+				Expect(err).To(MatchError("failed to make raw"))
+			})
+		})
+	})
+
+	Describe("Director", func() {
+		It("opens a shell on the director through the jumpbox", func() {
+			err := sshShell.Director(sshPrivateKey, jumpbox.Addr(), sshPrivateKey, director.Addr())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hostKeyGetter.GetCall.CallCount).To(Equal(1))
+			Expect(hostKeyGetter.GetCall.Receives.ServerURL).To(Equal(jumpbox.Addr()))
+
+			Expect(<-jumpbox.forwarded).To(Equal(director.Addr()))
+			Expect(stdout.String()).To(Equal("director shell: some-input\n"))
+		})
+
+		Context("when there is no jumpbox", func() {
+			It("opens a shell on the director directly", func() {
+				err := sshShell.Director("", "", sshPrivateKey, director.Addr())
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(hostKeyGetter.GetCall.Receives.ServerURL).To(Equal(director.Addr()))
+				Expect(jumpbox.forwarded).To(BeEmpty())
+				Expect(stdout.String()).To(Equal("director shell: some-input\n"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the director key is invalid", func() {
+				err := sshShell.Director(sshPrivateKey, jumpbox.Addr(), "some-invalid-key", director.Addr())
+				Expect(err).To(MatchError("ssh: no key found"))
+			})
+
+			It("returns an error when the jumpbox cannot reach the director", func() {
+				director.Close()
+
+				err := sshShell.Director(sshPrivateKey, jumpbox.Addr(), sshPrivateKey, director.Addr())
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
+
+// shellServer is an ssh server that answers every shell with its name and the
+// first line of input, and forwards direct-tcpip channels like a jumpbox.
+type shellServer struct {
+	listener   net.Listener
+	name       string
+	exitStatus uint32
+	pty        chan string
+	forwarded  chan string
+}
+
+func startShellServer(name string, exitStatus uint32) *shellServer {
+	signer, err := ssh.ParsePrivateKey([]byte(sshPrivateKey))
+	Expect(err).NotTo(HaveOccurred())
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(c ssh.ConnMetadata, pubKey ssh.PublicKey) (*ssh.Permissions, error) {
+			if c.User() == "jumpbox" && bytes.Equal(signer.PublicKey().Marshal(), pubKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown public key for %q", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	server := &shellServer{
+		listener:   listener,
+		name:       name,
+		exitStatus: exitStatus,
+		pty:        make(chan string, 1),
+		forwarded:  make(chan string, 1),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn, config)
+		}
+	}()
+
+	return server
+}
+
+func (s *shellServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *shellServer) Close() {
+	s.listener.Close()
+}
+
+func (s *shellServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "session":
+			go s.session(newChannel)
+		case "direct-tcpip":
+			go s.forward(newChannel)
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+		}
+	}
+}
+
+func (s *shellServer) session(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	for request := range requests {
+		switch request.Type {
+		case "pty-req":
+			var pty struct {
+				Term   string
+				Width  uint32
+				Height uint32
+				PixelW uint32
+				PixelH uint32
+				Modes  string
+			}
+			ssh.Unmarshal(request.Payload, &pty)
+			s.pty <- fmt.Sprintf("%dx%d", pty.Width, pty.Height)
+			request.Reply(true, nil)
+		case "shell":
+			request.Reply(true, nil)
+
+			input := make([]byte, len("some-input\n"))
+			io.ReadFull(channel, input)
+			fmt.Fprintf(channel, "%s shell: %s", s.name, input)
+			fmt.Fprintf(channel.Stderr(), "%s stderr\n", s.name)
+
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{s.exitStatus}))
+			return
+		default:
+			request.Reply(false, nil)
+		}
+	}
+}
+
+func (s *shellServer) forward(newChannel ssh.NewChannel) {
+	var target struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	ssh.Unmarshal(newChannel.ExtraData(), &target)
+
+	addr := net.JoinHostPort(target.Host, fmt.Sprintf("%d", target.Port))
+	remote, err := net.Dial("tcp", addr)
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	defer remote.Close()
+
+	channel, _, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	s.forwarded <- addr
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, channel)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(channel, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Terminal controls the local terminal with stty, so that key presses such
+// as Ctrl-C are sent to the remote shell instead of stopping bbl.
+type Terminal struct {
+	stdin *os.File
+}
+
+func NewTerminal(stdin *os.File) Terminal {
+	return Terminal{
+		stdin: stdin,
+	}
+}
+
+// Size returns the width and height of the terminal. It fails when stdin is
+// not a terminal.
+func (t Terminal) Size() (int, int, error) {
+	output, err := t.stty("size")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var width, height int
+	_, err = fmt.Sscanf(output, "%d %d", &height, &width)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return width, height, nil
+}
+
+// MakeRaw puts the terminal in raw mode and returns a func that restores the
+// previous mode.
+func (t Terminal) MakeRaw() (func() error, error) {
+	previous, err := t.stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = t.stty("raw", "-echo")
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_, err := t.stty(previous)
+		return err
+	}, nil
+}
+
+func (t Terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.stdin
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %s", strings.Join(args, " "), err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
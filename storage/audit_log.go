@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const AuditLogFileName = "audit.log"
+
+// AuditRecord is a line of the audit log, a bbl command that was run against
+// the environment in the state dir.
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	Result   string    `json:"result"`
+	ExitCode int       `json:"exitCode"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// AuditLog appends a json record of every bbl command run against the
+// environment in dir to the audit.log file next to bbl-state.json.
+type AuditLog struct {
+	path string
+}
+
+func NewAuditLog(dir string) AuditLog {
+	return AuditLog{
+		path: filepath.Join(dir, AuditLogFileName),
+	}
+}
+
+// Append adds record to the audit log. Nothing is written to a directory
+// that has neither a bbl state nor an audit log, so that commands run
+// outside of an environment leave no file behind, while the destroy that
+// removes the bbl state is still recorded.
+func (a AuditLog) Append(record AuditRecord) error {
+	if !fileExists(a.path) && !fileExists(filepath.Join(filepath.Dir(a.path), StateFileName)) {
+		return nil
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err //not tested
+	}
+
+	file, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.FileMode(0644))
+	if err != nil {
+		return fmt.Errorf("failed to write the audit log: %s", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write the audit log: %s", err)
+	}
+
+	return nil
+}
+
+// Records returns the records in the audit log, oldest first.
+func (a AuditLog) Records() ([]AuditRecord, error) {
+	records := []AuditRecord{}
+
+	file, err := os.Open(a.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record AuditRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s is not a valid audit record: %s", line, a.path, err)
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
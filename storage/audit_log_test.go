@@ -0,0 +1,93 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuditLog", func() {
+	var (
+		tempDir  string
+		auditLog storage.AuditLog
+		record   storage.AuditRecord
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		auditLog = storage.NewAuditLog(tempDir)
+
+		record = storage.AuditRecord{
+			Time:    time.Date(2017, 7, 1, 12, 0, 0, 0, time.UTC),
+			User:    "some-user",
+			Command: "up",
+			Args:    []string{"up", "--name", "some-name"},
+			Result:  "success",
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("appends records next to the bbl state and reads them back", func() {
+		err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte("{}"), os.FileMode(0644))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = auditLog.Append(record)
+		Expect(err).NotTo(HaveOccurred())
+
+		failed := record
+		failed.Command = "destroy"
+		failed.Result = "failure"
+		failed.ExitCode = 1
+		failed.Error = "failed to destroy"
+		err = auditLog.Append(failed)
+		Expect(err).NotTo(HaveOccurred())
+
+		records, err := auditLog.Records()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(Equal([]storage.AuditRecord{record, failed}))
+	})
+
+	It("keeps appending once the bbl state has been destroyed", func() {
+		err := ioutil.WriteFile(filepath.Join(tempDir, "audit.log"), []byte{}, os.FileMode(0644))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = auditLog.Append(record)
+		Expect(err).NotTo(HaveOccurred())
+
+		records, err := auditLog.Records()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+	})
+
+	It("does not write to a directory without an environment", func() {
+		err := auditLog.Append(record)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = os.Stat(filepath.Join(tempDir, "audit.log"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		records, err := auditLog.Records()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(BeEmpty())
+	})
+
+	It("returns an error when a line is not a record", func() {
+		err := ioutil.WriteFile(filepath.Join(tempDir, "audit.log"), []byte("%%%\n"), os.FileMode(0644))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = auditLog.Records()
+		Expect(err).To(MatchError(ContainSubstring("line 1 of")))
+	})
+})
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	BackupsDirName = "backups"
+
+	backupFilePrefix        = "bbl-state-"
+	backupFileSuffix        = ".json"
+	secretsBackupFilePrefix = "bbl-secrets-"
+	secretsBackupFileSuffix = ".enc"
+	backupTimeFormat        = "20060102T150405.000000000Z"
+)
+
+var backupTime = time.Now
+
+// Backups returns the names of the state backups, oldest first.
+func (s Store) Backups() ([]string, error) {
+	files, err := ioutil.ReadDir(s.backupsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	backups := []string{}
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+// Restore replaces the state file and the secrets file with the named
+// backup. The current files are backed up first so that a restore can itself
+// be rolled back.
+func (s Store) Restore(name string) error {
+	if filepath.Base(name) != name {
+		return fmt.Errorf("invalid backup name %q", name)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(s.backupsDir(), name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("backup %q not found in %s", name, s.backupsDir())
+		}
+		return err
+	}
+
+	secrets, err := ioutil.ReadFile(filepath.Join(s.backupsDir(), secretsBackupName(name)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	current, err := ioutil.ReadFile(s.stateFile)
+	switch {
+	case err == nil:
+		err = s.writeBackup(current)
+		if err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	err = ioutil.WriteFile(s.stateFile, contents, os.FileMode(0644))
+	if err != nil {
+		return err
+	}
+
+	if secrets != nil {
+		err = ioutil.WriteFile(s.secretsFile(), secrets, os.FileMode(0600))
+	} else {
+		err = os.Remove(s.secretsFile())
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if s.backend != nil {
+		return pushState(s.backend, filepath.Dir(s.stateFile))
+	}
+
+	return nil
+}
+
+// backup keeps a copy of the current state file when the terraform state
+// is about to change.
+func (s Store) backup(state State) error {
+	if s.backups == 0 {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(s.stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var previous State
+	err = json.Unmarshal(contents, &previous)
+	if err == nil && previous.TFState == state.TFState {
+		return nil
+	}
+
+	return s.writeBackup(contents)
+}
+
+func (s Store) writeBackup(contents []byte) error {
+	if s.backups == 0 {
+		return nil
+	}
+
+	err := os.MkdirAll(s.backupsDir(), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s%s%s", backupFilePrefix, backupTime().UTC().Format(backupTimeFormat), backupFileSuffix)
+	err = ioutil.WriteFile(filepath.Join(s.backupsDir(), name), contents, os.FileMode(0600))
+	if err != nil {
+		return err
+	}
+
+	secrets, err := ioutil.ReadFile(s.secretsFile())
+	switch {
+	case err == nil:
+		err = ioutil.WriteFile(filepath.Join(s.backupsDir(), secretsBackupName(name)), secrets, os.FileMode(0600))
+		if err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	backups, err := s.Backups()
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > s.backups {
+		for _, file := range []string{backups[0], secretsBackupName(backups[0])} {
+			err = os.Remove(filepath.Join(s.backupsDir(), file))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+func (s Store) backupsDir() string {
+	return filepath.Join(filepath.Dir(s.stateFile), BackupsDirName)
+}
+
+func (s Store) secretsFile() string {
+	return filepath.Join(filepath.Dir(s.stateFile), SecretsFileName)
+}
+
+// secretsBackupName is the name of the copy of the secrets file that was
+// taken together with the state backup name.
+func secretsBackupName(name string) string {
+	timestamp := strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), backupFileSuffix)
+	return secretsBackupFilePrefix + timestamp + secretsBackupFileSuffix
+}
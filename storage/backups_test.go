@@ -0,0 +1,196 @@
+package storage_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backups", func() {
+	var (
+		store      storage.Store
+		tempDir    string
+		backupsDir string
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		backupsDir = filepath.Join(tempDir, "backups")
+
+		store = storage.NewStore(tempDir, 2, nil)
+
+		now = time.Date(2017, time.August, 1, 12, 30, 0, 0, time.UTC)
+		storage.SetBackupTime(func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		})
+	})
+
+	AfterEach(func() {
+		storage.ResetBackupTime()
+	})
+
+	Describe("Set", func() {
+		It("backs up the previous state file when the terraform state changes", func() {
+			err := store.Set(storage.State{IAAS: "gcp", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			previous, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = store.Set(storage.State{IAAS: "gcp", TFState: "some-new-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			backups, err := store.Backups()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backups).To(Equal([]string{"bbl-state-20170801T123001.000000000Z.json"}))
+
+			backup, err := ioutil.ReadFile(filepath.Join(backupsDir, backups[0]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backup).To(Equal(previous))
+		})
+
+		Context("when the secrets are kept in an encrypted file", func() {
+			BeforeEach(func() {
+				os.Setenv("BBL_SECRET_STORE_KEY", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("BBL_SECRET_STORE_KEY")
+			})
+
+			It("backs up the secrets file together with the state file", func() {
+				for _, tfState := range []string{"tf-state-1", "tf-state-2", "tf-state-3", "tf-state-4"} {
+					err := store.Set(storage.State{
+						IAAS:        "gcp",
+						SecretStore: "encrypted-file",
+						TFState:     tfState,
+						BOSH:        storage.BOSH{DirectorPassword: "password-for-" + tfState},
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				files, err := ioutil.ReadDir(backupsDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				names := []string{}
+				for _, file := range files {
+					names = append(names, file.Name())
+				}
+				Expect(names).To(ConsistOf(
+					"bbl-secrets-20170801T123002.000000000Z.enc",
+					"bbl-secrets-20170801T123003.000000000Z.enc",
+					"bbl-state-20170801T123002.000000000Z.json",
+					"bbl-state-20170801T123003.000000000Z.json",
+				))
+
+				err = store.Restore("bbl-state-20170801T123002.000000000Z.json")
+				Expect(err).NotTo(HaveOccurred())
+
+				state, err := storage.GetState(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.TFState).To(Equal("tf-state-2"))
+				Expect(state.BOSH.DirectorPassword).To(Equal("password-for-tf-state-2"))
+			})
+		})
+
+		It("does not back up the state when the terraform state is unchanged", func() {
+			err := store.Set(storage.State{IAAS: "gcp", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = store.Set(storage.State{IAAS: "gcp", EnvID: "some-env-id", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(backupsDir)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("only keeps the configured number of backups", func() {
+			for _, tfState := range []string{"tf-state-1", "tf-state-2", "tf-state-3", "tf-state-4"} {
+				err := store.Set(storage.State{IAAS: "gcp", TFState: tfState})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			backups, err := store.Backups()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backups).To(Equal([]string{
+				"bbl-state-20170801T123002.000000000Z.json",
+				"bbl-state-20170801T123003.000000000Z.json",
+			}))
+
+			backup, err := ioutil.ReadFile(filepath.Join(backupsDir, backups[1]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(backup)).To(ContainSubstring(`"tfState": "tf-state-3"`))
+		})
+
+		It("does not keep backups when backups are disabled", func() {
+			store = storage.NewStore(tempDir, 0, nil)
+
+			err := store.Set(storage.State{IAAS: "gcp", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = store.Set(storage.State{IAAS: "gcp", TFState: "some-new-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(backupsDir)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Backups", func() {
+		It("returns an empty list when there are no backups", func() {
+			backups, err := store.Backups()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backups).To(BeEmpty())
+		})
+	})
+
+	Describe("Restore", func() {
+		BeforeEach(func() {
+			err := store.Set(storage.State{IAAS: "gcp", TFState: "some-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = store.Set(storage.State{IAAS: "gcp", TFState: "some-bad-tf-state"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("replaces the state file with the backup and backs up the current state", func() {
+			err := store.Restore("bbl-state-20170801T123001.000000000Z.json")
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.TFState).To(Equal("some-tf-state"))
+
+			backups, err := store.Backups()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backups).To(HaveLen(2))
+
+			backup, err := ioutil.ReadFile(filepath.Join(backupsDir, backups[1]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(backup)).To(ContainSubstring(`"tfState": "some-bad-tf-state"`))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the backup does not exist", func() {
+				err := store.Restore("bbl-state-missing.json")
+				Expect(err).To(MatchError(ContainSubstring(`backup "bbl-state-missing.json" not found in`)))
+			})
+
+			It("returns an error when the backup name is a path", func() {
+				err := store.Restore("../bbl-state.json")
+				Expect(err).To(MatchError(`invalid backup name "../bbl-state.json"`))
+			})
+		})
+	})
+})
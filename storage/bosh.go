@@ -15,8 +15,38 @@ type BOSH struct {
 	State                  map[string]interface{} `json:"state"`
 	Manifest               string                 `json:"manifest"`
 	UserOpsFile            string                 `json:"userOpsFile"`
+	UserOpsFiles           []string               `json:"userOpsFiles,omitempty"`
+	UserCACertificate      string                 `json:"userCACertificate,omitempty"`
+	UserCAPrivateKey       string                 `json:"userCAPrivateKey,omitempty"`
+	DirectorSpot           bool                   `json:"directorSpot,omitempty"`
+	DirectorSpotPrice      string                 `json:"directorSpotPrice,omitempty"`
+	DirectorDiskType       string                 `json:"directorDiskType,omitempty"`
+	DirectorVMType         string                 `json:"directorVMType,omitempty"`
+	DirectorDiskSize       int                    `json:"directorDiskSize,omitempty"`
+
+	// DeploymentPath and DeploymentVersion pin the bosh-deployment the
+	// director is interpolated from to a local checkout or to a tag or commit
+	// of cloudfoundry/bosh-deployment, instead of the copy compiled into bbl.
+	DeploymentPath    string `json:"deploymentPath,omitempty"`
+	DeploymentVersion string `json:"deploymentVersion,omitempty"`
+
+	// StemcellURL and StemcellSHA1 replace the stemcell bosh-deployment and
+	// jumpbox-deployment pin, for both the director and the jumpbox.
+	StemcellURL  string `json:"stemcellURL,omitempty"`
+	StemcellSHA1 string `json:"stemcellSHA1,omitempty"`
 }
 
 func (b BOSH) IsEmpty() bool {
 	return reflect.DeepEqual(b, BOSH{})
 }
+
+// OpsFiles returns the user ops files in the order they are applied. A
+// UserOpsFile saved by a bbl that took a single --ops-file comes first.
+func (b BOSH) OpsFiles() []string {
+	var opsFiles []string
+	if b.UserOpsFile != "" {
+		opsFiles = append(opsFiles, b.UserOpsFile)
+	}
+
+	return append(opsFiles, b.UserOpsFiles...)
+}
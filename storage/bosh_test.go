@@ -27,4 +27,23 @@ var _ = Describe("BOSH", func() {
 			Expect(bosh.IsEmpty()).To(BeFalse())
 		})
 	})
+
+	Describe("OpsFiles", func() {
+		It("returns the user ops files in order", func() {
+			bosh := storage.BOSH{
+				UserOpsFiles: []string{"some-ops-file", "some-other-ops-file"},
+			}
+
+			Expect(bosh.OpsFiles()).To(Equal([]string{"some-ops-file", "some-other-ops-file"}))
+		})
+
+		It("applies an ops file saved by an earlier bbl first", func() {
+			bosh := storage.BOSH{
+				UserOpsFile:  "some-legacy-ops-file",
+				UserOpsFiles: []string{"some-ops-file"},
+			}
+
+			Expect(bosh.OpsFiles()).To(Equal([]string{"some-legacy-ops-file", "some-ops-file"}))
+		})
+	})
 })
@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	StateBundleKeyEnv = "BBL_STATE_BUNDLE_KEY"
+
+	// UpLogFileName is the log of an up --detach, written to the state dir.
+	UpLogFileName = "bbl-up.log"
+
+	stateDirBackupPrefix = "state-dir-"
+	generatedTemplate    = "terraform/bbl-template.tf"
+)
+
+// unexportedSuffixes are left out of a bundle: other bundles and terraform
+// state a manual terraform run left next to the overrides.
+var unexportedSuffixes = []string{".tgz", ".bundle", ".tfstate", ".tfstate.backup"}
+
+// Export packages the state directory, the bbl state with its terraform
+// state, manifests and variables, and the files generated next to it, into a
+// gzipped tarball encrypted with AES-256-GCM using the base64 encoded 32 byte
+// key. The secrets the state refers to are resolved from its secret store and
+// kept inline in the exported bbl state, so that the bundle does not depend on
+// the store or its credentials. Backups, the lock, logs and the files bbl
+// generates again on the next run are left out.
+func (s Store) Export(key string) ([]byte, error) {
+	aead, err := newKeyCipher(key, StateBundleKeyEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(s.stateFile)
+	_, err = os.Stat(s.stateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := GetState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	state.SecretStore = ""
+	state.VaultPath = ""
+	state.CredHubPath = ""
+
+	stateContents, err := marshalIndent(state, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && (name == BackupsDirName || info.Name() == ".terraform") {
+			return filepath.SkipDir
+		}
+
+		if !info.Mode().IsRegular() || !exportedFile(name) {
+			return nil
+		}
+
+		contents := stateContents
+		if name != StateFileName {
+			contents, err = ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tarWriter.WriteHeader(&tar.Header{
+			Name:    filepath.ToSlash(name),
+			Mode:    int64(info.Mode().Perm()),
+			Size:    int64(len(contents)),
+			ModTime: info.ModTime(),
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tarWriter.Write(contents)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, archive.Bytes(), nil), nil
+}
+
+// exportedFile returns whether the file name of the state dir belongs in a
+// bundle. The secrets file is left out since the secrets are kept inline in
+// the exported state.
+func exportedFile(name string) bool {
+	name = filepath.ToSlash(name)
+	switch name {
+	case SecretsFileName, LockFileName, UpLogFileName, AuditLogFileName, generatedTemplate:
+		return false
+	}
+
+	for _, suffix := range unexportedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Import unpacks a bundle written by Export into the state directory. A state
+// directory that is not empty is only replaced when replace is set. Its files
+// are then moved to a directory under backups, so that none of them are left
+// next to the imported ones, and its state is backed up so that the import can
+// be rolled back with restore-state. The backups and the audit log stay in
+// place.
+func (s Store) Import(bundle []byte, key string, replace bool) error {
+	aead, err := newKeyCipher(key, StateBundleKeyEnv)
+	if err != nil {
+		return err
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(bundle) < nonceSize {
+		return errors.New("state bundle is corrupt")
+	}
+
+	archive, err := aead.Open(nil, bundle[:nonceSize], bundle[nonceSize:], nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt the state bundle, check %s: %s", StateBundleKeyEnv, err)
+	}
+
+	files, err := readBundle(archive)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := files[StateFileName]; !ok {
+		return fmt.Errorf("state bundle does not contain %s", StateFileName)
+	}
+
+	dir := filepath.Dir(s.stateFile)
+	existing, err := stateDirFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(existing) > 0 {
+		if !replace {
+			return fmt.Errorf("%s is not empty, pass --force to replace it", dir)
+		}
+
+		err = s.clearStateDir(existing)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, file := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		err = os.MkdirAll(filepath.Dir(target), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(target, file.contents, file.mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.backend != nil {
+		return pushState(s.backend, dir)
+	}
+
+	return nil
+}
+
+// stateDirFiles returns the names of the files and directories in dir,
+// leaving out the backups and the audit log.
+func stateDirFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.Name() == BackupsDirName || entry.Name() == AuditLogFileName {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+
+	return names, nil
+}
+
+// clearStateDir backs up the state and moves the named files of the state
+// directory to backups/state-dir-<time>.
+func (s Store) clearStateDir(names []string) error {
+	current, err := ioutil.ReadFile(s.stateFile)
+	switch {
+	case err == nil:
+		err = s.writeBackup(current)
+		if err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	target := filepath.Join(s.backupsDir(), stateDirBackupPrefix+backupTime().UTC().Format(backupTimeFormat))
+	err = os.MkdirAll(target, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.stateFile)
+	for _, name := range names {
+		err = os.Rename(filepath.Join(dir, name), filepath.Join(target, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type bundleFile struct {
+	contents []byte
+	mode     os.FileMode
+}
+
+func readBundle(archive []byte) (map[string]bundleFile, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("state bundle is corrupt: %s", err)
+	}
+
+	files := map[string]bundleFile{}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("state bundle is corrupt: %s", err)
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("state bundle contains a file outside of the state directory: %s", header.Name)
+		}
+
+		contents, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("state bundle is corrupt: %s", err)
+		}
+
+		files[name] = bundleFile{
+			contents: contents,
+			mode:     os.FileMode(header.Mode).Perm(),
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,239 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundle", func() {
+	const (
+		key      = "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="
+		otherKey = "YWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXphYmNkZWY="
+	)
+
+	var (
+		sourceDir string
+		targetDir string
+		source    storage.Store
+		target    storage.Store
+	)
+
+	BeforeEach(func() {
+		var err error
+		sourceDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		targetDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		source = storage.NewStore(sourceDir, 2, nil)
+		target = storage.NewStore(targetDir, 2, nil)
+
+		err = source.Set(storage.State{IAAS: "gcp", EnvID: "some-env", TFState: "some-tf-state"})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.MkdirAll(filepath.Join(sourceDir, "terraform"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(filepath.Join(sourceDir, "terraform", "template.tf"), []byte("some-template"), os.FileMode(0644))
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(filepath.Join(sourceDir, "bbl-secrets.enc"), []byte("some-secrets"), os.FileMode(0600))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.MkdirAll(filepath.Join(sourceDir, "backups"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(filepath.Join(sourceDir, "backups", "bbl-state-some-time.json"), []byte("some-backup"), os.FileMode(0644))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(sourceDir)
+		os.RemoveAll(targetDir)
+	})
+
+	It("exports the state directory and imports it into another one", func() {
+		bundle, err := source.Export(key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(bundle)).NotTo(ContainSubstring("some-tf-state"))
+
+		err = target.Import(bundle, key, false)
+		Expect(err).NotTo(HaveOccurred())
+
+		state, err := storage.GetState(targetDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.EnvID).To(Equal("some-env"))
+		Expect(state.TFState).To(Equal("some-tf-state"))
+
+		template, err := ioutil.ReadFile(filepath.Join(targetDir, "terraform", "template.tf"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(template)).To(Equal("some-template"))
+
+		_, err = os.Stat(filepath.Join(targetDir, "bbl-secrets.enc"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		_, err = os.Stat(filepath.Join(targetDir, "backups"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("leaves out the lock, the logs and the files bbl generates", func() {
+		excluded := []string{
+			"bbl-state.lock",
+			"bbl-up.log",
+			"audit.log",
+			"my-env.bundle",
+			"old-state.tgz",
+			filepath.Join("terraform", "bbl-template.tf"),
+			filepath.Join("terraform", "terraform.tfstate"),
+			filepath.Join("terraform", "terraform.tfstate.backup"),
+			filepath.Join("terraform", ".terraform", "plugins", "some-plugin"),
+		}
+		included := []string{
+			filepath.Join("terraform", "my_override.tf"),
+			filepath.Join("cloud-config", "ops", "my-ops.yml"),
+			"bbl.yml",
+		}
+
+		for _, name := range append(excluded, included...) {
+			path := filepath.Join(sourceDir, name)
+			err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(path, []byte("some-contents"), os.FileMode(0644))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		bundle, err := source.Export(key)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = target.Import(bundle, key, false)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, name := range excluded {
+			Expect(filepath.Join(targetDir, name)).NotTo(BeAnExistingFile())
+		}
+		for _, name := range included {
+			Expect(filepath.Join(targetDir, name)).To(BeAnExistingFile())
+		}
+	})
+
+	It("resolves the secrets of the state into the bundle", func() {
+		os.Setenv("BBL_SECRET_STORE_KEY", otherKey)
+		defer os.Unsetenv("BBL_SECRET_STORE_KEY")
+
+		err := os.Remove(filepath.Join(sourceDir, "bbl-secrets.enc"))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = source.Set(storage.State{
+			IAAS:        "gcp",
+			EnvID:       "some-env",
+			SecretStore: "encrypted-file",
+			BOSH:        storage.BOSH{DirectorPassword: "some-director-password"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		bundle, err := source.Export(key)
+		Expect(err).NotTo(HaveOccurred())
+
+		os.Unsetenv("BBL_SECRET_STORE_KEY")
+
+		err = target.Import(bundle, key, false)
+		Expect(err).NotTo(HaveOccurred())
+
+		state, err := storage.GetState(targetDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.SecretStore).To(BeEmpty())
+		Expect(state.BOSH.DirectorPassword).To(Equal("some-director-password"))
+
+		_, err = os.Stat(filepath.Join(targetDir, "bbl-secrets.enc"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("backs up the existing state directory and clears it when it replaces it", func() {
+		err := target.Set(storage.State{IAAS: "gcp", EnvID: "some-other-env"})
+		Expect(err).NotTo(HaveOccurred())
+		err = os.MkdirAll(filepath.Join(targetDir, "vars"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(filepath.Join(targetDir, "vars", "some-stale-file"), []byte("some-stale-contents"), os.FileMode(0644))
+		Expect(err).NotTo(HaveOccurred())
+
+		bundle, err := source.Export(key)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = target.Import(bundle, key, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		state, err := storage.GetState(targetDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.EnvID).To(Equal("some-env"))
+
+		_, err = os.Stat(filepath.Join(targetDir, "vars"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		backups, err := target.Backups()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backups).To(HaveLen(1))
+
+		stateDirs, err := filepath.Glob(filepath.Join(targetDir, "backups", "state-dir-*"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stateDirs).To(HaveLen(1))
+
+		stale, err := ioutil.ReadFile(filepath.Join(stateDirs[0], "vars", "some-stale-file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(stale)).To(Equal("some-stale-contents"))
+
+		_, err = os.Stat(filepath.Join(stateDirs[0], "bbl-state.json"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the key is invalid", func() {
+			_, err := source.Export("some-key")
+			Expect(err).To(MatchError("BBL_STATE_BUNDLE_KEY must be a base64 encoded 32 byte key"))
+		})
+
+		It("returns an error when there is no state to export", func() {
+			_, err := target.Export(key)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("returns an error when the bundle was encrypted with another key", func() {
+			bundle, err := source.Export(key)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = target.Import(bundle, otherKey, false)
+			Expect(err).To(MatchError(ContainSubstring("failed to decrypt the state bundle, check BBL_STATE_BUNDLE_KEY")))
+		})
+
+		It("does not replace an existing state unless asked to", func() {
+			err := target.Set(storage.State{IAAS: "gcp", EnvID: "some-other-env"})
+			Expect(err).NotTo(HaveOccurred())
+
+			bundle, err := source.Export(key)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = target.Import(bundle, key, false)
+			Expect(err).To(MatchError(targetDir + " is not empty, pass --force to replace it"))
+
+			state, err := storage.GetState(targetDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.EnvID).To(Equal("some-other-env"))
+		})
+
+		It("does not import into a state directory that has other files unless asked to", func() {
+			err := ioutil.WriteFile(filepath.Join(targetDir, "some-file"), []byte("some-contents"), os.FileMode(0644))
+			Expect(err).NotTo(HaveOccurred())
+
+			bundle, err := source.Export(key)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = target.Import(bundle, key, false)
+			Expect(err).To(MatchError(targetDir + " is not empty, pass --force to replace it"))
+
+			_, err = os.Stat(filepath.Join(targetDir, "bbl-state.json"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,506 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	CredHubServerEnv = "CREDHUB_SERVER"
+	CredHubClientEnv = "CREDHUB_CLIENT"
+	CredHubSecretEnv = "CREDHUB_SECRET"
+	CredHubCACertEnv = "CREDHUB_CA_CERT"
+
+	defaultCredHubPathPrefix = "/bbl/"
+)
+
+// variablesSecrets are the vars-stores of bosh create-env. Each variable in
+// them is kept as a credential of its own, so that credhub holds passwords,
+// certificates and keys as it would for a bosh deployment.
+var variablesSecrets = map[string]bool{
+	"bosh.variables":    true,
+	"jumpbox.variables": true,
+}
+
+var errCredentialNotFound = errors.New("credential not found")
+
+type credHubCredential struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// CredHubSecretStore keeps the secrets of an environment as credentials under
+// path in a credhub, authenticating with the client credentials of its UAA.
+type CredHubSecretStore struct {
+	server     string
+	client     string
+	secret     string
+	path       string
+	httpClient *http.Client
+	token      string
+
+	credentials map[string]credHubCredential
+	listed      map[string]bool
+}
+
+// credHubSecretStores keeps one store per credhub and path for a Store, so
+// that the credentials read or written by one save of the state are not read
+// again by the next.
+type credHubSecretStores struct {
+	mutex  sync.Mutex
+	stores map[string]*CredHubSecretStore
+}
+
+func newCredHubSecretStores() *credHubSecretStores {
+	return &credHubSecretStores{stores: map[string]*CredHubSecretStore{}}
+}
+
+// get returns the store for the credhub and path, creating it on first use.
+// A nil cache always creates a new store.
+func (c *credHubSecretStores) get(server, client, secret, caCert, path string) (*CredHubSecretStore, error) {
+	if c == nil {
+		return NewCredHubSecretStore(server, client, secret, caCert, path)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := strings.Join([]string{server, client, path}, "|")
+	if store, ok := c.stores[key]; ok {
+		return store, nil
+	}
+
+	store, err := NewCredHubSecretStore(server, client, secret, caCert, path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.stores[key] = store
+	return store, nil
+}
+
+// NewCredHubSecretStore trusts caCert, a PEM encoded certificate or the path
+// of one like the credhub cli accepts, in addition to the system roots.
+func NewCredHubSecretStore(server, client, secret, caCert, path string) (*CredHubSecretStore, error) {
+	if server == "" || client == "" || secret == "" {
+		return nil, fmt.Errorf("%s, %s and %s must be set to use the %s secret store", CredHubServerEnv, CredHubClientEnv, CredHubSecretEnv, SecretStoreCredHub)
+	}
+
+	path = strings.TrimSuffix(path, "/")
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	tlsConfig := &tls.Config{}
+	if caCert != "" {
+		if contents, err := ioutil.ReadFile(caCert); err == nil {
+			caCert = string(contents)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, fmt.Errorf("%s must be a PEM encoded certificate or the path of one", CredHubCACertEnv)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &CredHubSecretStore{
+		server: strings.TrimSuffix(server, "/"),
+		client: client,
+		secret: secret,
+		path:   path,
+		httpClient: &http.Client{
+			Timeout:   time.Minute,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+		},
+		credentials: map[string]credHubCredential{},
+		listed:      map[string]bool{},
+	}, nil
+}
+
+func (c *CredHubSecretStore) Get(name string) (string, error) {
+	if !variablesSecrets[name] {
+		credential, err := c.credential(c.credentialName(name))
+		if err == errCredentialNotFound {
+			return "", fmt.Errorf("secret %q not found in credhub at %s", name, c.credentialName(name))
+		}
+		if err != nil {
+			return "", err
+		}
+
+		value, _ := credential.Value.(string)
+		return value, nil
+	}
+
+	credentials, err := c.list(c.credentialName(name))
+	if err != nil {
+		return "", err
+	}
+
+	if len(credentials) == 0 {
+		return "", fmt.Errorf("secret %q not found in credhub at %s", name, c.credentialName(name))
+	}
+
+	variables := map[string]interface{}{}
+	for credentialName, credential := range credentials {
+		variables[credentialName[strings.LastIndex(credentialName, "/")+1:]] = fromCredHub(credential)
+	}
+
+	contents, err := yaml.Marshal(variables)
+	if err != nil {
+		return "", err //not tested
+	}
+
+	return string(contents), nil
+}
+
+// Set writes the credentials whose value changed, and for a vars-store
+// deletes the variables it no longer holds, so that a variable dropped to
+// have bosh generate it again is not read back from credhub.
+func (c *CredHubSecretStore) Set(name, value string) error {
+	if !variablesSecrets[name] {
+		return c.put(c.credentialName(name), credHubCredential{Type: "value", Value: value})
+	}
+
+	variables := map[interface{}]interface{}{}
+	err := yaml.Unmarshal([]byte(value), &variables)
+	if err != nil {
+		return fmt.Errorf("failed to read the variables of %s: %s", name, err)
+	}
+
+	dir := c.credentialName(name)
+	current, err := c.list(dir)
+	if err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	for variable, variableValue := range variables {
+		credentialName := fmt.Sprintf("%s/%v", dir, variable)
+		names[credentialName] = true
+
+		err = c.put(credentialName, toCredHub(variableValue))
+		if err != nil {
+			return err
+		}
+	}
+
+	for credentialName := range current {
+		if !names[credentialName] {
+			err = c.delete(credentialName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *CredHubSecretStore) credentialName(name string) string {
+	return c.path + "/" + strings.Replace(name, ".", "/", -1)
+}
+
+func (c *CredHubSecretStore) credential(name string) (credHubCredential, error) {
+	if credential, ok := c.credentials[name]; ok {
+		return credential, nil
+	}
+
+	if c.listed[name[:strings.LastIndex(name, "/")]] {
+		return credHubCredential{}, errCredentialNotFound
+	}
+
+	var response struct {
+		Data []credHubCredential `json:"data"`
+	}
+	err := c.do("GET", "/api/v1/data?current=true&name="+url.QueryEscape(name), nil, &response)
+	if err != nil {
+		return credHubCredential{}, err
+	}
+
+	if len(response.Data) == 0 {
+		return credHubCredential{}, errCredentialNotFound
+	}
+
+	c.credentials[name] = response.Data[0]
+	return response.Data[0], nil
+}
+
+func (c *CredHubSecretStore) list(dir string) (map[string]credHubCredential, error) {
+	if !c.listed[dir] {
+		var response struct {
+			Credentials []struct {
+				Name string `json:"name"`
+			} `json:"credentials"`
+		}
+		err := c.do("GET", "/api/v1/data?path="+url.QueryEscape(dir), nil, &response)
+		if err != nil && err != errCredentialNotFound {
+			return nil, err
+		}
+
+		for _, credential := range response.Credentials {
+			if strings.HasPrefix(credential.Name, dir+"/") && !strings.Contains(strings.TrimPrefix(credential.Name, dir+"/"), "/") {
+				_, err = c.credential(credential.Name)
+				if err != nil && err != errCredentialNotFound {
+					return nil, err
+				}
+			}
+		}
+		c.listed[dir] = true
+	}
+
+	credentials := map[string]credHubCredential{}
+	for name, credential := range c.credentials {
+		if strings.HasPrefix(name, dir+"/") && !strings.Contains(strings.TrimPrefix(name, dir+"/"), "/") {
+			credentials[name] = credential
+		}
+	}
+
+	return credentials, nil
+}
+
+func (c *CredHubSecretStore) put(name string, credential credHubCredential) error {
+	if current, err := c.credential(name); err == nil && sameCredential(current, credential) {
+		return nil
+	} else if err != nil && err != errCredentialNotFound {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"name":  name,
+		"type":  credential.Type,
+		"value": credential.Value,
+	})
+	if err != nil {
+		return err //not tested
+	}
+
+	err = c.do("PUT", "/api/v1/data", body, nil)
+	if err != nil {
+		return err
+	}
+
+	c.credentials[name] = credential
+	return nil
+}
+
+func (c *CredHubSecretStore) delete(name string) error {
+	err := c.do("DELETE", "/api/v1/data?name="+url.QueryEscape(name), nil, nil)
+	if err != nil && err != errCredentialNotFound {
+		return err
+	}
+
+	delete(c.credentials, name)
+	return nil
+}
+
+func (c *CredHubSecretStore) do(method, path string, body []byte, response interface{}) error {
+	return c.request(method, path, body, response, false)
+}
+
+func (c *CredHubSecretStore) request(method, path string, body []byte, response interface{}, retried bool) error {
+	if c.path == "" {
+		return fmt.Errorf("the credhub path is only known once the environment has an id, set --credhub-path to use the %s secret store", SecretStoreCredHub)
+	}
+
+	if c.token == "" {
+		token, err := c.authenticate()
+		if err != nil {
+			return err
+		}
+		c.token = token
+	}
+
+	req, err := http.NewRequest(method, c.server+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach credhub at %s: %s", c.server, err)
+	}
+	defer resp.Body.Close()
+
+	// the token expires during long runs such as an up, get a new one
+	if resp.StatusCode == http.StatusUnauthorized && !retried {
+		c.token = ""
+		return c.request(method, path, body, response, true)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errCredentialNotFound
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("failed to %s credhub %s: %s", strings.ToLower(method), path, resp.Status)
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// authenticate asks credhub where its UAA is and gets a token for the
+// client from it.
+func (c *CredHubSecretStore) authenticate() (string, error) {
+	resp, err := c.httpClient.Get(c.server + "/info")
+	if err != nil {
+		return "", fmt.Errorf("failed to reach credhub at %s: %s", c.server, err)
+	}
+	defer resp.Body.Close()
+
+	var info struct {
+		AuthServer struct {
+			URL string `json:"url"`
+		} `json:"auth-server"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil || info.AuthServer.URL == "" {
+		return "", fmt.Errorf("failed to find the UAA of credhub at %s", c.server)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}, "response_type": {"token"}}
+	req, err := http.NewRequest("POST", strings.TrimSuffix(info.AuthServer.URL, "/")+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(c.client, c.secret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err = c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the UAA of credhub at %s: %s", info.AuthServer.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to authenticate %s with the UAA of credhub: %s", c.client, resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
+
+// toCredHub picks the credhub type of a variable generated by bosh from the
+// fields of its value.
+func toCredHub(value interface{}) credHubCredential {
+	fields, ok := stringKeys(value).(map[string]interface{})
+	if !ok {
+		return credHubCredential{Type: "password", Value: fmt.Sprintf("%v", value)}
+	}
+
+	pick := func(names ...string) map[string]interface{} {
+		picked := map[string]interface{}{}
+		for _, name := range names {
+			picked[name] = fields[name]
+		}
+		return picked
+	}
+
+	_, hasCertificate := fields["certificate"]
+	_, hasPrivateKey := fields["private_key"]
+	_, hasPublicKey := fields["public_key"]
+	_, hasFingerprint := fields["public_key_fingerprint"]
+
+	switch {
+	case hasCertificate:
+		return credHubCredential{Type: "certificate", Value: pick("ca", "certificate", "private_key")}
+	case hasPrivateKey && hasPublicKey && hasFingerprint:
+		return credHubCredential{Type: "ssh", Value: pick("private_key", "public_key")}
+	case hasPrivateKey && hasPublicKey:
+		return credHubCredential{Type: "rsa", Value: pick("private_key", "public_key")}
+	default:
+		return credHubCredential{Type: "json", Value: fields}
+	}
+}
+
+// fromCredHub returns a credential in the form bosh keeps it in a vars-store.
+func fromCredHub(credential credHubCredential) interface{} {
+	fields, ok := credential.Value.(map[string]interface{})
+	if !ok {
+		return credential.Value
+	}
+
+	switch credential.Type {
+	case "certificate":
+		return map[string]interface{}{"ca": fields["ca"], "certificate": fields["certificate"], "private_key": fields["private_key"]}
+	case "ssh":
+		key := map[string]interface{}{"private_key": fields["private_key"], "public_key": fields["public_key"]}
+		if fingerprint, ok := fields["public_key_fingerprint"]; ok {
+			key["public_key_fingerprint"] = fingerprint
+		}
+		return key
+	case "rsa":
+		return map[string]interface{}{"private_key": fields["private_key"], "public_key": fields["public_key"]}
+	default:
+		return fields
+	}
+}
+
+// sameCredential compares credentials in the form they are written in, as
+// credhub adds fields such as the fingerprint of an ssh key when it returns
+// them.
+func sameCredential(current, credential credHubCredential) bool {
+	normalize := func(c credHubCredential) interface{} {
+		contents, _ := json.Marshal(toCredHub(fromCredHub(c)).Value)
+		if c.Type == "value" {
+			contents, _ = json.Marshal(c.Value)
+		}
+
+		var v interface{}
+		json.Unmarshal(contents, &v)
+		return v
+	}
+
+	return current.Type == credential.Type && reflect.DeepEqual(normalize(current), normalize(credential))
+}
+
+// stringKeys converts the maps yaml unmarshals to ones json can marshal.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range v {
+			converted[fmt.Sprintf("%v", key)] = stringKeys(item)
+		}
+		return converted
+	case []interface{}:
+		converted := []interface{}{}
+		for _, item := range v {
+			converted = append(converted, stringKeys(item))
+		}
+		return converted
+	default:
+		return value
+	}
+}
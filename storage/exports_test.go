@@ -1,6 +1,9 @@
 package storage
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 func SetMarshalIndent(f func(state interface{}, prefix, indent string) ([]byte, error)) {
 	marshalIndent = f
@@ -9,3 +12,26 @@ func SetMarshalIndent(f func(state interface{}, prefix, indent string) ([]byte,
 func ResetMarshalIndent() {
 	marshalIndent = json.MarshalIndent
 }
+
+func SetBackupTime(f func() time.Time) {
+	backupTime = f
+}
+
+func ResetBackupTime() {
+	backupTime = time.Now
+}
+
+func ResetCredHubSecretStores(store Store) {
+	store.credHubStores.mutex.Lock()
+	defer store.credHubStores.mutex.Unlock()
+
+	store.credHubStores.stores = map[string]*CredHubSecretStore{}
+}
+
+func SetS3Endpoint(f func(bucket, region string) string) {
+	s3Endpoint = f
+}
+
+func ResetS3Endpoint() {
+	s3Endpoint = virtualHostedEndpoint
+}
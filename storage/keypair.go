@@ -6,8 +6,15 @@ type KeyPair struct {
 	Name       string `json:"name"`
 	PrivateKey string `json:"privateKey"`
 	PublicKey  string `json:"publicKey"`
+	Type       string `json:"type,omitempty"`
+	Bits       int    `json:"bits,omitempty"`
+	External   bool   `json:"external,omitempty"`
 }
 
+// IsEmpty ignores Type and Bits since they only describe how the next key
+// should be generated.
 func (k KeyPair) IsEmpty() bool {
+	k.Type = ""
+	k.Bits = 0
 	return reflect.DeepEqual(k, KeyPair{})
 }
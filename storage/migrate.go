@@ -0,0 +1,60 @@
+package storage
+
+import "fmt"
+
+type stateMigration struct {
+	description string
+	migrate     func(State) (State, bool)
+}
+
+// stateMigrations upgrade the fields an earlier bbl wrote that the current
+// schema replaced. Each one leaves the state untouched when it does not apply.
+var stateMigrations = []stateMigration{
+	{
+		description: "move the single bosh userOpsFile into userOpsFiles",
+		migrate:     migrateUserOpsFile,
+	},
+	{
+		description: "drop the bosh-init credentials that the director variables replaced",
+		migrate:     migrateBOSHCredentials,
+	},
+}
+
+// Migrate upgrades a state written by an earlier bbl to STATE_VERSION. It
+// returns the upgraded state and a description of every change it made.
+func Migrate(state State) (State, []string) {
+	changes := []string{}
+	for _, m := range stateMigrations {
+		var changed bool
+		state, changed = m.migrate(state)
+		if changed {
+			changes = append(changes, m.description)
+		}
+	}
+
+	if state.Version < STATE_VERSION {
+		changes = append(changes, fmt.Sprintf("upgrade the schema from version %d to %d", state.Version, STATE_VERSION))
+		state.Version = STATE_VERSION
+	}
+
+	return state, changes
+}
+
+func migrateUserOpsFile(state State) (State, bool) {
+	if state.BOSH.UserOpsFile == "" {
+		return state, false
+	}
+
+	state.BOSH.UserOpsFiles = state.BOSH.OpsFiles()
+	state.BOSH.UserOpsFile = ""
+	return state, true
+}
+
+func migrateBOSHCredentials(state State) (State, bool) {
+	if len(state.BOSH.Credentials) == 0 || state.BOSH.Variables == "" {
+		return state, false
+	}
+
+	state.BOSH.Credentials = nil
+	return state, true
+}
@@ -0,0 +1,63 @@
+package storage_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrate", func() {
+	It("upgrades the schema version", func() {
+		state, changes := storage.Migrate(storage.State{Version: 3, IAAS: "gcp"})
+
+		Expect(state).To(Equal(storage.State{Version: 8, IAAS: "gcp"}))
+		Expect(changes).To(Equal([]string{"upgrade the schema from version 3 to 8"}))
+	})
+
+	It("moves the single user ops file in front of the user ops files", func() {
+		state, changes := storage.Migrate(storage.State{
+			Version: 8,
+			BOSH: storage.BOSH{
+				UserOpsFile:  "some-legacy-ops-file",
+				UserOpsFiles: []string{"some-ops-file"},
+			},
+		})
+
+		Expect(state.BOSH.UserOpsFile).To(BeEmpty())
+		Expect(state.BOSH.UserOpsFiles).To(Equal([]string{"some-legacy-ops-file", "some-ops-file"}))
+		Expect(changes).To(Equal([]string{"move the single bosh userOpsFile into userOpsFiles"}))
+	})
+
+	It("drops the bosh-init credentials once the director has variables", func() {
+		state, changes := storage.Migrate(storage.State{
+			Version: 8,
+			BOSH: storage.BOSH{
+				Credentials: map[string]string{"mbusPassword": "some-mbus-password"},
+				Variables:   "some-variables",
+			},
+		})
+
+		Expect(state.BOSH.Credentials).To(BeNil())
+		Expect(changes).To(Equal([]string{"drop the bosh-init credentials that the director variables replaced"}))
+	})
+
+	It("keeps the bosh-init credentials of a director without variables", func() {
+		state, changes := storage.Migrate(storage.State{
+			Version: 8,
+			BOSH: storage.BOSH{
+				Credentials: map[string]string{"mbusPassword": "some-mbus-password"},
+			},
+		})
+
+		Expect(state.BOSH.Credentials).To(HaveKey("mbusPassword"))
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("changes nothing in a current state", func() {
+		state, changes := storage.Migrate(storage.State{Version: 8, IAAS: "aws"})
+
+		Expect(state).To(Equal(storage.State{Version: 8, IAAS: "aws"}))
+		Expect(changes).To(BeEmpty())
+	})
+})
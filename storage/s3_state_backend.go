@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+var s3Endpoint = virtualHostedEndpoint
+
+func virtualHostedEndpoint(bucket, region string) string {
+	if region == "" {
+		return fmt.Sprintf("https://%s.s3.amazonaws.com", bucket)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+}
+
+// S3StateBackend stores the state files as objects under key in an S3
+// bucket. The bucket's region is looked up on first use so that only the
+// bucket and key have to be configured.
+//
+// Uploads are conditional on the object being the one bbl downloaded, so a
+// bbl working from an out of date copy fails instead of overwriting the
+// changes of another.
+type S3StateBackend struct {
+	bucket string
+	key    string
+	region string
+	signer *v4.Signer
+	client *http.Client
+
+	// etags holds the ETag of every object that was downloaded or uploaded,
+	// and "" for the ones that were missing.
+	etags map[string]string
+}
+
+// NewS3StateBackend signs requests with the given access key, or with the
+// default credential chain of the aws sdk when no key is given: the AWS_*
+// environment variables, the shared credentials file, and the ECS task or EC2
+// instance role.
+func NewS3StateBackend(bucket, key, accessKeyID, secretAccessKey string) *S3StateBackend {
+	creds := defaults.CredChain(defaults.Config(), defaults.Handlers())
+	if accessKeyID != "" && secretAccessKey != "" {
+		creds = credentials.NewStaticCredentials(accessKeyID, secretAccessKey, "")
+	}
+
+	return &S3StateBackend{
+		bucket: bucket,
+		key:    key,
+		signer: v4.NewSigner(creds),
+		client: &http.Client{Timeout: time.Minute},
+		etags:  map[string]string{},
+	}
+}
+
+func (s *S3StateBackend) Get(name string) ([]byte, error) {
+	resp, err := s.do("GET", name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		s.etags[name] = resp.Header.Get("ETag")
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		s.etags[name] = ""
+		return nil, ErrRemoteStateNotFound
+	default:
+		return nil, s.responseError("download", name, resp)
+	}
+}
+
+func (s *S3StateBackend) Set(name string, contents []byte) error {
+	header := http.Header{}
+	if etag, ok := s.etags[name]; ok {
+		if etag == "" {
+			header.Set("If-None-Match", "*")
+		} else {
+			header.Set("If-Match", etag)
+		}
+	}
+
+	resp, err := s.do("PUT", name, contents, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		s.etags[name] = resp.Header.Get("ETag")
+		return nil
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return StateLockedError{Remote: true}
+	default:
+		return s.responseError("upload", name, resp)
+	}
+}
+
+func (s *S3StateBackend) Delete(name string) error {
+	resp, err := s.do("DELETE", name, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		s.etags[name] = ""
+		return nil
+	default:
+		return s.responseError("delete", name, resp)
+	}
+}
+
+func (s *S3StateBackend) do(method, name string, contents []byte, header http.Header) (*http.Response, error) {
+	if s.region == "" {
+		region, err := s.bucketRegion()
+		if err != nil {
+			return nil, err
+		}
+		s.region = region
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", s3Endpoint(s.bucket, s.region), s.objectKey(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	var body io.ReadSeeker
+	if contents != nil {
+		body = bytes.NewReader(contents)
+		req.ContentLength = int64(len(contents))
+		// The state holds director credentials, keep them encrypted at rest
+		// even when the bucket has no default encryption.
+		req.Header.Set("X-Amz-Server-Side-Encryption", "AES256")
+	}
+
+	_, err = s.signer.Sign(req, body, "s3", s.region, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the request for s3://%s/%s: %s", s.bucket, s.objectKey(name), err)
+	}
+
+	return s.client.Do(req)
+}
+
+// bucketRegion asks S3 where the bucket lives. S3 answers with the region
+// header even when the anonymous request itself is denied.
+func (s *S3StateBackend) bucketRegion() (string, error) {
+	req, err := http.NewRequest("HEAD", s3Endpoint(s.bucket, ""), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("state bucket %q does not exist", s.bucket)
+	}
+
+	region := resp.Header.Get("X-Amz-Bucket-Region")
+	if region == "" {
+		return "", fmt.Errorf("failed to find the region of state bucket %q", s.bucket)
+	}
+
+	return region, nil
+}
+
+func (s *S3StateBackend) objectKey(name string) string {
+	return path.Join(s.key, name)
+}
+
+func (s *S3StateBackend) responseError(action, name string, resp *http.Response) error {
+	return fmt.Errorf("failed to %s s3://%s/%s: %s", action, s.bucket, s.objectKey(name), resp.Status)
+}
@@ -0,0 +1,183 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("S3StateBackend", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		bodies   []string
+		objects  map[string]string
+		backend  *storage.S3StateBackend
+	)
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		bodies = []string{}
+		objects = map[string]string{}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requests = append(requests, r)
+			bodies = append(bodies, string(body))
+
+			if r.URL.Path == "/some-bucket" {
+				w.Header().Set("X-Amz-Bucket-Region", "some-region")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			switch r.Method {
+			case "HEAD":
+				w.WriteHeader(http.StatusNotFound)
+			case "GET":
+				contents, ok := objects[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("ETag", strconv.Quote(contents))
+				w.Write([]byte(contents))
+			case "PUT":
+				contents, ok := objects[r.URL.Path]
+				ifMatch := r.Header.Get("If-Match")
+				if (ifMatch != "" && ifMatch != strconv.Quote(contents)) || (r.Header.Get("If-None-Match") == "*" && ok) {
+					w.WriteHeader(http.StatusPreconditionFailed)
+					return
+				}
+				objects[r.URL.Path] = string(body)
+				w.Header().Set("ETag", strconv.Quote(string(body)))
+			case "DELETE":
+				delete(objects, r.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}))
+
+		storage.SetS3Endpoint(func(bucket, region string) string {
+			if region == "" {
+				return server.URL + "/" + bucket
+			}
+			return server.URL + "/" + region + "/" + bucket
+		})
+
+		backend = storage.NewS3StateBackend("some-bucket", "some-env", "some-access-key-id", "some-secret-access-key")
+	})
+
+	AfterEach(func() {
+		server.Close()
+		storage.ResetS3Endpoint()
+	})
+
+	It("stores files under the key in the bucket's region", func() {
+		err := backend.Set("bbl-state.json", []byte("some-state"))
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := backend.Get("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-state"))
+
+		By("looking up the region once", func() {
+			Expect(requests).To(HaveLen(3))
+			Expect(requests[0].Method).To(Equal("HEAD"))
+			Expect(requests[0].Header.Get("Authorization")).To(BeEmpty())
+		})
+
+		By("signing the requests for the region", func() {
+			put := requests[1]
+			Expect(put.URL.Path).To(Equal("/some-region/some-bucket/some-env/bbl-state.json"))
+			Expect(put.Header.Get("Authorization")).To(ContainSubstring("Credential=some-access-key-id/"))
+			Expect(put.Header.Get("Authorization")).To(ContainSubstring("/some-region/s3/aws4_request"))
+			Expect(put.Header.Get("X-Amz-Server-Side-Encryption")).To(Equal("AES256"))
+			Expect(bodies[1]).To(Equal("some-state"))
+		})
+	})
+
+	It("only replaces the copy it downloaded", func() {
+		objects["/some-region/some-bucket/some-env/bbl-state.json"] = "some-state"
+
+		_, err := backend.Get("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = backend.Set("bbl-state.json", []byte("some-new-state"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests[2].Header.Get("If-Match")).To(Equal(`"some-state"`))
+
+		err = backend.Set("bbl-state.json", []byte("some-newer-state"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests[3].Header.Get("If-Match")).To(Equal(`"some-new-state"`))
+	})
+
+	It("only creates a file it did not find if it is still missing", func() {
+		_, err := backend.Get("bbl-state.json")
+		Expect(err).To(Equal(storage.ErrRemoteStateNotFound))
+
+		err = backend.Set("bbl-state.json", []byte("some-state"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests[2].Header.Get("If-None-Match")).To(Equal("*"))
+	})
+
+	It("returns ErrRemoteStateNotFound for a missing file", func() {
+		_, err := backend.Get("bbl-state.json")
+		Expect(err).To(Equal(storage.ErrRemoteStateNotFound))
+	})
+
+	It("deletes files", func() {
+		objects["/some-region/some-bucket/some-env/bbl-state.json"] = "some-state"
+
+		err := backend.Delete("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(BeEmpty())
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the bucket does not exist", func() {
+			backend = storage.NewS3StateBackend("some-missing-bucket", "some-env", "some-access-key-id", "some-secret-access-key")
+
+			_, err := backend.Get("bbl-state.json")
+			Expect(err).To(MatchError(`state bucket "some-missing-bucket" does not exist`))
+		})
+
+		It("returns a state locked error when the file changed since it was downloaded", func() {
+			objects["/some-region/some-bucket/some-env/bbl-state.json"] = "some-state"
+
+			_, err := backend.Get("bbl-state.json")
+			Expect(err).NotTo(HaveOccurred())
+
+			objects["/some-region/some-bucket/some-env/bbl-state.json"] = "some-other-state"
+
+			err = backend.Set("bbl-state.json", []byte("some-new-state"))
+			Expect(err).To(Equal(storage.StateLockedError{Remote: true}))
+			Expect(objects["/some-region/some-bucket/some-env/bbl-state.json"]).To(Equal("some-other-state"))
+		})
+
+		It("returns a state locked error when a file it did not find was created since", func() {
+			_, err := backend.Get("bbl-state.json")
+			Expect(err).To(Equal(storage.ErrRemoteStateNotFound))
+
+			objects["/some-region/some-bucket/some-env/bbl-state.json"] = "some-other-state"
+
+			err = backend.Set("bbl-state.json", []byte("some-new-state"))
+			Expect(err).To(Equal(storage.StateLockedError{Remote: true}))
+		})
+
+		It("returns an error when s3 rejects the upload", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Amz-Bucket-Region", "some-region")
+				w.WriteHeader(http.StatusForbidden)
+			})
+
+			err := backend.Set("bbl-state.json", []byte("some-state"))
+			Expect(err).To(MatchError("failed to upload s3://some-bucket/some-env/bbl-state.json: 403 Forbidden"))
+		})
+	})
+})
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	SecretStoreInline        = "inline"
+	SecretStoreEncryptedFile = "encrypted-file"
+	SecretStoreVault         = "vault"
+	SecretStoreCredHub       = "credhub"
+
+	SecretStoreKeyEnv     = "BBL_SECRET_STORE_KEY"
+	SecretsFileName       = "bbl-secrets.enc"
+	secretReferencePrefix = "secret:"
+)
+
+// SecretStore is a backend that holds secret state fields so that
+// bbl-state.json only contains references to them.
+type SecretStore interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+}
+
+// newSecretStore returns the secret store of state, taking a credhub store
+// from credHubStores when it is not nil.
+var newSecretStore = func(state State, dir string, credHubStores *credHubSecretStores) (SecretStore, error) {
+	switch state.SecretStore {
+	case "", SecretStoreInline:
+		return nil, nil
+	case SecretStoreEncryptedFile:
+		return NewEncryptedFileSecretStore(filepath.Join(dir, SecretsFileName), os.Getenv(SecretStoreKeyEnv))
+	case SecretStoreVault:
+		return NewVaultSecretStore(os.Getenv(VaultAddrEnv), os.Getenv(VaultTokenEnv), os.Getenv(VaultNamespaceEnv), os.Getenv(VaultCACertEnv), os.Getenv(VaultSkipVerifyEnv), vaultPath(state))
+	case SecretStoreCredHub:
+		return credHubStores.get(os.Getenv(CredHubServerEnv), os.Getenv(CredHubClientEnv), os.Getenv(CredHubSecretEnv), os.Getenv(CredHubCACertEnv), credHubPath(state))
+	default:
+		return nil, fmt.Errorf("unknown secret store %q, valid options are %q, %q, %q and %q", state.SecretStore, SecretStoreInline, SecretStoreEncryptedFile, SecretStoreVault, SecretStoreCredHub)
+	}
+}
+
+// vaultPath defaults to a secret named after the environment, so that
+// environments sharing a vault do not overwrite each other's secrets.
+func vaultPath(state State) string {
+	if state.VaultPath != "" || state.EnvID == "" {
+		return state.VaultPath
+	}
+
+	return defaultVaultPathPrefix + state.EnvID
+}
+
+func credHubPath(state State) string {
+	if state.CredHubPath != "" || state.EnvID == "" {
+		return state.CredHubPath
+	}
+
+	return defaultCredHubPathPrefix + state.EnvID
+}
+
+type secretField struct {
+	name  string
+	value *string
+}
+
+func secretFields(state *State) []secretField {
+	return []secretField{
+		{"bosh.directorPassword", &state.BOSH.DirectorPassword},
+		{"bosh.directorSSLPrivateKey", &state.BOSH.DirectorSSLPrivateKey},
+		{"bosh.variables", &state.BOSH.Variables},
+		{"bosh.userCAPrivateKey", &state.BOSH.UserCAPrivateKey},
+		{"bosh.manifest", &state.BOSH.Manifest},
+		{"jumpbox.variables", &state.Jumpbox.Variables},
+		{"jumpbox.manifest", &state.Jumpbox.Manifest},
+		{"keyPair.privateKey", &state.KeyPair.PrivateKey},
+		{"directorDB.password", &state.DirectorDB.Password},
+		{"lb.key", &state.LB.Key},
+		{"lb.acme.accountKey", &state.LB.ACME.AccountKey},
+		{"lb.previous.key", &state.LB.Previous.Key},
+		{"aws.secretAccessKey", &state.AWS.SecretAccessKey},
+		{"azure.clientSecret", &state.Azure.ClientSecret},
+		{"gcp.serviceAccountKey", &state.GCP.ServiceAccountKey},
+		{"openStack.password", &state.OpenStack.Password},
+	}
+}
+
+// batchSecretStore is a SecretStore that writes the secrets of a state in a
+// single request, so that a concurrent write is not interleaved with them.
+type batchSecretStore interface {
+	SetAll(secrets map[string]string) error
+}
+
+func externalizeSecrets(state State, store SecretStore) (State, error) {
+	fields := []secretField{}
+	for _, field := range secretFields(&state) {
+		if *field.value != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	if batchStore, ok := store.(batchSecretStore); ok {
+		secrets := map[string]string{}
+		for _, field := range fields {
+			secrets[field.name] = *field.value
+		}
+
+		err := batchStore.SetAll(secrets)
+		if err != nil {
+			return State{}, fmt.Errorf("failed to store the secrets: %s", err)
+		}
+	} else {
+		for _, field := range fields {
+			err := store.Set(field.name, *field.value)
+			if err != nil {
+				return State{}, fmt.Errorf("failed to store secret %s: %s", field.name, err)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		*field.value = secretReferencePrefix + field.name
+	}
+
+	return state, nil
+}
+
+func resolveSecrets(state State, store SecretStore) (State, error) {
+	for _, field := range secretFields(&state) {
+		if !strings.HasPrefix(*field.value, secretReferencePrefix) {
+			continue
+		}
+
+		value, err := store.Get(strings.TrimPrefix(*field.value, secretReferencePrefix))
+		if err != nil {
+			return State{}, fmt.Errorf("failed to resolve secret %s: %s", field.name, err)
+		}
+		*field.value = value
+	}
+
+	return state, nil
+}
+
+type EncryptedFileSecretStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileSecretStore stores secrets in a file encrypted with
+// AES-256-GCM using the base64 encoded 32 byte key.
+func NewEncryptedFileSecretStore(path, key string) (EncryptedFileSecretStore, error) {
+	if key == "" {
+		return EncryptedFileSecretStore{}, fmt.Errorf("%s must be set to use the %s secret store", SecretStoreKeyEnv, SecretStoreEncryptedFile)
+	}
+
+	aead, err := newKeyCipher(key, SecretStoreKeyEnv)
+	if err != nil {
+		return EncryptedFileSecretStore{}, err
+	}
+
+	return EncryptedFileSecretStore{
+		path: path,
+		aead: aead,
+	}, nil
+}
+
+func (e EncryptedFileSecretStore) Get(name string) (string, error) {
+	secrets, err := e.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in %s", name, e.path)
+	}
+
+	return value, nil
+}
+
+func (e EncryptedFileSecretStore) Set(name, value string) error {
+	secrets, err := e.read()
+	if err != nil {
+		return err
+	}
+
+	secrets[name] = value
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, e.aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(e.path, e.aead.Seal(nonce, nonce, plaintext, nil), os.FileMode(0600))
+}
+
+func (e EncryptedFileSecretStore) read() (map[string]string, error) {
+	secrets := map[string]string{}
+
+	contents, err := ioutil.ReadFile(e.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets, nil
+		}
+		return nil, err
+	}
+
+	nonceSize := e.aead.NonceSize()
+	if len(contents) < nonceSize {
+		return nil, errors.New("secrets file is corrupt")
+	}
+
+	plaintext, err := e.aead.Open(nil, contents[:nonceSize], contents[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file: %s", err)
+	}
+
+	err = json.Unmarshal(plaintext, &secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// newKeyCipher returns an AES-256-GCM cipher for the base64 encoded 32 byte
+// key read from env.
+func newKeyCipher(key, env string) (cipher.AEAD, error) {
+	rawKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(rawKey) != 32 {
+		return nil, fmt.Errorf("%s must be a base64 encoded 32 byte key", env)
+	}
+
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
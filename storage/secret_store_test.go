@@ -0,0 +1,554 @@
+package storage_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SecretStore", func() {
+	var (
+		store   storage.Store
+		tempDir string
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		store = storage.NewStore(tempDir, 0, nil)
+
+		os.Setenv("BBL_SECRET_STORE_KEY", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+
+		state = storage.State{
+			IAAS:        "gcp",
+			EnvID:       "some-env-id",
+			SecretStore: "encrypted-file",
+			BOSH: storage.BOSH{
+				DirectorPassword:      "some-director-password",
+				DirectorSSLPrivateKey: "some-director-ssl-private-key",
+				DirectorSSLCA:         "some-director-ssl-ca",
+				Variables:             "admin_password: some-director-password",
+				Manifest:              "director_password: some-director-password",
+			},
+			Jumpbox: storage.Jumpbox{
+				Manifest: "jumpbox_ssh_private_key: some-jumpbox-private-key",
+			},
+			KeyPair: storage.KeyPair{
+				Name:       "some-keypair",
+				PrivateKey: "some-jumpbox-private-key",
+			},
+			GCP: storage.GCP{
+				ServiceAccountKey: "some-service-account-key",
+			},
+			LB: storage.LB{
+				Type: "cf",
+				Cert: "some-lb-cert",
+				Key:  "some-lb-key",
+				ACME: storage.ACME{
+					Email:      "some-email@example.com",
+					AccountKey: "some-acme-account-key",
+				},
+				Previous: storage.LBCertificate{
+					Cert: "some-previous-lb-cert",
+					Key:  "some-previous-lb-key",
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("BBL_SECRET_STORE_KEY")
+	})
+
+	Context("when the encrypted-file secret store is configured", func() {
+		It("stores references in the state file and resolves them when read", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			stateFile, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-director-password"))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-director-ssl-private-key"))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-jumpbox-private-key"))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-service-account-key"))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-lb-key"))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-acme-account-key"))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-previous-lb-key"))
+			Expect(string(stateFile)).To(ContainSubstring(`"directorPassword": "secret:bosh.directorPassword"`))
+			Expect(string(stateFile)).To(ContainSubstring(`"manifest": "secret:bosh.manifest"`))
+			Expect(string(stateFile)).To(ContainSubstring(`"directorSSLCA": "some-director-ssl-ca"`))
+			Expect(string(stateFile)).To(ContainSubstring(`"cert": "some-lb-cert"`))
+
+			secretsFile, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-secrets.enc"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(secretsFile)).NotTo(ContainSubstring("some-director-password"))
+
+			loadedState, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			state.Version = 8
+			Expect(loadedState).To(Equal(state))
+		})
+
+		It("removes the secrets file when the state is emptied", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = store.Set(storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(filepath.Join(tempDir, "bbl-secrets.enc"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the key is not set", func() {
+				os.Unsetenv("BBL_SECRET_STORE_KEY")
+
+				err := store.Set(state)
+				Expect(err).To(MatchError("BBL_SECRET_STORE_KEY must be set to use the encrypted-file secret store"))
+			})
+
+			It("returns an error when the key is not 32 bytes", func() {
+				os.Setenv("BBL_SECRET_STORE_KEY", base64.StdEncoding.EncodeToString([]byte("too-short")))
+
+				err := store.Set(state)
+				Expect(err).To(MatchError("BBL_SECRET_STORE_KEY must be a base64 encoded 32 byte key"))
+			})
+
+			It("returns an error when the secrets were encrypted with another key", func() {
+				err := store.Set(state)
+				Expect(err).NotTo(HaveOccurred())
+
+				os.Setenv("BBL_SECRET_STORE_KEY", base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210")))
+
+				_, err = storage.GetState(tempDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to resolve secret bosh.directorPassword: failed to decrypt secrets file")))
+			})
+		})
+	})
+
+	Context("when the inline secret store is configured", func() {
+		It("stores secrets in the state file", func() {
+			state.SecretStore = "inline"
+
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			stateFile, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(stateFile)).To(ContainSubstring(`"directorPassword": "some-director-password"`))
+
+			_, err = os.Stat(filepath.Join(tempDir, "bbl-secrets.enc"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Context("when the vault secret store is configured", func() {
+		var (
+			server   *httptest.Server
+			handler  http.HandlerFunc
+			secrets  map[string]map[string]string
+			versions map[string]int
+			requests []string
+		)
+
+		BeforeEach(func() {
+			secrets = map[string]map[string]string{}
+			versions = map[string]int{}
+			requests = []string{}
+
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+
+				if r.Header.Get("X-Vault-Token") != "some-token" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				switch r.Method {
+				case "GET":
+					data, ok := secrets[r.URL.Path]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+						"data":     data,
+						"metadata": map[string]interface{}{"version": versions[r.URL.Path]},
+					}})
+				case "POST":
+					var body struct {
+						Options struct {
+							CAS *int `json:"cas"`
+						} `json:"options"`
+						Data map[string]string `json:"data"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					if body.Options.CAS == nil || *body.Options.CAS != versions[r.URL.Path] {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					secrets[r.URL.Path] = body.Data
+					versions[r.URL.Path]++
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": versions[r.URL.Path]}})
+				}
+			})
+			server = httptest.NewServer(handler)
+
+			os.Setenv("VAULT_ADDR", server.URL)
+			os.Setenv("VAULT_TOKEN", "some-token")
+
+			state.SecretStore = "vault"
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.Unsetenv("VAULT_ADDR")
+			os.Unsetenv("VAULT_TOKEN")
+		})
+
+		It("stores the secrets in a secret named after the environment and resolves them when read", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			stateFile, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-director-password"))
+			Expect(string(stateFile)).To(ContainSubstring(`"directorPassword": "secret:bosh.directorPassword"`))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-jumpbox-private-key"))
+
+			Expect(secrets).To(HaveKey("/v1/secret/data/bbl/some-env-id"))
+			Expect(secrets["/v1/secret/data/bbl/some-env-id"]).To(HaveKeyWithValue("bosh.directorPassword", "some-director-password"))
+
+			loadedState, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			state.Version = 8
+			Expect(loadedState).To(Equal(state))
+		})
+
+		It("uses the configured vault path", func() {
+			state.VaultPath = "kv/some/path"
+
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(secrets).To(HaveKey("/v1/kv/data/some/path"))
+		})
+
+		It("does not write the secrets again when they have not changed", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			requests = []string{}
+			err = store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(Equal([]string{"GET /v1/secret/data/bbl/some-env-id"}))
+		})
+
+		It("writes the secrets in a single request checked against the version it read", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"GET /v1/secret/data/bbl/some-env-id", "POST /v1/secret/data/bbl/some-env-id"}))
+
+			requests = []string{}
+			state.BOSH.DirectorPassword = "some-other-director-password"
+			state.KeyPair.PrivateKey = "some-other-private-key"
+
+			err = store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"GET /v1/secret/data/bbl/some-env-id", "POST /v1/secret/data/bbl/some-env-id"}))
+			Expect(versions["/v1/secret/data/bbl/some-env-id"]).To(Equal(2))
+			Expect(secrets["/v1/secret/data/bbl/some-env-id"]).To(HaveKeyWithValue("bosh.directorPassword", "some-other-director-password"))
+			Expect(secrets["/v1/secret/data/bbl/some-env-id"]).To(HaveKeyWithValue("keyPair.privateKey", "some-other-private-key"))
+		})
+
+		Context("when vault is served over tls", func() {
+			BeforeEach(func() {
+				server.Close()
+				server = httptest.NewTLSServer(handler)
+				os.Setenv("VAULT_ADDR", server.URL)
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("VAULT_CACERT")
+				os.Unsetenv("VAULT_SKIP_VERIFY")
+			})
+
+			It("trusts the certificate in VAULT_CACERT", func() {
+				caCert := filepath.Join(tempDir, "vault-ca.crt")
+				err := ioutil.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+				os.Setenv("VAULT_CACERT", caCert)
+
+				err = store.Set(state)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(secrets).To(HaveKey("/v1/secret/data/bbl/some-env-id"))
+			})
+
+			It("skips the verification of the certificate with VAULT_SKIP_VERIFY", func() {
+				os.Setenv("VAULT_SKIP_VERIFY", "true")
+
+				err := store.Set(state)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(secrets).To(HaveKey("/v1/secret/data/bbl/some-env-id"))
+			})
+
+			It("returns an error when the certificate is not trusted", func() {
+				err := store.Set(state)
+				Expect(err).To(MatchError(ContainSubstring("failed to reach vault at " + server.URL)))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when vault is not configured", func() {
+				os.Unsetenv("VAULT_TOKEN")
+
+				err := store.Set(state)
+				Expect(err).To(MatchError("VAULT_ADDR and VAULT_TOKEN must be set to use the vault secret store"))
+			})
+
+			It("returns an error when the path has no mount", func() {
+				state.VaultPath = "some-path"
+
+				err := store.Set(state)
+				Expect(err).To(MatchError(`vault path "some-path" must start with the mount of a kv secrets engine, such as secret/bbl/some-env`))
+			})
+
+			It("returns an error when vault denies the request", func() {
+				os.Setenv("VAULT_TOKEN", "some-other-token")
+
+				err := store.Set(state)
+				Expect(err).To(MatchError("failed to store the secrets: failed to read the secrets from vault at secret/bbl/some-env-id: 403 Forbidden"))
+			})
+
+			It("returns an error when the secrets were changed since they were read", func() {
+				secrets["/v1/secret/data/bbl/some-env-id"] = map[string]string{"bosh.directorPassword": "some-director-password"}
+				handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Method == "GET" {
+						json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+							"data":     secrets[r.URL.Path],
+							"metadata": map[string]interface{}{"version": 1},
+						}})
+						return
+					}
+					w.WriteHeader(http.StatusBadRequest)
+				})
+				server.Config.Handler = handler
+
+				err := store.Set(state)
+				Expect(err).To(MatchError("failed to store the secrets: failed to write the secrets to vault at secret/bbl/some-env-id, they may have been changed since bbl read them: 400 Bad Request"))
+			})
+
+			It("returns an error when VAULT_SKIP_VERIFY is not a boolean", func() {
+				os.Setenv("VAULT_SKIP_VERIFY", "some-value")
+				defer os.Unsetenv("VAULT_SKIP_VERIFY")
+
+				err := store.Set(state)
+				Expect(err).To(MatchError("VAULT_SKIP_VERIFY must be true or false"))
+			})
+
+			It("returns an error when a secret is missing from vault", func() {
+				err := store.Set(state)
+				Expect(err).NotTo(HaveOccurred())
+
+				secrets = map[string]map[string]string{}
+
+				_, err = storage.GetState(tempDir)
+				Expect(err).To(MatchError(`failed to resolve secret bosh.directorPassword: secret "bosh.directorPassword" not found in vault at secret/bbl/some-env-id`))
+			})
+		})
+	})
+
+	Context("when the credhub secret store is configured", func() {
+		var (
+			server      *httptest.Server
+			credentials map[string]map[string]interface{}
+			writes      []string
+		)
+
+		BeforeEach(func() {
+			credentials = map[string]map[string]interface{}{}
+			writes = []string{}
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/info":
+					json.NewEncoder(w).Encode(map[string]interface{}{"auth-server": map[string]string{"url": "http://" + r.Host}})
+					return
+				case r.URL.Path == "/oauth/token":
+					client, secret, _ := r.BasicAuth()
+					if client != "some-client" || secret != "some-secret" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]string{"access_token": "some-token"})
+					return
+				}
+
+				if r.Header.Get("Authorization") != "bearer some-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				name := r.URL.Query().Get("name")
+				switch {
+				case r.Method == "GET" && r.URL.Query().Get("path") != "":
+					found := []map[string]string{}
+					for credentialName := range credentials {
+						if strings.HasPrefix(credentialName, r.URL.Query().Get("path")+"/") {
+							found = append(found, map[string]string{"name": credentialName})
+						}
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"credentials": found})
+				case r.Method == "GET":
+					credential, ok := credentials[name]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{credential}})
+				case r.Method == "PUT":
+					var credential map[string]interface{}
+					json.NewDecoder(r.Body).Decode(&credential)
+					if credential["type"] == "ssh" {
+						credential["value"].(map[string]interface{})["public_key_fingerprint"] = "some-fingerprint"
+					}
+					credentials[credential["name"].(string)] = credential
+					writes = append(writes, "PUT "+credential["name"].(string))
+				case r.Method == "DELETE":
+					delete(credentials, name)
+					writes = append(writes, "DELETE "+name)
+				}
+			}))
+
+			os.Setenv("CREDHUB_SERVER", server.URL)
+			os.Setenv("CREDHUB_CLIENT", "some-client")
+			os.Setenv("CREDHUB_SECRET", "some-secret")
+			storage.ResetCredHubSecretStores(store)
+
+			state.SecretStore = "credhub"
+			state.KeyPair = storage.KeyPair{}
+			state.BOSH.Variables = `admin_password: some-admin-password
+director_ssl:
+  ca: some-ca
+  certificate: some-certificate
+  private_key: some-certificate-key
+jumpbox_ssh:
+  private_key: some-ssh-private-key
+  public_key: some-ssh-public-key
+  public_key_fingerprint: some-fingerprint
+`
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.Unsetenv("CREDHUB_SERVER")
+			os.Unsetenv("CREDHUB_CLIENT")
+			os.Unsetenv("CREDHUB_SECRET")
+			storage.ResetCredHubSecretStores(store)
+		})
+
+		It("stores each create-env variable as a credential and resolves them when read", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			stateFile, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-admin-password"))
+			Expect(string(stateFile)).NotTo(ContainSubstring("some-certificate-key"))
+			Expect(string(stateFile)).To(ContainSubstring(`"variables": "secret:bosh.variables"`))
+
+			Expect(credentials["/bbl/some-env-id/bosh/variables/admin_password"]).To(HaveKeyWithValue("type", "password"))
+			Expect(credentials["/bbl/some-env-id/bosh/variables/director_ssl"]).To(HaveKeyWithValue("type", "certificate"))
+			Expect(credentials["/bbl/some-env-id/bosh/variables/jumpbox_ssh"]).To(HaveKeyWithValue("type", "ssh"))
+			Expect(credentials["/bbl/some-env-id/bosh/directorPassword"]).To(HaveKeyWithValue("value", "some-director-password"))
+
+			storage.ResetCredHubSecretStores(store)
+			loadedState, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(loadedState.BOSH.DirectorPassword).To(Equal("some-director-password"))
+			Expect(loadedState.BOSH.Variables).To(MatchYAML(state.BOSH.Variables))
+		})
+
+		It("only writes the credentials that changed", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			storage.ResetCredHubSecretStores(store)
+			loadedState, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			writes = []string{}
+			loadedState.BOSH.Variables = strings.Replace(loadedState.BOSH.Variables, "some-admin-password", "some-new-admin-password", 1)
+			err = store.Set(loadedState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(writes).To(Equal([]string{"PUT /bbl/some-env-id/bosh/variables/admin_password"}))
+		})
+
+		It("deletes the variables that were dropped from the vars-store", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			writes = []string{}
+			state.BOSH.Variables = "admin_password: some-admin-password\n"
+			err = store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(writes).To(ConsistOf(
+				"DELETE /bbl/some-env-id/bosh/variables/director_ssl",
+				"DELETE /bbl/some-env-id/bosh/variables/jumpbox_ssh",
+			))
+		})
+
+		It("uses the configured credhub path", func() {
+			state.CredHubPath = "/some/path"
+
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(credentials).To(HaveKey("/some/path/bosh/variables/admin_password"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when credhub is not configured", func() {
+				os.Unsetenv("CREDHUB_SECRET")
+
+				err := store.Set(state)
+				Expect(err).To(MatchError("CREDHUB_SERVER, CREDHUB_CLIENT and CREDHUB_SECRET must be set to use the credhub secret store"))
+			})
+
+			It("returns an error when the client cannot authenticate", func() {
+				os.Setenv("CREDHUB_SECRET", "some-other-secret")
+
+				err := store.Set(state)
+				Expect(err).To(MatchError(ContainSubstring("failed to authenticate some-client with the UAA of credhub: 401 Unauthorized")))
+			})
+		})
+	})
+
+	It("returns an error for an unknown secret store", func() {
+		state.SecretStore = "some-secret-store"
+
+		err := store.Set(state)
+		Expect(err).To(MatchError(`unknown secret store "some-secret-store", valid options are "inline", "encrypted-file", "vault" and "credhub"`))
+	})
+})
@@ -19,6 +19,9 @@ const (
 
 	OS_READ_WRITE_MODE = os.FileMode(0644)
 	StateFileName      = "bbl-state.json"
+
+	DefaultNetworkCIDR = "10.0.0.0/16"
+	DefaultSubnetCIDR  = "10.0.0.0/24"
 )
 
 type logger interface {
@@ -29,6 +32,26 @@ type AWS struct {
 	AccessKeyID     string `json:"accessKeyId"`
 	SecretAccessKey string `json:"secretAccessKey"`
 	Region          string `json:"region"`
+	Profile         string `json:"profile,omitempty"`
+
+	AssumeRoleARN        string `json:"assumeRoleArn,omitempty"`
+	AssumeRoleExternalID string `json:"assumeRoleExternalId,omitempty"`
+	MFASerial            string `json:"mfaSerial,omitempty"`
+	SessionToken         string `json:"-"`
+
+	ExistingVPCID     string   `json:"existingVpcId,omitempty"`
+	ExistingSubnetIDs []string `json:"existingSubnetIds,omitempty"`
+
+	// AZs limits the environment to these availability zones of the region.
+	AZs []string `json:"azs,omitempty"`
+
+	// NAT is "gateway" when the internal subnets reach the internet through
+	// an AWS managed NAT gateway in every zone instead of the NAT instance.
+	NAT string `json:"nat,omitempty"`
+
+	// SpotBidPrice adds a spot vm_extension to the cloud config.
+	SpotBidPrice         string `json:"spotBidPrice,omitempty"`
+	SpotOnDemandFallback bool   `json:"spotOnDemandFallback,omitempty"`
 }
 
 type Azure struct {
@@ -36,14 +59,52 @@ type Azure struct {
 	TenantID       string `json:"tenantId"`
 	ClientID       string `json:"clientId"`
 	ClientSecret   string `json:"clientSecret"`
+	AuthMethod     string `json:"authMethod,omitempty"`
+	Region         string `json:"region"`
 }
 
 type GCP struct {
-	ServiceAccountKey string   `json:"serviceAccountKey"`
-	ProjectID         string   `json:"projectID"`
-	Zone              string   `json:"zone"`
-	Region            string   `json:"region"`
-	Zones             []string `json:"zones"`
+	ServiceAccountKey         string            `json:"serviceAccountKey"`
+	ProjectID                 string            `json:"projectID"`
+	Zone                      string            `json:"zone"`
+	Region                    string            `json:"region"`
+	Zones                     []string          `json:"zones"`
+	SelectedZones             []string          `json:"selectedZones,omitempty"`
+	FirewallRules             []GCPFirewallRule `json:"firewallRules,omitempty"`
+	ImpersonateServiceAccount string            `json:"impersonateServiceAccount,omitempty"`
+	ExistingNetworkName       string            `json:"existingNetworkName,omitempty"`
+	ExistingSubnetworkName    string            `json:"existingSubnetworkName,omitempty"`
+	NoPublicIPs               bool              `json:"noPublicIPs,omitempty"`
+}
+
+type OpenStack struct {
+	AuthURL         string `json:"authURL"`
+	AZ              string `json:"az"`
+	ExternalNetwork string `json:"externalNetwork"`
+	Region          string `json:"region"`
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+	Domain          string `json:"domain"`
+	Project         string `json:"project"`
+}
+
+type GCPFirewallRule struct {
+	Name        string   `json:"name"`
+	Protocol    string   `json:"protocol"`
+	Ports       []string `json:"ports,omitempty"`
+	SourceRange string   `json:"sourceRange"`
+}
+
+// Tag is a tag of the aws resources, or a label of the gcp resources, of the
+// environment.
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type Network struct {
+	CIDR       string `json:"cidr,omitempty"`
+	SubnetCIDR string `json:"subnetCIDR,omitempty"`
 }
 
 type Stack struct {
@@ -59,6 +120,37 @@ type LB struct {
 	Key    string `json:"key"`
 	Chain  string `json:"chain"`
 	Domain string `json:"domain,omitempty"`
+	IPv6   bool   `json:"ipv6,omitempty"`
+	Flavor string `json:"flavor,omitempty"`
+	ACME   ACME   `json:"acme,omitempty"`
+
+	// CertSlot is "alt" when the certificate is held by the alternate
+	// certificate resource. Rotations swap slots so that the new certificate
+	// is created next to the previous one instead of replacing it.
+	CertSlot string        `json:"certSlot,omitempty"`
+	Previous LBCertificate `json:"previous,omitempty"`
+}
+
+// LBCertificate is a certificate that was rotated out and is kept attached
+// to the environment until it is cleaned up.
+type LBCertificate struct {
+	Cert  string `json:"cert,omitempty"`
+	Key   string `json:"key,omitempty"`
+	Chain string `json:"chain,omitempty"`
+}
+
+// ACME holds what is needed to renew a certificate that was issued through
+// ACME DNS-01 challenges, and the TXT records of a challenge in progress.
+type ACME struct {
+	Email      string          `json:"email,omitempty"`
+	AccountKey string          `json:"accountKey,omitempty"`
+	AccountURL string          `json:"accountURL,omitempty"`
+	Challenges []ACMEChallenge `json:"challenges,omitempty"`
+}
+
+type ACMEChallenge struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 type Jumpbox struct {
@@ -67,35 +159,114 @@ type Jumpbox struct {
 	Variables string                 `json:"variables"`
 	Manifest  string                 `json:"manifest"`
 	State     map[string]interface{} `json:"state"`
+
+	// ProxyPort is the port of the socks5 proxy to the jumpbox, when it is
+	// not a free port picked by each run.
+	ProxyPort int `json:"proxyPort,omitempty"`
+
+	// DeploymentPath and DeploymentVersion pin the jumpbox-deployment the
+	// jumpbox is interpolated from, like BOSH.DeploymentPath and
+	// BOSH.DeploymentVersion.
+	DeploymentPath    string `json:"deploymentPath,omitempty"`
+	DeploymentVersion string `json:"deploymentVersion,omitempty"`
+}
+
+// DirectorDB is the database bbl provisions for the director when it is
+// deployed with --director-external-db.
+type DirectorDB struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// UpProgress tracks a bbl up that continues in the background after
+// --detach: the pid of that bbl, the last phase it completed, the log it
+// writes to, and whether it failed.
+type UpProgress struct {
+	PID     int    `json:"pid,omitempty"`
+	Phase   string `json:"phase,omitempty"`
+	LogFile string `json:"logFile,omitempty"`
+	Failed  bool   `json:"failed,omitempty"`
+}
+
+// UpCheckpoint records that a phase of bbl up completed, with the checksum of
+// the state it converged to, so that a re-run after a failure can skip it.
+type UpCheckpoint struct {
+	Phase    string `json:"phase"`
+	Checksum string `json:"checksum"`
+}
+
+type Stemcell struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+type LatestError struct {
+	Message string `json:"message,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+// TFOutputs caches the outputs of the terraform state, so that commands which
+// only read them do not run terraform. Checksum is the sha256 of the TFState
+// they were read from.
+type TFOutputs struct {
+	Checksum string                 `json:"checksum,omitempty"`
+	Outputs  map[string]interface{} `json:"outputs,omitempty"`
 }
 
 type State struct {
-	Version                    int     `json:"version"`
-	IAAS                       string  `json:"iaas"`
-	NoDirector                 bool    `json:"noDirector"`
-	MigratedFromCloudFormation bool    `json:"migratedFromCloudFormation"`
-	AWS                        AWS     `json:"aws,omitempty"`
-	Azure                      Azure   `json:"azure,omitempty"`
-	GCP                        GCP     `json:"gcp,omitempty"`
-	KeyPair                    KeyPair `json:"keyPair,omitempty"`
-	Jumpbox                    Jumpbox `json:"jumpbox,omitempty"`
-	BOSH                       BOSH    `json:"bosh,omitempty"`
-	Stack                      Stack   `json:"stack"`
-	EnvID                      string  `json:"envID"`
-	TFState                    string  `json:"tfState"`
-	LB                         LB      `json:"lb"`
-	LatestTFOutput             string  `json:"latestTFOutput"`
+	Version                    int            `json:"version"`
+	IAAS                       string         `json:"iaas"`
+	NoDirector                 bool           `json:"noDirector"`
+	MigratedFromCloudFormation bool           `json:"migratedFromCloudFormation"`
+	AWS                        AWS            `json:"aws,omitempty"`
+	Azure                      Azure          `json:"azure,omitempty"`
+	GCP                        GCP            `json:"gcp,omitempty"`
+	OpenStack                  OpenStack      `json:"openstack,omitempty"`
+	KeyPair                    KeyPair        `json:"keyPair,omitempty"`
+	Jumpbox                    Jumpbox        `json:"jumpbox,omitempty"`
+	BOSH                       BOSH           `json:"bosh,omitempty"`
+	DirectorDB                 DirectorDB     `json:"directorDB,omitempty"`
+	Stack                      Stack          `json:"stack"`
+	EnvID                      string         `json:"envID"`
+	TFState                    string         `json:"tfState"`
+	TFLastApplied              string         `json:"tfLastApplied,omitempty"`
+	TFOutputs                  TFOutputs      `json:"tfOutputs,omitempty"`
+	LB                         LB             `json:"lb"`
+	Network                    Network        `json:"network,omitempty"`
+	LatestTFOutput             string         `json:"latestTFOutput"`
+	LatestError                LatestError    `json:"latestError,omitempty"`
+	UpProgress                 UpProgress     `json:"upProgress,omitempty"`
+	UpCheckpoints              []UpCheckpoint `json:"upCheckpoints,omitempty"`
+	SecretStore                string         `json:"secretStore,omitempty"`
+	VaultPath                  string         `json:"vaultPath,omitempty"`
+	CredHubPath                string         `json:"credhubPath,omitempty"`
+	StateBucket                string         `json:"stateBucket,omitempty"`
+	StateKey                   string         `json:"stateKey,omitempty"`
+	Stemcell                   Stemcell       `json:"stemcell,omitempty"`
+	SSHPort                    int            `json:"sshPort,omitempty"`
+	DirectorSSH                bool           `json:"directorSSH,omitempty"`
+	Tags                       []Tag          `json:"tags,omitempty"`
 }
 
 type Store struct {
-	version   int
-	stateFile string
+	version       int
+	stateFile     string
+	backups       int
+	backend       StateBackend
+	credHubStores *credHubSecretStores
 }
 
-func NewStore(dir string) Store {
+// NewStore returns a store for the state file in dir that keeps up to
+// backups previous copies of the state whenever the terraform state changes.
+// Every saved state is also uploaded to backend when it is not nil.
+func NewStore(dir string, backups int, backend StateBackend) Store {
 	return Store{
-		version:   STATE_VERSION,
-		stateFile: filepath.Join(dir, StateFileName),
+		version:       STATE_VERSION,
+		stateFile:     filepath.Join(dir, StateFileName),
+		backups:       backups,
+		backend:       backend,
+		credHubStores: newCredHubSecretStores(),
 	}
 }
 
@@ -106,28 +277,84 @@ func (s Store) Set(state State) error {
 	}
 
 	if reflect.DeepEqual(state, State{}) {
-		err := os.Remove(s.stateFile)
-		if err != nil && !os.IsNotExist(err) {
-			return err
+		for _, file := range []string{s.stateFile, filepath.Join(filepath.Dir(s.stateFile), SecretsFileName)} {
+			err := os.Remove(file)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		if s.backend != nil {
+			return deleteRemoteState(s.backend)
 		}
 
 		return nil
 	}
 
-	state.Version = s.version
+	// A state read from an earlier schema keeps its version until bbl
+	// migrate-state upgrades it.
+	if state.Version == 0 {
+		state.Version = s.version
+	}
+
+	if state.AWS.Profile != "" || state.AWS.AssumeRoleARN != "" {
+		// Credentials read from a shared credentials file profile, and the
+		// temporary credentials of an assumed role, are resolved again on
+		// every run and never written to the state.
+		state.AWS.AccessKeyID = ""
+		state.AWS.SecretAccessKey = ""
+	}
+
+	secretStore, err := newSecretStore(state, filepath.Dir(s.stateFile), s.credHubStores)
+	if err != nil {
+		return err
+	}
+
+	// The backup is taken before the secrets are stored, so that it pairs
+	// the previous state file with the secrets file it refers to.
+	err = s.backup(state)
+	if err != nil {
+		return err
+	}
+
+	if secretStore != nil {
+		state, err = externalizeSecrets(state, secretStore)
+		if err != nil {
+			return err
+		}
+	}
 
 	jsonData, err := marshalIndent(state, "", "\t")
 	if err != nil {
 		return err
 	}
+
 	err = ioutil.WriteFile(s.stateFile, jsonData, os.FileMode(0644))
 	if err != nil {
 		return err
 	}
 
+	if s.backend != nil {
+		return pushState(s.backend, filepath.Dir(s.stateFile))
+	}
+
 	return nil
 }
 
+func (n Network) NetworkCIDR() string {
+	if n.CIDR == "" {
+		return DefaultNetworkCIDR
+	}
+	return n.CIDR
+}
+
+func (n Network) DirectorSubnetCIDR() string {
+	if n.SubnetCIDR == "" {
+		return DefaultSubnetCIDR
+	}
+	return n.SubnetCIDR
+}
+
 func (g GCP) Empty() bool {
 	return g.ServiceAccountKey == "" && g.ProjectID == "" && g.Region == "" && g.Zone == ""
 }
@@ -170,6 +397,15 @@ func GetState(dir string) (State, error) {
 		return state, fmt.Errorf("Existing bbl environment was created with a newer version of bbl. Please upgrade to a version of bbl compatible with schema version %d.\n", state.Version)
 	}
 
+	secretStore, err := newSecretStore(state, dir, nil)
+	if err != nil {
+		return state, err
+	}
+
+	if secretStore != nil {
+		return resolveSecrets(state, secretStore)
+	}
+
 	return state, nil
 }
 
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrRemoteStateNotFound is returned by a StateBackend when it holds no copy
+// of the requested file.
+var ErrRemoteStateNotFound = errors.New("file not found in the remote state backend")
+
+// StateBackend keeps a copy of the state files outside of the state
+// directory so that several people can work on the same environment. The
+// state directory stays the working copy, bbl reads and writes it as usual
+// and the backend is synced around it.
+type StateBackend interface {
+	Get(name string) ([]byte, error)
+	Set(name string, contents []byte) error
+	Delete(name string) error
+}
+
+// remoteStateFiles are the files kept in a StateBackend. The terraform state
+// lives inside bbl-state.json, backups stay local.
+var remoteStateFiles = []string{StateFileName, SecretsFileName}
+
+// PullState copies the files held by the backend into dir, replacing the
+// local copies. Files missing from the backend are left alone so that an
+// existing local environment is uploaded the first time the state is saved.
+func PullState(backend StateBackend, dir string) error {
+	_, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range remoteStateFiles {
+		contents, err := backend.Get(name)
+		if err == ErrRemoteStateNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(filepath.Join(dir, name), contents, os.FileMode(0600))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pushState(backend StateBackend, dir string) error {
+	for _, name := range remoteStateFiles {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = backend.Set(name, contents)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteRemoteState(backend StateBackend) error {
+	for _, name := range remoteStateFiles {
+		err := backend.Delete(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package storage_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateBackend", func() {
+	var (
+		backend *fakes.StateBackend
+		tempDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		backend = &fakes.StateBackend{}
+	})
+
+	Describe("PullState", func() {
+		It("replaces the local state files with the remote ones", func() {
+			err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte("local-state"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			backend.GetCall.Stub = func(name string) ([]byte, error) {
+				return []byte("remote-" + name), nil
+			}
+
+			err = storage.PullState(backend, tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("remote-bbl-state.json"))
+
+			contents, err = ioutil.ReadFile(filepath.Join(tempDir, "bbl-secrets.enc"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("remote-bbl-secrets.enc"))
+		})
+
+		It("keeps the local state when the backend does not have one", func() {
+			err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte("local-state"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			backend.GetCall.Returns.Error = storage.ErrRemoteStateNotFound
+
+			err = storage.PullState(backend, tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("local-state"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state dir does not exist", func() {
+				err := storage.PullState(backend, "/some/missing/dir")
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+
+			It("returns an error when the backend fails", func() {
+				backend.GetCall.Returns.Error = errors.New("access denied")
+
+				err := storage.PullState(backend, tempDir)
+				Expect(err).To(MatchError("access denied"))
+			})
+		})
+	})
+
+	Describe("Store", func() {
+		var store storage.Store
+
+		BeforeEach(func() {
+			store = storage.NewStore(tempDir, 0, backend)
+		})
+
+		It("uploads the state every time it is saved", func() {
+			err := store.Set(storage.State{IAAS: "gcp", EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			localState, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(backend.SetCall.CallCount).To(Equal(1))
+			Expect(backend.SetCall.Receives[0].Name).To(Equal("bbl-state.json"))
+			Expect(backend.SetCall.Receives[0].Contents).To(Equal(localState))
+		})
+
+		It("deletes the remote state when the state is emptied", func() {
+			err := store.Set(storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(backend.SetCall.CallCount).To(Equal(0))
+			Expect(backend.DeleteCall.Receives).To(Equal([]string{"bbl-state.json", "bbl-secrets.enc"}))
+		})
+
+		It("returns an error when the state fails to upload", func() {
+			backend.SetCall.Returns.Error = errors.New("access denied")
+
+			err := store.Set(storage.State{IAAS: "gcp"})
+			Expect(err).To(MatchError("access denied"))
+		})
+	})
+})
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const LockFileName = "bbl-state.lock"
+
+const lockPollInterval = 100 * time.Millisecond
+
+// StateLockedError is returned when another bbl process is changing the
+// state dir, or changed the copy of the state in the state bucket.
+type StateLockedError struct {
+	PID    int
+	Remote bool
+}
+
+func (e StateLockedError) Error() string {
+	if e.Remote {
+		return "the state in the state bucket was changed by another bbl process since it was downloaded, the state of this run is only in the local state dir and the next command replaces it with the copy in the bucket"
+	}
+	return fmt.Sprintf("the state dir is locked by another bbl process (pid %d)", e.PID)
+}
+
+// StateLocker keeps two bbl processes from changing the same state dir at the
+// same time. The lock is taken before the state is loaded, so that the
+// command works with the state the previous one left behind.
+type StateLocker struct{}
+
+func NewStateLocker() StateLocker {
+	return StateLocker{}
+}
+
+// Lock creates the lock file in the state dir and returns a function that
+// removes it again. A lock left behind by a bbl process that is no longer
+// running is taken over. The lock of the bbl that started this one with up
+// --detach is waited for, that bbl releases it once it recorded the pid of
+// this one in the state.
+func (l StateLocker) Lock(dir string) (func(), error) {
+	lockFile := filepath.Join(dir, LockFileName)
+
+	err := createLockFile(lockFile)
+	for os.IsExist(err) && lockedByParent(lockFile) {
+		time.Sleep(lockPollInterval)
+		err = createLockFile(lockFile)
+	}
+
+	if os.IsExist(err) {
+		pid, readErr := lockOwner(lockFile)
+		switch {
+		case readErr == nil && processRunning(pid):
+			return nil, StateLockedError{PID: pid}
+		case readErr != nil && !os.IsNotExist(readErr):
+			return nil, readErr
+		}
+
+		err = os.Remove(lockFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		err = createLockFile(lockFile)
+		if os.IsExist(err) {
+			pid, _ = lockOwner(lockFile)
+			return nil, StateLockedError{PID: pid}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := func() {
+		if pid, err := lockOwner(lockFile); err == nil && pid == os.Getpid() {
+			os.Remove(lockFile)
+		}
+	}
+
+	return unlock, nil
+}
+
+// ProcessChecker reports whether a bbl process, like the one an up --detach
+// continues in, is still running.
+type ProcessChecker struct{}
+
+func NewProcessChecker() ProcessChecker {
+	return ProcessChecker{}
+}
+
+func (ProcessChecker) Running(pid int) bool {
+	return processRunning(pid)
+}
+
+func createLockFile(lockFile string) error {
+	file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0644))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(strconv.Itoa(os.Getpid()))
+	return err
+}
+
+func lockedByParent(lockFile string) bool {
+	pid, err := lockOwner(lockFile)
+	return err == nil && pid == os.Getppid() && processRunning(pid)
+}
+
+func lockOwner(lockFile string) (int, error) {
+	contents, err := ioutil.ReadFile(lockFile)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid state dir lock %s: %s", lockFile, err)
+	}
+
+	return pid, nil
+}
@@ -0,0 +1,131 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateLocker", func() {
+	var (
+		locker   storage.StateLocker
+		tempDir  string
+		lockFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		lockFile = filepath.Join(tempDir, "bbl-state.lock")
+
+		locker = storage.NewStateLocker()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	Describe("Lock", func() {
+		It("writes the pid to the lock file and removes it on unlock", func() {
+			unlock, err := locker.Lock(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(lockFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(strconv.Itoa(os.Getpid())))
+
+			unlock()
+			Expect(lockFile).NotTo(BeAnExistingFile())
+		})
+
+		It("returns a state locked error while another bbl holds the lock", func() {
+			unlock, err := locker.Lock(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			defer unlock()
+
+			_, err = locker.Lock(tempDir)
+			Expect(err).To(Equal(storage.StateLockedError{PID: os.Getpid()}))
+			Expect(err).To(MatchError(ContainSubstring("locked by another bbl process")))
+		})
+
+		It("takes over the lock of a bbl that is no longer running", func() {
+			command := exec.Command("true")
+			Expect(command.Run()).To(Succeed())
+
+			err := ioutil.WriteFile(lockFile, []byte(strconv.Itoa(command.Process.Pid)), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			unlock, err := locker.Lock(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			defer unlock()
+
+			contents, err := ioutil.ReadFile(lockFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(strconv.Itoa(os.Getpid())))
+		})
+
+		It("waits for the bbl that started this one to release the lock", func() {
+			err := ioutil.WriteFile(lockFile, []byte(strconv.Itoa(os.Getppid())), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				os.Remove(lockFile)
+			}()
+
+			unlock, err := locker.Lock(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			defer unlock()
+
+			contents, err := ioutil.ReadFile(lockFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(strconv.Itoa(os.Getpid())))
+		})
+
+		It("does not remove a lock that another bbl took over on unlock", func() {
+			unlock, err := locker.Lock(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(lockFile, []byte(strconv.Itoa(os.Getppid())), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			unlock()
+			Expect(lockFile).To(BeAnExistingFile())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the lock file is not valid", func() {
+				err := ioutil.WriteFile(lockFile, []byte("not-a-pid"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = locker.Lock(tempDir)
+				Expect(err).To(MatchError(ContainSubstring("invalid state dir lock")))
+			})
+
+			It("returns an error when the state dir does not exist", func() {
+				_, err := locker.Lock("/some/missing/dir")
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+	})
+
+	Describe("ProcessChecker", func() {
+		It("reports whether a process is running", func() {
+			command := exec.Command("true")
+			Expect(command.Run()).To(Succeed())
+
+			processChecker := storage.NewProcessChecker()
+			Expect(processChecker.Running(os.Getpid())).To(BeTrue())
+			Expect(processChecker.Running(command.Process.Pid)).To(BeFalse())
+		})
+	})
+})
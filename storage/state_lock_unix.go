@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package storage
+
+import "syscall"
+
+// processRunning reports whether the process that created a lock file is
+// still around. A process owned by another user is reported as running.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
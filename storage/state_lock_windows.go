@@ -0,0 +1,14 @@
+package storage
+
+import "os"
+
+// processRunning reports whether the process that created a lock file is
+// still around. Finding a process on windows fails once it has exited.
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
@@ -23,7 +23,7 @@ var _ = Describe("Store", func() {
 		var err error
 		tempDir, err = ioutil.TempDir("", "")
 
-		store = storage.NewStore(tempDir)
+		store = storage.NewStore(tempDir, 0, nil)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -45,6 +45,7 @@ var _ = Describe("Store", func() {
 					TenantID:       "tenant-id",
 					ClientID:       "client-id",
 					ClientSecret:   "client-secret",
+					Region:         "some-azure-region",
 				},
 				GCP: storage.GCP{
 					ServiceAccountKey: "some-service-account-key",
@@ -53,6 +54,16 @@ var _ = Describe("Store", func() {
 					Region:            "some-region",
 					Zones:             []string{"some-zone", "some-other-zone"},
 				},
+				OpenStack: storage.OpenStack{
+					AuthURL:         "some-auth-url",
+					AZ:              "some-az",
+					ExternalNetwork: "some-external-network",
+					Region:          "some-openstack-region",
+					Username:        "some-username",
+					Password:        "some-password",
+					Domain:          "some-domain",
+					Project:         "some-project",
+				},
 				KeyPair: storage.KeyPair{
 					Name:       "some-name",
 					PrivateKey: "some-private",
@@ -133,7 +144,8 @@ var _ = Describe("Store", func() {
 					"subscriptionId": "subscription-id",
 					"tenantId": "tenant-id",
 					"clientId": "client-id",
-					"clientSecret": "client-secret"
+					"clientSecret": "client-secret",
+					"region": "some-azure-region"
 				},
 				"gcp": {
 					"serviceAccountKey": "some-service-account-key",
@@ -142,6 +154,16 @@ var _ = Describe("Store", func() {
 					"region": "some-region",
 					"zones": ["some-zone", "some-other-zone"]
 				},
+				"openstack": {
+					"authURL": "some-auth-url",
+					"az": "some-az",
+					"externalNetwork": "some-external-network",
+					"region": "some-openstack-region",
+					"username": "some-username",
+					"password": "some-password",
+					"domain": "some-domain",
+					"project": "some-project"
+				},
 				"keyPair": {
 					"name": "some-name",
 					"privateKey": "some-private",
@@ -152,7 +174,9 @@ var _ = Describe("Store", func() {
 					"cert": "some-cert",
 					"key": "some-key",
 					"chain": "some-chain",
-					"domain": "some-domain"
+					"domain": "some-domain",
+					"acme": {},
+					"previous": {}
 				},
 				"jumpbox":{
 					"enabled": true,
@@ -202,7 +226,13 @@ var _ = Describe("Store", func() {
 				},
 				"envID": "some-env-id",
 				"tfState": "some-tf-state",
-				"latestTFOutput": ""
+				"tfOutputs": {},
+				"latestTFOutput": "",
+				"upProgress": {},
+				"network": {},
+				"latestError": {},
+				"stemcell": {},
+				"directorDB": {}
 			}`))
 
 			fileInfo, err := os.Stat(filepath.Join(tempDir, "bbl-state.json"))
@@ -210,6 +240,63 @@ var _ = Describe("Store", func() {
 			Expect(fileInfo.Mode()).To(Equal(os.FileMode(0644)))
 		})
 
+		It("keeps the schema version of a state written by an earlier bbl", func() {
+			err := store.Set(storage.State{Version: 5, IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Version).To(Equal(5))
+		})
+
+		Context("when the AWS credentials come from a profile", func() {
+			It("stores the profile name instead of the credentials", func() {
+				err := store.Set(storage.State{
+					IAAS: "aws",
+					AWS: storage.AWS{
+						AccessKeyID:     "some-aws-access-key-id",
+						SecretAccessKey: "some-aws-secret-access-key",
+						Region:          "some-region",
+						Profile:         "some-profile",
+					},
+					EnvID: "some-env-id",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				data, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring(`"profile": "some-profile"`))
+				Expect(string(data)).NotTo(ContainSubstring("some-aws-access-key-id"))
+				Expect(string(data)).NotTo(ContainSubstring("some-aws-secret-access-key"))
+			})
+		})
+
+		Context("when the AWS credentials come from an assumed role", func() {
+			It("stores the role instead of the temporary credentials", func() {
+				err := store.Set(storage.State{
+					IAAS: "aws",
+					AWS: storage.AWS{
+						AccessKeyID:          "some-temporary-access-key-id",
+						SecretAccessKey:      "some-temporary-secret-access-key",
+						SessionToken:         "some-session-token",
+						Region:               "some-region",
+						AssumeRoleARN:        "arn:aws:iam::123456789012:role/bbl",
+						AssumeRoleExternalID: "some-external-id",
+					},
+					EnvID: "some-env-id",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				data, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring(`"assumeRoleArn": "arn:aws:iam::123456789012:role/bbl"`))
+				Expect(string(data)).To(ContainSubstring(`"assumeRoleExternalId": "some-external-id"`))
+				Expect(string(data)).NotTo(ContainSubstring("some-temporary-access-key-id"))
+				Expect(string(data)).NotTo(ContainSubstring("some-temporary-secret-access-key"))
+				Expect(string(data)).NotTo(ContainSubstring("some-session-token"))
+			})
+		})
+
 		Context("when the state is empty", func() {
 			It("removes the bbl-state.json file", func() {
 				err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte("{}"), os.ModePerm)
@@ -257,7 +344,7 @@ var _ = Describe("Store", func() {
 			})
 
 			It("fails when the directory does not exist", func() {
-				store = storage.NewStore("non-valid-dir")
+				store = storage.NewStore("non-valid-dir", 0, nil)
 				err := store.Set(storage.State{})
 				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
 			})
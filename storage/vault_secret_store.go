@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	VaultAddrEnv       = "VAULT_ADDR"
+	VaultTokenEnv      = "VAULT_TOKEN"
+	VaultNamespaceEnv  = "VAULT_NAMESPACE"
+	VaultCACertEnv     = "VAULT_CACERT"
+	VaultSkipVerifyEnv = "VAULT_SKIP_VERIFY"
+
+	defaultVaultPathPrefix = "secret/bbl/"
+)
+
+// VaultSecretStore keeps the secrets of an environment in a single secret of
+// a HashiCorp Vault KV version 2 secrets engine. The first segment of path
+// is the mount of the engine, "secret" in "secret/bbl/some-env".
+type VaultSecretStore struct {
+	addr      string
+	token     string
+	namespace string
+	path      string
+	client    *http.Client
+	secrets   map[string]string
+
+	// version is the version of the secret the secrets were read from, 0
+	// when it does not exist yet, which writes are checked against.
+	version int
+}
+
+// NewVaultSecretStore authenticates with token against the vault at addr,
+// trusting the PEM encoded certificate in the file caCert in addition to the
+// system roots, or skipping verification when skipVerify is true, like the
+// vault cli does with VAULT_CACERT and VAULT_SKIP_VERIFY. The secrets are
+// read once and cached for the lifetime of the store.
+func NewVaultSecretStore(addr, token, namespace, caCert, skipVerify, path string) (*VaultSecretStore, error) {
+	if addr == "" || token == "" {
+		return nil, fmt.Errorf("%s and %s must be set to use the %s secret store", VaultAddrEnv, VaultTokenEnv, SecretStoreVault)
+	}
+
+	path = strings.Trim(path, "/")
+	if path != "" && !strings.Contains(path, "/") {
+		return nil, fmt.Errorf("vault path %q must start with the mount of a kv secrets engine, such as secret/bbl/some-env", path)
+	}
+
+	tlsConfig := &tls.Config{}
+	if skipVerify != "" {
+		skip, err := strconv.ParseBool(skipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", VaultSkipVerifyEnv)
+		}
+		tlsConfig.InsecureSkipVerify = skip
+	}
+
+	if caCert != "" {
+		contents, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", VaultCACertEnv, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(contents) {
+			return nil, fmt.Errorf("%s must be the path of a PEM encoded certificate", VaultCACertEnv)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &VaultSecretStore{
+		addr:      strings.TrimSuffix(addr, "/"),
+		token:     token,
+		namespace: namespace,
+		path:      path,
+		client: &http.Client{
+			Timeout:   time.Minute,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+		},
+	}, nil
+}
+
+func (v *VaultSecretStore) Get(name string) (string, error) {
+	secrets, err := v.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in vault at %s", name, v.path)
+	}
+
+	return value, nil
+}
+
+func (v *VaultSecretStore) Set(name, value string) error {
+	return v.SetAll(map[string]string{name: value})
+}
+
+// SetAll writes the secrets in a single request unless vault already holds
+// their values, so that saving the state does not add a version of the secret
+// each time. The write is checked against the version the secrets were read
+// from, so that it does not overwrite the secrets saved by another bbl in the
+// meantime.
+func (v *VaultSecretStore) SetAll(secrets map[string]string) error {
+	current, err := v.read()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]string{}
+	for n, s := range current {
+		updated[n] = s
+	}
+
+	changed := false
+	for name, value := range secrets {
+		if currentValue, ok := current[name]; !ok || currentValue != value {
+			updated[name] = value
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"options": map[string]int{"cas": v.version},
+		"data":    updated,
+	})
+	if err != nil {
+		return err //not tested
+	}
+
+	resp, err := v.do("POST", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	case http.StatusBadRequest:
+		return fmt.Errorf("failed to write the secrets to vault at %s, they may have been changed since bbl read them: %s", v.path, resp.Status)
+	default:
+		return fmt.Errorf("failed to write the secrets to vault at %s: %s", v.path, resp.Status)
+	}
+
+	var written struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&written)
+	if err != nil || written.Data.Version == 0 {
+		// read the secrets and their version again before the next write
+		v.secrets = nil
+		return nil
+	}
+
+	v.secrets = updated
+	v.version = written.Data.Version
+	return nil
+}
+
+func (v *VaultSecretStore) read() (map[string]string, error) {
+	if v.secrets != nil {
+		return v.secrets, nil
+	}
+
+	resp, err := v.do("GET", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		v.secrets = map[string]string{}
+		return v.secrets, nil
+	default:
+		return nil, fmt.Errorf("failed to read the secrets from vault at %s: %s", v.path, resp.Status)
+	}
+
+	var secret struct {
+		Data struct {
+			Data     map[string]string `json:"data"`
+			Metadata struct {
+				Version int `json:"version"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the secrets from vault at %s: %s", v.path, err)
+	}
+
+	v.secrets = secret.Data.Data
+	v.version = secret.Data.Metadata.Version
+	if v.secrets == nil {
+		v.secrets = map[string]string{}
+	}
+
+	return v.secrets, nil
+}
+
+func (v *VaultSecretStore) do(method string, body []byte) (*http.Response, error) {
+	if v.path == "" {
+		return nil, fmt.Errorf("the vault path is only known once the environment has an id, set --vault-path to use the %s secret store", SecretStoreVault)
+	}
+
+	parts := strings.SplitN(v.path, "/", 2)
+	url := fmt.Sprintf("%s/v1/%s/data/%s", v.addr, parts[0], parts[1])
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vault at %s: %s", v.addr, err)
+	}
+
+	return resp, nil
+}
@@ -1,11 +1,31 @@
 package aws
 
-const BaseTemplate = `resource "aws_eip" "bosh_eip" {
+const BaseTemplate = BOSHEIPTemplate + DirectorTemplate + NATTemplate + ProviderTemplate + DefaultSecurityGroupTemplate + SecurityGroupsTemplate + NetworkTemplate
+
+const ExistingVPCTemplate = ExistingVPCBOSHEIPTemplate + DirectorTemplate + ProviderTemplate + SecurityGroupsTemplate + ExistingVPCNetworkTemplate
+
+const BOSHEIPTemplate = `resource "aws_eip" "bosh_eip" {
   depends_on = ["aws_internet_gateway.ig"]
   vpc      = true
+
+  tags {
+    Name = "${var.env_id}-bosh-eip"
+  }
+}
+
+`
+
+const ExistingVPCBOSHEIPTemplate = `resource "aws_eip" "bosh_eip" {
+  vpc      = true
+
+  tags {
+    Name = "${var.env_id}-bosh-eip"
+  }
 }
 
-output "external_ip" {
+`
+
+const DirectorTemplate = `output "external_ip" {
   value = "${aws_eip.bosh_eip.public_ip}"
 }
 
@@ -102,7 +122,9 @@ output "bosh_iam_instance_profile" {
   value = "${aws_iam_instance_profile.bosh.name}"
 }
 
-variable "nat_ami_map" {
+`
+
+const NATTemplate = `variable "nat_ami_map" {
   type = "map"
 
   default = {
@@ -150,7 +172,7 @@ resource "aws_security_group" "nat_security_group" {
 variable "nat_ssh_key_pair_name" {}
 
 resource "aws_instance" "nat" {
-  private_ip             = "10.0.0.7"
+  private_ip             = "${cidrhost(var.bosh_subnet_cidr, 7)}"
   instance_type          = "t2.medium"
   subnet_id              = "${aws_subnet.bosh_subnet.id}"
   source_dest_check      = false
@@ -168,13 +190,96 @@ resource "aws_eip" "nat_eip" {
   depends_on = ["aws_internet_gateway.ig"]
   instance = "${aws_instance.nat.id}"
   vpc      = true
+
+  tags {
+    Name = "${var.env_id}-nat-eip"
+  }
 }
 
 output "nat_eip" {
   value = "${aws_eip.nat_eip.public_ip}"
 }
 
-variable "access_key" {
+`
+
+// NATGatewayTemplate replaces NATTemplate with an AWS managed NAT gateway in
+// a public subnet of every availability zone.
+const NATGatewayTemplate = `resource "aws_subnet" "nat_subnets" {
+  count             = "${length(var.availability_zones)}"
+  vpc_id            = "${aws_vpc.vpc.id}"
+  cidr_block        = "${cidrsubnet(var.vpc_cidr, 8, count.index+8)}"
+  availability_zone = "${element(var.availability_zones, count.index)}"
+
+  tags {
+    Name = "${var.env_id}-nat-subnet${count.index}"
+  }
+
+  lifecycle {
+    ignore_changes = ["cidr_block", "availability_zone"]
+  }
+}
+
+resource "aws_route_table" "nat_route_table" {
+  vpc_id = "${aws_vpc.vpc.id}"
+}
+
+resource "aws_route" "nat_route_table" {
+  destination_cidr_block = "0.0.0.0/0"
+  gateway_id = "${aws_internet_gateway.ig.id}"
+  route_table_id = "${aws_route_table.nat_route_table.id}"
+}
+
+resource "aws_route_table_association" "route_nat_subnets" {
+  count          = "${length(var.availability_zones)}"
+  subnet_id      = "${element(aws_subnet.nat_subnets.*.id, count.index)}"
+  route_table_id = "${aws_route_table.nat_route_table.id}"
+}
+
+resource "aws_eip" "nat_eips" {
+  count      = "${length(var.availability_zones)}"
+  depends_on = ["aws_internet_gateway.ig"]
+  vpc        = true
+
+  tags {
+    Name = "${var.env_id}-nat-eip${count.index}"
+  }
+}
+
+resource "aws_nat_gateway" "nat" {
+  count         = "${length(var.availability_zones)}"
+  allocation_id = "${element(aws_eip.nat_eips.*.id, count.index)}"
+  subnet_id     = "${element(aws_subnet.nat_subnets.*.id, count.index)}"
+}
+
+output "nat_eips" {
+  value = ["${aws_eip.nat_eips.*.public_ip}"]
+}
+
+`
+
+const ProviderTemplate = `variable "access_key" {
+  type = "string"
+}
+
+variable "secret_key" {
+  type = "string"
+}
+
+variable "region" {
+  type = "string"
+}
+
+provider "aws" {
+  access_key = "${var.access_key}"
+  secret_key = "${var.secret_key}"
+  region     = "${var.region}"
+}
+
+`
+
+// AssumedRoleProviderTemplate replaces ProviderTemplate when bbl works with
+// the temporary credentials of an assumed role.
+const AssumedRoleProviderTemplate = `variable "access_key" {
   type = "string"
 }
 
@@ -182,6 +287,10 @@ variable "secret_key" {
   type = "string"
 }
 
+variable "session_token" {
+  type = "string"
+}
+
 variable "region" {
   type = "string"
 }
@@ -189,16 +298,21 @@ variable "region" {
 provider "aws" {
   access_key = "${var.access_key}"
   secret_key = "${var.secret_key}"
+  token      = "${var.session_token}"
   region     = "${var.region}"
 }
 
-resource "aws_default_security_group" "default_security_group" {
+`
+
+const DefaultSecurityGroupTemplate = `resource "aws_default_security_group" "default_security_group" {
 	vpc_id = "${aws_vpc.vpc.id}"
 }
 
-resource "aws_security_group" "internal_security_group" {
+`
+
+const SecurityGroupsTemplate = `resource "aws_security_group" "internal_security_group" {
   description = "{{.InternalDescription}}"
-  vpc_id      = "${aws_vpc.vpc.id}"
+  vpc_id      = "{{.VPCID}}"
 
   tags {
     Name = "${var.env_id}-internal-security-group"
@@ -251,7 +365,7 @@ variable "bosh_inbound_cidr" {
 
 resource "aws_security_group" "bosh_security_group" {
   description = "{{.BOSHDescription}}"
-  vpc_id      = "${aws_vpc.vpc.id}"
+  vpc_id      = "{{.VPCID}}"
 
   tags {
     Name = "${var.env_id}-bosh-security-group"
@@ -334,7 +448,9 @@ resource "aws_security_group_rule" "bosh_internal_security_rule_udp" {
   source_security_group_id = "${aws_security_group.bosh_security_group.id}"
 }
 
-variable "bosh_subnet_cidr" {
+`
+
+const NetworkTemplate = `variable "bosh_subnet_cidr" {
   type    = "string"
   default = "10.0.0.0/24"
 }
@@ -346,10 +462,15 @@ variable "bosh_availability_zone" {
 resource "aws_subnet" "bosh_subnet" {
   vpc_id            = "${aws_vpc.vpc.id}"
   cidr_block        = "${var.bosh_subnet_cidr}"
+  availability_zone = "${var.bosh_availability_zone}"
 
   tags {
     Name = "${var.env_id}-bosh-subnet"
   }
+
+  lifecycle {
+    ignore_changes = ["availability_zone"]
+  }
 }
 
 resource "aws_route_table" "bosh_route_table" {
@@ -382,7 +503,7 @@ variable "availability_zones" {
 resource "aws_subnet" "internal_subnets" {
   count             = "${length(var.availability_zones)}"
   vpc_id            = "${aws_vpc.vpc.id}"
-  cidr_block        = "${cidrsubnet("10.0.0.0/16", 4, count.index+1)}"
+  cidr_block        = "${cidrsubnet(var.vpc_cidr, 4, count.index+1)}"
   availability_zone = "${element(var.availability_zones, count.index)}"
 
   tags {
@@ -394,22 +515,7 @@ resource "aws_subnet" "internal_subnets" {
   }
 }
 
-resource "aws_route_table" "internal_route_table" {
-  vpc_id = "${aws_vpc.vpc.id}"
-}
-
-resource "aws_route" "internal_route_table" {
-  destination_cidr_block = "0.0.0.0/0"
-  instance_id = "${aws_instance.nat.id}"
-  route_table_id = "${aws_route_table.internal_route_table.id}"
-}
-
-resource "aws_route_table_association" "route_internal_subnets" {
-  count          = "${length(var.availability_zones)}"
-  subnet_id      = "${element(aws_subnet.internal_subnets.*.id, count.index)}"
-  route_table_id = "${aws_route_table.internal_route_table.id}"
-}
-
+` + InternalRouteTableTemplate + `
 output "internal_az_subnet_id_mapping" {
 	value = "${
 	  zipmap("${aws_subnet.internal_subnets.*.availability_zone}", "${aws_subnet.internal_subnets.*.id}")
@@ -509,10 +615,103 @@ EOF
 }
 `
 
+const ExistingVPCNetworkTemplate = `variable "existing_vpc_id" {
+  type = "string"
+}
+
+variable "existing_subnet_ids" {
+  type = "list"
+}
+
+data "aws_vpc" "vpc" {
+  id = "${var.existing_vpc_id}"
+}
+
+output "vpc_id" {
+  value = "${data.aws_vpc.vpc.id}"
+}
+
+data "aws_subnet" "bosh_subnet" {
+  id = "${element(var.existing_subnet_ids, 0)}"
+}
+
+output "bosh_subnet_id" {
+  value = "${data.aws_subnet.bosh_subnet.id}"
+}
+
+output "bosh_subnet_availability_zone" {
+  value = "${data.aws_subnet.bosh_subnet.availability_zone}"
+}
+
+output "bosh_subnet_cidr" {
+  value = "${data.aws_subnet.bosh_subnet.cidr_block}"
+}
+
+data "aws_subnet" "internal_subnets" {
+  count = "${length(var.existing_subnet_ids)}"
+  id    = "${element(var.existing_subnet_ids, count.index)}"
+}
+
+output "internal_az_subnet_id_mapping" {
+	value = "${
+	  zipmap("${data.aws_subnet.internal_subnets.*.availability_zone}", "${data.aws_subnet.internal_subnets.*.id}")
+	}"
+}
+
+output "internal_az_subnet_cidr_mapping" {
+	value = "${
+	  zipmap("${data.aws_subnet.internal_subnets.*.availability_zone}", "${data.aws_subnet.internal_subnets.*.cidr_block}")
+	}"
+}
+
+variable "env_id" {
+  type = "string"
+}
+`
+
+const InternalRouteTableTemplate = `resource "aws_route_table" "internal_route_table" {
+  vpc_id = "${aws_vpc.vpc.id}"
+}
+
+resource "aws_route" "internal_route_table" {
+  destination_cidr_block = "0.0.0.0/0"
+  instance_id = "${aws_instance.nat.id}"
+  route_table_id = "${aws_route_table.internal_route_table.id}"
+}
+
+resource "aws_route_table_association" "route_internal_subnets" {
+  count          = "${length(var.availability_zones)}"
+  subnet_id      = "${element(aws_subnet.internal_subnets.*.id, count.index)}"
+  route_table_id = "${aws_route_table.internal_route_table.id}"
+}
+`
+
+// NATGatewayRouteTablesTemplate replaces InternalRouteTableTemplate with a
+// route table for every availability zone, through the NAT gateway of the
+// zone, so that a zone keeps its internet access when another one fails.
+const NATGatewayRouteTablesTemplate = `resource "aws_route_table" "internal_route_tables" {
+  count  = "${length(var.availability_zones)}"
+  vpc_id = "${aws_vpc.vpc.id}"
+}
+
+resource "aws_route" "internal_route_tables" {
+  count                  = "${length(var.availability_zones)}"
+  destination_cidr_block = "0.0.0.0/0"
+  nat_gateway_id         = "${element(aws_nat_gateway.nat.*.id, count.index)}"
+  route_table_id         = "${element(aws_route_table.internal_route_tables.*.id, count.index)}"
+}
+
+resource "aws_route_table_association" "route_internal_subnets" {
+  count          = "${length(var.availability_zones)}"
+  subnet_id      = "${element(aws_subnet.internal_subnets.*.id, count.index)}"
+  route_table_id = "${element(aws_route_table.internal_route_tables.*.id, count.index)}"
+}
+`
+
 const LBSubnetTemplate = `resource "aws_subnet" "lb_subnets" {
   count             = "${length(var.availability_zones)}"
   vpc_id            = "${aws_vpc.vpc.id}"
-  cidr_block        = "${cidrsubnet("10.0.0.0/20", 4, count.index+2)}"
+  cidr_block        = "${cidrsubnet(var.vpc_cidr, 8, count.index+2)}"
   availability_zone = "${element(var.availability_zones, count.index)}"
 
   tags {
@@ -587,6 +786,84 @@ resource "aws_iam_server_certificate" "lb_cert" {
 }
 `
 
+const PreviousSSLCertificateTemplate = `variable "previous_ssl_certificate" {
+  type = "string"
+}
+
+variable "previous_ssl_certificate_chain" {
+  type = "string"
+}
+
+variable "previous_ssl_certificate_private_key" {
+  type = "string"
+}
+
+resource "aws_iam_server_certificate" "{{.PreviousSSLCertificateResource}}" {
+  {{.SSLCertificateNameProperty}}
+
+  certificate_body  = "${var.previous_ssl_certificate}"
+  certificate_chain = "${var.previous_ssl_certificate_chain}"
+  private_key       = "${var.previous_ssl_certificate_private_key}"
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+`
+
+const DirectorDBTemplate = `variable "director_db_password" {
+  type = "string"
+}
+
+resource "aws_security_group" "director_db_security_group" {
+  description = "{{.DirectorDBDescription}}"
+  vpc_id      = "{{.VPCID}}"
+
+  tags {
+    Name = "${var.env_id}-director-db-security-group"
+  }
+}
+
+resource "aws_security_group_rule" "director_db_security_group_rule_postgres" {
+  security_group_id        = "${aws_security_group.director_db_security_group.id}"
+  type                     = "ingress"
+  protocol                 = "tcp"
+  from_port                = 5432
+  to_port                  = 5432
+  source_security_group_id = "${aws_security_group.bosh_security_group.id}"
+}
+
+resource "aws_db_subnet_group" "director_db_subnet_group" {
+  name       = "${var.env_id}-director-db"
+  subnet_ids = ["${aws_subnet.internal_subnets.*.id}"]
+}
+
+resource "aws_db_instance" "director_db" {
+  identifier              = "${var.env_id}-director-db"
+  engine                  = "postgres"
+  engine_version          = "9.6"
+  instance_class          = "db.t2.medium"
+  allocated_storage       = 20
+  storage_type            = "gp2"
+  name                    = "bosh"
+  username                = "bosh"
+  password                = "${var.director_db_password}"
+  db_subnet_group_name    = "${aws_db_subnet_group.director_db_subnet_group.name}"
+  vpc_security_group_ids  = ["${aws_security_group.director_db_security_group.id}"]
+  multi_az                = true
+  backup_retention_period = 7
+  skip_final_snapshot     = true
+}
+
+output "director_db_host" {
+  value = "${aws_db_instance.director_db.address}"
+}
+
+output "director_db_port" {
+  value = "${aws_db_instance.director_db.port}"
+}
+`
+
 const ConcourseLBTemplate = `resource "aws_security_group" "concourse_lb_security_group" {
   description = "{{.ConcourseDescription}}"
   vpc_id      = "${aws_vpc.vpc.id}"
@@ -1691,6 +1968,260 @@ output "cf_tcp_lb_url" {
 }
 `
 
+const CFNLBTemplate = `resource "aws_security_group" "cf_ssh_lb_internal_security_group" {
+  description = "{{.SSHLBInternalDescription}}"
+  vpc_id      = "${aws_vpc.vpc.id}"
+
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+    protocol    = "tcp"
+    from_port   = 2222
+    to_port     = 2222
+  }
+
+  egress {
+    from_port = 0
+    to_port = 0
+    protocol = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  tags {
+    Name = "${var.env_id}-cf-ssh-lb-internal-security-group"
+  }
+}
+
+output "cf_ssh_lb_internal_security_group" {
+  value="${aws_security_group.cf_ssh_lb_internal_security_group.id}"
+}
+
+resource "aws_lb" "cf_ssh_lb" {
+  name                             = "${var.short_env_id}-cf-ssh-lb"
+  load_balancer_type               = "network"
+  enable_cross_zone_load_balancing = true
+  subnets                          = ["${aws_subnet.lb_subnets.*.id}"]
+}
+
+resource "aws_lb_target_group" "cf_ssh_lb" {
+  name     = "${var.short_env_id}-cf-ssh"
+  port     = 2222
+  protocol = "TCP"
+  vpc_id   = "${aws_vpc.vpc.id}"
+
+  health_check {
+    protocol            = "TCP"
+    healthy_threshold   = 5
+    unhealthy_threshold = 5
+    interval            = 10
+  }
+}
+
+resource "aws_lb_listener" "cf_ssh_lb" {
+  load_balancer_arn = "${aws_lb.cf_ssh_lb.arn}"
+  port              = 2222
+  protocol          = "TCP"
+
+  default_action {
+    type             = "forward"
+    target_group_arn = "${aws_lb_target_group.cf_ssh_lb.arn}"
+  }
+}
+
+output "cf_ssh_lb_name" {
+  value = "${aws_lb.cf_ssh_lb.name}"
+}
+
+output "cf_ssh_lb_url" {
+  value = "${aws_lb.cf_ssh_lb.dns_name}"
+}
+
+output "cf_ssh_lb_target_group" {
+  value = "${aws_lb_target_group.cf_ssh_lb.name}"
+}
+
+resource "aws_security_group" "cf_router_lb_internal_security_group" {
+  description = "{{.RouterInternalDescription}}"
+  vpc_id      = "${aws_vpc.vpc.id}"
+
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+    protocol    = "tcp"
+    from_port   = 80
+    to_port     = 80
+  }
+
+  egress {
+    from_port = 0
+    to_port = 0
+    protocol = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  tags {
+    Name = "${var.env_id}-cf-router-lb-internal-security-group"
+  }
+}
+
+output "cf_router_lb_internal_security_group" {
+  value="${aws_security_group.cf_router_lb_internal_security_group.id}"
+}
+
+resource "aws_lb" "cf_router_lb" {
+  name                             = "${var.short_env_id}-cf-router-lb"
+  load_balancer_type               = "network"
+  enable_cross_zone_load_balancing = true
+  subnets                          = ["${aws_subnet.lb_subnets.*.id}"]
+}
+
+resource "aws_lb_target_group" "cf_router_lb" {
+  name     = "${var.short_env_id}-cf-router"
+  port     = 80
+  protocol = "TCP"
+  vpc_id   = "${aws_vpc.vpc.id}"
+
+  health_check {
+    protocol            = "TCP"
+    healthy_threshold   = 5
+    unhealthy_threshold = 5
+    interval            = 10
+  }
+}
+
+resource "aws_lb_listener" "cf_router_lb_http" {
+  load_balancer_arn = "${aws_lb.cf_router_lb.arn}"
+  port              = 80
+  protocol          = "TCP"
+
+  default_action {
+    type             = "forward"
+    target_group_arn = "${aws_lb_target_group.cf_router_lb.arn}"
+  }
+}
+
+resource "aws_lb_listener" "cf_router_lb_https" {
+  load_balancer_arn = "${aws_lb.cf_router_lb.arn}"
+  port              = 443
+  protocol          = "TLS"
+  ssl_policy        = "ELBSecurityPolicy-2016-08"
+  certificate_arn   = "${aws_iam_server_certificate.lb_cert.arn}"
+
+  default_action {
+    type             = "forward"
+    target_group_arn = "${aws_lb_target_group.cf_router_lb.arn}"
+  }
+}
+
+resource "aws_lb_listener" "cf_router_lb_websockets" {
+  load_balancer_arn = "${aws_lb.cf_router_lb.arn}"
+  port              = 4443
+  protocol          = "TLS"
+  ssl_policy        = "ELBSecurityPolicy-2016-08"
+  certificate_arn   = "${aws_iam_server_certificate.lb_cert.arn}"
+
+  default_action {
+    type             = "forward"
+    target_group_arn = "${aws_lb_target_group.cf_router_lb.arn}"
+  }
+}
+
+output "cf_router_lb_name" {
+  value = "${aws_lb.cf_router_lb.name}"
+}
+
+output "cf_router_lb_url" {
+  value = "${aws_lb.cf_router_lb.dns_name}"
+}
+
+output "cf_router_lb_target_group" {
+  value = "${aws_lb_target_group.cf_router_lb.name}"
+}
+
+variable "cf_tcp_lb_ports" {
+  default = 50
+}
+
+resource "aws_security_group" "cf_tcp_lb_internal_security_group" {
+  description = "{{.TCPLBInternalDescription}}"
+  vpc_id      = "${aws_vpc.vpc.id}"
+
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+    protocol    = "tcp"
+    from_port   = 1024
+    to_port     = "${1023 + var.cf_tcp_lb_ports}"
+  }
+
+  ingress {
+    cidr_blocks = ["${var.vpc_cidr}"]
+    protocol    = "tcp"
+    from_port   = 80
+    to_port     = 80
+  }
+
+  egress {
+    from_port = 0
+    to_port = 0
+    protocol = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  tags {
+    Name = "${var.env_id}-cf-tcp-lb-internal-security-group"
+  }
+}
+
+output "cf_tcp_lb_internal_security_group" {
+  value="${aws_security_group.cf_tcp_lb_internal_security_group.id}"
+}
+
+resource "aws_lb" "cf_tcp_lb" {
+  name                             = "${var.short_env_id}-cf-tcp-lb"
+  load_balancer_type               = "network"
+  enable_cross_zone_load_balancing = true
+  subnets                          = ["${aws_subnet.lb_subnets.*.id}"]
+}
+
+resource "aws_lb_target_group" "cf_tcp_lb" {
+  count    = "${var.cf_tcp_lb_ports}"
+  name     = "${var.short_env_id}-cf-tcp-${1024 + count.index}"
+  port     = "${1024 + count.index}"
+  protocol = "TCP"
+  vpc_id   = "${aws_vpc.vpc.id}"
+
+  health_check {
+    protocol            = "TCP"
+    port                = 80
+    healthy_threshold   = 3
+    unhealthy_threshold = 3
+    interval            = 10
+  }
+}
+
+resource "aws_lb_listener" "cf_tcp_lb" {
+  count             = "${var.cf_tcp_lb_ports}"
+  load_balancer_arn = "${aws_lb.cf_tcp_lb.arn}"
+  port              = "${1024 + count.index}"
+  protocol          = "TCP"
+
+  default_action {
+    type             = "forward"
+    target_group_arn = "${element(aws_lb_target_group.cf_tcp_lb.*.arn, count.index)}"
+  }
+}
+
+output "cf_tcp_lb_name" {
+  value = "${aws_lb.cf_tcp_lb.name}"
+}
+
+output "cf_tcp_lb_url" {
+  value = "${aws_lb.cf_tcp_lb.dns_name}"
+}
+
+output "cf_tcp_lb_target_groups" {
+  value = ["${aws_lb_target_group.cf_tcp_lb.*.name}"]
+}
+`
+
 const CFDNSTemplate = `variable "system_domain" {
   type = "string"
 }
@@ -1710,19 +2241,37 @@ output "env_dns_zone_name_servers" {
 resource "aws_route53_record" "wildcard_dns" {
   zone_id = "${aws_route53_zone.env_dns_zone.id}"
   name    = "*.${var.system_domain}"
-  type    = "CNAME"
-  ttl     = 300
+  type    = "A"
 
-  records = ["${aws_elb.cf_router_lb.dns_name}"]
+  alias {
+    name                   = "${aws_elb.cf_router_lb.dns_name}"
+    zone_id                = "${aws_elb.cf_router_lb.zone_id}"
+    evaluate_target_health = false
+  }
+}
+
+resource "aws_route53_record" "wildcard_ws_dns" {
+  zone_id = "${aws_route53_zone.env_dns_zone.id}"
+  name    = "*.ws.${var.system_domain}"
+  type    = "A"
+
+  alias {
+    name                   = "${aws_elb.cf_router_lb.dns_name}"
+    zone_id                = "${aws_elb.cf_router_lb.zone_id}"
+    evaluate_target_health = false
+  }
 }
 
 resource "aws_route53_record" "ssh" {
   zone_id = "${aws_route53_zone.env_dns_zone.id}"
   name    = "ssh.${var.system_domain}"
-  type    = "CNAME"
-  ttl     = 300
+  type    = "A"
 
-  records = ["${aws_elb.cf_ssh_lb.dns_name}"]
+  alias {
+    name                   = "${aws_elb.cf_ssh_lb.dns_name}"
+    zone_id                = "${aws_elb.cf_ssh_lb.zone_id}"
+    evaluate_target_health = false
+  }
 }
 
 resource "aws_route53_record" "bosh" {
@@ -1737,9 +2286,12 @@ resource "aws_route53_record" "bosh" {
 resource "aws_route53_record" "tcp" {
   zone_id = "${aws_route53_zone.env_dns_zone.id}"
   name    = "tcp.${var.system_domain}"
-  type    = "CNAME"
-  ttl     = 300
+  type    = "A"
 
-  records = ["${aws_elb.cf_tcp_lb.dns_name}"]
+  alias {
+    name                   = "${aws_elb.cf_tcp_lb.dns_name}"
+    zone_id                = "${aws_elb.cf_tcp_lb.zone_id}"
+    evaluate_target_health = false
+  }
 }
 `
@@ -0,0 +1,17 @@
+resource "aws_route53_record" "acme_challenge_0" {
+  zone_id = "${aws_route53_zone.env_dns_zone.id}"
+  name    = "_acme-challenge.some-domain"
+  type    = "TXT"
+  ttl     = 60
+
+  records = ["some-value", "some-other-value"]
+}
+
+resource "aws_route53_record" "acme_challenge_1" {
+  zone_id = "${aws_route53_zone.env_dns_zone.id}"
+  name    = "_acme-challenge.ws.some-domain"
+  type    = "TXT"
+  ttl     = 60
+
+  records = ["some-ws-value"]
+}
@@ -1,6 +1,10 @@
 resource "aws_eip" "bosh_eip" {
   depends_on = ["aws_internet_gateway.ig"]
   vpc      = true
+
+  tags {
+    Name = "${var.env_id}-bosh-eip"
+  }
 }
 
 output "external_ip" {
@@ -159,7 +163,7 @@ resource "aws_security_group" "nat_security_group" {
 variable "nat_ssh_key_pair_name" {}
 
 resource "aws_instance" "nat" {
-  private_ip             = "10.0.0.7"
+  private_ip             = "${cidrhost(var.bosh_subnet_cidr, 7)}"
   instance_type          = "t2.medium"
   subnet_id              = "${aws_subnet.bosh_subnet.id}"
   source_dest_check      = false
@@ -177,6 +181,10 @@ resource "aws_eip" "nat_eip" {
   depends_on = ["aws_internet_gateway.ig"]
   instance = "${aws_instance.nat.id}"
   vpc      = true
+
+  tags {
+    Name = "${var.env_id}-nat-eip"
+  }
 }
 
 output "nat_eip" {
@@ -355,10 +363,15 @@ variable "bosh_availability_zone" {
 resource "aws_subnet" "bosh_subnet" {
   vpc_id            = "${aws_vpc.vpc.id}"
   cidr_block        = "${var.bosh_subnet_cidr}"
+  availability_zone = "${var.bosh_availability_zone}"
 
   tags {
     Name = "${var.env_id}-bosh-subnet"
   }
+
+  lifecycle {
+    ignore_changes = ["availability_zone"]
+  }
 }
 
 resource "aws_route_table" "bosh_route_table" {
@@ -391,7 +404,7 @@ variable "availability_zones" {
 resource "aws_subnet" "internal_subnets" {
   count             = "${length(var.availability_zones)}"
   vpc_id            = "${aws_vpc.vpc.id}"
-  cidr_block        = "${cidrsubnet("10.0.0.0/16", 4, count.index+1)}"
+  cidr_block        = "${cidrsubnet(var.vpc_cidr, 4, count.index+1)}"
   availability_zone = "${element(var.availability_zones, count.index)}"
 
   tags {
@@ -4,6 +4,7 @@ import (
 	"crypto/sha1"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
@@ -27,22 +28,34 @@ func NewInputGenerator(availabilityZoneRetriever availabilityZoneRetriever) Inpu
 }
 
 func (i InputGenerator) Generate(state storage.State) (map[string]string, error) {
+	if state.AWS.ExistingVPCID != "" {
+		return existingVPCInputs(state)
+	}
+
 	azs, err := i.availabilityZoneRetriever.Retrieve(state.AWS.Region)
 	if err != nil {
 		return map[string]string{}, err
 	}
 
+	azs, err = selectAZs(azs, state.AWS.AZs, state.Stack.BOSHAZ, state.AWS.Region)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
 	azsString, err := jsonMarshal(azs)
 	if err != nil {
 		return map[string]string{}, err
 	}
 
-	shortEnvID := state.EnvID
-	if len(shortEnvID) > terraformNameCharLimit {
-		sha1 := fmt.Sprintf("%x", sha1.Sum([]byte(state.EnvID)))
-		shortEnvID = fmt.Sprintf("%s-%s", shortEnvID[:terraformNameCharLimit-8], sha1[:terraformNameCharLimit-11])
+	// the director goes in the first selected availability zone, unless the
+	// environment was migrated from a stack with its own.
+	boshAZ := state.Stack.BOSHAZ
+	if boshAZ == "" && len(state.AWS.AZs) > 0 {
+		boshAZ = azs[0]
 	}
 
+	shortEnvID := ShortEnvID(state.EnvID)
+
 	inputs := map[string]string{
 		"env_id":                 state.EnvID,
 		"short_env_id":           shortEnvID,
@@ -50,10 +63,22 @@ func (i InputGenerator) Generate(state storage.State) (map[string]string, error)
 		"access_key":             state.AWS.AccessKeyID,
 		"secret_key":             state.AWS.SecretAccessKey,
 		"region":                 state.AWS.Region,
-		"bosh_availability_zone": state.Stack.BOSHAZ,
+		"bosh_availability_zone": boshAZ,
 		"availability_zones":     string(azsString),
 	}
 
+	if state.AWS.AssumeRoleARN != "" {
+		inputs["session_token"] = state.AWS.SessionToken
+	}
+
+	if state.Network.CIDR != "" {
+		inputs["vpc_cidr"] = state.Network.CIDR
+	}
+
+	if state.Network.SubnetCIDR != "" {
+		inputs["bosh_subnet_cidr"] = state.Network.SubnetCIDR
+	}
+
 	if state.LB.Type == "cf" || state.LB.Type == "concourse" {
 		inputs["ssl_certificate_name_prefix"] = ""
 		inputs["ssl_certificate_name"] = state.Stack.CertificateName
@@ -62,6 +87,12 @@ func (i InputGenerator) Generate(state storage.State) (map[string]string, error)
 			inputs["ssl_certificate"] = state.LB.Cert
 			inputs["ssl_certificate_private_key"] = state.LB.Key
 			inputs["ssl_certificate_chain"] = state.LB.Chain
+
+			if state.LB.Previous.Cert != "" {
+				inputs["previous_ssl_certificate"] = state.LB.Previous.Cert
+				inputs["previous_ssl_certificate_private_key"] = state.LB.Previous.Key
+				inputs["previous_ssl_certificate_chain"] = state.LB.Previous.Chain
+			}
 		}
 
 		if state.LB.Domain != "" {
@@ -69,5 +100,88 @@ func (i InputGenerator) Generate(state storage.State) (map[string]string, error)
 		}
 	}
 
+	if state.DirectorDB.Enabled {
+		inputs["director_db_password"] = state.DirectorDB.Password
+	}
+
+	return inputs, nil
+}
+
+func existingVPCInputs(state storage.State) (map[string]string, error) {
+	subnetIDsString, err := jsonMarshal(state.AWS.ExistingSubnetIDs)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	inputs := map[string]string{
+		"env_id":              state.EnvID,
+		"access_key":          state.AWS.AccessKeyID,
+		"secret_key":          state.AWS.SecretAccessKey,
+		"region":              state.AWS.Region,
+		"existing_vpc_id":     state.AWS.ExistingVPCID,
+		"existing_subnet_ids": string(subnetIDsString),
+	}
+
+	if state.AWS.AssumeRoleARN != "" {
+		inputs["session_token"] = state.AWS.SessionToken
+	}
+
+	if state.DirectorDB.Enabled {
+		inputs["director_db_password"] = state.DirectorDB.Password
+	}
+
 	return inputs, nil
 }
+
+// selectAZs limits the availability zones of the region to the ones passed to
+// bbl up, keeping the order of the region.
+func selectAZs(azs, selected []string, boshAZ, region string) ([]string, error) {
+	if len(selected) == 0 {
+		return azs, nil
+	}
+
+	isSelected := map[string]bool{}
+	for _, az := range selected {
+		isSelected[az] = true
+	}
+
+	selectedAZs := []string{}
+	for _, az := range azs {
+		if isSelected[az] {
+			selectedAZs = append(selectedAZs, az)
+			delete(isSelected, az)
+		}
+	}
+
+	for _, az := range selected {
+		if isSelected[az] {
+			return nil, fmt.Errorf("availability zone %q is not in region %s, choose from: %s", az, region, strings.Join(azs, ", "))
+		}
+	}
+
+	if boshAZ != "" && !contains(selectedAZs, boshAZ) {
+		return nil, fmt.Errorf("the bosh availability zone %q must be one of --azs", boshAZ)
+	}
+
+	return selectedAZs, nil
+}
+
+func contains(list []string, element string) bool {
+	for _, e := range list {
+		if e == element {
+			return true
+		}
+	}
+	return false
+}
+
+// ShortEnvID shortens env ids that are too long for the aws resource names
+// bbl prefixes with them, such as load balancers and server certificates.
+func ShortEnvID(envID string) string {
+	if len(envID) <= terraformNameCharLimit {
+		return envID
+	}
+
+	sha1 := fmt.Sprintf("%x", sha1.Sum([]byte(envID)))
+	return fmt.Sprintf("%s-%s", envID[:terraformNameCharLimit-8], sha1[:terraformNameCharLimit-11])
+}
@@ -56,6 +56,152 @@ var _ = Describe("InputGenerator", func() {
 		})
 	})
 
+	Context("when custom network cidrs are provided", func() {
+		It("returns the vpc and bosh subnet cidrs as terraform variables", func() {
+			inputs, err := inputGenerator.Generate(storage.State{
+				IAAS:  "aws",
+				EnvID: "some-env-id",
+				AWS: storage.AWS{
+					Region: "some-region",
+				},
+				Network: storage.Network{
+					CIDR:       "172.16.0.0/16",
+					SubnetCIDR: "172.16.0.0/24",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inputs["vpc_cidr"]).To(Equal("172.16.0.0/16"))
+			Expect(inputs["bosh_subnet_cidr"]).To(Equal("172.16.0.0/24"))
+		})
+	})
+
+	Context("when the environment is in an existing vpc", func() {
+		It("returns the vpc and subnet ids instead of the network inputs", func() {
+			inputs, err := inputGenerator.Generate(storage.State{
+				IAAS:  "aws",
+				EnvID: "some-env-id",
+				AWS: storage.AWS{
+					AccessKeyID:       "some-access-key-id",
+					SecretAccessKey:   "some-secret-access-key",
+					Region:            "some-region",
+					ExistingVPCID:     "vpc-123",
+					ExistingSubnetIDs: []string{"subnet-1", "subnet-2"},
+				},
+				KeyPair: storage.KeyPair{
+					Name: "some-key-pair-name",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(availabilityZoneRetriever.RetrieveCall.Receives.Region).To(BeEmpty())
+
+			Expect(inputs).To(Equal(map[string]string{
+				"env_id":              "some-env-id",
+				"access_key":          "some-access-key-id",
+				"secret_key":          "some-secret-access-key",
+				"region":              "some-region",
+				"existing_vpc_id":     "vpc-123",
+				"existing_subnet_ids": `["subnet-1","subnet-2"]`,
+			}))
+		})
+	})
+
+	Context("when availability zones are selected", func() {
+		It("limits the availability zones to the selected ones in the order of the region", func() {
+			inputs, err := inputGenerator.Generate(storage.State{
+				AWS: storage.AWS{
+					Region: "some-region",
+					AZs:    []string{"z3", "z1"},
+				},
+				Stack: storage.Stack{
+					BOSHAZ: "z1",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inputs["availability_zones"]).To(Equal(`["z1","z3"]`))
+			Expect(inputs["bosh_availability_zone"]).To(Equal("z1"))
+		})
+
+		It("places the director in the first selected availability zone of the region", func() {
+			inputs, err := inputGenerator.Generate(storage.State{
+				AWS: storage.AWS{
+					Region: "some-region",
+					AZs:    []string{"z3", "z2"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inputs["bosh_availability_zone"]).To(Equal("z2"))
+		})
+
+		It("returns an error when a selected zone is not in the region", func() {
+			_, err := inputGenerator.Generate(storage.State{
+				AWS: storage.AWS{
+					Region: "some-region",
+					AZs:    []string{"z1", "z4"},
+				},
+			})
+			Expect(err).To(MatchError(`availability zone "z4" is not in region some-region, choose from: z1, z2, z3`))
+		})
+
+		It("returns an error when the bosh availability zone is not selected", func() {
+			_, err := inputGenerator.Generate(storage.State{
+				AWS: storage.AWS{
+					Region: "some-region",
+					AZs:    []string{"z1"},
+				},
+				Stack: storage.Stack{
+					BOSHAZ: "z2",
+				},
+			})
+			Expect(err).To(MatchError(`the bosh availability zone "z2" must be one of --azs`))
+		})
+	})
+
+	Context("when the director has an external database", func() {
+		It("returns the database password", func() {
+			state := storage.State{
+				EnvID:      "some-env-id",
+				DirectorDB: storage.DirectorDB{Enabled: true, Password: "some-db-password"},
+			}
+
+			inputs, err := inputGenerator.Generate(state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inputs["director_db_password"]).To(Equal("some-db-password"))
+
+			state.AWS.ExistingVPCID = "vpc-123"
+			inputs, err = inputGenerator.Generate(state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inputs["director_db_password"]).To(Equal("some-db-password"))
+		})
+	})
+
+	Context("when a role is assumed", func() {
+		It("returns the session token of the temporary credentials", func() {
+			state := storage.State{
+				EnvID: "some-env-id",
+				AWS: storage.AWS{
+					AccessKeyID:     "some-temporary-access-key-id",
+					SecretAccessKey: "some-temporary-secret-access-key",
+					SessionToken:    "some-session-token",
+					AssumeRoleARN:   "arn:aws:iam::123456789012:role/bbl",
+				},
+			}
+
+			inputs, err := inputGenerator.Generate(state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inputs["access_key"]).To(Equal("some-temporary-access-key-id"))
+			Expect(inputs["session_token"]).To(Equal("some-session-token"))
+
+			state.AWS.ExistingVPCID = "vpc-123"
+			inputs, err = inputGenerator.Generate(state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inputs["session_token"]).To(Equal("some-session-token"))
+		})
+	})
+
 	Context("when no lbs exist", func() {
 		It("receives BBL state and returns a map of terraform variables", func() {
 			inputs, err := inputGenerator.Generate(storage.State{
@@ -226,6 +372,23 @@ var _ = Describe("InputGenerator", func() {
 				"ssl_certificate_name_prefix": "some-env-id",
 			}))
 		})
+
+		Context("when a previous certificate is kept after a rotation", func() {
+			It("returns the previous certificate inputs", func() {
+				state.LB.Previous = storage.LBCertificate{
+					Cert:  "some-old-cert",
+					Key:   "some-old-key",
+					Chain: "some-old-chain",
+				}
+
+				inputs, err := inputGenerator.Generate(state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(inputs).To(HaveKeyWithValue("previous_ssl_certificate", "some-old-cert"))
+				Expect(inputs).To(HaveKeyWithValue("previous_ssl_certificate_private_key", "some-old-key"))
+				Expect(inputs).To(HaveKeyWithValue("previous_ssl_certificate_chain", "some-old-chain"))
+			})
+		})
 	})
 
 	Context("failure cases", func() {
@@ -29,5 +29,13 @@ func (g OutputGenerator) Generate(tfState string) (map[string]interface{}, error
 		tfOutputs["env_dns_zone_name_servers"] = servers
 	}
 
+	if val, ok := tfOutputs["cf_tcp_lb_target_groups"]; ok {
+		targetGroups := []string{}
+		for _, targetGroup := range val.([]interface{}) {
+			targetGroups = append(targetGroups, targetGroup.(string))
+		}
+		tfOutputs["cf_tcp_lb_target_groups"] = targetGroups
+	}
+
 	return tfOutputs, nil
 }
@@ -45,6 +45,18 @@ var _ = Describe("OutputGenerator", func() {
 		})
 	})
 
+	Context("when network load balancers are provided", func() {
+		It("formats the tcp target groups", func() {
+			executor.OutputsCall.Returns.Outputs = map[string]interface{}{
+				"cf_tcp_lb_target_groups": []interface{}{"target-group-1", "target-group-2"},
+			}
+
+			outputs, err := outputGenerator.Generate("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outputs).To(HaveKeyWithValue("cf_tcp_lb_target_groups", []string{"target-group-1", "target-group-2"}))
+		})
+	})
+
 	Context("when executor outputs returns an error", func() {
 		It("returns an empty map and the error", func() {
 			executor.OutputsCall.Returns.Error = errors.New("executor outputs failed")
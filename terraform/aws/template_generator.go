@@ -3,6 +3,7 @@ package aws
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"text/template"
 
@@ -41,6 +42,9 @@ type TemplateData struct {
 	SSLCertificateNameProperty     string
 	IgnoreSSLCertificateProperties string
 	AWSNATAMIs                     map[string]string
+	VPCID                          string
+	PreviousSSLCertificateResource string
+	DirectorDBDescription          string
 }
 
 func NewTemplateGenerator() TemplateGenerator {
@@ -49,18 +53,63 @@ func NewTemplateGenerator() TemplateGenerator {
 
 func (tg TemplateGenerator) Generate(state storage.State) string {
 	t := BaseTemplate
+	vpcID := "${aws_vpc.vpc.id}"
+	if state.AWS.ExistingVPCID != "" {
+		t = ExistingVPCTemplate
+		vpcID = "${data.aws_vpc.vpc.id}"
+	}
+
+	if state.AWS.AssumeRoleARN != "" {
+		t = strings.Replace(t, ProviderTemplate, AssumedRoleProviderTemplate, 1)
+	}
+
+	if state.AWS.NAT == "gateway" {
+		t = strings.Replace(t, NATTemplate, NATGatewayTemplate, 1)
+		t = strings.Replace(t, InternalRouteTableTemplate, NATGatewayRouteTablesTemplate, 1)
+	}
 
 	switch state.LB.Type {
 	case "concourse":
 		t = strings.Join([]string{t, LBSubnetTemplate, ConcourseLBTemplate, SSLCertificateTemplate}, "\n")
 	case "cf":
-		t = strings.Join([]string{t, LBSubnetTemplate, CFLBTemplate, SSLCertificateTemplate}, "\n")
+		lbTemplate := CFLBTemplate
+		dnsTemplate := CFDNSTemplate
+		if state.LB.Flavor == "nlb" {
+			lbTemplate = CFNLBTemplate
+			dnsTemplate = strings.Replace(CFDNSTemplate, "${aws_elb.", "${aws_lb.", -1)
+		}
+
+		t = strings.Join([]string{t, LBSubnetTemplate, lbTemplate, SSLCertificateTemplate}, "\n")
 
 		if state.LB.Domain != "" {
-			t = strings.Join([]string{t, CFDNSTemplate}, "\n")
+			t = strings.Join([]string{t, dnsTemplate}, "\n")
+
+			if len(state.LB.ACME.Challenges) > 0 {
+				t = strings.Join([]string{t, tg.GenerateACMEChallengeRecords(state.LB.ACME.Challenges)}, "\n")
+			}
 		}
 	}
 
+	previousCertResource := "lb_cert_alt"
+	if state.LB.CertSlot == "alt" {
+		previousCertResource = "lb_cert"
+		t = strings.Replace(t, `"aws_iam_server_certificate" "lb_cert"`, `"aws_iam_server_certificate" "lb_cert_alt"`, 1)
+		t = strings.Replace(t, "${aws_iam_server_certificate.lb_cert.", "${aws_iam_server_certificate.lb_cert_alt.", -1)
+	}
+
+	if state.LB.Type != "" && state.LB.Previous.Cert != "" {
+		t = strings.Join([]string{t, PreviousSSLCertificateTemplate}, "\n")
+	}
+
+	if state.DirectorDB.Enabled {
+		dbTemplate := DirectorDBTemplate
+		if state.AWS.ExistingVPCID != "" {
+			dbTemplate = strings.Replace(dbTemplate, "${aws_subnet.internal_subnets.", "${data.aws_subnet.internal_subnets.", -1)
+		}
+
+		t = strings.Join([]string{t, dbTemplate}, "\n")
+	}
+
 	var ami map[string]string
 
 	err := json.Unmarshal([]byte(AMIs), &ami)
@@ -103,6 +152,10 @@ func (tg TemplateGenerator) Generate(state storage.State) string {
 		}
 	}
 
+	templateData.VPCID = vpcID
+	templateData.PreviousSSLCertificateResource = previousCertResource
+	templateData.DirectorDBDescription = "BOSH Director DB"
+
 	if state.LB.Cert == "" || state.LB.Key == "" {
 		templateData.IgnoreSSLCertificateProperties = `ignore_changes = ["certificate_body", "certificate_chain", "private_key"]`
 	}
@@ -120,5 +173,90 @@ func (tg TemplateGenerator) Generate(state storage.State) string {
 		panic(err)
 	}
 
+	if len(state.Tags) > 0 {
+		return addTags(finalTemplate.String(), state.Tags)
+	}
+
 	return finalTemplate.String()
 }
+
+// taggedResources are the resources of the templates that take tags.
+var taggedResources = map[string]bool{
+	"aws_vpc":                  true,
+	"aws_subnet":               true,
+	"aws_internet_gateway":     true,
+	"aws_route_table":          true,
+	"aws_security_group":       true,
+	"aws_eip":                  true,
+	"aws_instance":             true,
+	"aws_nat_gateway":          true,
+	"aws_elb":                  true,
+	"aws_lb":                   true,
+	"aws_lb_target_group":      true,
+	"aws_db_instance":          true,
+	"aws_db_subnet_group":      true,
+	"aws_route53_zone":         true,
+	"aws_iam_role":             true,
+	"aws_cloudwatch_log_group": true,
+}
+
+// addTags adds the tags to the tags of every resource that takes them, and
+// gives the resources without tags of their own a tags block.
+func addTags(template string, tags []storage.Tag) string {
+	var tagLines []string
+	for _, tag := range tags {
+		tagLines = append(tagLines, fmt.Sprintf("    %q = %q", tag.Key, strings.Replace(tag.Value, "${", "$${", -1)))
+	}
+
+	var lines []string
+	var inTaggedResource, tagged bool
+	for _, line := range strings.Split(template, "\n") {
+		switch {
+		case strings.HasPrefix(line, "resource "):
+			fields := strings.Fields(line)
+			inTaggedResource = len(fields) > 1 && taggedResources[strings.Trim(fields[1], `"`)]
+			tagged = false
+		case inTaggedResource && line == "  tags {":
+			lines = append(append(lines, line), tagLines...)
+			tagged = true
+			continue
+		case inTaggedResource && line == "}":
+			if !tagged {
+				lines = append(append(append(lines, "", "  tags {"), tagLines...), "  }")
+			}
+			inTaggedResource = false
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GenerateACMEChallengeRecords returns a TXT record in the environment's hosted
+// zone for each name that has a pending ACME DNS-01 challenge.
+func (tg TemplateGenerator) GenerateACMEChallengeRecords(challenges []storage.ACMEChallenge) string {
+	var names []string
+	values := map[string][]string{}
+	for _, challenge := range challenges {
+		if _, ok := values[challenge.Name]; !ok {
+			names = append(names, challenge.Name)
+		}
+		values[challenge.Name] = append(values[challenge.Name], challenge.Value)
+	}
+
+	var records []string
+	for i, name := range names {
+		records = append(records, fmt.Sprintf(`resource "aws_route53_record" "acme_challenge_%d" {
+  zone_id = "${aws_route53_zone.env_dns_zone.id}"
+  name    = "%s"
+  type    = "TXT"
+  ttl     = 60
+
+  records = ["%s"]
+}
+`, i, name, strings.Join(values[name], `", "`)))
+	}
+
+	return strings.Join(records, "\n")
+}
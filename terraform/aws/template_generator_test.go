@@ -2,6 +2,7 @@ package aws_test
 
 import (
 	"io/ioutil"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform/aws"
@@ -41,6 +42,239 @@ var _ = Describe("TemplateGenerator", func() {
 			Entry("when a cf lb type is provided with a system domain", "fixtures/template_cf_lb_with_domain.tf", "cf", "some-domain"),
 		)
 
+		Context("when the environment is in an existing vpc", func() {
+			var template string
+
+			BeforeEach(func() {
+				template = templateGenerator.Generate(storage.State{
+					AWS: storage.AWS{
+						ExistingVPCID:     "vpc-123",
+						ExistingSubnetIDs: []string{"subnet-1"},
+					},
+				})
+			})
+
+			It("looks up the vpc and subnets instead of creating them", func() {
+				Expect(template).To(ContainSubstring(`data "aws_vpc" "vpc"`))
+				Expect(template).To(ContainSubstring(`data "aws_subnet" "bosh_subnet"`))
+				Expect(template).To(ContainSubstring(`data "aws_subnet" "internal_subnets"`))
+				Expect(template).To(ContainSubstring(`output "bosh_subnet_cidr"`))
+
+				Expect(template).NotTo(ContainSubstring(`resource "aws_vpc"`))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_subnet"`))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_internet_gateway"`))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_instance" "nat"`))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_default_security_group"`))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_flow_log"`))
+			})
+
+			It("creates the security groups in the existing vpc", func() {
+				Expect(template).To(ContainSubstring(`resource "aws_security_group" "internal_security_group"`))
+				Expect(template).To(ContainSubstring(`resource "aws_security_group" "bosh_security_group"`))
+				Expect(template).NotTo(ContainSubstring("${aws_vpc.vpc.id}"))
+				Expect(template).To(ContainSubstring(`vpc_id      = "${data.aws_vpc.vpc.id}"`))
+			})
+		})
+
+		Context("when tags are provided", func() {
+			var template string
+
+			BeforeEach(func() {
+				template = templateGenerator.Generate(storage.State{
+					Tags: []storage.Tag{
+						{Key: "cost-center", Value: "1234"},
+						{Key: "owner", Value: "${team}"},
+					},
+					LB: storage.LB{
+						Type: "cf",
+					},
+				})
+			})
+
+			It("adds them to the tags of every resource", func() {
+				Expect(template).To(ContainSubstring(`  tags {
+    "cost-center" = "1234"
+    "owner" = "$${team}"
+    Name = "${var.env_id}-vpc"
+  }`))
+				Expect(strings.Count(template, `"cost-center" = "1234"`)).To(Equal(strings.Count(template, "  tags {")))
+			})
+
+			It("adds a tags block to the resources without tags", func() {
+				Expect(template).To(ContainSubstring(`resource "aws_route_table" "bosh_route_table" {
+  vpc_id = "${aws_vpc.vpc.id}"
+
+  tags {
+    "cost-center" = "1234"
+    "owner" = "$${team}"
+  }
+}`))
+			})
+
+			It("does not tag the resources that take no tags", func() {
+				Expect(template).To(ContainSubstring(`resource "aws_route" "bosh_route_table" {
+  destination_cidr_block = "0.0.0.0/0"
+  gateway_id = "${aws_internet_gateway.ig.id}"
+  route_table_id = "${aws_route_table.bosh_route_table.id}"
+}`))
+			})
+		})
+
+		Context("when the internal subnets use nat gateways", func() {
+			var template string
+
+			BeforeEach(func() {
+				template = templateGenerator.Generate(storage.State{
+					AWS: storage.AWS{
+						NAT: "gateway",
+					},
+				})
+			})
+
+			It("creates a nat gateway in a public subnet of every zone instead of the nat instance", func() {
+				Expect(template).To(ContainSubstring(`resource "aws_subnet" "nat_subnets"`))
+				Expect(template).To(ContainSubstring(`resource "aws_nat_gateway" "nat"`))
+				Expect(template).To(ContainSubstring(`output "nat_eips"`))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_instance" "nat"`))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_security_group" "nat_security_group"`))
+				Expect(template).NotTo(ContainSubstring(`output "nat_eip"`))
+			})
+
+			It("routes the internal subnet of every zone through the nat gateway of the zone", func() {
+				Expect(template).To(ContainSubstring(`resource "aws_route_table" "internal_route_tables"`))
+				Expect(template).To(ContainSubstring(`nat_gateway_id         = "${element(aws_nat_gateway.nat.*.id, count.index)}"`))
+				Expect(template).To(ContainSubstring(`route_table_id = "${element(aws_route_table.internal_route_tables.*.id, count.index)}"`))
+				Expect(template).NotTo(ContainSubstring("aws_instance.nat"))
+				Expect(template).NotTo(ContainSubstring(`resource "aws_route_table" "internal_route_table"`))
+			})
+		})
+
+		Context("when a cf lb type is provided with the nlb flavor", func() {
+			var template string
+
+			BeforeEach(func() {
+				template = templateGenerator.Generate(storage.State{
+					LB: storage.LB{
+						Type:   "cf",
+						Domain: "some-domain",
+						Flavor: "nlb",
+					},
+				})
+			})
+
+			It("creates network load balancers instead of classic load balancers", func() {
+				Expect(template).To(ContainSubstring(`resource "aws_lb" "cf_router_lb"`))
+				Expect(template).To(ContainSubstring(`resource "aws_lb" "cf_ssh_lb"`))
+				Expect(template).To(ContainSubstring(`resource "aws_lb" "cf_tcp_lb"`))
+				Expect(template).To(ContainSubstring(`load_balancer_type               = "network"`))
+				Expect(template).To(ContainSubstring(`output "cf_router_lb_target_group"`))
+				Expect(template).To(ContainSubstring(`output "cf_ssh_lb_target_group"`))
+				Expect(template).To(ContainSubstring(`output "cf_tcp_lb_target_groups"`))
+
+				Expect(template).NotTo(ContainSubstring(`resource "aws_elb"`))
+			})
+
+			It("points the dns records at the network load balancers", func() {
+				Expect(template).To(ContainSubstring(`zone_id                = "${aws_lb.cf_router_lb.zone_id}"`))
+				Expect(template).NotTo(ContainSubstring("${aws_elb."))
+			})
+		})
+
+		Context("when acme challenges are pending for a cf lb with a domain", func() {
+			It("appends a TXT record for each challenge name", func() {
+				cfLBDomainTemplate, err := ioutil.ReadFile("fixtures/template_cf_lb_with_domain.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				challengeTemplate, err := ioutil.ReadFile("fixtures/acme_challenge_records.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					LB: storage.LB{
+						Type:   "cf",
+						Domain: "some-domain",
+						ACME: storage.ACME{
+							Challenges: []storage.ACMEChallenge{
+								{Name: "_acme-challenge.some-domain", Value: "some-value"},
+								{Name: "_acme-challenge.ws.some-domain", Value: "some-ws-value"},
+								{Name: "_acme-challenge.some-domain", Value: "some-other-value"},
+							},
+						},
+					},
+				})
+
+				Expect(template).To(Equal(strings.Join([]string{string(cfLBDomainTemplate), string(challengeTemplate)}, "\n")))
+			})
+		})
+
+		Context("when the lb certificate is being rotated", func() {
+			It("keeps the previous certificate next to the current one", func() {
+				template := templateGenerator.Generate(storage.State{
+					LB: storage.LB{
+						Type:     "cf",
+						Previous: storage.LBCertificate{Cert: "some-old-cert", Key: "some-old-key"},
+					},
+				})
+
+				Expect(template).To(ContainSubstring(`resource "aws_iam_server_certificate" "lb_cert" {`))
+				Expect(template).To(ContainSubstring(`resource "aws_iam_server_certificate" "lb_cert_alt" {`))
+				Expect(template).To(ContainSubstring(`certificate_body  = "${var.previous_ssl_certificate}"`))
+				Expect(template).To(ContainSubstring(`ssl_certificate_id = "${aws_iam_server_certificate.lb_cert.arn}"`))
+			})
+
+			It("points the listeners at the alternate certificate when it holds the current certificate", func() {
+				template := templateGenerator.Generate(storage.State{
+					LB: storage.LB{
+						Type:     "cf",
+						CertSlot: "alt",
+						Previous: storage.LBCertificate{Cert: "some-old-cert", Key: "some-old-key"},
+					},
+				})
+
+				Expect(template).To(ContainSubstring(`ssl_certificate_id = "${aws_iam_server_certificate.lb_cert_alt.arn}"`))
+				Expect(template).NotTo(ContainSubstring("${aws_iam_server_certificate.lb_cert.arn}"))
+				Expect(template).To(MatchRegexp(`resource "aws_iam_server_certificate" "lb_cert" {\s+name_prefix\s+= "\$\{var.ssl_certificate_name_prefix\}"\s+certificate_body  = "\$\{var.previous_ssl_certificate\}"`))
+			})
+		})
+
+		Context("when a role is assumed", func() {
+			It("passes the session token to the provider", func() {
+				template := templateGenerator.Generate(storage.State{
+					AWS: storage.AWS{AssumeRoleARN: "arn:aws:iam::123456789012:role/bbl"},
+				})
+
+				Expect(template).To(ContainSubstring(`variable "session_token" {`))
+				Expect(template).To(ContainSubstring(`token      = "${var.session_token}"`))
+				Expect(strings.Count(template, `provider "aws" {`)).To(Equal(1))
+			})
+		})
+
+		Context("when the director has an external database", func() {
+			It("appends an rds instance the director can reach", func() {
+				template := templateGenerator.Generate(storage.State{
+					DirectorDB: storage.DirectorDB{Enabled: true},
+				})
+
+				Expect(template).To(ContainSubstring(`resource "aws_db_instance" "director_db" {`))
+				Expect(template).To(ContainSubstring(`source_security_group_id = "${aws_security_group.bosh_security_group.id}"`))
+				Expect(template).To(ContainSubstring(`subnet_ids = ["${aws_subnet.internal_subnets.*.id}"]`))
+				Expect(template).To(ContainSubstring(`description = "BOSH Director DB"`))
+				Expect(template).To(ContainSubstring(`output "director_db_host" {`))
+			})
+
+			It("places the rds instance in the existing subnets", func() {
+				template := templateGenerator.Generate(storage.State{
+					AWS: storage.AWS{
+						ExistingVPCID:     "vpc-123",
+						ExistingSubnetIDs: []string{"subnet-1", "subnet-2"},
+					},
+					DirectorDB: storage.DirectorDB{Enabled: true},
+				})
+
+				Expect(template).To(ContainSubstring(`subnet_ids = ["${data.aws_subnet.internal_subnets.*.id}"]`))
+				Expect(template).To(ContainSubstring(`vpc_id      = "${data.aws_vpc.vpc.id}"`))
+			})
+		})
+
 		Context("when migrated from CloudFormation", func() {
 			It("changes the security group descriptions", func() {
 				template := templateGenerator.Generate(storage.State{
@@ -0,0 +1,210 @@
+package azure
+
+const VarsTemplate = `variable "env_id" {
+	type = "string"
+}
+
+variable "simple_env_id" {
+	type = "string"
+}
+
+variable "subscription_id" {
+	type = "string"
+}
+
+variable "tenant_id" {
+	type = "string"
+}
+
+variable "client_id" {
+	type = "string"
+}
+
+variable "client_secret" {
+	type = "string"
+}
+
+variable "region" {
+	type = "string"
+}
+
+`
+
+const ProviderTemplate = `provider "azurerm" {
+	subscription_id = "${var.subscription_id}"
+	tenant_id       = "${var.tenant_id}"
+	client_id       = "${var.client_id}"
+	client_secret   = "${var.client_secret}"
+}
+`
+
+// CLIProviderTemplate leaves out the service principal, so the provider uses
+// the account the az cli is logged in with.
+const CLIProviderTemplate = `provider "azurerm" {
+	subscription_id = "${var.subscription_id}"
+	tenant_id       = "${var.tenant_id}"
+}
+`
+
+// ManagedIdentityProviderTemplate authenticates as the managed identity of the
+// vm terraform runs on, a user-assigned one when the client id is set.
+const ManagedIdentityProviderTemplate = `provider "azurerm" {
+	subscription_id = "${var.subscription_id}"
+	tenant_id       = "${var.tenant_id}"
+	client_id       = "${var.client_id}"
+	use_msi         = true
+}
+`
+
+const BOSHDirectorTemplate = `output "external_ip" {
+    value = "${azurerm_public_ip.bosh.ip_address}"
+}
+
+output "director_address" {
+	value = "https://${azurerm_public_ip.bosh.ip_address}:25555"
+}
+
+output "vnet_name" {
+    value = "${azurerm_virtual_network.bosh.name}"
+}
+
+output "subnet_name" {
+    value = "${azurerm_subnet.bosh.name}"
+}
+
+output "resource_group_name" {
+    value = "${azurerm_resource_group.bosh.name}"
+}
+
+output "storage_account_name" {
+    value = "${azurerm_storage_account.bosh.name}"
+}
+
+output "default_security_group" {
+    value = "${azurerm_network_security_group.bosh.name}"
+}
+
+variable "network_cidr" {
+  type    = "string"
+  default = "10.0.0.0/16"
+}
+
+resource "azurerm_resource_group" "bosh" {
+  name     = "${var.env_id}-bosh"
+  location = "${var.region}"
+
+  tags {
+    environment = "${var.env_id}"
+  }
+}
+
+resource "azurerm_public_ip" "bosh" {
+  name                         = "${var.env_id}-bosh"
+  location                     = "${var.region}"
+  resource_group_name          = "${azurerm_resource_group.bosh.name}"
+  public_ip_address_allocation = "static"
+
+  tags {
+    environment = "${var.env_id}"
+  }
+}
+
+resource "azurerm_virtual_network" "bosh" {
+  name                = "${var.env_id}-bosh-vn"
+  address_space       = ["${var.network_cidr}"]
+  location            = "${var.region}"
+  resource_group_name = "${azurerm_resource_group.bosh.name}"
+}
+
+resource "azurerm_subnet" "bosh" {
+  name                      = "${var.env_id}-bosh-sn"
+  address_prefix            = "${var.network_cidr}"
+  resource_group_name       = "${azurerm_resource_group.bosh.name}"
+  virtual_network_name      = "${azurerm_virtual_network.bosh.name}"
+  network_security_group_id = "${azurerm_network_security_group.bosh.id}"
+}
+
+resource "random_string" "account" {
+  length  = 4
+  upper   = false
+  special = false
+}
+
+resource "azurerm_storage_account" "bosh" {
+  name                     = "${var.simple_env_id}${random_string.account.result}"
+  resource_group_name      = "${azurerm_resource_group.bosh.name}"
+  location                 = "${var.region}"
+  account_tier             = "Standard"
+  account_replication_type = "GRS"
+
+  tags {
+    environment = "${var.env_id}"
+  }
+}
+
+resource "azurerm_storage_container" "bosh" {
+  name                  = "bosh"
+  resource_group_name   = "${azurerm_resource_group.bosh.name}"
+  storage_account_name  = "${azurerm_storage_account.bosh.name}"
+  container_access_type = "private"
+}
+
+resource "azurerm_storage_container" "stemcell" {
+  name                  = "stemcell"
+  resource_group_name   = "${azurerm_resource_group.bosh.name}"
+  storage_account_name  = "${azurerm_storage_account.bosh.name}"
+  container_access_type = "blob"
+}
+
+resource "azurerm_network_security_group" "bosh" {
+  name                = "${var.env_id}-bosh"
+  location            = "${var.region}"
+  resource_group_name = "${azurerm_resource_group.bosh.name}"
+
+  tags {
+    environment = "${var.env_id}"
+  }
+}
+
+resource "azurerm_network_security_rule" "ssh" {
+  name                        = "${var.env_id}-ssh"
+  priority                    = 200
+  direction                   = "Inbound"
+  access                      = "Allow"
+  protocol                    = "Tcp"
+  source_port_range           = "*"
+  destination_port_range      = "22"
+  source_address_prefix       = "*"
+  destination_address_prefix  = "*"
+  resource_group_name         = "${azurerm_resource_group.bosh.name}"
+  network_security_group_name = "${azurerm_network_security_group.bosh.name}"
+}
+
+resource "azurerm_network_security_rule" "bosh-agent" {
+  name                        = "${var.env_id}-bosh-agent"
+  priority                    = 201
+  direction                   = "Inbound"
+  access                      = "Allow"
+  protocol                    = "Tcp"
+  source_port_range           = "*"
+  destination_port_range      = "6868"
+  source_address_prefix       = "*"
+  destination_address_prefix  = "*"
+  resource_group_name         = "${azurerm_resource_group.bosh.name}"
+  network_security_group_name = "${azurerm_network_security_group.bosh.name}"
+}
+
+resource "azurerm_network_security_rule" "bosh-director" {
+  name                        = "${var.env_id}-bosh-director"
+  priority                    = 202
+  direction                   = "Inbound"
+  access                      = "Allow"
+  protocol                    = "Tcp"
+  source_port_range           = "*"
+  destination_port_range      = "25555"
+  source_address_prefix       = "*"
+  destination_address_prefix  = "*"
+  resource_group_name         = "${azurerm_resource_group.bosh.name}"
+  network_security_group_name = "${azurerm_network_security_group.bosh.name}"
+}
+`
@@ -0,0 +1,47 @@
+package azure
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// storage account names are globally unique, at most 24 lowercase letters
+// and digits. terraform appends 4 random characters to the simple env id.
+const simpleEnvIDMaxLength = 20
+
+var nonAlphanumeric = regexp.MustCompile("[^a-z0-9]")
+
+type InputGenerator struct{}
+
+func NewInputGenerator() InputGenerator {
+	return InputGenerator{}
+}
+
+func (i InputGenerator) Generate(state storage.State) (map[string]string, error) {
+	input := map[string]string{
+		"env_id":          state.EnvID,
+		"simple_env_id":   simpleEnvID(state.EnvID),
+		"subscription_id": state.Azure.SubscriptionID,
+		"tenant_id":       state.Azure.TenantID,
+		"client_id":       state.Azure.ClientID,
+		"client_secret":   state.Azure.ClientSecret,
+		"region":          state.Azure.Region,
+	}
+
+	if state.Network.CIDR != "" {
+		input["network_cidr"] = state.Network.CIDR
+	}
+
+	return input, nil
+}
+
+func simpleEnvID(envID string) string {
+	simple := nonAlphanumeric.ReplaceAllString(strings.ToLower(envID), "")
+	if len(simple) > simpleEnvIDMaxLength {
+		simple = simple[:simpleEnvIDMaxLength]
+	}
+
+	return simple
+}
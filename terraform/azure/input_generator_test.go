@@ -0,0 +1,71 @@
+package azure_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform/azure"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InputGenerator", func() {
+	var (
+		inputGenerator azure.InputGenerator
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		state = storage.State{
+			IAAS:  "azure",
+			EnvID: "some-env-id",
+			Azure: storage.Azure{
+				SubscriptionID: "some-subscription-id",
+				TenantID:       "some-tenant-id",
+				ClientID:       "some-client-id",
+				ClientSecret:   "some-client-secret",
+				Region:         "some-region",
+			},
+			TFState: "some-tf-state",
+		}
+
+		inputGenerator = azure.NewInputGenerator()
+	})
+
+	It("receives BBL state and returns a map of terraform variables", func() {
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(inputs).To(Equal(map[string]string{
+			"env_id":          "some-env-id",
+			"simple_env_id":   "someenvid",
+			"subscription_id": "some-subscription-id",
+			"tenant_id":       "some-tenant-id",
+			"client_id":       "some-client-id",
+			"client_secret":   "some-client-secret",
+			"region":          "some-region",
+		}))
+	})
+
+	Context("when the env id is too long for a storage account name", func() {
+		It("truncates the simple env id to 20 characters", func() {
+			state.EnvID = "Some-Really-Long-Environment-Name"
+
+			inputs, err := inputGenerator.Generate(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inputs["simple_env_id"]).To(Equal("somereallylongenviro"))
+		})
+	})
+
+	Context("when a network cidr is in the state", func() {
+		It("passes it to terraform", func() {
+			state.Network.CIDR = "172.16.0.0/16"
+
+			inputs, err := inputGenerator.Generate(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inputs).To(HaveKeyWithValue("network_cidr", "172.16.0.0/16"))
+		})
+	})
+})
@@ -0,0 +1,25 @@
+package azure
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type TemplateGenerator struct{}
+
+func NewTemplateGenerator() TemplateGenerator {
+	return TemplateGenerator{}
+}
+
+func (t TemplateGenerator) Generate(state storage.State) string {
+	provider := ProviderTemplate
+	switch state.Azure.AuthMethod {
+	case "cli":
+		provider = CLIProviderTemplate
+	case "managed-identity":
+		provider = ManagedIdentityProviderTemplate
+	}
+
+	return strings.Join([]string{VarsTemplate + provider, BOSHDirectorTemplate}, "\n")
+}
@@ -0,0 +1,51 @@
+package azure_test
+
+import (
+	"io/ioutil"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform/azure"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TemplateGenerator", func() {
+	var (
+		templateGenerator azure.TemplateGenerator
+	)
+
+	BeforeEach(func() {
+		templateGenerator = azure.NewTemplateGenerator()
+	})
+
+	Describe("Generate", func() {
+		It("generates a terraform template for azure", func() {
+			expectedTemplate, err := ioutil.ReadFile("fixtures/azure_template.tf")
+			Expect(err).NotTo(HaveOccurred())
+
+			template := templateGenerator.Generate(storage.State{
+				Azure: storage.Azure{
+					Region: "some-region",
+				},
+			})
+			Expect(template).To(Equal(string(expectedTemplate)))
+		})
+
+		It("uses the account of the az cli", func() {
+			template := templateGenerator.Generate(storage.State{
+				Azure: storage.Azure{AuthMethod: "cli"},
+			})
+			Expect(template).To(ContainSubstring(azure.CLIProviderTemplate))
+			Expect(template).NotTo(ContainSubstring("client_secret   ="))
+		})
+
+		It("uses the managed identity of the vm", func() {
+			template := templateGenerator.Generate(storage.State{
+				Azure: storage.Azure{AuthMethod: "managed-identity"},
+			})
+			Expect(template).To(ContainSubstring("use_msi         = true"))
+			Expect(template).NotTo(ContainSubstring("client_secret   ="))
+		})
+	})
+})
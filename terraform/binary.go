@@ -0,0 +1,152 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	// PinnedVersion is the terraform bbl downloads and runs, the version its
+	// templates are tested against.
+	PinnedVersion = "0.10.8"
+	ReleasesURL   = "https://releases.hashicorp.com/terraform"
+)
+
+type Binary struct {
+	cacheDir    string
+	releasesURL string
+	httpClient  *http.Client
+	logger      logger
+}
+
+func NewBinary(cacheDir, releasesURL string, httpClient *http.Client, logger logger) Binary {
+	return Binary{
+		cacheDir:    cacheDir,
+		releasesURL: releasesURL,
+		httpClient:  httpClient,
+		logger:      logger,
+	}
+}
+
+// Path returns the pinned terraform in the cache dir, downloading it first
+// when it is not there yet. The release archive is checked against the
+// checksum pinned in pinnedSHA256Sums before it is unpacked, so a changed
+// release or a tampered download is never run.
+func (b Binary) Path() (string, error) {
+	name := "terraform"
+	if runtime.GOOS == "windows" {
+		name = "terraform.exe"
+	}
+
+	versionDir := filepath.Join(b.cacheDir, PinnedVersion)
+	path := filepath.Join(versionDir, name)
+
+	_, err := os.Stat(path)
+	if err == nil {
+		return path, nil
+	}
+
+	archiveName := fmt.Sprintf("terraform_%s_%s_%s.zip", PinnedVersion, runtime.GOOS, runtime.GOARCH)
+
+	expectedSum, ok := pinnedSHA256Sums[archiveName]
+	if !ok {
+		return "", fmt.Errorf("bbl has no pinned checksum for %s to download it with, put a terraform %s you verified at %s", archiveName, PinnedVersion, path)
+	}
+
+	b.logger.Step("downloading terraform %s to %s", PinnedVersion, versionDir)
+
+	archive, err := b.download(archiveName)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(archive)
+	if hex.EncodeToString(sum[:]) != expectedSum {
+		return "", fmt.Errorf("checksum of %s does not match the pinned checksum: expected %s, got %s", archiveName, expectedSum, hex.EncodeToString(sum[:]))
+	}
+
+	err = os.MkdirAll(versionDir, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	err = unzipBinary(archive, name, versionDir, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to unpack %s: %s", archiveName, err)
+	}
+
+	return path, nil
+}
+
+func (b Binary) download(fileName string) ([]byte, error) {
+	url := fmt.Sprintf("%s/%s/%s", b.releasesURL, PinnedVersion, fileName)
+
+	response, err := b.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %s", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, response.Status)
+	}
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %s", url, err) //not tested
+	}
+
+	return contents, nil
+}
+
+// unzipBinary writes the named file of the archive next to path and renames
+// it into place, so an interrupted download never leaves a partial binary at
+// path.
+func unzipBinary(archive []byte, name, dir, path string) error {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+
+		contents, err := file.Open()
+		if err != nil {
+			return err //not tested
+		}
+		defer contents.Close()
+
+		tempFile, err := ioutil.TempFile(dir, name)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tempFile.Name())
+
+		_, err = io.Copy(tempFile, contents)
+		tempFile.Close()
+		if err != nil {
+			return err //not tested
+		}
+
+		err = os.Chmod(tempFile.Name(), 0755)
+		if err != nil {
+			return err //not tested
+		}
+
+		return os.Rename(tempFile.Name(), path)
+	}
+
+	return fmt.Errorf("%s is not in the archive", name)
+}
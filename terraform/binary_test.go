@@ -0,0 +1,149 @@
+package terraform_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Binary", func() {
+	var (
+		server   *httptest.Server
+		logger   *fakes.Logger
+		cacheDir string
+		requests []string
+
+		archive     []byte
+		archiveName string
+
+		binary terraform.Binary
+	)
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		buffer := bytes.NewBuffer([]byte{})
+		writer := zip.NewWriter(buffer)
+		file, err := writer.Create("terraform")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = file.Write([]byte("some-terraform"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.Close()).To(Succeed())
+		archive = buffer.Bytes()
+
+		archiveName = fmt.Sprintf("terraform_%s_%s_%s.zip", terraform.PinnedVersion, runtime.GOOS, runtime.GOARCH)
+		sum := sha256.Sum256(archive)
+		terraform.SetPinnedSHA256Sums(map[string]string{
+			fmt.Sprintf("terraform_%s_other_arch.zip", terraform.PinnedVersion): fmt.Sprintf("%064d", 0),
+			archiveName: hex.EncodeToString(sum[:]),
+		})
+
+		requests = []string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Path)
+			switch r.URL.Path {
+			case fmt.Sprintf("/%s/%s", terraform.PinnedVersion, archiveName):
+				w.Write(archive)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		logger = &fakes.Logger{}
+		binary = terraform.NewBinary(cacheDir, server.URL, http.DefaultClient, logger)
+	})
+
+	AfterEach(func() {
+		terraform.ResetPinnedSHA256Sums()
+		server.Close()
+		os.RemoveAll(cacheDir)
+	})
+
+	Describe("Path", func() {
+		It("downloads the pinned terraform into the cache dir", func() {
+			path, err := binary.Path()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path).To(Equal(filepath.Join(cacheDir, terraform.PinnedVersion, "terraform")))
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-terraform"))
+
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			Expect(logger.StepCall.Messages).To(ContainElement(fmt.Sprintf("downloading terraform %s to %s", terraform.PinnedVersion, filepath.Join(cacheDir, terraform.PinnedVersion))))
+		})
+
+		It("uses the cached terraform without downloading it again", func() {
+			_, err := binary.Path()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(HaveLen(1))
+
+			path, err := binary.Path()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path).To(Equal(filepath.Join(cacheDir, terraform.PinnedVersion, "terraform")))
+			Expect(requests).To(HaveLen(1))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error and caches nothing when the checksum does not match", func() {
+				terraform.SetPinnedSHA256Sums(map[string]string{archiveName: fmt.Sprintf("%064d", 0)})
+
+				_, err := binary.Path()
+				Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("checksum of %s does not match the pinned checksum: expected %064d", archiveName, 0))))
+
+				_, err = os.Stat(filepath.Join(cacheDir, terraform.PinnedVersion, "terraform"))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+
+			It("returns an error without downloading when there is no pinned checksum for the platform", func() {
+				terraform.SetPinnedSHA256Sums(map[string]string{})
+
+				_, err := binary.Path()
+				Expect(err).To(MatchError(fmt.Sprintf("bbl has no pinned checksum for %s to download it with, put a terraform %s you verified at %s", archiveName, terraform.PinnedVersion, filepath.Join(cacheDir, terraform.PinnedVersion, "terraform"))))
+				Expect(requests).To(BeEmpty())
+			})
+
+			It("returns an error when the download fails", func() {
+				archiveName = "missing.zip"
+				terraform.SetPinnedSHA256Sums(map[string]string{fmt.Sprintf("terraform_%s_%s_%s.zip", terraform.PinnedVersion, runtime.GOOS, runtime.GOARCH): fmt.Sprintf("%064d", 0)})
+
+				_, err := binary.Path()
+				Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+			})
+
+			It("returns an error when the archive does not hold terraform", func() {
+				buffer := bytes.NewBuffer([]byte{})
+				writer := zip.NewWriter(buffer)
+				_, err := writer.Create("README")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(writer.Close()).To(Succeed())
+				archive = buffer.Bytes()
+				sum := sha256.Sum256(archive)
+				terraform.SetPinnedSHA256Sums(map[string]string{archiveName: hex.EncodeToString(sum[:])})
+
+				_, err = binary.Path()
+				Expect(err).To(MatchError(fmt.Sprintf("failed to unpack %s: terraform is not in the archive", archiveName)))
+			})
+		})
+	})
+})
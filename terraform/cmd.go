@@ -1,24 +1,40 @@
 package terraform
 
 import (
+	"fmt"
 	"io"
 	"os/exec"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 )
 
+type terraformBinary interface {
+	Path() (string, error)
+}
+
 type Cmd struct {
 	stderr       io.Writer
 	outputBuffer io.Writer
+	binary       terraformBinary
+	timeout      helpers.Timeout
 }
 
-func NewCmd(stderr, outputBuffer io.Writer) Cmd {
+func NewCmd(stderr, outputBuffer io.Writer, binary terraformBinary, timeout helpers.Timeout) Cmd {
 	return Cmd{
 		stderr:       stderr,
 		outputBuffer: outputBuffer,
+		binary:       binary,
+		timeout:      timeout,
 	}
 }
 
 func (c Cmd) Run(stdout io.Writer, workingDirectory string, args []string, debug bool) error {
-	command := exec.Command("terraform", args...)
+	path, err := c.binary.Path()
+	if err != nil {
+		return err
+	}
+
+	command := exec.Command(path, args...)
 	command.Dir = workingDirectory
 
 	if debug {
@@ -29,5 +45,5 @@ func (c Cmd) Run(stdout io.Writer, workingDirectory string, args []string, debug
 		command.Stderr = c.outputBuffer
 	}
 
-	return command.Run()
+	return helpers.RunCommand(command, fmt.Sprintf("terraform %s", args[0]), c.timeout)
 }
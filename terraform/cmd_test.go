@@ -3,15 +3,16 @@ package terraform_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 
 	. "github.com/onsi/ginkgo"
@@ -24,6 +25,7 @@ var _ = Describe("Run", func() {
 		stdout       *bytes.Buffer
 		stderr       *bytes.Buffer
 		outputBuffer *bytes.Buffer
+		binary       *fakes.TerraformBinary
 
 		cmd terraform.Cmd
 
@@ -52,8 +54,9 @@ var _ = Describe("Run", func() {
 		stdout = bytes.NewBuffer([]byte{})
 		stderr = bytes.NewBuffer([]byte{})
 		outputBuffer = bytes.NewBuffer([]byte{})
+		binary = &fakes.TerraformBinary{}
 
-		cmd = terraform.NewCmd(stderr, outputBuffer)
+		cmd = terraform.NewCmd(stderr, outputBuffer, binary, helpers.Timeout{})
 
 		fakeTerraformBackendServer = httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
 			if getFastFailTerraform() {
@@ -80,11 +83,7 @@ var _ = Describe("Run", func() {
 			"--ldflags", fmt.Sprintf("-X main.backendURL=%s", fakeTerraformBackendServer.URL))
 		Expect(err).NotTo(HaveOccurred())
 
-		os.Setenv("PATH", strings.Join([]string{filepath.Dir(pathToTerraform), originalPath}, ":"))
-	})
-
-	AfterEach(func() {
-		os.Setenv("PATH", originalPath)
+		binary.PathCall.Returns.Path = pathToTerraform
 	})
 
 	It("runs terraform with args", func() {
@@ -120,7 +119,21 @@ var _ = Describe("Run", func() {
 		Expect(stdout).To(ContainSubstring("apply some-arg"))
 	})
 
+	It("returns a timeout error when terraform runs past the timeout", func() {
+		cmd = terraform.NewCmd(stderr, outputBuffer, binary, helpers.Timeout{Deadline: time.Now().Add(-time.Second)})
+
+		err := cmd.Run(stdout, "/tmp", []string{"apply", "some-arg"}, false)
+		Expect(err).To(MatchError("terraform apply timed out after 1ns"))
+	})
+
 	Context("failure case", func() {
+		It("returns an error when the terraform binary cannot be found", func() {
+			binary.PathCall.Returns.Error = errors.New("failed to download terraform")
+
+			err := cmd.Run(stdout, "/tmp", []string{"apply"}, false)
+			Expect(err).To(MatchError("failed to download terraform"))
+		})
+
 		BeforeEach(func() {
 			setFastFailTerraform(true)
 		})
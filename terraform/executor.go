@@ -8,9 +8,12 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
@@ -19,9 +22,25 @@ var tempDir func(dir, prefix string) (string, error) = ioutil.TempDir
 var writeFile func(file string, data []byte, perm os.FileMode) error = ioutil.WriteFile
 var readFile func(filename string) ([]byte, error) = ioutil.ReadFile
 
+// TemplateDir is where, under the state dir, the generated template is
+// written and user *_override.tf files are picked up from.
+const TemplateDir = "terraform"
+
 type Executor struct {
-	cmd   terraformCmd
-	debug bool
+	cmd       terraformCmd
+	stdout    io.Writer
+	stateDir  string
+	pluginDir string
+	debug     bool
+	outputs   *outputsCache
+}
+
+// outputsCache keeps the outputs of the last terraform state they were read
+// from, since one bbl run reads the outputs of the same state many times.
+type outputsCache struct {
+	mutex   sync.Mutex
+	tfState string
+	outputs map[string]interface{}
 }
 
 type ImportInput struct {
@@ -41,17 +60,22 @@ type terraformCmd interface {
 	Run(stdout io.Writer, workingDirectory string, args []string, debug bool) error
 }
 
-func NewExecutor(cmd terraformCmd, debug bool) Executor {
-	return Executor{cmd: cmd, debug: debug}
+func NewExecutor(cmd terraformCmd, stdout io.Writer, stateDir, pluginDir string, debug bool) Executor {
+	return Executor{cmd: cmd, stdout: stdout, stateDir: stateDir, pluginDir: pluginDir, debug: debug, outputs: &outputsCache{}}
 }
 
-func (e Executor) Apply(input map[string]string, template, prevTFState string) (string, error) {
+func (e Executor) Apply(input map[string]string, template, prevTFState string, targets []string) (string, error) {
 	tempDir, err := tempDir("", "")
 	if err != nil {
 		return "", err
 	}
 
-	err = writeFile(filepath.Join(tempDir, "template.tf"), []byte(template), os.ModePerm)
+	err = e.writeTemplate(tempDir, template)
+	if err != nil {
+		return "", err
+	}
+
+	err = e.keepTemplate(template)
 	if err != nil {
 		return "", err
 	}
@@ -63,7 +87,7 @@ func (e Executor) Apply(input map[string]string, template, prevTFState string) (
 		}
 	}
 
-	err = e.cmd.Run(os.Stdout, tempDir, []string{"init"}, e.debug)
+	err = e.init(tempDir, e.debug)
 	if err != nil {
 		return "", err
 	}
@@ -72,7 +96,10 @@ func (e Executor) Apply(input map[string]string, template, prevTFState string) (
 	for k, v := range input {
 		args = append(args, makeVar(k, v)...)
 	}
-	err = e.cmd.Run(os.Stdout, tempDir, args, e.debug)
+	for _, target := range targets {
+		args = append(args, "-target", target)
+	}
+	err = e.cmd.Run(e.stdout, tempDir, args, e.debug)
 	if err != nil {
 		return "", NewExecutorError(filepath.Join(tempDir, "terraform.tfstate"), err, e.debug)
 	}
@@ -91,7 +118,7 @@ func (e Executor) Destroy(input map[string]string, template, prevTFState string)
 		return "", err
 	}
 
-	err = writeFile(filepath.Join(tempDir, "template.tf"), []byte(template), os.ModePerm)
+	err = e.writeTemplate(tempDir, template)
 	if err != nil {
 		return "", err
 	}
@@ -103,7 +130,7 @@ func (e Executor) Destroy(input map[string]string, template, prevTFState string)
 		}
 	}
 
-	err = e.cmd.Run(os.Stdout, tempDir, []string{"init"}, e.debug)
+	err = e.init(tempDir, e.debug)
 	if err != nil {
 		return "", err
 	}
@@ -112,7 +139,7 @@ func (e Executor) Destroy(input map[string]string, template, prevTFState string)
 	for k, v := range input {
 		args = append(args, makeVar(k, v)...)
 	}
-	err = e.cmd.Run(os.Stdout, tempDir, args, e.debug)
+	err = e.cmd.Run(e.stdout, tempDir, args, e.debug)
 	if err != nil {
 		return "", NewExecutorError(filepath.Join(tempDir, "terraform.tfstate"), err, e.debug)
 	}
@@ -125,6 +152,129 @@ func (e Executor) Destroy(input map[string]string, template, prevTFState string)
 	return string(tfState), nil
 }
 
+// Plan returns the changes terraform would make to reach the template, or to
+// remove everything in prevTFState when destroy is set, without making them.
+func (e Executor) Plan(input map[string]string, template, prevTFState string, destroy bool) (string, error) {
+	tempDir, err := tempDir("", "")
+	if err != nil {
+		return "", err
+	}
+
+	err = e.writeTemplate(tempDir, template)
+	if err != nil {
+		return "", err
+	}
+
+	if prevTFState != "" {
+		err = writeFile(filepath.Join(tempDir, "terraform.tfstate"), []byte(prevTFState), os.ModePerm)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = e.init(tempDir, e.debug)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"plan", "-input=false"}
+	if destroy {
+		args = append(args, "-destroy")
+	}
+	for k, v := range input {
+		args = append(args, makeVar(k, v)...)
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err = e.cmd.Run(buffer, tempDir, args, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to plan: %s", err)
+	}
+
+	return buffer.String(), nil
+}
+
+// Refresh updates prevTFState with the real infrastructure without changing
+// it, and returns the refreshed terraform state.
+func (e Executor) Refresh(input map[string]string, template, prevTFState string) (string, error) {
+	tempDir, err := tempDir("", "")
+	if err != nil {
+		return "", err
+	}
+
+	err = e.writeTemplate(tempDir, template)
+	if err != nil {
+		return "", err
+	}
+
+	err = writeFile(filepath.Join(tempDir, "terraform.tfstate"), []byte(prevTFState), os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	err = e.init(tempDir, e.debug)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"refresh", "-input=false"}
+	for k, v := range input {
+		args = append(args, makeVar(k, v)...)
+	}
+	err = e.cmd.Run(e.stdout, tempDir, args, e.debug)
+	if err != nil {
+		return "", NewExecutorError(filepath.Join(tempDir, "terraform.tfstate"), err, e.debug)
+	}
+
+	tfState, err := readFile(filepath.Join(tempDir, "terraform.tfstate"))
+	if err != nil {
+		return "", err
+	}
+
+	return string(tfState), nil
+}
+
+// Drift plans the template against the real infrastructure of prevTFState
+// and reports whether terraform would change anything, along with the plan.
+func (e Executor) Drift(input map[string]string, template, prevTFState string) (string, bool, error) {
+	tempDir, err := tempDir("", "")
+	if err != nil {
+		return "", false, err
+	}
+
+	err = e.writeTemplate(tempDir, template)
+	if err != nil {
+		return "", false, err
+	}
+
+	err = writeFile(filepath.Join(tempDir, "terraform.tfstate"), []byte(prevTFState), os.ModePerm)
+	if err != nil {
+		return "", false, err
+	}
+
+	err = e.init(tempDir, e.debug)
+	if err != nil {
+		return "", false, err
+	}
+
+	args := []string{"plan", "-input=false", "-no-color", "-detailed-exitcode"}
+	for k, v := range input {
+		args = append(args, makeVar(k, v)...)
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err = e.cmd.Run(buffer, tempDir, args, true)
+	if err != nil {
+		// -detailed-exitcode exits with 2 when the plan has changes
+		if exitStatus(err) == 2 {
+			return buffer.String(), true, nil
+		}
+		return "", false, fmt.Errorf("failed to plan: %s", err)
+	}
+
+	return buffer.String(), false, nil
+}
+
 func (e Executor) Import(input ImportInput) (string, error) {
 	tempDir, err := tempDir("", "")
 	if err != nil {
@@ -140,10 +290,11 @@ provider "aws" {
 	region     = %q
 	access_key = %q
 	secret_key = %q
+	token      = %q
 }
 
 resource %q %q {
-}`, input.Creds.Region, input.Creds.AccessKeyID, input.Creds.SecretAccessKey, resourceType, resourceName)
+}`, input.Creds.Region, input.Creds.AccessKeyID, input.Creds.SecretAccessKey, input.Creds.SessionToken, resourceType, resourceName)
 
 	err = writeFile(filepath.Join(tempDir, "template.tf"), []byte(template), os.ModePerm)
 	if err != nil {
@@ -155,12 +306,12 @@ resource %q %q {
 		return "", err
 	}
 
-	err = e.cmd.Run(os.Stdout, tempDir, []string{"init"}, e.debug)
+	err = e.init(tempDir, e.debug)
 	if err != nil {
 		return "", err
 	}
 
-	err = e.cmd.Run(os.Stdout, tempDir, []string{"import", input.TerraformAddr, input.AWSResourceID}, e.debug)
+	err = e.cmd.Run(e.stdout, tempDir, []string{"import", input.TerraformAddr, input.AWSResourceID}, e.debug)
 	if err != nil {
 		return "", fmt.Errorf("failed to import: %s", err)
 	}
@@ -190,33 +341,52 @@ func (e Executor) Version() (string, error) {
 	return version, nil
 }
 
+// Output returns one output of the terraform state, read with the others by
+// Outputs.
 func (e Executor) Output(tfState, outputName string) (string, error) {
-	templateDir, err := tempDir("", "")
+	outputs, err := e.Outputs(tfState)
 	if err != nil {
 		return "", err
 	}
 
-	err = writeFile(filepath.Join(templateDir, "terraform.tfstate"), []byte(tfState), os.ModePerm)
-	if err != nil {
-		return "", err
+	value, ok := outputs[outputName]
+	if !ok {
+		return "", fmt.Errorf("the terraform state has no output %q", outputName)
 	}
 
-	err = e.cmd.Run(os.Stdout, templateDir, []string{"init"}, e.debug)
-	if err != nil {
-		return "", err
+	return fmt.Sprintf("%v", value), nil
+}
+
+// Outputs returns every output of the terraform state from a single
+// terraform output call. The outputs are kept until a different state is read.
+func (e Executor) Outputs(tfState string) (map[string]interface{}, error) {
+	if e.outputs == nil {
+		return e.readOutputs(tfState)
 	}
 
-	args := []string{"output", outputName}
-	buffer := bytes.NewBuffer([]byte{})
-	err = e.cmd.Run(buffer, templateDir, args, true)
-	if err != nil {
-		return "", err
+	e.outputs.mutex.Lock()
+	defer e.outputs.mutex.Unlock()
+
+	if e.outputs.outputs == nil || e.outputs.tfState != tfState {
+		outputs, err := e.readOutputs(tfState)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+
+		e.outputs.tfState = tfState
+		e.outputs.outputs = outputs
+	}
+
+	// The output generators replace values in the map they are given.
+	outputs := map[string]interface{}{}
+	for key, value := range e.outputs.outputs {
+		outputs[key] = value
 	}
 
-	return strings.TrimSuffix(buffer.String(), "\n"), nil
+	return outputs, nil
 }
 
-func (e Executor) Outputs(tfState string) (map[string]interface{}, error) {
+func (e Executor) readOutputs(tfState string) (map[string]interface{}, error) {
 	templateDir, err := tempDir("", "")
 	if err != nil {
 		return map[string]interface{}{}, err
@@ -227,7 +397,7 @@ func (e Executor) Outputs(tfState string) (map[string]interface{}, error) {
 		return map[string]interface{}{}, err
 	}
 
-	err = e.cmd.Run(os.Stdout, templateDir, []string{"init"}, false)
+	err = e.init(templateDir, false)
 	if err != nil {
 		return map[string]interface{}{}, err
 	}
@@ -254,6 +424,71 @@ func (e Executor) Outputs(tfState string) (map[string]interface{}, error) {
 	return outputs, nil
 }
 
+// writeTemplate writes the template to the working directory together with
+// the *_override.tf files the user put in the template dir of the state dir,
+// which terraform merges into the template. The state dir is only read.
+func (e Executor) writeTemplate(workingDirectory, template string) error {
+	err := writeFile(filepath.Join(workingDirectory, "template.tf"), []byte(template), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	templateDir := filepath.Join(e.stateDir, TemplateDir)
+	overrides, err := filepath.Glob(filepath.Join(templateDir, "*_override.tf"))
+	if err != nil {
+		return err //not tested
+	}
+
+	for _, override := range overrides {
+		contents, err := readFile(override)
+		if err != nil {
+			return err
+		}
+
+		err = writeFile(filepath.Join(workingDirectory, filepath.Base(override)), contents, os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keepTemplate keeps a copy of the applied template in the template dir of
+// the state dir to write the overrides against.
+func (e Executor) keepTemplate(template string) error {
+	templateDir := filepath.Join(e.stateDir, TemplateDir)
+	err := os.MkdirAll(templateDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(templateDir, "bbl-template.tf"), []byte(template), os.FileMode(0600))
+}
+
+func (e Executor) init(workingDirectory string, debug bool) error {
+	args := []string{"init"}
+	if e.pluginDir != "" {
+		args = append(args, "-plugin-dir", e.pluginDir)
+	}
+
+	return e.cmd.Run(e.stdout, workingDirectory, args, debug)
+}
+
+func exitStatus(err error) int {
+	exitError, ok := err.(*exec.ExitError)
+	if !ok {
+		return -1
+	}
+
+	status, ok := exitError.Sys().(syscall.WaitStatus)
+	if !ok {
+		return -1 //not tested
+	}
+
+	return status.ExitStatus()
+}
+
 func makeVar(name string, value string) []string {
 	return []string{"-var", fmt.Sprintf("%s=%s", name, value)}
 }
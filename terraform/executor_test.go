@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -22,16 +23,20 @@ var _ = Describe("Executor", func() {
 		cmd      *fakes.TerraformCmd
 		executor terraform.Executor
 
-		tempDir string
-		input   map[string]string
+		tempDir  string
+		stateDir string
+		input    map[string]string
 	)
 
 	BeforeEach(func() {
 		cmd = &fakes.TerraformCmd{}
 
-		executor = terraform.NewExecutor(cmd, true)
-
 		var err error
+		stateDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		executor = terraform.NewExecutor(cmd, os.Stdout, stateDir, "", true)
+
 		tempDir, err = ioutil.TempDir("", "")
 		Expect(err).NotTo(HaveOccurred())
 
@@ -59,11 +64,12 @@ var _ = Describe("Executor", func() {
 		terraform.ResetTempDir()
 		terraform.ResetReadFile()
 		terraform.ResetWriteFile()
+		os.RemoveAll(stateDir)
 	})
 
 	Describe("Apply", func() {
 		It("writes the terraform template to a file", func() {
-			_, err := executor.Apply(input, "some-template", "")
+			_, err := executor.Apply(input, "some-template", "", nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "template.tf"))
@@ -72,8 +78,45 @@ var _ = Describe("Executor", func() {
 			Expect(string(fileContents)).To(Equal("some-template"))
 		})
 
+		It("keeps a copy of the template in the state dir", func() {
+			_, err := executor.Apply(input, "some-template", "", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fileContents, err := ioutil.ReadFile(filepath.Join(stateDir, "terraform", "bbl-template.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(fileContents)).To(Equal("some-template"))
+		})
+
+		It("includes the override files of the state dir", func() {
+			terraform.ResetReadFile()
+			Expect(os.MkdirAll(filepath.Join(stateDir, "terraform"), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "terraform", "peering_override.tf"), []byte("some-override"), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "terraform", "notes.tf"), []byte("some-notes"), os.ModePerm)).To(Succeed())
+
+			_, err := executor.Apply(input, "some-template", "some-tf-state", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "peering_override.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(fileContents)).To(Equal("some-override"))
+
+			_, err = os.Stat(filepath.Join(tempDir, "notes.tf"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("returns an error when an override file cannot be read", func() {
+			Expect(os.MkdirAll(filepath.Join(stateDir, "terraform"), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "terraform", "peering_override.tf"), []byte("some-override"), os.ModePerm)).To(Succeed())
+			terraform.SetReadFile(func(filename string) ([]byte, error) {
+				return nil, errors.New("failed to read override")
+			})
+
+			_, err := executor.Apply(input, "some-template", "", nil)
+			Expect(err).To(MatchError("failed to read override"))
+		})
+
 		It("passes the correct args and dir to run command", func() {
-			_, err := executor.Apply(input, "some-template", "")
+			_, err := executor.Apply(input, "some-template", "", nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
@@ -91,6 +134,18 @@ var _ = Describe("Executor", func() {
 			Expect(cmd.RunCall.Receives.Debug).To(BeTrue())
 		})
 
+		It("passes a -target for each of the targets", func() {
+			_, err := executor.Apply(input, "some-template", "", []string{"google_compute_firewall.bosh-open", "google_compute_subnetwork.bbl-subnet"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cmd.RunCall.Receives.Args[0]).To(Equal("apply"))
+			Expect(cmd.RunCall.Receives.Args).To(HaveLen(21))
+			Expect(cmd.RunCall.Receives.Args[17:]).To(Equal([]string{
+				"-target", "google_compute_firewall.bosh-open",
+				"-target", "google_compute_subnetwork.bbl-subnet",
+			}))
+		})
+
 		It("reads and returns the terraform state written by the command", func() {
 			var actualFilename string
 
@@ -99,7 +154,7 @@ var _ = Describe("Executor", func() {
 				return []byte("some-terraform-state"), nil
 			})
 
-			terraformState, err := executor.Apply(input, "some-template", "")
+			terraformState, err := executor.Apply(input, "some-template", "", nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(actualFilename).To(ContainSubstring("terraform.tfstate"))
@@ -130,16 +185,37 @@ var _ = Describe("Executor", func() {
 			})
 
 			It("does not write the previous tf state file", func() {
-				_, err := executor.Apply(input, "some-template", "")
+				_, err := executor.Apply(input, "some-template", "", nil)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(writeTFStateFileCallCount).To(Equal(0))
 			})
 		})
 
+		It("runs terraform init without a plugin dir", func() {
+			_, err := executor.Apply(input, "some-template", "", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cmd.RunCall.Receives.InitArgs).To(Equal([]string{"init"}))
+		})
+
+		Context("when a terraform plugin dir is provided", func() {
+			BeforeEach(func() {
+				executor = terraform.NewExecutor(cmd, os.Stdout, stateDir, "some/plugin/dir", true)
+			})
+
+			It("runs terraform init with the plugin dir before applying", func() {
+				_, err := executor.Apply(input, "some-template", "", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cmd.RunCall.Receives.InitArgs).To(Equal([]string{"init", "-plugin-dir", "some/plugin/dir"}))
+				Expect(cmd.RunCall.Receives.Args[0]).To(Equal("apply"))
+			})
+		})
+
 		Context("when previous tf state is not blank", func() {
 			It("writes the tf state to a file", func() {
-				_, err := executor.Apply(input, "some-template", "some-tf-state")
+				_, err := executor.Apply(input, "some-template", "some-tf-state", nil)
 				Expect(err).NotTo(HaveOccurred())
 
 				fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "terraform.tfstate"))
@@ -154,7 +230,7 @@ var _ = Describe("Executor", func() {
 				terraform.SetTempDir(func(dir, prefix string) (string, error) {
 					return "", errors.New("failed to make temp dir")
 				})
-				_, err := executor.Apply(input, "some-template", "")
+				_, err := executor.Apply(input, "some-template", "", nil)
 				Expect(err).To(MatchError("failed to make temp dir"))
 			})
 
@@ -167,7 +243,7 @@ var _ = Describe("Executor", func() {
 					return nil
 				})
 
-				_, err := executor.Apply(input, "some-template", "")
+				_, err := executor.Apply(input, "some-template", "", nil)
 				Expect(err).To(MatchError("failed to write template file"))
 			})
 
@@ -180,14 +256,14 @@ var _ = Describe("Executor", func() {
 					return nil
 				})
 
-				_, err := executor.Apply(input, "some-template", "some-tf-state")
+				_, err := executor.Apply(input, "some-template", "some-tf-state", nil)
 				Expect(err).To(MatchError("failed to write tf state file"))
 			})
 
 			It("returns an error when terraform init fails", func() {
 				cmd.RunCall.Returns.Errors = []error{errors.New("failed to initialize terraform")}
 
-				_, err := executor.Apply(input, "some-template", "")
+				_, err := executor.Apply(input, "some-template", "", nil)
 				Expect(err).To(MatchError("failed to initialize terraform"))
 			})
 
@@ -197,7 +273,7 @@ var _ = Describe("Executor", func() {
 
 				cmd.RunCall.Returns.Errors = []error{nil, errors.New("failed to run terraform command")}
 
-				_, err = executor.Apply(input, "some-template", "")
+				_, err = executor.Apply(input, "some-template", "", nil)
 				taErr := err.(terraform.ExecutorError)
 				Expect(taErr).To(MatchError("failed to run terraform command"))
 
@@ -211,13 +287,13 @@ var _ = Describe("Executor", func() {
 					return []byte{}, errors.New("failed to read tf state file")
 				})
 
-				_, err := executor.Apply(input, "some-template", "")
+				_, err := executor.Apply(input, "some-template", "", nil)
 				Expect(err).To(MatchError("failed to read tf state file"))
 			})
 
 			Context("when --debug is false", func() {
 				BeforeEach(func() {
-					executor = terraform.NewExecutor(cmd, false)
+					executor = terraform.NewExecutor(cmd, os.Stdout, stateDir, "", false)
 				})
 
 				It("returns an error and the current tf state when it fails to call terraform command run", func() {
@@ -226,7 +302,7 @@ var _ = Describe("Executor", func() {
 
 					cmd.RunCall.Returns.Errors = []error{nil, errors.New("failed to run terraform command")}
 
-					_, err = executor.Apply(input, "some-template", "")
+					_, err = executor.Apply(input, "some-template", "", nil)
 					taErr := err.(terraform.ExecutorError)
 
 					tfState, err := taErr.TFState()
@@ -237,6 +313,207 @@ var _ = Describe("Executor", func() {
 		})
 	})
 
+	Describe("Plan", func() {
+		It("includes the override files without writing the template to the state dir", func() {
+			terraform.ResetReadFile()
+			Expect(os.MkdirAll(filepath.Join(stateDir, "terraform"), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "terraform", "peering_override.tf"), []byte("some-override"), os.ModePerm)).To(Succeed())
+
+			_, err := executor.Plan(input, "some-template", "some-tf-state", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "peering_override.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(fileContents)).To(Equal("some-override"))
+
+			Expect(filepath.Join(stateDir, "terraform", "bbl-template.tf")).NotTo(BeAnExistingFile())
+		})
+
+		It("writes the template and tf state to a temp dir", func() {
+			_, err := executor.Plan(input, "some-template", "some-tf-state", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			templateContents, err := ioutil.ReadFile(filepath.Join(tempDir, "template.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(templateContents)).To(Equal("some-template"))
+
+			tfStateContents, err := ioutil.ReadFile(filepath.Join(tempDir, "terraform.tfstate"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(tfStateContents)).To(Equal("some-tf-state"))
+		})
+
+		It("runs terraform plan and returns its output", func() {
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				fmt.Fprint(stdout, "Plan: 1 to add, 0 to change, 0 to destroy.")
+			}
+
+			plan, err := executor.Plan(input, "some-template", "some-tf-state", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal("Plan: 1 to add, 0 to change, 0 to destroy."))
+
+			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
+			Expect(cmd.RunCall.Receives.Args).To(ConsistOf([]string{
+				"plan",
+				"-input=false",
+				"-var", "project_id=some-project-id",
+				"-var", "env_id=some-env-id",
+				"-var", "region=some-region",
+				"-var", "zone=some-zone",
+				"-var", "ssl_certificate=some/certificate/path",
+				"-var", "ssl_certificate_private_key=some/key/path",
+				"-var", "credentials=some/credentials/path",
+				"-var", "system_domain=some-domain",
+			}))
+		})
+
+		It("plans a destroy", func() {
+			_, err := executor.Plan(input, "some-template", "some-tf-state", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cmd.RunCall.Receives.Args[:3]).To(Equal([]string{"plan", "-input=false", "-destroy"}))
+		})
+
+		Context("when an error occurs", func() {
+			It("returns an error when it fails to create a temp dir", func() {
+				terraform.SetTempDir(func(dir, prefix string) (string, error) {
+					return "", errors.New("failed to make temp dir")
+				})
+
+				_, err := executor.Plan(input, "some-template", "", false)
+				Expect(err).To(MatchError("failed to make temp dir"))
+			})
+
+			It("returns an error when terraform init fails", func() {
+				cmd.RunCall.Returns.Errors = []error{errors.New("failed to initialize terraform")}
+
+				_, err := executor.Plan(input, "some-template", "", false)
+				Expect(err).To(MatchError("failed to initialize terraform"))
+			})
+
+			It("returns an error when terraform plan fails", func() {
+				cmd.RunCall.Returns.Errors = []error{nil, errors.New("exit status 1")}
+
+				_, err := executor.Plan(input, "some-template", "", false)
+				Expect(err).To(MatchError("failed to plan: exit status 1"))
+			})
+		})
+	})
+
+	Describe("Refresh", func() {
+		It("refreshes the tf state against the real infrastructure", func() {
+			terraform.SetReadFile(func(filename string) ([]byte, error) {
+				if filename == filepath.Join(tempDir, "terraform.tfstate") {
+					return []byte("some-refreshed-tf-state"), nil
+				}
+				return []byte{}, nil
+			})
+
+			tfState, err := executor.Refresh(input, "some-template", "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tfState).To(Equal("some-refreshed-tf-state"))
+
+			tfStateContents, err := ioutil.ReadFile(filepath.Join(tempDir, "terraform.tfstate"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(tfStateContents)).To(Equal("some-tf-state"))
+
+			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
+			Expect(cmd.RunCall.Receives.Args[:2]).To(Equal([]string{"refresh", "-input=false"}))
+			Expect(cmd.RunCall.Receives.Args).To(ContainElement("env_id=some-env-id"))
+		})
+
+		Context("when an error occurs", func() {
+			It("returns an error when terraform init fails", func() {
+				cmd.RunCall.Returns.Errors = []error{errors.New("failed to initialize terraform")}
+
+				_, err := executor.Refresh(input, "some-template", "some-tf-state")
+				Expect(err).To(MatchError("failed to initialize terraform"))
+			})
+
+			It("returns an executor error when terraform refresh fails", func() {
+				cmd.RunCall.Returns.Errors = []error{nil, errors.New("failed to refresh")}
+
+				_, err := executor.Refresh(input, "some-template", "some-tf-state")
+				Expect(err).To(BeAssignableToTypeOf(terraform.ExecutorError{}))
+				Expect(err).To(MatchError("failed to refresh"))
+			})
+		})
+	})
+
+	Describe("Drift", func() {
+		It("includes the override files without writing the template to the state dir", func() {
+			terraform.ResetReadFile()
+			Expect(os.MkdirAll(filepath.Join(stateDir, "terraform"), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "terraform", "peering_override.tf"), []byte("some-override"), os.ModePerm)).To(Succeed())
+
+			_, _, err := executor.Drift(input, "some-template", "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "peering_override.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(fileContents)).To(Equal("some-override"))
+
+			Expect(filepath.Join(stateDir, "terraform", "bbl-template.tf")).NotTo(BeAnExistingFile())
+		})
+
+		It("writes the template and tf state to a temp dir", func() {
+			_, _, err := executor.Drift(input, "some-template", "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			templateContents, err := ioutil.ReadFile(filepath.Join(tempDir, "template.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(templateContents)).To(Equal("some-template"))
+
+			tfStateContents, err := ioutil.ReadFile(filepath.Join(tempDir, "terraform.tfstate"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(tfStateContents)).To(Equal("some-tf-state"))
+		})
+
+		It("reports no drift when the plan has no changes", func() {
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				fmt.Fprint(stdout, "No changes. Infrastructure is up-to-date.")
+			}
+
+			plan, drifted, err := executor.Drift(input, "some-template", "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(BeFalse())
+			Expect(plan).To(Equal("No changes. Infrastructure is up-to-date."))
+
+			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
+			Expect(cmd.RunCall.Receives.Args[:4]).To(Equal([]string{"plan", "-input=false", "-no-color", "-detailed-exitcode"}))
+			Expect(cmd.RunCall.Receives.Args).To(ContainElement("env_id=some-env-id"))
+		})
+
+		It("reports drift when terraform exits with 2", func() {
+			exitTwo := exec.Command("sh", "-c", "exit 2").Run()
+			cmd.RunCall.Returns.Errors = []error{nil, exitTwo}
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				fmt.Fprint(stdout, "Plan: 1 to add, 0 to change, 0 to destroy.")
+			}
+
+			plan, drifted, err := executor.Drift(input, "some-template", "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(BeTrue())
+			Expect(plan).To(Equal("Plan: 1 to add, 0 to change, 0 to destroy."))
+		})
+
+		Context("when an error occurs", func() {
+			It("returns an error when terraform init fails", func() {
+				cmd.RunCall.Returns.Errors = []error{errors.New("failed to initialize terraform")}
+
+				_, _, err := executor.Drift(input, "some-template", "some-tf-state")
+				Expect(err).To(MatchError("failed to initialize terraform"))
+			})
+
+			It("returns an error when terraform plan fails", func() {
+				exitOne := exec.Command("sh", "-c", "exit 1").Run()
+				cmd.RunCall.Returns.Errors = []error{nil, exitOne}
+
+				_, _, err := executor.Drift(input, "some-template", "some-tf-state")
+				Expect(err).To(MatchError("failed to plan: exit status 1"))
+			})
+		})
+	})
+
 	Describe("Destroy", func() {
 		It("writes the template and tf state to a temp dir", func() {
 			_, err := executor.Destroy(input, "some-template", "some-tf-state")
@@ -251,6 +528,26 @@ var _ = Describe("Executor", func() {
 			Expect(string(tfStateContents)).To(Equal("some-tf-state"))
 		})
 
+		It("includes the override files of the state dir so the resources they add are destroyed", func() {
+			terraform.ResetReadFile()
+			Expect(os.MkdirAll(filepath.Join(stateDir, "terraform"), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "terraform", "peering_override.tf"), []byte("some-override"), os.ModePerm)).To(Succeed())
+
+			_, err := executor.Destroy(input, "some-template", "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "peering_override.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(fileContents)).To(Equal("some-override"))
+		})
+
+		It("does not write the template to the state dir", func() {
+			_, err := executor.Destroy(input, "some-template", "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(stateDir, "terraform")).NotTo(BeAnExistingFile())
+		})
+
 		It("passes the correct args and dir to run command", func() {
 			_, err := executor.Destroy(input, "some-template", "some-tf-state")
 			Expect(err).NotTo(HaveOccurred())
@@ -271,6 +568,20 @@ var _ = Describe("Executor", func() {
 			Expect(cmd.RunCall.Receives.Debug).To(BeTrue())
 		})
 
+		Context("when a terraform plugin dir is provided", func() {
+			BeforeEach(func() {
+				executor = terraform.NewExecutor(cmd, os.Stdout, stateDir, "some/plugin/dir", true)
+			})
+
+			It("runs terraform init with the plugin dir before destroying", func() {
+				_, err := executor.Destroy(input, "some-template", "some-tf-state")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cmd.RunCall.Receives.InitArgs).To(Equal([]string{"init", "-plugin-dir", "some/plugin/dir"}))
+				Expect(cmd.RunCall.Receives.Args[0]).To(Equal("destroy"))
+			})
+		})
+
 		It("reads and returns the tf state", func() {
 			terraform.SetReadFile(func(filename string) ([]byte, error) {
 				return []byte{}, nil
@@ -350,7 +661,7 @@ var _ = Describe("Executor", func() {
 
 			Context("when --debug is false", func() {
 				BeforeEach(func() {
-					executor = terraform.NewExecutor(cmd, false)
+					executor = terraform.NewExecutor(cmd, os.Stdout, stateDir, "", false)
 				})
 
 				It("returns an error and the current tf state when it fails to call terraform command run", func() {
@@ -413,6 +724,7 @@ var _ = Describe("Executor", func() {
 					Region:          "some-region",
 					AccessKeyID:     "some-access-key",
 					SecretAccessKey: "some-secret",
+					SessionToken:    "some-session-token",
 				},
 			})
 			Expect(err).NotTo(HaveOccurred())
@@ -422,6 +734,7 @@ provider "aws" {
 	region     = "some-region"
 	access_key = "some-access-key"
 	secret_key = "some-secret"
+	token      = "some-session-token"
 }
 
 resource "some-resource-type" "some-addr" {
@@ -567,39 +880,26 @@ resource "some-resource-type" "some-addr" {
 	})
 
 	Describe("Output", func() {
-		It("returns an output from the terraform state", func() {
+		BeforeEach(func() {
 			cmd.RunCall.Stub = func(stdout io.Writer) {
-				fmt.Fprintf(stdout, "some-external-ip\n")
+				fmt.Fprintf(stdout, `{"external_ip": {"sensitive": false, "type": "string", "value": "some-external-ip"}}`)
 			}
+		})
+
+		It("returns an output from the terraform state", func() {
 			output, err := executor.Output("some-tf-state", "external_ip")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(output).To(Equal("some-external-ip"))
 
 			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
-			Expect(cmd.RunCall.Receives.Args).To(Equal([]string{"output", "external_ip"}))
+			Expect(cmd.RunCall.Receives.Args).To(Equal([]string{"output", "--json"}))
 			Expect(cmd.RunCall.Receives.Debug).To(BeTrue())
 		})
 
 		Context("when an error occurs", func() {
-			It("returns an error when it fails to create a temp dir", func() {
-				terraform.SetTempDir(func(dir, prefix string) (string, error) {
-					return "", errors.New("failed to make temp dir")
-				})
-				_, err := executor.Output("some-tf-state", "external_ip")
-				Expect(err).To(MatchError("failed to make temp dir"))
-			})
-
-			It("returns an error when it fails to write the tfstate file", func() {
-				terraform.SetWriteFile(func(file string, data []byte, perm os.FileMode) error {
-					if strings.Contains(file, "terraform.tfstate") {
-						return errors.New("failed to write tf state file")
-					}
-
-					return nil
-				})
-
-				_, err := executor.Output("some-tf-state", "external_ip")
-				Expect(err).To(MatchError("failed to write tf state file"))
+			It("returns an error when the output is not in the terraform state", func() {
+				_, err := executor.Output("some-tf-state", "internal_ip")
+				Expect(err).To(MatchError(`the terraform state has no output "internal_ip"`))
 			})
 
 			It("returns an error when terraform init fails", func() {
@@ -647,6 +947,29 @@ resource "some-resource-type" "some-addr" {
 			Expect(cmd.RunCall.Receives.Debug).To(BeTrue())
 		})
 
+		It("reads the outputs of a terraform state only once", func() {
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				fmt.Fprintf(stdout, `{"external_ip": {"sensitive": false, "type": "string", "value": "some-external-ip"}}`)
+			}
+
+			outputs, err := executor.Outputs("some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmd.RunCall.CallCount).To(Equal(2))
+
+			outputs["external_ip"] = "some-changed-ip"
+
+			_, err = executor.Output("some-tf-state", "external_ip")
+			Expect(err).NotTo(HaveOccurred())
+			outputs, err = executor.Outputs("some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outputs).To(Equal(map[string]interface{}{"external_ip": "some-external-ip"}))
+			Expect(cmd.RunCall.CallCount).To(Equal(2))
+
+			_, err = executor.Outputs("some-other-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmd.RunCall.CallCount).To(Equal(4))
+		})
+
 		Context("when an error occurs", func() {
 			It("returns an error when it fails to create a temp dir", func() {
 				terraform.SetTempDir(func(dir, prefix string) (string, error) {
@@ -3,6 +3,7 @@ package terraform
 import (
 	"io/ioutil"
 	"os"
+	"time"
 )
 
 func SetTempDir(f func(dir, prefix string) (string, error)) {
@@ -28,3 +29,21 @@ func SetReadFile(f func(filename string) ([]byte, error)) {
 func ResetReadFile() {
 	readFile = ioutil.ReadFile
 }
+
+var originalPinnedSHA256Sums = pinnedSHA256Sums
+
+func SetPinnedSHA256Sums(sums map[string]string) {
+	pinnedSHA256Sums = sums
+}
+
+func ResetPinnedSHA256Sums() {
+	pinnedSHA256Sums = originalPinnedSHA256Sums
+}
+
+func SetNow(f func() time.Time) {
+	now = f
+}
+
+func ResetNow() {
+	now = time.Now
+}
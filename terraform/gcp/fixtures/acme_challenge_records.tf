@@ -0,0 +1,19 @@
+resource "google_dns_record_set" "acme-challenge-0" {
+  name = "_acme-challenge.some-domain."
+  type = "TXT"
+  ttl  = 60
+
+  managed_zone = "${google_dns_managed_zone.env_dns_zone.name}"
+
+  rrdatas = ["\"some-value\"", "\"some-other-value\""]
+}
+
+resource "google_dns_record_set" "acme-challenge-1" {
+  name = "_acme-challenge.ws.some-domain."
+  type = "TXT"
+  ttl  = 60
+
+  managed_zone = "${google_dns_managed_zone.env_dns_zone.name}"
+
+  rrdatas = ["\"some-ws-value\""]
+}
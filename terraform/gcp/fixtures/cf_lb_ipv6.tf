@@ -0,0 +1,22 @@
+output "router_lb_ipv6" {
+    value = "${google_compute_global_address.cf-address-ipv6.address}"
+}
+
+resource "google_compute_global_address" "cf-address-ipv6" {
+  name       = "${var.env_id}-cf-ipv6"
+  ip_version = "IPV6"
+}
+
+resource "google_compute_global_forwarding_rule" "cf-http-forwarding-rule-ipv6" {
+  name       = "${var.env_id}-cf-http-ipv6"
+  ip_address = "${google_compute_global_address.cf-address-ipv6.address}"
+  target     = "${google_compute_target_http_proxy.cf-http-lb-proxy.self_link}"
+  port_range = "80"
+}
+
+resource "google_compute_global_forwarding_rule" "cf-https-forwarding-rule-ipv6" {
+  name       = "${var.env_id}-cf-https-ipv6"
+  ip_address = "${google_compute_global_address.cf-address-ipv6.address}"
+  target     = "${google_compute_target_https_proxy.cf-https-lb-proxy.self_link}"
+  port_range = "443"
+}
@@ -0,0 +1,10 @@
+resource "google_dns_record_set" "wildcard-dns-ipv6" {
+  name       = "*.${google_dns_managed_zone.env_dns_zone.dns_name}"
+  depends_on = ["google_compute_global_address.cf-address-ipv6"]
+  type       = "AAAA"
+  ttl        = 300
+
+  managed_zone = "${google_dns_managed_zone.env_dns_zone.name}"
+
+  rrdatas = ["${google_compute_global_address.cf-address-ipv6.address}"]
+}
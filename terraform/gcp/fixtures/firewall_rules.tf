@@ -0,0 +1,22 @@
+resource "google_compute_firewall" "custom-some-app" {
+  name    = "${var.env_id}-custom-some-app"
+  network = "${google_compute_network.bbl-network.name}"
+
+  source_ranges = ["10.0.0.0/8"]
+
+  allow {
+    ports = ["8080", "9000-9010"]
+    protocol = "tcp"
+  }
+}
+
+resource "google_compute_firewall" "custom-ping" {
+  name    = "${var.env_id}-custom-ping"
+  network = "${google_compute_network.bbl-network.name}"
+
+  source_ranges = ["0.0.0.0/0"]
+
+  allow {
+    protocol = "icmp"
+  }
+}
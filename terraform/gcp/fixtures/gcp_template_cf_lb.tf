@@ -28,6 +28,10 @@ output "external_ip" {
     value = "${google_compute_address.bosh-external-ip.address}"
 }
 
+output "region" {
+    value = "${var.region}"
+}
+
 output "network_name" {
     value = "${google_compute_network.bbl-network.name}"
 }
@@ -56,12 +60,22 @@ resource "google_compute_network" "bbl-network" {
   name		 = "${var.env_id}-network"
 }
 
+variable "network_cidr" {
+  type    = "string"
+  default = "10.0.0.0/16"
+}
+
 resource "google_compute_subnetwork" "bbl-subnet" {
   name			= "${var.env_id}-subnet"
-  ip_cidr_range = "10.0.0.0/16"
+  ip_cidr_range = "${var.network_cidr}"
   network		= "${google_compute_network.bbl-network.self_link}"
 }
 
+variable "ssh_port" {
+  type    = "string"
+  default = "22"
+}
+
 resource "google_compute_address" "bosh-external-ip" {
   name = "${var.env_id}-bosh-external-ip"
 }
@@ -73,7 +87,7 @@ resource "google_compute_firewall" "external" {
   source_ranges = ["0.0.0.0/0"]
 
   allow {
-    ports = ["22", "6868", "25555"]
+    ports = ["${var.ssh_port}", "6868", "25555"]
     protocol = "tcp"
   }
 
@@ -143,7 +157,7 @@ resource "google_compute_firewall" "internal" {
 }
 
 output "jumpbox_url" {
-    value = "${google_compute_address.bosh-external-ip.address}:22"
+    value = "${google_compute_address.bosh-external-ip.address}:${var.ssh_port}"
 }
 
 variable "ssl_certificate" {
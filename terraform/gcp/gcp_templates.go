@@ -20,17 +20,36 @@ variable "credentials" {
 	type = "string"
 }
 
-provider "google" {
+`
+
+const ProviderTemplate = `provider "google" {
 	credentials = "${file("${var.credentials}")}"
 	project = "${var.project_id}"
 	region = "${var.region}"
 }
 `
 
-const BOSHDirectorTemplate = `output "external_ip" {
+const ImpersonatedProviderTemplate = `variable "access_token" {
+	type = "string"
+}
+
+provider "google" {
+	access_token = "${var.access_token}"
+	project = "${var.project_id}"
+	region = "${var.region}"
+}
+`
+
+const BOSHDirectorTemplate = BOSHDirectorOutputsTemplate + NetworkTemplate + BOSHDirectorResourcesTemplate
+
+const BOSHDirectorOutputsTemplate = `output "external_ip" {
     value = "${google_compute_address.bosh-external-ip.address}"
 }
 
+output "region" {
+    value = "${var.region}"
+}
+
 output "network_name" {
     value = "${google_compute_network.bbl-network.name}"
 }
@@ -55,20 +74,59 @@ output "director_address" {
 	value = "https://${google_compute_address.bosh-external-ip.address}:25555"
 }
 
-resource "google_compute_network" "bbl-network" {
+`
+
+const NetworkTemplate = `resource "google_compute_network" "bbl-network" {
   name		 = "${var.env_id}-network"
 }
 
+variable "network_cidr" {
+  type    = "string"
+  default = "10.0.0.0/16"
+}
+
 resource "google_compute_subnetwork" "bbl-subnet" {
   name			= "${var.env_id}-subnet"
-  ip_cidr_range = "10.0.0.0/16"
+  ip_cidr_range = "${var.network_cidr}"
   network		= "${google_compute_network.bbl-network.self_link}"
 }
 
-resource "google_compute_address" "bosh-external-ip" {
+`
+
+const ExistingNetworkTemplate = `variable "existing_network_name" {
+  type = "string"
+}
+
+variable "existing_subnetwork_name" {
+  type = "string"
+}
+
+data "google_compute_network" "bbl-network" {
+  name = "${var.existing_network_name}"
+}
+
+data "google_compute_subnetwork" "bbl-subnet" {
+  name   = "${var.existing_subnetwork_name}"
+  region = "${var.region}"
+}
+
+output "subnetwork_cidr" {
+  value = "${data.google_compute_subnetwork.bbl-subnet.ip_cidr_range}"
+}
+
+`
+
+const externalAddressTemplate = `resource "google_compute_address" "bosh-external-ip" {
   name = "${var.env_id}-bosh-external-ip"
 }
+`
+
+const BOSHDirectorResourcesTemplate = `variable "ssh_port" {
+  type    = "string"
+  default = "22"
+}
 
+` + externalAddressTemplate + `
 resource "google_compute_firewall" "external" {
   name    = "${var.env_id}-external"
   network = "${google_compute_network.bbl-network.name}"
@@ -76,7 +134,7 @@ resource "google_compute_firewall" "external" {
   source_ranges = ["0.0.0.0/0"]
 
   allow {
-    ports = ["22", "6868", "25555"]
+    ports = ["${var.ssh_port}", "6868", "25555"]
     protocol = "tcp"
   }
 
@@ -146,7 +204,7 @@ resource "google_compute_firewall" "internal" {
 }
 
 output "jumpbox_url" {
-    value = "${google_compute_address.bosh-external-ip.address}:22"
+    value = "${google_compute_address.bosh-external-ip.address}:${var.ssh_port}"
 }
 `
 
@@ -420,6 +478,121 @@ resource "google_compute_forwarding_rule" "cf-ws-http" {
 }
 `
 
+const CFIPv6LBTemplate = `output "router_lb_ipv6" {
+    value = "${google_compute_global_address.cf-address-ipv6.address}"
+}
+
+resource "google_compute_global_address" "cf-address-ipv6" {
+  name       = "${var.env_id}-cf-ipv6"
+  ip_version = "IPV6"
+}
+
+resource "google_compute_global_forwarding_rule" "cf-http-forwarding-rule-ipv6" {
+  name       = "${var.env_id}-cf-http-ipv6"
+  ip_address = "${google_compute_global_address.cf-address-ipv6.address}"
+  target     = "${google_compute_target_http_proxy.cf-http-lb-proxy.self_link}"
+  port_range = "80"
+}
+
+resource "google_compute_global_forwarding_rule" "cf-https-forwarding-rule-ipv6" {
+  name       = "${var.env_id}-cf-https-ipv6"
+  ip_address = "${google_compute_global_address.cf-address-ipv6.address}"
+  target     = "${google_compute_target_https_proxy.cf-https-lb-proxy.self_link}"
+  port_range = "443"
+}
+`
+
+const CFIPv6DNSTemplate = `resource "google_dns_record_set" "wildcard-dns-ipv6" {
+  name       = "*.${google_dns_managed_zone.env_dns_zone.dns_name}"
+  depends_on = ["google_compute_global_address.cf-address-ipv6"]
+  type       = "AAAA"
+  ttl        = 300
+
+  managed_zone = "${google_dns_managed_zone.env_dns_zone.name}"
+
+  rrdatas = ["${google_compute_global_address.cf-address-ipv6.address}"]
+}
+`
+
+const PreviousSSLCertificateTemplate = `variable "previous_ssl_certificate" {
+  type = "string"
+}
+
+variable "previous_ssl_certificate_private_key" {
+  type = "string"
+}
+
+resource "google_compute_ssl_certificate" "%s" {
+  name_prefix = "${var.env_id}"
+  description = "user provided ssl private key / ssl certificate pair"
+  private_key = "${file(var.previous_ssl_certificate_private_key)}"
+  certificate = "${file(var.previous_ssl_certificate)}"
+  lifecycle {
+	create_before_destroy = true
+  }
+}
+`
+
+const DirectorDBTemplate = `variable "director_db_password" {
+  type = "string"
+}
+
+resource "google_compute_global_address" "director-db-peering" {
+  name          = "${var.env_id}-director-db-peering"
+  purpose       = "VPC_PEERING"
+  address_type  = "INTERNAL"
+  prefix_length = 16
+  network       = "${google_compute_network.bbl-network.self_link}"
+}
+
+resource "google_service_networking_connection" "director-db" {
+  network                 = "${google_compute_network.bbl-network.self_link}"
+  service                 = "servicenetworking.googleapis.com"
+  reserved_peering_ranges = ["${google_compute_global_address.director-db-peering.name}"]
+}
+
+resource "google_sql_database_instance" "director-db" {
+  name             = "${var.env_id}-director-db"
+  database_version = "POSTGRES_9_6"
+  region           = "${var.region}"
+
+  depends_on = ["google_service_networking_connection.director-db"]
+
+  settings {
+    tier              = "db-custom-1-3840"
+    availability_type = "REGIONAL"
+
+    backup_configuration {
+      enabled = true
+    }
+
+    ip_configuration {
+      ipv4_enabled    = false
+      private_network = "${google_compute_network.bbl-network.self_link}"
+    }
+  }
+}
+
+resource "google_sql_database" "director-db" {
+  name     = "bosh"
+  instance = "${google_sql_database_instance.director-db.name}"
+}
+
+resource "google_sql_user" "director-db" {
+  name     = "bosh"
+  instance = "${google_sql_database_instance.director-db.name}"
+  password = "${var.director_db_password}"
+}
+
+output "director_db_host" {
+  value = "${google_sql_database_instance.director-db.private_ip_address}"
+}
+
+output "director_db_port" {
+  value = "5432"
+}
+`
+
 const CFDNSTemplate = `variable "system_domain" {
   type = "string"
 }
@@ -1,9 +1,11 @@
 package gcp
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
@@ -11,11 +13,18 @@ import (
 var tempDir func(dir, prefix string) (string, error) = ioutil.TempDir
 var writeFile func(file string, data []byte, perm os.FileMode) error = ioutil.WriteFile
 
+type accessTokenProvider interface {
+	AccessToken() (string, error)
+}
+
 type InputGenerator struct {
+	accessTokenProvider accessTokenProvider
 }
 
-func NewInputGenerator() InputGenerator {
-	return InputGenerator{}
+func NewInputGenerator(accessTokenProvider accessTokenProvider) InputGenerator {
+	return InputGenerator{
+		accessTokenProvider: accessTokenProvider,
+	}
 }
 
 func (i InputGenerator) Generate(state storage.State) (map[string]string, error) {
@@ -24,6 +33,8 @@ func (i InputGenerator) Generate(state storage.State) (map[string]string, error)
 		return map[string]string{}, err
 	}
 
+	// without a service account key the file is empty, and the google
+	// provider falls back to the application default credentials, as bbl does.
 	credentialsPath := filepath.Join(dir, "credentials.json")
 	err = writeFile(credentialsPath, []byte(state.GCP.ServiceAccountKey), os.ModePerm)
 	if err != nil {
@@ -39,9 +50,33 @@ func (i InputGenerator) Generate(state storage.State) (map[string]string, error)
 		"system_domain": state.LB.Domain,
 	}
 
+	if state.GCP.ImpersonateServiceAccount != "" {
+		accessToken, err := i.accessTokenProvider.AccessToken()
+		if err != nil {
+			return map[string]string{}, fmt.Errorf("failed to get an access token for %s: %s", state.GCP.ImpersonateServiceAccount, err)
+		}
+		input["access_token"] = accessToken
+	}
+
+	if state.GCP.ExistingNetworkName != "" {
+		input["existing_network_name"] = state.GCP.ExistingNetworkName
+		input["existing_subnetwork_name"] = state.GCP.ExistingSubnetworkName
+	} else if state.Network.CIDR != "" {
+		input["network_cidr"] = state.Network.CIDR
+	}
+
+	if state.DirectorDB.Enabled {
+		input["director_db_password"] = state.DirectorDB.Password
+	}
+
+	if state.SSHPort != 0 {
+		input["ssh_port"] = strconv.Itoa(state.SSHPort)
+	}
+
 	if state.LB.Cert != "" && state.LB.Key != "" {
+		// gcp takes the intermediates in the same file as the certificate.
 		certPath := filepath.Join(dir, "cert")
-		err = writeFile(certPath, []byte(state.LB.Cert), os.ModePerm)
+		err = writeFile(certPath, []byte(state.LB.Cert+state.LB.Chain), os.ModePerm)
 		if err != nil {
 			return map[string]string{}, err
 		}
@@ -53,6 +88,22 @@ func (i InputGenerator) Generate(state storage.State) (map[string]string, error)
 			return map[string]string{}, err
 		}
 		input["ssl_certificate_private_key"] = keyPath
+
+		if state.LB.Previous.Cert != "" {
+			previousCertPath := filepath.Join(dir, "previous_cert")
+			err = writeFile(previousCertPath, []byte(state.LB.Previous.Cert+state.LB.Previous.Chain), os.ModePerm)
+			if err != nil {
+				return map[string]string{}, err
+			}
+			input["previous_ssl_certificate"] = previousCertPath
+
+			previousKeyPath := filepath.Join(dir, "previous_key")
+			err = writeFile(previousKeyPath, []byte(state.LB.Previous.Key), os.ModePerm)
+			if err != nil {
+				return map[string]string{}, err
+			}
+			input["previous_ssl_certificate_private_key"] = previousKeyPath
+		}
 	}
 
 	return input, nil
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform/gcp"
 	. "github.com/onsi/ginkgo"
@@ -15,7 +16,8 @@ import (
 
 var _ = Describe("InputGenerator", func() {
 	var (
-		inputGenerator gcp.InputGenerator
+		inputGenerator      gcp.InputGenerator
+		accessTokenProvider *fakes.GCPClientProvider
 
 		tempDir string
 		state   storage.State
@@ -46,7 +48,10 @@ var _ = Describe("InputGenerator", func() {
 			},
 		}
 
-		inputGenerator = gcp.NewInputGenerator()
+		accessTokenProvider = &fakes.GCPClientProvider{}
+		accessTokenProvider.AccessTokenCall.Returns.AccessToken = "some-access-token"
+
+		inputGenerator = gcp.NewInputGenerator(accessTokenProvider)
 	})
 
 	AfterEach(func() {
@@ -72,6 +77,64 @@ var _ = Describe("InputGenerator", func() {
 		Expect(string(credentials)).To(Equal("some-service-account-key"))
 	})
 
+	It("returns a map containing the network cidr when one is provided", func() {
+		state.Network.CIDR = "172.16.0.0/16"
+
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(inputs["network_cidr"]).To(Equal("172.16.0.0/16"))
+	})
+
+	It("returns a map containing the existing network instead of the network cidr", func() {
+		state.Network.CIDR = "172.16.0.0/16"
+		state.GCP.ExistingNetworkName = "some-network"
+		state.GCP.ExistingSubnetworkName = "some-subnetwork"
+
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(inputs["existing_network_name"]).To(Equal("some-network"))
+		Expect(inputs["existing_subnetwork_name"]).To(Equal("some-subnetwork"))
+		Expect(inputs).NotTo(HaveKey("network_cidr"))
+	})
+
+	It("returns a map containing the ssh port when one is provided", func() {
+		state.SSHPort = 2222
+
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(inputs["ssh_port"]).To(Equal("2222"))
+	})
+
+	It("returns a map containing the director database password when the director has an external database", func() {
+		state.DirectorDB = storage.DirectorDB{Enabled: true, Password: "some-db-password"}
+
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(inputs["director_db_password"]).To(Equal("some-db-password"))
+	})
+
+	It("returns a map containing an access token when a service account is impersonated", func() {
+		state.GCP.ImpersonateServiceAccount = "some-env@some-project-id.iam.gserviceaccount.com"
+
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(accessTokenProvider.AccessTokenCall.CallCount).To(Equal(1))
+		Expect(inputs["access_token"]).To(Equal("some-access-token"))
+	})
+
+	It("does not request an access token when no service account is impersonated", func() {
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(accessTokenProvider.AccessTokenCall.CallCount).To(Equal(0))
+		Expect(inputs).NotTo(HaveKey("access_token"))
+	})
+
 	It("returns a map containing cert and key variables when cert/key are provided", func() {
 		state.LB.Cert = "some-cert"
 		state.LB.Key = "some-key"
@@ -99,6 +162,36 @@ var _ = Describe("InputGenerator", func() {
 		Expect(string(sslCertificatePrivateKey)).To(Equal("some-key"))
 	})
 
+	It("appends the chain to the certificate when a chain is provided", func() {
+		state.LB.Cert = "some-cert\n"
+		state.LB.Key = "some-key"
+		state.LB.Chain = "some-chain\n"
+
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		sslCertificate, err := ioutil.ReadFile(inputs["ssl_certificate"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(sslCertificate)).To(Equal("some-cert\nsome-chain\n"))
+	})
+
+	It("writes the previous certificate when one is kept after a rotation", func() {
+		state.LB.Cert = "some-cert"
+		state.LB.Key = "some-key"
+		state.LB.Previous = storage.LBCertificate{Cert: "some-old-cert", Key: "some-old-key"}
+
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		previousCertificate, err := ioutil.ReadFile(inputs["previous_ssl_certificate"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(previousCertificate)).To(Equal("some-old-cert"))
+
+		previousKey, err := ioutil.ReadFile(inputs["previous_ssl_certificate_private_key"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(previousKey)).To(Equal("some-old-key"))
+	})
+
 	Context("failure cases", func() {
 		It("returns an error if temp dir cannot be created", func() {
 			gcp.SetTempDir(func(dir, prefix string) (string, error) {
@@ -108,6 +201,14 @@ var _ = Describe("InputGenerator", func() {
 			Expect(err).To(MatchError("failed to create temp dir"))
 		})
 
+		It("returns an error if the access token cannot be retrieved", func() {
+			state.GCP.ImpersonateServiceAccount = "some-env@some-project-id.iam.gserviceaccount.com"
+			accessTokenProvider.AccessTokenCall.Returns.Error = errors.New("permission denied")
+
+			_, err := inputGenerator.Generate(state)
+			Expect(err).To(MatchError("failed to get an access token for some-env@some-project-id.iam.gserviceaccount.com: permission denied"))
+		})
+
 		It("returns an error if the credentials cannot be written", func() {
 			gcp.SetWriteFile(func(filename string, data []byte, perm os.FileMode) error {
 				if strings.Contains(filename, "credentials.json") {
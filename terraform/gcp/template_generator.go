@@ -25,7 +25,17 @@ func NewTemplateGenerator() TemplateGenerator {
 }
 
 func (t TemplateGenerator) Generate(state storage.State) string {
-	template := strings.Join([]string{VarsTemplate, BOSHDirectorTemplate}, "\n")
+	provider := ProviderTemplate
+	if state.GCP.ImpersonateServiceAccount != "" {
+		provider = ImpersonatedProviderTemplate
+	}
+
+	director := BOSHDirectorTemplate
+	if state.GCP.ExistingNetworkName != "" {
+		director = BOSHDirectorOutputsTemplate + ExistingNetworkTemplate + BOSHDirectorResourcesTemplate
+	}
+
+	template := strings.Join([]string{VarsTemplate + provider, director}, "\n")
 
 	switch state.LB.Type {
 	case "concourse":
@@ -34,15 +44,159 @@ func (t TemplateGenerator) Generate(state storage.State) string {
 		instanceGroups := t.GenerateInstanceGroups(state.GCP.Zones)
 		backendService := t.GenerateBackendService(state.GCP.Zones)
 
-		template = strings.Join([]string{template, CFLBTemplate, instanceGroups, backendService}, "\n")
+		lbTemplate := CFLBTemplate
+		previousCertResource := "cf-cert-alt"
+		if state.LB.CertSlot == "alt" {
+			previousCertResource = "cf-cert"
+			lbTemplate = strings.Replace(lbTemplate, `"google_compute_ssl_certificate" "cf-cert"`, `"google_compute_ssl_certificate" "cf-cert-alt"`, 1)
+			lbTemplate = strings.Replace(lbTemplate, "${google_compute_ssl_certificate.cf-cert.", "${google_compute_ssl_certificate.cf-cert-alt.", -1)
+		}
+
+		template = strings.Join([]string{template, lbTemplate, instanceGroups, backendService}, "\n")
+
+		if state.LB.Previous.Cert != "" {
+			template = strings.Join([]string{template, fmt.Sprintf(PreviousSSLCertificateTemplate, previousCertResource)}, "\n")
+		}
 
 		if state.LB.Domain != "" {
 			template = strings.Join([]string{template, CFDNSTemplate}, "\n")
+
+			if len(state.LB.ACME.Challenges) > 0 {
+				template = strings.Join([]string{template, t.GenerateACMEChallengeRecords(state.LB.ACME.Challenges)}, "\n")
+			}
 		}
+
+		if state.LB.IPv6 {
+			template = strings.Join([]string{template, CFIPv6LBTemplate}, "\n")
+
+			if state.LB.Domain != "" {
+				template = strings.Join([]string{template, CFIPv6DNSTemplate}, "\n")
+			}
+		}
+	}
+
+	if state.DirectorDB.Enabled {
+		template = strings.Join([]string{template, DirectorDBTemplate}, "\n")
 	}
+
+	if len(state.GCP.FirewallRules) > 0 {
+		template = strings.Join([]string{template, t.GenerateFirewallRules(state.GCP.FirewallRules)}, "\n")
+	}
+
+	if state.GCP.NoPublicIPs {
+		template = usePrivateAddress(template, state)
+	}
+
+	if state.GCP.ExistingNetworkName != "" {
+		template = referenceExistingNetwork(template)
+	}
+
+	if len(state.Tags) > 0 {
+		template = addLabels(template, state.Tags)
+	}
+
 	return template
 }
 
+// addLabels labels the dns zone and the director database, the resources of
+// the templates that take labels.
+func addLabels(template string, tags []storage.Tag) string {
+	var labels []string
+	for _, tag := range tags {
+		labels = append(labels, fmt.Sprintf("%s = %q", tag.Key, tag.Value))
+	}
+
+	template = strings.Replace(template, "  description = \"DNS zone for the ${var.env_id} environment\"\n",
+		fmt.Sprintf("  description = \"DNS zone for the ${var.env_id} environment\"\n\n  labels = {\n    %s\n  }\n", strings.Join(labels, "\n    ")), 1)
+	return strings.Replace(template, "  settings {\n",
+		fmt.Sprintf("  settings {\n    user_labels = {\n      %s\n    }\n\n", strings.Join(labels, "\n      ")), 1)
+}
+
+// usePrivateAddress drops the external address of the jumpbox, or of the
+// director without one, and has every output and record that pointed at it
+// use the internal address bosh deploys the vm at instead.
+func usePrivateAddress(template string, state storage.State) string {
+	host := 6
+	if state.Jumpbox.Enabled {
+		host = 5
+	}
+
+	subnet := fmt.Sprintf("%q", state.Network.DirectorSubnetCIDR())
+	if state.GCP.ExistingNetworkName != "" {
+		// the director is in the first /24 of the existing subnetwork.
+		subnet = "data.google_compute_subnetwork.bbl-subnet.ip_cidr_range"
+	}
+
+	template = strings.Replace(template, externalAddressTemplate, "", 1)
+	template = strings.Replace(template, "  depends_on = [\"google_compute_address.bosh-external-ip\"]\n", "", -1)
+	return strings.Replace(template, "${google_compute_address.bosh-external-ip.address}", fmt.Sprintf("${cidrhost(%s, %d)}", subnet, host), -1)
+}
+
+// referenceExistingNetwork points every resource at the network and subnetwork
+// data sources, which replace the resources bbl would otherwise create.
+func referenceExistingNetwork(template string) string {
+	template = strings.Replace(template, "  depends_on = [\"google_compute_network.bbl-network\"]\n", "", -1)
+	template = strings.Replace(template, "${google_compute_network.bbl-network.", "${data.google_compute_network.bbl-network.", -1)
+	return strings.Replace(template, "${google_compute_subnetwork.bbl-subnet.", "${data.google_compute_subnetwork.bbl-subnet.", -1)
+}
+
+// GenerateFirewallRules returns a firewall for each rule of --gcp-firewall-rule.
+// Their names are prefixed with custom- so that they cannot collide with the
+// firewalls of bbl, and they have no target tags, so they apply to every vm
+// in the network.
+func (t TemplateGenerator) GenerateFirewallRules(rules []storage.GCPFirewallRule) string {
+	var resources []string
+	for _, rule := range rules {
+		var ports string
+		if len(rule.Ports) > 0 {
+			ports = fmt.Sprintf("    ports = [\"%s\"]\n", strings.Join(rule.Ports, `", "`))
+		}
+
+		resources = append(resources, fmt.Sprintf(`resource "google_compute_firewall" "custom-%[1]s" {
+  name    = "${var.env_id}-custom-%[1]s"
+  network = "${google_compute_network.bbl-network.name}"
+
+  source_ranges = ["%[2]s"]
+
+  allow {
+%[3]s    protocol = "%[4]s"
+  }
+}
+`, rule.Name, rule.SourceRange, ports, rule.Protocol))
+	}
+
+	return strings.Join(resources, "\n")
+}
+
+// GenerateACMEChallengeRecords returns a TXT record set in the environment's
+// managed zone for each name that has a pending ACME DNS-01 challenge.
+func (t TemplateGenerator) GenerateACMEChallengeRecords(challenges []storage.ACMEChallenge) string {
+	var names []string
+	values := map[string][]string{}
+	for _, challenge := range challenges {
+		if _, ok := values[challenge.Name]; !ok {
+			names = append(names, challenge.Name)
+		}
+		values[challenge.Name] = append(values[challenge.Name], fmt.Sprintf(`"\"%s\""`, challenge.Value))
+	}
+
+	var recordSets []string
+	for i, name := range names {
+		recordSets = append(recordSets, fmt.Sprintf(`resource "google_dns_record_set" "acme-challenge-%d" {
+  name = "%s."
+  type = "TXT"
+  ttl  = 60
+
+  managed_zone = "${google_dns_managed_zone.env_dns_zone.name}"
+
+  rrdatas = [%s]
+}
+`, i, name, strings.Join(values[name], ", ")))
+	}
+
+	return strings.Join(recordSets, "\n")
+}
+
 func (t TemplateGenerator) GenerateBackendService(zoneList []string) string {
 	var backends string
 	for i := 0; i < len(zoneList); i++ {
@@ -2,6 +2,7 @@ package gcp_test
 
 import (
 	"io/ioutil"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform/gcp"
@@ -16,11 +17,31 @@ var _ = Describe("TemplateGenerator", func() {
 		templateGenerator gcp.TemplateGenerator
 		expectedTemplate  []byte
 		zones             []string
+		firewallRules     []storage.GCPFirewallRule
+		acmeChallenges    []storage.ACMEChallenge
 	)
 
 	BeforeEach(func() {
 		templateGenerator = gcp.NewTemplateGenerator()
 		zones = []string{"z1", "z2", "z3"}
+		firewallRules = []storage.GCPFirewallRule{
+			{
+				Name:        "some-app",
+				Protocol:    "tcp",
+				Ports:       []string{"8080", "9000-9010"},
+				SourceRange: "10.0.0.0/8",
+			},
+			{
+				Name:        "ping",
+				Protocol:    "icmp",
+				SourceRange: "0.0.0.0/0",
+			},
+		}
+		acmeChallenges = []storage.ACMEChallenge{
+			{Name: "_acme-challenge.some-domain", Value: "some-value"},
+			{Name: "_acme-challenge.ws.some-domain", Value: "some-ws-value"},
+			{Name: "_acme-challenge.some-domain", Value: "some-other-value"},
+		}
 	})
 
 	Describe("Generate", func() {
@@ -45,6 +66,330 @@ var _ = Describe("TemplateGenerator", func() {
 			Entry("when a cf lb type is provided", "fixtures/gcp_template_cf_lb.tf", "some-region", "cf", ""),
 			Entry("when a cf lb type is provided with a domain", "fixtures/gcp_template_cf_lb_dns.tf", "some-region", "cf", "some-domain"),
 		)
+
+		Context("when ipv6 is enabled for a cf lb", func() {
+			It("appends the ipv6 address and forwarding rules", func() {
+				cfLBTemplate, err := ioutil.ReadFile("fixtures/gcp_template_cf_lb.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				ipv6Template, err := ioutil.ReadFile("fixtures/cf_lb_ipv6.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region: "some-region",
+						Zones:  zones,
+					},
+					LB: storage.LB{
+						Type: "cf",
+						IPv6: true,
+					},
+				})
+				Expect(template).To(Equal(strings.Join([]string{string(cfLBTemplate), string(ipv6Template)}, "\n")))
+			})
+
+			It("appends an AAAA record when a domain is provided", func() {
+				cfLBDNSTemplate, err := ioutil.ReadFile("fixtures/gcp_template_cf_lb_dns.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				ipv6Template, err := ioutil.ReadFile("fixtures/cf_lb_ipv6.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				ipv6DNSTemplate, err := ioutil.ReadFile("fixtures/cf_lb_ipv6_dns.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region: "some-region",
+						Zones:  zones,
+					},
+					LB: storage.LB{
+						Type:   "cf",
+						Domain: "some-domain",
+						IPv6:   true,
+					},
+				})
+				Expect(template).To(Equal(strings.Join([]string{string(cfLBDNSTemplate), string(ipv6Template), string(ipv6DNSTemplate)}, "\n")))
+			})
+		})
+
+		Context("when acme challenges are pending for a cf lb with a domain", func() {
+			It("appends a TXT record set for each challenge name", func() {
+				cfLBDNSTemplate, err := ioutil.ReadFile("fixtures/gcp_template_cf_lb_dns.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				challengeTemplate, err := ioutil.ReadFile("fixtures/acme_challenge_records.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region: "some-region",
+						Zones:  zones,
+					},
+					LB: storage.LB{
+						Type:   "cf",
+						Domain: "some-domain",
+						ACME: storage.ACME{
+							Challenges: acmeChallenges,
+						},
+					},
+				})
+				Expect(template).To(Equal(strings.Join([]string{string(cfLBDNSTemplate), string(challengeTemplate)}, "\n")))
+			})
+		})
+
+		Context("when the lb certificate is being rotated", func() {
+			It("keeps the previous certificate next to the current one", func() {
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region: "some-region",
+						Zones:  zones,
+					},
+					LB: storage.LB{
+						Type:     "cf",
+						Previous: storage.LBCertificate{Cert: "some-old-cert", Key: "some-old-key"},
+					},
+				})
+
+				Expect(template).To(ContainSubstring(`resource "google_compute_ssl_certificate" "cf-cert" {`))
+				Expect(template).To(ContainSubstring(`resource "google_compute_ssl_certificate" "cf-cert-alt" {`))
+				Expect(template).To(ContainSubstring(`certificate = "${file(var.previous_ssl_certificate)}"`))
+				Expect(template).To(ContainSubstring(`ssl_certificates = ["${google_compute_ssl_certificate.cf-cert.self_link}"]`))
+			})
+
+			It("points the https proxy at the alternate certificate when it holds the current certificate", func() {
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region: "some-region",
+						Zones:  zones,
+					},
+					LB: storage.LB{
+						Type:     "cf",
+						CertSlot: "alt",
+						Previous: storage.LBCertificate{Cert: "some-old-cert", Key: "some-old-key"},
+					},
+				})
+
+				Expect(template).To(ContainSubstring(`ssl_certificates = ["${google_compute_ssl_certificate.cf-cert-alt.self_link}"]`))
+				Expect(template).To(MatchRegexp(`resource "google_compute_ssl_certificate" "cf-cert" {\s+name_prefix = "\$\{var.env_id\}"\s+description = "[^"]+"\s+private_key = "\$\{file\(var.previous_ssl_certificate_private_key\)\}"`))
+			})
+		})
+
+		Context("when the director has an external database", func() {
+			It("appends a cloud sql instance the director can reach", func() {
+				noLBTemplate, err := ioutil.ReadFile("fixtures/gcp_template_no_lb.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region: "some-region",
+						Zones:  zones,
+					},
+					DirectorDB: storage.DirectorDB{Enabled: true},
+				})
+
+				Expect(template).To(HavePrefix(string(noLBTemplate)))
+				Expect(template).To(ContainSubstring(`resource "google_sql_database_instance" "director-db" {`))
+				Expect(template).To(ContainSubstring(`ipv4_enabled    = false`))
+				Expect(template).To(ContainSubstring(`private_network = "${google_compute_network.bbl-network.self_link}"`))
+				Expect(template).To(ContainSubstring(`value = "${google_sql_database_instance.director-db.private_ip_address}"`))
+				Expect(template).To(ContainSubstring(`password = "${var.director_db_password}"`))
+				Expect(template).To(ContainSubstring(`output "director_db_host" {`))
+			})
+		})
+
+		Context("when a service account is impersonated", func() {
+			It("configures the provider with an access token instead of the service account key", func() {
+				noLBTemplate, err := ioutil.ReadFile("fixtures/gcp_template_no_lb.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region:                    "some-region",
+						Zones:                     zones,
+						ImpersonateServiceAccount: "some-env@some-project-id.iam.gserviceaccount.com",
+					},
+				})
+
+				expectedTemplate := strings.Replace(string(noLBTemplate), `provider "google" {
+	credentials = "${file("${var.credentials}")}"`, `variable "access_token" {
+	type = "string"
+}
+
+provider "google" {
+	access_token = "${var.access_token}"`, 1)
+				Expect(template).To(Equal(expectedTemplate))
+			})
+		})
+
+		Context("when an existing network is provided", func() {
+			var template string
+
+			BeforeEach(func() {
+				template = templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region:                 "some-region",
+						Zones:                  zones,
+						ExistingNetworkName:    "some-network",
+						ExistingSubnetworkName: "some-subnetwork",
+						FirewallRules:          firewallRules,
+					},
+					LB: storage.LB{
+						Type: "concourse",
+					},
+				})
+			})
+
+			It("looks up the network and subnetwork instead of creating them", func() {
+				Expect(template).To(ContainSubstring(`data "google_compute_network" "bbl-network"`))
+				Expect(template).To(ContainSubstring(`data "google_compute_subnetwork" "bbl-subnet"`))
+				Expect(template).To(ContainSubstring(`output "subnetwork_cidr"`))
+				Expect(template).NotTo(ContainSubstring(`resource "google_compute_network"`))
+				Expect(template).NotTo(ContainSubstring(`resource "google_compute_subnetwork"`))
+				Expect(template).NotTo(ContainSubstring(`variable "network_cidr"`))
+			})
+
+			It("adds the firewall rules, addresses and load balancers to the existing network", func() {
+				Expect(template).To(ContainSubstring(`resource "google_compute_address" "bosh-external-ip"`))
+				Expect(template).To(ContainSubstring(`resource "google_compute_firewall" "firewall-concourse"`))
+				Expect(template).To(ContainSubstring(`resource "google_compute_firewall" "custom-some-app"`))
+				Expect(template).To(ContainSubstring(`network = "${data.google_compute_network.bbl-network.name}"`))
+				Expect(template).To(ContainSubstring(`value = "${data.google_compute_subnetwork.bbl-subnet.name}"`))
+				Expect(template).NotTo(ContainSubstring("${google_compute_network.bbl-network."))
+				Expect(template).NotTo(ContainSubstring("depends_on"))
+			})
+		})
+
+		Context("when tags are provided", func() {
+			It("labels the dns zone and the director database", func() {
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region: "some-region",
+						Zones:  zones,
+					},
+					LB: storage.LB{
+						Type:   "cf",
+						Domain: "some-domain",
+					},
+					DirectorDB: storage.DirectorDB{
+						Enabled: true,
+					},
+					Tags: []storage.Tag{
+						{Key: "cost-center", Value: "1234"},
+						{Key: "owner", Value: "some-team"},
+					},
+				})
+
+				Expect(template).To(ContainSubstring(`  description = "DNS zone for the ${var.env_id} environment"
+
+  labels = {
+    cost-center = "1234"
+    owner = "some-team"
+  }
+}`))
+				Expect(template).To(ContainSubstring(`  settings {
+    user_labels = {
+      cost-center = "1234"
+      owner = "some-team"
+    }
+
+    tier              = "db-custom-1-3840"`))
+			})
+		})
+
+		Context("when the environment has no public ips", func() {
+			It("points the outputs and dns record at the director's internal address", func() {
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region:      "some-region",
+						Zones:       zones,
+						NoPublicIPs: true,
+					},
+					LB: storage.LB{
+						Type:   "cf",
+						Domain: "some-domain",
+					},
+				})
+
+				Expect(template).NotTo(ContainSubstring("bosh-external-ip"))
+				Expect(template).To(ContainSubstring(`value = "https://${cidrhost("10.0.0.0/24", 6)}:25555"`))
+				Expect(template).To(ContainSubstring(`rrdatas = ["${cidrhost("10.0.0.0/24", 6)}"]`))
+			})
+
+			It("points the jumpbox url at the jumpbox's internal address", func() {
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region:      "some-region",
+						Zones:       zones,
+						NoPublicIPs: true,
+					},
+					Jumpbox: storage.Jumpbox{
+						Enabled: true,
+					},
+					Network: storage.Network{
+						SubnetCIDR: "10.1.16.0/24",
+					},
+				})
+
+				Expect(template).NotTo(ContainSubstring("bosh-external-ip"))
+				Expect(template).To(ContainSubstring(`value = "${cidrhost("10.1.16.0/24", 5)}:${var.ssh_port}"`))
+			})
+
+			It("uses the range of an existing subnetwork", func() {
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region:                 "some-region",
+						Zones:                  zones,
+						ExistingNetworkName:    "some-network",
+						ExistingSubnetworkName: "some-subnetwork",
+						NoPublicIPs:            true,
+					},
+				})
+
+				Expect(template).To(ContainSubstring(`value = "${cidrhost(data.google_compute_subnetwork.bbl-subnet.ip_cidr_range, 6)}"`))
+			})
+		})
+
+		Context("when firewall rules are provided", func() {
+			It("appends a firewall resource for each rule", func() {
+				noLBTemplate, err := ioutil.ReadFile("fixtures/gcp_template_no_lb.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				firewallRulesTemplate, err := ioutil.ReadFile("fixtures/firewall_rules.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					GCP: storage.GCP{
+						Region:        "some-region",
+						Zones:         zones,
+						FirewallRules: firewallRules,
+					},
+				})
+				Expect(template).To(Equal(strings.Join([]string{string(noLBTemplate), string(firewallRulesTemplate)}, "\n")))
+			})
+		})
+	})
+
+	Describe("GenerateFirewallRules", func() {
+		It("returns a firewall resource for each rule", func() {
+			expectedTemplate, err := ioutil.ReadFile("fixtures/firewall_rules.tf")
+			Expect(err).NotTo(HaveOccurred())
+
+			template := templateGenerator.GenerateFirewallRules(firewallRules)
+
+			Expect(template).To(Equal(string(expectedTemplate)))
+		})
+	})
+
+	Describe("GenerateACMEChallengeRecords", func() {
+		It("returns a TXT record set for each challenge name", func() {
+			expectedTemplate, err := ioutil.ReadFile("fixtures/acme_challenge_records.tf")
+			Expect(err).NotTo(HaveOccurred())
+
+			template := templateGenerator.GenerateACMEChallengeRecords(acmeChallenges)
+
+			Expect(template).To(Equal(string(expectedTemplate)))
+		})
 	})
 
 	Describe("GenerateBackendService", func() {
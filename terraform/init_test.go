@@ -1,7 +1,6 @@
 package terraform_test
 
 import (
-	"os"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -14,14 +13,6 @@ func TestTerraform(t *testing.T) {
 	RunSpecs(t, "terraform")
 }
 
-var (
-	originalPath string
-)
-
-var _ = BeforeSuite(func() {
-	originalPath = os.Getenv("PATH")
-})
-
 var _ = AfterSuite(func() {
 	gexec.CleanupBuildArtifacts()
 })
@@ -7,14 +7,19 @@ import (
 )
 
 type InputGenerator struct {
-	gcpInputGenerator inputGenerator
-	awsInputGenerator inputGenerator
+	gcpInputGenerator       inputGenerator
+	awsInputGenerator       inputGenerator
+	azureInputGenerator     inputGenerator
+	openstackInputGenerator inputGenerator
 }
 
-func NewInputGenerator(gcpInputGenerator inputGenerator, awsInputGenerator inputGenerator) InputGenerator {
+func NewInputGenerator(gcpInputGenerator inputGenerator, awsInputGenerator inputGenerator, azureInputGenerator inputGenerator,
+	openstackInputGenerator inputGenerator) InputGenerator {
 	return InputGenerator{
-		gcpInputGenerator: gcpInputGenerator,
-		awsInputGenerator: awsInputGenerator,
+		gcpInputGenerator:       gcpInputGenerator,
+		awsInputGenerator:       awsInputGenerator,
+		azureInputGenerator:     azureInputGenerator,
+		openstackInputGenerator: openstackInputGenerator,
 	}
 }
 
@@ -24,6 +29,10 @@ func (i InputGenerator) Generate(state storage.State) (map[string]string, error)
 		return i.gcpInputGenerator.Generate(state)
 	case "aws":
 		return i.awsInputGenerator.Generate(state)
+	case "azure":
+		return i.azureInputGenerator.Generate(state)
+	case "openstack":
+		return i.openstackInputGenerator.Generate(state)
 	default:
 		return map[string]string{}, fmt.Errorf("invalid iaas: %q", state.IAAS)
 	}
@@ -12,8 +12,10 @@ import (
 var _ = Describe("InputGenerator", func() {
 	Describe("Generate", func() {
 		var (
-			gcpInputGenerator *fakes.InputGenerator
-			awsInputGenerator *fakes.InputGenerator
+			gcpInputGenerator       *fakes.InputGenerator
+			awsInputGenerator       *fakes.InputGenerator
+			azureInputGenerator     *fakes.InputGenerator
+			openstackInputGenerator *fakes.InputGenerator
 
 			inputGenerator terraform.InputGenerator
 		)
@@ -28,7 +30,17 @@ var _ = Describe("InputGenerator", func() {
 				"some-input": "some-value",
 			}
 
-			inputGenerator = terraform.NewInputGenerator(gcpInputGenerator, awsInputGenerator)
+			azureInputGenerator = &fakes.InputGenerator{}
+			azureInputGenerator.GenerateCall.Returns.Inputs = map[string]string{
+				"some-input": "some-value",
+			}
+
+			openstackInputGenerator = &fakes.InputGenerator{}
+			openstackInputGenerator.GenerateCall.Returns.Inputs = map[string]string{
+				"some-input": "some-value",
+			}
+
+			inputGenerator = terraform.NewInputGenerator(gcpInputGenerator, awsInputGenerator, azureInputGenerator, openstackInputGenerator)
 		})
 
 		Context("when iaas is gcp", func() {
@@ -65,6 +77,41 @@ var _ = Describe("InputGenerator", func() {
 			})
 		})
 
+		Context("when iaas is azure", func() {
+			It("returns the inputs from the azure input generator", func() {
+				input, err := inputGenerator.Generate(storage.State{
+					IAAS: "azure",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(input).To(Equal(map[string]string{
+					"some-input": "some-value",
+				}))
+				Expect(gcpInputGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(awsInputGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(azureInputGenerator.GenerateCall.Receives.State).To(Equal(storage.State{
+					IAAS: "azure",
+				}))
+			})
+		})
+
+		Context("when iaas is openstack", func() {
+			It("returns the inputs from the openstack input generator", func() {
+				input, err := inputGenerator.Generate(storage.State{
+					IAAS: "openstack",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(input).To(Equal(map[string]string{
+					"some-input": "some-value",
+				}))
+				Expect(azureInputGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(openstackInputGenerator.GenerateCall.Receives.State).To(Equal(storage.State{
+					IAAS: "openstack",
+				}))
+			})
+		})
+
 		Context("failure cases", func() {
 			Context("when iaas is invalid", func() {
 				It("returns an error", func() {
@@ -75,6 +122,8 @@ var _ = Describe("InputGenerator", func() {
 
 					Expect(gcpInputGenerator.GenerateCall.CallCount).To(Equal(0))
 					Expect(awsInputGenerator.GenerateCall.CallCount).To(Equal(0))
+					Expect(azureInputGenerator.GenerateCall.CallCount).To(Equal(0))
+					Expect(openstackInputGenerator.GenerateCall.CallCount).To(Equal(0))
 				})
 			})
 		})
@@ -2,39 +2,53 @@ package terraform
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/coreos/go-semver/semver"
 )
 
+const (
+	MinimumVersion      = "0.8.5"
+	IncompatibleVersion = "0.9.0"
+)
+
+var now = time.Now
+
+var resourceDeclaration = regexp.MustCompile(`(?m)^\s*resource\s+"([^"]+)"\s+"([^"]+)"`)
+
 type Manager struct {
-	executor              executor
-	templateGenerator     templateGenerator
-	inputGenerator        inputGenerator
-	gcpOutputGenerator    outputGenerator
-	awsOutputGenerator    outputGenerator
-	terraformOutputBuffer *bytes.Buffer
-	logger                logger
-	stackMigrator         stackMigrator
+	executor                 executor
+	templateGenerator        templateGenerator
+	inputGenerator           inputGenerator
+	gcpOutputGenerator       outputGenerator
+	awsOutputGenerator       outputGenerator
+	azureOutputGenerator     outputGenerator
+	openstackOutputGenerator outputGenerator
+	terraformOutputBuffer    *bytes.Buffer
+	logger                   logger
 }
 
 type executor interface {
 	Version() (string, error)
 	Destroy(inputs map[string]string, terraformTemplate, tfState string) (string, error)
-	Apply(inputs map[string]string, terraformTemplate, tfState string) (string, error)
+	Apply(inputs map[string]string, terraformTemplate, tfState string, targets []string) (string, error)
+	Plan(inputs map[string]string, terraformTemplate, tfState string, destroy bool) (string, error)
+	Drift(inputs map[string]string, terraformTemplate, tfState string) (string, bool, error)
+	Refresh(inputs map[string]string, terraformTemplate, tfState string) (string, error)
 }
 
 type templateGenerator interface {
 	Generate(storage.State) string
 }
 
-//go:generate counterfeiter -o ./fakes/stack_migrator.go --fake-name StackMigrator . stackMigrator
-type stackMigrator interface {
-	Migrate(state storage.State) (storage.State, error)
-}
-
 type inputGenerator interface {
 	Generate(storage.State) (map[string]string, error)
 }
@@ -48,26 +62,28 @@ type logger interface {
 }
 
 type NewManagerArgs struct {
-	Executor              executor
-	TemplateGenerator     templateGenerator
-	InputGenerator        inputGenerator
-	AWSOutputGenerator    outputGenerator
-	GCPOutputGenerator    outputGenerator
-	TerraformOutputBuffer *bytes.Buffer
-	Logger                logger
-	StackMigrator         stackMigrator
+	Executor                 executor
+	TemplateGenerator        templateGenerator
+	InputGenerator           inputGenerator
+	AWSOutputGenerator       outputGenerator
+	GCPOutputGenerator       outputGenerator
+	AzureOutputGenerator     outputGenerator
+	OpenStackOutputGenerator outputGenerator
+	TerraformOutputBuffer    *bytes.Buffer
+	Logger                   logger
 }
 
 func NewManager(args NewManagerArgs) Manager {
 	return Manager{
-		executor:              args.Executor,
-		templateGenerator:     args.TemplateGenerator,
-		inputGenerator:        args.InputGenerator,
-		awsOutputGenerator:    args.AWSOutputGenerator,
-		gcpOutputGenerator:    args.GCPOutputGenerator,
-		terraformOutputBuffer: args.TerraformOutputBuffer,
-		logger:                args.Logger,
-		stackMigrator:         args.StackMigrator,
+		executor:                 args.Executor,
+		templateGenerator:        args.TemplateGenerator,
+		inputGenerator:           args.InputGenerator,
+		awsOutputGenerator:       args.AWSOutputGenerator,
+		gcpOutputGenerator:       args.GCPOutputGenerator,
+		azureOutputGenerator:     args.AzureOutputGenerator,
+		openstackOutputGenerator: args.OpenStackOutputGenerator,
+		terraformOutputBuffer:    args.TerraformOutputBuffer,
+		logger:                   args.Logger,
 	}
 }
 
@@ -87,53 +103,65 @@ func (m Manager) ValidateVersion() error {
 	}
 
 	// This shouldn't fail, so there is no test for capturing the error.
-	minimumVersion, err := semver.NewVersion("0.8.5")
+	minimumVersion, err := semver.NewVersion(MinimumVersion)
 	if err != nil {
 		return err
 	}
 
 	if currentVersion.LessThan(*minimumVersion) {
-		return errors.New("Terraform version must be at least v0.8.5")
+		return fmt.Errorf("Terraform version must be at least v%s", MinimumVersion)
 	}
 
 	// This shouldn't fail, so there is no test for capturing the error.
-	blacklistedVersion, err := semver.NewVersion("0.9.0")
+	blacklistedVersion, err := semver.NewVersion(IncompatibleVersion)
 	if err != nil {
 		return err
 	}
 
 	if currentVersion.Equal(*blacklistedVersion) {
-		return errors.New("Version 0.9.0 of terraform is incompatible with bbl, please try a later version.")
+		return fmt.Errorf("Version %s of terraform is incompatible with bbl, please try a later version.", IncompatibleVersion)
 	}
 
 	return nil
 }
 
 func (m Manager) Apply(bblState storage.State) (storage.State, error) {
-	var err error
+	return m.apply(bblState, nil)
+}
 
-	m.logger.Step("validating whether stack needs to be migrated")
-	bblState, err = m.stackMigrator.Migrate(bblState)
+// ApplyTargets applies only the given resource addresses, and the resources
+// they depend on, after checking that each one is declared in the template.
+func (m Manager) ApplyTargets(bblState storage.State, targets []string) (storage.State, error) {
+	return m.apply(bblState, targets)
+}
 
-	switch err.(type) {
-	case executorError:
-		return storage.State{}, NewManagerError(bblState, err.(executorError))
-	case error:
-		return storage.State{}, err
+func (m Manager) apply(bblState storage.State, targets []string) (storage.State, error) {
+	if bblState.Stack.Name != "" {
+		return storage.State{}, fmt.Errorf("the cloudformation stack %s has not been migrated to terraform, run bbl migrate-state first", bblState.Stack.Name)
 	}
 
 	m.logger.Step("generating terraform template")
 	template := m.templateGenerator.Generate(bblState)
 
+	err := validateTargets(template, targets)
+	if err != nil {
+		return storage.State{}, err
+	}
+
 	input, err := m.inputGenerator.Generate(bblState)
 	if err != nil {
 		return storage.State{}, err
 	}
 
+	if len(targets) > 0 {
+		m.logger.Step("applying terraform template to %s", strings.Join(targets, ", "))
+	}
+
 	tfState, err := m.executor.Apply(
 		input,
 		template,
 		bblState.TFState,
+		targets,
 	)
 
 	bblState.LatestTFOutput = readAndReset(m.terraformOutputBuffer)
@@ -148,6 +176,16 @@ func (m Manager) Apply(bblState storage.State) (storage.State, error) {
 	m.logger.Step("applied terraform template")
 
 	bblState.TFState = tfState
+	bblState.TFLastApplied = now().UTC().Format(time.RFC3339)
+
+	// The outputs are read again by the next command that needs them when
+	// they cannot be cached now.
+	bblState.TFOutputs = storage.TFOutputs{}
+	outputs, err := m.readOutputs(bblState)
+	if err == nil {
+		bblState.TFOutputs = cacheOutputs(bblState.TFState, outputs)
+	}
+
 	return bblState, nil
 }
 
@@ -180,23 +218,215 @@ func (m Manager) Destroy(bblState storage.State) (storage.State, error) {
 	m.logger.Step("finished destroying infrastructure")
 
 	bblState.TFState = tfState
+	bblState.TFOutputs = storage.TFOutputs{}
 	return bblState, nil
 }
 
+// Plan returns the changes applying the template for bblState would make.
+func (m Manager) Plan(bblState storage.State) (string, error) {
+	return m.plan(bblState, false)
+}
+
+// Template returns the terraform template Apply would use for bblState.
+func (m Manager) Template(bblState storage.State) string {
+	return m.templateGenerator.Generate(bblState)
+}
+
+// PlanDestroy returns the changes destroying the infrastructure in bblState
+// would make.
+func (m Manager) PlanDestroy(bblState storage.State) (string, error) {
+	if bblState.TFState == "" {
+		return "", nil
+	}
+
+	return m.plan(bblState, true)
+}
+
+func (m Manager) plan(bblState storage.State, destroy bool) (string, error) {
+	m.logger.Step("planning terraform template")
+	template := m.templateGenerator.Generate(bblState)
+
+	input, err := m.inputGenerator.Generate(bblState)
+	if err != nil {
+		return "", err
+	}
+
+	return m.executor.Plan(input, template, bblState.TFState, destroy)
+}
+
+// Drift reports whether the real infrastructure has diverged from what bbl
+// applied for bblState, along with the plan that would bring it back.
+func (m Manager) Drift(bblState storage.State) (string, bool, error) {
+	if bblState.TFState == "" {
+		return "", false, errors.New("bbl has not applied any infrastructure for this environment")
+	}
+
+	m.logger.Step("checking the infrastructure for drift")
+	template := m.templateGenerator.Generate(bblState)
+
+	input, err := m.inputGenerator.Generate(bblState)
+	if err != nil {
+		return "", false, err
+	}
+
+	return m.executor.Drift(input, template, bblState.TFState)
+}
+
+// Refresh updates the terraform state with the real infrastructure, for
+// changes made outside of bbl, and caches its outputs again.
+func (m Manager) Refresh(bblState storage.State) (storage.State, error) {
+	if bblState.TFState == "" {
+		return storage.State{}, errors.New("bbl has not applied any infrastructure for this environment")
+	}
+
+	m.logger.Step("refreshing the terraform state")
+	template := m.templateGenerator.Generate(bblState)
+
+	input, err := m.inputGenerator.Generate(bblState)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	tfState, err := m.executor.Refresh(input, template, bblState.TFState)
+
+	bblState.LatestTFOutput = readAndReset(m.terraformOutputBuffer)
+
+	switch err.(type) {
+	case executorError:
+		return storage.State{}, NewManagerError(bblState, err.(executorError))
+	case error:
+		return storage.State{}, err
+	}
+
+	bblState.TFState = tfState
+
+	outputs, err := m.readOutputs(bblState)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	bblState.TFOutputs = cacheOutputs(bblState.TFState, outputs)
+	return bblState, nil
+}
+
+// GetOutputs returns the outputs cached in the state for its terraform state,
+// and reads them with terraform otherwise.
 func (m Manager) GetOutputs(state storage.State) (map[string]interface{}, error) {
+	if state.TFOutputs.Outputs != nil && state.TFOutputs.Checksum == tfStateChecksum(state.TFState) {
+		return cachedOutputs(state.TFOutputs.Outputs), nil
+	}
+
+	return m.readOutputs(state)
+}
+
+func (m Manager) readOutputs(state storage.State) (map[string]interface{}, error) {
 	switch state.IAAS {
 	case "gcp":
 		return m.gcpOutputGenerator.Generate(state.TFState)
 	case "aws":
 		return m.awsOutputGenerator.Generate(state.TFState)
+	case "azure":
+		return m.azureOutputGenerator.Generate(state.TFState)
+	case "openstack":
+		return m.openstackOutputGenerator.Generate(state.TFState)
 	default:
 		return map[string]interface{}{}, fmt.Errorf("invalid iaas: %q", state.IAAS)
 	}
 }
 
+func (m Manager) Resources(state storage.State) ([]string, error) {
+	if state.TFState == "" {
+		return []string{}, nil
+	}
+
+	var tfState struct {
+		Modules []struct {
+			Resources map[string]struct {
+				Primary struct {
+					ID string `json:"id"`
+				} `json:"primary"`
+			} `json:"resources"`
+		} `json:"modules"`
+	}
+
+	err := json.Unmarshal([]byte(state.TFState), &tfState)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to parse terraform state: %s", err)
+	}
+
+	resources := []string{}
+	for _, module := range tfState.Modules {
+		for address, resource := range module.Resources {
+			if strings.HasPrefix(address, "data.") {
+				continue
+			}
+			resources = append(resources, fmt.Sprintf("%s (%s)", address, resource.Primary.ID))
+		}
+	}
+	sort.Strings(resources)
+
+	return resources, nil
+}
+
+func validateTargets(template string, targets []string) error {
+	declared := map[string]bool{}
+	for _, match := range resourceDeclaration.FindAllStringSubmatch(template, -1) {
+		declared[fmt.Sprintf("%s.%s", match[1], match[2])] = true
+	}
+
+	for _, target := range targets {
+		address := strings.SplitN(target, "[", 2)[0]
+		if !declared[address] {
+			return fmt.Errorf("unknown terraform resource address %q", target)
+		}
+	}
+
+	return nil
+}
+
 func readAndReset(buf *bytes.Buffer) string {
 	contents := buf.Bytes()
 	buf.Reset()
 
 	return string(contents)
 }
+
+func tfStateChecksum(tfState string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(tfState)))
+}
+
+func cacheOutputs(tfState string, outputs map[string]interface{}) storage.TFOutputs {
+	return storage.TFOutputs{
+		Checksum: tfStateChecksum(tfState),
+		Outputs:  outputs,
+	}
+}
+
+// cachedOutputs copies the cached outputs, turning the lists of strings read
+// back from the state file into []string as the output generators return them.
+func cachedOutputs(outputs map[string]interface{}) map[string]interface{} {
+	copied := map[string]interface{}{}
+	for key, value := range outputs {
+		copied[key] = value
+
+		list, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+
+		values := []string{}
+		for _, element := range list {
+			s, ok := element.(string)
+			if !ok {
+				break
+			}
+			values = append(values, s)
+		}
+
+		if len(values) == len(list) {
+			copied[key] = values
+		}
+	}
+
+	return copied
+}
@@ -2,15 +2,17 @@ package terraform_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
-	newFakes "github.com/cloudfoundry/bosh-bootloader/terraform/fakes"
 	"github.com/pivotal-cf-experimental/gomegamatchers"
 
 	. "github.com/onsi/ginkgo"
@@ -24,7 +26,6 @@ var _ = Describe("Manager", func() {
 		inputGenerator        *fakes.InputGenerator
 		outputGenerator       *fakes.OutputGenerator
 		logger                *fakes.Logger
-		migrator              *newFakes.StackMigrator
 		manager               terraform.Manager
 		terraformOutputBuffer bytes.Buffer
 		expectedTFState       string
@@ -37,20 +38,20 @@ var _ = Describe("Manager", func() {
 		inputGenerator = &fakes.InputGenerator{}
 		outputGenerator = &fakes.OutputGenerator{}
 		logger = &fakes.Logger{}
-		migrator = &newFakes.StackMigrator{}
 
 		expectedTFOutput = "some terraform output"
 		expectedTFState = "some-updated-tf-state"
 
 		manager = terraform.NewManager(terraform.NewManagerArgs{
-			Executor:              executor,
-			TemplateGenerator:     templateGenerator,
-			InputGenerator:        inputGenerator,
-			AWSOutputGenerator:    outputGenerator,
-			GCPOutputGenerator:    outputGenerator,
-			TerraformOutputBuffer: &terraformOutputBuffer,
-			Logger:                logger,
-			StackMigrator:         migrator,
+			Executor:                 executor,
+			TemplateGenerator:        templateGenerator,
+			InputGenerator:           inputGenerator,
+			AWSOutputGenerator:       outputGenerator,
+			GCPOutputGenerator:       outputGenerator,
+			AzureOutputGenerator:     outputGenerator,
+			OpenStackOutputGenerator: outputGenerator,
+			TerraformOutputBuffer:    &terraformOutputBuffer,
+			Logger:                   logger,
 		})
 	})
 
@@ -82,6 +83,17 @@ var _ = Describe("Manager", func() {
 			expectedState = incomingState
 			expectedState.TFState = expectedTFState
 			expectedState.LatestTFOutput = expectedTFOutput
+			expectedState.TFLastApplied = "2017-08-01T12:30:00Z"
+			expectedState.TFOutputs = storage.TFOutputs{
+				Checksum: fmt.Sprintf("%x", sha256.Sum256([]byte(expectedTFState))),
+				Outputs:  map[string]interface{}{"external_ip": "some-external-ip"},
+			}
+
+			outputGenerator.GenerateCall.Returns.Outputs = map[string]interface{}{"external_ip": "some-external-ip"}
+
+			terraform.SetNow(func() time.Time {
+				return time.Date(2017, time.August, 1, 12, 30, 0, 0, time.UTC)
+			})
 
 			templateGenerator.GenerateCall.Returns.Template = "some-gcp-terraform-template"
 			inputGenerator.GenerateCall.Returns.Inputs = map[string]string{
@@ -92,8 +104,10 @@ var _ = Describe("Manager", func() {
 				"credentials":   "some-path",
 				"system_domain": incomingState.LB.Domain,
 			}
+		})
 
-			migrator.MigrateReturns(incomingState, nil)
+		AfterEach(func() {
+			terraform.ResetNow()
 		})
 
 		It("logs steps", func() {
@@ -101,7 +115,6 @@ var _ = Describe("Manager", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(logger.StepCall.Messages).To(gomegamatchers.ContainSequence([]string{
-				"validating whether stack needs to be migrated",
 				"generating terraform template",
 				"applied terraform template",
 			}))
@@ -113,9 +126,6 @@ var _ = Describe("Manager", func() {
 			state, err := manager.Apply(incomingState)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(migrator.MigrateCallCount()).To(Equal(1))
-			Expect(migrator.MigrateArgsForCall(0)).To(Equal(incomingState))
-
 			Expect(templateGenerator.GenerateCall.Receives.State).To(Equal(incomingState))
 
 			Expect(inputGenerator.GenerateCall.Receives.State).To(Equal(incomingState))
@@ -130,16 +140,58 @@ var _ = Describe("Manager", func() {
 			}))
 			Expect(executor.ApplyCall.Receives.TFState).To(Equal("some-tf-state"))
 			Expect(executor.ApplyCall.Receives.Template).To(Equal(string("some-gcp-terraform-template")))
+			Expect(executor.ApplyCall.Receives.Targets).To(BeEmpty())
+			Expect(outputGenerator.GenerateCall.Receives.TFState).To(Equal(expectedTFState))
 			Expect(state).To(Equal(expectedState))
 		})
 
+		It("does not cache the outputs when they cannot be read", func() {
+			outputGenerator.GenerateCall.Returns.Error = errors.New("failed to read outputs")
+
+			state, err := manager.Apply(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state.TFState).To(Equal(expectedTFState))
+			Expect(state.TFOutputs).To(Equal(storage.TFOutputs{}))
+		})
+
+		Describe("ApplyTargets", func() {
+			BeforeEach(func() {
+				templateGenerator.GenerateCall.Returns.Template = `
+resource "google_compute_firewall" "bosh-open" {
+  name = "some-name"
+}
+
+resource "google_compute_subnetwork" "bbl-subnet" {
+  name = "some-name"
+}`
+			})
+
+			It("applies only the targeted resources", func() {
+				state, err := manager.ApplyTargets(incomingState, []string{"google_compute_firewall.bosh-open", "google_compute_subnetwork.bbl-subnet[0]"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executor.ApplyCall.Receives.Targets).To(Equal([]string{"google_compute_firewall.bosh-open", "google_compute_subnetwork.bbl-subnet[0]"}))
+				Expect(logger.StepCall.Messages).To(ContainElement("applying terraform template to google_compute_firewall.bosh-open, google_compute_subnetwork.bbl-subnet[0]"))
+				Expect(state.TFState).To(Equal(expectedTFState))
+			})
+
+			It("returns an error without applying when a target is not declared in the template", func() {
+				_, err := manager.ApplyTargets(incomingState, []string{"google_compute_firewall.bosh-open", "google_compute_network.missing"})
+				Expect(err).To(MatchError(`unknown terraform resource address "google_compute_network.missing"`))
+
+				Expect(executor.ApplyCall.CallCount).To(Equal(0))
+			})
+		})
+
 		Context("when an error occurs", func() {
-			Context("when the stack cannot be migrated", func() {
-				It("returns an error", func() {
-					migrator.MigrateReturns(storage.State{}, errors.New("failed to migrate"))
+			Context("when the cloudformation stack has not been migrated", func() {
+				It("returns an error without applying", func() {
+					incomingState.Stack.Name = "some-stack"
 
 					_, err := manager.Apply(incomingState)
-					Expect(err).To(MatchError("failed to migrate"))
+					Expect(err).To(MatchError("the cloudformation stack some-stack has not been migrated to terraform, run bbl migrate-state first"))
+					Expect(executor.ApplyCall.CallCount).To(Equal(0))
 				})
 			})
 
@@ -172,18 +224,6 @@ var _ = Describe("Manager", func() {
 				})
 			})
 
-			Context("when migrating causes an executor error", func() {
-				BeforeEach(func() {
-					migrator.MigrateReturns(storage.State{}, &fakes.TerraformExecutorError{})
-				})
-
-				It("returns the bblState with latest terraform output and a ManagerError", func() {
-					_, err := manager.Apply(incomingState)
-
-					Expect(err).To(BeAssignableToTypeOf(terraform.ManagerError{}))
-				})
-			})
-
 			Context("when Executor.Apply returns a non-ExecutorError error", func() {
 				executorError := errors.New("some-error")
 
@@ -203,6 +243,126 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Plan", func() {
+		var incomingState storage.State
+
+		BeforeEach(func() {
+			incomingState = storage.State{
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+			}
+			templateGenerator.GenerateCall.Returns.Template = "some-terraform-template"
+			inputGenerator.GenerateCall.Returns.Inputs = map[string]string{"env_id": "some-env-id"}
+			executor.PlanCall.Returns.Plan = "some-plan"
+		})
+
+		It("plans the template without applying anything", func() {
+			plan, err := manager.Plan(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal("some-plan"))
+
+			Expect(templateGenerator.GenerateCall.Receives.State).To(Equal(incomingState))
+			Expect(inputGenerator.GenerateCall.Receives.State).To(Equal(incomingState))
+			Expect(executor.PlanCall.Receives.Inputs).To(Equal(map[string]string{"env_id": "some-env-id"}))
+			Expect(executor.PlanCall.Receives.Template).To(Equal("some-terraform-template"))
+			Expect(executor.PlanCall.Receives.TFState).To(Equal("some-tf-state"))
+			Expect(executor.PlanCall.Receives.Destroy).To(BeFalse())
+
+			Expect(executor.ApplyCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(ContainElement("planning terraform template"))
+		})
+
+		It("returns the template it plans", func() {
+			Expect(manager.Template(incomingState)).To(Equal("some-terraform-template"))
+			Expect(templateGenerator.GenerateCall.Receives.State).To(Equal(incomingState))
+		})
+
+		It("plans the destruction of the infrastructure", func() {
+			plan, err := manager.PlanDestroy(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal("some-plan"))
+
+			Expect(executor.PlanCall.Receives.Destroy).To(BeTrue())
+			Expect(executor.DestroyCall.CallCount).To(Equal(0))
+		})
+
+		It("has nothing to destroy when there is no terraform state", func() {
+			plan, err := manager.PlanDestroy(storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(BeEmpty())
+
+			Expect(executor.PlanCall.CallCount).To(Equal(0))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the inputs cannot be generated", func() {
+				inputGenerator.GenerateCall.Returns.Error = errors.New("failed to generate inputs")
+
+				_, err := manager.Plan(incomingState)
+				Expect(err).To(MatchError("failed to generate inputs"))
+			})
+
+			It("returns an error when the plan fails", func() {
+				executor.PlanCall.Returns.Error = errors.New("failed to plan")
+
+				_, err := manager.Plan(incomingState)
+				Expect(err).To(MatchError("failed to plan"))
+			})
+		})
+	})
+
+	Describe("Drift", func() {
+		var incomingState storage.State
+
+		BeforeEach(func() {
+			incomingState = storage.State{
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+			}
+			templateGenerator.GenerateCall.Returns.Template = "some-terraform-template"
+			inputGenerator.GenerateCall.Returns.Inputs = map[string]string{"env_id": "some-env-id"}
+			executor.DriftCall.Returns.Plan = "some-plan"
+			executor.DriftCall.Returns.Drifted = true
+		})
+
+		It("plans the template against the stored terraform state", func() {
+			plan, drifted, err := manager.Drift(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal("some-plan"))
+			Expect(drifted).To(BeTrue())
+
+			Expect(executor.DriftCall.Receives.Inputs).To(Equal(map[string]string{"env_id": "some-env-id"}))
+			Expect(executor.DriftCall.Receives.Template).To(Equal("some-terraform-template"))
+			Expect(executor.DriftCall.Receives.TFState).To(Equal("some-tf-state"))
+
+			Expect(executor.ApplyCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(ContainElement("checking the infrastructure for drift"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when there is no terraform state", func() {
+				_, _, err := manager.Drift(storage.State{})
+				Expect(err).To(MatchError("bbl has not applied any infrastructure for this environment"))
+
+				Expect(executor.DriftCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the inputs cannot be generated", func() {
+				inputGenerator.GenerateCall.Returns.Error = errors.New("failed to generate inputs")
+
+				_, _, err := manager.Drift(incomingState)
+				Expect(err).To(MatchError("failed to generate inputs"))
+			})
+
+			It("returns an error when the plan fails", func() {
+				executor.DriftCall.Returns.Error = errors.New("failed to plan")
+
+				_, _, err := manager.Drift(incomingState)
+				Expect(err).To(MatchError("failed to plan"))
+			})
+		})
+	})
+
 	Describe("Destroy", func() {
 		Context("when the bbl state contains a non-empty TFState", func() {
 			var (
@@ -356,6 +516,63 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Refresh", func() {
+		var incomingState storage.State
+
+		BeforeEach(func() {
+			incomingState = storage.State{
+				IAAS:    "gcp",
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+			}
+
+			templateGenerator.GenerateCall.Returns.Template = "some-gcp-terraform-template"
+			inputGenerator.GenerateCall.Returns.Inputs = map[string]string{"env_id": "some-env-id"}
+			executor.RefreshCall.Returns.TFState = expectedTFState
+			outputGenerator.GenerateCall.Returns.Outputs = map[string]interface{}{"external_ip": "some-external-ip"}
+		})
+
+		It("refreshes the terraform state and caches its outputs", func() {
+			terraformOutputBuffer.Write([]byte(expectedTFOutput))
+
+			state, err := manager.Refresh(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.RefreshCall.Receives.Inputs).To(Equal(map[string]string{"env_id": "some-env-id"}))
+			Expect(executor.RefreshCall.Receives.Template).To(Equal("some-gcp-terraform-template"))
+			Expect(executor.RefreshCall.Receives.TFState).To(Equal("some-tf-state"))
+			Expect(outputGenerator.GenerateCall.Receives.TFState).To(Equal(expectedTFState))
+
+			Expect(state.TFState).To(Equal(expectedTFState))
+			Expect(state.LatestTFOutput).To(Equal(expectedTFOutput))
+			Expect(state.TFOutputs).To(Equal(storage.TFOutputs{
+				Checksum: fmt.Sprintf("%x", sha256.Sum256([]byte(expectedTFState))),
+				Outputs:  map[string]interface{}{"external_ip": "some-external-ip"},
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when no infrastructure was applied", func() {
+				_, err := manager.Refresh(storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("bbl has not applied any infrastructure for this environment"))
+			})
+
+			It("returns an error when terraform fails to refresh", func() {
+				executor.RefreshCall.Returns.Error = errors.New("failed to refresh")
+
+				_, err := manager.Refresh(incomingState)
+				Expect(err).To(MatchError("failed to refresh"))
+			})
+
+			It("returns an error when the outputs cannot be read", func() {
+				outputGenerator.GenerateCall.Returns.Error = errors.New("failed to read outputs")
+
+				_, err := manager.Refresh(incomingState)
+				Expect(err).To(MatchError("failed to read outputs"))
+			})
+		})
+	})
+
 	Describe("GetOutputs", func() {
 		BeforeEach(func() {
 			outputGenerator.GenerateCall.Returns.Outputs = map[string]interface{}{
@@ -378,6 +595,47 @@ var _ = Describe("Manager", func() {
 			}))
 		})
 
+		Context("when the outputs are cached in the state", func() {
+			var incomingState storage.State
+
+			BeforeEach(func() {
+				incomingState = storage.State{
+					IAAS:    "gcp",
+					TFState: "some-tf-state",
+					TFOutputs: storage.TFOutputs{
+						Checksum: fmt.Sprintf("%x", sha256.Sum256([]byte("some-tf-state"))),
+						Outputs: map[string]interface{}{
+							"external_ip":               "some-cached-external-ip",
+							"system_domain_dns_servers": []interface{}{"some-dns-server"},
+						},
+					},
+				}
+			})
+
+			It("returns the cached outputs without reading them with terraform", func() {
+				terraformOutputs, err := manager.GetOutputs(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(outputGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(terraformOutputs).To(Equal(map[string]interface{}{
+					"external_ip":               "some-cached-external-ip",
+					"system_domain_dns_servers": []string{"some-dns-server"},
+				}))
+			})
+
+			It("reads the outputs with terraform when the terraform state has changed", func() {
+				incomingState.TFState = "some-other-tf-state"
+
+				terraformOutputs, err := manager.GetOutputs(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(outputGenerator.GenerateCall.Receives.TFState).To(Equal("some-other-tf-state"))
+				Expect(terraformOutputs).To(Equal(map[string]interface{}{
+					"external_ip": "some-external-ip",
+				}))
+			})
+		})
+
 		Context("when the output generator fails", func() {
 			It("returns the error to the caller", func() {
 				outputGenerator.GenerateCall.Returns.Error = errors.New("fail")
@@ -387,6 +645,80 @@ var _ = Describe("Manager", func() {
 				Expect(err).To(MatchError("fail"))
 			})
 		})
+
+		Context("when iaas is azure", func() {
+			It("returns the outputs from the azure output generator", func() {
+				terraformOutputs, err := manager.GetOutputs(storage.State{
+					IAAS:    "azure",
+					TFState: "some-tf-state",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(outputGenerator.GenerateCall.Receives.TFState).To(Equal("some-tf-state"))
+				Expect(terraformOutputs).To(Equal(map[string]interface{}{
+					"external_ip": "some-external-ip",
+				}))
+			})
+		})
+
+		Context("when iaas is openstack", func() {
+			It("returns the outputs from the openstack output generator", func() {
+				terraformOutputs, err := manager.GetOutputs(storage.State{
+					IAAS:    "openstack",
+					TFState: "some-tf-state",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(outputGenerator.GenerateCall.Receives.TFState).To(Equal("some-tf-state"))
+				Expect(terraformOutputs).To(Equal(map[string]interface{}{
+					"external_ip": "some-external-ip",
+				}))
+			})
+		})
+	})
+
+	Describe("Resources", func() {
+		It("returns the managed resources in the terraform state", func() {
+			resources, err := manager.Resources(storage.State{
+				TFState: `{
+					"version": 3,
+					"modules": [{
+						"path": ["root"],
+						"resources": {
+							"google_compute_network.bbl-network": {
+								"type": "google_compute_network",
+								"primary": {"id": "some-network"}
+							},
+							"google_compute_address.bosh-external-ip": {
+								"type": "google_compute_address",
+								"primary": {"id": "some-address"}
+							},
+							"data.template_file.some-template": {
+								"type": "template_file",
+								"primary": {"id": "some-template"}
+							}
+						}
+					}]
+				}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(Equal([]string{
+				"google_compute_address.bosh-external-ip (some-address)",
+				"google_compute_network.bbl-network (some-network)",
+			}))
+		})
+
+		It("returns no resources when there is no terraform state", func() {
+			resources, err := manager.Resources(storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources).To(BeEmpty())
+		})
+
+		It("returns an error when the terraform state cannot be parsed", func() {
+			_, err := manager.Resources(storage.State{TFState: "%%%"})
+			Expect(err).To(MatchError(ContainSubstring("failed to parse terraform state:")))
+		})
 	})
 
 	Describe("Version", func() {
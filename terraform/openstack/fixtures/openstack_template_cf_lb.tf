@@ -0,0 +1,242 @@
+variable "env_id" {
+	type = "string"
+}
+
+variable "auth_url" {
+	type = "string"
+}
+
+variable "availability_zone" {
+	type = "string"
+}
+
+variable "ext_net_name" {
+	type = "string"
+}
+
+variable "region" {
+	type = "string"
+}
+
+variable "user_name" {
+	type = "string"
+}
+
+variable "password" {
+	type = "string"
+}
+
+variable "domain_name" {
+	type = "string"
+}
+
+variable "tenant_name" {
+	type = "string"
+}
+
+variable "public_key" {
+	type = "string"
+}
+
+provider "openstack" {
+	auth_url    = "${var.auth_url}"
+	user_name   = "${var.user_name}"
+	password    = "${var.password}"
+	domain_name = "${var.domain_name}"
+	tenant_name = "${var.tenant_name}"
+	region      = "${var.region}"
+	use_octavia = true
+}
+
+output "external_ip" {
+    value = "${openstack_networking_floatingip_v2.bosh.address}"
+}
+
+output "director_address" {
+	value = "https://${openstack_networking_floatingip_v2.bosh.address}:25555"
+}
+
+output "net_id" {
+    value = "${openstack_networking_network_v2.bosh.id}"
+}
+
+output "default_key_name" {
+    value = "${openstack_compute_keypair_v2.bosh.name}"
+}
+
+output "default_security_group" {
+    value = "${openstack_networking_secgroup_v2.bosh.name}"
+}
+
+variable "network_cidr" {
+  type    = "string"
+  default = "10.0.0.0/16"
+}
+
+data "openstack_networking_network_v2" "external" {
+  name = "${var.ext_net_name}"
+}
+
+resource "openstack_compute_keypair_v2" "bosh" {
+  name       = "${var.env_id}-keypair"
+  public_key = "${var.public_key}"
+}
+
+resource "openstack_networking_network_v2" "bosh" {
+  name           = "${var.env_id}-network"
+  admin_state_up = "true"
+}
+
+resource "openstack_networking_subnet_v2" "bosh" {
+  name            = "${var.env_id}-subnet"
+  network_id      = "${openstack_networking_network_v2.bosh.id}"
+  cidr            = "${var.network_cidr}"
+  ip_version      = 4
+  dns_nameservers = ["8.8.8.8"]
+}
+
+resource "openstack_networking_router_v2" "bosh" {
+  name                = "${var.env_id}-router"
+  admin_state_up      = "true"
+  external_network_id = "${data.openstack_networking_network_v2.external.id}"
+}
+
+resource "openstack_networking_router_interface_v2" "bosh" {
+  router_id = "${openstack_networking_router_v2.bosh.id}"
+  subnet_id = "${openstack_networking_subnet_v2.bosh.id}"
+}
+
+resource "openstack_networking_floatingip_v2" "bosh" {
+  pool       = "${var.ext_net_name}"
+  depends_on = ["openstack_networking_router_interface_v2.bosh"]
+}
+
+resource "openstack_networking_secgroup_v2" "bosh" {
+  name        = "${var.env_id}-bosh"
+  description = "BOSH director and deployed VMs"
+}
+
+resource "openstack_networking_secgroup_rule_v2" "internal" {
+  direction         = "ingress"
+  ethertype         = "IPv4"
+  remote_group_id   = "${openstack_networking_secgroup_v2.bosh.id}"
+  security_group_id = "${openstack_networking_secgroup_v2.bosh.id}"
+}
+
+resource "openstack_networking_secgroup_rule_v2" "ssh" {
+  direction         = "ingress"
+  ethertype         = "IPv4"
+  protocol          = "tcp"
+  port_range_min    = 22
+  port_range_max    = 22
+  remote_ip_prefix  = "0.0.0.0/0"
+  security_group_id = "${openstack_networking_secgroup_v2.bosh.id}"
+}
+
+resource "openstack_networking_secgroup_rule_v2" "bosh-agent" {
+  direction         = "ingress"
+  ethertype         = "IPv4"
+  protocol          = "tcp"
+  port_range_min    = 6868
+  port_range_max    = 6868
+  remote_ip_prefix  = "0.0.0.0/0"
+  security_group_id = "${openstack_networking_secgroup_v2.bosh.id}"
+}
+
+resource "openstack_networking_secgroup_rule_v2" "bosh-director" {
+  direction         = "ingress"
+  ethertype         = "IPv4"
+  protocol          = "tcp"
+  port_range_min    = 25555
+  port_range_max    = 25555
+  remote_ip_prefix  = "0.0.0.0/0"
+  security_group_id = "${openstack_networking_secgroup_v2.bosh.id}"
+}
+
+output "router_lb_ip" {
+    value = "${openstack_networking_floatingip_v2.cf.address}"
+}
+
+output "ssh_proxy_lb_ip" {
+    value = "${openstack_networking_floatingip_v2.cf.address}"
+}
+
+output "cf_router_http_pool" {
+    value = "${openstack_lb_pool_v2.cf-router-http.name}"
+}
+
+output "cf_router_https_pool" {
+    value = "${openstack_lb_pool_v2.cf-router-https.name}"
+}
+
+output "cf_ssh_proxy_pool" {
+    value = "${openstack_lb_pool_v2.cf-ssh-proxy.name}"
+}
+
+resource "openstack_lb_loadbalancer_v2" "cf" {
+  name          = "${var.env_id}-cf"
+  vip_subnet_id = "${openstack_networking_subnet_v2.bosh.id}"
+}
+
+resource "openstack_networking_floatingip_v2" "cf" {
+  pool       = "${var.ext_net_name}"
+  depends_on = ["openstack_networking_router_interface_v2.bosh"]
+}
+
+resource "openstack_networking_floatingip_associate_v2" "cf" {
+  floating_ip = "${openstack_networking_floatingip_v2.cf.address}"
+  port_id     = "${openstack_lb_loadbalancer_v2.cf.vip_port_id}"
+}
+
+resource "openstack_lb_listener_v2" "cf-router-http" {
+  name            = "${var.env_id}-cf-router-http"
+  protocol        = "TCP"
+  protocol_port   = 80
+  loadbalancer_id = "${openstack_lb_loadbalancer_v2.cf.id}"
+}
+
+resource "openstack_lb_pool_v2" "cf-router-http" {
+  name        = "${var.env_id}-cf-router-http"
+  protocol    = "TCP"
+  lb_method   = "ROUND_ROBIN"
+  listener_id = "${openstack_lb_listener_v2.cf-router-http.id}"
+}
+
+resource "openstack_lb_listener_v2" "cf-router-https" {
+  name            = "${var.env_id}-cf-router-https"
+  protocol        = "TCP"
+  protocol_port   = 443
+  loadbalancer_id = "${openstack_lb_loadbalancer_v2.cf.id}"
+}
+
+resource "openstack_lb_pool_v2" "cf-router-https" {
+  name        = "${var.env_id}-cf-router-https"
+  protocol    = "TCP"
+  lb_method   = "ROUND_ROBIN"
+  listener_id = "${openstack_lb_listener_v2.cf-router-https.id}"
+}
+
+resource "openstack_lb_listener_v2" "cf-ssh-proxy" {
+  name            = "${var.env_id}-cf-ssh-proxy"
+  protocol        = "TCP"
+  protocol_port   = 2222
+  loadbalancer_id = "${openstack_lb_loadbalancer_v2.cf.id}"
+}
+
+resource "openstack_lb_pool_v2" "cf-ssh-proxy" {
+  name        = "${var.env_id}-cf-ssh-proxy"
+  protocol    = "TCP"
+  lb_method   = "ROUND_ROBIN"
+  listener_id = "${openstack_lb_listener_v2.cf-ssh-proxy.id}"
+}
+
+resource "openstack_networking_secgroup_rule_v2" "cf" {
+  count             = 3
+  direction         = "ingress"
+  ethertype         = "IPv4"
+  protocol          = "tcp"
+  port_range_min    = "${element(list(80, 443, 2222), count.index)}"
+  port_range_max    = "${element(list(80, 443, 2222), count.index)}"
+  remote_ip_prefix  = "${var.network_cidr}"
+  security_group_id = "${openstack_networking_secgroup_v2.bosh.id}"
+}
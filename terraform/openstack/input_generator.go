@@ -0,0 +1,30 @@
+package openstack
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type InputGenerator struct{}
+
+func NewInputGenerator() InputGenerator {
+	return InputGenerator{}
+}
+
+func (i InputGenerator) Generate(state storage.State) (map[string]string, error) {
+	input := map[string]string{
+		"env_id":            state.EnvID,
+		"auth_url":          state.OpenStack.AuthURL,
+		"availability_zone": state.OpenStack.AZ,
+		"ext_net_name":      state.OpenStack.ExternalNetwork,
+		"region":            state.OpenStack.Region,
+		"user_name":         state.OpenStack.Username,
+		"password":          state.OpenStack.Password,
+		"domain_name":       state.OpenStack.Domain,
+		"tenant_name":       state.OpenStack.Project,
+		"public_key":        state.KeyPair.PublicKey,
+	}
+
+	if state.Network.CIDR != "" {
+		input["network_cidr"] = state.Network.CIDR
+	}
+
+	return input, nil
+}
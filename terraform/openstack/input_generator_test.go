@@ -0,0 +1,69 @@
+package openstack_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform/openstack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InputGenerator", func() {
+	var (
+		inputGenerator openstack.InputGenerator
+
+		state storage.State
+	)
+
+	BeforeEach(func() {
+		state = storage.State{
+			IAAS:  "openstack",
+			EnvID: "some-env-id",
+			OpenStack: storage.OpenStack{
+				AuthURL:         "some-auth-url",
+				AZ:              "some-az",
+				ExternalNetwork: "some-external-network",
+				Region:          "some-region",
+				Username:        "some-username",
+				Password:        "some-password",
+				Domain:          "some-domain",
+				Project:         "some-project",
+			},
+			KeyPair: storage.KeyPair{
+				PublicKey: "some-public-key",
+			},
+			TFState: "some-tf-state",
+		}
+
+		inputGenerator = openstack.NewInputGenerator()
+	})
+
+	It("receives BBL state and returns a map of terraform variables", func() {
+		inputs, err := inputGenerator.Generate(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(inputs).To(Equal(map[string]string{
+			"env_id":            "some-env-id",
+			"auth_url":          "some-auth-url",
+			"availability_zone": "some-az",
+			"ext_net_name":      "some-external-network",
+			"region":            "some-region",
+			"user_name":         "some-username",
+			"password":          "some-password",
+			"domain_name":       "some-domain",
+			"tenant_name":       "some-project",
+			"public_key":        "some-public-key",
+		}))
+	})
+
+	Context("when a network cidr is in the state", func() {
+		It("passes it to terraform", func() {
+			state.Network.CIDR = "172.16.0.0/16"
+
+			inputs, err := inputGenerator.Generate(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inputs).To(HaveKeyWithValue("network_cidr", "172.16.0.0/16"))
+		})
+	})
+})
@@ -0,0 +1,26 @@
+package openstack
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type TemplateGenerator struct{}
+
+func NewTemplateGenerator() TemplateGenerator {
+	return TemplateGenerator{}
+}
+
+func (t TemplateGenerator) Generate(state storage.State) string {
+	template := strings.Join([]string{VarsTemplate, BOSHDirectorTemplate}, "\n")
+
+	switch state.LB.Type {
+	case "concourse":
+		template = strings.Join([]string{template, ConcourseLBTemplate}, "\n")
+	case "cf":
+		template = strings.Join([]string{template, CFLBTemplate}, "\n")
+	}
+
+	return template
+}
@@ -0,0 +1,44 @@
+package openstack_test
+
+import (
+	"io/ioutil"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform/openstack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TemplateGenerator", func() {
+	var (
+		templateGenerator openstack.TemplateGenerator
+	)
+
+	BeforeEach(func() {
+		templateGenerator = openstack.NewTemplateGenerator()
+	})
+
+	Describe("Generate", func() {
+		DescribeTable("generates a terraform template for openstack",
+			func(fixtureFilename, lbType string) {
+				expectedTemplate, err := ioutil.ReadFile(fixtureFilename)
+				Expect(err).NotTo(HaveOccurred())
+
+				template := templateGenerator.Generate(storage.State{
+					OpenStack: storage.OpenStack{
+						Region: "some-region",
+					},
+					LB: storage.LB{
+						Type: lbType,
+					},
+				})
+				Expect(template).To(Equal(string(expectedTemplate)))
+			},
+			Entry("when no lb type is provided", "fixtures/openstack_template_no_lb.tf", ""),
+			Entry("when a concourse lb type is provided", "fixtures/openstack_template_concourse_lb.tf", "concourse"),
+			Entry("when a cf lb type is provided", "fixtures/openstack_template_cf_lb.tf", "cf"),
+		)
+	})
+})
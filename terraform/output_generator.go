@@ -0,0 +1,26 @@
+package terraform
+
+type outputsReader interface {
+	Outputs(string) (map[string]interface{}, error)
+}
+
+// OutputGenerator returns the terraform outputs as they are, for the iaases
+// whose templates already output what the director and cloud config need.
+type OutputGenerator struct {
+	executor outputsReader
+}
+
+func NewOutputGenerator(executor outputsReader) OutputGenerator {
+	return OutputGenerator{
+		executor: executor,
+	}
+}
+
+func (g OutputGenerator) Generate(tfState string) (map[string]interface{}, error) {
+	tfOutputs, err := g.executor.Outputs(tfState)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+
+	return tfOutputs, nil
+}
@@ -0,0 +1,47 @@
+package terraform_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OutputGenerator", func() {
+	Describe("Generate", func() {
+		var (
+			executor        *fakes.TerraformExecutor
+			outputGenerator terraform.OutputGenerator
+		)
+
+		BeforeEach(func() {
+			executor = &fakes.TerraformExecutor{}
+			outputGenerator = terraform.NewOutputGenerator(executor)
+
+			executor.OutputsCall.Returns.Outputs = map[string]interface{}{
+				"vnet_name": "some-vnet-name",
+			}
+		})
+
+		It("returns the outputs from the terraform state", func() {
+			outputs, err := outputGenerator.Generate("some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.OutputsCall.Receives.TFState).To(Equal("some-tf-state"))
+			Expect(outputs).To(HaveKeyWithValue("vnet_name", "some-vnet-name"))
+		})
+
+		Context("when executor outputs returns an error", func() {
+			It("returns an empty map and the error", func() {
+				executor.OutputsCall.Returns.Error = errors.New("executor outputs failed")
+
+				outputs, err := outputGenerator.Generate("")
+				Expect(err).To(MatchError("executor outputs failed"))
+				Expect(outputs).To(BeEmpty())
+			})
+		})
+	})
+})
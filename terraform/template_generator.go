@@ -3,14 +3,19 @@ package terraform
 import "github.com/cloudfoundry/bosh-bootloader/storage"
 
 type TemplateGenerator struct {
-	gcpTemplateGenerator templateGenerator
-	awsTemplateGenerator templateGenerator
+	gcpTemplateGenerator       templateGenerator
+	awsTemplateGenerator       templateGenerator
+	azureTemplateGenerator     templateGenerator
+	openstackTemplateGenerator templateGenerator
 }
 
-func NewTemplateGenerator(gcpTemplateGenerator templateGenerator, awsTemplateGenerator templateGenerator) TemplateGenerator {
+func NewTemplateGenerator(gcpTemplateGenerator templateGenerator, awsTemplateGenerator templateGenerator, azureTemplateGenerator templateGenerator,
+	openstackTemplateGenerator templateGenerator) TemplateGenerator {
 	return TemplateGenerator{
-		gcpTemplateGenerator: gcpTemplateGenerator,
-		awsTemplateGenerator: awsTemplateGenerator,
+		gcpTemplateGenerator:       gcpTemplateGenerator,
+		awsTemplateGenerator:       awsTemplateGenerator,
+		azureTemplateGenerator:     azureTemplateGenerator,
+		openstackTemplateGenerator: openstackTemplateGenerator,
 	}
 }
 
@@ -20,6 +25,10 @@ func (t TemplateGenerator) Generate(state storage.State) string {
 		return t.gcpTemplateGenerator.Generate(state)
 	case "aws":
 		return t.awsTemplateGenerator.Generate(state)
+	case "azure":
+		return t.azureTemplateGenerator.Generate(state)
+	case "openstack":
+		return t.openstackTemplateGenerator.Generate(state)
 	default:
 		return ""
 	}
@@ -12,8 +12,10 @@ import (
 var _ = Describe("TemplateGenerator", func() {
 	Describe("Generate", func() {
 		var (
-			gcpTemplateGenerator *fakes.TemplateGenerator
-			awsTemplateGenerator *fakes.TemplateGenerator
+			gcpTemplateGenerator       *fakes.TemplateGenerator
+			awsTemplateGenerator       *fakes.TemplateGenerator
+			azureTemplateGenerator     *fakes.TemplateGenerator
+			openstackTemplateGenerator *fakes.TemplateGenerator
 
 			templateGenerator terraform.TemplateGenerator
 		)
@@ -21,11 +23,15 @@ var _ = Describe("TemplateGenerator", func() {
 		BeforeEach(func() {
 			gcpTemplateGenerator = &fakes.TemplateGenerator{}
 			awsTemplateGenerator = &fakes.TemplateGenerator{}
+			azureTemplateGenerator = &fakes.TemplateGenerator{}
+			openstackTemplateGenerator = &fakes.TemplateGenerator{}
 
 			gcpTemplateGenerator.GenerateCall.Returns.Template = "some-gcp-template"
 			awsTemplateGenerator.GenerateCall.Returns.Template = "some-aws-template"
+			azureTemplateGenerator.GenerateCall.Returns.Template = "some-azure-template"
+			openstackTemplateGenerator.GenerateCall.Returns.Template = "some-openstack-template"
 
-			templateGenerator = terraform.NewTemplateGenerator(gcpTemplateGenerator, awsTemplateGenerator)
+			templateGenerator = terraform.NewTemplateGenerator(gcpTemplateGenerator, awsTemplateGenerator, azureTemplateGenerator, openstackTemplateGenerator)
 		})
 
 		Context("when iaas is gcp", func() {
@@ -56,6 +62,37 @@ var _ = Describe("TemplateGenerator", func() {
 			})
 		})
 
+		Context("when iaas is azure", func() {
+			It("returns the template from the azure template generator", func() {
+				template := templateGenerator.Generate(storage.State{
+					IAAS: "azure",
+				})
+
+				Expect(template).To(Equal("some-azure-template"))
+				Expect(gcpTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(awsTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(azureTemplateGenerator.GenerateCall.Receives.State).To(Equal(storage.State{
+					IAAS: "azure",
+				}))
+			})
+		})
+
+		Context("when iaas is openstack", func() {
+			It("returns the template from the openstack template generator", func() {
+				template := templateGenerator.Generate(storage.State{
+					IAAS: "openstack",
+				})
+
+				Expect(template).To(Equal("some-openstack-template"))
+				Expect(gcpTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(awsTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(azureTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(openstackTemplateGenerator.GenerateCall.Receives.State).To(Equal(storage.State{
+					IAAS: "openstack",
+				}))
+			})
+		})
+
 		Context("when iaas is invalid", func() {
 			It("returns an empty string", func() {
 				template := templateGenerator.Generate(storage.State{})
@@ -63,6 +100,8 @@ var _ = Describe("TemplateGenerator", func() {
 				Expect(template).To(Equal(""))
 				Expect(gcpTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
 				Expect(awsTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(azureTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
+				Expect(openstackTemplateGenerator.GenerateCall.CallCount).To(Equal(0))
 			})
 		})
 	})